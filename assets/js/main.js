@@ -5,13 +5,41 @@ window.initWasm().then(() => {
     console.error("❌ Failed to initialize WebAssembly:", err);
 });
 
+// Fill the country and category pickers from the reference data the
+// business logic checks against
+document.addEventListener('DOMContentLoaded', () => {
+    const fill = (id, options) => {
+	const select = document.getElementById(id);
+	options.forEach(([value, label]) => select.add(new Option(label, value)));
+    };
+    fill('userCountry', window.referenceData.markets.map(market => [market.code, market.name]));
+    fill('productCategory', window.referenceData.categories.map(category =>
+	[category, category.charAt(0).toUpperCase() + category.slice(1)]));
+});
+
+// Fetch the benchmark module once the benchmarks scroll into view, so the
+// business logic demo loads without it
+document.addEventListener('DOMContentLoaded', () => {
+    const section = document.getElementById('benchmarks');
+    if (!section || !('IntersectionObserver' in window)) {
+        return;
+    }
+    const observer = new IntersectionObserver((entries) => {
+        if (entries.some(entry => entry.isIntersecting)) {
+            observer.disconnect();
+            window.loadBenchmarks().catch(err => console.error("❌ Failed to load the benchmark module:", err));
+        }
+    });
+    observer.observe(section);
+});
+
 // Demo data
 const demoProducts = [
-    {"id": 1, "name": "Wireless Headphones", "price": 99.99, "category": "electronics", "in_stock": true, "rating": 4.5, "description": "High-quality wireless headphones"},
-    {"id": 2, "name": "Cotton T-Shirt", "price": 24.99, "category": "clothing", "in_stock": true, "rating": 4.2, "description": "Comfortable 100% cotton t-shirt"},
-    {"id": 3, "name": "Programming Book", "price": 49.99, "category": "books", "in_stock": true, "rating": 4.8, "description": "Learn advanced programming techniques"},
-    {"id": 4, "name": "Coffee Mug", "price": 12.99, "category": "home", "in_stock": true, "rating": 4.0, "description": "Ceramic coffee mug"},
-    {"id": 5, "name": "Running Shoes", "price": 129.99, "category": "sports", "in_stock": true, "rating": 4.6, "description": "Lightweight running shoes"}
+    {"id": 1, "name": "Wireless Headphones", "price": 99.99, "category": "electronics", "on_hand": 25, "rating": 4.5, "description": "High-quality wireless headphones"},
+    {"id": 2, "name": "Cotton T-Shirt", "price": 24.99, "category": "clothing", "on_hand": 25, "rating": 4.2, "description": "Comfortable 100% cotton t-shirt"},
+    {"id": 3, "name": "Programming Book", "price": 49.99, "category": "books", "on_hand": 25, "rating": 4.8, "description": "Learn advanced programming techniques"},
+    {"id": 4, "name": "Coffee Mug", "price": 12.99, "category": "home", "on_hand": 25, "rating": 4.0, "description": "Ceramic coffee mug"},
+    {"id": 5, "name": "Running Shoes", "price": 129.99, "category": "sports", "on_hand": 25, "rating": 4.6, "description": "Lightweight running shoes"}
 ];
 
 // Performance tracking
@@ -19,7 +47,8 @@ let performanceData = {
     userValidation: { wasm: null, server: null },
     productValidation: { wasm: null, server: null },
     orderCalculation: { wasm: null, server: null },
-    recommendations: { wasm: null, server: null }
+    recommendations: { wasm: null, server: null },
+    baskets: { wasm: null, server: null }
 };
 
 // User validation functions
@@ -44,7 +73,7 @@ function validateUserWasmButton() {
 	const elapsed = performance.now() - start;
 	const parsedResult = (typeof result === 'string') ? JSON.parse(result) : result;
 	performanceData.userValidation.wasm = elapsed;
-	displayResult('userResults', parsedResult, '🌐 WebAssembly Client-Side Validation', elapsed, 'userValidation');
+	displayResult('userResults', parsedResult, '🌐 WebAssembly Client-Side Validation', elapsed, 'userValidation', parsedResult.trace && parsedResult.trace.spans);
     } catch (error) {
 	displayError('userResults', error);
     }
@@ -60,23 +89,45 @@ function validateUserServer() {
     };
 
     const start = performance.now();
-    fetch('/api/validate-user', {
+    fetch('/api/validate-user?envelope=true', {
 	method: 'POST',
 	headers: { 'Content-Type': 'application/json' },
 	body: JSON.stringify(user)
     })
     .then(response => {
 	const elapsed = performance.now() - start;
-	return response.json().then(result => ({ result, elapsed }));
+	return response.json().then(body => ({ ...unwrapEnvelope(body), elapsed }));
     })
-    .then(({ result, elapsed }) => {
+    .then(({ result, spans, elapsed }) => {
 	performanceData.userValidation.server = elapsed;
-	displayResult('userResults', result, '🖥️ Server-Side API Validation', elapsed, 'userValidation');
+	displayResult('userResults', result, '🖥️ Server-Side API Validation', elapsed, 'userValidation', spans);
     })
     .catch(error => displayError('userResults', error));
 }
 
 // Product validation functions
+function productFromForm() {
+    const product = {
+	name: document.getElementById('productName').value,
+	price: parseFloat(document.getElementById('productPrice').value) || 0,
+	category: document.getElementById('productCategory').value,
+	rating: parseFloat(document.getElementById('productRating').value) || 0,
+	on_hand: parseInt(document.getElementById('productOnHand').value, 10) || 0
+    };
+    // The picture is the product's main image, with its size and alt text
+    const imageUrl = document.getElementById('productImageUrl').value.trim();
+    if (imageUrl) {
+	product.image_url = imageUrl;
+	product.images = [{
+	    url: imageUrl,
+	    width: parseInt(document.getElementById('productImageWidth').value, 10) || 0,
+	    height: parseInt(document.getElementById('productImageHeight').value, 10) || 0,
+	    alt: document.getElementById('productImageAlt').value
+	}];
+    }
+    return product;
+}
+
 function validateProductWasmButton() {
     if (!window.isWasmReady()) {
 	document.getElementById('productResults').className = 'results error';
@@ -84,13 +135,7 @@ function validateProductWasmButton() {
 	return;
     }
 
-    const product = {
-	name: document.getElementById('productName').value,
-	price: parseFloat(document.getElementById('productPrice').value) || 0,
-	category: document.getElementById('productCategory').value,
-	rating: parseFloat(document.getElementById('productRating').value) || 0,
-	in_stock: document.getElementById('productInStock').checked
-    };
+    const product = productFromForm();
 
     try {
 	const start = performance.now();
@@ -98,39 +143,39 @@ function validateProductWasmButton() {
 	const elapsed = performance.now() - start;
 	const parsedResult = (typeof result === 'string') ? JSON.parse(result) : result;
 	performanceData.productValidation.wasm = elapsed;
-	displayResult('productResults', parsedResult, '🌐 WebAssembly Client-Side Validation', elapsed, 'productValidation');
+	displayResult('productResults', parsedResult, '🌐 WebAssembly Client-Side Validation', elapsed, 'productValidation', parsedResult.trace && parsedResult.trace.spans);
     } catch (error) {
 	displayError('productResults', error);
     }
 }
 
 function validateProductServer() {
-    const product = {
-	name: document.getElementById('productName').value,
-	price: parseFloat(document.getElementById('productPrice').value) || 0,
-	category: document.getElementById('productCategory').value,
-	rating: parseFloat(document.getElementById('productRating').value) || 0,
-	in_stock: document.getElementById('productInStock').checked
-    };
+    const product = productFromForm();
 
     const start = performance.now();
-    fetch('/api/validate-product', {
+    fetch('/api/validate-product?envelope=true', {
 	method: 'POST',
 	headers: { 'Content-Type': 'application/json' },
 	body: JSON.stringify(product)
     })
     .then(response => {
 	const elapsed = performance.now() - start;
-	return response.json().then(result => ({ result, elapsed }));
+	return response.json().then(body => ({ ...unwrapEnvelope(body), elapsed }));
     })
-    .then(({ result, elapsed }) => {
+    .then(({ result, spans, elapsed }) => {
 	performanceData.productValidation.server = elapsed;
-	displayResult('productResults', result, '🖥️ Server-Side API Validation', elapsed, 'productValidation');
+	displayResult('productResults', result, '🖥️ Server-Side API Validation', elapsed, 'productValidation', spans);
     })
     .catch(error => displayError('productResults', error));
 }
 
 // Order calculation functions
+// Some markets see tax-inclusive prices (PricesIncludeTax in shared_tax.go)
+function pricesIncludeTax(user) {
+    const country = (user.country || '').toUpperCase();
+    return window.referenceData.markets.some(market => market.code === country && market.prices_include_tax);
+}
+
 function calculateOrderWasmButton() {
     if (!window.isWasmReady()) {
 	document.getElementById('orderResults').className = 'results error';
@@ -145,7 +190,8 @@ function calculateOrderWasmButton() {
 
 	const order = {
 	    products: products,
-	    quantities: quantities
+	    quantities: quantities,
+	    includes_tax: pricesIncludeTax(user)
 	};
 
 	const start = performance.now();
@@ -153,7 +199,7 @@ function calculateOrderWasmButton() {
 	const elapsed = performance.now() - start;
 	const parsedResult = (typeof result === 'string') ? JSON.parse(result) : result;
 	performanceData.orderCalculation.wasm = elapsed;
-	displayOrderResult('orderResults', parsedResult, '🌐 WebAssembly Client-Side Calculation', elapsed, 'orderCalculation');
+	displayOrderResult('orderResults', parsedResult, '🌐 WebAssembly Client-Side Calculation', elapsed, 'orderCalculation', parsedResult.trace && parsedResult.trace.spans);
     } catch (error) {
 	displayError('orderResults', error);
     }
@@ -168,24 +214,25 @@ function calculateOrderServer() {
 	const requestData = {
 	    order: {
 		products: products,
-		quantities: quantities
+		quantities: quantities,
+		includes_tax: pricesIncludeTax(user)
 	    },
 	    user: user
 	};
 
 	const start = performance.now();
-	fetch('/api/calculate-order', {
+	fetch('/api/calculate-order?envelope=true', {
 	    method: 'POST',
 	    headers: { 'Content-Type': 'application/json' },
 	    body: JSON.stringify(requestData)
 	})
 	.then(response => {
 	    const elapsed = performance.now() - start;
-	    return response.json().then(result => ({ result, elapsed }));
+	    return response.json().then(body => ({ ...unwrapEnvelope(body), elapsed }));
 	})
-	.then(({ result, elapsed }) => {
+	.then(({ result, spans, elapsed }) => {
 	    performanceData.orderCalculation.server = elapsed;
-	    displayOrderResult('orderResults', result, '🖥️ Server-Side API Calculation', elapsed, 'orderCalculation');
+	    displayOrderResult('orderResults', result, '🖥️ Server-Side API Calculation', elapsed, 'orderCalculation', spans);
 	})
 	.catch(error => displayError('orderResults', error));
     } catch (error) {
@@ -201,33 +248,22 @@ function getRecommendationsWasmButton() {
 	return;
     }
 
+    let order;
     try {
-	const user = getCurrentUser();
-	const products = demoProducts;
-	const order = { products: JSON.parse(document.getElementById('orderProducts').value) };
-
-	const start = performance.now();
-	const result = window.recommendProductsWasm(
-	    JSON.stringify(user),
-	    JSON.stringify(products),
-	    JSON.stringify(order)
-	);
-	const elapsed = performance.now() - start;
-
-	const parsedResult = (typeof result === 'string') ? JSON.parse(result) : result;
-	
-	// WASM function returns an object with error and recommendations fields
-	if (parsedResult.error && parsedResult.error !== "") {
-	    displayError('recommendationResults', new Error(parsedResult.error));
-	} else {
-	    // Extract the recommendations array from the WASM response
-	    const recommendations = parsedResult.recommendations || parsedResult;
-	    performanceData.recommendations.wasm = elapsed;
-	    displayRecommendations('recommendationResults', recommendations, '🌐 WebAssembly Client-Side Recommendations', elapsed, 'recommendations');
-	}
+	order = { products: JSON.parse(document.getElementById('orderProducts').value) };
     } catch (error) {
 	displayError('recommendationResults', error);
+	return;
     }
+
+    const start = performance.now();
+    window.wasmApi.recommendProducts(getCurrentUser(), demoProducts, order)
+	.then(result => {
+	    const elapsed = performance.now() - start;
+	    performanceData.recommendations.wasm = elapsed;
+	    displayRecommendations('recommendationResults', result.recommendations, '🌐 WebAssembly Client-Side Recommendations', elapsed, 'recommendations');
+	})
+	.catch(error => displayError('recommendationResults', error));
 }
 
 function getRecommendationsServer() {
@@ -262,6 +298,122 @@ function getRecommendationsServer() {
     }
 }
 
+// Offline data cache: fetched records are kept in the WebAssembly data
+// cache (localStorage), the demos read them from there when the server
+// can't be reached, and edits made offline are synced once back online.
+let dataCacheOpened = null;
+
+function openDataCache() {
+    if (!dataCacheOpened) {
+	dataCacheOpened = window.wasmApi.openDataCache('local');
+    }
+    return dataCacheOpened;
+}
+
+function fetchCached(model, url) {
+    return fetch(url)
+	.then(response => response.json())
+	.then(records => {
+	    if (window.isWasmReady()) {
+		openDataCache()
+		    .then(() => window.wasmApi.cacheStore(model, records))
+		    .catch(error => console.warn('Caching ' + model + 's failed:', error));
+	    }
+	    return records;
+	})
+	.catch(error => {
+	    if (!window.isWasmReady()) {
+		throw error;
+	    }
+	    return openDataCache()
+		.then(() => window.wasmApi.cacheRecords(model))
+		.then(result => JSON.parse(result.records));
+	});
+}
+
+function syncDataCache() {
+    if (!window.isWasmReady()) {
+	return Promise.resolve();
+    }
+    return openDataCache()
+	.then(() => window.wasmApi.cacheSyncRequest())
+	.then(request => request.changes === 0 ? null : fetch('/api/data/sync', {
+	    method: 'POST',
+	    headers: { 'Content-Type': 'application/json' },
+	    body: request.body
+	})
+	    .then(response => response.json())
+	    .then(response => window.wasmApi.cacheApplySync(response)))
+	.then(summary => {
+	    if (summary && summary.conflicts !== '[]') {
+		console.warn('Offline edits conflict with the server:', JSON.parse(summary.conflicts));
+	    }
+	})
+	.catch(error => console.warn('Syncing offline edits failed:', error));
+}
+
+window.addEventListener('online', syncDataCache);
+
+// Basket analysis functions
+function fetchDemoUsersAndOrders() {
+    return Promise.all([
+	fetchCached('user', '/api/demo-users'),
+	fetchCached('order', '/api/demo-orders')
+    ]);
+}
+
+function analyzeBasketsWasmButton() {
+    if (!window.isWasmReady()) {
+	document.getElementById('basketResults').className = 'results error';
+	document.getElementById('basketResults').textContent = 'WebAssembly not ready yet. Please wait...';
+	return;
+    }
+
+    fetchDemoUsersAndOrders()
+	.then(([users, orders]) => {
+	    const start = performance.now();
+	    return window.wasmApi.analyzeUserBehavior(users, orders)
+		.then(result => ({ result, elapsed: performance.now() - start }));
+	})
+	.then(({ result, elapsed }) => {
+	    performanceData.baskets.wasm = elapsed;
+	    displayBaskets('basketResults', result.baskets, '🌐 WebAssembly Client-Side Basket Analysis', elapsed);
+	})
+	.catch(error => displayError('basketResults', error));
+}
+
+function analyzeBasketsServer() {
+    fetchDemoUsersAndOrders()
+	.then(([users, orders]) => {
+	    const start = performance.now();
+	    return fetch('/api/analyze-behavior', {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify({ users: users, orders: orders })
+	    })
+	    .then(response => response.json())
+	    .then(result => {
+		const elapsed = performance.now() - start;
+		performanceData.baskets.server = elapsed;
+		displayBaskets('basketResults', result.baskets, '🖥️ Server-Side API Basket Analysis', elapsed);
+	    });
+	})
+	.catch(error => displayError('basketResults', error));
+}
+
+function displayBaskets(elementId, baskets, title, elapsed) {
+    const element = document.getElementById(elementId);
+    element.className = 'results success';
+    const percent = share => `${(share * 100).toFixed(1)}%`;
+    element.textContent = `${title}\n⚡ Execution Time: ${elapsed.toFixed(2)}ms\n\n` +
+	`Orders: ${baskets.orders}, ${baskets.average_items} items on average\n` +
+	`Single-category: ${percent(baskets.single_category_share)} | Multi-category: ${percent(baskets.multi_category_share)}\n\n` +
+	`Items per order:\n` +
+	baskets.items_per_order.map(b => `• ${b.label}: ${b.count}`).join('\n') +
+	`\n\nCategory mixes:\n` +
+	baskets.category_mixes.map(m => `• ${m.categories}: ${m.orders} orders, $${m.average_value} average`).join('\n');
+}
+
 // Benchmark functions
 function benchmarkMatrix() {
     const size = parseInt(document.getElementById('matrixSize').value);
@@ -327,7 +479,7 @@ function benchmarkMandelbrot() {
 	let wasmTotalTime = 0;
 	for (let run = 0; run < 5; run++) {
 	    const wasmStart = performance.now();
-	    const wasmResult = mandelbrotWasm(width, height, -2, 1, -1.5, 1.5, iterations);
+	    const wasmResult = mandelbrotWasm(width, height, -2, 1, -1.5, 1.5, iterations, false);
 	    wasmTotalTime += performance.now() - wasmStart;
 	}
 	const wasmDuration = wasmTotalTime / 5;
@@ -393,7 +545,31 @@ function getCurrentUser() {
     };
 }
 
-function displayResult(elementId, result, title, elapsed, performanceKey) {
+// unwrapEnvelope splits an enveloped API response into its payload and the
+// spans of its trace. Error responses aren't enveloped.
+function unwrapEnvelope(body) {
+    if (body && body.data !== undefined && body.meta) {
+	return { result: body.data, spans: body.meta.spans };
+    }
+    return { result: body, spans: undefined };
+}
+
+// spanBreakdown lists the business logic a call's trace timed, and the rest
+// of the call's time: crossing the boundary, decoding and encoding JSON, or
+// on the server, routing and middleware.
+function spanBreakdown(spans) {
+    if (!spans || spans.length === 0) {
+	return '';
+    }
+    const [call, ...others] = spans;
+    const children = others.filter(span => span.parent_id === call.span_id);
+    const timed = children.reduce((sum, span) => sum + span.duration_ms, 0);
+    const parts = children.map(span => `${span.name} ${span.duration_ms.toFixed(3)}ms`);
+    parts.push(`rest of ${call.name} ${Math.max(call.duration_ms - timed, 0).toFixed(3)}ms`);
+    return `🔍 Trace: ${parts.join(', ')}\n`;
+}
+
+function displayResult(elementId, result, title, elapsed, performanceKey, spans) {
     const element = document.getElementById(elementId);
     const isValid = result.valid;
 
@@ -414,7 +590,7 @@ function displayResult(elementId, result, title, elapsed, performanceKey) {
 		timingInfo += ` | 🏆 ${faster} ${speedup}x faster (${difference}ms difference)`;
 	    }
 	}
-	timingInfo += '\n\n';
+	timingInfo += '\n' + spanBreakdown(spans) + '\n';
     }
     
     element.textContent = `${title}\n${timingInfo}` +
@@ -424,7 +600,7 @@ function displayResult(elementId, result, title, elapsed, performanceKey) {
 	    'All validation rules passed!');
 }
 
-function displayOrderResult(elementId, result, title, elapsed, performanceKey) {
+function displayOrderResult(elementId, result, title, elapsed, performanceKey, spans) {
     const element = document.getElementById(elementId);
     element.className = 'results success';
     
@@ -443,12 +619,20 @@ function displayOrderResult(elementId, result, title, elapsed, performanceKey) {
 		timingInfo += ` | 🏆 ${faster} ${speedup}x faster (${difference}ms difference)`;
 	    }
 	}
-	timingInfo += '\n\n';
+	timingInfo += '\n' + spanBreakdown(spans) + '\n';
     }
     
-    element.textContent = `${title}\n${timingInfo}` +
-	`Subtotal: $${result.subtotal.toFixed(2)}\n` +
-	`Tax: $${result.tax.toFixed(2)}\n` +
+    // Tax-inclusive orders list each line net, tax and gross
+    let lines = '';
+    if (result.includes_tax && result.lines) {
+	lines = result.lines.map(line =>
+	    `  #${line.product_id} x${line.quantity}: ${line.gross.toFixed(2)} (net ${line.net.toFixed(2)} + ${(line.tax_rate * 100).toFixed(1)}% tax ${line.tax.toFixed(2)})\n`
+	).join('') + '\n';
+    }
+
+    element.textContent = `${title}\n${timingInfo}` + lines +
+	`Subtotal: $${result.subtotal.toFixed(2)}${result.includes_tax ? ' (incl. tax)' : ''}\n` +
+	`Tax: $${result.tax.toFixed(2)}${result.includes_tax ? ' (included)' : ''}\n` +
 	`Shipping: $${result.shipping.toFixed(2)}\n` +
 	`Discount: $${result.discount.toFixed(2)}\n` +
 	`Total: $${result.total.toFixed(2)}`;
@@ -478,7 +662,8 @@ function displayRecommendations(elementId, result, title, elapsed, performanceKe
     
     if (Array.isArray(result) && result.length > 0) {
 	element.textContent = `${title}\n${timingInfo}Recommended Products:\n` +
-	    result.map(p => `• ${p.name} - $${p.price} (${p.category})`).join('\n');
+	    result.map(p => `• ${p.name} - $${p.price} (${p.category})` +
+		(p.reasons && p.reasons.length ? `\n    why: ${p.reasons.map(r => r.reason).join(', ')}` : '')).join('\n');
     } else {
 	element.textContent = `${title}\n${timingInfo}No recommendations available.`;
     }
@@ -522,9 +707,8 @@ function displayPerformanceComparison(elementId, jsDuration, wasmDuration) {
 
 // Comprehensive benchmark functions for 3-way comparison
 function benchmarkMatrixComprehensive() {
-    if (!window.isWasmReady()) {
-	document.getElementById('matrixResults').className = 'results error';
-	document.getElementById('matrixResults').textContent = 'WebAssembly not ready yet. Please wait...';
+    if (!window.isBenchmarkModuleReady()) {
+	window.whenBenchmarksLoaded('matrixResults', benchmarkMatrixComprehensive);
 	return;
     }
 
@@ -574,9 +758,8 @@ function benchmarkMatrixComprehensive() {
 }
 
 function benchmarkMandelbrotComprehensive() {
-    if (!window.isWasmReady()) {
-	document.getElementById('mandelbrotResults').className = 'results error';
-	document.getElementById('mandelbrotResults').textContent = 'WebAssembly not ready yet. Please wait...';
+    if (!window.isBenchmarkModuleReady()) {
+	window.whenBenchmarksLoaded('mandelbrotResults', benchmarkMandelbrotComprehensive);
 	return;
     }
 
@@ -620,12 +803,12 @@ function benchmarkMandelbrotComprehensive() {
 
 	// Single-threaded WASM benchmark
 	const singleStart = performance.now();
-	window.mandelbrotWasm(width, height, xmin, xmax, ymin, ymax, maxIter);
+	window.mandelbrotWasm(width, height, xmin, xmax, ymin, ymax, maxIter, false);
 	const singleTime = performance.now() - singleStart;
 
 	// Concurrent WASM benchmark
 	const concurrentStart = performance.now();
-	window.mandelbrotConcurrentWasm(width, height, xmin, xmax, ymin, ymax, maxIter);
+	window.mandelbrotConcurrentWasm(width, height, xmin, xmax, ymin, ymax, maxIter, false);
 	const concurrentTime = performance.now() - concurrentStart;
 
 	// Display results
@@ -647,9 +830,8 @@ function benchmarkMandelbrotComprehensive() {
 }
 
 function benchmarkHashComprehensive() {
-    if (!window.isWasmReady()) {
-	document.getElementById('hashResults').className = 'results error';
-	document.getElementById('hashResults').textContent = 'WebAssembly not ready yet. Please wait...';
+    if (!window.isBenchmarkModuleReady()) {
+	window.whenBenchmarksLoaded('hashResults', benchmarkHashComprehensive);
 	return;
     }
 
@@ -695,9 +877,8 @@ function benchmarkHashComprehensive() {
 }
 
 function benchmarkRayTracingComprehensive() {
-    if (!window.isWasmReady()) {
-	document.getElementById('rayTracingResults').className = 'results error';
-	document.getElementById('rayTracingResults').textContent = 'WebAssembly not ready yet. Please wait...';
+    if (!window.isBenchmarkModuleReady()) {
+	window.whenBenchmarksLoaded('rayTracingResults', benchmarkRayTracingComprehensive);
 	return;
     }
 
@@ -781,4 +962,6 @@ window.addEventListener('load', () => {
     document.getElementById('productPrice').value = '99.99';
     document.getElementById('productCategory').value = 'electronics';
     document.getElementById('productRating').value = '4.5';
+    document.getElementById('productImageUrl').value = 'https://images.example.com/products/1/wireless-headphones-480.jpg';
+    document.getElementById('productImageAlt').value = 'Wireless Headphones';
 });
@@ -92,8 +92,8 @@ document.addEventListener('DOMContentLoaded', function() {
         updateLoadingProgress(wasmProgress, 100, 'WebAssembly', 'Loading module...');
     }, 200);
     
-    // Initialize WebAssembly using shared function
-    window.initWasm().then(() => {
+    // Initialize the benchmark module using shared function
+    window.initWasm('bench').then(() => {
         clearInterval(wasmProgressInterval);
         updateLoadingProgress(100, 100, 'WebAssembly', 'Module loaded successfully!');
         
@@ -127,10 +127,16 @@ const benchmarks = [
     {
         name: 'Matrix Multiplication',
         icon: '🔢',
+        key: 'matrix',
+        historyParams: (p) => ({ size: p.size }),
         tests: [
             { name: 'JavaScript', fn: 'matrixMultiplyJSOptimized' },
             { name: 'Single-Thread WASM', fn: 'matrixMultiplyWasm' },
-            { name: 'Concurrent WASM', fn: 'matrixMultiplyConcurrentWasm' }
+            { name: 'Concurrent WASM', fn: 'matrixMultiplyConcurrentWasm' },
+            // The optimized variant's kernels, which the lite build leaves out
+            { name: 'Blocked WASM', fn: 'matrixMultiplyOptimizedWasm', args: ['blocked'], optional: true },
+            { name: 'Cache-Oblivious WASM', fn: 'matrixMultiplyOptimizedWasm', args: ['recursive'], optional: true },
+            { name: 'Strassen WASM', fn: 'matrixMultiplyOptimizedWasm', args: ['strassen'], optional: true }
         ],
         setup: () => {
             const size = parseInt(document.getElementById('matrixSize').value);
@@ -146,6 +152,8 @@ const benchmarks = [
     {
         name: 'Mandelbrot Set',
         icon: '🌀',
+        key: 'mandelbrot',
+        historyParams: (p) => ({ width: p.width, height: p.height, iterations: p.maxIter }),
         tests: [
             { name: 'JavaScript', fn: 'mandelbrotJSOptimized' },
             { name: 'Single-Thread WASM', fn: 'mandelbrotWasm' },
@@ -176,6 +184,8 @@ const benchmarks = [
     {
         name: 'Cryptographic Hash',
         icon: '🔐',
+        key: 'hash',
+        historyParams: (p) => ({ count: p.iterations }),
         tests: [
             { name: 'JavaScript', fn: 'sha256HashJSOptimized' },
             { name: 'Single-Thread WASM', fn: 'sha256HashWasm' },
@@ -190,6 +200,8 @@ const benchmarks = [
     {
         name: 'Ray Tracing',
         icon: '🎨',
+        key: 'raytracing',
+        historyParams: (p) => ({ width: p.width, height: p.height, samples: p.samples }),
         tests: [
             { name: 'JavaScript', fn: 'rayTracingJSOptimized' },
             { name: 'Single-Thread WASM', fn: 'rayTracingWasm' },
@@ -221,7 +233,7 @@ async function runAllBenchmarks() {
     progressBar.style.display = 'none';
     
     const iterations = parseInt(document.getElementById('iterations').value);
-    const totalTests = benchmarks.length * 3 * iterations;
+    const totalTests = benchmarks.reduce((total, benchmark) => total + benchmark.tests.length, 0) * iterations;
     let completedTests = 0;
     
     // Initialize progress
@@ -239,6 +251,7 @@ async function runAllBenchmarks() {
         
         const params = benchmark.setup();
         const results = [];
+        const challenge = await window.requestBenchmarkChallenge(benchmark.key, benchmark.historyParams(params));
 
         // Run each test variant
         for (const test of benchmark.tests) {
@@ -246,8 +259,10 @@ async function runAllBenchmarks() {
             const fn = window[test.fn];
             
             if (!fn) {
-                console.error(`Function ${test.fn} not found`);
-                results.push({ name: test.name, avg: 0, times: [] });
+                if (!test.optional) {
+                    console.error(`Function ${test.fn} not found`);
+                    results.push({ name: test.name, avg: 0, times: [] });
+                }
                 // Still update progress for missing functions
                 for (let i = 0; i < iterations; i++) {
                     updateProgress(benchmark.name, test.name);
@@ -258,9 +273,9 @@ async function runAllBenchmarks() {
             // Warm-up run
             try {
                 if (benchmark.name === 'Matrix Multiplication') {
-                    fn(params.matrixA, params.matrixB, params.size);
+                    fn(params.matrixA, params.matrixB, params.size, ...(test.args || []));
                 } else if (benchmark.name === 'Mandelbrot Set') {
-                    fn(params.width, params.height, params.xmin, params.xmax, params.ymin, params.ymax, params.maxIter);
+                    fn(params.width, params.height, params.xmin, params.xmax, params.ymin, params.ymax, params.maxIter, false);
                 } else if (benchmark.name === 'Cryptographic Hash') {
                     fn(params.data, params.iterations);
                 } else if (benchmark.name === 'Ray Tracing') {
@@ -276,9 +291,9 @@ async function runAllBenchmarks() {
                     const start = performance.now();
                     
                     if (benchmark.name === 'Matrix Multiplication') {
-                        fn(params.matrixA, params.matrixB, params.size);
+                        fn(params.matrixA, params.matrixB, params.size, ...(test.args || []));
                     } else if (benchmark.name === 'Mandelbrot Set') {
-                        fn(params.width, params.height, params.xmin, params.xmax, params.ymin, params.ymax, params.maxIter);
+                        fn(params.width, params.height, params.xmin, params.xmax, params.ymin, params.ymax, params.maxIter, false);
                     } else if (benchmark.name === 'Cryptographic Hash') {
                         fn(params.data, params.iterations);
                     } else if (benchmark.name === 'Ray Tracing') {
@@ -304,6 +319,9 @@ async function runAllBenchmarks() {
         // Update card with results
         updateBenchmarkCard(card, results);
         benchmarkResults[benchmark.name] = results;
+
+        // Store the runs in the server's benchmark history (if a server is available)
+        window.recordBenchmarkResults(benchmark.key, benchmark.historyParams(params), results, challenge);
     }
 
     // Complete progress and show summary
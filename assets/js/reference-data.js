@@ -0,0 +1,2291 @@
+// Code generated by go generate from src/shared_reference_data.go; DO NOT EDIT.
+
+// The tables the business logic prices, taxes and ships by, as
+// GET /api/reference-data serves them
+window.referenceData = {
+    "markets": [
+        {
+            "code": "US",
+            "name": "United States",
+            "currency": "USD",
+            "prices_include_tax": false,
+            "shipping_rate": 8.99
+        },
+        {
+            "code": "CA",
+            "name": "Canada",
+            "currency": "CAD",
+            "prices_include_tax": false,
+            "shipping_rate": 12.99
+        },
+        {
+            "code": "UK",
+            "name": "United Kingdom",
+            "currency": "GBP",
+            "prices_include_tax": false,
+            "shipping_rate": 15.99
+        },
+        {
+            "code": "DE",
+            "name": "Germany",
+            "currency": "EUR",
+            "prices_include_tax": true,
+            "shipping_rate": 14.99
+        },
+        {
+            "code": "FR",
+            "name": "France",
+            "currency": "EUR",
+            "prices_include_tax": true,
+            "shipping_rate": 14.99
+        },
+        {
+            "code": "JP",
+            "name": "Japan",
+            "currency": "JPY",
+            "prices_include_tax": false,
+            "shipping_rate": 18.99
+        },
+        {
+            "code": "AU",
+            "name": "Australia",
+            "currency": "AUD",
+            "prices_include_tax": false,
+            "shipping_rate": 19.99
+        },
+        {
+            "code": "IN",
+            "name": "India",
+            "currency": "INR",
+            "prices_include_tax": false,
+            "shipping_rate": 9.99
+        },
+        {
+            "code": "BR",
+            "name": "Brazil",
+            "currency": "BRL",
+            "prices_include_tax": false,
+            "shipping_rate": 16.99
+        },
+        {
+            "code": "MX",
+            "name": "Mexico",
+            "currency": "MXN",
+            "prices_include_tax": false,
+            "shipping_rate": 13.99
+        }
+    ],
+    "categories": [
+        "electronics",
+        "clothing",
+        "books",
+        "home",
+        "sports",
+        "toys",
+        "beauty"
+    ],
+    "currencies": [
+        {
+            "code": "AUD",
+            "symbol": "A$",
+            "decimals": 2
+        },
+        {
+            "code": "BRL",
+            "symbol": "R$",
+            "decimals": 2
+        },
+        {
+            "code": "CAD",
+            "symbol": "CA$",
+            "decimals": 2
+        },
+        {
+            "code": "EUR",
+            "symbol": "€",
+            "decimals": 2
+        },
+        {
+            "code": "GBP",
+            "symbol": "£",
+            "decimals": 2
+        },
+        {
+            "code": "INR",
+            "symbol": "₹",
+            "decimals": 2
+        },
+        {
+            "code": "JPY",
+            "symbol": "¥",
+            "decimals": 0
+        },
+        {
+            "code": "MXN",
+            "symbol": "MX$",
+            "decimals": 2
+        },
+        {
+            "code": "USD",
+            "symbol": "$",
+            "decimals": 2
+        }
+    ],
+    "tax_rules": [
+        {
+            "country": "US",
+            "rate": 0.08
+        },
+        {
+            "country": "CA",
+            "rate": 0.13
+        },
+        {
+            "country": "UK",
+            "rate": 0.175,
+            "exempt_categories": [
+                "books"
+            ]
+        },
+        {
+            "country": "UK",
+            "rate": 0.2,
+            "effective": "2011-01-04",
+            "exempt_categories": [
+                "books"
+            ]
+        },
+        {
+            "country": "DE",
+            "rate": 0.19
+        },
+        {
+            "country": "FR",
+            "rate": 0.2
+        },
+        {
+            "country": "JP",
+            "rate": 0.08
+        },
+        {
+            "country": "JP",
+            "rate": 0.1,
+            "effective": "2019-10-01"
+        },
+        {
+            "country": "AU",
+            "rate": 0.1
+        },
+        {
+            "country": "IN",
+            "rate": 0.18
+        },
+        {
+            "country": "BR",
+            "rate": 0.17
+        },
+        {
+            "country": "MX",
+            "rate": 0.16
+        },
+        {
+            "country": "US",
+            "region": "CA",
+            "rate": 0.0725
+        },
+        {
+            "country": "US",
+            "region": "NY",
+            "rate": 0.04
+        },
+        {
+            "country": "US",
+            "region": "TX",
+            "rate": 0.0625
+        },
+        {
+            "country": "US",
+            "region": "FL",
+            "rate": 0.06
+        },
+        {
+            "country": "US",
+            "region": "WA",
+            "rate": 0.065
+        },
+        {
+            "country": "US",
+            "region": "IL",
+            "rate": 0.0625
+        },
+        {
+            "country": "US",
+            "region": "PA",
+            "rate": 0.06,
+            "exempt_categories": [
+                "clothing",
+                "books"
+            ]
+        },
+        {
+            "country": "US",
+            "region": "MN",
+            "rate": 0.06875,
+            "exempt_categories": [
+                "clothing"
+            ]
+        },
+        {
+            "country": "US",
+            "region": "OR",
+            "rate": 0
+        },
+        {
+            "country": "US",
+            "region": "DE",
+            "rate": 0
+        },
+        {
+            "country": "US",
+            "region": "NH",
+            "rate": 0
+        },
+        {
+            "country": "US",
+            "region": "MT",
+            "rate": 0
+        },
+        {
+            "country": "CA",
+            "region": "AB",
+            "rate": 0.05
+        },
+        {
+            "country": "CA",
+            "region": "BC",
+            "rate": 0.12
+        },
+        {
+            "country": "CA",
+            "region": "MB",
+            "rate": 0.12
+        },
+        {
+            "country": "CA",
+            "region": "NB",
+            "rate": 0.15
+        },
+        {
+            "country": "CA",
+            "region": "NL",
+            "rate": 0.15
+        },
+        {
+            "country": "CA",
+            "region": "NS",
+            "rate": 0.15
+        },
+        {
+            "country": "CA",
+            "region": "NS",
+            "rate": 0.14,
+            "effective": "2025-04-01"
+        },
+        {
+            "country": "CA",
+            "region": "NT",
+            "rate": 0.05
+        },
+        {
+            "country": "CA",
+            "region": "NU",
+            "rate": 0.05
+        },
+        {
+            "country": "CA",
+            "region": "ON",
+            "rate": 0.13
+        },
+        {
+            "country": "CA",
+            "region": "PE",
+            "rate": 0.15
+        },
+        {
+            "country": "CA",
+            "region": "QC",
+            "rate": 0.14975
+        },
+        {
+            "country": "CA",
+            "region": "SK",
+            "rate": 0.11
+        },
+        {
+            "country": "CA",
+            "region": "YT",
+            "rate": 0.05
+        }
+    ],
+    "default_tax_rate": 0.08,
+    "shipping": {
+        "origin": "US",
+        "zone_rates": {
+            "AU": 19.99,
+            "BR": 16.99,
+            "CA": 12.99,
+            "DE": 14.99,
+            "FR": 14.99,
+            "IN": 9.99,
+            "JP": 18.99,
+            "MX": 13.99,
+            "UK": 15.99,
+            "US": 8.99
+        },
+        "region_zone_rates": {
+            "Africa": 21.99,
+            "Americas": 13.99,
+            "Asia": 18.99,
+            "Europe": 15.99,
+            "Oceania": 19.99
+        },
+        "default_zone_rate": 12.99,
+        "services": [
+            {
+                "carrier": "demopost",
+                "carrier_name": "DemoPost",
+                "level": "standard",
+                "multiplier": 1,
+                "per_kg": 2,
+                "max_weight_kg": 30,
+                "min_days": 3,
+                "max_days": 6,
+                "extra_days_abroad": 5
+            },
+            {
+                "carrier": "demopost",
+                "carrier_name": "DemoPost",
+                "level": "express",
+                "multiplier": 1.8,
+                "per_kg": 3.5,
+                "max_weight_kg": 30,
+                "min_days": 2,
+                "max_days": 3,
+                "extra_days_abroad": 3
+            },
+            {
+                "carrier": "swiftship",
+                "carrier_name": "SwiftShip",
+                "level": "express",
+                "multiplier": 2,
+                "per_kg": 3,
+                "max_weight_kg": 70,
+                "min_days": 1,
+                "max_days": 2,
+                "extra_days_abroad": 2
+            },
+            {
+                "carrier": "swiftship",
+                "carrier_name": "SwiftShip",
+                "level": "overnight",
+                "countries": [
+                    "US"
+                ],
+                "multiplier": 3.2,
+                "per_kg": 5,
+                "max_weight_kg": 70,
+                "min_days": 1,
+                "max_days": 1,
+                "extra_days_abroad": 0
+            }
+        ]
+    },
+    "countries": [
+        {
+            "code": "AD",
+            "alpha3": "AND",
+            "name": "Andorra",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.045
+        },
+        {
+            "code": "AE",
+            "alpha3": "ARE",
+            "name": "United Arab Emirates",
+            "region": "Asia",
+            "currency": "AED",
+            "tax_rate": 0.05
+        },
+        {
+            "code": "AF",
+            "alpha3": "AFG",
+            "name": "Afghanistan",
+            "region": "Asia",
+            "currency": "AFN"
+        },
+        {
+            "code": "AG",
+            "alpha3": "ATG",
+            "name": "Antigua and Barbuda",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "AI",
+            "alpha3": "AIA",
+            "name": "Anguilla",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "AL",
+            "alpha3": "ALB",
+            "name": "Albania",
+            "region": "Europe",
+            "currency": "ALL",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "AM",
+            "alpha3": "ARM",
+            "name": "Armenia",
+            "region": "Asia",
+            "currency": "AMD",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "AO",
+            "alpha3": "AGO",
+            "name": "Angola",
+            "region": "Africa",
+            "currency": "AOA",
+            "tax_rate": 0.14
+        },
+        {
+            "code": "AQ",
+            "alpha3": "ATA",
+            "name": "Antarctica",
+            "region": "Antarctica"
+        },
+        {
+            "code": "AR",
+            "alpha3": "ARG",
+            "name": "Argentina",
+            "region": "Americas",
+            "currency": "ARS",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "AS",
+            "alpha3": "ASM",
+            "name": "American Samoa",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "AT",
+            "alpha3": "AUT",
+            "name": "Austria",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "AU",
+            "alpha3": "AUS",
+            "name": "Australia",
+            "region": "Oceania",
+            "currency": "AUD",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "AW",
+            "alpha3": "ABW",
+            "name": "Aruba",
+            "region": "Americas",
+            "currency": "AWG"
+        },
+        {
+            "code": "AX",
+            "alpha3": "ALA",
+            "name": "Åland Islands",
+            "region": "Europe",
+            "currency": "EUR"
+        },
+        {
+            "code": "AZ",
+            "alpha3": "AZE",
+            "name": "Azerbaijan",
+            "region": "Asia",
+            "currency": "AZN",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "BA",
+            "alpha3": "BIH",
+            "name": "Bosnia and Herzegovina",
+            "region": "Europe",
+            "currency": "BAM",
+            "tax_rate": 0.17
+        },
+        {
+            "code": "BB",
+            "alpha3": "BRB",
+            "name": "Barbados",
+            "region": "Americas",
+            "currency": "BBD",
+            "tax_rate": 0.175
+        },
+        {
+            "code": "BD",
+            "alpha3": "BGD",
+            "name": "Bangladesh",
+            "region": "Asia",
+            "currency": "BDT",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "BE",
+            "alpha3": "BEL",
+            "name": "Belgium",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "BF",
+            "alpha3": "BFA",
+            "name": "Burkina Faso",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "BG",
+            "alpha3": "BGR",
+            "name": "Bulgaria",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "BH",
+            "alpha3": "BHR",
+            "name": "Bahrain",
+            "region": "Asia",
+            "currency": "BHD",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "BI",
+            "alpha3": "BDI",
+            "name": "Burundi",
+            "region": "Africa",
+            "currency": "BIF"
+        },
+        {
+            "code": "BJ",
+            "alpha3": "BEN",
+            "name": "Benin",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "BL",
+            "alpha3": "BLM",
+            "name": "Saint Barthélemy",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "BM",
+            "alpha3": "BMU",
+            "name": "Bermuda",
+            "region": "Americas",
+            "currency": "BMD"
+        },
+        {
+            "code": "BN",
+            "alpha3": "BRN",
+            "name": "Brunei",
+            "region": "Asia",
+            "currency": "BND"
+        },
+        {
+            "code": "BO",
+            "alpha3": "BOL",
+            "name": "Bolivia",
+            "region": "Americas",
+            "currency": "BOB",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "BQ",
+            "alpha3": "BES",
+            "name": "Caribbean Netherlands",
+            "region": "Americas",
+            "currency": "USD"
+        },
+        {
+            "code": "BR",
+            "alpha3": "BRA",
+            "name": "Brazil",
+            "region": "Americas",
+            "currency": "BRL",
+            "tax_rate": 0.17
+        },
+        {
+            "code": "BS",
+            "alpha3": "BHS",
+            "name": "Bahamas",
+            "region": "Americas",
+            "currency": "BSD",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "BT",
+            "alpha3": "BTN",
+            "name": "Bhutan",
+            "region": "Asia",
+            "currency": "BTN"
+        },
+        {
+            "code": "BV",
+            "alpha3": "BVT",
+            "name": "Bouvet Island",
+            "region": "Antarctica",
+            "currency": "NOK"
+        },
+        {
+            "code": "BW",
+            "alpha3": "BWA",
+            "name": "Botswana",
+            "region": "Africa",
+            "currency": "BWP",
+            "tax_rate": 0.14
+        },
+        {
+            "code": "BY",
+            "alpha3": "BLR",
+            "name": "Belarus",
+            "region": "Europe",
+            "currency": "BYN",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "BZ",
+            "alpha3": "BLZ",
+            "name": "Belize",
+            "region": "Americas",
+            "currency": "BZD",
+            "tax_rate": 0.125
+        },
+        {
+            "code": "CA",
+            "alpha3": "CAN",
+            "name": "Canada",
+            "region": "Americas",
+            "currency": "CAD",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "CC",
+            "alpha3": "CCK",
+            "name": "Cocos (Keeling) Islands",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "CD",
+            "alpha3": "COD",
+            "name": "DR Congo",
+            "region": "Africa",
+            "currency": "CDF",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "CF",
+            "alpha3": "CAF",
+            "name": "Central African Republic",
+            "region": "Africa",
+            "currency": "XAF"
+        },
+        {
+            "code": "CG",
+            "alpha3": "COG",
+            "name": "Republic of the Congo",
+            "region": "Africa",
+            "currency": "XAF"
+        },
+        {
+            "code": "CH",
+            "alpha3": "CHE",
+            "name": "Switzerland",
+            "region": "Europe",
+            "currency": "CHF",
+            "tax_rate": 0.081
+        },
+        {
+            "code": "CI",
+            "alpha3": "CIV",
+            "name": "Côte d'Ivoire",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "CK",
+            "alpha3": "COK",
+            "name": "Cook Islands",
+            "region": "Oceania",
+            "currency": "NZD"
+        },
+        {
+            "code": "CL",
+            "alpha3": "CHL",
+            "name": "Chile",
+            "region": "Americas",
+            "currency": "CLP",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "CM",
+            "alpha3": "CMR",
+            "name": "Cameroon",
+            "region": "Africa",
+            "currency": "XAF",
+            "tax_rate": 0.1925
+        },
+        {
+            "code": "CN",
+            "alpha3": "CHN",
+            "name": "China",
+            "region": "Asia",
+            "currency": "CNY",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "CO",
+            "alpha3": "COL",
+            "name": "Colombia",
+            "region": "Americas",
+            "currency": "COP",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "CR",
+            "alpha3": "CRI",
+            "name": "Costa Rica",
+            "region": "Americas",
+            "currency": "CRC",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "CU",
+            "alpha3": "CUB",
+            "name": "Cuba",
+            "region": "Americas",
+            "currency": "CUP"
+        },
+        {
+            "code": "CV",
+            "alpha3": "CPV",
+            "name": "Cabo Verde",
+            "region": "Africa",
+            "currency": "CVE",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "CW",
+            "alpha3": "CUW",
+            "name": "Curaçao",
+            "region": "Americas",
+            "currency": "XCG"
+        },
+        {
+            "code": "CX",
+            "alpha3": "CXR",
+            "name": "Christmas Island",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "CY",
+            "alpha3": "CYP",
+            "name": "Cyprus",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "CZ",
+            "alpha3": "CZE",
+            "name": "Czechia",
+            "region": "Europe",
+            "currency": "CZK",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "DE",
+            "alpha3": "DEU",
+            "name": "Germany",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "DJ",
+            "alpha3": "DJI",
+            "name": "Djibouti",
+            "region": "Africa",
+            "currency": "DJF"
+        },
+        {
+            "code": "DK",
+            "alpha3": "DNK",
+            "name": "Denmark",
+            "region": "Europe",
+            "currency": "DKK",
+            "tax_rate": 0.25
+        },
+        {
+            "code": "DM",
+            "alpha3": "DMA",
+            "name": "Dominica",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "DO",
+            "alpha3": "DOM",
+            "name": "Dominican Republic",
+            "region": "Americas",
+            "currency": "DOP",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "DZ",
+            "alpha3": "DZA",
+            "name": "Algeria",
+            "region": "Africa",
+            "currency": "DZD",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "EC",
+            "alpha3": "ECU",
+            "name": "Ecuador",
+            "region": "Americas",
+            "currency": "USD",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "EE",
+            "alpha3": "EST",
+            "name": "Estonia",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.24
+        },
+        {
+            "code": "EG",
+            "alpha3": "EGY",
+            "name": "Egypt",
+            "region": "Africa",
+            "currency": "EGP",
+            "tax_rate": 0.14
+        },
+        {
+            "code": "EH",
+            "alpha3": "ESH",
+            "name": "Western Sahara",
+            "region": "Africa",
+            "currency": "MAD"
+        },
+        {
+            "code": "ER",
+            "alpha3": "ERI",
+            "name": "Eritrea",
+            "region": "Africa",
+            "currency": "ERN"
+        },
+        {
+            "code": "ES",
+            "alpha3": "ESP",
+            "name": "Spain",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "ET",
+            "alpha3": "ETH",
+            "name": "Ethiopia",
+            "region": "Africa",
+            "currency": "ETB",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "FI",
+            "alpha3": "FIN",
+            "name": "Finland",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.255
+        },
+        {
+            "code": "FJ",
+            "alpha3": "FJI",
+            "name": "Fiji",
+            "region": "Oceania",
+            "currency": "FJD"
+        },
+        {
+            "code": "FK",
+            "alpha3": "FLK",
+            "name": "Falkland Islands",
+            "region": "Americas",
+            "currency": "FKP"
+        },
+        {
+            "code": "FM",
+            "alpha3": "FSM",
+            "name": "Micronesia",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "FO",
+            "alpha3": "FRO",
+            "name": "Faroe Islands",
+            "region": "Europe",
+            "currency": "DKK",
+            "tax_rate": 0.25
+        },
+        {
+            "code": "FR",
+            "alpha3": "FRA",
+            "name": "France",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "GA",
+            "alpha3": "GAB",
+            "name": "Gabon",
+            "region": "Africa",
+            "currency": "XAF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "GB",
+            "alpha3": "GBR",
+            "name": "United Kingdom",
+            "region": "Europe",
+            "currency": "GBP",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "GD",
+            "alpha3": "GRD",
+            "name": "Grenada",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "GE",
+            "alpha3": "GEO",
+            "name": "Georgia",
+            "region": "Asia",
+            "currency": "GEL",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "GF",
+            "alpha3": "GUF",
+            "name": "French Guiana",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "GG",
+            "alpha3": "GGY",
+            "name": "Guernsey",
+            "region": "Europe",
+            "currency": "GBP"
+        },
+        {
+            "code": "GH",
+            "alpha3": "GHA",
+            "name": "Ghana",
+            "region": "Africa",
+            "currency": "GHS"
+        },
+        {
+            "code": "GI",
+            "alpha3": "GIB",
+            "name": "Gibraltar",
+            "region": "Europe",
+            "currency": "GIP"
+        },
+        {
+            "code": "GL",
+            "alpha3": "GRL",
+            "name": "Greenland",
+            "region": "Americas",
+            "currency": "DKK"
+        },
+        {
+            "code": "GM",
+            "alpha3": "GMB",
+            "name": "Gambia",
+            "region": "Africa",
+            "currency": "GMD"
+        },
+        {
+            "code": "GN",
+            "alpha3": "GIN",
+            "name": "Guinea",
+            "region": "Africa",
+            "currency": "GNF"
+        },
+        {
+            "code": "GP",
+            "alpha3": "GLP",
+            "name": "Guadeloupe",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "GQ",
+            "alpha3": "GNQ",
+            "name": "Equatorial Guinea",
+            "region": "Africa",
+            "currency": "XAF"
+        },
+        {
+            "code": "GR",
+            "alpha3": "GRC",
+            "name": "Greece",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.24
+        },
+        {
+            "code": "GS",
+            "alpha3": "SGS",
+            "name": "South Georgia and the South Sandwich Islands",
+            "region": "Antarctica",
+            "currency": "GBP"
+        },
+        {
+            "code": "GT",
+            "alpha3": "GTM",
+            "name": "Guatemala",
+            "region": "Americas",
+            "currency": "GTQ",
+            "tax_rate": 0.12
+        },
+        {
+            "code": "GU",
+            "alpha3": "GUM",
+            "name": "Guam",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "GW",
+            "alpha3": "GNB",
+            "name": "Guinea-Bissau",
+            "region": "Africa",
+            "currency": "XOF"
+        },
+        {
+            "code": "GY",
+            "alpha3": "GUY",
+            "name": "Guyana",
+            "region": "Americas",
+            "currency": "GYD",
+            "tax_rate": 0.14
+        },
+        {
+            "code": "HK",
+            "alpha3": "HKG",
+            "name": "Hong Kong",
+            "region": "Asia",
+            "currency": "HKD",
+            "tax_rate": 0
+        },
+        {
+            "code": "HM",
+            "alpha3": "HMD",
+            "name": "Heard Island and McDonald Islands",
+            "region": "Antarctica",
+            "currency": "AUD"
+        },
+        {
+            "code": "HN",
+            "alpha3": "HND",
+            "name": "Honduras",
+            "region": "Americas",
+            "currency": "HNL",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "HR",
+            "alpha3": "HRV",
+            "name": "Croatia",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.25
+        },
+        {
+            "code": "HT",
+            "alpha3": "HTI",
+            "name": "Haiti",
+            "region": "Americas",
+            "currency": "HTG"
+        },
+        {
+            "code": "HU",
+            "alpha3": "HUN",
+            "name": "Hungary",
+            "region": "Europe",
+            "currency": "HUF",
+            "tax_rate": 0.27
+        },
+        {
+            "code": "ID",
+            "alpha3": "IDN",
+            "name": "Indonesia",
+            "region": "Asia",
+            "currency": "IDR",
+            "tax_rate": 0.11
+        },
+        {
+            "code": "IE",
+            "alpha3": "IRL",
+            "name": "Ireland",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.23
+        },
+        {
+            "code": "IL",
+            "alpha3": "ISR",
+            "name": "Israel",
+            "region": "Asia",
+            "currency": "ILS",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "IM",
+            "alpha3": "IMN",
+            "name": "Isle of Man",
+            "region": "Europe",
+            "currency": "GBP",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "IN",
+            "alpha3": "IND",
+            "name": "India",
+            "region": "Asia",
+            "currency": "INR",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "IO",
+            "alpha3": "IOT",
+            "name": "British Indian Ocean Territory",
+            "region": "Asia",
+            "currency": "USD"
+        },
+        {
+            "code": "IQ",
+            "alpha3": "IRQ",
+            "name": "Iraq",
+            "region": "Asia",
+            "currency": "IQD"
+        },
+        {
+            "code": "IR",
+            "alpha3": "IRN",
+            "name": "Iran",
+            "region": "Asia",
+            "currency": "IRR",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "IS",
+            "alpha3": "ISL",
+            "name": "Iceland",
+            "region": "Europe",
+            "currency": "ISK",
+            "tax_rate": 0.24
+        },
+        {
+            "code": "IT",
+            "alpha3": "ITA",
+            "name": "Italy",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.22
+        },
+        {
+            "code": "JE",
+            "alpha3": "JEY",
+            "name": "Jersey",
+            "region": "Europe",
+            "currency": "GBP",
+            "tax_rate": 0.05
+        },
+        {
+            "code": "JM",
+            "alpha3": "JAM",
+            "name": "Jamaica",
+            "region": "Americas",
+            "currency": "JMD",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "JO",
+            "alpha3": "JOR",
+            "name": "Jordan",
+            "region": "Asia",
+            "currency": "JOD",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "JP",
+            "alpha3": "JPN",
+            "name": "Japan",
+            "region": "Asia",
+            "currency": "JPY",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "KE",
+            "alpha3": "KEN",
+            "name": "Kenya",
+            "region": "Africa",
+            "currency": "KES",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "KG",
+            "alpha3": "KGZ",
+            "name": "Kyrgyzstan",
+            "region": "Asia",
+            "currency": "KGS",
+            "tax_rate": 0.12
+        },
+        {
+            "code": "KH",
+            "alpha3": "KHM",
+            "name": "Cambodia",
+            "region": "Asia",
+            "currency": "KHR",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "KI",
+            "alpha3": "KIR",
+            "name": "Kiribati",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "KM",
+            "alpha3": "COM",
+            "name": "Comoros",
+            "region": "Africa",
+            "currency": "KMF"
+        },
+        {
+            "code": "KN",
+            "alpha3": "KNA",
+            "name": "Saint Kitts and Nevis",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "KP",
+            "alpha3": "PRK",
+            "name": "North Korea",
+            "region": "Asia",
+            "currency": "KPW"
+        },
+        {
+            "code": "KR",
+            "alpha3": "KOR",
+            "name": "South Korea",
+            "region": "Asia",
+            "currency": "KRW",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "KW",
+            "alpha3": "KWT",
+            "name": "Kuwait",
+            "region": "Asia",
+            "currency": "KWD",
+            "tax_rate": 0
+        },
+        {
+            "code": "KY",
+            "alpha3": "CYM",
+            "name": "Cayman Islands",
+            "region": "Americas",
+            "currency": "KYD",
+            "tax_rate": 0
+        },
+        {
+            "code": "KZ",
+            "alpha3": "KAZ",
+            "name": "Kazakhstan",
+            "region": "Asia",
+            "currency": "KZT"
+        },
+        {
+            "code": "LA",
+            "alpha3": "LAO",
+            "name": "Laos",
+            "region": "Asia",
+            "currency": "LAK"
+        },
+        {
+            "code": "LB",
+            "alpha3": "LBN",
+            "name": "Lebanon",
+            "region": "Asia",
+            "currency": "LBP",
+            "tax_rate": 0.11
+        },
+        {
+            "code": "LC",
+            "alpha3": "LCA",
+            "name": "Saint Lucia",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "LI",
+            "alpha3": "LIE",
+            "name": "Liechtenstein",
+            "region": "Europe",
+            "currency": "CHF",
+            "tax_rate": 0.081
+        },
+        {
+            "code": "LK",
+            "alpha3": "LKA",
+            "name": "Sri Lanka",
+            "region": "Asia",
+            "currency": "LKR",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "LR",
+            "alpha3": "LBR",
+            "name": "Liberia",
+            "region": "Africa",
+            "currency": "LRD"
+        },
+        {
+            "code": "LS",
+            "alpha3": "LSO",
+            "name": "Lesotho",
+            "region": "Africa",
+            "currency": "LSL",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "LT",
+            "alpha3": "LTU",
+            "name": "Lithuania",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "LU",
+            "alpha3": "LUX",
+            "name": "Luxembourg",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.17
+        },
+        {
+            "code": "LV",
+            "alpha3": "LVA",
+            "name": "Latvia",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "LY",
+            "alpha3": "LBY",
+            "name": "Libya",
+            "region": "Africa",
+            "currency": "LYD"
+        },
+        {
+            "code": "MA",
+            "alpha3": "MAR",
+            "name": "Morocco",
+            "region": "Africa",
+            "currency": "MAD",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "MC",
+            "alpha3": "MCO",
+            "name": "Monaco",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "MD",
+            "alpha3": "MDA",
+            "name": "Moldova",
+            "region": "Europe",
+            "currency": "MDL",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "ME",
+            "alpha3": "MNE",
+            "name": "Montenegro",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "MF",
+            "alpha3": "MAF",
+            "name": "Saint Martin",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "MG",
+            "alpha3": "MDG",
+            "name": "Madagascar",
+            "region": "Africa",
+            "currency": "MGA",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "MH",
+            "alpha3": "MHL",
+            "name": "Marshall Islands",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "MK",
+            "alpha3": "MKD",
+            "name": "North Macedonia",
+            "region": "Europe",
+            "currency": "MKD",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "ML",
+            "alpha3": "MLI",
+            "name": "Mali",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "MM",
+            "alpha3": "MMR",
+            "name": "Myanmar",
+            "region": "Asia",
+            "currency": "MMK"
+        },
+        {
+            "code": "MN",
+            "alpha3": "MNG",
+            "name": "Mongolia",
+            "region": "Asia",
+            "currency": "MNT",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "MO",
+            "alpha3": "MAC",
+            "name": "Macao",
+            "region": "Asia",
+            "currency": "MOP"
+        },
+        {
+            "code": "MP",
+            "alpha3": "MNP",
+            "name": "Northern Mariana Islands",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "MQ",
+            "alpha3": "MTQ",
+            "name": "Martinique",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "MR",
+            "alpha3": "MRT",
+            "name": "Mauritania",
+            "region": "Africa",
+            "currency": "MRU"
+        },
+        {
+            "code": "MS",
+            "alpha3": "MSR",
+            "name": "Montserrat",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "MT",
+            "alpha3": "MLT",
+            "name": "Malta",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "MU",
+            "alpha3": "MUS",
+            "name": "Mauritius",
+            "region": "Africa",
+            "currency": "MUR",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "MV",
+            "alpha3": "MDV",
+            "name": "Maldives",
+            "region": "Asia",
+            "currency": "MVR"
+        },
+        {
+            "code": "MW",
+            "alpha3": "MWI",
+            "name": "Malawi",
+            "region": "Africa",
+            "currency": "MWK"
+        },
+        {
+            "code": "MX",
+            "alpha3": "MEX",
+            "name": "Mexico",
+            "region": "Americas",
+            "currency": "MXN",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "MY",
+            "alpha3": "MYS",
+            "name": "Malaysia",
+            "region": "Asia",
+            "currency": "MYR"
+        },
+        {
+            "code": "MZ",
+            "alpha3": "MOZ",
+            "name": "Mozambique",
+            "region": "Africa",
+            "currency": "MZN",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "NA",
+            "alpha3": "NAM",
+            "name": "Namibia",
+            "region": "Africa",
+            "currency": "NAD",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "NC",
+            "alpha3": "NCL",
+            "name": "New Caledonia",
+            "region": "Oceania",
+            "currency": "XPF"
+        },
+        {
+            "code": "NE",
+            "alpha3": "NER",
+            "name": "Niger",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "NF",
+            "alpha3": "NFK",
+            "name": "Norfolk Island",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "NG",
+            "alpha3": "NGA",
+            "name": "Nigeria",
+            "region": "Africa",
+            "currency": "NGN",
+            "tax_rate": 0.075
+        },
+        {
+            "code": "NI",
+            "alpha3": "NIC",
+            "name": "Nicaragua",
+            "region": "Americas",
+            "currency": "NIO",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "NL",
+            "alpha3": "NLD",
+            "name": "Netherlands",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "NO",
+            "alpha3": "NOR",
+            "name": "Norway",
+            "region": "Europe",
+            "currency": "NOK",
+            "tax_rate": 0.25
+        },
+        {
+            "code": "NP",
+            "alpha3": "NPL",
+            "name": "Nepal",
+            "region": "Asia",
+            "currency": "NPR",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "NR",
+            "alpha3": "NRU",
+            "name": "Nauru",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "NU",
+            "alpha3": "NIU",
+            "name": "Niue",
+            "region": "Oceania",
+            "currency": "NZD"
+        },
+        {
+            "code": "NZ",
+            "alpha3": "NZL",
+            "name": "New Zealand",
+            "region": "Oceania",
+            "currency": "NZD",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "OM",
+            "alpha3": "OMN",
+            "name": "Oman",
+            "region": "Asia",
+            "currency": "OMR",
+            "tax_rate": 0.05
+        },
+        {
+            "code": "PA",
+            "alpha3": "PAN",
+            "name": "Panama",
+            "region": "Americas",
+            "currency": "PAB",
+            "tax_rate": 0.07
+        },
+        {
+            "code": "PE",
+            "alpha3": "PER",
+            "name": "Peru",
+            "region": "Americas",
+            "currency": "PEN",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "PF",
+            "alpha3": "PYF",
+            "name": "French Polynesia",
+            "region": "Oceania",
+            "currency": "XPF"
+        },
+        {
+            "code": "PG",
+            "alpha3": "PNG",
+            "name": "Papua New Guinea",
+            "region": "Oceania",
+            "currency": "PGK",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "PH",
+            "alpha3": "PHL",
+            "name": "Philippines",
+            "region": "Asia",
+            "currency": "PHP",
+            "tax_rate": 0.12
+        },
+        {
+            "code": "PK",
+            "alpha3": "PAK",
+            "name": "Pakistan",
+            "region": "Asia",
+            "currency": "PKR",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "PL",
+            "alpha3": "POL",
+            "name": "Poland",
+            "region": "Europe",
+            "currency": "PLN",
+            "tax_rate": 0.23
+        },
+        {
+            "code": "PM",
+            "alpha3": "SPM",
+            "name": "Saint Pierre and Miquelon",
+            "region": "Americas",
+            "currency": "EUR"
+        },
+        {
+            "code": "PN",
+            "alpha3": "PCN",
+            "name": "Pitcairn Islands",
+            "region": "Oceania",
+            "currency": "NZD"
+        },
+        {
+            "code": "PR",
+            "alpha3": "PRI",
+            "name": "Puerto Rico",
+            "region": "Americas",
+            "currency": "USD"
+        },
+        {
+            "code": "PS",
+            "alpha3": "PSE",
+            "name": "Palestine",
+            "region": "Asia",
+            "currency": "ILS"
+        },
+        {
+            "code": "PT",
+            "alpha3": "PRT",
+            "name": "Portugal",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.23
+        },
+        {
+            "code": "PW",
+            "alpha3": "PLW",
+            "name": "Palau",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "PY",
+            "alpha3": "PRY",
+            "name": "Paraguay",
+            "region": "Americas",
+            "currency": "PYG",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "QA",
+            "alpha3": "QAT",
+            "name": "Qatar",
+            "region": "Asia",
+            "currency": "QAR",
+            "tax_rate": 0
+        },
+        {
+            "code": "RE",
+            "alpha3": "REU",
+            "name": "Réunion",
+            "region": "Africa",
+            "currency": "EUR"
+        },
+        {
+            "code": "RO",
+            "alpha3": "ROU",
+            "name": "Romania",
+            "region": "Europe",
+            "currency": "RON",
+            "tax_rate": 0.21
+        },
+        {
+            "code": "RS",
+            "alpha3": "SRB",
+            "name": "Serbia",
+            "region": "Europe",
+            "currency": "RSD",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "RU",
+            "alpha3": "RUS",
+            "name": "Russia",
+            "region": "Europe",
+            "currency": "RUB"
+        },
+        {
+            "code": "RW",
+            "alpha3": "RWA",
+            "name": "Rwanda",
+            "region": "Africa",
+            "currency": "RWF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "SA",
+            "alpha3": "SAU",
+            "name": "Saudi Arabia",
+            "region": "Asia",
+            "currency": "SAR",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "SB",
+            "alpha3": "SLB",
+            "name": "Solomon Islands",
+            "region": "Oceania",
+            "currency": "SBD"
+        },
+        {
+            "code": "SC",
+            "alpha3": "SYC",
+            "name": "Seychelles",
+            "region": "Africa",
+            "currency": "SCR",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "SD",
+            "alpha3": "SDN",
+            "name": "Sudan",
+            "region": "Africa",
+            "currency": "SDG"
+        },
+        {
+            "code": "SE",
+            "alpha3": "SWE",
+            "name": "Sweden",
+            "region": "Europe",
+            "currency": "SEK",
+            "tax_rate": 0.25
+        },
+        {
+            "code": "SG",
+            "alpha3": "SGP",
+            "name": "Singapore",
+            "region": "Asia",
+            "currency": "SGD",
+            "tax_rate": 0.09
+        },
+        {
+            "code": "SH",
+            "alpha3": "SHN",
+            "name": "Saint Helena",
+            "region": "Africa",
+            "currency": "SHP"
+        },
+        {
+            "code": "SI",
+            "alpha3": "SVN",
+            "name": "Slovenia",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.22
+        },
+        {
+            "code": "SJ",
+            "alpha3": "SJM",
+            "name": "Svalbard and Jan Mayen",
+            "region": "Europe",
+            "currency": "NOK"
+        },
+        {
+            "code": "SK",
+            "alpha3": "SVK",
+            "name": "Slovakia",
+            "region": "Europe",
+            "currency": "EUR",
+            "tax_rate": 0.23
+        },
+        {
+            "code": "SL",
+            "alpha3": "SLE",
+            "name": "Sierra Leone",
+            "region": "Africa",
+            "currency": "SLE"
+        },
+        {
+            "code": "SM",
+            "alpha3": "SMR",
+            "name": "San Marino",
+            "region": "Europe",
+            "currency": "EUR"
+        },
+        {
+            "code": "SN",
+            "alpha3": "SEN",
+            "name": "Senegal",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "SO",
+            "alpha3": "SOM",
+            "name": "Somalia",
+            "region": "Africa",
+            "currency": "SOS"
+        },
+        {
+            "code": "SR",
+            "alpha3": "SUR",
+            "name": "Suriname",
+            "region": "Americas",
+            "currency": "SRD"
+        },
+        {
+            "code": "SS",
+            "alpha3": "SSD",
+            "name": "South Sudan",
+            "region": "Africa",
+            "currency": "SSP"
+        },
+        {
+            "code": "ST",
+            "alpha3": "STP",
+            "name": "São Tomé and Príncipe",
+            "region": "Africa",
+            "currency": "STN"
+        },
+        {
+            "code": "SV",
+            "alpha3": "SLV",
+            "name": "El Salvador",
+            "region": "Americas",
+            "currency": "USD",
+            "tax_rate": 0.13
+        },
+        {
+            "code": "SX",
+            "alpha3": "SXM",
+            "name": "Sint Maarten",
+            "region": "Americas",
+            "currency": "XCG"
+        },
+        {
+            "code": "SY",
+            "alpha3": "SYR",
+            "name": "Syria",
+            "region": "Asia",
+            "currency": "SYP"
+        },
+        {
+            "code": "SZ",
+            "alpha3": "SWZ",
+            "name": "Eswatini",
+            "region": "Africa",
+            "currency": "SZL",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "TC",
+            "alpha3": "TCA",
+            "name": "Turks and Caicos Islands",
+            "region": "Americas",
+            "currency": "USD"
+        },
+        {
+            "code": "TD",
+            "alpha3": "TCD",
+            "name": "Chad",
+            "region": "Africa",
+            "currency": "XAF"
+        },
+        {
+            "code": "TF",
+            "alpha3": "ATF",
+            "name": "French Southern Territories",
+            "region": "Antarctica",
+            "currency": "EUR"
+        },
+        {
+            "code": "TG",
+            "alpha3": "TGO",
+            "name": "Togo",
+            "region": "Africa",
+            "currency": "XOF",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "TH",
+            "alpha3": "THA",
+            "name": "Thailand",
+            "region": "Asia",
+            "currency": "THB",
+            "tax_rate": 0.07
+        },
+        {
+            "code": "TJ",
+            "alpha3": "TJK",
+            "name": "Tajikistan",
+            "region": "Asia",
+            "currency": "TJS"
+        },
+        {
+            "code": "TK",
+            "alpha3": "TKL",
+            "name": "Tokelau",
+            "region": "Oceania",
+            "currency": "NZD"
+        },
+        {
+            "code": "TL",
+            "alpha3": "TLS",
+            "name": "Timor-Leste",
+            "region": "Asia",
+            "currency": "USD"
+        },
+        {
+            "code": "TM",
+            "alpha3": "TKM",
+            "name": "Turkmenistan",
+            "region": "Asia",
+            "currency": "TMT"
+        },
+        {
+            "code": "TN",
+            "alpha3": "TUN",
+            "name": "Tunisia",
+            "region": "Africa",
+            "currency": "TND",
+            "tax_rate": 0.19
+        },
+        {
+            "code": "TO",
+            "alpha3": "TON",
+            "name": "Tonga",
+            "region": "Oceania",
+            "currency": "TOP",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "TR",
+            "alpha3": "TUR",
+            "name": "Türkiye",
+            "region": "Asia",
+            "currency": "TRY",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "TT",
+            "alpha3": "TTO",
+            "name": "Trinidad and Tobago",
+            "region": "Americas",
+            "currency": "TTD",
+            "tax_rate": 0.125
+        },
+        {
+            "code": "TV",
+            "alpha3": "TUV",
+            "name": "Tuvalu",
+            "region": "Oceania",
+            "currency": "AUD"
+        },
+        {
+            "code": "TW",
+            "alpha3": "TWN",
+            "name": "Taiwan",
+            "region": "Asia",
+            "currency": "TWD",
+            "tax_rate": 0.05
+        },
+        {
+            "code": "TZ",
+            "alpha3": "TZA",
+            "name": "Tanzania",
+            "region": "Africa",
+            "currency": "TZS",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "UA",
+            "alpha3": "UKR",
+            "name": "Ukraine",
+            "region": "Europe",
+            "currency": "UAH",
+            "tax_rate": 0.2
+        },
+        {
+            "code": "UG",
+            "alpha3": "UGA",
+            "name": "Uganda",
+            "region": "Africa",
+            "currency": "UGX",
+            "tax_rate": 0.18
+        },
+        {
+            "code": "UM",
+            "alpha3": "UMI",
+            "name": "United States Minor Outlying Islands",
+            "region": "Oceania",
+            "currency": "USD"
+        },
+        {
+            "code": "US",
+            "alpha3": "USA",
+            "name": "United States",
+            "region": "Americas",
+            "currency": "USD",
+            "tax_rate": 0.08
+        },
+        {
+            "code": "UY",
+            "alpha3": "URY",
+            "name": "Uruguay",
+            "region": "Americas",
+            "currency": "UYU",
+            "tax_rate": 0.22
+        },
+        {
+            "code": "UZ",
+            "alpha3": "UZB",
+            "name": "Uzbekistan",
+            "region": "Asia",
+            "currency": "UZS",
+            "tax_rate": 0.12
+        },
+        {
+            "code": "VA",
+            "alpha3": "VAT",
+            "name": "Vatican City",
+            "region": "Europe",
+            "currency": "EUR"
+        },
+        {
+            "code": "VC",
+            "alpha3": "VCT",
+            "name": "Saint Vincent and the Grenadines",
+            "region": "Americas",
+            "currency": "XCD"
+        },
+        {
+            "code": "VE",
+            "alpha3": "VEN",
+            "name": "Venezuela",
+            "region": "Americas",
+            "currency": "VES",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "VG",
+            "alpha3": "VGB",
+            "name": "British Virgin Islands",
+            "region": "Americas",
+            "currency": "USD"
+        },
+        {
+            "code": "VI",
+            "alpha3": "VIR",
+            "name": "U.S. Virgin Islands",
+            "region": "Americas",
+            "currency": "USD"
+        },
+        {
+            "code": "VN",
+            "alpha3": "VNM",
+            "name": "Vietnam",
+            "region": "Asia",
+            "currency": "VND",
+            "tax_rate": 0.1
+        },
+        {
+            "code": "VU",
+            "alpha3": "VUT",
+            "name": "Vanuatu",
+            "region": "Oceania",
+            "currency": "VUV",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "WF",
+            "alpha3": "WLF",
+            "name": "Wallis and Futuna",
+            "region": "Oceania",
+            "currency": "XPF"
+        },
+        {
+            "code": "WS",
+            "alpha3": "WSM",
+            "name": "Samoa",
+            "region": "Oceania",
+            "currency": "WST",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "YE",
+            "alpha3": "YEM",
+            "name": "Yemen",
+            "region": "Asia",
+            "currency": "YER"
+        },
+        {
+            "code": "YT",
+            "alpha3": "MYT",
+            "name": "Mayotte",
+            "region": "Africa",
+            "currency": "EUR"
+        },
+        {
+            "code": "ZA",
+            "alpha3": "ZAF",
+            "name": "South Africa",
+            "region": "Africa",
+            "currency": "ZAR",
+            "tax_rate": 0.15
+        },
+        {
+            "code": "ZM",
+            "alpha3": "ZMB",
+            "name": "Zambia",
+            "region": "Africa",
+            "currency": "ZMW",
+            "tax_rate": 0.16
+        },
+        {
+            "code": "ZW",
+            "alpha3": "ZWE",
+            "name": "Zimbabwe",
+            "region": "Africa",
+            "currency": "ZWG",
+            "tax_rate": 0.155
+        }
+    ]
+};
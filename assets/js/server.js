@@ -36,7 +36,7 @@ async function testProductValidation() {
         price: 99.99,
         category: "electronics",
         rating: 4.5,
-        in_stock: true
+        on_hand: 25
     };
     
     document.getElementById('productValidationResults').textContent = 'Testing product validation API...\n';
@@ -109,11 +109,11 @@ async function testRecommendations() {
             premium: true
         },
         products: [
-            { id: 1, name: "Wireless Headphones", price: 99.99, category: "electronics", in_stock: true, rating: 4.5 },
-            { id: 2, name: "Cotton T-Shirt", price: 24.99, category: "clothing", in_stock: true, rating: 4.2 },
-            { id: 3, name: "Programming Book", price: 49.99, category: "books", in_stock: true, rating: 4.8 },
-            { id: 4, name: "Coffee Mug", price: 12.99, category: "home", in_stock: true, rating: 4.0 },
-            { id: 5, name: "Running Shoes", price: 129.99, category: "sports", in_stock: true, rating: 4.6 }
+            { id: 1, name: "Wireless Headphones", price: 99.99, category: "electronics", on_hand: 25, rating: 4.5 },
+            { id: 2, name: "Cotton T-Shirt", price: 24.99, category: "clothing", on_hand: 25, rating: 4.2 },
+            { id: 3, name: "Programming Book", price: 49.99, category: "books", on_hand: 25, rating: 4.8 },
+            { id: 4, name: "Coffee Mug", price: 12.99, category: "home", on_hand: 25, rating: 4.0 },
+            { id: 5, name: "Running Shoes", price: 129.99, category: "sports", on_hand: 25, rating: 4.6 }
         ],
         order: {
             products: [
@@ -135,7 +135,7 @@ async function testRecommendations() {
         document.getElementById('recommendationsResults').textContent = 
             `✅ Recommendations API Response:\n\n` +
             `Recommended Products:\n` +
-            result.map(p => `• ${p.name} - $${p.price} (${p.category})`).join('\n');
+            result.map(p => `• ${p.name} - $${p.price} (${p.category})\n    why: ${p.reasons.map(r => r.reason).join(', ')}`).join('\n');
     } catch (error) {
         document.getElementById('recommendationsResults').textContent = `❌ Error: ${error.message}`;
     }
@@ -5,14 +5,47 @@
 // WASM INITIALIZATION AND STATUS
 // ============================================================================
 let wasmReady = false;
+let benchmarksReady = false;
 
-function initializeWasm() {
-    const go = new Go();
-    return WebAssembly.instantiateStreaming(fetch("main.wasm"), go.importObject)
-        .then((result) => {
-            go.run(result.instance);
+// Load promises of the started modules, by URL
+const wasmModules = {};
+
+// The server publishes cache-busted module URLs (main.wasm?v=<hash>) so the
+// binaries can be cached long-term. logic.wasm and bench.wasm are main.wasm
+// split in two, business logic and benchmarks; where one isn't built,
+// main.wasm stands in for it, and without the server it is the plain name.
+function wasmModuleURL(module) {
+    const meta = document.querySelector(`meta[name="wasm-${module}-url"]`) ||
+        document.querySelector('meta[name="wasm-url"]');
+    return meta ? meta.content : "main.wasm";
+}
+
+// Fetch and start a module ("logic" or "bench") once; modules that resolve
+// to the same URL share an instance. A failed load is retried next time.
+function loadWasmModule(module) {
+    const url = wasmModuleURL(module);
+    if (!wasmModules[url]) {
+        const go = new Go();
+        wasmModules[url] = WebAssembly.instantiateStreaming(fetch(url), go.importObject)
+            .then((result) => {
+                go.run(result.instance);
+                console.log(`✅ WebAssembly module ${url} loaded and ready!`);
+                return true;
+            })
+            .catch((err) => {
+                delete wasmModules[url];
+                throw err;
+            });
+    }
+    return wasmModules[url];
+}
+
+// Load the module a page starts with: the business logic, or the
+// benchmarks on the benchmark suite page.
+function initializeWasm(module = "logic") {
+    return (module === "bench" ? loadBenchmarks() : loadWasmModule(module))
+        .then(() => {
             wasmReady = true;
-            console.log("✅ WebAssembly module loaded and ready!");
             return true;
         })
         .catch((err) => {
@@ -21,6 +54,32 @@ function initializeWasm() {
         });
 }
 
+// Load the benchmark module, the first time the benchmarks are opened or
+// run, and have it register the benchmark functions, which the modules
+// defer until asked.
+function loadBenchmarks() {
+    return loadWasmModule("bench").then(() => {
+        const result = window.initBenchmarksWasm();
+        if (result.error) {
+            throw new Error(result.error);
+        }
+        benchmarksReady = true;
+        return true;
+    });
+}
+
+// Show that the benchmark module is loading in the results element, then
+// call run once it has.
+function whenBenchmarksLoaded(resultsId, run) {
+    const results = document.getElementById(resultsId);
+    results.className = 'results info';
+    results.textContent = 'Loading the benchmark module...';
+    loadBenchmarks().then(run).catch((err) => {
+        results.className = 'results error';
+        results.textContent = `Failed to load the benchmark module: ${err.message}`;
+    });
+}
+
 // ============================================================================
 // SHARED JAVASCRIPT IMPLEMENTATIONS (FOR FAIR COMPARISON)
 // ============================================================================
@@ -113,7 +172,23 @@ function hashJSShared(data, iterations) {
     return hash | 0; // Convert to signed 32-bit
 }
 
-// Fixed and optimized Ray Tracing implementation matching WASM complexity
+// Ray tracing samples are summed in blocks of this many, as the Go kernel
+// sums them (benchkernels.SampleBlock)
+const RAY_SAMPLE_BLOCK = 16;
+
+// The lowbias32 integer hash the Go kernel draws its sample jitter from
+function rayJitterHash(x) {
+    x ^= x >>> 16;
+    x = Math.imul(x, 0x7feb352d);
+    x ^= x >>> 15;
+    x = Math.imul(x, 0x846ca68b);
+    x ^= x >>> 16;
+    return x >>> 0;
+}
+
+// Fixed and optimized Ray Tracing implementation matching WASM complexity.
+// Samples are stratified and jittered within the pixel exactly as in
+// internal/benchkernels/raytrace.go, so the images are the same.
 function rayTracingJSShared(width, height, samples) {
     const result = new Float64Array(width * height * 3);
 
@@ -124,72 +199,86 @@ function rayTracingJSShared(width, height, samples) {
     // Light direction (same as WASM implementation)
     const lightX = -0.57735027, lightY = -0.57735027, lightZ = -0.57735027;
 
+    // Sample strata: a grid of about samples cells per pixel
+    const columns = Math.ceil(Math.sqrt(samples));
+    const rows = Math.ceil(samples / columns);
+
     for (let y = 0; y < height; y++) {
-        const ny = (y / height) * 2.0 - 1.0;
-        
         for (let x = 0; x < width; x++) {
-            const nx = (x / width) * 2.0 - 1.0;
-            
+            const pixelState = rayJitterHash((y * width + x) >>> 0);
             let colorR = 0, colorG = 0, colorB = 0;
 
-            // Sample accumulation (same complexity as WASM)
-            for (let s = 0; s < samples; s++) {
-                // Ray direction normalization
-                const rayLen = Math.sqrt(nx * nx + ny * ny + 1.0);
-                const invRayLen = 1.0 / rayLen;
-                const dirX = nx * invRayLen;
-                const dirY = ny * invRayLen;
-                const dirZ = -1.0 * invRayLen;
-
-                // Ray-sphere intersection (same algorithm as WASM)
-                const ocX = 0.0 - sphereX;
-                const ocY = 0.0 - sphereY;
-                const ocZ = 0.0 - sphereZ;
-                
-                const rayA = dirX * dirX + dirY * dirY + dirZ * dirZ;
-                const rayB = 2.0 * (ocX * dirX + ocY * dirY + ocZ * dirZ);
-                const rayC = ocX * ocX + ocY * ocY + ocZ * ocZ - sphereRadius2;
-                
-                const discriminant = rayB * rayB - 4.0 * rayA * rayC;
-                
-                if (discriminant < 0) {
-                    // Background color
-                    colorR += 0.2;
-                    colorG += 0.2;
-                    colorB += 0.8;
-                } else {
-                    // Hit the sphere
-                    const sqrtDisc = Math.sqrt(discriminant);
-                    let t = (-rayB - sqrtDisc) / (2.0 * rayA);
-                    if (t < 0) {
-                        t = (-rayB + sqrtDisc) / (2.0 * rayA);
+            for (let block = 0; block < samples; block += RAY_SAMPLE_BLOCK) {
+                let blockR = 0, blockG = 0, blockB = 0;
+                const blockEnd = Math.min(block + RAY_SAMPLE_BLOCK, samples);
+
+                for (let s = block; s < blockEnd; s++) {
+                    // Jittered point in the sample's cell; one sample is the center
+                    let offsetX = 0.5, offsetY = 0.5;
+                    if (samples > 1) {
+                        const state = (pixelState + s * 2) >>> 0;
+                        offsetX = ((s % columns) + rayJitterHash((state + 1) >>> 0) / 4294967296) / columns;
+                        offsetY = (Math.floor(s / columns) + rayJitterHash((state + 2) >>> 0) / 4294967296) / rows;
+                    }
+                    const nx = ((x + offsetX) / width) * 2.0 - 1.0;
+                    const ny = ((y + offsetY) / height) * 2.0 - 1.0;
+
+                    // Ray direction normalization
+                    const rayLen = Math.sqrt(nx * nx + ny * ny + 1.0);
+                    const invRayLen = 1.0 / rayLen;
+                    const dirX = nx * invRayLen;
+                    const dirY = ny * invRayLen;
+                    const dirZ = -1.0 * invRayLen;
+
+                    // Ray-sphere intersection (same algorithm as WASM)
+                    const ocX = 0.0 - sphereX;
+                    const ocY = 0.0 - sphereY;
+                    const ocZ = 0.0 - sphereZ;
+
+                    const rayA = dirX * dirX + dirY * dirY + dirZ * dirZ;
+                    const rayB = 2.0 * (ocX * dirX + ocY * dirY + ocZ * dirZ);
+                    const rayC = ocX * ocX + ocY * ocY + ocZ * ocZ - sphereRadius2;
+
+                    const discriminant = rayB * rayB - 4.0 * rayA * rayC;
+
+                    let t = -1;
+                    if (discriminant >= 0) {
+                        const sqrtDisc = Math.sqrt(discriminant);
+                        t = (-rayB - sqrtDisc) / (2.0 * rayA);
+                        if (t < 0) {
+                            t = (-rayB + sqrtDisc) / (2.0 * rayA);
+                        }
                     }
-                    
+
                     if (t < 0) {
-                        // Behind camera
-                        colorR += 0.2;
-                        colorG += 0.2;
-                        colorB += 0.8;
+                        // Background color, or the sphere is behind the camera
+                        blockR += 0.2;
+                        blockG += 0.2;
+                        blockB += 0.8;
                     } else {
                         // Calculate intersection point and normal
                         const ix = 0.0 + t * dirX;
                         const iy = 0.0 + t * dirY;
                         const iz = 0.0 + t * dirZ;
-                        
+
                         const normalX = ix - sphereX;
                         const normalY = iy - sphereY;
                         const normalZ = iz - sphereZ;
-                        
+
                         // Lighting calculation (same as WASM)
                         const dot = normalX * lightX + normalY * lightY + normalZ * lightZ;
-                        const intensity = Math.max(0.0, dot);
-                        
+                        const intensity = dot > 0.0 ? dot : 0.0;
+
                         const baseColor = 0.2 + 0.8 * intensity;
-                        colorR += baseColor * 1.0;
-                        colorG += baseColor * 0.7;
-                        colorB += baseColor * 0.3;
+                        blockR += baseColor * 1.0;
+                        blockG += baseColor * 0.7;
+                        blockB += baseColor * 0.3;
                     }
                 }
+
+                colorR += blockR;
+                colorG += blockG;
+                colorB += blockB;
             }
 
             const invSamples = 1.0 / samples;
@@ -255,18 +344,92 @@ function displayThreeWayComparison(elementId, jsTime, singleTime, concurrentTime
     `;
 }
 
+// ============================================================================
+// BENCHMARK HISTORY
+// ============================================================================
+
+// Ask the server for a challenge before running a benchmark, so the results
+// can be recorded as verified. Resolves to null when the server is missing
+// or doesn't issue challenges for the benchmark.
+function requestBenchmarkChallenge(benchmark, params) {
+    return fetch('/api/benchmark/challenges', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ benchmark, params }),
+    })
+        .then(response => (response.ok ? response.json() : null))
+        .catch(() => null);
+}
+
+// Submit timed runs to /api/benchmark/results so they can be compared with
+// server-side runs and other browsers. The runs are on the page's own
+// inputs, so they are submitted unverified; with a challenge (and WASM
+// loaded) one more run on the challenge's inputs is timed and submitted with
+// the proof of its output, as verified. Failures are ignored: the pages also
+// work when opened without the Go server.
+function recordBenchmarkResults(benchmark, params, results, challenge) {
+    let goVersion;
+    if (benchmarksReady && typeof window.debugConcurrency === 'function') {
+        goVersion = window.debugConcurrency().GoVersion;
+    }
+
+    const submissions = [];
+    results.forEach(result => {
+        result.times.forEach(time => {
+            submissions.push({
+                benchmark,
+                environment: result.name,
+                params,
+                duration_ms: time,
+                cpu_count: navigator.hardwareConcurrency,
+                go_version: result.name === 'JavaScript' ? undefined : goVersion,
+            });
+        });
+    });
+
+    if (challenge && benchmarksReady && typeof window.runChallengeBenchmarkWasm === 'function') {
+        const run = window.runChallengeBenchmarkWasm(challenge.benchmark, JSON.stringify(challenge.params), challenge.seed, challenge.id);
+        if (!run.error) {
+            submissions.push({
+                benchmark,
+                environment: 'Single-Thread WASM',
+                params: challenge.params,
+                duration_ms: run.duration_ms,
+                cpu_count: navigator.hardwareConcurrency,
+                go_version: goVersion,
+                challenge: challenge.token,
+                proof: run.proof,
+            });
+        }
+    }
+    if (submissions.length === 0) {
+        return Promise.resolve();
+    }
+
+    return fetch('/api/benchmark/results', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(submissions),
+    }).catch(() => {});
+}
+
 // ============================================================================
 // EXPORTED UTILITY FUNCTIONS
 // ============================================================================
 
 // Check if WebAssembly is ready
 window.isWasmReady = () => wasmReady;
+window.isBenchmarkModuleReady = () => benchmarksReady;
+window.loadBenchmarks = loadBenchmarks;
+window.whenBenchmarksLoaded = whenBenchmarksLoaded;
 
 // Initialize WebAssembly (call this from your main scripts)
 window.initWasm = initializeWasm;
 
 // Export shared display functions
 window.displayThreeWayComparison = displayThreeWayComparison;
+window.recordBenchmarkResults = recordBenchmarkResults;
+window.requestBenchmarkChallenge = requestBenchmarkChallenge;
 
 // Export individual JS implementations for compatibility
 window.matrixMultiplyJSOptimized = matrixMultiplyJSShared;
@@ -17,6 +17,142 @@ window.isWasmReady = function() {
     return window.wasmReady;
 };
 
+// ============================================================================
+// CLIENT-SIDE EXPORT
+// ============================================================================
+
+// Export a dataset (users, products, orders or analytics) as a CSV download
+// using the WebAssembly exportCsvWasm function, which shares its formatting
+// with the server's /api/export endpoints. Resolves with the row count.
+async function downloadCsvWasm(dataset, data) {
+    const result = await window.wasmApi.exportCsv(dataset, data);
+
+    const url = URL.createObjectURL(new Blob([result.csv], { type: 'text/csv;charset=utf-8' }));
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = result.filename;
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    URL.revokeObjectURL(url);
+    return result.rows;
+}
+
+window.downloadCsvWasm = downloadCsvWasm;
+
+// ============================================================================
+// DEMO DATA CHANGES
+// ============================================================================
+
+// Call onChange(entity, change) whenever users, products or orders change on
+// the server (in this or another tab), via /api/data/stream. A "resync"
+// change means events were missed and everything should be refetched.
+// Returns the EventSource so the caller can close it.
+function subscribeToDataChanges(entities, onChange) {
+    const query = entities && entities.length ? `?entities=${entities.join(',')}` : '';
+    const source = new EventSource(`/api/data/stream${query}`);
+    ['users', 'products', 'orders', 'resync'].forEach(entity => {
+        source.addEventListener(entity, event => onChange(entity, JSON.parse(event.data)));
+    });
+    return source;
+}
+
+window.subscribeToDataChanges = subscribeToDataChanges;
+
+// ============================================================================
+// EXCHANGE RATES
+// ============================================================================
+
+// Load the server's exchange rates from /api/rates into the WebAssembly
+// module, so convertCurrencyWasm converts with the same table as the server
+async function loadExchangeRates() {
+    const response = await fetch('/api/rates');
+    if (!response.ok) {
+        throw new Error(`Failed to load exchange rates: ${response.status}`);
+    }
+    return window.wasmApi.setExchangeRates(await response.text());
+}
+
+window.loadExchangeRates = loadExchangeRates;
+
+// ============================================================================
+// VALIDATION RULES
+// ============================================================================
+
+// Load the server's validation ruleset from /api/validation-rules into the
+// WebAssembly module, so validateUserWasm and validateProductWasm apply the
+// same rules as the server
+async function loadValidationRules() {
+    const response = await fetch('/api/validation-rules');
+    if (!response.ok) {
+        throw new Error(`Failed to load validation rules: ${response.status}`);
+    }
+    return window.wasmApi.setValidationRules(await response.text());
+}
+
+window.loadValidationRules = loadValidationRules;
+
+// Load the promotions running on the server from /api/promotions into the
+// WebAssembly module, so calculateOrderTotalWasm previews the same campaigns
+async function loadPromotions() {
+    const response = await fetch('/api/promotions');
+    if (!response.ok) {
+        throw new Error(`Failed to load promotions: ${response.status}`);
+    }
+    return window.wasmApi.setPromotions(await response.text());
+}
+
+window.loadPromotions = loadPromotions;
+
+// Load the server's item-item similarity matrix from
+// /api/recommendations/similarity into the WebAssembly module, so
+// recommendProductsWasm blends in the same order history
+async function loadItemSimilarity() {
+    const response = await fetch('/api/recommendations/similarity');
+    if (!response.ok) {
+        throw new Error(`Failed to load item similarity: ${response.status}`);
+    }
+    return window.wasmApi.setItemSimilarity(await response.text());
+}
+
+window.loadItemSimilarity = loadItemSimilarity;
+
+// Load the server's trending products from /api/analytics/trending into the
+// WebAssembly module, so recommendProductsWasm boosts the same products
+async function loadTrending() {
+    const response = await fetch('/api/analytics/trending');
+    if (!response.ok) {
+        throw new Error(`Failed to load trending products: ${response.status}`);
+    }
+    return window.wasmApi.setTrending(await response.text());
+}
+
+window.loadTrending = loadTrending;
+
+// Load the server's cohort preferences from /api/recommendations/cohorts into
+// the WebAssembly module, so recommendProductsWasm has the same cold start
+async function loadCohortPreferences() {
+    const response = await fetch('/api/recommendations/cohorts');
+    if (!response.ok) {
+        throw new Error(`Failed to load cohort preferences: ${response.status}`);
+    }
+    return window.wasmApi.setCohortPreferences(await response.text());
+}
+
+window.loadCohortPreferences = loadCohortPreferences;
+
+// Load the server's recommendation scoring weights from
+// /api/recommendations/weights into the WebAssembly module
+async function loadRecommendationWeights() {
+    const response = await fetch('/api/recommendations/weights');
+    if (!response.ok) {
+        throw new Error(`Failed to load recommendation weights: ${response.status}`);
+    }
+    return window.wasmApi.setRecommendationWeights(await response.text());
+}
+
+window.loadRecommendationWeights = loadRecommendationWeights;
+
 // ============================================================================
 // COMMON UI UTILITIES
 // ============================================================================
@@ -187,7 +323,12 @@ async function makeApiRequest(url, data, method = 'POST') {
         });
         
         if (!response.ok) {
-            throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+            // Error bodies are {error: {code, message, fields}}
+            const body = await response.json().catch(() => null);
+            const message = body && body.error ? body.error.message : response.statusText;
+            const error = new Error(`HTTP ${response.status}: ${message}`);
+            error.details = body && body.error;
+            throw error;
         }
         
         return await response.json();
@@ -0,0 +1,810 @@
+// Code generated by go generate from src/shared_wasm_api.go; DO NOT EDIT.
+
+// ============================================================================
+// WASM API
+// Typed wrappers of the business logic functions the WebAssembly module
+// registers: window.wasmApi.validateUser(user) calls validateUserWasm once the
+// module is loaded, resolving with its result or rejecting with its error
+// ============================================================================
+
+(function() {
+    'use strict';
+
+    const kindNames = {
+        json: 'an object or a JSON string',
+        string: 'a string',
+        number: 'a finite number',
+        integer: 'a whole number',
+    };
+
+    // Convert an argument to what the Go function takes for its kind, or
+    // throw a TypeError naming the argument
+    function convert(fn, name, kind, value) {
+        switch (kind) {
+        case 'json':
+            if (typeof value === 'string') {
+                return value;
+            }
+            if (value !== null && typeof value === 'object') {
+                return JSON.stringify(value);
+            }
+            break;
+        case 'string':
+            if (typeof value === 'string') {
+                return value;
+            }
+            break;
+        case 'number':
+            if (Number.isFinite(value)) {
+                return value;
+            }
+            break;
+        case 'integer':
+            if (Number.isInteger(value)) {
+                return value;
+            }
+            break;
+        }
+        throw new TypeError(`wasmApi.${fn}: ${name} must be ${kindNames[kind]}`);
+    }
+
+    // Check values against params ([name, kind, optional]), load the module
+    // and call the Go function; optional arguments left undefined at the end
+    // are not passed
+    async function call(fn, goName, params, values) {
+        const args = [];
+        let missing = null;
+        params.forEach(([name, kind, optional], i) => {
+            if (values[i] === undefined) {
+                if (!optional) {
+                    throw new TypeError(`wasmApi.${fn}: ${name} is required`);
+                }
+                missing = missing || name;
+                return;
+            }
+            if (missing) {
+                throw new TypeError(`wasmApi.${fn}: ${name} needs ${missing} too`);
+            }
+            args.push(convert(fn, name, kind, values[i]));
+        });
+
+        await window.initializeWasm();
+        const goFunc = window[goName];
+        if (typeof goFunc !== 'function') {
+            throw new Error(`wasmApi.${fn}: this module does not register ${goName}`);
+        }
+        const result = goFunc(...args);
+        if (result && typeof result.error === 'string' && result.error !== '') {
+            throw new Error(result.error);
+        }
+        return result;
+    }
+
+    window.wasmApi = {
+        /**
+         * Validates a user with the server's rules.
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        validateUser(user) {
+            return call('validateUser', 'validateUserWasm', [['user', 'json']], [user]);
+        },
+
+        /**
+         * Validates a product with the server's rules.
+         * @param {Object|string} product
+         * @returns {Promise<Object>}
+         */
+        validateProduct(product) {
+            return call('validateProduct', 'validateProductWasm', [['product', 'json']], [product]);
+        },
+
+        /**
+         * Checks a phone number for a country and returns it in E.164 form.
+         * @param {string} phone
+         * @param {string} country
+         * @returns {Promise<Object>}
+         */
+        validatePhone(phone, country) {
+            return call('validatePhone', 'validatePhoneWasm', [['phone', 'string'], ['country', 'string']], [phone, country]);
+        },
+
+        /**
+         * Checks a postal code for a country and returns it in canonical form.
+         * @param {string} code
+         * @param {string} country
+         * @returns {Promise<Object>}
+         */
+        validatePostalCode(code, country) {
+            return call('validatePostalCode', 'validatePostalCodeWasm', [['code', 'string'], ['country', 'string']], [code, country]);
+        },
+
+        /**
+         * Rates a password with the rules the server applies.
+         * @param {string} password
+         * @returns {Promise<Object>}
+         */
+        validatePassword(password) {
+            return call('validatePassword', 'validatePasswordWasm', [['password', 'string']], [password]);
+        },
+
+        /**
+         * Checks preferences as the server does and returns the user JSON with them set.
+         * @param {Object|string} user
+         * @param {Object|string} preferences
+         * @returns {Promise<Object>}
+         */
+        setUserPreferences(user, preferences) {
+            return call('setUserPreferences', 'setUserPreferencesWasm', [['user', 'json'], ['preferences', 'json']], [user, preferences]);
+        },
+
+        /**
+         * Finds a country by alpha-2 or alpha-3 code.
+         * @param {string} code
+         * @returns {Promise<Object>}
+         */
+        lookupCountry(code) {
+            return call('lookupCountry', 'lookupCountryWasm', [['code', 'string']], [code]);
+        },
+
+        /**
+         * Prices an order for a user, optionally paying with a gift card.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @param {Object|string} [giftCard]
+         * @returns {Promise<Object>}
+         */
+        calculateOrderTotal(order, user, giftCard) {
+            return call('calculateOrderTotal', 'calculateOrderTotalWasm', [['order', 'json'], ['user', 'json'], ['giftCard', 'json', true]], [order, user, giftCard]);
+        },
+
+        /**
+         * Recommends products for a user and order; pass an empty wishlist string to give only options.
+         * @param {Object|string} user
+         * @param {Object|string} products
+         * @param {Object|string} order
+         * @param {Object|string} [wishlist]
+         * @param {Object|string} [options]
+         * @returns {Promise<Object>}
+         */
+        recommendProducts(user, products, order, wishlist, options) {
+            return call('recommendProducts', 'recommendProductsWasm', [['user', 'json'], ['products', 'json'], ['order', 'json'], ['wishlist', 'json', true], ['options', 'json', true]], [user, products, order, wishlist, options]);
+        },
+
+        /**
+         * Recommends accessories for an order's products.
+         * @param {Object|string} order
+         * @param {Object|string} products
+         * @returns {Promise<Object>}
+         */
+        recommendCrossSells(order, products) {
+            return call('recommendCrossSells', 'recommendCrossSellsWasm', [['order', 'json'], ['products', 'json']], [order, products]);
+        },
+
+        /**
+         * Recommends higher-tier alternatives to a product.
+         * @param {Object|string} product
+         * @param {Object|string} products
+         * @returns {Promise<Object>}
+         */
+        recommendUpsells(product, products) {
+            return call('recommendUpsells', 'recommendUpsellsWasm', [['product', 'json'], ['products', 'json']], [product, products]);
+        },
+
+        /**
+         * Finds the count products most like one by name and description.
+         * @param {Object|string} products
+         * @param {number} productId
+         * @param {number} count
+         * @returns {Promise<Object>}
+         */
+        similarProducts(products, productId, count) {
+            return call('similarProducts', 'similarProductsWasm', [['products', 'json'], ['productId', 'integer'], ['count', 'integer']], [products, productId, count]);
+        },
+
+        /**
+         * Picks the picture of a product to show in a tile of size pixels square, or null.
+         * @param {Object|string} product
+         * @param {number} size
+         * @returns {Promise<Object>}
+         */
+        selectThumbnail(product, size) {
+            return call('selectThumbnail', 'selectThumbnailWasm', [['product', 'json'], ['size', 'integer']], [product, size]);
+        },
+
+        /**
+         * Searches products typo-tolerantly, with options picking the sort and limit.
+         * @param {Object|string} products
+         * @param {string} query
+         * @param {Object|string} [options]
+         * @returns {Promise<Object>}
+         */
+        searchProducts(products, query, options) {
+            return call('searchProducts', 'searchProductsWasm', [['products', 'json'], ['query', 'string'], ['options', 'json', true]], [products, query, options]);
+        },
+
+        /**
+         * Selects the products a filter matches, with the facets to narrow them.
+         * @param {Object|string} products
+         * @param {Object|string} filter
+         * @returns {Promise<Object>}
+         */
+        filterProducts(products, filter) {
+            return call('filterProducts', 'filterProductsWasm', [['products', 'json'], ['filter', 'json']], [products, filter]);
+        },
+
+        /**
+         * Mines frequently bought together rules, optionally with a minimum support and confidence.
+         * @param {Object|string} orders
+         * @param {number} [minSupport]
+         * @param {number} [minConfidence]
+         * @returns {Promise<Object>}
+         */
+        mineAssociations(orders, minSupport, minConfidence) {
+            return call('mineAssociations', 'mineAssociationsWasm', [['orders', 'json'], ['minSupport', 'number', true], ['minConfidence', 'number', true]], [orders, minSupport, minConfidence]);
+        },
+
+        /**
+         * Ranks products by recency-weighted sales velocity over an optional window in days.
+         * @param {Object|string} orders
+         * @param {number} [days]
+         * @returns {Promise<Object>}
+         */
+        trendingProducts(orders, days) {
+            return call('trendingProducts', 'trendingProductsWasm', [['orders', 'json'], ['days', 'integer', true]], [orders, days]);
+        },
+
+        /**
+         * Segments customers by recency, frequency and monetary value.
+         * @param {Object|string} users
+         * @param {Object|string} orders
+         * @returns {Promise<Object>}
+         */
+        segmentUsersRFM(users, orders) {
+            return call('segmentUsersRFM', 'segmentUsersRFMWasm', [['users', 'json'], ['orders', 'json']], [users, orders]);
+        },
+
+        /**
+         * Scores each customer's churn probability, optionally only those above a minimum.
+         * @param {Object|string} users
+         * @param {Object|string} orders
+         * @param {number} [minProbability]
+         * @returns {Promise<Object>}
+         */
+        scoreChurnRisk(users, orders, minProbability) {
+            return call('scoreChurnRisk', 'scoreChurnRiskWasm', [['users', 'json'], ['orders', 'json'], ['minProbability', 'number', true]], [users, orders, minProbability]);
+        },
+
+        /**
+         * Projects a user's lifetime value over an optional horizon in months.
+         * @param {Object|string} user
+         * @param {Object|string} orders
+         * @param {number} [months]
+         * @returns {Promise<Object>}
+         */
+        calculateCLV(user, orders, months) {
+            return call('calculateCLV', 'calculateCLVWasm', [['user', 'json'], ['orders', 'json'], ['months', 'integer', true]], [user, orders, months]);
+        },
+
+        /**
+         * Aggregates revenue by day, week or month, optionally only from one date to another.
+         * @param {Object|string} orders
+         * @param {string} granularity
+         * @param {string} [from]
+         * @param {string} [to]
+         * @returns {Promise<Object>}
+         */
+        aggregateRevenue(orders, granularity, from, to) {
+            return call('aggregateRevenue', 'aggregateRevenueWasm', [['orders', 'json'], ['granularity', 'string'], ['from', 'string', true], ['to', 'string', true]], [orders, granularity, from, to]);
+        },
+
+        /**
+         * Forecasts the revenue series, with optional settings for horizon, seasons and smoothing.
+         * @param {Object|string} orders
+         * @param {string} granularity
+         * @param {Object|string} [settings]
+         * @returns {Promise<Object>}
+         */
+        forecastRevenue(orders, granularity, settings) {
+            return call('forecastRevenue', 'forecastRevenueWasm', [['orders', 'json'], ['granularity', 'string'], ['settings', 'json', true]], [orders, granularity, settings]);
+        },
+
+        /**
+         * Follows an event log from viewed to purchased.
+         * @param {Object|string} events
+         * @returns {Promise<Object>}
+         */
+        computeFunnel(events) {
+            return call('computeFunnel', 'computeFunnelWasm', [['events', 'json']], [events]);
+        },
+
+        /**
+         * Splits an event log into sessions at an optional idle gap in minutes.
+         * @param {Object|string} events
+         * @param {number} [gapMinutes]
+         * @returns {Promise<Object>}
+         */
+        sessionizeEvents(events, gapMinutes) {
+            return call('sessionizeEvents', 'sessionizeEventsWasm', [['events', 'json'], ['gapMinutes', 'integer', true]], [events, gapMinutes]);
+        },
+
+        /**
+         * Buffers a behavior event and reports whether a batch is due.
+         * @param {Object|string} event
+         * @returns {Promise<Object>}
+         */
+        trackEvent(event) {
+            return call('trackEvent', 'trackEventWasm', [['event', 'json']], [event]);
+        },
+
+        /**
+         * Takes the oldest buffered events as the body of a POST /api/events.
+         * @returns {Promise<Object>}
+         */
+        flushEvents() {
+            return call('flushEvents', 'flushEventsWasm', [], []);
+        },
+
+        /**
+         * Ranks the first count best sellers by revenue, units or margin.
+         * @param {Object|string} orders
+         * @param {number} count
+         * @param {string} metric
+         * @returns {Promise<Object>}
+         */
+        topProducts(orders, count, metric) {
+            return call('topProducts', 'topProductsWasm', [['orders', 'json'], ['count', 'integer'], ['metric', 'string']], [orders, count, metric]);
+        },
+
+        /**
+         * Buckets values by the lower edges of a bucket spec.
+         * @param {Object|string} values
+         * @param {Object|string} spec
+         * @returns {Promise<Object>}
+         */
+        buildHistogram(values, spec) {
+            return call('buildHistogram', 'buildHistogramWasm', [['values', 'json'], ['spec', 'json']], [values, spec]);
+        },
+
+        /**
+         * Assigns a user to an experiment's variant, as the server does.
+         * @param {Object|string} experiment
+         * @param {number} userId
+         * @returns {Promise<Object>}
+         */
+        assignVariant(experiment, userId) {
+            return call('assignVariant', 'assignVariantWasm', [['experiment', 'json'], ['userId', 'integer']], [experiment, userId]);
+        },
+
+        /**
+         * Measures the conversion lift of an experiment's variants.
+         * @param {Object|string} experiment
+         * @param {Object|string} users
+         * @param {Object|string} orders
+         * @returns {Promise<Object>}
+         */
+        analyzeExperiment(experiment, users, orders) {
+            return call('analyzeExperiment', 'analyzeExperimentWasm', [['experiment', 'json'], ['users', 'json'], ['orders', 'json']], [experiment, users, orders]);
+        },
+
+        /**
+         * Analyzes users and orders, optionally through a filter.
+         * @param {Object|string} users
+         * @param {Object|string} orders
+         * @param {Object|string} [filter]
+         * @returns {Promise<Object>}
+         */
+        analyzeUserBehavior(users, orders, filter) {
+            return call('analyzeUserBehavior', 'analyzeUserBehaviorWasm', [['users', 'json'], ['orders', 'json'], ['filter', 'json', true]], [users, orders, filter]);
+        },
+
+        /**
+         * Starts the page's incremental analytics over.
+         * @returns {Promise<Object>}
+         */
+        resetAnalytics() {
+            return call('resetAnalytics', 'resetAnalyticsWasm', [], []);
+        },
+
+        /**
+         * Adds a user to the page's analytics; add users before their orders.
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        addAnalyticsUser(user) {
+            return call('addAnalyticsUser', 'addAnalyticsUserWasm', [['user', 'json']], [user]);
+        },
+
+        /**
+         * Adds an order to the page's analytics.
+         * @param {Object|string} order
+         * @returns {Promise<Object>}
+         */
+        addAnalyticsOrder(order) {
+            return call('addAnalyticsOrder', 'addAnalyticsOrderWasm', [['order', 'json']], [order]);
+        },
+
+        /**
+         * Reports the analytics of what was added so far, optionally ranking topCountries countries.
+         * @param {number} [topCountries]
+         * @returns {Promise<Object>}
+         */
+        analyticsSnapshot(topCountries) {
+            return call('analyticsSnapshot', 'analyticsSnapshotWasm', [['topCountries', 'integer', true]], [topCountries]);
+        },
+
+        /**
+         * Scores how complete a user's profile is.
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        profileCompleteness(user) {
+            return call('profileCompleteness', 'profileCompletenessWasm', [['user', 'json']], [user]);
+        },
+
+        /**
+         * Formats a dataset as CSV like the server's /api/export endpoints.
+         * @param {string} dataset
+         * @param {Object|string} data
+         * @returns {Promise<Object>}
+         */
+        exportCsv(dataset, data) {
+            return call('exportCsv', 'exportCsvWasm', [['dataset', 'string'], ['data', 'json']], [dataset, data]);
+        },
+
+        /**
+         * Prices a cart like the server's /api/cart endpoints.
+         * @param {Object|string} cart
+         * @param {Object|string} products
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        cartSummary(cart, products, user) {
+            return call('cartSummary', 'cartSummaryWasm', [['cart', 'json'], ['products', 'json'], ['user', 'json']], [cart, products, user]);
+        },
+
+        /**
+         * Folds a guest cart into the user's cart.
+         * @param {Object|string} guestCart
+         * @param {Object|string} userCart
+         * @returns {Promise<Object>}
+         */
+        mergeCarts(guestCart, userCart) {
+            return call('mergeCarts', 'mergeCartsWasm', [['guestCart', 'json'], ['userCart', 'json']], [guestCart, userCart]);
+        },
+
+        /**
+         * Moves a wishlisted product into the cart, optionally a quantity of one variant SKU.
+         * @param {Object|string} wishlist
+         * @param {Object|string} cart
+         * @param {Object|string} products
+         * @param {number} productId
+         * @param {number} [quantity]
+         * @param {string} [sku]
+         * @returns {Promise<Object>}
+         */
+        moveToCart(wishlist, cart, products, productId, quantity, sku) {
+            return call('moveToCart', 'moveToCartWasm', [['wishlist', 'json'], ['cart', 'json'], ['products', 'json'], ['productId', 'integer'], ['quantity', 'integer', true], ['sku', 'string', true]], [wishlist, cart, products, productId, quantity, sku]);
+        },
+
+        /**
+         * Labels a price history was/now, as of now or an RFC 3339 time.
+         * @param {Object|string} history
+         * @param {string} [at]
+         * @returns {Promise<Object>}
+         */
+        comparePrice(history, at) {
+            return call('comparePrice', 'comparePriceWasm', [['history', 'json'], ['at', 'string', true]], [history, at]);
+        },
+
+        /**
+         * Previews a product's demand-based price, optionally with pricing overrides.
+         * @param {Object|string} product
+         * @param {Object|string} orders
+         * @param {Object|string} [pricing]
+         * @returns {Promise<Object>}
+         */
+        getDynamicPrice(product, orders, pricing) {
+            return call('getDynamicPrice', 'getDynamicPriceWasm', [['product', 'json'], ['orders', 'json'], ['pricing', 'json', true]], [product, orders, pricing]);
+        },
+
+        /**
+         * Scores an order's fraud risk, optionally against the order history.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @param {Object|string} [history]
+         * @returns {Promise<Object>}
+         */
+        scoreOrderRisk(order, user, history) {
+            return call('scoreOrderRisk', 'scoreOrderRiskWasm', [['order', 'json'], ['user', 'json'], ['history', 'json', true]], [order, user, history]);
+        },
+
+        /**
+         * Renders an order's invoice as HTML and plain text.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        generateInvoice(order, user) {
+            return call('generateInvoice', 'generateInvoiceWasm', [['order', 'json'], ['user', 'json']], [order, user]);
+        },
+
+        /**
+         * Previews an order's confirmation or receipt email as HTML and plain text, from the server's templates.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @param {string} [kind]
+         * @param {string} [locale]
+         * @returns {Promise<Object>}
+         */
+        renderReceipt(order, user, kind, locale) {
+            return call('renderReceipt', 'renderReceiptWasm', [['order', 'json'], ['user', 'json'], ['kind', 'string', true], ['locale', 'string', true]], [order, user, kind, locale]);
+        },
+
+        /**
+         * Previews the notification a user gets about an order event, as order webhooks carry it.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @param {string} event
+         * @param {string} [previousStatus]
+         * @returns {Promise<Object>}
+         */
+        previewNotification(order, user, event, previousStatus) {
+            return call('previewNotification', 'previewNotificationWasm', [['order', 'json'], ['user', 'json'], ['event', 'string'], ['previousStatus', 'string', true]], [order, user, event, previousStatus]);
+        },
+
+        /**
+         * Finds the likely duplicate users of a bulk import.
+         * @param {Object|string} users
+         * @returns {Promise<Object>}
+         */
+        findDuplicateUsers(users) {
+            return call('findDuplicateUsers', 'findDuplicateUsersWasm', [['users', 'json']], [users]);
+        },
+
+        /**
+         * Generates a seeded dataset of demo users, products and orders, the same as /api/demo-data.
+         * @param {Object|string} [options]
+         * @returns {Promise<Object>}
+         */
+        generateDemoData(options) {
+            return call('generateDemoData', 'generateDemoDataWasm', [['options', 'json', true]], [options]);
+        },
+
+        /**
+         * Applies a JSON merge patch to a user, product or order made against the record's current version, as the server's updates do.
+         * @param {string} model
+         * @param {Object|string} record
+         * @param {Object|string} patch
+         * @returns {Promise<Object>}
+         */
+        updateRecord(model, record, patch) {
+            return call('updateRecord', 'updateRecordWasm', [['model', 'string'], ['record', 'json'], ['patch', 'json']], [model, record, patch]);
+        },
+
+        /**
+         * Opens the page's cache of fetched users, products and orders in localStorage, sessionStorage or memory.
+         * @param {string} [storage]
+         * @returns {Promise<Object>}
+         */
+        openDataCache(storage) {
+            return call('openDataCache', 'openDataCacheWasm', [['storage', 'string', true]], [storage]);
+        },
+
+        /**
+         * Caches users, products or orders fetched from the server, keeping records with edits not yet synced.
+         * @param {string} model
+         * @param {Object|string} records
+         * @returns {Promise<Object>}
+         */
+        cacheStore(model, records) {
+            return call('cacheStore', 'cacheStoreWasm', [['model', 'string'], ['records', 'json']], [model, records]);
+        },
+
+        /**
+         * Returns the cached records of a model with the page's edits, for working offline.
+         * @param {string} model
+         * @returns {Promise<Object>}
+         */
+        cacheRecords(model) {
+            return call('cacheRecords', 'cacheRecordsWasm', [['model', 'string']], [model]);
+        },
+
+        /**
+         * Edits a cached user or product with a JSON merge patch naming its version, to sync later.
+         * @param {string} model
+         * @param {number} id
+         * @param {Object|string} patch
+         * @returns {Promise<Object>}
+         */
+        cacheUpdate(model, id, patch) {
+            return call('cacheUpdate', 'cacheUpdateWasm', [['model', 'string'], ['id', 'integer'], ['patch', 'json']], [model, id, patch]);
+        },
+
+        /**
+         * Returns the body of a POST /api/data/sync with the edits not yet synced.
+         * @returns {Promise<Object>}
+         */
+        cacheSyncRequest() {
+            return call('cacheSyncRequest', 'cacheSyncRequestWasm', [], []);
+        },
+
+        /**
+         * Takes in the answer to POST /api/data/sync and returns the conflicts and rejected edits.
+         * @param {Object|string} response
+         * @returns {Promise<Object>}
+         */
+        cacheApplySync(response) {
+            return call('cacheApplySync', 'cacheApplySyncWasm', [['response', 'json']], [response]);
+        },
+
+        /**
+         * Settles a record in conflict by keeping the page's edits (local) or the server's record (server).
+         * @param {string} model
+         * @param {number} id
+         * @param {string} keep
+         * @returns {Promise<Object>}
+         */
+        cacheResolve(model, id, keep) {
+            return call('cacheResolve', 'cacheResolveWasm', [['model', 'string'], ['id', 'integer'], ['keep', 'string']], [model, id, keep]);
+        },
+
+        /**
+         * Prices orders again for their users and reports the orders whose totals would change, as /api/orders/recalculate does.
+         * @param {Object|string} orders
+         * @param {Object|string} users
+         * @returns {Promise<Object>}
+         */
+        recalculateOrders(orders, users) {
+            return call('recalculateOrders', 'recalculateOrdersWasm', [['orders', 'json'], ['users', 'json']], [orders, users]);
+        },
+
+        /**
+         * Converts an amount between currencies, optionally formatted for a locale.
+         * @param {number} amount
+         * @param {string} from
+         * @param {string} to
+         * @param {string} [locale]
+         * @returns {Promise<Object>}
+         */
+        convertCurrency(amount, from, to, locale) {
+            return call('convertCurrency', 'convertCurrencyWasm', [['amount', 'number'], ['from', 'string'], ['to', 'string'], ['locale', 'string', true]], [amount, from, to, locale]);
+        },
+
+        /**
+         * Formats an amount for a locale exactly as the server does.
+         * @param {number} amount
+         * @param {string} currency
+         * @param {string} locale
+         * @returns {Promise<Object>}
+         */
+        formatMoney(amount, currency, locale) {
+            return call('formatMoney', 'formatMoneyWasm', [['amount', 'number'], ['currency', 'string'], ['locale', 'string']], [amount, currency, locale]);
+        },
+
+        /**
+         * Quotes the shipping options of an order.
+         * @param {Object|string} order
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        shippingQuotes(order, user) {
+            return call('shippingQuotes', 'shippingQuotesWasm', [['order', 'json'], ['user', 'json']], [order, user]);
+        },
+
+        /**
+         * Checks a cart against available stock.
+         * @param {Object|string} cart
+         * @param {Object|string} products
+         * @returns {Promise<Object>}
+         */
+        checkAvailability(cart, products) {
+            return call('checkAvailability', 'checkAvailabilityWasm', [['cart', 'json'], ['products', 'json']], [cart, products]);
+        },
+
+        /**
+         * Splits an order into shipments by warehouse and stock.
+         * @param {Object|string} order
+         * @param {Object|string} products
+         * @param {Object|string} user
+         * @returns {Promise<Object>}
+         */
+        splitOrder(order, products, user) {
+            return call('splitOrder', 'splitOrderWasm', [['order', 'json'], ['products', 'json'], ['user', 'json']], [order, products, user]);
+        },
+
+        /**
+         * Previews a subscription's next count renewals.
+         * @param {Object|string} subscription
+         * @param {Object|string} user
+         * @param {number} count
+         * @returns {Promise<Object>}
+         */
+        upcomingCharges(subscription, user, count) {
+            return call('upcomingCharges', 'upcomingChargesWasm', [['subscription', 'json'], ['user', 'json'], ['count', 'integer']], [subscription, user, count]);
+        },
+
+        /**
+         * Prorates a subscription change on a YYYY-MM-DD date.
+         * @param {Object|string} subscription
+         * @param {Object|string} change
+         * @param {string} date
+         * @returns {Promise<Object>}
+         */
+        prorateSubscription(subscription, change, date) {
+            return call('prorateSubscription', 'prorateSubscriptionWasm', [['subscription', 'json'], ['change', 'json'], ['date', 'string']], [subscription, change, date]);
+        },
+
+        /**
+         * Installs a rate table, normally the JSON served by /api/rates.
+         * @param {Object|string} rates
+         * @returns {Promise<Object>}
+         */
+        setExchangeRates(rates) {
+            return call('setExchangeRates', 'setExchangeRatesWasm', [['rates', 'json']], [rates]);
+        },
+
+        /**
+         * Installs a validation ruleset, normally the JSON served by /api/validation-rules.
+         * @param {Object|string} rules
+         * @returns {Promise<Object>}
+         */
+        setValidationRules(rules) {
+            return call('setValidationRules', 'setValidationRulesWasm', [['rules', 'json']], [rules]);
+        },
+
+        /**
+         * Installs the promotions, normally the JSON served by /api/promotions.
+         * @param {Object|string} promotions
+         * @returns {Promise<Object>}
+         */
+        setPromotions(promotions) {
+            return call('setPromotions', 'setPromotionsWasm', [['promotions', 'json']], [promotions]);
+        },
+
+        /**
+         * Installs the similarity matrix, normally the JSON served by /api/recommendations/similarity.
+         * @param {Object|string} similarity
+         * @returns {Promise<Object>}
+         */
+        setItemSimilarity(similarity) {
+            return call('setItemSimilarity', 'setItemSimilarityWasm', [['similarity', 'json']], [similarity]);
+        },
+
+        /**
+         * Installs the trending list, normally the JSON served by /api/analytics/trending.
+         * @param {Object|string} trending
+         * @returns {Promise<Object>}
+         */
+        setTrending(trending) {
+            return call('setTrending', 'setTrendingWasm', [['trending', 'json']], [trending]);
+        },
+
+        /**
+         * Installs the cohort preferences, normally the JSON served by /api/recommendations/cohorts.
+         * @param {Object|string} preferences
+         * @returns {Promise<Object>}
+         */
+        setCohortPreferences(preferences) {
+            return call('setCohortPreferences', 'setCohortPreferencesWasm', [['preferences', 'json']], [preferences]);
+        },
+
+        /**
+         * Installs the scoring weights, normally the JSON served by /api/recommendations/weights.
+         * @param {Object|string} weights
+         * @returns {Promise<Object>}
+         */
+        setRecommendationWeights(weights) {
+            return call('setRecommendationWeights', 'setRecommendationWeightsWasm', [['weights', 'json']], [weights]);
+        },
+
+        /**
+         * Installs the margin and discount rate CLV projects with.
+         * @param {Object|string} settings
+         * @returns {Promise<Object>}
+         */
+        setCLVSettings(settings) {
+            return call('setCLVSettings', 'setCLVSettingsWasm', [['settings', 'json']], [settings]);
+        },
+    };
+})();
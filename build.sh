@@ -34,8 +34,16 @@ fi
 $ECHO_CMD "🚀 Building WebAssembly in Go: Bridging Web and Backend"
 $ECHO_CMD "======================================================="
 
+# --lite builds the modules without regexp, the invoice templates and the
+# benchmark variants the pages don't call
+PROFILE_TAGS=""
+if [[ "$1" == "--lite" ]]; then
+    PROFILE_TAGS="lite"
+    $ECHO_CMD "${YELLOW}🪶 Lite profile: no regexp, invoice templates or benchmark variants${NC}"
+fi
+
 $ECHO_CMD "${BLUE}📦 Building optimized WebAssembly module...${NC}"
-GOOS=js GOARCH=wasm go build -ldflags="-s -w" -o main.wasm src/main_wasm.go src/shared_models.go src/benchmarks_wasm.go src/benchmarks_types.go src/benchmarks_comprehensive.go src/benchmarks_optimized.go src/benchmarks_shared.go src/benchmarks_unified.go src/mandelbrot.go src/mandelbrot_concurrent.go
+GOOS=js GOARCH=wasm go build -tags "$PROFILE_TAGS" -ldflags="-s -w" -o main.wasm ./src
 
 if [ $? -eq 0 ]; then
     $ECHO_CMD "${GREEN}✅ WebAssembly module built successfully: main.wasm${NC}"
@@ -44,8 +52,49 @@ else
     exit 1
 fi
 
+# The same module split in two, so pages fetch the benchmarks only when
+# they run them
+$ECHO_CMD "${BLUE}📦 Building split modules (logic.wasm, bench.wasm)...${NC}"
+if GOOS=js GOARCH=wasm go build -tags "logic $PROFILE_TAGS" -ldflags="-s -w" -o logic.wasm ./src &&
+    GOOS=js GOARCH=wasm go build -tags "bench $PROFILE_TAGS" -ldflags="-s -w" -o bench.wasm ./src; then
+    $ECHO_CMD "${GREEN}✅ Split modules built successfully: logic.wasm, bench.wasm${NC}"
+else
+    $ECHO_CMD "${RED}❌ Failed to build split modules${NC}"
+    exit 1
+fi
+
+# Precompressed variants served to clients that send Accept-Encoding
+$ECHO_CMD "${BLUE}🗜️  Precompressing the modules...${NC}"
+for module in main.wasm logic.wasm bench.wasm; do
+    gzip -9 -k -f "$module"
+    if command -v brotli >/dev/null 2>&1; then
+        brotli -q 11 -k -f "$module"
+    fi
+done
+if command -v brotli >/dev/null 2>&1; then
+    $ECHO_CMD "${GREEN}✅ Created .wasm.gz and .wasm.br variants${NC}"
+else
+    $ECHO_CMD "${GREEN}✅ Created .wasm.gz variants${NC} ${YELLOW}(install brotli for .wasm.br)${NC}"
+fi
+
+# Optional TinyGo build of the business logic alone (no benchmarks)
+if [[ "$1" == "--tinygo" ]]; then
+    if command -v tinygo >/dev/null 2>&1; then
+        $ECHO_CMD "${BLUE}🪶 Building TinyGo WebAssembly module...${NC}"
+        if tinygo build -o main-tiny.wasm -target wasm -no-debug ./src; then
+            cp "$(tinygo env TINYGOROOT)/targets/wasm_exec.js" wasm_exec_tiny.js
+            $ECHO_CMD "${GREEN}✅ Created main-tiny.wasm and wasm_exec_tiny.js${NC}"
+        else
+            $ECHO_CMD "${RED}❌ Failed to build TinyGo WebAssembly module${NC}"
+            exit 1
+        fi
+    else
+        $ECHO_CMD "${YELLOW}⚠️  tinygo not found, skipping main-tiny.wasm${NC}"
+    fi
+fi
+
 $ECHO_CMD "${BLUE}🖥️  Building optimized server binary...${NC}"
-go build -ldflags="-s -w" -o server src/main_server.go src/shared_models.go
+go build -ldflags="-s -w" -o server ./src
 
 if [ $? -eq 0 ]; then
     $ECHO_CMD "${GREEN}✅ Server binary built successfully: server${NC}"
@@ -67,12 +116,18 @@ $ECHO_CMD "   ${CYAN}http://localhost:8181/server.html${NC} - Server API demo"
 $ECHO_CMD ""
 $ECHO_CMD "${YELLOW}🔧 Development Commands:${NC}"
 $ECHO_CMD "• Rebuild WebAssembly (optimized):"
-$ECHO_CMD "  ${CYAN}GOOS=js GOARCH=wasm go build -ldflags=\"-s -w\" -o main.wasm src/main_wasm.go src/shared_models.go src/benchmarks_wasm.go src/benchmarks_types.go src/benchmarks_comprehensive.go src/benchmarks_optimized.go src/benchmarks_shared.go src/benchmarks_unified.go src/mandelbrot.go src/mandelbrot_concurrent.go${NC}"
+$ECHO_CMD "  ${CYAN}GOOS=js GOARCH=wasm go build -ldflags=\"-s -w\" -o main.wasm ./src${NC}"
+$ECHO_CMD ""
+$ECHO_CMD "• Build the smaller lite profile:"
+$ECHO_CMD "  ${CYAN}./build.sh --lite${NC}"
+$ECHO_CMD ""
+$ECHO_CMD "• Build the slim business-logic module with TinyGo:"
+$ECHO_CMD "  ${CYAN}./build.sh --tinygo${NC}"
 $ECHO_CMD ""
 $ECHO_CMD "• Rebuild Server (optimized):"
-$ECHO_CMD "  ${CYAN}go build -ldflags=\"-s -w\" -o server src/main_server.go src/shared_models.go ${NC}"
+$ECHO_CMD "  ${CYAN}go build -ldflags=\"-s -w\" -o server ./src${NC}"
 $ECHO_CMD ""
 $ECHO_CMD "• Run directly:"
-$ECHO_CMD "  ${CYAN}go run src/main_server.go src/shared_models.go ${NC}"
+$ECHO_CMD "  ${CYAN}go run ./src${NC}"
 $ECHO_CMD ""
 $ECHO_CMD "${GREEN}🌟 Enjoy exploring the power of shared Go business logic!${NC}"
@@ -0,0 +1,333 @@
+// Command bench runs the demo's benchmarks natively, from the registry the
+// server's /api/benchmark endpoints and the WASM module's <name>BenchmarkWasm
+// functions are built from, with the same parameters and defaults. It prints
+// the runs as the JSON the benchmark pages post to /api/benchmark/results.
+//
+//	go run ./cmd/bench -benchmark matrix -size 200 -runs 5
+//
+// With -server it also posts the runs to a demo server, times the same
+// workloads there, and summarizes /api/benchmark/compare on stderr, where
+// the native runs sit next to the server's and those the pages recorded for
+// WebAssembly and JavaScript.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"go-wasm-demo/internal/benchkernels"
+)
+
+// nativeEnvironment is the environment runs are recorded under by default.
+const nativeEnvironment = "Go native"
+
+// submission is one timed run, as POST /api/benchmark/results takes it.
+type submission struct {
+	Benchmark   string         `json:"benchmark"`
+	Environment string         `json:"environment"`
+	Params      map[string]int `json:"params,omitempty"`
+	DurationMs  float64        `json:"duration_ms"`
+	CPUCount    int            `json:"cpu_count,omitempty"`
+	GoVersion   string         `json:"go_version,omitempty"`
+	UserAgent   string         `json:"user_agent,omitempty"`
+}
+
+// workload is a benchmark with its parameters resolved.
+type workload struct {
+	Benchmark benchkernels.Benchmark
+	Params    map[string]int
+}
+
+// options are the command-line settings. Params holds the parameters given
+// on the command line, to which each benchmark adds its defaults.
+type options struct {
+	Benchmarks  []string
+	Params      map[string]int
+	Runs        int
+	Environment string
+	Server      string
+}
+
+func main() {
+	opts, err := parseOptions(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bench:", err)
+		os.Exit(2)
+	}
+	if err := run(opts, os.Stdout, os.Stderr, http.DefaultClient); err != nil {
+		fmt.Fprintln(os.Stderr, "bench:", err)
+		os.Exit(1)
+	}
+}
+
+// parseOptions reads the command line. Every parameter of a registered
+// benchmark is a flag.
+func parseOptions(args []string, output io.Writer) (options, error) {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(output)
+	var names []string
+	for _, b := range benchkernels.All() {
+		names = append(names, b.Name())
+	}
+	benchmarks := fs.String("benchmark", strings.Join(names, ","), "benchmarks to run, comma-separated")
+	paramFlags := defineParamFlags(fs)
+	runs := fs.Int("runs", 5, "timed runs of each benchmark")
+	environment := fs.String("environment", nativeEnvironment, "environment to record the runs under")
+	server := fs.String("server", "", "demo server URL to post the runs to and compare with, e.g. http://localhost:8181")
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	if fs.NArg() > 0 {
+		return options{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	opts := options{
+		Params:      map[string]int{},
+		Runs:        *runs,
+		Environment: strings.TrimSpace(*environment),
+		Server:      strings.TrimRight(*server, "/"),
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if paramFlags[f.Name] {
+			opts.Params[f.Name] = f.Value.(flag.Getter).Get().(int)
+		}
+	})
+	for _, name := range strings.Split(*benchmarks, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Benchmarks = append(opts.Benchmarks, name)
+		}
+	}
+
+	var errs []error
+	if len(opts.Benchmarks) == 0 {
+		errs = append(errs, errors.New("-benchmark names no benchmark"))
+	}
+	if opts.Runs < 1 {
+		errs = append(errs, errors.New("-runs must be positive"))
+	}
+	if opts.Environment == "" {
+		errs = append(errs, errors.New("-environment is required"))
+	}
+	return opts, errors.Join(errs...)
+}
+
+// defineParamFlags defines an int flag for each parameter name the
+// registered benchmarks take, described with each benchmark's default, and
+// returns the names.
+func defineParamFlags(fs *flag.FlagSet) map[string]bool {
+	var order []string
+	descriptions := map[string]string{}
+	defaults := map[string][]string{}
+	for _, b := range benchkernels.All() {
+		for _, p := range b.ParamsSchema() {
+			if _, seen := descriptions[p.Name]; !seen {
+				order = append(order, p.Name)
+				descriptions[p.Name] = p.Description
+			}
+			defaults[p.Name] = append(defaults[p.Name], fmt.Sprintf("%s %d", b.Name(), p.Default))
+		}
+	}
+	names := map[string]bool{}
+	for _, name := range order {
+		fs.Int(name, 0, fmt.Sprintf("%s (default: %s)", descriptions[name], strings.Join(defaults[name], ", ")))
+		names[name] = true
+	}
+	return names
+}
+
+// newWorkload resolves a registered benchmark's parameters from the
+// options'.
+func newWorkload(name string, params map[string]int) (workload, error) {
+	b, ok := benchkernels.Lookup(name)
+	if !ok {
+		return workload{}, fmt.Errorf("unknown benchmark %q", name)
+	}
+	resolved, err := benchkernels.Resolve(b, params)
+	if err != nil {
+		return workload{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return workload{Benchmark: b, Params: resolved}, nil
+}
+
+// run times the workloads, prints the runs to stdout and, with a server,
+// posts and compares them.
+func run(opts options, stdout, stderr io.Writer, client *http.Client) error {
+	workloads := make([]workload, 0, len(opts.Benchmarks))
+	for _, name := range opts.Benchmarks {
+		w, err := newWorkload(name, opts.Params)
+		if err != nil {
+			return err
+		}
+		workloads = append(workloads, w)
+	}
+
+	var submissions []submission
+	for _, w := range workloads {
+		for i := 0; i < opts.Runs; i++ {
+			run, err := benchkernels.Measure(context.Background(), w.Benchmark, w.Params)
+			if err != nil {
+				return err
+			}
+			submissions = append(submissions, submission{
+				Benchmark:   run.Benchmark,
+				Environment: opts.Environment,
+				Params:      run.Params,
+				DurationMs:  run.DurationMs(),
+				CPUCount:    runtime.NumCPU(),
+				GoVersion:   runtime.Version(),
+				UserAgent:   fmt.Sprintf("go-wasm-demo bench (%s/%s)", runtime.GOOS, runtime.GOARCH),
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(submissions); err != nil {
+		return err
+	}
+
+	if opts.Server == "" {
+		return nil
+	}
+	if err := postJSON(client, opts.Server+"/api/benchmark/results", submissions); err != nil {
+		return fmt.Errorf("posting results: %w", err)
+	}
+	for _, w := range workloads {
+		for i := 0; i < opts.Runs; i++ {
+			if err := runOnServer(client, opts.Server, w); err != nil {
+				return fmt.Errorf("running %s on the server: %w", w.Benchmark.Name(), err)
+			}
+		}
+	}
+	for _, w := range workloads {
+		if err := printComparison(client, opts.Server, opts.Environment, w, stderr); err != nil {
+			return fmt.Errorf("comparing %s: %w", w.Benchmark.Name(), err)
+		}
+	}
+	return nil
+}
+
+// query is w's parameters as the server's query string.
+func (w workload) query() url.Values {
+	query := url.Values{}
+	for name, value := range w.Params {
+		query.Set(name, strconv.Itoa(value))
+	}
+	return query
+}
+
+// runOnServer runs w through the server's endpoint, which records the run
+// under the "server" environment.
+func runOnServer(client *http.Client, server string, w workload) error {
+	response, err := client.Get(server + "/api/benchmark/" + w.Benchmark.Name() + "?" + w.query().Encode())
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return checkResponse(response)
+}
+
+// comparison is the part of /api/benchmark/compare's response bench shows.
+type comparison struct {
+	Groups []struct {
+		Params       string `json:"params"`
+		Environments []struct {
+			Environment string  `json:"environment"`
+			Runs        int     `json:"runs"`
+			MeanMs      float64 `json:"mean_ms"`
+			MedianMs    float64 `json:"median_ms"`
+			Speedup     float64 `json:"speedup"`
+		} `json:"environments"`
+	} `json:"groups"`
+}
+
+// printComparison writes the server's comparison of w's workload, relative
+// to the native runs, as a table.
+func printComparison(client *http.Client, server, environment string, w workload, out io.Writer) error {
+	query := url.Values{"benchmark": {w.Benchmark.Name()}, "baseline": {environment}}
+	response, err := client.Get(server + "/api/benchmark/compare?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if err := checkResponse(response); err != nil {
+		return err
+	}
+	var result comparison
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	// The server keys workloads by their sorted name=value pairs
+	names := make([]string, 0, len(w.Params))
+	for name := range w.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, w.Params[name])
+	}
+	params := strings.Join(parts, ",")
+
+	for _, group := range result.Groups {
+		if group.Params != params {
+			continue
+		}
+		fmt.Fprintf(out, "%s (%s)\n", w.Benchmark.Name(), params)
+		table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "  ENVIRONMENT\tRUNS\tMEAN MS\tMEDIAN MS\tSPEEDUP")
+		for _, env := range group.Environments {
+			fmt.Fprintf(table, "  %s\t%d\t%.3f\t%.3f\t%.2fx\n", env.Environment, env.Runs, env.MeanMs, env.MedianMs, env.Speedup)
+		}
+		return table.Flush()
+	}
+	return nil
+}
+
+// postJSON posts v as JSON to target.
+func postJSON(client *http.Client, target string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	response, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return checkResponse(response)
+}
+
+// checkResponse is the error of a failed response, with the server's
+// message when it sent one.
+func checkResponse(response *http.Response) error {
+	if response.StatusCode < 300 {
+		return nil
+	}
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err == nil && body.Error.Message != "" {
+		return fmt.Errorf("%s: %s", response.Status, body.Error.Message)
+	}
+	return errors.New(response.Status)
+}
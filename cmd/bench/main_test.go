@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts, err := parseOptions([]string{"-benchmark", "matrix, hash", "-size", "8", "-runs", "2"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(opts.Benchmarks, ",") != "matrix,hash" || len(opts.Params) != 1 || opts.Params["size"] != 8 || opts.Runs != 2 {
+		t.Errorf("options = %+v", opts)
+	}
+	if opts.Environment != nativeEnvironment {
+		t.Errorf("defaults = %+v", opts)
+	}
+
+	// Every registered benchmark runs by default, and has its parameters
+	// as flags
+	opts, err = parseOptions([]string{"-samples", "2"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(opts.Benchmarks, ",") != "hash,mandelbrot,matrix,raytracing" || opts.Params["samples"] != 2 {
+		t.Errorf("options = %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"-runs", "0"},
+		{"-benchmark", " , "},
+		{"-environment", " "},
+		{"extra"},
+	} {
+		if _, err := parseOptions(args, io.Discard); err == nil {
+			t.Errorf("parseOptions(%q) succeeded", args)
+		}
+	}
+}
+
+func TestRunPrintsSubmissions(t *testing.T) {
+	opts, err := parseOptions([]string{"-benchmark", "matrix,mandelbrot", "-size", "4", "-width", "8", "-height", "6", "-iterations", "10", "-runs", "2"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	if err := run(opts, &stdout, io.Discard, http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []submission
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a submission array: %v\n%s", err, stdout.String())
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d submissions, want 4", len(got))
+	}
+	if got[0].Benchmark != "matrix" || len(got[0].Params) != 1 || got[0].Params["size"] != 4 {
+		t.Errorf("matrix run = %+v", got[0])
+	}
+	if got[2].Benchmark != "mandelbrot" || len(got[2].Params) != 3 || got[2].Params["iterations"] != 10 {
+		t.Errorf("mandelbrot run = %+v", got[2])
+	}
+	for _, sub := range got {
+		if sub.Environment != nativeEnvironment || sub.DurationMs < 0 || sub.CPUCount < 1 || sub.GoVersion == "" {
+			t.Errorf("submission = %+v", sub)
+		}
+	}
+
+	opts.Benchmarks = []string{"sort"}
+	if err := run(opts, io.Discard, io.Discard, http.DefaultClient); err == nil || !strings.Contains(err.Error(), "unknown benchmark") {
+		t.Errorf("unknown benchmark: err = %v", err)
+	}
+	opts.Benchmarks, opts.Params = []string{"matrix"}, map[string]int{"size": 0}
+	if err := run(opts, io.Discard, io.Discard, http.DefaultClient); err == nil || !strings.Contains(err.Error(), "size must be at least 1") {
+		t.Errorf("size 0: err = %v", err)
+	}
+}
+
+func TestRunWithServer(t *testing.T) {
+	var posted []submission
+	var serverRuns []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/benchmark/results":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/api/benchmark/hash":
+			serverRuns = append(serverRuns, r.URL.RawQuery)
+			w.Write([]byte(`{"result_hash": 1}`))
+		case r.URL.Path == "/api/benchmark/compare":
+			if r.URL.Query().Get("baseline") != nativeEnvironment {
+				t.Errorf("compare query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"groups": [{"params": "count=3", "environments": [
+				{"environment": "Go native", "runs": 1, "mean_ms": 2, "median_ms": 2, "speedup": 1},
+				{"environment": "Go WASM", "runs": 4, "mean_ms": 4, "median_ms": 4, "speedup": 0.5}
+			]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	opts, err := parseOptions([]string{"-benchmark", "hash", "-count", "3", "-runs", "1", "-server", server.URL + "/"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if err := run(opts, io.Discard, &stderr, server.Client()); err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 || posted[0].Params["count"] != 3 {
+		t.Errorf("posted %+v", posted)
+	}
+	if len(serverRuns) != 1 || serverRuns[0] != "count=3" {
+		t.Errorf("server runs = %q", serverRuns)
+	}
+	if !strings.Contains(stderr.String(), "Go WASM") || !strings.Contains(stderr.String(), "0.50x") {
+		t.Errorf("comparison:\n%s", stderr.String())
+	}
+}
+
+func TestRunReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": "bad_request", "message": "result 0: duration_ms must be a positive number"}}`))
+	}))
+	defer server.Close()
+
+	opts, err := parseOptions([]string{"-benchmark", "hash", "-count", "1", "-runs", "1", "-server", server.URL}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = run(opts, io.Discard, io.Discard, server.Client())
+	if err == nil || !strings.Contains(err.Error(), "duration_ms must be a positive number") {
+		t.Errorf("err = %v", err)
+	}
+}
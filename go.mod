@@ -1,3 +1,3 @@
 module go-wasm-demo
 
-go 1.23
+go 1.24
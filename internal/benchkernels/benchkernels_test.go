@@ -0,0 +1,205 @@
+package benchkernels
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestKernelHashes(t *testing.T) {
+	// The hashes the server endpoints reported before the kernels moved here
+	tests := []struct {
+		name string
+		run  func() int
+		want int
+	}{
+		{"matrix 3x3", func() int { return MatrixMultiply(3) }, 94},
+		{"matrix 100x100", func() int { return MatrixMultiply(DefaultMatrixSize) }, 7200},
+		{"mandelbrot 40x30", func() int { return Mandelbrot(40, 30, 100) }, 103},
+		{"sha256 10", func() int { return SHA256(10) }, 1409},
+	}
+	for _, tt := range tests {
+		if got := tt.run(); got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var names []string
+	for _, b := range All() {
+		names = append(names, b.Name())
+		if got, ok := Lookup(b.Name()); !ok || got.Name() != b.Name() {
+			t.Errorf("Lookup(%q) = %v, %v", b.Name(), got, ok)
+		}
+		for _, p := range b.ParamsSchema() {
+			if p.Default < p.Min || (p.Max > 0 && p.Default > p.Max) {
+				t.Errorf("%s: default %s = %d is out of bounds", b.Name(), p.Name, p.Default)
+			}
+		}
+	}
+	if got := strings.Join(names, ","); got != "hash,mandelbrot,matrix,raytracing" {
+		t.Errorf("All() = %s", got)
+	}
+	if _, ok := Lookup("sort"); ok {
+		t.Error("Lookup of an unregistered benchmark succeeded")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering matrix twice didn't panic")
+		}
+	}()
+	Register(matrixBenchmark{})
+}
+
+func TestResolve(t *testing.T) {
+	mandelbrot, _ := Lookup("mandelbrot")
+	params, err := Resolve(mandelbrot, map[string]int{"width": 64, "size": 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"width": 64, "height": DefaultMandelbrotHeight, "iterations": DefaultMandelbrotIterations}
+	if !maps.Equal(params, want) {
+		t.Errorf("Resolve = %v, want %v", params, want)
+	}
+
+	raytrace, _ := Lookup("raytracing")
+	for params, want := range map[string]string{
+		"samples=0":  "samples must be at least 1",
+		"samples=33": "samples must be at most 32",
+	} {
+		name, value, _ := strings.Cut(params, "=")
+		n, _ := strconv.Atoi(value)
+		if _, err := Resolve(raytrace, map[string]int{name: n}); err == nil || err.Error() != want {
+			t.Errorf("Resolve(%s) error = %v, want %q", params, err, want)
+		}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	matrix, _ := Lookup("matrix")
+	run, err := Measure(context.Background(), matrix, map[string]int{"size": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := run.Report()
+	if report["operation"] != "Matrix Multiplication" || report["result_hash"] != 94 || report["size"] != "3x3" || report["operations"] != 27 {
+		t.Errorf("Report() = %v", report)
+	}
+	if _, ok := report["duration_ms"].(float64); !ok || run.Benchmark != "matrix" {
+		t.Errorf("run = %+v", run)
+	}
+
+	// The ray tracer's pixels are those the WASM module renders
+	raytrace, _ := Lookup("raytracing")
+	run, err = Measure(context.Background(), raytrace, map[string]int{"width": 4, "height": 2, "samples": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pixels := RayTrace(4, 2, 1); len(pixels) != 24 || run.Details["pixels"] != 8 {
+		t.Errorf("RayTrace rendered %d values, run = %+v", len(pixels), run)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, b := range All() {
+		if _, err := Measure(ctx, b, nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with a cancelled context: err = %v", b.Name(), err)
+		}
+	}
+}
+
+func TestRayTraceSampling(t *testing.T) {
+	const width, height = 40, 30
+
+	// One sample is the pixel's center
+	nx := ((5+0.5)/width)*2.0 - 1.0
+	ny := ((7+0.5)/height)*2.0 - 1.0
+	wantR, wantG, wantB := rayColor(nx, ny)
+	if r, g, b := PixelColor(5, 7, width, height, 1); r != wantR || g != wantG || b != wantB {
+		t.Errorf("PixelColor with 1 sample = %v %v %v, want the center's %v %v %v", r, g, b, wantR, wantG, wantB)
+	}
+
+	// Jittered samples antialias the sphere's edge: some pixel there is
+	// neither background nor all sphere, which one identical sample per
+	// pixel never gives
+	one, many := RayTrace(width, height, 1), RayTrace(width, height, 16)
+	blended := false
+	for i := 0; i < len(many); i += 3 {
+		if many[i] != one[i] && many[i] != backgroundR && one[i] == backgroundR {
+			blended = true
+			break
+		}
+	}
+	if !blended {
+		t.Error("Expected 16 samples to blend the background into an edge pixel")
+	}
+
+	// Renders repeat exactly, and summing blocks gives PixelColor
+	if again := RayTrace(width, height, 16); !slices.Equal(again, many) {
+		t.Error("Expected two renders to be the same")
+	}
+	const samples = 2*SampleBlock + 3
+	var sumR, sumG, sumB float64
+	for block := 0; block < SampleBlocks(samples); block++ {
+		r, g, b := SampleBlockColor(11, 13, width, height, samples, block)
+		sumR, sumG, sumB = sumR+r, sumG+g, sumB+b
+	}
+	r, g, b := PixelColor(11, 13, width, height, samples)
+	if avgR, avgG, avgB := AverageColor(sumR, sumG, sumB, samples); r != avgR || g != avgG || b != avgB {
+		t.Errorf("PixelColor = %v %v %v, blocks give %v %v %v", r, g, b, avgR, avgG, avgB)
+	}
+	if SampleBlocks(samples) != 3 || SampleBlocks(SampleBlock) != 1 {
+		t.Errorf("SampleBlocks(%d) = %d", samples, SampleBlocks(samples))
+	}
+
+	// Jitter stays in [0, 1) and differs between neighbouring pixels
+	for s := 0; s < 100; s++ {
+		x, y := sampleJitter(uint32(s), s)
+		if x < 0 || x >= 1 || y < 0 || y >= 1 {
+			t.Fatalf("sampleJitter(%d) = %v, %v", s, x, y)
+		}
+	}
+	nextSample, _ := sampleJitter(0, 1)
+	nextPixel, _ := sampleJitter(1, 0)
+	if nextSample == nextPixel {
+		t.Error("Expected the next pixel's jitter not to be the next sample's")
+	}
+}
+
+func TestMandelbrotEscapeShortcuts(t *testing.T) {
+	// Shortcuts give the same counts over views of the whole set, its
+	// boundary and its interior
+	for _, view := range [][4]float64{{-2.5, 1.5, -1.5, 1.5}, {-0.8, -0.7, 0.05, 0.15}, {-0.5, 0.2, -0.3, 0.3}} {
+		for py := 0; py < 60; py++ {
+			cy := view[2] + float64(py)*(view[3]-view[2])/60
+			for px := 0; px < 80; px++ {
+				cx := view[0] + float64(px)*(view[1]-view[0])/80
+				if got, want := MandelbrotEscape(cx, cy, 200, true), MandelbrotEscape(cx, cy, 200, false); got != want {
+					t.Fatalf("MandelbrotEscape(%v, %v) = %d with shortcuts, %d without", cx, cy, got, want)
+				}
+			}
+		}
+	}
+
+	for _, c := range [][2]float64{{0, 0}, {-0.1, 0.3}, {0.2, 0}, {-1, 0}, {-1.1, 0.1}} {
+		if !inMandelbrotCardioidOrBulb(c[0], c[1]) {
+			t.Errorf("Expected %v inside the cardioid or bulb", c)
+		}
+		if got := MandelbrotEscape(c[0], c[1], 1000, true); got != 1000 {
+			t.Errorf("MandelbrotEscape(%v) = %d, want 1000", c, got)
+		}
+	}
+	// In the period-3 bulb, outside both, the orbit's cycle is found
+	if inMandelbrotCardioidOrBulb(-0.12, 0.74) || MandelbrotEscape(-0.12, 0.74, 1000, true) != 1000 {
+		t.Error("Expected -0.12+0.74i not to escape")
+	}
+	if got := MandelbrotEscape(1, 1, 100, true); got != 2 {
+		t.Errorf("MandelbrotEscape(1+i) = %d, want 2", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package benchkernels holds the native benchmarks: the matrix
+// multiplication, Mandelbrot, SHA256 and ray tracing workloads the server's
+// /api/benchmark endpoints time, the WASM module runs as <name>BenchmarkWasm
+// and cmd/bench runs from the command line, so all three measure the same
+// code with the same parameters. Each benchmark is a Benchmark registered by
+// its own file; adding one is a file with a Register call in its init.
+package benchkernels
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Benchmark is a workload that can be timed.
+type Benchmark interface {
+	// Name identifies the benchmark in URLs, submissions and flags, such as
+	// "matrix".
+	Name() string
+	// ParamsSchema lists the integer parameters Run takes.
+	ParamsSchema() []Param
+	// Run does the work for params, which Resolve has completed, and stops
+	// early with ctx's error when ctx is done.
+	Run(ctx context.Context, params map[string]int) (Result, error)
+}
+
+// Param describes one benchmark parameter.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     int    `json:"default"`
+	Min         int    `json:"min"`
+	Max         int    `json:"max,omitempty"` // 0 for no limit
+}
+
+// Result is what a run computed. Hash ties a timing to the work actually
+// done; Details are reported next to it, such as the matrix size.
+type Result struct {
+	Operation string
+	Hash      int
+	Details   map[string]interface{}
+}
+
+var registry = map[string]Benchmark{}
+
+// Register makes a benchmark available by its name. It panics when the name
+// is empty or already registered.
+func Register(b Benchmark) {
+	name := b.Name()
+	if name == "" {
+		panic("benchkernels: Register of a benchmark without a name")
+	}
+	if _, dup := registry[name]; dup {
+		panic("benchkernels: Register called twice for benchmark " + name)
+	}
+	registry[name] = b
+}
+
+// Lookup returns the benchmark registered under name.
+func Lookup(name string) (Benchmark, bool) {
+	b, ok := registry[name]
+	return b, ok
+}
+
+// All returns the registered benchmarks, sorted by name.
+func All() []Benchmark {
+	all := make([]Benchmark, 0, len(registry))
+	for _, b := range registry {
+		all = append(all, b)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// Resolve returns b's parameters from params, with defaults for those left
+// out, checked against the schema's bounds. Names b doesn't take are ignored.
+func Resolve(b Benchmark, params map[string]int) (map[string]int, error) {
+	resolved := map[string]int{}
+	for _, p := range b.ParamsSchema() {
+		value, ok := params[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		if value < p.Min {
+			return nil, fmt.Errorf("%s must be at least %d", p.Name, p.Min)
+		}
+		if p.Max > 0 && value > p.Max {
+			return nil, fmt.Errorf("%s must be at most %d", p.Name, p.Max)
+		}
+		resolved[p.Name] = value
+	}
+	return resolved, nil
+}
+
+// Measurement is one timed run of a benchmark.
+type Measurement struct {
+	Benchmark string
+	Params    map[string]int
+	Result
+	Duration time.Duration
+}
+
+// DurationMs is the run's duration in milliseconds.
+func (m Measurement) DurationMs() float64 {
+	return float64(m.Duration.Nanoseconds()) / 1000000
+}
+
+// Report is the run as the benchmark endpoints respond with it: the
+// operation, duration_ms, result_hash and the result's details.
+func (m Measurement) Report() map[string]interface{} {
+	report := map[string]interface{}{
+		"operation":   m.Operation,
+		"duration_ms": m.DurationMs(),
+		"result_hash": m.Hash,
+	}
+	for key, value := range m.Details {
+		report[key] = value
+	}
+	return report
+}
+
+// Measure resolves params and times one run of b.
+func Measure(ctx context.Context, b Benchmark, params map[string]int) (Measurement, error) {
+	resolved, err := Resolve(b, params)
+	if err != nil {
+		return Measurement{}, err
+	}
+	start := time.Now()
+	result, err := b.Run(ctx, resolved)
+	duration := time.Since(start)
+	if err != nil {
+		return Measurement{}, err
+	}
+	return Measurement{Benchmark: b.Name(), Params: resolved, Result: result, Duration: duration}, nil
+}
@@ -0,0 +1,59 @@
+package benchkernels
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+)
+
+// DefaultHashCount is the number of hashes runs compute by default.
+const DefaultHashCount = 10000
+
+// hashData is the message the benchmark hashes, suffixed with a counter.
+const hashData = "WebAssembly performance test data for hashing benchmark"
+
+func init() { Register(hashBenchmark{}) }
+
+// hashBenchmark computes SHA256 hashes of numbered messages.
+type hashBenchmark struct{}
+
+func (hashBenchmark) Name() string { return "hash" }
+
+func (hashBenchmark) ParamsSchema() []Param {
+	return []Param{{Name: "count", Description: "Number of hashes to compute", Default: DefaultHashCount, Min: 1}}
+}
+
+func (hashBenchmark) Run(ctx context.Context, params map[string]int) (Result, error) {
+	count := params["count"]
+	hash, err := sha256Hashes(ctx, count)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Operation: "SHA256 Hashing",
+		Hash:      hash,
+		Details:   map[string]interface{}{"count": count},
+	}, nil
+}
+
+// SHA256 hashes count numbered messages and returns the result hash.
+func SHA256(count int) int {
+	hash, _ := sha256Hashes(context.Background(), count)
+	return hash
+}
+
+func sha256Hashes(ctx context.Context, count int) (int, error) {
+	hash := 0
+	for i := 0; i < count; i++ {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		hasher := sha256.New()
+		hasher.Write([]byte(fmt.Sprintf("%s-%d", hashData, i)))
+		sum := hasher.Sum(nil)
+		hash += int(sum[0])
+	}
+	return hash, nil
+}
@@ -0,0 +1,133 @@
+package benchkernels
+
+import (
+	"context"
+	"fmt"
+)
+
+// Default Mandelbrot parameters.
+const (
+	DefaultMandelbrotWidth      = 400
+	DefaultMandelbrotHeight     = 300
+	DefaultMandelbrotIterations = 100
+)
+
+// Mandelbrot view the benchmark renders.
+const (
+	mandelbrotXMin, mandelbrotXMax = -2.0, 1.0
+	mandelbrotYMin, mandelbrotYMax = -1.5, 1.5
+)
+
+func init() { Register(mandelbrotBenchmark{}) }
+
+// mandelbrotBenchmark renders the Mandelbrot set.
+type mandelbrotBenchmark struct{}
+
+func (mandelbrotBenchmark) Name() string { return "mandelbrot" }
+
+func (mandelbrotBenchmark) ParamsSchema() []Param {
+	return []Param{
+		{Name: "width", Description: "Image width in pixels", Default: DefaultMandelbrotWidth, Min: 1},
+		{Name: "height", Description: "Image height in pixels", Default: DefaultMandelbrotHeight, Min: 1},
+		{Name: "iterations", Description: "Maximum iterations per pixel", Default: DefaultMandelbrotIterations, Min: 1},
+	}
+}
+
+func (mandelbrotBenchmark) Run(ctx context.Context, params map[string]int) (Result, error) {
+	width, height, iterations := params["width"], params["height"], params["iterations"]
+	hash, err := mandelbrot(ctx, width, height, iterations)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Operation: "Mandelbrot Set",
+		Hash:      hash,
+		Details: map[string]interface{}{
+			"size":       fmt.Sprintf("%dx%d", width, height),
+			"iterations": iterations,
+			"pixels":     width * height,
+		},
+	}, nil
+}
+
+// Mandelbrot renders a width×height Mandelbrot set of at most iterations
+// per pixel and returns the result hash.
+func Mandelbrot(width, height, iterations int) int {
+	hash, _ := mandelbrot(context.Background(), width, height, iterations)
+	return hash
+}
+
+// mandelbrot iterates every point, without shortcuts, so its timings compare
+// with the JavaScript version's.
+func mandelbrot(ctx context.Context, width, height, iterations int) (int, error) {
+	result := make([]int, width*height)
+	dx := (mandelbrotXMax - mandelbrotXMin) / float64(width)
+	dy := (mandelbrotYMax - mandelbrotYMin) / float64(height)
+
+	idx := 0
+	for py := 0; py < height; py++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		cy := mandelbrotYMin + float64(py)*dy
+		for px := 0; px < width; px++ {
+			cx := mandelbrotXMin + float64(px)*dx
+			result[idx] = MandelbrotEscape(cx, cy, iterations, false)
+			idx++
+		}
+	}
+
+	return result[0] + result[len(result)/2] + result[len(result)-1], nil
+}
+
+// MandelbrotEscape is the number of iterations of z = z² + c, from z = 0,
+// before |z| > 2 for c = cx + cy·i, or maxIter if it stays within.
+//
+// With shortcuts, two kinds of points that never escape skip the rest of
+// their iterations: points in the main cardioid or the period-2 bulb, which
+// hold most of the set's interior, aren't iterated at all, and an orbit that
+// comes back exactly to a value it had is periodic, which Brent's cycle
+// detection spots by comparing z against the value saved at each power of 2
+// iterations. Either way the count is maxIter, as iterating would give, so
+// shortcuts only change how long deep interior regions take.
+func MandelbrotEscape(cx, cy float64, maxIter int, shortcuts bool) int {
+	if shortcuts && inMandelbrotCardioidOrBulb(cx, cy) {
+		return maxIter
+	}
+
+	zx, zy := 0.0, 0.0
+	savedX, savedY := 0.0, 0.0
+	steps, period := 0, 1
+	for iter := 0; iter < maxIter; iter++ {
+		zx2 := zx * zx
+		zy2 := zy * zy
+		if zx2+zy2 > 4.0 {
+			return iter
+		}
+
+		zy = (zx+zx)*zy + cy
+		zx = zx2 - zy2 + cx
+
+		if shortcuts {
+			if zx == savedX && zy == savedY {
+				return maxIter
+			}
+			if steps++; steps == period {
+				savedX, savedY = zx, zy
+				steps, period = 0, period*2
+			}
+		}
+	}
+	return maxIter
+}
+
+// inMandelbrotCardioidOrBulb reports whether c is strictly inside the main
+// cardioid or the period-2 bulb around -1.
+func inMandelbrotCardioidOrBulb(cx, cy float64) bool {
+	y2 := cy * cy
+	q := (cx-0.25)*(cx-0.25) + y2
+	if q*(q+(cx-0.25)) < 0.25*y2 {
+		return true
+	}
+	return (cx+1)*(cx+1)+y2 < 0.0625
+}
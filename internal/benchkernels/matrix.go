@@ -0,0 +1,69 @@
+package benchkernels
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultMatrixSize is the matrix size runs take by default.
+const DefaultMatrixSize = 100
+
+func init() { Register(matrixBenchmark{}) }
+
+// matrixBenchmark multiplies two size×size matrices.
+type matrixBenchmark struct{}
+
+func (matrixBenchmark) Name() string { return "matrix" }
+
+func (matrixBenchmark) ParamsSchema() []Param {
+	return []Param{{Name: "size", Description: "Matrix dimension (size x size)", Default: DefaultMatrixSize, Min: 1}}
+}
+
+func (matrixBenchmark) Run(ctx context.Context, params map[string]int) (Result, error) {
+	size := params["size"]
+	hash, err := matrixMultiply(ctx, size)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Operation: "Matrix Multiplication",
+		Hash:      hash,
+		Details: map[string]interface{}{
+			"size":       fmt.Sprintf("%dx%d", size, size),
+			"operations": size * size * size,
+		},
+	}, nil
+}
+
+// MatrixMultiply multiplies two size×size matrices of test data and returns
+// the result hash.
+func MatrixMultiply(size int) int {
+	hash, _ := matrixMultiply(context.Background(), size)
+	return hash
+}
+
+func matrixMultiply(ctx context.Context, size int) (int, error) {
+	matrixA := make([]float64, size*size)
+	matrixB := make([]float64, size*size)
+	result := make([]float64, size*size)
+
+	// Initialize with test data
+	for i := 0; i < size*size; i++ {
+		matrixA[i] = float64(i % 10)
+		matrixB[i] = float64((i * 2) % 10)
+	}
+
+	for i := 0; i < size; i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		for k := 0; k < size; k++ {
+			aik := matrixA[i*size+k]
+			for j := 0; j < size; j++ {
+				result[i*size+j] += aik * matrixB[k*size+j]
+			}
+		}
+	}
+
+	return int(result[0] + result[size-1] + result[len(result)-1]), nil
+}
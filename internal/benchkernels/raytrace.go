@@ -0,0 +1,232 @@
+package benchkernels
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Default ray tracing parameters, as the WASM demo page picks them.
+const (
+	DefaultRayTraceWidth   = 400
+	DefaultRayTraceHeight  = 300
+	DefaultRayTraceSamples = 8
+)
+
+// Scene of the ray tracer: one lit sphere in front of the camera.
+const (
+	sphereX       = 0.0
+	sphereY       = 0.0
+	sphereZ       = -5.0
+	sphereRadius2 = 1.0
+	lightX        = -0.57735027
+	lightY        = -0.57735027
+	lightZ        = -0.57735027
+	backgroundR   = 0.2
+	backgroundG   = 0.2
+	backgroundB   = 0.8
+)
+
+func init() { Register(rayTraceBenchmark{}) }
+
+// rayTraceBenchmark renders the ray tracing scene.
+type rayTraceBenchmark struct{}
+
+func (rayTraceBenchmark) Name() string { return "raytracing" }
+
+func (rayTraceBenchmark) ParamsSchema() []Param {
+	return []Param{
+		{Name: "width", Description: "Image width in pixels", Default: DefaultRayTraceWidth, Min: 1, Max: 1600},
+		{Name: "height", Description: "Image height in pixels", Default: DefaultRayTraceHeight, Min: 1, Max: 1200},
+		{Name: "samples", Description: "Samples per pixel", Default: DefaultRayTraceSamples, Min: 1, Max: 32},
+	}
+}
+
+func (rayTraceBenchmark) Run(ctx context.Context, params map[string]int) (Result, error) {
+	width, height, samples := params["width"], params["height"], params["samples"]
+	pixels, err := rayTrace(ctx, width, height, samples)
+	if err != nil {
+		return Result{}, err
+	}
+	// Sum of the first, middle and last pixels' channels, in thousandths
+	middle := (len(pixels) / 3 / 2) * 3
+	sum := 0.0
+	for _, idx := range []int{0, middle, len(pixels) - 3} {
+		sum += pixels[idx] + pixels[idx+1] + pixels[idx+2]
+	}
+	return Result{
+		Operation: "Ray Tracing",
+		Hash:      int(math.Round(sum * 1000)),
+		Details: map[string]interface{}{
+			"size":    fmt.Sprintf("%dx%d", width, height),
+			"samples": samples,
+			"pixels":  width * height,
+		},
+	}, nil
+}
+
+// RayTrace renders the scene at width×height with samples per pixel, as
+// RGB triples row by row.
+func RayTrace(width, height, samples int) []float64 {
+	pixels, _ := rayTrace(context.Background(), width, height, samples)
+	return pixels
+}
+
+func rayTrace(ctx context.Context, width, height, samples int) ([]float64, error) {
+	result := make([]float64, width*height*3)
+
+	for y := 0; y < height; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := 0; x < width; x++ {
+			colorR, colorG, colorB := PixelColor(x, y, width, height, samples)
+
+			idx := (y*width + x) * 3
+			result[idx] = colorR
+			result[idx+1] = colorG
+			result[idx+2] = colorB
+		}
+	}
+
+	return result, nil
+}
+
+// SampleBlock is how many of a pixel's samples are summed together. A pixel's
+// color is the sum of its blocks' sums, added in block order, so renderers
+// that compute the blocks of a pixel in parallel get the same color as
+// PixelColor.
+const SampleBlock = 16
+
+// SampleBlocks is how many blocks samples are summed in.
+func SampleBlocks(samples int) int {
+	return (samples + SampleBlock - 1) / SampleBlock
+}
+
+// PixelColor is the color of pixel x, y of a width×height image, averaged
+// over samples jittered samples.
+func PixelColor(x, y, width, height, samples int) (float64, float64, float64) {
+	var colorR, colorG, colorB float64
+	for block := 0; block < SampleBlocks(samples); block++ {
+		blockR, blockG, blockB := SampleBlockColor(x, y, width, height, samples, block)
+		colorR += blockR
+		colorG += blockG
+		colorB += blockB
+	}
+	return AverageColor(colorR, colorG, colorB, samples)
+}
+
+// AverageColor divides a pixel's summed color by its samples.
+func AverageColor(colorR, colorG, colorB float64, samples int) (float64, float64, float64) {
+	invSamples := 1.0 / float64(samples)
+	return colorR * invSamples, colorG * invSamples, colorB * invSamples
+}
+
+// SampleBlockColor is the summed color of the samples in block of pixel x, y.
+//
+// The samples are stratified: the pixel is divided into a grid of about
+// samples cells, and sample s is at a random point of cell s. A single
+// sample is the pixel's center. The random points come from the pixel's
+// position and the sample's number alone, so any block can be computed on
+// its own and a render is the same every time.
+func SampleBlockColor(x, y, width, height, samples, block int) (float64, float64, float64) {
+	columns := int(math.Ceil(math.Sqrt(float64(samples))))
+	rows := (samples + columns - 1) / columns
+	seed := uint32(y*width + x)
+
+	var colorR, colorG, colorB float64
+	for s := block * SampleBlock; s < min((block+1)*SampleBlock, samples); s++ {
+		offsetX, offsetY := 0.5, 0.5
+		if samples > 1 {
+			jitterX, jitterY := sampleJitter(seed, s)
+			offsetX = (float64(s%columns) + jitterX) / float64(columns)
+			offsetY = (float64(s/columns) + jitterY) / float64(rows)
+		}
+		nx := ((float64(x)+offsetX)/float64(width))*2.0 - 1.0
+		ny := ((float64(y)+offsetY)/float64(height))*2.0 - 1.0
+
+		sampleR, sampleG, sampleB := rayColor(nx, ny)
+		colorR += sampleR
+		colorG += sampleG
+		colorB += sampleB
+	}
+	return colorR, colorG, colorB
+}
+
+// sampleJitter is sample s's random offset into its cell, each coordinate in
+// [0, 1), from a 32-bit integer hash of the pixel's seed and the sample's
+// number. assets/js/shared-benchmarks.js computes the same offsets.
+func sampleJitter(seed uint32, s int) (float64, float64) {
+	state := hash32(seed) + uint32(s)*2
+	return float64(hash32(state+1)) / (1 << 32), float64(hash32(state+2)) / (1 << 32)
+}
+
+// hash32 is the lowbias32 integer hash.
+func hash32(x uint32) uint32 {
+	x ^= x >> 16
+	x *= 0x7feb352d
+	x ^= x >> 15
+	x *= 0x846ca68b
+	x ^= x >> 16
+	return x
+}
+
+// rayColor is the color of the ray through normalized coordinates nx, ny
+// (-1 to 1). The computation is fully inlined for performance.
+func rayColor(nx, ny float64) (float64, float64, float64) {
+	// Ray direction normalization
+	rayLenSq := nx*nx + ny*ny + 1.0
+	rayLen := math.Sqrt(rayLenSq)
+
+	invRayLen := 1.0 / rayLen
+	dirX := nx * invRayLen
+	dirY := ny * invRayLen
+	dirZ := -1.0 * invRayLen
+
+	// Ray-sphere intersection
+	ocX := 0.0 - sphereX
+	ocY := 0.0 - sphereY
+	ocZ := 0.0 - sphereZ
+
+	rayA := dirX*dirX + dirY*dirY + dirZ*dirZ
+	rayB := 2.0 * (ocX*dirX + ocY*dirY + ocZ*dirZ)
+	rayC := ocX*ocX + ocY*ocY + ocZ*ocZ - sphereRadius2
+
+	discriminant := rayB*rayB - 4.0*rayA*rayC
+
+	if discriminant < 0 {
+		// Background color
+		return backgroundR, backgroundG, backgroundB
+	}
+	sqrtDisc := math.Sqrt(discriminant)
+
+	t := (-rayB - sqrtDisc) / (2.0 * rayA)
+	if t < 0 {
+		t = (-rayB + sqrtDisc) / (2.0 * rayA)
+	}
+	if t < 0 {
+		// Behind camera
+		return backgroundR, backgroundG, backgroundB
+	}
+
+	// Intersection point, normal, and lighting
+	ix := 0.0 + t*dirX
+	iy := 0.0 + t*dirY
+	iz := 0.0 + t*dirZ
+
+	normalX := ix - sphereX
+	normalY := iy - sphereY
+	normalZ := iz - sphereZ
+
+	// Inlined max(0, dot)
+	dot := normalX*lightX + normalY*lightY + normalZ*lightZ
+	var intensity float64
+	if dot > 0.0 {
+		intensity = dot
+	} else {
+		intensity = 0.0
+	}
+
+	baseColor := 0.2 + 0.8*intensity
+	return baseColor * 1.0, baseColor * 0.7, baseColor * 0.3
+}
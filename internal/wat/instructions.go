@@ -0,0 +1,168 @@
+package wat
+
+// immediate is the kind of operand an instruction takes.
+type immediate int
+
+const (
+	immediateNone immediate = iota
+	immediateLocal
+	immediateFunc
+	immediateLabel
+	immediateBlock // block, loop and if: an optional $label and (result t)
+	immediateElse
+	immediateEnd
+	immediateI32
+	immediateI64
+	immediateF64
+	immediateMemory      // offset=N align=N, both optional
+	immediateLane        // a lane index
+	immediateMemoryIndex // memory.size and memory.grow's memory, always 0
+)
+
+// opEnd closes a block, and every function body.
+const opEnd = 0x0b
+
+type instruction struct {
+	code      []byte
+	immediate immediate
+	align     uint64 // log2 of a memory access's natural alignment
+	lanes     int    // a lane instruction's lane count
+}
+
+func op(code byte) instruction {
+	return instruction{code: []byte{code}}
+}
+
+func with(instr instruction, imm immediate) instruction {
+	instr.immediate = imm
+	return instr
+}
+
+// access is a load or store of 1<<align bytes.
+func access(instr instruction, align uint64) instruction {
+	instr.immediate, instr.align = immediateMemory, align
+	return instr
+}
+
+// lane is a lane instruction over lanes lanes.
+func lane(instr instruction, lanes int) instruction {
+	instr.immediate, instr.lanes = immediateLane, lanes
+	return instr
+}
+
+// simd is an instruction with the SIMD prefix.
+func simd(code uint64) instruction {
+	return instruction{code: appendULEB([]byte{0xfd}, code)}
+}
+
+// instructions are the supported instructions by name.
+var instructions = map[string]instruction{
+	// Control
+	"unreachable": op(0x00),
+	"nop":         op(0x01),
+	"block":       with(op(0x02), immediateBlock),
+	"loop":        with(op(0x03), immediateBlock),
+	"if":          with(op(0x04), immediateBlock),
+	"else":        with(op(0x05), immediateElse),
+	"end":         with(op(opEnd), immediateEnd),
+	"br":          with(op(0x0c), immediateLabel),
+	"br_if":       with(op(0x0d), immediateLabel),
+	"return":      op(0x0f),
+	"call":        with(op(0x10), immediateFunc),
+	"drop":        op(0x1a),
+	"select":      op(0x1b),
+
+	// Variables
+	"local.get": with(op(0x20), immediateLocal),
+	"local.set": with(op(0x21), immediateLocal),
+	"local.tee": with(op(0x22), immediateLocal),
+
+	// Memory
+	"i32.load":    access(op(0x28), 2),
+	"i64.load":    access(op(0x29), 3),
+	"f32.load":    access(op(0x2a), 2),
+	"f64.load":    access(op(0x2b), 3),
+	"i32.store":   access(op(0x36), 2),
+	"i64.store":   access(op(0x37), 3),
+	"f32.store":   access(op(0x38), 2),
+	"f64.store":   access(op(0x39), 3),
+	"memory.size": with(op(0x3f), immediateMemoryIndex),
+	"memory.grow": with(op(0x40), immediateMemoryIndex),
+
+	// Constants
+	"i32.const": with(op(0x41), immediateI32),
+	"i64.const": with(op(0x42), immediateI64),
+	"f64.const": with(op(0x44), immediateF64),
+
+	// i32
+	"i32.eqz":   op(0x45),
+	"i32.eq":    op(0x46),
+	"i32.ne":    op(0x47),
+	"i32.lt_s":  op(0x48),
+	"i32.lt_u":  op(0x49),
+	"i32.gt_s":  op(0x4a),
+	"i32.gt_u":  op(0x4b),
+	"i32.le_s":  op(0x4c),
+	"i32.le_u":  op(0x4d),
+	"i32.ge_s":  op(0x4e),
+	"i32.ge_u":  op(0x4f),
+	"i32.add":   op(0x6a),
+	"i32.sub":   op(0x6b),
+	"i32.mul":   op(0x6c),
+	"i32.and":   op(0x71),
+	"i32.or":    op(0x72),
+	"i32.xor":   op(0x73),
+	"i32.shl":   op(0x74),
+	"i32.shr_s": op(0x75),
+	"i32.shr_u": op(0x76),
+
+	// f64
+	"f64.eq":  op(0x61),
+	"f64.ne":  op(0x62),
+	"f64.lt":  op(0x63),
+	"f64.gt":  op(0x64),
+	"f64.le":  op(0x65),
+	"f64.ge":  op(0x66),
+	"f64.add": op(0xa0),
+	"f64.sub": op(0xa1),
+	"f64.mul": op(0xa2),
+	"f64.div": op(0xa3),
+
+	// Conversions
+	"i32.wrap_i64":      op(0xa7),
+	"i64.extend_i32_s":  op(0xac),
+	"f64.convert_i32_s": op(0xb7),
+
+	// SIMD
+	"v128.load":          access(simd(0), 4),
+	"v128.store":         access(simd(11), 4),
+	"i32x4.splat":        simd(17),
+	"i64x2.splat":        simd(18),
+	"f64x2.splat":        simd(20),
+	"i32x4.extract_lane": lane(simd(27), 4),
+	"i64x2.extract_lane": lane(simd(29), 2),
+	"i64x2.replace_lane": lane(simd(30), 2),
+	"f64x2.extract_lane": lane(simd(33), 2),
+	"f64x2.replace_lane": lane(simd(34), 2),
+	"f64x2.eq":           simd(71),
+	"f64x2.ne":           simd(72),
+	"f64x2.lt":           simd(73),
+	"f64x2.gt":           simd(74),
+	"f64x2.le":           simd(75),
+	"f64x2.ge":           simd(76),
+	"v128.not":           simd(77),
+	"v128.and":           simd(78),
+	"v128.andnot":        simd(79),
+	"v128.or":            simd(80),
+	"v128.xor":           simd(81),
+	"v128.bitselect":     simd(82),
+	"v128.any_true":      simd(83),
+	"i32x4.add":          simd(174),
+	"i32x4.sub":          simd(177),
+	"i64x2.add":          simd(206),
+	"i64x2.sub":          simd(209),
+	"f64x2.add":          simd(240),
+	"f64x2.sub":          simd(241),
+	"f64x2.mul":          simd(242),
+	"f64x2.div":          simd(243),
+}
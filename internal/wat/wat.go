@@ -0,0 +1,676 @@
+// Package wat assembles the small subset of the WebAssembly text format the
+// hand-written kernels in src are written in, so they build with the Go
+// toolchain alone. A module has memories and functions with inline exports;
+// function bodies are flat instruction sequences (no folded expressions)
+// over the numeric, memory, control and SIMD instructions in instructions.go.
+// Assemble checks names and syntax only: type errors are left to the engine
+// that validates the module.
+package wat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Assemble returns the binary encoding of the module in src.
+func Assemble(src []byte) ([]byte, error) {
+	tree, err := parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+	m, err := readModule(tree)
+	if err != nil {
+		return nil, err
+	}
+	return m.encode()
+}
+
+// Error is a problem with the text, at a line.
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wat: line %d: %s", e.Line, e.Message)
+}
+
+func errorf(line int, format string, args ...interface{}) error {
+	return &Error{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// ============================================================================
+// S-EXPRESSIONS
+// ============================================================================
+
+// sexpr is an atom (a keyword, name, number or string) or a list.
+type sexpr struct {
+	atom   string
+	list   []sexpr
+	isList bool
+	line   int
+}
+
+// parse reads src as one list, skipping ;; line and (; block ;) comments.
+func parse(src string) (sexpr, error) {
+	var stack [][]sexpr
+	var lines []int
+	var top []sexpr
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], ";;"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "(;"):
+			end := strings.Index(src[i:], ";)")
+			if end < 0 {
+				return sexpr{}, errorf(line, "unterminated block comment")
+			}
+			line += strings.Count(src[i:i+end], "\n")
+			i += end + 2
+		case c == '(':
+			stack = append(stack, top)
+			lines = append(lines, line)
+			top = nil
+			i++
+		case c == ')':
+			if len(stack) == 0 {
+				return sexpr{}, errorf(line, "unexpected )")
+			}
+			list := sexpr{list: top, isList: true, line: lines[len(lines)-1]}
+			top = append(stack[len(stack)-1], list)
+			stack, lines = stack[:len(stack)-1], lines[:len(lines)-1]
+			i++
+		case c == '"':
+			end := strings.IndexAny(src[i+1:], "\"\n")
+			if end < 0 || src[i+1+end] != '"' {
+				return sexpr{}, errorf(line, "unterminated string")
+			}
+			top = append(top, sexpr{atom: src[i : i+end+2], line: line})
+			i += end + 2
+		default:
+			start := i
+			for i < len(src) && !strings.ContainsRune(" \t\r\n()\";", rune(src[i])) {
+				i++
+			}
+			top = append(top, sexpr{atom: src[start:i], line: line})
+		}
+	}
+	if len(stack) > 0 {
+		return sexpr{}, errorf(lines[len(lines)-1], "unclosed (")
+	}
+	if len(top) != 1 || !top[0].isList {
+		return sexpr{}, errorf(1, "the text must be one (module ...)")
+	}
+	return top[0], nil
+}
+
+// keyword is the first atom of a list, or "".
+func (s sexpr) keyword() string {
+	if !s.isList || len(s.list) == 0 || s.list[0].isList {
+		return ""
+	}
+	return s.list[0].atom
+}
+
+// isName reports whether s is a $name.
+func (s sexpr) isName() bool {
+	return !s.isList && strings.HasPrefix(s.atom, "$")
+}
+
+// str is the content of a string atom. Escapes are not supported.
+func (s sexpr) str() (string, error) {
+	if s.isList || len(s.atom) < 2 || s.atom[0] != '"' {
+		return "", errorf(s.line, "expected a string")
+	}
+	text := s.atom[1 : len(s.atom)-1]
+	if strings.Contains(text, `\`) {
+		return "", errorf(s.line, "string escapes are not supported")
+	}
+	return text, nil
+}
+
+// ============================================================================
+// MODULE
+// ============================================================================
+
+// Value types.
+const (
+	typeI32  = 0x7f
+	typeI64  = 0x7e
+	typeF32  = 0x7d
+	typeF64  = 0x7c
+	typeV128 = 0x7b
+)
+
+var valueTypes = map[string]byte{"i32": typeI32, "i64": typeI64, "f32": typeF32, "f64": typeF64, "v128": typeV128}
+
+type module struct {
+	memories  []memory
+	functions []*function
+	funcIndex map[string]int
+}
+
+type memory struct {
+	min, max uint64
+	hasMax   bool
+	exports  []string
+	line     int
+}
+
+type function struct {
+	exports []string
+	params  []byte
+	results []byte
+	locals  []byte // after the params
+	names   map[string]int
+	body    []sexpr
+	line    int
+}
+
+// signature is the function's type, as the type section encodes it.
+func (f *function) signature() string {
+	return string(f.params) + ":" + string(f.results)
+}
+
+func readModule(tree sexpr) (*module, error) {
+	if tree.keyword() != "module" {
+		return nil, errorf(tree.line, "expected (module ...)")
+	}
+	m := &module{funcIndex: map[string]int{}}
+	for _, field := range tree.list[1:] {
+		switch field.keyword() {
+		case "memory":
+			mem, err := readMemory(field)
+			if err != nil {
+				return nil, err
+			}
+			m.memories = append(m.memories, mem)
+		case "func":
+			f, name, err := readFunction(field)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				if _, ok := m.funcIndex[name]; ok {
+					return nil, errorf(field.line, "function %s is defined twice", name)
+				}
+				m.funcIndex[name] = len(m.functions)
+			}
+			m.functions = append(m.functions, f)
+		default:
+			return nil, errorf(field.line, "unsupported module field %q", field.keyword())
+		}
+	}
+	if len(m.memories) > 1 {
+		return nil, errorf(tree.line, "at most one memory is supported")
+	}
+	return m, nil
+}
+
+// readExport returns the name of an (export "name") list.
+func readExport(s sexpr) (string, error) {
+	if len(s.list) != 2 {
+		return "", errorf(s.line, "expected (export \"name\")")
+	}
+	return s.list[1].str()
+}
+
+func readMemory(field sexpr) (memory, error) {
+	mem := memory{line: field.line}
+	var limits []uint64
+	for _, item := range field.list[1:] {
+		switch {
+		case item.isName():
+		case item.keyword() == "export":
+			name, err := readExport(item)
+			if err != nil {
+				return mem, err
+			}
+			mem.exports = append(mem.exports, name)
+		case !item.isList:
+			n, err := strconv.ParseUint(item.atom, 0, 32)
+			if err != nil {
+				return mem, errorf(item.line, "invalid memory limit %q", item.atom)
+			}
+			limits = append(limits, n)
+		default:
+			return mem, errorf(item.line, "unsupported memory field %q", item.keyword())
+		}
+	}
+	switch len(limits) {
+	case 2:
+		mem.max, mem.hasMax = limits[1], true
+		fallthrough
+	case 1:
+		mem.min = limits[0]
+	default:
+		return mem, errorf(field.line, "expected a memory's minimum and optional maximum pages")
+	}
+	return mem, nil
+}
+
+func readFunction(field sexpr) (*function, string, error) {
+	f := &function{names: map[string]int{}, line: field.line}
+	items := field.list[1:]
+	name := ""
+	if len(items) > 0 && items[0].isName() {
+		name, items = items[0].atom, items[1:]
+	}
+	declare := func(list sexpr, add func(byte)) error {
+		args := list.list[1:]
+		if len(args) == 2 && args[0].isName() {
+			if _, ok := f.names[args[0].atom]; ok {
+				return errorf(list.line, "%s is declared twice", args[0].atom)
+			}
+			f.names[args[0].atom] = len(f.params) + len(f.locals)
+			args = args[1:]
+		}
+		for _, arg := range args {
+			t, ok := valueTypes[arg.atom]
+			if arg.isList || !ok {
+				return errorf(arg.line, "invalid value type %q", arg.atom)
+			}
+			add(t)
+		}
+		return nil
+	}
+	for len(items) > 0 && items[0].isList {
+		list := items[0]
+		var err error
+		switch list.keyword() {
+		case "export":
+			var export string
+			export, err = readExport(list)
+			f.exports = append(f.exports, export)
+		case "param":
+			if len(f.results) > 0 || len(f.locals) > 0 {
+				return nil, "", errorf(list.line, "params must come before results and locals")
+			}
+			err = declare(list, func(t byte) { f.params = append(f.params, t) })
+		case "result":
+			if len(f.locals) > 0 || (len(list.list) > 1 && list.list[1].isName()) {
+				return nil, "", errorf(list.line, "invalid (result ...)")
+			}
+			err = declare(list, func(t byte) { f.results = append(f.results, t) })
+		case "local":
+			err = declare(list, func(t byte) { f.locals = append(f.locals, t) })
+		default:
+			return nil, "", errorf(list.line, "folded instructions are not supported")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		items = items[1:]
+	}
+	f.body = items
+	return f, name, nil
+}
+
+// ============================================================================
+// ENCODING
+// ============================================================================
+
+// Section IDs.
+const (
+	sectionType     = 1
+	sectionFunction = 3
+	sectionMemory   = 5
+	sectionExport   = 7
+	sectionCode     = 10
+)
+
+// Export kinds.
+const (
+	exportFunc   = 0x00
+	exportMemory = 0x02
+)
+
+func appendULEB(b []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendSLEB(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendBytes(b, data []byte) []byte {
+	return append(appendULEB(b, uint64(len(data))), data...)
+}
+
+func appendSection(b []byte, id byte, count int, content []byte) []byte {
+	if count == 0 {
+		return b
+	}
+	return appendBytes(append(b, id), append(appendULEB(nil, uint64(count)), content...))
+}
+
+func (m *module) encode() ([]byte, error) {
+	out := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+	var types, funcs []byte
+	typeIndex := map[string]int{}
+	for _, f := range m.functions {
+		index, ok := typeIndex[f.signature()]
+		if !ok {
+			index = len(typeIndex)
+			typeIndex[f.signature()] = index
+			types = append(types, 0x60)
+			types = appendBytes(types, f.params)
+			types = appendBytes(types, f.results)
+		}
+		funcs = appendULEB(funcs, uint64(index))
+	}
+	out = appendSection(out, sectionType, len(typeIndex), types)
+	out = appendSection(out, sectionFunction, len(m.functions), funcs)
+
+	var memories []byte
+	for _, mem := range m.memories {
+		if mem.hasMax {
+			memories = appendULEB(append(memories, 0x01), mem.min)
+			memories = appendULEB(memories, mem.max)
+		} else {
+			memories = appendULEB(append(memories, 0x00), mem.min)
+		}
+	}
+	out = appendSection(out, sectionMemory, len(m.memories), memories)
+
+	var exports []byte
+	exportCount := 0
+	seen := map[string]bool{}
+	export := func(name string, kind byte, index int, line int) error {
+		if seen[name] {
+			return errorf(line, "%q is exported twice", name)
+		}
+		seen[name] = true
+		exports = appendBytes(exports, []byte(name))
+		exports = appendULEB(append(exports, kind), uint64(index))
+		exportCount++
+		return nil
+	}
+	for i, mem := range m.memories {
+		for _, name := range mem.exports {
+			if err := export(name, exportMemory, i, mem.line); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, f := range m.functions {
+		for _, name := range f.exports {
+			if err := export(name, exportFunc, i, f.line); err != nil {
+				return nil, err
+			}
+		}
+	}
+	out = appendSection(out, sectionExport, exportCount, exports)
+
+	var code []byte
+	for _, f := range m.functions {
+		body, err := m.encodeBody(f)
+		if err != nil {
+			return nil, err
+		}
+		code = appendBytes(code, body)
+	}
+	out = appendSection(out, sectionCode, len(m.functions), code)
+	return out, nil
+}
+
+// encodeBody encodes f's locals, as runs of one type, and its instructions.
+func (m *module) encodeBody(f *function) ([]byte, error) {
+	var runs []byte
+	runCount := 0
+	for i := 0; i < len(f.locals); {
+		j := i
+		for j < len(f.locals) && f.locals[j] == f.locals[i] {
+			j++
+		}
+		runs = append(appendULEB(runs, uint64(j-i)), f.locals[i])
+		runCount++
+		i = j
+	}
+	body := append(appendULEB(nil, uint64(runCount)), runs...)
+
+	a := &assembler{module: m, function: f, out: body}
+	if err := a.instructions(f.body); err != nil {
+		return nil, err
+	}
+	if len(a.labels) > 0 {
+		return nil, errorf(f.line, "%d blocks are missing their end", len(a.labels))
+	}
+	return append(a.out, opEnd), nil
+}
+
+// assembler encodes a function body, tracking the enclosing blocks' labels
+// for branches.
+type assembler struct {
+	module   *module
+	function *function
+	labels   []string // innermost last; "" for unnamed blocks
+	out      []byte
+}
+
+func (a *assembler) instructions(items []sexpr) error {
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		if item.isList {
+			return errorf(item.line, "folded instructions are not supported")
+		}
+		instr, ok := instructions[item.atom]
+		if !ok {
+			return errorf(item.line, "unknown instruction %q", item.atom)
+		}
+		a.out = append(a.out, instr.code...)
+
+		// operand returns the next atom, which the instruction takes
+		operand := func() (sexpr, error) {
+			if i+1 >= len(items) || items[i+1].isList {
+				return sexpr{}, errorf(item.line, "%s is missing its operand", item.atom)
+			}
+			i++
+			return items[i], nil
+		}
+
+		switch instr.immediate {
+		case immediateNone:
+		case immediateLocal:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			index, err := a.index(arg, a.function.names, len(a.function.params)+len(a.function.locals), "local")
+			if err != nil {
+				return err
+			}
+			a.out = appendULEB(a.out, uint64(index))
+		case immediateFunc:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			index, err := a.index(arg, a.module.funcIndex, len(a.module.functions), "function")
+			if err != nil {
+				return err
+			}
+			a.out = appendULEB(a.out, uint64(index))
+		case immediateLabel:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			depth, err := a.labelDepth(arg)
+			if err != nil {
+				return err
+			}
+			a.out = appendULEB(a.out, uint64(depth))
+		case immediateBlock:
+			label := ""
+			if i+1 < len(items) && items[i+1].isName() {
+				i++
+				label = items[i].atom
+			}
+			blockType := byte(0x40)
+			if i+1 < len(items) && items[i+1].keyword() == "result" {
+				i++
+				result := items[i]
+				t, ok := byte(0), false
+				if len(result.list) == 2 && !result.list[1].isList {
+					t, ok = valueTypes[result.list[1].atom]
+				}
+				if !ok {
+					return errorf(result.line, "a block's (result ...) must be one value type")
+				}
+				blockType = t
+			}
+			a.labels = append(a.labels, label)
+			a.out = append(a.out, blockType)
+		case immediateElse:
+			if len(a.labels) == 0 {
+				return errorf(item.line, "else outside a block")
+			}
+		case immediateEnd:
+			if len(a.labels) == 0 {
+				return errorf(item.line, "end outside a block")
+			}
+			a.labels = a.labels[:len(a.labels)-1]
+			// A label may follow end, naming the block it closes
+			if i+1 < len(items) && items[i+1].isName() {
+				i++
+			}
+		case immediateI32, immediateI64:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			bits := 32
+			if instr.immediate == immediateI64 {
+				bits = 64
+			}
+			v, err := parseInt(arg.atom, bits)
+			if err != nil {
+				return errorf(arg.line, "invalid %s operand %q", item.atom, arg.atom)
+			}
+			a.out = appendSLEB(a.out, v)
+		case immediateF64:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			v, err := strconv.ParseFloat(strings.ReplaceAll(arg.atom, "_", ""), 64)
+			if err != nil {
+				return errorf(arg.line, "invalid f64.const operand %q", arg.atom)
+			}
+			a.out = binary.LittleEndian.AppendUint64(a.out, math.Float64bits(v))
+		case immediateMemory:
+			offset, align := uint64(0), instr.align
+			for i+1 < len(items) && !items[i+1].isList {
+				key, value, ok := strings.Cut(items[i+1].atom, "=")
+				if !ok || (key != "offset" && key != "align") {
+					break
+				}
+				i++
+				n, err := strconv.ParseUint(value, 0, 32)
+				if err != nil {
+					return errorf(items[i].line, "invalid %s", items[i].atom)
+				}
+				if key == "offset" {
+					offset = n
+					continue
+				}
+				if n == 0 || n&(n-1) != 0 || n > 1<<instr.align {
+					return errorf(items[i].line, "%s: alignment must be a power of two no larger than %d", item.atom, 1<<instr.align)
+				}
+				align = uint64(math.Log2(float64(n)))
+			}
+			a.out = appendULEB(appendULEB(a.out, align), offset)
+		case immediateLane:
+			arg, err := operand()
+			if err != nil {
+				return err
+			}
+			lane, err := strconv.ParseUint(arg.atom, 10, 8)
+			if err != nil || lane >= uint64(instr.lanes) {
+				return errorf(arg.line, "%s: invalid lane %q", item.atom, arg.atom)
+			}
+			a.out = append(a.out, byte(lane))
+		case immediateMemoryIndex:
+			a.out = append(a.out, 0x00)
+		}
+	}
+	return nil
+}
+
+// index resolves a $name or number among count items.
+func (a *assembler) index(arg sexpr, names map[string]int, count int, kind string) (int, error) {
+	if arg.isName() {
+		index, ok := names[arg.atom]
+		if !ok {
+			return 0, errorf(arg.line, "unknown %s %s", kind, arg.atom)
+		}
+		return index, nil
+	}
+	index, err := strconv.Atoi(arg.atom)
+	if err != nil || index < 0 || index >= count {
+		return 0, errorf(arg.line, "invalid %s index %q", kind, arg.atom)
+	}
+	return index, nil
+}
+
+// labelDepth resolves a branch target: a block's $label or a depth.
+func (a *assembler) labelDepth(arg sexpr) (int, error) {
+	if arg.isName() {
+		for depth := 0; depth < len(a.labels); depth++ {
+			if a.labels[len(a.labels)-1-depth] == arg.atom {
+				return depth, nil
+			}
+		}
+		return 0, errorf(arg.line, "unknown label %s", arg.atom)
+	}
+	depth, err := strconv.Atoi(arg.atom)
+	if err != nil || depth < 0 || depth >= len(a.labels) {
+		return 0, errorf(arg.line, "invalid branch depth %q", arg.atom)
+	}
+	return depth, nil
+}
+
+// parseInt parses a decimal or 0x hexadecimal integer operand, which may be
+// negative or, as in the text format, the unsigned bit pattern.
+func parseInt(text string, bits int) (int64, error) {
+	text = strings.ReplaceAll(text, "_", "")
+	if v, err := strconv.ParseInt(text, 0, bits); err == nil {
+		return v, nil
+	}
+	u, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 0, bits)
+	if err != nil {
+		return 0, err
+	}
+	if bits == 32 {
+		return int64(int32(uint32(u))), nil
+	}
+	return int64(u), nil
+}
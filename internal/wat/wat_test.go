@@ -0,0 +1,129 @@
+package wat
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssemble(t *testing.T) {
+	src := `
+(module
+  ;; A comment (; and a block comment ;)
+  (memory (export "mem") 1 2)
+  (func $add (export "add") (param $x i32) (param i32) (result i32)
+    (local $sum i32) (local f64) (local f64)
+    local.get $x
+    local.get 1
+    i32.add
+    local.tee $sum
+    i32.const -129
+    drop
+    drop
+    local.get $sum
+  )
+  (func (export "loop") (param $p i32)
+    block $done
+      loop $again
+        local.get $p
+        br_if $done
+        br 0
+      end $again
+    end
+    local.get $p
+    v128.load offset=16 align=8
+    f64x2.extract_lane 1
+    call $add
+    drop
+  )
+)`
+	got, err := Assemble([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00,
+		// Types: (i32 i32) -> i32, (i32) -> ()
+		0x01, 0x0b, 0x02, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x00,
+		// Functions
+		0x03, 0x03, 0x02, 0x00, 0x01,
+		// Memory: 1 to 2 pages
+		0x05, 0x04, 0x01, 0x01, 0x01, 0x02,
+		// Exports
+		0x07, 0x14, 0x03,
+		0x03, 'm', 'e', 'm', 0x02, 0x00,
+		0x03, 'a', 'd', 'd', 0x00, 0x00,
+		0x04, 'l', 'o', 'o', 'p', 0x00, 0x01,
+		// Code
+		0x0a, 0x31, 0x02,
+		0x14, 0x02, 0x01, 0x7f, 0x02, 0x7c,
+		0x20, 0x00, 0x20, 0x01, 0x6a, 0x22, 0x02, 0x41, 0xff, 0x7e, 0x1a, 0x1a, 0x20, 0x02, 0x0b,
+		0x1a, 0x00,
+		0x02, 0x40, 0x03, 0x40, 0x20, 0x00, 0x0d, 0x01, 0x0c, 0x00, 0x0b, 0x0b,
+		0x20, 0x00, 0xfd, 0x00, 0x03, 0x10, 0xfd, 0x21, 0x01, 0x10, 0x00, 0x1a, 0x0b,
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Assemble =\n% x\nwant\n% x", got, want)
+	}
+}
+
+func TestAssembleConstants(t *testing.T) {
+	for _, tc := range []struct {
+		instr string
+		want  []byte
+	}{
+		{"i32.const 0", []byte{0x41, 0x00}},
+		{"i32.const 63", []byte{0x41, 0x3f}},
+		{"i32.const 64", []byte{0x41, 0xc0, 0x00}},
+		{"i32.const -1", []byte{0x41, 0x7f}},
+		{"i32.const 0xffffffff", []byte{0x41, 0x7f}},
+		{"i32.const 1_000", []byte{0x41, 0xe8, 0x07}},
+		{"i64.const -9223372036854775808", []byte{0x42, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}},
+		{"f64.const 2", []byte{0x44, 0, 0, 0, 0, 0, 0, 0, 0x40}},
+		{"f64.const -0.5", []byte{0x44, 0, 0, 0, 0, 0, 0, 0xe0, 0xbf}},
+	} {
+		got, err := Assemble([]byte("(module (func " + tc.instr + " drop))"))
+		if err != nil {
+			t.Errorf("%s: %v", tc.instr, err)
+			continue
+		}
+		// The body follows its size, the local count and ends with drop, end
+		body := got[len(got)-len(tc.want)-2:]
+		if !bytes.Equal(body[:len(tc.want)], tc.want) {
+			t.Errorf("%s = % x, want % x", tc.instr, body[:len(tc.want)], tc.want)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src, want string
+	}{
+		{"(module", "line 1: unclosed ("},
+		{"(module))", "line 1: unexpected )"},
+		{"(module (table 1))", `unsupported module field "table"`},
+		{"(module (memory))", "minimum and optional maximum"},
+		{"(module\n(func\ni32.frob))", `line 3: unknown instruction "i32.frob"`},
+		{"(module (func local.get $x drop))", "unknown local $x"},
+		{"(module (func (param $x i32) (local $x i32)))", "$x is declared twice"},
+		{"(module (func block br $out end))", "unknown label $out"},
+		{"(module (func br 0))", `invalid branch depth "0"`},
+		{"(module (func block))", "1 blocks are missing their end"},
+		{"(module (func end))", "end outside a block"},
+		{"(module (func (i32.add (i32.const 1) (i32.const 2))))", "folded instructions are not supported"},
+		{"(module (func i32.const))", "i32.const is missing its operand"},
+		{"(module (func i32.const 1x drop))", `invalid i32.const operand "1x"`},
+		{"(module (func (param i32) local.get 0 i32.load align=8 drop))", "alignment must be a power of two no larger than 4"},
+		{"(module (func v128.load f64x2.extract_lane 2 drop))", `invalid lane "2"`},
+		{"(module (func (export \"f\")) (func (export \"f\")))", `"f" is exported twice`},
+		{"(module (func $f) (func $f))", "function $f is defined twice"},
+		{"(module (func (export \"a\n\")))", "line 1: unterminated string"},
+	} {
+		_, err := Assemble([]byte(tc.src))
+		var watErr *Error
+		if err == nil || !errors.As(err, &watErr) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Assemble(%q) error = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
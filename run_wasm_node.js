@@ -0,0 +1,35 @@
+// Runs the WebAssembly module under Node.js: the module reads a job spec
+// from --job (or stdin), calls the functions it names and prints the
+// results as JSON.
+//
+//   node run_wasm_node.js --job '{"function": "validateUserWasm", "args": ["{\"name\": \"Ann\"}"]}'
+//   echo '[{"function": "mandelbrotWasm", "args": [64, 64, -2, 1, -1.5, 1.5, 50]}]' | node run_wasm_node.js
+//
+// WASM_MODULE picks another module, such as main-tiny.wasm.
+"use strict";
+
+const fs = require("fs");
+const path = require("path");
+
+globalThis.require = require;
+globalThis.fs = fs;
+globalThis.path = path;
+globalThis.TextEncoder = require("util").TextEncoder;
+globalThis.TextDecoder = require("util").TextDecoder;
+globalThis.performance ??= require("perf_hooks").performance;
+globalThis.crypto ??= require("crypto");
+
+require(process.env.WASM_EXEC || path.join(__dirname, "wasm_exec.js"));
+
+const wasmPath = process.env.WASM_MODULE || path.join(__dirname, "main.wasm");
+const go = new Go();
+go.argv = [wasmPath, ...process.argv.slice(2)];
+go.env = Object.assign({ TMPDIR: require("os").tmpdir() }, process.env);
+go.exit = process.exit;
+
+WebAssembly.instantiate(fs.readFileSync(wasmPath), go.importObject)
+  .then((result) => go.run(result.instance))
+  .catch((err) => {
+    console.error(err);
+    process.exit(1);
+  });
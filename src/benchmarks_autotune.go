@@ -0,0 +1,221 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+// ============================================================================
+// KERNEL AUTO-TUNING
+// The best block sizes of the blocked matrix kernel and the tile size of
+// the optimized Mandelbrot depend on the device's caches. The first call
+// that needs them times each candidate on a small problem and keeps the
+// fastest. The choice is saved in localStorage, where there is one, so
+// later page loads on the same device skip the timing. autoTuneWasm reports
+// the sizes, and times them again on request.
+// ============================================================================
+
+// kernelTuning is the block and tile sizes the kernels use.
+type kernelTuning struct {
+	MatrixOuterBlock int `json:"matrixOuterBlock"`
+	MatrixInnerBlock int `json:"matrixInnerBlock"`
+	MandelbrotTile   int `json:"mandelbrotTile"`
+}
+
+// Where a kernelTuning came from.
+const (
+	tuningMeasured = "measured"
+	tuningStored   = "stored"
+)
+
+// kernelTuningStorageKey is the localStorage key of the saved tuning. Its
+// version changes with the candidates, so a device doesn't keep sizes
+// chosen among others.
+const kernelTuningStorageKey = "go-wasm-demo.kernel-tuning.v1"
+
+// The candidate sizes, all valid for matrixMultiplyBlockedSizes.
+var (
+	matrixOuterBlockCandidates = []int{32, 64, 128}
+	matrixInnerBlockCandidates = []int{4, 8, 16}
+	mandelbrotTileCandidates   = []int{16, 32, 64, 128}
+)
+
+// The problems the candidates are timed on: big enough that the blocks
+// matter, small enough that tuning takes a fraction of a second.
+const (
+	autoTuneMatrixSize       = 128
+	autoTuneMandelbrotWidth  = 256
+	autoTuneMandelbrotHeight = 192
+	autoTuneMandelbrotIter   = 64
+	autoTuneRuns             = 3
+)
+
+// tunedKernels is the tuning in use, once the first use has found it.
+var tunedKernels struct {
+	sync.Mutex
+	params kernelTuning
+	source string // empty until tuned
+}
+
+// currentKernelTuning returns the tuning in use, loading or measuring it
+// the first time.
+func currentKernelTuning() kernelTuning {
+	tunedKernels.Lock()
+	defer tunedKernels.Unlock()
+
+	if tunedKernels.source == "" {
+		if params, ok := loadKernelTuning(); ok {
+			tunedKernels.params, tunedKernels.source = params, tuningStored
+		} else {
+			tunedKernels.params, tunedKernels.source = autoTuneKernels(), tuningMeasured
+			saveKernelTuning(tunedKernels.params)
+		}
+	}
+	return tunedKernels.params
+}
+
+// retuneKernels measures the tuning again, saves it and uses it from then on.
+func retuneKernels() kernelTuning {
+	tunedKernels.Lock()
+	defer tunedKernels.Unlock()
+
+	tunedKernels.params, tunedKernels.source = autoTuneKernels(), tuningMeasured
+	saveKernelTuning(tunedKernels.params)
+	return tunedKernels.params
+}
+
+// autoTuneKernels times every candidate and returns the fastest ones.
+func autoTuneKernels() kernelTuning {
+	var params kernelTuning
+
+	a := make([]float64, autoTuneMatrixSize*autoTuneMatrixSize)
+	b := make([]float64, len(a))
+	for i := range a {
+		a[i] = float64(i % 10)
+		b[i] = float64((i * 2) % 10)
+	}
+	result := make([]float64, len(a))
+	best := time.Duration(-1)
+	for _, outer := range matrixOuterBlockCandidates {
+		for _, inner := range matrixInnerBlockCandidates {
+			elapsed := fastestRun(func() {
+				clear(result)
+				matrixMultiplyBlockedSizes(a, b, result, autoTuneMatrixSize, outer, inner)
+			})
+			if best < 0 || elapsed < best {
+				best, params.MatrixOuterBlock, params.MatrixInnerBlock = elapsed, outer, inner
+			}
+		}
+	}
+
+	const width, height = autoTuneMandelbrotWidth, autoTuneMandelbrotHeight
+	counts := make([]int32, width*height)
+	best = -1
+	for _, tile := range mandelbrotTileCandidates {
+		elapsed := fastestRun(func() {
+			mandelbrotTiled(counts, width, height, autoTuneMandelbrotIter, -2.5, -1.5, 4.0/width, 3.0/height, tile)
+		})
+		if best < 0 || elapsed < best {
+			best, params.MandelbrotTile = elapsed, tile
+		}
+	}
+	return params
+}
+
+// fastestRun is the shortest of autoTuneRuns runs of run.
+func fastestRun(run func()) time.Duration {
+	fastest := time.Duration(-1)
+	for i := 0; i < autoTuneRuns; i++ {
+		start := time.Now()
+		run()
+		if elapsed := time.Since(start); fastest < 0 || elapsed < fastest {
+			fastest = elapsed
+		}
+	}
+	return fastest
+}
+
+// valid reports whether every size is one of the candidates.
+func (t kernelTuning) valid() bool {
+	return slices.Contains(matrixOuterBlockCandidates, t.MatrixOuterBlock) &&
+		slices.Contains(matrixInnerBlockCandidates, t.MatrixInnerBlock) &&
+		slices.Contains(mandelbrotTileCandidates, t.MandelbrotTile)
+}
+
+// kernelTuningStorage returns localStorage, or false where there is none,
+// as under Node.js.
+func kernelTuningStorage() (js.Value, bool) {
+	storage := js.Global().Get("localStorage")
+	return storage, storage.Type() == js.TypeObject
+}
+
+// loadKernelTuning returns the tuning saved on this device, if there is a
+// valid one. Browsers throw from localStorage when storage is blocked,
+// which counts as nothing saved.
+func loadKernelTuning() (params kernelTuning, ok bool) {
+	storage, found := kernelTuningStorage()
+	if !found {
+		return kernelTuning{}, false
+	}
+	defer func() {
+		if recover() != nil {
+			params, ok = kernelTuning{}, false
+		}
+	}()
+
+	saved := storage.Call("getItem", kernelTuningStorageKey)
+	if saved.Type() != js.TypeString {
+		return kernelTuning{}, false
+	}
+	if err := json.Unmarshal([]byte(saved.String()), &params); err != nil || !params.valid() {
+		return kernelTuning{}, false
+	}
+	return params, true
+}
+
+// saveKernelTuning saves the tuning on this device, where it can.
+func saveKernelTuning(params kernelTuning) {
+	storage, found := kernelTuningStorage()
+	if !found {
+		return
+	}
+	defer func() { recover() }()
+
+	encoded, _ := json.Marshal(params)
+	storage.Call("setItem", kernelTuningStorageKey, string(encoded))
+}
+
+// WebAssembly wrapper reporting the block and tile sizes the kernels use,
+// and whether they were measured or saved by an earlier visit. Tunes them
+// on the first call, like the first kernel call; passing true times them
+// again.
+func autoTuneWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) > 1 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected nothing, or true to tune again",
+		}
+	}
+
+	var params kernelTuning
+	if len(args) == 1 && args[0].Truthy() {
+		params = retuneKernels()
+	} else {
+		params = currentKernelTuning()
+	}
+
+	tunedKernels.Lock()
+	source := tunedKernels.source
+	tunedKernels.Unlock()
+	return map[string]interface{}{
+		"error":            "",
+		"matrixOuterBlock": params.MatrixOuterBlock,
+		"matrixInnerBlock": params.MatrixInnerBlock,
+		"mandelbrotTile":   params.MandelbrotTile,
+		"source":           source,
+	}
+}
@@ -0,0 +1,116 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"slices"
+	"syscall/js"
+	"testing"
+)
+
+// resetKernelTuning forgets the tuning in use, as a new page load would.
+func resetKernelTuning() {
+	tunedKernels.Lock()
+	tunedKernels.params, tunedKernels.source = kernelTuning{}, ""
+	tunedKernels.Unlock()
+}
+
+// fakeLocalStorage installs a localStorage backed by a map for the test.
+func fakeLocalStorage(t *testing.T) map[string]string {
+	items := map[string]string{}
+	getItem := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if value, ok := items[args[0].String()]; ok {
+			return value
+		}
+		return nil
+	})
+	setItem := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		items[args[0].String()] = args[1].String()
+		return nil
+	})
+	storage := js.Global().Get("Object").New()
+	storage.Set("getItem", getItem)
+	storage.Set("setItem", setItem)
+	js.Global().Set("localStorage", storage)
+	t.Cleanup(func() {
+		js.Global().Delete("localStorage")
+		getItem.Release()
+		setItem.Release()
+		resetKernelTuning()
+	})
+	return items
+}
+
+func TestAutoTuneKernelsMatchForEverySize(t *testing.T) {
+	// Odd sizes end in partial blocks of every size
+	for _, size := range []int{7, 70, 130} {
+		a := make([]float64, size*size)
+		b := make([]float64, size*size)
+		for i := range a {
+			a[i] = float64(i%9) / 7
+			b[i] = float64((i*5)%13) * 1.3
+		}
+		want := naiveMultiply(a, b, size)
+		for _, outer := range matrixOuterBlockCandidates {
+			for _, inner := range matrixInnerBlockCandidates {
+				got := make([]float64, size*size)
+				matrixMultiplyBlockedSizes(a, b, got, size, outer, inner)
+				if !slices.Equal(got, want) {
+					t.Errorf("size %d with %d/%d blocks differs from the naive product", size, outer, inner)
+				}
+			}
+		}
+	}
+
+	const width, height = 150, 70
+	want := make([]int32, width*height)
+	mandelbrotChunkV2(mandelbrotChunk{startY: 0, endY: height}, want, width, 3.0/width, 2.0/height, -2, -1, 60, false)
+	for _, tile := range mandelbrotTileCandidates {
+		got := make([]int32, width*height)
+		mandelbrotTiled(got, width, height, 60, -2, -1, 3.0/width, 2.0/height, tile)
+		if !slices.Equal(got, want) {
+			t.Errorf("mandelbrotTiled with %d tiles differs from mandelbrotChunkV2", tile)
+		}
+	}
+}
+
+func TestAutoTuneStored(t *testing.T) {
+	resetKernelTuning()
+	items := fakeLocalStorage(t)
+
+	// The first use measures and saves the tuning
+	first := autoTuneWasm(js.Null(), nil).(map[string]interface{})
+	if first["source"] != tuningMeasured {
+		t.Fatalf("first tuning = %v, want it measured", first)
+	}
+	if !currentKernelTuning().valid() {
+		t.Errorf("tuning %+v isn't among the candidates", currentKernelTuning())
+	}
+	if items[kernelTuningStorageKey] == "" {
+		t.Fatal("Expected the tuning saved in localStorage")
+	}
+
+	// A new page load reads it back
+	items[kernelTuningStorageKey] = `{"matrixOuterBlock":32,"matrixInnerBlock":16,"mandelbrotTile":128}`
+	resetKernelTuning()
+	want := kernelTuning{MatrixOuterBlock: 32, MatrixInnerBlock: 16, MandelbrotTile: 128}
+	if got := currentKernelTuning(); got != want {
+		t.Errorf("stored tuning = %+v, want %+v", got, want)
+	}
+	if stored := autoTuneWasm(js.Null(), nil).(map[string]interface{}); stored["source"] != tuningStored || stored["matrixOuterBlock"] != 32 {
+		t.Errorf("autoTuneWasm = %v, want the stored tuning", stored)
+	}
+
+	// Sizes that aren't candidates are measured again, as is asking for it
+	items[kernelTuningStorageKey] = `{"matrixOuterBlock":30,"matrixInnerBlock":16,"mandelbrotTile":128}`
+	resetKernelTuning()
+	if got := currentKernelTuning(); !got.valid() {
+		t.Errorf("tuning = %+v after an invalid stored one", got)
+	}
+	if retuned := autoTuneWasm(js.Null(), []js.Value{js.ValueOf(true)}).(map[string]interface{}); retuned["source"] != tuningMeasured {
+		t.Errorf("autoTuneWasm(true) = %v, want it measured", retuned)
+	}
+	if result := autoTuneWasm(js.Null(), []js.Value{js.ValueOf(true), js.ValueOf(1)}).(map[string]interface{}); result["error"] == "" {
+		t.Error("Expected an error for two arguments")
+	}
+}
@@ -0,0 +1,145 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// ============================================================================
+// BOUNDARY OVERHEAD CALIBRATION
+// Times the ways Go and JavaScript exchange values, at several sizes, so the
+// pages can tell how much of a benchmark's time is spent crossing the
+// boundary rather than computing: a single-threaded benchmark that reads
+// n² elements with Index spends about n² times the Index cost doing so.
+// ============================================================================
+
+// boundaryDefaultSizes are the element counts timed when none are given.
+var boundaryDefaultSizes = []int{1, 100, 10_000, 100_000}
+
+// boundaryMaxSize is the largest element count boundaryOverheadWasm times.
+const boundaryMaxSize = 1 << 20
+
+// boundaryMinDuration is how long each measurement repeats its operation
+// for, at least, so that small sizes take more than the timer's resolution.
+const boundaryMinDuration = 5 * time.Millisecond
+
+// boundaryMaxRepeats bounds the repeats of one measurement.
+const boundaryMaxRepeats = 10_000
+
+// boundaryOperations are the operations timed, in report order. Each takes
+// the element count and returns the function to time.
+var boundaryOperations = []struct {
+	name  string
+	setup func(size int) func()
+}{
+	{"Index", func(size int) func() {
+		array := js.Global().Get("Array").Call("from", copyToJSTypedArray(make([]float64, size)))
+		return func() {
+			for i := 0; i < size; i++ {
+				array.Index(i).Float()
+			}
+		}
+	}},
+	{"SetIndex", func(size int) func() {
+		array := js.Global().Get("Array").New(size)
+		return func() {
+			for i := 0; i < size; i++ {
+				array.SetIndex(i, float64(i))
+			}
+		}
+	}},
+	{"CopyBytesToJS", func(size int) func() {
+		data := make([]float64, size)
+		bytes := js.Global().Get("Uint8Array").New(size * 8)
+		return func() { js.CopyBytesToJS(bytes, sliceBytes(data)) }
+	}},
+	{"CopyBytesToGo", func(size int) func() {
+		data := make([]float64, size)
+		bytes := js.Global().Get("Uint8Array").New(size * 8)
+		return func() { js.CopyBytesToGo(sliceBytes(data), bytes) }
+	}},
+	{"String", func(size int) func() {
+		value := js.ValueOf(strings.Repeat("x", size))
+		return func() { _ = value.String() }
+	}},
+	{"ValueOf", func(size int) func() {
+		text := strings.Repeat("x", size)
+		return func() { js.ValueOf(text) }
+	}},
+}
+
+// timeBoundaryOperation runs fn repeatedly for at least
+// boundaryMinDuration, or boundaryMaxRepeats times, and returns the time per
+// run.
+func timeBoundaryOperation(fn func()) time.Duration {
+	fn() // warm up
+	start := time.Now()
+	repeats := 0
+	for repeats < boundaryMaxRepeats {
+		fn()
+		repeats++
+		if time.Since(start) >= boundaryMinDuration {
+			break
+		}
+	}
+	return time.Since(start) / time.Duration(repeats)
+}
+
+// WebAssembly wrapper for the boundary calibration. Takes an optional array
+// of element counts and returns, for each operation and count, the time of
+// one call (Index and SetIndex are called once per element, the others once
+// with all of them) in milliseconds and per element in nanoseconds.
+// CopyBytesToJS and CopyBytesToGo copy that many float64s; String and
+// ValueOf convert a string of that many characters.
+func boundaryOverheadWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) > 1 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected an optional array of sizes",
+		}
+	}
+
+	sizes := boundaryDefaultSizes
+	if len(args) == 1 && !args[0].IsUndefined() && !args[0].IsNull() {
+		if !args[0].InstanceOf(js.Global().Get("Array")) || args[0].Length() == 0 {
+			return map[string]interface{}{
+				"error": "sizes must be a non-empty array",
+			}
+		}
+		sizes = make([]int, args[0].Length())
+		for i := range sizes {
+			sizes[i] = args[0].Index(i).Int()
+			if sizes[i] < 1 || sizes[i] > boundaryMaxSize {
+				return map[string]interface{}{
+					"error": fmt.Sprintf("sizes must be between 1 and %d", boundaryMaxSize),
+				}
+			}
+		}
+	}
+
+	results := []interface{}{}
+	for _, operation := range boundaryOperations {
+		for _, size := range sizes {
+			perCall := timeBoundaryOperation(operation.setup(size))
+			results = append(results, map[string]interface{}{
+				"operation":    operation.name,
+				"size":         size,
+				"callMs":       float64(perCall.Nanoseconds()) / 1e6,
+				"nsPerElement": float64(perCall.Nanoseconds()) / float64(size),
+			})
+		}
+	}
+
+	sizeValues := make([]interface{}, len(sizes))
+	for i, size := range sizes {
+		sizeValues[i] = size
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"sizes":   sizeValues,
+		"results": results,
+	}
+}
@@ -0,0 +1,45 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestBoundaryOverhead(t *testing.T) {
+	sizes := js.ValueOf([]interface{}{1, 1000})
+	report := boundaryOverheadWasm(js.Null(), []js.Value{sizes}).(map[string]interface{})
+	if report["error"] != "" {
+		t.Fatalf("error = %v", report["error"])
+	}
+	results := report["results"].([]interface{})
+	if len(results) != len(boundaryOperations)*2 {
+		t.Fatalf("%d results, want %d", len(results), len(boundaryOperations)*2)
+	}
+	for i, r := range results {
+		result := r.(map[string]interface{})
+		if want := boundaryOperations[i/2].name; result["operation"] != want {
+			t.Errorf("result %d is %v, want %s", i, result["operation"], want)
+		}
+		if callMs, perElement := result["callMs"].(float64), result["nsPerElement"].(float64); callMs <= 0 || perElement <= 0 {
+			t.Errorf("%v at %v: callMs %v, nsPerElement %v", result["operation"], result["size"], callMs, perElement)
+		}
+	}
+	// The report converts to a JavaScript object
+	if js.ValueOf(report).Get("results").Length() != len(results) {
+		t.Error("Expected the report to convert to a JavaScript object")
+	}
+
+	for _, bad := range [][]js.Value{
+		{js.ValueOf([]interface{}{})},
+		{js.ValueOf([]interface{}{0})},
+		{js.ValueOf([]interface{}{boundaryMaxSize + 1})},
+		{js.ValueOf(5)},
+		{js.ValueOf(1), js.ValueOf(2)},
+	} {
+		if result := boundaryOverheadWasm(js.Null(), bad).(map[string]interface{}); result["error"] == "" {
+			t.Errorf("Expected an error for %v", bad)
+		}
+	}
+}
@@ -1,12 +1,12 @@
-//go:build js && wasm
+//go:build js && wasm && !tinygo && !logic
 
 package main
 
 import (
 	"math"
-	"runtime"
-	"sync"
 	"syscall/js"
+
+	"go-wasm-demo/internal/benchkernels"
 )
 
 // ============================================================================
@@ -53,6 +53,14 @@ func matrixMultiplyWasmSingle(this js.Value, args []js.Value) interface{} {
 	return jsArray
 }
 
+// mandelbrotShortcutsArg is the optional argument after the iteration
+// count: whether to take benchkernels.MandelbrotEscape's shortcuts for points
+// that never escape. It is true unless false is passed, as the pages'
+// comparisons with the JavaScript version do.
+func mandelbrotShortcutsArg(args []js.Value) bool {
+	return len(args) < 8 || args[7].IsUndefined() || args[7].Truthy()
+}
+
 // Single-threaded Mandelbrot
 func mandelbrotWasmSingle(this js.Value, args []js.Value) interface{} {
 	if len(args) < 6 {
@@ -69,6 +77,7 @@ func mandelbrotWasmSingle(this js.Value, args []js.Value) interface{} {
 	if len(args) > 6 {
 		maxIter = args[6].Int()
 	}
+	shortcuts := mandelbrotShortcutsArg(args)
 
 	dx := (xmax - xmin) / float64(width)
 	dy := (ymax - ymin) / float64(height)
@@ -81,29 +90,13 @@ func mandelbrotWasmSingle(this js.Value, args []js.Value) interface{} {
 
 		for px := 0; px < width; px++ {
 			cx := xmin + float64(px)*dx
-
-			zx, zy := 0.0, 0.0
-			iter := int32(0)
-
-			for iter < int32(maxIter) {
-				zx2 := zx * zx
-				zy2 := zy * zy
-				if zx2+zy2 > 4.0 {
-					break
-				}
-				temp := zx2 - zy2 + cx
-				zy = 2*zx*zy + cy
-				zx = temp
-				iter++
-			}
-
-			result[idx] = iter
+			result[idx] = int32(benchkernels.MandelbrotEscape(cx, cy, maxIter, shortcuts))
 			idx++
 		}
 	}
 
 	// Use shared conversion function for efficient result conversion
-	return createInt32TypedArray(result)
+	return copyToJSTypedArray(result)
 }
 
 // Single-threaded hash computation
@@ -139,7 +132,7 @@ func rayTracingWasmSingle(this js.Value, args []js.Value) interface{} {
 	result := rayTracingSharedSingle(width, height, samples)
 
 	// Return result using shared conversion function
-	return createFloat64TypedArray(result)
+	return copyToJSTypedArray(result)
 }
 
 // Note: fastSqrt function removed - replaced with math.Sqrt() for better performance
@@ -170,10 +163,7 @@ func matrixMultiplyWasmConcurrentV2(this js.Value, args []js.Value) interface{}
 	result := make([]float64, size*size)
 
 	// Adaptive worker count based on problem size
-	numWorkers := runtime.GOMAXPROCS(0)
-	if numWorkers < 1 {
-		numWorkers = 4
-	}
+	numWorkers := benchmarkPool.size
 
 	// Adjust for small matrices
 	if size < 100 {
@@ -191,25 +181,14 @@ func matrixMultiplyWasmConcurrentV2(this js.Value, args []js.Value) interface{}
 		chunkSize = 64 // Cache-friendly chunk size
 	}
 
-	workChan := make(chan matrixWorkChunk, numWorkers*2)
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go matrixMultiplyChunkWorker(workChan, &wg, goMatrixA, goMatrixB, result, size)
+	// Generate work chunks for the pool
+	var chunks []matrixWorkChunk
+	for row := 0; row < size; row += chunkSize {
+		chunks = append(chunks, matrixWorkChunk{startRow: row, endRow: minInt(row+chunkSize, size)})
 	}
-
-	// Generate work chunks
-	go func() {
-		defer close(workChan)
-		for row := 0; row < size; row += chunkSize {
-			endRow := minInt(row+chunkSize, size)
-			workChan <- matrixWorkChunk{startRow: row, endRow: endRow}
-		}
-	}()
-
-	wg.Wait()
+	runPooled(benchmarkPool, chunks, func(chunk matrixWorkChunk) {
+		matrixMultiplyChunk(chunk, goMatrixA, goMatrixB, result, size)
+	})
 
 	// Convert result
 	jsArray := js.Global().Get("Array").New(size * size)
@@ -220,23 +199,19 @@ func matrixMultiplyWasmConcurrentV2(this js.Value, args []js.Value) interface{}
 	return jsArray
 }
 
-func matrixMultiplyChunkWorker(workChan <-chan matrixWorkChunk, wg *sync.WaitGroup, matrixA, matrixB, result []float64, size int) {
-	defer wg.Done()
+func matrixMultiplyChunk(chunk matrixWorkChunk, matrixA, matrixB, result []float64, size int) {
+	// Process chunk of rows
+	for i := chunk.startRow; i < chunk.endRow; i++ {
+		rowOffset := i * size
 
-	for chunk := range workChan {
-		// Process chunk of rows
-		for i := chunk.startRow; i < chunk.endRow; i++ {
-			rowOffset := i * size
-
-			// Cache-optimized computation
-			for k := 0; k < size; k++ {
-				aik := matrixA[rowOffset+k]
-				bRowOffset := k * size
+		// Cache-optimized computation
+		for k := 0; k < size; k++ {
+			aik := matrixA[rowOffset+k]
+			bRowOffset := k * size
 
-				// Vectorizable inner loop
-				for j := 0; j < size; j++ {
-					result[rowOffset+j] += aik * matrixB[bRowOffset+j]
-				}
+			// Vectorizable inner loop
+			for j := 0; j < size; j++ {
+				result[rowOffset+j] += aik * matrixB[bRowOffset+j]
 			}
 		}
 	}
@@ -258,16 +233,14 @@ func mandelbrotWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 	if len(args) > 6 {
 		maxIter = args[6].Int()
 	}
+	shortcuts := mandelbrotShortcutsArg(args)
 
 	dx := (xmax - xmin) / float64(width)
 	dy := (ymax - ymin) / float64(height)
 	pixels := width * height
 	result := make([]int32, pixels)
 
-	numWorkers := runtime.GOMAXPROCS(0)
-	if numWorkers < 1 {
-		numWorkers = 4
-	}
+	numWorkers := benchmarkPool.size
 
 	// Adaptive chunk size for better load balancing
 	totalChunks := numWorkers * 8 // More chunks than workers for better load distribution
@@ -277,62 +250,27 @@ func mandelbrotWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 		totalChunks = height
 	}
 
-	workChan := make(chan mandelbrotChunk, totalChunks)
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go mandelbrotChunkWorkerV2(workChan, &wg, result, width, dx, dy, xmin, ymin, maxIter)
+	// Generate work chunks for the pool
+	chunks := make([]mandelbrotChunk, 0, totalChunks)
+	for y := 0; y < height; y += chunkHeight {
+		chunks = append(chunks, mandelbrotChunk{startY: y, endY: minInt(y+chunkHeight, height)})
 	}
-
-	// Generate work chunks
-	go func() {
-		defer close(workChan)
-		for y := 0; y < height; y += chunkHeight {
-			endY := minInt(y+chunkHeight, height)
-			workChan <- mandelbrotChunk{startY: y, endY: endY}
-		}
-	}()
-
-	wg.Wait()
+	runPooled(benchmarkPool, chunks, func(chunk mandelbrotChunk) {
+		mandelbrotChunkV2(chunk, result, width, dx, dy, xmin, ymin, maxIter, shortcuts)
+	})
 
 	// Use shared conversion function for efficient result conversion
-	return createInt32TypedArray(result)
+	return copyToJSTypedArray(result)
 }
 
-func mandelbrotChunkWorkerV2(workChan <-chan mandelbrotChunk, wg *sync.WaitGroup, result []int32, width int, dx, dy, xmin, ymin float64, maxIter int) {
-	defer wg.Done()
-
-	for chunk := range workChan {
-		for py := chunk.startY; py < chunk.endY; py++ {
-			cy := ymin + float64(py)*dy
-			rowOffset := py * width
-
-			for px := 0; px < width; px++ {
-				cx := xmin + float64(px)*dx
-
-				// Optimized Mandelbrot with early escape
-				zx, zy := 0.0, 0.0
-				iter := int32(0)
-
-				// Unrolled first few iterations for better performance
-				for iter < int32(maxIter) {
-					zx2 := zx * zx
-					zy2 := zy * zy
-					if zx2+zy2 > 4.0 {
-						break
-					}
-
-					// Compute next iteration
-					temp := zx2 - zy2 + cx
-					zy = 2*zx*zy + cy
-					zx = temp
-					iter++
-				}
+func mandelbrotChunkV2(chunk mandelbrotChunk, result []int32, width int, dx, dy, xmin, ymin float64, maxIter int, shortcuts bool) {
+	for py := chunk.startY; py < chunk.endY; py++ {
+		cy := ymin + float64(py)*dy
+		rowOffset := py * width
 
-				result[rowOffset+px] = iter
-			}
+		for px := 0; px < width; px++ {
+			cx := xmin + float64(px)*dx
+			result[rowOffset+px] = int32(benchkernels.MandelbrotEscape(cx, cy, maxIter, shortcuts))
 		}
 	}
 }
@@ -355,10 +293,7 @@ func sha256HashWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 	}
 
 	// Smart worker count: use fewer workers to reduce overhead
-	numWorkers := runtime.GOMAXPROCS(0)
-	if numWorkers < 1 {
-		numWorkers = 4
-	}
+	numWorkers := benchmarkPool.size
 	if numWorkers > 8 {
 		numWorkers = 8 // Cap at 8 workers - diminishing returns beyond this
 	}
@@ -369,60 +304,56 @@ func sha256HashWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 
 	// Pre-allocate result array to avoid channel overhead
 	results := make([]uint32, numWorkers)
-	var wg sync.WaitGroup
 
 	// Distribute work evenly with larger chunks
 	baseIterations := iterations / numWorkers
 	remainder := iterations % numWorkers
 
-	// Start workers - each works on a different portion of iterations
-	for workerID := 0; workerID < numWorkers; workerID++ {
-		wg.Add(1)
-
-		workerIterations := baseIterations
-		if workerID < remainder {
-			workerIterations++
+	// One task per worker share - each works on a different portion of
+	// iterations
+	workers := make([]int, numWorkers)
+	for workerID := range workers {
+		workers[workerID] = workerID
+	}
+	runPooled(benchmarkPool, workers, func(id int) {
+		iters := baseIterations
+		if id < remainder {
+			iters++
 		}
 
 		// CRITICAL: Minimize allocations and function calls
-		go func(id, iters int) {
-			defer wg.Done()
-
-			// Each worker uses a different hash seed for better distribution
-			hash := uint32(0x12345678) + uint32(id)*0x9E3779B9
-
-			// ULTRA-OPTIMIZED inner loop - no function calls, minimal operations
-			for iter := 0; iter < iters; iter++ {
-				// Process data in 4-byte chunks for maximum efficiency
-				i := 0
-				for ; i <= dataLen-4; i += 4 {
-					// Unrolled 4-byte processing
-					hash = hash*33 + uint32(dataBytes[i])
-					hash = (hash << 5) | (hash >> 27)
-					hash = hash*33 + uint32(dataBytes[i+1])
-					hash = (hash << 3) | (hash >> 29)
-					hash = hash*33 + uint32(dataBytes[i+2])
-					hash = (hash << 7) | (hash >> 25)
-					hash = hash*33 + uint32(dataBytes[i+3])
-					hash = (hash << 11) | (hash >> 21)
-				}
-
-				// Process remaining bytes (0-3)
-				for ; i < dataLen; i++ {
-					hash = hash*33 + uint32(dataBytes[i])
-					hash = (hash << 5) | (hash >> 27)
-				}
+		// Each worker uses a different hash seed for better distribution
+		hash := uint32(0x12345678) + uint32(id)*0x9E3779B9
+
+		// ULTRA-OPTIMIZED inner loop - no function calls, minimal operations
+		for iter := 0; iter < iters; iter++ {
+			// Process data in 4-byte chunks for maximum efficiency
+			i := 0
+			for ; i <= dataLen-4; i += 4 {
+				// Unrolled 4-byte processing
+				hash = hash*33 + uint32(dataBytes[i])
+				hash = (hash << 5) | (hash >> 27)
+				hash = hash*33 + uint32(dataBytes[i+1])
+				hash = (hash << 3) | (hash >> 29)
+				hash = hash*33 + uint32(dataBytes[i+2])
+				hash = (hash << 7) | (hash >> 25)
+				hash = hash*33 + uint32(dataBytes[i+3])
+				hash = (hash << 11) | (hash >> 21)
+			}
 
-				// Mix in iteration counter efficiently
-				hash ^= uint32(iter)
-				hash = hash*0x85EBCA6B + 0xC2B2AE35
+			// Process remaining bytes (0-3)
+			for ; i < dataLen; i++ {
+				hash = hash*33 + uint32(dataBytes[i])
+				hash = (hash << 5) | (hash >> 27)
 			}
 
-			results[id] = hash
-		}(workerID, workerIterations)
-	}
+			// Mix in iteration counter efficiently
+			hash ^= uint32(iter)
+			hash = hash*0x85EBCA6B + 0xC2B2AE35
+		}
 
-	wg.Wait()
+		results[id] = hash
+	})
 
 	// OPTIMIZED result combination - no channel overhead
 	finalHash := uint32(0x9E3779B9)
@@ -450,11 +381,6 @@ func rayTracingWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 
 	result := make([]float64, width*height*3)
 
-	numWorkers := runtime.GOMAXPROCS(0)
-	if numWorkers < 1 {
-		numWorkers = 4
-	}
-
 	// Tile-based rendering for better cache performance
 	tileSize := 32 // 32x32 tiles
 	if tileSize > width {
@@ -464,51 +390,83 @@ func rayTracingWasmConcurrentV2(this js.Value, args []js.Value) interface{} {
 		tileSize = height
 	}
 
-	tileChan := make(chan tile, numWorkers*4)
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go rayTracingTileWorker(tileChan, &wg, result, width, height, samples)
-	}
-
-	// Generate tiles
-	go func() {
-		defer close(tileChan)
-		for y := 0; y < height; y += tileSize {
-			endY := minInt(y+tileSize, height)
-			for x := 0; x < width; x += tileSize {
-				endX := minInt(x+tileSize, width)
-				tileChan <- tile{startX: x, endX: endX, startY: y, endY: endY}
-			}
+	// Generate tiles for the pool
+	var tiles []tile
+	for y := 0; y < height; y += tileSize {
+		endY := minInt(y+tileSize, height)
+		for x := 0; x < width; x += tileSize {
+			tiles = append(tiles, tile{startX: x, endX: minInt(x+tileSize, width), startY: y, endY: endY})
 		}
-	}()
-
-	wg.Wait()
+	}
+	if samples >= rayTracingSampleParallelMin {
+		rayTracingTileBlocks(tiles, result, width, height, samples)
+	} else {
+		runPooled(benchmarkPool, tiles, func(t tile) {
+			rayTracingTile(t, result, width, height, samples)
+		})
+	}
 
 	// Use shared conversion function to avoid duplication
-	return createFloat64TypedArray(result)
+	return copyToJSTypedArray(result)
 }
 
-func rayTracingTileWorker(tileChan chan tile, wg *sync.WaitGroup, result []float64, width, height, samples int) {
-	defer wg.Done()
+// rayTracingSampleParallelMin is the sample count from which the concurrent
+// ray tracer splits each pixel's samples across the pool too.
+const rayTracingSampleParallelMin = 4 * benchkernels.SampleBlock
+
+// rayTracingTileBlocks renders the tiles one after another, each with its
+// sample blocks run in parallel. Each block sums into its own slice, and the
+// sums are added in block order, as benchkernels.PixelColor adds them, so
+// the image is the one rayTracingTile renders.
+func rayTracingTileBlocks(tiles []tile, result []float64, width, height, samples int) {
+	blocks := make([]int, benchkernels.SampleBlocks(samples))
+	tilePixels := 0
+	for _, t := range tiles {
+		tilePixels = maxInt(tilePixels, (t.endX-t.startX)*(t.endY-t.startY))
+	}
+	sums := make([][]float64, len(blocks))
+	for i := range blocks {
+		blocks[i] = i
+		sums[i] = make([]float64, tilePixels*3)
+	}
+
+	for _, t := range tiles {
+		tileWidth := t.endX - t.startX
+		runPooled(benchmarkPool, blocks, func(block int) {
+			for y := t.startY; y < t.endY; y++ {
+				for x := t.startX; x < t.endX; x++ {
+					idx := ((y-t.startY)*tileWidth + x - t.startX) * 3
+					sums[block][idx], sums[block][idx+1], sums[block][idx+2] = benchkernels.SampleBlockColor(x, y, width, height, samples, block)
+				}
+			}
+		})
 
-	for t := range tileChan {
 		for y := t.startY; y < t.endY; y++ {
-			ny := (float64(y)/float64(height))*2.0 - 1.0
-
 			for x := t.startX; x < t.endX; x++ {
-				nx := (float64(x)/float64(width))*2.0 - 1.0
+				idx := ((y-t.startY)*tileWidth + x - t.startX) * 3
+				var colorR, colorG, colorB float64
+				for _, sum := range sums {
+					colorR += sum[idx]
+					colorG += sum[idx+1]
+					colorB += sum[idx+2]
+				}
+				out := (y*width + x) * 3
+				result[out], result[out+1], result[out+2] = benchkernels.AverageColor(colorR, colorG, colorB, samples)
+			}
+		}
+	}
+}
 
-				// Use shared ray computation to avoid code duplication
-				colorR, colorG, colorB := computeRayColor(nx, ny, samples)
+func rayTracingTile(t tile, result []float64, width, height, samples int) {
+	for y := t.startY; y < t.endY; y++ {
+		for x := t.startX; x < t.endX; x++ {
+			// Use shared ray computation to avoid code duplication
+			colorR, colorG, colorB := benchkernels.PixelColor(x, y, width, height, samples)
 
-				idx := (y*width + x) * 3
-				result[idx] = colorR
-				result[idx+1] = colorG
-				result[idx+2] = colorB
-			}
+			idx := (y*width + x) * 3
+			result[idx] = colorR
+			result[idx+1] = colorG
+			result[idx+2] = colorB
 		}
 	}
 }
@@ -0,0 +1,16 @@
+//go:build js && wasm && !tinygo && !logic && (bench_crypto || !(bench_fractal || bench_linear || bench_graphics))
+
+package main
+
+import "syscall/js"
+
+// The crypto benchmark family: SHA-256 hashing.
+func init() {
+	benchmarkFamilies = append(benchmarkFamilies, benchmarkFamily{
+		Name: "crypto",
+		Functions: map[string]func(this js.Value, args []js.Value) interface{}{
+			"sha256HashWasm":           sha256HashWasmSingle,
+			"sha256HashConcurrentWasm": sha256HashWasmConcurrentV2,
+		},
+	})
+}
@@ -0,0 +1,17 @@
+//go:build js && wasm && !tinygo && !logic && (bench_fractal || !(bench_linear || bench_crypto || bench_graphics))
+
+package main
+
+import "syscall/js"
+
+// The fractal benchmark family: Mandelbrot set rendering.
+func init() {
+	benchmarkFamilies = append(benchmarkFamilies, benchmarkFamily{
+		Name: "fractal",
+		Functions: map[string]func(this js.Value, args []js.Value) interface{}{
+			"mandelbrotWasm":           mandelbrotWasmSingle,
+			"mandelbrotConcurrentWasm": mandelbrotWasmConcurrentV2,
+			"mandelbrotFrameWasm":      mandelbrotFrameWasm,
+		},
+	})
+}
@@ -0,0 +1,17 @@
+//go:build js && wasm && !tinygo && !logic && (bench_graphics || !(bench_fractal || bench_linear || bench_crypto))
+
+package main
+
+import "syscall/js"
+
+// The graphics benchmark family: ray tracing.
+func init() {
+	benchmarkFamilies = append(benchmarkFamilies, benchmarkFamily{
+		Name: "graphics",
+		Functions: map[string]func(this js.Value, args []js.Value) interface{}{
+			"rayTracingWasm":           rayTracingWasmSingle,
+			"rayTracingConcurrentWasm": rayTracingWasmConcurrentV2,
+			"rayTracingFrameWasm":      rayTracingFrameWasm,
+		},
+	})
+}
@@ -0,0 +1,16 @@
+//go:build js && wasm && !tinygo && !logic && (bench_linear || !(bench_fractal || bench_crypto || bench_graphics))
+
+package main
+
+import "syscall/js"
+
+// The linear benchmark family: matrix multiplication.
+func init() {
+	benchmarkFamilies = append(benchmarkFamilies, benchmarkFamily{
+		Name: "linear",
+		Functions: map[string]func(this js.Value, args []js.Value) interface{}{
+			"matrixMultiplyWasm":           matrixMultiplyWasmSingle,
+			"matrixMultiplyConcurrentWasm": matrixMultiplyWasmConcurrentV2,
+		},
+	})
+}
@@ -0,0 +1,163 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"slices"
+	"sync"
+	"syscall/js"
+)
+
+// ============================================================================
+// FRAME BUFFERS
+// Animations render the same Mandelbrot or ray tracing image size frame
+// after frame. The frame functions render into a Go slice and copy it into a
+// typed array that are both kept per image size, so a repeated frame
+// allocates nothing. The buffers of the frameBufferLimit most recently used
+// sizes are kept; releaseFrameBuffersWasm drops them early.
+//
+// The typed array a frame function returns is the cached one: the next frame
+// of the same size overwrites it, so a caller that keeps frames copies them
+// first.
+// ============================================================================
+
+// frameBufferLimit is how many frame buffers are kept.
+const frameBufferLimit = 8
+
+// Frame kinds.
+const (
+	mandelbrotFrame = "mandelbrot"
+	rayTracingFrame = "raytracing"
+)
+
+// frameKey identifies a frame buffer: the kind of image and its size.
+type frameKey struct {
+	kind          string
+	width, height int
+}
+
+// frameBuffer is a rendered frame and the typed array it is copied into.
+type frameBuffer struct {
+	key   frameKey
+	data  any      // []int32 for Mandelbrot, []float64 for ray tracing
+	array js.Value // the typed array returned
+	bytes js.Value // a Uint8Array over array, the copy's target
+}
+
+// frameBuffers are the kept buffers, least recently used first.
+var frameBuffers struct {
+	sync.Mutex
+	entries []*frameBuffer
+}
+
+// frameBufferFor returns the buffer for key, of length elements of T,
+// creating it (and dropping the least recently used one past
+// frameBufferLimit) if it isn't kept.
+func frameBufferFor[T typedArrayElement](key frameKey, length int) *frameBuffer {
+	frameBuffers.Lock()
+	defer frameBuffers.Unlock()
+
+	if i := slices.IndexFunc(frameBuffers.entries, func(buf *frameBuffer) bool { return buf.key == key }); i >= 0 {
+		buf := frameBuffers.entries[i]
+		// Move it to the most recently used end, in place
+		copy(frameBuffers.entries[i:], frameBuffers.entries[i+1:])
+		frameBuffers.entries[len(frameBuffers.entries)-1] = buf
+		return buf
+	}
+
+	array := js.Global().Get(typedArrayName[T]()).New(length)
+	buf := &frameBuffer{
+		key:   key,
+		data:  make([]T, length),
+		array: array,
+		bytes: js.Global().Get("Uint8Array").New(array.Get("buffer")),
+	}
+	if len(frameBuffers.entries) >= frameBufferLimit {
+		frameBuffers.entries = slices.Delete(frameBuffers.entries, 0, 1)
+	}
+	frameBuffers.entries = append(frameBuffers.entries, buf)
+	return buf
+}
+
+// releaseFrameBuffers drops the kept buffers of frames of width×height, or
+// of every size when width and height are 0, and returns how many it
+// dropped.
+func releaseFrameBuffers(width, height int) int {
+	frameBuffers.Lock()
+	defer frameBuffers.Unlock()
+
+	before := len(frameBuffers.entries)
+	frameBuffers.entries = slices.DeleteFunc(frameBuffers.entries, func(buf *frameBuffer) bool {
+		return (width == 0 && height == 0) || (buf.key.width == width && buf.key.height == height)
+	})
+	return before - len(frameBuffers.entries)
+}
+
+// renderMandelbrotFrame renders a Mandelbrot frame into its buffer, as
+// mandelbrotWasm renders the image.
+func renderMandelbrotFrame(width, height, maxIter int, xmin, xmax, ymin, ymax float64, shortcuts bool) *frameBuffer {
+	buf := frameBufferFor[int32](frameKey{mandelbrotFrame, width, height}, width*height)
+	result := buf.data.([]int32)
+	dx := (xmax - xmin) / float64(width)
+	dy := (ymax - ymin) / float64(height)
+	mandelbrotChunkV2(mandelbrotChunk{startY: 0, endY: height}, result, width, dx, dy, xmin, ymin, maxIter, shortcuts)
+	js.CopyBytesToJS(buf.bytes, sliceBytes(result))
+	return buf
+}
+
+// renderRayTracingFrame renders a ray tracing frame into its buffer, as
+// rayTracingWasm renders the image.
+func renderRayTracingFrame(width, height, samples int) *frameBuffer {
+	buf := frameBufferFor[float64](frameKey{rayTracingFrame, width, height}, width*height*3)
+	result := buf.data.([]float64)
+	rayTracingTile(tile{startX: 0, endX: width, startY: 0, endY: height}, result, width, height, samples)
+	js.CopyBytesToJS(buf.bytes, sliceBytes(result))
+	return buf
+}
+
+// WebAssembly wrapper for one frame of a Mandelbrot animation. Takes the
+// same arguments as mandelbrotWasm and returns the iteration counts in the
+// Int32Array kept for the image size.
+func mandelbrotFrameWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 6 {
+		return js.ValueOf("Missing arguments")
+	}
+
+	maxIter := 100
+	if len(args) > 6 {
+		maxIter = args[6].Int()
+	}
+
+	return renderMandelbrotFrame(args[0].Int(), args[1].Int(), maxIter,
+		args[2].Float(), args[3].Float(), args[4].Float(), args[5].Float(), mandelbrotShortcutsArg(args)).array
+}
+
+// WebAssembly wrapper for one frame of a ray tracing animation. Takes the
+// same arguments as rayTracingWasm and returns the RGB values in the
+// Float64Array kept for the image size.
+func rayTracingFrameWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return js.ValueOf("Missing arguments")
+	}
+
+	return renderRayTracingFrame(args[0].Int(), args[1].Int(), args[2].Int()).array
+}
+
+// WebAssembly wrapper releasing the frame buffers of an image size, given
+// its width and height, or of every size with no arguments.
+func releaseFrameBuffersWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 0 && len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected the width and height, or none to release every frame buffer",
+		}
+	}
+
+	width, height := 0, 0
+	if len(args) == 2 {
+		width, height = args[0].Int(), args[1].Int()
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"released": releaseFrameBuffers(width, height),
+	}
+}
@@ -0,0 +1,91 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"slices"
+	"syscall/js"
+	"testing"
+)
+
+func TestFrameBuffersReused(t *testing.T) {
+	t.Cleanup(func() { releaseFrameBuffers(0, 0) })
+
+	args := []js.Value{js.ValueOf(30), js.ValueOf(20), js.ValueOf(-2.0), js.ValueOf(1.0), js.ValueOf(-1.0), js.ValueOf(1.0), js.ValueOf(40)}
+	first := mandelbrotFrameWasm(js.Null(), args).(js.Value)
+	got, _ := copyFromJSTypedArray[int32](first)
+	want, _ := copyFromJSTypedArray[int32](mandelbrotWasmSingle(js.Null(), args).(js.Value))
+	if !slices.Equal(got, want) {
+		t.Fatalf("mandelbrotFrameWasm = %v, want %v", got, want)
+	}
+
+	// The next frame of the size is rendered into the same array
+	args[2] = js.ValueOf(-1.5)
+	if next := mandelbrotFrameWasm(js.Null(), args).(js.Value); !next.Equal(first) {
+		t.Error("Expected the second frame in the first frame's array")
+	}
+	want, _ = copyFromJSTypedArray[int32](mandelbrotWasmSingle(js.Null(), args).(js.Value))
+	if got, _ := copyFromJSTypedArray[int32](first); !slices.Equal(got, want) {
+		t.Errorf("second frame = %v, want %v", got, want)
+	}
+
+	rayArgs := []js.Value{js.ValueOf(12), js.ValueOf(8), js.ValueOf(2)}
+	frame := rayTracingFrameWasm(js.Null(), rayArgs).(js.Value)
+	gotRGB, _ := copyFromJSTypedArray[float64](frame)
+	wantRGB, _ := copyFromJSTypedArray[float64](rayTracingWasmSingle(js.Null(), rayArgs).(js.Value))
+	if !slices.Equal(gotRGB, wantRGB) {
+		t.Errorf("rayTracingFrameWasm = %v, want %v", gotRGB, wantRGB)
+	}
+	if !rayTracingFrameWasm(js.Null(), rayArgs).(js.Value).Equal(frame) {
+		t.Error("Expected the second ray tracing frame in the first one's array")
+	}
+
+	// Repeated frames allocate nothing
+	if allocs := testing.AllocsPerRun(10, func() { renderMandelbrotFrame(30, 20, 40, -2, 1, -1, 1, true) }); allocs != 0 {
+		t.Errorf("Mandelbrot frame: %v allocations, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { renderRayTracingFrame(12, 8, 2) }); allocs != 0 {
+		t.Errorf("ray tracing frame: %v allocations, want 0", allocs)
+	}
+}
+
+func TestFrameBuffersLimit(t *testing.T) {
+	releaseFrameBuffers(0, 0)
+	t.Cleanup(func() { releaseFrameBuffers(0, 0) })
+
+	first := renderMandelbrotFrame(1, 1, 10, -2, 1, -1, 1, true)
+	for width := 2; width <= frameBufferLimit; width++ {
+		renderMandelbrotFrame(width, 1, 10, -2, 1, -1, 1, true)
+	}
+	// Using the first size again keeps it past the next new size
+	renderMandelbrotFrame(1, 1, 10, -2, 1, -1, 1, true)
+	renderMandelbrotFrame(frameBufferLimit+1, 1, 10, -2, 1, -1, 1, true)
+	if len(frameBuffers.entries) != frameBufferLimit {
+		t.Fatalf("%d buffers kept, want %d", len(frameBuffers.entries), frameBufferLimit)
+	}
+	if renderMandelbrotFrame(1, 1, 10, -2, 1, -1, 1, true) != first {
+		t.Error("Expected the recently used 1x1 buffer to be kept")
+	}
+	if slices.ContainsFunc(frameBuffers.entries, func(buf *frameBuffer) bool { return buf.key.width == 2 }) {
+		t.Error("Expected the least recently used 2x1 buffer to be dropped")
+	}
+
+	// Releasing one size drops its buffers of every kind
+	renderRayTracingFrame(1, 1, 1)
+	result := releaseFrameBuffersWasm(js.Null(), []js.Value{js.ValueOf(1), js.ValueOf(1)}).(map[string]interface{})
+	if result["released"] != 2 {
+		t.Errorf("release 1x1 = %v, want 2 released", result)
+	}
+	if renderMandelbrotFrame(1, 1, 10, -2, 1, -1, 1, true) == first {
+		t.Error("Expected a released size to get a new buffer")
+	}
+
+	kept := len(frameBuffers.entries)
+	result = releaseFrameBuffersWasm(js.Null(), nil).(map[string]interface{})
+	if result["released"] != kept || len(frameBuffers.entries) != 0 {
+		t.Errorf("release all = %v, %d left", result, len(frameBuffers.entries))
+	}
+	if result := releaseFrameBuffersWasm(js.Null(), []js.Value{js.ValueOf(1)}).(map[string]interface{}); result["error"] == "" {
+		t.Error("Expected an error for one argument")
+	}
+}
@@ -0,0 +1,116 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// ============================================================================
+// MATRIX MULTIPLICATION ALGORITHMS
+// matrixMultiplyOptimizedWasm's optional fourth argument picks how it
+// multiplies: the blocked kernel, tuned with fixed block sizes; Strassen's
+// algorithm (see benchmarks_strassen.go); or a cache-oblivious recursive
+// kernel, which halves the largest dimension until the blocks are small, so
+// that some level of the recursion fits each level of whatever cache the
+// device has, without knowing its size.
+// ============================================================================
+
+// Matrix multiplication algorithms, as matrixMultiplyOptimizedWasm's fourth
+// argument names them.
+const (
+	matrixAlgorithmAuto      = "auto"
+	matrixAlgorithmBlocked   = "blocked"
+	matrixAlgorithmStrassen  = "strassen"
+	matrixAlgorithmRecursive = "recursive"
+)
+
+// recursiveLeafVolume is the most multiply-adds, rows × inner × columns, the
+// recursive kernel does in one block with plain loops. It only amortizes the
+// recursion; the blocks that fit the caches are found by halving.
+const recursiveLeafVolume = 16 * 16 * 16
+
+// matrixAlgorithmArg is the optional algorithm argument after the size,
+// matrixAlgorithmAuto when it isn't given.
+func matrixAlgorithmArg(args []js.Value) string {
+	if len(args) < 4 || args[3].Type() != js.TypeString {
+		return matrixAlgorithmAuto
+	}
+	return args[3].String()
+}
+
+// matrixAlgorithmFor is the algorithm that multiplies size×size matrices
+// when algorithm is asked for: matrixAlgorithmAuto, and names it doesn't
+// know, take Strassen's algorithm from strassenThreshold and the blocked
+// kernel below.
+func matrixAlgorithmFor(size int, algorithm string) string {
+	switch algorithm {
+	case matrixAlgorithmBlocked, matrixAlgorithmStrassen, matrixAlgorithmRecursive:
+		return algorithm
+	}
+	if size >= strassenThreshold {
+		return matrixAlgorithmStrassen
+	}
+	return matrixAlgorithmBlocked
+}
+
+// multiplyMatrices returns the product of the size×size row-major matrices
+// a and b, multiplied with the algorithm matrixAlgorithmFor picks.
+func multiplyMatrices(a, b []float64, size int, algorithm string) []float64 {
+	switch matrixAlgorithmFor(size, algorithm) {
+	case matrixAlgorithmStrassen:
+		return strassenMultiply(a, b, size)
+	case matrixAlgorithmRecursive:
+		result := make([]float64, size*size)
+		matrixMultiplyRecursive(a, b, result, size)
+		return result
+	default:
+		result := make([]float64, size*size)
+		matrixMultiplyBlocked(a, b, result, size)
+		return result
+	}
+}
+
+// matrixMultiplyRecursive adds the product of the size×size row-major
+// matrices a and b to result. Each element sums its products in the same
+// order as the naive loops, so the result is the same to the bit.
+func matrixMultiplyRecursive(a, b, result []float64, size int) {
+	multiplyRecursive(a, b, result, size, 0, size, 0, size, 0, size)
+}
+
+// multiplyRecursive adds rows [i0, i1) × inner [k0, k1) of a times
+// inner × columns [j0, j1) of b to the block of result, all stored with
+// rows of stride elements.
+func multiplyRecursive(a, b, result []float64, stride, i0, i1, k0, k1, j0, j1 int) {
+	rows, inner, cols := i1-i0, k1-k0, j1-j0
+	if rows*inner*cols <= recursiveLeafVolume {
+		for i := i0; i < i1; i++ {
+			row := result[i*stride+j0 : i*stride+j1]
+			for k := k0; k < k1; k++ {
+				aik := a[i*stride+k]
+				bRow := b[k*stride+j0 : k*stride+j1]
+				for j := range row {
+					row[j] += aik * bRow[j]
+				}
+			}
+		}
+		return
+	}
+
+	// Halve the largest dimension. The inner halves both add to the same
+	// block, the first before the second, which keeps the order of the sums
+	switch {
+	case rows >= inner && rows >= cols:
+		mid := i0 + rows/2
+		multiplyRecursive(a, b, result, stride, i0, mid, k0, k1, j0, j1)
+		multiplyRecursive(a, b, result, stride, mid, i1, k0, k1, j0, j1)
+	case cols >= inner:
+		mid := j0 + cols/2
+		multiplyRecursive(a, b, result, stride, i0, i1, k0, k1, j0, mid)
+		multiplyRecursive(a, b, result, stride, i0, i1, k0, k1, mid, j1)
+	default:
+		mid := k0 + inner/2
+		multiplyRecursive(a, b, result, stride, i0, i1, k0, mid, j0, j1)
+		multiplyRecursive(a, b, result, stride, i0, i1, mid, k1, j0, j1)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"math"
+	"slices"
+	"syscall/js"
+	"testing"
+)
+
+func TestMatrixAlgorithmSelection(t *testing.T) {
+	for _, tc := range []struct {
+		size      int
+		algorithm string
+		want      string
+	}{
+		{strassenThreshold - 1, matrixAlgorithmAuto, matrixAlgorithmBlocked},
+		{strassenThreshold, matrixAlgorithmAuto, matrixAlgorithmStrassen},
+		{strassenThreshold, "unknown", matrixAlgorithmStrassen},
+		{strassenThreshold, matrixAlgorithmBlocked, matrixAlgorithmBlocked},
+		{8, matrixAlgorithmStrassen, matrixAlgorithmStrassen},
+		{8, matrixAlgorithmRecursive, matrixAlgorithmRecursive},
+	} {
+		if got := matrixAlgorithmFor(tc.size, tc.algorithm); got != tc.want {
+			t.Errorf("matrixAlgorithmFor(%d, %q) = %q, want %q", tc.size, tc.algorithm, got, tc.want)
+		}
+	}
+
+	// Every algorithm multiplies the same through the WASM function
+	const size = strassenCutoff + 2
+	a := make([]float64, size*size)
+	b := make([]float64, size*size)
+	for i := range a {
+		a[i] = float64(i % 13)
+		b[i] = float64(i % 7)
+	}
+	want := naiveMultiply(a, b, size)
+	for _, algorithm := range []interface{}{nil, matrixAlgorithmBlocked, matrixAlgorithmStrassen, matrixAlgorithmRecursive} {
+		args := []js.Value{copyToJSTypedArray(a), copyToJSTypedArray(b), js.ValueOf(size)}
+		if algorithm != nil {
+			args = append(args, js.ValueOf(algorithm))
+		}
+		got, _ := copyFromJSTypedArray[float64](matrixMultiplyOptimizedWasm(js.Null(), args).(js.Value))
+		if !slices.Equal(got, want) {
+			t.Errorf("matrixMultiplyOptimizedWasm with %v differs from the naive product", algorithm)
+		}
+	}
+}
+
+func TestMatrixAlgorithmRecursive(t *testing.T) {
+	// Sizes below one leaf, odd sizes that split unevenly, and ones that
+	// recurse a few levels
+	for _, size := range []int{0, 1, 3, 16, 17, 33, 100, 129} {
+		a := make([]float64, size*size)
+		b := make([]float64, size*size)
+		for i := range a {
+			a[i] = math.Sin(float64(i)) * 3
+			b[i] = math.Cos(float64(i)*0.7) / 2
+		}
+		got := make([]float64, size*size)
+		matrixMultiplyRecursive(a, b, got, size)
+		if want := naiveMultiply(a, b, size); !slices.Equal(got, want) {
+			t.Errorf("matrixMultiplyRecursive at size %d differs from the naive product", size)
+		}
+	}
+}
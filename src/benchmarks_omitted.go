@@ -0,0 +1,28 @@
+//go:build js && wasm && (tinygo || logic)
+
+package main
+
+import "syscall/js"
+
+// benchmarksBuilt reports the benchmarks are left out.
+const benchmarksBuilt = false
+
+// ensureBenchmarks registers nothing when the benchmarks are left out:
+// logic.wasm and the TinyGo build carry only the business logic, so pages
+// load it for validation, pricing and analytics and fetch bench.wasm (or
+// the standard build) to run benchmarks.
+func ensureBenchmarks() {}
+
+// benchmarkFamilyNames lists no families.
+func benchmarkFamilyNames() []string {
+	return []string{}
+}
+
+// initBenchmarksWasm reports that this module has no benchmarks.
+func initBenchmarksWasm(this js.Value, args []js.Value) interface{} {
+	return map[string]interface{}{
+		"error":      "This module was built without the benchmarks - load bench.wasm or main.wasm",
+		"registered": false,
+		"families":   benchmarkFamiliesValue(),
+	}
+}
@@ -1,10 +1,9 @@
-//go:build js && wasm
+//go:build js && wasm && !tinygo && !logic && !lite
 
 package main
 
 import (
 	"syscall/js"
-	"unsafe"
 )
 
 // ============================================================================
@@ -25,49 +24,47 @@ func matrixMultiplyOptimizedWasm(this js.Value, args []js.Value) interface{} {
 	// Old approach: matrixA.Index(i).Float() in loop = size² boundary calls
 	// New approach: Single bulk copy = 2 boundary calls total
 
-	goMatrixA := make([]float64, totalElements)
-	goMatrixB := make([]float64, totalElements)
-
-	// Check if inputs are typed arrays and can be bulk copied
-	if args[0].Get("constructor").Get("name").String() == "Float64Array" {
-		// Use efficient bulk copy for typed arrays via Uint8Array view
-		matrixABuffer := args[0].Get("buffer")
-		matrixBBuffer := args[1].Get("buffer")
-
-		uint8ViewA := js.Global().Get("Uint8Array").New(matrixABuffer)
-		uint8ViewB := js.Global().Get("Uint8Array").New(matrixBBuffer)
-
-		js.CopyBytesToGo(
-			unsafe.Slice((*byte)(unsafe.Pointer(&goMatrixA[0])), totalElements*8),
-			uint8ViewA,
-		)
-		js.CopyBytesToGo(
-			unsafe.Slice((*byte)(unsafe.Pointer(&goMatrixB[0])), totalElements*8),
-			uint8ViewB,
-		)
-	} else {
+	goMatrixA, okA := copyFromJSTypedArray[float64](args[0])
+	goMatrixB, okB := copyFromJSTypedArray[float64](args[1])
+	if !okA || !okB || len(goMatrixA) < totalElements || len(goMatrixB) < totalElements {
 		// Fallback to element-by-element copy for regular arrays
+		goMatrixA = make([]float64, totalElements)
+		goMatrixB = make([]float64, totalElements)
 		for i := 0; i < totalElements; i++ {
 			goMatrixA[i] = args[0].Index(i).Float()
 			goMatrixB[i] = args[1].Index(i).Float()
 		}
 	}
 
-	result := make([]float64, totalElements)
-
 	// ALL COMPUTATION IN PURE GO - ZERO BOUNDARY CALLS
+	result := multiplyMatrices(goMatrixA, goMatrixB, size, matrixAlgorithmArg(args))
+
+	// Use shared conversion function to avoid duplication
+	return copyToJSTypedArray(result)
+}
+
+// matrixMultiplyBlocked adds the product of the size×size row-major
+// matrices a and b to result, in the block sizes currentKernelTuning picks
+func matrixMultiplyBlocked(matrixA, matrixB, result []float64, size int) {
+	tuning := currentKernelTuning()
+	matrixMultiplyBlockedSizes(matrixA, matrixB, result, size, tuning.MatrixOuterBlock, tuning.MatrixInnerBlock)
+}
+
+// matrixMultiplyBlockedSizes is matrixMultiplyBlocked with outer blocks of
+// outerBlockSize and inner ones of innerBlockSize. The register tiling
+// takes 2×2 steps, so both are even and the outer size is a multiple of the
+// inner one. Every element sums its products in order, so the result doesn't
+// depend on the sizes.
+func matrixMultiplyBlockedSizes(matrixA, matrixB, result []float64, size, outerBlockSize, innerBlockSize int) {
 	// Transpose matrix B for cache optimization
-	matrixBT := make([]float64, totalElements)
+	matrixBT := make([]float64, size*size)
 	for i := 0; i < size; i++ {
 		for j := 0; j < size; j++ {
-			matrixBT[i*size+j] = goMatrixB[j*size+i]
+			matrixBT[i*size+j] = matrixB[j*size+i]
 		}
 	}
 
 	// Hierarchical blocking with register tiling
-	const outerBlockSize = 64
-	const innerBlockSize = 8
-
 	for bi := 0; bi < size; bi += outerBlockSize {
 		for bj := 0; bj < size; bj += outerBlockSize {
 			for bk := 0; bk < size; bk += outerBlockSize {
@@ -106,10 +103,10 @@ func matrixMultiplyOptimizedWasm(this js.Value, args []js.Value) interface{} {
 									// Compute 2x2 block
 									for kk := k; kk < kEnd; kk += 2 {
 										if ii < size && kk < size {
-											a00 := goMatrixA[ii*size+kk]
+											a00 := matrixA[ii*size+kk]
 											a10 := 0.0
 											if ii+1 < size {
-												a10 = goMatrixA[(ii+1)*size+kk]
+												a10 = matrixA[(ii+1)*size+kk]
 											}
 
 											if jj < size {
@@ -126,10 +123,10 @@ func matrixMultiplyOptimizedWasm(this js.Value, args []js.Value) interface{} {
 
 										// Second k iteration
 										if kk+1 < kEnd && ii < size && kk+1 < size {
-											a01 := goMatrixA[ii*size+(kk+1)]
+											a01 := matrixA[ii*size+(kk+1)]
 											a11 := 0.0
 											if ii+1 < size {
-												a11 = goMatrixA[(ii+1)*size+(kk+1)]
+												a11 = matrixA[(ii+1)*size+(kk+1)]
 											}
 
 											if jj < size {
@@ -166,9 +163,6 @@ func matrixMultiplyOptimizedWasm(this js.Value, args []js.Value) interface{} {
 			}
 		}
 	}
-
-	// Use shared conversion function to avoid duplication
-	return createFloat64TypedArray(result)
 }
 
 // sha256HashOptimizedWasm - ULTRA-FAST single-threaded with ZERO overhead
@@ -265,9 +259,16 @@ func mandelbrotOptimizedWasm(this js.Value, args []js.Value) interface{} {
 	pixels := width * height
 
 	result := make([]int32, pixels)
+	mandelbrotTiled(result, width, height, maxIter, xmin, ymin, dx, dy, currentKernelTuning().MandelbrotTile)
 
+	// Use shared conversion function to avoid duplication
+	return copyToJSTypedArray(result)
+}
+
+// mandelbrotTiled computes the escape counts of the width×height image in
+// tileSize×tileSize tiles, four pixels of a row at a time
+func mandelbrotTiled(result []int32, width, height, maxIter int, xmin, ymin, dx, dy float64, tileSize int) {
 	// Vectorized hierarchical tiling (pure Go)
-	const tileSize = 64
 	const vecSize = 4
 
 	for ty := 0; ty < height; ty += tileSize {
@@ -338,9 +339,6 @@ func mandelbrotOptimizedWasm(this js.Value, args []js.Value) interface{} {
 			}
 		}
 	}
-
-	// Use shared conversion function to avoid duplication
-	return createInt32TypedArray(result)
 }
 
 // rayTracingOptimizedWasm - ULTRA-SIMPLE ZERO-FUNCTION-CALL VERSION
@@ -353,7 +351,7 @@ func rayTracingOptimizedWasm(this js.Value, args []js.Value) interface{} {
 	result := rayTracingSharedSingle(width, height, samples)
 
 	// Return result using shared conversion function
-	return createFloat64TypedArray(result)
+	return copyToJSTypedArray(result)
 }
 
 // ============================================================================
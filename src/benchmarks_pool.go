@@ -0,0 +1,162 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ============================================================================
+// BENCHMARK WORKER POOL
+// The concurrent benchmarks hand their chunks of work to one pool of
+// goroutines started with the module, instead of starting workers and a
+// channel on every call, which interactive demos calling them many times in
+// a row paid for on each call.
+//
+// The pool schedules by work stealing. runPooled deals each worker a
+// contiguous run of the items onto its own deque, which the worker takes
+// from the front, in order. A worker whose deque is empty steals from the
+// back of another's, so when some chunks cost far more than others, as the
+// rows through the set of a deep-zoom Mandelbrot do, the workers that
+// finish early take over the rest rather than sit idle.
+// ============================================================================
+
+// workerPool runs tasks on a fixed set of long-lived goroutines, one deque
+// of tasks per worker.
+type workerPool struct {
+	size   int
+	deques []taskDeque
+
+	// queued counts the tasks in the deques. Idle workers wait on wake
+	// until it is positive.
+	queued atomic.Int64
+	mu     sync.Mutex
+	wake   *sync.Cond
+}
+
+// taskDeque is one worker's tasks, tasks[head:], taken from the front by
+// the worker and from the back by thieves.
+type taskDeque struct {
+	sync.Mutex
+	tasks []func()
+	head  int
+}
+
+// benchmarkPool is the pool every concurrent benchmark runs on, one worker
+// per GOMAXPROCS.
+var benchmarkPool = newWorkerPool(runtime.GOMAXPROCS(0))
+
+// newWorkerPool starts a pool of size workers, at least one. The workers
+// live as long as the module.
+func newWorkerPool(size int) *workerPool {
+	size = maxInt(size, 1)
+	pool := &workerPool{size: size, deques: make([]taskDeque, size)}
+	pool.wake = sync.NewCond(&pool.mu)
+	for id := 0; id < size; id++ {
+		go pool.work(id)
+	}
+	return pool
+}
+
+// work runs worker id's tasks, and other workers' when it has none.
+func (pool *workerPool) work(id int) {
+	for {
+		if task := pool.take(id); task != nil {
+			task()
+			continue
+		}
+		pool.mu.Lock()
+		for pool.queued.Load() <= 0 {
+			pool.wake.Wait()
+		}
+		pool.mu.Unlock()
+	}
+}
+
+// take returns the next task of worker id's deque, or else one stolen from
+// the others, trying them in turn from the next worker on. It returns nil
+// when every deque is empty.
+func (pool *workerPool) take(id int) func() {
+	task := pool.deques[id].popFront()
+	for i := 1; task == nil && i < pool.size; i++ {
+		task = pool.deques[(id+i)%pool.size].popBack()
+	}
+	if task != nil {
+		pool.queued.Add(-1)
+	}
+	return task
+}
+
+// push adds tasks to worker id's deque.
+func (pool *workerPool) push(id int, tasks []func()) {
+	if len(tasks) == 0 {
+		return
+	}
+	pool.deques[id].pushBack(tasks)
+	pool.queued.Add(int64(len(tasks)))
+	pool.mu.Lock()
+	pool.wake.Broadcast()
+	pool.mu.Unlock()
+}
+
+func (d *taskDeque) pushBack(tasks []func()) {
+	d.Lock()
+	defer d.Unlock()
+	d.tasks = append(d.tasks, tasks...)
+}
+
+func (d *taskDeque) popFront() func() {
+	d.Lock()
+	defer d.Unlock()
+	if d.head == len(d.tasks) {
+		return nil
+	}
+	task := d.tasks[d.head]
+	d.tasks[d.head] = nil
+	d.head++
+	d.reset()
+	return task
+}
+
+func (d *taskDeque) popBack() func() {
+	d.Lock()
+	defer d.Unlock()
+	if d.head == len(d.tasks) {
+		return nil
+	}
+	last := len(d.tasks) - 1
+	task := d.tasks[last]
+	d.tasks[last] = nil
+	d.tasks = d.tasks[:last]
+	d.reset()
+	return task
+}
+
+// reset reuses the slice from its start once the deque is empty.
+func (d *taskDeque) reset() {
+	if d.head == len(d.tasks) {
+		d.tasks, d.head = d.tasks[:0], 0
+	}
+}
+
+// runPooled calls fn with each item on pool's workers and returns once all
+// calls have. Each worker is dealt a contiguous run of the items, and idle
+// workers steal from the others. Tasks must not call runPooled themselves:
+// a task waiting for the pool can hold up the workers it waits for.
+func runPooled[T any](pool *workerPool, items []T, fn func(T)) {
+	var wg sync.WaitGroup
+	wg.Add(len(items))
+	tasks := make([]func(), len(items))
+	for i, item := range items {
+		tasks[i] = func() {
+			defer wg.Done()
+			fn(item)
+		}
+	}
+	for id := 0; id < pool.size; id++ {
+		pool.push(id, tasks[id*len(tasks)/pool.size:(id+1)*len(tasks)/pool.size])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func TestRunPooled(t *testing.T) {
+	pool := newWorkerPool(3)
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i + 1
+	}
+
+	// Calls from several goroutines share the workers
+	var wg sync.WaitGroup
+	var sum atomic.Int64
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runPooled(pool, items, func(n int) { sum.Add(int64(n)) })
+		}()
+	}
+	wg.Wait()
+	if got := sum.Load(); got != 4*5050 {
+		t.Errorf("sum = %d, want %d", got, 4*5050)
+	}
+
+	runPooled(pool, nil, func(int) { t.Error("called for no items") })
+	if newWorkerPool(0).size != 1 {
+		t.Error("Expected a pool of at least one worker")
+	}
+}
+
+func TestRunPooledSteals(t *testing.T) {
+	pool := newWorkerPool(3)
+	const count = 30
+	items := make([]int, count)
+	for i := range items {
+		items[i] = i
+	}
+
+	// Item 0 holds its worker until every other item is done, which takes
+	// the other workers stealing the rest of its deque
+	var doneCount atomic.Int64
+	othersDone := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		runPooled(pool, items, func(n int) {
+			if n == 0 {
+				<-othersDone
+				return
+			}
+			if doneCount.Add(1) == count-1 {
+				close(othersDone)
+			}
+		})
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%d of %d items done with item 0's worker busy", doneCount.Load(), count-1)
+	}
+
+	// Each deque is worked from its front, in order, by its own worker
+	var order []int
+	var mu sync.Mutex
+	runPooled(newWorkerPool(1), items[:5], func(n int) {
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+	})
+	if !slices.Equal(order, items[:5]) {
+		t.Errorf("order = %v, want %v", order, items[:5])
+	}
+}
+
+// TestPooledBenchmarksMatchSingle tests that the concurrent benchmarks
+// compute what the single-threaded ones do
+func TestPooledBenchmarksMatchSingle(t *testing.T) {
+	args := func(values ...interface{}) []js.Value {
+		out := make([]js.Value, len(values))
+		for i, v := range values {
+			out[i] = js.ValueOf(v)
+		}
+		return out
+	}
+	for i := 0; i < 2; i++ {
+		single := mandelbrotWasmSingle(js.Null(), args(40, 30, -2.0, 1.0, -1.0, 1.0, 50)).(js.Value)
+		pooled := mandelbrotWasmConcurrentV2(js.Null(), args(40, 30, -2.0, 1.0, -1.0, 1.0, 50)).(js.Value)
+		if single.Length() != pooled.Length() {
+			t.Fatalf("mandelbrot lengths %d and %d", single.Length(), pooled.Length())
+		}
+		for p := 0; p < single.Length(); p++ {
+			if single.Index(p).Int() != pooled.Index(p).Int() {
+				t.Fatalf("mandelbrot pixel %d: %d and %d", p, single.Index(p).Int(), pooled.Index(p).Int())
+			}
+		}
+
+		// Without the shortcuts, every point is iterated to the same counts
+		full := mandelbrotWasmSingle(js.Null(), args(40, 30, -2.0, 1.0, -1.0, 1.0, 50, false)).(js.Value)
+		fullPooled := mandelbrotWasmConcurrentV2(js.Null(), args(40, 30, -2.0, 1.0, -1.0, 1.0, 50, false)).(js.Value)
+		for p := 0; p < single.Length(); p++ {
+			if full.Index(p).Int() != single.Index(p).Int() || fullPooled.Index(p).Int() != single.Index(p).Int() {
+				t.Fatalf("mandelbrot pixel %d without shortcuts: %d and %d, want %d", p, full.Index(p).Int(), fullPooled.Index(p).Int(), single.Index(p).Int())
+			}
+		}
+
+		// 70 samples also splits them across the pool, with a partial last
+		// tile and a partial last block
+		for _, samples := range []int{2, 70} {
+			single = rayTracingWasmSingle(js.Null(), args(40, 12, samples)).(js.Value)
+			pooled = rayTracingWasmConcurrentV2(js.Null(), args(40, 12, samples)).(js.Value)
+			for p := 0; p < single.Length(); p++ {
+				if single.Index(p).Float() != pooled.Index(p).Float() {
+					t.Fatalf("ray tracing %d samples, channel %d: %v and %v", samples, p, single.Index(p).Float(), pooled.Index(p).Float())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+// benchmarksBuilt reports the benchmarks are part of this build.
+const benchmarksBuilt = true
+
+// benchmarkFamily is a group of benchmarks with the functions it registers,
+// by global name. A family is compiled in when its bench_<name> build tag is
+// given, or when none of them are (see benchmarks_family_*.go).
+type benchmarkFamily struct {
+	Name      string
+	Functions map[string]func(this js.Value, args []js.Value) interface{}
+}
+
+// benchmarkFamilies are the families compiled in, in the order their files
+// add them.
+var benchmarkFamilies []benchmarkFamily
+
+// benchmarkFamilyNames lists the families compiled in.
+func benchmarkFamilyNames() []string {
+	names := []string{}
+	for _, family := range benchmarkFamilies {
+		names = append(names, family.Name)
+	}
+	return names
+}
+
+// hasBenchmarkFamily reports whether the family is compiled in.
+func hasBenchmarkFamily(name string) bool {
+	return slices.Contains(benchmarkFamilyNames(), name)
+}
+
+// benchmarksOnce guards registerBenchmarks.
+var benchmarksOnce sync.Once
+
+// ensureBenchmarks registers the benchmark functions the first time it is
+// called. The logic.wasm and TinyGo builds leave them out (see
+// benchmarks_omitted.go).
+func ensureBenchmarks() {
+	benchmarksOnce.Do(registerBenchmarks)
+}
+
+// initBenchmarksWasm registers the benchmark functions on first use, so
+// pages that only validate and price orders never wrap them.
+func initBenchmarksWasm(this js.Value, args []js.Value) interface{} {
+	ensureBenchmarks()
+	return map[string]interface{}{
+		"error":      "",
+		"registered": true,
+		"families":   benchmarkFamiliesValue(),
+	}
+}
+
+// registerBenchmarks registers the benchmark functions; call it through
+// ensureBenchmarks.
+func registerBenchmarks() {
+	// ====================================================================
+	// BENCHMARK FAMILIES
+	// Single-threaded and concurrent versions of each family compiled in
+	// ====================================================================
+	for _, family := range benchmarkFamilies {
+		for _, name := range slices.Sorted(maps.Keys(family.Functions)) {
+			js.Global().Set(name, js.FuncOf(family.Functions[name]))
+		}
+	}
+
+	// The native benchmarks, as the server and cmd/bench run them
+	registerNativeBenchmarks()
+
+	// Dropping the buffers the animation frames are rendered into
+	js.Global().Set("releaseFrameBuffersWasm", js.FuncOf(releaseFrameBuffersWasm))
+
+	// Timed runs of server challenges, with the proof of their output
+	js.Global().Set("runChallengeBenchmarkWasm", js.FuncOf(runChallengeBenchmarkWasm))
+
+	// Variants the pages don't call, left out of the lite build
+	registerBenchmarkVariants()
+
+	// ====================================================================
+	// UTILITY FUNCTIONS
+	// Debugging and system information functions
+	// ====================================================================
+	js.Global().Set("debugConcurrency", js.FuncOf(debugConcurrencyWasm))
+	js.Global().Set("boundaryOverheadWasm", js.FuncOf(boundaryOverheadWasm))
+}
+
+// WebAssembly wrapper for challenged benchmark runs: runs the workload on
+// the inputs the challenge seed derives, as the server checks results
+// submitted with the challenge against, and returns how long it took with
+// the proof of its output. Takes the benchmark name, params JSON and the
+// challenge seed, and optionally the challenge ID to log the run under, as
+// the server logs its check.
+func runChallengeBenchmarkWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 && len(args) != 4 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected benchmark, params JSON and seed, and optionally the challenge ID",
+		}
+	}
+
+	var params map[string]int
+	if err := json.Unmarshal([]byte(args[1].String()), &params); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid params JSON: " + err.Error(),
+		}
+	}
+
+	ctx, trace := wasmCallContext("runChallengeBenchmarkWasm")
+	if len(args) == 4 {
+		ctx = WithBenchmarkID(ctx, args[3].String())
+	}
+	start := time.Now()
+	run, err := runChallengeBenchmark(ctx, args[0].String(), params, uint32(args[2].Int()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":       "",
+		"proof":       run.Proof,
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"trace":       traceToJS(trace),
+	}
+}
+
+// ====================================================================
+// UTILITY FUNCTIONS
+// ====================================================================
+
+// Debug function to check concurrency and system info
+func debugConcurrencyWasm(this js.Value, args []js.Value) interface{} {
+	return map[string]interface{}{
+		"GOMAXPROCS":    runtime.GOMAXPROCS(0),
+		"NumCPU":        runtime.NumCPU(),
+		"NumGoroutines": runtime.NumGoroutine(),
+		"GoVersion":     runtime.Version(),
+		"GOARCH":        runtime.GOARCH,
+		"GOOS":          runtime.GOOS,
+	}
+}
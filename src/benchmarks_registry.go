@@ -0,0 +1,89 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	"go-wasm-demo/internal/benchkernels"
+)
+
+// registerNativeBenchmarks registers <name>BenchmarkWasm for each benchmark
+// in the native registry, the workloads the server's /api/benchmark/<name>
+// endpoints and cmd/bench run, and listBenchmarksWasm to describe them.
+func registerNativeBenchmarks() {
+	for _, b := range benchkernels.All() {
+		js.Global().Set(b.Name()+"BenchmarkWasm", js.FuncOf(nativeBenchmarkWasm(b)))
+	}
+	js.Global().Set("listBenchmarksWasm", js.FuncOf(listBenchmarksWasm))
+}
+
+// nativeBenchmarkWasm wraps a registered benchmark. The function takes an
+// optional params JSON object, defaults filling in what it leaves out, and
+// returns the run as the server endpoint responds with it.
+func nativeBenchmarkWasm(b benchkernels.Benchmark) func(this js.Value, args []js.Value) interface{} {
+	return func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 1 || (len(args) == 1 && args[0].Type() != js.TypeString) {
+			return map[string]interface{}{
+				"error": "Invalid arguments - expected an optional params JSON object",
+			}
+		}
+
+		params := map[string]int{}
+		if len(args) == 1 && args[0].String() != "" {
+			if err := json.Unmarshal([]byte(args[0].String()), &params); err != nil {
+				return map[string]interface{}{
+					"error": "Invalid params JSON: " + err.Error(),
+				}
+			}
+		}
+
+		run, err := benchkernels.Measure(context.Background(), b, params)
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+		report := run.Report()
+		report["error"] = ""
+		report["params"] = intsToJS(run.Params)
+		return report
+	}
+}
+
+// listBenchmarksWasm lists the native benchmarks and their parameters, as
+// GET /api/benchmarks does.
+func listBenchmarksWasm(this js.Value, args []js.Value) interface{} {
+	benchmarks := []interface{}{}
+	for _, b := range benchkernels.All() {
+		params := []interface{}{}
+		for _, p := range b.ParamsSchema() {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"description": p.Description,
+				"default":     p.Default,
+				"min":         p.Min,
+				"max":         p.Max,
+			})
+		}
+		benchmarks = append(benchmarks, map[string]interface{}{
+			"name":   b.Name(),
+			"params": params,
+		})
+	}
+	return map[string]interface{}{
+		"error":      "",
+		"benchmarks": benchmarks,
+	}
+}
+
+// intsToJS converts an int map to a value js.ValueOf takes.
+func intsToJS(values map[string]int) map[string]interface{} {
+	converted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		converted[key] = value
+	}
+	return converted
+}
@@ -0,0 +1,165 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	_ "embed"
+	"sync"
+	"syscall/js"
+)
+
+// ============================================================================
+// SIMD KERNELS
+// The matrix multiplication and Mandelbrot inner loops, hand-written with
+// WebAssembly SIMD instructions in simd_kernels.wat, which the Go compiler
+// doesn't emit. The assembled module is small enough to compile
+// synchronously, even on a browser's main thread, and it isn't compiled
+// until the first SIMD benchmark call. On an engine without SIMD, validating
+// it fails and the benchmarks run the Go kernels instead, with the same
+// results. simd_kernels.wasm is generated: go generate in src assembles it
+// from simd_kernels.wat.
+// ============================================================================
+
+//go:embed simd_kernels.wasm
+var simdKernelsWasm []byte
+
+// wasmPageBytes is the size of a WebAssembly memory page.
+const wasmPageBytes = 64 << 10
+
+// simdKernels is an instance of the kernel module. A nil *simdKernels is
+// no SIMD: its methods run the Go kernels.
+type simdKernels struct {
+	memory           js.Value
+	matmulKernel     js.Value
+	mandelbrotKernel js.Value
+}
+
+var (
+	simdKernelsOnce   sync.Once
+	loadedSIMDKernels *simdKernels
+)
+
+// loadSIMDKernels instantiates the kernel module the first time it is
+// called, and returns nil when the engine can't run it.
+func loadSIMDKernels() *simdKernels {
+	simdKernelsOnce.Do(func() {
+		wasm := js.Global().Get("WebAssembly")
+		if wasm.Type() != js.TypeObject {
+			return
+		}
+		bytes := copyToJSTypedArray(simdKernelsWasm)
+		if !wasm.Call("validate", bytes).Bool() {
+			return
+		}
+		module := wasm.Get("Module").New(bytes)
+		exports := wasm.Get("Instance").New(module, js.Global().Get("Object").New()).Get("exports")
+		loadedSIMDKernels = &simdKernels{
+			memory:           exports.Get("memory"),
+			matmulKernel:     exports.Get("matmul"),
+			mandelbrotKernel: exports.Get("mandelbrot"),
+		}
+	})
+	return loadedSIMDKernels
+}
+
+// reserve grows the kernels' memory to at least size bytes, and reports
+// whether it could.
+func (k *simdKernels) reserve(size int) (ok bool) {
+	current := k.memory.Get("buffer").Get("byteLength").Int()
+	if size <= current {
+		return true
+	}
+	// grow throws a RangeError past the engine's limit
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	k.memory.Call("grow", (size-current+wasmPageBytes-1)/wasmPageBytes)
+	return true
+}
+
+// view is the kernels' memory from offset, for size bytes. It is invalid
+// once the memory grows.
+func (k *simdKernels) view(offset, size int) js.Value {
+	return js.Global().Get("Uint8Array").New(k.memory.Get("buffer"), offset, size)
+}
+
+// matrixMultiply returns the product of the size×size row-major matrices a
+// and b.
+func (k *simdKernels) matrixMultiply(a, b []float64, size int) []float64 {
+	result := make([]float64, size*size)
+	matrixBytes := len(result) * 8
+	if k == nil || !k.reserve(3*matrixBytes) {
+		matrixMultiplyChunk(matrixWorkChunk{startRow: 0, endRow: size}, a, b, result, size)
+		return result
+	}
+	js.CopyBytesToJS(k.view(0, matrixBytes), sliceBytes(a[:len(result)]))
+	js.CopyBytesToJS(k.view(matrixBytes, matrixBytes), sliceBytes(b[:len(result)]))
+	k.matmulKernel.Invoke(0, matrixBytes, 2*matrixBytes, size)
+	js.CopyBytesToGo(sliceBytes(result), k.view(2*matrixBytes, matrixBytes))
+	return result
+}
+
+// mandelbrot returns the escape iteration counts of a width×height image of
+// the region, row by row, as mandelbrotChunkV2 computes them.
+func (k *simdKernels) mandelbrot(width, height, maxIter int, xmin, xmax, ymin, ymax float64) []int32 {
+	dx := (xmax - xmin) / float64(width)
+	dy := (ymax - ymin) / float64(height)
+	result := make([]int32, width*height)
+	if k == nil || !k.reserve(len(result)*4) {
+		mandelbrotChunkV2(mandelbrotChunk{startY: 0, endY: height}, result, width, dx, dy, xmin, ymin, maxIter, false)
+		return result
+	}
+	k.mandelbrotKernel.Invoke(0, width, height, maxIter, xmin, ymin, dx, dy)
+	js.CopyBytesToGo(sliceBytes(result), k.view(0, len(result)*4))
+	return result
+}
+
+// WebAssembly wrapper for the SIMD matrix multiplication. Takes matrices A
+// and B (Float64Arrays or arrays) and the size, like matrixMultiplyWasm, and
+// returns the product as a Float64Array.
+func matrixMultiplySIMDWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return js.ValueOf("Missing arguments")
+	}
+
+	size := args[2].Int()
+	totalElements := size * size
+	matrixA, okA := copyFromJSTypedArray[float64](args[0])
+	matrixB, okB := copyFromJSTypedArray[float64](args[1])
+	if !okA || !okB || len(matrixA) < totalElements || len(matrixB) < totalElements {
+		matrixA = make([]float64, totalElements)
+		matrixB = make([]float64, totalElements)
+		for i := 0; i < totalElements; i++ {
+			matrixA[i] = args[0].Index(i).Float()
+			matrixB[i] = args[1].Index(i).Float()
+		}
+	}
+
+	return copyToJSTypedArray(loadSIMDKernels().matrixMultiply(matrixA, matrixB, size))
+}
+
+// WebAssembly wrapper for the SIMD Mandelbrot. Takes the same arguments as
+// mandelbrotWasm and returns the iteration counts as an Int32Array.
+func mandelbrotSIMDWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 6 {
+		return js.ValueOf("Missing arguments")
+	}
+
+	maxIter := 100
+	if len(args) > 6 {
+		maxIter = args[6].Int()
+	}
+
+	return copyToJSTypedArray(loadSIMDKernels().mandelbrot(args[0].Int(), args[1].Int(), maxIter,
+		args[2].Float(), args[3].Float(), args[4].Float(), args[5].Float()))
+}
+
+// WebAssembly wrapper reporting whether the SIMD benchmarks run the SIMD
+// kernels, rather than falling back to the Go ones.
+func simdAvailableWasm(this js.Value, args []js.Value) interface{} {
+	return map[string]interface{}{
+		"available": loadSIMDKernels() != nil,
+	}
+}
@@ -0,0 +1,73 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"slices"
+	"syscall/js"
+	"testing"
+)
+
+// TestSIMDKernelsMatchGo tests that the SIMD kernels compute what the Go
+// kernels they fall back to do, bit for bit
+func TestSIMDKernelsMatchGo(t *testing.T) {
+	kernels := loadSIMDKernels()
+	if kernels == nil {
+		t.Fatal("Expected Node.js to run the SIMD kernels")
+	}
+	var none *simdKernels
+
+	// Odd sizes take the scalar last column; 100 grows the memory past its
+	// first page
+	for _, size := range []int{0, 1, 2, 3, 8, 17, 100} {
+		a := make([]float64, size*size)
+		b := make([]float64, size*size)
+		for i := range a {
+			a[i] = float64(i%10) / 3
+			b[i] = float64((i*7)%11) * 1.1
+		}
+		got, want := kernels.matrixMultiply(a, b, size), none.matrixMultiply(a, b, size)
+		if !slices.Equal(got, want) {
+			t.Errorf("matrixMultiply at size %d = %v, want %v", size, got, want)
+		}
+	}
+
+	for _, image := range [][2]int{{0, 0}, {1, 1}, {7, 5}, {40, 30}, {301, 200}} {
+		width, height := image[0], image[1]
+		got := kernels.mandelbrot(width, height, 80, -2, 1, -1.2, 1.2)
+		want := none.mandelbrot(width, height, 80, -2, 1, -1.2, 1.2)
+		if !slices.Equal(got, want) {
+			t.Errorf("mandelbrot %dx%d = %v, want %v", width, height, got, want)
+		}
+	}
+	if got := kernels.mandelbrot(4, 2, 0, -2, 1, -1, 1); !slices.Equal(got, make([]int32, 8)) {
+		t.Errorf("mandelbrot with no iterations = %v", got)
+	}
+}
+
+func TestSIMDWasmFunctions(t *testing.T) {
+	if available := simdAvailableWasm(js.Null(), nil).(map[string]interface{})["available"]; available != true {
+		t.Errorf("available = %v, want true", available)
+	}
+
+	// Arrays and typed arrays both work
+	a := []interface{}{1.0, 2.0, 3.0, 4.0}
+	b := copyToJSTypedArray([]float64{5, 6, 7, 8})
+	product := matrixMultiplySIMDWasm(js.Null(), []js.Value{js.ValueOf(a), b, js.ValueOf(2)}).(js.Value)
+	if got, _ := copyFromJSTypedArray[float64](product); !slices.Equal(got, []float64{19, 22, 43, 50}) {
+		t.Errorf("matrixMultiplySIMDWasm = %v, want [19 22 43 50]", got)
+	}
+
+	args := []js.Value{js.ValueOf(9), js.ValueOf(7), js.ValueOf(-2.0), js.ValueOf(1.0), js.ValueOf(-1.0), js.ValueOf(1.0), js.ValueOf(50)}
+	simd := mandelbrotSIMDWasm(js.Null(), args).(js.Value)
+	single := mandelbrotWasmSingle(js.Null(), args).(js.Value)
+	got, _ := copyFromJSTypedArray[int32](simd)
+	want, _ := copyFromJSTypedArray[int32](single)
+	if !slices.Equal(got, want) {
+		t.Errorf("mandelbrotSIMDWasm = %v, want %v", got, want)
+	}
+
+	if got := matrixMultiplySIMDWasm(js.Null(), nil).(js.Value).String(); got != "Missing arguments" {
+		t.Errorf("no arguments: %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+// ============================================================================
+// STRASSEN MATRIX MULTIPLICATION
+// Strassen's algorithm multiplies two matrices from seven products of their
+// halves rather than eight, so it does O(n^2.81) work instead of O(n^3).
+// The recursion stops at strassenCutoff, below which the extra additions
+// cost more than the product they save, and multiplies the blocks with
+// matrixMultiplyBlocked. matrixMultiplyOptimizedWasm takes it from
+// strassenThreshold up, or as its optional fourth argument asks.
+// ============================================================================
+
+const (
+	// strassenThreshold is the smallest size matrixAlgorithmAuto
+	// multiplies with Strassen's algorithm.
+	strassenThreshold = 512
+
+	// strassenCutoff is the largest size the recursion multiplies with
+	// matrixMultiplyBlocked rather than splitting further.
+	strassenCutoff = 128
+)
+
+// strassenMultiply returns the product of the size×size row-major matrices
+// a and b. Rounding makes it differ from the blocked product in the last
+// bits, but products of integers below 2^53 are exact either way.
+func strassenMultiply(a, b []float64, size int) []float64 {
+	if size <= strassenCutoff {
+		result := make([]float64, size*size)
+		matrixMultiplyBlocked(a, b, result, size)
+		return result
+	}
+	if size%2 != 0 {
+		// Pad odd sizes with a zero row and column, which the product drops
+		padded := size + 1
+		product := strassenMultiply(padMatrix(a, size, padded), padMatrix(b, size, padded), padded)
+		result := make([]float64, size*size)
+		for i := 0; i < size; i++ {
+			copy(result[i*size:(i+1)*size], product[i*padded:i*padded+size])
+		}
+		return result
+	}
+
+	half := size / 2
+	a11, a12, a21, a22 := matrixQuadrants(a, size)
+	b11, b12, b21, b22 := matrixQuadrants(b, size)
+
+	m1 := strassenMultiply(addMatrices(a11, a22), addMatrices(b11, b22), half)
+	m2 := strassenMultiply(addMatrices(a21, a22), b11, half)
+	m3 := strassenMultiply(a11, subtractMatrices(b12, b22), half)
+	m4 := strassenMultiply(a22, subtractMatrices(b21, b11), half)
+	m5 := strassenMultiply(addMatrices(a11, a12), b22, half)
+	m6 := strassenMultiply(subtractMatrices(a21, a11), addMatrices(b11, b12), half)
+	m7 := strassenMultiply(subtractMatrices(a12, a22), addMatrices(b21, b22), half)
+
+	result := make([]float64, size*size)
+	for i := 0; i < half; i++ {
+		top := result[i*size : i*size+size]
+		bottom := result[(i+half)*size : (i+half)*size+size]
+		for j := 0; j < half; j++ {
+			k := i*half + j
+			top[j] = m1[k] + m4[k] - m5[k] + m7[k]
+			top[half+j] = m3[k] + m5[k]
+			bottom[j] = m2[k] + m4[k]
+			bottom[half+j] = m1[k] - m2[k] + m3[k] + m6[k]
+		}
+	}
+	return result
+}
+
+// matrixQuadrants copies the four half-size quadrants out of the size×size
+// matrix m, for an even size.
+func matrixQuadrants(m []float64, size int) (q11, q12, q21, q22 []float64) {
+	half := size / 2
+	q11 = make([]float64, half*half)
+	q12 = make([]float64, half*half)
+	q21 = make([]float64, half*half)
+	q22 = make([]float64, half*half)
+	for i := 0; i < half; i++ {
+		top := m[i*size : i*size+size]
+		bottom := m[(i+half)*size : (i+half)*size+size]
+		copy(q11[i*half:], top[:half])
+		copy(q12[i*half:], top[half:])
+		copy(q21[i*half:], bottom[:half])
+		copy(q22[i*half:], bottom[half:])
+	}
+	return q11, q12, q21, q22
+}
+
+// padMatrix copies the size×size matrix m into the top left of a zero
+// padded×padded one.
+func padMatrix(m []float64, size, padded int) []float64 {
+	out := make([]float64, padded*padded)
+	for i := 0; i < size; i++ {
+		copy(out[i*padded:], m[i*size:(i+1)*size])
+	}
+	return out
+}
+
+func addMatrices(x, y []float64) []float64 {
+	out := make([]float64, len(x))
+	for i := range out {
+		out[i] = x[i] + y[i]
+	}
+	return out
+}
+
+func subtractMatrices(x, y []float64) []float64 {
+	out := make([]float64, len(x))
+	for i := range out {
+		out[i] = x[i] - y[i]
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// naiveMultiply is the textbook product the Strassen results are checked
+// against.
+func naiveMultiply(a, b []float64, size int) []float64 {
+	result := make([]float64, size*size)
+	matrixMultiplyChunk(matrixWorkChunk{startRow: 0, endRow: size}, a, b, result, size)
+	return result
+}
+
+func TestStrassenMatchesNaive(t *testing.T) {
+	// Sizes at and around the cutoff, odd sizes that are padded at one level
+	// or several, and a power of two
+	for _, size := range []int{1, 2, strassenCutoff, strassenCutoff + 1, 200, 2*strassenCutoff + 1, 256} {
+		// Small integers multiply exactly, whatever the order of the sums
+		a := make([]float64, size*size)
+		b := make([]float64, size*size)
+		for i := range a {
+			a[i] = float64(i%10) - 4
+			b[i] = float64((i*7)%11) - 5
+		}
+		if got, want := strassenMultiply(a, b, size), naiveMultiply(a, b, size); !slices.Equal(got, want) {
+			t.Errorf("strassenMultiply at size %d differs from the naive product", size)
+		}
+
+		// Other values differ only by rounding
+		for i := range a {
+			a[i] = math.Sin(float64(i)) * 3
+			b[i] = math.Cos(float64(i)*0.7) / 2
+		}
+		got, want := strassenMultiply(a, b, size), naiveMultiply(a, b, size)
+		for i := range want {
+			if math.Abs(got[i]-want[i]) > 1e-9*float64(size) {
+				t.Fatalf("strassenMultiply at size %d, element %d = %v, want %v", size, i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -2,12 +2,45 @@
 
 package main
 
+//go:generate go test -run TestSIMDKernelsGenerated -update .
+
 import (
+	"bytes"
 	"crypto/sha256"
 	"fmt"
+	"os"
 	"testing"
+
+	"go-wasm-demo/internal/benchkernels"
+	"go-wasm-demo/internal/wat"
 )
 
+// TestSIMDKernelsGenerated checks simd_kernels.wasm, which the WASM module
+// embeds, is simd_kernels.wat assembled.
+func TestSIMDKernelsGenerated(t *testing.T) {
+	src, err := os.ReadFile("simd_kernels.wat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := wat.Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updateGenerated {
+		if err := os.WriteFile("simd_kernels.wasm", want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile("simd_kernels.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Error("simd_kernels.wasm is out of date - run go generate in src")
+	}
+}
+
 // TestMatrixMultiplicationLogic tests the matrix multiplication algorithm correctness
 func TestMatrixMultiplicationLogic(t *testing.T) {
 	// Test small 2x2 matrix multiplication
@@ -289,19 +322,19 @@ func benchmarkHashing(b *testing.B, iterations int) {
 // Benchmark the actual server-side functions for comparison
 func BenchmarkServerMatrixMultiplication(b *testing.B) {
 	for n := 0; n < b.N; n++ {
-		_ = benchmarkMatrixMultiply(100)
+		_ = benchkernels.MatrixMultiply(100)
 	}
 }
 
 func BenchmarkServerMandelbrot(b *testing.B) {
 	for n := 0; n < b.N; n++ {
-		_ = benchmarkMandelbrot(800, 600, 200)
+		_ = benchkernels.Mandelbrot(800, 600, 200)
 	}
 }
 
 func BenchmarkServerHashing(b *testing.B) {
 	for n := 0; n < b.N; n++ {
-		_ = benchmarkSHA256(10000)
+		_ = benchkernels.SHA256(10000)
 	}
 }
 
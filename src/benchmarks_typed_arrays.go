@@ -0,0 +1,77 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"syscall/js"
+	"unsafe"
+)
+
+// ============================================================================
+// TYPED ARRAY CONVERSION
+// Bulk copies between Go slices and JavaScript typed arrays, one boundary
+// call per copy instead of one per element.
+//
+// Both directions copy: the typed array copyToJSTypedArray returns is owned
+// by JavaScript, and the slice copyFromJSTypedArray returns is owned by Go,
+// so neither side holds a view into the other's memory. That matters because
+// a view into Go memory is invalidated when the WASM memory grows, and the
+// garbage collector doesn't know about it. WebAssembly and the typed arrays
+// of every JavaScript engine it runs in are little-endian, so the bytes copy
+// as they are.
+// ============================================================================
+
+// typedArrayElement is a Go element type with a JavaScript typed array.
+type typedArrayElement interface {
+	uint8 | int32 | float32 | float64
+}
+
+// typedArrayName is the JavaScript typed array constructor for T.
+func typedArrayName[T typedArrayElement]() string {
+	var zero T
+	switch any(zero).(type) {
+	case uint8:
+		return "Uint8Array"
+	case int32:
+		return "Int32Array"
+	case float32:
+		return "Float32Array"
+	default:
+		return "Float64Array"
+	}
+}
+
+// sliceBytes is the memory of data as bytes. It shares data's memory, so it
+// is only for handing to a copy.
+func sliceBytes[T typedArrayElement](data []T) []byte {
+	var zero T
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(data))), len(data)*int(unsafe.Sizeof(zero)))
+}
+
+// copyToJSTypedArray returns a new typed array of T (a Float64Array for
+// []float64, and so on) holding a copy of data. An empty slice gives an empty
+// array.
+func copyToJSTypedArray[T typedArrayElement](data []T) js.Value {
+	array := js.Global().Get(typedArrayName[T]()).New(len(data))
+	if len(data) > 0 {
+		bytes := js.Global().Get("Uint8Array").New(array.Get("buffer"), array.Get("byteOffset"), array.Get("byteLength"))
+		js.CopyBytesToJS(bytes, sliceBytes(data))
+	}
+	return array
+}
+
+// copyFromJSTypedArray copies a typed array of T into a new slice. It copies
+// only the elements the array covers, so subarrays of a larger buffer work.
+// ok is false when value is not a typed array of T's type; callers with
+// other arrays fall back to reading them element by element.
+func copyFromJSTypedArray[T typedArrayElement](value js.Value) (data []T, ok bool) {
+	if value.Type() != js.TypeObject || !value.InstanceOf(js.Global().Get(typedArrayName[T]())) {
+		return nil, false
+	}
+	data = make([]T, value.Length())
+	if len(data) > 0 {
+		bytes := js.Global().Get("Uint8Array").New(value.Get("buffer"), value.Get("byteOffset"), value.Get("byteLength"))
+		js.CopyBytesToGo(sliceBytes(data), bytes)
+	}
+	return data, true
+}
@@ -0,0 +1,69 @@
+//go:build js && wasm && !tinygo && !logic
+
+package main
+
+import (
+	"reflect"
+	"syscall/js"
+	"testing"
+)
+
+// Run with:
+//
+//	GOOS=js GOARCH=wasm go test -exec "$(go env GOROOT)/lib/wasm/go_js_wasm_exec" -run TypedArray ./src
+
+func testTypedArrayRoundTrip[T typedArrayElement](t *testing.T, data []T) {
+	t.Helper()
+	name := typedArrayName[T]()
+	array := copyToJSTypedArray(data)
+	if !array.InstanceOf(js.Global().Get(name)) || array.Length() != len(data) {
+		t.Fatalf("copyToJSTypedArray(%v) = %v, want a %s of %d", data, array, name, len(data))
+	}
+	for i, want := range data {
+		if got := array.Index(i).Float(); got != float64(want) {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got, want)
+		}
+	}
+	back, ok := copyFromJSTypedArray[T](array)
+	if !ok || !reflect.DeepEqual(back, data) {
+		t.Errorf("copyFromJSTypedArray(%s) = %v, %v, want %v", name, back, ok, data)
+	}
+
+	// The copies are independent
+	if len(data) > 0 {
+		array.SetIndex(0, 9)
+		if data[0] == 9 || back[0] == 9 {
+			t.Errorf("Expected changing the %s to leave the Go slices alone", name)
+		}
+	}
+}
+
+func TestTypedArrayRoundTrip(t *testing.T) {
+	testTypedArrayRoundTrip(t, []uint8{0, 1, 255})
+	testTypedArrayRoundTrip(t, []int32{-7, 0, 1 << 30})
+	testTypedArrayRoundTrip(t, []float32{-1.5, 0, 3.25})
+	testTypedArrayRoundTrip(t, []float64{-1e300, 0.1, 42})
+	testTypedArrayRoundTrip(t, []float64{})
+}
+
+func TestCopyFromJSTypedArray(t *testing.T) {
+	// A subarray copies only its own elements
+	buffer := js.Global().Get("Float64Array").New(js.ValueOf([]interface{}{1, 2, 3, 4}))
+	data, ok := copyFromJSTypedArray[float64](buffer.Call("subarray", 1, 3))
+	if !ok || !reflect.DeepEqual(data, []float64{2, 3}) {
+		t.Errorf("copyFromJSTypedArray(subarray) = %v, %v, want [2 3]", data, ok)
+	}
+
+	for name, value := range map[string]js.Value{
+		"array":          js.ValueOf([]interface{}{1, 2}),
+		"other typed":    js.Global().Get("Int32Array").New(2),
+		"number":         js.ValueOf(1),
+		"undefined":      js.Undefined(),
+		"null":           js.Null(),
+		"array of float": js.Global().Get("Float32Array").New(2),
+	} {
+		if _, ok := copyFromJSTypedArray[float64](value); ok {
+			t.Errorf("copyFromJSTypedArray[float64](%s) succeeded", name)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-//go:build js && wasm
+//go:build js && wasm && !tinygo && !logic && !lite
 
 package main
 
@@ -154,25 +154,27 @@ func createBenchmarkSuite(prefix string, config BenchmarkConfig) map[string]js.F
 	}
 }
 
-// Registers all benchmark variants using the unified interface
-func registerUnifiedBenchmarks() {
-	// Register single-threaded benchmarks
-	singleSuite := createBenchmarkSuite("single", SingleThreadedConfig)
-	for name, fn := range singleSuite {
-		js.Global().Set(name+"Wasm", fn)
+// Registers the benchmark variants using the unified interface, those of
+// the benchmarks include accepts
+func registerUnifiedBenchmarks(include func(name string) bool) {
+	register := func(suite map[string]js.Func, suffix string) {
+		for name, fn := range suite {
+			if include(name) {
+				js.Global().Set(name+suffix, fn)
+			} else {
+				fn.Release()
+			}
+		}
 	}
 
+	// Register single-threaded benchmarks
+	register(createBenchmarkSuite("single", SingleThreadedConfig), "Wasm")
+
 	// Register optimized benchmarks
-	optimizedSuite := createBenchmarkSuite("optimized", OptimizedConfig)
-	for name, fn := range optimizedSuite {
-		js.Global().Set(name+"WasmFast", fn)
-	}
+	register(createBenchmarkSuite("optimized", OptimizedConfig), "WasmFast")
 
 	// Register concurrent benchmarks
-	concurrentSuite := createBenchmarkSuite("concurrent", ConcurrentConfig)
-	for name, fn := range concurrentSuite {
-		js.Global().Set(name+"WasmConcurrent", fn)
-	}
+	register(createBenchmarkSuite("concurrent", ConcurrentConfig), "WasmConcurrent")
 }
 
 // ============================================================================
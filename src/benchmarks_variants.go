@@ -0,0 +1,87 @@
+//go:build js && wasm && !tinygo && !logic && !lite
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"syscall/js"
+)
+
+// benchmarkVariantsBuilt reports the variants are part of this build.
+const benchmarkVariantsBuilt = true
+
+// benchmarkVariants are the optimized versions and legacy aliases of each
+// family, by global name.
+var benchmarkVariants = map[string]map[string]func(this js.Value, args []js.Value) interface{}{
+	// ====================================================================
+	// BENCHMARK FUNCTIONS - OPTIMIZED VERSIONS
+	// Highly optimized single-threaded implementations with boundary call reduction,
+	// under standardized names and legacy names for backward compatibility
+	// ====================================================================
+	"fractal": {
+		"mandelbrotOptimizedWasm": mandelbrotOptimizedWasm,
+		"mandelbrotWasmFast":      mandelbrotOptimizedWasm,
+		"mandelbrotFast":          mandelbrotOptimizedWasm,
+		"mandelbrotSIMDWasm":      mandelbrotSIMDWasm,
+	},
+	"linear": {
+		"matrixMultiplyOptimizedWasm": matrixMultiplyOptimizedWasm,
+		"matrixMultiplyWasmFast":      matrixMultiplyOptimizedWasm,
+		"matrixMultiplyFast":          matrixMultiplyOptimizedWasm,
+		"matrixMultiplySIMDWasm":      matrixMultiplySIMDWasm,
+	},
+	"crypto": {
+		"sha256HashOptimizedWasm": sha256HashOptimizedWasm,
+		"sha256HashWasmFast":      sha256HashOptimizedWasm,
+		"sha256HashFast":          sha256HashOptimizedWasm,
+	},
+	"graphics": {
+		"rayTracingOptimizedWasm": rayTracingOptimizedWasm,
+		// PERFORMANCE FIX: rayTracingWasm stays the optimized rayTracingWasmSingle;
+		// the old implementation keeps only its legacy name
+		"rayTracing": rayTracingWasm,
+	},
+}
+
+// unifiedBenchmarkFamilies are the families of the unified benchmarks, by
+// name without the suite prefix.
+var unifiedBenchmarkFamilies = map[string]string{
+	"MatrixMultiply": "linear",
+	"Mandelbrot":     "fractal",
+	"Hash":           "crypto",
+	"RayTracing":     "graphics",
+}
+
+// registerBenchmarkVariants registers the optimized and unified benchmark
+// interfaces and the legacy aliases of the families compiled in. The demo
+// pages only call the single-threaded and concurrent versions, so the lite
+// build leaves these out (see benchmarks_variants_omitted.go).
+func registerBenchmarkVariants() {
+	for _, family := range benchmarkFamilyNames() {
+		variants := benchmarkVariants[family]
+		for _, name := range slices.Sorted(maps.Keys(variants)) {
+			js.Global().Set(name, js.FuncOf(variants[name]))
+		}
+	}
+
+	// Whether the SIMD versions run the SIMD kernels
+	js.Global().Set("simdAvailableWasm", js.FuncOf(simdAvailableWasm))
+
+	// The block and tile sizes the optimized kernels were tuned to
+	js.Global().Set("autoTuneWasm", js.FuncOf(autoTuneWasm))
+
+	// ====================================================================
+	// UNIFIED BENCHMARK INTERFACE
+	// Register consolidated benchmark functions for cleaner API
+	// ====================================================================
+	registerUnifiedBenchmarks(func(name string) bool {
+		for benchmark, family := range unifiedBenchmarkFamilies {
+			if strings.HasSuffix(name, benchmark) {
+				return hasBenchmarkFamily(family)
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,10 @@
+//go:build js && wasm && (tinygo || logic || lite)
+
+package main
+
+// benchmarkVariantsBuilt reports the variants are left out.
+const benchmarkVariantsBuilt = false
+
+// registerBenchmarkVariants registers nothing in the lite build, which keeps
+// only the benchmark versions the demo pages call.
+func registerBenchmarkVariants() {}
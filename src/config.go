@@ -0,0 +1,535 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// SERVER CONFIGURATION
+// Every setting is declared once as a flag. The same flag.Value is also fed
+// from an optional config file and from the environment, with precedence:
+//
+//   defaults < config file < environment < command-line flags
+//
+// Environment variables are the upper-snake-case flag name (read-timeout ->
+// READ_TIMEOUT), which keeps the original PORT, ENABLE_PPROF, ... working.
+// Config files are JSON objects or flat "key: value" YAML, keyed by the flag
+// name with either dashes or underscores.
+// ============================================================================
+
+// ServerConfig holds all runtime settings for the HTTP server.
+type ServerConfig struct {
+	// HTTP server
+	Port            string
+	StaticDir       string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	ComputeTimeout  time.Duration
+	MaxHeaderBytes  int
+	MaxBodyBytes    int64
+	MaxImportBytes  int64
+	MaxImportRows   int
+
+	// TLS and HTTP/2
+	TLSCertFile string
+	TLSKeyFile  string
+	EnableH2C   bool
+
+	// Cross-origin policy
+	CORSAllowedOrigins         string
+	EnableCrossOriginIsolation bool
+	COEPPolicy                 string
+
+	// Benchmark limits
+	MaxMatrixSize          int
+	MaxMandelbrotPixels    int
+	MaxMandelbrotIteration int
+	MaxHashCount           int
+
+	// Benchmark challenges
+	BenchmarkSigningKey   string
+	BenchmarkChallengeTTL time.Duration
+
+	// Asynchronous benchmark jobs
+	JobWorkers   int
+	JobQueueSize int
+
+	// Load tests
+	MaxLoadTestDuration    time.Duration
+	MaxLoadTestConcurrency int
+
+	// Order recalculation
+	MaxRecalculateWorkers int
+
+	// Validation cache
+	ValidationCacheSize int
+
+	// Generated demo data
+	MaxDemoRecords int
+
+	// Sandboxes
+	SandboxTTL   time.Duration
+	MaxSandboxes int
+
+	// Sessions
+	SessionTTL  time.Duration
+	MaxSessions int
+
+	// Idempotency keys
+	IdempotencyTTL     time.Duration
+	MaxIdempotencyKeys int
+
+	// Exchange rates
+	RatesFile    string
+	RatesURL     string
+	RatesRefresh time.Duration
+
+	// Validation rules
+	ValidationRulesFile string
+
+	// Webhooks
+	AdminToken         string
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int
+
+	// Dynamic pricing
+	DynamicPricing          bool
+	DynamicPriceMin         float64
+	DynamicPriceMax         float64
+	DynamicPriceWindow      time.Duration
+	DynamicPriceTargetCover time.Duration
+
+	// Customer lifetime value
+	CLVMargin       float64
+	CLVDiscountRate float64
+
+	// Development
+	DevMode bool
+
+	// Fault injection
+	FaultPaths       string
+	FaultLatency     time.Duration
+	FaultJitter      time.Duration
+	FaultErrorRate   float64
+	FaultErrorStatus int
+
+	// Profiling
+	EnablePprof bool
+	PprofToken  string
+
+	// Storage and logging
+	StoragePath string
+	// SnapshotFile is where the shared store is snapshotted, JSON or gob by
+	// its extension; see snapshotPath
+	SnapshotFile     string
+	SnapshotInterval time.Duration
+
+	LogLevel      string
+	LogFile       string
+	LogMaxSizeMB  int
+	LogMaxAge     time.Duration
+	LogMaxBackups int
+	AccessLog     bool
+}
+
+// serverConfig is the active configuration. main replaces it with the loaded
+// configuration before serving; tests use the defaults.
+var serverConfig = defaultServerConfig()
+
+func defaultServerConfig() *ServerConfig {
+	cfg := &ServerConfig{}
+	// Binding the flags applies every default in one place
+	bindConfigFlags(flag.NewFlagSet("defaults", flag.ContinueOnError), cfg)
+	return cfg
+}
+
+// bindConfigFlags declares every setting on fs, bound to cfg.
+func bindConfigFlags(fs *flag.FlagSet, cfg *ServerConfig) {
+	fs.StringVar(&cfg.Port, "port", "8181", "HTTP listen port")
+	fs.StringVar(&cfg.StaticDir, "static-dir", ".", "directory static files are served from")
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", 15*time.Second, "maximum duration for reading a request")
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", 15*time.Second, "maximum duration before timing out writes of a response")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", 60*time.Second, "keep-alive idle timeout")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "graceful shutdown deadline")
+	fs.DurationVar(&cfg.ComputeTimeout, "compute-timeout", 10*time.Second, "maximum duration of the recommendations, analytics or search a request runs")
+	fs.IntVar(&cfg.MaxHeaderBytes, "max-header-bytes", 1<<20, "maximum request header size in bytes")
+	fs.Int64Var(&cfg.MaxBodyBytes, "max-body-bytes", 1<<20, "maximum API request body size in bytes")
+	fs.Int64Var(&cfg.MaxImportBytes, "max-import-bytes", 10<<20, "maximum bulk import upload size in bytes")
+	fs.IntVar(&cfg.MaxImportRows, "max-import-rows", 10000, "maximum rows accepted by a single bulk import")
+
+	fs.StringVar(&cfg.TLSCertFile, "tls-cert-file", "", "TLS certificate file (enables HTTPS and HTTP/2)")
+	fs.StringVar(&cfg.TLSKeyFile, "tls-key-file", "", "TLS private key file")
+	fs.BoolVar(&cfg.EnableH2C, "enable-h2c", false, "allow HTTP/2 over cleartext connections")
+
+	fs.StringVar(&cfg.CORSAllowedOrigins, "cors-allowed-origins", "*", "comma-separated origins allowed by CORS, or *")
+	fs.BoolVar(&cfg.EnableCrossOriginIsolation, "enable-cross-origin-isolation", false, "send COOP/COEP headers for SharedArrayBuffer support")
+	fs.StringVar(&cfg.COEPPolicy, "coep-policy", "require-corp", "Cross-Origin-Embedder-Policy value (require-corp or credentialless)")
+
+	fs.IntVar(&cfg.MaxMatrixSize, "max-matrix-size", 1000, "largest matrix dimension accepted by benchmarks")
+	fs.IntVar(&cfg.MaxMandelbrotPixels, "max-mandelbrot-pixels", 4000*3000, "largest width*height accepted by the Mandelbrot benchmark")
+	fs.IntVar(&cfg.MaxMandelbrotIteration, "max-mandelbrot-iterations", 10000, "largest iteration count accepted by the Mandelbrot benchmark")
+	fs.IntVar(&cfg.MaxHashCount, "max-hash-count", 5000000, "largest hash count accepted by the hash benchmark")
+
+	fs.StringVar(&cfg.BenchmarkSigningKey, "benchmark-signing-key", "", "HMAC key for benchmark challenges (random per process if empty)")
+	fs.DurationVar(&cfg.BenchmarkChallengeTTL, "benchmark-challenge-ttl", 10*time.Minute, "how long a benchmark challenge can be redeemed")
+
+	fs.IntVar(&cfg.JobWorkers, "job-workers", 2, "benchmark jobs executed concurrently")
+	fs.IntVar(&cfg.JobQueueSize, "job-queue-size", 32, "benchmark jobs that may wait for a worker")
+
+	fs.DurationVar(&cfg.MaxLoadTestDuration, "max-loadtest-duration", 10*time.Second, "longest load test /api/loadtest runs")
+	fs.IntVar(&cfg.MaxLoadTestConcurrency, "max-loadtest-concurrency", 64, "most workers a load test may use")
+
+	fs.IntVar(&cfg.MaxRecalculateWorkers, "max-recalculate-workers", 8, "most orders /api/orders/recalculate prices at once")
+	fs.IntVar(&cfg.ValidationCacheSize, "validation-cache-size", 10000, "validation results imports and load tests keep for repeated records (0 disables)")
+
+	fs.IntVar(&cfg.MaxDemoRecords, "max-demo-records", 100000, "most users, products and orders /api/demo-data generates at once")
+
+	fs.DurationVar(&cfg.SandboxTTL, "sandbox-ttl", 30*time.Minute, "how long an unused sandbox dataset is kept")
+	fs.IntVar(&cfg.MaxSandboxes, "max-sandboxes", 100, "maximum number of sandbox datasets at once")
+
+	fs.DurationVar(&cfg.SessionTTL, "session-ttl", 24*time.Hour, "how long an idle session (and its cart) is kept")
+	fs.IntVar(&cfg.MaxSessions, "max-sessions", 10000, "maximum number of sessions kept in memory")
+
+	fs.DurationVar(&cfg.IdempotencyTTL, "idempotency-ttl", 24*time.Hour, "how long a response is replayed for a repeated Idempotency-Key")
+	fs.IntVar(&cfg.MaxIdempotencyKeys, "max-idempotency-keys", 10000, "maximum number of Idempotency-Key responses kept in memory")
+
+	fs.StringVar(&cfg.RatesFile, "rates-file", "", "JSON exchange rate table loaded at startup")
+	fs.StringVar(&cfg.RatesURL, "rates-url", "", "provider endpoint serving the exchange rate table as JSON")
+	fs.DurationVar(&cfg.RatesRefresh, "rates-refresh", time.Hour, "how often exchange rates are refetched from -rates-url")
+
+	fs.StringVar(&cfg.ValidationRulesFile, "validation-rules-file", "", "JSON validation ruleset loaded at startup instead of the built-in rules")
+
+	fs.StringVar(&cfg.AdminToken, "admin-token", "", "bearer token required by the webhook administration endpoints")
+	fs.DurationVar(&cfg.WebhookTimeout, "webhook-timeout", 5*time.Second, "timeout for each webhook delivery attempt")
+	fs.IntVar(&cfg.WebhookMaxAttempts, "webhook-max-attempts", 5, "delivery attempts before a webhook event is marked failed")
+
+	fs.BoolVar(&cfg.DynamicPricing, "dynamic-pricing", false, "price the catalog for recent demand and stock levels")
+	fs.Float64Var(&cfg.DynamicPriceMin, "dynamic-price-min", DefaultDynamicPricing.MinFactor, "lowest dynamic price as a fraction of the list price")
+	fs.Float64Var(&cfg.DynamicPriceMax, "dynamic-price-max", DefaultDynamicPricing.MaxFactor, "highest dynamic price as a fraction of the list price")
+	fs.DurationVar(&cfg.DynamicPriceWindow, "dynamic-price-window", time.Duration(DefaultDynamicPricing.WindowDays)*24*time.Hour, "order history demand is measured over (whole days)")
+	fs.DurationVar(&cfg.DynamicPriceTargetCover, "dynamic-price-target-cover", time.Duration(DefaultDynamicPricing.TargetCoverDays)*24*time.Hour, "stock cover at the recent sales pace that keeps the list price")
+
+	fs.Float64Var(&cfg.CLVMargin, "clv-margin", DefaultCLVSettings.Margin, "fraction of a sale kept as profit in customer lifetime value")
+	fs.Float64Var(&cfg.CLVDiscountRate, "clv-discount-rate", DefaultCLVSettings.DiscountRate, "annual rate future customer lifetime value is discounted at")
+
+	fs.BoolVar(&cfg.DevMode, "dev", false, "reload open pages when static files change, and serve them uncached")
+
+	fs.StringVar(&cfg.FaultPaths, "fault-paths", "", "comma-separated paths (a trailing * matches a prefix) to inject latency and errors into")
+	fs.DurationVar(&cfg.FaultLatency, "fault-latency", 0, "delay added to each request to -fault-paths")
+	fs.DurationVar(&cfg.FaultJitter, "fault-jitter", 0, "up to this much more random delay on -fault-paths")
+	fs.Float64Var(&cfg.FaultErrorRate, "fault-error-rate", 0, "fraction of requests to -fault-paths that fail, 0 to 1")
+	fs.IntVar(&cfg.FaultErrorStatus, "fault-error-status", http.StatusServiceUnavailable, "status of the failures -fault-error-rate injects")
+
+	fs.BoolVar(&cfg.EnablePprof, "enable-pprof", false, "mount /debug/pprof and /api/benchmark/profile")
+	fs.StringVar(&cfg.PprofToken, "pprof-token", "", "token required by the profiling endpoints")
+
+	fs.StringVar(&cfg.StoragePath, "storage-path", defaultStoragePath(), "directory for persisted server data, kept out of -static-dir")
+	fs.StringVar(&cfg.SnapshotFile, "snapshot-file", "", "data store snapshot file, .json or .gob (default <storage-path>/store_snapshot.json)")
+	fs.DurationVar(&cfg.SnapshotInterval, "snapshot-interval", 0, "how often the data store is snapshotted when it changed; 0 snapshots only on request")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "log level (debug, info, warn, error)")
+	fs.StringVar(&cfg.LogFile, "log-file", "", "also write JSON logs to this file, rotated by size and age")
+	fs.IntVar(&cfg.LogMaxSizeMB, "log-max-size", 100, "megabytes a log file may reach before it is rotated")
+	fs.DurationVar(&cfg.LogMaxAge, "log-max-age", 24*time.Hour, "age at which a log file is rotated")
+	fs.IntVar(&cfg.LogMaxBackups, "log-max-backups", 7, "rotated log files to keep")
+	fs.BoolVar(&cfg.AccessLog, "access-log", false, "log every HTTP request")
+}
+
+// loadServerConfig builds the configuration from command-line args, the
+// environment and an optional config file (-config or CONFIG_FILE).
+func loadServerConfig(args []string, lookupEnv func(string) (string, bool)) (*ServerConfig, error) {
+	cfg := &ServerConfig{}
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	bindConfigFlags(fs, cfg)
+
+	configFile := fs.String("config", "", "path to a JSON or YAML config file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if *configFile == "" {
+		if path, ok := lookupEnv("CONFIG_FILE"); ok {
+			*configFile = path
+		}
+	}
+
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+			if name == "config" || explicit[name] {
+				continue
+			}
+			if fs.Lookup(name) == nil {
+				return nil, fmt.Errorf("config file %s: unknown setting %q", *configFile, key)
+			}
+			if err := fs.Set(name, value); err != nil {
+				return nil, fmt.Errorf("config file %s: %s: %w", *configFile, key, err)
+			}
+		}
+	}
+
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] || f.Name == "config" || envErr != nil {
+			return
+		}
+		if value, ok := lookupEnv(configEnvName(f.Name)); ok && value != "" {
+			if err := fs.Set(f.Name, value); err != nil {
+				envErr = fmt.Errorf("environment %s: %w", configEnvName(f.Name), err)
+			}
+		}
+	})
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	return cfg, cfg.validate()
+}
+
+// configEnvName maps a flag name to its environment variable.
+func configEnvName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// readConfigFile parses a JSON or flat YAML config file into string values.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return parseJSONConfig(data)
+	case ".yaml", ".yml":
+		return parseYAMLConfig(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format (use .json, .yaml or .yml)", path)
+	}
+}
+
+func parseJSONConfig(data []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parse JSON config: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case json.Number, bool:
+			values[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("parse JSON config: %s must be a string, number or boolean", key)
+		}
+	}
+	return values, nil
+}
+
+// parseYAMLConfig reads the flat "key: value" subset of YAML: one setting per
+// line, # comments, and optionally quoted scalar values. Nesting is not needed
+// because every setting is a top-level key.
+func parseYAMLConfig(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("parse YAML config: line %d: expected key: value", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
+
+// validate checks settings that the flag types can't express.
+func (cfg *ServerConfig) validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("port %q is not a valid TCP port", cfg.Port))
+	}
+	for name, d := range map[string]time.Duration{
+		"read-timeout":     cfg.ReadTimeout,
+		"write-timeout":    cfg.WriteTimeout,
+		"idle-timeout":     cfg.IdleTimeout,
+		"shutdown-timeout": cfg.ShutdownTimeout,
+		"compute-timeout":  cfg.ComputeTimeout,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+	if cfg.MaxBodyBytes <= 0 || cfg.MaxImportBytes <= 0 || cfg.MaxImportRows <= 0 {
+		errs = append(errs, errors.New("max-body-bytes, max-import-bytes and max-import-rows must be positive"))
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls-cert-file and tls-key-file must be set together"))
+	}
+	if cfg.COEPPolicy != "require-corp" && cfg.COEPPolicy != "credentialless" {
+		errs = append(errs, fmt.Errorf("coep-policy %q must be require-corp or credentialless", cfg.COEPPolicy))
+	}
+	if _, err := cfg.slogLevel(); err != nil {
+		errs = append(errs, err)
+	}
+	if ext := filepath.Ext(cfg.SnapshotFile); cfg.SnapshotFile != "" && ext != ".json" && ext != ".gob" {
+		errs = append(errs, fmt.Errorf("snapshot-file %q must end in .json or .gob", cfg.SnapshotFile))
+	}
+	if cfg.SnapshotInterval < 0 {
+		errs = append(errs, errors.New("snapshot-interval must not be negative"))
+	}
+	if cfg.LogMaxSizeMB <= 0 || cfg.LogMaxAge <= 0 || cfg.LogMaxBackups < 0 {
+		errs = append(errs, errors.New("log-max-size and log-max-age must be positive and log-max-backups not negative"))
+	}
+	if cfg.MaxMatrixSize <= 0 || cfg.MaxMandelbrotPixels <= 0 || cfg.MaxMandelbrotIteration <= 0 || cfg.MaxHashCount <= 0 {
+		errs = append(errs, errors.New("benchmark limits must be positive"))
+	}
+	if cfg.BenchmarkChallengeTTL <= 0 {
+		errs = append(errs, errors.New("benchmark-challenge-ttl must be positive"))
+	}
+	if cfg.JobWorkers <= 0 || cfg.JobQueueSize <= 0 {
+		errs = append(errs, errors.New("job-workers and job-queue-size must be positive"))
+	}
+	if cfg.MaxLoadTestDuration <= 0 || cfg.MaxLoadTestConcurrency <= 0 {
+		errs = append(errs, errors.New("max-loadtest-duration and max-loadtest-concurrency must be positive"))
+	}
+	if cfg.MaxRecalculateWorkers <= 0 {
+		errs = append(errs, errors.New("max-recalculate-workers must be positive"))
+	}
+	if cfg.ValidationCacheSize < 0 {
+		errs = append(errs, errors.New("validation-cache-size must not be negative"))
+	}
+	if cfg.MaxDemoRecords <= 0 || cfg.MaxDemoRecords > MaxDemoRecords {
+		errs = append(errs, fmt.Errorf("max-demo-records must be between 1 and %d", MaxDemoRecords))
+	}
+	if cfg.SandboxTTL <= 0 || cfg.MaxSandboxes <= 0 {
+		errs = append(errs, errors.New("sandbox-ttl and max-sandboxes must be positive"))
+	}
+	if cfg.SessionTTL <= 0 || cfg.MaxSessions <= 0 {
+		errs = append(errs, errors.New("session-ttl and max-sessions must be positive"))
+	}
+	if cfg.IdempotencyTTL <= 0 || cfg.MaxIdempotencyKeys <= 0 {
+		errs = append(errs, errors.New("idempotency-ttl and max-idempotency-keys must be positive"))
+	}
+	if cfg.RatesRefresh <= 0 {
+		errs = append(errs, errors.New("rates-refresh must be positive"))
+	}
+	if cfg.WebhookTimeout <= 0 || cfg.WebhookMaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhook-timeout and webhook-max-attempts must be positive"))
+	}
+	if cfg.DynamicPriceMin <= 0 || cfg.DynamicPriceMin > 1 || cfg.DynamicPriceMax < 1 {
+		errs = append(errs, errors.New("dynamic-price-min must be in (0, 1] and dynamic-price-max at least 1"))
+	}
+	if cfg.DynamicPriceWindow < 24*time.Hour || cfg.DynamicPriceTargetCover < 24*time.Hour {
+		errs = append(errs, errors.New("dynamic-price-window and dynamic-price-target-cover must be at least a day"))
+	}
+	if cfg.FaultLatency < 0 || cfg.FaultJitter < 0 || cfg.FaultErrorRate < 0 || cfg.FaultErrorRate > 1 {
+		errs = append(errs, errors.New("fault-latency and fault-jitter must not be negative and fault-error-rate must be between 0 and 1"))
+	}
+	if cfg.FaultErrorStatus < 400 || cfg.FaultErrorStatus > 599 {
+		errs = append(errs, fmt.Errorf("fault-error-status %d must be a 4xx or 5xx status", cfg.FaultErrorStatus))
+	}
+	if !ValidateCLVSettings(cfg.clvSettings()).Valid {
+		errs = append(errs, errors.New("clv-margin and clv-discount-rate must be between 0 and 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// dynamicPricing is the pricing engine configuration.
+func (cfg *ServerConfig) dynamicPricing() DynamicPricing {
+	return DynamicPricing{
+		MinFactor:       cfg.DynamicPriceMin,
+		MaxFactor:       cfg.DynamicPriceMax,
+		WindowDays:      int(cfg.DynamicPriceWindow / (24 * time.Hour)),
+		TargetCoverDays: cfg.DynamicPriceTargetCover.Hours() / 24,
+	}
+}
+
+// defaultStoragePath is go-wasm-demo in the user's cache directory, outside
+// the static root the server is usually started in, so the snapshot and
+// history aren't served with the pages.
+func defaultStoragePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "go-wasm-demo")
+}
+
+// snapshotPath is the data store's snapshot file.
+func (cfg *ServerConfig) snapshotPath() string {
+	if cfg.SnapshotFile != "" {
+		return cfg.SnapshotFile
+	}
+	return filepath.Join(cfg.StoragePath, "store_snapshot.json")
+}
+
+// clvSettings are the customer lifetime value settings.
+func (cfg *ServerConfig) clvSettings() CLVSettings {
+	return CLVSettings{Margin: cfg.CLVMargin, DiscountRate: cfg.CLVDiscountRate}
+}
+
+// slogLevel converts LogLevel to a slog.Level.
+func (cfg *ServerConfig) slogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return 0, fmt.Errorf("log-level %q must be debug, info, warn or error", cfg.LogLevel)
+	}
+	return level, nil
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, or "" if the origin is not allowed.
+func (cfg *ServerConfig) allowedOrigin(origin string) string {
+	for _, allowed := range strings.Split(cfg.CORSAllowedOrigins, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withServerConfig applies mutate to a copy of the active configuration for
+// the duration of the test.
+func withServerConfig(t *testing.T, mutate func(cfg *ServerConfig)) {
+	t.Helper()
+	previous := serverConfig
+	cfg := *previous
+	mutate(&cfg)
+	serverConfig = &cfg
+	t.Cleanup(func() { serverConfig = previous })
+}
+
+func envFrom(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadServerConfig tests defaults, sources and their precedence
+func TestLoadServerConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := loadServerConfig(nil, envFrom(nil))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Port != "8181" || cfg.ReadTimeout != 15*time.Second || cfg.MaxBodyBytes != 1<<20 {
+			t.Errorf("Unexpected defaults: %+v", cfg)
+		}
+		if cfg.CORSAllowedOrigins != "*" || cfg.COEPPolicy != "require-corp" {
+			t.Errorf("Unexpected cross-origin defaults: %+v", cfg)
+		}
+	})
+
+	t.Run("LegacyEnvironmentNames", func(t *testing.T) {
+		cfg, err := loadServerConfig(nil, envFrom(map[string]string{
+			"PORT":         "9000",
+			"ENABLE_PPROF": "true",
+			"PPROF_TOKEN":  "secret",
+			"ENABLE_H2C":   "1",
+		}))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Port != "9000" || !cfg.EnablePprof || cfg.PprofToken != "secret" || !cfg.EnableH2C {
+			t.Errorf("Environment not applied: %+v", cfg)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		path := writeConfigFile(t, "server.json", `{"port": "7000", "read_timeout": "5s", "max-matrix-size": 200}`)
+		cfg, err := loadServerConfig(
+			[]string{"-config", path, "-port", "9999"},
+			envFrom(map[string]string{"PORT": "8000", "READ_TIMEOUT": "7s"}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Port != "9999" {
+			t.Errorf("Flag should win over environment and file, got port %s", cfg.Port)
+		}
+		if cfg.ReadTimeout != 7*time.Second {
+			t.Errorf("Environment should win over file, got %v", cfg.ReadTimeout)
+		}
+		if cfg.MaxMatrixSize != 200 {
+			t.Errorf("File should win over defaults, got %d", cfg.MaxMatrixSize)
+		}
+	})
+
+	t.Run("YAMLFileFromEnvironment", func(t *testing.T) {
+		path := writeConfigFile(t, "server.yaml", `# demo settings
+port: 8282
+log-level: "debug"
+cors_allowed_origins: https://a.example, https://b.example # trusted
+`)
+		cfg, err := loadServerConfig(nil, envFrom(map[string]string{"CONFIG_FILE": path}))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Port != "8282" || cfg.LogLevel != "debug" {
+			t.Errorf("YAML not applied: %+v", cfg)
+		}
+		if cfg.CORSAllowedOrigins != "https://a.example, https://b.example" {
+			t.Errorf("Trailing comment not stripped: %q", cfg.CORSAllowedOrigins)
+		}
+	})
+
+	t.Run("UnknownFileSetting", func(t *testing.T) {
+		path := writeConfigFile(t, "server.json", `{"prot": "7000"}`)
+		if _, err := loadServerConfig([]string{"-config", path}, envFrom(nil)); err == nil {
+			t.Error("Expected error for unknown setting")
+		}
+	})
+
+	t.Run("InvalidValues", func(t *testing.T) {
+		_, err := loadServerConfig(
+			[]string{"-port", "99999", "-coep-policy", "none", "-log-level", "loud", "-tls-cert-file", "cert.pem", "-dynamic-price-min", "1.5", "-clv-margin", "2", "-fault-error-rate", "1.5", "-fault-error-status", "200", "-max-demo-records", "0", "-max-recalculate-workers", "0", "-validation-cache-size", "-1", "-snapshot-file", "store.xml"},
+			envFrom(nil),
+		)
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+		for _, want := range []string{"port", "coep-policy", "log-level", "tls-key-file", "dynamic-price-min", "clv-margin", "fault-error-rate", "fault-error-status", "max-demo-records", "max-recalculate-workers", "validation-cache-size", "snapshot-file"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to mention %s, got: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("InvalidEnvironmentValue", func(t *testing.T) {
+		if _, err := loadServerConfig(nil, envFrom(map[string]string{"READ_TIMEOUT": "soon"})); err == nil {
+			t.Error("Expected error for unparsable duration")
+		}
+	})
+}
+
+// TestAllowedOrigin tests CORS origin matching
+func TestAllowedOrigin(t *testing.T) {
+	cfg := &ServerConfig{CORSAllowedOrigins: "https://a.example, https://b.example"}
+
+	if got := cfg.allowedOrigin("https://b.example"); got != "https://b.example" {
+		t.Errorf("Expected listed origin to be echoed, got %q", got)
+	}
+	if got := cfg.allowedOrigin("https://evil.example"); got != "" {
+		t.Errorf("Expected unlisted origin to be refused, got %q", got)
+	}
+
+	cfg.CORSAllowedOrigins = "*"
+	if got := cfg.allowedOrigin(""); got != "*" {
+		t.Errorf("Expected wildcard, got %q", got)
+	}
+}
+
+// TestBenchmarkLimits tests that oversized benchmark requests are rejected
+func TestBenchmarkLimits(t *testing.T) {
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.MaxMatrixSize = 50 })
+
+	for _, query := range []url.Values{
+		{"size": {"51"}},
+		{"size": {"0"}},
+	} {
+		if _, err := runServerBenchmark("matrix", query); err == nil {
+			t.Errorf("Expected size=%s to be rejected", query.Get("size"))
+		}
+	}
+	if _, err := runServerBenchmark("matrix", url.Values{"size": {"10"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := runServerBenchmark("hash", url.Values{"count": {"-5"}}); err == nil {
+		t.Error("Expected negative hash count to be rejected")
+	}
+}
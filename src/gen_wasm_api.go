@@ -0,0 +1,19 @@
+//go:build ignore
+
+// gen_wasm_api renders the WASM API registry of shared_wasm_api.go into
+// assets/js/wasm-api.js. Run it with go generate in src.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	out := flag.String("o", "../assets/js/wasm-api.js", "file to write")
+	flag.Parse()
+	if err := os.WriteFile(*out, RenderWasmAPIJS(wasmAPI), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
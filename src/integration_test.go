@@ -53,7 +53,7 @@ func TestServerAPIEndpoints(t *testing.T) {
 			"price":    99.99,
 			"category": "electronics",
 			"rating":   4.5,
-			"in_stock": true,
+			"on_hand":  25,
 		}
 
 		jsonData, _ := json.Marshal(testProduct)
@@ -78,6 +78,26 @@ func TestServerAPIEndpoints(t *testing.T) {
 		}
 	})
 
+	// Test phone validation endpoint
+	t.Run("PhoneValidationAPI", func(t *testing.T) {
+		for body, want := range map[string]phoneValidationResponse{
+			`{"number": "030 1234567", "country": "DE"}`: {Valid: true, E164: "+49301234567"},
+			`{"number": "555-0132", "country": "US"}`:    {Valid: false},
+		} {
+			req := httptest.NewRequest("POST", "/api/validate-phone", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			handleValidatePhone(w, req)
+
+			var result phoneValidationResponse
+			json.NewDecoder(w.Body).Decode(&result)
+			if w.Code != http.StatusOK || result.Valid != want.Valid || result.E164 != want.E164 || result.Valid == (len(result.Errors) > 0) {
+				t.Errorf("%s: expected %+v, got %d %+v", body, want, w.Code, result)
+			}
+		}
+	})
+
 	// Test order calculation endpoint
 	t.Run("OrderCalculationAPI", func(t *testing.T) {
 		testData := map[string]interface{}{
@@ -143,7 +163,7 @@ func TestServerAPIEndpoints(t *testing.T) {
 					"name":        "Product 1",
 					"price":       50.0,
 					"category":    "electronics",
-					"in_stock":    true,
+					"on_hand":     25,
 					"rating":      4.5,
 					"description": "Test product",
 				},
@@ -152,7 +172,7 @@ func TestServerAPIEndpoints(t *testing.T) {
 					"name":        "Product 2",
 					"price":       30.0,
 					"category":    "books",
-					"in_stock":    true,
+					"on_hand":     25,
 					"rating":      4.0,
 					"description": "Test book",
 				},
@@ -195,6 +215,60 @@ func TestServerAPIEndpoints(t *testing.T) {
 			t.Errorf("Too many recommendations: %d, expected <= 5", len(result))
 		}
 	})
+
+	// Test cross-sell and upsell endpoints against the demo catalog
+	t.Run("CrossSellsAndUpsellsAPI", func(t *testing.T) {
+		catalog := generateDemoProducts()
+		post := func(target string, handler http.HandlerFunc, body map[string]interface{}) []Product {
+			jsonData, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("POST", target, bytes.NewReader(jsonData)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", target, w.Code)
+			}
+			var result []Product
+			json.NewDecoder(w.Body).Decode(&result)
+			return result
+		}
+
+		book, _ := findProduct(catalog, 3)
+		crossSells := post("/api/recommend-cross-sells", handleRecommendCrossSells, map[string]interface{}{
+			"order": Order{Products: []Product{book}, Quantities: []int{1}}, "products": catalog,
+		})
+		if len(crossSells) == 0 || crossSells[0].ID != 4 {
+			t.Errorf("Expected the mug named as the book's accessory first, got %+v", crossSells)
+		}
+
+		cookbook, _ := findProduct(catalog, 8)
+		upsells := post("/api/recommend-upsells", handleRecommendUpsells, map[string]interface{}{"product": cookbook, "products": catalog})
+		if len(upsells) != 1 || upsells[0].ID != 3 {
+			t.Errorf("Expected the programming book as the cookbook's upsell, got %+v", upsells)
+		}
+	})
+
+	t.Run("AnalyticsFilterAPI", func(t *testing.T) {
+		analyze := func(filter map[string]interface{}) (int, UserAnalytics) {
+			jsonData, _ := json.Marshal(map[string]interface{}{"users": generateDemoUsers(), "orders": generateDemoOrders(), "filter": filter})
+			w := httptest.NewRecorder()
+			handleAnalyzeBehavior(w, httptest.NewRequest("POST", "/api/analyze-behavior", bytes.NewReader(jsonData)))
+			var analytics UserAnalytics
+			json.NewDecoder(w.Body).Decode(&analytics)
+			return w.Code, analytics
+		}
+
+		// Only the first demo user is a premium member in the US
+		code, analytics := analyze(map[string]interface{}{"country": "us", "premium_only": true})
+		if code != http.StatusOK || analytics.OrderValues.Count != 1 || analytics.PremiumPercentage != 100 || analytics.TopCountries[0].Country != "US" {
+			t.Errorf("Expected the US premium member's analytics, got %d %+v", code, analytics)
+		}
+		_, analytics = analyze(map[string]interface{}{"from": "2023-05-02", "category": "books"})
+		if analytics.OrderValues.Count != 1 || analytics.OrderValues.Min != 84.16 {
+			t.Errorf("Expected the second demo order only, got %+v", analytics.OrderValues)
+		}
+		if code, _ := analyze(map[string]interface{}{"from": "2023-06-01", "to": "2023-05-01"}); code != http.StatusBadRequest {
+			t.Errorf("Expected an empty range to be rejected, got %d", code)
+		}
+	})
 }
 
 // TestBenchmarkEndpoints tests the performance benchmark endpoints
@@ -203,7 +277,7 @@ func TestBenchmarkEndpoints(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/benchmark/matrix?size=50", nil)
 		w := httptest.NewRecorder()
 
-		handleMatrixBenchmark(w, req)
+		benchmarkHandler("matrix")(w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d", w.Code)
@@ -228,7 +302,7 @@ func TestBenchmarkEndpoints(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/benchmark/mandelbrot?width=200&height=150&iterations=50", nil)
 		w := httptest.NewRecorder()
 
-		handleMandelbrotBenchmark(w, req)
+		benchmarkHandler("mandelbrot")(w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d", w.Code)
@@ -253,7 +327,7 @@ func TestBenchmarkEndpoints(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/benchmark/hash?count=1000", nil)
 		w := httptest.NewRecorder()
 
-		handleHashBenchmark(w, req)
+		benchmarkHandler("hash")(w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d", w.Code)
@@ -273,6 +347,34 @@ func TestBenchmarkEndpoints(t *testing.T) {
 			t.Errorf("Wrong count: %v", count)
 		}
 	})
+	// Every registered benchmark is served and listed
+	t.Run("RegisteredBenchmarks", func(t *testing.T) {
+		mux := newServerMux()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/benchmarks", nil))
+		var infos []benchmarkInfo
+		if err := json.NewDecoder(w.Body).Decode(&infos); w.Code != http.StatusOK || err != nil {
+			t.Fatalf("Expected the benchmark list, got %d: %v", w.Code, err)
+		}
+		if len(infos) != 4 {
+			t.Errorf("Expected 4 benchmarks, got %+v", infos)
+		}
+
+		for _, info := range infos {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/benchmark/"+info.Name+"?size=8&width=16&height=12&count=10", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: expected status 200, got %d: %s", info.Name, w.Code, w.Body.String())
+			}
+		}
+
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/benchmark/raytracing?samples=64", nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "samples must be at most 32") {
+			t.Errorf("Expected the schema's bound to reject 64 samples, got %d: %s", w.Code, w.Body.String())
+		}
+	})
 }
 
 // TestErrorHandling tests error conditions in API endpoints
@@ -322,8 +424,8 @@ func TestCORSHeaders(t *testing.T) {
 
 	expectedHeaders := map[string]string{
 		"Access-Control-Allow-Origin":  "*",
-		"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
-		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization, X-Sandbox-ID, Idempotency-Key, traceparent, If-Match",
 	}
 
 	for header, expectedValue := range expectedHeaders {
@@ -469,7 +571,7 @@ func TestServerPerformance(t *testing.T) {
 				handleCalculateOrder(w, req)
 			default:
 				if strings.Contains(tc.path, "matrix") {
-					handleMatrixBenchmark(w, req)
+					benchmarkHandler("matrix")(w, req)
 				}
 			}
 
@@ -563,13 +665,12 @@ func TestDataConsistency(t *testing.T) {
 		var apiResult map[string]float64
 		json.NewDecoder(w.Body).Decode(&apiResult)
 
-		// Compare results (allowing small floating-point differences)
-		tolerance := 0.01
-		if absFloat(directOrder.Subtotal-apiResult["subtotal"]) > tolerance {
+		// Amounts are whole cents, so both sides agree exactly
+		if directOrder.Subtotal != apiResult["subtotal"] {
 			t.Errorf("Subtotal mismatch: direct=%f, api=%f", directOrder.Subtotal, apiResult["subtotal"])
 		}
 
-		if absFloat(directOrder.Total-apiResult["total"]) > tolerance {
+		if directOrder.Total != apiResult["total"] {
 			t.Errorf("Total mismatch: direct=%f, api=%f", directOrder.Total, apiResult["total"])
 		}
 	})
@@ -590,11 +691,3 @@ func TestStaticFileServing(t *testing.T) {
 		}
 	})
 }
-
-// Helper function for floating point comparison in tests
-func absFloat(x float64) float64 {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
@@ -0,0 +1,29 @@
+//go:build js && wasm && bench
+
+package main
+
+import (
+	"os"
+	"syscall/js"
+)
+
+// businessLogicBuilt reports that bench.wasm leaves the business logic out.
+const businessLogicBuilt = false
+
+// main of bench.wasm registers only the benchmark functions; pages fetch
+// it when the benchmarks are opened, next to logic.wasm for the business
+// logic.
+func main() {
+	setupConsoleLogging()
+	ensureBenchmarks()
+	js.Global().Set("initBenchmarksWasm", js.FuncOf(initBenchmarksWasm))
+	js.Global().Set("wasmApiInfo", js.FuncOf(wasmApiInfoWasm))
+	js.Global().Set("setLogLevelWasm", js.FuncOf(setLogLevelWasm))
+
+	if runningInNode() {
+		os.Exit(runHeadless(os.Args[1:]))
+	}
+
+	// Keep the program running
+	select {}
+}
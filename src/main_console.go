@@ -0,0 +1,177 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall/js"
+)
+
+// consoleLogLevel is the level the module logs from, set by setLogLevelWasm.
+var consoleLogLevel = new(slog.LevelVar)
+
+// wasmCalls numbers the calls wasmCallContext identifies.
+var wasmCalls atomic.Int64
+
+// setupConsoleLogging installs the module's default logger: the browser
+// console, or text lines on stderr under Node.js, where stdout is the job
+// results.
+func setupConsoleLogging() {
+	var handler slog.Handler = consoleHandler{}
+	if runningInNode() {
+		handler = slog.NewTextHandler(consoleErrorWriter{}, &slog.HandlerOptions{Level: consoleLogLevel})
+	}
+	slog.SetDefault(slog.New(logIDHandler{handler}))
+}
+
+// consoleErrorWriter writes lines with console.error. Writing os.Stderr
+// instead would deadlock in a call from JavaScript, which can't wait for
+// the asynchronous write.
+type consoleErrorWriter struct{}
+
+func (consoleErrorWriter) Write(p []byte) (int, error) {
+	js.Global().Get("console").Call("error", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// wasmCallContext is the context of one call from the page, identified as
+// request wasm-1, wasm-2 and so on, and traced with the call as the root
+// span, named after the bridge function.
+func wasmCallContext(name string) (context.Context, *Trace) {
+	ctx, trace := StartTrace(context.Background(), name, "", "")
+	return WithRequestID(ctx, "wasm-"+strconv.FormatInt(wasmCalls.Add(1), 10)), trace
+}
+
+// traceToJS finishes a call's trace and returns it for the call's result,
+// in the shape of the trace fields of the server's response meta.
+func traceToJS(trace *Trace) map[string]interface{} {
+	trace.Finish()
+	spans := []interface{}{}
+	for _, span := range trace.Spans() {
+		spans = append(spans, map[string]interface{}{
+			"name":        span.Name,
+			"span_id":     span.SpanID,
+			"parent_id":   span.ParentID,
+			"start_ms":    span.StartMs,
+			"duration_ms": span.DurationMs,
+		})
+	}
+	return map[string]interface{}{
+		"trace_id":      trace.ID,
+		"span_id":       trace.RootSpanID(),
+		"spans":         spans,
+		"dropped_spans": trace.Dropped(),
+	}
+}
+
+// consoleHandler logs each record as console.debug, info, warn or error of
+// its message and an object of its attributes, which the console shows
+// expandable.
+type consoleHandler struct {
+	attrs []slog.Attr
+	group []string
+}
+
+func (h consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= consoleLogLevel.Level()
+}
+
+func (h consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := map[string]interface{}{}
+	for _, attr := range h.attrs {
+		addConsoleAttr(fields, attr)
+	}
+	target := fields
+	for _, name := range h.group {
+		target = consoleGroup(target, name)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addConsoleAttr(target, attr)
+		return true
+	})
+
+	method := "info"
+	switch {
+	case record.Level >= slog.LevelError:
+		method = "error"
+	case record.Level >= slog.LevelWarn:
+		method = "warn"
+	case record.Level < slog.LevelInfo:
+		method = "debug"
+	}
+	js.Global().Get("console").Call(method, record.Message, js.ValueOf(fields))
+	return nil
+}
+
+func (h consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// Attributes added in a group belong in it
+	for i := len(h.group) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: h.group[i], Value: slog.GroupValue(attrs...)}}
+	}
+	h.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return h
+}
+
+func (h consoleHandler) WithGroup(name string) slog.Handler {
+	h.group = append(append([]string{}, h.group...), name)
+	return h
+}
+
+// consoleGroup is the object of the group name in fields, created if need be.
+func consoleGroup(fields map[string]interface{}, name string) map[string]interface{} {
+	group, ok := fields[name].(map[string]interface{})
+	if !ok {
+		group = map[string]interface{}{}
+		fields[name] = group
+	}
+	return group
+}
+
+// addConsoleAttr adds attr to fields as a value js.ValueOf takes.
+func addConsoleAttr(fields map[string]interface{}, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := consoleGroup(fields, attr.Key)
+		for _, member := range value.Group() {
+			addConsoleAttr(group, member)
+		}
+	case slog.KindString:
+		fields[attr.Key] = value.String()
+	case slog.KindInt64:
+		fields[attr.Key] = value.Int64()
+	case slog.KindUint64:
+		fields[attr.Key] = float64(value.Uint64())
+	case slog.KindFloat64:
+		fields[attr.Key] = value.Float64()
+	case slog.KindBool:
+		fields[attr.Key] = value.Bool()
+	default:
+		fields[attr.Key] = value.String()
+	}
+}
+
+// setLogLevelWasm sets the level the module logs from: debug, info, warn or
+// error.
+func setLogLevelWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected a level",
+		}
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(args[0].String())); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid log level: " + err.Error(),
+		}
+	}
+	consoleLogLevel.Set(level)
+	return map[string]interface{}{
+		"error": "",
+		"level": level.String(),
+	}
+}
@@ -0,0 +1,61 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"runtime"
+	"syscall/js"
+)
+
+// Build profiles, from the build tags a module was built with.
+const (
+	ProfileFull   = "full"   // main.wasm: everything
+	ProfileLogic  = "logic"  // logic.wasm: no benchmarks
+	ProfileBench  = "bench"  // bench.wasm: only benchmarks
+	ProfileLite   = "lite"   // no regexp, invoice templates or benchmark variants
+	ProfileTinyGo = "tinygo" // the lite file set, built with TinyGo
+)
+
+// buildProfile names the profile of this module.
+func buildProfile() string {
+	switch {
+	case !businessLogicBuilt:
+		return ProfileBench
+	case runtime.Compiler == "tinygo":
+		return ProfileTinyGo
+	case !regexpBuilt:
+		return ProfileLite
+	case !benchmarksBuilt:
+		return ProfileLogic
+	}
+	return ProfileFull
+}
+
+// benchmarkFamiliesValue lists the benchmark families compiled in for
+// JavaScript.
+func benchmarkFamiliesValue() []interface{} {
+	families := []interface{}{}
+	for _, name := range benchmarkFamilyNames() {
+		families = append(families, name)
+	}
+	return families
+}
+
+// wasmApiInfoWasm describes the module: its build profile, toolchain and
+// which optional parts it carries.
+func wasmApiInfoWasm(this js.Value, args []js.Value) interface{} {
+	return map[string]interface{}{
+		"error":      "",
+		"profile":    buildProfile(),
+		"compiler":   runtime.Compiler,
+		"go_version": runtime.Version(),
+		"features": map[string]interface{}{
+			"business_logic":     businessLogicBuilt,
+			"benchmarks":         benchmarksBuilt,
+			"benchmark_variants": benchmarkVariantsBuilt,
+			"regexp":             regexpBuilt,
+			"invoice_templates":  invoiceTemplatesBuilt,
+		},
+		"benchmark_families": benchmarkFamiliesValue(),
+	}
+}
@@ -0,0 +1,94 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"syscall/js"
+)
+
+// runningInNode reports whether the module runs under Node.js rather than
+// in a page: there is a process but no document.
+func runningInNode() bool {
+	global := js.Global()
+	return global.Get("document").IsUndefined() && global.Get("process").Type() == js.TypeObject
+}
+
+// runHeadless runs the job spec named by args against the registered
+// functions and prints the results to stdout. It returns the exit code: 2
+// when the spec can't be read, 1 when a job failed or reported an error.
+func runHeadless(args []string) int {
+	data, err := headlessSpec(args, os.Stdin)
+	if err == nil {
+		var jobs []HeadlessJob
+		var single bool
+		if jobs, single, err = ParseHeadlessJobs(data); err == nil {
+			return printHeadlessResults(jobs, single)
+		}
+	}
+	printHeadlessJSON(map[string]interface{}{"error": err.Error()})
+	return 2
+}
+
+// printHeadlessResults runs each job and prints its result, a lone result
+// for a single job.
+func printHeadlessResults(jobs []HeadlessJob, single bool) int {
+	code := 0
+	results := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		result, ok := runHeadlessJob(job)
+		if !ok {
+			code = 1
+		}
+		results[i] = result
+	}
+	if single {
+		printHeadlessJSON(results[0])
+	} else {
+		printHeadlessJSON(results)
+	}
+	return code
+}
+
+// runHeadlessJob calls the job's function as a page would, returning its
+// result as JSON and whether it succeeded.
+func runHeadlessJob(job HeadlessJob) (interface{}, bool) {
+	fn := js.Global().Get(job.Function)
+	if fn.Type() != js.TypeFunction {
+		return map[string]interface{}{"error": fmt.Sprintf("unknown function %q", job.Function)}, false
+	}
+	args := make([]interface{}, len(job.Args))
+	for i, arg := range job.Args {
+		args[i] = js.ValueOf(arg)
+	}
+
+	value := fn.Invoke(args...)
+	if value.IsUndefined() {
+		return nil, true
+	}
+	if js.Global().Get("ArrayBuffer").Call("isView", value).Bool() {
+		// Typed arrays, as the benchmarks return, would stringify as objects
+		value = js.Global().Get("Array").Call("from", value)
+	}
+	var result interface{}
+	if err := json.Unmarshal([]byte(js.Global().Get("JSON").Call("stringify", value).String()), &result); err != nil {
+		return map[string]interface{}{"error": "result is not JSON: " + err.Error()}, false
+	}
+	if value.Type() == js.TypeObject {
+		if message := value.Get("error"); message.Type() == js.TypeString && message.String() != "" {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+// printHeadlessJSON writes v to stdout as one line of JSON.
+func printHeadlessJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		encoder.Encode(map[string]string{"error": err.Error()})
+	}
+}
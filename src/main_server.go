@@ -4,64 +4,162 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"time"
+
+	"go-wasm-demo/internal/benchkernels"
 )
 
 func main() {
-	// Get port from environment variable or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8181"
+	// "server logic ..." runs the business logic on stdin instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "logic" {
+		rebuildRecommendations(demoStore, time.Now())
+		os.Exit(runLogicCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	// Load configuration from flags, environment and optional config file
+	cfg, err := loadServerConfig(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	serverConfig = cfg
+
+	closeLogs, err := setupLogging(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	defer closeLogs()
+
+	// Persist benchmark history under the storage path
+	historyPath := filepath.Join(cfg.StoragePath, "benchmark_history.jsonl")
+	if store, err := openBenchmarkHistory(historyPath); err != nil {
+		log.Printf("⚠️  Benchmark history will not be persisted: %v", err)
+	} else {
+		benchmarkHistory = store
+	}
+
+	// Sandbox datasets, sessions and replayable responses expire after
+	// -sandbox-ttl/-session-ttl/-idempotency-ttl
+	sandboxes = newSandboxRegistry(cfg.SandboxTTL, cfg.MaxSandboxes)
+	sessions = newSessionStore(cfg.SessionTTL, cfg.MaxSessions)
+	idempotencyKeys = newIdempotencyStore(cfg.IdempotencyTTL, cfg.MaxIdempotencyKeys)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go sandboxes.runCleanup(cleanupCtx)
+	go sessions.runCleanup(cleanupCtx)
+	go idempotencyKeys.runCleanup(cleanupCtx)
+
+	// Imports and load tests share one cache of validation results
+	validationCache = NewValidationCache(cfg.ValidationCacheSize)
+
+	// Exchange rates: a provider endpoint wins over a file, and either over
+	// the built-in demo rates
+	if cfg.RatesFile != "" {
+		if err := loadExchangeRatesFile(cfg.RatesFile); err != nil {
+			log.Printf("⚠️  Using built-in exchange rates: %v", err)
+		}
+	}
+	if cfg.RatesURL != "" {
+		client := &http.Client{Timeout: 10 * time.Second}
+		if err := fetchExchangeRates(cleanupCtx, client, cfg.RatesURL); err != nil {
+			log.Printf("⚠️  Failed to fetch exchange rates: %v", err)
+		}
+		go runRatesRefresh(cleanupCtx, client, cfg.RatesURL, cfg.RatesRefresh)
+	}
+
+	if cfg.ValidationRulesFile != "" {
+		if err := loadValidationRulesFile(cfg.ValidationRulesFile); err != nil {
+			log.Printf("⚠️  Using built-in validation rules: %v", err)
+		}
 	}
 
+	SetCLVSettings(cfg.clvSettings())
+
+	if err := loadRecommendationWeightsFile(recommendationWeightsPath()); err != nil {
+		log.Printf("⚠️  Using default recommendation weights: %v", err)
+	}
+
+	// Pick up the data as it was when the server last stopped
+	if info, ok, err := restoreStoreSnapshotFile(demoStore, cfg.snapshotPath()); err != nil {
+		log.Printf("⚠️  Starting from the demo data: %v", err)
+	} else if ok {
+		log.Printf("Restored %d users, %d products and %d orders from %s", info.Users, info.Products, info.Orders, info.File)
+	}
+	if cfg.SnapshotInterval > 0 {
+		go runStoreSnapshots(cleanupCtx, demoStore, cfg.snapshotPath(), cfg.SnapshotInterval)
+	}
+
+	// Recommendations blend in what the demo orders bought together, and
+	// who bought what
+	rebuildRecommendations(demoStore, time.Now())
+
+	benchmarkChallenges = newChallengeIssuer([]byte(cfg.BenchmarkSigningKey), cfg.BenchmarkChallengeTTL)
+
+	webhooks = newWebhookDispatcher(cfg.WebhookTimeout, cfg.WebhookMaxAttempts, time.Second)
+
 	// Setup HTTP server with timeouts
 	server := &http.Server{
-		Addr:           ":" + port,
-		ReadTimeout:    15 * time.Second,
-		WriteTimeout:   15 * time.Second,
-		IdleTimeout:    60 * time.Second,
-		MaxHeaderBytes: 1 << 20, // 1MB
+		Addr:           ":" + cfg.Port,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
 	}
 
-	// Serve static files
-	http.HandleFunc("/", serveStaticFile)
-
-	// API endpoints using shared business logic
-	http.HandleFunc("/api/validate-user", handleValidateUser)
-	http.HandleFunc("/api/validate-product", handleValidateProduct)
-	http.HandleFunc("/api/calculate-order", handleCalculateOrder)
-	http.HandleFunc("/api/recommend-products", handleRecommendProducts)
-	http.HandleFunc("/api/analyze-behavior", handleAnalyzeBehavior)
+	server.Handler = newServerHandler()
 
-	// Demo data endpoints
-	http.HandleFunc("/api/demo-users", handleDemoUsers)
-	http.HandleFunc("/api/demo-products", handleDemoProducts)
-	http.HandleFunc("/api/demo-orders", handleDemoOrders)
+	// Generate main.wasm.gz up front so it can be served precompressed.
+	// Development mode serves the files uncompressed, and watches them
+	// instead.
+	if cfg.DevMode {
+		go watchStaticFiles(cleanupCtx, cfg.StaticDir, devWatchInterval, devReloads.publish)
+	} else if err := precompressStaticAssets(); err != nil {
+		log.Printf("⚠️  Failed to precompress static assets: %v", err)
+	}
 
-	// Performance benchmark endpoints
-	http.HandleFunc("/api/benchmark/matrix", handleMatrixBenchmark)
-	http.HandleFunc("/api/benchmark/mandelbrot", handleMandelbrotBenchmark)
-	http.HandleFunc("/api/benchmark/hash", handleHashBenchmark)
+	// HTTP/2 is negotiated automatically over TLS (-tls-cert-file/-tls-key-file).
+	// -enable-h2c additionally allows HTTP/2 over cleartext for local testing,
+	// so wasm_exec.js and main.wasm are multiplexed on a single connection.
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+	if cfg.EnableH2C {
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
 
 	// Setup graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		fmt.Printf("🚀 Server starting on http://localhost:%s\n", port)
+		scheme := "http"
+		if cfg.TLSCertFile != "" {
+			scheme = "https"
+		}
+		fmt.Printf("🚀 Server starting on %s://localhost:%s\n", scheme, cfg.Port)
 		fmt.Println("📊 Visit /server.html for server-side demo")
 		fmt.Println("🌐 Visit / for WebAssembly demo")
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if scheme == "https" {
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
@@ -71,460 +169,891 @@ func main() {
 	fmt.Println("\n🛑 Shutting down server gracefully...")
 
 	// Create a deadline for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	// Finish running benchmark jobs first so their event streams can close
+	if err := benchmarkJobs().shutdown(ctx); err != nil {
+		log.Printf("Benchmark jobs still running at shutdown: %v", err)
+	}
+
+	// End the demo data streams, which would otherwise stay open
+	dataChanges.close()
+	devReloads.close()
+
+	// Give webhook deliveries in flight a chance to complete
+	if err := webhooks.shutdown(ctx); err != nil {
+		log.Printf("Webhook deliveries still in flight at shutdown: %v", err)
+	}
+
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	} else {
 		fmt.Println("✅ Server shut down successfully")
 	}
-}
 
-func serveStaticFile(w http.ResponseWriter, r *http.Request) {
-	// Handle root path
-	if r.URL.Path == "/" {
-		http.ServeFile(w, r, "./index.html")
-		return
+	// Keep the last changes, which the periodic snapshots may have missed
+	if cfg.SnapshotInterval > 0 {
+		if _, err := saveStoreSnapshot(demoStore, cfg.snapshotPath(), time.Now()); err != nil {
+			log.Printf("⚠️  Failed to write the store snapshot: %v", err)
+		}
 	}
+}
+
+// newServerMux builds the request router. A dedicated mux is used instead of
+// http.DefaultServeMux so that optional handlers (such as net/http/pprof, which
+// registers itself on the default mux at import time) are only exposed when
+// explicitly mounted.
+func newServerMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Serve static files
+	mux.HandleFunc("/", serveStaticFile)
+
+	// API endpoints are declared once in apiRoutes (server_routes.go) so the
+	// router and the generated OpenAPI document always agree; each is also
+	// mounted under /api/v1 and /api/v2 (server_versions.go)
+	registerAPIRoutes(mux, apiRoutes())
+
+	// Swagger UI for the generated OpenAPI document
+	mux.HandleFunc("/api/docs", handleAPIDocs)
+
+	// Optional profiling endpoints (disabled unless -enable-pprof is set)
+	registerProfilingRoutes(mux)
+
+	// Reload socket and script (only with -dev)
+	registerDevRoutes(mux)
+
+	return mux
+}
 
-	// Serve other static files
-	http.ServeFile(w, r, "."+r.URL.Path)
+// Request and response payloads for the business-logic endpoints
+type calculateOrderRequest struct {
+	Order Order `json:"order"`
+	User  User  `json:"user"`
+}
+
+type orderTotalsResponse struct {
+	Subtotal float64 `json:"subtotal"`
+	Tax      float64 `json:"tax"`
+	Shipping float64 `json:"shipping"`
+	Discount float64 `json:"discount"`
+	Total    float64 `json:"total"`
+	// Currency is only set for orders not priced in USD, and the line
+	// breakdown only for tax-inclusive orders, so existing clients see the
+	// same all-number response as before
+	Currency          string           `json:"currency,omitempty"`
+	IncludesTax       bool             `json:"includes_tax,omitempty"`
+	Lines             []PriceBreakdown `json:"lines,omitempty"`
+	EstimatedDelivery string           `json:"estimated_delivery,omitempty"`
+	Shipments         []Shipment       `json:"shipments,omitempty"`
+	// Only orders paying by gift card have an amount due other than the total
+	GiftCardAmount float64  `json:"gift_card_amount,omitempty"`
+	AmountDue      *float64 `json:"amount_due,omitempty"`
+	// Only orders redeeming a coupon or points itemize their discounts
+	Discounts  []DiscountLine     `json:"discounts,omitempty"`
+	Promotions []AppliedPromotion `json:"promotions,omitempty"`
+	// RiskScore is ScoreOrderRisk against the stored orders of user.id;
+	// the reasons are only listed for orders that score above zero
+	RiskScore   int      `json:"risk_score"`
+	RiskReasons []string `json:"risk_reasons,omitempty"`
+}
+
+type recommendProductsRequest struct {
+	User     User      `json:"user"`
+	Products []Product `json:"products"`
+	Order    Order     `json:"order"`
+	Wishlist Wishlist  `json:"wishlist"` // optional
+	// Diversity defaults to DefaultDiversity, and weights left out to those
+	// in use
+	Diversity *Diversity             `json:"diversity,omitempty"`
+	Weights   *RecommendationWeights `json:"weights,omitempty"`
+}
+
+// crossSellRequest is the body of POST /api/recommend-cross-sells.
+type crossSellRequest struct {
+	Order    Order     `json:"order"`
+	Products []Product `json:"products"`
+}
+
+// upsellRequest is the body of POST /api/recommend-upsells.
+type upsellRequest struct {
+	Product  Product   `json:"product"`
+	Products []Product `json:"products"`
+}
+
+// validatePhoneRequest is the body of POST /api/validate-phone.
+type validatePhoneRequest struct {
+	Number  string `json:"number" validate:"required"`
+	Country string `json:"country"`
+}
+
+// phoneValidationResponse is a checked phone number, in E.164 form when
+// it is valid.
+type phoneValidationResponse struct {
+	Valid  bool     `json:"valid"`
+	E164   string   `json:"e164,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+type analyzeBehaviorRequest struct {
+	Users  []User          `json:"users"`
+	Orders []Order         `json:"orders"`
+	Filter AnalyticsFilter `json:"filter"`
 }
 
 // API endpoint for user validation using shared business logic
 func handleValidateUser(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+	enableCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Add content length check to prevent memory issues
-	if r.ContentLength > 1024*1024 { // 1MB limit
-		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+	user := userRequests.get()
+	defer userRequests.put(user)
+	if !decodeRequest(w, r, user) {
 		return
 	}
 
-	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	// Use shared business logic - identical to WebAssembly version
+	result := ValidateUserContext(r.Context(), *user)
+
+	writeNegotiated(w, r, http.StatusOK, result)
+}
+
+// API endpoint for phone number validation using shared business logic
+func handleValidatePhone(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
 		return
 	}
 
-	// Use shared business logic - identical to WebAssembly version
-	result := ValidateUser(user)
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	var req validatePhoneRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+
+	// Use shared business logic - identical to WebAssembly version
+	response := phoneValidationResponse{Valid: true, Errors: []string{}}
+	if e164, err := ValidatePhone(req.Number, req.Country); err != nil {
+		response.Valid = false
+		response.Errors = append(response.Errors, err.Error())
+	} else {
+		response.E164 = e164
+	}
+
+	writeNegotiated(w, r, http.StatusOK, response)
 }
 
 // API endpoint for product validation using shared business logic
 func handleValidateProduct(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+	enableCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var product Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeRequest(w, r, &product) {
 		return
 	}
 
 	// Use shared business logic - identical to WebAssembly version
-	result := ValidateProduct(product)
+	result := ValidateProductContext(r.Context(), product)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	writeNegotiated(w, r, http.StatusOK, result)
 }
 
 // API endpoint for order calculation using shared business logic
 func handleCalculateOrder(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+	enableCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	requestData := orderRequests.get()
+	defer orderRequests.put(requestData)
+	if !decodeRequest(w, r, requestData) {
 		return
 	}
 
-	// Add content length check to prevent memory issues
-	if r.ContentLength > 1024*1024 { // 1MB limit
-		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+	fields := map[string]string{}
+	// Validate order has products, with a quantity each
+	switch err := CheckOrderLines(requestData.Order); {
+	case errors.Is(err, ErrEmptyOrder):
+		fields["order.products"] = "must contain at least one product"
+	case errors.Is(err, ErrQuantityMismatch):
+		fields["order.quantities"] = "must have one quantity per product"
+	}
+	// Validate user data is present
+	if requestData.User.Country == "" {
+		fields["user.country"] = "is required"
+	}
+	if err := CheckOrderCurrency(requestData.Order); err != nil {
+		fields["order.currency"] = err.Error()
+	}
+	if _, _, err := SelectedShipping(requestData.Order, requestData.User, orderPlacedDate(requestData.Order)); err != nil {
+		fields["order.shipping_service"] = err.Error()
+	}
+	if err := ValidateShipments(requestData.Order); err != nil {
+		fields["order.shipments"] = err.Error()
+	}
+	giftCardFieldErrors(fields, "order.gift_card_code", storeFor(r), &requestData.Order)
+	discountFieldErrors(fields, "order.", requestData.Order, requestData.User)
+	addressFieldErrors(fields, "order.shipping_address", requestData.Order.ShippingAddress)
+	addressFieldErrors(fields, "user.address", requestData.User.Address)
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
 		return
 	}
 
-	var requestData struct {
-		Order Order `json:"order"`
-		User  User  `json:"user"`
+	// Use shared business logic - identical to WebAssembly version
+	CalculateOrderTotalContext(r.Context(), &requestData.Order, requestData.User)
+
+	response := orderTotalsResponse{
+		Subtotal: requestData.Order.Subtotal,
+		Tax:      requestData.Order.Tax,
+		Shipping: requestData.Order.Shipping,
+		Discount: requestData.Order.Discount,
+		Total:    requestData.Order.Total,
+	}
+	if requestData.Order.Currency != DefaultCurrency {
+		response.Currency = requestData.Order.Currency
+	}
+	if requestData.Order.IncludesTax {
+		response.IncludesTax = true
+		response.Lines = requestData.Order.Lines
+	}
+	response.EstimatedDelivery = requestData.Order.EstimatedDelivery
+	response.Shipments = requestData.Order.Shipments
+	if requestData.Order.GiftCard != nil {
+		response.GiftCardAmount = requestData.Order.GiftCardAmount
+		response.AmountDue = &requestData.Order.AmountDue
+	}
+	if requestData.Order.CouponCode != "" || requestData.Order.LoyaltyPoints > 0 {
+		response.Discounts = requestData.Order.Discounts
 	}
+	response.Promotions = requestData.Order.Promotions
+	risk := ScoreOrderRisk(requestData.Order, requestData.User, storeFor(r).listOrders())
+	response.RiskScore, response.RiskReasons = risk.Score, risk.Reasons
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+	writeNegotiated(w, r, http.StatusOK, response)
+}
+
+// addressFieldErrors reports the problems with an optional address under
+// field, one per address field ("shipping_address.postal_code").
+func addressFieldErrors(fields map[string]string, field string, address *Address) {
+	if address == nil {
 		return
 	}
+	for _, fieldErr := range ValidateAddress(*address).FieldErrors {
+		if fieldErr.Severity == SeverityError {
+			fields[field+"."+fieldErr.Field] = fieldErr.Message
+		}
+	}
+}
 
-	// Validate order has products
-	if len(requestData.Order.Products) == 0 {
-		http.Error(w, "Order must contain at least one product", http.StatusBadRequest)
+// API endpoint for shipping quotes using the shared shipping engine
+func handleShippingQuotes(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
 		return
 	}
 
-	// Validate quantities match products
-	if len(requestData.Order.Products) != len(requestData.Order.Quantities) {
-		http.Error(w, "Product and quantity arrays must be the same length", http.StatusBadRequest)
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Validate user data is present
+	var requestData calculateOrderRequest
+	if !decodeRequest(w, r, &requestData) {
+		return
+	}
+
+	fields := map[string]string{}
+	switch err := CheckOrderLines(requestData.Order); {
+	case errors.Is(err, ErrEmptyOrder):
+		fields["order.products"] = "must contain at least one product"
+	case errors.Is(err, ErrQuantityMismatch):
+		fields["order.quantities"] = "must have one quantity per product"
+	}
 	if requestData.User.Country == "" {
-		http.Error(w, "User country is required", http.StatusBadRequest)
+		fields["user.country"] = "is required"
+	}
+	addressFieldErrors(fields, "order.shipping_address", requestData.Order.ShippingAddress)
+	addressFieldErrors(fields, "user.address", requestData.User.Address)
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
 		return
 	}
 
 	// Use shared business logic - identical to WebAssembly version
-	CalculateOrderTotal(&requestData.Order, requestData.User)
+	quotes := QuoteShipping(requestData.Order, requestData.User, orderPlacedDate(requestData.Order))
 
-	response := map[string]interface{}{
-		"subtotal": requestData.Order.Subtotal,
-		"tax":      requestData.Order.Tax,
-		"shipping": requestData.Order.Shipping,
-		"discount": requestData.Order.Discount,
-		"total":    requestData.Order.Total,
-	}
+	writeNegotiated(w, r, http.StatusOK, quotes)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
-	}
+// computeContext is the context a request's recommendations, analytics or
+// search run under: it is done when the client goes away or the compute
+// timeout passes, which stops the work.
+func computeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), serverConfig.ComputeTimeout)
+}
+
+// writeComputeStopped responds to a request whose work computeContext
+// stopped.
+func writeComputeStopped(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusServiceUnavailable, "Request stopped: "+err.Error())
 }
 
 // API endpoint for product recommendations using shared business logic
 func handleRecommendProducts(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+	enableCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	options := DefaultRecommendOptions()
+	requestData := recommendProductsRequest{Weights: &options.Weights}
+	if !decodeRequest(w, r, &requestData) {
+		return
+	}
+
+	if requestData.Diversity != nil {
+		options.Diversity = *requestData.Diversity
+	}
+	if requestData.Weights != nil {
+		options.Weights = *requestData.Weights
+	}
+	fields := map[string]string{}
+	for _, fieldErr := range ValidateDiversity(options.Diversity).FieldErrors {
+		fields["diversity."+fieldErr.Field] = fieldErr.Message
+	}
+	for _, fieldErr := range ValidateRecommendationWeights(options.Weights).FieldErrors {
+		fields["weights."+fieldErr.Field] = fieldErr.Message
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	// Use shared business logic - identical to WebAssembly version
+	ctx, cancel := computeContext(r)
+	defer cancel()
+	recommendations, err := ExplainRecommendationsContext(ctx, requestData.User, requestData.Products, requestData.Order, requestData.Wishlist, options)
+	if err != nil {
+		writeComputeStopped(w, err)
 		return
 	}
 
-	var requestData struct {
-		User     User      `json:"user"`
-		Products []Product `json:"products"`
-		Order    Order     `json:"order"`
+	writeNegotiated(w, r, http.StatusOK, recommendations)
+}
+
+// API endpoint for accessories to the products in an order
+func handleRecommendCrossSells(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	var requestData crossSellRequest
+	if !decodeRequest(w, r, &requestData) {
 		return
 	}
 
 	// Use shared business logic - identical to WebAssembly version
-	recommendations := RecommendProducts(requestData.User, requestData.Products, requestData.Order)
+	writeNegotiated(w, r, http.StatusOK, RecommendCrossSells(requestData.Order, requestData.Products))
+}
+
+// API endpoint for higher-tier alternatives to a product
+func handleRecommendUpsells(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recommendations)
+	var requestData upsellRequest
+	if !decodeRequest(w, r, &requestData) {
+		return
+	}
+	if requestData.Product.Price <= 0 {
+		writeFieldErrors(w, map[string]string{"product.price": "must be positive"})
+		return
+	}
+
+	// Use shared business logic - identical to WebAssembly version
+	writeNegotiated(w, r, http.StatusOK, RecommendUpsells(requestData.Product, requestData.Products))
 }
 
 // API endpoint for user behavior analysis using shared business logic
 func handleAnalyzeBehavior(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+	enableCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var requestData struct {
-		Users  []User  `json:"users"`
-		Orders []Order `json:"orders"`
+	requestData := behaviorRequests.get()
+	defer behaviorRequests.put(requestData)
+	if !decodeRequest(w, r, requestData) {
+		return
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if raw := r.URL.Query().Get("top_countries"); raw != "" {
+		n, err := parseTopCountries(raw)
+		if err != nil {
+			writeFieldErrors(w, map[string]string{"top_countries": err.Error()})
+			return
+		}
+		requestData.Filter.TopCountries = n
+	}
+	if result := ValidateAnalyticsFilter(requestData.Filter); !result.Valid {
+		fields := map[string]string{}
+		for _, fieldErr := range result.FieldErrors {
+			fields["filter."+fieldErr.Field] = fieldErr.Message
+		}
+		writeFieldErrors(w, fields)
 		return
 	}
 
 	// Use shared business logic - identical to WebAssembly version
-	analytics := AnalyzeUserBehavior(requestData.Users, requestData.Orders)
+	ctx, cancel := computeContext(r)
+	defer cancel()
+	analytics, err := AnalyzeUserBehaviorContext(ctx, requestData.Users, requestData.Orders, requestData.Filter)
+	if err != nil {
+		writeComputeStopped(w, err)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(analytics)
+	writeNegotiated(w, r, http.StatusOK, analytics)
 }
 
 // Demo data endpoints
 func handleDemoUsers(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	users := generateDemoUsers()
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users)
+	enableCORS(w, r)
+	writeCacheableJSON(w, r, storeFor(r).listUsers())
 }
 
 func handleDemoProducts(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	products := generateDemoProducts()
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	enableCORS(w, r)
+	writeCacheableJSON(w, r, storeFor(r).catalog(time.Now()))
 }
 
 func handleDemoOrders(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	orders := generateDemoOrders()
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(orders)
+	enableCORS(w, r)
+	writeCacheableJSON(w, r, storeFor(r).listOrders())
 }
 
-// Performance benchmark endpoints
-func handleMatrixBenchmark(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	size := 100
-	if sizeParam := r.URL.Query().Get("size"); sizeParam != "" {
-		if parsedSize, err := strconv.Atoi(sizeParam); err == nil {
-			size = parsedSize
-		}
-	}
-
-	result := benchmarkMatrixMultiply(size)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+// orderStatusRequest is the body of PUT /api/orders/{id}/status.
+type orderStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	// Version is the order's version, unless If-Match has it
+	Version int `json:"version,omitempty"`
 }
 
-func handleMandelbrotBenchmark(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	width, height := 400, 300
-	iterations := 100
+// handleOrderStatus moves an order to a new status and notifies
+// order.status_changed webhooks.
+func handleOrderStatus(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
 
-	if w := r.URL.Query().Get("width"); w != "" {
-		if parsed, err := strconv.Atoi(w); err == nil {
-			width = parsed
-		}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return
 	}
-	if h := r.URL.Query().Get("height"); h != "" {
-		if parsed, err := strconv.Atoi(h); err == nil {
-			height = parsed
-		}
+	var req orderStatusRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
 	}
-	if iter := r.URL.Query().Get("iterations"); iter != "" {
-		if parsed, err := strconv.Atoi(iter); err == nil {
-			iterations = parsed
-		}
+	version, ok := requestVersion(w, r, req.Version)
+	if !ok {
+		return
 	}
 
-	result := benchmarkMandelbrot(width, height, iterations)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
-}
-
-func handleHashBenchmark(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	count := 10000
-	if countParam := r.URL.Query().Get("count"); countParam != "" {
-		if parsed, err := strconv.Atoi(countParam); err == nil {
-			count = parsed
+	order, previous, err := storeFor(r).setOrderStatus(id, version, req.Status, isAdmin(r))
+	if errors.Is(err, errOrderNotFound) {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	if writeVersionConflict(w, err) {
+		return
+	}
+	if errors.Is(err, ErrRiskReview) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if previous != order.Status {
+		webhooks.publish(eventOrderStatusChanged, sandboxID(r), newOrderEventData(storeFor(r), eventOrderStatusChanged, order, previous))
+		dataChanges.publish(sandboxID(r), entityOrders, changeUpdated, []int{order.ID})
+		if stockSettled(previous, order.Status) {
+			dataChanges.publish(sandboxID(r), entityProducts, changeUpdated, orderProductIDs(order))
 		}
 	}
 
-	result := benchmarkSHA256(count)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	setVersionETag(w, order.Version)
+	writeJSON(w, r, http.StatusOK, order)
 }
 
-func enableCORS(w http.ResponseWriter) {
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// Security headers
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.Header().Set("X-Frame-Options", "DENY")
-	w.Header().Set("X-XSS-Protection", "1; mode=block")
-	w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+// shipmentStatusRequest is the body of PUT
+// /api/orders/{id}/shipments/{shipment_id}/status.
+type shipmentStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	// Version is the order's version, unless If-Match has it
+	Version int `json:"version,omitempty"`
 }
 
-// Demo data generators
-func generateDemoUsers() []User {
-	return []User{
-		{ID: 1, Email: "john.doe@example.com", Name: "John Doe", Age: 28, Country: "US", Premium: true, JoinDate: "2023-01-15"},
-		{ID: 2, Email: "jane.smith@example.com", Name: "Jane Smith", Age: 34, Country: "CA", Premium: false, JoinDate: "2023-02-20"},
-		{ID: 3, Email: "alice.johnson@example.com", Name: "Alice Johnson", Age: 22, Country: "UK", Premium: true, JoinDate: "2023-03-10"},
-		{ID: 4, Email: "bob.wilson@example.com", Name: "Bob Wilson", Age: 45, Country: "AU", Premium: false, JoinDate: "2023-01-30"},
-		{ID: 5, Email: "carol.brown@example.com", Name: "Carol Brown", Age: 31, Country: "DE", Premium: true, JoinDate: "2023-04-05"},
+// handleShipmentStatus moves one shipment of an order to a new status, and
+// the order along with it, notifying order.status_changed webhooks when the
+// order's status changes.
+func handleShipmentStatus(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
 	}
-}
-
-// Server-side benchmark implementations using the same algorithms
-func benchmarkMatrixMultiply(size int) map[string]interface{} {
-	start := time.Now()
-
-	// Create test matrices
-	matrixA := make([]float64, size*size)
-	matrixB := make([]float64, size*size)
-	result := make([]float64, size*size)
 
-	// Initialize with test data
-	for i := 0; i < size*size; i++ {
-		matrixA[i] = float64(i % 10)
-		matrixB[i] = float64((i * 2) % 10)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	shipmentID, err := strconv.Atoi(r.PathValue("shipment_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid shipment ID")
+		return
+	}
+	var req shipmentStatusRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	version, ok := requestVersion(w, r, req.Version)
+	if !ok {
+		return
 	}
 
-	// Matrix multiplication
-	for i := 0; i < size; i++ {
-		for k := 0; k < size; k++ {
-			aik := matrixA[i*size+k]
-			for j := 0; j < size; j++ {
-				result[i*size+j] += aik * matrixB[k*size+j]
-			}
+	order, previous, err := storeFor(r).setShipmentStatus(id, shipmentID, version, req.Status, isAdmin(r))
+	if errors.Is(err, errOrderNotFound) {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	if writeVersionConflict(w, err) {
+		return
+	}
+	if errors.Is(err, errShipmentNotFound) {
+		writeError(w, http.StatusNotFound, "Shipment not found")
+		return
+	}
+	if errors.Is(err, ErrRiskReview) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if previous != order.Status {
+		webhooks.publish(eventOrderStatusChanged, sandboxID(r), newOrderEventData(storeFor(r), eventOrderStatusChanged, order, previous))
+		if stockSettled(previous, order.Status) {
+			dataChanges.publish(sandboxID(r), entityProducts, changeUpdated, orderProductIDs(order))
 		}
 	}
+	dataChanges.publish(sandboxID(r), entityOrders, changeUpdated, []int{order.ID})
 
-	duration := time.Since(start)
-
-	return map[string]interface{}{
-		"operation":   "Matrix Multiplication",
-		"size":        fmt.Sprintf("%dx%d", size, size),
-		"duration_ms": float64(duration.Nanoseconds()) / 1000000,
-		"operations":  size * size * size,
-		"result_hash": int(result[0] + result[size-1] + result[len(result)-1]),
-	}
+	setVersionETag(w, order.Version)
+	writeJSON(w, r, http.StatusOK, order)
 }
 
-func benchmarkMandelbrot(width, height, iterations int) map[string]interface{} {
-	start := time.Now()
+// stockSettled reports whether moving an order between two statuses commits
+// or releases the stock it reserved.
+func stockSettled(previous, status string) bool {
+	holding := previous == "pending" || previous == "processing"
+	return holding && (status == "shipped" || status == "delivered" || status == "cancelled")
+}
 
-	xmin, xmax := -2.0, 1.0
-	ymin, ymax := -1.5, 1.5
+// orderProductIDs lists the IDs of an order's products.
+func orderProductIDs(order Order) []int {
+	ids := make([]int, 0, len(order.Products))
+	for _, product := range order.Products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
 
-	result := make([]int, width*height)
-	dx := (xmax - xmin) / float64(width)
-	dy := (ymax - ymin) / float64(height)
+// Performance benchmark endpoints
 
-	idx := 0
-	for py := 0; py < height; py++ {
-		cy := ymin + float64(py)*dy
-		for px := 0; px < width; px++ {
-			cx := xmin + float64(px)*dx
+// serveServerBenchmark runs a benchmark inline and records the result in the
+// benchmark history.
+func serveServerBenchmark(w http.ResponseWriter, r *http.Request, kind string) {
+	enableCORS(w, r)
+	bench, err := prepareServerBenchmark(kind, r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-			zx, zy := 0.0, 0.0
-			iter := 0
+	// A client that gives up stops the run
+	result, err := bench.Run(r.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "Benchmark stopped: "+err.Error())
+		return
+	}
+	recordServerBenchmark(bench, result)
 
-			for iter < iterations {
-				zx2 := zx * zx
-				zy2 := zy * zy
+	writeJSON(w, r, http.StatusOK, result)
+}
 
-				if zx2+zy2 > 4.0 {
-					break
-				}
+// benchmarkHandler serves GET /api/benchmark/<name> for a registered
+// benchmark.
+func benchmarkHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serveServerBenchmark(w, r, name)
+	}
+}
 
-				zy = (zx+zx)*zy + cy
-				zx = zx2 - zy2 + cx
-				iter++
-			}
+// benchmarkInfo describes a registered benchmark for GET /api/benchmarks.
+type benchmarkInfo struct {
+	Name   string               `json:"name"`
+	Params []benchkernels.Param `json:"params"`
+}
 
-			result[idx] = iter
-			idx++
-		}
+// handleBenchmarkList lists the benchmarks the server runs, with the
+// parameters their endpoints take.
+func handleBenchmarkList(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
 	}
 
-	duration := time.Since(start)
+	infos := []benchmarkInfo{}
+	for _, b := range benchkernels.All() {
+		infos = append(infos, benchmarkInfo{Name: b.Name(), Params: b.ParamsSchema()})
+	}
+	writeJSON(w, r, http.StatusOK, infos)
+}
 
-	return map[string]interface{}{
-		"operation":   "Mandelbrot Set",
-		"size":        fmt.Sprintf("%dx%d", width, height),
-		"iterations":  iterations,
-		"duration_ms": float64(duration.Nanoseconds()) / 1000000,
-		"pixels":      width * height,
-		"result_hash": result[0] + result[len(result)/2] + result[len(result)-1],
+// runServerBenchmark runs the named server-side benchmark, reading its
+// parameters from the query string with the same defaults as the individual
+// benchmark endpoints.
+func runServerBenchmark(kind string, query url.Values) (map[string]interface{}, error) {
+	bench, err := prepareServerBenchmark(kind, query)
+	if err != nil {
+		return nil, err
 	}
+	return bench.Run(context.Background())
 }
 
-func benchmarkSHA256(count int) map[string]interface{} {
-	start := time.Now()
+// serverBenchmark is a validated benchmark ready to run, with its parameters
+// resolved to their effective values. Run reports the run as the benchmark
+// endpoints respond with it.
+type serverBenchmark struct {
+	Kind   string
+	Params map[string]int
+	Run    func(ctx context.Context) (map[string]interface{}, error)
+}
 
-	data := "WebAssembly performance test data for hashing benchmark"
-	hash := 0
+// serverBenchmarkLimits check the parameters of the benchmarks with
+// configured limits, before their schema's own bounds.
+var serverBenchmarkLimits = map[string]func(params map[string]int) error{
+	"matrix": func(params map[string]int) error {
+		if size := params["size"]; size < 1 || size > serverConfig.MaxMatrixSize {
+			return fmt.Errorf("size must be between 1 and %d", serverConfig.MaxMatrixSize)
+		}
+		return nil
+	},
+	"mandelbrot": func(params map[string]int) error {
+		width, height := params["width"], params["height"]
+		if width < 1 || height < 1 || width*height > serverConfig.MaxMandelbrotPixels {
+			return fmt.Errorf("width and height must be positive with at most %d pixels", serverConfig.MaxMandelbrotPixels)
+		}
+		if iterations := params["iterations"]; iterations < 1 || iterations > serverConfig.MaxMandelbrotIteration {
+			return fmt.Errorf("iterations must be between 1 and %d", serverConfig.MaxMandelbrotIteration)
+		}
+		return nil
+	},
+	"hash": func(params map[string]int) error {
+		if count := params["count"]; count < 1 || count > serverConfig.MaxHashCount {
+			return fmt.Errorf("count must be between 1 and %d", serverConfig.MaxHashCount)
+		}
+		return nil
+	},
+}
 
-	for i := 0; i < count; i++ {
-		hasher := sha256.New()
-		hasher.Write([]byte(fmt.Sprintf("%s-%d", data, i)))
-		sum := hasher.Sum(nil)
-		hash += int(sum[0])
+// prepareServerBenchmark validates the benchmark parameters against the
+// configured limits without running anything, so callers can reject bad input
+// before queueing or profiling the work.
+func prepareServerBenchmark(kind string, query url.Values) (serverBenchmark, error) {
+	b, ok := benchkernels.Lookup(kind)
+	if !ok {
+		return serverBenchmark{}, fmt.Errorf("unknown benchmark type %q", kind)
+	}
+	params := map[string]int{}
+	for _, p := range b.ParamsSchema() {
+		params[p.Name] = queryInt(query, p.Name, p.Default)
+	}
+	if limit, ok := serverBenchmarkLimits[kind]; ok {
+		if err := limit(params); err != nil {
+			return serverBenchmark{}, err
+		}
 	}
+	params, err := benchkernels.Resolve(b, params)
+	if err != nil {
+		return serverBenchmark{}, err
+	}
+	return serverBenchmark{
+		Kind:   kind,
+		Params: params,
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			run, err := benchkernels.Measure(ctx, b, params)
+			if err != nil {
+				return nil, err
+			}
+			return run.Report(), nil
+		},
+	}, nil
+}
 
-	duration := time.Since(start)
+// queryInt returns the integer value of a query parameter, or def when the
+// parameter is missing or not a valid integer.
+func queryInt(query url.Values, name string, def int) int {
+	if raw := query.Get(name); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
 
-	return map[string]interface{}{
-		"operation":   "SHA256 Hashing",
-		"count":       count,
-		"duration_ms": float64(duration.Nanoseconds()) / 1000000,
-		"result_hash": hash,
+func enableCORS(w http.ResponseWriter, r *http.Request) {
+	// CORS headers
+	if origin := serverConfig.allowedOrigin(r.Header.Get("Origin")); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if origin != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
 	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+sandboxHeader+", "+idempotencyKeyHeader+", "+traceparentHeader+", If-Match")
+	w.Header().Set("Access-Control-Expose-Headers", sandboxHeader+", "+requestIDHeader+", "+traceresponseHeader+", "+injectedFaultHeader+", ETag")
+
+	// Security headers
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("X-XSS-Protection", "1; mode=block")
+	w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
 }
 
-func generateDemoProducts() []Product {
-	return []Product{
-		{ID: 1, Name: "Wireless Headphones", Price: 99.99, Category: "electronics", InStock: true, Rating: 4.5, Description: "High-quality wireless headphones with noise cancellation"},
-		{ID: 2, Name: "Cotton T-Shirt", Price: 24.99, Category: "clothing", InStock: true, Rating: 4.2, Description: "Comfortable 100% cotton t-shirt"},
-		{ID: 3, Name: "Programming Book", Price: 49.99, Category: "books", InStock: true, Rating: 4.8, Description: "Learn advanced programming techniques"},
-		{ID: 4, Name: "Coffee Mug", Price: 12.99, Category: "home", InStock: true, Rating: 4.0, Description: "Ceramic coffee mug with handle"},
-		{ID: 5, Name: "Running Shoes", Price: 129.99, Category: "sports", InStock: true, Rating: 4.6, Description: "Lightweight running shoes for athletes"},
-		{ID: 6, Name: "Smartphone", Price: 699.99, Category: "electronics", InStock: false, Rating: 4.7, Description: "Latest smartphone with advanced features"},
-		{ID: 7, Name: "Jeans", Price: 79.99, Category: "clothing", InStock: true, Rating: 4.3, Description: "Classic blue jeans"},
-		{ID: 8, Name: "Cookbook", Price: 29.99, Category: "books", InStock: true, Rating: 4.4, Description: "Delicious recipes for home cooking"},
+// generateDemoPriceHistory gives every product its price from 90 days before
+// now, with a few changes since: the headphones and mug are on sale, and the
+// running shoes went up.
+func generateDemoPriceHistory(products []Product, now time.Time) PriceHistories {
+	start := now.AddDate(0, 0, -90)
+	earlier := map[int]struct {
+		price float64
+		days  int
+	}{
+		1: {119.99, 12},
+		4: {14.99, 5},
+		5: {119.99, 20},
+	}
+	histories := PriceHistories{}
+	for _, product := range products {
+		change, changed := earlier[product.ID]
+		if !changed {
+			histories[product.ID] = RecordPriceChange(nil, product.Price, start)
+			continue
+		}
+		history := RecordPriceChange(nil, change.price, start)
+		histories[product.ID] = RecordPriceChange(history, product.Price, now.AddDate(0, 0, -change.days))
 	}
+	return histories
 }
 
-func generateDemoOrders() []Order {
-	products := generateDemoProducts()
-	return []Order{
-		{
-			ID:         1,
-			UserID:     1,
-			Products:   products[0:2],
-			Quantities: []int{1, 2},
-			Subtotal:   149.97,
-			Tax:        12.00,
-			Shipping:   0.00,
-			Total:      161.97,
-			Discount:   0.00,
-			OrderDate:  "2023-05-01",
-			Status:     "delivered",
-		},
-		{
-			ID:         2,
-			UserID:     2,
-			Products:   products[2:4],
-			Quantities: []int{1, 1},
-			Subtotal:   62.98,
-			Tax:        8.19,
-			Shipping:   12.99,
-			Total:      84.16,
-			Discount:   0.00,
-			OrderDate:  "2023-05-03",
-			Status:     "shipped",
-		},
+// generateDemoEvents walks the demo users through the purchase funnel: two
+// buy (the demo orders), one stops at checkout, one at the cart and one
+// only looks.
+func generateDemoEvents() []BehaviorEvent {
+	var events []BehaviorEvent
+	walk := func(userID int, day string, productIDs []int, steps int) {
+		at, _ := time.Parse(time.RFC3339, day+"T09:00:00Z")
+		add := func(eventType string, productID int) {
+			events = append(events, BehaviorEvent{Type: eventType, UserID: userID, ProductID: productID, Time: at.Format(time.RFC3339)})
+			at = at.Add(5 * time.Minute)
+		}
+		for _, id := range productIDs {
+			add(EventViewed, id)
+		}
+		for _, id := range productIDs {
+			if steps > 1 {
+				add(EventAddedToCart, id)
+			}
+		}
+		if steps > 2 {
+			add(EventCheckedOut, 0)
+		}
+		if steps > 3 {
+			add(EventPurchased, 0)
+		}
 	}
+	walk(1, "2023-05-01", []int{1, 2}, 4)
+	walk(2, "2023-05-03", []int{3, 4}, 4)
+	walk(3, "2023-05-04", []int{6}, 2)
+	walk(4, "2023-05-05", []int{5}, 1)
+	walk(5, "2023-05-06", []int{1}, 3)
+	return events
 }
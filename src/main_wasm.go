@@ -1,20 +1,31 @@
-//go:build js && wasm
+//go:build js && wasm && !bench
 
 package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"runtime"
+	"os"
+	"strings"
 	"syscall/js"
+	"time"
 )
 
+// businessLogicBuilt reports that the business logic is part of this build.
+const businessLogicBuilt = true
+
 func main() {
 	// ====================================================================
 	// WASM FUNCTION REGISTRATION
 	// ====================================================================
-	// This function registers all Go functions to be callable from JavaScript.
-	// Functions are organized by category for maintainability.
+	// main registers all Go functions to be callable from JavaScript.
+	// Functions are organized by category for maintainability: the
+	// business logic here, the benchmarks in registerBenchmarks, which
+	// logic.wasm (-tags logic) and the TinyGo build leave out for a smaller
+	// module. bench.wasm (-tags bench) has its own main in main_bench.go.
+	// The benchmarks are registered on the first initBenchmarksWasm() call,
+	// keeping startup to the business logic.
 	//
 	// Naming Convention:
 	// - Business Logic: [function]Wasm (e.g., validateUserWasm)
@@ -26,75 +37,100 @@ func main() {
 	// All functions follow consistent error handling patterns.
 	// ====================================================================
 
+	setupConsoleLogging()
+	registerBusinessLogic()
+	js.Global().Set("initBenchmarksWasm", js.FuncOf(initBenchmarksWasm))
+	js.Global().Set("wasmApiInfo", js.FuncOf(wasmApiInfoWasm))
+	js.Global().Set("setLogLevelWasm", js.FuncOf(setLogLevelWasm))
+
+	// Under Node.js there is no page to call in: run the job spec and exit
+	if runningInNode() {
+		ensureBenchmarks()
+		os.Exit(runHeadless(os.Args[1:]))
+	}
+
+	// Keep the program running
+	select {}
+}
+
+// registerBusinessLogic registers the shared business logic functions.
+func registerBusinessLogic() {
 	// ====================================================================
 	// BUSINESS LOGIC FUNCTIONS
 	// Shared business logic that runs identically on client and server
 	// ====================================================================
 	js.Global().Set("validateUserWasm", js.FuncOf(validateUserWasm))
 	js.Global().Set("validateProductWasm", js.FuncOf(validateProductWasm))
+	js.Global().Set("validatePhoneWasm", js.FuncOf(validatePhoneWasm))
+	js.Global().Set("validatePostalCodeWasm", js.FuncOf(validatePostalCodeWasm))
+	js.Global().Set("validatePasswordWasm", js.FuncOf(validatePasswordWasm))
+	js.Global().Set("setUserPreferencesWasm", js.FuncOf(setUserPreferencesWasm))
+	js.Global().Set("lookupCountryWasm", js.FuncOf(lookupCountryWasm))
 	js.Global().Set("calculateOrderTotalWasm", js.FuncOf(calculateOrderTotalWasm))
 	js.Global().Set("recommendProductsWasm", js.FuncOf(recommendProductsWasm))
+	js.Global().Set("recommendCrossSellsWasm", js.FuncOf(recommendCrossSellsWasm))
+	js.Global().Set("recommendUpsellsWasm", js.FuncOf(recommendUpsellsWasm))
+	js.Global().Set("similarProductsWasm", js.FuncOf(similarProductsWasm))
+	js.Global().Set("selectThumbnailWasm", js.FuncOf(selectThumbnailWasm))
+	js.Global().Set("searchProductsWasm", js.FuncOf(searchProductsWasm))
+	js.Global().Set("filterProductsWasm", js.FuncOf(filterProductsWasm))
+	js.Global().Set("mineAssociationsWasm", js.FuncOf(mineAssociationsWasm))
+	js.Global().Set("trendingProductsWasm", js.FuncOf(trendingProductsWasm))
+	js.Global().Set("segmentUsersRFMWasm", js.FuncOf(segmentUsersRFMWasm))
+	js.Global().Set("scoreChurnRiskWasm", js.FuncOf(scoreChurnRiskWasm))
+	js.Global().Set("calculateCLVWasm", js.FuncOf(calculateCLVWasm))
+	js.Global().Set("aggregateRevenueWasm", js.FuncOf(aggregateRevenueWasm))
+	js.Global().Set("forecastRevenueWasm", js.FuncOf(forecastRevenueWasm))
+	js.Global().Set("computeFunnelWasm", js.FuncOf(computeFunnelWasm))
+	js.Global().Set("sessionizeEventsWasm", js.FuncOf(sessionizeEventsWasm))
+	js.Global().Set("trackEventWasm", js.FuncOf(trackEventWasm))
+	js.Global().Set("flushEventsWasm", js.FuncOf(flushEventsWasm))
+	js.Global().Set("topProductsWasm", js.FuncOf(topProductsWasm))
+	js.Global().Set("buildHistogramWasm", js.FuncOf(buildHistogramWasm))
+	js.Global().Set("assignVariantWasm", js.FuncOf(assignVariantWasm))
+	js.Global().Set("analyzeExperimentWasm", js.FuncOf(analyzeExperimentWasm))
 	js.Global().Set("analyzeUserBehaviorWasm", js.FuncOf(analyzeUserBehaviorWasm))
-
-	// ====================================================================
-	// BENCHMARK FUNCTIONS - SINGLE-THREADED VERSIONS
-	// Basic single-threaded implementations for performance comparison
-	// ====================================================================
-	js.Global().Set("mandelbrotWasm", js.FuncOf(mandelbrotWasmSingle))
-	js.Global().Set("matrixMultiplyWasm", js.FuncOf(matrixMultiplyWasmSingle))
-	js.Global().Set("sha256HashWasm", js.FuncOf(sha256HashWasmSingle))
-	js.Global().Set("rayTracingWasm", js.FuncOf(rayTracingWasmSingle))
-
-	// ====================================================================
-	// BENCHMARK FUNCTIONS - OPTIMIZED VERSIONS
-	// Highly optimized single-threaded implementations with boundary call reduction
-	// ====================================================================
-	js.Global().Set("mandelbrotOptimizedWasm", js.FuncOf(mandelbrotOptimizedWasm))
-	js.Global().Set("matrixMultiplyOptimizedWasm", js.FuncOf(matrixMultiplyOptimizedWasm))
-	js.Global().Set("sha256HashOptimizedWasm", js.FuncOf(sha256HashOptimizedWasm))
-	js.Global().Set("rayTracingOptimizedWasm", js.FuncOf(rayTracingOptimizedWasm))
-
-	// ====================================================================
-	// BENCHMARK FUNCTIONS - CONCURRENT VERSIONS
-	// Multi-threaded implementations using goroutines for parallel processing
-	// ====================================================================
-	js.Global().Set("mandelbrotConcurrentWasm", js.FuncOf(mandelbrotWasmConcurrentV2))
-	js.Global().Set("matrixMultiplyConcurrentWasm", js.FuncOf(matrixMultiplyWasmConcurrentV2))
-	js.Global().Set("sha256HashConcurrentWasm", js.FuncOf(sha256HashWasmConcurrentV2))
-	js.Global().Set("rayTracingConcurrentWasm", js.FuncOf(rayTracingWasmConcurrentV2))
-
-	// ====================================================================
-	// LEGACY/COMPATIBILITY ALIASES
-	// Standardized function names for backward compatibility and ease of use
-	// ====================================================================
-	// PERFORMANCE FIX: Don't overwrite the optimized rayTracingWasmSingle!
-	// js.Global().Set("rayTracingWasm", js.FuncOf(rayTracingWasm)) // REMOVED - was overwriting optimized version
-
-	// User-friendly standardized names for optimized versions
-	js.Global().Set("mandelbrotWasmFast", js.FuncOf(mandelbrotOptimizedWasm))
-	js.Global().Set("matrixMultiplyWasmFast", js.FuncOf(matrixMultiplyOptimizedWasm))
-	js.Global().Set("sha256HashWasmFast", js.FuncOf(sha256HashOptimizedWasm))
-
-	// Keep legacy names for backward compatibility
-	js.Global().Set("rayTracing", js.FuncOf(rayTracingWasm)) // Keep this for legacy compatibility only
-	js.Global().Set("mandelbrotFast", js.FuncOf(mandelbrotOptimizedWasm))
-	js.Global().Set("matrixMultiplyFast", js.FuncOf(matrixMultiplyOptimizedWasm))
-	js.Global().Set("sha256HashFast", js.FuncOf(sha256HashOptimizedWasm))
-
-	// ====================================================================
-	// UNIFIED BENCHMARK INTERFACE
-	// Register consolidated benchmark functions for cleaner API
-	// ====================================================================
-	registerUnifiedBenchmarks()
-
-	// ====================================================================
-	// UTILITY FUNCTIONS
-	// Debugging and system information functions
-	// ====================================================================
-	js.Global().Set("debugConcurrency", js.FuncOf(debugConcurrencyWasm))
-
-	// Keep the program running
-	select {}
+	js.Global().Set("resetAnalyticsWasm", js.FuncOf(resetAnalyticsWasm))
+	js.Global().Set("addAnalyticsUserWasm", js.FuncOf(addAnalyticsUserWasm))
+	js.Global().Set("addAnalyticsOrderWasm", js.FuncOf(addAnalyticsOrderWasm))
+	js.Global().Set("analyticsSnapshotWasm", js.FuncOf(analyticsSnapshotWasm))
+	js.Global().Set("profileCompletenessWasm", js.FuncOf(profileCompletenessWasm))
+	js.Global().Set("exportCsvWasm", js.FuncOf(exportCsvWasm))
+	js.Global().Set("cartSummaryWasm", js.FuncOf(cartSummaryWasm))
+	js.Global().Set("mergeCartsWasm", js.FuncOf(mergeCartsWasm))
+	js.Global().Set("moveToCartWasm", js.FuncOf(moveToCartWasm))
+	js.Global().Set("comparePriceWasm", js.FuncOf(comparePriceWasm))
+	js.Global().Set("getDynamicPriceWasm", js.FuncOf(getDynamicPriceWasm))
+	js.Global().Set("scoreOrderRiskWasm", js.FuncOf(scoreOrderRiskWasm))
+	js.Global().Set("generateInvoiceWasm", js.FuncOf(generateInvoiceWasm))
+	js.Global().Set("renderReceiptWasm", js.FuncOf(renderReceiptWasm))
+	js.Global().Set("previewNotificationWasm", js.FuncOf(previewNotificationWasm))
+	js.Global().Set("findDuplicateUsersWasm", js.FuncOf(findDuplicateUsersWasm))
+	js.Global().Set("generateDemoDataWasm", js.FuncOf(generateDemoDataWasm))
+	js.Global().Set("updateRecordWasm", js.FuncOf(updateRecordWasm))
+	js.Global().Set("openDataCacheWasm", js.FuncOf(openDataCacheWasm))
+	js.Global().Set("cacheStoreWasm", js.FuncOf(cacheStoreWasm))
+	js.Global().Set("cacheRecordsWasm", js.FuncOf(cacheRecordsWasm))
+	js.Global().Set("cacheUpdateWasm", js.FuncOf(cacheUpdateWasm))
+	js.Global().Set("cacheSyncRequestWasm", js.FuncOf(cacheSyncRequestWasm))
+	js.Global().Set("cacheApplySyncWasm", js.FuncOf(cacheApplySyncWasm))
+	js.Global().Set("cacheResolveWasm", js.FuncOf(cacheResolveWasm))
+	js.Global().Set("recalculateOrdersWasm", js.FuncOf(recalculateOrdersWasm))
+	js.Global().Set("convertCurrencyWasm", js.FuncOf(convertCurrencyWasm))
+	js.Global().Set("formatMoneyWasm", js.FuncOf(formatMoneyWasm))
+	js.Global().Set("shippingQuotesWasm", js.FuncOf(shippingQuotesWasm))
+	js.Global().Set("checkAvailabilityWasm", js.FuncOf(checkAvailabilityWasm))
+	js.Global().Set("splitOrderWasm", js.FuncOf(splitOrderWasm))
+	js.Global().Set("upcomingChargesWasm", js.FuncOf(upcomingChargesWasm))
+	js.Global().Set("prorateSubscriptionWasm", js.FuncOf(prorateSubscriptionWasm))
+	js.Global().Set("setExchangeRatesWasm", js.FuncOf(setExchangeRatesWasm))
+	js.Global().Set("setValidationRulesWasm", js.FuncOf(setValidationRulesWasm))
+	js.Global().Set("setPromotionsWasm", js.FuncOf(setPromotionsWasm))
+	js.Global().Set("setItemSimilarityWasm", js.FuncOf(setItemSimilarityWasm))
+	js.Global().Set("setTrendingWasm", js.FuncOf(setTrendingWasm))
+	js.Global().Set("setCohortPreferencesWasm", js.FuncOf(setCohortPreferencesWasm))
+	js.Global().Set("setRecommendationWeightsWasm", js.FuncOf(setRecommendationWeightsWasm))
+	js.Global().Set("setCLVSettingsWasm", js.FuncOf(setCLVSettingsWasm))
 }
 
 // WebAssembly wrapper for user validation
@@ -115,6 +151,9 @@ func validateUserWasm(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
+	// The call's trace times it from here, the JSON decoding included
+	ctx, trace := wasmCallContext("validateUserWasm")
+
 	// Parse JSON input with safety check
 	userJSON := args[0].String()
 	if len(userJSON) == 0 {
@@ -133,7 +172,7 @@ func validateUserWasm(this js.Value, args []js.Value) interface{} {
 	}
 
 	// Use shared business logic
-	result := ValidateUser(user)
+	result := ValidateUserContext(ctx, user)
 
 	// Convert back to JavaScript-compatible format
 	// Convert errors slice to JavaScript array
@@ -141,10 +180,138 @@ func validateUserWasm(this js.Value, args []js.Value) interface{} {
 	for i, err := range result.Errors {
 		jsErrors[i] = err
 	}
+	jsFieldErrors := fieldErrorsToJS(result.FieldErrors)
+	jsWarnings := make([]interface{}, len(result.Warnings))
+	for i, warning := range result.Warnings {
+		jsWarnings[i] = map[string]interface{}{
+			"field":      warning.Field,
+			"code":       warning.Code,
+			"message":    warning.Message,
+			"suggestion": warning.Suggestion,
+		}
+	}
+
+	return map[string]interface{}{
+		"valid":        result.Valid,
+		"errors":       jsErrors,
+		"field_errors": jsFieldErrors,
+		"warnings":     jsWarnings,
+		"trace":        traceToJS(trace),
+	}
+}
+
+// WebAssembly wrapper for ValidatePhone - checks a number for a country
+// code and returns it in E.164 form.
+func validatePhoneWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"valid":  false,
+			"errors": []interface{}{"Invalid number of arguments - expected phone number and country"},
+		}
+	}
+
+	// Use shared business logic
+	e164, err := ValidatePhone(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"valid":  false,
+			"errors": []interface{}{err.Error()},
+		}
+	}
+
+	return map[string]interface{}{
+		"valid":  true,
+		"e164":   e164,
+		"errors": []interface{}{},
+	}
+}
+
+// WebAssembly wrapper for ValidatePostalCode - checks a postal code for a
+// country code and returns it in the country's canonical form.
+func validatePostalCodeWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"valid":  false,
+			"errors": []interface{}{"Invalid number of arguments - expected postal code and country"},
+		}
+	}
+
+	// Use shared business logic
+	code, err := ValidatePostalCode(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"valid":  false,
+			"errors": []interface{}{err.Error()},
+		}
+	}
+
+	return map[string]interface{}{
+		"valid":       true,
+		"postal_code": code,
+		"errors":      []interface{}{},
+	}
+}
+
+// WebAssembly wrapper for LookupCountry - finds a country by alpha-2 or
+// alpha-3 code in the dataset the server validates against.
+func lookupCountryWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected country code",
+		}
+	}
+
+	// Use shared business logic
+	country, ok := LookupCountry(args[0].String())
+	if !ok {
+		return map[string]interface{}{
+			"error": "Unknown country code",
+		}
+	}
+
+	result := map[string]interface{}{
+		"error":    "",
+		"code":     country.Code,
+		"alpha3":   country.Alpha3,
+		"name":     country.Name,
+		"region":   country.Region,
+		"currency": country.Currency,
+	}
+	if country.TaxRate != nil {
+		result["tax_rate"] = *country.TaxRate
+	}
+	return result
+}
+
+// WebAssembly wrapper for ValidatePasswordStrength - rates a password as it
+// is typed, with the rules the server applies.
+func validatePasswordWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return map[string]interface{}{
+			"valid":  false,
+			"errors": []interface{}{"Invalid number of arguments - expected password"},
+		}
+	}
+
+	// Use shared business logic
+	strength := ValidatePasswordStrength(args[0].String())
+
+	jsErrors := make([]interface{}, len(strength.Errors))
+	for i, err := range strength.Errors {
+		jsErrors[i] = err
+	}
+	jsSuggestions := make([]interface{}, len(strength.Suggestions))
+	for i, suggestion := range strength.Suggestions {
+		jsSuggestions[i] = suggestion
+	}
 
 	return map[string]interface{}{
-		"valid":  result.Valid,
-		"errors": jsErrors,
+		"valid":        strength.Valid,
+		"score":        strength.Score,
+		"rating":       strength.Rating,
+		"entropy_bits": strength.EntropyBits,
+		"errors":       jsErrors,
+		"suggestions":  jsSuggestions,
 	}
 }
 
@@ -166,6 +333,7 @@ func validateProductWasm(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
+	ctx, trace := wasmCallContext("validateProductWasm")
 	productJSON := args[0].String()
 	if len(productJSON) == 0 {
 		return map[string]interface{}{
@@ -182,7 +350,7 @@ func validateProductWasm(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
-	result := ValidateProduct(product)
+	result := ValidateProductContext(ctx, product)
 
 	// Convert errors slice to JavaScript array
 	jsErrors := make([]interface{}, len(result.Errors))
@@ -191,26 +359,90 @@ func validateProductWasm(this js.Value, args []js.Value) interface{} {
 	}
 
 	return map[string]interface{}{
-		"valid":  result.Valid,
-		"errors": jsErrors,
+		"valid":        result.Valid,
+		"errors":       jsErrors,
+		"field_errors": fieldErrorsToJS(result.FieldErrors),
+		"trace":        traceToJS(trace),
+	}
+}
+
+// WebAssembly wrapper for user preferences - checks preferences as PUT
+// /api/users/{id}/preferences does and returns the user JSON with them set,
+// for recommending to the user in the page
+func setUserPreferencesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected user JSON and preferences JSON",
+		}
+	}
+
+	user, err := UserFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+		}
+	}
+	var prefs Preferences
+	if err := json.Unmarshal([]byte(args[1].String()), &prefs); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid preferences JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic - identical to the API's checks
+	result := ValidatePreferences(prefs)
+	if !result.Valid {
+		return map[string]interface{}{
+			"error":        "",
+			"valid":        false,
+			"field_errors": fieldErrorsToJS(result.FieldErrors),
+		}
+	}
+	user.Preferences = &prefs
+	userJSON, err := UserToJSON(user)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode user: " + err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"error":        "",
+		"valid":        true,
+		"field_errors": []interface{}{},
+		"user":         userJSON,
+	}
+}
+
+// fieldErrorsToJS converts field errors to a JavaScript array of objects
+func fieldErrorsToJS(fieldErrors []FieldError) []interface{} {
+	jsFieldErrors := make([]interface{}, len(fieldErrors))
+	for i, fieldErr := range fieldErrors {
+		jsFieldErrors[i] = map[string]interface{}{
+			"field":    fieldErr.Field,
+			"code":     fieldErr.Code,
+			"message":  fieldErr.Message,
+			"severity": fieldErr.Severity,
+		}
 	}
+	return jsFieldErrors
 }
 
 // WebAssembly wrapper for order total calculation
 func calculateOrderTotalWasm(this js.Value, args []js.Value) interface{} {
-	if len(args) != 2 {
+	if len(args) != 2 && len(args) != 3 {
 		return map[string]interface{}{
-			"error": "Invalid number of arguments - expected order and user JSON",
+			"error": "Invalid number of arguments - expected order and user JSON, and optionally gift card JSON",
 		}
 	}
 
 	// Validate argument types
-	if args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+	if args[0].Type() != js.TypeString || args[1].Type() != js.TypeString || (len(args) == 3 && args[2].Type() != js.TypeString) {
 		return map[string]interface{}{
 			"error": "Invalid argument types - expected strings",
 		}
 	}
 
+	ctx, trace := wasmCallContext("calculateOrderTotalWasm")
 	orderJSON := args[0].String()
 	userJSON := args[1].String()
 
@@ -230,6 +462,7 @@ func calculateOrderTotalWasm(this js.Value, args []js.Value) interface{} {
 	if err != nil {
 		return map[string]interface{}{
 			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
 		}
 	}
 
@@ -237,41 +470,215 @@ func calculateOrderTotalWasm(this js.Value, args []js.Value) interface{} {
 	if err != nil {
 		return map[string]interface{}{
 			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
 		}
 	}
 
-	// Validate order has products
-	if len(order.Products) == 0 {
+	// Validate order has products, with a quantity each
+	if err := CheckOrderLines(order); err != nil {
 		return map[string]interface{}{
-			"error": "Order must contain at least one product",
+			"error": err.Error(),
+			"code":  ErrorCode(err),
 		}
 	}
 
-	// Validate quantities match products
-	if len(order.Products) != len(order.Quantities) {
+	if err := CheckOrderCurrency(order); err != nil {
 		return map[string]interface{}{
-			"error": "Product and quantity arrays must be the same length",
+			"error": err.Error(),
 		}
 	}
+	if order.ShippingAddress != nil {
+		if result := ValidateAddress(*order.ShippingAddress); !result.Valid {
+			return map[string]interface{}{
+				"error": strings.Join(result.Errors, "; "),
+			}
+		}
+	}
+	if _, _, err := SelectedShipping(order, user, orderPlacedDate(order)); err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	if err := ValidateShipments(order); err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	if order.CouponCode != "" {
+		if err := CheckCoupon(order.CouponCode, orderPlacedDate(order)); err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+	}
+	if order.LoyaltyPoints < 0 || order.LoyaltyPoints > user.LoyaltyPoints {
+		return map[string]interface{}{
+			"error": fmt.Sprintf("Loyalty points must be between 0 and the user's %d", user.LoyaltyPoints),
+		}
+	}
+	// The page passes the card it fetched from /api/gift-cards/{code}
+	if len(args) == 3 {
+		var card GiftCard
+		if err := json.Unmarshal([]byte(args[2].String()), &card); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid gift card JSON: " + err.Error(),
+			}
+		}
+		if err := CheckGiftCard(card, OrderCurrency(order), orderPlacedDate(order)); err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+		order.GiftCardCode, order.GiftCard = card.Code, &card
+	}
 
 	// Use shared business logic
-	CalculateOrderTotal(&order, user)
+	CalculateOrderTotalContext(ctx, &order, user)
+
+	// Convert the line breakdown to JavaScript-compatible format
+	lines := make([]interface{}, len(order.Lines))
+	for i, line := range order.Lines {
+		lines[i] = map[string]interface{}{
+			"product_id": line.ProductID,
+			"quantity":   line.Quantity,
+			"tax_rate":   line.TaxRate,
+			"unit_net":   line.UnitNet,
+			"unit_gross": line.UnitGross,
+			"net":        line.Net,
+			"tax":        line.Tax,
+			"gross":      line.Gross,
+		}
+	}
 
 	// Return updated order with validation
 	return map[string]interface{}{
-		"subtotal": order.Subtotal,
-		"tax":      order.Tax,
-		"shipping": order.Shipping,
-		"discount": order.Discount,
-		"total":    order.Total,
+		"subtotal":           order.Subtotal,
+		"tax":                order.Tax,
+		"shipping":           order.Shipping,
+		"discount":           order.Discount,
+		"total":              order.Total,
+		"currency":           order.Currency,
+		"includes_tax":       order.IncludesTax,
+		"estimated_delivery": order.EstimatedDelivery,
+		"lines":              lines,
+		"shipments":          shipmentsToJS(order.Shipments),
+		"gift_card_amount":   order.GiftCardAmount,
+		"amount_due":         order.AmountDue,
+		"discounts":          discountsToJS(order.Discounts),
+		"promotions":         promotionsToJS(order.Promotions),
+		"trace":              traceToJS(trace),
+	}
+}
+
+// promotionsToJS converts applied promotions to JavaScript-compatible format.
+func promotionsToJS(applied []AppliedPromotion) []interface{} {
+	result := make([]interface{}, len(applied))
+	for i, promotion := range applied {
+		result[i] = map[string]interface{}{
+			"id":              promotion.ID,
+			"name":            promotion.Name,
+			"kind":            promotion.Kind,
+			"gift_product_id": promotion.GiftProductID,
+			"saving":          promotion.Saving,
+		}
+	}
+	return result
+}
+
+// discountsToJS converts discount lines to JavaScript-compatible format.
+func discountsToJS(discounts []DiscountLine) []interface{} {
+	result := make([]interface{}, len(discounts))
+	for i, line := range discounts {
+		result[i] = map[string]interface{}{
+			"source":      line.Source,
+			"code":        line.Code,
+			"description": line.Description,
+			"stacking":    line.Stacking,
+			"amount":      line.Amount,
+			"points":      line.Points,
+			"in_price":    line.InPrice,
+		}
+	}
+	return result
+}
+
+// shipmentsToJS converts shipments to JavaScript-compatible format.
+func shipmentsToJS(shipments []Shipment) []interface{} {
+	result := make([]interface{}, len(shipments))
+	for i, shipment := range shipments {
+		items := make([]interface{}, len(shipment.Items))
+		for j, item := range shipment.Items {
+			items[j] = map[string]interface{}{
+				"product_id": item.ProductID,
+				"quantity":   item.Quantity,
+			}
+		}
+		result[i] = map[string]interface{}{
+			"id":                 shipment.ID,
+			"warehouse":          shipment.Warehouse,
+			"items":              items,
+			"status":             shipment.Status,
+			"shipping":           shipment.Shipping,
+			"estimated_delivery": shipment.EstimatedDelivery,
+		}
+	}
+	return result
+}
+
+// WebAssembly wrapper for splitting an order into shipments by warehouse and
+// stock, priced like the server's checkout does
+func splitOrderWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected order, products and user JSON",
+		}
+	}
+	for i, arg := range args {
+		if arg.Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+	}
+
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	var products []Product
+	if err := json.Unmarshal([]byte(args[1].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid products JSON: " + err.Error(),
+		}
+	}
+	user, err := UserFromJSON(args[2].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic
+	order.Shipments = SplitOrder(order, products)
+	CalculateOrderTotal(&order, user)
+
+	return map[string]interface{}{
+		"error":     "",
+		"shipping":  order.Shipping,
+		"total":     order.Total,
+		"shipments": shipmentsToJS(order.Shipments),
 	}
 }
 
 // WebAssembly wrapper for product recommendations
 func recommendProductsWasm(this js.Value, args []js.Value) interface{} {
-	if len(args) != 3 {
+	if len(args) < 3 || len(args) > 5 {
 		return map[string]interface{}{
-			"error":           "Invalid number of arguments - expected 3",
+			"error":           "Invalid number of arguments - expected user, products and order JSON, and optionally wishlist and options JSON",
 			"recommendations": []interface{}{},
 		}
 	}
@@ -302,6 +709,7 @@ func recommendProductsWasm(this js.Value, args []js.Value) interface{} {
 	if err != nil {
 		return map[string]interface{}{
 			"error":           "Invalid user JSON: " + err.Error(),
+			"code":            ErrorCode(err),
 			"recommendations": []interface{}{},
 		}
 	}
@@ -319,59 +727,537 @@ func recommendProductsWasm(this js.Value, args []js.Value) interface{} {
 	if err != nil {
 		return map[string]interface{}{
 			"error":           "Invalid order JSON: " + err.Error(),
+			"code":            ErrorCode(err),
 			"recommendations": []interface{}{},
 		}
 	}
 
+	var wishlist Wishlist
+	if len(args) >= 4 && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &wishlist); err != nil {
+			return map[string]interface{}{
+				"error":           "Invalid wishlist JSON: " + err.Error(),
+				"recommendations": []interface{}{},
+			}
+		}
+	}
+
+	// Options left out keep DefaultRecommendOptions
+	options := DefaultRecommendOptions()
+	if len(args) == 5 {
+		if err := json.Unmarshal([]byte(args[4].String()), &options); err != nil {
+			return map[string]interface{}{
+				"error":           "Invalid options JSON: " + err.Error(),
+				"recommendations": []interface{}{},
+			}
+		}
+		result := newValidationResult()
+		result.Merge("diversity.", ValidateDiversity(options.Diversity))
+		result.Merge("weights.", ValidateRecommendationWeights(options.Weights))
+		if !result.Valid {
+			return map[string]interface{}{
+				"error":           strings.Join(result.Errors, "; "),
+				"recommendations": []interface{}{},
+			}
+		}
+	}
+
 	// Use shared business logic
-	recommendations := RecommendProducts(user, products, order)
+	recommendations := ExplainRecommendations(user, products, order, wishlist, options)
 
-	// Convert to JavaScript-compatible format
 	result := make([]interface{}, len(recommendations))
-	for i, product := range recommendations {
-		result[i] = map[string]interface{}{
-			"id":          product.ID,
-			"name":        product.Name,
-			"price":       product.Price,
-			"category":    product.Category,
-			"in_stock":    product.InStock,
-			"rating":      product.Rating,
-			"description": product.Description,
+	for i, recommendation := range recommendations {
+		entry := productsToJS([]Product{recommendation.Product})[0].(map[string]interface{})
+		reasons := make([]interface{}, len(recommendation.Reasons))
+		for j, reason := range recommendation.Reasons {
+			reasons[j] = map[string]interface{}{
+				"reason": reason.Reason,
+				"score":  reason.Score,
+			}
 		}
+		entry["score"] = recommendation.Score
+		entry["reasons"] = reasons
+		result[i] = entry
 	}
-
 	return map[string]interface{}{
 		"error":           "",
 		"recommendations": result,
 	}
 }
 
-// WebAssembly wrapper for user behavior analysis
-func analyzeUserBehaviorWasm(this js.Value, args []js.Value) interface{} {
-	if len(args) != 2 {
+// WebAssembly wrapper for cross-sells - accessories for an order's products
+func recommendCrossSellsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
 		return map[string]interface{}{
-			"error": "Invalid number of arguments - expected 2",
+			"error":           "Invalid arguments - expected order and products JSON",
+			"recommendations": []interface{}{},
 		}
 	}
 
-	// Validate argument types
-	if args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
 		return map[string]interface{}{
-			"error": "Invalid argument types - expected strings",
+			"error":           "Invalid order JSON: " + err.Error(),
+			"code":            ErrorCode(err),
+			"recommendations": []interface{}{},
 		}
 	}
-
-	usersJSON := args[0].String()
-	ordersJSON := args[1].String()
-
-	// Validate inputs are not empty
-	if len(usersJSON) == 0 {
+	var products []Product
+	if err := json.Unmarshal([]byte(args[1].String()), &products); err != nil {
 		return map[string]interface{}{
-			"error": "Empty users JSON",
+			"error":           "Invalid products JSON: " + err.Error(),
+			"recommendations": []interface{}{},
 		}
 	}
-	if len(ordersJSON) == 0 {
-		return map[string]interface{}{
+
+	// Use shared business logic
+	return map[string]interface{}{
+		"error":           "",
+		"recommendations": productsToJS(RecommendCrossSells(order, products)),
+	}
+}
+
+// WebAssembly wrapper for upsells - higher-tier alternatives to a product
+func recommendUpsellsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":           "Invalid arguments - expected product and products JSON",
+			"recommendations": []interface{}{},
+		}
+	}
+
+	var product Product
+	if err := json.Unmarshal([]byte(args[0].String()), &product); err != nil {
+		return map[string]interface{}{
+			"error":           "Invalid product JSON: " + err.Error(),
+			"recommendations": []interface{}{},
+		}
+	}
+	if product.Price <= 0 {
+		return map[string]interface{}{
+			"error":           "Product price must be positive",
+			"recommendations": []interface{}{},
+		}
+	}
+	var products []Product
+	if err := json.Unmarshal([]byte(args[1].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error":           "Invalid products JSON: " + err.Error(),
+			"recommendations": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	return map[string]interface{}{
+		"error":           "",
+		"recommendations": productsToJS(RecommendUpsells(product, products)),
+	}
+}
+
+// WebAssembly wrapper for content-based similarity - the products of a
+// catalog most like one by name and description
+func similarProductsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber || args[2].Type() != js.TypeNumber {
+		return map[string]interface{}{
+			"error":   "Invalid arguments - expected products JSON, a product ID and a count",
+			"similar": []interface{}{},
+		}
+	}
+
+	var products []Product
+	if err := json.Unmarshal([]byte(args[0].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error":   "Invalid products JSON: " + err.Error(),
+			"similar": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	similar := BuildTextIndex(products).SimilarProducts(args[1].Int(), args[2].Int())
+
+	result := make([]interface{}, len(similar))
+	for i, entry := range similar {
+		product := productsToJS([]Product{entry.Product})[0].(map[string]interface{})
+		product["similarity"] = entry.Similarity
+		result[i] = product
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"similar": result,
+	}
+}
+
+// WebAssembly wrapper for SelectThumbnail - the picture of a product to show
+// in a tile of some pixels square, as GET /api/products/{id}?thumbnail= picks
+func selectThumbnailWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected product JSON and a size in pixels",
+		}
+	}
+
+	product, err := ProductFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid product JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	thumbnail, ok := product.SelectThumbnail(args[1].Int())
+	if !ok {
+		return map[string]interface{}{
+			"error":     "",
+			"thumbnail": nil,
+		}
+	}
+	return map[string]interface{}{
+		"error":     "",
+		"thumbnail": imageToJS(thumbnail),
+	}
+}
+
+// WebAssembly wrapper for product search - typo-tolerant, sorted, with the
+// spans to highlight; options JSON picks the sort and limit
+func searchProductsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":   "Invalid arguments - expected products JSON and a query, and optionally options JSON",
+			"results": []interface{}{},
+		}
+	}
+
+	var products []Product
+	if err := json.Unmarshal([]byte(args[0].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error":   "Invalid products JSON: " + err.Error(),
+			"results": []interface{}{},
+		}
+	}
+	var options SearchOptions
+	if len(args) == 3 {
+		if err := json.Unmarshal([]byte(args[2].String()), &options); err != nil {
+			return map[string]interface{}{
+				"error":   "Invalid options JSON: " + err.Error(),
+				"results": []interface{}{},
+			}
+		}
+		if result := ValidateSearchOptions(options); !result.Valid {
+			return map[string]interface{}{
+				"error":   result.Errors[0],
+				"results": []interface{}{},
+			}
+		}
+	}
+
+	// Use shared business logic
+	results := SearchProducts(products, args[1].String(), options)
+
+	list := make([]interface{}, len(results))
+	for i, result := range results {
+		product := productsToJS([]Product{result.Product})[0].(map[string]interface{})
+		matches := make([]interface{}, len(result.Matches))
+		for j, match := range result.Matches {
+			matches[j] = map[string]interface{}{
+				"field": match.Field,
+				"start": match.Start,
+				"end":   match.End,
+			}
+		}
+		product["score"] = result.Score
+		product["text_score"] = result.TextScore
+		product["matches"] = matches
+		list[i] = product
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"results": list,
+	}
+}
+
+// WebAssembly wrapper for the catalog listing - the products a filter
+// selects, with the facets to narrow them
+func filterProductsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":    "Invalid arguments - expected products JSON and filter JSON",
+			"products": []interface{}{},
+		}
+	}
+
+	var products []Product
+	if err := json.Unmarshal([]byte(args[0].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid products JSON: " + err.Error(),
+			"products": []interface{}{},
+		}
+	}
+	var filter ProductFilter
+	if err := json.Unmarshal([]byte(args[1].String()), &filter); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid filter JSON: " + err.Error(),
+			"products": []interface{}{},
+		}
+	}
+	if result := ValidateProductFilter(filter); !result.Valid {
+		return map[string]interface{}{
+			"error":    result.Errors[0],
+			"products": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	listing := ListProducts(products, filter)
+
+	categories := make([]interface{}, len(listing.Facets.Categories))
+	for i, category := range listing.Facets.Categories {
+		categories[i] = map[string]interface{}{"value": category.Value, "count": category.Count}
+	}
+	prices := make([]interface{}, len(listing.Facets.Prices))
+	for i, bucket := range listing.Facets.Prices {
+		prices[i] = map[string]interface{}{"min": bucket.Min, "max": bucket.Max, "count": bucket.Count}
+	}
+	ratings := make([]interface{}, len(listing.Facets.Ratings))
+	for i, band := range listing.Facets.Ratings {
+		ratings[i] = map[string]interface{}{"min_rating": band.MinRating, "count": band.Count}
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"products": productsToJS(listing.Products),
+		"total":    listing.Total,
+		"facets": map[string]interface{}{
+			"categories": categories,
+			"prices":     prices,
+			"ratings":    ratings,
+			"in_stock":   listing.Facets.InStock,
+		},
+	}
+}
+
+// WebAssembly wrapper for market-basket analysis - frequently bought together
+// rules of orders, with optional minimum support and confidence
+func mineAssociationsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 && len(args) != 3 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected orders JSON, and optionally minimum support and confidence",
+			"rules": []interface{}{},
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid orders JSON: " + err.Error(),
+			"rules": []interface{}{},
+		}
+	}
+	minSupport, minConfidence := 0.1, 0.5
+	if len(args) == 3 {
+		minSupport, minConfidence = args[1].Float(), args[2].Float()
+	}
+	if result := ValidateAssociationThresholds(minSupport, minConfidence); !result.Valid {
+		return map[string]interface{}{
+			"error": strings.Join(result.Errors, "; "),
+			"rules": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	rules := MineAssociations(orders, minSupport, minConfidence)
+
+	result := make([]interface{}, len(rules))
+	for i, rule := range rules {
+		antecedent := make([]interface{}, len(rule.Antecedent))
+		for j, id := range rule.Antecedent {
+			antecedent[j] = id
+		}
+		result[i] = map[string]interface{}{
+			"antecedent": antecedent,
+			"consequent": rule.Consequent,
+			"count":      rule.Count,
+			"support":    rule.Support,
+			"confidence": rule.Confidence,
+			"lift":       rule.Lift,
+		}
+	}
+	return map[string]interface{}{
+		"error": "",
+		"rules": result,
+	}
+}
+
+// WebAssembly wrapper for RFM segmentation - the segments of customers in
+// users and orders passed from the page
+func segmentUsersRFMWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":     "Invalid arguments - expected users JSON and orders JSON",
+			"customers": []interface{}{},
+		}
+	}
+
+	var users []User
+	if err := json.Unmarshal([]byte(args[0].String()), &users); err != nil {
+		return map[string]interface{}{
+			"error":     "Invalid users JSON: " + err.Error(),
+			"customers": []interface{}{},
+		}
+	}
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[1].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":     "Invalid orders JSON: " + err.Error(),
+			"customers": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	segmentation := SegmentUsersRFM(users, orders)
+
+	customers := make([]interface{}, len(segmentation.Customers))
+	for i, customer := range segmentation.Customers {
+		customers[i] = map[string]interface{}{
+			"user_id":         customer.UserID,
+			"name":            customer.Name,
+			"last_order":      customer.LastOrder,
+			"recency_days":    customer.RecencyDays,
+			"orders":          customer.Orders,
+			"monetary":        customer.Monetary,
+			"recency_score":   customer.RecencyScore,
+			"frequency_score": customer.FrequencyScore,
+			"monetary_score":  customer.MonetaryScore,
+			"segment":         customer.Segment,
+		}
+	}
+	segments := make([]interface{}, len(segmentation.Segments))
+	for i, summary := range segmentation.Segments {
+		segments[i] = map[string]interface{}{
+			"segment":   summary.Segment,
+			"customers": summary.Customers,
+			"monetary":  summary.Monetary,
+		}
+	}
+	return map[string]interface{}{
+		"error":     "",
+		"as_of":     segmentation.AsOf,
+		"customers": customers,
+		"segments":  segments,
+	}
+}
+
+// WebAssembly wrapper for trending products - recency-weighted sales
+// velocity of orders over an optional window in days
+func trendingProductsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 && len(args) != 2 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":    "Invalid arguments - expected orders JSON, and optionally a window in days",
+			"products": []interface{}{},
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid orders JSON: " + err.Error(),
+			"products": []interface{}{},
+		}
+	}
+	window := DefaultTrendingWindow
+	if len(args) == 2 {
+		days := args[1].Int()
+		if days < 1 || days > 365 {
+			return map[string]interface{}{
+				"error":    "Window must be from 1 to 365 days",
+				"products": []interface{}{},
+			}
+		}
+		window = time.Duration(days) * 24 * time.Hour
+	}
+
+	// Use shared business logic
+	list := TrendingProducts(orders, window)
+
+	result := make([]interface{}, len(list))
+	for i, entry := range list {
+		result[i] = map[string]interface{}{
+			"product_id": entry.ProductID,
+			"name":       entry.Name,
+			"units":      entry.Units,
+			"orders":     entry.Orders,
+			"score":      entry.Score,
+		}
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"products": result,
+	}
+}
+
+// productsToJS converts recommended products to JavaScript-compatible format.
+func productsToJS(products []Product) []interface{} {
+	result := make([]interface{}, len(products))
+	for i, product := range products {
+		result[i] = map[string]interface{}{
+			"id":          product.ID,
+			"name":        product.Name,
+			"price":       product.Price,
+			"category":    product.Category,
+			"on_hand":     product.OnHand,
+			"reserved":    product.Reserved,
+			"available":   product.Available(),
+			"rating":      product.Rating,
+			"description": product.Description,
+			"image_url":   product.ImageURL,
+			"images":      imagesToJS(product.Images),
+		}
+	}
+	return result
+}
+
+// imagesToJS converts product pictures to JavaScript-compatible format.
+func imagesToJS(images []ProductImage) []interface{} {
+	result := make([]interface{}, len(images))
+	for i, image := range images {
+		result[i] = imageToJS(image)
+	}
+	return result
+}
+
+func imageToJS(image ProductImage) map[string]interface{} {
+	return map[string]interface{}{
+		"url":    image.URL,
+		"width":  image.Width,
+		"height": image.Height,
+		"alt":    image.Alt,
+	}
+}
+
+// WebAssembly wrapper for user behavior analysis
+func analyzeUserBehaviorWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected 2, or 3 with a filter",
+		}
+	}
+
+	// Validate argument types
+	if args[0].Type() != js.TypeString || args[1].Type() != js.TypeString || len(args) == 3 && args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid argument types - expected strings",
+		}
+	}
+
+	usersJSON := args[0].String()
+	ordersJSON := args[1].String()
+
+	// Validate inputs are not empty
+	if len(usersJSON) == 0 {
+		return map[string]interface{}{
+			"error": "Empty users JSON",
+		}
+	}
+	if len(ordersJSON) == 0 {
+		return map[string]interface{}{
 			"error": "Empty orders JSON",
 		}
 	}
@@ -392,38 +1278,2158 @@ func analyzeUserBehaviorWasm(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
+	var filter AnalyticsFilter
+	if len(args) == 3 {
+		if err := json.Unmarshal([]byte(args[2].String()), &filter); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid filter JSON: " + err.Error(),
+			}
+		}
+		if result := ValidateAnalyticsFilter(filter); !result.Valid {
+			return map[string]interface{}{
+				"error": result.Errors[0],
+			}
+		}
+	}
+
 	// Use shared business logic
-	analytics := AnalyzeUserBehavior(users, orders)
+	return analyticsResult(AnalyzeUserBehavior(users, orders, filter))
+}
 
+// analyticsResult is the JavaScript form of analytics.
+func analyticsResult(analytics UserAnalytics) map[string]interface{} {
+	geography := func(areas []GeoRevenue) []interface{} {
+		result := make([]interface{}, len(areas))
+		for i, area := range areas {
+			result[i] = map[string]interface{}{
+				"country":             area.Country,
+				"region":              area.Region,
+				"users":               area.Users,
+				"orders":              area.Orders,
+				"revenue":             area.Revenue,
+				"average_order_value": area.AverageOrderValue,
+				"premium_share":       area.PremiumShare,
+				"revenue_share":       area.RevenueShare,
+			}
+		}
+		return result
+	}
+	countries := make([]interface{}, len(analytics.TopCountries))
+	for i, country := range analytics.TopCountries {
+		countries[i] = map[string]interface{}{
+			"country":       country.Country,
+			"users":         country.Users,
+			"percentage":    country.Percentage,
+			"revenue":       country.Revenue,
+			"revenue_share": country.RevenueShare,
+		}
+	}
+	mixes := make([]interface{}, len(analytics.Baskets.CategoryMixes))
+	for i, mix := range analytics.Baskets.CategoryMixes {
+		mixes[i] = map[string]interface{}{
+			"categories":    mix.Categories,
+			"orders":        mix.Orders,
+			"share":         mix.Share,
+			"average_value": mix.AverageValue,
+		}
+	}
+	anomalies := make([]interface{}, len(analytics.Anomalies))
+	for i, anomaly := range analytics.Anomalies {
+		reasons := make([]interface{}, len(anomaly.Reasons))
+		for j, reason := range anomaly.Reasons {
+			reasons[j] = reason
+		}
+		anomalies[i] = map[string]interface{}{
+			"order_id":   anomaly.OrderID,
+			"user_id":    anomaly.UserID,
+			"order_date": anomaly.OrderDate,
+			"total":      anomaly.Total,
+			"reasons":    reasons,
+		}
+	}
 	return map[string]interface{}{
-		"error":               "",
-		"average_age":         analytics.AverageAge,
-		"premium_percentage":  analytics.PremiumPercentage,
-		"top_countries":       analytics.TopCountries,
-		"total_revenue":       analytics.TotalRevenue,
-		"average_order_value": analytics.AverageOrderValue,
+		"error":              "",
+		"average_age":        analytics.AverageAge,
+		"premium_percentage": analytics.PremiumPercentage,
+		"top_countries":      countries,
+		"age_histogram":      histogramResult(analytics.AgeHistogram),
+		"country_revenue":    geography(analytics.CountryRevenue),
+		"region_revenue":     geography(analytics.RegionRevenue),
+		"total_revenue":      analytics.TotalRevenue,
+		"order_values": map[string]interface{}{
+			"count":   analytics.OrderValues.Count,
+			"mean":    analytics.OrderValues.Mean,
+			"std_dev": analytics.OrderValues.StdDev,
+			"min":     analytics.OrderValues.Min,
+			"max":     analytics.OrderValues.Max,
+			"p50":     analytics.OrderValues.P50,
+			"p90":     analytics.OrderValues.P90,
+			"p99":     analytics.OrderValues.P99,
+		},
+		"order_value_histogram": histogramResult(analytics.OrderValueHistogram),
+		"baskets": map[string]interface{}{
+			"orders":                analytics.Baskets.Orders,
+			"average_items":         analytics.Baskets.AverageItems,
+			"items_per_order":       histogramResult(analytics.Baskets.ItemsPerOrder),
+			"single_category_share": analytics.Baskets.SingleCategoryShare,
+			"multi_category_share":  analytics.Baskets.MultiCategoryShare,
+			"category_mixes":        mixes,
+		},
+
+		"average_profile_completeness": analytics.AverageProfileCompleteness,
+		"incomplete_profiles":          analytics.IncompleteProfiles,
+		"average_churn_probability":    analytics.AverageChurnProbability,
+		"high_churn_risk":              analytics.HighChurnRisk,
+		"total_clv":                    analytics.TotalCLV,
+		"average_clv":                  analytics.AverageCLV,
+		"anomalies":                    anomalies,
 	}
 }
 
-// ====================================================================
-// UTILITY FUNCTIONS
-// ====================================================================
-
-// Debug function to check concurrency and system info
-func debugConcurrencyWasm(this js.Value, args []js.Value) interface{} {
-	return map[string]interface{}{
-		"GOMAXPROCS":    runtime.GOMAXPROCS(0),
-		"NumCPU":        runtime.NumCPU(),
-		"NumGoroutines": runtime.NumGoroutine(),
-		"GoVersion":     runtime.Version(),
-		"GOARCH":        runtime.GOARCH,
-		"GOOS":          runtime.GOOS,
+// histogramResult lists histogram buckets for the page.
+func histogramResult(buckets []HistogramBucket) []interface{} {
+	result := make([]interface{}, len(buckets))
+	for i, bucket := range buckets {
+		result[i] = map[string]interface{}{
+			"label":      bucket.Label,
+			"min":        bucket.Min,
+			"count":      bucket.Count,
+			"percentage": bucket.Percentage,
+		}
 	}
+	return result
 }
 
-// Additional helper functions for parsing products
-func ProductFromJSON(jsonStr string) (Product, error) {
-	var product Product
-	err := json.Unmarshal([]byte(jsonStr), &product)
-	return product, err
+// WebAssembly wrapper for histograms - buckets values passed from the page
+// by the lower edges of a bucket spec
+func buildHistogramWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":   "Invalid arguments - expected values JSON and bucket spec JSON",
+			"buckets": []interface{}{},
+		}
+	}
+
+	var values []float64
+	if err := json.Unmarshal([]byte(args[0].String()), &values); err != nil {
+		return map[string]interface{}{
+			"error":   "Invalid values JSON: " + err.Error(),
+			"buckets": []interface{}{},
+		}
+	}
+	var spec BucketSpec
+	if err := json.Unmarshal([]byte(args[1].String()), &spec); err != nil {
+		return map[string]interface{}{
+			"error":   "Invalid bucket spec JSON: " + err.Error(),
+			"buckets": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	buckets, err := BuildHistogram(values, spec)
+	if err != nil {
+		return map[string]interface{}{
+			"error":   err.Error(),
+			"buckets": []interface{}{},
+		}
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"buckets": histogramResult(buckets),
+	}
+}
+
+// pageAnalytics is the page's incremental analytics, fed by
+// addAnalyticsUserWasm and addAnalyticsOrderWasm.
+var pageAnalytics = NewAnalyticsAccumulator()
+
+// WebAssembly wrapper for incremental analytics - starts the page's
+// analytics over with no users or orders
+func resetAnalyticsWasm(this js.Value, args []js.Value) interface{} {
+	pageAnalytics = NewAnalyticsAccumulator()
+	return map[string]interface{}{
+		"error": "",
+	}
+}
+
+// WebAssembly wrapper for incremental analytics - adds a user to the page's
+// analytics; add users before their orders
+func addAnalyticsUserWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected user JSON",
+		}
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(args[0].String()), &user); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	pageAnalytics.AddUser(user)
+	return map[string]interface{}{
+		"error": "",
+	}
+}
+
+// WebAssembly wrapper for incremental analytics - adds an order to the
+// page's analytics as it arrives
+func addAnalyticsOrderWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected order JSON",
+		}
+	}
+
+	var order Order
+	if err := json.Unmarshal([]byte(args[0].String()), &order); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	pageAnalytics.AddOrder(order)
+	return map[string]interface{}{
+		"error": "",
+	}
+}
+
+// WebAssembly wrapper for incremental analytics - the analytics of the users
+// and orders added so far, as analyzeUserBehaviorWasm reports them, with
+// optionally how many top countries to rank
+func analyticsSnapshotWasm(this js.Value, args []js.Value) interface{} {
+	pageAnalytics.TopCountries = DefaultTopCountries
+	if len(args) == 1 {
+		n := args[0].Int()
+		if n < 1 || n > maxTopCountries {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Top countries must be from 1 to %d", maxTopCountries),
+			}
+		}
+		pageAnalytics.TopCountries = n
+	}
+
+	// Use shared business logic
+	return analyticsResult(pageAnalytics.Snapshot())
+}
+
+// WebAssembly wrapper for the revenue time series - orders passed from the
+// page by day, week or month, optionally only those from one date to another
+func aggregateRevenueWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 4 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":  "Invalid arguments - expected orders JSON and a granularity, and optionally from and to dates",
+			"points": []interface{}{},
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":  "Invalid orders JSON: " + err.Error(),
+			"points": []interface{}{},
+		}
+	}
+	if len(args) == 4 {
+		orders = ordersBetween(orders, args[2].String(), args[3].String())
+	}
+
+	// Use shared business logic
+	points, err := AggregateRevenue(orders, args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error":  err.Error(),
+			"points": []interface{}{},
+		}
+	}
+
+	result := make([]interface{}, len(points))
+	for i, point := range points {
+		result[i] = map[string]interface{}{
+			"period":              point.Period,
+			"revenue":             point.Revenue,
+			"orders":              point.Orders,
+			"average_order_value": point.AverageOrderValue,
+		}
+	}
+	return map[string]interface{}{
+		"error":  "",
+		"points": result,
+	}
+}
+
+// WebAssembly wrapper for revenue forecasting - forecasts the revenue series
+// of orders passed from the page, with optional settings JSON to try
+// horizons, seasons and smoothing
+func forecastRevenueWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":  "Invalid arguments - expected orders JSON and a granularity, and optionally settings JSON",
+			"points": []interface{}{},
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":  "Invalid orders JSON: " + err.Error(),
+			"points": []interface{}{},
+		}
+	}
+	settings := DefaultForecastSettings
+	if len(args) == 3 {
+		var err error
+		if settings, err = ParseForecastSettings([]byte(args[2].String())); err != nil {
+			return map[string]interface{}{
+				"error":  err.Error(),
+				"points": []interface{}{},
+			}
+		}
+	}
+
+	// Use shared business logic
+	history, err := AggregateRevenue(orders, args[1].String())
+	var forecast RevenueForecast
+	if err == nil {
+		forecast, err = ForecastRevenue(history, args[1].String(), settings)
+	}
+	if err != nil {
+		return map[string]interface{}{
+			"error":  err.Error(),
+			"points": []interface{}{},
+		}
+	}
+
+	points := make([]interface{}, len(forecast.Points))
+	for i, point := range forecast.Points {
+		points[i] = map[string]interface{}{
+			"period":  point.Period,
+			"revenue": point.Revenue,
+			"lower":   point.Lower,
+			"upper":   point.Upper,
+		}
+	}
+	return map[string]interface{}{
+		"error":       "",
+		"granularity": forecast.Granularity,
+		"method":      forecast.Method,
+		"season":      forecast.Season,
+		"rmse":        forecast.RMSE,
+		"points":      points,
+	}
+}
+
+// WebAssembly wrapper for the purchase funnel - a locally loaded event log
+// followed from viewed to purchased
+func computeFunnelWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected events JSON",
+			"steps": []interface{}{},
+		}
+	}
+
+	var events []BehaviorEvent
+	if err := json.Unmarshal([]byte(args[0].String()), &events); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid events JSON: " + err.Error(),
+			"steps": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	steps := ComputeFunnel(events)
+
+	result := make([]interface{}, len(steps))
+	for i, step := range steps {
+		result[i] = map[string]interface{}{
+			"step":       step.Step,
+			"shoppers":   step.Shoppers,
+			"conversion": step.Conversion,
+			"overall":    step.Overall,
+			"drop_off":   step.DropOff,
+		}
+	}
+	return map[string]interface{}{
+		"error": "",
+		"steps": result,
+	}
+}
+
+// WebAssembly wrapper for sessionization - a locally loaded event log split
+// into sessions at an optional idle gap in minutes, and their funnel
+func sessionizeEventsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 && len(args) != 2 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":    "Invalid arguments - expected events JSON, and optionally a gap in minutes",
+			"sessions": []interface{}{},
+		}
+	}
+
+	var events []BehaviorEvent
+	if err := json.Unmarshal([]byte(args[0].String()), &events); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid events JSON: " + err.Error(),
+			"sessions": []interface{}{},
+		}
+	}
+	gap := DefaultSessionGap
+	if len(args) == 2 {
+		gap = time.Duration(args[1].Int()) * time.Minute
+		if gap < time.Minute || gap > maxSessionGap {
+			return map[string]interface{}{
+				"error":    fmt.Sprintf("Gap must be from 1 to %d minutes", int(maxSessionGap/time.Minute)),
+				"sessions": []interface{}{},
+			}
+		}
+	}
+
+	// Use shared business logic
+	sessions := SessionizeEvents(events, gap)
+
+	result := make([]interface{}, len(sessions))
+	for i, session := range sessions {
+		result[i] = map[string]interface{}{
+			"id":               session.ID,
+			"user_id":          session.UserID,
+			"session_id":       session.SessionID,
+			"start":            session.Start,
+			"end":              session.End,
+			"duration_seconds": session.DurationSeconds,
+			"events":           session.Events,
+			"views":            session.Views,
+			"clicks":           session.Clicks,
+			"products":         session.Products,
+			"added_to_cart":    session.AddedToCart,
+			"checked_out":      session.CheckedOut,
+			"purchased":        session.Purchased,
+		}
+	}
+	funnel := SessionFunnel(sessions)
+	steps := make([]interface{}, len(funnel))
+	for i, step := range funnel {
+		steps[i] = map[string]interface{}{
+			"step":       step.Step,
+			"shoppers":   step.Shoppers,
+			"conversion": step.Conversion,
+			"overall":    step.Overall,
+			"drop_off":   step.DropOff,
+		}
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"sessions": result,
+		"funnel":   steps,
+	}
+}
+
+// pageEvents are the page's tracked events, waiting for flushEventsWasm.
+var pageEvents = &EventBuffer{}
+
+// WebAssembly wrapper for event tracking - buffers a behavior event and
+// reports whether a batch is due for flushEventsWasm
+func trackEventWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected event JSON",
+		}
+	}
+
+	var event BehaviorEvent
+	if err := json.Unmarshal([]byte(args[0].String()), &event); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid event JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	if result := pageEvents.Track(event, time.Now()); !result.Valid {
+		return map[string]interface{}{
+			"error":    "Invalid event: " + result.Errors[0],
+			"buffered": pageEvents.Len(),
+		}
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"buffered": pageEvents.Len(),
+		"flush":    pageEvents.Ready(),
+	}
+}
+
+// WebAssembly wrapper for event tracking - takes the oldest buffered events
+// as the body of a POST /api/events for the page to send
+func flushEventsWasm(this js.Value, args []js.Value) interface{} {
+	// Use shared business logic
+	batch := pageEvents.Flush()
+	body, err := json.Marshal(map[string]interface{}{"events": batch})
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode events: " + err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"error":     "",
+		"count":     len(batch),
+		"body":      string(body),
+		"remaining": pageEvents.Len(),
+	}
+}
+
+// WebAssembly wrapper for top products - the dashboard's best sellers of
+// orders passed from the page, the first n by revenue, units or margin
+func topProductsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":    "Invalid arguments - expected orders JSON, a count and a metric",
+			"products": []interface{}{},
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid orders JSON: " + err.Error(),
+			"products": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	products, err := TopProducts(orders, args[1].Int(), args[2].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error":    err.Error(),
+			"products": []interface{}{},
+		}
+	}
+
+	result := make([]interface{}, len(products))
+	for i, product := range products {
+		result[i] = map[string]interface{}{
+			"rank":       product.Rank,
+			"product_id": product.ProductID,
+			"name":       product.Name,
+			"units":      product.Units,
+			"revenue":    product.Revenue,
+			"margin":     product.Margin,
+			"value":      product.Value,
+			"share":      product.Share,
+		}
+	}
+	return map[string]interface{}{
+		"error":    "",
+		"products": result,
+	}
+}
+
+// experimentArg decodes and validates an experiment passed from the page.
+func experimentArg(arg js.Value) (Experiment, string) {
+	var experiment Experiment
+	if err := json.Unmarshal([]byte(arg.String()), &experiment); err != nil {
+		return experiment, "Invalid experiment JSON: " + err.Error()
+	}
+	if result := ValidateExperiment(experiment); !result.Valid {
+		return experiment, "Invalid experiment: " + result.Errors[0]
+	}
+	return experiment, ""
+}
+
+// WebAssembly wrapper for experiment assignment - the variant a user is in,
+// the same the server assigns
+func assignVariantWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected experiment JSON and a user ID",
+		}
+	}
+	experiment, problem := experimentArg(args[0])
+	if problem != "" {
+		return map[string]interface{}{
+			"error": problem,
+		}
+	}
+
+	// Use shared business logic
+	return map[string]interface{}{
+		"error":   "",
+		"user_id": args[1].Int(),
+		"variant": AssignVariant(experiment, args[1].Int()),
+	}
+}
+
+// WebAssembly wrapper for experiment analysis - the conversion lift of an
+// experiment's variants over users and orders passed from the page
+func analyzeExperimentWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":    "Invalid arguments - expected experiment JSON, users JSON and orders JSON",
+			"variants": []interface{}{},
+		}
+	}
+	experiment, problem := experimentArg(args[0])
+	if problem != "" {
+		return map[string]interface{}{
+			"error":    problem,
+			"variants": []interface{}{},
+		}
+	}
+	var users []User
+	if err := json.Unmarshal([]byte(args[1].String()), &users); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid users JSON: " + err.Error(),
+			"variants": []interface{}{},
+		}
+	}
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[2].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":    "Invalid orders JSON: " + err.Error(),
+			"variants": []interface{}{},
+		}
+	}
+
+	// Use shared business logic
+	analysis := AnalyzeExperiment(experiment, users, orders)
+
+	variants := make([]interface{}, len(analysis.Variants))
+	for i, variant := range analysis.Variants {
+		variants[i] = map[string]interface{}{
+			"variant":          variant.Variant,
+			"users":            variant.Users,
+			"conversions":      variant.Conversions,
+			"conversion_rate":  variant.ConversionRate,
+			"revenue":          variant.Revenue,
+			"revenue_per_user": variant.RevenuePerUser,
+			"lift":             variant.Lift,
+			"lift_lower":       variant.LiftLower,
+			"lift_upper":       variant.LiftUpper,
+			"significant":      variant.Significant,
+		}
+	}
+	return map[string]interface{}{
+		"error":      "",
+		"experiment": analysis.Experiment,
+		"control":    analysis.Control,
+		"variants":   variants,
+	}
+}
+
+// WebAssembly wrapper for customer lifetime value - a user's projected value
+// from orders passed from the page, over an optional horizon in months
+func calculateCLVWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected user JSON and orders JSON, and optionally a horizon in months",
+		}
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(args[0].String()), &user); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+		}
+	}
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[1].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid orders JSON: " + err.Error(),
+		}
+	}
+	months := DefaultCLVHorizonMonths
+	if len(args) == 3 {
+		months = args[2].Int()
+	}
+	if months < 1 || months > maxCLVHorizonMonths {
+		return map[string]interface{}{
+			"error": fmt.Sprintf("Horizon must be from 1 to %d months", maxCLVHorizonMonths),
+		}
+	}
+
+	// Use shared business logic
+	value := CalculateCLV(user, orders, months)
+
+	return map[string]interface{}{
+		"error":               "",
+		"user_id":             value.UserID,
+		"name":                value.Name,
+		"orders":              value.Orders,
+		"average_order_value": value.AverageOrderValue,
+		"monthly_orders":      value.MonthlyOrders,
+		"horizon_months":      value.HorizonMonths,
+		"clv":                 value.CLV,
+	}
+}
+
+// WebAssembly wrapper for churn risk - the churn probability of each
+// customer in users and orders passed from the page, optionally only those
+// at least as likely as a minimum
+func scoreChurnRiskWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error":     "Invalid arguments - expected users JSON and orders JSON, and optionally a minimum probability",
+			"customers": []interface{}{},
+		}
+	}
+
+	var users []User
+	if err := json.Unmarshal([]byte(args[0].String()), &users); err != nil {
+		return map[string]interface{}{
+			"error":     "Invalid users JSON: " + err.Error(),
+			"customers": []interface{}{},
+		}
+	}
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[1].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error":     "Invalid orders JSON: " + err.Error(),
+			"customers": []interface{}{},
+		}
+	}
+	minProbability := 0.0
+	if len(args) == 3 {
+		minProbability = args[2].Float()
+	}
+
+	// Use shared business logic
+	risks := FilterChurnRisk(ScoreChurnRisk(users, orders), minProbability, "")
+
+	customers := make([]interface{}, len(risks))
+	for i, risk := range risks {
+		customers[i] = map[string]interface{}{
+			"user_id":           risk.UserID,
+			"name":              risk.Name,
+			"last_order":        risk.LastOrder,
+			"last_active":       risk.LastActive,
+			"days_since_order":  risk.DaysSinceOrder,
+			"orders":            risk.Orders,
+			"frequency_decline": risk.FrequencyDecline,
+			"premium_lapsed":    risk.PremiumLapsed,
+			"probability":       risk.Probability,
+			"risk":              risk.Risk,
+		}
+	}
+	return map[string]interface{}{
+		"error":     "",
+		"customers": customers,
+	}
+}
+
+// WebAssembly wrapper for ProfileCompleteness - scores a user's profile for
+// a progress widget. Takes the user as JSON.
+func profileCompletenessWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected user JSON",
+		}
+	}
+
+	user, err := UserFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic
+	score := ProfileCompleteness(user)
+
+	gaps := make([]interface{}, len(score.Gaps))
+	for i, gap := range score.Gaps {
+		gaps[i] = map[string]interface{}{
+			"field":   gap.Field,
+			"status":  gap.Status,
+			"message": gap.Message,
+			"weight":  gap.Weight,
+		}
+	}
+
+	return map[string]interface{}{
+		"error":   "",
+		"percent": score.Percent,
+		"gaps":    gaps,
+	}
+}
+
+// WebAssembly wrapper for CSV export, using the same table formatting as the
+// server's /api/export endpoints. Takes the dataset name and its JSON.
+func exportCsvWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected 2",
+		}
+	}
+
+	if args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid argument types - expected strings",
+		}
+	}
+
+	table, err := ExportTableFromJSON(args[0].String(), []byte(args[1].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	csvText, err := ExportCSV(table)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to generate CSV: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"csv":      csvText,
+		"filename": table.Name + ".csv",
+		"rows":     len(table.Rows),
+	}
+}
+
+// WebAssembly wrapper for cart pricing, using the same shared logic as the
+// server's /api/cart endpoints. Takes cart, products and user JSON.
+func cartSummaryWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected cart, products and user JSON",
+		}
+	}
+
+	for i, arg := range args {
+		if arg.Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+	}
+
+	var cart Cart
+	if err := json.Unmarshal([]byte(args[0].String()), &cart); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid cart JSON: " + err.Error(),
+		}
+	}
+
+	var products []Product
+	if err := json.Unmarshal([]byte(args[1].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid products JSON: " + err.Error(),
+		}
+	}
+
+	user, err := UserFromJSON(args[2].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic
+	summary := SummarizeCart(cart, products, user)
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode cart summary: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":   "",
+		"summary": string(summaryJSON),
+		"total":   summary.Order.Total,
+	}
+}
+
+// WebAssembly wrapper for MergeCarts - folds the guest cart a page kept
+// before sign-in into the user's cart. Takes guest and user cart JSON.
+func mergeCartsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected guest and user cart JSON",
+		}
+	}
+
+	carts := make([]Cart, 2)
+	for i, name := range []string{"guest", "user"} {
+		if args[i].Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+		if err := json.Unmarshal([]byte(args[i].String()), &carts[i]); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid " + name + " cart JSON: " + err.Error(),
+			}
+		}
+	}
+
+	// Use shared business logic
+	cartJSON, err := json.Marshal(MergeCarts(carts[0], carts[1]))
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode cart: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error": "",
+		"cart":  string(cartJSON),
+	}
+}
+
+// WebAssembly wrapper for MoveToCart - moves a wishlisted product into the
+// cart. Takes wishlist, cart and products JSON, the product ID and
+// optionally a quantity (default 1) and the variant SKU.
+func moveToCartWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 4 || len(args) > 6 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected wishlist, cart and products JSON, product ID and optionally quantity and SKU",
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if args[i].Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+	}
+	for i := 3; i < min(len(args), 5); i++ {
+		if args[i].Type() != js.TypeNumber {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a number", i),
+			}
+		}
+	}
+
+	var wishlist Wishlist
+	if err := json.Unmarshal([]byte(args[0].String()), &wishlist); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid wishlist JSON: " + err.Error(),
+		}
+	}
+	var cart Cart
+	if err := json.Unmarshal([]byte(args[1].String()), &cart); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid cart JSON: " + err.Error(),
+		}
+	}
+	var products []Product
+	if err := json.Unmarshal([]byte(args[2].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid products JSON: " + err.Error(),
+		}
+	}
+	quantity, sku := 1, ""
+	if len(args) >= 5 {
+		quantity = args[4].Int()
+	}
+	if len(args) == 6 {
+		if args[5].Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": "Argument 5 is not a string",
+			}
+		}
+		sku = args[5].String()
+	}
+
+	// Use shared business logic
+	if err := MoveToCart(&wishlist, &cart, products, args[3].Int(), sku, quantity, time.Now()); err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	wishlistJSON, err := json.Marshal(wishlist)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode wishlist: " + err.Error(),
+		}
+	}
+	cartJSON, err := json.Marshal(cart)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode cart: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":    "",
+		"wishlist": string(wishlistJSON),
+		"cart":     string(cartJSON),
+	}
+}
+
+// WebAssembly wrapper for ComparePrice - the was/now label of a product from
+// its price history JSON, as of now or an optional RFC 3339 time.
+func comparePriceWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 && len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected price history JSON and optionally a time",
+		}
+	}
+	for i, arg := range args {
+		if arg.Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+	}
+
+	var history PriceHistory
+	if err := json.Unmarshal([]byte(args[0].String()), &history); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid price history JSON: " + err.Error(),
+		}
+	}
+	now := time.Now()
+	if len(args) == 2 {
+		var err error
+		if now, err = time.Parse(time.RFC3339, args[1].String()); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid time: " + err.Error(),
+			}
+		}
+	}
+
+	// Use shared business logic
+	comparison := ComparePrice(history, now, WasPricePeriod)
+
+	return map[string]interface{}{
+		"error":            "",
+		"now":              comparison.Now,
+		"was":              comparison.Was,
+		"discount_percent": comparison.DiscountPercent,
+		"on_sale":          comparison.OnSale,
+		"since":            comparison.Since,
+	}
+}
+
+// WebAssembly wrapper for DynamicPrice - previews a product's demand-based
+// price from product and order history JSON. An optional pricing JSON
+// overrides fields of DefaultDynamicPricing.
+func getDynamicPriceWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected product JSON, orders JSON and optionally pricing JSON",
+		}
+	}
+
+	var product Product
+	if err := json.Unmarshal([]byte(args[0].String()), &product); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid product JSON: " + err.Error(),
+		}
+	}
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[1].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid orders JSON: " + err.Error(),
+		}
+	}
+	pricing := DefaultDynamicPricing
+	if len(args) == 3 {
+		if err := json.Unmarshal([]byte(args[2].String()), &pricing); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid pricing JSON: " + err.Error(),
+			}
+		}
+	}
+	if err := pricing.Validate(); err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	// Use shared business logic - identical to the server catalog
+	signals := ComputeDemandSignals(product, orders, time.Now(), pricing.WindowDays)
+	quote := DynamicPrice(product, signals, pricing)
+
+	return map[string]interface{}{
+		"error":          "",
+		"base_price":     quote.BasePrice,
+		"price":          quote.Price,
+		"factor":         quote.Factor,
+		"units_sold":     signals.UnitsSold,
+		"daily_velocity": signals.DailyVelocity,
+		"days_of_cover":  signals.DaysOfCover,
+	}
+}
+
+// WebAssembly wrapper for ScoreOrderRisk - prices an order from order and
+// user JSON and scores it against an optional order history JSON.
+func scoreOrderRiskWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected order JSON, user JSON and optionally order history JSON",
+		}
+	}
+
+	var order Order
+	if err := json.Unmarshal([]byte(args[0].String()), &order); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+		}
+	}
+	var user User
+	if err := json.Unmarshal([]byte(args[1].String()), &user); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+		}
+	}
+	var history []Order
+	if len(args) == 3 {
+		if err := json.Unmarshal([]byte(args[2].String()), &history); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid order history JSON: " + err.Error(),
+			}
+		}
+	}
+	if err := CheckOrderLines(order); errors.Is(err, ErrQuantityMismatch) {
+		return map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic - identical to calculate-order
+	CalculateOrderTotal(&order, user)
+	risk := ScoreOrderRisk(order, user, history)
+
+	reasons := make([]interface{}, len(risk.Reasons))
+	for i, reason := range risk.Reasons {
+		reasons[i] = reason
+	}
+	return map[string]interface{}{
+		"error":        "",
+		"score":        risk.Score,
+		"reasons":      reasons,
+		"needs_review": risk.NeedsReview(),
+	}
+}
+
+// WebAssembly wrapper for GenerateInvoice - previews an order's invoice as
+// HTML and plain text from the templates the server renders. Orders not yet
+// priced are priced first.
+func generateInvoiceWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected order JSON and user JSON",
+		}
+	}
+
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	user, err := UserFromJSON(args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	if err := CheckOrderLines(order); errors.Is(err, ErrQuantityMismatch) {
+		return map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	if len(order.Lines) == 0 {
+		CalculateOrderTotal(&order, user)
+	}
+
+	// Use shared business logic - identical to /api/orders/{id}/invoice
+	invoice := GenerateInvoice(order, user)
+	html, err := RenderInvoiceHTML(invoice)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	text, err := RenderInvoiceText(invoice)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":      "",
+		"number":     invoice.Number,
+		"due_date":   invoice.DueDate,
+		"total":      invoice.Total,
+		"amount_due": invoice.AmountDue,
+		"html":       html,
+		"text":       text,
+	}
+}
+
+// WebAssembly wrapper for GenerateReceipt - previews an order's
+// confirmation or receipt email as HTML and plain text from the templates the
+// server renders, optionally of a kind and for a locale. Orders not yet
+// priced are priced first.
+func renderReceiptWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || len(args) > 4 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected order JSON, user JSON and optionally a kind and locale",
+		}
+	}
+
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	user, err := UserFromJSON(args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	if err := CheckOrderLines(order); errors.Is(err, ErrQuantityMismatch) {
+		return map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	if len(order.Lines) == 0 {
+		CalculateOrderTotal(&order, user)
+	}
+	kind, locale := "", ""
+	if len(args) > 2 {
+		kind = args[2].String()
+	}
+	if len(args) > 3 {
+		locale = args[3].String()
+	}
+
+	// Use shared business logic - identical to /api/orders/{id}/receipt
+	receipt, err := GenerateReceipt(order, user, kind, locale)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	html, err := RenderReceiptHTML(receipt)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	text, err := RenderReceiptText(receipt)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":  "",
+		"kind":   receipt.Kind,
+		"number": receipt.Number,
+		"locale": receipt.Locale,
+		"total":  receipt.Total,
+		"html":   html,
+		"text":   text,
+	}
+}
+
+// WebAssembly wrapper for FormatOrderNotification - previews the message a
+// user gets about an order event, as order webhook events carry it
+func previewNotificationWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 || len(args) > 4 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected order JSON, user JSON, an event and optionally the previous status",
+		}
+	}
+
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	user, err := UserFromJSON(args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	previousStatus := ""
+	if len(args) > 3 {
+		previousStatus = args[3].String()
+	}
+
+	// Use shared business logic - identical to the webhook events' payload
+	notification, err := FormatOrderNotification(args[2].String(), order, previousStatus, user)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":       "",
+		"deliverable": notification.Deliverable(),
+		"event":       notification.Event,
+		"channel":     notification.Channel,
+		"digest":      notification.Digest,
+		"locale":      notification.Locale,
+		"to":          notification.To,
+		"subject":     notification.Subject,
+		"body":        notification.Body,
+	}
+}
+
+// WebAssembly wrapper for FindDuplicateUsers - previews the likely duplicate
+// users of a bulk import from users JSON before it is uploaded.
+func findDuplicateUsersWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected users JSON",
+		}
+	}
+
+	var users []User
+	if err := json.Unmarshal([]byte(args[0].String()), &users); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid users JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic - identical to /api/users/duplicates
+	duplicates := FindDuplicateUsers(users)
+
+	pairs := make([]interface{}, len(duplicates))
+	for i, pair := range duplicates {
+		reasons := make([]interface{}, len(pair.Reasons))
+		for j, reason := range pair.Reasons {
+			reasons[j] = reason
+		}
+		pairs[i] = map[string]interface{}{
+			"first":     pair.First,
+			"second":    pair.Second,
+			"first_id":  pair.FirstID,
+			"second_id": pair.SecondID,
+			"score":     pair.Score,
+			"reasons":   reasons,
+		}
+	}
+	return map[string]interface{}{
+		"error":      "",
+		"duplicates": pairs,
+	}
+}
+
+// WebAssembly wrapper for GenerateDemoData - the options JSON takes the
+// parameters of /api/demo-data, with the weights as objects, and the same
+// options give the same records as the server
+func generateDemoDataWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) > 1 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected optionally an options JSON",
+		}
+	}
+
+	var opts DemoDataOptions
+	if len(args) == 1 {
+		if err := json.Unmarshal([]byte(args[0].String()), &opts); err != nil {
+			return map[string]interface{}{
+				"error": "Invalid options JSON: " + err.Error(),
+			}
+		}
+	}
+
+	data, err := GenerateDemoData(opts)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode demo data: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":    "",
+		"users":    len(data.Users),
+		"products": len(data.Products),
+		"orders":   len(data.Orders),
+		"data":     string(dataJSON),
+	}
+}
+
+// WebAssembly wrapper for ApplyVersionedPatch - updates a record the page
+// keeps, refusing patches made against an old version. Takes the model
+// ("user", "product" or "order"), the record JSON and the patch JSON, which
+// names the version it was made against.
+func updateRecordWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected model, record JSON and patch JSON",
+		}
+	}
+
+	// Use shared business logic
+	record, err := ApplyVersionedPatch(args[0].String(), args[1].String(), args[2].String())
+	if err != nil {
+		result := map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+		var conflict *VersionConflictError
+		if errors.As(err, &conflict) {
+			result["current_version"] = conflict.Current
+		}
+		return result
+	}
+
+	return map[string]interface{}{
+		"error":  "",
+		"record": record,
+	}
+}
+
+// jsCacheStorage keeps the data cache in a Web Storage object such as
+// localStorage.
+type jsCacheStorage struct {
+	storage js.Value
+}
+
+func (s jsCacheStorage) GetItem(key string) (string, bool) {
+	value := s.storage.Call("getItem", key)
+	if value.IsNull() || value.IsUndefined() {
+		return "", false
+	}
+	return value.String(), true
+}
+
+// SetItem reports the exception setItem throws when the storage is full.
+func (s jsCacheStorage) SetItem(key, value string) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("storing the data cache failed: %v", recovered)
+		}
+	}()
+	s.storage.Call("setItem", key, value)
+	return nil
+}
+
+// pageCache is the page's data cache, opened by openDataCacheWasm.
+var pageCache *DataCache
+
+// cacheOpen returns the page's data cache, or the error result for a page
+// that hasn't opened it.
+func cacheOpen() (*DataCache, map[string]interface{}) {
+	if pageCache == nil {
+		return nil, map[string]interface{}{
+			"error": "Data cache not open - call openDataCacheWasm first",
+		}
+	}
+	return pageCache, nil
+}
+
+// WebAssembly wrapper for NewDataCache - opens the page's data cache in
+// localStorage ("local", the default), sessionStorage ("session") or memory
+// ("memory"), falling back to memory where the storage is unavailable
+func openDataCacheWasm(this js.Value, args []js.Value) interface{} {
+	kind := "local"
+	if len(args) > 0 {
+		kind = args[0].String()
+	}
+	var storage CacheStorage
+	switch kind {
+	case "local", "session":
+		if bridge := js.Global().Get(kind + "Storage"); bridge.Truthy() {
+			storage = jsCacheStorage{storage: bridge}
+		} else {
+			kind = "memory"
+		}
+	case "memory":
+	default:
+		return map[string]interface{}{
+			"error": fmt.Sprintf("Unknown storage %q (use local, session or memory)", kind),
+		}
+	}
+
+	cache, err := NewDataCache(storage)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	pageCache = cache
+	return map[string]interface{}{
+		"error":   "",
+		"storage": kind,
+		"pending": len(cache.Pending().Changes),
+	}
+}
+
+// WebAssembly wrapper for DataCache.Store - caches the users, products or
+// orders the page fetched from the server
+func cacheStoreWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected model and records JSON",
+		}
+	}
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+
+	stored, err := cache.Store(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	return map[string]interface{}{
+		"error":  "",
+		"stored": stored,
+	}
+}
+
+// WebAssembly wrapper for DataCache.Records - the cached records of a model
+// with the page's edits, as a JSON array for the validation and
+// recommendation functions
+func cacheRecordsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected model",
+		}
+	}
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+
+	records, err := cache.Records(args[0].String())
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(records); err == nil {
+			return map[string]interface{}{
+				"error":   "",
+				"count":   len(records),
+				"records": string(data),
+			}
+		}
+	}
+	return map[string]interface{}{
+		"error": err.Error(),
+	}
+}
+
+// WebAssembly wrapper for DataCache.Update - edits a cached user or product
+// with a merge patch, to sync when the page is online
+func cacheUpdateWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected model, record ID and patch JSON",
+		}
+	}
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+
+	// Use shared business logic - the server applies the same patches
+	record, err := cache.Update(args[0].String(), args[1].Int(), args[2].String())
+	if err != nil {
+		result := map[string]interface{}{
+			"error": err.Error(),
+			"code":  ErrorCode(err),
+		}
+		var conflict *VersionConflictError
+		if errors.As(err, &conflict) {
+			result["current_version"] = conflict.Current
+		}
+		return result
+	}
+	return map[string]interface{}{
+		"error":  "",
+		"record": record,
+	}
+}
+
+// WebAssembly wrapper for DataCache.Pending - the body of a POST
+// /api/data/sync with the edits not yet synced
+func cacheSyncRequestWasm(this js.Value, args []js.Value) interface{} {
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+
+	request := cache.Pending()
+	body, err := json.Marshal(request)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode sync request: " + err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"changes": len(request.Changes),
+		"body":    string(body),
+	}
+}
+
+// WebAssembly wrapper for DataCache.ApplySync - takes in the server's
+// answer to a sync and returns the conflicts and rejections as JSON
+func cacheApplySyncWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected sync response JSON",
+		}
+	}
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+	var response SyncResponse
+	if err := json.Unmarshal([]byte(args[0].String()), &response); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid sync response JSON: " + err.Error(),
+		}
+	}
+
+	summary, err := cache.ApplySync(response)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	conflicts, _ := json.Marshal(summary.Conflicts)
+	rejected, _ := json.Marshal(summary.Rejected)
+	return map[string]interface{}{
+		"error":     "",
+		"applied":   summary.Applied,
+		"conflicts": string(conflicts),
+		"rejected":  string(rejected),
+	}
+}
+
+// WebAssembly wrapper for DataCache.Resolve - settles a record in conflict
+// by keeping the page's edits ("local") or the server's record ("server")
+func cacheResolveWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected model, record ID and local or server",
+		}
+	}
+	cache, failed := cacheOpen()
+	if failed != nil {
+		return failed
+	}
+	keep := args[2].String()
+	if keep != "local" && keep != "server" {
+		return map[string]interface{}{
+			"error": fmt.Sprintf("Unknown side %q (use local or server)", keep),
+		}
+	}
+
+	record, err := cache.Resolve(args[0].String(), args[1].Int(), keep == "local")
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"error":  "",
+		"record": record,
+	}
+}
+
+// WebAssembly wrapper for RecalculateOrders - prices the orders again for
+// their users one order at a time, as there is a single thread to run them
+// on, and returns the report of /api/orders/recalculate as JSON
+func recalculateOrdersWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected orders and users JSON",
+		}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal([]byte(args[0].String()), &orders); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid orders JSON: " + err.Error(),
+		}
+	}
+	var users []User
+	if err := json.Unmarshal([]byte(args[1].String()), &users); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid users JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic - identical to /api/orders/recalculate
+	report := RecalculateOrders(orders, users, 1)
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to encode report: " + err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"error":   "",
+		"orders":  report.Orders,
+		"changed": report.Changed,
+		"report":  string(reportJSON),
+	}
+}
+
+// WebAssembly wrapper for shipping quotes with the shared QuoteShipping
+func shippingQuotesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected order and user JSON",
+		}
+	}
+
+	order, err := OrderFromJSON(args[0].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid order JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+	user, err := UserFromJSON(args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic
+	quotes := QuoteShipping(order, user, orderPlacedDate(order))
+
+	// Convert to JavaScript-compatible format
+	result := make([]interface{}, len(quotes))
+	for i, quote := range quotes {
+		result[i] = map[string]interface{}{
+			"carrier":            quote.Carrier,
+			"carrier_name":       quote.CarrierName,
+			"service":            quote.Service,
+			"price":              quote.Price,
+			"currency":           quote.Currency,
+			"billable_weight_kg": quote.BillableWeightKg,
+			"earliest_delivery":  quote.EarliestDelivery,
+			"latest_delivery":    quote.LatestDelivery,
+		}
+	}
+
+	return map[string]interface{}{
+		"error":  "",
+		"quotes": result,
+	}
+}
+
+// WebAssembly wrapper for checking a cart against available stock, as
+// POST /api/inventory/availability does on the server
+func checkAvailabilityWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected cart and products JSON",
+		}
+	}
+
+	var cart Cart
+	if err := json.Unmarshal([]byte(args[0].String()), &cart); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid cart JSON: " + err.Error(),
+		}
+	}
+	var products []Product
+	if err := json.Unmarshal([]byte(args[1].String()), &products); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid products JSON: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	items := CheckAvailability(products, cart.Items)
+
+	available := true
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		available = available && item.OK
+		result[i] = map[string]interface{}{
+			"product_id": item.ProductID,
+			"requested":  item.Requested,
+			"available":  item.Available,
+			"ok":         item.OK,
+		}
+	}
+
+	return map[string]interface{}{
+		"error":     "",
+		"available": available,
+		"items":     result,
+	}
+}
+
+// WebAssembly wrapper previewing a subscription's next renewals, as
+// GET /api/subscriptions/{id}/upcoming does on the server
+func upcomingChargesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString || args[2].Type() != js.TypeNumber {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected subscription JSON, user JSON and a count",
+		}
+	}
+
+	var sub Subscription
+	if err := json.Unmarshal([]byte(args[0].String()), &sub); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid subscription JSON: " + err.Error(),
+		}
+	}
+	user, err := UserFromJSON(args[1].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid user JSON: " + err.Error(),
+			"code":  ErrorCode(err),
+		}
+	}
+
+	// Use shared business logic
+	charges, err := UpcomingCharges(sub, user, min(max(args[2].Int(), 1), maxUpcomingCharges))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	result := make([]interface{}, len(charges))
+	for i, charge := range charges {
+		result[i] = map[string]interface{}{
+			"date":     charge.Date,
+			"subtotal": charge.Subtotal,
+			"tax":      charge.Tax,
+			"shipping": charge.Shipping,
+			"discount": charge.Discount,
+			"total":    charge.Total,
+			"currency": charge.Currency,
+		}
+	}
+	return map[string]interface{}{
+		"error":   "",
+		"charges": result,
+	}
+}
+
+// WebAssembly wrapper for the prorated adjustment of a subscription change
+// on a YYYY-MM-DD date
+func prorateSubscriptionWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected subscription JSON, changed subscription JSON and a date",
+		}
+	}
+	for i, arg := range args {
+		if arg.Type() != js.TypeString {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("Argument %d is not a string", i),
+			}
+		}
+	}
+
+	var sub, change Subscription
+	if err := json.Unmarshal([]byte(args[0].String()), &sub); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid subscription JSON: " + err.Error(),
+		}
+	}
+	if err := json.Unmarshal([]byte(args[1].String()), &change); err != nil {
+		return map[string]interface{}{
+			"error": "Invalid changed subscription JSON: " + err.Error(),
+		}
+	}
+	date, err := time.Parse(taxDateLayout, args[2].String())
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Invalid date: " + err.Error(),
+		}
+	}
+
+	// Use shared business logic
+	proration, err := ProrateChange(sub, change, date)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"error":           "",
+		"date":            proration.Date,
+		"period_end":      proration.PeriodEnd,
+		"unused_fraction": proration.UnusedFraction,
+		"credit":          proration.Credit,
+		"charge":          proration.Charge,
+		"net":             proration.Net,
+		"currency":        proration.Currency,
+	}
+}
+
+// WebAssembly wrapper for currency conversion with the shared ConvertPrice
+func convertCurrencyWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 && len(args) != 4 {
+		return map[string]interface{}{
+			"error": "Invalid number of arguments - expected amount, from, to and optionally a locale",
+		}
+	}
+	if args[0].Type() != js.TypeNumber || args[1].Type() != js.TypeString || args[2].Type() != js.TypeString ||
+		(len(args) == 4 && args[3].Type() != js.TypeString) {
+		return map[string]interface{}{
+			"error": "Invalid argument types - expected a number and two currency codes",
+		}
+	}
+
+	to := args[2].String()
+	result, err := ConvertPrice(args[0].Float(), args[1].String(), to)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	formatted := FormatCurrency(result, to)
+	if len(args) == 4 {
+		formatted = FormatMoney(result, to, args[3].String())
+	}
+
+	return map[string]interface{}{
+		"error":     "",
+		"result":    result,
+		"currency":  currencyFor(to).Code,
+		"formatted": formatted,
+	}
+}
+
+// WebAssembly wrapper for FormatMoney - formats an amount for a locale
+// exactly as the server does
+func formatMoneyWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 || args[0].Type() != js.TypeNumber || args[1].Type() != js.TypeString || args[2].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected amount, currency and locale",
+		}
+	}
+
+	// Use shared business logic
+	return map[string]interface{}{
+		"error":     "",
+		"formatted": FormatMoney(args[0].Float(), args[1].String(), args[2].String()),
+		"locale":    localeFor(args[2].String()).Tag,
+	}
+}
+
+// setExchangeRatesWasm installs a rate table, normally the JSON served by
+// /api/rates, so conversions match the server's
+func setExchangeRatesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected rates JSON",
+		}
+	}
+
+	rates, err := ParseExchangeRates([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetExchangeRates(rates)
+
+	return map[string]interface{}{
+		"error": "",
+		"base":  rates.Base,
+		"date":  rates.Date,
+	}
+}
+
+// setValidationRulesWasm installs a validation ruleset, normally the JSON
+// served by /api/validation-rules, so validation matches the server's
+func setValidationRulesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected validation rules JSON",
+		}
+	}
+
+	rules, err := ParseValidationRules([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetValidationRules(rules)
+
+	models := make([]interface{}, 0, len(rules))
+	for model := range rules {
+		models = append(models, model)
+	}
+	return map[string]interface{}{
+		"error":  "",
+		"models": models,
+	}
+}
+
+// setPromotionsWasm installs the promotions calculateOrderTotalWasm
+// evaluates, normally the JSON served by /api/promotions
+func setPromotionsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected promotions JSON",
+		}
+	}
+
+	list, err := ParsePromotions([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetPromotions(list)
+
+	return map[string]interface{}{
+		"error": "",
+		"count": len(list),
+	}
+}
+
+// setItemSimilarityWasm installs the item-item similarity matrix
+// recommendProductsWasm blends in, normally the JSON served by
+// /api/recommendations/similarity
+func setItemSimilarityWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected similarity JSON",
+		}
+	}
+
+	similarity, err := ParseItemSimilarity([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetItemSimilarity(similarity)
+
+	return map[string]interface{}{
+		"error":    "",
+		"orders":   similarity.Orders,
+		"products": len(similarity.Scores),
+	}
+}
+
+// setTrendingWasm installs the trending list recommendProductsWasm boosts,
+// normally the JSON served by /api/analytics/trending
+func setTrendingWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected trending JSON",
+		}
+	}
+
+	list, err := ParseTrending([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetTrending(list)
+
+	return map[string]interface{}{
+		"error":    "",
+		"products": len(list),
+	}
+}
+
+// setCohortPreferencesWasm installs the cohort preferences cold-start
+// recommendations use, normally the JSON served by
+// /api/recommendations/cohorts
+func setCohortPreferencesWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected cohort preferences JSON",
+		}
+	}
+
+	preferences, err := ParseCohortPreferences([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetCohortPreferences(preferences)
+
+	return map[string]interface{}{
+		"error":   "",
+		"cohorts": len(preferences.Cohorts),
+	}
+}
+
+// setRecommendationWeightsWasm installs the scoring weights
+// recommendProductsWasm uses, normally the JSON served by
+// /api/recommendations/weights
+func setRecommendationWeightsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected weights JSON",
+		}
+	}
+
+	weights, err := ParseRecommendationWeights([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetRecommendationWeights(weights)
+
+	return map[string]interface{}{
+		"error": "",
+	}
+}
+
+// setCLVSettingsWasm installs the margin and discount rate calculateCLVWasm
+// projects with
+func setCLVSettingsWasm(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return map[string]interface{}{
+			"error": "Invalid arguments - expected CLV settings JSON",
+		}
+	}
+
+	settings, err := ParseCLVSettings([]byte(args[0].String()))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+	SetCLVSettings(settings)
+
+	return map[string]interface{}{
+		"error": "",
+	}
 }
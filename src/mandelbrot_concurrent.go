@@ -1,14 +1,12 @@
-//go:build js && wasm
+//go:build js && wasm && !tinygo && !logic && !lite
 
 package main
 
 import (
-	"runtime"
-	"sync"
 	"syscall/js"
 )
 
-// Concurrent Mandelbrot implementation using goroutines
+// Concurrent Mandelbrot implementation on the benchmark pool
 func mandelbrotWasmConcurrent(this js.Value, args []js.Value) interface{} {
 	if len(args) < 6 {
 		return js.ValueOf("Missing arguments")
@@ -33,33 +31,14 @@ func mandelbrotWasmConcurrent(this js.Value, args []js.Value) interface{} {
 	// Use Go slice for computation
 	result := make([]int32, pixels)
 
-	// Determine number of workers
-	numWorkers := runtime.GOMAXPROCS(0)
-	if numWorkers < 1 {
-		numWorkers = 4 // Default for WASM
+	// One task per row on the shared pool
+	rows := make([]int, height)
+	for y := range rows {
+		rows[y] = y
 	}
-	if numWorkers > height {
-		numWorkers = height // Don't create more workers than rows
-	}
-
-	// Channel for work distribution
-	rowChan := make(chan int, height)
-	var wg sync.WaitGroup
-
-	// Start worker goroutines
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go mandelbrotWorker(rowChan, &wg, result, width, height, dx, dy, xmin, ymin, maxIter)
-	}
-
-	// Send work to workers
-	for y := 0; y < height; y++ {
-		rowChan <- y
-	}
-	close(rowChan)
-
-	// Wait for all workers to complete
-	wg.Wait()
+	runPooled(benchmarkPool, rows, func(py int) {
+		mandelbrotRow(py, result, width, dx, dy, xmin, ymin, maxIter)
+	})
 
 	// Create typed array and copy data efficiently
 	jsArray := js.Global().Get("Int32Array").New(pixels)
@@ -72,81 +51,30 @@ func mandelbrotWasmConcurrent(this js.Value, args []js.Value) interface{} {
 	return jsArray
 }
 
-// Worker function that processes rows of the Mandelbrot set
-func mandelbrotWorker(rowChan <-chan int, wg *sync.WaitGroup, result []int32, width, height int, dx, dy, xmin, ymin float64, maxIter int) {
-	defer wg.Done()
-
-	for py := range rowChan {
-		cy := ymin + float64(py)*dy
-		rowOffset := py * width
+// mandelbrotRow computes one row of the Mandelbrot set
+func mandelbrotRow(py int, result []int32, width int, dx, dy, xmin, ymin float64, maxIter int) {
+	cy := ymin + float64(py)*dy
+	rowOffset := py * width
 
-		for px := 0; px < width; px++ {
-			cx := xmin + float64(px)*dx
+	for px := 0; px < width; px++ {
+		cx := xmin + float64(px)*dx
 
-			// Optimized Mandelbrot calculation
-			zx, zy := 0.0, 0.0
-			iter := int32(0)
+		// Optimized Mandelbrot calculation
+		zx, zy := 0.0, 0.0
+		iter := int32(0)
 
-			// Hot loop - optimized for performance
-			for iter < int32(maxIter) {
-				zx2 := zx * zx
-				zy2 := zy * zy
-				if zx2+zy2 > 4.0 {
-					break
-				}
-				zy = 2*zx*zy + cy
-				zx = zx2 - zy2 + cx
-				iter++
+		// Hot loop - optimized for performance
+		for iter < int32(maxIter) {
+			zx2 := zx * zx
+			zy2 := zy * zy
+			if zx2+zy2 > 4.0 {
+				break
 			}
-
-			result[rowOffset+px] = iter
+			zy = 2*zx*zy + cy
+			zx = zx2 - zy2 + cx
+			iter++
 		}
-	}
-}
-
-// NOTE: mandelbrotWasmWorkStealing removed - was unused dead code
-// The main mandelbrotWasmConcurrent implementation already provides excellent performance
-
-// Chunk worker for work-stealing approach
-func mandelbrotChunkWorker(workChan <-chan mandelbrotChunk, wg *sync.WaitGroup, result []int32, width int, dx, dy, xmin, ymin float64, maxIter int) {
-	defer wg.Done()
-
-	for chunk := range workChan {
-		for py := chunk.startY; py < chunk.endY; py++ {
-			cy := ymin + float64(py)*dy
-			rowOffset := py * width
-
-			for px := 0; px < width; px++ {
-				cx := xmin + float64(px)*dx
 
-				// Optimized Mandelbrot calculation with loop unrolling
-				zx, zy := 0.0, 0.0
-				iter := int32(0)
-
-				// Unroll first iteration
-				zx2 := zx * zx // 0
-				zy2 := zy * zy // 0
-				if zx2+zy2 <= 4.0 {
-					zy = 2*zx*zy + cy   // cy
-					zx = zx2 - zy2 + cx // cx
-					iter = 1
-
-					// Continue with main loop
-					for iter < int32(maxIter) {
-						zx2 = zx * zx
-						zy2 = zy * zy
-						if zx2+zy2 > 4.0 {
-							break
-						}
-						temp := zx2 - zy2 + cx
-						zy = 2*zx*zy + cy
-						zx = temp
-						iter++
-					}
-				}
-
-				result[rowOffset+px] = iter
-			}
-		}
+		result[rowOffset+px] = iter
 	}
 }
@@ -0,0 +1,50 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// ANALYTICS SUMMARY
+// GET /api/analytics/summary reports the analytics of the stored users and
+// orders - the same UserAnalytics as /api/analyze-behavior - from the
+// store's AnalyticsAccumulator, which new users and orders update as they
+// arrive. Both take ?top_countries=5 for how many countries to rank.
+// ============================================================================
+
+// parseTopCountries reads ?top_countries=.
+func parseTopCountries(raw string) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > maxTopCountries {
+		return 0, fmt.Errorf("must be a whole number from 1 to %d", maxTopCountries)
+	}
+	return n, nil
+}
+
+// handleAnalyticsSummary serves the analytics of the stored data.
+func handleAnalyticsSummary(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	n := DefaultTopCountries
+	if raw := r.URL.Query().Get("top_countries"); raw != "" {
+		var err error
+		if n, err = parseTopCountries(raw); err != nil {
+			writeFieldErrors(w, map[string]string{"top_countries": err.Error()})
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, storeFor(r).analyticsSnapshot(n))
+}
@@ -0,0 +1,78 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestAnalyticsSummary tests that the summary follows new and cancelled
+// orders
+func TestAnalyticsSummary(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	summary := func() UserAnalytics {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/summary", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var analytics UserAnalytics
+		json.NewDecoder(w.Body).Decode(&analytics)
+		return analytics
+	}
+	current := func() UserAnalytics {
+		t.Helper()
+		data, _ := json.Marshal(AnalyzeUserBehavior(demoStore.listUsers(), demoStore.listOrders(), AnalyticsFilter{}))
+		var analytics UserAnalytics
+		json.Unmarshal(data, &analytics)
+		return analytics
+	}
+
+	if got, want := summary(), current(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Summary = %+v, want %+v", got, want)
+	}
+
+	order, err := demoStore.placeOrder(Order{UserID: 3, Products: []Product{{ID: 4, Price: 12.99}}, Quantities: []int{1}, Total: 12.99, OrderDate: "2023-05-10", Status: "pending"})
+	if err != nil {
+		t.Fatalf("Unexpected error placing an order: %v", err)
+	}
+	after := summary()
+	if after.OrderValues.Count != 3 || !reflect.DeepEqual(after, current()) {
+		t.Errorf("Expected the new order in the summary, got %+v", after)
+	}
+
+	if _, _, err := demoStore.setOrderStatus(order.ID, anyVersion, "cancelled", false); err != nil {
+		t.Fatalf("Unexpected error cancelling: %v", err)
+	}
+	if got, want := summary(), current(); !reflect.DeepEqual(got, want) || got.CountryRevenue[0].Orders != 1 {
+		t.Errorf("Expected the cancellation in the summary, got %+v, want %+v", got, want)
+	}
+}
+
+// TestAnalyticsSummaryTopCountries tests ?top_countries=
+func TestAnalyticsSummaryTopCountries(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/summary?top_countries=1", nil))
+	var analytics UserAnalytics
+	json.NewDecoder(w.Body).Decode(&analytics)
+	if w.Code != http.StatusOK || len(analytics.TopCountries) != 1 || analytics.TopCountries[0].Country != "US" {
+		t.Errorf("Expected only the US, got %d %+v", w.Code, analytics.TopCountries)
+	}
+
+	for _, n := range []string{"0", "51", "three"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/summary?top_countries="+n, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected top_countries=%s to be rejected, got %d", n, w.Code)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// MARKET-BASKET ANALYSIS
+// GET /api/analytics/associations mines the stored orders with
+// MineAssociations for "frequently bought together" rules:
+//
+//   ?min_support=0.1&min_confidence=0.5   the defaults
+//
+// mineAssociationsWasm mines orders passed from the page the same way.
+// ============================================================================
+
+// Default association thresholds, generous enough for the demo orders.
+const (
+	defaultMinSupport    = 0.1
+	defaultMinConfidence = 0.5
+)
+
+// associationsResponse is the body of GET /api/analytics/associations.
+type associationsResponse struct {
+	Orders        int               `json:"orders"`
+	MinSupport    float64           `json:"min_support"`
+	MinConfidence float64           `json:"min_confidence"`
+	Rules         []AssociationRule `json:"rules"`
+}
+
+// handleAssociations serves the association rules of the stored orders.
+func handleAssociations(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	response := associationsResponse{MinSupport: defaultMinSupport, MinConfidence: defaultMinConfidence}
+	fields := map[string]string{}
+	for name, threshold := range map[string]*float64{"min_support": &response.MinSupport, "min_confidence": &response.MinConfidence} {
+		if raw := r.URL.Query().Get(name); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				fields[name] = "must be a number"
+				continue
+			}
+			*threshold = value
+		}
+	}
+	if len(fields) == 0 {
+		for _, fieldErr := range ValidateAssociationThresholds(response.MinSupport, response.MinConfidence).FieldErrors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	orders := storeFor(r).listOrders()
+	response.Orders = len(orders)
+	response.Rules = MineAssociations(orders, response.MinSupport, response.MinConfidence)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
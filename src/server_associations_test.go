@@ -0,0 +1,47 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestAssociationsEndpoint tests mining the demo orders and rejecting bad
+// thresholds
+func TestAssociationsEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/associations", nil))
+	var response associationsResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.MinSupport != defaultMinSupport || response.Orders != len(generateDemoOrders()) {
+		t.Fatalf("Expected the demo orders mined with the defaults, got %d %+v", w.Code, response)
+	}
+	// The book and mug are bought together in a demo order
+	if !slices.ContainsFunc(response.Rules, func(rule AssociationRule) bool {
+		return slices.Equal(rule.Antecedent, []int{3}) && rule.Consequent == 4
+	}) {
+		t.Errorf("Expected a book -> mug rule, got %+v", response.Rules)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/associations?min_support=x&min_confidence=2", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if fields := decodeErrorResponse(t, w).Fields; fields["min_support"] == "" {
+		t.Errorf("Expected a min_support field error, got %v", fields)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/associations?min_support=1.5", nil))
+	if w.Code != http.StatusBadRequest || decodeErrorResponse(t, w).Fields["min_support"] == "" {
+		t.Errorf("Expected an out of range min_support to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// ============================================================================
+// POOLED BUFFERS
+// The busiest endpoints (validation, order pricing, behavior analytics) read
+// a body and write a response on every call. Bodies are read into, and JSON
+// responses encoded into, buffers taken from a pool, and those endpoints
+// decode into request values they reuse, keeping the capacity their slices
+// grew to. BenchmarkPooledHandlers measures the allocations this saves.
+// ============================================================================
+
+// maxPooledBufferBytes is the largest buffer returned to a pool; the
+// occasional huge body shouldn't stay in memory.
+const maxPooledBufferBytes = 64 << 10
+
+// bodyBuffers holds the buffers request bodies are read into.
+var bodyBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func getBodyBuffer() *bytes.Buffer {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferBytes {
+		bodyBuffers.Put(buf)
+	}
+}
+
+// jsonBuffer is a pooled buffer with a JSON encoder writing to it.
+type jsonBuffer struct {
+	bytes.Buffer
+	encoder *json.Encoder
+}
+
+var jsonBuffers = sync.Pool{New: func() any {
+	buf := &jsonBuffer{}
+	buf.encoder = json.NewEncoder(&buf.Buffer)
+	return buf
+}}
+
+// encodeJSON encodes v as json.Encoder does, newline included, into a
+// pooled buffer, which the caller returns with putJSONBuffer once it has
+// written it.
+func encodeJSON(v interface{}) (*jsonBuffer, error) {
+	buf := jsonBuffers.Get().(*jsonBuffer)
+	buf.Reset()
+	if err := buf.encoder.Encode(v); err != nil {
+		putJSONBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func putJSONBuffer(buf *jsonBuffer) {
+	if buf.Cap() <= maxPooledBufferBytes {
+		jsonBuffers.Put(buf)
+	}
+}
+
+// requestPool reuses a handler's decode targets across requests. reset
+// empties a target for its next request, keeping the capacity of its slices
+// but none of their contents.
+type requestPool[T any] struct {
+	pool  sync.Pool
+	reset func(*T)
+}
+
+func (p *requestPool[T]) get() *T {
+	if v, ok := p.pool.Get().(*T); ok {
+		return v
+	}
+	return new(T)
+}
+
+// put returns a target once nothing from the request is in use any more:
+// after the response is written.
+func (p *requestPool[T]) put(v *T) {
+	p.reset(v)
+	p.pool.Put(v)
+}
+
+// emptied is s with its elements zeroed and its length 0, for decoding into
+// again. A list missing from the next body then decodes as empty rather than
+// nil, which the handlers treat alike.
+func emptied[S ~[]E, E any](s S) S {
+	if s == nil {
+		return nil
+	}
+	clear(s[:cap(s)])
+	return s[:0]
+}
+
+var (
+	userRequests = requestPool[User]{reset: func(user *User) { *user = User{} }}
+
+	orderRequests = requestPool[calculateOrderRequest]{reset: func(req *calculateOrderRequest) {
+		products, quantities := emptied(req.Order.Products), emptied(req.Order.Quantities)
+		*req = calculateOrderRequest{}
+		req.Order.Products, req.Order.Quantities = products, quantities
+	}}
+
+	behaviorRequests = requestPool[analyzeBehaviorRequest]{reset: func(req *analyzeBehaviorRequest) {
+		users, orders := emptied(req.Users), emptied(req.Orders)
+		*req = analyzeBehaviorRequest{}
+		req.Users, req.Orders = users, orders
+	}}
+)
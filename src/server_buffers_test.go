@@ -0,0 +1,122 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	value := map[string]interface{}{"name": "<Lamp>", "prices": []float64{1.5, 2}}
+	var want bytes.Buffer
+	json.NewEncoder(&want).Encode(value)
+	for i := 0; i < 3; i++ {
+		buf, err := encodeJSON(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want.String() {
+			t.Errorf("encodeJSON() = %q, want %q", buf.String(), want.String())
+		}
+		putJSONBuffer(buf)
+	}
+	if _, err := encodeJSON(func() {}); err == nil {
+		t.Error("Expected an error encoding a func")
+	}
+}
+
+// TestPooledRequestsDontLeak tests that a reused decode target carries
+// nothing over to the next request
+func TestPooledRequestsDontLeak(t *testing.T) {
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleCalculateOrder(w, httptest.NewRequest("POST", "/api/calculate-order", strings.NewReader(body)))
+		return w
+	}
+	full := `{"order": {"products": [{"id": 1, "name": "Lamp", "price": 40, "category": "home"}, {"id": 2, "name": "Mug", "price": 8, "category": "home"}], "quantities": [2, 1], "coupon_code": "NOPE"}, "user": {"country": "DE", "premium": true}}`
+	post(full)
+	for i := 0; i < 2; i++ {
+		w := post(`{"user": {"country": "US"}}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "at least one product") || strings.Contains(w.Body.String(), "coupon") {
+			t.Errorf("Expected only the missing products reported, got %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handleValidateUser(w, httptest.NewRequest("POST", "/api/validate-user", strings.NewReader(`{"name": "Ann Lee", "email": "ann@example.com", "age": 30, "country": "US"}`)))
+	first := w.Body.String()
+	w = httptest.NewRecorder()
+	handleValidateUser(w, httptest.NewRequest("POST", "/api/validate-user", strings.NewReader(`{"email": "ann@example.com"}`)))
+	if w.Body.String() == first {
+		t.Errorf("Expected the second user validated on its own, got %s", w.Body.String())
+	}
+}
+
+func TestEmptied(t *testing.T) {
+	if emptied([]int(nil)) != nil {
+		t.Error("Expected nil to stay nil")
+	}
+	s := emptied([]*User{{ID: 1}, {ID: 2}})
+	if len(s) != 0 || cap(s) != 2 || s[:2][0] != nil || s[:2][1] != nil {
+		t.Errorf("emptied() = %v (cap %d), want an empty slice of nils", s[:cap(s)], cap(s))
+	}
+}
+
+// BenchmarkPooledHandlers measures the hot handlers' allocations per request
+// with pooled buffers and decode targets, next to encoding without them
+func BenchmarkPooledHandlers(b *testing.B) {
+	bodies := map[string]struct {
+		handler http.HandlerFunc
+		body    string
+	}{
+		"validate-user":    {handleValidateUser, `{"name": "Ann Lee", "email": "ann@example.com", "age": 30, "country": "US"}`},
+		"calculate-order":  {handleCalculateOrder, `{"order": {"products": [{"id": 1, "name": "Lamp", "price": 40, "category": "home"}], "quantities": [2]}, "user": {"country": "DE"}}`},
+		"analyze-behavior": {handleAnalyzeBehavior, analyzeBehaviorBenchmarkBody()},
+	}
+	for name, tt := range bodies {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r := httptest.NewRequest("POST", "/api/"+name, strings.NewReader(tt.body))
+				tt.handler(discardResponse{header: http.Header{}}, r)
+			}
+		})
+	}
+
+	analytics := AnalyzeUserBehavior(generateDemoUsers(), generateDemoOrders(), AnalyticsFilter{})
+	b.Run("encode/unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			json.NewEncoder(&buf).Encode(analytics)
+			io.Discard.Write(buf.Bytes())
+		}
+	})
+	b.Run("encode/pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ := encodeJSON(analytics)
+			io.Discard.Write(buf.Bytes())
+			putJSONBuffer(buf)
+		}
+	})
+}
+
+func analyzeBehaviorBenchmarkBody() string {
+	body, _ := json.Marshal(analyzeBehaviorRequest{Users: generateDemoUsers(), Orders: generateDemoOrders()})
+	return string(body)
+}
+
+// discardResponse is a ResponseWriter that keeps nothing, so the benchmark
+// counts the handler's allocations rather than a recorder's.
+type discardResponse struct{ header http.Header }
+
+func (d discardResponse) Header() http.Header         { return d.header }
+func (d discardResponse) Write(p []byte) (int, error) { return len(p), nil }
+func (d discardResponse) WriteHeader(int)             {}
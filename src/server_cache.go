@@ -0,0 +1,310 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// HTTP CACHING
+// ETag/Last-Modified support for static files and demo data, plus long-lived
+// caching of the WebAssembly runtime files using content-hash cache busting.
+//
+// HTML pages are rewritten on the way out so that their references to the
+// versioned assets carry ?v=<content hash>. A request whose ?v= matches the
+// current hash is served as immutable for a year; any other request for the
+// asset must revalidate, so a rebuilt main.wasm is picked up immediately.
+// ============================================================================
+
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+	assetVersionQueryParam = "v"
+	assetVersionHashLength = 16
+)
+
+// versionedAssets are the files referenced from HTML with a ?v= cache buster.
+var versionedAssets = []string{"/main.wasm", "/logic.wasm", "/bench.wasm", "/wasm_exec.js"}
+
+// wasmURLMetaNames are the <meta> tags that publish the versioned URL of
+// each WebAssembly module to the loader.
+var wasmURLMetaNames = map[string]string{
+	"/main.wasm":  "wasm-url",
+	"/logic.wasm": "wasm-logic-url",
+	"/bench.wasm": "wasm-bench-url",
+}
+
+type fileFingerprint struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// assetFingerprints caches content hashes by path; entries are recomputed when
+// the file's size or modification time changes.
+var assetFingerprints = struct {
+	sync.Mutex
+	entries map[string]fileFingerprint
+}{entries: map[string]fileFingerprint{}}
+
+// contentETag returns a strong ETag for a byte slice.
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:])[:assetVersionHashLength] + `"`
+}
+
+// fileETag returns the ETag and stat info for a file on disk.
+func fileETag(name string) (string, os.FileInfo, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+	if info.IsDir() {
+		return "", info, nil
+	}
+
+	assetFingerprints.Lock()
+	cached, ok := assetFingerprints.entries[name]
+	assetFingerprints.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.etag, info, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+	etag := contentETag(data)
+
+	assetFingerprints.Lock()
+	assetFingerprints.entries[name] = fileFingerprint{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	assetFingerprints.Unlock()
+
+	return etag, info, nil
+}
+
+// assetVersion returns the cache-busting version string for a URL path, or ""
+// if the file doesn't exist.
+func assetVersion(urlPath string) string {
+	etag, info, err := fileETag(serverConfig.StaticDir + urlPath)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	return strings.Trim(etag, `"`)
+}
+
+func isVersionedAsset(urlPath string) bool {
+	for _, asset := range versionedAssets {
+		if urlPath == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteAssetURLs adds ?v=<hash> to script references of the versioned assets
+// and publishes the versioned URLs of the built WebAssembly modules in <meta>
+// tags for the loader.
+func rewriteAssetURLs(html []byte) []byte {
+	for _, asset := range versionedAssets {
+		version := assetVersion(asset)
+		if version == "" {
+			continue
+		}
+		name := strings.TrimPrefix(asset, "/")
+		versioned := name + "?" + assetVersionQueryParam + "=" + version
+		html = bytes.ReplaceAll(html, []byte(`src="`+name+`"`), []byte(`src="`+versioned+`"`))
+
+		if metaName, ok := wasmURLMetaNames[asset]; ok {
+			meta := `<head>` + "\n    " + `<meta name="` + metaName + `" content="` + versioned + `">`
+			html = bytes.Replace(html, []byte("<head>"), []byte(meta), 1)
+		}
+	}
+	return html
+}
+
+// privateStaticPath reports whether a URL path names a file that must not
+// be served although it is under the static root: dotfiles such as .git and
+// .env, and the server's own data under -storage-path (the store snapshot
+// with its users and gift cards, the benchmark history, the recommendation
+// weights), which the default static root of . would otherwise expose.
+func privateStaticPath(urlPath string) bool {
+	for _, segment := range strings.Split(urlPath, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	name, err := filepath.Abs(filepath.Join(serverConfig.StaticDir, filepath.FromSlash(urlPath)))
+	if err != nil {
+		return true
+	}
+	for _, private := range []string{serverConfig.StoragePath, serverConfig.snapshotPath()} {
+		dir, err := filepath.Abs(private)
+		if err != nil {
+			return true
+		}
+		if rel, err := filepath.Rel(dir, name); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveStaticFile serves files from the working directory with ETag and
+// Cache-Control headers. Conditional and Range requests are handled by
+// http.ServeFile/ServeContent using the ETag and modification time.
+func serveStaticFile(w http.ResponseWriter, r *http.Request) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	if privateStaticPath(urlPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Handle root path
+	if urlPath == "/" {
+		serveHTMLFile(w, r, serverConfig.StaticDir+"/index.html")
+		return
+	}
+
+	name := serverConfig.StaticDir + urlPath
+	if strings.HasSuffix(urlPath, ".html") {
+		serveHTMLFile(w, r, name)
+		return
+	}
+
+	// Development mode: whatever is on disk now, never from a cache
+	if serverConfig.DevMode {
+		w.Header().Set("Cache-Control", devCacheControl)
+		if path.Ext(urlPath) == ".wasm" {
+			w.Header().Set("Content-Type", "application/wasm")
+		}
+		http.ServeFile(w, r, name)
+		return
+	}
+
+	etag, info, err := fileETag(name)
+	if err != nil || info.IsDir() {
+		http.ServeFile(w, r, name)
+		return
+	}
+
+	// The ?v= cache buster always refers to the uncompressed file's hash
+	if isVersionedAsset(urlPath) && r.URL.Query().Get(assetVersionQueryParam) == strings.Trim(etag, `"`) {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", revalidateCacheControl)
+	}
+
+	// Set the type explicitly: WebAssembly.instantiateStreaming rejects
+	// anything but application/wasm, and some OS mime tables lack .wasm
+	if path.Ext(urlPath) == ".wasm" {
+		w.Header().Set("Content-Type", "application/wasm")
+	}
+
+	if precompressedExtensions[path.Ext(urlPath)] {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if encoding, variant := precompressedVariant(r.Header.Get("Accept-Encoding"), name); variant != "" {
+			if variantETag, _, err := fileETag(variant); err == nil {
+				// Each representation needs its own validator
+				w.Header().Set("ETag", strings.TrimSuffix(variantETag, `"`)+"-"+encoding+`"`)
+				w.Header().Set("Content-Encoding", encoding)
+				http.ServeFile(w, r, variant)
+				return
+			}
+		}
+	}
+
+	// Serve other static files
+	w.Header().Set("ETag", etag)
+	http.ServeFile(w, r, name)
+}
+
+// serveHTMLFile serves an HTML page with versioned asset URLs.
+func serveHTMLFile(w http.ResponseWriter, r *http.Request, name string) {
+	info, err := os.Stat(name)
+	if err != nil || info.IsDir() {
+		http.ServeFile(w, r, name)
+		return
+	}
+
+	html, err := os.ReadFile(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read file")
+		return
+	}
+	html = rewriteAssetURLs(html)
+	if serverConfig.DevMode {
+		w.Header().Set("Cache-Control", devCacheControl)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(injectDevReloadScript(html))
+		return
+	}
+
+	// The rewritten page changes whenever an asset does, so both validators
+	// account for the versioned assets as well as the HTML file itself.
+	lastModified := info.ModTime()
+	for _, asset := range versionedAssets {
+		if assetInfo, err := os.Stat(serverConfig.StaticDir + asset); err == nil && assetInfo.ModTime().After(lastModified) {
+			lastModified = assetInfo.ModTime()
+		}
+	}
+
+	w.Header().Set("ETag", contentETag(html))
+	w.Header().Set("Cache-Control", revalidateCacheControl)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, name, lastModified, bytes.NewReader(html))
+}
+
+// writeCacheableJSON writes v as JSON with an ETag, answering 304 Not Modified
+// when the client already holds the same representation.
+func writeCacheableJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	body = append(body, '\n')
+
+	etag := contentETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", revalidateCacheControl)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// The ETag covers the data only, so enveloped and plain responses
+	// revalidate alike
+	if wantsEnvelope(r) {
+		writeJSON(w, r, http.StatusOK, v)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// etagMatches implements the weak comparison used by If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withStaticRoot points static file serving at a temporary directory
+func withStaticRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.StaticDir = dir })
+	return dir
+}
+
+// TestStaticFilePrivatePaths tests that the server's data and dotfiles
+// aren't served from the static root
+func TestStaticFilePrivatePaths(t *testing.T) {
+	dir := withStaticRoot(t, map[string]string{
+		"style.css":                        "body {}",
+		"data/store_snapshot.json":         `{"users": [{"email": "john@example.com"}]}`,
+		"data/benchmark_history.jsonl":     "{}",
+		"data/recommendation_weights.json": "{}",
+		"backup.json":                      "{}",
+		".env":                             "ADMIN_TOKEN=secret",
+		".git/config":                      "[core]",
+		"assets/.secret":                   "secret",
+	})
+	withServerConfig(t, func(cfg *ServerConfig) {
+		cfg.StoragePath = filepath.Join(dir, "data")
+		cfg.SnapshotFile = filepath.Join(dir, "backup.json")
+	})
+
+	for _, urlPath := range []string{"/data/store_snapshot.json", "/data/benchmark_history.jsonl", "/data/recommendation_weights.json", "/data/", "/backup.json", "/.env", "/.git/config", "/assets/.secret", "/assets/../data/store_snapshot.json"} {
+		w := httptest.NewRecorder()
+		serveStaticFile(w, httptest.NewRequest("GET", urlPath, nil))
+		if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "secret") || strings.Contains(w.Body.String(), "example.com") {
+			t.Errorf("GET %s = %d %q, want 404", urlPath, w.Code, w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	serveStaticFile(w, httptest.NewRequest("GET", "/style.css", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected other files served, got %d", w.Code)
+	}
+
+	// By default the data is kept outside the working directory, the
+	// default static root
+	wd, _ := os.Getwd()
+	if rel, err := filepath.Rel(wd, defaultStoragePath()); err == nil && !strings.HasPrefix(rel, "..") {
+		t.Errorf("Expected the default storage path outside %s, got %s", wd, defaultStoragePath())
+	}
+}
+
+// TestStaticFileCaching tests ETag, conditional requests and cache busting
+func TestStaticFileCaching(t *testing.T) {
+	withStaticRoot(t, map[string]string{
+		"index.html":   `<html><head><title>t</title></head><body><script src="wasm_exec.js"></script></body></html>`,
+		"wasm_exec.js": "// runtime",
+		"main.wasm":    "\x00asm\x01\x00\x00\x00",
+		"logic.wasm":   "\x00asm\x01\x00\x00\x00logic",
+		"style.css":    "body {}",
+	})
+
+	t.Run("ETagAndNotModified", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/style.css", nil)
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Fatalf("Expected 200 with ETag, got %d etag=%q", w.Code, etag)
+		}
+		if w.Header().Get("Last-Modified") == "" {
+			t.Error("Expected Last-Modified header")
+		}
+
+		req = httptest.NewRequest("GET", "/style.css", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("Expected 304 for matching If-None-Match, got %d", w.Code)
+		}
+	})
+
+	t.Run("CacheBustedAssetIsImmutable", func(t *testing.T) {
+		version := assetVersion("/main.wasm")
+		if version == "" {
+			t.Fatal("Expected a version for main.wasm")
+		}
+
+		req := httptest.NewRequest("GET", "/main.wasm?v="+version, nil)
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+		if got := w.Header().Get("Cache-Control"); got != immutableCacheControl {
+			t.Errorf("Cache-Control for versioned asset = %q, want %q", got, immutableCacheControl)
+		}
+
+		req = httptest.NewRequest("GET", "/main.wasm?v=stale", nil)
+		w = httptest.NewRecorder()
+		serveStaticFile(w, req)
+		if got := w.Header().Get("Cache-Control"); got != revalidateCacheControl {
+			t.Errorf("Cache-Control for stale version = %q, want %q", got, revalidateCacheControl)
+		}
+	})
+
+	t.Run("HTMLReferencesVersionedAssets", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		body := w.Body.String()
+		if !strings.Contains(body, `src="wasm_exec.js?v=`+assetVersion("/wasm_exec.js")+`"`) {
+			t.Errorf("Expected versioned wasm_exec.js reference, got %s", body)
+		}
+		if !strings.Contains(body, `<meta name="wasm-url" content="main.wasm?v=`+assetVersion("/main.wasm")+`">`) {
+			t.Errorf("Expected wasm-url meta tag, got %s", body)
+		}
+		if !strings.Contains(body, `<meta name="wasm-logic-url" content="logic.wasm?v=`+assetVersion("/logic.wasm")+`">`) {
+			t.Errorf("Expected wasm-logic-url meta tag, got %s", body)
+		}
+		if strings.Contains(body, "wasm-bench-url") {
+			t.Errorf("Expected no wasm-bench-url meta tag without bench.wasm, got %s", body)
+		}
+		if w.Header().Get("ETag") == "" {
+			t.Error("Expected ETag on HTML page")
+		}
+	})
+}
+
+// TestDemoDataETag tests conditional requests on demo data endpoints
+func TestDemoDataETag(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/demo-products", nil)
+	w := httptest.NewRecorder()
+	handleDemoProducts(w, req)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag on demo data response")
+	}
+
+	req = httptest.NewRequest("GET", "/api/demo-products", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	w = httptest.NewRecorder()
+	handleDemoProducts(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Error("Expected empty body on 304")
+	}
+}
+
+// TestWASMServing tests content type, Range and precompressed variants
+func TestWASMServing(t *testing.T) {
+	wasm := "\x00asm\x01\x00\x00\x00" + strings.Repeat("wasm-payload-", 64)
+	withStaticRoot(t, map[string]string{"main.wasm": wasm})
+
+	if err := precompressStaticAssets(); err != nil {
+		t.Fatalf("precompressStaticAssets failed: %v", err)
+	}
+
+	t.Run("ContentType", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		serveStaticFile(w, httptest.NewRequest("GET", "/main.wasm", nil))
+
+		if got := w.Header().Get("Content-Type"); got != "application/wasm" {
+			t.Errorf("Content-Type = %q, want application/wasm", got)
+		}
+		if w.Body.String() != wasm {
+			t.Error("Expected uncompressed body without Accept-Encoding")
+		}
+	})
+
+	t.Run("RangeRequest", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/main.wasm", nil)
+		req.Header.Set("Range", "bytes=0-3")
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("Expected 206, got %d", w.Code)
+		}
+		if w.Body.String() != "\x00asm" {
+			t.Errorf("Unexpected range body %q", w.Body.String())
+		}
+	})
+
+	t.Run("GzipVariant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/main.wasm", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/wasm" {
+			t.Errorf("Content-Type = %q, want application/wasm", got)
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+			t.Error("Expected Vary: Accept-Encoding")
+		}
+		if w.Body.Len() >= len(wasm) {
+			t.Errorf("Expected compressed body smaller than %d bytes, got %d", len(wasm), w.Body.Len())
+		}
+	})
+
+	t.Run("GzipRefused", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/main.wasm", nil)
+		req.Header.Set("Accept-Encoding", "gzip;q=0")
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Expected identity encoding, got %q", got)
+		}
+	})
+}
+
+// TestAcceptsEncoding tests Accept-Encoding parsing
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		encoding string
+		want     bool
+	}{
+		{"gzip, deflate, br", "br", true},
+		{"gzip;q=0.5", "gzip", true},
+		{"gzip;q=0", "gzip", false},
+		{"*", "br", true},
+		{"*;q=0, gzip", "br", false},
+		{"", "gzip", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsEncoding(tt.header, tt.encoding); got != tt.want {
+			t.Errorf("acceptsEncoding(%q, %q) = %v, want %v", tt.header, tt.encoding, got, tt.want)
+		}
+	}
+}
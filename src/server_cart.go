@@ -0,0 +1,337 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// SHOPPING CART
+// Each session has a cart of product IDs and quantities. Every response is
+// the cart priced as an order (shared_cart.go, the same code the WASM build
+// runs in cartSummaryWasm) together with recommendations, and checkout turns
+// the cart into a stored order:
+//
+//   GET    /api/cart                      cart summary
+//   POST   /api/cart/items                {"product_id": 3, "quantity": 2},
+//                                         with "sku" for a product's variant
+//   PUT    /api/cart/items/{product_id}   {"quantity": 5} (0 removes)
+//   DELETE /api/cart/items/{product_id}
+//
+// The item endpoints take ?sku= for a line of a product variant.
+//   DELETE /api/cart                      empty the cart
+//   POST   /api/cart/merge                {"items": [...]}, a guest cart kept
+//                                         by the page, merged into this one
+//   POST   /api/cart/checkout             place the order, optionally with
+//                                         {"shipping_address": {...},
+//                                         "gift_card_code": "GIFT-..."}; its
+//                                         stock is reserved, or 409 if short
+//
+// Prices use the user given by ?user_id= (tax, shipping and premium
+// discounts depend on it) or a non-premium US guest.
+// ============================================================================
+
+// guestUser prices carts of sessions that don't name a user.
+var guestUser = User{Name: "Guest", Country: "US"}
+
+// cartItemRequest is the body of the add and update cart endpoints.
+type cartItemRequest struct {
+	ProductID int    `json:"product_id"`
+	SKU       string `json:"sku,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+// mergeCartRequest is the body of the merge endpoint: a cart the page built
+// before the shopper signed in.
+type mergeCartRequest struct {
+	Items []CartItem `json:"items"`
+}
+
+// checkoutRequest is the optional body of checkout.
+type checkoutRequest struct {
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	GiftCardCode    string   `json:"gift_card_code,omitempty"`
+	CouponCode      string   `json:"coupon_code,omitempty"`
+	LoyaltyPoints   int      `json:"loyalty_points,omitempty"`
+}
+
+// cartUser resolves ?user_id= against the store.
+func cartUser(r *http.Request, store *dataStore) (User, error) {
+	param := r.URL.Query().Get("user_id")
+	if param == "" {
+		return guestUser, nil
+	}
+	id, err := strconv.Atoi(param)
+	if err == nil {
+		for _, user := range store.listUsers() {
+			if user.ID == id {
+				return user, nil
+			}
+		}
+	}
+	return User{}, fmt.Errorf("unknown user_id %q", param)
+}
+
+// serveCart applies update (if any) to the session's cart and responds with
+// the cart summary. update returns an HTTP status and message to reject the
+// request instead.
+func serveCart(w http.ResponseWriter, r *http.Request, update func(cart *Cart, catalog []Product) (int, string)) {
+	store := storeFor(r)
+	user, err := cartUser(r, store)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	serveCartFor(w, r, store, user, update)
+}
+
+// serveCartFor is serveCart for a user already resolved. Recommendations
+// favour the categories on the user's wishlist.
+func serveCartFor(w http.ResponseWriter, r *http.Request, store *dataStore, user User, update func(cart *Cart, catalog []Product) (int, string)) {
+	catalog := store.catalog(time.Now())
+
+	var cart Cart
+	status, message := http.StatusOK, ""
+	sessions.with(w, r, func(sess *session) {
+		if update != nil {
+			status, message = update(&sess.cart, catalog)
+		}
+		cart = Cart{Items: append([]CartItem(nil), sess.cart.Items...)}
+	})
+	if message != "" {
+		writeError(w, status, message)
+		return
+	}
+
+	summary := SummarizeCart(cart, catalog, user)
+	if wishlist := store.wishlist(user.ID); len(wishlist.Items) > 0 {
+		summary.Recommendations = RecommendWithWishlist(user, catalog, summary.Order, wishlist)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, summary)
+}
+
+// decodeCartItem reads a cart item request body.
+func decodeCartItem(w http.ResponseWriter, r *http.Request) (cartItemRequest, bool) {
+	var req cartItemRequest
+	ok := decodeJSONBody(w, r, &req)
+	return req, ok
+}
+
+// handleCart returns (GET) or empties (DELETE) the session's cart.
+func handleCart(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		serveCart(w, r, nil)
+	case "DELETE":
+		serveCart(w, r, func(cart *Cart, catalog []Product) (int, string) {
+			cart.Items = nil
+			return http.StatusOK, ""
+		})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleCartItems adds a product to the cart.
+func handleCartItems(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	req, ok := decodeCartItem(w, r)
+	if !ok {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	serveCart(w, r, func(cart *Cart, catalog []Product) (int, string) {
+		if result := ValidateCartItem(CartItem{ProductID: req.ProductID, SKU: req.SKU, Quantity: req.Quantity}, catalog); !result.Valid {
+			return http.StatusBadRequest, strings.Join(result.Errors, "; ")
+		}
+		product, _ := findProduct(catalog, req.ProductID)
+		cart.AddItem(product, req.SKU, req.Quantity, time.Now())
+		return http.StatusOK, ""
+	})
+}
+
+// handleCartItem changes the quantity of (PUT) or removes (DELETE) a line.
+func handleCartItem(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	productID, err := strconv.Atoi(r.PathValue("product_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	sku := r.URL.Query().Get("sku")
+	notInCart := fmt.Sprintf("Product %d is not in the cart", productID)
+	if sku != "" {
+		notInCart = fmt.Sprintf("Product %d variant %s is not in the cart", productID, sku)
+	}
+
+	switch r.Method {
+	case "PUT":
+		req, ok := decodeCartItem(w, r)
+		if !ok {
+			return
+		}
+		serveCart(w, r, func(cart *Cart, catalog []Product) (int, string) {
+			if req.Quantity > 0 {
+				item := CartItem{ProductID: productID, SKU: sku, Quantity: req.Quantity}
+				if result := ValidateCartItem(item, catalog); !result.Valid {
+					return http.StatusBadRequest, strings.Join(result.Errors, "; ")
+				}
+			}
+			if !cart.UpdateQuantity(productID, sku, req.Quantity) {
+				return http.StatusNotFound, notInCart
+			}
+			return http.StatusOK, ""
+		})
+	case "DELETE":
+		serveCart(w, r, func(cart *Cart, catalog []Product) (int, string) {
+			if !cart.RemoveItem(productID, sku) {
+				return http.StatusNotFound, notInCart
+			}
+			return http.StatusOK, ""
+		})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleCartMerge merges a guest cart into the session's cart. Lines for
+// products no longer sold are dropped, and lines without an added time are
+// stamped now at the current price.
+func handleCartMerge(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req mergeCartRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	serveCart(w, r, func(cart *Cart, catalog []Product) (int, string) {
+		var guest Cart
+		now := time.Now()
+		for _, item := range req.Items {
+			product, ok := findProduct(catalog, item.ProductID)
+			if !ok || item.Quantity <= 0 {
+				continue
+			}
+			if item.AddedAt == "" {
+				item.AddedAt, item.PriceAtAdd = now.UTC().Format(time.RFC3339), product.Price
+			}
+			guest.Items = append(guest.Items, item)
+		}
+		*cart = MergeCarts(guest, *cart)
+		return http.StatusOK, ""
+	})
+}
+
+// handleCartCheckout stores the priced cart as a pending order reserving its
+// stock and split into shipments by warehouse, empties the cart and notifies
+// order.created webhooks.
+func handleCartCheckout(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	store := storeFor(r)
+	user, err := cartUser(r, store)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	catalog := store.catalog(time.Now())
+
+	var req checkoutRequest
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+	fields := map[string]string{}
+	addressFieldErrors(fields, "shipping_address", req.ShippingAddress)
+	addressFieldErrors(fields, "user.address", user.Address)
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	// The order is placed while the session is held, so the cart is only
+	// emptied once its stock is reserved
+	var order Order
+	var placeErr error
+	sessions.with(w, r, func(sess *session) {
+		order = CartToOrder(sess.cart, catalog, user)
+		if len(order.Products) == 0 {
+			return
+		}
+		if req.ShippingAddress != nil {
+			address := NormalizeAddress(*req.ShippingAddress)
+			order.ShippingAddress = &address
+		}
+		order.Shipments = SplitOrder(order, catalog)
+		order.GiftCardCode = req.GiftCardCode
+		giftCardFieldErrors(fields, "gift_card_code", store, &order)
+		order.CouponCode, order.LoyaltyPoints = req.CouponCode, req.LoyaltyPoints
+		discountFieldErrors(fields, "", order, user)
+		if len(fields) > 0 {
+			return
+		}
+		CalculateOrderTotal(&order, user)
+		order.Status = "pending"
+		order.OrderDate = time.Now().Format("2006-01-02")
+		order.RiskScore = ScoreOrderRisk(order, user, store.listOrders()).Score
+		if order, placeErr = store.placeOrder(order); placeErr == nil {
+			sess.cart.Items = nil
+		}
+	})
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+	if placeErr != nil {
+		writeError(w, http.StatusConflict, placeErr.Error())
+		return
+	}
+	if len(order.Products) == 0 {
+		writeError(w, http.StatusConflict, "Cart is empty")
+		return
+	}
+
+	webhooks.publish(eventOrderCreated, sandboxID(r), newOrderEventData(storeFor(r), eventOrderCreated, order, ""))
+	dataChanges.publish(sandboxID(r), entityOrders, changeCreated, []int{order.ID})
+	dataChanges.publish(sandboxID(r), entityProducts, changeUpdated, orderProductIDs(order))
+
+	writeJSON(w, r, http.StatusCreated, order)
+}
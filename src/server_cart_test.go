@@ -0,0 +1,277 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSessions replaces the session store with an empty one.
+func withSessions(t *testing.T, limit int) {
+	t.Helper()
+	previous := sessions
+	sessions = newSessionStore(time.Hour, limit)
+	t.Cleanup(func() { sessions = previous })
+}
+
+// cartClient sends requests through the router, replaying the session cookie
+type cartClient struct {
+	t       *testing.T
+	handler http.Handler
+	cookies []*http.Cookie
+}
+
+func (c *cartClient) do(method, target, body string) *httptest.ResponseRecorder {
+	c.t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, req)
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		c.cookies = cookies
+	}
+	return w
+}
+
+func (c *cartClient) summary(w *httptest.ResponseRecorder) CartSummary {
+	c.t.Helper()
+	if w.Code != http.StatusOK {
+		c.t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary CartSummary
+	json.NewDecoder(w.Body).Decode(&summary)
+	return summary
+}
+
+// TestCartFlow tests the cart -> order -> recommendation flow over a session
+func TestCartFlow(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	summary := client.summary(client.do("GET", "/api/cart", ""))
+	if len(summary.Items) != 0 || len(client.cookies) != 1 || !client.cookies[0].HttpOnly {
+		t.Fatalf("Expected an empty cart and an HttpOnly session cookie, got %+v %+v", summary, client.cookies)
+	}
+
+	client.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	client.do("POST", "/api/cart/items", `{"product_id": 3, "quantity": 2}`)
+	summary = client.summary(client.do("PUT", "/api/cart/items/1?user_id=1", `{"quantity": 3}`))
+	if len(summary.Items) != 2 || summary.Items[0].Quantity != 3 {
+		t.Fatalf("Unexpected cart: %+v", summary.Items)
+	}
+	if summary.Order.Subtotal != 99.99*3+49.99*2 || summary.Order.Discount == 0 || len(summary.Recommendations) == 0 {
+		t.Errorf("Expected the cart priced for premium user 1 with recommendations, got %+v", summary)
+	}
+
+	// Out-of-stock products and lines not in the cart are rejected
+	if w := client.do("POST", "/api/cart/items", `{"product_id": 6}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an out-of-stock product, got %d", w.Code)
+	}
+	if w := client.do("DELETE", "/api/cart/items/5", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 removing a product not in the cart, got %d", w.Code)
+	}
+
+	// Another session has its own cart
+	other := &cartClient{t: t, handler: client.handler}
+	if summary := other.summary(other.do("GET", "/api/cart", "")); len(summary.Items) != 0 {
+		t.Errorf("Expected a new session to have an empty cart, got %+v", summary.Items)
+	}
+
+	w := client.do("POST", "/api/cart/checkout?user_id=1", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	orders := demoStore.listOrders()
+	if order.Status != "pending" || order.UserID != 1 || orders[len(orders)-1].ID != order.ID {
+		t.Errorf("Expected a stored pending order, got %+v", order)
+	}
+
+	if summary := client.summary(client.do("GET", "/api/cart", "")); len(summary.Items) != 0 {
+		t.Errorf("Expected checkout to empty the cart, got %+v", summary.Items)
+	}
+	if w := client.do("POST", "/api/cart/checkout", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 checking out an empty cart, got %d", w.Code)
+	}
+}
+
+// TestCartMerge tests merging a guest cart into the session's cart
+func TestCartMerge(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	client.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	summary := client.summary(client.do("POST", "/api/cart/merge", `{"items": [
+		{"product_id": 1, "quantity": 2, "added_at": "2026-01-05T12:00:00Z", "price_at_add": 89.99},
+		{"product_id": 3, "quantity": 1},
+		{"product_id": 999, "quantity": 1}
+	]}`))
+	if len(summary.Items) != 2 {
+		t.Fatalf("Expected the unknown product dropped, got %+v", summary.Items)
+	}
+	if line := summary.Items[0]; line.Quantity != 3 || line.AddedAt != "2026-01-05T12:00:00Z" || line.PriceAtAdd != 89.99 {
+		t.Errorf("Expected the shared line summed and dated from the guest cart, got %+v", line)
+	}
+	if line := summary.Items[1]; line.AddedAt == "" || line.PriceAtAdd != 49.99 {
+		t.Errorf("Expected the undated guest line stamped at the current price, got %+v", line)
+	}
+	if w := client.do("GET", "/api/cart/merge", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+// TestCartVariants tests adding, pricing and checking out product variants
+func TestCartVariants(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	if w := client.do("POST", "/api/cart/items", `{"product_id": 2}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a variant, got %d", w.Code)
+	}
+	client.do("POST", "/api/cart/items", `{"product_id": 2, "sku": "TSHIRT-BLK-M"}`)
+	summary := client.summary(client.do("POST", "/api/cart/items", `{"product_id": 2, "sku": "TSHIRT-BLK-XL", "quantity": 2}`))
+	if len(summary.Items) != 2 || summary.Order.Subtotal != 24.99+2*26.99 {
+		t.Fatalf("Expected a line per variant priced with its delta, got %+v", summary)
+	}
+	summary = client.summary(client.do("PUT", "/api/cart/items/2?sku=TSHIRT-BLK-XL", `{"quantity": 1}`))
+	if summary.Items[1].Quantity != 1 {
+		t.Errorf("Expected the XL line updated, got %+v", summary.Items)
+	}
+
+	w := client.do("POST", "/api/cart/checkout", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	product, _ := findProduct(demoStore.listProducts(), 2)
+	if product.Reserved != 2 || product.Variants[variantIndex(product, "TSHIRT-BLK-XL")].Reserved != 1 {
+		t.Errorf("Expected the variants' stock reserved, got %+v", product)
+	}
+}
+
+// TestSessionEviction tests that the least recently used session is evicted
+func TestSessionEviction(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 2)
+	handler := newServerMux()
+
+	first := &cartClient{t: t, handler: handler}
+	first.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	second := &cartClient{t: t, handler: handler}
+	second.do("GET", "/api/cart", "")
+	third := &cartClient{t: t, handler: handler}
+	third.do("GET", "/api/cart", "")
+
+	if summary := first.summary(first.do("GET", "/api/cart", "")); len(summary.Items) != 0 {
+		t.Errorf("Expected the oldest session to have been evicted, got %+v", summary.Items)
+	}
+}
+
+// TestCartCheckoutAddress tests shipping a checkout to another address
+func TestCartCheckoutAddress(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	client.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	w := client.do("POST", "/api/cart/checkout", `{"shipping_address": {"street": "1 Main St", "city": "Portland", "postal_code": "97201", "country": "US"}}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "region is required") {
+		t.Fatalf("Expected the address without a state to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = client.do("POST", "/api/cart/checkout", `{"shipping_address": {"street": "1 Main St", "city": "Portland", "region": "OR", "postal_code": "97201", "country": "US"}}`)
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if w.Code != http.StatusCreated || order.ShippingAddress == nil || order.Tax != 0 {
+		t.Errorf("Expected an untaxed order shipped to Oregon, got %d: %+v", w.Code, order)
+	}
+}
+
+// TestCartCheckoutShipments tests that checkout splits the order by warehouse
+// and that the order follows its shipments
+func TestCartCheckoutShipments(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	client.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	client.do("POST", "/api/cart/items", `{"product_id": 3}`) // stocked in the media warehouse
+	w := client.do("POST", "/api/cart/checkout", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if len(order.Shipments) != 2 || order.Shipments[1].Warehouse != "media" {
+		t.Fatalf("Expected a shipment per warehouse, got %+v", order.Shipments)
+	}
+
+	version := order.Version
+	shipment := func(id int, status string) Order {
+		t.Helper()
+		w := client.do("PUT", fmt.Sprintf("/api/orders/%d/shipments/%d/status", order.ID, id), fmt.Sprintf(`{"status": %q, "version": %d}`, status, version))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var updated Order
+		json.NewDecoder(w.Body).Decode(&updated)
+		version = updated.Version
+		return updated
+	}
+	if updated := shipment(1, ShipmentShipped); updated.Status != "processing" {
+		t.Errorf("Expected a partly shipped order to be processing, got %q", updated.Status)
+	}
+	if updated := shipment(2, ShipmentShipped); updated.Status != "shipped" {
+		t.Errorf("Expected a fully shipped order to be shipped, got %q", updated.Status)
+	}
+	if stock := stockOf(demoStore, 3); stock.Reserved != 0 {
+		t.Errorf("Expected shipping to commit the reserved stock, got %+v", stock)
+	}
+	if w := client.do("PUT", fmt.Sprintf("/api/orders/%d/shipments/9/status", order.ID), fmt.Sprintf(`{"status": "shipped", "version": %d}`, version)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown shipment, got %d", w.Code)
+	}
+}
+
+// TestCartCheckoutRiskHold tests that a risky order is scored at checkout and
+// held until an administrator advances it
+func TestCartCheckoutRiskHold(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	mux := newServerMux()
+	client := &cartClient{t: t, handler: mux}
+
+	client.do("POST", "/api/cart/items", `{"product_id": 1, "quantity": 9}`)
+	w := client.do("POST", "/api/cart/checkout?user_id=1", `{"shipping_address": {"street": "Hauptstr. 1", "city": "Berlin", "postal_code": "10115", "country": "DE"}}`)
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if w.Code != http.StatusCreated || order.RiskScore < RiskReviewThreshold {
+		t.Fatalf("Expected a high-risk order shipped abroad at five times the usual spend, got %d: %+v", w.Code, order)
+	}
+
+	target := fmt.Sprintf("/api/orders/%d/status", order.ID)
+	if w := client.do("PUT", target, `{"status": "shipped", "version": 1}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 shipping a held order, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := client.do("PUT", fmt.Sprintf("/api/orders/%d/shipments/1/status", order.ID), `{"status": "shipped", "version": 1}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 shipping a held order's shipment, got %d", w.Code)
+	}
+	req := httptest.NewRequest("PUT", target, strings.NewReader(`{"status": "processing", "version": 1}`))
+	req.Header.Set("Authorization", "Bearer admin")
+	approved := httptest.NewRecorder()
+	mux.ServeHTTP(approved, req)
+	if approved.Code != http.StatusOK {
+		t.Errorf("Expected the administrator to advance the order, got %d: %s", approved.Code, approved.Body.String())
+	}
+}
@@ -0,0 +1,252 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// BENCHMARK CHALLENGES
+// Browser results are self-reported, so anyone can post a 0.1 ms matrix
+// multiply. A client that wants its results to count as verified first asks
+// for a challenge:
+//
+//   POST /api/benchmark/challenges {"benchmark": "matrix", "params": {"size": 200}}
+//
+// The challenge carries a random seed and an HMAC-signed token. The client
+// runs the workload on the inputs the seed derives and submits the timings
+// with the token and the proof of the output (shared_benchmark_proof.go,
+// run and timed in pages by runChallengeBenchmarkWasm). The server checks
+// the signature, that the token hasn't expired or been used, that the
+// workload matches, and that the proof is right - running the workload
+// itself - and rejects durations shorter than that work could plausibly
+// take or longer together than the time since the challenge was issued.
+// Results that pass are stored with verified set; ?verified=true on the
+// results and compare endpoints ignores everything else.
+//
+// The signing key comes from -benchmark-signing-key; without one a random
+// key is used, so challenges don't survive a restart.
+// ============================================================================
+
+var (
+	errChallengeInvalid = errors.New("challenge token is invalid")
+	errChallengeExpired = errors.New("challenge has expired")
+	errChallengeUsed    = errors.New("challenge has already been used")
+)
+
+// benchmarkChallenge is the signed content of a challenge token.
+type benchmarkChallenge struct {
+	ID        string         `json:"id"`
+	Benchmark string         `json:"benchmark"`
+	Params    map[string]int `json:"params"`
+	Seed      uint32         `json:"seed"`
+	IssuedAt  time.Time      `json:"issued_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// benchmarkChallengeResponse is a challenge as issued to the client.
+type benchmarkChallengeResponse struct {
+	benchmarkChallenge
+	Token string `json:"token"`
+}
+
+// benchmarkChallengeRequest asks for a challenge for one workload.
+type benchmarkChallengeRequest struct {
+	Benchmark string         `json:"benchmark" validate:"required"`
+	Params    map[string]int `json:"params,omitempty"`
+}
+
+// challengeIssuer signs challenges and remembers redeemed ones until they
+// expire, so each token verifies one submission.
+type challengeIssuer struct {
+	mu   sync.Mutex
+	key  []byte
+	ttl  time.Duration
+	used map[string]time.Time
+	now  func() time.Time
+}
+
+// benchmarkChallenges is the active issuer. main replaces it with one using
+// the configured key and TTL.
+var benchmarkChallenges = newChallengeIssuer(nil, serverConfig.BenchmarkChallengeTTL)
+
+// newChallengeIssuer creates an issuer; an empty key is replaced with a
+// random one.
+func newChallengeIssuer(key []byte, ttl time.Duration) *challengeIssuer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &challengeIssuer{key: key, ttl: ttl, used: map[string]time.Time{}, now: time.Now}
+}
+
+func (ci *challengeIssuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, ci.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issue creates a signed challenge for a workload.
+func (ci *challengeIssuer) issue(benchmark string, params map[string]int) (benchmarkChallengeResponse, error) {
+	var seed [4]byte
+	rand.Read(seed[:])
+	now := ci.now().UTC()
+	challenge := benchmarkChallenge{
+		ID:        newRandomID(),
+		Benchmark: benchmark,
+		Params:    params,
+		Seed:      binary.BigEndian.Uint32(seed[:]),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ci.ttl),
+	}
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return benchmarkChallengeResponse{}, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return benchmarkChallengeResponse{benchmarkChallenge: challenge, Token: payload + "." + ci.sign(payload)}, nil
+}
+
+// redeem verifies a token and marks its challenge used.
+func (ci *challengeIssuer) redeem(token string) (benchmarkChallenge, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(ci.sign(payload))) {
+		return benchmarkChallenge{}, errChallengeInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return benchmarkChallenge{}, errChallengeInvalid
+	}
+	var challenge benchmarkChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return benchmarkChallenge{}, errChallengeInvalid
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	now := ci.now()
+	for id, expires := range ci.used {
+		if now.After(expires) {
+			delete(ci.used, id)
+		}
+	}
+	if now.After(challenge.ExpiresAt) {
+		return benchmarkChallenge{}, errChallengeExpired
+	}
+	if _, used := ci.used[challenge.ID]; used {
+		return benchmarkChallenge{}, errChallengeUsed
+	}
+	ci.used[challenge.ID] = challenge.ExpiresAt
+	return challenge, nil
+}
+
+// verifySubmissions checks the challenges of submitted results, returning
+// the challenge ID verifying each one ("" for results without a challenge).
+// A challenge may cover several results of one workload, such as repeated
+// runs in different environments, as long as they arrive together.
+func verifySubmissions(ctx context.Context, submissions []benchmarkResultSubmission) ([]string, error) {
+	type redeemed struct {
+		challenge benchmarkChallenge
+		run       challengeRun
+		claimedMs float64
+	}
+	byToken := map[string]*redeemed{}
+	ids := make([]string, len(submissions))
+
+	for i, sub := range submissions {
+		if sub.Challenge == "" {
+			if sub.Proof != "" {
+				return nil, fmt.Errorf("result %d: proof given without a challenge", i)
+			}
+			continue
+		}
+
+		entry, ok := byToken[sub.Challenge]
+		if !ok {
+			challenge, err := benchmarkChallenges.redeem(sub.Challenge)
+			if err != nil {
+				return nil, fmt.Errorf("result %d: %w", i, err)
+			}
+			run, err := runChallengeBenchmark(WithBenchmarkID(ctx, challenge.ID), challenge.Benchmark, challenge.Params, challenge.Seed)
+			if err != nil {
+				return nil, fmt.Errorf("result %d: %w", i, err)
+			}
+			entry = &redeemed{challenge: challenge, run: run}
+			byToken[sub.Challenge] = entry
+		}
+
+		challenge := entry.challenge
+		if strings.TrimSpace(sub.Benchmark) != challenge.Benchmark || !maps.Equal(sub.Params, challenge.Params) {
+			return nil, fmt.Errorf("result %d: benchmark and params must match the challenge", i)
+		}
+		if sub.Proof != entry.run.Proof {
+			return nil, fmt.Errorf("result %d: proof does not match the challenge", i)
+		}
+		if minMs := entry.run.minDurationMs(challenge.Benchmark); sub.DurationMs < minMs {
+			return nil, fmt.Errorf("result %d: %g ms is faster than the workload can run (at least %.3g ms)", i, sub.DurationMs, minMs)
+		}
+		entry.claimedMs += sub.DurationMs
+		if elapsed := float64(benchmarkChallenges.now().Sub(challenge.IssuedAt).Milliseconds()); entry.claimedMs > elapsed {
+			return nil, fmt.Errorf("result %d: durations exceed the time since the challenge was issued", i)
+		}
+		ids[i] = challenge.ID
+	}
+	return ids, nil
+}
+
+// handleBenchmarkChallenges issues a challenge for a benchmark workload.
+func handleBenchmarkChallenges(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req benchmarkChallengeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	// The server's own limits and defaults apply to challenged workloads
+	query := url.Values{}
+	for name, value := range req.Params {
+		query.Set(name, strconv.Itoa(value))
+	}
+	bench, err := prepareServerBenchmark(strings.TrimSpace(req.Benchmark), query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Only benchmarks with challenged runs can be verified
+	if err := checkChallengeParams(bench.Kind, bench.Params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	challenge, err := benchmarkChallenges.issue(bench.Kind, bench.Params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to issue challenge")
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, challenge)
+}
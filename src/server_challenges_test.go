@@ -0,0 +1,167 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withBenchmarkChallenges replaces the active issuer with one whose clock
+// the test controls.
+func withBenchmarkChallenges(t *testing.T) *time.Time {
+	t.Helper()
+	now := time.Now()
+	issuer := newChallengeIssuer([]byte("test-key"), time.Minute)
+	issuer.now = func() time.Time { return now }
+	previous := benchmarkChallenges
+	benchmarkChallenges = issuer
+	t.Cleanup(func() { benchmarkChallenges = previous })
+	return &now
+}
+
+func issueTestChallenge(t *testing.T, body string) benchmarkChallengeResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleBenchmarkChallenges(w, httptest.NewRequest("POST", "/api/benchmark/challenges", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var challenge benchmarkChallengeResponse
+	json.NewDecoder(w.Body).Decode(&challenge)
+	return challenge
+}
+
+// challengeProof is the proof of a challenged run of the workload.
+func challengeProof(t *testing.T, challenge benchmarkChallengeResponse) string {
+	t.Helper()
+	run, err := runChallengeBenchmark(context.Background(), challenge.Benchmark, challenge.Params, challenge.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run.Proof
+}
+
+func submitTestResults(body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handleBenchmarkResults(w, httptest.NewRequest("POST", "/api/benchmark/results", strings.NewReader(body)))
+	return w
+}
+
+// TestBenchmarkChallenges tests verified result submission
+func TestBenchmarkChallenges(t *testing.T) {
+	withBenchmarkHistory(t)
+	now := withBenchmarkChallenges(t)
+
+	challenge := issueTestChallenge(t, `{"benchmark": "matrix", "params": {"size": 20}}`)
+	if challenge.Token == "" || challenge.Params["size"] != 20 || !challenge.ExpiresAt.After(challenge.IssuedAt) {
+		t.Fatalf("Unexpected challenge: %+v", challenge)
+	}
+	proof := challengeProof(t, challenge)
+	result := func(token, proof string, durationMs float64) string {
+		return fmt.Sprintf(`{"benchmark": "matrix", "environment": "JavaScript", "params": {"size": 20}, "duration_ms": %v, "challenge": %q, "proof": %q}`, durationMs, token, proof)
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		tampered := issueTestChallenge(t, `{"benchmark": "hash", "params": {"count": 10}}`)
+		*now = now.Add(time.Second)
+		for name, body := range map[string]string{
+			"BadSignature":  result(tampered.Token[:len(tampered.Token)-2]+"xx", proof, 5),
+			"WrongWorkload": result(tampered.Token, proof, 5),
+			"NoChallenge":   result("", proof, 5),
+		} {
+			if w := submitTestResults(body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", name, w.Code)
+			}
+		}
+
+		wrongProof := issueTestChallenge(t, `{"benchmark": "matrix", "params": {"size": 20}}`)
+		*now = now.Add(time.Second)
+		if w := submitTestResults(result(wrongProof.Token, strings.Repeat("0", 64), 5)); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a wrong proof to be rejected, got %d", w.Code)
+		}
+	})
+
+	t.Run("DurationsExceedElapsedTime", func(t *testing.T) {
+		quick := issueTestChallenge(t, `{"benchmark": "matrix", "params": {"size": 20}}`)
+		quickProof := challengeProof(t, quick)
+		*now = now.Add(100 * time.Millisecond)
+		w := submitTestResults("[" + result(quick.Token, quickProof, 60) + "," + result(quick.Token, quickProof, 60) + "]")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 120 ms of runs 100 ms after the challenge to be rejected, got %d", w.Code)
+		}
+	})
+
+	t.Run("FasterThanTheWorkload", func(t *testing.T) {
+		// 200³ multiply-adds take at least 0.8 ms
+		big := issueTestChallenge(t, `{"benchmark": "matrix", "params": {"size": 200}}`)
+		bigProof := challengeProof(t, big)
+		*now = now.Add(time.Second)
+		body := fmt.Sprintf(`{"benchmark": "matrix", "environment": "JavaScript", "params": {"size": 200}, "duration_ms": 0.1, "challenge": %q, "proof": %q}`, big.Token, bigProof)
+		if w := submitTestResults(body); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "faster than the workload") {
+			t.Errorf("Expected a 0.1 ms 200x200 matrix multiply to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		*now = now.Add(time.Second)
+		w := submitTestResults("[" + result(challenge.Token, proof, 40) + "," + result(challenge.Token, proof, 50) + "]")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var stored []benchmarkRecord
+		json.NewDecoder(w.Body).Decode(&stored)
+		if len(stored) != 2 || !stored[0].Verified || stored[1].ChallengeID != challenge.ID {
+			t.Errorf("Expected verified records, got %+v", stored)
+		}
+
+		// Each challenge verifies one submission
+		if w := submitTestResults(result(challenge.Token, proof, 40)); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "already been used") {
+			t.Errorf("Expected a reused challenge to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		late := issueTestChallenge(t, `{"benchmark": "hash", "params": {"count": 10}}`)
+		lateProof := challengeProof(t, late)
+		*now = now.Add(2 * time.Minute)
+		body := fmt.Sprintf(`{"benchmark": "hash", "environment": "JavaScript", "params": {"count": 10}, "duration_ms": 1, "challenge": %q, "proof": %q}`, late.Token, lateProof)
+		if w := submitTestResults(body); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "expired") {
+			t.Errorf("Expected an expired challenge to be rejected, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("VerifiedFilter", func(t *testing.T) {
+		submitTestResults(`{"benchmark": "matrix", "environment": "JavaScript", "params": {"size": 20}, "duration_ms": 0.1}`)
+
+		w := httptest.NewRecorder()
+		handleBenchmarkResults(w, httptest.NewRequest("GET", "/api/benchmark/results?verified=true", nil))
+		var records []benchmarkRecord
+		json.NewDecoder(w.Body).Decode(&records)
+		if len(records) != 2 {
+			t.Errorf("Expected only the 2 verified records, got %+v", records)
+		}
+
+		w = httptest.NewRecorder()
+		handleBenchmarkCompare(w, httptest.NewRequest("GET", "/api/benchmark/compare?verified=true", nil))
+		var comparison benchmarkComparison
+		json.NewDecoder(w.Body).Decode(&comparison)
+		if len(comparison.Groups) != 1 || comparison.Groups[0].Environments[0].MinMs != 40 {
+			t.Errorf("Expected the unverified 0.1 ms run to be ignored, got %+v", comparison)
+		}
+	})
+
+	t.Run("UnsupportedBenchmark", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handleBenchmarkChallenges(w, httptest.NewRequest("POST", "/api/benchmark/challenges", strings.NewReader(`{"benchmark": "raytracing"}`)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// ============================================================================
+// CHURN RISK
+// GET /api/analytics/churn lists the stored customers by
+// ScoreChurnRiskWithSessions over the stored events, riskiest first:
+//
+//   ?min_probability=0.5   only customers at least this likely to churn
+//   ?risk=high             only customers at this level: low, medium or high
+// ============================================================================
+
+// churnLevels are the risk levels ?risk= accepts.
+var churnLevels = []string{ChurnLow, ChurnMedium, ChurnHigh}
+
+// handleChurnRisk serves the churn risk of the stored customers.
+func handleChurnRisk(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	fields := map[string]string{}
+	minProbability := 0.0
+	if raw := r.URL.Query().Get("min_probability"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(value >= 0 && value <= 1) {
+			fields["min_probability"] = "must be a number from 0 to 1"
+		}
+		minProbability = value
+	}
+	risk := r.URL.Query().Get("risk")
+	if risk != "" && !slices.Contains(churnLevels, risk) {
+		fields["risk"] = "must be low, medium or high"
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	store := storeFor(r)
+	sessions := SessionizeEvents(store.listEvents(), DefaultSessionGap)
+	risks := FilterChurnRisk(ScoreChurnRiskWithSessions(store.listUsers(), store.listOrders(), sessions), minProbability, risk)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, risks)
+}
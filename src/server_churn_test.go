@@ -0,0 +1,52 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestChurnRisk tests listing and filtering the demo customers' churn risk
+func TestChurnRisk(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/churn", nil))
+	var risks []ChurnRisk
+	json.NewDecoder(w.Body).Decode(&risks)
+	if w.Code != http.StatusOK || len(risks) == 0 {
+		t.Fatalf("Expected scored customers, got %d %+v", w.Code, risks)
+	}
+	for i := 1; i < len(risks); i++ {
+		if risks[i].Probability > risks[i-1].Probability {
+			t.Errorf("Expected riskiest first, got %+v", risks)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/churn?risk=low", nil))
+	var low []ChurnRisk
+	json.NewDecoder(w.Body).Decode(&low)
+	for _, risk := range low {
+		if risk.Risk != ChurnLow {
+			t.Errorf("Expected only low risk, got %+v", risk)
+		}
+	}
+
+	for target, status := range map[string]int{
+		"/api/analytics/churn?min_probability=0.5": http.StatusOK,
+		"/api/analytics/churn?min_probability=2":   http.StatusBadRequest,
+		"/api/analytics/churn?min_probability=x":   http.StatusBadRequest,
+		"/api/analytics/churn?risk=extreme":        http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// CUSTOMER LIFETIME VALUE
+// GET /api/users/{id}/clv projects a user's value from the stored orders
+// with CalculateCLV, at the -clv-margin and -clv-discount-rate settings:
+//
+//   ?months=12   the horizon, 1 to 120 months (the default is 12)
+//
+// /api/analyze-behavior reports the total and average over every user.
+// ============================================================================
+
+// handleUserCLV serves a user's projected lifetime value.
+func handleUserCLV(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := privacyUserID(w, r)
+	if !ok {
+		return
+	}
+	months := DefaultCLVHorizonMonths
+	if raw := r.URL.Query().Get("months"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxCLVHorizonMonths {
+			writeFieldErrors(w, map[string]string{"months": "must be a whole number of months from 1 to " + strconv.Itoa(maxCLVHorizonMonths)})
+			return
+		}
+		months = value
+	}
+
+	store := storeFor(r)
+	for _, user := range store.listUsers() {
+		if user.ID == id {
+			w.Header().Set("Cache-Control", "no-store")
+			writeJSON(w, r, http.StatusOK, CalculateCLV(user, store.listOrders(), months))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "User not found")
+}
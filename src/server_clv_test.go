@@ -0,0 +1,37 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestUserCLV tests a demo user's lifetime value
+func TestUserCLV(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/1/clv?months=24", nil))
+	var value CustomerValue
+	json.NewDecoder(w.Body).Decode(&value)
+	if w.Code != http.StatusOK || value.UserID != 1 || value.HorizonMonths != 24 || value.Orders == 0 || value.CLV <= 0 {
+		t.Fatalf("Expected user 1's value over 24 months, got %d %+v", w.Code, value)
+	}
+
+	for target, status := range map[string]int{
+		"/api/users/99/clv":          http.StatusNotFound,
+		"/api/users/x/clv":           http.StatusBadRequest,
+		"/api/users/1/clv?months=0":  http.StatusBadRequest,
+		"/api/users/1/clv?months=xx": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
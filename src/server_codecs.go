@@ -0,0 +1,395 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// CONTENT NEGOTIATION
+// The business-logic endpoints read and write JSON, MessagePack or XML. The
+// request body is decoded according to Content-Type (JSON unless it names
+// another codec) and the response encoded according to Accept (JSON when
+// there is no preference):
+//
+//   curl -H 'Accept: application/msgpack' -d @user.json localhost:8181/api/validate-user
+//
+// MessagePack and XML carry the same field names as JSON. In XML the
+// field names become elements, list entries are <item> elements and the
+// document element is <response> (or <request>, which isn't checked).
+// ============================================================================
+
+// payloadCodec is one encoding in the registry.
+type payloadCodec struct {
+	name       string
+	mediaTypes []string // the first is sent in Content-Type
+	marshal    func(v interface{}) ([]byte, error)
+	unmarshal  func(data []byte, v interface{}) error
+}
+
+// payloadCodecs is the codec registry; the first entry is the default.
+var payloadCodecs = []payloadCodec{
+	{
+		name:       "JSON",
+		mediaTypes: []string{"application/json"},
+		marshal: func(v interface{}) ([]byte, error) {
+			data, err := json.Marshal(v)
+			return append(data, '\n'), err
+		},
+		unmarshal: json.Unmarshal,
+	},
+	{
+		name:       "MessagePack",
+		mediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+		marshal:    MarshalMsgPack,
+		unmarshal:  UnmarshalMsgPack,
+	},
+	{
+		name:       "XML",
+		mediaTypes: []string{"application/xml", "text/xml"},
+		marshal:    marshalXMLPayload,
+		unmarshal:  unmarshalXMLPayload,
+	},
+}
+
+var jsonCodec = payloadCodecs[0]
+
+func codecForMediaType(mediaType string) (payloadCodec, bool) {
+	for _, codec := range payloadCodecs {
+		for _, candidate := range codec.mediaTypes {
+			if candidate == mediaType {
+				return codec, true
+			}
+		}
+	}
+	return payloadCodec{}, false
+}
+
+// requestCodec picks the codec for the request body from Content-Type.
+// Bodies of other types (or none) are read as JSON, as they always were.
+func requestCodec(r *http.Request) payloadCodec {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if codec, ok := codecForMediaType(mediaType); ok {
+		return codec
+	}
+	return jsonCodec
+}
+
+// responseCodec picks the codec for the response from Accept, honouring
+// q-values. ok is false when nothing acceptable is available.
+func responseCodec(r *http.Request) (payloadCodec, bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return jsonCodec, true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, rng := range ranges {
+		if rng.mediaType == "*/*" || rng.mediaType == "application/*" {
+			return jsonCodec, true
+		}
+		if codec, ok := codecForMediaType(rng.mediaType); ok {
+			return codec, true
+		}
+	}
+	return payloadCodec{}, false
+}
+
+// decodeRequest decodes a request body in any registered encoding; see
+// decodeJSONBody.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, requestCodec(r), dst)
+}
+
+// writeNegotiated responds with v in the encoding the client accepts.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Add("Vary", "Accept")
+	codec, ok := responseCodec(r)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, "No acceptable response type (use "+supportedMediaTypes()+")")
+		return
+	}
+	if codec.name == jsonCodec.name {
+		writeJSON(w, r, status, v)
+		return
+	}
+	data, err := codec.marshal(envelope(r, v))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	w.Header().Set("Content-Type", codec.mediaTypes[0])
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func supportedMediaTypes() string {
+	types := make([]string, len(payloadCodecs))
+	for i, codec := range payloadCodecs {
+		types[i] = codec.mediaTypes[0]
+	}
+	return strings.Join(types, ", ")
+}
+
+// ----------------------------------------------------------------------------
+// XML
+// ----------------------------------------------------------------------------
+
+func marshalXMLPayload(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := ParseJSONTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := writeXMLElement(enc, "response", tree); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeXMLElement(enc *xml.Encoder, name string, node JSONTree) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !validXMLName(name) {
+		// Map keys that aren't element names, e.g. benchmark parameters
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch {
+	case node.Object:
+		for i, item := range node.Items {
+			if err := writeXMLElement(enc, node.Keys[i], item); err != nil {
+				return err
+			}
+		}
+	case node.Array:
+		for _, item := range node.Items {
+			if err := writeXMLElement(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case node.Value != nil:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(node.Value))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, c := range name {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || !(c == '-' || c == '.' || (c >= '0' && c <= '9'))) {
+			return false
+		}
+	}
+	return true
+}
+
+// xmlNode is a parsed XML element.
+type xmlNode struct {
+	name     string
+	key      string // key attribute of <entry> elements
+	text     string
+	children []*xmlNode
+}
+
+func (n *xmlNode) fieldName() string {
+	if n.key != "" {
+		return n.key
+	}
+	return n.name
+}
+
+// unmarshalXMLPayload decodes XML into v. XML has no types of its own, so
+// the element tree is read according to v's type and then decoded like JSON,
+// which gives the same type errors as a JSON body.
+func unmarshalXMLPayload(data []byte, v interface{}) error {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(xmlValue(root, reflect.TypeOf(v)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) >= maxMsgPackDepth {
+				return nil, errors.New("xml: nesting too deep")
+			}
+			node := &xmlNode{name: t.Name.Local}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "key" {
+					node.key = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("xml: more than one document element")
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("xml: no document element")
+	}
+	return root, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// xmlValue converts an element to the JSON value for a Go type: objects for
+// structs and maps (one entry per child), lists for slices (one entry per
+// child), numbers and booleans for their kinds and strings otherwise. Text
+// that doesn't parse as the expected kind is kept as a string so JSON
+// decoding reports the mismatch.
+func xmlValue(node *xmlNode, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	text := strings.TrimSpace(node.text)
+
+	if t == nil || t.Kind() == reflect.Interface {
+		if len(node.children) == 0 {
+			return text
+		}
+		object := map[string]interface{}{}
+		for _, child := range node.children {
+			object[child.fieldName()] = xmlValue(child, nil)
+		}
+		return object
+	}
+
+	switch {
+	case t == timeType:
+		return text
+	case t.Kind() == reflect.Struct:
+		object := map[string]interface{}{}
+		for _, child := range node.children {
+			object[child.fieldName()] = xmlValue(child, structFieldType(t, child.fieldName()))
+		}
+		return object
+	case t.Kind() == reflect.Map:
+		object := map[string]interface{}{}
+		for _, child := range node.children {
+			object[child.fieldName()] = xmlValue(child, t.Elem())
+		}
+		return object
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return text
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		items := make([]interface{}, len(node.children))
+		for i, child := range node.children {
+			items[i] = xmlValue(child, t.Elem())
+		}
+		return items
+	case text == "":
+		return nil
+	case t.Kind() == reflect.Bool:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64:
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	}
+	return text
+}
+
+// structFieldType finds the type of the field JSON decodes name into, or nil.
+func structFieldType(t reflect.Type, name string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if embedded := structFieldType(field.Type, name); embedded != nil {
+				return embedded
+			}
+			continue
+		}
+		if strings.EqualFold(jsonFieldName(field), name) {
+			return field.Type
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestContentNegotiation tests codec selection for business-logic endpoints
+func TestContentNegotiation(t *testing.T) {
+	userJSON := `{"email": "ann@example.com", "name": "Ann", "age": 30, "country": "US"}`
+	userMsgPack, _ := MarshalMsgPack(User{Email: "ann@example.com", Name: "Ann", Age: 30, Country: "US"})
+	userXML := `<request><email>ann@example.com</email><name>Ann</name><age>30</age><country>US</country></request>`
+
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		body        string
+		status      int
+		responseCT  string
+	}{
+		{"Default", "", "", userJSON, 200, "application/json"},
+		{"MsgPackRequest", "application/msgpack", "", string(userMsgPack), 200, "application/json"},
+		{"XMLRequestAndResponse", "application/xml; charset=utf-8", "application/xml", userXML, 200, "application/xml"},
+		{"QValues", "", "application/xml;q=0.5, application/x-msgpack", userJSON, 200, "application/msgpack"},
+		{"Wildcard", "", "text/html, */*;q=0.1", userJSON, 200, "application/json"},
+		{"NotAcceptable", "", "text/html", userJSON, 406, "application/json"},
+		{"XMLTypeError", "text/xml", "", `<request><age>old</age></request>`, 400, "application/json"},
+		{"BadMsgPack", "application/msgpack", "", "\xc1", 400, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/validate-user", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handleValidateUser(w, req)
+
+			if w.Code != tt.status || w.Header().Get("Content-Type") != tt.responseCT {
+				t.Fatalf("Expected %d %s, got %d %s: %s", tt.status, tt.responseCT, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var result ValidationResult
+			codec, _ := codecForMediaType(tt.responseCT)
+			err := codec.unmarshal(w.Body.Bytes(), &result)
+			if err != nil || !result.Valid {
+				t.Errorf("Expected a valid user, got %+v (%v): %s", result, err, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestXMLPayload tests the XML mapping of nested lists and odd map keys
+func TestXMLPayload(t *testing.T) {
+	data, err := marshalXMLPayload(map[string]interface{}{
+		"order":  Order{ID: 1, Products: []Product{{ID: 2}, {ID: 3}}, Quantities: []int{1, 4}},
+		"params": map[string]int{"2x2": 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(data, new(interface{})); err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, data)
+	}
+	for _, expected := range []string{"<quantities><item>1</item><item>4</item></quantities>", `<entry key="2x2">4</entry>`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %s in\n%s", expected, data)
+		}
+	}
+
+	var decoded struct {
+		Order  Order          `json:"order"`
+		Params map[string]int `json:"params"`
+	}
+	if err := unmarshalXMLPayload(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Order.Products) != 2 || decoded.Order.Quantities[1] != 4 || decoded.Params["2x2"] != 4 {
+		t.Errorf("Unexpected round trip: %+v", decoded)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// OPTIMISTIC CONCURRENCY
+// Updates to users, products and orders must say which version of the
+// record they were made against, in an If-Match header or as the "version"
+// of the JSON body:
+//
+//   PUT /api/orders/2/status
+//   If-Match: "3"
+//   {"status": "shipped"}
+//
+// Responses with a single record carry its version as the ETag. An update
+// without a version is refused with 428 Precondition Required, and one
+// against an old version with 409 Conflict and the record's current_version,
+// leaving the record as it is. If-Match: * updates whatever the version.
+// ============================================================================
+
+// requestVersion is the version an update was made against: the If-Match
+// header's, or else bodyVersion. It responds with an error and returns false
+// when there is neither.
+func requestVersion(w http.ResponseWriter, r *http.Request, bodyVersion int) (int, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		if bodyVersion < 1 {
+			writeAPIError(w, http.StatusPreconditionRequired, apiError{
+				Code:    codeVersionRequired,
+				Message: "Updates must name the version they were made against, in If-Match or as \"version\"",
+			})
+			return 0, false
+		}
+		return bodyVersion, true
+	}
+	if ifMatch == "*" {
+		return anyVersion, true
+	}
+	version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || version < 1 {
+		writeError(w, http.StatusBadRequest, `If-Match must be a record version such as "3", or *`)
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionConflict responds to an update refused with a
+// *VersionConflictError, returning false for other errors.
+func writeVersionConflict(w http.ResponseWriter, err error) bool {
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	writeAPIError(w, http.StatusConflict, apiError{
+		Code:           codeVersionConflict,
+		Message:        "The record has changed since version " + strconv.Itoa(conflict.Expected) + "; fetch it again and retry",
+		CurrentVersion: conflict.Current,
+	})
+	return true
+}
+
+// setVersionETag sets the ETag of a response with a single record to its
+// version, for If-Match.
+func setVersionETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
@@ -0,0 +1,109 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreVersions(t *testing.T) {
+	store := newDemoDataStore()
+	for _, user := range store.listUsers() {
+		if user.Version != 1 {
+			t.Fatalf("Expected the demo users at version 1, got %+v", user)
+		}
+	}
+
+	order, err := store.placeOrder(Order{UserID: 2, Products: []Product{{ID: 5, Price: 89.99}}, Quantities: []int{1}, Status: "pending"})
+	if err != nil || order.Version != 1 {
+		t.Fatalf("Expected a new order at version 1, got %+v (%v)", order, err)
+	}
+	// Reserving stock changes the product
+	if product, _ := findProduct(store.listProducts(), 5); product.Version != 2 {
+		t.Errorf("Expected the reservation to move the product to version 2, got %d", product.Version)
+	}
+
+	updated, _, err := store.setOrderStatus(order.ID, 1, "shipped", false)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("Expected the order at version 2, got %+v (%v)", updated, err)
+	}
+	if _, _, err := store.setOrderStatus(order.ID, 1, "delivered", false); err == nil || !strings.Contains(err.Error(), "current version is 2") {
+		t.Errorf("Expected a conflict with version 2, got %v", err)
+	}
+	if current, _, _ := store.orderWithUser(order.ID); current.Status != "shipped" {
+		t.Errorf("Expected a refused update to leave the order, got %q", current.Status)
+	}
+	// Setting the status it already has changes nothing
+	if same, _, _ := store.setOrderStatus(order.ID, anyVersion, "shipped", false); same.Version != 2 {
+		t.Errorf("Expected no new version without a change, got %d", same.Version)
+	}
+
+	if _, err := store.setProductPrice(5, 1, 79.99, time.Now()); err == nil {
+		t.Error("Expected a price change against the old product version to be refused")
+	}
+	if product, err := store.setProductPrice(5, 3, 79.99, time.Now()); err != nil || product.Version != 4 {
+		t.Errorf("Expected the price change at version 4, got %+v (%v)", product, err)
+	}
+}
+
+func TestOptimisticConcurrencyEndpoints(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	put := func(body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/orders/1/status", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(`{"status": "processing"}`, "")
+	if w.Code != http.StatusPreconditionRequired || decodeErrorResponse(t, w).Code != codeVersionRequired {
+		t.Errorf("Expected status 428 without a version, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := put(`{"status": "processing"}`, "soon"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed If-Match, got %d", w.Code)
+	}
+
+	w = put(`{"status": "processing"}`, `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected the update with ETag \"2\", got %d %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// A second client still holding version 1 is refused and told the
+	// current version
+	w = put(`{"status": "cancelled", "version": 1}`, "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var conflict errorResponse
+	json.NewDecoder(w.Body).Decode(&conflict)
+	if conflict.Error.Code != codeVersionConflict || conflict.Error.CurrentVersion != 2 {
+		t.Errorf("Expected a version conflict at version 2, got %+v", conflict.Error)
+	}
+	orders := demoStore.listOrders()
+	if orders[0].Status != "processing" {
+		t.Errorf("Expected the refused update to leave the order processing, got %q", orders[0].Status)
+	}
+
+	// and succeeds once it retries with the current version
+	if w := put(`{"status": "cancelled", "version": 2}`, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the retry to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := put(`{"status": "delivered"}`, "*"); w.Code != http.StatusOK {
+		t.Errorf("Expected If-Match: * to update any version, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/products/5", nil))
+	if w.Header().Get("ETag") != `"1"` {
+		t.Errorf("Expected the product's version as its ETag, got %q", w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !wasm
+
+package main
+
+import "net/http"
+
+// ============================================================================
+// COUNTRIES
+// The ISO 3166 country dataset (shared_countries.go) that user, address, tax
+// and shipping rules are checked against, for country pickers:
+//
+//   GET /api/countries[?region=Europe]   the countries by code
+//   GET /api/countries/{code}            one country, by alpha-2 or alpha-3
+//                                        code
+// ============================================================================
+
+// countryCodeParam is the {code} of /api/countries/{code}.
+var countryCodeParam = apiParam{Name: "code", In: "path", Type: "string", Description: "ISO 3166-1 alpha-2 or alpha-3 code"}
+
+// handleCountries lists the countries, optionally of one region.
+func handleCountries(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeNegotiated(w, r, http.StatusOK, Countries(r.URL.Query().Get("region")))
+}
+
+// handleCountry looks up one country.
+func handleCountry(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	country, ok := LookupCountry(r.PathValue("code"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unknown country code")
+		return
+	}
+	writeNegotiated(w, r, http.StatusOK, country)
+}
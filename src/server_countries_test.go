@@ -0,0 +1,37 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCountriesEndpoints tests listing and looking up countries
+func TestCountriesEndpoints(t *testing.T) {
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/countries?region=Europe", nil))
+	var countries []Country
+	json.NewDecoder(w.Body).Decode(&countries)
+	if w.Code != http.StatusOK || len(countries) == 0 || countries[0].Region != "Europe" {
+		t.Fatalf("Expected the European countries, got %d: %+v", w.Code, countries)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/countries/fra", nil))
+	var country Country
+	json.NewDecoder(w.Body).Decode(&country)
+	if w.Code != http.StatusOK || country.Code != "FR" || country.TaxRate == nil || *country.TaxRate != 0.20 {
+		t.Errorf("Expected France, got %d: %+v", w.Code, country)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/countries/XX", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown code, got %d", w.Code)
+	}
+}
@@ -0,0 +1,200 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// DATA CHANGE STREAM
+// /api/data/stream is a Server-Sent Events stream of changes to the demo
+// data, so several open demo pages stay in sync:
+//
+//   users     created by bulk import
+//   products  created by bulk import
+//   orders    created by checkout, updated by PUT /api/orders/{id}/status
+//
+// Each event is named after its entity and carries the affected IDs; pages
+// refetch what they show. ?entities=orders,products limits the stream to
+// those entities. Like webhooks, changes in a sandbox are only seen by
+// streams opened in the same sandbox.
+//
+// A client that falls more than dataChangeBuffer events behind is sent a
+// "resync" event instead of the events it missed. Streams end when the
+// server shuts down, so open tabs don't hold up a graceful shutdown.
+// ============================================================================
+
+// Data entities and change actions
+const (
+	entityUsers    = "users"
+	entityProducts = "products"
+	entityOrders   = "orders"
+
+	changeCreated = "created"
+	changeUpdated = "updated"
+)
+
+var dataEntities = []string{entityUsers, entityProducts, entityOrders}
+
+// dataChangeBuffer is the number of undelivered events kept per stream.
+const dataChangeBuffer = 32
+
+// dataChange is one event on the stream.
+type dataChange struct {
+	Entity string    `json:"entity"`
+	Action string    `json:"action"`
+	IDs    []int     `json:"ids"`
+	Time   time.Time `json:"time"`
+}
+
+// dataSubscriber is one connected stream.
+type dataSubscriber struct {
+	sandbox  string
+	entities []string
+	changes  chan dataChange
+	missed   bool // events were dropped since the last resync
+}
+
+// dataChangeHub fans changes out to connected streams.
+type dataChangeHub struct {
+	mu          sync.Mutex
+	subscribers map[*dataSubscriber]struct{}
+	done        chan struct{} // closed by close
+	closeOnce   sync.Once
+}
+
+// dataChanges is the active hub.
+var dataChanges = newDataChangeHub()
+
+func newDataChangeHub() *dataChangeHub {
+	return &dataChangeHub{subscribers: map[*dataSubscriber]struct{}{}, done: make(chan struct{})}
+}
+
+// close ends every stream, current and future.
+func (h *dataChangeHub) close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// subscribe registers a stream for changes to entities in a sandbox.
+func (h *dataChangeHub) subscribe(sandboxID string, entities []string) (*dataSubscriber, func()) {
+	sub := &dataSubscriber{sandbox: sandboxID, entities: entities, changes: make(chan dataChange, dataChangeBuffer)}
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub, func() {
+		h.mu.Lock()
+		delete(h.subscribers, sub)
+		h.mu.Unlock()
+	}
+}
+
+// publish notifies the sandbox's streams of a change. It never blocks: a
+// stream whose buffer is full misses the change and is told to resync.
+func (h *dataChangeHub) publish(sandboxID, entity, action string, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	change := dataChange{Entity: entity, Action: action, IDs: ids, Time: time.Now().UTC()}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if sub.sandbox != sandboxID || !slices.Contains(sub.entities, entity) {
+			continue
+		}
+		select {
+		case sub.changes <- change:
+		default:
+			sub.missed = true
+		}
+	}
+}
+
+// takeMissed reports and clears whether a stream missed events.
+func (h *dataChangeHub) takeMissed(sub *dataSubscriber) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	missed := sub.missed
+	sub.missed = false
+	return missed
+}
+
+// parseDataEntities reads the entities filter; empty means all of them.
+func parseDataEntities(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return dataEntities, nil
+	}
+	var entities []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(dataEntities, name) {
+			return nil, fmt.Errorf("unknown entity %q (use %s)", name, strings.Join(dataEntities, ", "))
+		}
+		if !slices.Contains(entities, name) {
+			entities = append(entities, name)
+		}
+	}
+	return entities, nil
+}
+
+// handleDataStream streams demo data changes as Server-Sent Events.
+func handleDataStream(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	entities, err := parseDataEntities(r.URL.Query().Get("entities"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hub := dataChanges
+	sub, unsubscribe := hub.subscribe(sandboxID(r), entities)
+	defer unsubscribe()
+
+	// A stream legitimately outlives the server's WriteTimeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	ready, _ := json.Marshal(map[string][]string{"entities": entities})
+	fmt.Fprintf(w, "event: ready\ndata: %s\n\n", ready)
+	rc.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case change := <-sub.changes:
+			data, _ := json.Marshal(change)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Entity, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-hub.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if len(sub.changes) == 0 && hub.takeMissed(sub) {
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
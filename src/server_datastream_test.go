@@ -0,0 +1,154 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openDataStream connects to the change stream and returns its events
+// (name and data) as they arrive, after the ready event.
+func openDataStream(t *testing.T, url string) <-chan [2]string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan [2]string, 16)
+	go func() {
+		defer close(events)
+		var name string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if value, ok := strings.CutPrefix(line, "event: "); ok {
+				name = value
+			} else if value, ok := strings.CutPrefix(line, "data: "); ok {
+				events <- [2]string{name, value}
+			}
+		}
+	}()
+
+	if ready := nextDataEvent(t, events); ready[0] != "ready" {
+		t.Fatalf("Expected a ready event first, got %v", ready)
+	}
+	return events
+}
+
+func nextDataEvent(t *testing.T, events <-chan [2]string) [2]string {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return [2]string{}
+	}
+}
+
+// TestDataStream tests that changes reach streams for their entity and
+// sandbox only
+func TestDataStream(t *testing.T) {
+	withDemoStore(t)
+	withSandboxes(t, time.Hour, 10)
+	// Registered first so it closes after the streams, which it waits for
+	server := httptest.NewServer(newServerHandler())
+	t.Cleanup(server.Close)
+
+	orders := openDataStream(t, server.URL+"/api/data/stream?entities=orders")
+	all := openDataStream(t, server.URL+"/api/data/stream")
+	sandboxed := openDataStream(t, server.URL+"/sandbox/streams/api/data/stream")
+
+	importReq, _ := http.NewRequest("POST", server.URL+"/api/import?type=users", strings.NewReader(`[{"name": "Ann Lee", "email": "ann@example.com", "age": 30, "country": "US"}]`))
+	importReq.Header.Set("Content-Type", "application/json")
+	if resp, err := http.DefaultClient.Do(importReq); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Import failed: %v %v", resp, err)
+	}
+	statusReq, _ := http.NewRequest("PUT", server.URL+"/api/orders/1/status", strings.NewReader(`{"status": "cancelled", "version": 1}`))
+	if resp, err := http.DefaultClient.Do(statusReq); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status change failed: %v %v", resp, err)
+	}
+
+	var change dataChange
+	event := nextDataEvent(t, orders)
+	json.Unmarshal([]byte(event[1]), &change)
+	if event[0] != entityOrders || change.Action != changeUpdated || len(change.IDs) != 1 || change.IDs[0] != 1 {
+		t.Errorf("Expected the order update, got %v", event)
+	}
+
+	if event := nextDataEvent(t, all); event[0] != entityUsers {
+		t.Errorf("Expected the user import first, got %v", event)
+	}
+	if event := nextDataEvent(t, all); event[0] != entityOrders {
+		t.Errorf("Expected the order update second, got %v", event)
+	}
+
+	select {
+	case event := <-sandboxed:
+		t.Errorf("Expected no events in another sandbox, got %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestDataStreamOverflow tests that a stream that falls behind is told to
+// resync
+func TestDataStreamOverflow(t *testing.T) {
+	hub := newDataChangeHub()
+	sub, unsubscribe := hub.subscribe("", dataEntities)
+	defer unsubscribe()
+
+	for i := 0; i < dataChangeBuffer+1; i++ {
+		hub.publish("", entityProducts, changeCreated, []int{i})
+	}
+	if len(sub.changes) != dataChangeBuffer || !hub.takeMissed(sub) || hub.takeMissed(sub) {
+		t.Errorf("Expected a full buffer and one missed flag")
+	}
+
+	w := httptest.NewRecorder()
+	handleDataStream(w, httptest.NewRequest("GET", "/api/data/stream?entities=carts", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown entity, got %d", w.Code)
+	}
+}
+
+// TestDataStreamClose tests that closing the hub ends open streams and
+// rejects other methods
+func TestDataStreamClose(t *testing.T) {
+	previous := dataChanges
+	dataChanges = newDataChangeHub()
+	t.Cleanup(func() { dataChanges = previous })
+
+	server := httptest.NewServer(newServerMux())
+	t.Cleanup(server.Close)
+
+	resp, err := http.Post(server.URL+"/api/data/stream", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", resp.StatusCode)
+	}
+
+	events := openDataStream(t, server.URL+"/api/data/stream")
+	dataChanges.close()
+	select {
+	case _, open := <-events:
+		if open {
+			t.Error("Expected no events after close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stream to end when the hub closes")
+	}
+}
@@ -0,0 +1,186 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// ============================================================================
+// DATA SYNC
+// POST /api/data/sync takes the edits a page made to its cached users and
+// products while offline (shared_datacache.go):
+//
+//   {"changes": [{"model": "user", "id": 2,
+//                 "patches": [{"version": 3, "name": "Jane Doe"}]}]}
+//
+// Each record's patches are applied in order, all or none, as PATCHes the
+// page made against the versions it had. A record that has moved on since
+// is a conflict and one whose edits don't validate is rejected; either way
+// the result carries the record as it stands, for the page to resolve
+// against. Applied edits are published on the data change stream.
+//
+// Syncs need no token, so patches may only change what a person edits
+// about themselves or a catalog entry (syncFields). Prices change with the
+// admin token, as PUT /api/products/{id}/price does, and stock,
+// reservations, loyalty points, verification and anonymization only
+// through their own endpoints; a patch touching them is rejected whole.
+// ============================================================================
+
+// maxSyncChanges bounds the records one sync can change.
+const maxSyncChanges = 500
+
+// syncFields are the fields sync patches may change, by model. A patch's
+// version is the one it was made against and its id must be the record's.
+var syncFields = map[string][]string{
+	SchemaUser:    {"name", "age", "country", "region", "phone", "address", "preferences", "notifications"},
+	SchemaProduct: {"name", "description", "category", "image_url", "images"},
+}
+
+// adminSyncFields may also be changed by syncs carrying the admin token.
+var adminSyncFields = map[string][]string{
+	SchemaProduct: {"price"},
+}
+
+// checkSyncPatches reports the first field the patches change that the
+// sync may not.
+func checkSyncPatches(model string, patches []json.RawMessage, admin bool) error {
+	for _, patch := range patches {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &fields); err != nil {
+			return jsonDecodeError{err}
+		}
+		for _, name := range slices.Sorted(maps.Keys(fields)) {
+			switch {
+			case name == "version" || name == "id" || slices.Contains(syncFields[model], name):
+			case slices.Contains(adminSyncFields[model], name):
+				if !admin {
+					return fmt.Errorf("%s can only be changed with the admin token", name)
+				}
+			default:
+				return fmt.Errorf("%s can't be changed by sync", name)
+			}
+		}
+	}
+	return nil
+}
+
+// handleDataSync applies a page's offline edits.
+func handleDataSync(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req SyncRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("At most %d changes can be synced at once", maxSyncChanges))
+		return
+	}
+
+	store, admin := storeFor(r), isAdmin(r)
+	response := SyncResponse{Results: make([]SyncResult, len(req.Changes))}
+	changed := map[string][]int{}
+	for i, change := range req.Changes {
+		response.Results[i] = store.syncRecord(change, admin, time.Now())
+		if response.Results[i].Status == SyncApplied {
+			entity := entityUsers
+			if change.Model == SchemaProduct {
+				entity = entityProducts
+			}
+			changed[entity] = append(changed[entity], change.ID)
+		}
+	}
+	for _, entity := range dataEntities {
+		if ids := changed[entity]; len(ids) > 0 {
+			dataChanges.publish(sandboxID(r), entity, changeUpdated, ids)
+		}
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// syncRecord applies one record's offline edits, of the fields syncFields
+// and, for admins, adminSyncFields allow. Products whose price the edits
+// change record the new price at time at.
+func (s *dataStore) syncRecord(change SyncChange, admin bool, at time.Time) SyncResult {
+	result := SyncResult{Model: change.Model, ID: change.ID, Status: SyncRejected}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current string
+	var i int
+	var err error
+	switch change.Model {
+	case SchemaUser:
+		if i = slices.IndexFunc(s.users, func(user User) bool { return user.ID == change.ID }); i < 0 {
+			result.Error = errUserNotFound.Error()
+			return result
+		}
+		current, err = UserToJSON(s.users[i])
+	case SchemaProduct:
+		if i = productIndex(s.products, change.ID); i < 0 {
+			result.Error = errProductNotFound.Error()
+			return result
+		}
+		current, err = ProductToJSON(s.products[i])
+	case SchemaOrder:
+		result.Error = errCachedOrder.Error()
+		return result
+	default:
+		result.Error = fmt.Sprintf("unknown model %q (expected %s or %s)", change.Model, SchemaUser, SchemaProduct)
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Record = []byte(current)
+	if err := checkSyncPatches(change.Model, change.Patches, admin); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	patched, err := ApplyPatches(change.Model, current, change.Patches)
+	if errors.Is(err, ErrVersionConflict) {
+		result.Status, result.Error = SyncConflict, err.Error()
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if change.Model == SchemaUser {
+		user, err := UserFromJSON(patched)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		s.users[i] = user
+		s.analytics = nil
+	} else {
+		product, err := ProductFromJSON(patched)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if product.Price != s.products[i].Price {
+			s.prices[product.ID] = RecordPriceChange(s.prices[product.ID], product.Price, at)
+		}
+		s.products[i] = product
+	}
+	result.Status, result.Record, result.Applied = SyncApplied, []byte(patched), len(change.Patches)
+	return result
+}
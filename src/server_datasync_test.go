@@ -0,0 +1,145 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDataSyncEndpoint tests syncing a page's offline edits through its
+// cache
+func TestDataSyncEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin-secret" })
+	post := func(body string, admin bool) SyncResponse {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/data/sync", bytes.NewReader([]byte(body)))
+		if admin {
+			req.Header.Set("Authorization", "Bearer admin-secret")
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var response SyncResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return response
+	}
+	sync := func(cache *DataCache) SyncSummary {
+		t.Helper()
+		body, _ := json.Marshal(cache.Pending())
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/data/sync", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		var response SyncResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		summary, err := cache.ApplySync(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return summary
+	}
+
+	cache, _ := NewDataCache(nil)
+	users, _ := json.Marshal(demoStore.listUsers())
+	products, _ := json.Marshal(demoStore.listProducts())
+	cache.Store(SchemaUser, string(users))
+	cache.Store(SchemaProduct, string(products))
+
+	// Offline: rename a user and describe a product
+	if _, err := cache.Update(SchemaUser, 2, `{"version": 1, "name": "Jane Offline"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Update(SchemaProduct, 1, `{"version": 1, "description": "Described offline"}`); err != nil {
+		t.Fatal(err)
+	}
+	if summary := sync(cache); summary.Applied != 2 || len(summary.Conflicts) != 0 {
+		t.Fatalf("Expected both edits applied, got %+v", summary)
+	}
+	if user, _ := findUser(demoStore, 2); user.Name != "Jane Offline" || user.Version != 2 {
+		t.Errorf("Expected the stored user renamed at version 2, got %+v", user)
+	}
+	if product, _ := findProduct(demoStore.listProducts(), 1); product.Description != "Described offline" || product.Version != 2 {
+		t.Errorf("Expected the stored product described at version 2, got %+v", product)
+	}
+	if len(cache.Pending().Changes) != 0 {
+		t.Errorf("Expected nothing left to sync, got %+v", cache.Pending())
+	}
+
+	// The server changes the user while the page edits it offline
+	if _, err := demoStore.setUserPreferences(2, 2, Preferences{MarketingOptIn: true}); err != nil {
+		t.Fatal(err)
+	}
+	cache.Update(SchemaUser, 2, `{"version": 2, "age": 33}`)
+	summary := sync(cache)
+	if len(summary.Conflicts) != 1 || summary.Conflicts[0].ID != 2 {
+		t.Fatalf("Expected a conflict on user 2, got %+v", summary)
+	}
+	if user, _ := findUser(demoStore, 2); user.Age == 33 {
+		t.Error("Expected the conflicting edit not applied")
+	}
+	if _, err := cache.Resolve(SchemaUser, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	if summary := sync(cache); summary.Applied != 1 {
+		t.Fatalf("Expected the resolved edit applied, got %+v", summary)
+	}
+	if user, _ := findUser(demoStore, 2); user.Age != 33 || !UserPreferences(user).MarketingOptIn {
+		t.Errorf("Expected both the edit and the server's change kept, got %+v", user)
+	}
+
+	// Fields only their own endpoints change
+	before, _ := findProduct(demoStore.listProducts(), 1)
+	for _, change := range []string{
+		`{"model": "product", "id": 1, "patches": [{"version": 2, "price": 0.01}]}`,
+		`{"model": "product", "id": 1, "patches": [{"version": 2, "description": "x"}, {"version": 3, "on_hand": 99999}]}`,
+		`{"model": "product", "id": 1, "patches": [{"version": 2, "reserved": 0}]}`,
+		`{"model": "user", "id": 1, "patches": [{"version": 1, "anonymized": false}]}`,
+		`{"model": "user", "id": 1, "patches": [{"version": 1, "loyalty_points": 1000000}]}`,
+		`{"model": "user", "id": 1, "patches": [{"version": 1, "email_verified": true}]}`,
+	} {
+		response := post(`{"changes": [`+change+`]}`, false)
+		if len(response.Results) != 1 || response.Results[0].Status != SyncRejected || response.Results[0].Error == "" {
+			t.Errorf("Expected %s rejected, got %+v", change, response)
+		}
+	}
+	if after, _ := findProduct(demoStore.listProducts(), 1); after.Price != before.Price || after.OnHand != before.OnHand || after.Version != before.Version {
+		t.Errorf("Expected the product unchanged, got %+v, was %+v", after, before)
+	}
+
+	// With the admin token prices sync too
+	if response := post(`{"changes": [{"model": "product", "id": 1, "patches": [{"version": 2, "price": 899.99}]}]}`, true); response.Results[0].Status != SyncApplied {
+		t.Fatalf("Expected the admin's price change applied, got %+v", response)
+	}
+	if history, _ := demoStore.priceHistory(1); history[len(history)-1].Price != 899.99 {
+		t.Errorf("Expected the new price in the history, got %+v", history[len(history)-1])
+	}
+
+	// Edits the server refuses
+	response := post(`{"changes": [
+		{"model": "product", "id": 1, "patches": [{"version": 3, "name": ""}]},
+		{"model": "order", "id": 1, "patches": [{"version": 1, "status": "delivered"}]},
+		{"model": "user", "id": 99, "patches": [{"version": 1}]}]}`, false)
+	if len(response.Results) != 3 {
+		t.Fatalf("Expected a result per change, got %+v", response)
+	}
+	for _, result := range response.Results {
+		if result.Status != SyncRejected || result.Error == "" {
+			t.Errorf("Expected %s %d rejected, got %+v", result.Model, result.ID, result)
+		}
+	}
+	if stored, _ := findProduct(demoStore.listProducts(), 1); stored.Name != before.Name || response.Results[0].Record == nil {
+		t.Errorf("Expected the product unchanged and sent back, got %+v", stored)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/data/sync", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", w.Code)
+	}
+}
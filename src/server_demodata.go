@@ -0,0 +1,140 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// GENERATED DEMO DATA
+// GET /api/demo-data?users=10000&seed=42 generates a dataset with the shared
+// GenerateDemoData - the same records generateDemoDataWasm makes from the
+// same options - and returns it. POST takes the same parameters and loads
+// the dataset into the request's sandbox instead, replacing its data, so the
+// analytics, recommendation and search endpoints can be tried on thousands
+// of records without touching the shared store. A dataset has at most
+// -max-demo-records records.
+// ============================================================================
+
+// demoDataLoaded is the response to POST /api/demo-data.
+type demoDataLoaded struct {
+	Seed    uint64      `json:"seed"`
+	Sandbox sandboxInfo `json:"sandbox"`
+}
+
+// parseDemoWeights reads weights written as "US:3,DE:1". A key without a
+// weight counts 1.
+func parseDemoWeights(s string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, found := strings.Cut(part, ":")
+		weight := 1.0
+		if found {
+			var err error
+			if weight, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				return nil, fmt.Errorf("invalid weight %q for %s", value, key)
+			}
+		}
+		weights[strings.TrimSpace(key)] = weight
+	}
+	return weights, nil
+}
+
+// demoDataOptions reads the generator options from the query, reporting
+// the fields it can't use.
+func demoDataOptions(r *http.Request) (DemoDataOptions, map[string]string) {
+	query := r.URL.Query()
+	fields := map[string]string{}
+	var opts DemoDataOptions
+
+	for _, count := range []struct {
+		name  string
+		value *int
+	}{{"users", &opts.Users}, {"products", &opts.Products}, {"orders", &opts.Orders}} {
+		if raw := query.Get(count.name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 0 {
+				fields[count.name] = "must be a whole number, 0 for the default"
+				continue
+			}
+			*count.value = value
+		}
+	}
+	if raw := query.Get("seed"); raw != "" {
+		seed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			fields["seed"] = "must be a whole number"
+		}
+		opts.Seed = seed
+	}
+	for _, weights := range []struct {
+		name  string
+		value *map[string]float64
+	}{{"countries", &opts.Countries}, {"categories", &opts.Categories}} {
+		if raw := query.Get(weights.name); raw != "" {
+			value, err := parseDemoWeights(raw)
+			if err != nil {
+				fields[weights.name] = err.Error()
+				continue
+			}
+			*weights.value = value
+		}
+	}
+	if len(fields) > 0 {
+		return opts, fields
+	}
+
+	if err := opts.Validate(); err != nil {
+		fields["options"] = err.Error()
+	} else if resolved := opts.withDefaults(); resolved.Users+resolved.Products+resolved.Orders > serverConfig.MaxDemoRecords {
+		fields["options"] = fmt.Sprintf("%d users, %d products and %d orders exceed the limit of %d records (see -max-demo-records)",
+			resolved.Users, resolved.Products, resolved.Orders, serverConfig.MaxDemoRecords)
+	}
+	return opts, fields
+}
+
+// handleDemoData generates a dataset and returns it (GET) or loads it into
+// the request's sandbox (POST).
+func handleDemoData(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" && r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sb, inSandbox := r.Context().Value(sandboxContextKey{}).(*sandbox)
+	if r.Method == "POST" && !inSandbox {
+		writeError(w, http.StatusBadRequest, "Generated data is only loaded into a sandbox - see POST /api/sandboxes")
+		return
+	}
+	opts, fields := demoDataOptions(r)
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	data, err := GenerateDemoData(opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.Method == "GET" {
+		writeJSON(w, r, http.StatusOK, data)
+		return
+	}
+	sb.store.load(data, time.Now())
+	writeJSON(w, r, http.StatusOK, demoDataLoaded{Seed: data.Seed, Sandbox: sandboxes.info(sb)})
+}
@@ -0,0 +1,85 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDemoDataEndpoint(t *testing.T) {
+	withDemoStore(t)
+	withSandboxes(t, time.Hour, 10)
+	handler := newServerHandler()
+	serve := func(method, target, sandbox string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if sandbox != "" {
+			req.Header.Set(sandboxHeader, sandbox)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/api/demo-data?users=200&seed=42&countries=US:3,DE:1&categories=books,toys", "")
+	var data DemoData
+	if err := json.NewDecoder(w.Body).Decode(&data); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected a dataset, got %d: %v", w.Code, err)
+	}
+	want, _ := GenerateDemoData(DemoDataOptions{
+		Users: 200, Seed: 42,
+		Countries:  map[string]float64{"US": 3, "DE": 1},
+		Categories: map[string]float64{"books": 1, "toys": 1},
+	})
+	if len(data.Users) != 200 || len(data.Orders) != 600 || data.Seed != 42 || !reflect.DeepEqual(data.Users, want.Users) {
+		t.Errorf("Expected the GenerateDemoData dataset, got %d users and %d orders", len(data.Users), len(data.Orders))
+	}
+
+	// Loading replaces a sandbox's data and leaves the shared store alone
+	if w := serve("POST", "/api/demo-data?users=200", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected loading outside a sandbox to be refused, got %d", w.Code)
+	}
+	w = serve("POST", "/api/demo-data?users=300&products=40&seed=7", "big")
+	var loaded demoDataLoaded
+	if err := json.NewDecoder(w.Body).Decode(&loaded); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the data to load, got %d: %v", w.Code, err)
+	}
+	if loaded.Seed != 7 || loaded.Sandbox.ID != "big" || loaded.Sandbox.Users != 300 || loaded.Sandbox.Products != 40 || loaded.Sandbox.Orders != 900 {
+		t.Errorf("Unexpected load: %+v", loaded)
+	}
+	var users []User
+	json.NewDecoder(serve("GET", "/api/demo-users", "big").Body).Decode(&users)
+	if len(users) != 300 || users[0].Name != "John Doe" {
+		t.Errorf("Expected the sandbox to serve the generated users, got %d", len(users))
+	}
+	if n := len(demoStore.listUsers()); n != 5 {
+		t.Errorf("Expected the shared store untouched, got %d users", n)
+	}
+
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.MaxDemoRecords = 1000 })
+	for _, target := range []string{
+		"/api/demo-data?users=1000",
+		"/api/demo-data?users=ten",
+		"/api/demo-data?seed=-1",
+		"/api/demo-data?countries=US:many",
+		"/api/demo-data?categories=weapons",
+	} {
+		if w := serve("GET", target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestParseDemoWeights(t *testing.T) {
+	weights, err := parseDemoWeights(" US:3, DE:0.5,JP ,")
+	if err != nil || !reflect.DeepEqual(weights, map[string]float64{"US": 3, "DE": 0.5, "JP": 1}) {
+		t.Errorf("Unexpected weights %v (%v)", weights, err)
+	}
+	if _, err := parseDemoWeights("US:lots"); err == nil {
+		t.Error("Expected an unparsable weight to be rejected")
+	}
+}
@@ -0,0 +1,464 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// DEVELOPMENT MODE
+// With -dev the server watches the static files (HTML, JavaScript, CSS and
+// the WebAssembly modules) and tells open pages to reload when one changes,
+// so rebuilding main.wasm or editing main.js shows up without a manual
+// refresh. Pages are notified over a WebSocket at /ws/dev, which the script
+// /dev/reload.js opens; HTML pages are served with that script added.
+//
+// The static files are served with Cache-Control: no-store and without the
+// precompressed variants, so a reload always fetches what is on disk.
+//
+// The watcher polls file sizes and modification times rather than relying
+// on OS notifications, and waits for a quiet interval before notifying, so a
+// build writing several files causes one reload.
+// ============================================================================
+
+const (
+	devCacheControl       = "no-store"
+	devReloadScriptPath   = "/dev/reload.js"
+	devSocketPath         = "/ws/dev"
+	devWatchInterval      = 500 * time.Millisecond
+	devSocketPingInterval = 30 * time.Second
+	devSocketMaxPayload   = 4096
+)
+
+// devWatchedExtensions are the file types whose changes reload the pages.
+var devWatchedExtensions = map[string]bool{
+	".html": true,
+	".js":   true,
+	".css":  true,
+	".wasm": true,
+}
+
+// devReloadScript reconnects after the socket drops and reloads once it is
+// back, since that is usually the server restarting with new code.
+const devReloadScript = `// Development mode: reload when the server reports a changed file
+(() => {
+  const url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + "` + devSocketPath + `";
+  let disconnected = false;
+  function connect() {
+    const socket = new WebSocket(url);
+    socket.onopen = () => {
+      if (disconnected) {
+        location.reload();
+      }
+    };
+    socket.onmessage = (event) => {
+      const message = JSON.parse(event.data);
+      if (message.type === "reload") {
+        console.log("🔁 Reloading for", message.files.join(", "));
+        location.reload();
+      }
+    };
+    socket.onclose = () => {
+      disconnected = true;
+      setTimeout(connect, 1000);
+    };
+  }
+  connect();
+})();
+`
+
+// devReloadMessage is sent to the pages when files change.
+type devReloadMessage struct {
+	Type  string   `json:"type"`
+	Files []string `json:"files"`
+}
+
+// devReloadHub fans reloads out to connected pages.
+type devReloadHub struct {
+	mu          sync.Mutex
+	subscribers map[chan []string]struct{}
+	done        chan struct{} // closed by close
+	closeOnce   sync.Once
+}
+
+// devReloads is the active hub.
+var devReloads = newDevReloadHub()
+
+func newDevReloadHub() *devReloadHub {
+	return &devReloadHub{subscribers: map[chan []string]struct{}{}, done: make(chan struct{})}
+}
+
+// close ends every socket, current and future.
+func (h *devReloadHub) close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// subscribe registers a page for reloads.
+func (h *devReloadHub) subscribe() (chan []string, func()) {
+	reloads := make(chan []string, 1)
+	h.mu.Lock()
+	h.subscribers[reloads] = struct{}{}
+	h.mu.Unlock()
+	return reloads, func() {
+		h.mu.Lock()
+		delete(h.subscribers, reloads)
+		h.mu.Unlock()
+	}
+}
+
+// publish tells every page to reload for files. It never blocks: a page
+// with a reload still pending reloads once either way.
+func (h *devReloadHub) publish(files []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for reloads := range h.subscribers {
+		select {
+		case reloads <- files:
+		default:
+		}
+	}
+}
+
+// registerDevRoutes mounts the reload socket and script in development mode.
+func registerDevRoutes(mux *http.ServeMux) {
+	if !serverConfig.DevMode {
+		return
+	}
+
+	mux.HandleFunc(devSocketPath, handleDevSocket)
+	mux.HandleFunc(devReloadScriptPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Header().Set("Cache-Control", devCacheControl)
+		io.WriteString(w, devReloadScript)
+	})
+
+	fmt.Println("🔁 Development mode: open pages reload when static files change")
+}
+
+// injectDevReloadScript adds the reload script to an HTML page, at the end
+// of its body.
+func injectDevReloadScript(html []byte) []byte {
+	script := []byte(`<script src="` + devReloadScriptPath + `"></script>`)
+	for _, tag := range []string{"</body>", "</html>"} {
+		if i := bytes.LastIndex(html, []byte(tag)); i >= 0 {
+			return slices.Concat(html[:i], script, []byte("\n"), html[i:])
+		}
+	}
+	return slices.Concat(html, []byte("\n"), script)
+}
+
+// fileStamp is what the watcher compares to notice a change.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// scanStaticFiles stamps the watched files under dir, keyed by URL path.
+// Hidden directories and node_modules are skipped.
+func scanStaticFiles(dir string) map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if name != dir && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !devWatchedExtensions[filepath.Ext(name)] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return nil
+		}
+		stamps["/"+filepath.ToSlash(rel)] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return stamps
+}
+
+// changedStaticFiles lists, sorted, the files added, changed or removed
+// between two scans.
+func changedStaticFiles(previous, current map[string]fileStamp) []string {
+	var changed []string
+	for name, stamp := range current {
+		if old, ok := previous[name]; !ok || old.size != stamp.size || !old.modTime.Equal(stamp.modTime) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// watchStaticFiles scans dir every interval until ctx is done, and calls
+// publish with the changed files once a scan finds no further changes.
+func watchStaticFiles(ctx context.Context, dir string, interval time.Duration, publish func(files []string)) {
+	previous := scanStaticFiles(dir)
+	var pending []string
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := scanStaticFiles(dir)
+		if changed := changedStaticFiles(previous, current); len(changed) > 0 {
+			pending = slices.Compact(slices.Sorted(slices.Values(append(pending, changed...))))
+		} else if len(pending) > 0 {
+			log.Printf("🔁 Static files changed: %s", strings.Join(pending, ", "))
+			publish(pending)
+			pending = nil
+		}
+		previous = current
+	}
+}
+
+// handleDevSocket upgrades to a WebSocket and sends the page a message for
+// each reload, until either side closes it or the server shuts down.
+func handleDevSocket(w http.ResponseWriter, r *http.Request) {
+	reloads, unsubscribe := devReloads.subscribe()
+	defer unsubscribe()
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.serveControlFrames()
+	}()
+
+	ping := time.NewTicker(devSocketPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case files := <-reloads:
+			message, _ := json.Marshal(devReloadMessage{Type: "reload", Files: files})
+			if ws.writeFrame(wsOpText, message) != nil {
+				return
+			}
+		case <-ping.C:
+			if ws.writeFrame(wsOpPing, nil) != nil {
+				return
+			}
+		case <-devReloads.done:
+			ws.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, wsCloseGoingAway))
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// WebSocket (RFC 6455), as much as the reload socket needs: the handshake,
+// unfragmented server-to-client text frames, and the control frames.
+// ----------------------------------------------------------------------------
+
+const (
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+
+	wsCloseGoingAway = 1001
+)
+
+// webSocket is an upgraded connection. Writes are serialized so control
+// replies don't interleave with messages.
+type webSocket struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+	writer  *bufio.Writer
+}
+
+// webSocketAccept computes the Sec-WebSocket-Accept value for a key.
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether a comma-separated header contains token,
+// ignoring case.
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket completes the opening handshake and takes over the
+// connection. On failure it has already answered the request.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*webSocket, error) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return nil, errors.New("method not allowed")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") || key == "" {
+		writeError(w, http.StatusBadRequest, "Expected a WebSocket upgrade request")
+		return nil, errors.New("not a websocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeError(w, http.StatusUpgradeRequired, "Unsupported WebSocket version")
+		return nil, errors.New("unsupported websocket version")
+	}
+
+	// HTTP/2 connections can't be hijacked; browsers open WebSockets over
+	// HTTP/1.1 unless the server offers them over HTTP/2
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "WebSocket upgrade not supported on this connection")
+		return nil, err
+	}
+
+	// The server's read and write timeouts would end the socket otherwise
+	conn.SetDeadline(time.Time{})
+
+	ws := &webSocket{conn: conn, reader: rw.Reader, writer: rw.Writer}
+	fmt.Fprintf(ws.writer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", webSocketAccept(key))
+	if err := ws.writer.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// writeFrame writes an unmasked, unfragmented frame.
+func (ws *webSocket) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	ws.writer.Write(header)
+	ws.writer.Write(payload)
+	return ws.writer.Flush()
+}
+
+// readFrame reads one frame, unmasking it. Payloads over
+// devSocketMaxPayload are refused: pages only send control frames.
+func (ws *webSocket) readFrame() (opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > devSocketMaxPayload {
+		return 0, nil, fmt.Errorf("websocket frame of %d bytes exceeds %d", length, devSocketMaxPayload)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// serveControlFrames reads what the page sends, answering pings and the
+// closing handshake, and returns once the socket is closed or broken.
+func (ws *webSocket) serveControlFrames() {
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("Development socket closed: %v", err)
+			}
+			return
+		}
+		switch opcode {
+		case wsOpPing:
+			if ws.writeFrame(wsOpPong, payload) != nil {
+				return
+			}
+		case wsOpClose:
+			// Echo the status code, as the closing handshake asks
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			ws.writeFrame(wsOpClose, payload)
+			return
+		}
+	}
+}
@@ -0,0 +1,204 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialDevSocket opens the reload socket of a test server, returning the
+// connection as a webSocket to read the server's frames with.
+func dialDevSocket(t *testing.T, server *httptest.Server) *webSocket {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	request := "GET " + devSocketPath + " HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The accept value for the RFC 6455 sample key
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected 101 with the accept key, got %d %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return &webSocket{conn: conn, reader: reader, writer: bufio.NewWriter(conn)}
+}
+
+// writeMaskedFrame writes a frame as a browser would, masked.
+func writeMaskedFrame(t *testing.T, ws *webSocket, opcode byte, payload []byte) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := append([]byte{0x80 | opcode, 0x80 | byte(len(payload))}, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := ws.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDevSocket(t *testing.T) {
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.DevMode = true })
+	previous := devReloads
+	devReloads = newDevReloadHub()
+	t.Cleanup(func() { devReloads = previous })
+
+	server := httptest.NewServer(newServerMux())
+	defer server.Close()
+	ws := dialDevSocket(t, server)
+
+	// A reload reaches the page as a text message
+	devReloads.publish([]string{"/main.js", "/main.wasm"})
+	opcode, payload, err := ws.readFrame()
+	if err != nil || opcode != wsOpText {
+		t.Fatalf("Expected a text frame, got opcode %d: %v", opcode, err)
+	}
+	var message devReloadMessage
+	if err := json.Unmarshal(payload, &message); err != nil || message.Type != "reload" || !slices.Equal(message.Files, []string{"/main.js", "/main.wasm"}) {
+		t.Errorf("message = %s", payload)
+	}
+
+	// Pings are answered, and the closing handshake echoes the status
+	writeMaskedFrame(t, ws, wsOpPing, []byte("hi"))
+	if opcode, payload, err := ws.readFrame(); err != nil || opcode != wsOpPong || string(payload) != "hi" {
+		t.Errorf("Expected a pong with the ping's payload, got opcode %d %q: %v", opcode, payload, err)
+	}
+	writeMaskedFrame(t, ws, wsOpClose, binary.BigEndian.AppendUint16(nil, 1000))
+	if opcode, payload, err := ws.readFrame(); err != nil || opcode != wsOpClose || binary.BigEndian.Uint16(payload) != 1000 {
+		t.Errorf("Expected the close echoed, got opcode %d %v: %v", opcode, payload, err)
+	}
+
+	// Shutting down closes open sockets as going away
+	ws = dialDevSocket(t, server)
+	devReloads.close()
+	if opcode, payload, err := ws.readFrame(); err != nil || opcode != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseGoingAway {
+		t.Errorf("Expected a going-away close, got opcode %d %v: %v", opcode, payload, err)
+	}
+
+	// Plain requests aren't upgraded
+	resp, err := http.Get(server.URL + devSocketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a plain GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestDevRoutesOnlyInDevMode(t *testing.T) {
+	withStaticRoot(t, map[string]string{"index.html": "<html><body></body></html>"})
+
+	for _, dev := range []bool{false, true} {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.DevMode = dev })
+		w := httptest.NewRecorder()
+		newServerMux().ServeHTTP(w, httptest.NewRequest("GET", devReloadScriptPath, nil))
+		if found := w.Code == http.StatusOK && strings.Contains(w.Body.String(), devSocketPath); found != dev {
+			t.Errorf("dev mode %v: reload script served = %v (status %d)", dev, found, w.Code)
+		}
+	}
+}
+
+func TestDevModeStaticFiles(t *testing.T) {
+	withStaticRoot(t, map[string]string{
+		"index.html":   `<html><head></head><body><script src="main.js"></script></body></html>`,
+		"main.js":      "// app",
+		"main.wasm":    "\x00asm\x01\x00\x00\x00",
+		"main.wasm.gz": "stale",
+	})
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.DevMode = true })
+
+	w := httptest.NewRecorder()
+	serveStaticFile(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, `<script src="`+devReloadScriptPath+`"></script>`+"\n</body>") {
+		t.Errorf("Expected the reload script before </body>, got %s", body)
+	}
+	if w.Header().Get("Cache-Control") != devCacheControl || w.Header().Get("ETag") != "" {
+		t.Errorf("index.html headers = %v", w.Header())
+	}
+
+	for _, name := range []string{"/main.js", "/main.wasm"} {
+		req := httptest.NewRequest("GET", name, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		serveStaticFile(w, req)
+		if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != devCacheControl || w.Header().Get("ETag") != "" || w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: status %d, headers %v", name, w.Code, w.Header())
+		}
+	}
+
+	if got := string(injectDevReloadScript([]byte("<p>fragment</p>"))); got != "<p>fragment</p>\n"+`<script src="`+devReloadScriptPath+`"></script>` {
+		t.Errorf("Expected the script appended to a page without a body, got %q", got)
+	}
+}
+
+func TestWatchStaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":          "<html></html>",
+		"main.js":             "// app",
+		"assets/css/app.css":  "body {}",
+		"notes.txt":           "not watched",
+		".git/hooks/x.js":     "hidden",
+		"node_modules/dep.js": "dependency",
+	} {
+		full := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(scanStaticFiles(dir)))
+	if want := []string{"/assets/css/app.css", "/index.html", "/main.js"}; !slices.Equal(names, want) {
+		t.Errorf("watched files = %v, want %v", names, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	published := make(chan []string, 4)
+	go watchStaticFiles(ctx, dir, 10*time.Millisecond, func(files []string) { published <- files })
+	time.Sleep(30 * time.Millisecond)
+
+	// Changes within the quiet interval are reported together
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "main.js"), later, later)
+	os.Remove(filepath.Join(dir, "index.html"))
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("still not watched"), 0o644)
+	select {
+	case files := <-published:
+		if want := []string{"/index.html", "/main.js"}; !slices.Equal(files, want) {
+			t.Errorf("changed files = %v, want %v", files, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the change to be published")
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !wasm
+
+package main
+
+import "fmt"
+
+// ============================================================================
+// DISCOUNTS
+// calculate-order and checkout take an order's coupon_code and
+// loyalty_points and price them with the stacking rules of
+// shared_discounts.go. Codes that are unknown or expired, and more points
+// than the user holds, are rejected as field errors; placing the order takes
+// the points it redeemed off the user.
+// ============================================================================
+
+// discountFieldErrors records problems with the coupon and loyalty points an
+// order asks for under fields prefixed with prefix.
+func discountFieldErrors(fields map[string]string, prefix string, order Order, user User) {
+	if order.CouponCode != "" {
+		if err := CheckCoupon(order.CouponCode, orderPlacedDate(order)); err != nil {
+			fields[prefix+"coupon_code"] = err.Error()
+		}
+	}
+	switch {
+	case order.LoyaltyPoints < 0:
+		fields[prefix+"loyalty_points"] = "must not be negative"
+	case order.LoyaltyPoints > user.LoyaltyPoints:
+		fields[prefix+"loyalty_points"] = fmt.Sprintf("only %d points available", user.LoyaltyPoints)
+	}
+}
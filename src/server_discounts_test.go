@@ -0,0 +1,75 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCalculateOrderDiscounts tests coupon and loyalty field errors and the
+// itemized discounts of calculate-order
+func TestCalculateOrderDiscounts(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	calculate := func(order string) *httptest.ResponseRecorder {
+		body := `{"order": {"products": [{"id": 1, "price": 99.99, "category": "electronics"}], "quantities": [1], "order_date": "2026-10-14", ` + order + `},
+			"user": {"country": "US", "loyalty_points": 1000}}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/calculate-order", strings.NewReader(body)))
+		return w
+	}
+
+	w := calculate(`"coupon_code": "SPRING2024", "loyalty_points": 5000`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if fields := decodeErrorResponse(t, w).Fields; fields["order.coupon_code"] != ErrCouponExpired.Error() || fields["order.loyalty_points"] == "" {
+		t.Errorf("Expected coupon and points field errors, got %v", fields)
+	}
+
+	w = calculate(`"coupon_code": "save5", "loyalty_points": 1000`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response orderTotalsResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Discount != 15 || len(response.Discounts) != 2 || response.Discounts[1].Points != 1000 {
+		t.Errorf("Expected 5 off by coupon and 10 by points, got %v %+v", response.Discount, response.Discounts)
+	}
+}
+
+// TestCheckoutRedeemsLoyaltyPoints tests that checkout takes redeemed points
+// off the user and cancelling gives them back
+func TestCheckoutRedeemsLoyaltyPoints(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+	points := func() int {
+		user, _ := findUser(demoStore, 2)
+		return user.LoyaltyPoints
+	}
+
+	client.do("POST", "/api/cart/items?user_id=2", `{"product_id": 1}`)
+	if w := client.do("POST", "/api/cart/checkout?user_id=2", `{"loyalty_points": 99999}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 redeeming more points than held, got %d", w.Code)
+	}
+	w := client.do("POST", "/api/cart/checkout?user_id=2", `{"loyalty_points": 500}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if LoyaltyPointsRedeemed(order) != 500 || points() != 1000 {
+		t.Errorf("Expected 500 points redeemed from 1500, got %+v with %d left", order.Discounts, points())
+	}
+
+	client.do("PUT", "/api/orders/"+strconv.Itoa(order.ID)+"/status", `{"status": "cancelled", "version": 1}`)
+	if points() != 1500 {
+		t.Errorf("Expected cancelling to give the points back, got %d", points())
+	}
+}
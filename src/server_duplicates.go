@@ -0,0 +1,42 @@
+//go:build !wasm
+
+package main
+
+import "net/http"
+
+// ============================================================================
+// DUPLICATE USERS
+// Likely duplicate accounts among the store's users (shared_duplicates.go),
+// for administrators to merge or remove:
+//
+//   GET /api/users/duplicates   pairs of users, most likely first
+//                               (admin token)
+//
+// findDuplicateUsersWasm runs the same check on a bulk import in the browser.
+// ============================================================================
+
+// duplicateUsersResponse lists the likely duplicates with the users they
+// refer to; First and Second index Users.
+type duplicateUsersResponse struct {
+	Users      []User           `json:"users"`
+	Duplicates []DuplicateUsers `json:"duplicates"`
+}
+
+// handleDuplicateUsers lists likely duplicate users.
+func handleDuplicateUsers(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	users := storeFor(r).listUsers()
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, duplicateUsersResponse{Users: users, Duplicates: FindDuplicateUsers(users)})
+}
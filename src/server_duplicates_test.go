@@ -0,0 +1,37 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDuplicateUsersEndpoint tests listing the store's likely duplicates
+func TestDuplicateUsersEndpoint(t *testing.T) {
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	demoStore.insertUsers([]User{{Email: "John.Doe+promo@example.com", Name: "Johnny Doe", Age: 28, Country: "US"}}, true)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/duplicates", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/users/duplicates", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var response duplicateUsersResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || len(response.Duplicates) != 1 {
+		t.Fatalf("Expected one duplicate pair, got %d: %s", w.Code, w.Body.String())
+	}
+	if pair := response.Duplicates[0]; response.Users[pair.First].ID != 1 || response.Users[pair.Second].Name != "Johnny Doe" {
+		t.Errorf("Expected the new user to duplicate John Doe, got %+v", pair)
+	}
+}
@@ -0,0 +1,211 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ============================================================================
+// ERROR RESPONSES & REQUEST VALIDATION
+// Every endpoint reports failures with the same body and a matching status:
+//
+//   {"error": {"code": "validation_failed", "message": "Request validation failed",
+//              "fields": {"status": "is required"}}}
+//
+// code is machine-readable and stable (derived from the status unless a more
+// specific one applies); fields is only present when particular request
+// fields are at fault. GraphQL results are the exception: they follow the
+// GraphQL response format.
+//
+// decodeJSONBody is the one way handlers read JSON bodies, so every endpoint
+// gets the same size limit, malformed-JSON and type errors, and the
+// `validate:"required"` checks declared on the request types.
+// ============================================================================
+
+// Error codes that don't follow from the status alone
+const (
+	codeInvalidJSON      = "invalid_json"
+	codeInvalidBody      = "invalid_body" // malformed MessagePack or XML
+	codeValidationFailed = "validation_failed"
+	codeVersionConflict  = "version_conflict"
+	codeVersionRequired  = "version_required"
+)
+
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// apiError is the error object of every non-GraphQL error response.
+type apiError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	// CurrentVersion is the record's version when an update made against
+	// another is refused
+	CurrentVersion int `json:"current_version,omitempty"`
+}
+
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// writeError responds with the code for status and a message.
+func writeError(w http.ResponseWriter, status int, message string) {
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	writeAPIError(w, status, apiError{Code: code, Message: message})
+}
+
+// writeFieldErrors rejects a request because of the given fields.
+func writeFieldErrors(w http.ResponseWriter, fields map[string]string) {
+	writeAPIError(w, http.StatusBadRequest, apiError{Code: codeValidationFailed, Message: "Request validation failed", Fields: fields})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr apiError) {
+	header := w.Header()
+	// Drop headers meant for the successful response
+	header.Del("Content-Disposition")
+	header.Del("ETag")
+	header.Del("Last-Modified")
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: apiErr})
+}
+
+// decodeJSONBody decodes a JSON request body into dst (a pointer to a
+// struct or slice), responding with an error and returning false when the
+// body is too large, malformed, mistyped or missing required fields.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, jsonCodec, dst)
+}
+
+// decodeBody is decodeJSONBody for a body in any codec's encoding.
+func decodeBody(w http.ResponseWriter, r *http.Request, codec payloadCodec, dst interface{}) bool {
+	buf := getBodyBuffer()
+	defer putBodyBuffer(buf)
+	if !readBodyInto(w, r, serverConfig.MaxBodyBytes, buf) {
+		return false
+	}
+	body := buf.Bytes()
+	invalid := apiError{Code: codeInvalidJSON}
+	if codec.name != jsonCodec.name {
+		invalid.Code = codeInvalidBody
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		invalid.Message = "Request body is empty"
+		writeAPIError(w, http.StatusBadRequest, invalid)
+		return false
+	}
+
+	err := codec.unmarshal(body, dst)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		writeFieldErrors(w, map[string]string{typeErr.Field: "must be " + jsonTypeName(typeErr.Type)})
+		return false
+	case errors.As(err, &typeErr):
+		invalid.Message = "Request body must be " + jsonTypeName(typeErr.Type)
+		writeAPIError(w, http.StatusBadRequest, invalid)
+		return false
+	case err != nil:
+		invalid.Message = "Invalid " + codec.name + ": " + err.Error()
+		writeAPIError(w, http.StatusBadRequest, invalid)
+		return false
+	}
+
+	if fields := missingRequiredFields(dst); len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return false
+	}
+	return true
+}
+
+// readBody reads a raw request body of at most limit bytes.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	var buf bytes.Buffer
+	if !readBodyInto(w, r, limit, &buf) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// readBodyInto is readBody reading into buf.
+func readBodyInto(w http.ResponseWriter, r *http.Request, limit int64, buf *bytes.Buffer) bool {
+	if r.ContentLength > limit {
+		writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return false
+	}
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return false
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return false
+	}
+	return true
+}
+
+// missingRequiredFields lists the fields of a decoded struct that are tagged
+// `validate:"required"` but were left empty.
+func missingRequiredFields(dst interface{}) map[string]string {
+	v := reflect.Indirect(reflect.ValueOf(dst))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := map[string]string{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("validate") == "required" && v.Field(i).IsZero() {
+			fields[jsonFieldName(field)] = "is required"
+		}
+	}
+	return fields
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// jsonTypeName describes the JSON value expected for a Go type.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "a " + t.String()
+}
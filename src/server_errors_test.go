@@ -0,0 +1,85 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) apiError {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON error body, got Content-Type %q", ct)
+	}
+	var resp errorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Invalid error body: %v", err)
+	}
+	return resp.Error
+}
+
+// TestErrorResponses tests the shared error schema across handlers
+func TestErrorResponses(t *testing.T) {
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.MaxBodyBytes = 64 })
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{"MethodNotAllowed", "GET", "/api/validate-user", "", 405, "method_not_allowed", nil},
+		{"MalformedJSON", "POST", "/api/validate-product", "{", 400, codeInvalidJSON, nil},
+		{"EmptyBody", "POST", "/api/recommend-products", "", 400, codeInvalidJSON, nil},
+		{"WrongType", "POST", "/api/validate-user", `{"age": "old"}`, 400, codeValidationFailed, []string{"age"}},
+		{"NestedWrongType", "POST", "/api/calculate-order", `{"user": {"premium": 1}}`, 400, codeValidationFailed, []string{"user.premium"}},
+		{"RequiredField", "PUT", "/api/orders/1/status", `{}`, 400, codeValidationFailed, []string{"status"}},
+		{"BusinessRules", "POST", "/api/calculate-order", `{"order": {}, "user": {}}`, 400, codeValidationFailed, []string{"order.products", "user.country"}},
+		{"TooLarge", "POST", "/api/analyze-behavior", `{"users": [` + strings.Repeat(`{}, `, 40) + `{}]}`, 413, "payload_too_large", nil},
+		{"NotFound", "GET", "/api/sandboxes/missing", "", 404, "not_found", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newServerMux().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			apiErr := decodeErrorResponse(t, w)
+			if apiErr.Code != tt.code || apiErr.Message == "" {
+				t.Errorf("Expected code %q with a message, got %+v", tt.code, apiErr)
+			}
+			if len(apiErr.Fields) != len(tt.fields) {
+				t.Errorf("Expected fields %v, got %v", tt.fields, apiErr.Fields)
+			}
+			for _, field := range tt.fields {
+				if apiErr.Fields[field] == "" {
+					t.Errorf("Expected an error for %q, got %v", field, apiErr.Fields)
+				}
+			}
+		})
+	}
+}
+
+// TestErrorResponseDropsSuccessHeaders tests that errors raised after
+// download headers were set aren't served as attachments
+func TestErrorResponseDropsSuccessHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+	writeError(w, http.StatusInternalServerError, "Failed to generate CSV")
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Error("Expected Content-Disposition to be removed")
+	}
+	if apiErr := decodeErrorResponse(t, w); apiErr.Code != "internal_error" {
+		t.Errorf("Expected internal_error, got %q", apiErr.Code)
+	}
+}
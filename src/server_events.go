@@ -0,0 +1,122 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// BEHAVIOR EVENTS AND FUNNEL
+// POST /api/events records what shoppers did - {"events": [...]}, each with
+// a type, a user or session ID and a time (now when left out) - and GET
+// lists the stored events. GET /api/analytics/funnel follows the stored
+// events through the purchase funnel with ComputeFunnel:
+//
+//   ?from=2023-05-01   only events on or after this date
+//   ?to=2023-05-31     only events on or before this date
+//
+// Each store starts with the demo users' events.
+// ============================================================================
+
+// eventsRequest is the body of POST /api/events.
+type eventsRequest struct {
+	Events []BehaviorEvent `json:"events"`
+}
+
+// eventsResponse is the body of GET and POST /api/events.
+type eventsResponse struct {
+	Recorded int             `json:"recorded,omitempty"`
+	Events   []BehaviorEvent `json:"events"`
+}
+
+// funnelResponse is the body of GET /api/analytics/funnel.
+type funnelResponse struct {
+	From   string       `json:"from,omitempty"`
+	To     string       `json:"to,omitempty"`
+	Events int          `json:"events"`
+	Steps  []FunnelStep `json:"steps"`
+}
+
+// handleEvents lists or records behavior events.
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	store := storeFor(r)
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, eventsResponse{Events: store.listEvents()})
+	case "POST":
+		var req eventsRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if len(req.Events) == 0 || len(req.Events) > maxEventsPerRequest {
+			writeFieldErrors(w, map[string]string{"events": fmt.Sprintf("must list from 1 to %d events", maxEventsPerRequest)})
+			return
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		fields := map[string]string{}
+		for i := range req.Events {
+			if req.Events[i].Time == "" {
+				req.Events[i].Time = now
+			}
+			for _, fieldErr := range ValidateBehaviorEvent(req.Events[i]).FieldErrors {
+				fields[fmt.Sprintf("events[%d].%s", i, fieldErr.Field)] = fieldErr.Message
+			}
+		}
+		if len(fields) > 0 {
+			writeFieldErrors(w, fields)
+			return
+		}
+		store.recordEvents(req.Events)
+		writeJSON(w, r, http.StatusCreated, eventsResponse{Recorded: len(req.Events), Events: req.Events})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleFunnel serves the purchase funnel of the stored events.
+func handleFunnel(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	response := funnelResponse{From: r.URL.Query().Get("from"), To: r.URL.Query().Get("to")}
+	fields := map[string]string{}
+	for name, value := range map[string]string{"from": response.From, "to": response.To} {
+		if _, err := time.Parse(taxDateLayout, value); value != "" && err != nil {
+			fields[name] = "must be a date as YYYY-MM-DD"
+		}
+	}
+	if len(fields) == 0 && response.From != "" && response.To != "" && response.To < response.From {
+		fields["to"] = "must not be before from"
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	var events []BehaviorEvent
+	for _, event := range storeFor(r).listEvents() {
+		day := event.Time[:min(len(event.Time), len(taxDateLayout))]
+		if (response.From == "" || day >= response.From) && (response.To == "" || day <= response.To) {
+			events = append(events, event)
+		}
+	}
+	response.Events = len(events)
+	response.Steps = ComputeFunnel(events)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
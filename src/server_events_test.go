@@ -0,0 +1,137 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFunnelEndpoint tests the demo funnel and a date range
+func TestFunnelEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/funnel", nil))
+	var funnel funnelResponse
+	json.NewDecoder(w.Body).Decode(&funnel)
+	if w.Code != http.StatusOK || len(funnel.Steps) != 4 {
+		t.Fatalf("Expected four funnel steps, got %d %+v", w.Code, funnel)
+	}
+	for i, want := range []int{5, 4, 3, 2} {
+		if funnel.Steps[i].Shoppers != want {
+			t.Errorf("Step %s: expected %d shoppers, got %d", funnel.Steps[i].Step, want, funnel.Steps[i].Shoppers)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/funnel?from=2023-05-02&to=2023-05-03", nil))
+	json.NewDecoder(w.Body).Decode(&funnel)
+	if funnel.Steps[0].Shoppers != 1 || funnel.Steps[3].Shoppers != 1 {
+		t.Errorf("Expected only user 2 in range, got %+v", funnel.Steps)
+	}
+
+	for _, target := range []string{"/api/analytics/funnel?from=May", "/api/analytics/funnel?from=2023-06-01&to=2023-05-01"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
+
+// TestRecordEvents tests posting events and their effect on the funnel
+func TestRecordEvents(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	body := `{"events": [{"type": "viewed", "session_id": "s1", "product_id": 2}, {"type": "added_to_cart", "session_id": "s1", "product_id": 2}]}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/events", strings.NewReader(body)))
+	var recorded eventsResponse
+	json.NewDecoder(w.Body).Decode(&recorded)
+	if w.Code != http.StatusCreated || recorded.Recorded != 2 || recorded.Events[0].Time == "" {
+		t.Fatalf("Expected two events recorded with a time, got %d %+v", w.Code, recorded)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/funnel", nil))
+	var funnel funnelResponse
+	json.NewDecoder(w.Body).Decode(&funnel)
+	if funnel.Steps[0].Shoppers != 6 || funnel.Steps[1].Shoppers != 5 {
+		t.Errorf("Expected the session in the funnel, got %+v", funnel.Steps)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/events", strings.NewReader(`{"events": [{"type": "viewed", "user_id": 1}, {"type": "hovered"}]}`)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "events[1].type") || !strings.Contains(w.Body.String(), "events[1].user_id") {
+		t.Errorf("Expected indexed field errors, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/events", strings.NewReader(`{"events": []}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty batch to be rejected, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/events", nil))
+	var listed eventsResponse
+	json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed.Events) != len(generateDemoEvents())+2 {
+		t.Errorf("Expected the demo events and the two recorded, got %d", len(listed.Events))
+	}
+}
+
+// TestEventBufferFlushAccepted tests that a flushed batch is accepted as is
+func TestEventBufferFlushAccepted(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	buffer := &EventBuffer{}
+	buffer.Track(BehaviorEvent{Type: EventClicked, SessionID: "s1"}, time.Now())
+	buffer.Track(BehaviorEvent{Type: EventViewed, SessionID: "s1", ProductID: 3}, time.Now())
+
+	body, _ := json.Marshal(eventsRequest{Events: buffer.Flush()})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/events", strings.NewReader(string(body))))
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected the batch recorded, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestSessionsEndpoint tests sessionizing the demo events and the parameters
+func TestSessionsEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/sessions", nil))
+	var all sessionsResponse
+	json.NewDecoder(w.Body).Decode(&all)
+	// Each demo user browses once
+	if w.Code != http.StatusOK || all.GapMinutes != 30 || len(all.Sessions) != 5 || all.Funnel[3].Shoppers != 2 {
+		t.Fatalf("Expected five demo sessions, got %d %+v", w.Code, all)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/sessions?user_id=1&gap_minutes=1", nil))
+	var one sessionsResponse
+	json.NewDecoder(w.Body).Decode(&one)
+	// The demo events are five minutes apart
+	if w.Code != http.StatusOK || len(one.Sessions) < 2 || one.Sessions[0].UserID != 1 {
+		t.Errorf("Expected user 1's events split at a minute, got %d %+v", w.Code, one)
+	}
+
+	for _, target := range []string{"/api/analytics/sessions?gap_minutes=0", "/api/analytics/sessions?gap_minutes=1441", "/api/analytics/sessions?user_id=x"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ============================================================================
+// EXPERIMENTS
+// POST /api/experiments/assign puts users in an experiment's variants with
+// AssignVariant; POST /api/experiments/analyze compares the variants of the
+// stored users with AnalyzeExperiment. Both take the experiment in the body,
+// so nothing is kept between requests and the page, assigning with
+// assignVariantWasm, agrees with the server.
+// ============================================================================
+
+// maxAssignUsers is the most users one assign request may name.
+const maxAssignUsers = 1000
+
+// assignRequest is the body of POST /api/experiments/assign.
+type assignRequest struct {
+	Experiment Experiment `json:"experiment"`
+	UserIDs    []int      `json:"user_ids"`
+}
+
+// VariantAssignment is the variant a user is in.
+type VariantAssignment struct {
+	UserID  int    `json:"user_id"`
+	Variant string `json:"variant"`
+}
+
+// assignResponse is the body of a POST /api/experiments/assign reply.
+type assignResponse struct {
+	Experiment  string              `json:"experiment"`
+	Assignments []VariantAssignment `json:"assignments"`
+}
+
+// analyzeExperimentRequest is the body of POST /api/experiments/analyze.
+type analyzeExperimentRequest struct {
+	Experiment Experiment `json:"experiment"`
+}
+
+// experimentFieldErrors adds an invalid experiment's problems to fields,
+// under "experiment.".
+func experimentFieldErrors(experiment Experiment, fields map[string]string) {
+	for _, fieldErr := range ValidateExperiment(experiment).FieldErrors {
+		fields["experiment."+fieldErr.Field] = fieldErr.Message
+	}
+}
+
+// handleAssignVariants assigns users to an experiment's variants.
+func handleAssignVariants(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var request assignRequest
+	if !decodeJSONBody(w, r, &request) {
+		return
+	}
+	fields := map[string]string{}
+	experimentFieldErrors(request.Experiment, fields)
+	if len(request.UserIDs) == 0 || len(request.UserIDs) > maxAssignUsers {
+		fields["user_ids"] = fmt.Sprintf("must list 1 to %d user IDs", maxAssignUsers)
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	// Use shared business logic - identical to WebAssembly version
+	response := assignResponse{Experiment: request.Experiment.Key, Assignments: make([]VariantAssignment, len(request.UserIDs))}
+	for i, id := range request.UserIDs {
+		response.Assignments[i] = VariantAssignment{UserID: id, Variant: AssignVariant(request.Experiment, id)}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// handleAnalyzeExperiment compares an experiment's variants over the stored
+// users and orders.
+func handleAnalyzeExperiment(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var request analyzeExperimentRequest
+	if !decodeJSONBody(w, r, &request) {
+		return
+	}
+	fields := map[string]string{}
+	experimentFieldErrors(request.Experiment, fields)
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	store := storeFor(r)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, AnalyzeExperiment(request.Experiment, store.listUsers(), store.listOrders()))
+}
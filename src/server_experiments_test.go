@@ -0,0 +1,58 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestExperimentEndpoints tests assigning and analyzing over the stored
+// users, and rejecting invalid experiments
+func TestExperimentEndpoints(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	experiment := `{"key": "banner", "variants": [{"name": "control"}, {"name": "banner"}]}`
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/experiments/assign", strings.NewReader(`{"experiment": `+experiment+`, "user_ids": [1, 2, 3]}`)))
+	var assigned assignResponse
+	json.NewDecoder(w.Body).Decode(&assigned)
+	if w.Code != http.StatusOK || assigned.Experiment != "banner" || len(assigned.Assignments) != 3 {
+		t.Fatalf("Expected three assignments, got %d %+v", w.Code, assigned)
+	}
+	var parsed Experiment
+	json.Unmarshal([]byte(experiment), &parsed)
+	for _, assignment := range assigned.Assignments {
+		if want := AssignVariant(parsed, assignment.UserID); assignment.Variant != want {
+			t.Errorf("User %d assigned %q, want %q", assignment.UserID, assignment.Variant, want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/experiments/analyze", strings.NewReader(`{"experiment": `+experiment+`}`)))
+	var analysis ExperimentResult
+	json.NewDecoder(w.Body).Decode(&analysis)
+	if w.Code != http.StatusOK || analysis.Control != "control" || analysis.Variants[0].Users+analysis.Variants[1].Users != len(demoStore.listUsers()) {
+		t.Errorf("Expected every stored user analyzed, got %d %+v", w.Code, analysis)
+	}
+
+	for _, body := range []string{
+		`{"experiment": {"key": "banner", "variants": [{"name": "control"}]}, "user_ids": [1]}`,
+		`{"experiment": ` + experiment + `, "user_ids": []}`,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/experiments/assign", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", body, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/experiments/analyze", strings.NewReader(`{"experiment": {"variants": [{"name": "a"}, {"name": "b"}]}}`)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "experiment.key") {
+		t.Errorf("Expected the missing key to be reported, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,212 @@
+//go:build !wasm
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// DATA EXPORT
+// /api/export/{dataset}?format=csv|xlsx downloads the demo datasets (and the
+// analytics computed from them) as files. Tables come from shared_export.go,
+// so a CSV exported here is byte-for-byte what exportCsvWasm produces in the
+// browser. XLSX is written directly as SpreadsheetML inside a zip archive,
+// which keeps the server free of third-party dependencies.
+// ============================================================================
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// exportTableForDataset builds the export table for a dataset in the store.
+func exportTableForDataset(store *dataStore, dataset string) (ExportTable, bool) {
+	switch dataset {
+	case "users":
+		return UsersExportTable(store.listUsers()), true
+	case "products":
+		return ProductsExportTable(store.listProducts()), true
+	case "orders":
+		return OrdersExportTable(store.listOrders()), true
+	case "analytics":
+		return AnalyticsExportTable(AnalyzeUserBehavior(store.listUsers(), store.listOrders(), AnalyticsFilter{})), true
+	default:
+		return ExportTable{}, false
+	}
+}
+
+// handleExport serves a dataset as a CSV (default) or XLSX download.
+func handleExport(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	dataset := r.PathValue("dataset")
+	table, ok := exportTableForDataset(storeFor(r), dataset)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown dataset %q", dataset))
+		return
+	}
+
+	var body bytes.Buffer
+	var contentType, extension string
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		contentType, extension = "text/csv; charset=utf-8", "csv"
+		if err := WriteExportCSV(&body, table); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to generate CSV")
+			return
+		}
+	case "xlsx":
+		contentType, extension = xlsxContentType, "xlsx"
+		if err := writeXLSX(&body, table); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to generate XLSX")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q (use csv or xlsx)", format))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, table.Name, extension))
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.Write(body.Bytes())
+}
+
+// writeXLSX writes the table as a single-sheet workbook with a bold, frozen
+// header row. Numbers and booleans keep their cell types; strings are stored
+// inline so no shared-strings table is needed.
+func writeXLSX(w io.Writer, table ExportTable) error {
+	archive := zip.NewWriter(w)
+	modified := time.Now()
+
+	sheetName := table.Name
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(table)},
+	}
+	for _, part := range parts {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func xlsxSheet(table ExportTable) string {
+	var sb bytes.Buffer
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" state="frozen"/></sheetView></sheetViews>`)
+	sb.WriteString(`<sheetData>`)
+
+	writeRow := func(rowNumber int, cells []interface{}, style string) {
+		fmt.Fprintf(&sb, `<row r="%d">`, rowNumber)
+		for col, value := range cells {
+			ref := xlsxColumnName(col) + strconv.Itoa(rowNumber)
+			switch v := value.(type) {
+			case int:
+				fmt.Fprintf(&sb, `<c r="%s"%s><v>%d</v></c>`, ref, style, v)
+			case float64:
+				fmt.Fprintf(&sb, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+			case bool:
+				b := 0
+				if v {
+					b = 1
+				}
+				fmt.Fprintf(&sb, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, style, b)
+			case nil:
+			default:
+				// Spreadsheets don't evaluate inline strings, so no formula
+				// escaping is needed here
+				fmt.Fprintf(&sb, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(fmt.Sprint(v)))
+			}
+		}
+		sb.WriteString(`</row>`)
+	}
+
+	header := make([]interface{}, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+	writeRow(1, header, ` s="1"`)
+	for i, row := range table.Rows {
+		writeRow(i+2, row, "")
+	}
+
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// xlsxColumnName converts a zero-based column index to A, B, ..., Z, AA, ...
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// Style 0 is the default; style 1 is bold, used for the header row
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
@@ -0,0 +1,99 @@
+//go:build !wasm
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestExportEndpoints tests CSV and XLSX downloads of the demo datasets
+func TestExportEndpoints(t *testing.T) {
+	mux := newServerMux()
+
+	t.Run("CSV", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/export/products", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Errorf("Expected CSV content type, got %q", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="products.csv"` {
+			t.Errorf("Unexpected Content-Disposition %q", cd)
+		}
+
+		// Identical to the client-side export
+		expected, _ := ExportCSV(ProductsExportTable(demoStore.listProducts()))
+		if w.Body.String() != expected {
+			t.Error("Server CSV differs from shared ExportCSV output")
+		}
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/export/orders?format=xlsx", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != xlsxContentType {
+			t.Errorf("Unexpected content type %q", ct)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("XLSX is not a valid zip archive: %v", err)
+		}
+		parts := map[string]string{}
+		for _, file := range archive.File {
+			rc, _ := file.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			parts[file.Name] = string(data)
+		}
+
+		for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/worksheets/sheet1.xml", "xl/styles.xml"} {
+			if _, ok := parts[name]; !ok {
+				t.Errorf("XLSX missing part %s", name)
+			}
+		}
+		sheet := parts["xl/worksheets/sheet1.xml"]
+		if !strings.Contains(sheet, `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`) {
+			t.Error("Expected bold inline-string header cell A1")
+		}
+		if !strings.Contains(sheet, `<c r="A2"><v>1</v></c>`) {
+			t.Error("Expected numeric order ID in A2")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for target, want := range map[string]int{
+			"/api/export/invoices":           http.StatusNotFound,
+			"/api/export/users?format=pdf":   http.StatusBadRequest,
+			"/api/export/analytics?format=x": http.StatusBadRequest,
+		} {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+			if w.Code != want {
+				t.Errorf("%s: expected status %d, got %d", target, want, w.Code)
+			}
+		}
+	})
+}
+
+// TestXLSXColumnName tests spreadsheet column lettering
+func TestXLSXColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumnName(index); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
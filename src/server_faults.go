@@ -0,0 +1,88 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// FAULT INJECTION
+// For demos on a fast local network: -fault-paths picks endpoints that answer
+// as if over a slow or flaky one. Each matching request waits -fault-latency
+// plus up to -fault-jitter more, then fails with -fault-error-status at
+// -fault-error-rate. Running the same logic locally in WASM skips all of
+// it, which is the point the demo makes. Nothing is injected unless
+// -fault-paths is set.
+// ============================================================================
+
+// injectedFaultHeader reports what was injected into a response, such as
+// "delay=312ms" or "delay=312ms, error=503".
+const injectedFaultHeader = "X-Injected-Fault"
+
+// faultRandom draws the jitter and error fractions; tests replace it.
+var faultRandom = rand.Float64
+
+// faultPathMatches reports whether path is one of the comma-separated
+// patterns: exact paths, or prefixes ending in "*".
+func faultPathMatches(patterns, path string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if pattern != "" && pattern == path {
+			return true
+		}
+	}
+	return false
+}
+
+// faultInjectionMiddleware delays or fails the requests to -fault-paths.
+// Preflight requests pass untouched, and a client that gives up stops the
+// wait.
+func faultInjectionMiddleware(next http.Handler) http.Handler {
+	if strings.TrimSpace(serverConfig.FaultPaths) == "" {
+		return next
+	}
+	cfg := serverConfig
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" || !faultPathMatches(cfg.FaultPaths, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		delay := cfg.FaultLatency + time.Duration(faultRandom()*float64(cfg.FaultJitter))
+		injected := []string{"delay=" + delay.Round(time.Millisecond).String()}
+		if delay > 0 {
+			_, end := StartSpan(r.Context(), "injected latency")
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				end()
+				return
+			}
+			end()
+		}
+
+		if cfg.FaultErrorRate > 0 && faultRandom() < cfg.FaultErrorRate {
+			injected = append(injected, fmt.Sprintf("error=%d", cfg.FaultErrorStatus))
+			w.Header().Set(injectedFaultHeader, strings.Join(injected, ", "))
+			slog.DebugContext(r.Context(), "injected fault", "path", r.URL.Path, "delay", delay, "status", cfg.FaultErrorStatus)
+			enableCORS(w, r)
+			writeError(w, cfg.FaultErrorStatus, "Injected fault (see -fault-error-rate)")
+			return
+		}
+		w.Header().Set(injectedFaultHeader, strings.Join(injected, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
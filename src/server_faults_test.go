@@ -0,0 +1,103 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withFaultRandom makes the jitter and error draws return value.
+func withFaultRandom(t *testing.T, value float64) {
+	previous := faultRandom
+	faultRandom = func() float64 { return value }
+	t.Cleanup(func() { faultRandom = previous })
+}
+
+func TestFaultPathMatches(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		want bool
+	}{
+		{"/api/validate-user", true},
+		{"/api/validate-user/extra", false},
+		{"/api/benchmark/matrix", true},
+		{"/api/benchmark", false},
+		{"/index.html", false},
+	} {
+		if got := faultPathMatches(" /api/validate-user , /api/benchmark/*,", tt.path); got != tt.want {
+			t.Errorf("faultPathMatches(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	served := 0
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ })
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		faultInjectionMiddleware(ok).ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	// Off without -fault-paths, whatever else is set
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.FaultErrorRate = 1 })
+	if w := serve("GET", "/api/validate-user"); w.Code != http.StatusOK || w.Header().Get(injectedFaultHeader) != "" || served != 1 {
+		t.Errorf("Expected no faults without -fault-paths, got %d %v", w.Code, w.Header())
+	}
+
+	withServerConfig(t, func(cfg *ServerConfig) {
+		cfg.FaultPaths = "/api/validate-user"
+		cfg.FaultLatency = 20 * time.Millisecond
+		cfg.FaultJitter = 20 * time.Millisecond
+		cfg.FaultErrorRate = 0.4
+	})
+	withFaultRandom(t, 0.5)
+	start := time.Now()
+	w := serve("POST", "/api/validate-user")
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected latency plus half the jitter, waited %v", elapsed)
+	}
+	if w.Code != http.StatusOK || w.Header().Get(injectedFaultHeader) != "delay=30ms" || served != 2 {
+		t.Errorf("Expected a delayed success, got %d %v", w.Code, w.Header())
+	}
+
+	// Draws under the error rate fail, with CORS headers so pages see why
+	withFaultRandom(t, 0.1)
+	w = serve("POST", "/api/validate-user")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get(injectedFaultHeader) != "delay=22ms, error=503" || w.Header().Get("Access-Control-Allow-Origin") != "*" || served != 2 {
+		t.Errorf("Expected an injected 503, got %d %v", w.Code, w.Header())
+	}
+
+	// Other paths and preflights pass untouched
+	for _, req := range [][2]string{{"POST", "/api/validate-product"}, {"OPTIONS", "/api/validate-user"}} {
+		if w := serve(req[0], req[1]); w.Code != http.StatusOK || w.Header().Get(injectedFaultHeader) != "" {
+			t.Errorf("%s %s: got %d %v", req[0], req[1], w.Code, w.Header())
+		}
+	}
+	if served != 4 {
+		t.Errorf("Expected 4 requests served, got %d", served)
+	}
+}
+
+func TestFaultInjectionCancelled(t *testing.T) {
+	withServerConfig(t, func(cfg *ServerConfig) {
+		cfg.FaultPaths = "/api/*"
+		cfg.FaultLatency = time.Hour
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		faultInjectionMiddleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/products", nil).WithContext(ctx))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a cancelled request to stop waiting")
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// REVENUE FORECAST
+// GET /api/analytics/forecast series the stored orders with AggregateRevenue
+// and forecasts the periods after with ForecastRevenue:
+//
+//   ?granularity=week   day, week or month (the default)
+//   ?horizon=6          periods to forecast, 1 to 366
+//   ?season=12          periods in a season, 0 for none (the default)
+//   ?alpha=0.5          smoothing of the level, above 0 and at most 1
+//   ?beta=0.3           smoothing of the trend
+//   ?gamma=0.3          smoothing of the season
+//   ?from=2023-05-01    only orders on or after this date
+//   ?to=2023-05-31      only orders on or before this date
+// ============================================================================
+
+// forecastResponse is the body of GET /api/analytics/forecast: the series
+// forecast from and the forecast.
+type forecastResponse struct {
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	History  []RevenuePoint  `json:"history"`
+	Forecast RevenueForecast `json:"forecast"`
+}
+
+// handleRevenueForecast serves the revenue forecast of the stored orders.
+func handleRevenueForecast(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	response := forecastResponse{From: query.Get("from"), To: query.Get("to")}
+	granularity := GranularityMonth
+	if raw := query.Get("granularity"); raw != "" {
+		granularity = raw
+	}
+	fields := map[string]string{}
+	if granularity != GranularityDay && granularity != GranularityWeek && granularity != GranularityMonth {
+		fields["granularity"] = "must be day, week or month"
+	}
+	for name, value := range map[string]string{"from": response.From, "to": response.To} {
+		if _, err := time.Parse(taxDateLayout, value); value != "" && err != nil {
+			fields[name] = "must be a date as YYYY-MM-DD"
+		}
+	}
+	if len(fields) == 0 && response.From != "" && response.To != "" && response.To < response.From {
+		fields["to"] = "must not be before from"
+	}
+
+	settings := DefaultForecastSettings
+	for name, target := range map[string]*int{"horizon": &settings.Horizon, "season": &settings.Season} {
+		if raw := query.Get(name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				fields[name] = "must be a whole number"
+			}
+			*target = value
+		}
+	}
+	for name, target := range map[string]*float64{"alpha": &settings.Alpha, "beta": &settings.Beta, "gamma": &settings.Gamma} {
+		if raw := query.Get(name); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				fields[name] = "must be a number"
+			}
+			*target = value
+		}
+	}
+	for _, fieldErr := range ValidateForecastSettings(settings).FieldErrors {
+		if _, reported := fields[fieldErr.Field]; !reported {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	history, err := AggregateRevenue(ordersBetween(storeFor(r).listOrders(), response.From, response.To), granularity)
+	if err == nil {
+		response.History = history
+		response.Forecast, err = ForecastRevenue(history, granularity, settings)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
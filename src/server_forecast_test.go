@@ -0,0 +1,42 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRevenueForecastEndpoint tests forecasting the demo orders and the
+// parameters
+func TestRevenueForecastEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/forecast?granularity=day&horizon=3", nil))
+	var response forecastResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	// The demo orders span 2023-05-01 to 2023-05-03
+	if w.Code != http.StatusOK || len(response.History) != 3 || len(response.Forecast.Points) != 3 || response.Forecast.Points[0].Period != "2023-05-04" {
+		t.Fatalf("Expected three days forecast after the demo orders, got %d %+v", w.Code, response)
+	}
+
+	for target, status := range map[string]int{
+		"/api/analytics/forecast":                            http.StatusUnprocessableEntity, // one month of orders
+		"/api/analytics/forecast?granularity=day&season=2":   http.StatusOK,
+		"/api/analytics/forecast?granularity=hour":           http.StatusBadRequest,
+		"/api/analytics/forecast?granularity=day&horizon=0":  http.StatusBadRequest,
+		"/api/analytics/forecast?granularity=day&alpha=x":    http.StatusBadRequest,
+		"/api/analytics/forecast?granularity=day&gamma=1.5":  http.StatusBadRequest,
+		"/api/analytics/forecast?from=2023-05-03&to=2023-05": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//go:build !wasm
+
+package main
+
+import (
+	"crypto/rand"
+	"net/http"
+	"strings"
+)
+
+// ============================================================================
+// GIFT CARDS
+// Cards are issued by administrators and redeemed by naming their code on
+// an order (shared_giftcards.go):
+//
+//   POST /api/gift-cards          {"balance": 50, "currency": "USD",
+//                                  "expiry": "2027-12-31"} (admin token)
+//   GET  /api/gift-cards/{code}   balance check
+//
+// calculate-order prices an order's gift_card_code against the card's
+// current balance, and checkout takes the amount off the card when the
+// order is placed; cancelling the order puts it back.
+// ============================================================================
+
+// issueGiftCardRequest is the body of POST /api/gift-cards.
+type issueGiftCardRequest struct {
+	Balance  float64 `json:"balance" validate:"required"`
+	Currency string  `json:"currency,omitempty"` // default USD
+	Expiry   string  `json:"expiry,omitempty"`
+}
+
+// newGiftCardCode returns a random code matching giftCardCodePattern.
+func newGiftCardCode() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	var sb strings.Builder
+	sb.WriteString("GIFT")
+	for i, b := range random {
+		if i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(giftCardAlphabet[int(b)%len(giftCardAlphabet)])
+	}
+	return sb.String()
+}
+
+// handleGiftCards issues a gift card.
+func handleGiftCards(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req issueGiftCardRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = DefaultCurrency
+	}
+	card := GiftCard{Code: newGiftCardCode(), Currency: strings.ToUpper(req.Currency), Expiry: req.Expiry}
+	card.Balance = RoundToCurrency(req.Balance, card.Currency)
+	result := ValidateGiftCard(card)
+	if card.Balance <= 0 {
+		result.AddError("balance", CodeOutOfRange, "Balance must be positive")
+	}
+	if !result.Valid {
+		writeError(w, http.StatusBadRequest, strings.Join(result.Errors, "; "))
+		return
+	}
+	if err := storeFor(r).issueGiftCard(card); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, card)
+}
+
+// handleGiftCard reports a gift card's balance.
+func handleGiftCard(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	card, err := storeFor(r).giftCard(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Gift card not found")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, card)
+}
+
+// giftCardFieldErrors looks up the card an order names into order.GiftCard,
+// reporting an unknown or unusable card as a field error. It runs after the
+// order's currency is known.
+func giftCardFieldErrors(fields map[string]string, field string, store *dataStore, order *Order) {
+	if order.GiftCardCode == "" {
+		return
+	}
+	card, err := store.giftCard(order.GiftCardCode)
+	if err != nil {
+		fields[field] = "unknown gift card"
+		return
+	}
+	if err := CheckGiftCard(card, OrderCurrency(*order), orderPlacedDate(*order)); err != nil {
+		fields[field] = err.Error()
+		return
+	}
+	order.GiftCardCode, order.GiftCard = card.Code, &card
+}
@@ -0,0 +1,67 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestGiftCardCheckout tests issuing a card, paying with it and refunding it
+func TestGiftCardCheckout(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	mux := newServerMux()
+	client := &cartClient{t: t, handler: mux}
+
+	if w := client.do("POST", "/api/gift-cards", `{"balance": 25}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 issuing without the admin token, got %d", w.Code)
+	}
+	req := httptest.NewRequest("POST", "/api/gift-cards", strings.NewReader(`{"balance": 25}`))
+	req.Header.Set("Authorization", "Bearer admin")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var card GiftCard
+	json.NewDecoder(w.Body).Decode(&card)
+	if !ValidateGiftCard(card).Valid || card.Balance != 25 || card.Currency != "USD" {
+		t.Fatalf("Unexpected card: %+v", card)
+	}
+
+	// Codes are looked up regardless of case
+	var balance GiftCard
+	json.NewDecoder(client.do("GET", "/api/gift-cards/"+strings.ToLower(card.Code), "").Body).Decode(&balance)
+	if balance.Balance != 25 {
+		t.Errorf("Expected a balance of 25, got %+v", balance)
+	}
+	client.do("POST", "/api/cart/items", `{"product_id": 4}`)
+	if w := client.do("POST", "/api/cart/checkout", `{"gift_card_code": "GIFT-2222-2222-2222"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown card, got %d", w.Code)
+	}
+	w = client.do("POST", "/api/cart/checkout", `{"gift_card_code": "`+card.Code+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if order.GiftCardAmount != order.Total || order.AmountDue != 0 {
+		t.Errorf("Expected the card to pay for the mug, got %+v", order)
+	}
+	json.NewDecoder(client.do("GET", "/api/gift-cards/"+card.Code, "").Body).Decode(&balance)
+	if balance.Balance != RoundToCurrency(25-order.Total, "USD") {
+		t.Errorf("Expected the card to be debited, got %+v", balance)
+	}
+
+	client.do("PUT", "/api/orders/"+strconv.Itoa(order.ID)+"/status", `{"status": "cancelled", "version": 1}`)
+	json.NewDecoder(client.do("GET", "/api/gift-cards/"+card.Code, "").Body).Decode(&balance)
+	if balance.Balance != 25 {
+		t.Errorf("Expected cancelling to refund the card, got %+v", balance)
+	}
+}
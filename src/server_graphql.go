@@ -0,0 +1,828 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// GRAPHQL
+// /api/graphql answers GraphQL queries over the demo data, so a client can
+// ask for exactly the fields it needs (and related records in the same
+// request) instead of taking the fixed REST shapes:
+//
+//   { users(country: "US") { name orders { total products { name } } } }
+//
+// Object types mirror the shared models and keep their JSON field names.
+// Order pricing and recommendations run the same shared functions as the
+// REST endpoints and the WASM build. Only queries are supported; the SDL is
+// served at /api/graphql/schema.
+// ============================================================================
+
+// gqlMaxDepth bounds selection nesting so cyclic fields (user.orders.user...)
+// can't be used to build arbitrarily expensive queries.
+const gqlMaxDepth = 8
+
+// graphQLRequest is the body of POST /api/graphql.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse is a GraphQL result: data, errors, or both.
+type graphQLResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphQLError `json:"errors,omitempty"`
+}
+
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlObject is a result object. It keeps fields in selection order, as
+// GraphQL requires, which a Go map would not.
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	key   string
+	value interface{}
+}
+
+func (obj gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range obj {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.key)
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ----------------------------------------------------------------------------
+// Schema
+// ----------------------------------------------------------------------------
+
+type gqlArgDef struct {
+	name string
+	typ  string
+}
+
+type gqlResolver func(ctx *gqlContext, parent interface{}, args map[string]interface{}) (interface{}, error)
+
+type gqlField struct {
+	name    string
+	typ     string // GraphQL type reference, e.g. "[Order!]!"
+	args    []gqlArgDef
+	resolve gqlResolver
+}
+
+type gqlObjectType struct {
+	name   string
+	fields []*gqlField
+}
+
+func (t *gqlObjectType) field(name string) *gqlField {
+	for _, f := range t.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type gqlSchema struct {
+	types  map[string]*gqlObjectType
+	order  []string
+	inputs string // SDL of the input types
+}
+
+var graphQLSchema = sync.OnceValue(newGraphQLSchema)
+
+// gqlModelTypes names the shared models exposed as object types.
+var gqlModelTypes = map[reflect.Type]string{
+	reflect.TypeOf(User{}):                 "User",
+	reflect.TypeOf(Address{}):              "Address",
+	reflect.TypeOf(Preferences{}):          "Preferences",
+	reflect.TypeOf(NotificationSettings{}): "NotificationSettings",
+	reflect.TypeOf(Product{}):              "Product",
+	reflect.TypeOf(PriceTier{}):            "PriceTier",
+	reflect.TypeOf(ProductVariant{}):       "ProductVariant",
+	reflect.TypeOf(ProductImage{}):         "ProductImage",
+	reflect.TypeOf(Order{}):                "Order",
+	reflect.TypeOf(PriceBreakdown{}):       "PriceBreakdown",
+	reflect.TypeOf(Shipment{}):             "Shipment",
+	reflect.TypeOf(DiscountLine{}):         "DiscountLine",
+	reflect.TypeOf(AppliedPromotion{}):     "AppliedPromotion",
+	reflect.TypeOf(CartItem{}):             "CartItem",
+	reflect.TypeOf(UserAnalytics{}):        "UserAnalytics",
+	reflect.TypeOf(OrderValueStats{}):      "OrderValueStats",
+	reflect.TypeOf(GeoRevenue{}):           "GeoRevenue",
+	reflect.TypeOf(OrderAnomaly{}):         "OrderAnomaly",
+	reflect.TypeOf(TopCountry{}):           "TopCountry",
+	reflect.TypeOf(HistogramBucket{}):      "HistogramBucket",
+	reflect.TypeOf(BasketStats{}):          "BasketStats",
+	reflect.TypeOf(CategoryMix{}):          "CategoryMix",
+}
+
+// gqlStructType derives an object type from a model's exported fields and
+// JSON names.
+func gqlStructType(name string, model interface{}) *gqlObjectType {
+	t := reflect.TypeOf(model)
+	objType := &gqlObjectType{name: name}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || jsonName == "" || jsonName == "-" {
+			continue
+		}
+		index := i
+		objType.fields = append(objType.fields, &gqlField{
+			name: jsonName,
+			typ:  gqlTypeOf(field.Type),
+			resolve: func(ctx *gqlContext, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				value := reflect.ValueOf(parent).Field(index)
+				if value.Kind() == reflect.Ptr {
+					if value.IsNil() {
+						return nil, nil
+					}
+					value = value.Elem()
+				}
+				return value.Interface(), nil
+			},
+		})
+	}
+	return objType
+}
+
+func gqlTypeOf(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int:
+		return "Int!"
+	case reflect.Float64:
+		return "Float!"
+	case reflect.String:
+		return "String!"
+	case reflect.Bool:
+		return "Boolean!"
+	case reflect.Slice:
+		return "[" + gqlTypeOf(t.Elem()) + "]!"
+	case reflect.Ptr:
+		// Optional nested models are nullable
+		return strings.TrimSuffix(gqlTypeOf(t.Elem()), "!")
+	}
+	if name, ok := gqlModelTypes[t]; ok {
+		return name + "!"
+	}
+	panic("graphql: unsupported model field type " + t.String())
+}
+
+// namedType strips list and non-null wrappers from a type reference.
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+func newGraphQLSchema() *gqlSchema {
+	user := gqlStructType("User", User{})
+	address := gqlStructType("Address", Address{})
+	preferences := gqlStructType("Preferences", Preferences{})
+	notifications := gqlStructType("NotificationSettings", NotificationSettings{})
+	product := gqlStructType("Product", Product{})
+	priceTier := gqlStructType("PriceTier", PriceTier{})
+	variant := gqlStructType("ProductVariant", ProductVariant{})
+	image := gqlStructType("ProductImage", ProductImage{})
+	order := gqlStructType("Order", Order{})
+	priceBreakdown := gqlStructType("PriceBreakdown", PriceBreakdown{})
+	shipment := gqlStructType("Shipment", Shipment{})
+	discountLine := gqlStructType("DiscountLine", DiscountLine{})
+	appliedPromotion := gqlStructType("AppliedPromotion", AppliedPromotion{})
+	cartItem := gqlStructType("CartItem", CartItem{})
+	analytics := gqlStructType("UserAnalytics", UserAnalytics{})
+	orderValues := gqlStructType("OrderValueStats", OrderValueStats{})
+	geoRevenue := gqlStructType("GeoRevenue", GeoRevenue{})
+	orderAnomaly := gqlStructType("OrderAnomaly", OrderAnomaly{})
+	topCountry := gqlStructType("TopCountry", TopCountry{})
+	histogramBucket := gqlStructType("HistogramBucket", HistogramBucket{})
+	basketStats := gqlStructType("BasketStats", BasketStats{})
+	categoryMix := gqlStructType("CategoryMix", CategoryMix{})
+
+	page := []gqlArgDef{{"limit", "Int"}, {"offset", "Int"}}
+
+	user.fields = append(user.fields, &gqlField{
+		name: "orders", typ: "[Order!]!",
+		resolve: func(ctx *gqlContext, parent interface{}, args map[string]interface{}) (interface{}, error) {
+			return ordersWhere(ctx.store, parent.(User).ID, ""), nil
+		},
+	})
+	order.fields = append(order.fields, &gqlField{
+		name: "user", typ: "User",
+		resolve: func(ctx *gqlContext, parent interface{}, args map[string]interface{}) (interface{}, error) {
+			if u, ok := findUser(ctx.store, parent.(Order).UserID); ok {
+				return u, nil
+			}
+			return nil, nil
+		},
+	})
+
+	query := &gqlObjectType{name: "Query", fields: []*gqlField{
+		{
+			name: "users", typ: "[User!]!",
+			args: append([]gqlArgDef{{"country", "String"}, {"premium", "Boolean"}}, page...),
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				country, _ := args["country"].(string)
+				premium, filterPremium := args["premium"].(bool)
+				var users []User
+				for _, u := range ctx.store.listUsers() {
+					if (country == "" || u.Country == country) && (!filterPremium || u.Premium == premium) {
+						users = append(users, u)
+					}
+				}
+				return paginate(users, args)
+			},
+		},
+		{
+			name: "user", typ: "User", args: []gqlArgDef{{"id", "Int!"}},
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := gqlInt(args["id"])
+				if u, ok := findUser(ctx.store, id); ok && err == nil {
+					return u, nil
+				}
+				return nil, err
+			},
+		},
+		{
+			name: "products", typ: "[Product!]!",
+			args: append([]gqlArgDef{{"category", "String"}, {"in_stock", "Boolean"}}, page...),
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				category, _ := args["category"].(string)
+				inStock, filterStock := args["in_stock"].(bool)
+				var products []Product
+				for _, p := range ctx.store.catalog(time.Now()) {
+					if (category == "" || p.Category == category) && (!filterStock || p.InStock() == inStock) {
+						products = append(products, p)
+					}
+				}
+				return paginate(products, args)
+			},
+		},
+		{
+			name: "product", typ: "Product", args: []gqlArgDef{{"id", "Int!"}},
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := gqlInt(args["id"])
+				if p, ok := findProduct(ctx.store.catalog(time.Now()), id); ok && err == nil {
+					return p, nil
+				}
+				return nil, err
+			},
+		},
+		{
+			name: "orders", typ: "[Order!]!",
+			args: append([]gqlArgDef{{"user_id", "Int"}, {"status", "String"}}, page...),
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				userID := 0
+				if args["user_id"] != nil {
+					var err error
+					if userID, err = gqlInt(args["user_id"]); err != nil {
+						return nil, err
+					}
+				}
+				status, _ := args["status"].(string)
+				return paginate(ordersWhere(ctx.store, userID, status), args)
+			},
+		},
+		{
+			name: "order", typ: "Order", args: []gqlArgDef{{"id", "Int!"}},
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := gqlInt(args["id"])
+				for _, o := range ctx.store.listOrders() {
+					if o.ID == id && err == nil {
+						return o, nil
+					}
+				}
+				return nil, err
+			},
+		},
+		{
+			name: "analytics", typ: "UserAnalytics!",
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				return AnalyzeUserBehaviorContext(ctx.compute, ctx.store.listUsers(), ctx.store.listOrders(), AnalyticsFilter{})
+			},
+		},
+		{
+			name: "calculateOrder", typ: "Order!",
+			args: []gqlArgDef{{"user_id", "Int"}, {"items", "[CartItemInput!]!"}},
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				user, err := gqlUserArg(ctx, args["user_id"])
+				if err != nil {
+					return nil, err
+				}
+				cart, err := gqlCartArg(ctx, args["items"])
+				if err != nil {
+					return nil, err
+				}
+				return CartToOrder(cart, ctx.store.catalog(time.Now()), user), nil
+			},
+		},
+		{
+			name: "recommendations", typ: "[Product!]!",
+			args: []gqlArgDef{{"user_id", "Int!"}, {"product_ids", "[Int!]"}},
+			resolve: func(ctx *gqlContext, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				user, err := gqlUserArg(ctx, args["user_id"])
+				if err != nil {
+					return nil, err
+				}
+				order := Order{UserID: user.ID}
+				catalog := ctx.store.catalog(time.Now())
+				ids, _ := args["product_ids"].([]interface{})
+				for _, raw := range ids {
+					id, err := gqlInt(raw)
+					if err != nil {
+						return nil, err
+					}
+					if product, ok := findProduct(catalog, id); ok {
+						order.Products = append(order.Products, product)
+						order.Quantities = append(order.Quantities, 1)
+					}
+				}
+				return RecommendProductsContext(ctx.compute, user, catalog, order)
+			},
+		},
+	}}
+
+	schema := &gqlSchema{
+		types:  map[string]*gqlObjectType{},
+		inputs: "input CartItemInput {\n  product_id: Int!\n  sku: String\n  quantity: Int!\n}\n",
+	}
+	for _, t := range []*gqlObjectType{query, user, address, preferences, notifications, product, priceTier, variant, image, order, priceBreakdown, shipment, discountLine, appliedPromotion, cartItem, analytics, orderValues, geoRevenue, orderAnomaly, topCountry, histogramBucket, basketStats, categoryMix} {
+		schema.types[t.name] = t
+		schema.order = append(schema.order, t.name)
+	}
+	return schema
+}
+
+// sdl renders the schema in GraphQL schema definition language.
+func (s *gqlSchema) sdl() string {
+	var sb strings.Builder
+	for _, name := range s.order {
+		fmt.Fprintf(&sb, "type %s {\n", name)
+		for _, f := range s.types[name].fields {
+			args := make([]string, len(f.args))
+			for i, arg := range f.args {
+				args[i] = arg.name + ": " + arg.typ
+			}
+			signature := f.name
+			if len(args) > 0 {
+				signature += "(" + strings.Join(args, ", ") + ")"
+			}
+			fmt.Fprintf(&sb, "  %s: %s\n", signature, f.typ)
+		}
+		sb.WriteString("}\n\n")
+	}
+	sb.WriteString(s.inputs)
+	return sb.String()
+}
+
+func findUser(store *dataStore, id int) (User, bool) {
+	for _, u := range store.listUsers() {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// ordersWhere lists orders, optionally only a user's (userID != 0) or those
+// with a status.
+func ordersWhere(store *dataStore, userID int, status string) []Order {
+	orders := []Order{}
+	for _, o := range store.listOrders() {
+		if (userID == 0 || o.UserID == userID) && (status == "" || o.Status == status) {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// paginate applies the limit and offset arguments to a list.
+func paginate[T any](items []T, args map[string]interface{}) ([]T, error) {
+	offset, limit := 0, len(items)
+	if args["offset"] != nil {
+		n, err := gqlInt(args["offset"])
+		if err != nil || n < 0 {
+			return nil, errors.New("offset must be a non-negative Int")
+		}
+		offset = min(n, len(items))
+	}
+	if args["limit"] != nil {
+		n, err := gqlInt(args["limit"])
+		if err != nil || n < 0 {
+			return nil, errors.New("limit must be a non-negative Int")
+		}
+		limit = n
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return items, nil
+}
+
+// gqlInt accepts Int literals and integral JSON numbers from variables.
+func gqlInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("expected an Int, got %v", value)
+}
+
+func gqlUserArg(ctx *gqlContext, value interface{}) (User, error) {
+	if value == nil {
+		return guestUser, nil
+	}
+	id, err := gqlInt(value)
+	if err != nil {
+		return User{}, err
+	}
+	user, ok := findUser(ctx.store, id)
+	if !ok {
+		return User{}, fmt.Errorf("unknown user %d", id)
+	}
+	return user, nil
+}
+
+func gqlCartArg(ctx *gqlContext, value interface{}) (Cart, error) {
+	var cart Cart
+	items, ok := value.([]interface{})
+	if !ok {
+		return cart, errors.New("items must be a list of CartItemInput")
+	}
+	catalog := ctx.store.catalog(time.Now())
+	for i, raw := range items {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			return cart, fmt.Errorf("items[%d] must be a CartItemInput", i)
+		}
+		productID, err := gqlInt(fields["product_id"])
+		if err != nil {
+			return cart, fmt.Errorf("items[%d].product_id: %v", i, err)
+		}
+		quantity, err := gqlInt(fields["quantity"])
+		if err != nil {
+			return cart, fmt.Errorf("items[%d].quantity: %v", i, err)
+		}
+		sku := ""
+		if raw, ok := fields["sku"]; ok && raw != nil {
+			if sku, ok = raw.(string); !ok {
+				return cart, fmt.Errorf("items[%d].sku must be a String", i)
+			}
+		}
+		item := CartItem{ProductID: productID, SKU: sku, Quantity: quantity}
+		if result := ValidateCartItem(item, catalog); !result.Valid {
+			return cart, fmt.Errorf("items[%d]: %s", i, strings.Join(result.Errors, "; "))
+		}
+		product, _ := findProduct(catalog, productID)
+		cart.AddItem(product, sku, quantity, time.Now())
+	}
+	return cart, nil
+}
+
+// ----------------------------------------------------------------------------
+// Execution
+// ----------------------------------------------------------------------------
+
+type gqlContext struct {
+	schema    *gqlSchema
+	doc       *gqlDocument
+	variables map[string]interface{}
+	store     *dataStore
+	errors    []graphQLError
+	// compute stops the resolvers' recommendations and analytics
+	compute context.Context
+}
+
+func (ctx *gqlContext) fail(path []interface{}, format string, args ...interface{}) {
+	ctx.errors = append(ctx.errors, graphQLError{Message: fmt.Sprintf(format, args...), Path: append([]interface{}(nil), path...)})
+}
+
+// executeGraphQL runs a request against a store, stopping the resolvers'
+// heavy work once compute is done. The error is for requests that can't be
+// executed at all (syntax errors, unknown operations, missing variables);
+// field errors are returned in the response.
+func executeGraphQL(compute context.Context, store *dataStore, req graphQLRequest) (graphQLResponse, error) {
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		return graphQLResponse{}, err
+	}
+
+	var op *gqlOperation
+	for _, candidate := range doc.operations {
+		if req.OperationName == "" || candidate.name == req.OperationName {
+			if op != nil {
+				return graphQLResponse{}, errors.New("operationName is required when the document has several operations")
+			}
+			op = candidate
+		}
+	}
+	if op == nil {
+		return graphQLResponse{}, fmt.Errorf("unknown operation %q", req.OperationName)
+	}
+	if op.kind != "query" {
+		return graphQLResponse{}, fmt.Errorf("%s operations are not supported", op.kind)
+	}
+
+	variables := map[string]interface{}{}
+	for _, def := range op.variables {
+		value, ok := req.Variables[def.name]
+		if !ok && def.hasDefault {
+			value, ok = def.defaultValue, true
+		}
+		if (!ok || value == nil) && strings.HasSuffix(def.typ, "!") {
+			return graphQLResponse{}, fmt.Errorf("variable $%s of type %s is required", def.name, def.typ)
+		}
+		variables[def.name] = value
+	}
+
+	ctx := &gqlContext{schema: graphQLSchema(), doc: doc, variables: variables, store: store, compute: compute}
+	data := ctx.selectObject(ctx.schema.types["Query"], nil, op.selections, nil, 1)
+	return graphQLResponse{Data: data, Errors: ctx.errors}, nil
+}
+
+// collectFields flattens fragments and applies @skip/@include, merging
+// selections of the same response key.
+func (ctx *gqlContext) collectFields(typ *gqlObjectType, selections []gqlSelection, fields *[]gqlSelection, visited map[string]bool, path []interface{}) {
+	for _, sel := range selections {
+		if !ctx.included(sel.directives, path) {
+			continue
+		}
+		switch {
+		case sel.spread != "":
+			frag, ok := ctx.doc.fragments[sel.spread]
+			if !ok {
+				ctx.fail(path, "unknown fragment %q", sel.spread)
+				continue
+			}
+			if visited[sel.spread] {
+				continue
+			}
+			visited[sel.spread] = true
+			if frag.typeCond == typ.name {
+				ctx.collectFields(typ, frag.selections, fields, visited, path)
+			}
+		case sel.inline:
+			if sel.typeCond == "" || sel.typeCond == typ.name {
+				ctx.collectFields(typ, sel.selections, fields, visited, path)
+			}
+		default:
+			merged := false
+			for i := range *fields {
+				if (*fields)[i].responseKey() == sel.responseKey() {
+					(*fields)[i].selections = append((*fields)[i].selections, sel.selections...)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				*fields = append(*fields, sel)
+			}
+		}
+	}
+}
+
+func (ctx *gqlContext) included(directives []gqlDirective, path []interface{}) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		var condition interface{}
+		for _, arg := range d.args {
+			if arg.name == "if" {
+				condition = ctx.resolveValue(arg.value)
+			}
+		}
+		value, ok := condition.(bool)
+		if !ok {
+			ctx.fail(path, "@%s requires a Boolean \"if\" argument", d.name)
+			return false
+		}
+		if (d.name == "skip") == value {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveValue substitutes variables and enum names in an argument value.
+func (ctx *gqlContext) resolveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case gqlVariable:
+		return ctx.variables[string(v)]
+	case gqlEnum:
+		return string(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = ctx.resolveValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = ctx.resolveValue(item)
+		}
+		return object
+	}
+	return value
+}
+
+func (ctx *gqlContext) selectObject(typ *gqlObjectType, parent interface{}, selections []gqlSelection, path []interface{}, depth int) gqlObject {
+	if depth > gqlMaxDepth {
+		ctx.fail(path, "query exceeds the maximum depth of %d", gqlMaxDepth)
+		return nil
+	}
+
+	var fields []gqlSelection
+	ctx.collectFields(typ, selections, &fields, map[string]bool{}, path)
+
+	result := gqlObject{}
+	for _, sel := range fields {
+		key := sel.responseKey()
+		fieldPath := append(append([]interface{}(nil), path...), key)
+
+		if sel.name == "__typename" {
+			result = append(result, gqlEntry{key, typ.name})
+			continue
+		}
+		field := typ.field(sel.name)
+		if field == nil {
+			ctx.fail(fieldPath, "Cannot query field %q on type %q", sel.name, typ.name)
+			result = append(result, gqlEntry{key, nil})
+			continue
+		}
+
+		args, ok := ctx.arguments(field, sel.args, fieldPath)
+		if !ok {
+			result = append(result, gqlEntry{key, nil})
+			continue
+		}
+		value, err := field.resolve(ctx, parent, args)
+		if err != nil {
+			ctx.fail(fieldPath, "%v", err)
+			result = append(result, gqlEntry{key, nil})
+			continue
+		}
+		result = append(result, gqlEntry{key, ctx.complete(field.typ, value, sel, fieldPath, depth)})
+	}
+	return result
+}
+
+// arguments checks the arguments of a field against its definition.
+func (ctx *gqlContext) arguments(field *gqlField, given []gqlArgument, path []interface{}) (map[string]interface{}, bool) {
+	args := map[string]interface{}{}
+	for _, arg := range given {
+		known := false
+		for _, def := range field.args {
+			known = known || def.name == arg.name
+		}
+		if !known {
+			ctx.fail(path, "Unknown argument %q on field %q", arg.name, field.name)
+			return nil, false
+		}
+		args[arg.name] = ctx.resolveValue(arg.value)
+	}
+	for _, def := range field.args {
+		if strings.HasSuffix(def.typ, "!") && args[def.name] == nil {
+			ctx.fail(path, "Argument %q of type %s is required", def.name, def.typ)
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// complete shapes a resolved value according to its type: lists element by
+// element, objects through their selection set, scalars as they are.
+func (ctx *gqlContext) complete(typ string, value interface{}, sel gqlSelection, path []interface{}, depth int) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if inner := strings.TrimSuffix(typ, "!"); strings.HasPrefix(inner, "[") {
+		list := reflect.ValueOf(value)
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			items[i] = ctx.complete(inner[1:len(inner)-1], list.Index(i).Interface(), sel, append(path, i), depth)
+		}
+		return items
+	}
+
+	objType, isObject := ctx.schema.types[namedType(typ)]
+	switch {
+	case isObject && len(sel.selections) == 0:
+		ctx.fail(path, "Field %q of type %s must have a selection of subfields", sel.name, typ)
+		return nil
+	case isObject:
+		return ctx.selectObject(objType, value, sel.selections, path, depth+1)
+	case len(sel.selections) > 0:
+		ctx.fail(path, "Field %q of type %s cannot have a selection of subfields", sel.name, typ)
+		return nil
+	}
+	return value
+}
+
+// ----------------------------------------------------------------------------
+// HTTP
+// ----------------------------------------------------------------------------
+
+// handleGraphQL executes queries sent as JSON (POST), as a raw
+// application/graphql body (POST) or in the query string (GET).
+func handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+
+	var req graphQLRequest
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+		if raw := query.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				writeGraphQLError(w, "Invalid variables JSON: "+err.Error())
+				return
+			}
+		}
+	case "POST":
+		body, ok := readBody(w, r, serverConfig.MaxBodyBytes)
+		if !ok {
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, &req); err != nil {
+			writeGraphQLError(w, "Invalid JSON: "+err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		writeGraphQLError(w, "Missing query")
+		return
+	}
+
+	compute, cancel := computeContext(r)
+	defer cancel()
+	resp, err := executeGraphQL(compute, storeFor(r), req)
+	if err != nil {
+		writeGraphQLError(w, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeGraphQLError reports a request that could not be executed.
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(graphQLResponse{Errors: []graphQLError{{Message: message}}})
+}
+
+// handleGraphQLSchema serves the schema as SDL.
+func handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, graphQLSchema().sdl())
+}
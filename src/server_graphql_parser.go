@@ -0,0 +1,504 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// GRAPHQL PARSER
+// A parser for the executable subset of the GraphQL query language used by
+// /api/graphql: operations with variables, fields with aliases and
+// arguments, fragments (named and inline) and directives. Type-system
+// definitions and block strings are not supported.
+// ============================================================================
+
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []gqlVariableDef
+	selections []gqlSelection
+}
+
+type gqlVariableDef struct {
+	name         string
+	typ          string
+	defaultValue interface{}
+	hasDefault   bool
+}
+
+type gqlFragment struct {
+	name       string
+	typeCond   string
+	selections []gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread (spread != "") or an inline
+// fragment (inline).
+type gqlSelection struct {
+	alias      string
+	name       string
+	args       []gqlArgument
+	directives []gqlDirective
+	selections []gqlSelection
+
+	spread   string
+	inline   bool
+	typeCond string
+}
+
+func (sel gqlSelection) responseKey() string {
+	if sel.alias != "" {
+		return sel.alias
+	}
+	return sel.name
+}
+
+type gqlArgument struct {
+	name  string
+	value interface{}
+}
+
+type gqlDirective struct {
+	name string
+	args []gqlArgument
+}
+
+// Parsed values are int, float64, string, bool, nil, gqlEnum, gqlVariable,
+// []interface{} or map[string]interface{}.
+type (
+	gqlEnum     string
+	gqlVariable string
+)
+
+type gqlTokenKind int
+
+const (
+	tokEOF gqlTokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+type gqlParser struct {
+	src   string
+	pos   int
+	token gqlToken
+}
+
+// parseGraphQL parses an executable GraphQL document.
+func parseGraphQL(src string) (doc *gqlDocument, err error) {
+	p := &gqlParser{src: src}
+	defer func() {
+		// Syntax errors unwind the recursive descent via panic
+		if r := recover(); r != nil {
+			perr, ok := r.(gqlSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+
+	p.next()
+	doc = &gqlDocument{fragments: map[string]*gqlFragment{}}
+	for p.token.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: p.selectionSet()})
+		case p.peek(tokName, "fragment"):
+			frag := p.fragment()
+			if _, dup := doc.fragments[frag.name]; dup {
+				p.fail("duplicate fragment %q", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.peek(tokName, "query"), p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		default:
+			p.fail("unexpected %q", p.token.value)
+		}
+	}
+	if len(doc.operations) == 0 {
+		p.fail("document has no operations")
+	}
+	return doc, nil
+}
+
+type gqlSyntaxError struct {
+	message string
+	line    int
+	column  int
+}
+
+func (e gqlSyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.line, e.column, e.message)
+}
+
+func (p *gqlParser) fail(format string, args ...interface{}) {
+	line := 1 + strings.Count(p.src[:p.token.pos], "\n")
+	column := p.token.pos - strings.LastIndex(p.src[:p.token.pos], "\n")
+	panic(gqlSyntaxError{message: fmt.Sprintf(format, args...), line: line, column: column})
+}
+
+func (p *gqlParser) peek(kind gqlTokenKind, value string) bool {
+	return p.token.kind == kind && p.token.value == value
+}
+
+func (p *gqlParser) expect(kind gqlTokenKind, value string) {
+	if !p.peek(kind, value) {
+		p.fail("expected %q, found %q", value, p.token.value)
+	}
+	p.next()
+}
+
+func (p *gqlParser) name() string {
+	if p.token.kind != tokName {
+		p.fail("expected a name, found %q", p.token.value)
+	}
+	name := p.token.value
+	p.next()
+	return name
+}
+
+func (p *gqlParser) operation() *gqlOperation {
+	op := &gqlOperation{kind: p.name()}
+	if p.token.kind == tokName {
+		op.name = p.name()
+	}
+	if p.peek(tokPunct, "(") {
+		p.next()
+		for !p.peek(tokPunct, ")") {
+			p.expect(tokPunct, "$")
+			def := gqlVariableDef{name: p.name()}
+			p.expect(tokPunct, ":")
+			def.typ = p.typeRef()
+			if p.peek(tokPunct, "=") {
+				p.next()
+				def.defaultValue, def.hasDefault = p.value(true), true
+			}
+			op.variables = append(op.variables, def)
+		}
+		p.next()
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *gqlParser) typeRef() string {
+	var typ string
+	if p.peek(tokPunct, "[") {
+		p.next()
+		typ = "[" + p.typeRef() + "]"
+		p.expect(tokPunct, "]")
+	} else {
+		typ = p.name()
+	}
+	if p.peek(tokPunct, "!") {
+		p.next()
+		typ += "!"
+	}
+	return typ
+}
+
+func (p *gqlParser) fragment() *gqlFragment {
+	p.next()
+	frag := &gqlFragment{name: p.name()}
+	if frag.name == "on" {
+		p.fail("fragments cannot be named \"on\"")
+	}
+	if !p.peek(tokName, "on") {
+		p.fail("expected \"on\", found %q", p.token.value)
+	}
+	p.next()
+	frag.typeCond = p.name()
+	p.directives()
+	frag.selections = p.selectionSet()
+	return frag
+}
+
+func (p *gqlParser) selectionSet() []gqlSelection {
+	p.expect(tokPunct, "{")
+	var selections []gqlSelection
+	for !p.peek(tokPunct, "}") {
+		if p.token.kind == tokEOF {
+			p.fail("unterminated selection set")
+		}
+		selections = append(selections, p.selection())
+	}
+	p.next()
+	if len(selections) == 0 {
+		p.fail("empty selection set")
+	}
+	return selections
+}
+
+func (p *gqlParser) selection() gqlSelection {
+	if p.peek(tokPunct, "...") {
+		p.next()
+		if p.token.kind == tokName && p.token.value != "on" {
+			return gqlSelection{spread: p.name(), directives: p.directives()}
+		}
+		sel := gqlSelection{inline: true}
+		if p.peek(tokName, "on") {
+			p.next()
+			sel.typeCond = p.name()
+		}
+		sel.directives = p.directives()
+		sel.selections = p.selectionSet()
+		return sel
+	}
+
+	sel := gqlSelection{name: p.name()}
+	if p.peek(tokPunct, ":") {
+		p.next()
+		sel.alias, sel.name = sel.name, p.name()
+	}
+	sel.args = p.arguments(false)
+	sel.directives = p.directives()
+	if p.peek(tokPunct, "{") {
+		sel.selections = p.selectionSet()
+	}
+	return sel
+}
+
+func (p *gqlParser) arguments(constant bool) []gqlArgument {
+	if !p.peek(tokPunct, "(") {
+		return nil
+	}
+	p.next()
+	var args []gqlArgument
+	for !p.peek(tokPunct, ")") {
+		arg := gqlArgument{name: p.name()}
+		p.expect(tokPunct, ":")
+		arg.value = p.value(constant)
+		args = append(args, arg)
+	}
+	p.next()
+	return args
+}
+
+func (p *gqlParser) directives() []gqlDirective {
+	var directives []gqlDirective
+	for p.peek(tokPunct, "@") {
+		p.next()
+		directives = append(directives, gqlDirective{name: p.name(), args: p.arguments(false)})
+	}
+	return directives
+}
+
+func (p *gqlParser) value(constant bool) interface{} {
+	tok := p.token
+	switch {
+	case tok.kind == tokPunct && tok.value == "$":
+		if constant {
+			p.fail("variables are not allowed here")
+		}
+		p.next()
+		return gqlVariable(p.name())
+	case tok.kind == tokInt:
+		p.next()
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			p.fail("integer %s out of range", tok.value)
+		}
+		return n
+	case tok.kind == tokFloat:
+		p.next()
+		f, _ := strconv.ParseFloat(tok.value, 64)
+		return f
+	case tok.kind == tokString:
+		p.next()
+		return tok.value
+	case tok.kind == tokName:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return gqlEnum(tok.value)
+	case tok.kind == tokPunct && tok.value == "[":
+		p.next()
+		list := []interface{}{}
+		for !p.peek(tokPunct, "]") {
+			list = append(list, p.value(constant))
+		}
+		p.next()
+		return list
+	case tok.kind == tokPunct && tok.value == "{":
+		p.next()
+		object := map[string]interface{}{}
+		for !p.peek(tokPunct, "}") {
+			name := p.name()
+			p.expect(tokPunct, ":")
+			object[name] = p.value(constant)
+		}
+		p.next()
+		return object
+	}
+	p.fail("unexpected %q in value", tok.value)
+	return nil
+}
+
+// next advances to the next token, skipping whitespace, commas and comments.
+func (p *gqlParser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if strings.HasPrefix(p.src[p.pos:], "\ufeff") {
+			p.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	p.token = gqlToken{pos: start}
+	if p.pos >= len(p.src) {
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.token.kind, p.token.value = tokPunct, "..."
+	case strings.ContainsRune("!$()/:=@[]{}|&", rune(c)):
+		p.pos++
+		p.token.kind, p.token.value = tokPunct, string(c)
+	case c == '_' || isASCIILetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isASCIILetter(p.src[p.pos]) || isASCIIDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.token.kind, p.token.value = tokName, p.src[start:p.pos]
+	case c == '-' || isASCIIDigit(c):
+		p.number()
+	case c == '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			p.fail("block strings are not supported")
+		}
+		p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.token.value = string(r)
+		p.fail("unexpected character %q", r)
+	}
+}
+
+func (p *gqlParser) number() {
+	start := p.pos
+	kind := tokInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		from := p.pos
+		for p.pos < len(p.src) && isASCIIDigit(p.src[p.pos]) {
+			p.pos++
+		}
+		return p.pos - from
+	}
+	if digits() == 0 {
+		p.fail("invalid number")
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		kind = tokFloat
+		if digits() == 0 {
+			p.fail("invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		kind = tokFloat
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			p.fail("invalid number")
+		}
+	}
+	p.token.kind, p.token.value = kind, p.src[start:p.pos]
+}
+
+func (p *gqlParser) string() {
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.fail("unterminated string")
+		}
+		c := p.src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		if p.pos >= len(p.src) {
+			p.fail("unterminated string")
+		}
+		switch esc := p.src[p.pos]; esc {
+		case '"', '\\', '/':
+			sb.WriteByte(esc)
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			if p.pos+4 >= len(p.src) {
+				p.fail("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32)
+			if err != nil {
+				p.fail("invalid unicode escape")
+			}
+			sb.WriteRune(rune(code))
+			p.pos += 4
+		default:
+			p.fail("invalid escape \\%c", esc)
+		}
+		p.pos++
+	}
+	p.token.kind, p.token.value = tokString, sb.String()
+}
+
+func isASCIILetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isASCIIDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,135 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// graphQL posts a query through the router and decodes the response
+func graphQL(t *testing.T, query string, variables map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	w := httptest.NewRecorder()
+	newServerMux().ServeHTTP(w, httptest.NewRequest("POST", "/api/graphql", strings.NewReader(string(body))))
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Invalid response JSON: %v", err)
+	}
+	return w.Code, resp
+}
+
+// TestGraphQLQuery tests nested selections, aliases, fragments and variables
+func TestGraphQLQuery(t *testing.T) {
+	withDemoStore(t)
+
+	query := `
+		query Customer($id: Int!, $withOrders: Boolean = true) {
+			customer: user(id: $id) {
+				...names
+				orders @include(if: $withOrders) { id status products { name } }
+			}
+			electronics: products(category: "electronics", limit: 2) { __typename id }
+		}
+		fragment names on User { name email }`
+	code, resp := graphQL(t, query, map[string]interface{}{"id": 1})
+	if code != http.StatusOK || resp["errors"] != nil {
+		t.Fatalf("Expected success, got %d: %v", code, resp)
+	}
+
+	data := resp["data"].(map[string]interface{})
+	customer := data["customer"].(map[string]interface{})
+	if customer["name"] != "John Doe" || len(customer) != 3 {
+		t.Errorf("Unexpected customer: %v", customer)
+	}
+	orders := customer["orders"].([]interface{})
+	if len(orders) == 0 || orders[0].(map[string]interface{})["products"] == nil {
+		t.Errorf("Expected the user's orders with products, got %v", orders)
+	}
+	products := data["electronics"].([]interface{})
+	if len(products) != 2 || products[0].(map[string]interface{})["__typename"] != "Product" {
+		t.Errorf("Unexpected products: %v", products)
+	}
+
+	// Fields come back in selection order
+	w := httptest.NewRecorder()
+	newServerMux().ServeHTTP(w, httptest.NewRequest("GET", "/api/graphql?query="+url.QueryEscape("{ product(id: 1) { price name id } }"), nil))
+	if expected := `{"data":{"product":{"price":`; !strings.HasPrefix(w.Body.String(), expected) {
+		t.Errorf("Expected fields in query order, got %s", w.Body.String())
+	}
+}
+
+// TestGraphQLCalculateOrder tests that order pricing matches the shared logic
+func TestGraphQLCalculateOrder(t *testing.T) {
+	withDemoStore(t)
+
+	query := `{ calculateOrder(user_id: 1, items: [{product_id: 1, quantity: 2}]) { subtotal total user { premium } } }`
+	_, resp := graphQL(t, query, nil)
+	order := resp["data"].(map[string]interface{})["calculateOrder"].(map[string]interface{})
+
+	var cart Cart
+	user, _ := findUser(demoStore, 1)
+	product, _ := findProduct(demoStore.listProducts(), 1)
+	cart.AddItem(product, "", 2, time.Now())
+	expected := CartToOrder(cart, demoStore.listProducts(), user)
+	if order["total"] != expected.Total || order["subtotal"] != expected.Subtotal {
+		t.Errorf("Expected totals %v/%v, got %v", expected.Subtotal, expected.Total, order)
+	}
+
+	_, resp = graphQL(t, `{ calculateOrder(items: [{product_id: 6, quantity: 1}]) { total } }`, nil)
+	if resp["errors"] == nil {
+		t.Error("Expected an error for an out-of-stock product")
+	}
+}
+
+// TestGraphQLErrors tests request and field errors
+func TestGraphQLErrors(t *testing.T) {
+	withDemoStore(t)
+
+	requestErrors := []string{
+		`{ users { name }`,
+		`query Q($id: Int!) { user(id: $id) { name } }`,
+		`mutation { users { name } }`,
+	}
+	for _, query := range requestErrors {
+		if code, resp := graphQL(t, query, nil); code != http.StatusBadRequest || resp["errors"] == nil {
+			t.Errorf("Expected status 400 for %q, got %d: %v", query, code, resp)
+		}
+	}
+
+	code, resp := graphQL(t, `{ users(limit: 1) { name password } product(id: 99) { name } orders { user }}`, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200 with field errors, got %d", code)
+	}
+	data := resp["data"].(map[string]interface{})
+	if data["product"] != nil || len(data["users"].([]interface{})) != 1 {
+		t.Errorf("Unexpected data: %v", data)
+	}
+	if errs := resp["errors"].([]interface{}); len(errs) < 2 {
+		t.Errorf("Expected errors for the unknown field and missing selection, got %v", errs)
+	}
+
+	// Cyclic fields are cut off at the depth limit
+	deep := "{ user(id: 1) { " + strings.Repeat("orders { user { ", 5) + "name" + strings.Repeat(" } }", 5) + " } }"
+	if _, resp := graphQL(t, deep, nil); resp["errors"] == nil {
+		t.Error("Expected the depth limit to reject the query")
+	}
+}
+
+// TestGraphQLSchema tests the SDL endpoint
+func TestGraphQLSchema(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleGraphQLSchema(w, httptest.NewRequest("GET", "/api/graphql/schema", nil))
+	sdl := w.Body.String()
+	for _, expected := range []string{"type Query {", "user(id: Int!): User", "orders: [Order!]!", "input CartItemInput {"} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("Expected %q in the schema:\n%s", expected, sdl)
+		}
+	}
+}
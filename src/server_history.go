@@ -0,0 +1,553 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// BENCHMARK HISTORY
+// Every server benchmark run, and every result a browser submits, is stored
+// with the environment it ran in. /api/benchmark/compare turns the history
+// into side-by-side statistics so "is WASM faster than the server here?" can
+// be answered across machines and over time rather than from a single run.
+//
+// Records are appended to <storage-path>/benchmark_history.jsonl, one JSON
+// object per line, and reloaded at startup.
+// ============================================================================
+
+// maxHistoryRecords bounds the records kept in memory (and reloaded from disk).
+const maxHistoryRecords = 10000
+
+// serverEnvironment labels records produced by the server itself.
+const serverEnvironment = "server"
+
+// benchmarkEnvironment describes where a benchmark ran.
+type benchmarkEnvironment struct {
+	Platform  string `json:"platform"` // "server" or "browser"
+	UserAgent string `json:"user_agent,omitempty"`
+	CPUCount  int    `json:"cpu_count,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+}
+
+// benchmarkRecord is one stored benchmark measurement.
+type benchmarkRecord struct {
+	ID          string               `json:"id"`
+	Benchmark   string               `json:"benchmark"`
+	Environment string               `json:"environment"` // e.g. "server", "JavaScript", "Go WASM"
+	Params      map[string]int       `json:"params,omitempty"`
+	DurationMs  float64              `json:"duration_ms"`
+	Metadata    benchmarkEnvironment `json:"metadata"`
+	RecordedAt  time.Time            `json:"recorded_at"`
+	// Verified is set for server runs and for results submitted with a valid
+	// challenge (see server_challenges.go), identified by ChallengeID.
+	Verified    bool   `json:"verified,omitempty"`
+	ChallengeID string `json:"challenge_id,omitempty"`
+}
+
+// paramsKey identifies records that ran the same workload.
+func (rec benchmarkRecord) paramsKey() string {
+	names := make([]string, 0, len(rec.Params))
+	for name := range rec.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.Itoa(rec.Params[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// benchmarkResultSubmission is a client-measured result posted to
+// /api/benchmark/results.
+type benchmarkResultSubmission struct {
+	Benchmark   string         `json:"benchmark"`
+	Environment string         `json:"environment"`
+	Params      map[string]int `json:"params,omitempty"`
+	DurationMs  float64        `json:"duration_ms"`
+	CPUCount    int            `json:"cpu_count,omitempty"`
+	GoVersion   string         `json:"go_version,omitempty"`
+	UserAgent   string         `json:"user_agent,omitempty"`
+	// Challenge and Proof are optional; see handleBenchmarkChallenges.
+	Challenge string `json:"challenge,omitempty"`
+	Proof     string `json:"proof,omitempty"`
+}
+
+func (sub benchmarkResultSubmission) validate() error {
+	var errs []error
+	if strings.TrimSpace(sub.Benchmark) == "" {
+		errs = append(errs, errors.New("benchmark is required"))
+	}
+	switch strings.TrimSpace(sub.Environment) {
+	case "":
+		errs = append(errs, errors.New("environment is required"))
+	case serverEnvironment:
+		errs = append(errs, fmt.Errorf("environment %q is reserved for server-side runs", serverEnvironment))
+	}
+	if sub.DurationMs <= 0 || math.IsInf(sub.DurationMs, 0) || math.IsNaN(sub.DurationMs) {
+		errs = append(errs, errors.New("duration_ms must be a positive number"))
+	}
+	return errors.Join(errs...)
+}
+
+// benchmarkHistoryStore keeps benchmark records in memory and, when it has a
+// path, appends each one to a JSON-lines file.
+type benchmarkHistoryStore struct {
+	mu      sync.Mutex
+	path    string
+	records []benchmarkRecord
+}
+
+// benchmarkHistory is the active store. It is memory-only until main opens
+// the file under the configured storage path.
+var benchmarkHistory = &benchmarkHistoryStore{}
+
+// openBenchmarkHistory loads the history file at path, creating its directory
+// if needed. Malformed lines (for example a write cut short by a crash) are
+// skipped rather than failing startup.
+func openBenchmarkHistory(path string) (*benchmarkHistoryStore, error) {
+	store := &benchmarkHistoryStore{path: path}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open benchmark history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	skipped := 0
+	for scanner.Scan() {
+		var rec benchmarkRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			skipped++
+			continue
+		}
+		store.records = append(store.records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read benchmark history: %w", err)
+	}
+	if skipped > 0 {
+		log.Printf("⚠️  Skipped %d malformed benchmark history records in %s", skipped, path)
+	}
+	if excess := len(store.records) - maxHistoryRecords; excess > 0 {
+		store.records = store.records[excess:]
+	}
+	return store, nil
+}
+
+// add stores a record, assigning its ID and timestamp.
+func (s *benchmarkHistoryStore) add(rec benchmarkRecord) (benchmarkRecord, error) {
+	rec.ID = newRandomID()
+	rec.RecordedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path != "" {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return benchmarkRecord{}, err
+		}
+		file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return benchmarkRecord{}, fmt.Errorf("append benchmark history: %w", err)
+		}
+		_, err = file.Write(append(line, '\n'))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return benchmarkRecord{}, fmt.Errorf("append benchmark history: %w", err)
+		}
+	}
+
+	s.records = append(s.records, rec)
+	if excess := len(s.records) - maxHistoryRecords; excess > 0 {
+		s.records = append([]benchmarkRecord(nil), s.records[excess:]...)
+	}
+	return rec, nil
+}
+
+// benchmarkHistoryFilter selects records; zero fields match everything.
+type benchmarkHistoryFilter struct {
+	Benchmark   string
+	Environment string
+	Since       time.Time
+	Verified    bool // only verified records
+}
+
+func (f benchmarkHistoryFilter) matches(rec benchmarkRecord) bool {
+	return (f.Benchmark == "" || strings.EqualFold(rec.Benchmark, f.Benchmark)) &&
+		(f.Environment == "" || strings.EqualFold(rec.Environment, f.Environment)) &&
+		!rec.RecordedAt.Before(f.Since) &&
+		(!f.Verified || rec.Verified)
+}
+
+// query returns matching records, oldest first.
+func (s *benchmarkHistoryStore) query(filter benchmarkHistoryFilter) []benchmarkRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := []benchmarkRecord{}
+	for _, rec := range s.records {
+		if filter.matches(rec) {
+			matched = append(matched, rec)
+		}
+	}
+	return matched
+}
+
+// recordServerBenchmark stores the result of a server-side benchmark run.
+func recordServerBenchmark(bench serverBenchmark, result map[string]interface{}) {
+	duration, ok := result["duration_ms"].(float64)
+	if !ok {
+		return
+	}
+	_, err := benchmarkHistory.add(benchmarkRecord{
+		Benchmark:   bench.Kind,
+		Environment: serverEnvironment,
+		Params:      bench.Params,
+		DurationMs:  duration,
+		Metadata: benchmarkEnvironment{
+			Platform:  serverEnvironment,
+			CPUCount:  runtime.NumCPU(),
+			GoVersion: runtime.Version(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+		},
+		Verified: true,
+	})
+	if err != nil {
+		log.Printf("Failed to record benchmark history: %v", err)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Comparison statistics
+// ----------------------------------------------------------------------------
+
+// environmentStats summarises one environment's durations for a workload.
+type environmentStats struct {
+	Environment string  `json:"environment"`
+	Runs        int     `json:"runs"`
+	MeanMs      float64 `json:"mean_ms"`
+	MedianMs    float64 `json:"median_ms"`
+	MinMs       float64 `json:"min_ms"`
+	MaxMs       float64 `json:"max_ms"`
+	StdDevMs    float64 `json:"stddev_ms"`
+	// Speedup is the baseline's mean divided by this environment's mean, so
+	// values above 1 mean faster than the baseline. Omitted without a baseline.
+	Speedup float64 `json:"speedup,omitempty"`
+}
+
+// benchmarkTrendPoint is the mean duration of one environment on one day.
+type benchmarkTrendPoint struct {
+	Date        string  `json:"date"`
+	Environment string  `json:"environment"`
+	Runs        int     `json:"runs"`
+	MeanMs      float64 `json:"mean_ms"`
+}
+
+// benchmarkComparisonGroup compares environments on one benchmark workload.
+type benchmarkComparisonGroup struct {
+	Benchmark    string                `json:"benchmark"`
+	Params       string                `json:"params"`
+	Environments []environmentStats    `json:"environments"`
+	Trend        []benchmarkTrendPoint `json:"trend"`
+}
+
+// benchmarkComparison is the response of /api/benchmark/compare.
+type benchmarkComparison struct {
+	Baseline string                     `json:"baseline"`
+	Groups   []benchmarkComparisonGroup `json:"groups"`
+}
+
+// compareBenchmarks groups records by benchmark and parameters and computes
+// per-environment statistics relative to the baseline environment.
+func compareBenchmarks(records []benchmarkRecord, baseline string) benchmarkComparison {
+	type groupKey struct{ benchmark, params string }
+	grouped := map[groupKey][]benchmarkRecord{}
+	var keys []groupKey
+	for _, rec := range records {
+		key := groupKey{strings.ToLower(rec.Benchmark), rec.paramsKey()}
+		if _, seen := grouped[key]; !seen {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], rec)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].benchmark != keys[j].benchmark {
+			return keys[i].benchmark < keys[j].benchmark
+		}
+		return keys[i].params < keys[j].params
+	})
+
+	comparison := benchmarkComparison{Baseline: baseline, Groups: []benchmarkComparisonGroup{}}
+	for _, key := range keys {
+		group := grouped[key]
+
+		durations := map[string][]float64{}
+		daily := map[[2]string][]float64{}
+		for _, rec := range group {
+			durations[rec.Environment] = append(durations[rec.Environment], rec.DurationMs)
+			day := [2]string{rec.RecordedAt.UTC().Format("2006-01-02"), rec.Environment}
+			daily[day] = append(daily[day], rec.DurationMs)
+		}
+
+		stats := make([]environmentStats, 0, len(durations))
+		baselineMean := 0.0
+		for env, values := range durations {
+			s := summarizeDurations(env, values)
+			if strings.EqualFold(env, baseline) {
+				baselineMean = s.MeanMs
+			}
+			stats = append(stats, s)
+		}
+		for i := range stats {
+			if baselineMean > 0 && stats[i].MeanMs > 0 {
+				stats[i].Speedup = roundTo(baselineMean/stats[i].MeanMs, 3)
+			}
+		}
+		sort.Slice(stats, func(i, j int) bool { return stats[i].MeanMs < stats[j].MeanMs })
+
+		trend := make([]benchmarkTrendPoint, 0, len(daily))
+		for day, values := range daily {
+			trend = append(trend, benchmarkTrendPoint{
+				Date:        day[0],
+				Environment: day[1],
+				Runs:        len(values),
+				MeanMs:      roundTo(mean(values), 3),
+			})
+		}
+		sort.Slice(trend, func(i, j int) bool {
+			if trend[i].Date != trend[j].Date {
+				return trend[i].Date < trend[j].Date
+			}
+			return trend[i].Environment < trend[j].Environment
+		})
+
+		comparison.Groups = append(comparison.Groups, benchmarkComparisonGroup{
+			Benchmark:    group[0].Benchmark,
+			Params:       key.params,
+			Environments: stats,
+			Trend:        trend,
+		})
+	}
+	return comparison
+}
+
+func summarizeDurations(env string, values []float64) environmentStats {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	avg := mean(sorted)
+	variance := 0.0
+	for _, v := range sorted {
+		variance += (v - avg) * (v - avg)
+	}
+	variance /= float64(len(sorted))
+
+	return environmentStats{
+		Environment: env,
+		Runs:        len(sorted),
+		MeanMs:      roundTo(avg, 3),
+		MedianMs:    roundTo(median, 3),
+		MinMs:       roundTo(sorted[0], 3),
+		MaxMs:       roundTo(sorted[len(sorted)-1], 3),
+		StdDevMs:    roundTo(math.Sqrt(variance), 3),
+	}
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// parseSince accepts an RFC 3339 timestamp, a date (2006-01-02) or a Go
+// duration meaning "this long ago" (for example 168h).
+func parseSince(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q: use RFC 3339, YYYY-MM-DD or a duration such as 168h", raw)
+}
+
+// ----------------------------------------------------------------------------
+// Handlers
+// ----------------------------------------------------------------------------
+
+// handleBenchmarkResults stores client-measured results (POST, a single
+// result or an array) or lists stored results, newest first (GET).
+func handleBenchmarkResults(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		listBenchmarkResults(w, r)
+		return
+	case "POST":
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, ok := readBody(w, r, serverConfig.MaxBodyBytes)
+	if !ok {
+		return
+	}
+
+	var err error
+	var submissions []benchmarkResultSubmission
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &submissions)
+	} else {
+		var single benchmarkResultSubmission
+		err = json.Unmarshal(trimmed, &single)
+		submissions = []benchmarkResultSubmission{single}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	for i, sub := range submissions {
+		if err := sub.validate(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("result %d: %v", i, err))
+			return
+		}
+	}
+
+	challengeIDs, err := verifySubmissions(r.Context(), submissions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stored := make([]benchmarkRecord, 0, len(submissions))
+	for i, sub := range submissions {
+		userAgent := sub.UserAgent
+		if userAgent == "" {
+			userAgent = r.UserAgent()
+		}
+		rec, err := benchmarkHistory.add(benchmarkRecord{
+			Benchmark:   strings.TrimSpace(sub.Benchmark),
+			Environment: strings.TrimSpace(sub.Environment),
+			Params:      sub.Params,
+			DurationMs:  sub.DurationMs,
+			Metadata: benchmarkEnvironment{
+				Platform:  "browser",
+				UserAgent: userAgent,
+				CPUCount:  sub.CPUCount,
+				GoVersion: sub.GoVersion,
+			},
+			Verified:    challengeIDs[i] != "",
+			ChallengeID: challengeIDs[i],
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to store result")
+			return
+		}
+		stored = append(stored, rec)
+	}
+
+	writeJSON(w, r, http.StatusCreated, stored)
+}
+
+func listBenchmarkResults(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since, err := parseSince(query.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	verified, _ := strconv.ParseBool(query.Get("verified"))
+
+	records := benchmarkHistory.query(benchmarkHistoryFilter{
+		Benchmark:   query.Get("benchmark"),
+		Environment: query.Get("environment"),
+		Since:       since,
+		Verified:    verified,
+	})
+
+	limit := queryInt(query, "limit", 100)
+	newest := make([]benchmarkRecord, 0, min(limit, len(records)))
+	for i := len(records) - 1; i >= 0 && len(newest) < limit; i-- {
+		newest = append(newest, records[i])
+	}
+
+	writeJSON(w, r, http.StatusOK, newest)
+}
+
+// handleBenchmarkCompare returns per-environment statistics and speedups for
+// each benchmark workload in the history.
+func handleBenchmarkCompare(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	query := r.URL.Query()
+	since, err := parseSince(query.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	baseline := query.Get("baseline")
+	if baseline == "" {
+		baseline = serverEnvironment
+	}
+
+	verified, _ := strconv.ParseBool(query.Get("verified"))
+	records := benchmarkHistory.query(benchmarkHistoryFilter{Benchmark: query.Get("benchmark"), Since: since, Verified: verified})
+
+	writeJSON(w, r, http.StatusOK, compareBenchmarks(records, baseline))
+}
@@ -0,0 +1,159 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withBenchmarkHistory replaces the active history with an empty file-backed
+// store for the duration of the test.
+func withBenchmarkHistory(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history", "benchmark_history.jsonl")
+	store, err := openBenchmarkHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := benchmarkHistory
+	benchmarkHistory = store
+	t.Cleanup(func() { benchmarkHistory = previous })
+	return path
+}
+
+// TestBenchmarkHistoryPersistence tests that records survive a restart
+func TestBenchmarkHistoryPersistence(t *testing.T) {
+	path := withBenchmarkHistory(t)
+
+	if _, err := benchmarkHistory.add(benchmarkRecord{Benchmark: "matrix", Environment: "server", DurationMs: 12.5}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Simulate a write cut short by a crash
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.WriteString(`{"benchmark": "matr`)
+	file.Close()
+
+	reopened, err := openBenchmarkHistory(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening history: %v", err)
+	}
+	records := reopened.query(benchmarkHistoryFilter{})
+	if len(records) != 1 || records[0].DurationMs != 12.5 || records[0].ID == "" {
+		t.Errorf("Expected the one valid record to be reloaded, got %+v", records)
+	}
+}
+
+// TestBenchmarkResultSubmission tests recording client-measured results
+func TestBenchmarkResultSubmission(t *testing.T) {
+	withBenchmarkHistory(t)
+
+	t.Run("ArrayOfResults", func(t *testing.T) {
+		body := `[
+			{"benchmark": "matrix", "environment": "JavaScript", "params": {"size": 100}, "duration_ms": 40, "cpu_count": 8},
+			{"benchmark": "matrix", "environment": "Single-Thread WASM", "params": {"size": 100}, "duration_ms": 20, "go_version": "go1.24"}
+		]`
+		req := httptest.NewRequest("POST", "/api/benchmark/results", strings.NewReader(body))
+		req.Header.Set("User-Agent", "TestBrowser/1.0")
+		w := httptest.NewRecorder()
+		handleBenchmarkResults(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var stored []benchmarkRecord
+		json.NewDecoder(w.Body).Decode(&stored)
+		if len(stored) != 2 {
+			t.Fatalf("Expected 2 stored records, got %d", len(stored))
+		}
+		if stored[0].Metadata.Platform != "browser" || stored[0].Metadata.UserAgent != "TestBrowser/1.0" || stored[0].Metadata.CPUCount != 8 {
+			t.Errorf("Environment metadata not recorded: %+v", stored[0].Metadata)
+		}
+	})
+
+	t.Run("InvalidResults", func(t *testing.T) {
+		for _, body := range []string{
+			`{"benchmark": "matrix", "environment": "server", "duration_ms": 5}`,
+			`{"benchmark": "matrix", "environment": "JavaScript"}`,
+			`{"environment": "JavaScript", "duration_ms": 5}`,
+			`[{"benchmark": "matrix"`,
+		} {
+			w := httptest.NewRecorder()
+			handleBenchmarkResults(w, httptest.NewRequest("POST", "/api/benchmark/results", strings.NewReader(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("ServerRunsRecorded", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		benchmarkHandler("matrix")(w, httptest.NewRequest("GET", "/api/benchmark/matrix?size=10", nil))
+
+		records := benchmarkHistory.query(benchmarkHistoryFilter{Environment: serverEnvironment})
+		if len(records) != 1 || records[0].Params["size"] != 10 || records[0].Metadata.GoVersion == "" {
+			t.Errorf("Expected the server run to be recorded with metadata, got %+v", records)
+		}
+	})
+}
+
+// TestBenchmarkCompare tests cross-environment statistics and speedups
+func TestBenchmarkCompare(t *testing.T) {
+	withBenchmarkHistory(t)
+
+	for _, rec := range []benchmarkRecord{
+		{Benchmark: "matrix", Environment: "server", Params: map[string]int{"size": 100}, DurationMs: 10},
+		{Benchmark: "matrix", Environment: "server", Params: map[string]int{"size": 100}, DurationMs: 30},
+		{Benchmark: "matrix", Environment: "JavaScript", Params: map[string]int{"size": 100}, DurationMs: 80},
+		{Benchmark: "matrix", Environment: "JavaScript", Params: map[string]int{"size": 200}, DurationMs: 500},
+		{Benchmark: "hash", Environment: "server", Params: map[string]int{"count": 10}, DurationMs: 1},
+	} {
+		benchmarkHistory.add(rec)
+	}
+
+	w := httptest.NewRecorder()
+	handleBenchmarkCompare(w, httptest.NewRequest("GET", "/api/benchmark/compare?benchmark=matrix", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var comparison benchmarkComparison
+	json.NewDecoder(w.Body).Decode(&comparison)
+
+	if comparison.Baseline != "server" || len(comparison.Groups) != 2 {
+		t.Fatalf("Expected two matrix workloads against the server baseline, got %+v", comparison)
+	}
+
+	group := comparison.Groups[0]
+	if group.Params != "size=100" || len(group.Environments) != 2 {
+		t.Fatalf("Unexpected first group: %+v", group)
+	}
+
+	server, javascript := group.Environments[0], group.Environments[1]
+	if server.Environment != "server" || server.Runs != 2 || server.MeanMs != 20 || server.StdDevMs != 10 || server.Speedup != 1 {
+		t.Errorf("Unexpected server stats: %+v", server)
+	}
+	if javascript.Speedup != 0.25 {
+		t.Errorf("Expected JavaScript to be 0.25x the server, got %+v", javascript)
+	}
+	if len(group.Trend) != 2 {
+		t.Errorf("Expected one trend point per environment for today, got %+v", group.Trend)
+	}
+
+	// No baseline runs for the 200x200 workload, so no speedups
+	if comparison.Groups[1].Environments[0].Speedup != 0 {
+		t.Errorf("Expected no speedup without baseline runs, got %+v", comparison.Groups[1])
+	}
+
+	w = httptest.NewRecorder()
+	handleBenchmarkCompare(w, httptest.NewRequest("GET", "/api/benchmark/compare?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid since, got %d", w.Code)
+	}
+}
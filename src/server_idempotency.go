@@ -0,0 +1,264 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// IDEMPOTENCY KEYS
+// POST endpoints wrapped with idempotent accept an Idempotency-Key header.
+// The first request with a key runs normally and its response is kept for
+// -idempotency-ttl; repeating the key replays that response (marked with
+// Idempotent-Replayed: true) instead of running the handler again, so a
+// client that retries a checkout after a timeout doesn't place the order
+// twice.
+//
+// Keys are scoped to the sandbox, session cookie and path. Reusing a key
+// with a different body, query or negotiated response type is rejected
+// (422), as is a repeat that arrives while the first request is still
+// running (409). 5xx and 406 responses aren't kept, so the request can be
+// retried with the same key.
+// ============================================================================
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+var (
+	errIdempotencyKeyReused   = errors.New("Idempotency-Key was already used with a different request")
+	errIdempotencyInProgress  = errors.New("a request with this Idempotency-Key is still in progress")
+	errTooManyIdempotencyKeys = errors.New("too many requests in progress")
+)
+
+// idempotentResponse is a request in progress (done == false) or the
+// response to replay for its key.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	created     time.Time
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// idempotencyStore holds responses by scoped key.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+	ttl       time.Duration
+	limit     int
+	now       func() time.Time
+}
+
+// idempotencyKeys is the active store. main replaces it with one using the
+// configured TTL and limit.
+var idempotencyKeys = newIdempotencyStore(serverConfig.IdempotencyTTL, serverConfig.MaxIdempotencyKeys)
+
+func newIdempotencyStore(ttl time.Duration, limit int) *idempotencyStore {
+	return &idempotencyStore{
+		responses: map[string]*idempotentResponse{},
+		ttl:       ttl,
+		limit:     limit,
+		now:       time.Now,
+	}
+}
+
+// begin claims a key for a request. It returns the stored response when the
+// key was already answered, or nil when the caller should run the request
+// and report the outcome with finish.
+func (store *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (*idempotentResponse, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	if existing, ok := store.responses[key]; ok && (!existing.done || now.Sub(existing.created) < store.ttl) {
+		switch {
+		case existing.fingerprint != fingerprint:
+			return nil, errIdempotencyKeyReused
+		case !existing.done:
+			return nil, errIdempotencyInProgress
+		}
+		return existing, nil
+	}
+
+	if len(store.responses) >= store.limit && !store.evictLocked() {
+		return nil, errTooManyIdempotencyKeys
+	}
+	store.responses[key] = &idempotentResponse{fingerprint: fingerprint, created: now}
+	return nil, nil
+}
+
+// evictLocked drops the oldest finished response to make room.
+func (store *idempotencyStore) evictLocked() bool {
+	var oldestKey string
+	var oldest *idempotentResponse
+	for key, resp := range store.responses {
+		if resp.done && (oldest == nil || resp.created.Before(oldest.created)) {
+			oldestKey, oldest = key, resp
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(store.responses, oldestKey)
+	return true
+}
+
+// finish stores the response of a claimed key, or releases the key if the
+// request failed on the server side.
+func (store *idempotencyStore) finish(key string, status int, header http.Header, body []byte) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	resp, ok := store.responses[key]
+	if !ok {
+		return
+	}
+	// Neither server errors nor unnegotiable requests are answers worth
+	// replaying
+	if status >= 500 || status == http.StatusNotAcceptable {
+		delete(store.responses, key)
+		return
+	}
+	resp.done, resp.status, resp.header, resp.body = true, status, header, body
+}
+
+// runCleanup drops expired responses periodically until ctx is done.
+func (store *idempotencyStore) runCleanup(ctx context.Context) {
+	ticker := time.NewTicker(max(store.ttl/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			store.mu.Lock()
+			now := store.now()
+			for key, resp := range store.responses {
+				if resp.done && now.Sub(resp.created) >= store.ttl {
+					delete(store.responses, key)
+				}
+			}
+			store.mu.Unlock()
+		}
+	}
+}
+
+// validIdempotencyKey accepts 1-255 printable ASCII characters.
+func validIdempotencyKey(key string) bool {
+	if len(key) == 0 || len(key) > 255 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder passes a response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+		rec.header = rec.ResponseWriter.Header().Clone()
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+// requestFingerprint identifies what a request asks for: its query, body and
+// the response encoding negotiated from Accept, so a retry asking for a
+// different encoding isn't answered with the stored one.
+func requestFingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	var mediaType string
+	if codec, ok := responseCodec(r); ok {
+		mediaType = codec.mediaTypes[0]
+	}
+	return sha256.Sum256(append([]byte(mediaType+"\x00"+r.URL.RawQuery+"\x00"), body...))
+}
+
+// idempotent makes repeated POSTs with the same Idempotency-Key replay the
+// first response.
+func idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if r.Method != "POST" || key == "" {
+			next(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key must be 1-255 printable ASCII characters")
+			return
+		}
+
+		body, ok := readBody(w, r, serverConfig.MaxBodyBytes)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var sessionID string
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			sessionID = cookie.Value
+		}
+		scope := strings.Join([]string{sandboxID(r), sessionID, r.URL.Path, key}, "\x00")
+		stored, err := idempotencyKeys.begin(scope, requestFingerprint(r, body))
+		switch {
+		case errors.Is(err, errIdempotencyKeyReused):
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, errIdempotencyInProgress):
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			w.Header().Set("Retry-After", "5")
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		case stored != nil:
+			for name, values := range stored.header {
+				if name != requestIDHeader {
+					w.Header()[name] = values
+				}
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.status)
+			w.Write(stored.body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			// A handler that panicked or wrote nothing releases the key
+			status := rec.status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			idempotencyKeys.finish(scope, status, rec.header, rec.body.Bytes())
+		}()
+		next(rec, r)
+	}
+}
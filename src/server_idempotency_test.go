@@ -0,0 +1,140 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withIdempotencyKeys replaces the idempotency store with an empty one.
+func withIdempotencyKeys(t *testing.T, limit int) {
+	t.Helper()
+	previous := idempotencyKeys
+	idempotencyKeys = newIdempotencyStore(time.Hour, limit)
+	t.Cleanup(func() { idempotencyKeys = previous })
+}
+
+// TestIdempotentCheckout tests that a retried checkout doesn't place a
+// second order
+func TestIdempotentCheckout(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	withIdempotencyKeys(t, 10)
+	client := &cartClient{t: t, handler: newServerHandler()}
+	client.summary(client.do("POST", "/api/cart/items", `{"product_id": 1, "quantity": 1}`))
+	ordersBefore := len(demoStore.listOrders())
+
+	checkout := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/cart/checkout", nil)
+		req.Header.Set(idempotencyKeyHeader, key)
+		for _, cookie := range client.cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		client.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := checkout("order-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := checkout("order-1")
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected the first response to be replayed, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if orders := len(demoStore.listOrders()); orders != ordersBefore+1 {
+		t.Errorf("Expected exactly one new order, got %d", orders-ordersBefore)
+	}
+
+	// A new key runs the handler again, which finds the cart empty
+	if w := checkout("order-2"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a new key, got %d", w.Code)
+	}
+}
+
+// TestIdempotencyKeyMisuse tests reused keys, concurrent requests and
+// server errors
+func TestIdempotencyKeyMisuse(t *testing.T) {
+	withIdempotencyKeys(t, 1)
+
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/calculate-order", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, "key")
+		w := httptest.NewRecorder()
+		idempotent(handler)(w, req)
+		return w
+	}
+
+	body := `{"order": {"products": [{"id": 1, "price": 10}], "quantities": [1]}, "user": {"country": "US"}}`
+	if w := post(handleCalculateOrder, body); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w := post(handleCalculateOrder, strings.Replace(body, `[1]`, `[2]`, 1))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for a different body, got %d", w.Code)
+	}
+	var resp errorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Error.Code != "unprocessable_entity" {
+		t.Errorf("Expected code unprocessable_entity, got %q", resp.Error.Code)
+	}
+
+	withIdempotencyKeys(t, 1)
+	calls := 0
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeError(w, http.StatusServiceUnavailable, "try again")
+	}
+	post(failing, "{}")
+	post(failing, "{}")
+	if calls != 2 {
+		t.Errorf("Expected 5xx responses not to be replayed, handler ran %d times", calls)
+	}
+
+	// A key claimed by a request still in progress
+	inProgress := httptest.NewRequest("POST", "/api/calculate-order", nil)
+	idempotencyKeys.begin("\x00\x00/api/calculate-order\x00key", requestFingerprint(inProgress, []byte("{}")))
+	if w := post(failing, "{}"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 while the first request runs, got %d", w.Code)
+	}
+}
+
+// TestIdempotencyNegotiation tests that a replay honours the negotiated
+// response encoding
+func TestIdempotencyNegotiation(t *testing.T) {
+	withIdempotencyKeys(t, 10)
+	body := `{"order": {"products": [{"id": 1, "price": 10}], "quantities": [1]}, "user": {"country": "US"}}`
+
+	post := func(key, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/calculate-order", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, key)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		idempotent(handleCalculateOrder)(w, req)
+		return w
+	}
+
+	if w := post("msgpack", "application/msgpack"); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("Expected a MessagePack response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := post("msgpack", "application/msgpack"); w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected the same Accept to replay")
+	}
+	if w := post("msgpack", "application/json"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for a different Accept, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// A 406 isn't kept, so the request can be retried as it is
+	if w := post("unacceptable", "image/png"); w.Code != http.StatusNotAcceptable {
+		t.Fatalf("Expected status 406, got %d", w.Code)
+	}
+	if w := post("unacceptable", "image/png"); w.Code != http.StatusNotAcceptable || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Expected the 406 not to be replayed, got %d", w.Code)
+	}
+}
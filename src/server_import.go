@@ -0,0 +1,456 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// BULK IMPORT
+// /api/import loads users or products from a CSV or JSON upload into the data
+// store. Every row goes through the same ValidateUser/ValidateProduct the
+// validation endpoints and the WASM client use; valid rows are inserted and
+// the response reports, row by row, why the others were rejected.
+//
+// CSV uploads need a header row naming the columns of the matching export
+// (/api/export/users or /api/export/products), so an export can be edited in
+// a spreadsheet and imported again.
+// ============================================================================
+
+var (
+	errUnsupportedImport = errors.New("unsupported import format (upload CSV or JSON)")
+	errTooManyImportRows = errors.New("too many rows")
+)
+
+// importRowError lists why one row was rejected. Row is the 1-based line
+// number for CSV (the header is line 1) and the 1-based array position for
+// JSON.
+type importRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// importReport is the result of an import. In a dry run nothing is stored;
+// Imported and ImportedIDs describe what would have been inserted.
+type importReport struct {
+	Type        string           `json:"type"`
+	Format      string           `json:"format"`
+	DryRun      bool             `json:"dry_run"`
+	TotalRows   int              `json:"total_rows"`
+	Imported    int              `json:"imported"`
+	Rejected    int              `json:"rejected"`
+	ImportedIDs []int            `json:"imported_ids"`
+	Errors      []importRowError `json:"errors"`
+	// ValidationCache counts the rows validated from the cache
+	ValidationCache *ValidationCacheStats `json:"validation_cache,omitempty"`
+}
+
+func (report *importReport) reject(row int, errs []string) {
+	report.Rejected++
+	report.Errors = append(report.Errors, importRowError{Row: row, Errors: errs})
+}
+
+// importUpload is the dataset, format and content of an upload.
+type importUpload struct {
+	dataset string
+	format  string
+	data    []byte
+}
+
+// Import endpoint
+func handleImport(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if r.ContentLength > serverConfig.MaxImportBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, serverConfig.MaxImportBytes)
+
+	upload, err := readImportUpload(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		case errors.Is(err, errUnsupportedImport):
+			writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	var report importReport
+	switch upload.dataset {
+	case "users":
+		report, err = importUsers(storeFor(r), upload, dryRun)
+	case "products":
+		report, err = importProducts(storeFor(r), upload, dryRun)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown import type %q (use users or products)", upload.dataset))
+		return
+	}
+	if errors.Is(err, errTooManyImportRows) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Import exceeds %d rows", serverConfig.MaxImportRows))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !dryRun {
+		dataChanges.publish(sandboxID(r), upload.dataset, changeCreated, report.ImportedIDs)
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// readImportUpload reads either a multipart form with a "file" part (and
+// optionally a "type" field) or a raw CSV/JSON request body. The dataset
+// comes from ?type=, falling back to the form field.
+func readImportUpload(r *http.Request) (importUpload, error) {
+	upload := importUpload{dataset: r.URL.Query().Get("type")}
+	filename := ""
+	contentType := r.Header.Get("Content-Type")
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			return upload, fmt.Errorf("invalid multipart form: %w", err)
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		if upload.dataset == "" {
+			upload.dataset = r.FormValue("type")
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return upload, errors.New(`multipart upload needs a "file" part`)
+		}
+		defer file.Close()
+		filename, contentType = header.Filename, header.Header.Get("Content-Type")
+		if upload.data, err = io.ReadAll(file); err != nil {
+			return upload, err
+		}
+	} else {
+		var err error
+		if upload.data, err = io.ReadAll(r.Body); err != nil {
+			return upload, err
+		}
+	}
+
+	format, err := importFormat(filename, contentType, upload.data)
+	upload.format = format
+	return upload, err
+}
+
+// importFormat picks csv or json from the file extension, then the content
+// type, then the content itself.
+func importFormat(filename, contentType string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return "csv", nil
+	case ".json":
+		return "json", nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv", nil
+	case "application/json":
+		return "json", nil
+	case "", "text/plain", "application/octet-stream":
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			return "json", nil
+		}
+		return "csv", nil
+	default:
+		return "", errUnsupportedImport
+	}
+}
+
+// importUsers validates and inserts the users in an upload.
+func importUsers(store *dataStore, upload importUpload, dryRun bool) (importReport, error) {
+	report := importReport{Type: "users", Format: upload.format, DryRun: dryRun, ImportedIDs: []int{}, Errors: []importRowError{}}
+	validation := &validationRun{cache: validationCache}
+	var users []User
+	var rows []int
+
+	collect := func(row int, user User, errs []string) {
+		report.TotalRows++
+		if len(errs) == 0 {
+			errs = validation.validateUser(user).Errors
+		}
+		if len(errs) > 0 {
+			report.reject(row, errs)
+			return
+		}
+		// Phones are stored in E.164 form and postal codes in their
+		// country's form, however they were written
+		if user.Phone != "" {
+			user.Phone, _ = ValidatePhone(user.Phone, user.Country)
+		}
+		if user.Address != nil {
+			address := NormalizeAddress(*user.Address)
+			user.Address = &address
+		}
+		users = append(users, user)
+		rows = append(rows, row)
+	}
+
+	var err error
+	if upload.format == "csv" {
+		err = readCSVImport(upload.data, UsersExportTable(nil).Columns, []string{"name", "email"},
+			func(row int, record map[string]string, errs []string) {
+				user := User{
+					ID:       importInt(record, "id", &errs),
+					Name:     record["name"],
+					Email:    record["email"],
+					Age:      importInt(record, "age", &errs),
+					Country:  record["country"],
+					Premium:  importBool(record, "premium", &errs),
+					JoinDate: record["join_date"],
+					Phone:    record["phone"],
+				}
+				collect(row, user, errs)
+			})
+	} else {
+		err = readJSONImport(upload.data, func(row int, raw json.RawMessage) {
+			var user User
+			errs := decodeImportJSON(SchemaUser, raw, &user)
+			collect(row, user, errs)
+		})
+	}
+	if err != nil {
+		return report, err
+	}
+
+	inserted, conflicts := store.insertUsers(users, !dryRun)
+	for i, conflict := range conflicts {
+		report.reject(rows[i], []string{conflict.Error()})
+	}
+	for _, user := range inserted {
+		report.ImportedIDs = append(report.ImportedIDs, user.ID)
+	}
+	report.Imported = len(inserted)
+	report.ValidationCache = validation.stats()
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	return report, nil
+}
+
+// importProducts validates and inserts the products in an upload.
+func importProducts(store *dataStore, upload importUpload, dryRun bool) (importReport, error) {
+	report := importReport{Type: "products", Format: upload.format, DryRun: dryRun, ImportedIDs: []int{}, Errors: []importRowError{}}
+	validation := &validationRun{cache: validationCache}
+	var products []Product
+	var rows []int
+
+	collect := func(row int, product Product, errs []string) {
+		report.TotalRows++
+		// Reservations belong to orders, which an import doesn't bring along;
+		// the product's stock is its variants' total
+		product.Reserved = 0
+		for i := range product.Variants {
+			product.Variants[i].Reserved = 0
+		}
+		product = SumVariantStock(product)
+		if len(errs) == 0 {
+			errs = validation.validateProduct(product).Errors
+		}
+		if len(errs) > 0 {
+			report.reject(row, errs)
+			return
+		}
+		products = append(products, product)
+		rows = append(rows, row)
+	}
+
+	var err error
+	if upload.format == "csv" {
+		err = readCSVImport(upload.data, ProductsExportTable(nil).Columns, []string{"name", "price"},
+			func(row int, record map[string]string, errs []string) {
+				product := Product{
+					ID:          importInt(record, "id", &errs),
+					Name:        record["name"],
+					Category:    record["category"],
+					Price:       importFloat(record, "price", &errs),
+					OnHand:      importInt(record, "on_hand", &errs),
+					Rating:      importFloat(record, "rating", &errs),
+					Description: record["description"],
+				}
+				collect(row, product, errs)
+			})
+	} else {
+		err = readJSONImport(upload.data, func(row int, raw json.RawMessage) {
+			var product Product
+			errs := decodeImportJSON(SchemaProduct, raw, &product)
+			collect(row, product, errs)
+		})
+	}
+	if err != nil {
+		return report, err
+	}
+
+	inserted, conflicts := store.insertProducts(products, !dryRun)
+	for i, conflict := range conflicts {
+		report.reject(rows[i], []string{conflict.Error()})
+	}
+	for _, product := range inserted {
+		report.ImportedIDs = append(report.ImportedIDs, product.ID)
+	}
+	report.Imported = len(inserted)
+	report.ValidationCache = validation.stats()
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	return report, nil
+}
+
+// readCSVImport checks the header against the known columns and calls each
+// for every data row with its values keyed by column name. A row with the
+// wrong number of fields is passed with no values and the error in errs.
+func readCSVImport(data []byte, columns, required []string, each func(row int, record map[string]string, errs []string)) error {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("CSV upload is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	known := map[string]bool{}
+	for _, column := range columns {
+		known[column] = true
+	}
+	seen := map[string]bool{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !known[column] {
+			return fmt.Errorf("unknown CSV column %q (expected %s)", column, strings.Join(columns, ", "))
+		}
+		if seen[column] {
+			return fmt.Errorf("duplicate CSV column %q", column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	for _, column := range required {
+		if !seen[column] {
+			return fmt.Errorf("CSV is missing required column %q", column)
+		}
+	}
+
+	for rows := 0; ; rows++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+		if rows == serverConfig.MaxImportRows {
+			return errTooManyImportRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(fields) != len(header) {
+			each(line, map[string]string{}, []string{fmt.Sprintf("expected %d fields, got %d", len(header), len(fields))})
+			continue
+		}
+		record := make(map[string]string, len(header))
+		for i, column := range header {
+			record[column] = UnescapeExportCell(strings.TrimSpace(fields[i]))
+		}
+		each(line, record, nil)
+	}
+}
+
+// readJSONImport calls each for every element of a JSON array.
+func readJSONImport(data []byte, each func(row int, raw json.RawMessage)) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("invalid JSON (expected an array): %w", err)
+	}
+	if len(items) > serverConfig.MaxImportRows {
+		return errTooManyImportRows
+	}
+	for i, raw := range items {
+		each(i+1, raw)
+	}
+	return nil
+}
+
+// decodeImportJSON decodes one JSON row of a model strictly, so misspelled
+// fields are reported instead of silently dropped. Rows exported by older
+// versions are migrated first.
+func decodeImportJSON(model string, raw json.RawMessage, v interface{}) []string {
+	raw, err := MigrateJSON(model, raw)
+	if err != nil {
+		return []string{strings.TrimPrefix(err.Error(), "json: ")}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return []string{strings.TrimPrefix(err.Error(), "json: ")}
+	}
+	return nil
+}
+
+// importInt, importFloat and importBool parse an optional CSV column,
+// recording an error for values that don't parse.
+func importInt(record map[string]string, column string, errs *[]string) int {
+	if record[column] == "" {
+		return 0
+	}
+	value, err := strconv.Atoi(record[column])
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s must be an integer", column))
+	}
+	return value
+}
+
+func importFloat(record map[string]string, column string, errs *[]string) float64 {
+	if record[column] == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(record[column], 64)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s must be a number", column))
+	}
+	return value
+}
+
+func importBool(record map[string]string, column string, errs *[]string) bool {
+	if record[column] == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(record[column])
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s must be true or false", column))
+	}
+	return value
+}
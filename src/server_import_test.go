@@ -0,0 +1,222 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// withDemoStore replaces the data store with freshly generated demo data for
+// the duration of the test.
+func withDemoStore(t *testing.T) {
+	t.Helper()
+	previous := demoStore
+	demoStore = newDemoDataStore()
+	t.Cleanup(func() { demoStore = previous })
+}
+
+func postImport(t *testing.T, target, contentType, body string) (*httptest.ResponseRecorder, importReport) {
+	t.Helper()
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handleImport(w, req)
+
+	var report importReport
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode import report: %v", err)
+		}
+	}
+	return w, report
+}
+
+// TestImportUsersPhones tests that imported phone numbers are checked for
+// the user's country and stored in E.164 form
+func TestImportUsersPhones(t *testing.T) {
+	withDemoStore(t)
+	csv := "name,email,age,country,phone\n" +
+		"Ada Lovelace,ada@example.com,36,UK,020 7946 0958\n" +
+		"Bob Short,bob@example.com,30,US,555-0132\n"
+
+	_, report := postImport(t, "/api/import?type=users", "text/csv", csv)
+	if report.Imported != 1 || report.Rejected != 1 || !strings.Contains(report.Errors[0].Errors[0], "Invalid phone number") {
+		t.Fatalf("Expected the short US number rejected, got %+v", report)
+	}
+	if user, _ := findUser(demoStore, report.ImportedIDs[0]); user.Phone != "+442079460958" {
+		t.Errorf("Expected the phone stored in E.164 form, got %q", user.Phone)
+	}
+}
+
+// TestImportMigratesOldProducts tests importing products exported before
+// stock counts and variants
+func TestImportMigratesOldProducts(t *testing.T) {
+	withDemoStore(t)
+	body := `[{"name": "Desk Lamp", "price": 39.99, "category": "home", "in_stock": true},
+		{"name": "Tote Bag", "price": 19.99, "category": "clothing", "in_stock": false, "sku": "TOTE-RED", "color": "red"},
+		{"name": "Future Chair", "price": 99.99, "category": "home", "schema_version": 99}]`
+
+	_, report := postImport(t, "/api/import?type=products", "application/json", body)
+	if report.Imported != 2 || report.Rejected != 1 || !strings.Contains(report.Errors[0].Errors[0], "newer") {
+		t.Fatalf("Expected the old products imported and the newer one rejected, got %+v", report)
+	}
+	products := demoStore.listProducts()
+	lamp, bag := products[len(products)-2], products[len(products)-1]
+	if lamp.OnHand != migratedInStockUnits || lamp.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected the in-stock lamp given stock, got %+v", lamp)
+	}
+	if len(bag.Variants) != 1 || bag.Variants[0].SKU != "TOTE-RED" || bag.Variants[0].Color != "red" || bag.OnHand != 0 {
+		t.Errorf("Expected the bag's SKU made its variant, got %+v", bag)
+	}
+}
+
+// TestImportUsersCSV tests per-row validation and insertion from CSV
+func TestImportUsersCSV(t *testing.T) {
+	withDemoStore(t)
+	before := len(demoStore.listUsers())
+
+	csv := "name,email,age,country,premium\n" +
+		"Ada Lovelace,ada@example.com,36,UK,true\n" +
+		"X,not-an-email,8,ZZ,false\n" +
+		"'=Formula Fan,formula@example.com,40,US,false\n" +
+		"Bad Age,bad@example.com,old,US,false\n" +
+		"Short Row,short@example.com\n" +
+		"Duplicate,John.Doe@example.com,30,US,false\n"
+
+	w, report := postImport(t, "/api/import?type=users", "text/csv", csv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if report.Format != "csv" || report.TotalRows != 6 || report.Imported != 2 || report.Rejected != 4 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	rows := make([]int, len(report.Errors))
+	for i, rowErr := range report.Errors {
+		rows[i] = rowErr.Row
+	}
+	if want := []int{3, 5, 6, 7}; !slices.Equal(rows, want) {
+		t.Errorf("Expected rejected lines %v, got %v (%+v)", want, rows, report.Errors)
+	}
+	if len(report.Errors[0].Errors) != 4 {
+		t.Errorf("Expected every validation error for line 3, got %v", report.Errors[0].Errors)
+	}
+
+	users := demoStore.listUsers()
+	if len(users) != before+2 {
+		t.Fatalf("Expected 2 users to be stored, got %d", len(users)-before)
+	}
+	if imported := users[len(users)-1]; imported.Name != "=Formula Fan" || imported.ID != report.ImportedIDs[1] {
+		t.Errorf("Expected escaped cell to be restored and an ID assigned, got %+v", imported)
+	}
+}
+
+// TestImportProductsJSON tests JSON uploads, including multipart and dry runs
+func TestImportProductsJSON(t *testing.T) {
+	withDemoStore(t)
+	before := len(demoStore.listProducts())
+
+	body := `[
+		{"name": "Desk Lamp", "price": 39.5, "category": "Home"},
+		{"name": "No", "price": 0},
+		{"name": "Typo Field", "prise": 10},
+		{"id": 1, "name": "Existing ID", "price": 5}
+	]`
+
+	t.Run("DryRun", func(t *testing.T) {
+		w, report := postImport(t, "/api/import?type=products&dry_run=true", "application/json", body)
+		if w.Code != http.StatusOK || !report.DryRun || report.Imported != 1 || report.Rejected != 3 {
+			t.Fatalf("Unexpected dry-run result %d: %+v", w.Code, report)
+		}
+		if len(demoStore.listProducts()) != before {
+			t.Error("Dry run must not store products")
+		}
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		form.WriteField("type", "products")
+		file, _ := form.CreateFormFile("file", "products.json")
+		file.Write([]byte(body))
+		form.Close()
+
+		w, report := postImport(t, "/api/import", form.FormDataContentType(), buf.String())
+		if w.Code != http.StatusOK || report.Format != "json" || report.Imported != 1 {
+			t.Fatalf("Unexpected multipart result %d: %+v", w.Code, report)
+		}
+		if got := len(demoStore.listProducts()); got != before+1 {
+			t.Errorf("Expected one product to be stored, got %d", got-before)
+		}
+	})
+}
+
+// TestImportRejectsBadUploads tests whole-request failures
+func TestImportRejectsBadUploads(t *testing.T) {
+	withDemoStore(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"UnknownType", "/api/import?type=orders", "text/csv", "id\n1\n", http.StatusBadRequest},
+		{"UnknownColumn", "/api/import?type=users", "text/csv", "name,email,shoe_size\n", http.StatusBadRequest},
+		{"MissingColumn", "/api/import?type=products", "text/csv", "name\nWidget\n", http.StatusBadRequest},
+		{"NotAnArray", "/api/import?type=users", "application/json", `{"name": "Solo"}`, http.StatusBadRequest},
+		{"UnsupportedFormat", "/api/import?type=users", "application/xml", "<users/>", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := postImport(t, tt.target, tt.contentType, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("TooManyRows", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.MaxImportRows = 1 })
+		w, _ := postImport(t, "/api/import?type=products", "application/json", `[{"name": "One"}, {"name": "Two"}]`)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d", w.Code)
+		}
+	})
+}
+
+// TestImportValidationCache tests that a row repeated in an upload is
+// validated once
+func TestImportValidationCache(t *testing.T) {
+	withDemoStore(t)
+	previous := validationCache
+	validationCache = NewValidationCache(100)
+	t.Cleanup(func() { validationCache = previous })
+
+	csv := "name,email,age,country\n" +
+		"Ada Lovelace,ada@,36,UK\n" +
+		"Grace Hopper,grace@example.com,40,US\n" +
+		"Ada Lovelace,ada@,36,UK\n"
+	_, report := postImport(t, "/api/import?type=users&dry_run=true", "text/csv", csv)
+	if report.Rejected != 2 || len(report.Errors) != 2 || report.Errors[0].Errors[0] != report.Errors[1].Errors[0] {
+		t.Fatalf("Expected both copies rejected alike, got %+v", report)
+	}
+	if cache := report.ValidationCache; cache == nil || cache.Hits != 1 || cache.Misses != 2 || cache.Entries != 2 {
+		t.Errorf("Expected one row answered from the cache, got %+v", cache)
+	}
+
+	validationCache = nil
+	if _, report := postImport(t, "/api/import?type=users&dry_run=true", "text/csv", csv); report.ValidationCache != nil || report.Rejected != 2 {
+		t.Errorf("Expected no cache stats with the cache off, got %+v", report)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build !wasm
+
+package main
+
+import "net/http"
+
+// ============================================================================
+// INVENTORY
+// Stock levels of the store's products and availability checks, so a page
+// can warn about short stock before checkout rejects the cart:
+//
+//   GET  /api/inventory               on-hand, reserved and available units
+//   POST /api/inventory/availability  {"items": [{"product_id": 3, "quantity": 2}]},
+//                                     or no items to check the session's cart
+// ============================================================================
+
+// availabilityRequest is the body of the availability check.
+type availabilityRequest struct {
+	Items []CartItem `json:"items"`
+}
+
+// availabilityResponse reports whether every item is available.
+type availabilityResponse struct {
+	Available bool               `json:"available"`
+	Items     []ItemAvailability `json:"items"`
+}
+
+// handleInventory lists stock levels.
+func handleInventory(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, storeFor(r).stockLevels())
+}
+
+// handleInventoryAvailability checks items, or the session's cart, against
+// available stock.
+func handleInventoryAvailability(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req availabilityRequest
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if len(req.Items) == 0 {
+		sessions.with(w, r, func(sess *session) {
+			req.Items = append([]CartItem(nil), sess.cart.Items...)
+		})
+	}
+
+	items := CheckAvailability(storeFor(r).listProducts(), req.Items)
+	response := availabilityResponse{Available: true, Items: items}
+	for _, item := range items {
+		response.Available = response.Available && item.OK
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,102 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// TestInventoryCheckout tests that checkout reserves stock and that order
+// status changes settle it
+func TestInventoryCheckout(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+	stock := func(id int) StockLevel {
+		t.Helper()
+		var levels []StockLevel
+		json.NewDecoder(client.do("GET", "/api/inventory", "").Body).Decode(&levels)
+		for _, level := range levels {
+			if level.ProductID == id {
+				return level
+			}
+		}
+		t.Fatalf("Product %d missing from %+v", id, levels)
+		return StockLevel{}
+	}
+	onHand := stock(5).OnHand
+
+	client.do("POST", "/api/cart/items", `{"product_id": 5, "quantity": 2}`)
+	var check availabilityResponse
+	json.NewDecoder(client.do("POST", "/api/inventory/availability", "").Body).Decode(&check)
+	if !check.Available || len(check.Items) != 1 || check.Items[0].Available != onHand {
+		t.Errorf("Expected the cart to be available, got %+v", check)
+	}
+
+	w := client.do("POST", "/api/cart/checkout", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if level := stock(5); level.Reserved != 2 || level.Available != onHand-2 {
+		t.Errorf("Expected checkout to reserve 2 units, got %+v", level)
+	}
+
+	client.do("PUT", "/api/orders/"+strconv.Itoa(order.ID)+"/status", `{"status": "shipped", "version": 1}`)
+	if level := stock(5); level.Reserved != 0 || level.OnHand != onHand-2 {
+		t.Errorf("Expected shipping to commit the units, got %+v", level)
+	}
+
+	// More than is available is refused and the cart kept
+	json.NewDecoder(client.do("POST", "/api/inventory/availability", `{"items": [{"product_id": 5, "quantity": 99}]}`).Body).Decode(&check)
+	if check.Available || check.Items[0].OK {
+		t.Errorf("Expected 99 units to be unavailable, got %+v", check)
+	}
+	if w := client.do("POST", "/api/cart/items", `{"product_id": 5, "quantity": 99}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 adding more than is available, got %d", w.Code)
+	}
+}
+
+// TestInventoryCheckoutShortStock tests that a cart whose stock ran out
+// while it was open is not placed
+func TestInventoryCheckoutShortStock(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	first := &cartClient{t: t, handler: newServerMux()}
+	second := &cartClient{t: t, handler: newServerMux()}
+	available := stockOf(demoStore, 5).Available
+
+	first.do("POST", "/api/cart/items", `{"product_id": 5, "quantity": `+strconv.Itoa(available)+`}`)
+	second.do("POST", "/api/cart/items", `{"product_id": 5, "quantity": 1}`)
+	if w := first.do("POST", "/api/cart/checkout", ""); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := second.do("POST", "/api/cart/checkout", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for short stock, got %d", w.Code)
+	}
+	if summary := second.summary(second.do("GET", "/api/cart", "")); len(summary.Items) != 1 {
+		t.Errorf("Expected the refused cart to be kept, got %+v", summary.Items)
+	}
+
+	var orders []Order
+	json.NewDecoder(first.do("GET", "/api/demo-orders", "").Body).Decode(&orders)
+	last := orders[len(orders)-1]
+	first.do("PUT", "/api/orders/"+strconv.Itoa(last.ID)+"/status", `{"status": "cancelled", "version": 1}`)
+	if level := stockOf(demoStore, 5); level.Available != available {
+		t.Errorf("Expected cancelling to release the stock, got %+v", level)
+	}
+}
+
+// stockOf is the stock level of a product in a store.
+func stockOf(store *dataStore, id int) StockLevel {
+	for _, level := range store.stockLevels() {
+		if level.ProductID == id {
+			return level
+		}
+	}
+	return StockLevel{}
+}
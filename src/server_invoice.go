@@ -0,0 +1,66 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// INVOICES
+// GET /api/orders/{id}/invoice?format=json|html|text returns the invoice of
+// an order (shared_invoice.go): the Invoice document by default, or the page
+// and plain-text renderings generateInvoiceWasm previews in the browser.
+// ============================================================================
+
+// handleOrderInvoice serves an order's invoice.
+func handleOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	order, user, err := storeFor(r).orderWithUser(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	invoice := GenerateInvoice(order, user)
+
+	var body, contentType string
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, invoice)
+		return
+	case "html":
+		contentType = "text/html; charset=utf-8"
+		body, err = RenderInvoiceHTML(invoice)
+	case "text":
+		contentType = "text/plain; charset=utf-8"
+		body, err = RenderInvoiceText(invoice)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q (use json, html or text)", format))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to render invoice")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write([]byte(body))
+}
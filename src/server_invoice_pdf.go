@@ -0,0 +1,370 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// PDF INVOICES
+// GET /api/orders/{id}/invoice.pdf renders the same Invoice document as
+// /api/orders/{id}/invoice (shared_invoice.go) as an A4 PDF. The PDF is
+// written directly with the standard Helvetica fonts, which every viewer
+// has, so nothing is embedded and no third-party library is needed. Content
+// streams are left uncompressed; an invoice is a few kilobytes either way.
+// ============================================================================
+
+// A4 in points, and the margin around the page's content
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// The fonts every page's resources name
+const (
+	pdfRegular = "F1" // Helvetica
+	pdfBold    = "F2" // Helvetica-Bold
+)
+
+// handleOrderInvoicePDF serves an order's invoice as a PDF.
+func handleOrderInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	order, user, err := storeFor(r).orderWithUser(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	invoice := GenerateInvoice(order, user)
+
+	var body bytes.Buffer
+	if err := writeInvoicePDF(&body, invoice); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to generate PDF")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, invoice.Number))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.Write(body.Bytes())
+}
+
+// Columns of the invoice's line table: the description starts at the
+// margin, the others are right-aligned on these edges.
+const (
+	pdfColQty    = 330.0
+	pdfColUnit   = 410.0
+	pdfColTax    = 460.0
+	pdfColAmount = pdfPageWidth - pdfMargin
+)
+
+// writeInvoicePDF lays an invoice out as the text rendering does: the
+// header, who it is billed and shipped to, a line table that continues on
+// further pages as needed, and the totals.
+func writeInvoicePDF(w io.Writer, invoice Invoice) error {
+	doc := &pdfDocument{title: "Invoice " + invoice.Number}
+	money := func(amount float64) string { return FormatCurrency(amount, invoice.Currency) }
+
+	doc.newPage()
+	doc.text(pdfMargin, doc.y, pdfBold, 20, "Invoice")
+	doc.textRight(pdfColAmount, doc.y, pdfRegular, 12, invoice.Number)
+	doc.y -= 28
+	for _, line := range []string{
+		fmt.Sprintf("Order #%d", invoice.OrderID),
+		"Issued: " + invoiceDate(invoice.IssueDate),
+		fmt.Sprintf("Due: %s (%s)", invoiceDate(invoice.DueDate), invoice.PaymentTerms),
+	} {
+		doc.text(pdfMargin, doc.y, pdfRegular, 10, line)
+		doc.y -= 14
+	}
+
+	doc.y -= 14
+	billTo := append([]string{invoice.BillTo.Name, invoice.BillTo.Email}, pdfAddressLines(invoice.BillTo.Address)...)
+	shipTo := pdfAddressLines(invoice.ShipTo)
+	top := doc.y
+	doc.text(pdfMargin, top, pdfBold, 10, "Bill to")
+	for i, line := range billTo {
+		doc.text(pdfMargin, top-14*float64(i+1), pdfRegular, 10, line)
+	}
+	if len(shipTo) > 0 {
+		doc.text(300, top, pdfBold, 10, "Ship to")
+		for i, line := range shipTo {
+			doc.text(300, top-14*float64(i+1), pdfRegular, 10, line)
+		}
+	}
+	doc.y = top - 14*float64(max(len(billTo), len(shipTo))+1) - 14
+
+	tableHeader := func() {
+		doc.text(pdfMargin, doc.y, pdfBold, 10, "Item")
+		for _, column := range []struct {
+			x     float64
+			label string
+		}{{pdfColQty, "Qty"}, {pdfColUnit, "Unit price"}, {pdfColTax, "Tax"}, {pdfColAmount, "Amount"}} {
+			doc.textRight(column.x, doc.y, pdfBold, 10, column.label)
+		}
+		doc.rule(doc.y - 5)
+		doc.y -= 20
+	}
+	tableHeader()
+	for _, line := range invoice.Lines {
+		height := 14.0
+		if line.SKU != "" {
+			height += 11
+		}
+		if doc.y-height < pdfMargin+20 {
+			doc.newPage()
+			doc.text(pdfMargin, doc.y, pdfRegular, 10, invoice.Number+" (continued)")
+			doc.y -= 28
+			tableHeader()
+		}
+		doc.text(pdfMargin, doc.y, pdfRegular, 10, pdfFit(line.Description, pdfRegular, 10, pdfColQty-pdfMargin-40))
+		doc.textRight(pdfColQty, doc.y, pdfRegular, 10, strconv.Itoa(line.Quantity))
+		doc.textRight(pdfColUnit, doc.y, pdfRegular, 10, money(line.UnitPrice))
+		doc.textRight(pdfColTax, doc.y, pdfRegular, 10, invoicePercent(line.TaxRate))
+		doc.textRight(pdfColAmount, doc.y, pdfRegular, 10, money(line.Amount))
+		if line.SKU != "" {
+			doc.text(pdfMargin+10, doc.y-11, pdfRegular, 8, "SKU "+line.SKU)
+		}
+		doc.y -= height
+	}
+
+	type totalRow struct {
+		font, label, amount string
+	}
+	rows := []totalRow{{pdfRegular, "Subtotal", money(invoice.Subtotal)}}
+	if invoice.Discount != 0 {
+		rows = append(rows, totalRow{pdfRegular, "Discount", "-" + money(invoice.Discount)})
+	}
+	rows = append(rows, totalRow{pdfRegular, "Shipping", money(invoice.Shipping)})
+	for _, tax := range invoice.TaxBreakdown {
+		rows = append(rows, totalRow{pdfRegular, fmt.Sprintf("Tax %s on %s", invoicePercent(tax.Rate), money(tax.Net)), money(tax.Tax)})
+	}
+	total := "Total"
+	if invoice.IncludesTax {
+		total += " (tax included)"
+	}
+	rows = append(rows, totalRow{pdfBold, total, money(invoice.Total)})
+	if invoice.GiftCardAmount != 0 {
+		rows = append(rows, totalRow{pdfRegular, "Paid by gift card", "-" + money(invoice.GiftCardAmount)})
+	}
+	rows = append(rows, totalRow{pdfBold, "Amount due", money(invoice.AmountDue)})
+
+	if doc.y-14*float64(len(rows)+1) < pdfMargin+20 {
+		doc.newPage()
+	}
+	doc.rule(doc.y + 9)
+	doc.y -= 6
+	for _, row := range rows {
+		doc.text(pdfColQty-60, doc.y, row.font, 10, row.label)
+		doc.textRight(pdfColAmount, doc.y, row.font, 10, row.amount)
+		doc.y -= 14
+	}
+	return doc.write(w, pdfCreationDate(invoice.IssueDate))
+}
+
+// pdfAddressLines is an address as the invoice prints it, none for nil.
+func pdfAddressLines(address *Address) []string {
+	if address == nil {
+		return nil
+	}
+	city := address.City
+	if address.Region != "" {
+		city += ", " + address.Region
+	}
+	return []string{address.Street, city + " " + address.PostalCode, address.Country}
+}
+
+// pdfCreationDate is a YYYY-MM-DD date as a PDF date, empty for others.
+func pdfCreationDate(date string) string {
+	digits := strings.ReplaceAll(date, "-", "")
+	if len(digits) != 8 {
+		return ""
+	}
+	return "D:" + digits
+}
+
+// pdfDocument collects the pages of a PDF as their content streams. y is
+// where the next line of the current page goes, from the bottom edge.
+type pdfDocument struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// newPage starts a page with y at the top margin.
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// text writes s with its baseline starting at x, y.
+func (d *pdfDocument) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %s Tf %s %s Td %s Tj ET\n", font, pdfNumber(size), pdfNumber(x), pdfNumber(y), pdfString(s))
+}
+
+// textRight writes s ending at x.
+func (d *pdfDocument) textRight(x, y float64, font string, size float64, s string) {
+	d.text(x-pdfTextWidth(s, font, size), y, font, size, s)
+}
+
+// rule draws a thin line across the content at y.
+func (d *pdfDocument) rule(y float64) {
+	fmt.Fprintf(d.page(), "0.5 w %s %s m %s %s l S\n", pdfNumber(pdfMargin), pdfNumber(y), pdfNumber(pdfPageWidth-pdfMargin), pdfNumber(y))
+}
+
+// write writes the document: the catalog, the page tree, the two fonts and
+// the information dictionary, then each page and its content stream with a
+// page number at the foot, the cross-reference table and the trailer.
+func (d *pdfDocument) write(w io.Writer, created string) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	const firstPage = 6 // after the catalog, pages, fonts and info
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	info := "<< /Title " + pdfString(d.title) + " /Producer (go-wasm-demo)"
+	if created != "" {
+		info += " /CreationDate (" + created + ")"
+	}
+	object(info + " >>")
+
+	for i, content := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(content, "BT /%s 8 Tf %s %s Td %s Tj ET\n", pdfRegular, pdfNumber(pdfPageWidth-pdfMargin-pdfTextWidth(footer, pdfRegular, 8)), pdfNumber(pdfMargin/2), pdfString(footer))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(pdfPageWidth), pdfNumber(pdfPageHeight), pdfRegular, pdfBold, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pdfNumber writes a coordinate or size with at most two decimals.
+func pdfNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// pdfWinAnsi maps the characters outside Latin-1 that invoices print, such
+// as currency symbols and typographic punctuation, to WinAnsiEncoding.
+var pdfWinAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, '\u2009': ' ', '\u202f': 0xa0,
+}
+
+// pdfEncode converts s to WinAnsiEncoding. Characters the fonts don't have
+// become question marks.
+func pdfEncode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch b, mapped := pdfWinAnsi[r]; {
+		case mapped:
+			out = append(out, b)
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfString is s as a PDF literal string.
+func pdfString(s string) string {
+	var out strings.Builder
+	out.WriteByte('(')
+	for _, b := range pdfEncode(s) {
+		if b == '(' || b == ')' || b == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(b)
+	}
+	out.WriteByte(')')
+	return out.String()
+}
+
+// Advance widths of the printable ASCII characters, space to tilde, in
+// thousandths of the font size (from the Adobe Helvetica metrics). Bold is
+// wider by about a twentieth; other characters count as wide as a digit.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// pdfTextWidth is how wide s prints in font at size, in points.
+func pdfTextWidth(s, font string, size float64) float64 {
+	units := 0
+	for _, b := range pdfEncode(s) {
+		switch {
+		case b >= ' ' && b <= '~':
+			units += helveticaWidths[b-' ']
+		case b == 0xa0:
+			units += helveticaWidths[0]
+		default:
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if font == pdfBold {
+		width *= 1.05
+	}
+	return width
+}
+
+// pdfFit shortens s with an ellipsis until it is at most width wide.
+func pdfFit(s, font string, size, width float64) string {
+	if pdfTextWidth(s, font, size) <= width {
+		return s
+	}
+	for s != "" && pdfTextWidth(s+"…", font, size) > width {
+		_, last := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-last]
+	}
+	return strings.TrimRight(s, " ") + "…"
+}
@@ -0,0 +1,121 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkPDFStructure checks that every cross-reference entry points at its
+// object and startxref at the table.
+func checkPDFStructure(t *testing.T, pdf []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF header and trailer, got %q ... %q", pdf[:min(20, len(pdf))], pdf[max(0, len(pdf)-20):])
+	}
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if match == nil {
+		t.Fatal("Expected startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("Object %d isn't at offset %d", i+1, offset)
+		}
+	}
+	if !bytes.Contains(pdf, []byte(fmt.Sprintf("/Size %d ", len(entries)+1))) {
+		t.Errorf("Expected the trailer to count %d objects", len(entries)+1)
+	}
+}
+
+// TestOrderInvoicePDFEndpoint tests downloading an invoice as a PDF
+func TestOrderInvoicePDFEndpoint(t *testing.T) {
+	withDemoStore(t)
+	order, err := demoStore.placeOrder(Order{UserID: 1, Products: []Product{{ID: 1, Name: "Laptop (15\")", Price: 999.99, Category: "electronics"}}, Quantities: []int{1}, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := newServerMux()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/orders/"+strconv.Itoa(order.ID)+"/invoice.pdf", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("Expected a PDF, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	user, _ := findUser(demoStore, order.UserID)
+	invoice := GenerateInvoice(order, user)
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `inline; filename="`+invoice.Number+`.pdf"` {
+		t.Errorf("Unexpected Content-Disposition %q", disposition)
+	}
+	pdf := w.Body.Bytes()
+	checkPDFStructure(t, pdf)
+	for _, text := range []string{pdfString(invoice.Number), `(Laptop \(15"\))`, pdfString(FormatCurrency(invoice.AmountDue, invoice.Currency)), "(Page 1 of 1)"} {
+		if !bytes.Contains(pdf, []byte(text)) {
+			t.Errorf("Expected %s in the PDF", text)
+		}
+	}
+
+	for path, want := range map[string]int{"/api/orders/9999/invoice.pdf": http.StatusNotFound, "/api/orders/x/invoice.pdf": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+// TestInvoicePDFPages tests that long invoices continue on further pages
+func TestInvoicePDFPages(t *testing.T) {
+	invoice := Invoice{Number: "INV-000007", OrderID: 7, IssueDate: "2024-03-05", DueDate: "2024-04-04", Currency: "EUR", Total: 10, AmountDue: 10}
+	for i := 0; i < 80; i++ {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{Description: strings.Repeat("Very long product name ", 5), SKU: "SKU-" + strconv.Itoa(i), Quantity: 1, UnitPrice: 1, Amount: 1})
+	}
+	var out bytes.Buffer
+	if err := writeInvoicePDF(&out, invoice); err != nil {
+		t.Fatal(err)
+	}
+	pdf := out.Bytes()
+	checkPDFStructure(t, pdf)
+	pages := bytes.Count(pdf, []byte("/Type /Page "))
+	if pages < 3 || !bytes.Contains(pdf, []byte(fmt.Sprintf("/Count %d", pages))) || !bytes.Contains(pdf, []byte(fmt.Sprintf("(Page %d of %d)", pages, pages))) {
+		t.Errorf("Expected the lines over several numbered pages, got %d", pages)
+	}
+	if !bytes.Contains(pdf, []byte("(INV-000007 \\(continued\\))")) || !bytes.Contains(pdf, []byte("/CreationDate (D:20240305)")) {
+		t.Error("Expected continuation headers and the issue date")
+	}
+	if bytes.Contains(pdf, []byte(strings.Repeat("Very long product name ", 5))) {
+		t.Error("Expected long descriptions shortened to fit the column")
+	}
+}
+
+// TestPDFText tests the encoding and measuring of text
+func TestPDFText(t *testing.T) {
+	for s, want := range map[string]string{
+		"Total (net)": `(Total \(net\))`,
+		`C:\invoices`: `(C:\\invoices)`,
+		"€12 – Café":  "(\x8012 \x96 Caf\xe9)",
+		"ご注文":         "(???)",
+		"12\u202f345": "(12\xa0345)",
+	} {
+		if got := pdfString(s); got != want {
+			t.Errorf("pdfString(%q) = %q, want %q", s, got, want)
+		}
+	}
+	if width := pdfTextWidth("0.5", pdfRegular, 10); width != 13.9 {
+		t.Errorf("Expected 0.5 to be 13.9pt wide, got %v", width)
+	}
+	if fitted := pdfFit("Wireless noise-cancelling headphones", pdfRegular, 10, 80); pdfTextWidth(fitted, pdfRegular, 10) > 80 || !strings.HasSuffix(fitted, "…") {
+		t.Errorf("Expected the name shortened to 80pt, got %q", fitted)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOrderInvoiceEndpoint tests the invoice formats
+func TestOrderInvoiceEndpoint(t *testing.T) {
+	withDemoStore(t)
+	order, err := demoStore.placeOrder(Order{UserID: 1, Products: []Product{{ID: 1, Name: "Laptop", Price: 999.99, Category: "electronics"}}, Quantities: []int{1}, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := newServerMux()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	path := "/api/orders/" + strconv.Itoa(order.ID) + "/invoice"
+
+	w := get(path)
+	var invoice Invoice
+	if err := json.NewDecoder(w.Body).Decode(&invoice); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the invoice, got %d: %v", w.Code, err)
+	}
+	if invoice.OrderID != order.ID || invoice.Total != order.Total || len(invoice.Lines) != 1 {
+		t.Errorf("Unexpected invoice: %+v", invoice)
+	}
+
+	if w := get(path + "?format=html"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), invoice.Number) {
+		t.Errorf("Unexpected HTML invoice %q: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+	if w := get(path + "?format=text"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") || !strings.Contains(w.Body.String(), "INVOICE "+invoice.Number) {
+		t.Errorf("Unexpected text invoice %q: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	for path, want := range map[string]int{path + "?format=pdf": http.StatusBadRequest, "/api/orders/9999/invoice": http.StatusNotFound} {
+		if w := get(path); w.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// ASYNCHRONOUS BENCHMARK JOBS
+// Large benchmarks can take longer than the server's WriteTimeout, so instead
+// of running inline they can be submitted as jobs:
+//
+//   POST /api/benchmark/jobs              {"type": "matrix", "params": {"size": 800}}
+//   GET  /api/benchmark/jobs/{id}         poll the job
+//   GET  /api/benchmark/jobs/{id}/events  Server-Sent Events until it finishes
+//
+// A fixed pool of workers (-job-workers) bounds how many benchmarks run at
+// once, and at most -job-queue-size jobs wait for a worker; beyond that new
+// submissions are refused with 503 rather than piling up.
+// ============================================================================
+
+// Job lifecycle states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+// maxRetainedJobs bounds how many jobs are remembered. The oldest finished
+// jobs are forgotten first.
+const maxRetainedJobs = 1000
+
+// sseHeartbeatInterval keeps idle event streams from being closed by proxies.
+const sseHeartbeatInterval = 15 * time.Second
+
+var (
+	errJobQueueFull   = errors.New("benchmark job queue is full")
+	errJobQueueClosed = errors.New("benchmark job queue is shutting down")
+)
+
+// benchmarkJobRequest is the body of POST /api/benchmark/jobs. Params use the
+// same names and defaults as the query parameters of /api/benchmark/{type}.
+type benchmarkJobRequest struct {
+	Type   string         `json:"type" validate:"required"`
+	Params map[string]int `json:"params,omitempty"`
+}
+
+// benchmarkJob is the externally visible state of a submitted benchmark.
+type benchmarkJob struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Params     map[string]int         `json:"params,omitempty"`
+	Status     string                 `json:"status"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	StartedAt  *time.Time             `json:"started_at,omitempty"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+
+	bench serverBenchmark
+}
+
+func (job benchmarkJob) finished() bool {
+	return job.Status == jobCompleted || job.Status == jobFailed
+}
+
+// benchmarkJobQueue stores jobs and feeds them to a fixed pool of workers.
+type benchmarkJobQueue struct {
+	mu       sync.Mutex
+	jobs     map[string]*benchmarkJob
+	order    []string
+	watchers map[string]map[chan benchmarkJob]struct{}
+	pending  chan *benchmarkJob
+	closed   bool
+	workers  sync.WaitGroup
+}
+
+// benchmarkJobs is the server's job queue, started on first use with the
+// active configuration.
+var benchmarkJobs = sync.OnceValue(func() *benchmarkJobQueue {
+	return newBenchmarkJobQueue(serverConfig.JobWorkers, serverConfig.JobQueueSize)
+})
+
+func newBenchmarkJobQueue(workers, capacity int) *benchmarkJobQueue {
+	q := &benchmarkJobQueue{
+		jobs:     map[string]*benchmarkJob{},
+		watchers: map[string]map[chan benchmarkJob]struct{}{},
+		pending:  make(chan *benchmarkJob, capacity),
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// submit validates a request and queues it. Validation errors are returned
+// as-is; errJobQueueFull and errJobQueueClosed mean the job wasn't accepted.
+func (q *benchmarkJobQueue) submit(req benchmarkJobRequest) (benchmarkJob, error) {
+	query := url.Values{}
+	for name, value := range req.Params {
+		query.Set(name, strconv.Itoa(value))
+	}
+	bench, err := prepareServerBenchmark(req.Type, query)
+	if err != nil {
+		return benchmarkJob{}, err
+	}
+
+	job := &benchmarkJob{
+		ID:        newRandomID(),
+		Type:      req.Type,
+		Params:    bench.Params,
+		Status:    jobQueued,
+		CreatedAt: time.Now().UTC(),
+		bench:     bench,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return benchmarkJob{}, errJobQueueClosed
+	}
+	select {
+	case q.pending <- job:
+	default:
+		return benchmarkJob{}, errJobQueueFull
+	}
+
+	q.jobs[job.ID] = job
+	q.order = append(q.order, job.ID)
+	q.evictFinished()
+	return *job, nil
+}
+
+// evictFinished forgets the oldest finished jobs once more than
+// maxRetainedJobs are stored. Callers must hold q.mu.
+func (q *benchmarkJobQueue) evictFinished() {
+	excess := len(q.order) - maxRetainedJobs
+	if excess <= 0 {
+		return
+	}
+	kept := q.order[:0]
+	for _, id := range q.order {
+		if excess > 0 && q.jobs[id].finished() {
+			delete(q.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	q.order = kept
+}
+
+// get returns a snapshot of a job.
+func (q *benchmarkJobQueue) get(id string) (benchmarkJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return benchmarkJob{}, false
+	}
+	return *job, true
+}
+
+// list returns snapshots of all retained jobs, newest first.
+func (q *benchmarkJobQueue) list() []benchmarkJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]benchmarkJob, 0, len(q.order))
+	for i := len(q.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, *q.jobs[q.order[i]])
+	}
+	return jobs
+}
+
+// subscribe returns the current state of a job and a channel that receives
+// every later state change. The channel holds only the latest state, so a slow
+// reader skips intermediate updates but never misses the final one.
+func (q *benchmarkJobQueue) subscribe(id string) (benchmarkJob, <-chan benchmarkJob, func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return benchmarkJob{}, nil, nil, false
+	}
+
+	updates := make(chan benchmarkJob, 1)
+	if q.watchers[id] == nil {
+		q.watchers[id] = map[chan benchmarkJob]struct{}{}
+	}
+	q.watchers[id][updates] = struct{}{}
+
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.watchers[id], updates)
+		if len(q.watchers[id]) == 0 {
+			delete(q.watchers, id)
+		}
+	}
+	return *job, updates, unsubscribe, true
+}
+
+// update applies change to a job and notifies its watchers.
+func (q *benchmarkJobQueue) update(job *benchmarkJob, change func(job *benchmarkJob)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	change(job)
+	snapshot := *job
+	for updates := range q.watchers[job.ID] {
+		select {
+		case <-updates: // Replace an unread state with the newer one
+		default:
+		}
+		updates <- snapshot
+	}
+}
+
+func (q *benchmarkJobQueue) work() {
+	defer q.workers.Done()
+	for job := range q.pending {
+		q.execute(job)
+	}
+}
+
+func (q *benchmarkJobQueue) execute(job *benchmarkJob) {
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		q.update(job, func(job *benchmarkJob) {
+			finished := time.Now().UTC()
+			job.Status, job.Error, job.FinishedAt = jobFailed, "server shut down before the job started", &finished
+		})
+		return
+	}
+
+	q.update(job, func(job *benchmarkJob) {
+		started := time.Now().UTC()
+		job.Status, job.StartedAt = jobRunning, &started
+	})
+
+	result, err := runJobSafely(WithBenchmarkID(context.Background(), job.ID), job.bench.Run)
+	if err == nil {
+		recordServerBenchmark(job.bench, result)
+	}
+
+	q.update(job, func(job *benchmarkJob) {
+		finished := time.Now().UTC()
+		job.FinishedAt = &finished
+		if err != nil {
+			job.Status, job.Error = jobFailed, err.Error()
+			return
+		}
+		job.Status, job.Result = jobCompleted, result
+	})
+	slog.DebugContext(WithBenchmarkID(context.Background(), job.ID), "benchmark job finished", "type", job.Type, "error", err)
+}
+
+// runJobSafely keeps a panicking benchmark from taking down its worker.
+func runJobSafely(ctx context.Context, run func(context.Context) (map[string]interface{}, error)) (result map[string]interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("benchmark panicked: %v", recovered)
+		}
+	}()
+	return run(ctx)
+}
+
+// shutdown stops accepting jobs, fails the ones still queued and waits for
+// running benchmarks to finish or ctx to expire.
+func (q *benchmarkJobQueue) shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.pending)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newRandomID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// handleBenchmarkJobs submits (POST) or lists (GET) benchmark jobs.
+func handleBenchmarkJobs(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		writeJSON(w, r, http.StatusOK, benchmarkJobs().list())
+		return
+	case "POST":
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req benchmarkJobRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	job, err := benchmarkJobs().submit(req)
+	if errors.Is(err, errJobQueueFull) || errors.Is(err, errJobQueueClosed) {
+		w.Header().Set("Retry-After", "5")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", "/api/benchmark/jobs/"+job.ID)
+	writeJSON(w, r, http.StatusAccepted, job)
+}
+
+// handleBenchmarkJob returns the current state of one job.
+func handleBenchmarkJob(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	job, ok := benchmarkJobs().get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, job)
+}
+
+// handleBenchmarkJobEvents streams a job's state changes as Server-Sent
+// Events. Each event is named after the job status and carries the job as
+// JSON; the stream ends once the job has completed or failed.
+func handleBenchmarkJobEvents(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	job, updates, unsubscribe, ok := benchmarkJobs().subscribe(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	defer unsubscribe()
+
+	// A stream legitimately outlives the server's WriteTimeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	writeJobEvent(w, job)
+	rc.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for !job.finished() {
+		select {
+		case job = <-updates:
+			writeJobEvent(w, job)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeJobEvent(w http.ResponseWriter, job benchmarkJob) {
+	data, _ := json.Marshal(job)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", job.Status, data)
+}
@@ -0,0 +1,135 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func submitJob(t *testing.T, mux http.Handler, body string) (*httptest.ResponseRecorder, benchmarkJob) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/benchmark/jobs", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var job benchmarkJob
+	if w.Code == http.StatusAccepted {
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+	}
+	return w, job
+}
+
+// TestBenchmarkJobs tests submitting and polling asynchronous benchmarks
+func TestBenchmarkJobs(t *testing.T) {
+	mux := newServerMux()
+
+	t.Run("SubmitAndPoll", func(t *testing.T) {
+		w, job := submitJob(t, mux, `{"type": "matrix", "params": {"size": 20}}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		if location := w.Header().Get("Location"); location != "/api/benchmark/jobs/"+job.ID {
+			t.Errorf("Unexpected Location header %q", location)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !job.finished() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/benchmark/jobs/"+job.ID, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200 polling job, got %d", w.Code)
+			}
+			json.NewDecoder(w.Body).Decode(&job)
+		}
+
+		if job.Status != jobCompleted {
+			t.Fatalf("Expected job to complete, got %+v", job)
+		}
+		if job.Result["operation"] != "Matrix Multiplication" || job.StartedAt == nil || job.FinishedAt == nil {
+			t.Errorf("Unexpected completed job: %+v", job)
+		}
+	})
+
+	t.Run("RejectsInvalidParameters", func(t *testing.T) {
+		for _, body := range []string{
+			`{"type": "matrix", "params": {"size": 100000}}`,
+			`{"type": "unknown"}`,
+			`not json`,
+		} {
+			if w, _ := submitJob(t, mux, body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("UnknownJob", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/benchmark/jobs/missing", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+// TestBenchmarkJobEvents tests the Server-Sent Events stream for a job
+func TestBenchmarkJobEvents(t *testing.T) {
+	server := httptest.NewServer(newServerMux())
+	defer server.Close()
+
+	_, job := submitJob(t, server.Config.Handler, `{"type": "hash", "params": {"count": 100}}`)
+
+	resp, err := http.Get(server.URL + "/api/benchmark/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+
+	// The stream ends after the terminal event
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			events = append(events, name)
+		}
+	}
+
+	if len(events) == 0 || events[len(events)-1] != jobCompleted {
+		t.Errorf("Expected stream to end with a completed event, got %v", events)
+	}
+}
+
+// TestBenchmarkJobQueueLimits tests queue capacity and shutdown behaviour
+func TestBenchmarkJobQueueLimits(t *testing.T) {
+	// No workers, so submitted jobs stay queued
+	queue := newBenchmarkJobQueue(0, 1)
+	req := benchmarkJobRequest{Type: "hash", Params: map[string]int{"count": 10}}
+
+	if _, err := queue.submit(req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := queue.submit(req); !errors.Is(err, errJobQueueFull) {
+		t.Errorf("Expected errJobQueueFull, got %v", err)
+	}
+
+	if err := queue.shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+	if _, err := queue.submit(req); !errors.Is(err, errJobQueueClosed) {
+		t.Errorf("Expected errJobQueueClosed, got %v", err)
+	}
+}
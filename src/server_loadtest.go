@@ -0,0 +1,306 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// LOAD TESTS
+// POST /api/loadtest runs the shared logic in-process from many goroutines
+// at once - order pricing, user or product validation - for a while, at a
+// target rate or flat out, and reports the throughput, latency percentiles
+// and allocations. There is no HTTP or JSON in the loop, so it measures how
+// the business logic itself scales across the server's cores. One load test
+// runs at a time, bounded by -max-loadtest-duration and
+// -max-loadtest-concurrency.
+// ============================================================================
+
+// Load test operations
+const (
+	loadTestCalculateOrder  = "calculate-order"
+	loadTestValidateUser    = "validate-user"
+	loadTestValidateProduct = "validate-product"
+	loadTestMixed           = "mixed" // the three in turn
+)
+
+// maxLoadTestSamples bounds the latencies a load test keeps; beyond it each
+// worker keeps a uniform sample of its own.
+const maxLoadTestSamples = 1 << 20
+
+// loadTestRunning is set while a load test runs.
+var loadTestRunning atomic.Bool
+
+// loadTestRequest configures a load test. Zero values take the defaults.
+type loadTestRequest struct {
+	Operation   string  `json:"operation"`   // default calculate-order
+	Concurrency int     `json:"concurrency"` // workers; default 4
+	TargetRPS   float64 `json:"target_rps"`  // 0 runs flat out
+	DurationMs  int     `json:"duration_ms"` // default 1000
+	// ValidationCache validates through the server's validation cache
+	ValidationCache bool `json:"validation_cache"`
+}
+
+// loadTestLatency summarises the operations' latencies.
+type loadTestLatency struct {
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// loadTestAllocations are the process's allocations during the test, so
+// they include whatever else the server did meanwhile.
+type loadTestAllocations struct {
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	GCCycles    uint32  `json:"gc_cycles"`
+}
+
+// loadTestResult is the report of a load test.
+type loadTestResult struct {
+	Operation   string  `json:"operation"`
+	Concurrency int     `json:"concurrency"`
+	TargetRPS   float64 `json:"target_rps,omitempty"`
+	DurationMs  float64 `json:"duration_ms"` // as run
+	Operations  int     `json:"operations"`
+	// Dropped counts the operations a target rate called for while every
+	// worker was busy
+	Dropped     int                 `json:"dropped,omitempty"`
+	Throughput  float64             `json:"throughput_rps"`
+	Latency     loadTestLatency     `json:"latency"`
+	Allocations loadTestAllocations `json:"allocations"`
+	GOMAXPROCS  int                 `json:"gomaxprocs"`
+	Stopped     bool                `json:"stopped,omitempty"` // the client went away first
+	// ValidationCache counts the validations answered from the cache
+	ValidationCache *ValidationCacheStats `json:"validation_cache,omitempty"`
+}
+
+// loadTestWorkload returns the function operation i runs, validating
+// through validation. The calls share one copy of the demo data, which they
+// only read; i picks their user and products.
+func loadTestWorkload(operation string, validation *validationRun) (func(i int), bool) {
+	users, products := generateDemoUsers(), generateDemoProducts()
+	calculate := func(i int) {
+		first := i % len(products)
+		order := Order{
+			UserID:     users[i%len(users)].ID,
+			Products:   []Product{products[first], products[(first+1)%len(products)]},
+			Quantities: []int{1 + i%3, 1},
+		}
+		CalculateOrderTotal(&order, users[i%len(users)])
+	}
+	validateUser := func(i int) { validation.validateUser(users[i%len(users)]) }
+	validateProduct := func(i int) { validation.validateProduct(products[i%len(products)]) }
+
+	switch operation {
+	case loadTestCalculateOrder:
+		return calculate, true
+	case loadTestValidateUser:
+		return validateUser, true
+	case loadTestValidateProduct:
+		return validateProduct, true
+	case loadTestMixed:
+		mixed := []func(int){calculate, validateUser, validateProduct}
+		return func(i int) { mixed[i%len(mixed)](i) }, true
+	}
+	return nil, false
+}
+
+// loadTestWorker is one goroutine's latencies.
+type loadTestWorker struct {
+	ops     int
+	samples []float64 // ms
+	limit   int
+	rng     *rand.Rand
+}
+
+// record keeps a latency, replacing a random sample once limit is reached
+// (reservoir sampling) so long runs keep a fair sample.
+func (lw *loadTestWorker) record(ms float64) {
+	lw.ops++
+	if len(lw.samples) < lw.limit {
+		lw.samples = append(lw.samples, ms)
+	} else if j := lw.rng.IntN(lw.ops); j < lw.limit {
+		lw.samples[j] = ms
+	}
+}
+
+// runLoadTest runs op from concurrency workers for duration, at targetRPS
+// operations a second when positive. It stops early when ctx is done.
+func runLoadTest(ctx context.Context, op func(i int), concurrency int, targetRPS float64, duration time.Duration) loadTestResult {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var next atomic.Int64
+	var ticks chan struct{}
+	dropped := 0
+	if targetRPS > 0 {
+		ticks = make(chan struct{}, concurrency)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	workers := make([]loadTestWorker, concurrency)
+	var wg sync.WaitGroup
+	for w := range workers {
+		workers[w] = loadTestWorker{limit: maxLoadTestSamples / concurrency, rng: rand.New(rand.NewPCG(uint64(w), 0))}
+		wg.Add(1)
+		go func(lw *loadTestWorker) {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					if _, ok := <-ticks; !ok {
+						return
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+				began := time.Now()
+				op(int(next.Add(1)))
+				lw.record(float64(time.Since(began).Nanoseconds()) / 1e6)
+			}
+		}(&workers[w])
+	}
+
+	// A target rate hands out the operations due since the start every
+	// interval (a millisecond at least); those due while every worker is
+	// busy are dropped rather than queued
+	if ticks != nil {
+		ticker := time.NewTicker(max(time.Duration(float64(time.Second)/targetRPS), time.Millisecond))
+		issued := 0
+		for running := true; running; {
+			select {
+			case <-ticker.C:
+				due := int(time.Since(start).Seconds()*targetRPS) - issued
+				for ; due > 0; due-- {
+					select {
+					case ticks <- struct{}{}:
+					default:
+						dropped++
+					}
+					issued++
+				}
+			case <-ctx.Done():
+				running = false
+			}
+		}
+		ticker.Stop()
+		close(ticks)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	result := loadTestResult{
+		Concurrency: concurrency,
+		TargetRPS:   targetRPS,
+		DurationMs:  roundTo(float64(elapsed.Nanoseconds())/1e6, 3),
+		Dropped:     dropped,
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		Stopped:     errors.Is(context.Cause(ctx), context.Canceled),
+	}
+	var samples []float64
+	for _, lw := range workers {
+		result.Operations += lw.ops
+		samples = append(samples, lw.samples...)
+	}
+	if result.Operations == 0 {
+		return result
+	}
+	sort.Float64s(samples)
+	result.Throughput = roundTo(float64(result.Operations)/elapsed.Seconds(), 1)
+	result.Latency = loadTestLatency{
+		MeanMs: roundTo(mean(samples), 6),
+		P50Ms:  roundTo(Quantile(samples, 0.50), 6),
+		P90Ms:  roundTo(Quantile(samples, 0.90), 6),
+		P99Ms:  roundTo(Quantile(samples, 0.99), 6),
+		MaxMs:  roundTo(samples[len(samples)-1], 6),
+	}
+	result.Allocations = loadTestAllocations{
+		AllocsPerOp: roundTo(float64(after.Mallocs-before.Mallocs)/float64(result.Operations), 1),
+		BytesPerOp:  roundTo(float64(after.TotalAlloc-before.TotalAlloc)/float64(result.Operations), 1),
+		TotalBytes:  after.TotalAlloc - before.TotalAlloc,
+		GCCycles:    after.NumGC - before.NumGC,
+	}
+	return result
+}
+
+// handleLoadTest runs a load test and reports it.
+func handleLoadTest(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req loadTestRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Operation == "" {
+		req.Operation = loadTestCalculateOrder
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = 4
+	}
+	if req.DurationMs == 0 {
+		req.DurationMs = 1000
+	}
+
+	fields := map[string]string{}
+	validation := &validationRun{}
+	if req.ValidationCache {
+		validation.cache = validationCache
+		if validationCache == nil {
+			fields["validation_cache"] = "the server's validation cache is off (-validation-cache-size 0)"
+		}
+	}
+	op, ok := loadTestWorkload(req.Operation, validation)
+	if !ok {
+		fields["operation"] = "must be calculate-order, validate-user, validate-product or mixed"
+	}
+	if req.Concurrency < 1 || req.Concurrency > serverConfig.MaxLoadTestConcurrency {
+		fields["concurrency"] = "must be between 1 and " + strconv.Itoa(serverConfig.MaxLoadTestConcurrency)
+	}
+	if req.TargetRPS < 0 {
+		fields["target_rps"] = "must not be negative"
+	}
+	duration := time.Duration(req.DurationMs) * time.Millisecond
+	if duration <= 0 || duration > serverConfig.MaxLoadTestDuration {
+		fields["duration_ms"] = "must be positive and at most " + strconv.FormatInt(serverConfig.MaxLoadTestDuration.Milliseconds(), 10)
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	if !loadTestRunning.CompareAndSwap(false, true) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(serverConfig.MaxLoadTestDuration.Seconds())+1))
+		writeError(w, http.StatusServiceUnavailable, "A load test is already running")
+		return
+	}
+	defer loadTestRunning.Store(false)
+
+	result := runLoadTest(r.Context(), op, req.Concurrency, req.TargetRPS, duration)
+	result.Operation = req.Operation
+	result.ValidationCache = validation.stats()
+	writeJSON(w, r, http.StatusOK, result)
+}
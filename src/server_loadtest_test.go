@@ -0,0 +1,103 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTestEndpoint(t *testing.T) {
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		newServerMux().ServeHTTP(w, httptest.NewRequest("POST", "/api/loadtest", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"operation": "mixed", "concurrency": 2, "duration_ms": 50}`)
+	var result loadTestResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected a report, got %d: %v", w.Code, err)
+	}
+	if result.Operation != "mixed" || result.Concurrency != 2 || result.Operations == 0 || result.Throughput <= 0 || result.DurationMs < 50 {
+		t.Errorf("Unexpected report: %+v", result)
+	}
+	latency := result.Latency
+	if latency.P50Ms <= 0 || latency.P50Ms > latency.P90Ms || latency.P90Ms > latency.P99Ms || latency.P99Ms > latency.MaxMs {
+		t.Errorf("Expected ordered percentiles, got %+v", latency)
+	}
+	if result.Allocations.AllocsPerOp <= 0 || result.GOMAXPROCS <= 0 {
+		t.Errorf("Expected allocation stats, got %+v", result)
+	}
+
+	// Validating the same few demo users over and over is nearly all hits
+	w = post(`{"operation": "validate-user", "concurrency": 2, "duration_ms": 50, "validation_cache": true}`)
+	result = loadTestResult{}
+	json.NewDecoder(w.Body).Decode(&result)
+	if cache := result.ValidationCache; cache == nil || cache.Hits+cache.Misses != int64(result.Operations) || cache.Hits == 0 {
+		t.Errorf("Expected the validations counted as cache hits, got %+v of %d", cache, result.Operations)
+	}
+
+	w = post(`{"operation": "delete-everything", "concurrency": 1000, "target_rps": -1, "duration_ms": 3600000}`)
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusBadRequest || len(body.Error.Fields) != 4 {
+		t.Errorf("Expected the four fields rejected, got %d %+v", w.Code, body.Error)
+	}
+
+	// One load test at a time
+	loadTestRunning.Store(true)
+	w = post(`{}`)
+	loadTestRunning.Store(false)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 while a load test runs, got %d", w.Code)
+	}
+}
+
+func TestRunLoadTestTargetRate(t *testing.T) {
+	op, _ := loadTestWorkload(loadTestValidateUser, &validationRun{})
+	result := runLoadTest(context.Background(), op, 2, 500, 200*time.Millisecond)
+	// 100 operations are due; allow for scheduling on a busy machine
+	if result.Operations < 50 || result.Operations > 101 || result.TargetRPS != 500 {
+		t.Errorf("Expected about 100 operations at 500/s for 200ms, got %+v", result)
+	}
+
+	// Operations due while every worker is busy are dropped
+	slow := func(int) { time.Sleep(20 * time.Millisecond) }
+	if result := runLoadTest(context.Background(), slow, 1, 1000, 100*time.Millisecond); result.Dropped == 0 || result.Operations > 10 {
+		t.Errorf("Expected a saturated worker to drop operations, got %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := runLoadTest(ctx, op, 1, 0, time.Hour); !result.Stopped {
+		t.Errorf("Expected a cancelled load test to stop, got %+v", result)
+	}
+}
+
+func TestLoadTestSampling(t *testing.T) {
+	lw := loadTestWorker{limit: 10, rng: rand.New(rand.NewPCG(1, 0))}
+	for i := 0; i < 1000; i++ {
+		lw.record(float64(i))
+	}
+	if lw.ops != 1000 || len(lw.samples) != 10 {
+		t.Fatalf("Expected 1000 ops and 10 samples, got %d and %d", lw.ops, len(lw.samples))
+	}
+	late := 0
+	for _, sample := range lw.samples {
+		if sample >= 100 {
+			late++
+		}
+	}
+	if late == 0 {
+		t.Errorf("Expected the sample to reach past the first operations, got %v", lw.samples)
+	}
+}
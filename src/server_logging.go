@@ -0,0 +1,268 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// LOGGING
+// Application logs (slog and the log package) always go to stderr. With
+// -log-file they are also written there as JSON lines, one object per
+// record, and -access-log adds a record for every HTTP request. Records
+// logged for a request, the business logic's included, carry its
+// request_id (shared_logging.go):
+//
+//   {"time":"...","level":"INFO","msg":"request","method":"GET","path":"/api/demo-users","status":200,...}
+//
+// The file is rotated when it would grow past -log-max-size megabytes or is
+// older than -log-max-age; rotated files are renamed with a timestamp suffix
+// (server.log.20261014T093000.000) and only the newest -log-max-backups kept.
+// ============================================================================
+
+// setupLogging installs the default logger for cfg. The returned function
+// closes the log file.
+func setupLogging(cfg *ServerConfig) (func() error, error) {
+	level, err := cfg.slogLevel()
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogFile == "" {
+		slog.SetDefault(slog.New(logIDHandler{slog.NewTextHandler(os.Stderr, opts)}))
+		return func() error { return nil }, nil
+	}
+
+	file, err := openRotatingFile(cfg.LogFile, int64(cfg.LogMaxSizeMB)<<20, cfg.LogMaxAge, cfg.LogMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(logIDHandler{multiHandler{
+		slog.NewTextHandler(os.Stderr, opts),
+		slog.NewJSONHandler(file, opts),
+	}}))
+	return file.Close, nil
+}
+
+// multiHandler sends each record to every handler that accepts its level.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// rotatingFile is an append-only file that rotates by size and age.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	now        func() time.Time
+
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+var _ io.WriteCloser = (*rotatingFile)(nil)
+
+// openRotatingFile opens path for appending, creating its directory.
+func openRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open log file: %w", err)
+	}
+	rf.file, rf.size = file, info.Size()
+	// A file carried over from an earlier run ages from its last write
+	rf.created = rf.now()
+	if info.Size() > 0 {
+		rf.created = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p, rotating first if p would overflow the file or the file
+// has reached its maximum age. A single write is never split across files.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	tooBig := rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize
+	tooOld := rf.maxAge > 0 && rf.now().Sub(rf.created) >= rf.maxAge
+	if tooBig || tooOld {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file aside, opens a fresh one and removes
+// backups beyond maxBackups.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+
+	backup := rf.path + "." + rf.now().UTC().Format("20060102T150405.000")
+	if err := os.Rename(rf.path, backup); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return err
+	}
+	// Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for len(backups) > rf.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Close closes the current file.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// Access log
+// ----------------------------------------------------------------------------
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(p)
+	sw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.NewResponseController reach the connection, which the
+// event streams need to flush.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// accessLogMiddleware logs every request once it has been answered. It does
+// nothing unless -access-log is set.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	if !serverConfig.AccessLog {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		meta := metaFor(r)
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", sw.bytes),
+			slog.Float64("duration_ms", meta.DurationMs),
+			slog.String("request_id", meta.RequestID),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
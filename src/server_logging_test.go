@@ -0,0 +1,99 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotatingFile tests rotation by size and age and backup pruning
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "server.log")
+	file, err := openRotatingFile(path, 10, time.Hour, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	file.now = func() time.Time { return now }
+	file.created = now
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		now = now.Add(time.Second)
+		file.Write([]byte(line))
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("Expected the 2 newest backups to be kept, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "bbbbbb\n" {
+		t.Errorf("Expected the oldest backup to be pruned, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "dddddd\n" {
+		t.Errorf("Expected the current file to hold the last write, got %q", data)
+	}
+
+	// A small write still rotates once the file is old enough
+	now = now.Add(time.Hour)
+	file.Write([]byte("e\n"))
+	if data, _ := os.ReadFile(path); string(data) != "e\n" {
+		t.Errorf("Expected an age-based rotation, got %q", data)
+	}
+}
+
+// TestAccessLog tests the structured access log record
+func TestAccessLog(t *testing.T) {
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AccessLog = true })
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := httptest.NewRequest("GET", "/api/demo-users", nil)
+	req.Header.Set(requestIDHeader, "access-1")
+	newServerHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q", buf.String())
+	}
+	if record["msg"] != "request" || record["path"] != "/api/demo-users" || record["status"] != float64(http.StatusOK) ||
+		record["request_id"] != "access-1" || record["bytes"].(float64) == 0 {
+		t.Errorf("Unexpected access log record: %v", record)
+	}
+
+	// Disabled by default
+	buf.Reset()
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AccessLog = false })
+	newServerHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/demo-users", nil))
+	if strings.Contains(buf.String(), "request") {
+		t.Errorf("Expected no access log without -access-log, got %q", buf.String())
+	}
+}
+
+// TestBusinessLogicLogsRequestID tests that the business logic's records
+// for a request carry its request ID
+func TestBusinessLogicLogsRequestID(t *testing.T) {
+	withDemoStore(t)
+	buf := captureLogs(t)
+
+	req := httptest.NewRequest("POST", "/api/validate-user", strings.NewReader(`{"name": "x"}`))
+	req.Header.Set(requestIDHeader, "logged-1")
+	newServerHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	records := logRecords(t, buf)
+	if len(records) == 0 || records[0]["msg"] != "validation failed" || records[0]["request_id"] != "logged-1" {
+		t.Errorf("Expected the failed validation logged under the request ID, got %q", buf.String())
+	}
+}
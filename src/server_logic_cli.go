@@ -0,0 +1,134 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// The logic subcommand runs the business logic on JSON from stdin, for
+// scripts and for checking that the Go, WASM and HTTP outputs agree:
+//
+//	./server logic validate-user < user.json
+//	./server logic calculate-order < order-request.json
+//
+// The input is the body of the command's API endpoint and the output is the
+// endpoint's response body, because each command is served by that endpoint's
+// handler, without a server or its storage. main blends the demo orders into
+// recommendations first, as the server does at startup.
+
+// logicCommand is a command of the logic subcommand.
+type logicCommand struct {
+	Path    string // the endpoint the command answers for
+	Handler http.HandlerFunc
+}
+
+var logicCommands = map[string]logicCommand{
+	"validate-user":      {"/api/validate-user", handleValidateUser},
+	"validate-product":   {"/api/validate-product", handleValidateProduct},
+	"calculate-order":    {"/api/calculate-order", handleCalculateOrder},
+	"recommend-products": {"/api/recommend-products", handleRecommendProducts},
+}
+
+// runLogicCommand runs the logic subcommand with args and returns its exit
+// code: 0 for a result, 1 when the input is rejected or fails validation,
+// 2 when the command line or stdin can't be read.
+func runLogicCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("logic", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	rulesFile := fs.String("validation-rules-file", "", "JSON validation ruleset to validate with instead of the built-in rules")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: server logic [flags] <%s> < input.json\n", strings.Join(logicCommandNames(), "|"))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	command, ok := logicCommands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "logic: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	if *rulesFile != "" {
+		if err := loadValidationRulesFile(*rulesFile); err != nil {
+			fmt.Fprintf(stderr, "logic: %v\n", err)
+			return 2
+		}
+	}
+	input, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "logic: reading stdin: %v\n", err)
+		return 2
+	}
+
+	response := newLogicResponse()
+	request, err := http.NewRequest("POST", command.Path, bytes.NewReader(input))
+	if err != nil {
+		fmt.Fprintf(stderr, "logic: %v\n", err)
+		return 2
+	}
+	request.Header.Set("Content-Type", "application/json")
+	command.Handler(response, request)
+
+	stdout.Write(response.body.Bytes())
+	if response.status >= 300 {
+		return 1
+	}
+	var result struct {
+		Valid *bool `json:"valid"`
+	}
+	if json.Unmarshal(response.body.Bytes(), &result) == nil && result.Valid != nil && !*result.Valid {
+		return 1
+	}
+	return 0
+}
+
+func logicCommandNames() []string {
+	names := make([]string, 0, len(logicCommands))
+	for name := range logicCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// logicResponse collects the response a handler writes for a command.
+type logicResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newLogicResponse() *logicResponse {
+	return &logicResponse{header: http.Header{}}
+}
+
+func (r *logicResponse) Header() http.Header { return r.header }
+
+func (r *logicResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *logicResponse) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
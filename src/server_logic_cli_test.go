@@ -0,0 +1,69 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestLogicCommandMatchesAPI tests that each command prints what its
+// endpoint responds
+func TestLogicCommandMatchesAPI(t *testing.T) {
+	mux := newServerMux()
+	inputs := map[string]string{
+		"validate-user":      `{"name": "Ann Lee", "email": "ann@example.com", "age": 30, "country": "US"}`,
+		"validate-product":   `{"name": "Lamp", "price": -1, "category": "garden"}`,
+		"calculate-order":    `{"order": {"products": [{"id": 1, "name": "Lamp", "price": 40, "category": "home"}], "quantities": [2]}, "user": {"country": "DE", "premium": true}}`,
+		"recommend-products": `{"user": {"id": 1, "country": "US"}, "products": [{"id": 1, "name": "Lamp", "price": 40, "category": "home", "rating": 4.5}], "order": {}}`,
+	}
+	for name, command := range logicCommands {
+		input, ok := inputs[name]
+		if !ok {
+			t.Errorf("%s: no test input", name)
+			continue
+		}
+
+		var stdout bytes.Buffer
+		code := runLogicCommand([]string{name}, strings.NewReader(input), &stdout, io.Discard)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", command.Path, strings.NewReader(input))
+		r.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+		if stdout.String() != w.Body.String() {
+			t.Errorf("%s printed\n%s\nthe API responded\n%s", name, stdout.String(), w.Body.String())
+		}
+
+		want := 0
+		if name == "validate-product" {
+			want = 1 // invalid
+		}
+		if code != want {
+			t.Errorf("%s exit code = %d, want %d", name, code, want)
+		}
+	}
+}
+
+func TestLogicCommandErrors(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+		code  int
+	}{
+		{[]string{"calculate-order"}, `{"order": {"products": []}, "user": {"country": "US"}}`, 1},
+		{[]string{"validate-user"}, `{bad`, 1},
+		{[]string{"refund-order"}, `{}`, 2},
+		{[]string{}, `{}`, 2},
+		{[]string{"-validation-rules-file", "missing.json", "validate-user"}, `{}`, 2},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runLogicCommand(tt.args, strings.NewReader(tt.input), &stdout, &stderr); code != tt.code {
+			t.Errorf("logic %q: exit code = %d, want %d (%s%s)", tt.args, code, tt.code, stdout.String(), stderr.String())
+		}
+	}
+}
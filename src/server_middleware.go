@@ -0,0 +1,37 @@
+//go:build !wasm
+
+package main
+
+import "net/http"
+
+// ============================================================================
+// HTTP MIDDLEWARE
+// Cross-cutting handlers wrapped around the router in newServerHandler.
+// ============================================================================
+
+// newServerHandler returns the router wrapped in the server's middleware.
+func newServerHandler() http.Handler {
+	return chain(newServerMux(), requestMetaMiddleware, accessLogMiddleware, crossOriginIsolationMiddleware, sandboxMiddleware, faultInjectionMiddleware)
+}
+
+// crossOriginIsolationMiddleware sets Cross-Origin-Opener-Policy and
+// Cross-Origin-Embedder-Policy so pages become cross-origin isolated, which
+// browsers require before exposing SharedArrayBuffer to threaded WASM.
+//
+// COEP defaults to require-corp; set -coep-policy=credentialless to keep
+// loading cross-origin resources (such as the Swagger UI CDN bundle) that
+// don't send Cross-Origin-Resource-Policy headers.
+func crossOriginIsolationMiddleware(next http.Handler) http.Handler {
+	if !serverConfig.EnableCrossOriginIsolation {
+		return next
+	}
+
+	embedderPolicy := serverConfig.COEPPolicy
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
+		w.Header().Set("Cross-Origin-Embedder-Policy", embedderPolicy)
+		w.Header().Set("Cross-Origin-Resource-Policy", "same-origin")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,54 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCrossOriginIsolation tests the config-gated COOP/COEP middleware
+func TestCrossOriginIsolation(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.EnableCrossOriginIsolation = false })
+		w := httptest.NewRecorder()
+		crossOriginIsolationMiddleware(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		if got := w.Header().Get("Cross-Origin-Opener-Policy"); got != "" {
+			t.Errorf("Expected no COOP header, got %q", got)
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.EnableCrossOriginIsolation = true })
+		w := httptest.NewRecorder()
+		crossOriginIsolationMiddleware(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		expected := map[string]string{
+			"Cross-Origin-Opener-Policy":   "same-origin",
+			"Cross-Origin-Embedder-Policy": "require-corp",
+			"Cross-Origin-Resource-Policy": "same-origin",
+		}
+		for header, want := range expected {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("Header %s = %q, want %q", header, got, want)
+			}
+		}
+	})
+
+	t.Run("CustomEmbedderPolicy", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) {
+			cfg.EnableCrossOriginIsolation = true
+			cfg.COEPPolicy = "credentialless"
+		})
+		w := httptest.NewRecorder()
+		crossOriginIsolationMiddleware(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		if got := w.Header().Get("Cross-Origin-Embedder-Policy"); got != "credentialless" {
+			t.Errorf("COEP = %q, want credentialless", got)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ============================================================================
+// USER NOTIFICATIONS
+// How a user hears about their orders (shared_notifications.go), which
+// decides whether order webhook events carry a notification for them:
+//
+//   GET /api/users/{id}/notifications   the settings, defaults filled in
+//   PUT /api/users/{id}/notifications   {"channel": "email",
+//                                        "digest": "weekly"}
+//
+// A PUT replaces both settings and is an update of the user, so it names
+// the user's version (see OPTIMISTIC CONCURRENCY). Both answer with the
+// user's version as the ETag.
+// ============================================================================
+
+// userNotificationsRequest is the body of PUT /api/users/{id}/notifications.
+type userNotificationsRequest struct {
+	NotificationSettings
+	// Version is the user's version, unless If-Match has it
+	Version int `json:"version,omitempty"`
+}
+
+// userNotificationsResponse is a user's notification settings and version.
+type userNotificationsResponse struct {
+	UserID        int                  `json:"user_id"`
+	Version       int                  `json:"version"`
+	Notifications NotificationSettings `json:"notifications"`
+}
+
+// handleUserNotifications serves and replaces a user's notification
+// settings.
+func handleUserNotifications(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := privacyUserID(w, r)
+	if !ok {
+		return
+	}
+	store := storeFor(r)
+	if r.Method == "GET" {
+		user, found := findUser(store, id)
+		if !found {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		setVersionETag(w, user.Version)
+		writeJSON(w, r, http.StatusOK, userNotificationsResponse{UserID: id, Version: user.Version, Notifications: UserNotifications(user)})
+		return
+	}
+
+	var req userNotificationsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if result := ValidateNotificationSettings(req.NotificationSettings); !result.Valid {
+		fields := map[string]string{}
+		for _, fieldErr := range result.FieldErrors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+		writeFieldErrors(w, fields)
+		return
+	}
+	version, ok := requestVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	user, err := store.setUserNotifications(id, version, req.NotificationSettings)
+	if errors.Is(err, errUserNotFound) {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if writeVersionConflict(w, err) {
+		return
+	}
+	dataChanges.publish(sandboxID(r), entityUsers, changeUpdated, []int{id})
+	setVersionETag(w, user.Version)
+	writeJSON(w, r, http.StatusOK, userNotificationsResponse{UserID: id, Version: user.Version, Notifications: UserNotifications(user)})
+}
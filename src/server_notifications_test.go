@@ -0,0 +1,65 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserNotificationsEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	do := func(method, body, ifMatch string) (*httptest.ResponseRecorder, userNotificationsResponse) {
+		req := httptest.NewRequest(method, "/api/users/2/notifications", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var resp userNotificationsResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	w, resp := do("GET", "", "")
+	if w.Code != http.StatusOK || resp.Version != 1 || resp.Notifications != (NotificationSettings{Channel: NotificationEmail, Digest: DigestInstant}) {
+		t.Fatalf("Expected the default settings at version 1, got %d %+v", w.Code, resp)
+	}
+
+	body := `{"channel": "none"}`
+	if w, _ := do("PUT", body, ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected an update without a version refused, got %d", w.Code)
+	}
+	w, resp = do("PUT", body, `"1"`)
+	if w.Code != http.StatusOK || resp.Version != 2 || resp.Notifications.Channel != NotificationNone || resp.Notifications.Digest != DigestInstant {
+		t.Fatalf("Expected the settings saved at version 2, got %d %+v", w.Code, resp)
+	}
+	if w, _ := do("PUT", `{"channel": "email"}`, `"1"`); w.Code != http.StatusConflict {
+		t.Errorf("Expected an update against version 1 refused, got %d", w.Code)
+	}
+
+	// A user who wants no notifications gets none in order events
+	order := ordersWhere(demoStore, 2, "")[0]
+	if data := newOrderEventData(demoStore, eventOrderCreated, order, ""); data.Notification != nil {
+		t.Errorf("Expected no notification for user 2, got %+v", data.Notification)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("PUT", "/api/users/2/notifications", strings.NewReader(`{"channel": "none", "digest": "weekly", "version": 2}`)))
+	if fields := decodeErrorResponse(t, w).Fields; w.Code != http.StatusBadRequest || fields["digest"] == "" {
+		t.Errorf("Expected a field error for the digest, got %d %+v", w.Code, fields)
+	}
+
+	if w, _ := do("DELETE", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for DELETE, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/99/notifications", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown user, got %d", w.Code)
+	}
+}
@@ -0,0 +1,269 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// OPENAPI DOCUMENT GENERATION
+// Builds an OpenAPI 3 document from the route table in server_routes.go,
+// reflecting request/response schemas from the shared Go models' json tags.
+// ============================================================================
+
+const openAPIVersion = "3.0.3"
+
+// openAPISchema is a literal JSON schema, used where a payload has no Go type
+// (for example the map-shaped benchmark results).
+type openAPISchema map[string]interface{}
+
+// openAPISchemaBuilder reflects Go types into JSON schemas, collecting named
+// struct types into components/schemas and referencing them with $ref.
+type openAPISchemaBuilder struct {
+	components map[string]interface{}
+}
+
+func newOpenAPISchemaBuilder() *openAPISchemaBuilder {
+	return &openAPISchemaBuilder{components: map[string]interface{}{}}
+}
+
+// schemaFor returns the schema for an example value.
+func (b *openAPISchemaBuilder) schemaFor(example interface{}) interface{} {
+	if literal, ok := example.(openAPISchema); ok {
+		return map[string]interface{}(literal)
+	}
+	return b.schemaForType(reflect.TypeOf(example))
+}
+
+func (b *openAPISchemaBuilder) schemaForType(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.PkgPath() == "time" && t.Name() == "Time" {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaForType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, exists := b.components[name]; !exists {
+			// Reserve the name first so recursive types terminate
+			b.components[name] = map[string]interface{}{}
+			b.components[name] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		// interface{} and anything else: any JSON value
+		return map[string]interface{}{}
+	}
+}
+
+func (b *openAPISchemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		// Flatten embedded structs the same way encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedSchema := range b.structSchema(field.Type)["properties"].(map[string]interface{}) {
+				properties[embeddedName] = embeddedSchema
+			}
+			continue
+		}
+
+		properties[name] = b.schemaForType(field.Type)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// buildOpenAPISpec generates the OpenAPI document for the given routes.
+func buildOpenAPISpec(routes []apiRoute) map[string]interface{} {
+	builder := newOpenAPISchemaBuilder()
+	paths := map[string]interface{}{}
+	tagSet := map[string]bool{}
+
+	for _, route := range routes {
+		item := map[string]interface{}{}
+		for _, op := range route.Operations {
+			operation := map[string]interface{}{
+				"summary":     op.Summary,
+				"operationId": operationID(op.Method, route.Path),
+			}
+			if op.Tag != "" {
+				operation["tags"] = []string{op.Tag}
+				tagSet[op.Tag] = true
+			}
+
+			if len(op.Params) > 0 {
+				params := make([]interface{}, 0, len(op.Params))
+				for _, p := range op.Params {
+					schema := map[string]interface{}{"type": p.Type}
+					if p.Default != nil {
+						schema["default"] = p.Default
+					}
+					params = append(params, map[string]interface{}{
+						"name":        p.Name,
+						"in":          p.In,
+						"required":    p.In == "path",
+						"description": p.Description,
+						"schema":      schema,
+					})
+				}
+				operation["parameters"] = params
+			}
+
+			mediaTypes := []string{"application/json"}
+			if op.Negotiated {
+				mediaTypes = strings.Split(supportedMediaTypes(), ", ")
+			}
+			content := func(example interface{}) map[string]interface{} {
+				schema := builder.schemaFor(example)
+				content := map[string]interface{}{}
+				for _, mediaType := range mediaTypes {
+					content[mediaType] = map[string]interface{}{"schema": schema}
+				}
+				return content
+			}
+
+			if op.Request != nil {
+				operation["requestBody"] = map[string]interface{}{
+					"required": true,
+					"content":  content(op.Request),
+				}
+			}
+
+			responses := map[string]interface{}{}
+			if op.Response != nil {
+				responses["200"] = map[string]interface{}{
+					"description": "Successful response",
+					"content":     content(op.Response),
+				}
+			} else {
+				responses["200"] = map[string]interface{}{"description": "Successful response"}
+			}
+			errorContent := map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": builder.schemaFor(errorResponse{})},
+			}
+			if op.Request != nil {
+				responses["400"] = map[string]interface{}{"description": "Invalid request body", "content": errorContent}
+			}
+			responses["default"] = map[string]interface{}{"description": "Error", "content": errorContent}
+			operation["responses"] = responses
+
+			item[strings.ToLower(op.Method)] = operation
+		}
+		paths[route.Path] = item
+	}
+
+	tagNames := make([]string, 0, len(tagSet))
+	for tag := range tagSet {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	tags := make([]interface{}, len(tagNames))
+	for i, tag := range tagNames {
+		tags[i] = map[string]interface{}{"name": tag}
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "Go WebAssembly Demo API",
+			"description": "Server-side endpoints backed by the same Go business logic that runs in the browser via WebAssembly. Every path is also served under /api/v1 (identical responses) and /api/v2 (JSON responses wrapped in {data, meta}).",
+			"version":     apiVersion,
+		},
+		"tags":  tags,
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": builder.components,
+		},
+	}
+}
+
+// operationID derives a stable camelCase operation ID, e.g.
+// "POST /api/validate-user" -> "postValidateUser".
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(path, "/api"), func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '{' || r == '}'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// handleOpenAPISpec serves the generated OpenAPI document.
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildOpenAPISpec(apiRoutes()))
+}
+
+// handleAPIDocs serves a Swagger UI page pointed at /api/openapi.json.
+func handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Go WebAssembly Demo API</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.onload = () => {
+            window.ui = SwaggerUIBundle({
+                url: '/api/openapi.json',
+                dom_id: '#swagger-ui',
+            });
+        };
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,108 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPISpec tests the generated OpenAPI document
+func TestOpenAPISpec(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	newServerMux().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("Expected OpenAPI 3.x, got %q", spec.OpenAPI)
+	}
+
+	t.Run("EveryRouteDocumented", func(t *testing.T) {
+		for _, route := range apiRoutes() {
+			item, ok := spec.Paths[route.Path]
+			if !ok {
+				t.Errorf("Route %s missing from spec", route.Path)
+				continue
+			}
+			for _, op := range route.Operations {
+				if _, ok := item[strings.ToLower(op.Method)]; !ok {
+					t.Errorf("Operation %s %s missing from spec", op.Method, route.Path)
+				}
+			}
+		}
+	})
+
+	t.Run("ModelSchemasFromJSONTags", func(t *testing.T) {
+		user, ok := spec.Components.Schemas["User"]
+		if !ok {
+			t.Fatal("User schema missing from components")
+		}
+		for _, field := range []string{"id", "email", "name", "age", "country", "premium", "join_date"} {
+			if _, ok := user.Properties[field]; !ok {
+				t.Errorf("User schema missing property %q", field)
+			}
+		}
+
+		if _, ok := spec.Components.Schemas["ValidationResult"]; !ok {
+			t.Error("ValidationResult schema missing from components")
+		}
+	})
+
+	t.Run("RequestBodyReferencesComponent", func(t *testing.T) {
+		op := spec.Paths["/api/validate-user"]["post"]
+		body, _ := json.Marshal(op["requestBody"])
+		if !strings.Contains(string(body), "#/components/schemas/User") {
+			t.Errorf("Expected validate-user request body to reference User schema, got %s", body)
+		}
+	})
+}
+
+// TestAPIDocsPage tests that the Swagger UI page points at the spec
+func TestAPIDocsPage(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/docs", nil)
+	w := httptest.NewRecorder()
+	newServerMux().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "/api/openapi.json") {
+		t.Error("Expected docs page to load /api/openapi.json")
+	}
+}
+
+// TestOperationID tests operation ID derivation
+func TestOperationID(t *testing.T) {
+	tests := map[string]string{
+		"POST /api/validate-user":      "postValidateUser",
+		"GET /api/benchmark/matrix":    "getBenchmarkMatrix",
+		"GET /api/openapi.json":        "getOpenapiJson",
+		"GET /api/benchmark/jobs/{id}": "getBenchmarkJobsId",
+	}
+	for input, want := range tests {
+		parts := strings.SplitN(input, " ", 2)
+		if got := operationID(parts[0], parts[1]); got != want {
+			t.Errorf("operationID(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+//go:build !wasm
+
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// PRECOMPRESSED ASSETS
+// main.wasm compresses to roughly a quarter of its size. Rather than
+// compressing on every request, serve main.wasm.br or main.wasm.gz when the
+// client accepts it. build.sh produces both variants (brotli only when the
+// brotli CLI is installed) and the server regenerates a stale or missing .gz
+// at startup, since gzip is available in the standard library.
+// ============================================================================
+
+// precompressedExtensions lists the file types served from precompressed
+// variants when one is available.
+var precompressedExtensions = map[string]bool{
+	".wasm": true,
+}
+
+// contentEncodings in server preference order, with their file suffixes.
+var contentEncodings = []struct {
+	name   string
+	suffix string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding,
+// honouring q=0 exclusions and the "*" wildcard.
+func acceptsEncoding(header, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if name == encoding {
+			return quality > 0
+		}
+		if name == "*" {
+			wildcard = quality > 0
+		}
+	}
+	return wildcard
+}
+
+// precompressedVariant returns the encoding and file name of the best
+// precompressed variant of name the client accepts, or "" if none applies.
+// A variant older than its source is ignored so a rebuilt binary never
+// serves stale compressed bytes.
+func precompressedVariant(acceptEncoding, name string) (string, string) {
+	if !precompressedExtensions[filepath.Ext(name)] {
+		return "", ""
+	}
+
+	source, err := os.Stat(name)
+	if err != nil {
+		return "", ""
+	}
+
+	for _, enc := range contentEncodings {
+		if !acceptsEncoding(acceptEncoding, enc.name) {
+			continue
+		}
+		variant := name + enc.suffix
+		if info, err := os.Stat(variant); err == nil && !info.ModTime().Before(source.ModTime()) {
+			return enc.name, variant
+		}
+	}
+	return "", ""
+}
+
+// precompressStaticAssets writes a .gz variant next to every versioned asset
+// that can be served precompressed, when the variant is missing or stale.
+func precompressStaticAssets() error {
+	for _, asset := range versionedAssets {
+		name := serverConfig.StaticDir + asset
+		if !precompressedExtensions[filepath.Ext(name)] {
+			continue
+		}
+
+		source, err := os.Stat(name)
+		if err != nil {
+			continue // Asset not built yet
+		}
+		if info, err := os.Stat(name + ".gz"); err == nil && !info.ModTime().Before(source.ModTime()) {
+			continue
+		}
+
+		if err := gzipFile(name, name+".gz"); err != nil {
+			return fmt.Errorf("precompress %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func gzipFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a concurrent request never sees a
+	// partially written variant
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw, err := gzip.NewWriterLevel(tmp, gzip.BestCompression)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,96 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ============================================================================
+// USER PREFERENCES
+// A user's favourite and excluded categories, price sensitivity and
+// marketing opt-in (shared_preferences.go), which recommendations and
+// analytics filters go by:
+//
+//   GET /api/users/{id}/preferences   the preferences, empty if none are set
+//   PUT /api/users/{id}/preferences   {"favorite_categories": ["books"],
+//                                      "price_sensitivity": "high",
+//                                      "marketing_opt_in": true}
+//
+// A PUT replaces every preference and is an update of the user, so it names
+// the user's version (see OPTIMISTIC CONCURRENCY). Both answer with the
+// user's version as the ETag.
+// ============================================================================
+
+// userPreferencesRequest is the body of PUT /api/users/{id}/preferences.
+type userPreferencesRequest struct {
+	Preferences
+	// Version is the user's version, unless If-Match has it
+	Version int `json:"version,omitempty"`
+}
+
+// userPreferencesResponse is a user's preferences and version.
+type userPreferencesResponse struct {
+	UserID      int         `json:"user_id"`
+	Version     int         `json:"version"`
+	Preferences Preferences `json:"preferences"`
+}
+
+// handleUserPreferences serves and replaces a user's preferences.
+func handleUserPreferences(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := privacyUserID(w, r)
+	if !ok {
+		return
+	}
+	store := storeFor(r)
+	if r.Method == "GET" {
+		user, found := findUser(store, id)
+		if !found {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		setVersionETag(w, user.Version)
+		writeJSON(w, r, http.StatusOK, userPreferencesResponse{UserID: id, Version: user.Version, Preferences: UserPreferences(user)})
+		return
+	}
+
+	var req userPreferencesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if result := ValidatePreferences(req.Preferences); !result.Valid {
+		fields := map[string]string{}
+		for _, fieldErr := range result.FieldErrors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+		writeFieldErrors(w, fields)
+		return
+	}
+	version, ok := requestVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	user, err := store.setUserPreferences(id, version, req.Preferences)
+	if errors.Is(err, errUserNotFound) {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if writeVersionConflict(w, err) {
+		return
+	}
+	dataChanges.publish(sandboxID(r), entityUsers, changeUpdated, []int{id})
+	setVersionETag(w, user.Version)
+	writeJSON(w, r, http.StatusOK, userPreferencesResponse{UserID: id, Version: user.Version, Preferences: UserPreferences(user)})
+}
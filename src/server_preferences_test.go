@@ -0,0 +1,68 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserPreferencesEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	do := func(method, body, ifMatch string) (*httptest.ResponseRecorder, userPreferencesResponse) {
+		req := httptest.NewRequest(method, "/api/users/2/preferences", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var resp userPreferencesResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	w, resp := do("GET", "", "")
+	if w.Code != http.StatusOK || resp.UserID != 2 || resp.Version != 1 || resp.Preferences.MarketingOptIn || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected empty preferences at version 1, got %d %+v", w.Code, resp)
+	}
+
+	body := `{"favorite_categories": ["books"], "price_sensitivity": "high", "marketing_opt_in": true}`
+	if w, _ := do("PUT", body, ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected an update without a version refused, got %d", w.Code)
+	}
+	w, resp = do("PUT", body, `"1"`)
+	if w.Code != http.StatusOK || resp.Version != 2 || !resp.Preferences.MarketingOptIn || resp.Preferences.FavoriteCategories[0] != "books" {
+		t.Fatalf("Expected the preferences saved at version 2, got %d %+v", w.Code, resp)
+	}
+	if user, _ := findUser(demoStore, 2); UserPreferences(user).PriceSensitivity != PriceSensitivityHigh {
+		t.Errorf("Expected the stored user to have the preferences, got %+v", user)
+	}
+	if w, _ := do("PUT", `{"marketing_opt_in": false}`, `"1"`); w.Code != http.StatusConflict {
+		t.Errorf("Expected an update against version 1 refused, got %d", w.Code)
+	}
+
+	// The analytics filter finds the user by their preferences
+	users, _ := AnalyticsFilter{MarketingOptIn: true}.Apply(demoStore.listUsers(), nil)
+	if len(users) != 1 || users[0].ID != 2 {
+		t.Errorf("Expected only user 2 opted in, got %+v", users)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("PUT", "/api/users/2/preferences", strings.NewReader(`{"favorite_categories": ["garden"], "version": 2}`)))
+	if fields := decodeErrorResponse(t, w).Fields; w.Code != http.StatusBadRequest || fields["favorite_categories[0]"] == "" {
+		t.Errorf("Expected a field error for the unknown category, got %d %+v", w.Code, fields)
+	}
+
+	if w, _ := do("DELETE", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for DELETE, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/99/preferences", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown user, got %d", w.Code)
+	}
+}
@@ -0,0 +1,199 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// PRICE HISTORY
+// Every price change of a product is recorded (shared_price_history.go) for
+// price charts and was/now labels:
+//
+//   GET /api/products/{id}/price-history[?since=&until=&at=]
+//                                  the prices in effect between two RFC 3339
+//                                  times (default the last 90 days), the
+//                                  current was/now comparison and, with at,
+//                                  the price at that time
+//   PUT /api/products/{id}/price   {"price": 89.99} (admin token)
+//   GET /api/products/{id}/dynamic-price
+//                                  the demand-based price the pricing engine
+//                                  (shared_dynamic_pricing.go) quotes now
+//
+// With -dynamic-pricing on, the catalog, carts and checkout sell at the
+// dynamic prices; the price history keeps the list prices.
+// ============================================================================
+
+// defaultPriceHistorySpan is how far back a history goes without ?since=.
+const defaultPriceHistorySpan = 90 * 24 * time.Hour
+
+// priceHistoryResponse is a product's price history for a chart.
+type priceHistoryResponse struct {
+	ProductID  int             `json:"product_id"`
+	Currency   string          `json:"currency"`
+	History    PriceHistory    `json:"history"`
+	Comparison PriceComparison `json:"comparison"`
+	PriceAt    *float64        `json:"price_at,omitempty"`
+}
+
+// dynamicPriceResponse is a product's dynamic price quote. Applied reports
+// whether the catalog currently sells at it.
+type dynamicPriceResponse struct {
+	Currency string            `json:"currency"`
+	Applied  bool              `json:"applied"`
+	Quote    DynamicPriceQuote `json:"quote"`
+}
+
+// productPriceRequest is the body of PUT /api/products/{id}/price.
+type productPriceRequest struct {
+	Price float64 `json:"price" validate:"required"`
+	// Version is the product's version, unless If-Match has it
+	Version int `json:"version,omitempty"`
+}
+
+// priceProductID parses the {id} path value.
+func priceProductID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// handlePriceHistory returns a product's price history.
+func handlePriceHistory(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := priceProductID(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	fields := map[string]string{}
+	parseTime := func(name string, fallback time.Time) time.Time {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			return fallback
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fields[name] = "must be an RFC 3339 time"
+		}
+		return t
+	}
+	since := parseTime("since", now.Add(-defaultPriceHistorySpan))
+	until := parseTime("until", now)
+	at := parseTime("at", time.Time{})
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	store := storeFor(r)
+	history, err := store.priceHistory(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	product, _ := findProduct(store.listProducts(), id)
+	response := priceHistoryResponse{
+		ProductID:  id,
+		Currency:   currencyFor(product.Currency).Code,
+		History:    history.Between(since, until),
+		Comparison: ComparePrice(history, now, WasPricePeriod),
+	}
+	if !at.IsZero() {
+		if price, ok := history.PriceAt(at); ok {
+			response.PriceAt = &price
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// handleProductPrice changes a product's price and notifies data stream
+// subscribers.
+func handleProductPrice(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, ok := priceProductID(w, r)
+	if !ok {
+		return
+	}
+	var req productPriceRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	version, ok := requestVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	product, err := storeFor(r).setProductPrice(id, version, req.Price, time.Now())
+	if errors.Is(err, errProductNotFound) {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if writeVersionConflict(w, err) {
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dataChanges.publish(sandboxID(r), entityProducts, changeUpdated, []int{id})
+	setVersionETag(w, product.Version)
+	writeJSON(w, r, http.StatusOK, product)
+}
+
+// handleDynamicPrice quotes a product's demand-based price.
+func handleDynamicPrice(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := priceProductID(w, r)
+	if !ok {
+		return
+	}
+	store := storeFor(r)
+	quote, err := store.dynamicPrice(id, time.Now())
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	product, _ := findProduct(store.listProducts(), id)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, dynamicPriceResponse{
+		Currency: currencyFor(product.Currency).Code,
+		Applied:  serverConfig.DynamicPricing,
+		Quote:    quote,
+	})
+}
@@ -0,0 +1,85 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPriceHistoryEndpoints tests changing a price and reading its history
+func TestPriceHistoryEndpoints(t *testing.T) {
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	mux := newServerMux()
+	client := &cartClient{t: t, handler: mux}
+
+	var history priceHistoryResponse
+	json.NewDecoder(client.do("GET", "/api/products/1/price-history", "").Body).Decode(&history)
+	if len(history.History) != 2 || !history.Comparison.OnSale || history.Comparison.Was != 119.99 {
+		t.Fatalf("Expected the demo headphones on sale from 119.99, got %+v", history)
+	}
+
+	if w := client.do("PUT", "/api/products/8/price", `{"price": 24.99}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+	for body, status := range map[string]int{`{"price": 24.99}`: http.StatusOK, `{"price": -1}`: http.StatusBadRequest} {
+		req := httptest.NewRequest("PUT", "/api/products/8/price", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin")
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d: %s", body, status, w.Code, w.Body.String())
+		}
+	}
+
+	json.NewDecoder(client.do("GET", "/api/products/8/price-history?at=2000-01-01T00:00:00Z", "").Body).Decode(&history)
+	if len(history.History) != 2 || history.Comparison.Now != 24.99 || history.Comparison.DiscountPercent != 16.7 || history.PriceAt != nil {
+		t.Errorf("Expected the cookbook's price cut recorded, got %+v", history)
+	}
+	if w := client.do("GET", "/api/products/8/price-history?since=yesterday", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed time, got %d", w.Code)
+	}
+	if w := client.do("GET", "/api/products/99/price-history", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown product, got %d", w.Code)
+	}
+}
+
+// TestDynamicPricing tests the catalog and carts selling at dynamic prices
+func TestDynamicPricing(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	var quoted dynamicPriceResponse
+	json.NewDecoder(client.do("GET", "/api/products/1/dynamic-price", "").Body).Decode(&quoted)
+	if quoted.Applied || quoted.Quote.Price != 89.99 || quoted.Quote.BasePrice != 99.99 || quoted.Currency != "USD" {
+		t.Errorf("Expected an unapplied quote 10%% off the unsold headphones, got %+v", quoted)
+	}
+	var catalog []Product
+	json.NewDecoder(client.do("GET", "/api/demo-products", "").Body).Decode(&catalog)
+	if product, _ := findProduct(catalog, 1); product.Price != 99.99 {
+		t.Errorf("Expected list prices with dynamic pricing off, got %v", product.Price)
+	}
+
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.DynamicPricing = true })
+	json.NewDecoder(client.do("GET", "/api/demo-products", "").Body).Decode(&catalog)
+	if product, _ := findProduct(catalog, 1); product.Price != 89.99 {
+		t.Errorf("Expected the dynamic price in the catalog, got %v", product.Price)
+	}
+	if product, _ := findProduct(catalog, 6); product.Price != 699.99 {
+		t.Errorf("Expected the sold-out smartphone at its list price, got %v", product.Price)
+	}
+	summary := client.summary(client.do("POST", "/api/cart/items", `{"product_id": 1, "quantity": 2}`))
+	if summary.Items[0].PriceAtAdd != 89.99 || summary.Order.Subtotal != 89.99*2 {
+		t.Errorf("Expected the cart priced dynamically, got %+v", summary)
+	}
+
+	if w := client.do("GET", "/api/products/99/dynamic-price", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown product, got %d", w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// PERSONAL DATA REQUESTS
+// Data subject access and erasure (shared_privacy.go), carried out by an
+// administrator on a user's behalf:
+//
+//   GET  /api/users/{id}/export      the user, their orders, subscriptions
+//                                    and wishlist as one JSON download
+//                                    (admin token)
+//   POST /api/users/{id}/anonymize   scrub the user's personal data from the
+//                                    user and their orders, cancel their
+//                                    subscriptions and drop their wishlist
+//                                    (admin token)
+//
+// Anonymized users stay in the store so the analytics over them still add
+// up; exporting one afterwards shows what is left.
+// ============================================================================
+
+// userIDParam is the {id} of the user endpoints.
+var userIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "User ID"}
+
+// anonymizeUserResponse is the anonymized user and the orders scrubbed.
+type anonymizeUserResponse struct {
+	User     User  `json:"user"`
+	OrderIDs []int `json:"order_ids"`
+}
+
+// privacyUserID parses the {id} path value.
+func privacyUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// handleUserExport serves everything held about a user as a download.
+func handleUserExport(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, ok := privacyUserID(w, r)
+	if !ok {
+		return
+	}
+	export, err := storeFor(r).exportUserData(id, time.Now())
+	if err != nil {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-data.json"`, id))
+	writeJSON(w, r, http.StatusOK, export)
+}
+
+// handleUserAnonymize erases a user's personal data.
+func handleUserAnonymize(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, ok := privacyUserID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := requestVersion(w, r, 0)
+	if !ok {
+		return
+	}
+	user, orderIDs, err := storeFor(r).anonymizeUser(id, version)
+	if writeVersionConflict(w, err) {
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	dataChanges.publish(sandboxID(r), entityUsers, changeUpdated, []int{id})
+	if len(orderIDs) > 0 {
+		dataChanges.publish(sandboxID(r), entityOrders, changeUpdated, orderIDs)
+	}
+	setVersionETag(w, user.Version)
+	writeJSON(w, r, http.StatusOK, anonymizeUserResponse{User: user, OrderIDs: orderIDs})
+}
@@ -0,0 +1,89 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestUserDataRequests tests exporting and anonymizing a user's data
+func TestUserDataRequests(t *testing.T) {
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	address := &Address{Street: "1 Main St", City: "Austin", Region: "TX", PostalCode: "78701", Country: "US"}
+	order, err := demoStore.placeOrder(Order{UserID: 1, Products: []Product{{ID: 1, Name: "Laptop", Price: 999.99}}, Quantities: []int{1}, Status: "pending", ShippingAddress: address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	demoStore.addSubscription(Subscription{UserID: 1, Product: Product{ID: 2, Price: 29.99}, Quantity: 1, Interval: "monthly", Status: SubscriptionActive})
+	demoStore.updateWishlist(1, func(wishlist *Wishlist) error {
+		wishlist.Items = append(wishlist.Items, WishlistItem{ProductID: 3})
+		return nil
+	})
+	mux := newServerMux()
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	before := AnalyzeUserBehavior(demoStore.listUsers(), demoStore.listOrders(), AnalyticsFilter{})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/1/export", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+
+	w = do("GET", "/api/users/1/export")
+	var export UserDataExport
+	json.NewDecoder(w.Body).Decode(&export)
+	if w.Code != http.StatusOK || export.User.Email != "john.doe@example.com" || len(export.Subscriptions) != 1 || len(export.Wishlist.Items) != 1 {
+		t.Fatalf("Expected the user's records, got %d: %+v", w.Code, export)
+	}
+	if len(export.Orders) < 2 || export.Orders[len(export.Orders)-1].ShippingAddress.Street != "1 Main St" {
+		t.Errorf("Expected the user's orders with their addresses, got %+v", export.Orders)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="user-1-data.json"` {
+		t.Errorf("Expected a download, got Content-Disposition %q", got)
+	}
+
+	w = do("POST", "/api/users/1/anonymize")
+	var response anonymizeUserResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || !response.User.Anonymized || len(response.OrderIDs) != len(export.Orders) {
+		t.Fatalf("Expected the user anonymized, got %d: %s", w.Code, w.Body.String())
+	}
+	if response.User.Version != export.User.Version+1 || response.User.LoyaltyPoints != export.User.LoyaltyPoints {
+		t.Errorf("Expected the version bumped from %d and the loyalty points kept, got %+v", export.User.Version, response.User)
+	}
+
+	w = do("GET", "/api/users/1/export")
+	export = UserDataExport{}
+	json.NewDecoder(w.Body).Decode(&export)
+	if export.User.Name != anonymizedName || len(export.Wishlist.Items) != 0 || export.Subscriptions[0].Status != SubscriptionCancelled {
+		t.Errorf("Expected the personal data gone, got %+v", export)
+	}
+	for _, o := range export.Orders {
+		if o.ID == order.ID && (o.ShippingAddress.Street != "" || o.ShippingAddress.Country != "US") {
+			t.Errorf("Expected the shipping address reduced to its country, got %+v", o.ShippingAddress)
+		}
+	}
+
+	after := AnalyzeUserBehavior(demoStore.listUsers(), demoStore.listOrders(), AnalyticsFilter{})
+	if after.AverageAge != before.AverageAge || after.TotalRevenue != before.TotalRevenue || after.PremiumPercentage != before.PremiumPercentage {
+		t.Errorf("Expected the analytics unchanged, got %+v, was %+v", after, before)
+	}
+
+	if w := do("POST", "/api/users/999/anonymize"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown user, got %d", w.Code)
+	}
+	if w := do("GET", "/api/users/x/export"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid ID, got %d", w.Code)
+	}
+}
@@ -0,0 +1,134 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// PRODUCT LISTING AND DETAILS
+// GET /api/products lists the catalog through ListProducts, with the facets
+// a filter sidebar shows:
+//
+//   ?category=electronics   one category, any case
+//   ?min_price=25           priced at least this
+//   ?max_price=50           priced below this
+//   ?min_rating=4           rated at least this
+//   ?in_stock=true          only products that can be ordered
+//
+// GET /api/products/{id} is a product as priced in the catalog, with the
+// products whose names and descriptions are most like it (BuildTextIndex):
+//
+//   ?similar=5       how many similar products to list, 0 to 20
+//   ?thumbnail=160   the picture to show in a tile this many pixels square
+// ============================================================================
+
+// handleProducts lists the catalog products a filter selects, with facets.
+func handleProducts(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := ProductFilter{Category: strings.TrimSpace(query.Get("category"))}
+	fields := map[string]string{}
+	for name, value := range map[string]*float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice, "min_rating": &filter.MinRating} {
+		if raw := query.Get(name); raw != "" {
+			number, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				fields[name] = "must be a number"
+				continue
+			}
+			*value = number
+		}
+	}
+	if raw := query.Get("in_stock"); raw != "" {
+		inStock, err := strconv.ParseBool(raw)
+		if err != nil {
+			fields["in_stock"] = "must be true or false"
+		}
+		filter.InStock = inStock
+	}
+	if len(fields) == 0 {
+		for _, fieldErr := range ValidateProductFilter(filter).FieldErrors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	writeCacheableJSON(w, r, ListProducts(storeFor(r).catalog(time.Now()), filter))
+}
+
+// defaultSimilarProducts is how many similar products a detail lists.
+const defaultSimilarProducts = 5
+
+// productDetailResponse is the body of GET /api/products/{id}.
+type productDetailResponse struct {
+	Product
+	Similar   []SimilarProduct `json:"similar"`
+	Thumbnail *ProductImage    `json:"thumbnail,omitempty"`
+}
+
+// handleProductDetail serves a product with its similar products.
+func handleProductDetail(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := priceProductID(w, r)
+	if !ok {
+		return
+	}
+	n := defaultSimilarProducts
+	if raw := r.URL.Query().Get("similar"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > 20 {
+			writeFieldErrors(w, map[string]string{"similar": "must be a whole number from 0 to 20"})
+			return
+		}
+		n = value
+	}
+	thumbnailSize := 0
+	if raw := r.URL.Query().Get("thumbnail"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > MaxImageSidePx {
+			writeFieldErrors(w, map[string]string{"thumbnail": fmt.Sprintf("must be a whole number of pixels from 1 to %d", MaxImageSidePx)})
+			return
+		}
+		thumbnailSize = value
+	}
+
+	catalog := storeFor(r).catalog(time.Now())
+	product, found := findProduct(catalog, id)
+	if !found {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	setVersionETag(w, product.Version)
+	resp := productDetailResponse{Product: product, Similar: BuildTextIndex(catalog).SimilarProducts(id, n)}
+	if thumbnailSize > 0 {
+		if thumbnail, ok := product.SelectThumbnail(thumbnailSize); ok {
+			resp.Thumbnail = &thumbnail
+		}
+	}
+	writeJSON(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,91 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProductDetail tests the detail endpoint's similar products
+func TestProductDetail(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/products/3", nil))
+	var detail productDetailResponse
+	json.NewDecoder(w.Body).Decode(&detail)
+	if w.Code != http.StatusOK || detail.ID != 3 || detail.Name != "Programming Book" {
+		t.Fatalf("Expected the programming book, got %d %+v", w.Code, detail)
+	}
+	// "advanced" links the book to the smartphone across categories
+	if len(detail.Similar) == 0 || detail.Similar[0].ID != 6 {
+		t.Errorf("Expected the smartphone most similar, got %+v", detail.Similar)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/products/3?similar=0", nil))
+	detail = productDetailResponse{}
+	json.NewDecoder(w.Body).Decode(&detail)
+	if detail.Similar == nil || len(detail.Similar) != 0 {
+		t.Errorf("Expected an empty list for similar=0, got %#v", detail.Similar)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/products/3?thumbnail=200", nil))
+	detail = productDetailResponse{}
+	json.NewDecoder(w.Body).Decode(&detail)
+	if detail.Thumbnail == nil || detail.Thumbnail.Width != 480 || detail.Thumbnail.Alt != "Programming Book" {
+		t.Errorf("Expected the 480px picture for a 200px tile, got %+v", detail.Thumbnail)
+	}
+
+	for target, status := range map[string]int{
+		"/api/products/3?thumbnail=0": http.StatusBadRequest,
+		"/api/products/99":            http.StatusNotFound,
+		"/api/products/x":             http.StatusBadRequest,
+		"/api/products/3?similar=50":  http.StatusBadRequest,
+		"/api/products/3?similar=two": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
+
+// TestProductListing tests filtering the catalog with facets
+func TestProductListing(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/products?category=electronics&in_stock=true", nil))
+	var listing ProductListing
+	json.NewDecoder(w.Body).Decode(&listing)
+	// The smartphone is sold out
+	if w.Code != http.StatusOK || listing.Total != 1 || listing.Products[0].ID != 1 {
+		t.Fatalf("Expected only the headphones, got %d %+v", w.Code, listing.Products)
+	}
+	if len(listing.Facets.Categories) < 2 || len(listing.Facets.Prices) != 5 {
+		t.Errorf("Expected facets alongside the products, got %+v", listing.Facets)
+	}
+
+	for target, status := range map[string]int{
+		"/api/products":                            http.StatusOK,
+		"/api/products?min_price=abc":              http.StatusBadRequest,
+		"/api/products?min_price=50&max_price=25":  http.StatusBadRequest,
+		"/api/products?min_rating=7":               http.StatusBadRequest,
+		"/api/products?in_stock=maybe":             http.StatusBadRequest,
+		"/api/products?min_price=25&max_price=100": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
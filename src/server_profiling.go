@@ -0,0 +1,139 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// OPTIONAL PROFILING ENDPOINTS
+// net/http/pprof plus an on-demand CPU profile of a single benchmark, so the
+// "which side is faster and why" question can be answered with real profiles.
+//
+// Disabled by default. Enable with:
+//   ./server -enable-pprof -pprof-token=<secret>
+// or the equivalent ENABLE_PPROF/PPROF_TOKEN environment variables.
+//
+// Every profiling request must present the token, either as a bearer token
+// (Authorization: Bearer <secret>) or as the basic-auth password, which
+// lets `go tool pprof http://pprof:<secret>@localhost:8181/...` work as-is.
+// ============================================================================
+
+const (
+	defaultProfileDuration = 1 * time.Second
+	maxProfileDuration     = 10 * time.Second
+)
+
+// registerProfilingRoutes mounts /debug/pprof/* and /api/benchmark/profile on
+// mux when profiling is enabled and an auth token is configured.
+func registerProfilingRoutes(mux *http.ServeMux) {
+	if !serverConfig.EnablePprof {
+		return
+	}
+
+	token := serverConfig.PprofToken
+	if token == "" {
+		log.Println("⚠️  Profiling is enabled but no pprof token is configured - profiling endpoints not mounted")
+		return
+	}
+
+	mux.Handle("/debug/pprof/", requireProfilingToken(token, http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", requireProfilingToken(token, http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", requireProfilingToken(token, http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", requireProfilingToken(token, http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", requireProfilingToken(token, http.HandlerFunc(pprof.Trace)))
+	mux.Handle("/api/benchmark/profile", requireProfilingToken(token, http.HandlerFunc(handleBenchmarkProfile)))
+
+	fmt.Println("🔬 Profiling endpoints enabled at /debug/pprof/ and /api/benchmark/profile")
+}
+
+// requireProfilingToken rejects requests that don't carry the profiling token.
+func requireProfilingToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="pprof"`)
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBearerToken reports whether the request presents token as a bearer
+// token or as the basic-auth password.
+func hasBearerToken(r *http.Request, token string) bool {
+	presented := ""
+	if _, password, ok := r.BasicAuth(); ok {
+		presented = password
+	} else if auth := r.Header.Get("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+		presented = auth[7:]
+	}
+	return presented != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// handleBenchmarkProfile runs a server benchmark repeatedly under the CPU
+// profiler and returns the resulting pprof file.
+//
+//	GET /api/benchmark/profile?type=matrix&size=200&duration=2s
+//
+// The benchmark parameters are the same as the corresponding
+// /api/benchmark/{type} endpoint. The workload is repeated until duration
+// (default 1s, max 10s) has elapsed so the profile has enough samples.
+func handleBenchmarkProfile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	kind := query.Get("type")
+	if kind == "" {
+		kind = "matrix"
+	}
+
+	duration := defaultProfileDuration
+	if raw := query.Get("duration"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid duration")
+			return
+		}
+		duration = parsed
+	}
+	if duration > maxProfileDuration {
+		duration = maxProfileDuration
+	}
+
+	// Validate the benchmark parameters before starting the profiler
+	bench, err := prepareServerBenchmark(kind, query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profile bytes.Buffer
+	if err := runtimepprof.StartCPUProfile(&profile); err != nil {
+		// Only one CPU profile can run per process
+		writeError(w, http.StatusConflict, "A CPU profile is already in progress")
+		return
+	}
+
+	runs := 0
+	deadline := time.Now().Add(duration)
+	for runs == 0 || time.Now().Before(deadline) {
+		if _, err := bench.Run(r.Context()); err != nil {
+			break
+		}
+		runs++
+	}
+	runtimepprof.StopCPUProfile()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-cpu.pprof"`, kind))
+	w.Header().Set("X-Benchmark-Runs", strconv.Itoa(runs))
+	w.Write(profile.Bytes())
+}
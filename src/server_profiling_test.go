@@ -0,0 +1,86 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProfilingEndpoints tests that pprof is opt-in and token protected
+func TestProfilingEndpoints(t *testing.T) {
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.EnablePprof = false })
+		mux := newServerMux()
+
+		req := httptest.NewRequest("GET", "/api/benchmark/profile?type=matrix", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 when profiling is disabled, got %d", w.Code)
+		}
+	})
+
+	t.Run("EnabledWithoutTokenNotMounted", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.EnablePprof, cfg.PprofToken = true, "" })
+		mux := newServerMux()
+
+		req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 without a token, got %d", w.Code)
+		}
+	})
+
+	t.Run("RequiresToken", func(t *testing.T) {
+		withServerConfig(t, func(cfg *ServerConfig) { cfg.EnablePprof, cfg.PprofToken = true, "secret" })
+		mux := newServerMux()
+
+		req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 without credentials, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/debug/pprof/", nil)
+		req.SetBasicAuth("pprof", "secret")
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 with basic auth, got %d", w.Code)
+		}
+	})
+
+	t.Run("BenchmarkProfile", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/benchmark/profile?type=hash&count=1000&duration=50ms", nil)
+		w := httptest.NewRecorder()
+		handleBenchmarkProfile(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if w.Body.Len() == 0 {
+			t.Error("Expected a non-empty pprof file")
+		}
+		if w.Header().Get("X-Benchmark-Runs") == "" {
+			t.Error("Expected X-Benchmark-Runs header")
+		}
+	})
+
+	t.Run("UnknownBenchmarkType", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/benchmark/profile?type=unknown", nil)
+		w := httptest.NewRecorder()
+		handleBenchmarkProfile(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
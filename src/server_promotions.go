@@ -0,0 +1,65 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// PROMOTIONS
+// Campaigns evaluated by CalculateOrderTotal (shared_promotions.go):
+//
+//   GET /api/promotions   the promotions in use; ?active=YYYY-MM-DD lists
+//                         those running that day
+//   PUT /api/promotions   replace them with a JSON list (admin token)
+//
+// The list is the same for every sandbox, like the exchange rates. Pages
+// pass the GET response to setPromotionsWasm.
+// ============================================================================
+
+// handlePromotions lists or replaces the promotions.
+func handlePromotions(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		list := CurrentPromotions()
+		if day := r.URL.Query().Get("active"); day != "" {
+			date, err := time.Parse(taxDateLayout, day)
+			if err != nil {
+				writeFieldErrors(w, map[string]string{"active": "must be a YYYY-MM-DD date"})
+				return
+			}
+			running := []Promotion{}
+			for _, promotion := range list {
+				if promotion.Active(date) {
+					running = append(running, promotion)
+				}
+			}
+			list = running
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, list)
+	case "PUT":
+		if !requireAdmin(w, r) {
+			return
+		}
+		var list []Promotion
+		if !decodeJSONBody(w, r, &list) {
+			return
+		}
+		if err := CheckPromotions(list); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		SetPromotions(list)
+		writeJSON(w, r, http.StatusOK, CurrentPromotions())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
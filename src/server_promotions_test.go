@@ -0,0 +1,64 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPromotionsEndpoint tests replacing the promotions and checking out an
+// order with a free gift
+func TestPromotionsEndpoint(t *testing.T) {
+	withPromotions(t)
+	withDemoStore(t)
+	withSessions(t, 10)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	mux := newServerMux()
+	put := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/promotions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	gift := `[{"id": "gift-50", "name": "Free mug over 50", "kind": "free_gift", "min_subtotal": 50, "gift_product_id": 4},
+		{"id": "summer", "name": "Summer shipping", "kind": "free_shipping", "start_date": "2026-06-01", "end_date": "2026-08-31"}]`
+	if w := put("wrong", gift); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+	if w := put("admin", `[{"id": "x", "name": "X", "kind": "free_gift"}]`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a gift promotion without a gift, got %d", w.Code)
+	}
+	if w := put("admin", gift); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/promotions?active=2026-10-14", nil))
+	var running []Promotion
+	json.NewDecoder(w.Body).Decode(&running)
+	if len(running) != 1 || running[0].ID != "gift-50" {
+		t.Errorf("Expected only the gift promotion to be running, got %+v", running)
+	}
+
+	client := &cartClient{t: t, handler: mux}
+	mug, _ := findProduct(demoStore.listProducts(), 4)
+	client.do("POST", "/api/cart/items", `{"product_id": 1}`)
+	w = client.do("POST", "/api/cart/checkout", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if len(order.Promotions) != 1 || order.Promotions[0].GiftProductID != 4 {
+		t.Errorf("Expected the order to get the free mug, got %+v", order.Promotions)
+	}
+	if after, _ := findProduct(demoStore.listProducts(), 4); after.Available() != mug.Available()-1 {
+		t.Errorf("Expected the gift to be reserved, %d available before and %d after", mug.Available(), after.Available())
+	}
+}
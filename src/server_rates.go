@@ -0,0 +1,139 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// EXCHANGE RATES
+// The rate table used by ConvertPrice comes from, in order of preference:
+//
+//   -rates-url   a provider endpoint returning {"base": ..., "rates": {...}},
+//                fetched at startup and every -rates-refresh
+//   -rates-file  the same JSON read once at startup
+//   built-in     fixed demo rates
+//
+// A failed refresh keeps the last good table. GET /api/rates returns the
+// table; with ?amount=&from=&to= it converts an amount instead, formatted
+// for &locale= when given.
+// ============================================================================
+
+// maxRatesBytes bounds a rate table read from a file or provider.
+const maxRatesBytes = 1 << 20
+
+// conversionResponse is an amount converted by /api/rates.
+type conversionResponse struct {
+	Amount    float64 `json:"amount"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Result    float64 `json:"result"`
+	Formatted string  `json:"formatted"`
+}
+
+// loadExchangeRatesFile installs the rate table in a JSON file.
+func loadExchangeRatesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rates file: %w", err)
+	}
+	rates, err := ParseExchangeRates(data)
+	if err != nil {
+		return fmt.Errorf("rates file %s: %w", path, err)
+	}
+	SetExchangeRates(rates)
+	return nil
+}
+
+// fetchExchangeRates installs the rate table served by a provider.
+func fetchExchangeRates(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch rates: provider returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRatesBytes))
+	if err != nil {
+		return fmt.Errorf("fetch rates: %w", err)
+	}
+	rates, err := ParseExchangeRates(data)
+	if err != nil {
+		return fmt.Errorf("fetch rates: %w", err)
+	}
+	SetExchangeRates(rates)
+	return nil
+}
+
+// runRatesRefresh refetches the provider's rates until ctx is cancelled.
+func runRatesRefresh(ctx context.Context, client *http.Client, url string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fetchExchangeRates(ctx, client, url); err != nil {
+				slog.Warn("Keeping previous exchange rates", "error", err)
+			}
+		}
+	}
+}
+
+// handleRates serves the rate table or converts an amount.
+func handleRates(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	if !query.Has("amount") {
+		writeJSON(w, r, http.StatusOK, CurrentExchangeRates())
+		return
+	}
+
+	amount, err := strconv.ParseFloat(query.Get("amount"), 64)
+	if err != nil {
+		writeFieldErrors(w, map[string]string{"amount": "must be a number"})
+		return
+	}
+	from, to := query.Get("from"), query.Get("to")
+	result, err := ConvertPrice(amount, from, to)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	formatted := FormatCurrency(result, to)
+	if query.Has("locale") {
+		formatted = FormatMoney(result, to, query.Get("locale"))
+	}
+	writeJSON(w, r, http.StatusOK, conversionResponse{
+		Amount:    amount,
+		From:      currencyFor(from).Code,
+		To:        currencyFor(to).Code,
+		Result:    result,
+		Formatted: formatted,
+	})
+}
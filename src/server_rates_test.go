@@ -0,0 +1,80 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRatesEndpoint tests the rate table and conversions
+func TestRatesEndpoint(t *testing.T) {
+	withExchangeRates(t)
+	SetExchangeRates(defaultExchangeRates)
+
+	w := httptest.NewRecorder()
+	handleRates(w, httptest.NewRequest("GET", "/api/rates", nil))
+	var rates ExchangeRates
+	if err := json.NewDecoder(w.Body).Decode(&rates); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the rate table, got %d: %v", w.Code, err)
+	}
+	if rates.Base != "USD" || rates.Rates["EUR"] != 0.92 {
+		t.Errorf("Unexpected rate table: %+v", rates)
+	}
+
+	w = httptest.NewRecorder()
+	handleRates(w, httptest.NewRequest("GET", "/api/rates?amount=50&from=USD&to=eur", nil))
+	var conversion conversionResponse
+	json.NewDecoder(w.Body).Decode(&conversion)
+	if conversion.Result != 46 || conversion.To != "EUR" || conversion.Formatted != "€46.00" {
+		t.Errorf("Unexpected conversion: %+v", conversion)
+	}
+
+	w = httptest.NewRecorder()
+	handleRates(w, httptest.NewRequest("GET", "/api/rates?amount=5000&from=USD&to=eur&locale=de-DE", nil))
+	json.NewDecoder(w.Body).Decode(&conversion)
+	if conversion.Formatted != "4.600,00\u00a0€" {
+		t.Errorf("Expected a de-DE amount, got %q", conversion.Formatted)
+	}
+
+	for _, query := range []string{"amount=abc", "amount=1&to=XYZ"} {
+		w = httptest.NewRecorder()
+		handleRates(w, httptest.NewRequest("GET", "/api/rates?"+query, nil))
+		if w.Code == http.StatusOK {
+			t.Errorf("Expected %s to be rejected", query)
+		}
+	}
+}
+
+// TestFetchExchangeRates tests loading rates from a provider endpoint
+func TestFetchExchangeRates(t *testing.T) {
+	withExchangeRates(t)
+	SetExchangeRates(defaultExchangeRates)
+
+	body := `{"base": "USD", "rates": {"EUR": 0.5, "CAD": 1, "GBP": 1, "JPY": 100, "AUD": 1, "INR": 1, "BRL": 1, "MXN": 1}}`
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer provider.Close()
+
+	if err := fetchExchangeRates(context.Background(), provider.Client(), provider.URL+"/rates"); err != nil {
+		t.Fatalf("fetchExchangeRates() error = %v", err)
+	}
+	if got, _ := ConvertPrice(10, "USD", "EUR"); got != 5 {
+		t.Errorf("Expected the provider's rates to be used, got %v", got)
+	}
+
+	if err := fetchExchangeRates(context.Background(), provider.Client(), provider.URL+"/broken"); err == nil {
+		t.Error("Expected a failing provider to be reported")
+	}
+	if got, _ := ConvertPrice(10, "USD", "EUR"); got != 5 {
+		t.Errorf("Expected a failed fetch to keep the previous rates, got %v", got)
+	}
+}
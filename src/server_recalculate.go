@@ -0,0 +1,81 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// ORDER RECALCULATION
+// POST /api/orders/recalculate prices every stored order again with
+// RecalculateOrders - say after the tax tables or the promotions changed -
+// and reports the orders whose totals would change. The orders are left as
+// they are.
+//
+//   ?concurrency=4   orders priced at once, 1 to max-recalculate-workers
+//                    (GOMAXPROCS, within that limit, by default)
+//   ?limit=100       changed orders to list, 0 to 1000 (100 by default);
+//                    changed and differences count all of them
+// ============================================================================
+
+// maxRecalculationChanges is the most changed orders ?limit= may list.
+const maxRecalculationChanges = 1000
+
+// recalculateResponse is the body of POST /api/orders/recalculate.
+type recalculateResponse struct {
+	RecalculationReport
+	Workers    int     `json:"workers"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// handleRecalculateOrders prices the stored orders again and reports the
+// differences.
+func handleRecalculateOrders(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	fields := map[string]string{}
+	workers := min(runtime.GOMAXPROCS(0), serverConfig.MaxRecalculateWorkers)
+	if raw := r.URL.Query().Get("concurrency"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > serverConfig.MaxRecalculateWorkers {
+			fields["concurrency"] = "must be a whole number from 1 to " + strconv.Itoa(serverConfig.MaxRecalculateWorkers)
+		}
+		workers = value
+	}
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > maxRecalculationChanges {
+			fields["limit"] = "must be a whole number from 0 to " + strconv.Itoa(maxRecalculationChanges)
+		}
+		limit = value
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	store := storeFor(r)
+	start := time.Now()
+	report := RecalculateOrders(store.listOrders(), store.listUsers(), workers)
+	elapsed := time.Since(start)
+	if len(report.Changes) > limit {
+		report.Changes = report.Changes[:limit]
+	}
+	writeJSON(w, r, http.StatusOK, recalculateResponse{
+		RecalculationReport: report,
+		Workers:             workers,
+		DurationMs:          roundTo(float64(elapsed.Microseconds())/1000, 3),
+	})
+}
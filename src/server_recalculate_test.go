@@ -0,0 +1,73 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecalculateOrdersEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+	post := func(target string) (*httptest.ResponseRecorder, recalculateResponse) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", target, nil))
+		var resp recalculateResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	// The demo orders were written by hand, not priced at checkout
+	w, resp := post("/api/orders/recalculate")
+	if w.Code != http.StatusOK || resp.Orders != len(demoStore.listOrders()) || resp.Changed != resp.Orders || resp.Workers < 1 {
+		t.Fatalf("Expected every demo order to change, got %d %+v", w.Code, resp)
+	}
+
+	users := map[int]User{}
+	for _, user := range demoStore.listUsers() {
+		users[user.ID] = user
+	}
+	demoStore.mu.Lock()
+	for i, order := range demoStore.orders {
+		CalculateOrderTotal(&demoStore.orders[i], users[order.UserID])
+	}
+	demoStore.mu.Unlock()
+	if _, resp := post("/api/orders/recalculate"); resp.Changed != 0 {
+		t.Fatalf("Expected priced orders unchanged, got %+v", resp)
+	}
+
+	// Orders priced before the tax tables changed
+	demoStore.mu.Lock()
+	for i := range demoStore.orders[:2] {
+		demoStore.orders[i].Tax += 1
+		demoStore.orders[i].Total += 1
+		demoStore.orders[i].AmountDue += 1
+	}
+	stored := demoStore.orders[0].Total
+	demoStore.mu.Unlock()
+
+	w, resp = post("/api/orders/recalculate?concurrency=2&limit=1")
+	if w.Code != http.StatusOK || resp.Changed != 2 || len(resp.Changes) != 1 || resp.Workers != 2 {
+		t.Fatalf("Expected two changed orders with one listed, got %d %+v", w.Code, resp)
+	}
+	if change := resp.Changes[0]; change.Difference != -1 || change.After.Tax != change.Before.Tax-1 {
+		t.Errorf("Expected the total to fall by the extra tax, got %+v", change)
+	}
+	if demoStore.listOrders()[0].Total != stored {
+		t.Error("Expected the stored orders to be left as they are")
+	}
+
+	for _, target := range []string{"/api/orders/recalculate?concurrency=0", "/api/orders/recalculate?concurrency=1000", "/api/orders/recalculate?limit=-1"} {
+		if w, _ := post(target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", target, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/orders/recalculate", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", w.Code)
+	}
+}
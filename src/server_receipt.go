@@ -0,0 +1,73 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// RECEIPTS
+// GET /api/orders/{id}/receipt?kind=confirmation|receipt&locale=&format=
+// json|html|text returns an order's confirmation or receipt email
+// (shared_receipt.go): the Receipt by default, or the HTML and plain-text
+// renderings renderReceiptWasm previews in the browser. Without a locale the
+// customer's country picks it.
+// ============================================================================
+
+// handleOrderReceipt serves an order's confirmation or receipt.
+func handleOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	order, user, err := storeFor(r).orderWithUser(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	query := r.URL.Query()
+	receipt, err := GenerateReceipt(order, user, query.Get("kind"), query.Get("locale"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var body, contentType string
+	switch format := query.Get("format"); format {
+	case "", "json":
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, receipt)
+		return
+	case "html":
+		contentType = "text/html; charset=utf-8"
+		body, err = RenderReceiptHTML(receipt)
+	case "text":
+		contentType = "text/plain; charset=utf-8"
+		body, err = RenderReceiptText(receipt)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q (use json, html or text)", format))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to render receipt")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write([]byte(body))
+}
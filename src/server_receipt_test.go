@@ -0,0 +1,50 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOrderReceiptEndpoint tests the receipt kinds, locales and formats
+func TestOrderReceiptEndpoint(t *testing.T) {
+	withDemoStore(t)
+	order, err := demoStore.placeOrder(Order{UserID: 1, Products: []Product{{ID: 1, Name: "Laptop", Price: 999.99, Category: "electronics"}}, Quantities: []int{1}, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := newServerMux()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	path := "/api/orders/" + strconv.Itoa(order.ID) + "/receipt"
+
+	w := get(path + "?kind=confirmation")
+	var receipt Receipt
+	if err := json.NewDecoder(w.Body).Decode(&receipt); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the confirmation, got %d: %v", w.Code, err)
+	}
+	if receipt.Kind != ReceiptConfirmation || receipt.OrderID != order.ID || receipt.Total != order.Total || len(receipt.Lines) != 1 {
+		t.Errorf("Unexpected confirmation: %+v", receipt)
+	}
+
+	if w := get(path + "?format=html&locale=fr-FR"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "Reçu RCT-") {
+		t.Errorf("Unexpected HTML receipt %q: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+	if w := get(path + "?format=text&kind=confirmation&locale=ja-JP"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") || !strings.Contains(w.Body.String(), "ご注文確認 ORD-") {
+		t.Errorf("Unexpected text confirmation %q: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	for path, want := range map[string]int{path + "?format=pdf": http.StatusBadRequest, path + "?kind=refund": http.StatusBadRequest, "/api/orders/9999/receipt": http.StatusNotFound} {
+		if w := get(path); w.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============================================================================
+// COLLABORATIVE FILTERING
+// The item-item similarity matrix, trending list and cohort preferences
+// RecommendProducts blends in are built from the stored orders at startup
+// and again on request:
+//
+//   POST /api/recommendations/rebuild     rebuild from the order history
+//                                         (admin token)
+//   GET  /api/recommendations/similarity  the matrix in use
+//   GET  /api/recommendations/cohorts     the cohort preferences in use
+//
+// Like the exchange rates all are shared by every sandbox; a rebuild reads
+// the users and orders of the sandbox it is sent to. Pages pass the GET
+// responses to setItemSimilarityWasm and setCohortPreferencesWasm, and GET
+// /api/analytics/trending to setTrendingWasm.
+//
+// The scoring weights are kept in recommendation_weights.json under
+// -storage-path, so experiments survive a restart:
+//
+//   GET /api/recommendations/weights   the weights in use
+//   PUT /api/recommendations/weights   replace them (admin token); weights
+//                                      left out are reset to the defaults
+// ============================================================================
+
+// rebuildSummary describes a freshly built similarity matrix, trending list
+// and cohort preferences.
+type rebuildSummary struct {
+	Built    string `json:"built"`
+	Orders   int    `json:"orders"`
+	Products int    `json:"products"`
+	Pairs    int    `json:"pairs"`    // of products bought together
+	Trending int    `json:"trending"` // products sold in DefaultTrendingWindow
+	Cohorts  int    `json:"cohorts"`
+}
+
+// rebuildRecommendations builds and installs the matrix, trending list and
+// cohort preferences of a store's orders.
+func rebuildRecommendations(store *dataStore, now time.Time) rebuildSummary {
+	orders := store.listOrders()
+	similarity := BuildItemSimilarity(orders)
+	similarity.Built = now.UTC().Format(time.RFC3339)
+	SetItemSimilarity(similarity)
+	trending := TrendingProducts(orders, DefaultTrendingWindow)
+	SetTrending(trending)
+	cohorts := BuildCohortPreferences(store.listUsers(), orders)
+	cohorts.Built = similarity.Built
+	SetCohortPreferences(cohorts)
+
+	summary := rebuildSummary{Built: similarity.Built, Orders: similarity.Orders, Products: len(similarity.Scores), Trending: len(trending), Cohorts: len(cohorts.Cohorts)}
+	for _, neighbours := range similarity.Scores {
+		summary.Pairs += len(neighbours)
+	}
+	summary.Pairs /= 2
+	return summary
+}
+
+// handleRecommendationsRebuild rebuilds the similarity matrix, trending list
+// and cohort preferences.
+func handleRecommendationsRebuild(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, rebuildRecommendations(storeFor(r), time.Now()))
+}
+
+// handleItemSimilarity serves the similarity matrix in use.
+func handleItemSimilarity(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, CurrentItemSimilarity())
+}
+
+// handleCohortPreferences serves the cohort preferences in use.
+func handleCohortPreferences(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, CurrentCohortPreferences())
+}
+
+// recommendationWeightsPath is where the weights in use are persisted.
+func recommendationWeightsPath() string {
+	return filepath.Join(serverConfig.StoragePath, "recommendation_weights.json")
+}
+
+// loadRecommendationWeightsFile installs persisted weights; without a file
+// the defaults stay.
+func loadRecommendationWeightsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	weights, err := ParseRecommendationWeights(data)
+	if err != nil {
+		return err
+	}
+	SetRecommendationWeights(weights)
+	return nil
+}
+
+// saveRecommendationWeightsFile persists weights.
+func saveRecommendationWeightsFile(path string, weights RecommendationWeights) error {
+	data, err := json.MarshalIndent(weights, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, data)
+}
+
+// writeFileAtomically replaces the file at path whole, so a crash never
+// leaves half of it.
+func writeFileAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// handleRecommendationWeights serves or replaces the scoring weights.
+func handleRecommendationWeights(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, CurrentRecommendationWeights())
+	case "PUT":
+		if !requireAdmin(w, r) {
+			return
+		}
+		weights := DefaultRecommendationWeights
+		if !decodeJSONBody(w, r, &weights) {
+			return
+		}
+		fields := map[string]string{}
+		for _, fieldErr := range ValidateRecommendationWeights(weights).FieldErrors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+		if len(fields) > 0 {
+			writeFieldErrors(w, fields)
+			return
+		}
+		if err := saveRecommendationWeightsFile(recommendationWeightsPath(), weights); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to save recommendation weights")
+			return
+		}
+		SetRecommendationWeights(weights)
+		writeJSON(w, r, http.StatusOK, weights)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
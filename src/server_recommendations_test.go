@@ -0,0 +1,172 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRecommendationsRebuild tests that the rebuild endpoint installs the
+// matrix and cohorts of the stored orders and serves them back
+func TestRecommendationsRebuild(t *testing.T) {
+	withItemSimilarity(t)
+	withTrending(t)
+	withCohortPreferences(t)
+	withDemoStore(t)
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/recommendations/rebuild", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("POST", "/api/recommendations/rebuild", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var summary rebuildSummary
+	json.NewDecoder(w.Body).Decode(&summary)
+	if w.Code != http.StatusOK || summary.Orders == 0 || summary.Pairs == 0 || summary.Trending != len(CurrentTrending()) || summary.Trending == 0 {
+		t.Fatalf("Expected a matrix built from the demo orders, got %d %+v", w.Code, summary)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/recommendations/similarity", nil))
+	similarity, err := ParseItemSimilarity(w.Body.Bytes())
+	if err != nil || similarity.Built != summary.Built || similarity.Scores[3][4] == 0 {
+		t.Errorf("Expected the book and mug of a demo order to be similar, got %+v (%v)", similarity, err)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/recommendations/cohorts", nil))
+	cohorts, err := ParseCohortPreferences(w.Body.Bytes())
+	if err != nil || len(cohorts.Cohorts) != summary.Cohorts || cohorts.Cohorts[allCohorts].Orders != summary.Orders {
+		t.Errorf("Expected cohorts of the demo users' orders, got %+v (%v)", cohorts, err)
+	}
+}
+
+// TestRecommendProductsDiversity tests the request's diversity option
+func TestRecommendProductsDiversity(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+	mux := newServerMux()
+
+	catalog := []Product{
+		{ID: 1, Name: "Phone", Price: 100, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 2, Name: "Tablet", Price: 100, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 3, Name: "Laptop", Price: 100, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 4, Name: "Novel", Price: 10, Category: "books", OnHand: 5, Rating: 4},
+	}
+	recommend := func(diversity string) *httptest.ResponseRecorder {
+		products, _ := json.Marshal(catalog)
+		body := `{"user": {"age": 30}, "products": ` + string(products) + `, "order": {"products": [{"id": 1, "price": 100, "category": "electronics"}], "quantities": [1]}` + diversity + `}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/recommend-products", strings.NewReader(body)))
+		return w
+	}
+
+	for _, tt := range []struct {
+		diversity string
+		third     int
+	}{
+		{`, "diversity": {"weight": 0, "max_per_category": 0}`, 3},
+		{"", 4},
+	} {
+		w := recommend(tt.diversity)
+		var got []Recommendation
+		json.NewDecoder(w.Body).Decode(&got)
+		if w.Code != http.StatusOK || len(got) != 4 || got[2].ID != tt.third {
+			t.Errorf("With diversity %q expected product %d third, got %d %+v", tt.diversity, tt.third, w.Code, got)
+		}
+	}
+
+	w := recommend(`, "diversity": {"weight": 2}`)
+	if w.Code != http.StatusBadRequest || decodeErrorResponse(t, w).Fields["diversity.weight"] == "" {
+		t.Errorf("Expected an out of range weight to be rejected, got %d", w.Code)
+	}
+}
+
+// TestRecommendationWeightsEndpoint tests replacing, persisting and
+// reloading the scoring weights
+func TestRecommendationWeightsEndpoint(t *testing.T) {
+	withRecommendationWeights(t)
+	dir := t.TempDir()
+	withServerConfig(t, func(cfg *ServerConfig) {
+		cfg.AdminToken = "admin"
+		cfg.StoragePath = dir
+	})
+	mux := newServerMux()
+	put := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/recommendations/weights", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put(`{"category": 5}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the admin token, got %d", w.Code)
+	}
+	if w := put(`{"rating": -1}`, "admin"); w.Code != http.StatusBadRequest || decodeErrorResponse(t, w).Fields["rating"] == "" {
+		t.Errorf("Expected a negative weight to be rejected, got %d", w.Code)
+	}
+	if w := put(`{"category": 5}`, "admin"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/recommendations/weights", nil))
+	var weights RecommendationWeights
+	json.NewDecoder(w.Body).Decode(&weights)
+	if weights.Category != 5 || weights.Rating != DefaultRecommendationWeights.Rating {
+		t.Errorf("Expected the new category weight over the defaults, got %+v", weights)
+	}
+
+	// A restart reads the weights back from the storage path
+	SetRecommendationWeights(DefaultRecommendationWeights)
+	if err := loadRecommendationWeightsFile(recommendationWeightsPath()); err != nil || CurrentRecommendationWeights().Category != 5 {
+		t.Errorf("Expected the persisted weights to load, got %+v (%v)", CurrentRecommendationWeights(), err)
+	}
+	if err := loadRecommendationWeightsFile(dir + "/missing.json"); err != nil {
+		t.Errorf("Expected no file to keep the weights, got %v", err)
+	}
+}
+
+// TestRecommendProductsWeights tests per-request weights
+func TestRecommendProductsWeights(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+	mux := newServerMux()
+	recommend := func(weights string) *httptest.ResponseRecorder {
+		body := `{"user": {"age": 30}, "products": [{"id": 1, "name": "Novel", "price": 10, "category": "books", "on_hand": 5, "rating": 4}], "order": {"products": []}, "weights": ` + weights + `}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/recommend-products", strings.NewReader(body)))
+		return w
+	}
+
+	w := recommend(`{"rating": 1}`)
+	var got []Recommendation
+	json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusOK || len(got) != 1 || got[0].Score != 4 {
+		t.Errorf("Expected a score of 4 at one point per star, got %d %+v", w.Code, got)
+	}
+	if w := recommend(`{"category": -2}`); w.Code != http.StatusBadRequest || decodeErrorResponse(t, w).Fields["weights.category"] == "" {
+		t.Errorf("Expected a negative weight to be rejected, got %d", w.Code)
+	}
+}
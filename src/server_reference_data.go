@@ -0,0 +1,30 @@
+//go:build !wasm
+
+package main
+
+import "net/http"
+
+// ============================================================================
+// REFERENCE DATA
+// The tables the shared business logic prices, taxes and ships by
+// (shared_reference_data.go), in one response for pages and other clients:
+//
+//   GET /api/reference-data   markets, categories, currencies, tax rules,
+//                             shipping rates and countries
+//
+// assets/js/reference-data.js is the same response, generated for pages.
+// ============================================================================
+
+// handleReferenceData serves the reference tables.
+func handleReferenceData(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeNegotiated(w, r, http.StatusOK, BuildReferenceData())
+}
@@ -0,0 +1,48 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestReferenceDataEndpoint tests that the endpoint serves what pages load
+// from reference-data.js
+func TestReferenceDataEndpoint(t *testing.T) {
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/reference-data", nil))
+	var served map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&served); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("Expected the reference data, got %d: %v", w.Code, err)
+	}
+
+	script, err := os.ReadFile("../assets/js/reference-data.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, literal, _ := strings.Cut(string(script), "window.referenceData = ")
+	var generated map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(literal, ";\n")), &generated); err != nil {
+		t.Fatalf("reference-data.js: %v", err)
+	}
+	for key := range served {
+		a, _ := json.Marshal(served[key])
+		b, _ := json.Marshal(generated[key])
+		if string(a) != string(b) {
+			t.Errorf("%s differs between the endpoint and reference-data.js", key)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/reference-data", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", w.Code)
+	}
+}
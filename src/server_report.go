@@ -0,0 +1,346 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// BENCHMARK REPORT
+// GET /api/benchmark/report renders the benchmark history - server runs, the
+// native runner's and the pages' submissions - as one HTML page to share.
+// Each workload gets compareBenchmarks' table, a bar chart of the
+// environments' means with their min-max range, and a line chart of the daily
+// means. The charts are inline SVG and the styles inline, so the page has
+// nothing to fetch and reads the same saved to disk or attached to an issue.
+// ============================================================================
+
+//go:embed benchmark_report.html.tmpl
+var benchmarkReportSource string
+
+var benchmarkReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms": formatReportMs,
+}).Parse(benchmarkReportSource))
+
+// reportColors are the environments' chart colors, assigned in name order.
+var reportColors = []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#76b7b2", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// Chart geometry, in SVG user units
+const (
+	reportChartWidth  = 640
+	reportLabelWidth  = 150 // left of the bars, for environment names
+	reportValueWidth  = 90  // right of the bars, for the mean
+	reportBarHeight   = 22
+	reportBarGap      = 8
+	reportTrendHeight = 200
+	reportTrendMargin = 40
+)
+
+// benchmarkReport is what the report template executes on.
+type benchmarkReport struct {
+	GeneratedAt  string
+	Baseline     string
+	Filters      []string
+	Records      int
+	Environments []reportEnvironment
+	Groups       []reportGroup
+}
+
+// reportEnvironment summarises the records of one environment.
+type reportEnvironment struct {
+	Name      string
+	Color     string
+	Runs      int
+	Verified  int
+	Platforms string
+	CPUCounts string
+	Latest    string
+}
+
+// reportGroup is one workload of the report.
+type reportGroup struct {
+	benchmarkComparisonGroup
+	Colors map[string]string
+	Bars   reportBarChart
+	Trend  *reportTrendChart // nil with fewer than two days of runs
+}
+
+// reportBarChart draws each environment's mean as a bar, with a line over
+// its min-max range.
+type reportBarChart struct {
+	Width, Height float64
+	X, BarHeight  float64 // where the bars start, and how tall they are
+	Bars          []reportBar
+}
+
+type reportBar struct {
+	Label, Color, Value string
+	Y, Middle, Width    float64
+	RangeFrom, RangeTo  float64
+}
+
+// reportTrendChart draws each environment's daily means as a line.
+type reportTrendChart struct {
+	Width, Height       float64
+	Left, Right         float64
+	Top, Bottom         float64
+	FirstDate, LastDate string
+	MaxLabel            string
+	Lines               []reportLine
+}
+
+// reportLine is an environment's line, with a dot per day so a day on its
+// own still shows.
+type reportLine struct {
+	Environment, Color, Points string
+	Dots                       []reportDot
+}
+
+type reportDot struct {
+	X, Y  float64
+	Label string
+}
+
+// buildBenchmarkReport lays out the report of records, oldest first, against
+// the baseline environment.
+func buildBenchmarkReport(records []benchmarkRecord, baseline string, filters []string) benchmarkReport {
+	report := benchmarkReport{
+		GeneratedAt: time.Now().UTC().Format(time.RFC1123),
+		Baseline:    baseline,
+		Filters:     filters,
+		Records:     len(records),
+	}
+
+	byEnvironment := map[string][]benchmarkRecord{}
+	for _, rec := range records {
+		byEnvironment[rec.Environment] = append(byEnvironment[rec.Environment], rec)
+	}
+	names := make([]string, 0, len(byEnvironment))
+	for name := range byEnvironment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	colors := map[string]string{}
+	for i, name := range names {
+		colors[name] = reportColors[i%len(reportColors)]
+		report.Environments = append(report.Environments, summarizeReportEnvironment(name, colors[name], byEnvironment[name]))
+	}
+
+	for _, group := range compareBenchmarks(records, baseline).Groups {
+		report.Groups = append(report.Groups, reportGroup{
+			benchmarkComparisonGroup: group,
+			Colors:                   colors,
+			Bars:                     reportBars(group.Environments, colors),
+			Trend:                    reportTrend(group.Trend, colors),
+		})
+	}
+	return report
+}
+
+// summarizeReportEnvironment lists what an environment's records ran on.
+func summarizeReportEnvironment(name, color string, records []benchmarkRecord) reportEnvironment {
+	env := reportEnvironment{Name: name, Color: color, Runs: len(records)}
+	platforms, cpus := map[string]bool{}, map[int]bool{}
+	var latest time.Time
+	for _, rec := range records {
+		if rec.Verified {
+			env.Verified++
+		}
+		if rec.Metadata.Platform != "" {
+			platforms[rec.Metadata.Platform] = true
+		}
+		if rec.Metadata.CPUCount > 0 {
+			cpus[rec.Metadata.CPUCount] = true
+		}
+		if rec.RecordedAt.After(latest) {
+			latest = rec.RecordedAt
+		}
+	}
+	platformNames := make([]string, 0, len(platforms))
+	for platform := range platforms {
+		platformNames = append(platformNames, platform)
+	}
+	sort.Strings(platformNames)
+	counts := make([]int, 0, len(cpus))
+	for count := range cpus {
+		counts = append(counts, count)
+	}
+	sort.Ints(counts)
+	cpuNames := make([]string, len(counts))
+	for i, count := range counts {
+		cpuNames[i] = strconv.Itoa(count)
+	}
+
+	env.Platforms = strings.Join(platformNames, ", ")
+	env.CPUCounts = strings.Join(cpuNames, ", ")
+	if !latest.IsZero() {
+		env.Latest = latest.UTC().Format("2006-01-02 15:04")
+	}
+	return env
+}
+
+// reportBars scales the environments' means, fastest first, to the longest
+// max duration.
+func reportBars(stats []environmentStats, colors map[string]string) reportBarChart {
+	scale := 0.0
+	for _, s := range stats {
+		scale = math.Max(scale, s.MaxMs)
+	}
+	plot := float64(reportChartWidth - reportLabelWidth - reportValueWidth)
+	x := func(ms float64) float64 {
+		if scale <= 0 {
+			return reportLabelWidth
+		}
+		return roundTo(reportLabelWidth+ms/scale*plot, 1)
+	}
+
+	chart := reportBarChart{
+		Width:     reportChartWidth,
+		Height:    float64(len(stats)*(reportBarHeight+reportBarGap) + reportBarGap),
+		X:         reportLabelWidth,
+		BarHeight: reportBarHeight,
+	}
+	for i, s := range stats {
+		y := float64(reportBarGap + i*(reportBarHeight+reportBarGap))
+		chart.Bars = append(chart.Bars, reportBar{
+			Label:     s.Environment,
+			Color:     colors[s.Environment],
+			Value:     formatReportMs(s.MeanMs),
+			Y:         y,
+			Middle:    y + reportBarHeight/2,
+			Width:     x(s.MeanMs) - reportLabelWidth,
+			RangeFrom: x(s.MinMs),
+			RangeTo:   x(s.MaxMs),
+		})
+	}
+	return chart
+}
+
+// reportTrend plots the daily means of each environment over the days any
+// of them ran, or returns nil for a single day.
+func reportTrend(points []benchmarkTrendPoint, colors map[string]string) *reportTrendChart {
+	var dates []string
+	index := map[string]int{}
+	scale := 0.0
+	for _, point := range points {
+		if _, ok := index[point.Date]; !ok {
+			index[point.Date] = 0
+			dates = append(dates, point.Date)
+		}
+		scale = math.Max(scale, point.MeanMs)
+	}
+	if len(dates) < 2 || scale <= 0 {
+		return nil
+	}
+	sort.Strings(dates)
+	for i, date := range dates {
+		index[date] = i
+	}
+
+	chart := &reportTrendChart{
+		Width:     reportChartWidth,
+		Height:    reportTrendHeight,
+		Left:      reportTrendMargin * 2,
+		Right:     reportChartWidth - reportTrendMargin,
+		Top:       reportTrendMargin / 2,
+		Bottom:    reportTrendHeight - reportTrendMargin,
+		FirstDate: dates[0],
+		LastDate:  dates[len(dates)-1],
+		MaxLabel:  formatReportMs(scale),
+	}
+	// The points are in date order, so each line is too
+	lines := map[string]*reportLine{}
+	var order []string
+	for _, point := range points {
+		line, ok := lines[point.Environment]
+		if !ok {
+			line = &reportLine{Environment: point.Environment, Color: colors[point.Environment]}
+			lines[point.Environment] = line
+			order = append(order, point.Environment)
+		}
+		x := chart.Left + float64(index[point.Date])/float64(len(dates)-1)*(chart.Right-chart.Left)
+		y := chart.Bottom - point.MeanMs/scale*(chart.Bottom-chart.Top)
+		line.Dots = append(line.Dots, reportDot{X: roundTo(x, 1), Y: roundTo(y, 1), Label: point.Date + ": " + formatReportMs(point.MeanMs)})
+		line.Points = strings.TrimSpace(line.Points + fmt.Sprintf(" %.1f,%.1f", x, y))
+	}
+	sort.Strings(order)
+	for _, env := range order {
+		chart.Lines = append(chart.Lines, *lines[env])
+	}
+	return chart
+}
+
+// formatReportMs writes a duration in milliseconds, in seconds from one
+// second up and with fewer decimals the longer it is.
+func formatReportMs(ms float64) string {
+	switch {
+	case ms >= 1000:
+		return strconv.FormatFloat(ms/1000, 'f', 2, 64) + " s"
+	case ms >= 10:
+		return strconv.FormatFloat(ms, 'f', 1, 64) + " ms"
+	default:
+		return strconv.FormatFloat(ms, 'f', 3, 64) + " ms"
+	}
+}
+
+// handleBenchmarkReport renders the benchmark history as an HTML report,
+// taking the filters of /api/benchmark/compare. ?download=true serves it as
+// an attachment.
+func handleBenchmarkReport(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	since, err := parseSince(query.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	baseline := query.Get("baseline")
+	if baseline == "" {
+		baseline = serverEnvironment
+	}
+	verified, _ := strconv.ParseBool(query.Get("verified"))
+
+	var filters []string
+	if benchmark := query.Get("benchmark"); benchmark != "" {
+		filters = append(filters, "benchmark "+benchmark)
+	}
+	if !since.IsZero() {
+		filters = append(filters, "since "+since.UTC().Format("2006-01-02 15:04"))
+	}
+	if verified {
+		filters = append(filters, "verified results only")
+	}
+	records := benchmarkHistory.query(benchmarkHistoryFilter{Benchmark: query.Get("benchmark"), Since: since, Verified: verified})
+
+	var body bytes.Buffer
+	if err := benchmarkReportTemplate.Execute(&body, buildBenchmarkReport(records, baseline, filters)); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to render report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if download, _ := strconv.ParseBool(query.Get("download")); download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"benchmark-report-%s.html\"", time.Now().UTC().Format("2006-01-02")))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,84 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestBenchmarkReport tests the report's tables, charts and escaping
+func TestBenchmarkReport(t *testing.T) {
+	withBenchmarkHistory(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		newServerMux().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := get("/api/benchmark/report"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "No benchmark results recorded yet") {
+		t.Errorf("Expected an empty report, got %d: %s", w.Code, w.Body)
+	}
+
+	for _, rec := range []benchmarkRecord{
+		{Benchmark: "matrix", Environment: "server", Params: map[string]int{"size": 100}, DurationMs: 10, Metadata: benchmarkEnvironment{Platform: "server", CPUCount: 8}, Verified: true},
+		{Benchmark: "matrix", Environment: "server", Params: map[string]int{"size": 100}, DurationMs: 30, Metadata: benchmarkEnvironment{Platform: "server", CPUCount: 8}, Verified: true},
+		{Benchmark: "matrix", Environment: "Go native", Params: map[string]int{"size": 100}, DurationMs: 8, Metadata: benchmarkEnvironment{Platform: "browser", CPUCount: 4}},
+		{Benchmark: "matrix", Environment: "<script>alert(1)</script>", Params: map[string]int{"size": 100}, DurationMs: 80, Metadata: benchmarkEnvironment{Platform: "browser"}},
+	} {
+		benchmarkHistory.add(rec)
+	}
+
+	w := get("/api/benchmark/report?benchmark=matrix&download=true")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected an HTML report, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="benchmark-report-`) {
+		t.Errorf("Expected the report as an attachment, got %q", w.Header().Get("Content-Disposition"))
+	}
+	for _, want := range []string{"from 4 recorded runs", "benchmark matrix", "size=100", "Go native", "2.50×", "20.0 ms", "<svg", `fill="#4e79a7"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the report to contain %q:\n%s", want, body)
+		}
+	}
+	// Submitted environment names are escaped, and the page fetches nothing
+	for _, unwanted := range []string{"<script>", "ZgotmplZ", "src=", "href="} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("Expected the report not to contain %q:\n%s", unwanted, body)
+		}
+	}
+	if w.Header().Get("Content-Length") == "" {
+		t.Error("Expected a Content-Length")
+	}
+
+	if w := get("/api/benchmark/report?since=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid since, got %d", w.Code)
+	}
+}
+
+// TestReportTrend tests that daily means are plotted across the days run
+func TestReportTrend(t *testing.T) {
+	colors := map[string]string{"server": "#111", "Go WASM": "#222"}
+	if chart := reportTrend([]benchmarkTrendPoint{{Date: "2024-03-01", Environment: "server", MeanMs: 10}}, colors); chart != nil {
+		t.Errorf("Expected no trend for a single day, got %+v", chart)
+	}
+
+	chart := reportTrend([]benchmarkTrendPoint{
+		{Date: "2024-03-01", Environment: "server", MeanMs: 10},
+		{Date: "2024-03-01", Environment: "Go WASM", MeanMs: 20},
+		{Date: "2024-03-03", Environment: "server", MeanMs: 5},
+	}, colors)
+	if chart == nil || chart.FirstDate != "2024-03-01" || chart.LastDate != "2024-03-03" || len(chart.Lines) != 2 {
+		t.Fatalf("Unexpected trend: %+v", chart)
+	}
+	wasm, server := chart.Lines[0], chart.Lines[1]
+	if wasm.Environment != "Go WASM" || wasm.Points != "80.0,20.0" || wasm.Color != "#222" {
+		t.Errorf("Expected the slowest mean at the top left, got %+v", wasm)
+	}
+	if server.Points != "80.0,90.0 600.0,125.0" || len(server.Dots) != 2 || server.Dots[1].Label != "2024-03-03: 5.000 ms" {
+		t.Errorf("Unexpected server line: %+v", server)
+	}
+}
@@ -0,0 +1,132 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// RESPONSE ENVELOPE
+// Handlers write JSON through writeJSON. By default the body is the payload
+// itself; with ?envelope=true (and always under /api/v2) it is wrapped with
+// request metadata, the same timing information the browser harness reports
+// for WASM calls:
+//
+//   {"data": {...}, "meta": {"request_id": "3f9a...", "duration_ms": 0.42, "version": "1.0.0",
+//                            "trace_id": "4bf9...", "span_id": "00f0...", "spans": [...]}}
+//
+// Every response carries the request ID in X-Request-ID either way. Each
+// request is also a trace (shared_tracing.go): the meta lists its spans,
+// the request first and the business logic it ran after it, and the
+// traceresponse header names its trace and span. A traceparent header
+// continues the caller's trace.
+// ============================================================================
+
+// apiVersion is reported in envelopes and the OpenAPI document.
+const apiVersion = "1.0.0"
+
+const (
+	requestIDHeader     = "X-Request-ID"
+	traceparentHeader   = "traceparent"
+	traceresponseHeader = "traceresponse"
+)
+
+// A client-supplied X-Request-ID is kept if it looks like an identifier.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestMetaKey struct{}
+
+// requestMeta is attached to each request by requestMetaMiddleware.
+type requestMeta struct {
+	id    string
+	start time.Time
+	trace *Trace
+}
+
+type responseMeta struct {
+	RequestID    string  `json:"request_id"`
+	DurationMs   float64 `json:"duration_ms"`
+	Version      string  `json:"version"`
+	TraceID      string  `json:"trace_id,omitempty"`
+	SpanID       string  `json:"span_id,omitempty"`
+	Spans        []Span  `json:"spans,omitempty"`
+	DroppedSpans int     `json:"dropped_spans,omitempty"`
+}
+
+type responseEnvelope struct {
+	Data interface{}  `json:"data"`
+	Meta responseMeta `json:"meta"`
+}
+
+// requestMetaMiddleware assigns every request an ID, start time and trace.
+func requestMetaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRandomID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		// An invalid traceparent starts a new trace, as the spec asks
+		traceID, parentID, _ := ParseTraceparent(r.Header.Get(traceparentHeader))
+		ctx, trace := StartTrace(r.Context(), r.Method+" "+r.URL.Path, traceID, parentID)
+		defer trace.Finish()
+		w.Header().Set(traceresponseHeader, trace.Traceparent())
+
+		ctx = WithRequestID(context.WithValue(ctx, requestMetaKey{}, requestMeta{id: id, start: time.Now(), trace: trace}), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// metaFor reports the request's metadata so far. Requests that bypassed the
+// middleware (handlers called directly) are timed from now.
+func metaFor(r *http.Request) responseMeta {
+	meta, ok := r.Context().Value(requestMetaKey{}).(requestMeta)
+	if !ok {
+		meta = requestMeta{start: time.Now()}
+	}
+	elapsed := float64(time.Since(meta.start).Microseconds()) / 1000
+	response := responseMeta{RequestID: meta.id, DurationMs: math.Round(elapsed*1000) / 1000, Version: apiVersion}
+	if meta.trace != nil {
+		response.TraceID, response.SpanID = meta.trace.ID, meta.trace.RootSpanID()
+		response.Spans, response.DroppedSpans = meta.trace.Spans(), meta.trace.Dropped()
+	}
+	return response
+}
+
+// wantsEnvelope reports whether the client asked for enveloped responses,
+// which /api/v2 always does.
+func wantsEnvelope(r *http.Request) bool {
+	if version, ok := requestAPIVersion(r); ok && version.envelope {
+		return true
+	}
+	envelope, _ := strconv.ParseBool(r.URL.Query().Get("envelope"))
+	return envelope
+}
+
+// envelope wraps a payload for clients that asked for it.
+func envelope(r *http.Request, v interface{}) interface{} {
+	if !wantsEnvelope(r) {
+		return v
+	}
+	return responseEnvelope{Data: v, Meta: metaFor(r)}
+}
+
+// writeJSON responds with status and v, enveloped if requested.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	buf, err := encodeJSON(envelope(r, v))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	defer putJSONBuffer(buf)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,120 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestResponseEnvelope tests opt-in envelopes and request IDs
+func TestResponseEnvelope(t *testing.T) {
+	withDemoStore(t)
+	handler := newServerHandler()
+
+	req := httptest.NewRequest("POST", "/api/validate-user?envelope=true", strings.NewReader(`{"email": "a@example.com", "name": "Ann", "age": 30, "country": "US"}`))
+	req.Header.Set(requestIDHeader, "trace-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp struct {
+		Data ValidationResult `json:"data"`
+		Meta responseMeta     `json:"meta"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Data.Valid || resp.Meta.RequestID != "trace-123" || resp.Meta.Version != apiVersion || resp.Meta.DurationMs < 0 {
+		t.Errorf("Unexpected envelope: %+v", resp)
+	}
+	if w.Header().Get(requestIDHeader) != "trace-123" {
+		t.Errorf("Expected the request ID to be echoed, got %q", w.Header().Get(requestIDHeader))
+	}
+
+	// Without the flag the payload is unwrapped and an ID is generated
+	req = httptest.NewRequest("GET", "/api/demo-users", nil)
+	req.Header.Set(requestIDHeader, "not a valid id!")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	var users []User
+	if err := json.NewDecoder(w.Body).Decode(&users); err != nil || len(users) == 0 {
+		t.Errorf("Expected a plain user list, got %v", err)
+	}
+	if id := w.Header().Get(requestIDHeader); id == "" || id == "not a valid id!" {
+		t.Errorf("Expected a generated request ID, got %q", id)
+	}
+}
+
+// TestEnvelopeRevalidation tests that enveloped data keeps the data's ETag
+func TestEnvelopeRevalidation(t *testing.T) {
+	withDemoStore(t)
+	handler := newServerHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/demo-products", nil))
+	etag := w.Header().Get("ETag")
+
+	req := httptest.NewRequest("GET", "/api/demo-products?envelope=1", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("ETag") != etag || !strings.HasPrefix(w.Body.String(), `{"data":[`) {
+		t.Errorf("Expected an enveloped body with ETag %s, got %s: %s", etag, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304, got %d", w.Code)
+	}
+}
+
+// TestRequestTracing tests request traces, continued from a traceparent
+func TestRequestTracing(t *testing.T) {
+	withDemoStore(t)
+	handler := newServerHandler()
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req := httptest.NewRequest("POST", "/api/v2/validate-user", strings.NewReader(`{"email": "a@example.com", "name": "Ann", "age": 30, "country": "US"}`))
+	req.Header.Set(traceparentHeader, "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp struct {
+		Meta responseMeta `json:"meta"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	meta := resp.Meta
+	if meta.TraceID != traceID || len(meta.Spans) != 2 {
+		t.Fatalf("Expected the caller's trace with the request and ValidateUser spans, got %+v", meta)
+	}
+	root, validate := meta.Spans[0], meta.Spans[1]
+	if root.Name != "POST /api/v2/validate-user" || root.SpanID != meta.SpanID || root.ParentID != parentID {
+		t.Errorf("request span = %+v", root)
+	}
+	if validate.Name != "ValidateUser" || validate.ParentID != root.SpanID {
+		t.Errorf("ValidateUser span = %+v", validate)
+	}
+	if got := w.Header().Get(traceresponseHeader); got != "00-"+traceID+"-"+meta.SpanID+"-01" {
+		t.Errorf("traceresponse = %q", got)
+	}
+
+	// Without a valid traceparent each request starts its own trace
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/api/demo-users", nil)
+		req.Header.Set(traceparentHeader, "00-not-a-trace-01")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		id, _, ok := ParseTraceparent(w.Header().Get(traceresponseHeader))
+		if !ok || id == traceID || seen[id] {
+			t.Errorf("traceresponse = %q, want a new trace", w.Header().Get(traceresponseHeader))
+		}
+		seen[id] = true
+	}
+}
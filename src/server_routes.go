@@ -0,0 +1,779 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"slices"
+
+	"go-wasm-demo/internal/benchkernels"
+)
+
+// ============================================================================
+// API ROUTE TABLE
+// Single declaration of the HTTP API surface. newServerMux registers every
+// route from this table and the OpenAPI generator documents the same table,
+// so the router and /api/openapi.json can't drift apart.
+// ============================================================================
+
+// apiRoute is one mux path and the operations (HTTP methods) it serves.
+type apiRoute struct {
+	Path       string
+	Handler    http.HandlerFunc
+	Operations []apiOperation
+}
+
+// apiOperation documents a single method on a route. Request and Response are
+// example values whose Go types are reflected into JSON schemas; an
+// openAPISchema can be supplied instead for free-form payloads. Negotiated
+// operations also read and write the other registered codecs' media types.
+type apiOperation struct {
+	Method     string
+	Summary    string
+	Tag        string
+	Params     []apiParam
+	Request    interface{}
+	Response   interface{}
+	Negotiated bool
+}
+
+// apiParam documents a query or path parameter.
+type apiParam struct {
+	Name        string
+	In          string // "query", "path" or "header"
+	Type        string // OpenAPI primitive type
+	Description string
+	Default     interface{}
+}
+
+// Reusable schema for the map-shaped benchmark results
+var benchmarkResultSchema = openAPISchema{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"operation":   map[string]interface{}{"type": "string"},
+		"size":        map[string]interface{}{"type": "string"},
+		"duration_ms": map[string]interface{}{"type": "number"},
+		"operations":  map[string]interface{}{"type": "integer"},
+		"iterations":  map[string]interface{}{"type": "integer"},
+		"pixels":      map[string]interface{}{"type": "integer"},
+		"count":       map[string]interface{}{"type": "integer"},
+		"samples":     map[string]interface{}{"type": "integer"},
+		"result_hash": map[string]interface{}{"type": "integer"},
+	},
+}
+
+// Parameters shared by several endpoints
+var (
+	idempotencyKeyParam = apiParam{Name: idempotencyKeyHeader, In: "header", Type: "string", Description: "Replay the first response for repeated requests with this key"}
+	ifMatchParam        = apiParam{Name: "If-Match", In: "header", Type: "string", Description: `Version of the record the update was made against, such as "3", or * for any`}
+
+	cartUserParam    = apiParam{Name: "user_id", In: "query", Type: "integer", Description: "User the cart is priced for (default: a US guest)"}
+	cartProductParam = apiParam{Name: "product_id", In: "path", Type: "integer", Description: "Product ID"}
+	webhookIDParam   = apiParam{Name: "id", In: "path", Type: "string", Description: "Webhook ID"}
+
+	subscriptionIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "Subscription ID"}
+	wishlistUserParam   = apiParam{Name: "user_id", In: "path", Type: "integer", Description: "User ID"}
+	productIDParam      = apiParam{Name: "id", In: "path", Type: "integer", Description: "Product ID"}
+
+	demoDataParams = []apiParam{
+		{Name: "users", In: "query", Type: "integer", Description: "Users, the 5 curated ones included", Default: "5"},
+		{Name: "products", In: "query", Type: "integer", Description: "Products, at least the 8 curated ones (default: one for every 20 users)"},
+		{Name: "orders", In: "query", Type: "integer", Description: "Orders, at least the 2 curated ones (default: three a user)"},
+		{Name: "seed", In: "query", Type: "integer", Description: "Seed; the same options and seed give the same records", Default: "0"},
+		{Name: "countries", In: "query", Type: "string", Description: "Country weights of the generated users, like US:3,DE:1"},
+		{Name: "categories", In: "query", Type: "string", Description: "Category weights of the generated products, like books:2,toys:1"},
+	}
+)
+
+// apiRoutes returns the documented API routes. It is a function rather than a
+// package variable because the OpenAPI handler is itself part of the table.
+func apiRoutes() []apiRoute {
+	return slices.Concat([]apiRoute{
+		// API endpoints using shared business logic
+		{Path: "/api/validate-user", Handler: handleValidateUser, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Validate a user with the shared ValidateUser rules",
+			Request: User{}, Response: ValidationResult{},
+		}}},
+		{Path: "/api/validate-phone", Handler: handleValidatePhone, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Validate a phone number for a country and normalize it to E.164",
+			Request: validatePhoneRequest{}, Response: phoneValidationResponse{},
+		}}},
+		{Path: "/api/validate-product", Handler: handleValidateProduct, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Validate a product with the shared ValidateProduct rules",
+			Request: Product{}, Response: ValidationResult{},
+		}}},
+		{Path: "/api/calculate-order", Handler: idempotent(handleCalculateOrder), Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Calculate subtotal, discount, tax, shipping and total for an order",
+			Params:  []apiParam{idempotencyKeyParam},
+			Request: calculateOrderRequest{}, Response: orderTotalsResponse{},
+		}}},
+		{Path: "/api/shipping/quotes", Handler: handleShippingQuotes, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "List carrier shipping options for an order, cheapest first",
+			Request: calculateOrderRequest{}, Response: []ShippingQuote{},
+		}}},
+		{Path: "/api/recommend-products", Handler: handleRecommendProducts, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Recommend up to five products for a user, with the reasons for each",
+			Request: recommendProductsRequest{}, Response: []Recommendation{},
+		}}},
+		{Path: "/api/recommendations/rebuild", Handler: handleRecommendationsRebuild, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Summary: "Rebuild the item-item similarity matrix, trending list and cohort preferences from the order history (requires the admin token)",
+			Response: rebuildSummary{},
+		}}},
+		{Path: "/api/recommendations/similarity", Handler: handleItemSimilarity, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Get the item-item similarity matrix RecommendProducts blends in",
+			Response: ItemSimilarity{},
+		}}},
+		{Path: "/api/recommendations/cohorts", Handler: handleCohortPreferences, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Get the demographic cohort purchases cold-start recommendations use",
+			Response: CohortPreferences{},
+		}}},
+		{Path: "/api/recommendations/weights", Handler: handleRecommendationWeights, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Business Logic", Summary: "Get the scoring weights RecommendProducts uses",
+				Response: RecommendationWeights{},
+			},
+			{
+				Method: "PUT", Tag: "Business Logic", Summary: "Replace and persist the scoring weights (requires the admin token)",
+				Request: RecommendationWeights{}, Response: RecommendationWeights{},
+			},
+		}},
+		{Path: "/api/recommend-cross-sells", Handler: handleRecommendCrossSells, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Suggest accessories for the products in an order",
+			Request: crossSellRequest{}, Response: []Product{},
+		}}},
+		{Path: "/api/recommend-upsells", Handler: handleRecommendUpsells, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Suggest higher-tier alternatives to a product",
+			Request: upsellRequest{}, Response: []Product{},
+		}}},
+		{Path: "/api/analyze-behavior", Handler: handleAnalyzeBehavior, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Negotiated: true, Summary: "Aggregate user demographics and order revenue",
+			Params:  []apiParam{{Name: "top_countries", In: "query", Type: "integer", Description: "Countries to rank by revenue, 1 to 50", Default: "3"}},
+			Request: analyzeBehaviorRequest{}, Response: UserAnalytics{},
+		}}},
+		{Path: "/api/analytics/summary", Handler: handleAnalyticsSummary, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Aggregate the stored users and orders, kept up to date as they arrive",
+			Params:   []apiParam{{Name: "top_countries", In: "query", Type: "integer", Description: "Countries to rank by revenue, 1 to 50", Default: "3"}},
+			Response: UserAnalytics{},
+		}}},
+		{Path: "/api/analytics/associations", Handler: handleAssociations, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Mine frequently-bought-together rules from the stored orders",
+			Params: []apiParam{
+				{Name: "min_support", In: "query", Type: "number", Description: "Share of orders a rule's products must all be in", Default: "0.1"},
+				{Name: "min_confidence", In: "query", Type: "number", Description: "Share of orders with the antecedent that must have the consequent", Default: "0.5"},
+			},
+			Response: associationsResponse{},
+		}}},
+		{Path: "/api/analytics/trending", Handler: handleTrending, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Rank products by recency-weighted sales velocity",
+			Params: []apiParam{
+				{Name: "days", In: "query", Type: "integer", Description: "Window ending at the newest order, 1 to 365", Default: "30"},
+			},
+			Response: trendingResponse{},
+		}}},
+		{Path: "/api/analytics/segments", Handler: handleSegments, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Segment the stored customers by recency, frequency and monetary value",
+			Response: RFMSegmentation{},
+		}}},
+		{Path: "/api/analytics/churn", Handler: handleChurnRisk, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Score the stored customers' churn risk, riskiest first",
+			Params: []apiParam{
+				{Name: "min_probability", In: "query", Type: "number", Description: "Only customers at least this likely to churn, 0 to 1", Default: "0"},
+				{Name: "risk", In: "query", Type: "string", Description: "Only customers at this level: low, medium or high"},
+			},
+			Response: []ChurnRisk{},
+		}}},
+		{Path: "/api/analytics/timeseries", Handler: handleRevenueTimeseries, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Series the stored orders' revenue, order count and average order value by period",
+			Params: []apiParam{
+				{Name: "granularity", In: "query", Type: "string", Description: "day, week or month", Default: "month"},
+				{Name: "from", In: "query", Type: "string", Description: "Only orders on or after this date, YYYY-MM-DD"},
+				{Name: "to", In: "query", Type: "string", Description: "Only orders on or before this date, YYYY-MM-DD"},
+			},
+			Response: timeseriesResponse{},
+		}}},
+		{Path: "/api/analytics/forecast", Handler: handleRevenueForecast, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Forecast the stored orders' revenue with exponential smoothing, with 95% bands",
+			Params: []apiParam{
+				{Name: "granularity", In: "query", Type: "string", Description: "day, week or month", Default: "month"},
+				{Name: "horizon", In: "query", Type: "integer", Description: "Periods to forecast, 1 to 366", Default: "6"},
+				{Name: "season", In: "query", Type: "integer", Description: "Periods in a season, 0 for none", Default: "0"},
+				{Name: "alpha", In: "query", Type: "number", Description: "Smoothing of the level, above 0 and at most 1", Default: "0.5"},
+				{Name: "beta", In: "query", Type: "number", Description: "Smoothing of the trend, above 0 and at most 1", Default: "0.3"},
+				{Name: "gamma", In: "query", Type: "number", Description: "Smoothing of the season, above 0 and at most 1", Default: "0.3"},
+				{Name: "from", In: "query", Type: "string", Description: "Only orders on or after this date, YYYY-MM-DD"},
+				{Name: "to", In: "query", Type: "string", Description: "Only orders on or before this date, YYYY-MM-DD"},
+			},
+			Response: forecastResponse{},
+		}}},
+		{Path: "/api/analytics/funnel", Handler: handleFunnel, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Follow the stored behavior events through the purchase funnel",
+			Params: []apiParam{
+				{Name: "from", In: "query", Type: "string", Description: "Only events on or after this date, YYYY-MM-DD"},
+				{Name: "to", In: "query", Type: "string", Description: "Only events on or before this date, YYYY-MM-DD"},
+			},
+			Response: funnelResponse{},
+		}}},
+		{Path: "/api/analytics/sessions", Handler: handleSessions, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Split the stored events into sessions and follow them through the funnel",
+			Params: []apiParam{
+				{Name: "gap_minutes", In: "query", Type: "integer", Description: "Idle minutes that end a session, 1 to 1440", Default: "30"},
+				{Name: "user_id", In: "query", Type: "integer", Description: "Only this user's sessions"},
+			},
+			Response: sessionsResponse{},
+		}}},
+		{Path: "/api/analytics/top-products", Handler: handleTopProducts, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Rank the products in the stored orders by revenue, units or margin",
+			Params: []apiParam{
+				{Name: "metric", In: "query", Type: "string", Description: "revenue, units or margin", Default: "revenue"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Products to return, 1 to 100", Default: "10"},
+			},
+			Response: topProductsResponse{},
+		}}},
+		{Path: "/api/events", Handler: handleEvents, Operations: []apiOperation{
+			{Method: "GET", Tag: "Business Logic", Summary: "List the stored behavior events", Response: eventsResponse{}},
+			{Method: "POST", Tag: "Business Logic", Summary: "Record behavior events: viewed, added_to_cart, checked_out or purchased", Request: eventsRequest{}, Response: eventsResponse{}},
+		}},
+		{Path: "/api/experiments/assign", Handler: handleAssignVariants, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Summary: "Assign users to the variants of an A/B experiment",
+			Request: assignRequest{}, Response: assignResponse{},
+		}}},
+		{Path: "/api/experiments/analyze", Handler: handleAnalyzeExperiment, Operations: []apiOperation{{
+			Method: "POST", Tag: "Business Logic", Summary: "Compare the conversion of an experiment's variants over the stored users and orders",
+			Request: analyzeExperimentRequest{}, Response: ExperimentResult{},
+		}}},
+		{Path: "/api/rates", Handler: handleRates, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Get the exchange rate table, or convert an amount with ConvertPrice when amount is given",
+			Params: []apiParam{
+				{Name: "amount", In: "query", Type: "number", Description: "Amount to convert; the response is then a conversion"},
+				{Name: "from", In: "query", Type: "string", Description: "Currency of the amount", Default: DefaultCurrency},
+				{Name: "to", In: "query", Type: "string", Description: "Currency to convert to", Default: DefaultCurrency},
+				{Name: "locale", In: "query", Type: "string", Description: "Locale to format the result for with FormatMoney, such as de-DE"},
+			},
+			Response: ExchangeRates{},
+		}}},
+		{Path: "/api/validation-rules", Handler: handleValidationRules, Operations: []apiOperation{{
+			Method: "GET", Tag: "Business Logic", Summary: "Get the validation ruleset ValidateUser and ValidateProduct apply",
+			Response: ValidationRules{},
+		}}},
+		{Path: "/api/promotions", Handler: handlePromotions, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Business Logic", Summary: "List the promotions CalculateOrderTotal evaluates",
+				Params:   []apiParam{{Name: "active", In: "query", Type: "string", Description: "Only list promotions running on this YYYY-MM-DD day"}},
+				Response: []Promotion{},
+			},
+			{
+				Method: "PUT", Tag: "Business Logic", Summary: "Replace the promotions (requires the admin token)",
+				Request: []Promotion{}, Response: []Promotion{},
+			},
+		}},
+
+		// Demo data endpoints
+		{Path: "/api/demo-users", Handler: handleDemoUsers, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "List demo users", Response: []User{},
+		}}},
+		{Path: "/api/demo-products", Handler: handleDemoProducts, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "List demo products", Response: []Product{},
+		}}},
+		{Path: "/api/products", Handler: handleProducts, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "List catalog products with category, price, rating and stock facets",
+			Params: []apiParam{
+				{Name: "category", In: "query", Type: "string", Description: "Only this category"},
+				{Name: "min_price", In: "query", Type: "number", Description: "Lowest price, inclusive"},
+				{Name: "max_price", In: "query", Type: "number", Description: "Highest price, exclusive"},
+				{Name: "min_rating", In: "query", Type: "number", Description: "Lowest rating, 0 to 5"},
+				{Name: "in_stock", In: "query", Type: "boolean", Description: "Only products in stock"},
+			},
+			Response: ProductListing{},
+		}}},
+		{Path: "/api/search-products", Handler: handleSearchProducts, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Search product names and descriptions, tolerating typos",
+			Params: []apiParam{
+				{Name: "q", In: "query", Type: "string", Description: "Search query; misspelt words still match"},
+				{Name: "sort", In: "query", Type: "string", Description: "relevance, price_asc, price_desc, rating or newest", Default: "relevance"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Results to return, 1 to 20", Default: "20"},
+			},
+			Response: searchResponse{},
+		}}},
+		{Path: "/api/products/{id}", Handler: handleProductDetail, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Get a product with the products most like it by name and description",
+			Params: []apiParam{
+				productIDParam,
+				{Name: "similar", In: "query", Type: "integer", Description: "Similar products to list, 0 to 20", Default: "5"},
+				{Name: "thumbnail", In: "query", Type: "integer", Description: "Include the picture to show in a tile this many pixels square"},
+			},
+			Response: productDetailResponse{},
+		}}},
+		{Path: "/api/demo-orders", Handler: handleDemoOrders, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "List demo orders", Response: []Order{},
+		}}},
+		{Path: "/api/demo-data", Handler: handleDemoData, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Demo Data", Summary: "Generate a seeded dataset of demo users, products and orders",
+				Params: demoDataParams, Response: DemoData{},
+			},
+			{
+				Method: "POST", Tag: "Demo Data", Summary: "Generate a seeded dataset and load it into the request's sandbox",
+				Params: demoDataParams, Response: demoDataLoaded{},
+			},
+		}},
+
+		{Path: "/api/orders/recalculate", Handler: handleRecalculateOrders, Operations: []apiOperation{{
+			Method: "POST", Tag: "Demo Data", Summary: "Price the stored orders again and report the orders whose totals would change",
+			Params: []apiParam{
+				{Name: "concurrency", In: "query", Type: "integer", Description: "Orders priced at once, 1 to the server's max-recalculate-workers; GOMAXPROCS within that by default"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Changed orders to list, 0 to 1000", Default: "100"},
+			},
+			Response: recalculateResponse{},
+		}}},
+		{Path: "/api/orders/{id}/status", Handler: handleOrderStatus, Operations: []apiOperation{{
+			Method: "PUT", Tag: "Demo Data", Summary: "Change an order's status (pending, processing, shipped, delivered or cancelled)",
+			Params:  []apiParam{{Name: "id", In: "path", Type: "integer", Description: "Order ID"}, ifMatchParam},
+			Request: orderStatusRequest{}, Response: Order{},
+		}}},
+		{Path: "/api/orders/{id}/invoice", Handler: handleOrderInvoice, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Get an order's invoice as JSON, or rendered as HTML or plain text",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "integer", Description: "Order ID"},
+				{Name: "format", In: "query", Type: "string", Description: "json, html or text", Default: "json"},
+			},
+			Response: Invoice{},
+		}}},
+		{Path: "/api/orders/{id}/invoice.pdf", Handler: handleOrderInvoicePDF, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Download an order's invoice as a PDF",
+			Params: []apiParam{{Name: "id", In: "path", Type: "integer", Description: "Order ID"}},
+		}}},
+		{Path: "/api/orders/{id}/receipt", Handler: handleOrderReceipt, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Get an order's confirmation or receipt email as JSON, or rendered as HTML or plain text",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "integer", Description: "Order ID"},
+				{Name: "kind", In: "query", Type: "string", Description: "confirmation or receipt", Default: "receipt"},
+				{Name: "locale", In: "query", Type: "string", Description: "Locale of the labels, amounts and dates, such as de-DE; the customer's country's by default"},
+				{Name: "format", In: "query", Type: "string", Description: "json, html or text", Default: "json"},
+			},
+			Response: Receipt{},
+		}}},
+		{Path: "/api/orders/{id}/shipments/{shipment_id}/status", Handler: handleShipmentStatus, Operations: []apiOperation{{
+			Method: "PUT", Tag: "Demo Data", Summary: "Change a shipment's status (pending, backordered, shipped or delivered); the order follows its shipments",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "integer", Description: "Order ID"},
+				{Name: "shipment_id", In: "path", Type: "integer", Description: "Shipment ID"},
+				ifMatchParam,
+			},
+			Request: shipmentStatusRequest{}, Response: Order{},
+		}}},
+		{Path: "/api/data/stream", Handler: handleDataStream, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Stream changes to users, products and orders as Server-Sent Events (text/event-stream)",
+			Params: []apiParam{{Name: "entities", In: "query", Type: "string", Description: "Comma-separated entities to stream (users, products, orders); all by default"}},
+		}}},
+
+		{Path: "/api/data/sync", Handler: handleDataSync, Operations: []apiOperation{{
+			Method: "POST", Tag: "Demo Data", Summary: "Apply the edits a page made to its cached users and products while offline, reporting conflicts",
+			Request: SyncRequest{}, Response: SyncResponse{},
+		}}},
+
+		// Downloadable exports
+		{Path: "/api/export/{dataset}", Handler: handleExport, Operations: []apiOperation{{
+			Method: "GET", Tag: "Demo Data", Summary: "Download a demo dataset or its analytics as CSV or XLSX",
+			Params: []apiParam{
+				{Name: "dataset", In: "path", Type: "string", Description: "users, products, orders or analytics"},
+				{Name: "format", In: "query", Type: "string", Description: "csv or xlsx", Default: "csv"},
+			},
+		}}},
+
+		{Path: "/api/import", Handler: handleImport, Operations: []apiOperation{{
+			Method: "POST", Tag: "Demo Data", Summary: "Bulk-import users or products from a CSV or JSON upload (multipart or raw body)",
+			Params: []apiParam{
+				{Name: "type", In: "query", Type: "string", Description: "users or products (or a multipart \"type\" field)"},
+				{Name: "dry_run", In: "query", Type: "boolean", Description: "Validate and report without storing", Default: false},
+			},
+			Response: importReport{},
+		}}},
+
+		// GraphQL
+		{Path: "/api/graphql", Handler: handleGraphQL, Operations: []apiOperation{
+			{
+				Method: "POST", Tag: "GraphQL", Summary: "Run a GraphQL query (JSON body, or the raw query as application/graphql)",
+				Request: graphQLRequest{}, Response: graphQLResponse{},
+			},
+			{
+				Method: "GET", Tag: "GraphQL", Summary: "Run a GraphQL query given in the query string",
+				Params: []apiParam{
+					{Name: "query", In: "query", Type: "string", Description: "GraphQL query document"},
+					{Name: "variables", In: "query", Type: "string", Description: "JSON object of variable values"},
+					{Name: "operationName", In: "query", Type: "string", Description: "Operation to run when the document has several"},
+				},
+				Response: graphQLResponse{},
+			},
+		}},
+		{Path: "/api/graphql/schema", Handler: handleGraphQLSchema, Operations: []apiOperation{{
+			Method: "GET", Tag: "GraphQL", Summary: "The GraphQL schema in SDL (text/plain)",
+		}}},
+
+		// Performance benchmark endpoints, one per registered benchmark
+	}, benchmarkRoutes(), []apiRoute{
+		{Path: "/api/benchmarks", Handler: handleBenchmarkList, Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmarks", Summary: "List the server-side benchmarks and their parameters",
+			Response: []benchmarkInfo{},
+		}}},
+
+		// Benchmark history and cross-environment comparison
+		{Path: "/api/benchmark/results", Handler: handleBenchmarkResults, Operations: []apiOperation{
+			{
+				Method: "POST", Tag: "Benchmark History", Summary: "Record client-measured benchmark results (one object or an array)",
+				Request: benchmarkResultSubmission{}, Response: []benchmarkRecord{},
+			},
+			{
+				Method: "GET", Tag: "Benchmark History", Summary: "List recorded benchmark results, newest first",
+				Params: []apiParam{
+					{Name: "benchmark", In: "query", Type: "string", Description: "Only this benchmark"},
+					{Name: "environment", In: "query", Type: "string", Description: "Only this environment (e.g. server, JavaScript)"},
+					{Name: "since", In: "query", Type: "string", Description: "RFC 3339 time, YYYY-MM-DD or a duration such as 168h"},
+					{Name: "verified", In: "query", Type: "boolean", Description: "Only server runs and results verified by a challenge"},
+					{Name: "limit", In: "query", Type: "integer", Description: "Maximum results", Default: 100},
+				},
+				Response: []benchmarkRecord{},
+			},
+		}},
+		{Path: "/api/benchmark/compare", Handler: handleBenchmarkCompare, Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmark History", Summary: "Compare recorded results across environments and over time",
+			Params: []apiParam{
+				{Name: "benchmark", In: "query", Type: "string", Description: "Only this benchmark"},
+				{Name: "baseline", In: "query", Type: "string", Description: "Environment speedups are relative to", Default: "server"},
+				{Name: "since", In: "query", Type: "string", Description: "RFC 3339 time, YYYY-MM-DD or a duration such as 168h"},
+				{Name: "verified", In: "query", Type: "boolean", Description: "Only server runs and results verified by a challenge"},
+			},
+			Response: benchmarkComparison{},
+		}}},
+		{Path: "/api/benchmark/report", Handler: handleBenchmarkReport, Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmark History", Summary: "Render the comparison as a self-contained HTML report with tables and SVG charts (text/html)",
+			Params: []apiParam{
+				{Name: "benchmark", In: "query", Type: "string", Description: "Only this benchmark"},
+				{Name: "baseline", In: "query", Type: "string", Description: "Environment speedups are relative to", Default: "server"},
+				{Name: "since", In: "query", Type: "string", Description: "RFC 3339 time, YYYY-MM-DD or a duration such as 168h"},
+				{Name: "verified", In: "query", Type: "boolean", Description: "Only server runs and results verified by a challenge"},
+				{Name: "download", In: "query", Type: "boolean", Description: "Serve the report as an attachment"},
+			},
+		}}},
+		{Path: "/api/benchmark/challenges", Handler: handleBenchmarkChallenges, Operations: []apiOperation{{
+			Method: "POST", Tag: "Benchmark History", Summary: "Issue a signed challenge for submitting verified results",
+			Request: benchmarkChallengeRequest{}, Response: benchmarkChallengeResponse{},
+		}}},
+
+		// Asynchronous benchmark jobs
+		{Path: "/api/loadtest", Handler: handleLoadTest, Operations: []apiOperation{{
+			Method: "POST", Tag: "Benchmarks", Summary: "Run the shared logic in-process from concurrent workers and report throughput, latency percentiles and allocations",
+			Request: loadTestRequest{}, Response: loadTestResult{},
+		}}},
+		{Path: "/api/benchmark/jobs", Handler: handleBenchmarkJobs, Operations: []apiOperation{
+			{
+				Method: "POST", Tag: "Benchmark Jobs", Summary: "Queue a benchmark to run in the background",
+				Request: benchmarkJobRequest{}, Response: benchmarkJob{},
+			},
+			{Method: "GET", Tag: "Benchmark Jobs", Summary: "List retained benchmark jobs, newest first", Response: []benchmarkJob{}},
+		}},
+		{Path: "/api/benchmark/jobs/{id}", Handler: handleBenchmarkJob, Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmark Jobs", Summary: "Get the status and result of a benchmark job",
+			Params:   []apiParam{{Name: "id", In: "path", Type: "string", Description: "Job ID"}},
+			Response: benchmarkJob{},
+		}}},
+		{Path: "/api/benchmark/jobs/{id}/events", Handler: handleBenchmarkJobEvents, Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmark Jobs", Summary: "Stream job status changes as Server-Sent Events (text/event-stream)",
+			Params: []apiParam{{Name: "id", In: "path", Type: "string", Description: "Job ID"}},
+		}}},
+
+		// Session shopping cart
+		{Path: "/api/cart", Handler: handleCart, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Cart", Summary: "Get the session's cart priced as an order, with recommendations",
+				Params:   []apiParam{cartUserParam},
+				Response: CartSummary{},
+			},
+			{
+				Method: "DELETE", Tag: "Cart", Summary: "Empty the session's cart",
+				Params:   []apiParam{cartUserParam},
+				Response: CartSummary{},
+			},
+		}},
+		{Path: "/api/cart/items", Handler: handleCartItems, Operations: []apiOperation{{
+			Method: "POST", Tag: "Cart", Summary: "Add a product to the cart (quantity defaults to 1)",
+			Params:  []apiParam{cartUserParam},
+			Request: cartItemRequest{}, Response: CartSummary{},
+		}}},
+		{Path: "/api/cart/items/{product_id}", Handler: handleCartItem, Operations: []apiOperation{
+			{
+				Method: "PUT", Tag: "Cart", Summary: "Change the quantity of a cart line (0 removes it)",
+				Params:  []apiParam{cartProductParam, cartUserParam},
+				Request: cartItemRequest{}, Response: CartSummary{},
+			},
+			{
+				Method: "DELETE", Tag: "Cart", Summary: "Remove a product from the cart",
+				Params:   []apiParam{cartProductParam, cartUserParam},
+				Response: CartSummary{},
+			},
+		}},
+		{Path: "/api/cart/merge", Handler: handleCartMerge, Operations: []apiOperation{{
+			Method: "POST", Tag: "Cart", Summary: "Merge a guest cart into the session's cart, summing shared lines",
+			Params:  []apiParam{cartUserParam},
+			Request: mergeCartRequest{}, Response: CartSummary{},
+		}}},
+		{Path: "/api/cart/checkout", Handler: idempotent(handleCartCheckout), Operations: []apiOperation{{
+			Method: "POST", Tag: "Cart", Summary: "Place the cart as a pending order and empty it, optionally shipping to another address",
+			Params:  []apiParam{cartUserParam, idempotencyKeyParam},
+			Request: checkoutRequest{}, Response: Order{},
+		}}},
+
+		// Wishlists
+		{Path: "/api/wishlists/{user_id}", Handler: handleWishlist, Operations: []apiOperation{{
+			Method: "GET", Tag: "Wishlists", Summary: "Get a user's wishlist",
+			Params:   []apiParam{wishlistUserParam},
+			Response: Wishlist{},
+		}}},
+		{Path: "/api/wishlists/{user_id}/items", Handler: handleWishlistItems, Operations: []apiOperation{{
+			Method: "POST", Tag: "Wishlists", Summary: "Save a product to a user's wishlist",
+			Params:  []apiParam{wishlistUserParam},
+			Request: wishlistItemRequest{}, Response: Wishlist{},
+		}}},
+		{Path: "/api/wishlists/{user_id}/items/{product_id}", Handler: handleWishlistItem, Operations: []apiOperation{{
+			Method: "DELETE", Tag: "Wishlists", Summary: "Remove a product from a user's wishlist",
+			Params:   []apiParam{wishlistUserParam, cartProductParam},
+			Response: Wishlist{},
+		}}},
+		{Path: "/api/wishlists/{user_id}/items/{product_id}/move-to-cart", Handler: handleWishlistMoveToCart, Operations: []apiOperation{{
+			Method: "POST", Tag: "Wishlists", Summary: "Move a wishlisted product into the session's cart (quantity defaults to 1)",
+			Params:  []apiParam{wishlistUserParam, cartProductParam},
+			Request: moveToCartRequest{}, Response: CartSummary{},
+		}}},
+
+		// Gift cards
+		{Path: "/api/gift-cards", Handler: handleGiftCards, Operations: []apiOperation{{
+			Method: "POST", Tag: "Gift Cards", Summary: "Issue a gift card with a balance and optional expiry (requires the admin token)",
+			Request: issueGiftCardRequest{}, Response: GiftCard{},
+		}}},
+		{Path: "/api/gift-cards/{code}", Handler: handleGiftCard, Operations: []apiOperation{{
+			Method: "GET", Tag: "Gift Cards", Summary: "Check a gift card's balance and expiry",
+			Params:   []apiParam{{Name: "code", In: "path", Type: "string", Description: "Gift card code"}},
+			Response: GiftCard{},
+		}}},
+
+		// Subscriptions
+		{Path: "/api/subscriptions", Handler: handleSubscriptions, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Subscriptions", Summary: "List subscriptions, optionally one user's",
+				Params:   []apiParam{{Name: "user_id", In: "query", Type: "integer", Description: "Only this user's subscriptions"}},
+				Response: []Subscription{},
+			},
+			{
+				Method: "POST", Tag: "Subscriptions", Summary: "Subscribe a user to a product every interval (weekly, monthly, quarterly or yearly)",
+				Request: subscriptionRequest{}, Response: Subscription{},
+			},
+		}},
+		{Path: "/api/subscriptions/{id}/status", Handler: handleSubscriptionStatus, Operations: []apiOperation{{
+			Method: "PUT", Tag: "Subscriptions", Summary: "Pause, resume or cancel a subscription",
+			Params:  []apiParam{subscriptionIDParam},
+			Request: subscriptionStatusRequest{}, Response: Subscription{},
+		}}},
+		{Path: "/api/subscriptions/{id}/upcoming", Handler: handleSubscriptionUpcoming, Operations: []apiOperation{{
+			Method: "GET", Tag: "Subscriptions", Summary: "Preview the charges of a subscription's next renewals",
+			Params: []apiParam{
+				subscriptionIDParam,
+				{Name: "count", In: "query", Type: "integer", Description: "Renewals to preview (at most 24)", Default: 3},
+			},
+			Response: []UpcomingCharge{},
+		}}},
+		{Path: "/api/subscriptions/{id}/proration", Handler: handleSubscriptionProration, Operations: []apiOperation{{
+			Method: "POST", Tag: "Subscriptions", Summary: "Preview the prorated adjustment for changing a subscription's product or quantity",
+			Params:  []apiParam{subscriptionIDParam},
+			Request: prorationRequest{}, Response: Proration{},
+		}}},
+		{Path: "/api/subscriptions/{id}/renew", Handler: handleSubscriptionRenew, Operations: []apiOperation{{
+			Method: "POST", Tag: "Subscriptions", Summary: "Place a subscription's renewal order and move it to the next renewal date",
+			Params:   []apiParam{subscriptionIDParam},
+			Response: Order{},
+		}}},
+
+		// Inventory
+		{Path: "/api/inventory", Handler: handleInventory, Operations: []apiOperation{{
+			Method: "GET", Tag: "Inventory", Summary: "List on-hand, reserved and available stock of each product",
+			Response: []StockLevel{},
+		}}},
+		{Path: "/api/inventory/availability", Handler: handleInventoryAvailability, Operations: []apiOperation{{
+			Method: "POST", Tag: "Inventory", Summary: "Check items, or the session's cart when none are given, against available stock",
+			Request: availabilityRequest{}, Response: availabilityResponse{},
+		}}},
+
+		// Countries
+		{Path: "/api/countries", Handler: handleCountries, Operations: []apiOperation{{
+			Method: "GET", Tag: "Countries", Summary: "List the ISO 3166 countries with their region, currency and standard tax rate",
+			Params:     []apiParam{{Name: "region", In: "query", Type: "string", Description: "Only countries of this region (Africa, Americas, Asia, Europe, Oceania, Antarctica)"}},
+			Response:   []Country{},
+			Negotiated: true,
+		}}},
+		{Path: "/api/countries/{code}", Handler: handleCountry, Operations: []apiOperation{{
+			Method: "GET", Tag: "Countries", Summary: "Look up a country by alpha-2 or alpha-3 code",
+			Params:     []apiParam{countryCodeParam},
+			Response:   Country{},
+			Negotiated: true,
+		}}},
+
+		// Reference data
+		{Path: "/api/reference-data", Handler: handleReferenceData, Operations: []apiOperation{{
+			Method: "GET", Tag: "Countries", Summary: "Get the markets, categories, currencies, tax rules, shipping rates and countries the business logic uses",
+			Response:   ReferenceData{},
+			Negotiated: true,
+		}}},
+
+		// Users
+		{Path: "/api/users/duplicates", Handler: handleDuplicateUsers, Operations: []apiOperation{{
+			Method: "GET", Tag: "Users", Summary: "List likely duplicate users by email, name and join details (requires the admin token)",
+			Response: duplicateUsersResponse{},
+		}}},
+		{Path: "/api/users/{id}/export", Handler: handleUserExport, Operations: []apiOperation{{
+			Method: "GET", Tag: "Users", Summary: "Download every record linked to a user as one JSON document (requires the admin token)",
+			Params:   []apiParam{userIDParam},
+			Response: UserDataExport{},
+		}}},
+		{Path: "/api/users/{id}/preferences", Handler: handleUserPreferences, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Users", Summary: "Get the categories, price sensitivity and marketing consent recommendations go by",
+				Params:   []apiParam{userIDParam},
+				Response: userPreferencesResponse{},
+			},
+			{
+				Method: "PUT", Tag: "Users", Summary: "Replace a user's preferences",
+				Params:  []apiParam{userIDParam, ifMatchParam},
+				Request: userPreferencesRequest{}, Response: userPreferencesResponse{},
+			},
+		}},
+		{Path: "/api/users/{id}/notifications", Handler: handleUserNotifications, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Users", Summary: "Get how a user is notified of their orders",
+				Params:   []apiParam{userIDParam},
+				Response: userNotificationsResponse{},
+			},
+			{
+				Method: "PUT", Tag: "Users", Summary: "Replace a user's notification channel and digest frequency",
+				Params:  []apiParam{userIDParam, ifMatchParam},
+				Request: userNotificationsRequest{}, Response: userNotificationsResponse{},
+			},
+		}},
+		{Path: "/api/users/{id}/clv", Handler: handleUserCLV, Operations: []apiOperation{{
+			Method: "GET", Tag: "Users", Summary: "Project a user's customer lifetime value from their orders",
+			Params: []apiParam{
+				userIDParam,
+				{Name: "months", In: "query", Type: "integer", Description: "Horizon, 1 to 120 months", Default: "12"},
+			},
+			Response: CustomerValue{},
+		}}},
+		{Path: "/api/users/{id}/anonymize", Handler: handleUserAnonymize, Operations: []apiOperation{{
+			Method: "POST", Tag: "Users", Summary: "Irreversibly scrub a user's personal data, keeping their analytics (requires the admin token)",
+			Params:   []apiParam{userIDParam, ifMatchParam},
+			Response: anonymizeUserResponse{},
+		}}},
+
+		// Prices
+		{Path: "/api/products/{id}/price-history", Handler: handlePriceHistory, Operations: []apiOperation{{
+			Method: "GET", Tag: "Prices", Summary: "List a product's price changes and its current was/now comparison",
+			Params: []apiParam{
+				productIDParam,
+				{Name: "since", In: "query", Type: "string", Description: "Start of the history, RFC 3339 (default 90 days ago)"},
+				{Name: "until", In: "query", Type: "string", Description: "End of the history, RFC 3339 (default now)"},
+				{Name: "at", In: "query", Type: "string", Description: "Also return the price in effect at this RFC 3339 time"},
+			},
+			Response: priceHistoryResponse{},
+		}}},
+		{Path: "/api/products/{id}/price", Handler: handleProductPrice, Operations: []apiOperation{{
+			Method: "PUT", Tag: "Prices", Summary: "Change a product's price, recording it in the price history (requires the admin token)",
+			Params:  []apiParam{productIDParam, ifMatchParam},
+			Request: productPriceRequest{}, Response: Product{},
+		}}},
+		{Path: "/api/products/{id}/dynamic-price", Handler: handleDynamicPrice, Operations: []apiOperation{{
+			Method: "GET", Tag: "Prices", Summary: "Quote a product's price for its recent demand and stock level",
+			Params:   []apiParam{productIDParam},
+			Response: dynamicPriceResponse{},
+		}}},
+
+		// Webhooks (require the -admin-token bearer token)
+		{Path: "/api/webhooks", Handler: handleWebhooks, Operations: []apiOperation{
+			{
+				Method: "POST", Tag: "Webhooks", Summary: "Register a URL for order events; the response includes its signing secret",
+				Request: webhookRequest{}, Response: webhook{},
+			},
+			{Method: "GET", Tag: "Webhooks", Summary: "List registered webhooks", Response: []webhook{}},
+		}},
+		{Path: "/api/webhooks/{id}", Handler: handleWebhook, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Webhooks", Summary: "Get a registered webhook",
+				Params:   []apiParam{webhookIDParam},
+				Response: webhook{},
+			},
+			{
+				Method: "DELETE", Tag: "Webhooks", Summary: "Unregister a webhook",
+				Params: []apiParam{webhookIDParam},
+			},
+		}},
+		{Path: "/api/webhooks/{id}/deliveries", Handler: handleWebhookDeliveries, Operations: []apiOperation{{
+			Method: "GET", Tag: "Webhooks", Summary: "Delivery log with every attempt, newest first",
+			Params:   []apiParam{webhookIDParam},
+			Response: []webhookDelivery{},
+		}}},
+
+		// Sandboxes (isolated copies of the demo data, selected per request
+		// with the X-Sandbox-ID header or a /sandbox/{id}/ path prefix)
+		{Path: "/api/sandboxes", Handler: handleSandboxes, Operations: []apiOperation{{
+			Method: "POST", Tag: "Sandboxes", Summary: "Create a sandbox with a random ID and fresh demo data",
+			Response: sandboxInfo{},
+		}}},
+		{Path: "/api/sandboxes/{id}", Handler: handleSandbox, Operations: []apiOperation{
+			{
+				Method: "GET", Tag: "Sandboxes", Summary: "Describe a sandbox and when it expires",
+				Params:   []apiParam{{Name: "id", In: "path", Type: "string", Description: "Sandbox ID"}},
+				Response: sandboxInfo{},
+			},
+			{
+				Method: "DELETE", Tag: "Sandboxes", Summary: "Discard a sandbox",
+				Params: []apiParam{{Name: "id", In: "path", Type: "string", Description: "Sandbox ID"}},
+			},
+		}},
+
+		// Snapshots of the shared data store (require the -admin-token bearer
+		// token)
+		{Path: "/api/admin/snapshot", Handler: handleSnapshot, Operations: []apiOperation{
+			{
+				Method: "POST", Tag: "Admin", Summary: "Write the data store to the snapshot file now",
+				Response: snapshotInfo{},
+			},
+			{
+				Method: "GET", Tag: "Admin", Summary: "Download a snapshot of the data store",
+				Params:   []apiParam{{Name: "format", In: "query", Type: "string", Description: "json or gob", Default: "json"}},
+				Response: storeSnapshot{},
+			},
+		}},
+		{Path: "/api/admin/restore", Handler: handleRestore, Operations: []apiOperation{{
+			Method: "POST", Tag: "Admin", Summary: "Replace the data store with the snapshot in the body (JSON, or gob as application/x-gob), or with the snapshot file when the body is empty",
+			Request: storeSnapshot{}, Response: snapshotInfo{},
+		}}},
+
+		// API documentation
+		{Path: "/api/openapi.json", Handler: handleOpenAPISpec, Operations: []apiOperation{{
+			Method: "GET", Tag: "Documentation", Summary: "This OpenAPI 3 document",
+			Response: openAPISchema{"type": "object"},
+		}}},
+	})
+}
+
+// benchmarkRoutes are the GET /api/benchmark/<name> routes of the
+// registered benchmarks, documented from their parameter schemas.
+func benchmarkRoutes() []apiRoute {
+	var routes []apiRoute
+	for _, b := range benchkernels.All() {
+		var params []apiParam
+		for _, p := range b.ParamsSchema() {
+			params = append(params, apiParam{Name: p.Name, In: "query", Type: "integer", Description: p.Description, Default: p.Default})
+		}
+		routes = append(routes, apiRoute{Path: "/api/benchmark/" + b.Name(), Handler: benchmarkHandler(b.Name()), Operations: []apiOperation{{
+			Method: "GET", Tag: "Benchmarks", Summary: "Run the server-side " + b.Name() + " benchmark",
+			Params: params, Response: benchmarkResultSchema,
+		}}})
+	}
+	return routes
+}
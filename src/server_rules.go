@@ -0,0 +1,46 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ============================================================================
+// VALIDATION RULES
+// The ruleset applied by ValidateUser and ValidateProduct is the built-in
+// validation_rules.json unless -validation-rules-file names another, read
+// once at startup; a file that doesn't parse leaves the built-in rules in
+// place. GET /api/validation-rules returns the ruleset for the WebAssembly
+// module to load with setValidationRulesWasm.
+// ============================================================================
+
+// loadValidationRulesFile installs the ruleset in a JSON file.
+func loadValidationRulesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read validation rules file: %w", err)
+	}
+	rules, err := ParseValidationRules(data)
+	if err != nil {
+		return fmt.Errorf("validation rules file %s: %w", path, err)
+	}
+	SetValidationRules(rules)
+	return nil
+}
+
+// handleValidationRules serves the ruleset in use.
+func handleValidationRules(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, CurrentValidationRules())
+}
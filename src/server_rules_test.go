@@ -0,0 +1,47 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestValidationRulesEndpoint tests that the served ruleset parses back to
+// the rules in use
+func TestValidationRulesEndpoint(t *testing.T) {
+	withValidationRules(t)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	os.WriteFile(path, []byte(`{"product": [{"field": "price", "max": 50, "message": "Too expensive"}]}`), 0o644)
+	if err := loadValidationRulesFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handleValidationRules(w, httptest.NewRequest("GET", "/api/validation-rules", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the ruleset, got %d", w.Code)
+	}
+	rules, err := ParseValidationRules(w.Body.Bytes())
+	if err != nil || len(rules["product"]) != 1 || *rules["product"][0].Max != 50 {
+		t.Fatalf("Unexpected ruleset %s: %v", w.Body, err)
+	}
+	var raw map[string][]map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	if _, ok := raw["product"][0]["min"]; ok {
+		t.Errorf("Expected constraints not given to be omitted, got %s", w.Body)
+	}
+
+	os.WriteFile(path, []byte(`{"product": [{"field": "price", "max": 50}]}`), 0o644)
+	if err := loadValidationRulesFile(path); err == nil {
+		t.Error("Expected a rule without a message to be rejected")
+	}
+	if rules := CurrentValidationRules(); *rules["product"][0].Max != 50 {
+		t.Error("Expected a bad file to leave the rules in place")
+	}
+}
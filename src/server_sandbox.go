@@ -0,0 +1,285 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// SANDBOXES
+// A sandbox is a private copy of the demo data. Requests that name one -
+// with an X-Sandbox-ID header or a /sandbox/{id}/ path prefix - read and
+// write that copy instead of the shared store, so several people can try the
+// import (and other mutating) endpoints at once without seeing each other's
+// changes:
+//
+//   POST   /api/sandboxes                    create one with a random ID
+//   GET    /sandbox/{id}/api/demo-users      use it via the path prefix
+//   GET    /api/demo-users  (X-Sandbox-ID)   or via the header
+//   DELETE /api/sandboxes/{id}               discard it
+//
+// Naming an unknown ID creates the sandbox on first use. Sandboxes unused for
+// -sandbox-ttl are removed, and at most -max-sandboxes exist at once.
+// ============================================================================
+
+const (
+	sandboxHeader     = "X-Sandbox-ID"
+	sandboxPathPrefix = "/sandbox/"
+)
+
+var sandboxIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var (
+	errInvalidSandboxID = errors.New("sandbox IDs are 1-64 letters, digits, '-' or '_'")
+	errTooManySandboxes = errors.New("too many active sandboxes")
+)
+
+// sandbox is one isolated dataset.
+type sandbox struct {
+	id        string
+	store     *dataStore
+	createdAt time.Time
+	lastUsed  time.Time
+}
+
+// sandboxInfo describes a sandbox in API responses.
+type sandboxInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Users     int       `json:"users"`
+	Products  int       `json:"products"`
+	Orders    int       `json:"orders"`
+}
+
+// sandboxRegistry holds the active sandboxes.
+type sandboxRegistry struct {
+	mu        sync.Mutex
+	sandboxes map[string]*sandbox
+	ttl       time.Duration
+	limit     int
+	now       func() time.Time
+}
+
+// sandboxes is the active registry. main replaces it with one using the
+// configured TTL and limit.
+var sandboxes = newSandboxRegistry(serverConfig.SandboxTTL, serverConfig.MaxSandboxes)
+
+func newSandboxRegistry(ttl time.Duration, limit int) *sandboxRegistry {
+	return &sandboxRegistry{
+		sandboxes: map[string]*sandbox{},
+		ttl:       ttl,
+		limit:     limit,
+		now:       time.Now,
+	}
+}
+
+func (reg *sandboxRegistry) expired(sb *sandbox, now time.Time) bool {
+	return now.Sub(sb.lastUsed) >= reg.ttl
+}
+
+// acquire returns the sandbox with the given ID, creating it if it doesn't
+// exist (or has expired), and marks it as used.
+func (reg *sandboxRegistry) acquire(id string) (*sandbox, error) {
+	if !sandboxIDPattern.MatchString(id) {
+		return nil, errInvalidSandboxID
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	now := reg.now()
+	sb, ok := reg.sandboxes[id]
+	if !ok || reg.expired(sb, now) {
+		if !ok && len(reg.sandboxes) >= reg.limit {
+			reg.sweepLocked(now)
+			if len(reg.sandboxes) >= reg.limit {
+				return nil, errTooManySandboxes
+			}
+		}
+		sb = &sandbox{id: id, store: newDemoDataStore(), createdAt: now}
+		reg.sandboxes[id] = sb
+	}
+	sb.lastUsed = now
+	return sb, nil
+}
+
+// create starts a sandbox with a random ID.
+func (reg *sandboxRegistry) create() (*sandbox, error) {
+	return reg.acquire(newRandomID())
+}
+
+// get returns an active sandbox without creating it or extending its TTL.
+func (reg *sandboxRegistry) get(id string) (*sandbox, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	sb, ok := reg.sandboxes[id]
+	if !ok || reg.expired(sb, reg.now()) {
+		return nil, false
+	}
+	return sb, true
+}
+
+func (reg *sandboxRegistry) remove(id string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	_, ok := reg.sandboxes[id]
+	delete(reg.sandboxes, id)
+	return ok
+}
+
+// sweep removes expired sandboxes and reports how many were removed.
+func (reg *sandboxRegistry) sweep() int {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.sweepLocked(reg.now())
+}
+
+func (reg *sandboxRegistry) sweepLocked(now time.Time) int {
+	removed := 0
+	for id, sb := range reg.sandboxes {
+		if reg.expired(sb, now) {
+			delete(reg.sandboxes, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// runCleanup sweeps expired sandboxes periodically until ctx is done.
+func (reg *sandboxRegistry) runCleanup(ctx context.Context) {
+	ticker := time.NewTicker(max(reg.ttl/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reg.sweep()
+		}
+	}
+}
+
+func (reg *sandboxRegistry) info(sb *sandbox) sandboxInfo {
+	reg.mu.Lock()
+	expires := sb.lastUsed.Add(reg.ttl)
+	reg.mu.Unlock()
+	return sandboxInfo{
+		ID:        sb.id,
+		CreatedAt: sb.createdAt,
+		ExpiresAt: expires,
+		Users:     len(sb.store.listUsers()),
+		Products:  len(sb.store.listProducts()),
+		Orders:    len(sb.store.listOrders()),
+	}
+}
+
+type sandboxContextKey struct{}
+
+// storeFor returns the data store a request operates on: its sandbox's, or
+// the shared demo store when it doesn't name one.
+func storeFor(r *http.Request) *dataStore {
+	if sb, ok := r.Context().Value(sandboxContextKey{}).(*sandbox); ok {
+		return sb.store
+	}
+	return demoStore
+}
+
+// sandboxID returns the ID of the request's sandbox, or "" outside one.
+func sandboxID(r *http.Request) string {
+	if sb, ok := r.Context().Value(sandboxContextKey{}).(*sandbox); ok {
+		return sb.id
+	}
+	return ""
+}
+
+// sandboxMiddleware resolves the sandbox named by the path prefix or header,
+// strips the prefix so the router sees the plain route, and attaches the
+// sandbox to the request context.
+func sandboxMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(sandboxHeader)
+		if rest, ok := strings.CutPrefix(r.URL.Path, sandboxPathPrefix); ok {
+			var path string
+			id, path, _ = strings.Cut(rest, "/")
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + path
+			r.URL.RawPath = ""
+		}
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sb, err := sandboxes.acquire(id)
+		switch {
+		case errors.Is(err, errTooManySandboxes):
+			w.Header().Set("Retry-After", "60")
+			writeError(w, http.StatusServiceUnavailable, "Too many active sandboxes")
+			return
+		case err != nil:
+			writeError(w, http.StatusBadRequest, "Invalid sandbox ID: "+err.Error())
+			return
+		}
+
+		w.Header().Set(sandboxHeader, sb.id)
+		w.Header().Add("Vary", sandboxHeader)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sandboxContextKey{}, sb)))
+	})
+}
+
+// handleSandboxes creates a sandbox with a random ID.
+func handleSandboxes(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sb, err := sandboxes.create()
+	if err != nil {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, "Too many active sandboxes")
+		return
+	}
+
+	w.Header().Set("Location", "/api/sandboxes/"+sb.id)
+	writeJSON(w, r, http.StatusCreated, sandboxes.info(sb))
+}
+
+// handleSandbox describes (GET) or discards (DELETE) a sandbox.
+func handleSandbox(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id := r.PathValue("id")
+	switch r.Method {
+	case "GET":
+		sb, ok := sandboxes.get(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Sandbox not found")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, sandboxes.info(sb))
+	case "DELETE":
+		if !sandboxes.remove(id) {
+			writeError(w, http.StatusNotFound, "Sandbox not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
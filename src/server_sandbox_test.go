@@ -0,0 +1,128 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSandboxes replaces the sandbox registry with an empty one whose clock
+// the test controls.
+func withSandboxes(t *testing.T, ttl time.Duration, limit int) *time.Time {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSandboxRegistry(ttl, limit)
+	registry.now = func() time.Time { return now }
+
+	previous := sandboxes
+	sandboxes = registry
+	t.Cleanup(func() { sandboxes = previous })
+	return &now
+}
+
+// TestSandboxIsolation tests that imports into a sandbox leave other
+// sandboxes and the shared store untouched
+func TestSandboxIsolation(t *testing.T) {
+	withDemoStore(t)
+	withSandboxes(t, time.Hour, 10)
+	handler := newServerHandler()
+	shared := len(demoStore.listUsers())
+
+	importUser := func(target, header string) {
+		body := "name,email,age,country\nSandbox User,sandbox@example.com,30,US\n"
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		if header != "" {
+			req.Header.Set(sandboxHeader, header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Import into %s failed with %d: %s", target, w.Code, w.Body.String())
+		}
+	}
+	countUsers := func(target, header string) int {
+		req := httptest.NewRequest("GET", target, nil)
+		if header != "" {
+			req.Header.Set(sandboxHeader, header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var users []User
+		json.NewDecoder(w.Body).Decode(&users)
+		return len(users)
+	}
+
+	importUser("/sandbox/alpha/api/import?type=users", "")
+	importUser("/api/import?type=users", "beta")
+	importUser("/api/import?type=users", "beta")
+
+	if got := countUsers("/sandbox/alpha/api/demo-users", ""); got != shared+1 {
+		t.Errorf("Expected sandbox alpha to have %d users, got %d", shared+1, got)
+	}
+	// The second import into beta is a duplicate email
+	if got := countUsers("/api/demo-users", "beta"); got != shared+1 {
+		t.Errorf("Expected sandbox beta to have %d users, got %d", shared+1, got)
+	}
+	if got := countUsers("/api/demo-users", ""); got != shared {
+		t.Errorf("Expected the shared store to be unchanged, got %d users", got)
+	}
+}
+
+// TestSandboxLifecycle tests creation, expiry, deletion and limits
+func TestSandboxLifecycle(t *testing.T) {
+	now := withSandboxes(t, time.Minute, 2)
+
+	w := httptest.NewRecorder()
+	handleSandboxes(w, httptest.NewRequest("POST", "/api/sandboxes", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	var created sandboxInfo
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == "" || created.Users == 0 || !created.ExpiresAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Unexpected sandbox: %+v", created)
+	}
+	if w.Header().Get("Location") != "/api/sandboxes/"+created.ID {
+		t.Errorf("Unexpected Location %q", w.Header().Get("Location"))
+	}
+
+	if _, err := sandboxes.acquire("second"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := sandboxes.acquire("third"); err != errTooManySandboxes {
+		t.Errorf("Expected the sandbox limit to be enforced, got %v", err)
+	}
+	if _, err := sandboxes.acquire("not/valid"); err != errInvalidSandboxID {
+		t.Errorf("Expected invalid ID to be rejected, got %v", err)
+	}
+
+	// Keep "second" alive while the created sandbox expires
+	*now = now.Add(45 * time.Second)
+	sandboxes.acquire("second")
+	*now = now.Add(30 * time.Second)
+	if removed := sandboxes.sweep(); removed != 1 {
+		t.Errorf("Expected 1 expired sandbox to be removed, got %d", removed)
+	}
+
+	mux := newServerMux()
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/sandboxes/"+created.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected expired sandbox to be gone, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/sandboxes/second", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if _, ok := sandboxes.get("second"); ok {
+		t.Error("Expected deleted sandbox to be gone")
+	}
+}
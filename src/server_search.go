@@ -0,0 +1,77 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// PRODUCT SEARCH
+// GET /api/search-products searches the catalog with SearchProducts:
+//
+//   ?q=hedphones        the query; misspelt words still match
+//   ?sort=price_asc     relevance (the default), price_asc, price_desc,
+//                       rating or newest
+//   ?limit=10           results to return, 1 to 20 (the default)
+//
+// searchProductsWasm searches the catalog passed from the page the same way.
+// ============================================================================
+
+// searchResponse is the body of GET /api/search-products.
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Sort    string         `json:"sort"`
+	Results []SearchResult `json:"results"`
+}
+
+// handleSearchProducts searches the catalog.
+func handleSearchProducts(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	fields := map[string]string{}
+	if query == "" {
+		fields["q"] = "is required"
+	}
+	options := SearchOptions{Sort: SortRelevance, Limit: maxSearchResults}
+	if raw := r.URL.Query().Get("sort"); raw != "" {
+		options.Sort = raw
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxSearchResults {
+			fields["limit"] = "must be a whole number from 1 to " + strconv.Itoa(maxSearchResults)
+		}
+		options.Limit = value
+	}
+	for _, fieldErr := range ValidateSearchOptions(options).FieldErrors {
+		if fields[fieldErr.Field] == "" {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	ctx, cancel := computeContext(r)
+	defer cancel()
+	results, err := SearchProductsContext(ctx, storeFor(r).catalog(time.Now()), query, options)
+	if err != nil {
+		writeComputeStopped(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, searchResponse{Query: query, Sort: options.Sort, Results: results})
+}
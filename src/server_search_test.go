@@ -0,0 +1,54 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSearch tests searching the demo catalog
+func TestSearch(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/search-products?q=hedphones", nil))
+	var body searchResponse
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusOK || body.Query != "hedphones" || len(body.Results) == 0 || body.Results[0].ID != 1 {
+		t.Fatalf("Expected the headphones first, got %d %+v", w.Code, body)
+	}
+	if body.Sort != SortRelevance || len(body.Results[0].Matches) == 0 {
+		t.Errorf("Expected match spans, got %+v", body.Results[0])
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected Cache-Control no-store, got %q", got)
+	}
+
+	for target, status := range map[string]int{
+		"/api/search-products":                   http.StatusBadRequest,
+		"/api/search-products?q=mug&limit=0":     http.StatusBadRequest,
+		"/api/search-products?q=mug&limit=abc":   http.StatusBadRequest,
+		"/api/search-products?q=mug&limit=1":     http.StatusOK,
+		"/api/search-products?q=mug&sort=rating": http.StatusOK,
+		"/api/search-products?q=mug&sort=best":   http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+
+	// Searches still running at the compute timeout stop
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.ComputeTimeout = time.Nanosecond })
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/search-products?q=mug", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 past the compute timeout, got %d %s", w.Code, w.Body.String())
+	}
+}
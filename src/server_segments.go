@@ -0,0 +1,30 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+)
+
+// ============================================================================
+// CUSTOMER SEGMENTS
+// GET /api/analytics/segments places the customers of the stored orders in
+// RFM segments with SegmentUsersRFM; segmentUsersRFMWasm segments users and
+// orders passed from the page, such as an uploaded export.
+// ============================================================================
+
+// handleSegments serves the RFM segments of the stored users and orders.
+func handleSegments(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	store := storeFor(r)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, SegmentUsersRFM(store.listUsers(), store.listOrders()))
+}
@@ -0,0 +1,37 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSegments tests segmenting the demo customers
+func TestSegments(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/segments", nil))
+	var segmentation RFMSegmentation
+	json.NewDecoder(w.Body).Decode(&segmentation)
+	if w.Code != http.StatusOK || segmentation.AsOf == "" || len(segmentation.Customers) == 0 {
+		t.Fatalf("Expected segmented customers, got %d %+v", w.Code, segmentation)
+	}
+	customers := 0
+	for _, summary := range segmentation.Segments {
+		customers += summary.Customers
+	}
+	if customers != len(segmentation.Customers) || len(segmentation.Segments) != len(rfmSegments) {
+		t.Errorf("Expected every segment summarized, got %+v", segmentation.Segments)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/analytics/segments", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", w.Code)
+	}
+}
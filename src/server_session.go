@@ -0,0 +1,123 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// SESSIONS
+// Browser sessions identified by an HttpOnly cookie. Sessions are created on
+// first use, kept in memory and dropped after -session-ttl without requests;
+// when -max-sessions is reached the least recently used one is evicted.
+// ============================================================================
+
+const sessionCookieName = "demo_session"
+
+// session is the server-side state of one browser session.
+type session struct {
+	id       string
+	cart     Cart
+	lastUsed time.Time
+}
+
+// sessionStore holds the active sessions.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	ttl      time.Duration
+	limit    int
+	now      func() time.Time
+}
+
+// sessions is the active session store. main replaces it with one using the
+// configured TTL and limit.
+var sessions = newSessionStore(serverConfig.SessionTTL, serverConfig.MaxSessions)
+
+func newSessionStore(ttl time.Duration, limit int) *sessionStore {
+	return &sessionStore{
+		sessions: map[string]*session{},
+		ttl:      ttl,
+		limit:    limit,
+		now:      time.Now,
+	}
+}
+
+// with runs fn on the request's session, creating the session (and setting
+// its cookie) when the request has none or it has expired. fn runs with the
+// store locked, so it must not block.
+func (store *sessionStore) with(w http.ResponseWriter, r *http.Request, fn func(*session)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	var sess *session
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if existing, ok := store.sessions[cookie.Value]; ok && now.Sub(existing.lastUsed) < store.ttl {
+			sess = existing
+		}
+	}
+	if sess == nil {
+		if len(store.sessions) >= store.limit {
+			store.evictLocked(now)
+		}
+		// Two random IDs give 128 bits, enough that session IDs can't be guessed
+		sess = &session{id: newRandomID() + newRandomID()}
+		store.sessions[sess.id] = sess
+	}
+	sess.lastUsed = now
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    sess.id,
+		Path:     "/",
+		MaxAge:   int(store.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	fn(sess)
+}
+
+// evictLocked drops expired sessions, and the least recently used one if
+// none had expired.
+func (store *sessionStore) evictLocked(now time.Time) {
+	var oldest *session
+	for id, sess := range store.sessions {
+		if now.Sub(sess.lastUsed) >= store.ttl {
+			delete(store.sessions, id)
+			continue
+		}
+		if oldest == nil || sess.lastUsed.Before(oldest.lastUsed) {
+			oldest = sess
+		}
+	}
+	if len(store.sessions) >= store.limit && oldest != nil {
+		delete(store.sessions, oldest.id)
+	}
+}
+
+// runCleanup drops expired sessions periodically until ctx is done.
+func (store *sessionStore) runCleanup(ctx context.Context) {
+	ticker := time.NewTicker(max(store.ttl/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			store.mu.Lock()
+			now := store.now()
+			for id, sess := range store.sessions {
+				if now.Sub(sess.lastUsed) >= store.ttl {
+					delete(store.sessions, id)
+				}
+			}
+			store.mu.Unlock()
+		}
+	}
+}
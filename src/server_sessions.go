@@ -0,0 +1,73 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// SESSIONS
+// GET /api/analytics/sessions splits the stored events into sessions with
+// SessionizeEvents and follows the sessions through the funnel with
+// SessionFunnel:
+//
+//   ?gap_minutes=30   idle minutes that end a session, 1 to 1440
+//   ?user_id=1        only this user's sessions
+// ============================================================================
+
+// sessionsResponse is the body of GET /api/analytics/sessions.
+type sessionsResponse struct {
+	GapMinutes int          `json:"gap_minutes"`
+	Sessions   []Session    `json:"sessions"`
+	Funnel     []FunnelStep `json:"funnel"`
+}
+
+// handleSessions serves the sessions of the stored events.
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	fields := map[string]string{}
+	gap := DefaultSessionGap
+	if raw := r.URL.Query().Get("gap_minutes"); raw != "" {
+		minutes, err := strconv.Atoi(raw)
+		if err != nil || minutes < 1 || time.Duration(minutes)*time.Minute > maxSessionGap {
+			fields["gap_minutes"] = "must be a whole number from 1 to " + strconv.Itoa(int(maxSessionGap/time.Minute))
+		}
+		gap = time.Duration(minutes) * time.Minute
+	}
+	userID := 0
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id < 1 {
+			fields["user_id"] = "must be a user ID"
+		}
+		userID = id
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	sessions := SessionizeEvents(storeFor(r).listEvents(), gap)
+	if userID > 0 {
+		kept := []Session{}
+		for _, session := range sessions {
+			if session.UserID == userID {
+				kept = append(kept, session)
+			}
+		}
+		sessions = kept
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, sessionsResponse{GapMinutes: int(gap / time.Minute), Sessions: sessions, Funnel: SessionFunnel(sessions)})
+}
@@ -0,0 +1,346 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ============================================================================
+// DATA STORE SNAPSHOTS
+// The shared store lives in memory, so without snapshots a restart loses
+// everything changed through the API. A snapshot is the whole store - users,
+// products, orders, stock reservations, subscriptions, gift cards, wishlists,
+// price histories and behavior events - in one JSON or gob file
+// (-snapshot-file, by its extension):
+//
+//   POST /api/admin/snapshot   write the snapshot file now
+//   GET  /api/admin/snapshot   download a snapshot (?format=json or gob)
+//   POST /api/admin/restore    restore the file, or a snapshot in the body
+//
+// The server restores the snapshot file at startup when there is one. With
+// -snapshot-interval set it also writes the file that often while the store
+// keeps changing, and once more at shutdown. Sandboxes are not snapshotted.
+// All three endpoints need the admin token.
+// ============================================================================
+
+// storeSnapshotVersion is the layout of the snapshots written; restores
+// refuse others.
+const storeSnapshotVersion = 1
+
+// Snapshot formats
+const (
+	snapshotJSON = "json"
+	snapshotGob  = "gob"
+)
+
+// gobContentType is the media type of gob snapshots.
+const gobContentType = "application/x-gob"
+
+// storeSnapshot is everything a data store holds but its analytics, which
+// are rebuilt from it.
+type storeSnapshot struct {
+	Version       int                 `json:"version"`
+	TakenAt       time.Time           `json:"taken_at"`
+	Users         []User              `json:"users"`
+	Products      []Product           `json:"products"`
+	Orders        []Order             `json:"orders"`
+	Reservations  StockReservations   `json:"reservations"`
+	Subscriptions []Subscription      `json:"subscriptions"`
+	GiftCards     map[string]GiftCard `json:"gift_cards"`
+	Wishlists     map[int]Wishlist    `json:"wishlists"`
+	Prices        PriceHistories      `json:"prices"`
+	Events        []BehaviorEvent     `json:"events"`
+}
+
+// snapshotInfo describes a snapshot in API responses.
+type snapshotInfo struct {
+	File     string    `json:"file,omitempty"`
+	Format   string    `json:"format"`
+	TakenAt  time.Time `json:"taken_at"`
+	Bytes    int       `json:"bytes"`
+	Users    int       `json:"users"`
+	Products int       `json:"products"`
+	Orders   int       `json:"orders"`
+}
+
+func (snap storeSnapshot) info(file, format string, size int) snapshotInfo {
+	return snapshotInfo{
+		File: file, Format: format, TakenAt: snap.TakenAt, Bytes: size,
+		Users: len(snap.Users), Products: len(snap.Products), Orders: len(snap.Orders),
+	}
+}
+
+// snapshot copies the store's contents as of time now. Nested slices are
+// never changed in place (see dataStore), so copying the top level is
+// enough.
+func (s *dataStore) snapshot(now time.Time) storeSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return storeSnapshot{
+		Version:       storeSnapshotVersion,
+		TakenAt:       now.UTC(),
+		Users:         slices.Clone(s.users),
+		Products:      slices.Clone(s.products),
+		Orders:        slices.Clone(s.orders),
+		Reservations:  maps.Clone(s.reservations),
+		Subscriptions: slices.Clone(s.subscriptions),
+		GiftCards:     maps.Clone(s.giftCards),
+		Wishlists:     maps.Clone(s.wishlists),
+		Prices:        maps.Clone(s.prices),
+		Events:        slices.Clone(s.events),
+	}
+}
+
+// restore replaces the store's contents with a snapshot's.
+func (s *dataStore) restore(snap storeSnapshot) {
+	// Snapshots written from an empty map decode to nil, which can't be
+	// written to
+	if snap.Reservations == nil {
+		snap.Reservations = StockReservations{}
+	}
+	if snap.GiftCards == nil {
+		snap.GiftCards = map[string]GiftCard{}
+	}
+	if snap.Wishlists == nil {
+		snap.Wishlists = map[int]Wishlist{}
+	}
+	if snap.Prices == nil {
+		snap.Prices = PriceHistories{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = snap.Users
+	s.products = snap.Products
+	s.orders = snap.Orders
+	s.reservations = snap.Reservations
+	s.subscriptions = snap.Subscriptions
+	s.giftCards = snap.GiftCards
+	s.wishlists = snap.Wishlists
+	s.prices = snap.Prices
+	s.events = snap.Events
+	s.analytics = nil
+	s.startVersions()
+}
+
+// snapshotFormat is the format of a snapshot file, by its extension.
+func snapshotFormat(path string) string {
+	if filepath.Ext(path) == ".gob" {
+		return snapshotGob
+	}
+	return snapshotJSON
+}
+
+func encodeStoreSnapshot(snap storeSnapshot, format string) ([]byte, error) {
+	if format == snapshotGob {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(snap)
+}
+
+func decodeStoreSnapshot(data []byte, format string) (storeSnapshot, error) {
+	var snap storeSnapshot
+	var err error
+	if format == snapshotGob {
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(&snap)
+	} else {
+		err = json.Unmarshal(data, &snap)
+	}
+	if err != nil {
+		return storeSnapshot{}, fmt.Errorf("invalid %s snapshot: %w", format, err)
+	}
+	if snap.Version != storeSnapshotVersion {
+		return storeSnapshot{}, fmt.Errorf("snapshot version %d is not %d", snap.Version, storeSnapshotVersion)
+	}
+	return snap, nil
+}
+
+// saveStoreSnapshot writes a snapshot of store to path and describes it.
+func saveStoreSnapshot(store *dataStore, path string, now time.Time) (snapshotInfo, error) {
+	return writeStoreSnapshot(store.snapshot(now), path)
+}
+
+func writeStoreSnapshot(snap storeSnapshot, path string) (snapshotInfo, error) {
+	format := snapshotFormat(path)
+	data, err := encodeStoreSnapshot(snap, format)
+	if err != nil {
+		return snapshotInfo{}, err
+	}
+	if err := writeFileAtomically(path, data); err != nil {
+		return snapshotInfo{}, err
+	}
+	return snap.info(path, format, len(data)), nil
+}
+
+// restoreStoreSnapshotFile restores store from the snapshot at path; without
+// a file the store is left as it is and ok is false.
+func restoreStoreSnapshotFile(store *dataStore, path string) (info snapshotInfo, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return snapshotInfo{}, false, nil
+	}
+	if err != nil {
+		return snapshotInfo{}, false, err
+	}
+	format := snapshotFormat(path)
+	snap, err := decodeStoreSnapshot(data, format)
+	if err != nil {
+		return snapshotInfo{}, false, err
+	}
+	store.restore(snap)
+	return snap.info(path, format, len(data)), true, nil
+}
+
+// runStoreSnapshots writes the snapshot every interval until ctx is done,
+// skipping intervals in which the store didn't change.
+func runStoreSnapshots(ctx context.Context, store *dataStore, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last [sha256.Size]byte
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// The time taken is left out of the comparison, or every
+			// snapshot would differ
+			snap := store.snapshot(time.Time{})
+			data, err := json.Marshal(snap)
+			if err != nil {
+				slog.Error("store snapshot failed", "error", err)
+				continue
+			}
+			sum := sha256.Sum256(data)
+			if sum == last {
+				continue
+			}
+			snap.TakenAt = time.Now().UTC()
+			if _, err := writeStoreSnapshot(snap, path); err != nil {
+				slog.Error("store snapshot failed", "path", path, "error", err)
+				continue
+			}
+			last = sum
+			slog.Debug("store snapshot written", "path", path)
+		}
+	}
+}
+
+// handleSnapshot writes the snapshot file (POST) or downloads a snapshot
+// (GET).
+func handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		info, err := saveStoreSnapshot(demoStore, serverConfig.snapshotPath(), time.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "store snapshot failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "Failed to write the snapshot")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, info)
+	case "GET":
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = snapshotJSON
+		}
+		if format != snapshotJSON && format != snapshotGob {
+			writeFieldErrors(w, map[string]string{"format": "must be json or gob"})
+			return
+		}
+		now := time.Now()
+		data, err := encodeStoreSnapshot(demoStore.snapshot(now), format)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to encode the snapshot")
+			return
+		}
+		contentType := "application/json"
+		if format == snapshotGob {
+			contentType = gobContentType
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"store-snapshot-%s.%s\"", now.UTC().Format("20060102-150405"), format))
+		w.Write(data)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleRestore restores the shared store from the snapshot file, or from a
+// JSON or gob snapshot in the body, and rebuilds the recommendations from
+// the restored orders.
+func handleRestore(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Snapshots are bulk uploads, so they get the import size limit
+	body, ok := readBody(w, r, serverConfig.MaxImportBytes)
+	if !ok {
+		return
+	}
+
+	var info snapshotInfo
+	var err error
+	if len(body) == 0 {
+		var found bool
+		info, found, err = restoreStoreSnapshotFile(demoStore, serverConfig.snapshotPath())
+		if err == nil && !found {
+			writeError(w, http.StatusNotFound, "No snapshot file to restore - see POST /api/admin/snapshot")
+			return
+		}
+	} else {
+		format := snapshotJSON
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == gobContentType {
+			format = snapshotGob
+		}
+		var snap storeSnapshot
+		if snap, err = decodeStoreSnapshot(body, format); err == nil {
+			demoStore.restore(snap)
+			info = snap.info("", format, len(body))
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to restore: "+err.Error())
+		return
+	}
+
+	rebuildRecommendations(demoStore, time.Now())
+	slog.InfoContext(r.Context(), "store restored", "users", info.Users, "products", info.Products, "orders", info.Orders)
+	writeJSON(w, r, http.StatusOK, info)
+}
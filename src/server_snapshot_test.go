@@ -0,0 +1,208 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreSnapshotRoundTrip(t *testing.T) {
+	store := newDemoDataStore()
+	if err := store.issueGiftCard(GiftCard{Code: "SNAP-1", Balance: 25, Currency: "USD"}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	snap := store.snapshot(now)
+
+	for _, format := range []string{snapshotJSON, snapshotGob} {
+		data, err := encodeStoreSnapshot(snap, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		decoded, err := decodeStoreSnapshot(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		restored := &dataStore{}
+		restored.restore(decoded)
+		if got := restored.snapshot(now); !reflect.DeepEqual(got.Users, snap.Users) || !reflect.DeepEqual(got.Orders, snap.Orders) ||
+			len(got.Products) != len(snap.Products) || len(got.Prices) != len(snap.Prices) || len(got.Events) != len(snap.Events) {
+			t.Errorf("%s: expected the store back, got %d users, %d products and %d orders", format, len(got.Users), len(got.Products), len(got.Orders))
+		}
+		if card, err := restored.giftCard("SNAP-1"); err != nil || card.Balance != 25 {
+			t.Errorf("%s: expected the gift card back, got %+v (%v)", format, card, err)
+		}
+		// Maps left empty come back writable
+		if _, err := restored.updateWishlist(1, func(*Wishlist) error { return nil }); err != nil {
+			t.Errorf("%s: expected the restored store to take changes, got %v", format, err)
+		}
+	}
+
+	if _, err := decodeStoreSnapshot([]byte(`{"version": 99}`), snapshotJSON); err == nil {
+		t.Error("Expected an unknown snapshot version to be rejected")
+	}
+	if _, err := decodeStoreSnapshot([]byte(`not a snapshot`), snapshotGob); err == nil {
+		t.Error("Expected a malformed snapshot to be rejected")
+	}
+}
+
+func TestStoreSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+	store := newDemoDataStore()
+	store.insertUsers([]User{{Name: "Snapshot User", Email: "snapshot@example.com", Age: 30, Country: "US"}}, true)
+
+	for _, name := range []string{"store.json", "store.gob"} {
+		path := filepath.Join(dir, name)
+		info, err := saveStoreSnapshot(store, path, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Format != snapshotFormat(path) || info.Users != len(store.listUsers()) || info.Bytes == 0 {
+			t.Errorf("%s: unexpected snapshot info %+v", name, info)
+		}
+
+		restored := newDemoDataStore()
+		if _, ok, err := restoreStoreSnapshotFile(restored, path); err != nil || !ok {
+			t.Fatalf("%s: expected the file to restore, got %v (%v)", name, ok, err)
+		}
+		if users := restored.listUsers(); users[len(users)-1].Email != "snapshot@example.com" {
+			t.Errorf("%s: expected the added user back, got %+v", name, users[len(users)-1])
+		}
+	}
+
+	// Without a file the store keeps its data
+	restored := newDemoDataStore()
+	if _, ok, err := restoreStoreSnapshotFile(restored, filepath.Join(dir, "missing.json")); ok || err != nil {
+		t.Errorf("Expected a missing file to be skipped, got %v (%v)", ok, err)
+	}
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644)
+	if _, _, err := restoreStoreSnapshotFile(restored, filepath.Join(dir, "broken.json")); err == nil {
+		t.Error("Expected a broken file to be reported")
+	}
+}
+
+func TestRunStoreSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store := newDemoDataStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runStoreSnapshots(ctx, store, path, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForFile := func() os.FileInfo {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if info, err := os.Stat(path); err == nil {
+				return info
+			}
+		}
+		t.Fatal("Expected a snapshot to be written")
+		return nil
+	}
+	first := waitForFile()
+
+	// An unchanged store isn't written again
+	time.Sleep(50 * time.Millisecond)
+	if info, _ := os.Stat(path); !info.ModTime().Equal(first.ModTime()) {
+		t.Error("Expected no snapshot while the store is unchanged")
+	}
+
+	// A change is
+	os.Remove(path)
+	store.insertUsers([]User{{Name: "Later User", Email: "later@example.com", Age: 40, Country: "DE"}}, true)
+	waitForFile()
+}
+
+func TestSnapshotEndpoints(t *testing.T) {
+	withItemSimilarity(t)
+	withTrending(t)
+	withCohortPreferences(t)
+	withDemoStore(t)
+	dir := t.TempDir()
+	withServerConfig(t, func(cfg *ServerConfig) {
+		cfg.AdminToken = "admin"
+		cfg.StoragePath = dir
+	})
+	mux := newServerMux()
+	do := func(method, target, contentType string, body []byte, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, target := range []string{"/api/admin/snapshot", "/api/admin/restore"} {
+		if w := do("POST", target, "", nil, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401 without the admin token, got %d", target, w.Code)
+		}
+	}
+	if w := do("POST", "/api/admin/restore", "", nil, "admin"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a snapshot file, got %d", w.Code)
+	}
+
+	// Snapshot, change the store, then restore the snapshot
+	w := do("POST", "/api/admin/snapshot", "", nil, "admin")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var info snapshotInfo
+	json.NewDecoder(w.Body).Decode(&info)
+	if info.File != filepath.Join(dir, "store_snapshot.json") || info.Users != len(demoStore.listUsers()) {
+		t.Errorf("Unexpected snapshot info %+v", info)
+	}
+	users := len(demoStore.listUsers())
+	demoStore.insertUsers([]User{{Name: "Lost User", Email: "lost@example.com", Age: 30, Country: "US"}}, true)
+	if w := do("POST", "/api/admin/restore", "", nil, "admin"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := len(demoStore.listUsers()); got != users {
+		t.Errorf("Expected the restore to drop the later user, got %d users", got)
+	}
+
+	// Downloads restore as uploads in either format
+	for _, format := range []string{snapshotJSON, snapshotGob} {
+		w := do("GET", "/api/admin/snapshot?format="+format, "", nil, "admin")
+		if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Disposition"), "."+format) {
+			t.Fatalf("%s: expected a download, got %d %v", format, w.Code, w.Header())
+		}
+		download := w.Body.Bytes()
+		demoStore.insertUsers([]User{{Name: "Lost User", Email: "lost@example.com", Age: 30, Country: "US"}}, true)
+		w = do("POST", "/api/admin/restore", w.Header().Get("Content-Type"), download, "admin")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", format, w.Code, w.Body.String())
+		}
+		if got := len(demoStore.listUsers()); got != users {
+			t.Errorf("%s: expected the upload to restore %d users, got %d", format, users, got)
+		}
+	}
+
+	if w := do("GET", "/api/admin/snapshot?format=xml", "", nil, "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown format to be rejected, got %d", w.Code)
+	}
+	if w := do("POST", "/api/admin/restore", "application/json", []byte(`{"version": 1`), "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a malformed upload to be rejected, got %d", w.Code)
+	}
+}
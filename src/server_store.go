@@ -0,0 +1,795 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// DEMO DATA STORE
+// In-memory users, products and orders served by the demo-data endpoints.
+// It starts out with the generated demo data; bulk imports and cart
+// checkouts add to it. Placed orders reserve product stock until they ship
+// (committing it) or are cancelled (releasing it).
+//
+// Handlers share one store, so every method locks it: reads share the lock
+// and return copies, and nested slices (variants, shipments) are copied
+// before they are changed, since copies handed out earlier share them.
+// Handlers that change the store publish the change on dataChanges.
+//
+// Users, products and orders start at version 1 and every change moves them
+// to the next version, including the ones orders make elsewhere (stock,
+// loyalty points). Updates take the version the caller last saw and fail
+// with a *VersionConflictError when it's no longer current; anyVersion
+// skips the check.
+// ============================================================================
+
+// anyVersion updates a record whatever its version.
+const anyVersion = 0
+
+type dataStore struct {
+	mu       sync.RWMutex
+	users    []User
+	products []Product
+	orders   []Order
+	// reservations are the stock held by orders not yet shipped
+	reservations  StockReservations
+	subscriptions []Subscription
+	giftCards     map[string]GiftCard // by code
+	wishlists     map[int]Wishlist    // by user ID
+	prices        PriceHistories
+	// events are the behavior events posted to /api/events, oldest first
+	events []BehaviorEvent
+	// analytics follows the users and orders as they are added; it is built
+	// on first use and dropped on a change it can't add up, like a
+	// cancellation
+	analytics *AnalyticsAccumulator
+}
+
+// demoStore is the shared data store, used by requests outside a sandbox.
+var demoStore = newDemoDataStore()
+
+// newDemoDataStore returns a store seeded with the generated demo data.
+func newDemoDataStore() *dataStore {
+	products := generateDemoProducts()
+	store := &dataStore{
+		users:    generateDemoUsers(),
+		products: products,
+		orders:   generateDemoOrders(),
+		prices:   generateDemoPriceHistory(products, time.Now()),
+		events:   generateDemoEvents(),
+
+		reservations: StockReservations{},
+		giftCards:    map[string]GiftCard{},
+		wishlists:    map[int]Wishlist{},
+	}
+	store.startVersions()
+	return store
+}
+
+// startVersions puts the records without a version at version 1, with s.mu
+// held or before the store is shared.
+func (s *dataStore) startVersions() {
+	for i := range s.users {
+		s.users[i].Version = max(s.users[i].Version, 1)
+	}
+	for i := range s.products {
+		s.products[i].Version = max(s.products[i].Version, 1)
+	}
+	for i := range s.orders {
+		s.orders[i].Version = max(s.orders[i].Version, 1)
+	}
+}
+
+// touchProducts moves the products of items to their next version, with
+// s.mu held, after their stock has changed.
+func (s *dataStore) touchProducts(items []CartItem) {
+	var touched []int
+	for _, item := range items {
+		if i := productIndex(s.products, item.ProductID); i >= 0 && !slices.Contains(touched, item.ProductID) {
+			s.products[i].Version++
+			touched = append(touched, item.ProductID)
+		}
+	}
+}
+
+func (s *dataStore) listUsers() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]User(nil), s.users...)
+}
+
+func (s *dataStore) listProducts() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Product(nil), s.products...)
+}
+
+// catalog is the products as sold at time now: priced for their recent
+// demand when -dynamic-pricing is on, else at their list prices.
+func (s *dataStore) catalog(now time.Time) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !serverConfig.DynamicPricing {
+		return append([]Product(nil), s.products...)
+	}
+	return ApplyDynamicPricing(s.products, s.orders, now, serverConfig.dynamicPricing())
+}
+
+// maxStoredEvents is how many behavior events a store keeps; the oldest are
+// dropped first.
+const maxStoredEvents = 10000
+
+// listEvents returns a copy of the stored behavior events.
+func (s *dataStore) listEvents() []BehaviorEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.events)
+}
+
+// recordEvents appends behavior events, dropping the oldest beyond
+// maxStoredEvents.
+func (s *dataStore) recordEvents(events []BehaviorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	if extra := len(s.events) - maxStoredEvents; extra > 0 {
+		s.events = slices.Delete(s.events, 0, extra)
+	}
+}
+
+// analyticsSnapshot reports the analytics of the stored users and orders,
+// ranking topCountries countries.
+func (s *dataStore) analyticsSnapshot(topCountries int) UserAnalytics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.analytics == nil {
+		s.analytics = NewAnalyticsAccumulator()
+		for _, user := range s.users {
+			s.analytics.AddUser(user)
+		}
+		for _, order := range s.orders {
+			s.analytics.AddOrder(order)
+		}
+	}
+	s.analytics.TopCountries = topCountries
+	return s.analytics.Snapshot()
+}
+
+func (s *dataStore) listOrders() []Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Order(nil), s.orders...)
+}
+
+// load replaces everything in the store with a generated dataset, as of time
+// now: its records, the curated price history and events (the dataset
+// starts with the curated records), and nothing else.
+func (s *dataStore) load(data DemoData, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = data.Users
+	s.products = data.Products
+	s.orders = data.Orders
+	s.prices = generateDemoPriceHistory(data.Products, now)
+	s.events = generateDemoEvents()
+	s.reservations = StockReservations{}
+	s.subscriptions = nil
+	s.giftCards = map[string]GiftCard{}
+	s.wishlists = map[int]Wishlist{}
+	s.analytics = nil
+	s.startVersions()
+}
+
+// insertUsers adds users to the store. A zero ID is assigned the next free
+// ID. Conflicts with existing users (ID or email, case-insensitively) are
+// reported per user by index, and those users are not inserted. With commit
+// false the conflicts are checked but nothing is stored.
+func (s *dataStore) insertUsers(users []User, commit bool) ([]User, map[int]error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := map[int]bool{}
+	emails := map[string]bool{}
+	nextID := 1
+	for _, user := range s.users {
+		ids[user.ID] = true
+		emails[strings.ToLower(user.Email)] = true
+		nextID = max(nextID, user.ID+1)
+	}
+
+	conflicts := map[int]error{}
+	var inserted []User
+	for i, user := range users {
+		email := strings.ToLower(user.Email)
+		if user.ID != 0 && ids[user.ID] {
+			conflicts[i] = fmt.Errorf("user ID %d already exists", user.ID)
+			continue
+		}
+		if emails[email] {
+			conflicts[i] = fmt.Errorf("email %s already exists", user.Email)
+			continue
+		}
+		if user.ID == 0 {
+			for ids[nextID] {
+				nextID++
+			}
+			user.ID = nextID
+		}
+		ids[user.ID] = true
+		emails[email] = true
+		user.Version = 1
+		inserted = append(inserted, user)
+	}
+
+	if commit {
+		s.users = append(s.users, inserted...)
+		if s.analytics != nil {
+			for _, user := range inserted {
+				s.analytics.AddUser(user)
+			}
+		}
+	}
+	return inserted, conflicts
+}
+
+// insertProducts adds products to the store like insertUsers; products whose
+// ID is already taken are reported by index and not inserted.
+func (s *dataStore) insertProducts(products []Product, commit bool) ([]Product, map[int]error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := map[int]bool{}
+	nextID := 1
+	for _, product := range s.products {
+		ids[product.ID] = true
+		nextID = max(nextID, product.ID+1)
+	}
+
+	conflicts := map[int]error{}
+	var inserted []Product
+	for i, product := range products {
+		if product.ID != 0 && ids[product.ID] {
+			conflicts[i] = fmt.Errorf("product ID %d already exists", product.ID)
+			continue
+		}
+		if product.ID == 0 {
+			for ids[nextID] {
+				nextID++
+			}
+			product.ID = nextID
+		}
+		ids[product.ID] = true
+		product.Version = 1
+		inserted = append(inserted, product)
+	}
+
+	if commit {
+		s.products = append(s.products, inserted...)
+		now := time.Now()
+		for _, product := range inserted {
+			s.prices[product.ID] = RecordPriceChange(nil, product.Price, now)
+		}
+	}
+	return inserted, conflicts
+}
+
+var errOrderNotFound = errors.New("order not found")
+
+// orderStatuses are the states an order can be moved between.
+var orderStatuses = []string{"pending", "processing", "shipped", "delivered", "cancelled"}
+
+// placeOrder reserves the stock of an order and of its free gifts (leaving
+// off gifts that are out of stock), takes what it pays by gift card off the
+// card and the loyalty points it redeems off the user, and stores it under
+// the next free order ID. When stock is short or the card or points no
+// longer cover the amount nothing is stored and the problem is returned.
+func (s *dataStore) placeOrder(order Order) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.placeOrderLocked(order)
+}
+
+// placeOrderLocked is placeOrder with s.mu held.
+func (s *dataStore) placeOrderLocked(order Order) (Order, error) {
+	order.ID = 1
+	for _, existing := range s.orders {
+		order.ID = max(order.ID, existing.ID+1)
+	}
+	card, paysByCard := s.giftCards[order.GiftCardCode]
+	if order.GiftCardAmount > 0 && (!paysByCard || card.Balance < order.GiftCardAmount) {
+		return Order{}, errors.New("gift card balance changed while the order was priced")
+	}
+	customer := slices.IndexFunc(s.users, func(user User) bool { return user.ID == order.UserID })
+	points := LoyaltyPointsRedeemed(order)
+	if points > 0 && (customer < 0 || s.users[customer].LoyaltyPoints < points) {
+		return Order{}, errors.New("loyalty points changed while the order was priced")
+	}
+	// Free gifts ship with the order while they are in stock
+	order.Promotions = slices.DeleteFunc(slices.Clone(order.Promotions), func(promotion AppliedPromotion) bool {
+		return promotion.GiftProductID > 0 && availableUnits(s.products, CartItem{ProductID: promotion.GiftProductID}) < 1
+	})
+	items := append(OrderItems(order), GiftItems(order)...)
+	if err := ReserveStock(s.products, s.reservations, order.ID, items); err != nil {
+		return Order{}, err
+	}
+	s.touchProducts(items)
+	if order.GiftCardAmount > 0 {
+		card.Balance = RoundToCurrency(card.Balance-order.GiftCardAmount, card.Currency)
+		s.giftCards[card.Code] = card
+	}
+	if points > 0 {
+		s.users[customer].LoyaltyPoints -= points
+		s.users[customer].Version++
+	}
+	order.Version = 1
+	s.orders = append(s.orders, order)
+	if s.analytics != nil {
+		s.analytics.AddOrder(order)
+	}
+	return order, nil
+}
+
+var errGiftCardNotFound = errors.New("gift card not found")
+
+// issueGiftCard stores a new gift card; its code must be unused.
+func (s *dataStore) issueGiftCard(card GiftCard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.giftCards[card.Code]; ok {
+		return fmt.Errorf("gift card %s already exists", card.Code)
+	}
+	s.giftCards[card.Code] = card
+	return nil
+}
+
+// giftCard looks a gift card up by code, ignoring case and spaces.
+func (s *dataStore) giftCard(code string) (GiftCard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	card, ok := s.giftCards[NormalizeGiftCardCode(code)]
+	if !ok {
+		return GiftCard{}, errGiftCardNotFound
+	}
+	return card, nil
+}
+
+// wishlist returns a user's wishlist, empty if they saved nothing yet.
+func (s *dataStore) wishlist(userID int) Wishlist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wishlist := s.wishlists[userID]
+	return Wishlist{UserID: userID, Items: append([]WishlistItem{}, wishlist.Items...)}
+}
+
+// updateWishlist applies update to a user's wishlist, keeping the change
+// only when update succeeds, and returns the wishlist.
+func (s *dataStore) updateWishlist(userID int, update func(wishlist *Wishlist) error) (Wishlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wishlist := s.wishlists[userID]
+	wishlist.UserID = userID
+	wishlist.Items = append([]WishlistItem{}, wishlist.Items...)
+	if err := update(&wishlist); err != nil {
+		return Wishlist{}, err
+	}
+	s.wishlists[userID] = wishlist
+	return wishlist, nil
+}
+
+var errUserNotFound = errors.New("user not found")
+
+// exportUserData collects the records linked to a user.
+func (s *dataStore) exportUserData(userID int, now time.Time) (UserDataExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.users, func(user User) bool { return user.ID == userID })
+	if i < 0 {
+		return UserDataExport{}, errUserNotFound
+	}
+
+	export := UserDataExport{
+		ExportedAt:    now.UTC().Format(time.RFC3339),
+		User:          s.users[i],
+		Orders:        []Order{},
+		Subscriptions: []Subscription{},
+		Wishlist:      Wishlist{UserID: userID, Items: append([]WishlistItem{}, s.wishlists[userID].Items...)},
+	}
+	for _, order := range s.orders {
+		if order.UserID == userID {
+			export.Orders = append(export.Orders, order)
+		}
+	}
+	for _, sub := range s.subscriptions {
+		if sub.UserID == userID {
+			export.Subscriptions = append(export.Subscriptions, sub)
+		}
+	}
+	return export, nil
+}
+
+// anonymizeUser scrubs a user's personal data from the user and their
+// orders, cancels their subscriptions and drops their wishlist. It returns
+// the anonymized user and the IDs of the user's orders.
+func (s *dataStore) anonymizeUser(userID, version int) (User, []int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(user User) bool { return user.ID == userID })
+	if i < 0 {
+		return User{}, nil, errUserNotFound
+	}
+	if version != anyVersion {
+		if err := CheckVersion(s.users[i].Version, version); err != nil {
+			return User{}, nil, err
+		}
+	}
+
+	s.users[i] = AnonymizeUser(s.users[i])
+	s.users[i].Version++
+	s.analytics = nil
+	orderIDs := []int{}
+	for j := range s.orders {
+		if s.orders[j].UserID == userID {
+			s.orders[j] = AnonymizeOrder(s.orders[j])
+			s.orders[j].Version++
+			orderIDs = append(orderIDs, s.orders[j].ID)
+		}
+	}
+	for j := range s.subscriptions {
+		if s.subscriptions[j].UserID == userID {
+			s.subscriptions[j].Status = SubscriptionCancelled
+		}
+	}
+	delete(s.wishlists, userID)
+	return s.users[i], orderIDs, nil
+}
+
+// setUserPreferences replaces a user's preferences.
+func (s *dataStore) setUserPreferences(userID, version int, prefs Preferences) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(user User) bool { return user.ID == userID })
+	if i < 0 {
+		return User{}, errUserNotFound
+	}
+	if version != anyVersion {
+		if err := CheckVersion(s.users[i].Version, version); err != nil {
+			return User{}, err
+		}
+	}
+	s.users[i].Preferences = &prefs
+	s.users[i].Version++
+	s.analytics = nil
+	return s.users[i], nil
+}
+
+// setUserNotifications replaces a user's notification settings.
+func (s *dataStore) setUserNotifications(userID, version int, settings NotificationSettings) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(user User) bool { return user.ID == userID })
+	if i < 0 {
+		return User{}, errUserNotFound
+	}
+	if version != anyVersion {
+		if err := CheckVersion(s.users[i].Version, version); err != nil {
+			return User{}, err
+		}
+	}
+	s.users[i].Notifications = &settings
+	s.users[i].Version++
+	return s.users[i], nil
+}
+
+var errProductNotFound = errors.New("product not found")
+
+// priceHistory returns a product's price history.
+func (s *dataStore) priceHistory(productID int) (PriceHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if productIndex(s.products, productID) < 0 {
+		return nil, errProductNotFound
+	}
+	return append(PriceHistory{}, s.prices[productID]...), nil
+}
+
+// setProductPrice changes a product's price from time at on, recording the
+// change in its price history. Orders already placed keep their prices.
+func (s *dataStore) setProductPrice(productID, version int, price float64, at time.Time) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := productIndex(s.products, productID)
+	if i < 0 {
+		return Product{}, errProductNotFound
+	}
+	if version != anyVersion {
+		if err := CheckVersion(s.products[i].Version, version); err != nil {
+			return Product{}, err
+		}
+	}
+	product := s.products[i]
+	product.Price = price
+	product.Version++
+	if result := ValidateProduct(product); !result.Valid {
+		return Product{}, errors.New(strings.Join(result.Errors, "; "))
+	}
+	s.products[i] = product
+	s.prices[productID] = RecordPriceChange(s.prices[productID], price, at)
+	return product, nil
+}
+
+// dynamicPrice quotes a product's dynamic price at time now, whether or not
+// -dynamic-pricing applies it to the catalog.
+func (s *dataStore) dynamicPrice(productID int, now time.Time) (DynamicPriceQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := productIndex(s.products, productID)
+	if i < 0 {
+		return DynamicPriceQuote{}, errProductNotFound
+	}
+	pricing := serverConfig.dynamicPricing()
+	signals := ComputeDemandSignals(s.products[i], s.orders, now, pricing.WindowDays)
+	return DynamicPrice(s.products[i], signals, pricing), nil
+}
+
+// stockLevels lists the stock of each product.
+func (s *dataStore) stockLevels() []StockLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return StockLevels(s.products)
+}
+
+// setOrderStatus changes an order's status and returns the updated order and
+// its previous status. Shipping or delivering the order commits its reserved
+// stock; cancelling it releases the stock and refunds its gift card and
+// loyalty points. Orders held for risk review only leave pending for
+// cancelled unless approved.
+func (s *dataStore) setOrderStatus(id, version int, status string, approved bool) (Order, string, error) {
+	if !slices.Contains(orderStatuses, status) {
+		return Order{}, "", fmt.Errorf("invalid status %q (expected one of %s)", status, strings.Join(orderStatuses, ", "))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.orders {
+		if s.orders[i].ID == id {
+			if version != anyVersion {
+				if err := CheckVersion(s.orders[i].Version, version); err != nil {
+					return Order{}, "", err
+				}
+			}
+			previous := s.orders[i].Status
+			if err := CheckRiskHold(s.orders[i], status, approved); err != nil {
+				return Order{}, "", err
+			}
+			if status != previous {
+				s.moveOrder(i, status)
+				s.orders[i].Version++
+			}
+			return s.orders[i], previous, nil
+		}
+	}
+	return Order{}, "", errOrderNotFound
+}
+
+// orderWithUser returns an order and the user who placed it, a user with
+// only the ID when they are no longer in the store.
+func (s *dataStore) orderWithUser(id int) (Order, User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.orders, func(order Order) bool { return order.ID == id })
+	if i < 0 {
+		return Order{}, User{}, errOrderNotFound
+	}
+	order := s.orders[i]
+	user := User{ID: order.UserID}
+	if j := slices.IndexFunc(s.users, func(user User) bool { return user.ID == order.UserID }); j >= 0 {
+		user = s.users[j]
+	}
+	return order, user, nil
+}
+
+var errShipmentNotFound = errors.New("shipment not found")
+
+// setShipmentStatus changes the status of one shipment of an order and
+// returns the updated order and its previous status. The order follows its
+// shipments: it is processing once one has shipped, and shipped or
+// delivered once all have, which orders held for risk review need approval
+// for.
+func (s *dataStore) setShipmentStatus(orderID, shipmentID, version int, status string, approved bool) (Order, string, error) {
+	if !slices.Contains(shipmentStatuses, status) {
+		return Order{}, "", fmt.Errorf("invalid status %q (expected one of %s)", status, strings.Join(shipmentStatuses, ", "))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.orders, func(order Order) bool { return order.ID == orderID })
+	if i < 0 {
+		return Order{}, "", errOrderNotFound
+	}
+	order := &s.orders[i]
+	if version != anyVersion {
+		if err := CheckVersion(order.Version, version); err != nil {
+			return Order{}, "", err
+		}
+	}
+	j := slices.IndexFunc(order.Shipments, func(shipment Shipment) bool { return shipment.ID == shipmentID })
+	if j < 0 {
+		return Order{}, "", errShipmentNotFound
+	}
+	if order.Status == "cancelled" {
+		return Order{}, "", errors.New("order is cancelled")
+	}
+	if status != ShipmentPending {
+		if err := CheckRiskHold(*order, "processing", approved); err != nil {
+			return Order{}, "", err
+		}
+	}
+
+	previous := order.Status
+	if order.Shipments[j].Status == status {
+		return *order, previous, nil
+	}
+	order.Version++
+	// Shipments are changed in a copy, as orders handed out earlier share
+	// them
+	order.Shipments = slices.Clone(order.Shipments)
+	order.Shipments[j].Status = status
+	shipped, delivered := 0, 0
+	for _, shipment := range order.Shipments {
+		switch shipment.Status {
+		case ShipmentDelivered:
+			delivered++
+			shipped++
+		case ShipmentShipped:
+			shipped++
+		}
+	}
+	switch {
+	case delivered == len(order.Shipments):
+		s.moveOrder(i, "delivered")
+	case shipped == len(order.Shipments):
+		s.moveOrder(i, "shipped")
+	case shipped > 0:
+		s.moveOrder(i, "processing")
+	}
+	return *order, previous, nil
+}
+
+// moveOrder sets the status of s.orders[i] with s.mu held, settling its
+// stock, gift card and loyalty points and bringing shipments that are behind along to
+// shipped or delivered.
+func (s *dataStore) moveOrder(i int, status string) {
+	order := &s.orders[i]
+	// Cancelling refunds what a gift card paid
+	if status == "cancelled" && order.Status != "cancelled" && order.GiftCardAmount > 0 {
+		if card, ok := s.giftCards[order.GiftCardCode]; ok {
+			card.Balance = RoundToCurrency(card.Balance+order.GiftCardAmount, card.Currency)
+			s.giftCards[card.Code] = card
+		}
+	}
+	// and gives back the loyalty points redeemed
+	if points := LoyaltyPointsRedeemed(*order); status == "cancelled" && order.Status != "cancelled" && points > 0 {
+		if u := slices.IndexFunc(s.users, func(user User) bool { return user.ID == order.UserID }); u >= 0 {
+			s.users[u].LoyaltyPoints += points
+			s.users[u].Version++
+		}
+	}
+	if (status == "cancelled") != (order.Status == "cancelled") {
+		s.analytics = nil
+	}
+	order.Status = status
+	// Orders that reserved nothing, like the demo orders, have no stock to
+	// settle
+	reserved := s.reservations[order.ID]
+	switch status {
+	case "shipped", "delivered":
+		CommitStock(s.products, s.reservations, order.ID)
+		s.touchProducts(reserved)
+	case "cancelled":
+		ReleaseStock(s.products, s.reservations, order.ID)
+		s.touchProducts(reserved)
+	}
+
+	order.Shipments = slices.Clone(order.Shipments)
+	for j := range order.Shipments {
+		shipment := &order.Shipments[j]
+		switch {
+		case status == "delivered":
+			shipment.Status = ShipmentDelivered
+		case status == "shipped" && shipment.Status != ShipmentDelivered:
+			shipment.Status = ShipmentShipped
+		}
+	}
+}
+
+var errSubscriptionNotFound = errors.New("subscription not found")
+
+// listSubscriptions lists subscriptions, only a user's when userID != 0.
+func (s *dataStore) listSubscriptions(userID int) []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subs := []Subscription{}
+	for _, sub := range s.subscriptions {
+		if userID == 0 || sub.UserID == userID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// addSubscription stores a subscription under the next free ID.
+func (s *dataStore) addSubscription(sub Subscription) Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.ID = 1
+	for _, existing := range s.subscriptions {
+		sub.ID = max(sub.ID, existing.ID+1)
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+	return sub
+}
+
+// getSubscription finds a subscription by ID.
+func (s *dataStore) getSubscription(id int) (Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.subscriptions {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return Subscription{}, errSubscriptionNotFound
+}
+
+// setSubscriptionStatus pauses, resumes or cancels a subscription.
+func (s *dataStore) setSubscriptionStatus(id int, status string) (Subscription, error) {
+	if !slices.Contains(subscriptionStatuses, status) {
+		return Subscription{}, fmt.Errorf("invalid status %q (expected one of %s)", status, strings.Join(subscriptionStatuses, ", "))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.subscriptions {
+		if s.subscriptions[i].ID == id {
+			if s.subscriptions[i].Status == SubscriptionCancelled && status != SubscriptionCancelled {
+				return Subscription{}, errors.New("a cancelled subscription cannot be resumed")
+			}
+			s.subscriptions[i].Status = status
+			return s.subscriptions[i], nil
+		}
+	}
+	return Subscription{}, errSubscriptionNotFound
+}
+
+// renewSubscription places a subscription's renewal order, reserving its
+// stock, and moves the subscription to its following renewal date. Both
+// happen under one lock so a renewal is never placed twice.
+func (s *dataStore) renewSubscription(id int, user User) (Order, Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.subscriptions, func(sub Subscription) bool { return sub.ID == id })
+	if i < 0 {
+		return Order{}, Subscription{}, errSubscriptionNotFound
+	}
+
+	// Renewals are charged the product's current price
+	if j := productIndex(s.products, s.subscriptions[i].Product.ID); j >= 0 {
+		s.subscriptions[i].Product = s.products[j]
+	}
+	order, err := GenerateRenewalOrder(s.subscriptions[i], user)
+	if err != nil {
+		return Order{}, Subscription{}, err
+	}
+	if order, err = s.placeOrderLocked(order); err != nil {
+		return Order{}, Subscription{}, err
+	}
+	s.subscriptions[i] = AdvanceRenewal(s.subscriptions[i])
+	return order, s.subscriptions[i], nil
+}
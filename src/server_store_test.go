@@ -0,0 +1,78 @@
+//go:build !wasm
+
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func newShipmentTestStore() *dataStore {
+	return &dataStore{
+		users: []User{{ID: 1, Country: "US"}},
+		orders: []Order{{ID: 1, UserID: 1, Status: "pending", Shipments: []Shipment{
+			{ID: 1, Warehouse: "east", Status: ShipmentPending},
+			{ID: 2, Warehouse: "west", Status: ShipmentPending},
+		}}},
+		reservations: StockReservations{},
+		giftCards:    map[string]GiftCard{},
+		wishlists:    map[int]Wishlist{},
+	}
+}
+
+// TestDataStoreCopiesOnRead tests that orders handed out don't change with
+// the store
+func TestDataStoreCopiesOnRead(t *testing.T) {
+	store := newShipmentTestStore()
+	before := store.listOrders()
+	before[0].Status = "delivered"
+	if order, _, _ := store.orderWithUser(1); order.Status != "pending" {
+		t.Fatalf("Expected changing a listed order to leave the store alone, got %q", order.Status)
+	}
+
+	if _, _, err := store.setShipmentStatus(1, 1, anyVersion, ShipmentShipped, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.setOrderStatus(1, anyVersion, "delivered", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, shipment := range before[0].Shipments {
+		if shipment.Status != ShipmentPending {
+			t.Errorf("Expected the shipments listed before to stay pending, got %+v", before[0].Shipments)
+		}
+	}
+	after := store.listOrders()
+	if after[0].Status != "delivered" || after[0].Shipments[0].Status != ShipmentDelivered || after[0].Shipments[1].Status != ShipmentDelivered {
+		t.Errorf("Expected the order and its shipments delivered, got %+v", after[0])
+	}
+}
+
+// TestDataStoreConcurrentAccess runs readers against writers; run with -race
+func TestDataStoreConcurrentAccess(t *testing.T) {
+	store := newShipmentTestStore()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, order := range store.listOrders() {
+					for _, shipment := range order.Shipments {
+						_ = shipment.Status
+					}
+				}
+				store.listUsers()
+				store.analyticsSnapshot(3)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			statuses := []string{ShipmentShipped, ShipmentPending}
+			for j := 0; j < 100; j++ {
+				store.setShipmentStatus(1, 1+j%2, anyVersion, statuses[j%2], true)
+				store.updateWishlist(1, func(wishlist *Wishlist) error { return nil })
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,274 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// SUBSCRIPTIONS
+// Recurring orders of one product (shared_subscriptions.go):
+//
+//   GET  /api/subscriptions[?user_id=]          list
+//   POST /api/subscriptions                     {"user_id": 1, "product_id": 4,
+//                                                "quantity": 2, "interval": "monthly"}
+//   PUT  /api/subscriptions/{id}/status         {"status": "paused"}
+//   GET  /api/subscriptions/{id}/upcoming?count= preview the next renewals
+//   POST /api/subscriptions/{id}/proration      {"product_id": 2, "quantity": 1}
+//                                                preview a mid-period change
+//   POST /api/subscriptions/{id}/renew          place the renewal order
+//
+// Renewals are priced for the subscriber with the product's current price
+// and placed like checkouts, reserving stock and notifying order.created
+// webhooks.
+// ============================================================================
+
+// subscriptionRequest is the body of POST /api/subscriptions.
+type subscriptionRequest struct {
+	UserID      int    `json:"user_id" validate:"required"`
+	ProductID   int    `json:"product_id" validate:"required"`
+	Quantity    int    `json:"quantity"`
+	Interval    string `json:"interval" validate:"required"`
+	NextRenewal string `json:"next_renewal,omitempty"` // default today
+}
+
+// subscriptionStatusRequest is the body of PUT /api/subscriptions/{id}/status.
+type subscriptionStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+// prorationRequest is the body of the proration preview; omitted fields
+// keep the subscription's product or quantity.
+type prorationRequest struct {
+	ProductID int    `json:"product_id,omitempty"`
+	Quantity  int    `json:"quantity,omitempty"`
+	Date      string `json:"date,omitempty"` // default today
+}
+
+// handleSubscriptions lists (GET) or creates (POST) subscriptions.
+func handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	store := storeFor(r)
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, store.listSubscriptions(queryInt(r.URL.Query(), "user_id", 0)))
+	case "POST":
+		var req subscriptionRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Quantity == 0 {
+			req.Quantity = 1
+		}
+		if req.NextRenewal == "" {
+			req.NextRenewal = time.Now().Format(taxDateLayout)
+		}
+
+		sub := Subscription{UserID: req.UserID, Quantity: req.Quantity, Interval: req.Interval, NextRenewal: req.NextRenewal, Status: SubscriptionActive}
+		fields := map[string]string{}
+		if _, ok := findUser(store, req.UserID); !ok {
+			fields["user_id"] = "unknown user"
+		}
+		product, ok := findProduct(store.listProducts(), req.ProductID)
+		switch {
+		case !ok:
+			fields["product_id"] = "unknown product"
+		case len(product.Variants) > 0:
+			fields["product_id"] = "products with variants can't be subscribed to"
+		default:
+			sub.Product = product
+		}
+		if len(fields) > 0 {
+			writeFieldErrors(w, fields)
+			return
+		}
+		if result := ValidateSubscription(sub); !result.Valid {
+			writeError(w, http.StatusBadRequest, strings.Join(result.Errors, "; "))
+			return
+		}
+
+		writeJSON(w, r, http.StatusCreated, store.addSubscription(sub))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// subscriptionFor resolves the {id} path value and the subscriber, writing
+// the error response when either is missing.
+func subscriptionFor(w http.ResponseWriter, r *http.Request) (Subscription, User, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid subscription ID")
+		return Subscription{}, User{}, false
+	}
+	store := storeFor(r)
+	sub, err := store.getSubscription(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Subscription not found")
+		return Subscription{}, User{}, false
+	}
+	user, ok := findUser(store, sub.UserID)
+	if !ok {
+		writeError(w, http.StatusConflict, "Subscriber no longer exists")
+		return Subscription{}, User{}, false
+	}
+	return sub, user, true
+}
+
+// handleSubscriptionStatus pauses, resumes or cancels a subscription.
+func handleSubscriptionStatus(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "PUT" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid subscription ID")
+		return
+	}
+	var req subscriptionStatusRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	sub, err := storeFor(r).setSubscriptionStatus(id, req.Status)
+	if errors.Is(err, errSubscriptionNotFound) {
+		writeError(w, http.StatusNotFound, "Subscription not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusOK, sub)
+}
+
+// handleSubscriptionUpcoming previews a subscription's next renewals.
+func handleSubscriptionUpcoming(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	count := queryInt(r.URL.Query(), "count", 3)
+	if count < 1 || count > maxUpcomingCharges {
+		writeFieldErrors(w, map[string]string{"count": "must be between 1 and " + strconv.Itoa(maxUpcomingCharges)})
+		return
+	}
+	sub, user, ok := subscriptionFor(w, r)
+	if !ok {
+		return
+	}
+	if product, ok := findProduct(storeFor(r).listProducts(), sub.Product.ID); ok {
+		sub.Product = product
+	}
+
+	charges, err := UpcomingCharges(sub, user, count)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, charges)
+}
+
+// handleSubscriptionProration previews the adjustment for changing a
+// subscription's product or quantity.
+func handleSubscriptionProration(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req prorationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	sub, _, ok := subscriptionFor(w, r)
+	if !ok {
+		return
+	}
+
+	change := sub
+	fields := map[string]string{}
+	if req.ProductID != 0 {
+		if product, ok := findProduct(storeFor(r).listProducts(), req.ProductID); ok {
+			change.Product = product
+		} else {
+			fields["product_id"] = "unknown product"
+		}
+	}
+	if req.Quantity != 0 {
+		change.Quantity = req.Quantity
+	}
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse(taxDateLayout, req.Date)
+		if err != nil {
+			fields["date"] = "must be a YYYY-MM-DD date"
+		}
+		date = parsed
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+	if result := ValidateSubscription(change); !result.Valid {
+		writeError(w, http.StatusBadRequest, strings.Join(result.Errors, "; "))
+		return
+	}
+
+	proration, err := ProrateChange(sub, change, date)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusOK, proration)
+}
+
+// handleSubscriptionRenew places a subscription's renewal order.
+func handleSubscriptionRenew(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sub, user, ok := subscriptionFor(w, r)
+	if !ok {
+		return
+	}
+	order, _, err := storeFor(r).renewSubscription(sub.ID, user)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	webhooks.publish(eventOrderCreated, sandboxID(r), newOrderEventData(storeFor(r), eventOrderCreated, order, ""))
+	dataChanges.publish(sandboxID(r), entityOrders, changeCreated, []int{order.ID})
+	dataChanges.publish(sandboxID(r), entityProducts, changeUpdated, orderProductIDs(order))
+	writeJSON(w, r, http.StatusCreated, order)
+}
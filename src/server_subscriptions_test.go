@@ -0,0 +1,67 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestSubscriptionEndpoints tests creating, previewing and renewing a
+// subscription
+func TestSubscriptionEndpoints(t *testing.T) {
+	withDemoStore(t)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	w := client.do("POST", "/api/subscriptions", `{"user_id": 2, "product_id": 4, "quantity": 3, "interval": "monthly", "next_renewal": "2026-11-01"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var sub Subscription
+	json.NewDecoder(w.Body).Decode(&sub)
+	if sub.ID != 1 || sub.Product.Name != "Coffee Mug" || sub.Status != SubscriptionActive {
+		t.Fatalf("Unexpected subscription: %+v", sub)
+	}
+	if w := client.do("POST", "/api/subscriptions", `{"user_id": 2, "product_id": 99, "interval": "monthly"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown product, got %d", w.Code)
+	}
+
+	var charges []UpcomingCharge
+	json.NewDecoder(client.do("GET", "/api/subscriptions/1/upcoming?count=2", "").Body).Decode(&charges)
+	if len(charges) != 2 || charges[0].Date != "2026-11-01" || charges[1].Date != "2026-12-01" || charges[0].Total <= 0 {
+		t.Errorf("Unexpected upcoming charges: %+v", charges)
+	}
+
+	w = client.do("POST", "/api/subscriptions/1/proration", `{"quantity": 6, "date": "2026-10-17"}`)
+	var proration Proration
+	json.NewDecoder(w.Body).Decode(&proration)
+	if w.Code != http.StatusOK || proration.Net <= 0 {
+		t.Errorf("Expected a charge for doubling the quantity, got %d %+v", w.Code, proration)
+	}
+
+	onHand := stockOf(demoStore, 4).Available
+	w = client.do("POST", "/api/subscriptions/1/renew", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var order Order
+	json.NewDecoder(w.Body).Decode(&order)
+	if order.OrderDate != "2026-11-01" || order.Total != charges[0].Total || stockOf(demoStore, 4).Available != onHand-3 {
+		t.Errorf("Expected the previewed renewal to be placed with its stock reserved, got %+v", order)
+	}
+
+	var subs []Subscription
+	json.NewDecoder(client.do("GET", "/api/subscriptions?user_id=2", "").Body).Decode(&subs)
+	if len(subs) != 1 || subs[0].NextRenewal != "2026-12-01" {
+		t.Errorf("Expected the subscription to move to its next renewal, got %+v", subs)
+	}
+
+	client.do("PUT", "/api/subscriptions/1/status", `{"status": "cancelled"}`)
+	if w := client.do("POST", "/api/subscriptions/1/renew", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 renewing a cancelled subscription, got %d", w.Code)
+	}
+	if w := client.do("PUT", "/api/subscriptions/1/status", `{"status": "active"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 resuming a cancelled subscription, got %d", w.Code)
+	}
+}
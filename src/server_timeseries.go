@@ -0,0 +1,69 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// REVENUE TIME SERIES
+// GET /api/analytics/timeseries series the stored orders with
+// AggregateRevenue:
+//
+//   ?granularity=week   day, week or month (the default)
+//   ?from=2023-05-01    only orders on or after this date
+//   ?to=2023-05-31      only orders on or before this date
+// ============================================================================
+
+// timeseriesResponse is the body of GET /api/analytics/timeseries.
+type timeseriesResponse struct {
+	Granularity string         `json:"granularity"`
+	From        string         `json:"from,omitempty"`
+	To          string         `json:"to,omitempty"`
+	Points      []RevenuePoint `json:"points"`
+}
+
+// handleRevenueTimeseries serves the revenue series of the stored orders.
+func handleRevenueTimeseries(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	response := timeseriesResponse{Granularity: GranularityMonth, From: query.Get("from"), To: query.Get("to")}
+	if raw := query.Get("granularity"); raw != "" {
+		response.Granularity = raw
+	}
+	fields := map[string]string{}
+	if response.Granularity != GranularityDay && response.Granularity != GranularityWeek && response.Granularity != GranularityMonth {
+		fields["granularity"] = "must be day, week or month"
+	}
+	for name, value := range map[string]string{"from": response.From, "to": response.To} {
+		if _, err := time.Parse(taxDateLayout, value); value != "" && err != nil {
+			fields[name] = "must be a date as YYYY-MM-DD"
+		}
+	}
+	if len(fields) == 0 && response.From != "" && response.To != "" && response.To < response.From {
+		fields["to"] = "must not be before from"
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	points, err := AggregateRevenue(ordersBetween(storeFor(r).listOrders(), response.From, response.To), response.Granularity)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.Points = points
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,43 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRevenueTimeseries tests serving a range of the demo orders
+func TestRevenueTimeseries(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/timeseries?granularity=day&from=2023-05-01&to=2023-05-03", nil))
+	var series timeseriesResponse
+	json.NewDecoder(w.Body).Decode(&series)
+	if w.Code != http.StatusOK || series.Granularity != GranularityDay || len(series.Points) == 0 || series.Points[0].Period != "2023-05-01" {
+		t.Fatalf("Expected a daily series from May 1st, got %d %+v", w.Code, series)
+	}
+	for _, point := range series.Points {
+		if point.Period > "2023-05-03" {
+			t.Errorf("Expected no periods after the range, got %+v", point)
+		}
+	}
+
+	for target, status := range map[string]int{
+		"/api/analytics/timeseries":                                http.StatusOK,
+		"/api/analytics/timeseries?granularity=year":               http.StatusBadRequest,
+		"/api/analytics/timeseries?from=May":                       http.StatusBadRequest,
+		"/api/analytics/timeseries?from=2023-06-01&to=2023-05-01":  http.StatusBadRequest,
+		"/api/analytics/timeseries?granularity=week&to=2023-05-01": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// TOP PRODUCTS
+// GET /api/analytics/top-products ranks the products in the stored orders
+// with TopProducts:
+//
+//   ?metric=revenue   revenue (the default), units or margin
+//   ?limit=10         products to return, 1 to 100 (10 by default)
+// ============================================================================
+
+// maxTopProducts is the most products ?limit= may ask for.
+const maxTopProducts = 100
+
+// topProductsResponse is the body of GET /api/analytics/top-products.
+type topProductsResponse struct {
+	Metric   string       `json:"metric"`
+	Products []TopProduct `json:"products"`
+}
+
+// handleTopProducts serves the best-selling products of the stored orders.
+func handleTopProducts(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	fields := map[string]string{}
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = TopByRevenue
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxTopProducts {
+			fields["limit"] = "must be a whole number from 1 to " + strconv.Itoa(maxTopProducts)
+		}
+		limit = value
+	}
+	products, err := TopProducts(storeFor(r).listOrders(), limit, metric)
+	if err != nil {
+		fields["metric"] = "must be revenue, units or margin"
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(w, fields)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, topProductsResponse{Metric: metric, Products: products})
+}
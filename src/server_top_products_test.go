@@ -0,0 +1,42 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTopProductsEndpoint tests ranking the demo orders and the parameters
+func TestTopProductsEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/top-products?metric=units&limit=2", nil))
+	var top topProductsResponse
+	json.NewDecoder(w.Body).Decode(&top)
+	if w.Code != http.StatusOK || top.Metric != TopByUnits || len(top.Products) != 2 {
+		t.Fatalf("Expected the two best sellers by units, got %d %+v", w.Code, top)
+	}
+	// Two t-shirts were sold in the first demo order
+	if top.Products[0].ProductID != 2 || top.Products[0].Units != 2 {
+		t.Errorf("Expected the t-shirt first, got %+v", top.Products[0])
+	}
+
+	for target, status := range map[string]int{
+		"/api/analytics/top-products":               http.StatusOK,
+		"/api/analytics/top-products?metric=margin": http.StatusOK,
+		"/api/analytics/top-products?metric=profit": http.StatusBadRequest,
+		"/api/analytics/top-products?limit=0":       http.StatusBadRequest,
+		"/api/analytics/top-products?limit=many":    http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: expected status %d, got %d", target, status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !wasm
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// TRENDING PRODUCTS
+// GET /api/analytics/trending ranks the stored orders' products with
+// TrendingProducts:
+//
+//   ?days=30   the window, ending at the newest order (the default)
+//
+// The products of the response are what setTrendingWasm installs in the
+// browser; trendingProductsWasm ranks orders passed from the page.
+// ============================================================================
+
+// trendingResponse is the body of GET /api/analytics/trending.
+type trendingResponse struct {
+	Days     int               `json:"days"`
+	Products []TrendingProduct `json:"products"`
+}
+
+// handleTrending serves the trending products of the stored orders.
+func handleTrending(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	days := int(DefaultTrendingWindow.Hours() / 24)
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > 365 {
+			writeFieldErrors(w, map[string]string{"days": "must be a whole number of days from 1 to 365"})
+			return
+		}
+		days = value
+	}
+
+	products := TrendingProducts(storeFor(r).listOrders(), time.Duration(days)*24*time.Hour)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, trendingResponse{Days: days, Products: products})
+}
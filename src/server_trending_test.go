@@ -0,0 +1,42 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTrendingEndpoint tests ranking the demo orders and the days parameter
+func TestTrendingEndpoint(t *testing.T) {
+	withDemoStore(t)
+	mux := newServerMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/trending", nil))
+	var response trendingResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	// The demo orders are two days apart, so all four products trend; the
+	// two t-shirts of the older order still outsell the newer order
+	if w.Code != http.StatusOK || response.Days != 30 || len(response.Products) != 4 || response.Products[0].ProductID != 2 {
+		t.Fatalf("Expected the demo products ranked, got %d %+v", w.Code, response)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/trending?days=1", nil))
+	response = trendingResponse{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Products) != 2 {
+		t.Errorf("Expected only the newest order within a day, got %+v", response.Products)
+	}
+
+	for _, days := range []string{"0", "x", "400"} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/analytics/trending?days="+days, nil))
+		if w.Code != http.StatusBadRequest || decodeErrorResponse(t, w).Fields["days"] == "" {
+			t.Errorf("Expected days=%s to be rejected, got %d", days, w.Code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !wasm
+
+package main
+
+import "sync/atomic"
+
+// ============================================================================
+// VALIDATION CACHE
+// Bulk imports and validation load tests validate users and products through
+// one ValidationCache of -validation-cache-size results (0 turns it off), so
+// a record repeated in an upload is validated once. Their reports give the
+// cache's hits and misses during the run.
+// ============================================================================
+
+var validationCache = NewValidationCache(serverConfig.ValidationCacheSize)
+
+// validationRun validates through a cache, counting the run's hits and
+// misses. A run without a cache validates every record.
+type validationRun struct {
+	cache        *ValidationCache
+	hits, misses atomic.Int64
+}
+
+func (run *validationRun) validateUser(user User) ValidationResult {
+	result, hit := run.cache.ValidateUser(user)
+	run.count(hit)
+	return result
+}
+
+func (run *validationRun) validateProduct(product Product) ValidationResult {
+	result, hit := run.cache.ValidateProduct(product)
+	run.count(hit)
+	return result
+}
+
+func (run *validationRun) count(hit bool) {
+	if hit {
+		run.hits.Add(1)
+	} else {
+		run.misses.Add(1)
+	}
+}
+
+// stats are the cache's stats with the run's lookups in place of all of
+// them, or nil without a cache.
+func (run *validationRun) stats() *ValidationCacheStats {
+	if run.cache == nil {
+		return nil
+	}
+	stats := run.cache.Stats()
+	stats.Hits, stats.Misses = run.hits.Load(), run.misses.Load()
+	stats.HitRate = hitRate(stats.Hits, stats.Misses)
+	return &stats
+}
@@ -0,0 +1,79 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ============================================================================
+// API VERSIONS
+// Every route in apiRoutes is mounted under each version prefix as well as
+// at its original /api/ path, which existing pages keep using:
+//
+//   /api/v1/...   responses as documented (same as /api/...)
+//   /api/v2/...   JSON responses always in the {data, meta} envelope
+//
+// A version is a name plus the middleware its routes are wrapped in, so a
+// future version can change behaviour for all routes in one place.
+// ============================================================================
+
+// middleware wraps a handler with cross-cutting behaviour.
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h so that the first middleware listed runs first.
+func chain(h http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// apiVersionSpec describes one mounted API version.
+type apiVersionSpec struct {
+	name     string
+	envelope bool // wrap every JSON response in the envelope
+}
+
+var apiVersions = []apiVersionSpec{
+	{name: "v1"},
+	{name: "v2", envelope: true},
+}
+
+type apiVersionKey struct{}
+
+// versionedPath maps a route path such as /api/demo-users to its path in a
+// version, /api/v1/demo-users.
+func versionedPath(version, path string) string {
+	return "/api/" + version + strings.TrimPrefix(path, "/api")
+}
+
+// versionMiddleware marks requests as made against a version.
+func versionMiddleware(version apiVersionSpec) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", version.name)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+		})
+	}
+}
+
+// requestAPIVersion reports the version a request was routed through; ok is
+// false for unversioned paths.
+func requestAPIVersion(r *http.Request) (version apiVersionSpec, ok bool) {
+	version, ok = r.Context().Value(apiVersionKey{}).(apiVersionSpec)
+	return version, ok
+}
+
+// registerAPIRoutes mounts the route table at its own paths and under every
+// version.
+func registerAPIRoutes(mux *http.ServeMux, routes []apiRoute) {
+	for _, route := range routes {
+		mux.Handle(route.Path, route.Handler)
+		for _, version := range apiVersions {
+			mux.Handle(versionedPath(version.name, route.Path), chain(route.Handler, versionMiddleware(version)))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestAPIVersions tests that routes are mounted per version
+func TestAPIVersions(t *testing.T) {
+	withDemoStore(t)
+	handler := newServerHandler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", target, w.Code)
+		}
+		return w
+	}
+
+	legacy, v1 := get("/api/demo-orders"), get("/api/v1/demo-orders")
+	if legacy.Body.String() != v1.Body.String() || v1.Header().Get("API-Version") != "v1" {
+		t.Errorf("Expected v1 to match the unversioned route, got %s", v1.Body.String())
+	}
+
+	var resp struct {
+		Data []Order      `json:"data"`
+		Meta responseMeta `json:"meta"`
+	}
+	v2 := get("/api/v2/demo-orders")
+	if err := json.NewDecoder(v2.Body).Decode(&resp); err != nil || len(resp.Data) == 0 || resp.Meta.RequestID == "" {
+		t.Errorf("Expected an enveloped v2 response, got %+v (%v)", resp, err)
+	}
+
+	// Path parameters, sandbox prefixes and request bodies work per version
+	get("/sandbox/versions/api/v2/export/users")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/api/v2/orders/1/status", strings.NewReader(`{"status": "unknown", "version": 1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestChain tests middleware ordering
+func TestChain(t *testing.T) {
+	var order []string
+	tag := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { order = append(order, "handler") }), tag("a"), tag("b"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Errorf("Unexpected order %v", order)
+	}
+}
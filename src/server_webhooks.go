@@ -0,0 +1,479 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// WEBHOOKS
+// Administrators register URLs that are notified of order lifecycle events:
+//
+//   order.created         a cart was checked out
+//   order.status_changed  PUT /api/orders/{id}/status moved an order along
+//
+// Each event is POSTed as JSON with headers identifying it and an HMAC-SHA256
+// signature over "<timestamp>.<body>" using the webhook's secret:
+//
+//   X-Webhook-Event:      order.created
+//   X-Webhook-Delivery:   <delivery id>
+//   X-Webhook-Timestamp:  <unix seconds>
+//   X-Webhook-Signature:  sha256=<hex hmac>
+//
+// Failed deliveries (network errors, 429 and 5xx responses) are retried with
+// exponential backoff up to -webhook-max-attempts times; every attempt is
+// kept in the webhook's delivery log. The /api/webhooks endpoints require
+// the -admin-token bearer token and are disabled without one.
+// ============================================================================
+
+var webhookEventTypes = []string{eventOrderCreated, eventOrderStatusChanged}
+
+// maxWebhookDeliveries bounds the delivery log kept per webhook.
+const maxWebhookDeliveries = 100
+
+// maxConcurrentDeliveries bounds the webhook requests in flight at once.
+const maxConcurrentDeliveries = 8
+
+var errWebhookNotFound = errors.New("webhook not found")
+
+// webhookRequest is the body of POST /api/webhooks. No events means all of
+// them.
+type webhookRequest struct {
+	URL    string   `json:"url" validate:"required"`
+	Events []string `json:"events,omitempty"`
+}
+
+// webhook is a registered endpoint. The secret is only returned when the
+// webhook is created.
+type webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	secret     string
+	deliveries []*webhookDelivery
+}
+
+// webhookEvent is the JSON body POSTed to webhooks.
+type webhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Sandbox   string      `json:"sandbox,omitempty"`
+	Data      interface{} `json:"data"`
+}
+
+// orderEventData is the data of order events. Notification is the message
+// for the user who placed the order, left out when they want none.
+type orderEventData struct {
+	Order          Order         `json:"order"`
+	PreviousStatus string        `json:"previous_status,omitempty"`
+	Notification   *Notification `json:"notification,omitempty"`
+}
+
+// newOrderEventData is the data of an order event, with the user's
+// notification formatted as previewNotificationWasm formats it.
+func newOrderEventData(store *dataStore, event string, order Order, previousStatus string) orderEventData {
+	data := orderEventData{Order: order, PreviousStatus: previousStatus}
+	user, found := findUser(store, order.UserID)
+	if !found {
+		return data
+	}
+	if notification, err := FormatOrderNotification(event, order, previousStatus, user); err == nil && notification.Deliverable() {
+		data.Notification = &notification
+	}
+	return data
+}
+
+// webhookDelivery is the log entry for one event sent to one webhook.
+type webhookDelivery struct {
+	ID       string           `json:"id"`
+	Event    string           `json:"event"`
+	EventID  string           `json:"event_id"`
+	Status   string           `json:"status"` // pending, delivered or failed
+	Attempts []webhookAttempt `json:"attempts"`
+}
+
+// webhookAttempt records one POST of a delivery.
+type webhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+}
+
+// webhookDispatcher stores webhooks and delivers events to them.
+type webhookDispatcher struct {
+	mu       sync.Mutex
+	hooks    map[string]*webhook
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+
+	slots    chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	inFlight sync.WaitGroup
+}
+
+// webhooks is the active dispatcher. main replaces it with one using the
+// configured timeout and attempts.
+var webhooks = newWebhookDispatcher(serverConfig.WebhookTimeout, serverConfig.WebhookMaxAttempts, time.Second)
+
+func newWebhookDispatcher(timeout time.Duration, attempts int, backoff time.Duration) *webhookDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookDispatcher{
+		hooks:    map[string]*webhook{},
+		client:   &http.Client{Timeout: timeout},
+		attempts: attempts,
+		backoff:  backoff,
+		slots:    make(chan struct{}, maxConcurrentDeliveries),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (d *webhookDispatcher) register(req webhookRequest) (webhook, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return webhook{}, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	events := req.Events
+	if len(events) == 0 {
+		events = webhookEventTypes
+	}
+	for _, event := range events {
+		if !slices.Contains(webhookEventTypes, event) {
+			return webhook{}, fmt.Errorf("unknown event %q (expected %s)", event, strings.Join(webhookEventTypes, ", "))
+		}
+	}
+
+	hook := &webhook{
+		ID:        newRandomID(),
+		URL:       target.String(),
+		Events:    slices.Clone(events),
+		CreatedAt: time.Now().UTC(),
+		secret:    newRandomID() + newRandomID(),
+	}
+	d.mu.Lock()
+	d.hooks[hook.ID] = hook
+	d.mu.Unlock()
+
+	created := hook.public()
+	created.Secret = hook.secret
+	return created, nil
+}
+
+// public returns the webhook without its secret or delivery log. Callers
+// must hold the dispatcher lock or own the webhook.
+func (hook *webhook) public() webhook {
+	return webhook{ID: hook.ID, URL: hook.URL, Events: hook.Events, CreatedAt: hook.CreatedAt}
+}
+
+func (d *webhookDispatcher) list() []webhook {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hooks := make([]webhook, 0, len(d.hooks))
+	for _, hook := range d.hooks {
+		hooks = append(hooks, hook.public())
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks
+}
+
+func (d *webhookDispatcher) get(id string) (webhook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hook, ok := d.hooks[id]
+	if !ok {
+		return webhook{}, false
+	}
+	return hook.public(), true
+}
+
+func (d *webhookDispatcher) remove(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.hooks[id]
+	delete(d.hooks, id)
+	return ok
+}
+
+// deliveries returns a webhook's delivery log, newest first.
+func (d *webhookDispatcher) deliveries(id string) ([]webhookDelivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hook, ok := d.hooks[id]
+	if !ok {
+		return nil, errWebhookNotFound
+	}
+	log := make([]webhookDelivery, len(hook.deliveries))
+	for i, delivery := range hook.deliveries {
+		entry := *delivery
+		entry.Attempts = slices.Clone(delivery.Attempts)
+		log[len(log)-1-i] = entry
+	}
+	return log, nil
+}
+
+// publish sends an event to every webhook subscribed to it. Delivery happens
+// in the background.
+func (d *webhookDispatcher) publish(eventType, sandboxID string, data interface{}) {
+	event := webhookEvent{
+		ID:        newRandomID(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Sandbox:   sandboxID,
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ctx.Err() != nil {
+		return
+	}
+	for _, hook := range d.hooks {
+		if !slices.Contains(hook.Events, eventType) {
+			continue
+		}
+		delivery := &webhookDelivery{ID: newRandomID(), Event: eventType, EventID: event.ID, Status: "pending", Attempts: []webhookAttempt{}}
+		hook.deliveries = append(hook.deliveries, delivery)
+		if len(hook.deliveries) > maxWebhookDeliveries {
+			hook.deliveries = hook.deliveries[len(hook.deliveries)-maxWebhookDeliveries:]
+		}
+		d.inFlight.Add(1)
+		go d.deliver(hook.URL, hook.secret, delivery, body)
+	}
+}
+
+// deliver POSTs the event until it succeeds, fails permanently or runs out of
+// attempts, waiting backoff, 2*backoff, 4*backoff, ... between attempts.
+func (d *webhookDispatcher) deliver(target, secret string, delivery *webhookDelivery, body []byte) {
+	defer d.inFlight.Done()
+
+	delay := d.backoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case d.slots <- struct{}{}:
+		case <-d.ctx.Done():
+			d.finish(delivery, "failed")
+			return
+		}
+		result, retry := d.attempt(target, secret, delivery.ID, delivery.Event, body)
+		<-d.slots
+
+		d.mu.Lock()
+		delivery.Attempts = append(delivery.Attempts, result)
+		d.mu.Unlock()
+
+		if result.Error == "" {
+			d.finish(delivery, "delivered")
+			return
+		}
+		if !retry || attempt >= d.attempts {
+			d.finish(delivery, "failed")
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-d.ctx.Done():
+			d.finish(delivery, "failed")
+			return
+		}
+	}
+}
+
+func (d *webhookDispatcher) finish(delivery *webhookDelivery, status string) {
+	d.mu.Lock()
+	delivery.Status = status
+	d.mu.Unlock()
+}
+
+// attempt makes one signed POST and reports whether a failure is worth
+// retrying.
+func (d *webhookDispatcher) attempt(target, secret, deliveryID, event string, body []byte) (webhookAttempt, bool) {
+	start := time.Now()
+	result := webhookAttempt{At: start.UTC()}
+
+	req, err := http.NewRequestWithContext(d.ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result, false
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-wasm-demo-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Error = err.Error()
+		return result, true
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return result, false
+	}
+	result.Error = resp.Status
+	return result, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// shutdown abandons pending retries and waits for requests in flight.
+func (d *webhookDispatcher) shutdown(ctx context.Context) error {
+	// publish checks the context under the lock, so no delivery starts after this
+	d.mu.Lock()
+	d.cancel()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requireAdmin rejects requests without the admin token. It reports whether
+// the request may proceed.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if serverConfig.AdminToken == "" {
+		writeError(w, http.StatusForbidden, "Administration is disabled (set -admin-token)")
+		return false
+	}
+	if !hasBearerToken(r, serverConfig.AdminToken) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	return true
+}
+
+// isAdmin reports whether a request carries the admin token, for endpoints
+// that let administrators do more rather than requiring them.
+func isAdmin(r *http.Request) bool {
+	return serverConfig.AdminToken != "" && hasBearerToken(r, serverConfig.AdminToken)
+}
+
+// handleWebhooks registers (POST) or lists (GET) webhooks.
+func handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, r, http.StatusOK, webhooks.list())
+	case "POST":
+		var req webhookRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		hook, err := webhooks.register(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.Header().Set("Location", "/api/webhooks/"+hook.ID)
+		writeJSON(w, r, http.StatusCreated, hook)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleWebhook returns (GET) or unregisters (DELETE) a webhook.
+func handleWebhook(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id := r.PathValue("id")
+	switch r.Method {
+	case "GET":
+		hook, ok := webhooks.get(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, hook)
+	case "DELETE":
+		if !webhooks.remove(id) {
+			writeError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleWebhookDeliveries returns a webhook's delivery log.
+func handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	log, err := webhooks.deliveries(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, log)
+}
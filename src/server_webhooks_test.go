@@ -0,0 +1,162 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withWebhooks replaces the dispatcher with one that retries quickly and
+// enables the admin endpoints with the token "admin".
+func withWebhooks(t *testing.T, attempts int) {
+	t.Helper()
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "admin" })
+	previous := webhooks
+	webhooks = newWebhookDispatcher(time.Second, attempts, time.Millisecond)
+	t.Cleanup(func() {
+		webhooks.shutdown(context.Background())
+		webhooks = previous
+	})
+}
+
+func adminRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin")
+	return req
+}
+
+// waitForDelivery polls the delivery log until the first delivery finishes.
+func waitForDelivery(t *testing.T, hookID string) webhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		log, err := webhooks.deliveries(hookID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(log) > 0 && log[0].Status != "pending" {
+			return log[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("Delivery did not finish")
+	return webhookDelivery{}
+}
+
+// TestWebhookDelivery tests signed delivery of order events with retries
+func TestWebhookDelivery(t *testing.T) {
+	withDemoStore(t)
+	withWebhooks(t, 3)
+
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		// Fail the first attempt so the retry path is exercised
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	w := httptest.NewRecorder()
+	handleWebhooks(w, adminRequest("POST", "/api/webhooks", `{"url": "`+receiver.URL+`", "events": ["order.status_changed"]}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var hook webhook
+	json.NewDecoder(w.Body).Decode(&hook)
+	if hook.Secret == "" {
+		t.Fatal("Expected the signing secret in the registration response")
+	}
+
+	w = httptest.NewRecorder()
+	newServerMux().ServeHTTP(w, httptest.NewRequest("PUT", "/api/orders/2/status", strings.NewReader(`{"status": "delivered", "version": 1}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	delivery := waitForDelivery(t, hook.ID)
+	if delivery.Status != "delivered" || len(delivery.Attempts) != 2 || delivery.Attempts[0].StatusCode != 503 {
+		t.Fatalf("Expected one retry then success, got %+v", delivery)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	req, body := requests[1], bodies[1]
+	expected := "sha256=" + signWebhook(hook.Secret, req.Header.Get("X-Webhook-Timestamp"), body)
+	if req.Header.Get("X-Webhook-Signature") != expected || req.Header.Get("X-Webhook-Event") != eventOrderStatusChanged {
+		t.Errorf("Unexpected webhook headers: %v", req.Header)
+	}
+
+	var event struct {
+		Type string         `json:"type"`
+		Data orderEventData `json:"data"`
+	}
+	json.Unmarshal(body, &event)
+	if event.Data.Order.ID != 2 || event.Data.Order.Status != "delivered" || event.Data.PreviousStatus != "shipped" {
+		t.Errorf("Unexpected event payload: %s", body)
+	}
+	if notification := event.Data.Notification; notification == nil || notification.UserID != event.Data.Order.UserID || !strings.Contains(notification.Subject, "ORD-000002") {
+		t.Errorf("Expected the customer's notification in the payload: %s", body)
+	}
+}
+
+// TestWebhookPermanentFailure tests that 4xx responses are not retried
+func TestWebhookPermanentFailure(t *testing.T) {
+	withWebhooks(t, 5)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer receiver.Close()
+
+	hook, err := webhooks.register(webhookRequest{URL: receiver.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks.publish(eventOrderCreated, "", orderEventData{})
+
+	delivery := waitForDelivery(t, hook.ID)
+	if delivery.Status != "failed" || len(delivery.Attempts) != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v", delivery)
+	}
+}
+
+// TestWebhookAdmin tests authentication and registration validation
+func TestWebhookAdmin(t *testing.T) {
+	withWebhooks(t, 1)
+
+	w := httptest.NewRecorder()
+	handleWebhooks(w, httptest.NewRequest("GET", "/api/webhooks", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the token, got %d", w.Code)
+	}
+
+	for _, body := range []string{`{"url": "ftp://example.com"}`, `{"url": "http://example.com", "events": ["order.deleted"]}`} {
+		w = httptest.NewRecorder()
+		handleWebhooks(w, adminRequest("POST", "/api/webhooks", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	withServerConfig(t, func(cfg *ServerConfig) { cfg.AdminToken = "" })
+	w = httptest.NewRecorder()
+	handleWebhooks(w, adminRequest("GET", "/api/webhooks", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without an admin token configured, got %d", w.Code)
+	}
+}
@@ -0,0 +1,192 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// WISHLISTS
+// Products a user saved for later (shared_wishlist.go). Cart recommendations
+// for a user favour the categories on their wishlist:
+//
+//   GET    /api/wishlists/{user_id}                                 the wishlist
+//   POST   /api/wishlists/{user_id}/items                           {"product_id": 3}
+//   DELETE /api/wishlists/{user_id}/items/{product_id}
+//   POST   /api/wishlists/{user_id}/items/{product_id}/move-to-cart {"quantity": 2,
+//                                                                    "sku": "..."}
+//
+// Moving to the cart adds to the session's cart (see SHOPPING CART) and
+// responds with the cart priced for the user.
+// ============================================================================
+
+// wishlistItemRequest is the body of POST /api/wishlists/{user_id}/items.
+type wishlistItemRequest struct {
+	ProductID int `json:"product_id" validate:"required"`
+}
+
+// moveToCartRequest is the optional body of move-to-cart. SKU picks the
+// variant of products that have them.
+type moveToCartRequest struct {
+	SKU      string `json:"sku,omitempty"`
+	Quantity int    `json:"quantity,omitempty"` // default 1
+}
+
+// wishlistUser resolves the {user_id} path value, writing the error response
+// when it isn't a known user.
+func wishlistUser(w http.ResponseWriter, r *http.Request) (User, bool) {
+	id, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return User{}, false
+	}
+	user, ok := findUser(storeFor(r), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "User not found")
+		return User{}, false
+	}
+	return user, true
+}
+
+// wishlistProductID parses the {product_id} path value.
+func wishlistProductID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	productID, err := strconv.Atoi(r.PathValue("product_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, false
+	}
+	return productID, true
+}
+
+// handleWishlist returns a user's wishlist.
+func handleWishlist(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := wishlistUser(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, storeFor(r).wishlist(user.ID))
+}
+
+// handleWishlistItems saves a product to a user's wishlist. Saving a product
+// that is already there leaves the wishlist unchanged.
+func handleWishlistItems(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := wishlistUser(w, r)
+	if !ok {
+		return
+	}
+	var req wishlistItemRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	store := storeFor(r)
+	if _, ok := findProduct(store.listProducts(), req.ProductID); !ok {
+		writeFieldErrors(w, map[string]string{"product_id": "unknown product"})
+		return
+	}
+
+	wishlist, _ := store.updateWishlist(user.ID, func(wishlist *Wishlist) error {
+		wishlist.AddItem(req.ProductID, time.Now())
+		return nil
+	})
+	writeJSON(w, r, http.StatusOK, wishlist)
+}
+
+// handleWishlistItem removes a product from a user's wishlist.
+func handleWishlistItem(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "DELETE" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := wishlistUser(w, r)
+	if !ok {
+		return
+	}
+	productID, ok := wishlistProductID(w, r)
+	if !ok {
+		return
+	}
+
+	wishlist, err := storeFor(r).updateWishlist(user.ID, func(wishlist *Wishlist) error {
+		if !wishlist.RemoveItem(productID) {
+			return ErrNotWishlisted
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Product %d is not on the wishlist", productID))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, wishlist)
+}
+
+// handleWishlistMoveToCart moves a wishlisted product into the session's
+// cart and responds with the cart summary.
+func handleWishlistMoveToCart(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := wishlistUser(w, r)
+	if !ok {
+		return
+	}
+	productID, ok := wishlistProductID(w, r)
+	if !ok {
+		return
+	}
+	var req moveToCartRequest
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	store := storeFor(r)
+	serveCartFor(w, r, store, user, func(cart *Cart, catalog []Product) (int, string) {
+		_, err := store.updateWishlist(user.ID, func(wishlist *Wishlist) error {
+			return MoveToCart(wishlist, cart, catalog, productID, req.SKU, req.Quantity, time.Now())
+		})
+		switch {
+		case errors.Is(err, ErrNotWishlisted):
+			return http.StatusNotFound, fmt.Sprintf("Product %d is not on the wishlist", productID)
+		case err != nil:
+			return http.StatusBadRequest, err.Error()
+		}
+		return http.StatusOK, ""
+	})
+}
@@ -0,0 +1,51 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestWishlistEndpoints tests saving products and moving them to the cart
+func TestWishlistEndpoints(t *testing.T) {
+	withDemoStore(t)
+	withSessions(t, 10)
+	client := &cartClient{t: t, handler: newServerMux()}
+
+	client.do("POST", "/api/wishlists/2/items", `{"product_id": 5}`)
+	w := client.do("POST", "/api/wishlists/2/items", `{"product_id": 3}`)
+	var wishlist Wishlist
+	json.NewDecoder(w.Body).Decode(&wishlist)
+	if w.Code != http.StatusOK || wishlist.UserID != 2 || len(wishlist.Items) != 2 {
+		t.Fatalf("Expected two saved products, got %d %+v", w.Code, wishlist)
+	}
+	if w := client.do("POST", "/api/wishlists/2/items", `{"product_id": 99}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown product, got %d", w.Code)
+	}
+	if w := client.do("GET", "/api/wishlists/99", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown user, got %d", w.Code)
+	}
+
+	summary := client.summary(client.do("POST", "/api/wishlists/2/items/3/move-to-cart", `{"quantity": 2}`))
+	if len(summary.Items) != 1 || summary.Items[0].ProductID != 3 || summary.Items[0].Quantity != 2 {
+		t.Fatalf("Expected the book in the cart, got %+v", summary.Items)
+	}
+	user, _ := findUser(demoStore, 2)
+	expected := RecommendWithWishlist(user, demoStore.listProducts(), summary.Order, demoStore.wishlist(2))
+	if len(summary.Recommendations) != len(expected) || summary.Recommendations[0].ID != expected[0].ID {
+		t.Errorf("Expected recommendations boosted by the wishlist, got %+v", summary.Recommendations)
+	}
+	if w := client.do("POST", "/api/wishlists/2/items/3/move-to-cart", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 moving a product no longer wishlisted, got %d", w.Code)
+	}
+
+	json.NewDecoder(client.do("DELETE", "/api/wishlists/2/items/5", "").Body).Decode(&wishlist)
+	if len(wishlist.Items) != 0 {
+		t.Errorf("Expected an empty wishlist, got %+v", wishlist.Items)
+	}
+	if w := client.do("DELETE", "/api/wishlists/2/items/5", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 removing a product not on the wishlist, got %d", w.Code)
+	}
+}
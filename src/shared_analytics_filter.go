@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Shared analytics filters - an AnalyticsFilter narrows the users and orders
+// AnalyzeUserBehavior looks at: orders placed in a date range, users in one
+// country, only premium members, only those who opted in to marketing or
+// those with a favourite category (with just their orders), and orders with
+// a product in one category. Orders keep their whole total when only some
+// of their products are in the category. The zero filter keeps everything.
+
+// AnalyticsFilter selects the data analytics are computed over.
+type AnalyticsFilter struct {
+	From        string `json:"from,omitempty"`    // YYYY-MM-DD, inclusive
+	To          string `json:"to,omitempty"`      // YYYY-MM-DD, inclusive
+	Country     string `json:"country,omitempty"` // the users' country, any case
+	PremiumOnly bool   `json:"premium_only,omitempty"`
+	Category    string `json:"category,omitempty"` // any case
+	// MarketingOptIn keeps the users whose preferences let them be sent
+	// marketing, and FavoriteCategory those who picked the category as a
+	// favourite, in any case
+	MarketingOptIn   bool   `json:"marketing_opt_in,omitempty"`
+	FavoriteCategory string `json:"favorite_category,omitempty"`
+	// TopCountries is how many countries to rank, DefaultTopCountries when
+	// 0; it selects nothing
+	TopCountries int `json:"top_countries,omitempty"`
+}
+
+// ValidateAnalyticsFilter checks the dates, that the range isn't empty and
+// the number of top countries.
+func ValidateAnalyticsFilter(filter AnalyticsFilter) ValidationResult {
+	result := newValidationResult()
+	for _, date := range []struct{ field, value string }{{"from", filter.From}, {"to", filter.To}} {
+		if _, err := time.Parse(taxDateLayout, date.value); date.value != "" && err != nil {
+			result.AddError(date.field, CodeInvalidFormat, "Date must be YYYY-MM-DD")
+		}
+	}
+	if result.Valid && filter.From != "" && filter.To != "" && filter.To < filter.From {
+		result.AddError("to", CodeOutOfRange, "End date must not be before the start date")
+	}
+	if filter.TopCountries < 0 || filter.TopCountries > maxTopCountries {
+		result.AddError("top_countries", CodeOutOfRange, fmt.Sprintf("Top countries must be from 1 to %d", maxTopCountries))
+	}
+	return result
+}
+
+// segments reports whether the filter narrows the users.
+func (filter AnalyticsFilter) segments() bool {
+	return filter.Country != "" || filter.PremiumOnly || filter.MarketingOptIn || filter.FavoriteCategory != ""
+}
+
+// keepsUser reports whether the filter selects user.
+func (filter AnalyticsFilter) keepsUser(user User) bool {
+	prefs := UserPreferences(user)
+	switch {
+	case filter.Country != "" && !strings.EqualFold(user.Country, filter.Country):
+		return false
+	case filter.PremiumOnly && !user.Premium:
+		return false
+	case filter.MarketingOptIn && !prefs.MarketingOptIn:
+		return false
+	case filter.FavoriteCategory != "" && !prefs.favors(filter.FavoriteCategory):
+		return false
+	}
+	return true
+}
+
+// Apply keeps the users and orders the filter selects. With a filter on
+// users only the kept users' orders are kept; with a date range,
+// undated orders are left out.
+func (filter AnalyticsFilter) Apply(users []User, orders []Order) ([]User, []Order) {
+	if filter == (AnalyticsFilter{TopCountries: filter.TopCountries}) {
+		return users, orders
+	}
+	keptUsers := []User{}
+	ids := map[int]bool{}
+	for _, user := range users {
+		if !filter.keepsUser(user) {
+			continue
+		}
+		keptUsers = append(keptUsers, user)
+		ids[user.ID] = true
+	}
+
+	keptOrders := []Order{}
+	for _, order := range orders {
+		if filter.segments() && !ids[order.UserID] {
+			continue
+		}
+		if (filter.From != "" || filter.To != "") && order.OrderDate == "" {
+			continue
+		}
+		if filter.From != "" && order.OrderDate < filter.From || filter.To != "" && order.OrderDate > filter.To {
+			continue
+		}
+		if filter.Category != "" && !orderHasCategory(order, filter.Category) {
+			continue
+		}
+		keptOrders = append(keptOrders, order)
+	}
+	return keptUsers, keptOrders
+}
+
+// orderHasCategory reports whether any of an order's products is in
+// category, in any case.
+func orderHasCategory(order Order, category string) bool {
+	for _, product := range order.Products {
+		if strings.EqualFold(product.Category, category) {
+			return true
+		}
+	}
+	return false
+}
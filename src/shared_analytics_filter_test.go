@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestAnalyticsFilter tests each filter and that the zero filter keeps all
+func TestAnalyticsFilter(t *testing.T) {
+	users := []User{
+		{ID: 1, Country: "US", Premium: true},
+		{ID: 2, Country: "CA"},
+		{ID: 3, Country: "us"},
+	}
+	orders := []Order{
+		{ID: 1, UserID: 1, OrderDate: "2024-01-05", Products: []Product{{Category: "books"}, {Category: "home"}}},
+		{ID: 2, UserID: 2, OrderDate: "2024-02-05", Products: []Product{{Category: "Books"}}},
+		{ID: 3, UserID: 3, OrderDate: "2024-03-05", Products: []Product{{Category: "sports"}}},
+		{ID: 4, UserID: 3, Products: []Product{{Category: "books"}}},
+	}
+
+	ids := func(orders []Order) []int {
+		list := []int{}
+		for _, order := range orders {
+			list = append(list, order.ID)
+		}
+		return list
+	}
+	for name, tc := range map[string]struct {
+		filter AnalyticsFilter
+		users  int
+		orders []int
+	}{
+		"None":     {AnalyticsFilter{}, 3, []int{1, 2, 3, 4}},
+		"Dates":    {AnalyticsFilter{From: "2024-02-01", To: "2024-03-05"}, 3, []int{2, 3}},
+		"Country":  {AnalyticsFilter{Country: "US"}, 2, []int{1, 3, 4}},
+		"Premium":  {AnalyticsFilter{PremiumOnly: true}, 1, []int{1}},
+		"Category": {AnalyticsFilter{Category: "books"}, 3, []int{1, 2, 4}},
+		"Combined": {AnalyticsFilter{Country: "us", Category: "BOOKS", To: "2024-12-31"}, 2, []int{1}},
+	} {
+		keptUsers, keptOrders := tc.filter.Apply(users, orders)
+		if len(keptUsers) != tc.users || fmt.Sprint(ids(keptOrders)) != fmt.Sprint(tc.orders) {
+			t.Errorf("%s: kept %d users and orders %v, want %d and %v", name, len(keptUsers), ids(keptOrders), tc.users, tc.orders)
+		}
+	}
+
+	if analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{PremiumOnly: true}); analytics.PremiumPercentage != 100 || analytics.OrderValues.Count != 1 {
+		t.Errorf("Expected analytics of the premium member only, got %+v", analytics)
+	}
+}
+
+// TestValidateAnalyticsFilter tests the date checks
+func TestValidateAnalyticsFilter(t *testing.T) {
+	if result := ValidateAnalyticsFilter(AnalyticsFilter{From: "2024-01-01", To: "2024-01-01"}); !result.Valid {
+		t.Errorf("Expected a one-day range to be valid, got %v", result.Errors)
+	}
+	if result := ValidateAnalyticsFilter(AnalyticsFilter{From: "January"}); result.Valid || result.FieldErrors[0].Field != "from" {
+		t.Errorf("Expected a bad start date rejected, got %+v", result.FieldErrors)
+	}
+	if result := ValidateAnalyticsFilter(AnalyticsFilter{From: "2024-02-01", To: "2024-01-01"}); result.Valid || result.FieldErrors[0].Field != "to" {
+		t.Errorf("Expected an empty range rejected, got %+v", result.FieldErrors)
+	}
+}
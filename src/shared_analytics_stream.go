@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Shared incremental analytics - an AnalyticsAccumulator takes users and
+// orders one at a time as they arrive and reports the same UserAnalytics as
+// AnalyzeUserBehavior over everything added so far. Adding is cheap: each
+// user or order updates running totals, its customer's history and the
+// anomaly groups it joins. A snapshot then only goes over the customers, for
+// lifetime value and churn, and re-checks the anomaly groups that changed,
+// instead of every order. AnalyzeUserBehavior is an accumulator fed a whole
+// dataset at once. The server keeps one per store for
+// /api/analytics/summary; the page keeps one behind addAnalyticsOrderWasm.
+//
+// Add a user before their orders: an order's destination and premium share
+// go by the user as known when it is added. An accumulator is not safe for
+// concurrent use.
+
+// AnalyticsAccumulator is analytics kept up to date one user or order at a
+// time. TopCountries is how many countries a snapshot ranks,
+// DefaultTopCountries when 0.
+type AnalyticsAccumulator struct {
+	TopCountries int
+
+	users []User
+	byID  map[int]User
+
+	ageSum, premium          int
+	ages                     *histogram
+	completeness, incomplete int
+
+	orders    int
+	revenue   float64
+	values    orderValueSummary
+	valueBins *histogram
+	baskets   *basketSummary
+	geography *geoBreakdown
+	histories map[int]*customerHistory
+	newest    time.Time // of all dated orders that weren't cancelled
+	anomalies *anomalyDetector
+}
+
+// NewAnalyticsAccumulator returns an accumulator with no users or orders.
+func NewAnalyticsAccumulator() *AnalyticsAccumulator {
+	return &AnalyticsAccumulator{
+		byID:      map[int]User{},
+		ages:      newHistogram(AgeBuckets),
+		valueBins: newHistogram(OrderValueBuckets),
+		baskets:   newBasketSummary(),
+		geography: newGeoBreakdown(),
+		histories: map[int]*customerHistory{},
+		anomalies: newAnomalyDetector(),
+	}
+}
+
+// AddUser counts a user. Each call counts one more user, so add a user once.
+func (acc *AnalyticsAccumulator) AddUser(user User) {
+	acc.users = append(acc.users, user)
+	acc.byID[user.ID] = user
+	acc.ageSum += user.Age
+	acc.ages.add(float64(user.Age))
+	if user.Premium {
+		acc.premium++
+	}
+	score := ProfileCompleteness(user)
+	acc.completeness += score.Percent
+	if !score.Complete() {
+		acc.incomplete++
+	}
+	acc.geography.addUser(user)
+}
+
+// AddOrder counts an order.
+func (acc *AnalyticsAccumulator) AddOrder(order Order) {
+	acc.orders++
+	acc.revenue += order.Total
+	acc.values.add(riskAmount(order))
+	acc.valueBins.add(riskAmount(order))
+	acc.baskets.add(order)
+	acc.geography.addOrder(order, acc.byID[order.UserID])
+	acc.anomalies.add(order)
+
+	date, err := time.Parse(taxDateLayout, order.OrderDate)
+	if err != nil || order.Status == "cancelled" {
+		return
+	}
+	if date.After(acc.newest) {
+		acc.newest = date
+	}
+	if acc.histories[order.UserID] == nil {
+		acc.histories[order.UserID] = &customerHistory{}
+	}
+	acc.histories[order.UserID].add(order, date)
+}
+
+// Snapshot reports the analytics of everything added so far; with no users
+// they are all zero.
+func (acc *AnalyticsAccumulator) Snapshot() UserAnalytics {
+	analytics := UserAnalytics{}
+	if len(acc.users) == 0 {
+		return analytics
+	}
+	users := float64(len(acc.users))
+
+	// Demographics and profile completeness
+	analytics.AverageAge = float64(acc.ageSum) / users
+	analytics.AgeHistogram = acc.ages.buckets()
+	analytics.PremiumPercentage = float64(acc.premium) / users * 100
+	analytics.AverageProfileCompleteness = math.Round(float64(acc.completeness)/users*10) / 10
+	analytics.IncompleteProfiles = acc.incomplete
+
+	// Revenue by where orders ship; the top countries are those with the
+	// most revenue
+	analytics.CountryRevenue, analytics.RegionRevenue = acc.geography.lists()
+	n := acc.TopCountries
+	if n <= 0 {
+		n = DefaultTopCountries
+	}
+	analytics.TopCountries = topCountries(analytics.CountryRevenue, len(acc.users), n)
+
+	if acc.orders > 0 {
+		analytics.TotalRevenue = acc.revenue
+	}
+	analytics.OrderValues = acc.values.stats()
+	analytics.OrderValueHistogram = acc.valueBins.buckets()
+	analytics.Baskets = acc.baskets.stats()
+
+	// Lifetime value of every user over the default horizon
+	for _, user := range acc.users {
+		history := acc.histories[user.ID]
+		if history == nil {
+			history = &customerHistory{}
+		}
+		analytics.TotalCLV += history.clv(user, acc.newest, DefaultCLVHorizonMonths).CLV
+	}
+	analytics.TotalCLV = RoundToCurrency(analytics.TotalCLV, DefaultCurrency)
+	analytics.AverageCLV = RoundToCurrency(analytics.TotalCLV/users, DefaultCurrency)
+
+	// Churn risk of the customers who have ordered
+	if risks := scoreChurn(acc.byID, acc.histories, nil); len(risks) > 0 {
+		probability := 0.0
+		for _, risk := range risks {
+			probability += risk.Probability
+			if risk.Risk == ChurnHigh {
+				analytics.HighChurnRisk++
+			}
+		}
+		analytics.AverageChurnProbability = roundRatio(probability / float64(len(risks)))
+	}
+
+	analytics.Anomalies = acc.anomalies.results()
+	return analytics
+}
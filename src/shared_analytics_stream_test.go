@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAnalyticsAccumulator tests that every snapshot matches analyzing the
+// data so far in one go
+func TestAnalyticsAccumulator(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Ann Lee", Age: 30, Country: "US", Region: "CA", Premium: true, JoinDate: "2023-01-01"},
+		{ID: 2, Name: "Bo Chen", Age: 40, Country: "CA", JoinDate: "2023-02-01"},
+	}
+	orders := []Order{
+		{ID: 1, UserID: 1, OrderDate: "2023-03-01", Total: 40, Status: "delivered", Discounts: []DiscountLine{{Source: DiscountPremium, Amount: 4}}},
+		{ID: 2, UserID: 2, OrderDate: "2023-03-05", Total: 60, Status: "delivered"},
+		{ID: 3, UserID: 1, OrderDate: "2023-03-20", Total: 45, Status: "shipped"},
+		{ID: 4, UserID: 1, OrderDate: "2023-04-02", Total: 50, Status: "pending", ShippingAddress: &Address{Country: "CA", Region: "ON"}},
+		{ID: 5, UserID: 2, OrderDate: "2023-04-03", Total: 500, Status: "cancelled"},
+		{ID: 6, UserID: 1, OrderDate: "2023-06-01", Total: 600, Status: "delivered"},
+		{ID: 7, UserID: 2, OrderDate: "2023-06-02", Total: 20, Status: "delivered"},
+	}
+
+	acc := NewAnalyticsAccumulator()
+	if got := acc.Snapshot(); !reflect.DeepEqual(got, UserAnalytics{}) {
+		t.Errorf("Expected an empty snapshot, got %+v", got)
+	}
+	for _, user := range users {
+		acc.AddUser(user)
+	}
+	for i, order := range orders {
+		acc.AddOrder(order)
+		want := AnalyzeUserBehavior(users, orders[:i+1], AnalyticsFilter{})
+		if got := acc.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("After order %d: snapshot %+v, want %+v", order.ID, got, want)
+		}
+	}
+
+	// The final snapshot has something to show for each metric
+	final := acc.Snapshot()
+	if final.OrderValues.Count != 7 || final.TotalCLV == 0 || final.AverageChurnProbability == 0 || len(final.Anomalies) != 1 || len(final.RegionRevenue) != 2 {
+		t.Errorf("Unexpected final analytics %+v", final)
+	}
+	countries, _ := RevenueByGeography(users, orders)
+	clv := CalculateCLV(users[0], orders, DefaultCLVHorizonMonths).CLV + CalculateCLV(users[1], orders, DefaultCLVHorizonMonths).CLV
+	if !reflect.DeepEqual(final.Anomalies, DetectOrderAnomalies(orders)) || !reflect.DeepEqual(final.CountryRevenue, countries) || !floatEqual(final.TotalCLV, clv, 0.01) {
+		t.Errorf("Expected the snapshot to agree with the one-off functions, got %+v", final)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Shared order anomaly detection - DetectOrderAnomalies flags orders that
+// stand out from the rest of an order history, each with the reasons why:
+//
+//   - a total that is a statistical outlier among the same user's orders or
+//     the same day's: outside the interquartile fences (1.5 times the IQR
+//     beyond the quartiles) or at least 3 standard deviations from the mean
+//   - one of several identical orders - the same user, day and items -
+//     which reads as a double submit or a script
+//
+// Outliers are only looked for in groups of at least minAnomalySample
+// orders; fewer say nothing about what is usual. Totals are compared in
+// DefaultCurrency and cancelled orders are left out. AnalyzeUserBehavior
+// reports the flagged orders.
+
+const (
+	// minAnomalySample is the fewest orders in a group to look for
+	// outliers in.
+	minAnomalySample = 4
+	// anomalyZScore is the distance from the mean, in standard deviations,
+	// from which a total is an outlier.
+	anomalyZScore = 3.0
+	// identicalOrderThreshold is how many identical orders make a pattern.
+	identicalOrderThreshold = 3
+)
+
+// OrderAnomaly is a flagged order and why it was flagged.
+type OrderAnomaly struct {
+	OrderID   int      `json:"order_id"`
+	UserID    int      `json:"user_id"`
+	OrderDate string   `json:"order_date"`
+	Total     float64  `json:"total"`
+	Reasons   []string `json:"reasons"`
+}
+
+// totalOutliers tests the totals of one group of orders, returning a reason
+// for each outlier by index; of is what the group is, as in "the user's".
+func totalOutliers(totals []float64, of string) map[int]string {
+	reasons := map[int]string{}
+	if len(totals) < minAnomalySample {
+		return reasons
+	}
+	sorted := slices.Clone(totals)
+	sort.Float64s(sorted)
+	q1, q3 := Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+	low, high := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	mean, variance := 0.0, 0.0
+	for _, total := range totals {
+		mean += total
+	}
+	mean /= float64(len(totals))
+	for _, total := range totals {
+		variance += (total - mean) * (total - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(totals)))
+
+	for i, total := range totals {
+		z := 0.0
+		if deviation > 0 {
+			z = (total - mean) / deviation
+		}
+		if total >= low && total <= high && math.Abs(z) < anomalyZScore {
+			continue
+		}
+		direction := "above"
+		if total < mean {
+			direction = "below"
+		}
+		reasons[i] = fmt.Sprintf("Total is far %s %s %d orders (z-score %.1f, usual range %.2f to %.2f)", direction, of, len(totals), z, max(low, 0), high)
+	}
+	return reasons
+}
+
+// orderSignature identifies an order's items and quantities.
+func orderSignature(order Order) string {
+	lines := make([]string, len(order.Products))
+	for i, product := range order.Products {
+		quantity := 1
+		if i < len(order.Quantities) {
+			quantity = order.Quantities[i]
+		}
+		sku := ""
+		if i < len(order.SKUs) {
+			sku = order.SKUs[i]
+		}
+		lines[i] = fmt.Sprintf("%d/%s x%d", product.ID, sku, quantity)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, ",")
+}
+
+// anomalyGroup is orders compared with each other - one user's, one day's
+// or one set of identical orders - and the reasons its members are flagged
+// for, by order index, as of the last check.
+type anomalyGroup struct {
+	of      string // whose orders, as in "the user's"; "" for identical orders
+	members []int
+	totals  []float64
+	reasons map[int]string
+	dirty   bool
+}
+
+// check re-flags the group's members.
+func (group *anomalyGroup) check(orders []OrderAnomaly) {
+	group.reasons = map[int]string{}
+	group.dirty = false
+	if group.of != "" {
+		for j, reason := range totalOutliers(group.totals, group.of) {
+			group.reasons[group.members[j]] = reason
+		}
+		return
+	}
+	if len(group.members) >= identicalOrderThreshold {
+		for _, i := range group.members {
+			group.reasons[i] = fmt.Sprintf("One of %d identical orders from the user on %s", len(group.members), orders[i].OrderDate)
+		}
+	}
+}
+
+// anomalyDetector collects orders for DetectOrderAnomalies. Adding an order
+// only re-checks the groups it joins, the next time results are asked for.
+type anomalyDetector struct {
+	orders   []OrderAnomaly // the orders, without reasons
+	groups   map[string]*anomalyGroup
+	groupsOf [][]*anomalyGroup // each order's groups: user, day, identical
+	dirty    []*anomalyGroup
+	flagged  map[int]bool
+}
+
+func newAnomalyDetector() *anomalyDetector {
+	return &anomalyDetector{groups: map[string]*anomalyGroup{}, flagged: map[int]bool{}}
+}
+
+// add counts an order unless it was cancelled.
+func (detector *anomalyDetector) add(order Order) {
+	if order.Status == "cancelled" {
+		return
+	}
+	i := len(detector.orders)
+	detector.orders = append(detector.orders, OrderAnomaly{OrderID: order.ID, UserID: order.UserID, OrderDate: order.OrderDate, Total: order.Total})
+	keys := [][2]string{{fmt.Sprintf("user|%d", order.UserID), "the user's"}}
+	if order.OrderDate != "" {
+		keys = append(keys,
+			[2]string{"day|" + order.OrderDate, "the day's"},
+			[2]string{fmt.Sprintf("same|%d|%s|%s", order.UserID, order.OrderDate, orderSignature(order)), ""})
+	}
+	var joined []*anomalyGroup
+	for _, key := range keys {
+		group := detector.groups[key[0]]
+		if group == nil {
+			group = &anomalyGroup{of: key[1]}
+			detector.groups[key[0]] = group
+		}
+		group.members = append(group.members, i)
+		group.totals = append(group.totals, riskAmount(order))
+		if !group.dirty {
+			group.dirty = true
+			detector.dirty = append(detector.dirty, group)
+		}
+		joined = append(joined, group)
+	}
+	detector.groupsOf = append(detector.groupsOf, joined)
+}
+
+// results lists the flagged orders by order ID.
+func (detector *anomalyDetector) results() []OrderAnomaly {
+	for _, group := range detector.dirty {
+		group.check(detector.orders)
+		for _, i := range group.members {
+			detector.flagged[i] = len(detector.reasonsOf(i)) > 0
+		}
+	}
+	detector.dirty = nil
+
+	var indexes []int
+	for i, flagged := range detector.flagged {
+		if flagged {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		i, j := indexes[a], indexes[b]
+		if detector.orders[i].OrderID != detector.orders[j].OrderID {
+			return detector.orders[i].OrderID < detector.orders[j].OrderID
+		}
+		return i < j
+	})
+	anomalies := []OrderAnomaly{}
+	for _, i := range indexes {
+		anomaly := detector.orders[i]
+		anomaly.Reasons = detector.reasonsOf(i)
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
+
+// reasonsOf lists why an order is flagged, per-user reasons first.
+func (detector *anomalyDetector) reasonsOf(i int) []string {
+	var reasons []string
+	for _, group := range detector.groupsOf[i] {
+		if reason, ok := group.reasons[i]; ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// DetectOrderAnomalies flags the unusual orders of a history, by order ID.
+func DetectOrderAnomalies(orders []Order) []OrderAnomaly {
+	detector := newAnomalyDetector()
+	for _, order := range orders {
+		detector.add(order)
+	}
+	return detector.results()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDetectOrderAnomalies tests per-user and per-day outliers and repeats
+func TestDetectOrderAnomalies(t *testing.T) {
+	orders := []Order{
+		{ID: 1, UserID: 1, OrderDate: "2024-01-01", Total: 40, Status: "delivered"},
+		{ID: 2, UserID: 1, OrderDate: "2024-01-08", Total: 45, Status: "delivered"},
+		{ID: 3, UserID: 1, OrderDate: "2024-01-15", Total: 50, Status: "delivered"},
+		{ID: 4, UserID: 1, OrderDate: "2024-01-22", Total: 42, Status: "delivered"},
+		{ID: 5, UserID: 1, OrderDate: "2024-01-29", Total: 900, Status: "delivered"},
+		// Cancelled orders don't count, however odd
+		{ID: 6, UserID: 1, OrderDate: "2024-01-30", Total: 9000, Status: "cancelled"},
+		// Too few orders to tell what is usual for user 2
+		{ID: 7, UserID: 2, OrderDate: "2024-01-02", Total: 10, Status: "delivered"},
+		{ID: 8, UserID: 2, OrderDate: "2024-01-03", Total: 1000, Status: "delivered"},
+	}
+	for id := 10; id < 13; id++ {
+		orders = append(orders, Order{ID: id, UserID: 3, OrderDate: "2024-02-01", Total: 20, Status: "pending", Products: []Product{{ID: 4}}, Quantities: []int{1}})
+	}
+	orders = append(orders, Order{ID: 13, UserID: 4, OrderDate: "2024-02-01", Total: 20, Status: "pending", Products: []Product{{ID: 4}}, Quantities: []int{2}})
+
+	anomalies := DetectOrderAnomalies(orders)
+	if len(anomalies) != 4 {
+		t.Fatalf("Expected order 5 and the three repeats flagged, got %+v", anomalies)
+	}
+	if anomalies[0].OrderID != 5 || len(anomalies[0].Reasons) != 1 || !strings.Contains(anomalies[0].Reasons[0], "above the user's 5 orders") {
+		t.Errorf("Expected order 5 flagged as the user's outlier, got %+v", anomalies[0])
+	}
+	for _, anomaly := range anomalies[1:] {
+		if anomaly.UserID != 3 || len(anomaly.Reasons) != 1 || !strings.Contains(anomaly.Reasons[0], "One of 3 identical orders") {
+			t.Errorf("Expected user 3's repeated order, got %+v", anomaly)
+		}
+	}
+
+	// A day of small orders with one large one
+	day := []Order{}
+	for id, total := range []float64{30, 35, 32, 31, 400} {
+		day = append(day, Order{ID: id + 1, UserID: id + 1, OrderDate: "2024-03-01", Total: total})
+	}
+	flagged := DetectOrderAnomalies(day)
+	if len(flagged) != 1 || flagged[0].OrderID != 5 || !strings.Contains(flagged[0].Reasons[0], "the day's 5 orders") {
+		t.Errorf("Expected the day's outlier, got %+v", flagged)
+	}
+
+	if got := DetectOrderAnomalies(nil); got == nil || len(got) != 0 {
+		t.Errorf("Expected no anomalies, got %#v", got)
+	}
+}
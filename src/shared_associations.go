@@ -0,0 +1,205 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shared market-basket analysis - MineAssociations finds "frequently bought
+// together" rules in order history with Apriori: itemsets of products are
+// grown one product at a time, keeping only those in at least minSupport of
+// the orders (every subset of a frequent itemset is frequent, so larger
+// candidates are made only from frequent ones), and each frequent itemset
+// of two or more products gives a rule per product it can predict. Itemsets
+// stop at maxItemsetSize products, which is plenty for shopping baskets.
+
+// maxItemsetSize bounds the itemsets MineAssociations grows.
+const maxItemsetSize = 3
+
+// AssociationRule says orders with the Antecedent products also tend to
+// have the Consequent. Support is the share of orders with all of them,
+// Confidence the share of orders with the antecedent that also have the
+// consequent, and Lift how much likelier the consequent is with the
+// antecedent than without.
+type AssociationRule struct {
+	Antecedent []int   `json:"antecedent"`
+	Consequent int     `json:"consequent"`
+	Count      int     `json:"count"` // orders with all the products
+	Support    float64 `json:"support"`
+	Confidence float64 `json:"confidence"`
+	Lift       float64 `json:"lift"`
+}
+
+// ValidateAssociationThresholds checks thresholds for MineAssociations: the
+// support must be above 0, and both at most 1.
+func ValidateAssociationThresholds(minSupport, minConfidence float64) ValidationResult {
+	result := newValidationResult()
+	if !(minSupport > 0 && minSupport <= 1) {
+		result.AddError("min_support", CodeOutOfRange, "Minimum support must be above 0 and at most 1")
+	}
+	if !(minConfidence >= 0 && minConfidence <= 1) {
+		result.AddError("min_confidence", CodeOutOfRange, "Minimum confidence must be between 0 and 1")
+	}
+	return result
+}
+
+// MineAssociations mines the rules of orders with at least minSupport and
+// minConfidence, strongest first. Cancelled orders are left out, and a
+// product counts once per order.
+func MineAssociations(orders []Order, minSupport, minConfidence float64) []AssociationRule {
+	var baskets [][]int
+	for _, order := range orders {
+		if order.Status == "cancelled" {
+			continue
+		}
+		var basket []int
+		for _, product := range order.Products {
+			if !slices.Contains(basket, product.ID) {
+				basket = append(basket, product.ID)
+			}
+		}
+		if len(basket) > 0 {
+			slices.Sort(basket)
+			baskets = append(baskets, basket)
+		}
+	}
+	rules := []AssociationRule{}
+	if len(baskets) == 0 {
+		return rules
+	}
+
+	// Frequent itemsets by size, counted in one pass over the baskets per
+	// size
+	counts := map[string]int{}
+	var ids []int
+	for _, basket := range baskets {
+		for _, id := range basket {
+			key := itemsetKey([]int{id})
+			if counts[key] == 0 {
+				ids = append(ids, id)
+			}
+			counts[key]++
+		}
+	}
+	var level [][]int
+	for _, id := range ids {
+		if frequent(counts[itemsetKey([]int{id})], len(baskets), minSupport) {
+			level = append(level, []int{id})
+		}
+	}
+	var frequentSets [][]int
+	for size := 2; size <= maxItemsetSize && len(level) > 1; size++ {
+		candidates := aprioriCandidates(level, counts, len(baskets), minSupport)
+		for _, basket := range baskets {
+			for _, candidate := range candidates {
+				if containsAll(basket, candidate) {
+					counts[itemsetKey(candidate)]++
+				}
+			}
+		}
+		level = nil
+		for _, candidate := range candidates {
+			if frequent(counts[itemsetKey(candidate)], len(baskets), minSupport) {
+				level = append(level, candidate)
+				frequentSets = append(frequentSets, candidate)
+			}
+		}
+	}
+
+	total := float64(len(baskets))
+	for _, itemset := range frequentSets {
+		count := counts[itemsetKey(itemset)]
+		for i, consequent := range itemset {
+			antecedent := slices.Delete(slices.Clone(itemset), i, i+1)
+			confidence := float64(count) / float64(counts[itemsetKey(antecedent)])
+			if confidence < minConfidence {
+				continue
+			}
+			rules = append(rules, AssociationRule{
+				Antecedent: antecedent,
+				Consequent: consequent,
+				Count:      count,
+				Support:    roundRatio(float64(count) / total),
+				Confidence: roundRatio(confidence),
+				Lift:       roundRatio(confidence / (float64(counts[itemsetKey([]int{consequent})]) / total)),
+			})
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		switch {
+		case a.Confidence != b.Confidence:
+			return a.Confidence > b.Confidence
+		case a.Lift != b.Lift:
+			return a.Lift > b.Lift
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		case itemsetKey(a.Antecedent) != itemsetKey(b.Antecedent):
+			return slices.Compare(a.Antecedent, b.Antecedent) < 0
+		}
+		return a.Consequent < b.Consequent
+	})
+	return rules
+}
+
+// aprioriCandidates joins frequent itemsets that differ only in their last
+// product into itemsets one larger, dropping those with an infrequent
+// subset.
+func aprioriCandidates(level [][]int, counts map[string]int, baskets int, minSupport float64) [][]int {
+	sort.Slice(level, func(i, j int) bool { return slices.Compare(level[i], level[j]) < 0 })
+	var candidates [][]int
+	for i := range level {
+		for j := i + 1; j < len(level); j++ {
+			a, b := level[i], level[j]
+			if !slices.Equal(a[:len(a)-1], b[:len(b)-1]) {
+				break
+			}
+			candidate := append(slices.Clone(a), b[len(b)-1])
+			pruned := false
+			for k := range candidate {
+				subset := slices.Delete(slices.Clone(candidate), k, k+1)
+				if !frequent(counts[itemsetKey(subset)], baskets, minSupport) {
+					pruned = true
+					break
+				}
+			}
+			if !pruned {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates
+}
+
+func frequent(count, baskets int, minSupport float64) bool {
+	return count > 0 && float64(count) >= minSupport*float64(baskets)
+}
+
+// containsAll reports whether a sorted basket has every product of a
+// sorted itemset.
+func containsAll(basket, itemset []int) bool {
+	i := 0
+	for _, id := range basket {
+		if i < len(itemset) && id == itemset[i] {
+			i++
+		}
+	}
+	return i == len(itemset)
+}
+
+func itemsetKey(itemset []int) string {
+	parts := make([]string, len(itemset))
+	for i, id := range itemset {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// roundRatio rounds a ratio to four places for display.
+func roundRatio(ratio float64) float64 {
+	return math.Round(ratio*10000) / 10000
+}
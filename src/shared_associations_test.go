@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestMineAssociations tests Apriori support, confidence and lift
+func TestMineAssociations(t *testing.T) {
+	order := func(status string, ids ...int) Order {
+		order := Order{Status: status}
+		for _, id := range ids {
+			order.Products = append(order.Products, Product{ID: id})
+		}
+		return order
+	}
+	orders := []Order{
+		order("delivered", 1, 2, 3),
+		order("delivered", 1, 2),
+		order("shipped", 1, 2, 2),
+		order("pending", 1, 3),
+		order("delivered", 4),
+		order("cancelled", 3, 4),
+		order("cancelled", 3, 4),
+	}
+
+	rules := MineAssociations(orders, 0.4, 0.7)
+	// 5 baskets: {1,2} is in 3, 1 in 4, 2 in 3 and 3 in 2, so 2 -> 1 is
+	// certain and 1 -> 2 holds 3 times in 4; {1,3} is in 2 but 1 -> 3 holds
+	// only half the time
+	want := []AssociationRule{
+		{Antecedent: []int{2}, Consequent: 1, Count: 3, Support: 0.6, Confidence: 1, Lift: 1.25},
+		{Antecedent: []int{3}, Consequent: 1, Count: 2, Support: 0.4, Confidence: 1, Lift: 1.25},
+		{Antecedent: []int{1}, Consequent: 2, Count: 3, Support: 0.6, Confidence: 0.75, Lift: 1.25},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("Expected %d rules, got %+v", len(want), rules)
+	}
+	for i, rule := range rules {
+		if !slices.Equal(rule.Antecedent, want[i].Antecedent) || rule.Consequent != want[i].Consequent ||
+			rule.Count != want[i].Count || rule.Support != want[i].Support || rule.Confidence != want[i].Confidence || rule.Lift != want[i].Lift {
+			t.Errorf("Rule %d: expected %+v, got %+v", i, want[i], rule)
+		}
+	}
+
+	// Three products bought together give rules with two-product antecedents
+	rules = MineAssociations(orders, 0.2, 1)
+	if !slices.ContainsFunc(rules, func(rule AssociationRule) bool {
+		return slices.Equal(rule.Antecedent, []int{2, 3}) && rule.Consequent == 1
+	}) {
+		t.Errorf("Expected {2,3} -> 1 at low support, got %+v", rules)
+	}
+
+	if rules := MineAssociations(nil, 0.1, 0.5); rules == nil || len(rules) != 0 {
+		t.Errorf("Expected no rules without orders, got %#v", rules)
+	}
+}
+
+// TestValidateAssociationThresholds tests the threshold ranges
+func TestValidateAssociationThresholds(t *testing.T) {
+	if result := ValidateAssociationThresholds(0.1, 0); !result.Valid {
+		t.Errorf("Expected zero confidence to be allowed, got %v", result.Errors)
+	}
+	result := ValidateAssociationThresholds(0, 1.5)
+	if result.Valid || len(result.FieldErrors) != 2 {
+		t.Errorf("Expected both thresholds to be rejected, got %+v", result.FieldErrors)
+	}
+}
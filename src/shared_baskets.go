@@ -0,0 +1,142 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Shared basket analysis - AnalyzeBaskets describes what goes into an
+// order: how many items (the sum of the quantities) orders hold, in
+// BasketSizeBuckets, and which mix of categories they combine, with the
+// average order value of each mix. Single-category baskets are shoppers
+// buying one kind of thing; multi-category ones show what sells together.
+// Values are in DefaultCurrency and cancelled orders are left out.
+// AnalyzeUserBehavior reports the analysis as baskets.
+
+// BasketSizeBuckets are the bands of the items-per-order histogram.
+var BasketSizeBuckets = BucketSpec{Edges: []float64{1, 2, 3, 4, 5, 10}}
+
+// uncategorized is the category of a product without one.
+const uncategorized = "uncategorized"
+
+// CategoryMix is the orders combining exactly these categories, joined with
+// "+" in alphabetical order, and their average value. Share is of all the
+// orders analyzed.
+type CategoryMix struct {
+	Categories   string  `json:"categories"`
+	Orders       int     `json:"orders"`
+	Share        float64 `json:"share"`
+	AverageValue float64 `json:"average_value"`
+}
+
+// BasketStats describes the baskets of an order history. The shares are
+// fractions of Orders; mixes are listed most orders first.
+type BasketStats struct {
+	Orders              int               `json:"orders"`
+	AverageItems        float64           `json:"average_items"`
+	ItemsPerOrder       []HistogramBucket `json:"items_per_order"`
+	SingleCategoryShare float64           `json:"single_category_share"`
+	MultiCategoryShare  float64           `json:"multi_category_share"`
+	CategoryMixes       []CategoryMix     `json:"category_mixes"`
+}
+
+// basketSummary collects orders one at a time for BasketStats.
+type basketSummary struct {
+	orders, items, single int
+	sizes                 *histogram
+	mixes                 map[string]*mixTotal
+}
+
+// mixTotal is the orders of one category mix and their value.
+type mixTotal struct {
+	orders int
+	value  float64
+}
+
+// newBasketSummary returns a summary of no orders.
+func newBasketSummary() *basketSummary {
+	return &basketSummary{sizes: newHistogram(BasketSizeBuckets), mixes: map[string]*mixTotal{}}
+}
+
+// add counts one order.
+func (summary *basketSummary) add(order Order) {
+	if order.Status == "cancelled" {
+		return
+	}
+	items := 0
+	var categories []string
+	for i, product := range order.Products {
+		quantity := 1
+		if i < len(order.Quantities) {
+			quantity = order.Quantities[i]
+		}
+		items += quantity
+		category := product.Category
+		if category == "" {
+			category = uncategorized
+		}
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	summary.orders++
+	summary.items += items
+	summary.sizes.add(float64(items))
+	if len(categories) == 1 {
+		summary.single++
+	}
+	if len(categories) == 0 {
+		return
+	}
+	mix := strings.Join(categories, "+")
+	if summary.mixes[mix] == nil {
+		summary.mixes[mix] = &mixTotal{}
+	}
+	summary.mixes[mix].orders++
+	summary.mixes[mix].value += riskAmount(order)
+}
+
+// stats describes the orders counted so far.
+func (summary *basketSummary) stats() BasketStats {
+	stats := BasketStats{Orders: summary.orders, ItemsPerOrder: summary.sizes.buckets(), CategoryMixes: []CategoryMix{}}
+	if summary.orders == 0 {
+		return stats
+	}
+	orders := float64(summary.orders)
+	stats.AverageItems = math.Round(float64(summary.items)/orders*100) / 100
+	stats.SingleCategoryShare = roundRatio(float64(summary.single) / orders)
+	multi := 0
+	for mix, total := range summary.mixes {
+		if strings.Contains(mix, "+") {
+			multi += total.orders
+		}
+		stats.CategoryMixes = append(stats.CategoryMixes, CategoryMix{
+			Categories:   mix,
+			Orders:       total.orders,
+			Share:        roundRatio(float64(total.orders) / orders),
+			AverageValue: RoundToCurrency(total.value/float64(total.orders), DefaultCurrency),
+		})
+	}
+	stats.MultiCategoryShare = roundRatio(float64(multi) / orders)
+	sort.Slice(stats.CategoryMixes, func(i, j int) bool {
+		a, b := stats.CategoryMixes[i], stats.CategoryMixes[j]
+		if a.Orders != b.Orders {
+			return a.Orders > b.Orders
+		}
+		return a.Categories < b.Categories
+	})
+	return stats
+}
+
+// AnalyzeBaskets describes the baskets of an order history.
+func AnalyzeBaskets(orders []Order) BasketStats {
+	summary := newBasketSummary()
+	for _, order := range orders {
+		summary.add(order)
+	}
+	return summary.stats()
+}
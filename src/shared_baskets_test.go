@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAnalyzeBaskets tests item counts, single and multi-category shares and
+// the category mixes
+func TestAnalyzeBaskets(t *testing.T) {
+	book := Product{ID: 1, Category: "books", Price: 10}
+	lamp := Product{ID: 2, Category: "home", Price: 30}
+	orders := []Order{
+		{Products: []Product{book}, Quantities: []int{3}, Total: 30},
+		{Products: []Product{book, book}, Quantities: []int{1}, Total: 20},
+		{Products: []Product{lamp, book}, Quantities: []int{1, 1}, Total: 40},
+		{Products: []Product{book, lamp}, Quantities: []int{5, 1}, Total: 80},
+		{Products: []Product{lamp}, Quantities: []int{9}, Status: "cancelled"},
+	}
+	stats := AnalyzeBaskets(orders)
+	if stats.Orders != 4 || stats.AverageItems != 3.25 || stats.SingleCategoryShare != 0.5 || stats.MultiCategoryShare != 0.5 {
+		t.Errorf("Unexpected basket stats %+v", stats)
+	}
+	want := []CategoryMix{
+		{Categories: "books", Orders: 2, Share: 0.5, AverageValue: 25},
+		{Categories: "books+home", Orders: 2, Share: 0.5, AverageValue: 60},
+	}
+	if !reflect.DeepEqual(stats.CategoryMixes, want) {
+		t.Errorf("CategoryMixes = %+v, want %+v", stats.CategoryMixes, want)
+	}
+	sizes := map[string]int{}
+	for _, bucket := range stats.ItemsPerOrder {
+		sizes[bucket.Label] = bucket.Count
+	}
+	if sizes["2-3"] != 2 || sizes["3-4"] != 1 || sizes["5-10"] != 1 {
+		t.Errorf("Unexpected items per order %+v", stats.ItemsPerOrder)
+	}
+
+	empty := AnalyzeBaskets(nil)
+	if empty.Orders != 0 || empty.CategoryMixes == nil || len(empty.ItemsPerOrder) != len(BasketSizeBuckets.Edges) {
+		t.Errorf("Expected empty basket stats, got %+v", empty)
+	}
+	if got := AnalyzeUserBehavior([]User{{ID: 1}}, orders, AnalyticsFilter{}).Baskets; !reflect.DeepEqual(got, stats) {
+		t.Errorf("Expected the analytics baskets to match, got %+v", got)
+	}
+}
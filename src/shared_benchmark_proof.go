@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// Shared benchmark challenges - a challenged run is a benchmark workload on
+// inputs derived from the challenge seed: matrices of seeded digits, a
+// Mandelbrot view shifted by a seeded offset, or hash messages carrying the
+// seed. Its proof is a digest of the whole output - every cell of the
+// product, every pixel's iteration count, every hash - so it takes all of
+// the work to compute, and a new seed with each challenge means it can't be
+// computed ahead. The WebAssembly module times challenged runs and reports
+// the proof with the duration (runChallengeBenchmarkWasm); the server reruns
+// the workload to check the proof, and rejects durations shorter than the
+// work it did could plausibly take.
+
+// challengeWorkRates are the benchmarks results can be verified for, with
+// the most work a run can plausibly do in a millisecond: multiply-adds for
+// matrix, iterations for mandelbrot and hashes for hash. They are well
+// above what fast native code manages, so honest runs are never rejected.
+var challengeWorkRates = map[string]float64{
+	"matrix":     1e7, // 10G multiply-adds a second
+	"mandelbrot": 2e6, // 2G iterations a second
+	"hash":       2e4, // 50ns a hash
+}
+
+// challengeHashData is the message the hash workload hashes, as the hash
+// benchmark does, suffixed with the seed and a counter.
+const challengeHashData = "WebAssembly performance test data for hashing benchmark"
+
+// challengeRun is the outcome of a challenged run.
+type challengeRun struct {
+	Proof string
+	Work  int64 // in the units of challengeWorkRates
+}
+
+// minDurationMs is the shortest a run of the workload can plausibly take.
+func (r challengeRun) minDurationMs(benchmark string) float64 {
+	return float64(r.Work) / challengeWorkRates[benchmark]
+}
+
+// proofRand is a xorshift32 generator seeding the workload's inputs.
+type proofRand uint32
+
+func (r *proofRand) next(n int) int {
+	x := uint32(*r)
+	if x == 0 {
+		x = 0x9e3779b9
+	}
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	*r = proofRand(x)
+	return int(x % uint32(n))
+}
+
+// checkChallengeParams checks that results of a benchmark run with params
+// can be verified, without running it. Parameter names match those recorded
+// in the benchmark history: size for matrix; width, height and iterations
+// for mandelbrot; count for hash.
+func checkChallengeParams(benchmark string, params map[string]int) error {
+	var names []string
+	switch benchmark {
+	case "matrix":
+		names = []string{"size"}
+	case "mandelbrot":
+		names = []string{"width", "height", "iterations"}
+	case "hash":
+		names = []string{"count"}
+	default:
+		return fmt.Errorf("benchmark %q does not support challenges", benchmark)
+	}
+	for _, name := range names {
+		if value, ok := params[name]; !ok || value < 1 {
+			return fmt.Errorf("%s parameter must be a positive integer", name)
+		}
+	}
+	return nil
+}
+
+// runChallengeBenchmark runs a benchmark with params on the inputs seed
+// derives, logging and tracing for the run in ctx, and returns the proof of
+// its output. It stops early with ctx's error when ctx is done.
+func runChallengeBenchmark(ctx context.Context, benchmark string, params map[string]int, seed uint32) (challengeRun, error) {
+	ctx, end := StartSpan(ctx, "ChallengeBenchmark")
+	defer end()
+
+	run, err := challengeBenchmark(ctx, benchmark, params, seed)
+	if err != nil {
+		slog.WarnContext(ctx, "benchmark proof failed", "benchmark", benchmark, "error", err)
+		return challengeRun{}, err
+	}
+	slog.DebugContext(ctx, "benchmark proof computed", "benchmark", benchmark, "seed", seed, "work", run.Work)
+	return run, nil
+}
+
+// challengeBenchmark does the work runChallengeBenchmark reports.
+func challengeBenchmark(ctx context.Context, benchmark string, params map[string]int, seed uint32) (challengeRun, error) {
+	if err := checkChallengeParams(benchmark, params); err != nil {
+		return challengeRun{}, err
+	}
+
+	rng := proofRand(seed)
+	digest := sha256.New()
+	var buf [8]byte
+	writeInt := func(v int64) {
+		binary.BigEndian.PutUint64(buf[:], uint64(v))
+		digest.Write(buf[:])
+	}
+
+	var work int64
+	switch benchmark {
+	case "matrix":
+		// A*B for matrices of seeded digits
+		size := params["size"]
+		a, b := make([]int64, size*size), make([]int64, size*size)
+		for i := range a {
+			a[i] = int64(rng.next(10))
+		}
+		for i := range b {
+			b[i] = int64(rng.next(10))
+		}
+		for row := 0; row < size; row++ {
+			if err := ctx.Err(); err != nil {
+				return challengeRun{}, err
+			}
+			for col := 0; col < size; col++ {
+				var cell int64
+				for k := 0; k < size; k++ {
+					cell += a[row*size+k] * b[k*size+col]
+				}
+				writeInt(cell)
+			}
+		}
+		work = int64(size) * int64(size) * int64(size)
+	case "mandelbrot":
+		// The benchmark's [-2, 1] x [-1.5, 1.5] view, shifted by up to a
+		// quarter each way
+		width, height, iterations := params["width"], params["height"], params["iterations"]
+		xmin := -2.0 + float64(rng.next(1024)-512)/2048
+		ymin := -1.5 + float64(rng.next(1024)-512)/2048
+		for py := 0; py < height; py++ {
+			if err := ctx.Err(); err != nil {
+				return challengeRun{}, err
+			}
+			for px := 0; px < width; px++ {
+				iter := mandelbrotIterations(px, py, width, height, xmin, ymin, iterations)
+				writeInt(int64(iter))
+				work += int64(iter) + 1
+			}
+		}
+	case "hash":
+		count := params["count"]
+		prefix := challengeHashData + "-" + strconv.FormatUint(uint64(seed), 16) + "-"
+		for i := 0; i < count; i++ {
+			if i%1024 == 0 {
+				if err := ctx.Err(); err != nil {
+					return challengeRun{}, err
+				}
+			}
+			sum := sha256.Sum256([]byte(prefix + strconv.Itoa(i)))
+			digest.Write(sum[:])
+		}
+		work = int64(count)
+	}
+
+	return challengeRun{Proof: hex.EncodeToString(digest.Sum(nil)), Work: work}, nil
+}
+
+// mandelbrotIterations is the escape iteration count of one pixel of a
+// width x height rendering of the 3 x 3 region from (xmin, ymin). The
+// explicit float64 conversions stop the compiler fusing multiply-adds on
+// the architectures that have them, so the server and the WebAssembly
+// module agree bit for bit.
+func mandelbrotIterations(px, py, width, height int, xmin, ymin float64, iterations int) int {
+	dx := 3.0 / float64(width)
+	dy := 3.0 / float64(height)
+	cx := xmin + float64(float64(px)*dx)
+	cy := ymin + float64(float64(py)*dy)
+
+	zx, zy := 0.0, 0.0
+	iter := 0
+	for iter < iterations {
+		zx2 := float64(zx * zx)
+		zy2 := float64(zy * zy)
+		if zx2+zy2 > 4.0 {
+			break
+		}
+		zy = float64((zx+zx)*zy) + cy
+		zx = zx2 - zy2 + cx
+		iter++
+	}
+	return iter
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestChallengeBenchmark tests that proofs depend on the seed and workload only
+func TestChallengeBenchmark(t *testing.T) {
+	workloads := []struct {
+		benchmark string
+		params    map[string]int
+		work      int64
+	}{
+		{"matrix", map[string]int{"size": 50}, 50 * 50 * 50},
+		{"mandelbrot", map[string]int{"width": 40, "height": 30, "iterations": 100}, 0},
+		{"hash", map[string]int{"count": 1000}, 1000},
+	}
+	ctx := context.Background()
+	for _, w := range workloads {
+		first, err := runChallengeBenchmark(ctx, w.benchmark, w.params, 42)
+		if err != nil || len(first.Proof) != 64 {
+			t.Fatalf("runChallengeBenchmark(%s) = %+v, %v", w.benchmark, first, err)
+		}
+		if w.work != 0 && first.Work != w.work {
+			t.Errorf("Expected %s to do %d units of work, got %d", w.benchmark, w.work, first.Work)
+		}
+		if first.minDurationMs(w.benchmark) <= 0 {
+			t.Errorf("Expected a minimum duration for %s", w.benchmark)
+		}
+		if again, _ := runChallengeBenchmark(ctx, w.benchmark, w.params, 42); again != first {
+			t.Errorf("Expected %s proofs to be deterministic", w.benchmark)
+		}
+		if other, _ := runChallengeBenchmark(ctx, w.benchmark, w.params, 43); other.Proof == first.Proof {
+			t.Errorf("Expected %s proofs to depend on the seed", w.benchmark)
+		}
+	}
+
+	// Mandelbrot work is the iterations done: at least one per pixel, at
+	// most the limit
+	run, _ := runChallengeBenchmark(ctx, "mandelbrot", map[string]int{"width": 40, "height": 30, "iterations": 100}, 42)
+	if run.Work < 40*30 || run.Work > 40*30*101 {
+		t.Errorf("Unexpected mandelbrot work %d", run.Work)
+	}
+
+	for _, w := range []struct {
+		benchmark string
+		params    map[string]int
+	}{
+		{"matrix", nil},
+		{"mandelbrot", map[string]int{"width": 40, "height": 30}},
+		{"hash", map[string]int{"count": 0}},
+		{"raytracing", map[string]int{"width": 10}},
+	} {
+		if _, err := runChallengeBenchmark(ctx, w.benchmark, w.params, 1); err == nil {
+			t.Errorf("Expected an error for %s with %v", w.benchmark, w.params)
+		}
+		if err := checkChallengeParams(w.benchmark, w.params); err == nil {
+			t.Errorf("Expected %s with %v not to be challengeable", w.benchmark, w.params)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := runChallengeBenchmark(cancelled, "matrix", map[string]int{"size": 50}, 1); err == nil {
+		t.Error("Expected a cancelled run to stop")
+	}
+}
+
+// TestMandelbrotIterations tests pixels inside and outside the set
+func TestMandelbrotIterations(t *testing.T) {
+	// The centre pixel of a 4x4 grid is c = (-0.5, 0), inside the set
+	if got := mandelbrotIterations(2, 2, 4, 4, -2, -1.5, 50); got != 50 {
+		t.Errorf("Expected the centre to reach the iteration limit, got %d", got)
+	}
+	// The corner c = (-2, -1.5) escapes after one iteration
+	if got := mandelbrotIterations(0, 0, 4, 4, -2, -1.5, 50); got != 1 {
+		t.Errorf("Expected the corner to escape after 1 iteration, got %d", got)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "context"
+
+// cancelCheckInterval is how many items the *Context variants of the heavy
+// business logic (recommendations, analytics, search) process between checks
+// of their context, so a cancelled request stops them without the checks
+// costing much.
+const cancelCheckInterval = 256
+
+// checkCanceled returns ctx's error, if it is done, on every
+// cancelCheckInterval-th item i from 0.
+func checkCanceled(ctx context.Context, i int) error {
+	if i%cancelCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
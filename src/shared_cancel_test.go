@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestContextVariants tests that the *Context variants of the heavy
+// business logic match the plain functions, and stop once their context is
+// done
+func TestContextVariants(t *testing.T) {
+	var catalog []Product
+	for i := 1; i <= 3*cancelCheckInterval; i++ {
+		catalog = append(catalog, Product{ID: i, Name: fmt.Sprintf("Lamp %d", i), Description: "A desk lamp", Price: float64(10 + i%50), Category: "home", OnHand: 5, Rating: 4})
+	}
+	user := User{ID: 1, Age: 45, Country: "US"}
+	users := []User{user}
+	orders := []Order{{ID: 1, UserID: 1, Products: catalog[:2], Quantities: []int{1, 2}, Total: 30}}
+
+	background := context.Background()
+	recommendations, err := RecommendProductsContext(background, user, catalog, Order{})
+	if err != nil || !reflect.DeepEqual(recommendations, RecommendProducts(user, catalog, Order{})) {
+		t.Errorf("RecommendProductsContext() = %v, %v, want RecommendProducts()", recommendations, err)
+	}
+	analytics, err := AnalyzeUserBehaviorContext(background, users, orders, AnalyticsFilter{})
+	if err != nil || !reflect.DeepEqual(analytics, AnalyzeUserBehavior(users, orders, AnalyticsFilter{})) {
+		t.Errorf("AnalyzeUserBehaviorContext() = %+v, %v, want AnalyzeUserBehavior()", analytics, err)
+	}
+	results, err := SearchProductsContext(background, catalog, "lamp", SearchOptions{})
+	if err != nil || !reflect.DeepEqual(results, SearchProducts(catalog, "lamp", SearchOptions{})) {
+		t.Errorf("SearchProductsContext() = %v, %v, want SearchProducts()", results, err)
+	}
+
+	cancelled, cancel := context.WithCancel(background)
+	cancel()
+	index := BuildSearchIndex(catalog)
+	for name, run := range map[string]func() error{
+		"RecommendProductsContext": func() error {
+			_, err := RecommendProductsContext(cancelled, user, catalog, Order{})
+			return err
+		},
+		"ExplainRecommendationsContext": func() error {
+			_, err := ExplainRecommendationsContext(cancelled, user, catalog, Order{}, Wishlist{}, DefaultRecommendOptions())
+			return err
+		},
+		"AnalyzeUserBehaviorContext": func() error {
+			_, err := AnalyzeUserBehaviorContext(cancelled, users, orders, AnalyticsFilter{})
+			return err
+		},
+		"BuildSearchIndexContext": func() error {
+			_, err := BuildSearchIndexContext(cancelled, catalog)
+			return err
+		},
+		"SearchContext": func() error {
+			_, err := index.SearchContext(cancelled, "lamp", SearchOptions{})
+			return err
+		},
+	} {
+		if err := run(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with a cancelled context: err = %v, want context.Canceled", name, err)
+		}
+	}
+}
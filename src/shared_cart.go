@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Shared shopping cart logic - the server's /api/cart endpoints and the
+// WebAssembly cartSummaryWasm function price a cart with the same code
+
+// MaxCartQuantity bounds the quantity of a single cart line.
+const MaxCartQuantity = 99
+
+// CartItem is one product line in a cart, of the variant SKU names if the
+// product has variants. Cart lines also record when the product was first
+// added (RFC 3339) and its price then; other product and quantity pairs,
+// like shipment contents, leave those empty.
+type CartItem struct {
+	ProductID  int     `json:"product_id"`
+	SKU        string  `json:"sku,omitempty"`
+	Quantity   int     `json:"quantity"`
+	AddedAt    string  `json:"added_at,omitempty"`
+	PriceAtAdd float64 `json:"price_at_add,omitempty"`
+}
+
+// sameLine reports whether two items are of the same product variant.
+func (item CartItem) sameLine(other CartItem) bool {
+	return item.ProductID == other.ProductID && item.SKU == other.SKU
+}
+
+// Cart lists products by ID; prices come from the catalog when the cart is
+// summarized, so a cart never holds stale product data. PriceAtAdd only
+// lets a page point out prices that changed since.
+type Cart struct {
+	Items []CartItem `json:"items"`
+}
+
+// CartSummary is a cart priced as an order for a user, with recommendations
+// based on what is in it.
+type CartSummary struct {
+	Items           []CartItem `json:"items"`
+	Order           Order      `json:"order"`
+	Recommendations []Product  `json:"recommendations"`
+}
+
+// ValidateCartItem checks a cart line against the catalog.
+func ValidateCartItem(item CartItem, catalog []Product) ValidationResult {
+	result := newValidationResult()
+
+	if item.Quantity < 1 || item.Quantity > MaxCartQuantity {
+		result.AddError("quantity", CodeOutOfRange, fmt.Sprintf("Quantity must be between 1 and %d", MaxCartQuantity))
+	}
+
+	product, ok := findProduct(catalog, item.ProductID)
+	if !ok {
+		result.AddError("product_id", CodeNotFound, fmt.Sprintf("Product %d does not exist", item.ProductID))
+		return result
+	}
+	sold, err := VariantProduct(product, item.SKU)
+	switch {
+	case err != nil && item.SKU == "":
+		result.AddError("sku", CodeRequired, fmt.Sprintf("Choose a variant of %s", product.Name))
+	case err != nil:
+		result.AddError("sku", CodeNotFound, fmt.Sprintf("%s has no variant %s", product.Name, item.SKU))
+	case !sold.InStock():
+		result.AddError("quantity", CodeUnavailable, fmt.Sprintf("%s is out of stock", sold.Name))
+	case item.Quantity > sold.Available():
+		result.AddError("quantity", CodeUnavailable, fmt.Sprintf("Only %d of %s available", sold.Available(), sold.Name))
+	}
+
+	return result
+}
+
+// lineIndex is the index of the line for a product variant, or -1.
+func (cart *Cart) lineIndex(productID int, sku string) int {
+	key := CartItem{ProductID: productID, SKU: sku}
+	return slices.IndexFunc(cart.Items, key.sameLine)
+}
+
+// AddItem adds quantity of a product, of variant sku ("" for products
+// without variants), at time at. It merges with an existing line, which
+// keeps its original time and price. The merged quantity is capped at
+// MaxCartQuantity.
+func (cart *Cart) AddItem(product Product, sku string, quantity int, at time.Time) {
+	if i := cart.lineIndex(product.ID, sku); i >= 0 {
+		cart.Items[i].Quantity = min(cart.Items[i].Quantity+quantity, MaxCartQuantity)
+		return
+	}
+	price := product.Price
+	if sold, err := VariantProduct(product, sku); err == nil {
+		price = sold.Price
+	}
+	cart.Items = append(cart.Items, CartItem{
+		ProductID:  product.ID,
+		SKU:        sku,
+		Quantity:   min(quantity, MaxCartQuantity),
+		AddedAt:    at.UTC().Format(time.RFC3339),
+		PriceAtAdd: price,
+	})
+}
+
+// UpdateQuantity replaces the quantity of a line; zero removes it. It
+// reports whether the product variant was in the cart.
+func (cart *Cart) UpdateQuantity(productID int, sku string, quantity int) bool {
+	if quantity <= 0 {
+		return cart.RemoveItem(productID, sku)
+	}
+	i := cart.lineIndex(productID, sku)
+	if i < 0 {
+		return false
+	}
+	cart.Items[i].Quantity = quantity
+	return true
+}
+
+// RemoveItem deletes a line and reports whether the product variant was in
+// the cart.
+func (cart *Cart) RemoveItem(productID int, sku string) bool {
+	i := cart.lineIndex(productID, sku)
+	if i < 0 {
+		return false
+	}
+	cart.Items = slices.Delete(cart.Items, i, i+1)
+	return true
+}
+
+// MergeCarts folds a guest cart into a signed-in user's cart, as when a
+// shopper logs in after filling a cart anonymously. The user's lines come
+// first; a product variant in both carts gets the summed quantity, capped at
+// MaxCartQuantity, and keeps the time and price it was first added at.
+func MergeCarts(guest, user Cart) Cart {
+	merged := Cart{Items: append([]CartItem(nil), user.Items...)}
+	for _, item := range guest.Items {
+		i := slices.IndexFunc(merged.Items, item.sameLine)
+		if i < 0 {
+			item.Quantity = min(item.Quantity, MaxCartQuantity)
+			merged.Items = append(merged.Items, item)
+			continue
+		}
+		line := &merged.Items[i]
+		line.Quantity = min(line.Quantity+item.Quantity, MaxCartQuantity)
+		if item.AddedAt != "" && (line.AddedAt == "" || item.AddedAt < line.AddedAt) {
+			line.AddedAt, line.PriceAtAdd = item.AddedAt, item.PriceAtAdd
+		}
+	}
+	return merged
+}
+
+// CartToOrder prices a cart as an order for the user with CalculateOrderTotal,
+// tax-inclusive where the user's country quotes prices that way. Variant
+// lines are priced as their variant. Lines whose product or variant is no
+// longer in the catalog are left out.
+func CartToOrder(cart Cart, catalog []Product, user User) Order {
+	order := Order{UserID: user.ID, Products: []Product{}, Quantities: []int{}, Status: "cart", IncludesTax: PricesIncludeTax(user.Country)}
+	var skus []string
+	for _, item := range cart.Items {
+		product, ok := findProduct(catalog, item.ProductID)
+		if !ok {
+			continue
+		}
+		if product, err := VariantProduct(product, item.SKU); err == nil {
+			order.Products = append(order.Products, product)
+			order.Quantities = append(order.Quantities, item.Quantity)
+			skus = append(skus, item.SKU)
+		}
+	}
+	if slices.ContainsFunc(skus, func(sku string) bool { return sku != "" }) {
+		order.SKUs = skus
+	}
+	CalculateOrderTotal(&order, user)
+	return order
+}
+
+// SummarizeCart prices the cart and recommends products to go with it.
+func SummarizeCart(cart Cart, catalog []Product, user User) CartSummary {
+	items := cart.Items
+	if items == nil {
+		items = []CartItem{}
+	}
+	order := CartToOrder(cart, catalog, user)
+	return CartSummary{
+		Items:           items,
+		Order:           order,
+		Recommendations: RecommendProducts(user, catalog, order),
+	}
+}
+
+func findProduct(catalog []Product, id int) (Product, bool) {
+	for _, product := range catalog {
+		if product.ID == id {
+			return product, true
+		}
+	}
+	return Product{}, false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCartOperations tests the shared cart mutations and pricing
+func TestCartOperations(t *testing.T) {
+	added := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var cart Cart
+	cart.AddItem(testProducts[0], "", 2, added)
+	cart.AddItem(testProducts[2], "", 1, added)
+	cart.AddItem(testProducts[0], "", 98, added.Add(time.Hour))
+
+	if len(cart.Items) != 2 || cart.Items[0].Quantity != MaxCartQuantity {
+		t.Fatalf("Expected merged lines capped at %d, got %+v", MaxCartQuantity, cart.Items)
+	}
+	if line := cart.Items[0]; line.AddedAt != "2026-03-01T09:00:00Z" || line.PriceAtAdd != testProducts[0].Price {
+		t.Errorf("Expected the line to keep its first added time and price, got %+v", line)
+	}
+	if !cart.UpdateQuantity(1, "", 1) || cart.UpdateQuantity(42, "", 1) {
+		t.Error("UpdateQuantity should only update products in the cart")
+	}
+	if !cart.UpdateQuantity(3, "", 0) || len(cart.Items) != 1 {
+		t.Errorf("Expected quantity 0 to remove the line, got %+v", cart.Items)
+	}
+	if cart.RemoveItem(3, "") || !cart.RemoveItem(1, "") || len(cart.Items) != 0 {
+		t.Errorf("Unexpected result removing lines: %+v", cart.Items)
+	}
+}
+
+// TestMergeCarts tests folding a guest cart into a user's cart
+func TestMergeCarts(t *testing.T) {
+	user := Cart{Items: []CartItem{
+		{ProductID: 1, Quantity: 2, AddedAt: "2026-03-02T10:00:00Z", PriceAtAdd: 999.99},
+		{ProductID: 2, Quantity: 90, AddedAt: "2026-03-02T10:00:00Z", PriceAtAdd: 29.99},
+	}}
+	guest := Cart{Items: []CartItem{
+		{ProductID: 3, Quantity: 1, AddedAt: "2026-03-03T08:00:00Z", PriceAtAdd: 15.99},
+		{ProductID: 1, Quantity: 1, AddedAt: "2026-03-01T08:00:00Z", PriceAtAdd: 949.99},
+		{ProductID: 2, Quantity: 20, AddedAt: "2026-03-04T08:00:00Z", PriceAtAdd: 24.99},
+	}}
+
+	merged := MergeCarts(guest, user)
+	want := []CartItem{
+		{ProductID: 1, Quantity: 3, AddedAt: "2026-03-01T08:00:00Z", PriceAtAdd: 949.99},
+		{ProductID: 2, Quantity: MaxCartQuantity, AddedAt: "2026-03-02T10:00:00Z", PriceAtAdd: 29.99},
+		{ProductID: 3, Quantity: 1, AddedAt: "2026-03-03T08:00:00Z", PriceAtAdd: 15.99},
+	}
+	if len(merged.Items) != len(want) {
+		t.Fatalf("Expected %d lines, got %+v", len(want), merged.Items)
+	}
+	for i := range want {
+		if merged.Items[i] != want[i] {
+			t.Errorf("Line %d = %+v, want %+v", i, merged.Items[i], want[i])
+		}
+	}
+	if user.Items[0].Quantity != 2 {
+		t.Error("MergeCarts should not modify the user's cart")
+	}
+}
+
+// TestSummarizeCart tests that a cart is priced like the equivalent order
+func TestSummarizeCart(t *testing.T) {
+	cart := Cart{Items: []CartItem{{ProductID: 1, Quantity: 1}, {ProductID: 3, Quantity: 2}, {ProductID: 99, Quantity: 1}}}
+	summary := SummarizeCart(cart, testProducts, testUsers[0])
+
+	expected := Order{Products: []Product{testProducts[0], testProducts[2]}, Quantities: []int{1, 2}}
+	CalculateOrderTotal(&expected, testUsers[0])
+
+	if len(summary.Order.Products) != 2 {
+		t.Fatalf("Expected the unknown product to be left out, got %+v", summary.Order.Products)
+	}
+	if summary.Order.Total != expected.Total || summary.Order.Discount == 0 {
+		t.Errorf("Expected total %.2f with a premium discount, got %+v", expected.Total, summary.Order)
+	}
+
+	for _, tt := range []struct {
+		item  CartItem
+		valid bool
+	}{
+		{CartItem{ProductID: 1, Quantity: 1}, true},
+		{CartItem{ProductID: 1, Quantity: 0}, false},
+		{CartItem{ProductID: 1, Quantity: MaxCartQuantity + 1}, false},
+		{CartItem{ProductID: 99, Quantity: 1}, false},
+	} {
+		if got := ValidateCartItem(tt.item, testProducts).Valid; got != tt.valid {
+			t.Errorf("ValidateCartItem(%+v) = %v, want %v", tt.item, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Shared churn risk - ScoreChurnRisk estimates how likely each customer is
+// to have stopped buying. It is a heuristic, not a trained model: three
+// signals feed a logistic curve so the result reads as a probability.
+//
+//   - recency: days since the last order, reaching full weight at
+//     churnHorizonDays
+//   - frequency decline: the customer's latest gap between orders (or the
+//     one still open) against their earlier gaps
+//   - premium lapse: the customer had the premium member discount on an
+//     order but is no longer premium
+//
+// As with SegmentUsersRFM the days are counted back from the newest order,
+// so replayed or demo history scores sensibly. ScoreChurnRiskWithSessions
+// also counts recency from the customer's last session, as browsing shows
+// they are still around. AnalyzeUserBehavior reports the average and the
+// number of high-risk customers; GET /api/analytics/churn lists them, with
+// the stored events' sessions.
+
+// churnHorizonDays is the gap after which recency counts in full.
+const churnHorizonDays = 180
+
+// Churn model weights: the bias keeps a customer who just ordered near 5%.
+const (
+	churnBias          = -3.0
+	churnRecencyWeight = 4.0
+	churnDeclineWeight = 2.0
+	churnLapseWeight   = 1.5
+)
+
+// Churn risk levels by probability.
+const (
+	ChurnLow    = "low"    // below 0.3
+	ChurnMedium = "medium" // below 0.6
+	ChurnHigh   = "high"
+)
+
+// ChurnRisk is one customer's churn signals and probability.
+type ChurnRisk struct {
+	UserID           int     `json:"user_id"`
+	Name             string  `json:"name"`
+	LastOrder        string  `json:"last_order"`            // YYYY-MM-DD
+	LastActive       string  `json:"last_active,omitempty"` // YYYY-MM-DD, of the last session
+	DaysSinceOrder   int     `json:"days_since_order"`
+	Orders           int     `json:"orders"`
+	FrequencyDecline float64 `json:"frequency_decline"` // 0 to 1
+	PremiumLapsed    bool    `json:"premium_lapsed"`
+	Probability      float64 `json:"probability"`
+	Risk             string  `json:"risk"`
+}
+
+// churnLevel names the risk level of a probability.
+func churnLevel(probability float64) string {
+	switch {
+	case probability < 0.3:
+		return ChurnLow
+	case probability < 0.6:
+		return ChurnMedium
+	default:
+		return ChurnHigh
+	}
+}
+
+// frequencyDecline compares the latest gap between a customer's orders with
+// their earlier ones: 0 while the latest is no longer than usual, 1 once it
+// is three times as long. dates are sorted; open is the days since the last.
+func frequencyDecline(dates []time.Time, open float64) float64 {
+	n := len(dates)
+	if n < 2 {
+		return 0
+	}
+	latest := dates[n-1].Sub(dates[n-2]).Hours() / 24
+	baseline, recent := latest, open
+	if n > 2 {
+		// The mean of the earlier gaps spans from the first order to the
+		// one before the last
+		baseline = dates[n-2].Sub(dates[0]).Hours() / 24 / float64(n-2)
+		recent = max(open, latest)
+	}
+	return min(max((recent/max(baseline, 1)-1)/2, 0), 1)
+}
+
+// ScoreChurnRisk scores the users with at least one order, riskiest first
+// and ties going to the lower user ID. Cancelled and undated orders and
+// orders of unknown users are left out.
+func ScoreChurnRisk(users []User, orders []Order) []ChurnRisk {
+	byID, histories := churnHistories(users, orders)
+	return scoreChurn(byID, histories, nil)
+}
+
+// ScoreChurnRiskWithSessions scores as ScoreChurnRisk, but recency runs from
+// a customer's last order or last session, whichever is later; the days are
+// counted back from the newest of either.
+func ScoreChurnRiskWithSessions(users []User, orders []Order, sessions []Session) []ChurnRisk {
+	byID, histories := churnHistories(users, orders)
+	return scoreChurn(byID, histories, lastActive(sessions))
+}
+
+// churnHistories indexes the users and collects the order history of each
+// known user.
+func churnHistories(users []User, orders []Order) (map[int]User, map[int]*customerHistory) {
+	byID := map[int]User{}
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	histories := map[int]*customerHistory{}
+	for _, order := range orders {
+		date, err := time.Parse(taxDateLayout, order.OrderDate)
+		if _, known := byID[order.UserID]; !known || err != nil || order.Status == "cancelled" {
+			continue
+		}
+		if histories[order.UserID] == nil {
+			histories[order.UserID] = &customerHistory{}
+		}
+		histories[order.UserID].add(order, date)
+	}
+	return byID, histories
+}
+
+// scoreChurn scores the known users among histories, riskiest first; the
+// days are counted back from the newest of their orders and, if active
+// holds the day of their last session, of those.
+func scoreChurn(byID map[int]User, histories map[int]*customerHistory, active map[int]time.Time) []ChurnRisk {
+	var newest time.Time
+	for id, history := range histories {
+		if _, known := byID[id]; known && history.orders > 0 {
+			newest = latest(newest, history.dates[len(history.dates)-1], active[id])
+		}
+	}
+
+	risks := []ChurnRisk{}
+	for id, history := range histories {
+		user, known := byID[id]
+		if !known || history.orders == 0 {
+			continue
+		}
+		last := history.dates[len(history.dates)-1]
+		days := newest.Sub(last).Hours() / 24
+		idle := newest.Sub(latest(last, active[id])).Hours() / 24
+
+		risk := ChurnRisk{
+			UserID:           id,
+			Name:             user.Name,
+			LastOrder:        last.Format(taxDateLayout),
+			DaysSinceOrder:   int(days),
+			Orders:           history.orders,
+			FrequencyDecline: roundRatio(frequencyDecline(history.dates, days)),
+			PremiumLapsed:    history.hadPremium && !user.Premium,
+		}
+		if seen, ok := active[id]; ok {
+			risk.LastActive = seen.Format(taxDateLayout)
+		}
+		z := churnBias + churnRecencyWeight*min(idle/churnHorizonDays, 1) + churnDeclineWeight*risk.FrequencyDecline
+		if risk.PremiumLapsed {
+			z += churnLapseWeight
+		}
+		risk.Probability = roundRatio(1 / (1 + math.Exp(-z)))
+		risk.Risk = churnLevel(risk.Probability)
+		risks = append(risks, risk)
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].Probability != risks[j].Probability {
+			return risks[i].Probability > risks[j].Probability
+		}
+		return risks[i].UserID < risks[j].UserID
+	})
+	return risks
+}
+
+// latest is the latest of times.
+func latest(times ...time.Time) time.Time {
+	var newest time.Time
+	for _, at := range times {
+		if at.After(newest) {
+			newest = at
+		}
+	}
+	return newest
+}
+
+// FilterChurnRisk keeps the customers with at least minProbability and, if
+// risk is set, at that level.
+func FilterChurnRisk(risks []ChurnRisk, minProbability float64, risk string) []ChurnRisk {
+	filtered := []ChurnRisk{}
+	for _, entry := range risks {
+		if entry.Probability >= minProbability && (risk == "" || entry.Risk == risk) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestScoreChurnRisk tests the recency, decline and premium lapse signals
+func TestScoreChurnRisk(t *testing.T) {
+	users := []User{
+		{ID: 1, Name: "Recent", Premium: true},
+		{ID: 2, Name: "Lapsed"},
+		{ID: 3, Name: "Slowing"},
+		{ID: 4, Name: "Gone"},
+		{ID: 5, Name: "Never ordered"},
+	}
+	order := func(userID int, date string) Order {
+		return Order{UserID: userID, OrderDate: date, Status: "delivered"}
+	}
+	lapsed := order(2, "2024-06-01")
+	lapsed.Discounts = []DiscountLine{{Source: DiscountPremium, Amount: 5}}
+	orders := []Order{
+		order(1, "2024-06-30"),
+		lapsed,
+		order(3, "2024-01-01"), order(3, "2024-01-11"), order(3, "2024-01-21"), order(3, "2024-03-01"),
+		order(4, "2023-01-01"),
+		{UserID: 1, OrderDate: "2024-07-15", Status: "cancelled"},
+	}
+
+	risks := ScoreChurnRisk(users, orders)
+	var ids []int
+	for _, risk := range risks {
+		ids = append(ids, risk.UserID)
+	}
+	if !slices.Equal(ids, []int{3, 4, 2, 1}) {
+		t.Fatalf("Expected customers riskiest first, got %v", ids)
+	}
+	want := map[int]struct {
+		probability float64
+		risk        string
+	}{
+		3: {0.8441, ChurnHigh}, // 121 days, and gaps grew from 10 days
+		4: {0.7311, ChurnHigh}, // past the horizon
+		2: {0.2983, ChurnLow},  // 29 days, lost premium
+		1: {0.0474, ChurnLow},  // ordered on the last day
+	}
+	for _, risk := range risks {
+		if w := want[risk.UserID]; risk.Probability != w.probability || risk.Risk != w.risk {
+			t.Errorf("User %d: expected %v %s, got %v %s", risk.UserID, w.probability, w.risk, risk.Probability, risk.Risk)
+		}
+	}
+	if slowing := risks[0]; slowing.DaysSinceOrder != 121 || slowing.Orders != 4 || slowing.FrequencyDecline != 1 || slowing.LastOrder != "2024-03-01" {
+		t.Errorf("Unexpected signals for user 3: %+v", slowing)
+	}
+	if !risks[2].PremiumLapsed || risks[3].PremiumLapsed {
+		t.Errorf("Expected only user 2 to have lapsed, got %+v", risks)
+	}
+
+	if got := FilterChurnRisk(risks, 0.5, ""); len(got) != 2 {
+		t.Errorf("Expected 2 customers at 0.5 or more, got %+v", got)
+	}
+	if got := FilterChurnRisk(risks, 0, ChurnLow); len(got) != 2 || got[0].UserID != 2 {
+		t.Errorf("Expected the low-risk customers, got %+v", got)
+	}
+	if got := ScoreChurnRisk(users, nil); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list without orders, got %#v", got)
+	}
+
+	analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{})
+	if analytics.AverageChurnProbability != 0.4802 || analytics.HighChurnRisk != 2 {
+		t.Errorf("Expected churn in the analytics, got %v and %d", analytics.AverageChurnProbability, analytics.HighChurnRisk)
+	}
+}
+
+// TestFrequencyDecline tests the gap comparison
+func TestFrequencyDecline(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2024, 1, 1+n, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name  string
+		dates []time.Time
+		open  float64
+		want  float64
+	}{
+		{"one order", []time.Time{day(0)}, 100, 0},
+		{"open gap as usual", []time.Time{day(0), day(10)}, 10, 0},
+		{"open gap twice as long", []time.Time{day(0), day(10)}, 20, 0.5},
+		{"open gap three times as long", []time.Time{day(0), day(10)}, 30, 1},
+		{"latest closed gap longer", []time.Time{day(0), day(10), day(30)}, 0, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frequencyDecline(tt.dates, tt.open); got != tt.want {
+				t.Errorf("frequencyDecline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Shared customer lifetime value - CalculateCLV projects the profit a
+// customer brings over the coming months: their average order value times
+// how often they order, times the margin kept on a sale, with each month
+// discounted back to today at the annual discount rate. How often is the
+// customer's orders over their tenure, from joining (or the first order, if
+// earlier) to the newest order in the history. The margin and discount rate
+// are CLVSettings, set on the server with -clv-margin and -clv-discount-rate
+// and in the browser with setCLVSettingsWasm.
+
+// DefaultCLVHorizonMonths is the horizon AnalyzeUserBehavior and the API
+// project over unless asked for another.
+const DefaultCLVHorizonMonths = 12
+
+// maxCLVHorizonMonths is the longest horizon the API and page project
+// over.
+const maxCLVHorizonMonths = 120
+
+// daysPerMonth is the average length of a month.
+const daysPerMonth = 365.25 / 12
+
+// CLVSettings are the margin kept on a sale and the annual rate future
+// profit is discounted at, both fractions.
+type CLVSettings struct {
+	Margin       float64 `json:"margin"`
+	DiscountRate float64 `json:"discount_rate"`
+}
+
+// DefaultCLVSettings keep 30% of a sale and discount at 10% a year.
+var DefaultCLVSettings = CLVSettings{Margin: 0.3, DiscountRate: 0.1}
+
+var (
+	clvSettingsMu sync.RWMutex
+	clvSettings   = DefaultCLVSettings
+)
+
+// CustomerValue is a customer's projected lifetime value and what it was
+// projected from. MonthlyOrders is the customer's order rate; values are in
+// DefaultCurrency.
+type CustomerValue struct {
+	UserID            int     `json:"user_id"`
+	Name              string  `json:"name"`
+	Orders            int     `json:"orders"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	MonthlyOrders     float64 `json:"monthly_orders"`
+	HorizonMonths     int     `json:"horizon_months"`
+	CLV               float64 `json:"clv"`
+}
+
+// ValidateCLVSettings checks the margin is from 0 to 1 and the discount rate
+// from 0 to 1.
+func ValidateCLVSettings(settings CLVSettings) ValidationResult {
+	result := newValidationResult()
+	if !(settings.Margin >= 0 && settings.Margin <= 1) {
+		result.AddError("margin", CodeOutOfRange, "Margin must be between 0 and 1")
+	}
+	if !(settings.DiscountRate >= 0 && settings.DiscountRate <= 1) {
+		result.AddError("discount_rate", CodeOutOfRange, "Discount rate must be between 0 and 1")
+	}
+	return result
+}
+
+// ParseCLVSettings reads CLV settings from JSON; settings left out keep
+// their defaults.
+func ParseCLVSettings(data []byte) (CLVSettings, error) {
+	settings := DefaultCLVSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return CLVSettings{}, fmt.Errorf("invalid CLV settings JSON: %w", err)
+	}
+	if result := ValidateCLVSettings(settings); !result.Valid {
+		return CLVSettings{}, fmt.Errorf("invalid CLV settings: %s", result.Errors[0])
+	}
+	return settings, nil
+}
+
+// SetCLVSettings replaces the settings CalculateCLV projects with.
+func SetCLVSettings(settings CLVSettings) {
+	clvSettingsMu.Lock()
+	clvSettings = settings
+	clvSettingsMu.Unlock()
+}
+
+// CurrentCLVSettings returns the settings in use.
+func CurrentCLVSettings() CLVSettings {
+	clvSettingsMu.RLock()
+	defer clvSettingsMu.RUnlock()
+	return clvSettings
+}
+
+// customerHistory is what CLV and churn need of one customer's orders:
+// their count and spend, their dates in order and whether any had the
+// premium member discount. Cancelled and undated orders are left out.
+type customerHistory struct {
+	orders     int
+	spend      float64
+	dates      []time.Time
+	hadPremium bool
+}
+
+// add counts one order, placed on date.
+func (history *customerHistory) add(order Order, date time.Time) {
+	history.orders++
+	history.spend += riskAmount(order)
+	at, _ := slices.BinarySearchFunc(history.dates, date, time.Time.Compare)
+	history.dates = slices.Insert(history.dates, at, date)
+	for _, line := range order.Discounts {
+		if line.Source == DiscountPremium {
+			history.hadPremium = true
+		}
+	}
+}
+
+// CalculateCLV projects a user's value over horizonMonths from an order
+// history, which may hold other users' orders too. Cancelled and undated
+// orders are left out; a user without orders is worth nothing.
+func CalculateCLV(user User, orders []Order, horizonMonths int) CustomerValue {
+	var history customerHistory
+	var newest time.Time
+	for _, order := range orders {
+		date, err := time.Parse(taxDateLayout, order.OrderDate)
+		if err != nil || order.Status == "cancelled" {
+			continue
+		}
+		if date.After(newest) {
+			newest = date
+		}
+		if order.UserID == user.ID {
+			history.add(order, date)
+		}
+	}
+	return history.clv(user, newest, horizonMonths)
+}
+
+// clv projects the customer's value over horizonMonths, their tenure ending
+// at newest, the newest order in the whole history.
+func (history *customerHistory) clv(user User, newest time.Time, horizonMonths int) CustomerValue {
+	value := CustomerValue{UserID: user.ID, Name: user.Name, HorizonMonths: horizonMonths, Orders: history.orders}
+	if value.Orders == 0 {
+		return value
+	}
+
+	first := history.dates[0]
+	if joined, err := time.Parse(taxDateLayout, user.JoinDate); err == nil && joined.Before(first) {
+		first = joined
+	}
+	tenure := max(newest.Sub(first).Hours()/24/daysPerMonth, 1)
+	averageOrder := history.spend / float64(value.Orders)
+	perMonth := float64(value.Orders) / tenure
+	value.AverageOrderValue = RoundToCurrency(averageOrder, DefaultCurrency)
+	value.MonthlyOrders = roundRatio(perMonth)
+
+	settings := CurrentCLVSettings()
+	monthly := averageOrder * perMonth * settings.Margin
+	clv := 0.0
+	for month := 1; month <= horizonMonths; month++ {
+		clv += monthly / math.Pow(1+settings.DiscountRate, float64(month)/12)
+	}
+	value.CLV = RoundToCurrency(clv, DefaultCurrency)
+	return value
+}
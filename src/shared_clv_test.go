@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+// withCLVSettings restores the CLV settings after a test.
+func withCLVSettings(t *testing.T) {
+	t.Helper()
+	previous := CurrentCLVSettings()
+	t.Cleanup(func() { SetCLVSettings(previous) })
+}
+
+// TestCalculateCLV tests the projection and its settings
+func TestCalculateCLV(t *testing.T) {
+	withCLVSettings(t)
+	SetCLVSettings(DefaultCLVSettings)
+	user := User{ID: 1, Name: "Ann", JoinDate: "2024-01-01"}
+	orders := []Order{
+		{UserID: 1, OrderDate: "2024-01-01", Total: 100, Status: "delivered"},
+		{UserID: 1, OrderDate: "2024-04-01", Total: 200, Status: "delivered"},
+		{UserID: 1, OrderDate: "2024-05-01", Total: 1000, Status: "cancelled"},
+		{UserID: 2, OrderDate: "2024-07-01", Total: 50, Status: "delivered"},
+	}
+
+	// Two orders over six months of tenure, to the newest order
+	got := CalculateCLV(user, orders, 12)
+	if got.Orders != 2 || got.AverageOrderValue != 150 || got.MonthlyOrders != 0.3345 || got.HorizonMonths != 12 {
+		t.Errorf("Unexpected inputs %+v", got)
+	}
+	if got.CLV != 171.59 {
+		t.Errorf("Expected a 12-month CLV of 171.59, got %v", got.CLV)
+	}
+	if got := CalculateCLV(user, orders, 24); got.CLV != 327.59 {
+		t.Errorf("Expected a 24-month CLV of 327.59, got %v", got.CLV)
+	}
+
+	SetCLVSettings(CLVSettings{Margin: 0.5})
+	if got := CalculateCLV(user, orders, 12); got.CLV != 301.03 {
+		t.Errorf("Expected undiscounted CLV of 301.03 at a 50%% margin, got %v", got.CLV)
+	}
+
+	if got := CalculateCLV(User{ID: 3}, orders, 12); got.CLV != 0 || got.Orders != 0 {
+		t.Errorf("Expected no value without orders, got %+v", got)
+	}
+	// A single order on the newest day counts a month of tenure
+	if got := CalculateCLV(User{ID: 2}, orders, 12); got.MonthlyOrders != 1 {
+		t.Errorf("Expected a month of tenure at least, got %+v", got)
+	}
+
+	SetCLVSettings(DefaultCLVSettings)
+	analytics := AnalyzeUserBehavior([]User{user, {ID: 2}}, orders, AnalyticsFilter{})
+	if want := 171.59 + CalculateCLV(User{ID: 2}, orders, 12).CLV; analytics.TotalCLV != RoundToCurrency(want, DefaultCurrency) || analytics.AverageCLV != RoundToCurrency(want/2, DefaultCurrency) {
+		t.Errorf("Expected the CLV in the analytics, got %v and %v", analytics.TotalCLV, analytics.AverageCLV)
+	}
+}
+
+// TestParseCLVSettings tests defaults and bounds
+func TestParseCLVSettings(t *testing.T) {
+	settings, err := ParseCLVSettings([]byte(`{"margin": 0.4}`))
+	if err != nil || settings.Margin != 0.4 || settings.DiscountRate != DefaultCLVSettings.DiscountRate {
+		t.Errorf("Expected the discount rate to default, got %+v, %v", settings, err)
+	}
+	for _, body := range []string{`{"margin": 1.5}`, `{"discount_rate": -0.1}`, `not json`} {
+		if _, err := ParseCLVSettings([]byte(body)); err == nil {
+			t.Errorf("Expected %s to be rejected", body)
+		}
+	}
+}
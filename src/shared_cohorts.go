@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Shared cohort preferences - cold-start recommendations for users with an
+// empty cart. BuildCohortPreferences counts what each demographic cohort
+// (age band, country and premium status) bought across the order history.
+// Without a cart to go on, RecommendProducts then prefers the top category
+// of the user's cohort and boosts the cohort's best sellers. A cohort with
+// fewer than minCohortOrders orders is too small to go by, so the lookup
+// widens: first without the country, then by age band alone, then the whole
+// history. Without any history the age band's default category is used. The
+// preferences are installed alongside the similarity matrix.
+
+// minCohortOrders is how many orders a cohort needs to be used.
+const minCohortOrders = 2
+
+// allCohorts is the key of the whole order history.
+const allCohorts = "*"
+
+// CohortStats are the purchases of one cohort: orders, units per category
+// and units per product.
+type CohortStats struct {
+	Orders     int            `json:"orders"`
+	Categories map[string]int `json:"categories"`
+	Products   map[int]int    `json:"products"`
+}
+
+// CohortPreferences are the stats of every cohort seen, keyed as cohortKeys
+// lists them.
+type CohortPreferences struct {
+	Built   string                 `json:"built,omitempty"` // RFC 3339
+	Cohorts map[string]CohortStats `json:"cohorts"`
+}
+
+var (
+	cohortPreferencesMu sync.RWMutex
+	cohortPreferences   CohortPreferences
+)
+
+// ageBand names the age band of a user's cohort.
+func ageBand(age int) string {
+	switch {
+	case age < 25:
+		return "under-25"
+	case age < 40:
+		return "25-39"
+	case age < 60:
+		return "40-59"
+	default:
+		return "60-plus"
+	}
+}
+
+// cohortKeys are the keys of the cohorts a user belongs to, narrowest first
+// ("25-39/US/premium", "25-39/premium", "25-39", "*").
+func cohortKeys(user User) []string {
+	band := ageBand(user.Age)
+	tier := "standard"
+	if user.Premium {
+		tier = "premium"
+	}
+	return []string{
+		band + "/" + strings.ToUpper(user.Country) + "/" + tier,
+		band + "/" + tier,
+		band,
+		allCohorts,
+	}
+}
+
+// BuildCohortPreferences counts the purchases of each cohort in an order
+// history. Cancelled orders are left out, and orders of unknown users count
+// for the whole history only.
+func BuildCohortPreferences(users []User, orders []Order) CohortPreferences {
+	byID := map[int]User{}
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	preferences := CohortPreferences{Cohorts: map[string]CohortStats{}}
+	for _, order := range orders {
+		if order.Status == "cancelled" || len(order.Products) == 0 {
+			continue
+		}
+		keys := []string{allCohorts}
+		if user, ok := byID[order.UserID]; ok {
+			keys = cohortKeys(user)
+		}
+		for _, key := range keys {
+			stats, ok := preferences.Cohorts[key]
+			if !ok {
+				stats = CohortStats{Categories: map[string]int{}, Products: map[int]int{}}
+			}
+			stats.Orders++
+			for i, product := range order.Products {
+				quantity := 1
+				if i < len(order.Quantities) {
+					quantity = order.Quantities[i]
+				}
+				stats.Categories[strings.ToLower(product.Category)] += quantity
+				stats.Products[product.ID] += quantity
+			}
+			preferences.Cohorts[key] = stats
+		}
+	}
+	return preferences
+}
+
+// ParseCohortPreferences reads cohort preferences from JSON, as served by
+// /api/recommendations/cohorts.
+func ParseCohortPreferences(data []byte) (CohortPreferences, error) {
+	var preferences CohortPreferences
+	if err := json.Unmarshal(data, &preferences); err != nil {
+		return CohortPreferences{}, fmt.Errorf("invalid cohort preferences JSON: %w", err)
+	}
+	for key, stats := range preferences.Cohorts {
+		if stats.Orders < 0 {
+			return CohortPreferences{}, fmt.Errorf("cohort %q must not have negative orders", key)
+		}
+	}
+	return preferences, nil
+}
+
+// SetCohortPreferences replaces the preferences cold-start recommendations
+// use; empty preferences fall back to the age band defaults.
+func SetCohortPreferences(preferences CohortPreferences) {
+	cohortPreferencesMu.Lock()
+	cohortPreferences = preferences
+	cohortPreferencesMu.Unlock()
+}
+
+// CurrentCohortPreferences returns the preferences in use.
+func CurrentCohortPreferences() CohortPreferences {
+	cohortPreferencesMu.RLock()
+	defer cohortPreferencesMu.RUnlock()
+	return cohortPreferences
+}
+
+// userCohort finds the narrowest cohort of a user with enough orders, or
+// the whole history if it has any.
+func userCohort(user User) (CohortStats, bool) {
+	cohortPreferencesMu.RLock()
+	defer cohortPreferencesMu.RUnlock()
+	for _, key := range cohortKeys(user) {
+		stats, ok := cohortPreferences.Cohorts[key]
+		if ok && (stats.Orders >= minCohortOrders || key == allCohorts && stats.Orders > 0) {
+			return stats, true
+		}
+	}
+	return CohortStats{}, false
+}
+
+// topCategory is the category a cohort bought most of, ties going to the
+// first alphabetically.
+func (stats CohortStats) topCategory() string {
+	top := ""
+	for category, units := range stats.Categories {
+		if top == "" || units > stats.Categories[top] || units == stats.Categories[top] && category < top {
+			top = category
+		}
+	}
+	return top
+}
+
+// productShare is a product's units as a share of the cohort's best seller.
+func (stats CohortStats) productShare(productID int) float64 {
+	top := 0
+	for _, units := range stats.Products {
+		top = max(top, units)
+	}
+	if top == 0 {
+		return 0
+	}
+	return float64(stats.Products[productID]) / float64(top)
+}
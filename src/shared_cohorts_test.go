@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+// withCohortPreferences restores the cohort preferences after the test.
+func withCohortPreferences(t *testing.T) {
+	t.Helper()
+	previous := CurrentCohortPreferences()
+	t.Cleanup(func() { SetCohortPreferences(previous) })
+}
+
+// TestBuildCohortPreferences tests that orders count for every cohort of
+// their user
+func TestBuildCohortPreferences(t *testing.T) {
+	users := []User{
+		{ID: 1, Age: 30, Country: "us", Premium: true},
+		{ID: 2, Age: 35, Country: "GB", Premium: true},
+		{ID: 3, Age: 65, Country: "US"},
+	}
+	order := func(userID int, status string, products ...Product) Order {
+		order := Order{UserID: userID, Status: status, Products: products}
+		for range products {
+			order.Quantities = append(order.Quantities, 1)
+		}
+		return order
+	}
+	book := Product{ID: 3, Category: "books"}
+	shoes := Product{ID: 5, Category: "Sports"}
+	preferences := BuildCohortPreferences(users, []Order{
+		order(1, "delivered", book, shoes),
+		order(2, "shipped", shoes),
+		order(3, "delivered", book),
+		order(9, "delivered", book), // unknown user
+		order(1, "cancelled", book),
+	})
+
+	for key, want := range map[string]int{"25-39/US/premium": 1, "25-39/GB/premium": 1, "25-39/premium": 2, "25-39": 2, "60-plus/US/standard": 1, allCohorts: 4} {
+		if got := preferences.Cohorts[key].Orders; got != want {
+			t.Errorf("Cohort %s: expected %d orders, got %d", key, want, got)
+		}
+	}
+	if got := preferences.Cohorts["25-39/premium"].Categories["sports"]; got != 2 {
+		t.Errorf("Expected categories to be lower-cased and counted, got %d", got)
+	}
+}
+
+// TestColdStartRecommendations tests that an empty cart goes by the user's
+// cohort, widening when it is too small
+func TestColdStartRecommendations(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+
+	user := User{ID: 1, Age: 30, Country: "US", Premium: true}
+	if got := inferUserPreference(user, Order{}); got != "clothing" {
+		t.Errorf("Expected the age band default without cohorts, got %q", got)
+	}
+
+	// Premium 25-39s buy sports gear; the US cohort alone is too small
+	SetCohortPreferences(CohortPreferences{Cohorts: map[string]CohortStats{
+		"25-39/US/premium": {Orders: 1, Categories: map[string]int{"books": 1}, Products: map[int]int{3: 1}},
+		"25-39/premium":    {Orders: 3, Categories: map[string]int{"sports": 4, "books": 1}, Products: map[int]int{3: 1, 5: 4}},
+		allCohorts:         {Orders: 5, Categories: map[string]int{"home": 5}, Products: map[int]int{4: 5}},
+	}})
+	if got := inferUserPreference(user, Order{}); got != "sports" {
+		t.Errorf("Expected the widened cohort's top category, got %q", got)
+	}
+	if got := inferUserPreference(User{Age: 70}, Order{}); got != "home" {
+		t.Errorf("Expected the whole history for a cohort never seen, got %q", got)
+	}
+
+	catalog := []Product{
+		{ID: 3, Name: "Book", Price: 40, Category: "books", OnHand: 5, Rating: 4},
+		{ID: 4, Name: "Mug", Price: 40, Category: "home", OnHand: 5, Rating: 4},
+		{ID: 5, Name: "Shoes", Price: 40, Category: "sports", OnHand: 5, Rating: 4},
+	}
+	got := ExplainRecommendations(user, catalog, Order{}, Wishlist{}, RecommendOptions{Weights: DefaultRecommendationWeights})
+	if got[0].ID != 5 || got[0].Reasons[0].Reason != "popular category with customers like you" {
+		t.Errorf("Expected the cohort's best seller first, got %+v", got)
+	}
+
+	// A cart wins over the cohort
+	cart := Order{Products: []Product{catalog[0]}, Quantities: []int{1}}
+	if got := ExplainRecommendations(user, catalog, cart, Wishlist{}, RecommendOptions{Weights: DefaultRecommendationWeights}); got[0].ID != 3 {
+		t.Errorf("Expected the cart's category first, got %+v", got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Shared collaborative filtering - products bought together are likely to be
+// bought together again. BuildItemSimilarity turns order history into an
+// item-item similarity matrix: each product is the set of orders it was
+// bought in, and two products are as similar as the cosine of those sets,
+// co-purchases / sqrt(orders of one * orders of the other). Building is an
+// offline step (POST /api/recommendations/rebuild on the server); once a
+// matrix is installed RecommendProducts adds the similarity of each product
+// to the order's products to its score. Pages load the server's matrix with
+// setItemSimilarityWasm so both sides recommend alike.
+
+// maxSimilarItems bounds how many neighbours the matrix keeps per product.
+const maxSimilarItems = 20
+
+// ItemSimilarity is an item-item similarity matrix: Scores[a][b] is the
+// similarity of products a and b, from 0 to 1, for the products bought
+// together at least once.
+type ItemSimilarity struct {
+	Built  string                  `json:"built,omitempty"` // RFC 3339
+	Orders int                     `json:"orders"`          // order history the matrix was built from
+	Scores map[int]map[int]float64 `json:"scores"`
+}
+
+var (
+	itemSimilarityMu sync.RWMutex
+	itemSimilarity   ItemSimilarity
+)
+
+// BuildItemSimilarity builds the similarity matrix of an order history.
+// Cancelled orders are left out, and a product counts once per order
+// whatever the quantity.
+func BuildItemSimilarity(orders []Order) ItemSimilarity {
+	bought := map[int]int{}
+	together := map[[2]int]int{}
+	used := 0
+	for _, order := range orders {
+		if order.Status == "cancelled" {
+			continue
+		}
+		var ids []int
+		for _, product := range order.Products {
+			if !slices.Contains(ids, product.ID) {
+				ids = append(ids, product.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		used++
+		for i, a := range ids {
+			bought[a]++
+			for _, b := range ids[i+1:] {
+				together[[2]int{min(a, b), max(a, b)}]++
+			}
+		}
+	}
+
+	similarity := ItemSimilarity{Orders: used, Scores: map[int]map[int]float64{}}
+	for pair, count := range together {
+		score := float64(count) / math.Sqrt(float64(bought[pair[0]]*bought[pair[1]]))
+		score = math.Round(score*1e6) / 1e6
+		for _, edge := range [][2]int{pair, {pair[1], pair[0]}} {
+			if similarity.Scores[edge[0]] == nil {
+				similarity.Scores[edge[0]] = map[int]float64{}
+			}
+			similarity.Scores[edge[0]][edge[1]] = score
+		}
+	}
+	for id, neighbours := range similarity.Scores {
+		similarity.Scores[id] = nearestItems(neighbours)
+	}
+	return similarity
+}
+
+// nearestItems keeps the maxSimilarItems most similar neighbours, ties
+// going to the lower ID.
+func nearestItems(neighbours map[int]float64) map[int]float64 {
+	if len(neighbours) <= maxSimilarItems {
+		return neighbours
+	}
+	ids := make([]int, 0, len(neighbours))
+	for id := range neighbours {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if neighbours[ids[i]] != neighbours[ids[j]] {
+			return neighbours[ids[i]] > neighbours[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	kept := map[int]float64{}
+	for _, id := range ids[:maxSimilarItems] {
+		kept[id] = neighbours[id]
+	}
+	return kept
+}
+
+// ParseItemSimilarity reads a similarity matrix from JSON, as served by
+// /api/recommendations/similarity.
+func ParseItemSimilarity(data []byte) (ItemSimilarity, error) {
+	var similarity ItemSimilarity
+	if err := json.Unmarshal(data, &similarity); err != nil {
+		return ItemSimilarity{}, fmt.Errorf("invalid similarity JSON: %w", err)
+	}
+	for a, neighbours := range similarity.Scores {
+		for b, score := range neighbours {
+			if score < 0 || score > 1 || math.IsNaN(score) {
+				return ItemSimilarity{}, fmt.Errorf("similarity of %d and %d must be between 0 and 1", a, b)
+			}
+		}
+	}
+	return similarity, nil
+}
+
+// SetItemSimilarity replaces the matrix RecommendProducts blends in; an
+// empty matrix turns collaborative filtering off.
+func SetItemSimilarity(similarity ItemSimilarity) {
+	similarity.Scores = cloneScores(similarity.Scores)
+	itemSimilarityMu.Lock()
+	itemSimilarity = similarity
+	itemSimilarityMu.Unlock()
+}
+
+// CurrentItemSimilarity returns a copy of the matrix in use.
+func CurrentItemSimilarity() ItemSimilarity {
+	itemSimilarityMu.RLock()
+	defer itemSimilarityMu.RUnlock()
+	similarity := itemSimilarity
+	similarity.Scores = cloneScores(similarity.Scores)
+	return similarity
+}
+
+func cloneScores(scores map[int]map[int]float64) map[int]map[int]float64 {
+	cloned := make(map[int]map[int]float64, len(scores))
+	for id, neighbours := range scores {
+		cloned[id] = maps.Clone(neighbours)
+	}
+	return cloned
+}
+
+// collaborativeScore is how similar a product is to the products of an
+// order, summed over them; 0 without a matrix.
+func collaborativeScore(productID int, order Order) float64 {
+	itemSimilarityMu.RLock()
+	defer itemSimilarityMu.RUnlock()
+	score := 0.0
+	for _, item := range order.Products {
+		if item.ID != productID {
+			score += itemSimilarity.Scores[item.ID][productID]
+		}
+	}
+	return score
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// withItemSimilarity restores the similarity matrix after the test.
+func withItemSimilarity(t *testing.T) {
+	t.Helper()
+	previous := CurrentItemSimilarity()
+	t.Cleanup(func() { SetItemSimilarity(previous) })
+}
+
+// TestBuildItemSimilarity tests cosine similarity over co-purchases
+func TestBuildItemSimilarity(t *testing.T) {
+	order := func(status string, ids ...int) Order {
+		order := Order{Status: status}
+		for _, id := range ids {
+			order.Products = append(order.Products, Product{ID: id})
+		}
+		return order
+	}
+	similarity := BuildItemSimilarity([]Order{
+		order("delivered", 1, 2),
+		order("delivered", 1, 2, 2),
+		order("shipped", 1, 3),
+		order("pending", 2),
+		order("cancelled", 1, 3),
+	})
+
+	if similarity.Orders != 4 {
+		t.Errorf("Expected cancelled orders to be left out, got %d orders", similarity.Orders)
+	}
+	// 1 is in 3 orders and 2 in 3, together in 2: 2/sqrt(9)
+	if got := similarity.Scores[1][2]; math.Abs(got-2.0/3) > 1e-6 || similarity.Scores[2][1] != got {
+		t.Errorf("Expected a symmetric similarity of 2/3, got %v and %v", got, similarity.Scores[2][1])
+	}
+	// 1 is in 3 orders and 3 in 1, together in 1: 1/sqrt(3)
+	if got := similarity.Scores[1][3]; math.Abs(got-1/math.Sqrt(3)) > 1e-6 {
+		t.Errorf("Expected a similarity of 1/sqrt(3), got %v", got)
+	}
+	if _, ok := similarity.Scores[2][3]; ok {
+		t.Error("Expected no similarity for products never bought together")
+	}
+}
+
+// TestRecommendProductsCollaborative tests that history lifts products
+// bought with the order's products, and changes nothing without a matrix
+func TestRecommendProductsCollaborative(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+
+	user := User{Age: 30, Country: "US"}
+	catalog := []Product{
+		{ID: 1, Name: "Tent", Price: 100, Category: "sports", OnHand: 5, Rating: 4},
+		{ID: 2, Name: "Novel", Price: 100, Category: "books", OnHand: 5, Rating: 4.5},
+		{ID: 3, Name: "Lantern", Price: 100, Category: "home", OnHand: 5, Rating: 4},
+	}
+	order := Order{Products: []Product{catalog[0]}, Quantities: []int{1}}
+	if got := RecommendProducts(user, catalog, order); len(got) < 2 || got[1].ID != 2 {
+		t.Fatalf("Expected the better rated novel second without history, got %+v", got)
+	}
+
+	SetItemSimilarity(BuildItemSimilarity([]Order{
+		{Products: []Product{{ID: 1}, {ID: 3}}},
+		{Products: []Product{{ID: 1}, {ID: 3}}},
+	}))
+	if got := RecommendProducts(user, catalog, order); len(got) != 3 || got[2].ID != 2 {
+		t.Errorf("Expected the lantern bought with tents to pass the novel, got %+v", got)
+	}
+
+	if _, err := ParseItemSimilarity([]byte(`{"scores": {"1": {"2": 1.5}}}`)); err == nil {
+		t.Error("Expected a similarity above 1 to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Shared optimistic concurrency - users, products and orders carry a Version
+// that goes up by one with every change to them. An update names the version
+// it was made against and is refused when the record has changed since, so
+// two clients editing the same record can't silently overwrite each other:
+// the one that loses refetches the record and tries again. The server's
+// store checks the versions of its records, and ApplyVersionedPatch does the
+// same for records a page keeps itself.
+
+// Version errors
+var (
+	ErrVersionConflict = errors.New("version conflict")
+	ErrVersionRequired = errors.New("version required")
+)
+
+// VersionConflictError is an update made against a version other than the
+// record's current one. It matches ErrVersionConflict.
+type VersionConflictError struct {
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version %d is out of date; the current version is %d", e.Expected, e.Current)
+}
+
+func (e *VersionConflictError) Is(target error) bool { return target == ErrVersionConflict }
+
+// CheckVersion returns a *VersionConflictError unless expected is the
+// current version.
+func CheckVersion(current, expected int) error {
+	if expected != current {
+		return &VersionConflictError{Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// ApplyVersionedPatch applies a JSON merge patch (RFC 7386) to the JSON of a
+// user, product or order (SchemaUser, SchemaProduct or SchemaOrder) and
+// returns the patched record at the next version. The patch must carry the
+// record's current "version": without one it fails with ErrVersionRequired,
+// and with another with a *VersionConflictError. Patched users and products
+// must still validate, and the ID can't be changed.
+func ApplyVersionedPatch(model, recordJSON, patchJSON string) (string, error) {
+	if model != SchemaUser && model != SchemaProduct && model != SchemaOrder {
+		return "", fmt.Errorf("unknown model %q (expected %s, %s or %s)", model, SchemaUser, SchemaProduct, SchemaOrder)
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal([]byte(patchJSON), &patch); err != nil {
+		return "", jsonDecodeError{err}
+	}
+	if patch == nil {
+		return "", jsonDecodeError{errors.New("patch must be a JSON object")}
+	}
+	raw, ok := patch["version"]
+	if !ok {
+		return "", fmt.Errorf("%w: the patch must name the version it was made against", ErrVersionRequired)
+	}
+	expected, ok := raw.(float64)
+	if !ok || expected != math.Trunc(expected) {
+		return "", fmt.Errorf("invalid version %v", raw)
+	}
+	delete(patch, "version")
+
+	data, err := MigrateJSON(model, []byte(recordJSON))
+	if err != nil {
+		return "", jsonDecodeError{err}
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return "", jsonDecodeError{fmt.Errorf("%s must be a JSON object", model)}
+	}
+	current, _ := doc["version"].(float64)
+	if err := CheckVersion(int(current), int(expected)); err != nil {
+		return "", err
+	}
+	if id, ok := patch["id"]; ok && id != doc["id"] {
+		return "", fmt.Errorf("the %s ID can't be changed", model)
+	}
+	mergePatch(doc, patch)
+	doc["version"] = current + 1
+	if data, err = json.Marshal(doc); err != nil {
+		return "", err
+	}
+
+	switch model {
+	case SchemaUser:
+		user, err := UserFromJSON(string(data))
+		if err != nil {
+			return "", err
+		}
+		if result := ValidateUser(user); !result.Valid {
+			return "", fmt.Errorf("invalid user: %s", strings.Join(result.Errors, "; "))
+		}
+		return UserToJSON(user)
+	case SchemaProduct:
+		product, err := ProductFromJSON(string(data))
+		if err != nil {
+			return "", err
+		}
+		if result := ValidateProduct(product); !result.Valid {
+			return "", fmt.Errorf("invalid product: %s", strings.Join(result.Errors, "; "))
+		}
+		return ProductToJSON(product)
+	default:
+		order, err := OrderFromJSON(string(data))
+		if err != nil {
+			return "", err
+		}
+		return OrderToJSON(order)
+	}
+}
+
+// mergePatch merges a JSON merge patch into target: nulls remove members,
+// objects are merged member by member and anything else replaces the
+// member.
+func mergePatch(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]interface{}:
+			member, _ := target[key].(map[string]interface{})
+			if member == nil {
+				member = map[string]interface{}{}
+			}
+			mergePatch(member, value)
+			target[key] = member
+		default:
+			target[key] = value
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	if err := CheckVersion(3, 3); err != nil {
+		t.Errorf("Expected the current version to pass, got %v", err)
+	}
+	err := CheckVersion(4, 3)
+	var conflict *VersionConflictError
+	if !errors.Is(err, ErrVersionConflict) || !errors.As(err, &conflict) || conflict.Current != 4 || conflict.Expected != 3 {
+		t.Errorf("Expected a conflict with the current version, got %v", err)
+	}
+	if ErrorCode(err) != "version_conflict" {
+		t.Errorf("Expected the version_conflict code, got %q", ErrorCode(err))
+	}
+}
+
+func TestApplyVersionedPatch(t *testing.T) {
+	user := `{"id": 7, "name": "Ann Lee", "email": "ann@example.com", "age": 30, "country": "DE", "join_date": "2024-01-02", "email_verified": true, "address": {"street": "Hauptstr. 1", "city": "Berlin", "postal_code": "10115", "country": "DE"}, "version": 2}`
+
+	patched, err := ApplyVersionedPatch(SchemaUser, user, `{"version": 2, "name": "Ann Smith", "email_verified": null, "address": {"city": "Hamburg", "postal_code": "20095"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UserFromJSON(patched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Ann Smith" || got.Email != "ann@example.com" || got.EmailVerified || got.Version != 3 {
+		t.Errorf("Expected the name changed and the verification removed at version 3, got %+v", got)
+	}
+	if got.Address == nil || got.Address.City != "Hamburg" || got.Address.Street != "Hauptstr. 1" || got.Address.PostalCode != "20095" {
+		t.Errorf("Expected the address merged member by member, got %+v", got.Address)
+	}
+
+	// The patched record can't be patched from the old version again
+	_, err = ApplyVersionedPatch(SchemaUser, patched, `{"version": 2, "age": 31}`)
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Current != 3 {
+		t.Errorf("Expected a conflict at version 3, got %v", err)
+	}
+
+	for name, test := range map[string]struct {
+		model, record, patch string
+		want                 string
+	}{
+		"no version":    {SchemaUser, user, `{"age": 31}`, "version required"},
+		"bad version":   {SchemaUser, user, `{"version": "2", "age": 31}`, "invalid version"},
+		"id change":     {SchemaUser, user, `{"version": 2, "id": 8}`, "can't be changed"},
+		"invalid user":  {SchemaUser, user, `{"version": 2, "email": "nope"}`, "invalid user"},
+		"not an object": {SchemaUser, user, `null`, "JSON object"},
+		"unknown model": {"cart", user, `{"version": 2}`, "unknown model"},
+		"bad product":   {SchemaProduct, `{"id": 1, "name": "Mug", "price": 9.99, "category": "home", "version": 1}`, `{"version": 1, "price": -1}`, "invalid product"},
+	} {
+		if _, err := ApplyVersionedPatch(test.model, test.record, test.patch); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected an error with %q, got %v", name, test.want, err)
+		}
+	}
+	if _, err := ApplyVersionedPatch(SchemaUser, user, `{"age": 31}`); ErrorCode(err) != "version_required" {
+		t.Errorf("Expected the version_required code, got %q", ErrorCode(err))
+	}
+
+	// Orders aren't validated, only decoded
+	order, err := ApplyVersionedPatch(SchemaOrder, `{"id": 1, "user_id": 1, "status": "pending", "version": 1}`, `{"version": 1, "status": "shipped"}`)
+	if err != nil || !strings.Contains(order, `"status":"shipped"`) || !strings.Contains(order, `"version":2`) {
+		t.Errorf("Expected the order shipped at version 2, got %s (%v)", order, err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shared country data - every ISO 3166-1 country, embedded from
+// countries.csv with its alpha-3 code, name, region, currency and standard
+// VAT or sales tax rate (blank where the dataset has none). The tax engine
+// and shipping fall back to these for countries without rules of their own.
+//
+// The demo's tables use UK, the code ISO 3166 reserves for the United
+// Kingdom, rather than GB; both are accepted and lead to the same rules.
+
+//go:embed countries.csv
+var countriesCSV string
+
+// Country is one ISO 3166-1 country.
+type Country struct {
+	Code     string `json:"code"` // alpha-2
+	Alpha3   string `json:"alpha3"`
+	Name     string `json:"name"`
+	Region   string `json:"region"`             // continent
+	Currency string `json:"currency,omitempty"` // ISO 4217
+	// TaxRate is the standard VAT or sales tax rate, if known
+	TaxRate *float64 `json:"tax_rate,omitempty"`
+}
+
+// regionZoneRates are the standard shipping rates, in USD, to destinations
+// without a rate in shippingZoneRates.
+var regionZoneRates = map[string]float64{
+	"Americas": 13.99,
+	"Europe":   15.99,
+	"Asia":     18.99,
+	"Oceania":  19.99,
+	"Africa":   21.99,
+}
+
+var (
+	countryList    []Country
+	countriesByKey map[string]Country // by alpha-2 and alpha-3 code
+)
+
+func init() {
+	countryList, countriesByKey = mustParseCountries(countriesCSV)
+}
+
+// mustParseCountries reads the embedded dataset, which is part of the
+// source, so a malformed row is a bug.
+func mustParseCountries(data string) ([]Country, map[string]Country) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic("countries.csv: " + err.Error())
+	}
+	list := make([]Country, 0, len(records))
+	byKey := make(map[string]Country, 2*len(records))
+	for i, record := range records[1:] {
+		country := Country{Code: record[0], Alpha3: record[1], Name: record[2], Region: record[3], Currency: record[4]}
+		if record[5] != "" {
+			rate, err := strconv.ParseFloat(record[5], 64)
+			if err != nil {
+				panic(fmt.Sprintf("countries.csv row %d: %v", i+2, err))
+			}
+			country.TaxRate = &rate
+		}
+		list = append(list, country)
+		byKey[country.Code] = country
+		byKey[country.Alpha3] = country
+	}
+	return list, byKey
+}
+
+// LookupCountry finds a country by alpha-2 or alpha-3 code, ignoring case.
+// UK finds the United Kingdom.
+func LookupCountry(code string) (Country, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "UK" {
+		code = "GB"
+	}
+	country, ok := countriesByKey[code]
+	return country, ok
+}
+
+// Countries lists the countries by code, only those of a region when region
+// is not empty.
+func Countries(region string) []Country {
+	countries := []Country{}
+	for _, country := range countryList {
+		if region == "" || strings.EqualFold(country.Region, region) {
+			countries = append(countries, country)
+		}
+	}
+	sort.Slice(countries, func(i, j int) bool { return countries[i].Code < countries[j].Code })
+	return countries
+}
+
+// canonicalCountry is the code the demo's tables list a country under: its
+// alpha-2 code, with UK for the United Kingdom. Unknown codes are only
+// upper-cased.
+func canonicalCountry(code string) string {
+	country, ok := LookupCountry(code)
+	if !ok {
+		return strings.ToUpper(strings.TrimSpace(code))
+	}
+	if country.Code == "GB" {
+		return "UK"
+	}
+	return country.Code
+}
+
+// countryTaxRate is the tax rate of a country without tax rules: its
+// standard rate from the dataset, else defaultTaxRate.
+func countryTaxRate(country string) float64 {
+	if c, ok := LookupCountry(country); ok && c.TaxRate != nil {
+		return *c.TaxRate
+	}
+	return defaultTaxRate
+}
+
+// zoneRate is the standard shipping rate to a country: its own, else that of
+// its region, else defaultZoneRate.
+func zoneRate(country string) float64 {
+	if rate, ok := shippingZoneRates[canonicalCountry(country)]; ok {
+		return rate
+	}
+	if c, ok := LookupCountry(country); ok {
+		if rate, ok := regionZoneRates[c.Region]; ok {
+			return rate
+		}
+	}
+	return defaultZoneRate
+}
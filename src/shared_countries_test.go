@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLookupCountry tests the embedded ISO 3166 dataset
+func TestLookupCountry(t *testing.T) {
+	if len(Countries("")) != 249 {
+		t.Errorf("Expected all 249 ISO 3166-1 countries, got %d", len(Countries("")))
+	}
+	for _, code := range []string{"es", "ESP", " Es "} {
+		if country, ok := LookupCountry(code); !ok || country.Code != "ES" || country.Name != "Spain" || country.Currency != "EUR" {
+			t.Errorf("LookupCountry(%q) = %+v, %v", code, country, ok)
+		}
+	}
+	if country, ok := LookupCountry("UK"); !ok || country.Code != "GB" {
+		t.Errorf("Expected UK to find the United Kingdom, got %+v", country)
+	}
+	if _, ok := LookupCountry("XX"); ok {
+		t.Error("Expected XX to be unknown")
+	}
+	for _, country := range Countries("oceania") {
+		if country.Region != "Oceania" {
+			t.Errorf("Expected only Oceania, got %+v", country)
+		}
+	}
+	if canonicalCountry("gbr") != "UK" || canonicalCountry("deu") != "DE" || canonicalCountry("zz") != "ZZ" {
+		t.Error("Expected codes mapped to the demo tables' codes")
+	}
+}
+
+// TestCountryDefaults tests tax and shipping for countries without rules
+func TestCountryDefaults(t *testing.T) {
+	date := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		country  string
+		tax      float64
+		shipping float64
+	}{
+		{"ES", 0.21, 15.99},           // dataset rate, Europe zone
+		{"GB", 0.20, 15.99},           // the UK's own rules
+		{"NZ", 0.15, 19.99},           // Oceania
+		{"KW", 0, 18.99},              // no VAT
+		{"AF", defaultTaxRate, 18.99}, // no known rate
+		{"AQ", defaultTaxRate, defaultZoneRate},
+		{"ZZ", defaultTaxRate, defaultZoneRate},
+	}
+	for _, tt := range tests {
+		if got := TaxRateFor(tt.country, "", "", date); got != tt.tax {
+			t.Errorf("TaxRateFor(%s) = %v, want %v", tt.country, got, tt.tax)
+		}
+		if got := CalculateShipping(20, tt.country, false); got != tt.shipping {
+			t.Errorf("CalculateShipping(%s) = %v, want %v", tt.country, got, tt.shipping)
+		}
+	}
+	if TaxRateFor("GB", "", "books", date) != 0 {
+		t.Error("Expected GB to share the UK's books exemption")
+	}
+
+	if !ValidateUser(User{Email: "ana@example.es", Name: "Ana", Age: 30, Country: "ES"}).Valid {
+		t.Error("Expected a Spanish user to be valid")
+	}
+	if !ValidateAddress(Address{Street: "Calle Mayor 1", City: "Madrid", PostalCode: "28013", Country: "ES"}).Valid {
+		t.Error("Expected an address in a country without a postal code format to be valid")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Shared cross-sells and upsells - two narrower recommendation modes than
+// RecommendProducts, which guesses at what a user might like. Cross-sells
+// are accessories for what is already in an order: products a cart item
+// names in Accessories, or from a category that goes with its category,
+// and cheaper than the item. Upsells are better alternatives to one
+// product: the same category, rated no worse, at a higher price but not too
+// far above it. Neither suggests products that are out of stock.
+
+// maxSuggestions is how many products each mode suggests.
+const maxSuggestions = 5
+
+// maxUpsellRatio bounds an upsell's price as a multiple of the product's.
+const maxUpsellRatio = 2.5
+
+// complementaryCategories are the categories whose products go with a
+// category's.
+var complementaryCategories = map[string][]string{
+	"electronics": {"electronics"},
+	"clothing":    {"clothing", "sports"},
+	"sports":      {"clothing", "sports"},
+	"books":       {"books", "home"},
+	"home":        {"books", "home"},
+}
+
+// scoredProduct is a candidate suggestion.
+type scoredProduct struct {
+	product Product
+	score   float64
+}
+
+// RecommendCrossSells suggests accessories for the products in an order.
+func RecommendCrossSells(order Order, catalog []Product) []Product {
+	inOrder := map[int]bool{}
+	for _, product := range order.Products {
+		inOrder[product.ID] = true
+	}
+
+	var candidates []scoredProduct
+	for _, candidate := range catalog {
+		if inOrder[candidate.ID] || !candidate.InStock() {
+			continue
+		}
+		score := 0.0
+		for _, item := range order.Products {
+			related := 0.0
+			switch {
+			case slices.Contains(item.Accessories, candidate.ID):
+				related = 3 // named by the item
+			case slices.ContainsFunc(complementaryCategories[strings.ToLower(item.Category)], func(category string) bool { return strings.EqualFold(category, candidate.Category) }):
+				related = 1
+			default:
+				continue
+			}
+			// Accessories cost less than what they go with
+			if candidate.Price < item.Price {
+				related += 1
+			} else {
+				related /= 2
+			}
+			score = max(score, related)
+		}
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, scoredProduct{product: candidate, score: score + candidate.Rating*0.2})
+	}
+	return topSuggestions(candidates)
+}
+
+// RecommendUpsells suggests higher-tier alternatives to a product.
+func RecommendUpsells(product Product, catalog []Product) []Product {
+	var candidates []scoredProduct
+	for _, candidate := range catalog {
+		if candidate.ID == product.ID || !candidate.InStock() || !strings.EqualFold(candidate.Category, product.Category) {
+			continue
+		}
+		if candidate.Price <= product.Price || candidate.Price > product.Price*maxUpsellRatio || candidate.Rating < product.Rating {
+			continue
+		}
+		// A better rating counts for more, a bigger step up in price for
+		// less
+		ratio := candidate.Price / product.Price
+		score := 1 + (candidate.Rating-product.Rating)*2 - (ratio-1)/(maxUpsellRatio-1)
+		candidates = append(candidates, scoredProduct{product: candidate, score: score})
+	}
+	return topSuggestions(candidates)
+}
+
+// topSuggestions are the best scored candidates, ties going to the lower ID.
+func topSuggestions(candidates []scoredProduct) []Product {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].product.ID < candidates[j].product.ID
+	})
+	suggestions := []Product{}
+	for _, candidate := range candidates {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, candidate.product)
+	}
+	return suggestions
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+// suggestionIDs lists the IDs of suggested products.
+func suggestionIDs(products []Product) []int {
+	ids := []int{}
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+// TestRecommendCrossSells tests named accessories ahead of complementary
+// categories, and that ordered or unavailable products are left out
+func TestRecommendCrossSells(t *testing.T) {
+	camera := Product{ID: 1, Name: "Camera", Price: 400, Category: "electronics", OnHand: 5, Rating: 4.5, Accessories: []int{3}}
+	catalog := []Product{
+		camera,
+		{ID: 2, Name: "Memory Card", Price: 20, Category: "electronics", OnHand: 50, Rating: 4.8},
+		{ID: 3, Name: "Camera Bag", Price: 45, Category: "clothing", OnHand: 10, Rating: 3.9},
+		{ID: 4, Name: "Tripod", Price: 60, Category: "electronics", OnHand: 0, Rating: 4.9},
+		{ID: 5, Name: "Television", Price: 900, Category: "electronics", OnHand: 3, Rating: 4.9},
+		{ID: 6, Name: "Novel", Price: 15, Category: "books", OnHand: 20, Rating: 5},
+	}
+
+	got := suggestionIDs(RecommendCrossSells(Order{Products: []Product{camera}, Quantities: []int{1}}, catalog))
+	want := []int{3, 2, 5}
+	if len(got) != len(want) {
+		t.Fatalf("RecommendCrossSells() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("RecommendCrossSells() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+// TestRecommendUpsells tests that upsells are dearer, rated no worse and
+// within reach of the product's price
+func TestRecommendUpsells(t *testing.T) {
+	product := Product{ID: 1, Name: "Basic Headphones", Price: 50, Category: "electronics", OnHand: 5, Rating: 4.0}
+	catalog := []Product{
+		product,
+		{ID: 2, Name: "Studio Headphones", Price: 110, Category: "electronics", OnHand: 5, Rating: 4.6},
+		{ID: 3, Name: "Better Headphones", Price: 70, Category: "electronics", OnHand: 5, Rating: 4.2},
+		{ID: 4, Name: "Worse Headphones", Price: 80, Category: "electronics", OnHand: 5, Rating: 3.5},
+		{ID: 5, Name: "Flagship Headphones", Price: 400, Category: "electronics", OnHand: 5, Rating: 4.9},
+		{ID: 6, Name: "Cheaper Headphones", Price: 30, Category: "electronics", OnHand: 5, Rating: 4.5},
+		{ID: 7, Name: "Sold Out Headphones", Price: 90, Category: "electronics", OnHand: 0, Rating: 4.8},
+		{ID: 8, Name: "Speaker", Price: 90, Category: "home", OnHand: 5, Rating: 4.8},
+	}
+
+	got := suggestionIDs(RecommendUpsells(product, catalog))
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("RecommendUpsells() = %v, want [2 3]", got)
+	}
+}
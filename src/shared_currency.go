@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shared currency table - products and orders carry an ISO 4217 code, and
+// amounts are rounded and formatted with that currency's symbol and minor
+// units on both the server and the client. An empty code means USD, so data
+// written before currencies existed keeps its meaning.
+
+// DefaultCurrency is the currency of products and orders without one.
+const DefaultCurrency = "USD"
+
+// Currency describes how amounts in one currency are written.
+type Currency struct {
+	Code     string `json:"code"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+// currencies lists the supported currencies, one for each demo country.
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", Symbol: "$", Decimals: 2},
+	"CAD": {Code: "CAD", Symbol: "CA$", Decimals: 2},
+	"GBP": {Code: "GBP", Symbol: "£", Decimals: 2},
+	"EUR": {Code: "EUR", Symbol: "€", Decimals: 2},
+	"JPY": {Code: "JPY", Symbol: "¥", Decimals: 0},
+	"AUD": {Code: "AUD", Symbol: "A$", Decimals: 2},
+	"INR": {Code: "INR", Symbol: "₹", Decimals: 2},
+	"BRL": {Code: "BRL", Symbol: "R$", Decimals: 2},
+	"MXN": {Code: "MXN", Symbol: "MX$", Decimals: 2},
+}
+
+// LookupCurrency finds a currency by code, ignoring case. An empty code is
+// the default currency.
+func LookupCurrency(code string) (Currency, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		code = DefaultCurrency
+	}
+	currency, ok := currencies[code]
+	return currency, ok
+}
+
+// currencyFor is LookupCurrency falling back to the default currency.
+func currencyFor(code string) Currency {
+	if currency, ok := LookupCurrency(code); ok {
+		return currency
+	}
+	return currencies[DefaultCurrency]
+}
+
+// RoundToCurrency rounds an amount half up to the minor units of a
+// currency.
+func RoundToCurrency(amount float64, code string) float64 {
+	return NewMoney(amount, code).Float64()
+}
+
+// OrderCurrency is the currency an order is priced in: its own, else that of
+// its first product, else the default.
+func OrderCurrency(order Order) string {
+	code := order.Currency
+	if code == "" && len(order.Products) > 0 {
+		code = order.Products[0].Currency
+	}
+	return currencyFor(code).Code
+}
+
+// CheckOrderCurrency reports an order whose currency is unknown or whose
+// products are priced in a different currency, which CalculateOrderTotal
+// cannot add up.
+func CheckOrderCurrency(order Order) error {
+	if _, ok := LookupCurrency(order.Currency); !ok {
+		return fmt.Errorf("unsupported currency %q", order.Currency)
+	}
+	want := OrderCurrency(order)
+	for _, product := range order.Products {
+		currency, ok := LookupCurrency(product.Currency)
+		if !ok {
+			return fmt.Errorf("%s has unsupported currency %q", product.Name, product.Currency)
+		}
+		if currency.Code != want {
+			return fmt.Errorf("%s is priced in %s, not %s", product.Name, currency.Code, want)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// TestFormatCurrencyCodes tests symbols and minor units per currency
+func TestFormatCurrencyCodes(t *testing.T) {
+	tests := []struct {
+		amount float64
+		code   string
+		want   string
+	}{
+		{1234.567, "USD", "$1234.57"},
+		{19.5, "eur", "€19.50"},
+		{1500.4, "JPY", "¥1500"},
+		{-5, "GBP", "-£5.00"},
+		{10, "XYZ", "$10.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatCurrency(tt.amount, tt.code); got != tt.want {
+			t.Errorf("FormatCurrency(%v, %q) = %q, want %q", tt.amount, tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestCheckOrderCurrency tests that mixed and unknown currencies are rejected
+func TestCheckOrderCurrency(t *testing.T) {
+	eur := Product{Name: "Mug", Price: 8, Currency: "EUR"}
+	usd := Product{Name: "Pen", Price: 2}
+
+	if err := CheckOrderCurrency(Order{Products: []Product{eur, eur}}); err != nil {
+		t.Errorf("Expected a single-currency order to pass, got %v", err)
+	}
+	if err := CheckOrderCurrency(Order{Products: []Product{eur, usd}}); err == nil {
+		t.Error("Expected products in different currencies to be rejected")
+	}
+	if err := CheckOrderCurrency(Order{Products: []Product{usd}, Currency: "EUR"}); err == nil {
+		t.Error("Expected products priced outside the order currency to be rejected")
+	}
+	if err := CheckOrderCurrency(Order{Products: []Product{usd}, Currency: "ABC"}); err == nil {
+		t.Error("Expected an unknown order currency to be rejected")
+	}
+}
+
+// TestCalculateOrderTotalCurrency tests rounding to the order currency
+func TestCalculateOrderTotalCurrency(t *testing.T) {
+	order := Order{
+		Products:   []Product{{Name: "Tea", Price: 333.3, Currency: "JPY"}},
+		Quantities: []int{3},
+	}
+	CalculateOrderTotal(&order, User{Country: "JP"})
+
+	if order.Currency != "JPY" {
+		t.Fatalf("Expected the order to take its products' currency, got %q", order.Currency)
+	}
+	for name, amount := range map[string]float64{"subtotal": order.Subtotal, "tax": order.Tax, "total": order.Total} {
+		if amount != float64(int64(amount)) {
+			t.Errorf("Expected %s in whole yen, got %v", name, amount)
+		}
+	}
+	if order.Total != order.Subtotal-order.Discount+order.Tax+order.Shipping {
+		t.Errorf("Expected the total to be the sum of the rounded parts, got %+v", order)
+	}
+
+	usd := Order{Products: []Product{{Price: 10}}, Quantities: []int{1}}
+	CalculateOrderTotal(&usd, User{Country: "US"})
+	if usd.Currency != DefaultCurrency {
+		t.Errorf("Expected products without a currency to be priced in %s, got %q", DefaultCurrency, usd.Currency)
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Shared data cache - the users, products and orders a page has fetched,
+// kept so the validation and recommendation demos keep working while the
+// page is offline. Users and products can be edited in the cache as the
+// server would edit them (ApplyVersionedPatch); each edit is kept as a
+// merge patch on the version the server last sent. When the page is back
+// online it sends the patches to POST /api/data/sync, which applies them in
+// order or reports a conflict when the record changed on the server in the
+// meantime. A conflicting record keeps its edits until the page resolves it
+// by taking the server's record or replaying its edits on top of it. Orders
+// are cached to read only: they change through checkout and status updates.
+
+// dataCacheKey is where a DataCache keeps its records in its storage.
+const dataCacheKey = "go-wasm-demo.data-cache"
+
+// dataCacheModels are the models a DataCache holds, in the order it syncs
+// them.
+var dataCacheModels = []string{SchemaUser, SchemaProduct, SchemaOrder}
+
+// errCachedOrder is returned for edits of orders in the cache.
+var errCachedOrder = errors.New("orders can't be changed offline - they change through checkout and status updates")
+
+// CacheStorage is where a DataCache keeps its records between page loads.
+// Its methods are those of the Web Storage API, so the WASM build can hand
+// it localStorage.
+type CacheStorage interface {
+	GetItem(key string) (string, bool)
+	SetItem(key, value string) error
+}
+
+// cacheEntry is one cached record. Patches are the edits made since the
+// server sent the record; Server is the server's record when a sync found
+// it had changed since.
+type cacheEntry struct {
+	Record  json.RawMessage   `json:"record"`
+	Patches []json.RawMessage `json:"patches,omitempty"`
+	Server  json.RawMessage   `json:"server,omitempty"`
+}
+
+// DataCache holds fetched records by model and ID. It isn't safe for
+// concurrent use; the WASM module has a single thread to use it from.
+type DataCache struct {
+	storage CacheStorage
+	records map[string]map[int]*cacheEntry
+}
+
+// Sync outcomes
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict" // the record changed on the server
+	SyncRejected = "rejected" // the server refused the edits
+)
+
+// SyncChange is the edits of one record, each a merge patch naming the
+// version it was made against.
+type SyncChange struct {
+	Model   string            `json:"model"`
+	ID      int               `json:"id"`
+	Patches []json.RawMessage `json:"patches"`
+}
+
+// SyncRequest is the body of POST /api/data/sync.
+type SyncRequest struct {
+	Changes []SyncChange `json:"changes"`
+}
+
+// SyncResult is what the server made of one change. Record is the server's
+// record after the edits, or as it stands when they were refused.
+type SyncResult struct {
+	Model  string          `json:"model"`
+	ID     int             `json:"id"`
+	Status string          `json:"status"`
+	Error  string          `json:"error,omitempty"`
+	Record json.RawMessage `json:"record,omitempty"`
+	// Applied counts the patches applied
+	Applied int `json:"applied,omitempty"`
+}
+
+// SyncResponse is the answer to POST /api/data/sync, a result per change.
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+}
+
+// SyncSummary is what ApplySync made of a sync's results.
+type SyncSummary struct {
+	Applied   int          `json:"applied"`
+	Conflicts []SyncResult `json:"conflicts"`
+	Rejected  []SyncResult `json:"rejected"`
+}
+
+// recordKey is the ID and version of a record's JSON.
+type recordKey struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+func decodeRecordKey(record []byte) (recordKey, error) {
+	var key recordKey
+	if err := json.Unmarshal(record, &key); err != nil {
+		return recordKey{}, jsonDecodeError{err}
+	}
+	return key, nil
+}
+
+// ApplyPatches applies versioned merge patches to a record in order, as
+// ApplyVersionedPatch does, so each must name the version the one before
+// it left.
+func ApplyPatches(model, recordJSON string, patches []json.RawMessage) (string, error) {
+	for _, patch := range patches {
+		patched, err := ApplyVersionedPatch(model, recordJSON, string(patch))
+		if err != nil {
+			return "", err
+		}
+		recordJSON = patched
+	}
+	return recordJSON, nil
+}
+
+// NewDataCache returns the cache kept in storage, empty the first time. A
+// nil storage keeps the cache in memory only.
+func NewDataCache(storage CacheStorage) (*DataCache, error) {
+	cache := &DataCache{storage: storage, records: map[string]map[int]*cacheEntry{}}
+	if storage != nil {
+		if saved, ok := storage.GetItem(dataCacheKey); ok && saved != "" {
+			if err := json.Unmarshal([]byte(saved), &cache.records); err != nil {
+				return nil, fmt.Errorf("stored data cache is corrupt: %w", err)
+			}
+		}
+	}
+	for _, model := range dataCacheModels {
+		if cache.records[model] == nil {
+			cache.records[model] = map[int]*cacheEntry{}
+		}
+	}
+	return cache, nil
+}
+
+// save writes the cache to its storage.
+func (c *DataCache) save() error {
+	if c.storage == nil {
+		return nil
+	}
+	data, err := json.Marshal(c.records)
+	if err != nil {
+		return err
+	}
+	return c.storage.SetItem(dataCacheKey, string(data))
+}
+
+// entries returns the cache's records of a model.
+func (c *DataCache) entries(model string) (map[int]*cacheEntry, error) {
+	entries, ok := c.records[model]
+	if !ok {
+		return nil, fmt.Errorf("unknown model %q (expected %s, %s or %s)", model, SchemaUser, SchemaProduct, SchemaOrder)
+	}
+	return entries, nil
+}
+
+// Store caches records of a model fetched from the server, a JSON array, and
+// returns how many it stored. Records with edits not yet synced keep them;
+// a sync tells whether the server's record changed underneath.
+func (c *DataCache) Store(model, recordsJSON string) (int, error) {
+	entries, err := c.entries(model)
+	if err != nil {
+		return 0, err
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal([]byte(recordsJSON), &records); err != nil {
+		return 0, jsonDecodeError{err}
+	}
+	stored := 0
+	for _, record := range records {
+		migrated, err := MigrateJSON(model, record)
+		if err != nil {
+			return stored, jsonDecodeError{err}
+		}
+		key, err := decodeRecordKey(migrated)
+		if err != nil {
+			return stored, err
+		}
+		if entry, ok := entries[key.ID]; ok && len(entry.Patches) > 0 {
+			continue
+		}
+		entries[key.ID] = &cacheEntry{Record: migrated}
+		stored++
+	}
+	return stored, c.save()
+}
+
+// Records returns the cached records of a model by ID, with their edits.
+func (c *DataCache) Records(model string) ([]json.RawMessage, error) {
+	entries, err := c.entries(model)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	records := make([]json.RawMessage, len(ids))
+	for i, id := range ids {
+		records[i] = entries[id].Record
+	}
+	return records, nil
+}
+
+// Update edits a cached user or product with a merge patch naming the
+// record's version in the cache, and returns the edited record.
+func (c *DataCache) Update(model string, id int, patchJSON string) (string, error) {
+	if model == SchemaOrder {
+		return "", errCachedOrder
+	}
+	entries, err := c.entries(model)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := entries[id]
+	if !ok {
+		return "", fmt.Errorf("%s %d is not cached", model, id)
+	}
+	patched, err := ApplyVersionedPatch(model, string(entry.Record), patchJSON)
+	if err != nil {
+		return "", err
+	}
+	entry.Patches = append(entry.Patches, json.RawMessage(patchJSON))
+	entry.Record = json.RawMessage(patched)
+	return patched, c.save()
+}
+
+// Pending returns the edits to sync. Records in conflict are left out until
+// they are resolved.
+func (c *DataCache) Pending() SyncRequest {
+	request := SyncRequest{Changes: []SyncChange{}}
+	for _, model := range dataCacheModels {
+		entries := c.records[model]
+		ids := make([]int, 0, len(entries))
+		for id, entry := range entries {
+			if len(entry.Patches) > 0 && entry.Server == nil {
+				ids = append(ids, id)
+			}
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			request.Changes = append(request.Changes, SyncChange{Model: model, ID: id, Patches: entries[id].Patches})
+		}
+	}
+	return request
+}
+
+// ApplySync takes in the server's answer to a sync. Applied records become
+// the server's, keeping edits made since the sync was sent; conflicting
+// ones keep their edits and the server's record to resolve against; and
+// rejected ones drop their edits for the server's record.
+func (c *DataCache) ApplySync(response SyncResponse) (SyncSummary, error) {
+	summary := SyncSummary{Conflicts: []SyncResult{}, Rejected: []SyncResult{}}
+	for _, result := range response.Results {
+		entries, err := c.entries(result.Model)
+		if err != nil {
+			return summary, err
+		}
+		entry, ok := entries[result.ID]
+		if !ok {
+			continue
+		}
+		switch result.Status {
+		case SyncApplied:
+			later := entry.Patches[min(result.Applied, len(entry.Patches)):]
+			if err := entry.rebase(result.Model, result.Record, later); err != nil {
+				return summary, err
+			}
+			summary.Applied++
+		case SyncConflict:
+			entry.Server = result.Record
+			summary.Conflicts = append(summary.Conflicts, result)
+		default:
+			if result.Record != nil {
+				entry.Record = result.Record
+			}
+			entry.Patches, entry.Server = nil, nil
+			summary.Rejected = append(summary.Rejected, result)
+		}
+	}
+	return summary, c.save()
+}
+
+// Resolve settles a record in conflict: keepLocal replays its edits on the
+// server's record, to be synced again, and otherwise the server's record
+// replaces them. It returns the resolved record.
+func (c *DataCache) Resolve(model string, id int, keepLocal bool) (string, error) {
+	entries, err := c.entries(model)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := entries[id]
+	if !ok || entry.Server == nil {
+		return "", fmt.Errorf("%s %d is not in conflict", model, id)
+	}
+	var patches []json.RawMessage
+	if keepLocal {
+		patches = entry.Patches
+	}
+	if err := entry.rebase(model, entry.Server, patches); err != nil {
+		return "", err
+	}
+	return string(entry.Record), c.save()
+}
+
+// rebase makes server the record the entry's edits are on and replays
+// patches on it, renumbered from the server's version.
+func (e *cacheEntry) rebase(model string, server json.RawMessage, patches []json.RawMessage) error {
+	key, err := decodeRecordKey(server)
+	if err != nil {
+		return err
+	}
+	renumbered := make([]json.RawMessage, len(patches))
+	for i, patch := range patches {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(patch, &doc); err != nil {
+			return jsonDecodeError{err}
+		}
+		doc["version"] = key.Version + i
+		if renumbered[i], err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	record, err := ApplyPatches(model, string(server), renumbered)
+	if err != nil {
+		return err
+	}
+	e.Record, e.Patches, e.Server = json.RawMessage(record), renumbered, nil
+	return nil
+}
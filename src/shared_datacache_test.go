@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// mapStorage is a CacheStorage in a map, standing in for localStorage
+type mapStorage map[string]string
+
+func (s mapStorage) GetItem(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+func (s mapStorage) SetItem(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+// cachedUsers is the JSON of the test users at version 1, as the server
+// sends them
+func cachedUsers(t *testing.T) string {
+	t.Helper()
+	users := append([]User(nil), testUsers[0], testUsers[2])
+	for i := range users {
+		users[i].ID, users[i].Version = i+1, 1
+	}
+	data, err := json.Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func cachedUser(t *testing.T, cache *DataCache, id int) User {
+	t.Helper()
+	records, err := cache.Records(SchemaUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if user, _ := UserFromJSON(string(record)); user.ID == id {
+			return user
+		}
+	}
+	t.Fatalf("User %d not cached", id)
+	return User{}
+}
+
+// TestDataCacheEdits tests editing cached records and keeping them across
+// page loads
+func TestDataCacheEdits(t *testing.T) {
+	storage := mapStorage{}
+	cache, err := NewDataCache(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := cache.Store(SchemaUser, cachedUsers(t)); err != nil || stored != 2 {
+		t.Fatalf("Expected both users stored, got %d %v", stored, err)
+	}
+	if _, err := cache.Update(SchemaUser, 1, `{"version": 1, "name": "Johnny Doe"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Update(SchemaUser, 1, `{"version": 2, "premium": true}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Update(SchemaUser, 1, `{"version": 2, "age": 40}`); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("Expected an edit of an old version refused, got %v", err)
+	}
+	if _, err := cache.Update(SchemaUser, 2, `{"version": 1, "email": "not an email"}`); err == nil {
+		t.Error("Expected an invalid edit refused")
+	}
+	if _, err := cache.Update(SchemaOrder, 1, `{"version": 1, "status": "cancelled"}`); err != errCachedOrder {
+		t.Errorf("Expected orders read only, got %v", err)
+	}
+
+	// A new page load finds the edits, and fetching again keeps them
+	reopened, err := NewDataCache(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Store(SchemaUser, cachedUsers(t))
+	if user := cachedUser(t, reopened, 1); user.Name != "Johnny Doe" || !user.Premium || user.Version != 3 {
+		t.Errorf("Expected the edited user at version 3, got %+v", user)
+	}
+	pending := reopened.Pending()
+	if len(pending.Changes) != 1 || pending.Changes[0].ID != 1 || len(pending.Changes[0].Patches) != 2 {
+		t.Errorf("Expected user 1's two edits pending, got %+v", pending)
+	}
+
+	if _, err := NewDataCache(mapStorage{dataCacheKey: "{"}); err == nil {
+		t.Error("Expected a corrupt cache reported")
+	}
+}
+
+// TestDataCacheSync tests taking in the server's answers to a sync
+func TestDataCacheSync(t *testing.T) {
+	cache, _ := NewDataCache(nil)
+	cache.Store(SchemaUser, cachedUsers(t))
+	cache.Update(SchemaUser, 1, `{"version": 1, "name": "Johnny Doe"}`)
+	cache.Update(SchemaUser, 2, `{"version": 1, "age": 41}`)
+	pending := cache.Pending()
+
+	// An edit made while the sync is on its way is kept over the result
+	cache.Update(SchemaUser, 1, `{"version": 2, "premium": true}`)
+
+	server := func(id, version int, change string) json.RawMessage {
+		user := testUsers[0]
+		if id == 2 {
+			user = testUsers[2]
+		}
+		user.ID, user.Version = id, version
+		data, _ := json.Marshal(user)
+		patched, err := ApplyVersionedPatch(SchemaUser, string(data), change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return json.RawMessage(patched)
+	}
+	summary, err := cache.ApplySync(SyncResponse{Results: []SyncResult{
+		{Model: SchemaUser, ID: 1, Status: SyncApplied, Applied: len(pending.Changes[0].Patches), Record: server(1, 1, `{"version": 1, "name": "Johnny Doe"}`)},
+		{Model: SchemaUser, ID: 2, Status: SyncConflict, Record: server(2, 4, `{"version": 4, "country": "CA"}`)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Applied != 1 || len(summary.Conflicts) != 1 || summary.Conflicts[0].ID != 2 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	user := cachedUser(t, cache, 1)
+	if user.Name != "Johnny Doe" || !user.Premium || user.Version != 3 {
+		t.Errorf("Expected the later edit replayed on the synced user, got %+v", user)
+	}
+	pending = cache.Pending()
+	if len(pending.Changes) != 1 || pending.Changes[0].ID != 1 || !strings.Contains(string(pending.Changes[0].Patches[0]), `"version":2`) {
+		t.Errorf("Expected only the later edit pending, on version 2: %+v", pending)
+	}
+
+	// The conflict keeps the page's age and takes the server's country
+	if _, err := cache.Resolve(SchemaUser, 1, true); err == nil {
+		t.Error("Expected resolving a record not in conflict refused")
+	}
+	record, err := cache.Resolve(SchemaUser, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := UserFromJSON(record); user.Age != 41 || user.Country != "CA" || user.Version != 6 {
+		t.Errorf("Expected the edit replayed on the server's user, got %+v", user)
+	}
+	if pending := cache.Pending(); len(pending.Changes) != 2 {
+		t.Errorf("Expected the resolved user pending again, got %+v", pending)
+	}
+
+	summary, _ = cache.ApplySync(SyncResponse{Results: []SyncResult{{Model: SchemaUser, ID: 2, Status: SyncRejected, Error: "invalid user", Record: server(2, 5, `{"version": 5}`)}}})
+	if len(summary.Rejected) != 1 || cachedUser(t, cache, 2).Age == 41 || len(cache.Pending().Changes) != 1 {
+		t.Errorf("Expected the rejected edit dropped for the server's user, got %+v", summary)
+	}
+}
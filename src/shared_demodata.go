@@ -0,0 +1,572 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Shared demo data - the curated users, products and orders the server's
+// store starts with, and GenerateDemoData, which grows them into a dataset
+// of any size for the analytics and recommendation demos. Generation is
+// seeded and uses its own generator, so a seed gives the same records on
+// the server (/api/demo-data) and in the browser (generateDemoDataWasm).
+//
+// Every generated dataset starts with the curated records, so John Doe is
+// still user 1 and the examples in the README work against it. The rest
+// follow the options' country and category weights; each user leans to a
+// favourite category, a few products sell far more than the others and
+// premium users order more often, so the totals, top products and
+// recommendations have something to find. Names, emails, prices, ratings
+// and dates come from the faker (shared_faker.go). Orders are priced with
+// CalculateOrderTotal like checkout's.
+
+// MaxDemoRecords bounds the users, products and orders of one dataset.
+const MaxDemoRecords = 1000000
+
+// The curated records are the first of every dataset, so a dataset has at
+// least these many of each.
+const (
+	curatedDemoUsers    = 5
+	curatedDemoProducts = 8
+	curatedDemoOrders   = 2
+)
+
+// demoDataStart and demoDataEnd bound the generated join and order dates.
+var (
+	demoDataStart = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	demoDataEnd   = time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// DefaultDemoCountries and DefaultDemoCategories are the weights used when
+// the options give none.
+var (
+	DefaultDemoCountries = map[string]float64{
+		"US": 30, "UK": 12, "DE": 10, "CA": 8, "FR": 8, "AU": 6,
+		"IN": 6, "BR": 5, "JP": 5, "MX": 4, "ES": 3, "NL": 3,
+	}
+	DefaultDemoCategories = map[string]float64{
+		"electronics": 25, "clothing": 20, "books": 15, "home": 15,
+		"sports": 12, "toys": 7, "beauty": 6,
+	}
+)
+
+// DemoDataOptions configures GenerateDemoData. Counts include the curated
+// records: zero takes the default (5 users, a product for every 20 users
+// and three orders a user), and smaller counts are raised to the curated
+// ones. Countries and categories are relative weights.
+type DemoDataOptions struct {
+	Users      int                `json:"users"`
+	Products   int                `json:"products"`
+	Orders     int                `json:"orders"`
+	Seed       uint64             `json:"seed"`
+	Countries  map[string]float64 `json:"countries,omitempty"`
+	Categories map[string]float64 `json:"categories,omitempty"`
+}
+
+// DemoData is a generated dataset.
+type DemoData struct {
+	Seed     uint64    `json:"seed"`
+	Users    []User    `json:"users"`
+	Products []Product `json:"products"`
+	Orders   []Order   `json:"orders"`
+}
+
+// withDefaults fills in the counts and weights the options leave out.
+func (opts DemoDataOptions) withDefaults() DemoDataOptions {
+	if opts.Users == 0 {
+		opts.Users = curatedDemoUsers
+	}
+	if opts.Products == 0 {
+		opts.Products = opts.Users / 20
+	}
+	if opts.Orders == 0 {
+		opts.Orders = opts.Users * 3
+	}
+	opts.Users = max(opts.Users, curatedDemoUsers)
+	opts.Products = max(opts.Products, curatedDemoProducts)
+	opts.Orders = max(opts.Orders, curatedDemoOrders)
+	if len(opts.Countries) == 0 {
+		opts.Countries = DefaultDemoCountries
+	}
+	if len(opts.Categories) == 0 {
+		opts.Categories = DefaultDemoCategories
+	}
+	return opts
+}
+
+// Validate checks the counts and weights: counts can't be negative or
+// together exceed MaxDemoRecords, countries must be known, categories ones
+// products may have, and weights not negative with some positive.
+func (opts DemoDataOptions) Validate() error {
+	if opts.Users < 0 || opts.Products < 0 || opts.Orders < 0 {
+		return fmt.Errorf("demo data counts can't be negative")
+	}
+	resolved := opts.withDefaults()
+	if total := resolved.Users + resolved.Products + resolved.Orders; total > MaxDemoRecords {
+		return fmt.Errorf("demo data of %d records exceeds the limit of %d", total, MaxDemoRecords)
+	}
+	for country := range opts.Countries {
+		if _, ok := LookupCountry(country); !ok {
+			return fmt.Errorf("unknown country %q", country)
+		}
+	}
+	for category := range opts.Categories {
+		if _, ok := demoCatalog[category]; !ok {
+			return fmt.Errorf("unknown category %q", category)
+		}
+	}
+	if err := checkDemoWeights("country", opts.Countries); err != nil {
+		return err
+	}
+	return checkDemoWeights("category", opts.Categories)
+}
+
+func checkDemoWeights(kind string, weights map[string]float64) error {
+	total := 0.0
+	for key, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("%s %s has an invalid weight %v", kind, key, weight)
+		}
+		total += weight
+	}
+	if len(weights) > 0 && total == 0 {
+		return fmt.Errorf("%s weights are all zero", kind)
+	}
+	return nil
+}
+
+// demoRand is a splitmix64 generator: small, fast, and the same in every
+// build.
+type demoRand uint64
+
+func (r *demoRand) uint64() uint64 {
+	*r += 0x9e3779b97f4a7c15
+	z := uint64(*r)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// float returns a number in [0, 1).
+func (r *demoRand) float() float64 {
+	return float64(r.uint64()>>11) / (1 << 53)
+}
+
+// intn returns a number in [0, n).
+func (r *demoRand) intn(n int) int {
+	return int(r.uint64() % uint64(n))
+}
+
+// between returns a number in [lo, hi].
+func (r *demoRand) between(lo, hi int) int {
+	return lo + r.intn(hi-lo+1)
+}
+
+func (r *demoRand) chance(p float64) bool {
+	return r.float() < p
+}
+
+func (r *demoRand) pick(values []string) string {
+	return values[r.intn(len(values))]
+}
+
+// demoWeights picks keys, or indexes when it has no keys, in proportion to
+// their weights.
+type demoWeights struct {
+	keys       []string
+	cumulative []float64
+}
+
+// newDemoWeights sorts the keys, so the picks don't depend on map order.
+func newDemoWeights(weights map[string]float64) demoWeights {
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]float64, len(keys))
+	for i, key := range keys {
+		values[i] = weights[key]
+	}
+	w := newIndexWeights(values)
+	w.keys = keys
+	return w
+}
+
+func newIndexWeights(weights []float64) demoWeights {
+	w := demoWeights{cumulative: make([]float64, len(weights))}
+	total := 0.0
+	for i, weight := range weights {
+		total += weight
+		w.cumulative[i] = total
+	}
+	return w
+}
+
+func (w demoWeights) pick(r *demoRand) string {
+	return w.keys[w.index(r)]
+}
+
+func (w demoWeights) index(r *demoRand) int {
+	return w.indexBelow(r, len(w.cumulative))
+}
+
+// indexBelow picks among the first n: it finds the first running total
+// above a random share of theirs, which is never that of a zero weight.
+func (w demoWeights) indexBelow(r *demoRand, n int) int {
+	x := r.float() * w.cumulative[n-1]
+	i := sort.Search(n, func(i int) bool { return w.cumulative[i] > x })
+	return min(i, n-1)
+}
+
+// demoCategory is what products of a category are made from.
+type demoCategory struct {
+	adjectives, nouns, uses []string
+	minPrice, maxPrice      float64
+	minWeight, maxWeight    float64 // kg
+	warehouse               string
+	tiered                  bool   // sold by the dozen, with quantity breaks
+	accessoriesFrom         string // the category of products sold with these
+}
+
+var demoCatalog = map[string]demoCategory{
+	"electronics": {
+		adjectives: []string{"Wireless", "Compact", "Slim", "Portable", "Ultra HD", "Noise-Cancelling", "Rechargeable", "Bluetooth"},
+		nouns:      []string{"Speaker", "Earbuds", "Tablet", "Smartwatch", "Camera", "Keyboard", "Monitor", "Power Bank", "Router", "Drone"},
+		uses:       []string{"for music on the go", "for the home office", "with all-day battery life", "for streaming and gaming"},
+		minPrice:   15, maxPrice: 1500, minWeight: 0.1, maxWeight: 4,
+		accessoriesFrom: "electronics",
+	},
+	"clothing": {
+		adjectives: []string{"Organic Cotton", "Slim-Fit", "Waterproof", "Merino", "Linen", "Denim", "Fleece", "Classic"},
+		nouns:      []string{"T-Shirt", "Jacket", "Hoodie", "Chinos", "Dress", "Sweater", "Shorts", "Shirt", "Scarf", "Socks"},
+		uses:       []string{"for everyday wear", "for cold mornings", "that keeps its shape", "in a relaxed fit"},
+		minPrice:   8, maxPrice: 250, minWeight: 0.1, maxWeight: 1.2,
+		tiered: true, accessoriesFrom: "clothing",
+	},
+	"books": {
+		adjectives: []string{"Complete", "Illustrated", "Practical", "Beginner's", "Advanced", "Pocket", "Essential", "Modern"},
+		nouns:      []string{"Guide to Go", "Cookbook", "Atlas", "Field Guide", "History of Computing", "Novel", "Garden Handbook", "Travel Guide"},
+		uses:       []string{"for curious readers", "with worked examples", "to keep on the shelf", "in a new edition"},
+		minPrice:   6, maxPrice: 90, minWeight: 0.2, maxWeight: 1.8,
+		warehouse: "media", accessoriesFrom: "home",
+	},
+	"home": {
+		adjectives: []string{"Ceramic", "Bamboo", "Stainless Steel", "Hand-Made", "Cast Iron", "Glass", "Oak", "Minimalist"},
+		nouns:      []string{"Mug", "Teapot", "Cutting Board", "Frying Pan", "Lamp", "Vase", "Storage Jar", "Cushion", "Clock", "Bowl Set"},
+		uses:       []string{"for the kitchen", "that brightens any room", "built to last", "for everyday use"},
+		minPrice:   5, maxPrice: 300, minWeight: 0.2, maxWeight: 6,
+		tiered: true, accessoriesFrom: "home",
+	},
+	"sports": {
+		adjectives: []string{"Lightweight", "Pro", "Trail", "Adjustable", "Breathable", "All-Weather", "Foldable", "Training"},
+		nouns:      []string{"Running Shoes", "Yoga Mat", "Dumbbells", "Water Bottle", "Tennis Racket", "Backpack", "Bike Helmet", "Jump Rope"},
+		uses:       []string{"for athletes", "for weekend adventures", "for home workouts", "that goes the distance"},
+		minPrice:   8, maxPrice: 400, minWeight: 0.1, maxWeight: 10,
+		accessoriesFrom: "clothing",
+	},
+	"toys": {
+		adjectives: []string{"Wooden", "Classic", "Educational", "Glow-in-the-Dark", "Remote-Control", "Plush", "Magnetic", "Giant"},
+		nouns:      []string{"Puzzle", "Building Blocks", "Board Game", "Race Car", "Teddy Bear", "Train Set", "Kite", "Science Kit"},
+		uses:       []string{"for ages 3 and up", "for family game night", "that sparks curiosity", "for hours of fun"},
+		minPrice:   5, maxPrice: 150, minWeight: 0.1, maxWeight: 3,
+		accessoriesFrom: "toys",
+	},
+	"beauty": {
+		adjectives: []string{"Hydrating", "Natural", "Fragrance-Free", "Vitamin C", "Soothing", "Travel-Size", "Herbal", "Daily"},
+		nouns:      []string{"Face Cream", "Shampoo", "Lip Balm", "Serum", "Body Lotion", "Sunscreen", "Soap Bar", "Hand Cream"},
+		uses:       []string{"for sensitive skin", "with natural ingredients", "for daily care", "that travels well"},
+		minPrice:   4, maxPrice: 120, minWeight: 0.05, maxWeight: 0.8,
+		tiered: true, accessoriesFrom: "beauty",
+	},
+}
+
+// GenerateDemoData generates a dataset from the options' seed; the same
+// options always give the same records.
+func GenerateDemoData(opts DemoDataOptions) (DemoData, error) {
+	if err := opts.Validate(); err != nil {
+		return DemoData{}, err
+	}
+	opts = opts.withDefaults()
+	rng := demoRand(opts.Seed)
+	fake := newFaker(&rng)
+
+	data := DemoData{
+		Seed:     opts.Seed,
+		Users:    append(make([]User, 0, opts.Users), generateDemoUsers()...),
+		Products: append(make([]Product, 0, opts.Products), generateDemoProducts()...),
+		Orders:   append(make([]Order, 0, opts.Orders), generateDemoOrders()...),
+	}
+	for _, user := range data.Users {
+		fake.emails[user.Email] = true
+	}
+
+	countries := newDemoWeights(opts.Countries)
+	categories := newDemoWeights(opts.Categories)
+	for id := len(data.Users) + 1; id <= opts.Users; id++ {
+		data.Users = append(data.Users, generateDemoUser(fake, id, countries.pick(&rng)))
+	}
+
+	// Products are listed by category with a popularity each, heavy-tailed
+	// so a few sell most
+	byCategory := map[string][]int{} // indexes into data.Products
+	for i, product := range data.Products {
+		byCategory[product.Category] = append(byCategory[product.Category], i)
+	}
+	names := map[string]int{}
+	for id := len(data.Products) + 1; id <= opts.Products; id++ {
+		category := categories.pick(&rng)
+		product := generateDemoProduct(fake, id, category, names)
+		if from := byCategory[demoCatalog[category].accessoriesFrom]; len(from) > 0 && rng.chance(0.2) {
+			product.Accessories = []int{data.Products[from[rng.intn(len(from))]].ID}
+		}
+		byCategory[category] = append(byCategory[category], len(data.Products))
+		data.Products = append(data.Products, product)
+	}
+	type pool struct {
+		indexes []int
+		weights demoWeights
+	}
+	popularity := make([]float64, len(data.Products))
+	for i := range popularity {
+		popularity[i] = math.Pow(rng.float()+0.01, 3)
+	}
+	pools := map[string]pool{}
+	for category, indexes := range byCategory {
+		weights := make([]float64, len(indexes))
+		for j, i := range indexes {
+			weights[j] = popularity[i]
+		}
+		pools[category] = pool{indexes, newIndexWeights(weights)}
+	}
+	// Only the categories that have products can be ordered from
+	stocked := map[string]float64{}
+	for category := range byCategory {
+		stocked[category] = opts.Categories[category] + 0.01
+	}
+	orderedCategories := newDemoWeights(stocked)
+
+	// Orders come in at the faker's rate for the day, from the users who had
+	// joined by then in proportion to an activity of their own, doubled for
+	// premium users. Each user leans to a favourite category.
+	favourites := make([]string, len(data.Users))
+	for i := range data.Users {
+		favourites[i] = orderedCategories.pick(&rng)
+	}
+	byJoinDate := make([]int, len(data.Users)) // indexes into data.Users
+	for i := range byJoinDate {
+		byJoinDate[i] = i
+	}
+	sort.SliceStable(byJoinDate, func(a, b int) bool {
+		return data.Users[byJoinDate[a]].JoinDate < data.Users[byJoinDate[b]].JoinDate
+	})
+	activity := make([]float64, len(data.Users))
+	for i, u := range byJoinDate {
+		activity[i] = 0.2 + rng.float()*rng.float()*3
+		if data.Users[u].Premium {
+			activity[i] *= 2
+		}
+	}
+	customers := newIndexWeights(activity)
+
+	for id := len(data.Orders) + 1; id <= opts.Orders; id++ {
+		var placed time.Time
+		joined := 0 // users who had joined by the day
+		for joined == 0 {
+			placed = fake.orderDate(demoDataStart, demoDataEnd)
+			day := placed.Format(time.DateOnly)
+			joined = sort.Search(len(byJoinDate), func(i int) bool { return data.Users[byJoinDate[i]].JoinDate > day })
+		}
+		customer := byJoinDate[customers.indexBelow(&rng, joined)]
+		user := data.Users[customer]
+
+		lines := 1 + rng.intn(3)
+		if rng.chance(0.1) {
+			lines += rng.intn(3)
+		}
+		order := Order{ID: id, UserID: user.ID}
+		seen := map[int]bool{}
+		// A product picked twice is skipped, so a few orders come out shorter
+		for try := 0; try < 2*lines && len(order.Products) < lines; try++ {
+			category := favourites[customer]
+			if rng.chance(0.4) {
+				category = orderedCategories.pick(&rng)
+			}
+			p := pools[category].indexes[pools[category].weights.index(&rng)]
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			quantity := 1
+			if rng.chance(0.25) {
+				quantity = rng.between(2, 3)
+			}
+			order.Products = append(order.Products, data.Products[p])
+			order.Quantities = append(order.Quantities, quantity)
+		}
+
+		order.OrderDate = placed.Format(time.DateOnly)
+		switch {
+		case rng.chance(0.04):
+			order.Status = "cancelled"
+		case demoDataEnd.Sub(placed) < 7*24*time.Hour:
+			order.Status = "shipped"
+		default:
+			order.Status = "delivered"
+		}
+		CalculateOrderTotal(&order, user)
+		data.Orders = append(data.Orders, order)
+	}
+	return data, nil
+}
+
+// generateDemoUser makes user id, living in country.
+func generateDemoUser(fake *faker, id int, country string) User {
+	rng := fake.rng
+	given, family := fake.name(country)
+	age := fake.age()
+	joined := fake.joinDate(demoDataStart, demoDataEnd)
+	user := User{
+		ID:            id,
+		Email:         fake.email(given, family, demoDataEnd.Year()-age),
+		Name:          given + " " + family,
+		Age:           age,
+		Country:       canonicalCountry(country),
+		Premium:       rng.chance(0.25),
+		JoinDate:      joined.Format(time.DateOnly),
+		EmailVerified: rng.chance(0.8),
+	}
+	if rng.chance(0.2) {
+		user.LoyaltyPoints = 100 * rng.between(1, 30)
+	}
+	return user
+}
+
+// generateDemoProduct makes product id, of category. names counts the names
+// given so far, so a repeated one gets a model number.
+func generateDemoProduct(fake *faker, id int, category string, names map[string]int) Product {
+	rng := fake.rng
+	c := demoCatalog[category]
+	adjective, noun := rng.pick(c.adjectives), rng.pick(c.nouns)
+	name := adjective + " " + noun
+	names[name]++
+	if n := names[name]; n > 1 {
+		name = fmt.Sprintf("%s Mk %d", name, n)
+	}
+
+	price := fake.price(c.minPrice, c.maxPrice)
+	product := Product{
+		ID:          id,
+		Name:        name,
+		Price:       price,
+		UnitCost:    math.Round(price*(35+30*rng.float())) / 100,
+		Category:    category,
+		OnHand:      rng.between(5, 200),
+		Warehouse:   c.warehouse,
+		Rating:      fake.rating(),
+		Description: fmt.Sprintf("%s %s %s", adjective, strings.ToLower(noun), rng.pick(c.uses)),
+		WeightKg:    math.Round((c.minWeight+rng.float()*(c.maxWeight-c.minWeight))*100) / 100,
+	}
+	if rng.chance(0.05) {
+		product.OnHand = 0
+	}
+	if c.tiered && rng.chance(0.3) {
+		product.PriceTiers = []PriceTier{{MinQuantity: 10, DiscountPercent: 5}, {MinQuantity: 50, DiscountPercent: 12}}
+	}
+	return demoProductImages(product)
+}
+
+// demoProductImageSides are the sizes, in pixels, of the square pictures
+// demo products have: a thumbnail, a catalog tile and a zoomed view.
+var demoProductImageSides = []int{160, 480, 1200}
+
+// demoProductImages gives a demo product pictures named after it, the
+// largest as its main picture.
+func demoProductImages(product Product) Product {
+	slug := strings.Join(strings.FieldsFunc(strings.ToLower(product.Name), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}), "-")
+	product.Images = make([]ProductImage, len(demoProductImageSides))
+	for i, side := range demoProductImageSides {
+		product.Images[i] = ProductImage{
+			URL:   fmt.Sprintf("https://images.example.com/products/%d/%s-%d.jpg", product.ID, slug, side),
+			Width: side, Height: side,
+			Alt: product.Name,
+		}
+	}
+	product.ImageURL = product.Images[len(product.Images)-1].URL
+	return product
+}
+
+func generateDemoUsers() []User {
+	return []User{
+		{ID: 1, Email: "john.doe@example.com", Name: "John Doe", Age: 28, Country: "US", Premium: true, JoinDate: "2023-01-15"},
+		{ID: 2, Email: "jane.smith@example.com", Name: "Jane Smith", Age: 34, Country: "CA", Premium: false, JoinDate: "2023-02-20", LoyaltyPoints: 1500},
+		{ID: 3, Email: "alice.johnson@example.com", Name: "Alice Johnson", Age: 22, Country: "UK", Premium: true, JoinDate: "2023-03-10"},
+		{ID: 4, Email: "bob.wilson@example.com", Name: "Bob Wilson", Age: 45, Country: "AU", Premium: false, JoinDate: "2023-01-30"},
+		{ID: 5, Email: "carol.brown@example.com", Name: "Carol Brown", Age: 31, Country: "DE", Premium: true, JoinDate: "2023-04-05"},
+	}
+}
+
+func generateDemoProducts() []Product {
+	products := []Product{
+		{ID: 1, Name: "Wireless Headphones", Price: 99.99, UnitCost: 55, Category: "electronics", OnHand: 25, Rating: 4.5, Description: "High-quality wireless headphones with noise cancellation", WeightKg: 0.3},
+		{ID: 2, Name: "Cotton T-Shirt", Price: 24.99, UnitCost: 9.5, Category: "clothing", OnHand: 120, Rating: 4.2, Description: "Comfortable 100% cotton t-shirt", PriceTiers: []PriceTier{{MinQuantity: 10, DiscountPercent: 5}, {MinQuantity: 50, DiscountPercent: 12}}, WeightKg: 0.2, Variants: []ProductVariant{
+			{SKU: "TSHIRT-BLK-M", Size: "M", Color: "black", OnHand: 40},
+			{SKU: "TSHIRT-BLK-L", Size: "L", Color: "black", OnHand: 35},
+			{SKU: "TSHIRT-BLK-XL", Size: "XL", Color: "black", PriceDelta: 2, OnHand: 15},
+			{SKU: "TSHIRT-WHT-M", Size: "M", Color: "white", OnHand: 30},
+		}},
+		{ID: 3, Name: "Programming Book", Price: 49.99, UnitCost: 22, Category: "books", OnHand: 40, Warehouse: "media", Rating: 4.8, Description: "Learn advanced programming techniques", WeightKg: 0.8, Accessories: []int{4}},
+		{ID: 4, Name: "Coffee Mug", Price: 12.99, UnitCost: 4.5, Category: "home", OnHand: 150, Rating: 4.0, Description: "Ceramic coffee mug with handle", PriceTiers: []PriceTier{{MinQuantity: 10, DiscountPercent: 5}, {MinQuantity: 50, DiscountPercent: 12}}, WeightKg: 0.4},
+		{ID: 5, Name: "Running Shoes", Price: 129.99, UnitCost: 70, Category: "sports", OnHand: 30, Rating: 4.6, Description: "Lightweight running shoes for athletes", WeightKg: 0.9, Accessories: []int{2}},
+		{ID: 6, Name: "Smartphone", Price: 699.99, UnitCost: 480, Category: "electronics", OnHand: 0, Rating: 4.7, Description: "Latest smartphone with advanced features", WeightKg: 0.2, Accessories: []int{1}},
+		{ID: 7, Name: "Jeans", Price: 79.99, UnitCost: 35, Category: "clothing", OnHand: 45, Rating: 4.3, Description: "Classic blue jeans", WeightKg: 0.6, Variants: []ProductVariant{
+			{SKU: "JEANS-30", Size: "30", OnHand: 15},
+			{SKU: "JEANS-32", Size: "32", OnHand: 20},
+			{SKU: "JEANS-34", Size: "34", PriceDelta: 5, OnHand: 10},
+		}},
+		{ID: 8, Name: "Cookbook", Price: 29.99, UnitCost: 12, Category: "books", OnHand: 35, Warehouse: "media", Rating: 4.4, Description: "Delicious recipes for home cooking", WeightKg: 1.0},
+	}
+	for i := range products {
+		products[i] = demoProductImages(products[i])
+	}
+	return products
+}
+
+func generateDemoOrders() []Order {
+	products := generateDemoProducts()
+	tshirt, _ := VariantProduct(products[1], "TSHIRT-BLK-M")
+	return []Order{
+		{
+			ID:         1,
+			UserID:     1,
+			Products:   []Product{products[0], tshirt},
+			Quantities: []int{1, 2},
+			SKUs:       []string{"", "TSHIRT-BLK-M"},
+			Subtotal:   149.97,
+			Tax:        12.00,
+			Shipping:   0.00,
+			Total:      161.97,
+			Discount:   0.00,
+			OrderDate:  "2023-05-01",
+			Status:     "delivered",
+		},
+		{
+			ID:         2,
+			UserID:     2,
+			Products:   products[2:4],
+			Quantities: []int{1, 1},
+			Subtotal:   62.98,
+			Tax:        8.19,
+			Shipping:   12.99,
+			Total:      84.16,
+			Discount:   0.00,
+			OrderDate:  "2023-05-03",
+			Status:     "shipped",
+		},
+	}
+}
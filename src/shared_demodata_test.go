@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDemoData(t *testing.T) {
+	opts := DemoDataOptions{Users: 400, Products: 60, Orders: 1200, Seed: 42}
+	data, err := GenerateDemoData(opts)
+	if err != nil {
+		t.Fatalf("GenerateDemoData failed: %v", err)
+	}
+	if len(data.Users) != 400 || len(data.Products) != 60 || len(data.Orders) != 1200 {
+		t.Fatalf("Expected 400 users, 60 products and 1200 orders, got %d, %d and %d", len(data.Users), len(data.Products), len(data.Orders))
+	}
+
+	// The curated records come first
+	if !reflect.DeepEqual(data.Users[:5], generateDemoUsers()) || !reflect.DeepEqual(data.Orders[:2], generateDemoOrders()) {
+		t.Error("Expected the dataset to start with the curated records")
+	}
+
+	again, _ := GenerateDemoData(opts)
+	if !reflect.DeepEqual(data, again) {
+		t.Error("Expected the same seed to give the same records")
+	}
+	other, _ := GenerateDemoData(DemoDataOptions{Users: 400, Products: 60, Orders: 1200, Seed: 43})
+	if reflect.DeepEqual(data.Users, other.Users) {
+		t.Error("Expected another seed to give other records")
+	}
+
+	users := map[int]User{}
+	emails := map[string]bool{}
+	for i, user := range data.Users {
+		if user.ID != i+1 {
+			t.Fatalf("Expected user %d to have ID %d, got %d", i, i+1, user.ID)
+		}
+		if result := ValidateUser(user); !result.Valid {
+			t.Errorf("Generated user %d is invalid: %v", user.ID, result.Errors)
+		}
+		if emails[user.Email] {
+			t.Errorf("Duplicate email %s", user.Email)
+		}
+		emails[user.Email] = true
+		users[user.ID] = user
+	}
+	products := map[int]bool{}
+	for _, product := range data.Products {
+		if result := ValidateProduct(product); !result.Valid {
+			t.Errorf("Generated product %d is invalid: %v", product.ID, result.Errors)
+		}
+		for _, id := range product.Accessories {
+			if id < 1 || id > len(data.Products) || id == product.ID {
+				t.Errorf("Product %d lists accessory %d", product.ID, id)
+			}
+		}
+		products[product.ID] = true
+	}
+
+	// Orders are priced, placed after their user joined, and a few
+	// products and users account for much of them
+	sold := map[int]int{}
+	for _, order := range data.Orders[2:] {
+		user, ok := users[order.UserID]
+		if !ok {
+			t.Fatalf("Order %d is for unknown user %d", order.ID, order.UserID)
+		}
+		if order.OrderDate < user.JoinDate || order.Total <= 0 || len(order.Lines) != len(order.Products) {
+			t.Errorf("Unexpected order %+v for user joined %s", order, user.JoinDate)
+		}
+		for _, product := range order.Products {
+			if !products[product.ID] {
+				t.Errorf("Order %d has unknown product %d", order.ID, product.ID)
+			}
+			sold[product.ID]++
+		}
+	}
+	best := 0
+	for _, count := range sold {
+		best = max(best, count)
+	}
+	if average := float64(len(data.Orders)) / float64(len(sold)); float64(best) < 3*average {
+		t.Errorf("Expected a best seller well above the average of %.1f orders, got %d", average, best)
+	}
+}
+
+func TestGenerateDemoDataWeights(t *testing.T) {
+	data, err := GenerateDemoData(DemoDataOptions{
+		Users: 300, Products: 108, Orders: 2,
+		Countries:  map[string]float64{"JP": 1, "gb": 1, "DE": 0},
+		Categories: map[string]float64{"toys": 1},
+	})
+	if err != nil {
+		t.Fatalf("GenerateDemoData failed: %v", err)
+	}
+	countries := map[string]int{}
+	for _, user := range data.Users[5:] {
+		countries[user.Country]++
+	}
+	if len(countries) != 2 || countries["JP"] < 100 || countries["UK"] < 100 {
+		t.Errorf("Expected the users split between JP and UK, got %v", countries)
+	}
+	for _, product := range data.Products[8:] {
+		if product.Category != "toys" {
+			t.Fatalf("Expected only toys, got %+v", product)
+		}
+	}
+
+	// Counts below the curated records are raised to them, and zero takes
+	// the defaults
+	small, _ := GenerateDemoData(DemoDataOptions{Users: 1, Products: 1, Orders: 1})
+	if len(small.Users) != 5 || len(small.Products) != 8 || len(small.Orders) != 2 {
+		t.Errorf("Expected the curated records, got %d, %d and %d", len(small.Users), len(small.Products), len(small.Orders))
+	}
+	defaults, _ := GenerateDemoData(DemoDataOptions{Users: 1000})
+	if len(defaults.Products) != 50 || len(defaults.Orders) != 3000 {
+		t.Errorf("Expected 50 products and 3000 orders, got %d and %d", len(defaults.Products), len(defaults.Orders))
+	}
+
+	for _, opts := range []DemoDataOptions{
+		{Users: -1},
+		{Users: MaxDemoRecords},
+		{Countries: map[string]float64{"XX": 1}},
+		{Categories: map[string]float64{"weapons": 1}},
+		{Categories: map[string]float64{"books": -1}},
+		{Countries: map[string]float64{"US": 0}},
+	} {
+		if _, err := GenerateDemoData(opts); err == nil {
+			t.Errorf("Expected %+v to be rejected", opts)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Shared discounts - an order can qualify for several discounts at once: the
+// premium discount, a coupon and a redemption of loyalty points. Each has a
+// stacking rule. Best-of discounts don't combine with each other, so only
+// the largest applies; additive discounts combine with that one and with
+// each other, together up to MaxDiscountShare of the subtotal; an exclusive
+// discount applies alone, when it saves more than the combination does.
+// CalculateOrderTotal lists what applied in Order.Discounts, along with the
+// quantity breaks already taken off the line prices.
+
+// Stacking rules of a discount.
+const (
+	StackExclusive = "exclusive"
+	StackBestOf    = "best_of"
+	StackAdditive  = "additive"
+)
+
+// Sources of a discount line.
+const (
+	DiscountPremium = "premium"
+	DiscountCoupon  = "coupon"
+	DiscountLoyalty = "loyalty"
+	DiscountTier    = "tier"
+)
+
+// MaxDiscountShare caps the discounts of an order taken together, as a
+// fraction of its subtotal.
+const MaxDiscountShare = 0.5
+
+// LoyaltyPointsPerUnit is how many loyalty points redeem one unit of the
+// order's currency.
+const LoyaltyPointsPerUnit = 100
+
+// DiscountLine is one discount applied to an order. Amount is tax-inclusive
+// when the order is. Lines marked InPrice are savings already in the line
+// prices, which Order.Discount doesn't include.
+type DiscountLine struct {
+	Source      string  `json:"source"`
+	Code        string  `json:"code,omitempty"`
+	Description string  `json:"description"`
+	Stacking    string  `json:"stacking"`
+	Amount      float64 `json:"amount"`
+	Points      int     `json:"points,omitempty"` // loyalty points redeemed
+	InPrice     bool    `json:"in_price,omitempty"`
+}
+
+// Coupon is a discount code: Percent off the subtotal or a fixed Amount,
+// for orders of at least MinSubtotal. Expiry is the last taxDateLayout day
+// it can be used; empty means it never expires.
+type Coupon struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Percent     float64 `json:"percent,omitempty"`
+	Amount      float64 `json:"amount,omitempty"`
+	MinSubtotal float64 `json:"min_subtotal,omitempty"`
+	Stacking    string  `json:"stacking"`
+	Expiry      string  `json:"expiry,omitempty"`
+}
+
+// coupons are the demo coupons.
+var coupons = map[string]Coupon{
+	"WELCOME10":  {Code: "WELCOME10", Description: "10% off your order", Percent: 10, Stacking: StackBestOf},
+	"SAVE5":      {Code: "SAVE5", Description: "5 off orders of 25 or more", Amount: 5, MinSubtotal: 25, Stacking: StackAdditive},
+	"FLASH25":    {Code: "FLASH25", Description: "25% off orders of 100 or more", Percent: 25, MinSubtotal: 100, Stacking: StackExclusive},
+	"SPRING2024": {Code: "SPRING2024", Description: "20% off for spring", Percent: 20, Stacking: StackBestOf, Expiry: "2024-05-31"},
+}
+
+// Coupon errors.
+var (
+	ErrCouponUnknown = errors.New("unknown coupon code")
+	ErrCouponExpired = errors.New("coupon has expired")
+)
+
+// LookupCoupon finds a coupon by code, ignoring case and spaces.
+func LookupCoupon(code string) (Coupon, bool) {
+	coupon, ok := coupons[strings.ToUpper(strings.TrimSpace(code))]
+	return coupon, ok
+}
+
+// CheckCoupon reports why a coupon code can't be used on a date, or nil
+// when it can. Orders below the coupon's minimum still price, without it.
+func CheckCoupon(code string, date time.Time) error {
+	coupon, ok := LookupCoupon(code)
+	if !ok {
+		return ErrCouponUnknown
+	}
+	if coupon.Expiry != "" && coupon.Expiry < date.Format(taxDateLayout) {
+		return ErrCouponExpired
+	}
+	return nil
+}
+
+// discount is a discount an order qualifies for, before stacking. Lower
+// priorities apply first, so the cap trims the later ones.
+type discount struct {
+	line     DiscountLine
+	priority int
+	amount   Money
+}
+
+// Priorities of the discount sources.
+const (
+	premiumPriority = 10
+	couponPriority  = 20
+	loyaltyPriority = 30
+)
+
+// orderDiscounts are the discounts an order with a subtotal qualifies for.
+// Loyalty points are limited to the user's balance.
+func orderDiscounts(order Order, user User, subtotal Money, date time.Time) []discount {
+	var candidates []discount
+	if user.Premium {
+		switch {
+		case subtotal.Float64() > 100:
+			candidates = append(candidates, premiumDiscount(subtotal, 0.15)) // 15% premium discount
+		case subtotal.Float64() > 50:
+			candidates = append(candidates, premiumDiscount(subtotal, 0.10)) // 10% premium discount
+		}
+	}
+
+	if coupon, ok := LookupCoupon(order.CouponCode); ok && CheckCoupon(coupon.Code, date) == nil && subtotal.Float64() >= coupon.MinSubtotal {
+		amount := NewMoney(coupon.Amount, subtotal.Currency)
+		if coupon.Percent > 0 {
+			amount = subtotal.MulRate(coupon.Percent/100, RoundHalfUp)
+		}
+		candidates = append(candidates, discount{
+			line:     DiscountLine{Source: DiscountCoupon, Code: coupon.Code, Description: coupon.Description, Stacking: coupon.Stacking},
+			priority: couponPriority,
+			amount:   amount.Min(subtotal),
+		})
+	}
+
+	if points := min(order.LoyaltyPoints, user.LoyaltyPoints); points > 0 {
+		candidates = append(candidates, discount{
+			line:     DiscountLine{Source: DiscountLoyalty, Description: "Loyalty points", Stacking: StackAdditive},
+			priority: loyaltyPriority,
+			amount:   loyaltyValue(points, subtotal.Currency),
+		})
+	}
+	return candidates
+}
+
+func premiumDiscount(subtotal Money, rate float64) discount {
+	return discount{
+		line:     DiscountLine{Source: DiscountPremium, Description: "Premium member discount", Stacking: StackBestOf},
+		priority: premiumPriority,
+		amount:   subtotal.MulRate(rate, RoundHalfUp),
+	}
+}
+
+// stackDiscounts applies the stacking rules to the candidates, returning the
+// discounts that apply in priority order.
+func stackDiscounts(subtotal Money, candidates []discount) []discount {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].priority < candidates[j].priority })
+	limit := subtotal.MulRate(MaxDiscountShare, RoundDown)
+
+	best := -1
+	for i, candidate := range candidates {
+		if candidate.line.Stacking == StackBestOf && (best < 0 || candidate.amount.Minor > candidates[best].amount.Minor) {
+			best = i
+		}
+	}
+	var combined []discount
+	for i, candidate := range candidates {
+		if i == best || candidate.line.Stacking == StackAdditive {
+			combined = append(combined, candidate)
+		}
+	}
+	applied := capDiscounts(combined, limit)
+
+	for _, candidate := range candidates {
+		if candidate.line.Stacking != StackExclusive {
+			continue
+		}
+		if alone := capDiscounts([]discount{candidate}, limit); discountSum(alone) > discountSum(applied) {
+			applied = alone
+		}
+	}
+	return applied
+}
+
+// capDiscounts trims discounts, from the last, to limit in total, dropping
+// those left with nothing. Trimmed loyalty redemptions use fewer points.
+func capDiscounts(discounts []discount, limit Money) []discount {
+	var capped []discount
+	left := limit
+	for _, d := range discounts {
+		d.amount = d.amount.Min(left)
+		if d.amount.Minor <= 0 {
+			continue
+		}
+		left = left.Sub(d.amount)
+		if d.line.Source == DiscountLoyalty {
+			d.line.Points = loyaltyPoints(d.amount)
+		}
+		capped = append(capped, d)
+	}
+	return capped
+}
+
+func discountSum(discounts []discount) int64 {
+	var sum int64
+	for _, d := range discounts {
+		sum += d.amount.Minor
+	}
+	return sum
+}
+
+// loyaltyValue is what points redeem in a currency, in whole minor units.
+func loyaltyValue(points int, code string) Money {
+	unit := int64(math.Pow10(currencyFor(code).Decimals))
+	return Money{Minor: mulDivRound(int64(points), unit, LoyaltyPointsPerUnit, RoundDown), Currency: currencyFor(code).Code}
+}
+
+// loyaltyPoints is how many points an amount redeems.
+func loyaltyPoints(amount Money) int {
+	unit := int64(math.Pow10(currencyFor(amount.Currency).Decimals))
+	return int(mulDivRound(amount.Minor, LoyaltyPointsPerUnit, unit, RoundDown))
+}
+
+// LoyaltyPointsRedeemed is the points an order priced by CalculateOrderTotal
+// takes off the user's balance.
+func LoyaltyPointsRedeemed(order Order) int {
+	points := 0
+	for _, line := range order.Discounts {
+		points += line.Points
+	}
+	return points
+}
+
+// discountLines lists the applied discounts, sharing the gross discount of a
+// tax-inclusive order among them in proportion to their net amounts; the
+// last takes the rounding.
+func discountLines(applied []discount, net, gross Money) []DiscountLine {
+	var lines []DiscountLine
+	left := gross
+	for i, d := range applied {
+		amount := d.amount
+		if gross != net {
+			amount = gross.MulDiv(d.amount.Minor, net.Minor, RoundHalfUp)
+			if i == len(applied)-1 {
+				amount = left
+			}
+			left = left.Sub(amount)
+		}
+		line := d.line
+		line.Amount = amount.Float64()
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// discountOrder is an order of one product at a price, placed on a day coupons
+// are valid.
+func discountOrder(price float64) Order {
+	return Order{Products: []Product{{ID: 1, Name: "Desk", Price: price, Category: "home"}}, Quantities: []int{1}, OrderDate: "2026-10-14"}
+}
+
+// TestStackDiscounts tests best-of, additive, exclusive and the cap
+func TestStackDiscounts(t *testing.T) {
+	type applied struct {
+		source string
+		amount float64
+		points int
+	}
+	for _, test := range []struct {
+		name   string
+		price  float64
+		user   User
+		coupon string
+		points int // of a balance of 5000 unless the user has some
+		want   []applied
+	}{
+		{"premium alone", 120, User{Premium: true}, "", 0, []applied{{DiscountPremium, 18, 0}}},
+		{"best of keeps the larger", 120, User{Premium: true}, "WELCOME10", 0, []applied{{DiscountPremium, 18, 0}}},
+		{"best of without the premium discount", 40, User{Premium: true}, "WELCOME10", 0, []applied{{DiscountCoupon, 4, 0}}},
+		{"additive adds", 80, User{}, "SAVE5", 500, []applied{{DiscountCoupon, 5, 0}, {DiscountLoyalty, 5, 500}}},
+		{"below the minimum", 20, User{}, "SAVE5", 0, nil},
+		{"exclusive beats the combination", 200, User{Premium: true}, "FLASH25", 0, []applied{{DiscountCoupon, 50, 0}}},
+		{"combination beats exclusive", 200, User{Premium: true}, "FLASH25", 4000, []applied{{DiscountPremium, 30, 0}, {DiscountLoyalty, 40, 4000}}},
+		{"cap trims the last", 40, User{}, "SAVE5", 2000, []applied{{DiscountCoupon, 5, 0}, {DiscountLoyalty, 15, 1500}}},
+		{"points limited to the balance", 80, User{LoyaltyPoints: 300}, "", 900, []applied{{DiscountLoyalty, 3, 300}}},
+		{"expired coupon", 80, User{}, "SPRING2024", 0, nil},
+	} {
+		order := discountOrder(test.price)
+		order.CouponCode, order.LoyaltyPoints = test.coupon, test.points
+		test.user.Country = "US"
+		if test.user.LoyaltyPoints == 0 {
+			test.user.LoyaltyPoints = 5000
+		}
+		CalculateOrderTotal(&order, test.user)
+
+		if len(order.Discounts) != len(test.want) {
+			t.Errorf("%s: expected %d discounts, got %+v", test.name, len(test.want), order.Discounts)
+			continue
+		}
+		total, points := 0.0, 0
+		for i, want := range test.want {
+			got := order.Discounts[i]
+			if got.Source != want.source || got.Amount != want.amount || got.Points != want.points {
+				t.Errorf("%s: discount %d = %+v, want %+v", test.name, i, got, want)
+			}
+			total += got.Amount
+			points += want.points
+		}
+		if order.Discount != RoundToCurrency(total, "USD") {
+			t.Errorf("%s: expected Discount to be the sum of the lines, got %v", test.name, order.Discount)
+		}
+		if redeemed := LoyaltyPointsRedeemed(order); redeemed != points {
+			t.Errorf("%s: expected %d points redeemed, got %d", test.name, points, redeemed)
+		}
+	}
+}
+
+// TestDiscountLinesTierAndGross tests quantity breaks are listed but not
+// counted, and tax-inclusive lines add up to the gross discount
+func TestDiscountLinesTierAndGross(t *testing.T) {
+	mug := Product{ID: 2, Name: "Coffee Mug", Price: 20, Category: "home",
+		PriceTiers: []PriceTier{{MinQuantity: 10, DiscountPercent: 5}}}
+	order := Order{Products: []Product{mug}, Quantities: []int{10}, CouponCode: "SAVE5", OrderDate: "2026-10-14"}
+	CalculateOrderTotal(&order, User{Country: "US"})
+	if len(order.Discounts) != 2 || order.Discount != 5 {
+		t.Fatalf("Expected the coupon and a tier line, got %+v", order.Discounts)
+	}
+	if tier := order.Discounts[1]; tier.Source != DiscountTier || !tier.InPrice || tier.Amount != 10 {
+		t.Errorf("Expected 10 saved by quantity breaks, got %+v", tier)
+	}
+
+	order = discountOrder(99.99)
+	order.Currency, order.IncludesTax, order.CouponCode, order.LoyaltyPoints = "EUR", true, "SAVE5", 333
+	CalculateOrderTotal(&order, User{Country: "DE", LoyaltyPoints: 333})
+	sum := 0.0
+	for _, line := range order.Discounts {
+		sum += line.Amount
+	}
+	if len(order.Discounts) != 2 || RoundToCurrency(sum, "EUR") != order.Discount {
+		t.Errorf("Expected the lines to add up to the gross discount %v, got %+v", order.Discount, order.Discounts)
+	}
+}
+
+// TestCheckCoupon tests coupon codes and expiry
+func TestCheckCoupon(t *testing.T) {
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	if err := CheckCoupon(" welcome10 ", day); err != nil {
+		t.Errorf("Expected a lowercase code to be accepted, got %v", err)
+	}
+	if err := CheckCoupon("NOPE", day); !errors.Is(err, ErrCouponUnknown) {
+		t.Errorf("Expected an unknown coupon, got %v", err)
+	}
+	if err := CheckCoupon("SPRING2024", day); !errors.Is(err, ErrCouponExpired) {
+		t.Errorf("Expected an expired coupon, got %v", err)
+	}
+	if err := CheckCoupon("SPRING2024", time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("Expected the coupon to be valid on its last day, got %v", err)
+	}
+}
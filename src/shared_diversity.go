@@ -0,0 +1,82 @@
+package main
+
+import "strings"
+
+// Shared recommendation diversity - scoring alone tends to fill the top five
+// with near-identical products from the cart's category. diversify re-ranks
+// by maximal marginal relevance (MMR): each pick is the product with the
+// best mix of its own score and difference from the products already
+// picked, weighted by Diversity.Weight, and no category gets more than
+// Diversity.MaxPerCategory picks while others have candidates left.
+
+// Diversity configures how recommendations are diversified. A zero Weight
+// ranks by score alone, and a zero MaxPerCategory sets no cap.
+type Diversity struct {
+	Weight         float64 `json:"weight"`           // 0 to 1
+	MaxPerCategory int     `json:"max_per_category"` // 0 for no cap
+}
+
+// DefaultDiversity is used when a request sets none.
+var DefaultDiversity = Diversity{Weight: 0.3, MaxPerCategory: 2}
+
+// ValidateDiversity checks a diversity configuration's ranges.
+func ValidateDiversity(diversity Diversity) ValidationResult {
+	result := newValidationResult()
+	if !(diversity.Weight >= 0 && diversity.Weight <= 1) {
+		result.AddError("weight", CodeOutOfRange, "Diversity weight must be between 0 and 1")
+	}
+	if diversity.MaxPerCategory < 0 {
+		result.AddError("max_per_category", CodeOutOfRange, "Maximum per category must not be negative")
+	}
+	return result
+}
+
+// diversify picks up to limit of the candidates, sorted by score, by MMR.
+// Scores are scaled to the best so the weight means the same whatever the
+// catalog; two products are alike when they share a category.
+func diversify(candidates []Recommendation, diversity Diversity, limit int) []Recommendation {
+	picked := []Recommendation{}
+	if len(candidates) == 0 {
+		return picked
+	}
+	best := candidates[0].Score
+	if best <= 0 {
+		best = 1
+	}
+
+	perCategory := map[string]int{}
+	remaining := append([]Recommendation{}, candidates...)
+	for len(picked) < limit && len(remaining) > 0 {
+		// The cap holds while another category has a candidate
+		capped := func(candidate Recommendation) bool {
+			return diversity.MaxPerCategory > 0 && perCategory[strings.ToLower(candidate.Category)] >= diversity.MaxPerCategory
+		}
+		allCapped := true
+		for _, candidate := range remaining {
+			if !capped(candidate) {
+				allCapped = false
+				break
+			}
+		}
+
+		choice, choiceValue := -1, 0.0
+		for i, candidate := range remaining {
+			if capped(candidate) && !allCapped {
+				continue
+			}
+			similarity := 0.0
+			if perCategory[strings.ToLower(candidate.Category)] > 0 {
+				similarity = 1
+			}
+			value := (1-diversity.Weight)*candidate.Score/best - diversity.Weight*similarity
+			if choice == -1 || value > choiceValue {
+				choice, choiceValue = i, value
+			}
+		}
+
+		picked = append(picked, remaining[choice])
+		perCategory[strings.ToLower(remaining[choice].Category)]++
+		remaining = append(remaining[:choice], remaining[choice+1:]...)
+	}
+	return picked
+}
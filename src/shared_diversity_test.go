@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+// TestDiversify tests MMR re-ranking and the per-category cap
+func TestDiversify(t *testing.T) {
+	candidate := func(id int, category string, score float64) Recommendation {
+		return Recommendation{Product: Product{ID: id, Category: category}, Score: score}
+	}
+	// Scored alone, the top five are all electronics
+	candidates := []Recommendation{
+		candidate(1, "electronics", 10),
+		candidate(2, "electronics", 9.8),
+		candidate(3, "electronics", 9.6),
+		candidate(4, "electronics", 9.4),
+		candidate(5, "Electronics", 9.2),
+		candidate(6, "books", 8),
+		candidate(7, "home", 5),
+	}
+	ids := func(picked []Recommendation) []int {
+		var list []int
+		for _, recommendation := range picked {
+			list = append(list, recommendation.ID)
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		diversity Diversity
+		want      []int
+	}{
+		{"score alone", Diversity{}, []int{1, 2, 3, 4, 5}},
+		{"capped", Diversity{MaxPerCategory: 2}, []int{1, 2, 6, 7, 3}},
+		{"mmr", Diversity{Weight: 0.3}, []int{1, 6, 2, 3, 4}},
+		{"mmr heavy", Diversity{Weight: 0.6}, []int{1, 6, 7, 2, 3}},
+		{"default", DefaultDiversity, []int{1, 6, 2, 7, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(diversify(candidates, tt.diversity, 5))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	if got := diversify(nil, DefaultDiversity, 5); got == nil || len(got) != 0 {
+		t.Errorf("Expected no picks without candidates, got %#v", got)
+	}
+}
+
+// TestValidateDiversity tests the diversity ranges
+func TestValidateDiversity(t *testing.T) {
+	if result := ValidateDiversity(DefaultDiversity); !result.Valid {
+		t.Errorf("Expected the default to be valid, got %v", result.Errors)
+	}
+	if result := ValidateDiversity(Diversity{Weight: 1.5, MaxPerCategory: -1}); len(result.FieldErrors) != 2 {
+		t.Errorf("Expected both fields to be rejected, got %+v", result.FieldErrors)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Shared duplicate user detection - two accounts are likely the same person
+// when their emails normalize to one address, or when their names are all
+// but identical (Jaro-Winkler similarity) and they joined from the same
+// country on the same day. The same check previews duplicates in a bulk
+// import before it is sent.
+
+const (
+	// DuplicateThreshold is the score from which two users are reported.
+	DuplicateThreshold = 0.85
+	// nameMatchThreshold is how similar two names must be to count at all.
+	nameMatchThreshold = 0.9
+)
+
+// DuplicateUsers is a pair of users that look like the same person. First
+// and Second index the users passed in, First < Second; Score runs up to 1
+// (the same email).
+type DuplicateUsers struct {
+	First    int      `json:"first"`
+	Second   int      `json:"second"`
+	FirstID  int      `json:"first_id"`
+	SecondID int      `json:"second_id"`
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons"`
+}
+
+// FindDuplicateUsers lists the likely duplicate pairs among users, most
+// likely first.
+func FindDuplicateUsers(users []User) []DuplicateUsers {
+	emails := make([]string, len(users))
+	names := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = NormalizeEmail(user.Email)
+		names[i] = normalizeName(user.Name)
+	}
+
+	pairs := []DuplicateUsers{}
+	for i := range users {
+		for j := i + 1; j < len(users); j++ {
+			pair := DuplicateUsers{First: i, Second: j, FirstID: users[i].ID, SecondID: users[j].ID}
+			if emails[i] != "" && emails[i] == emails[j] {
+				pair.Score = 1
+				pair.Reasons = append(pair.Reasons, "Same email address")
+			}
+			if similarity := JaroWinkler(names[i], names[j]); names[i] != "" && similarity >= nameMatchThreshold {
+				score := 0.6 * similarity
+				pair.Reasons = append(pair.Reasons, fmt.Sprintf("Names are %.0f%% similar", similarity*100))
+				if users[i].Country != "" && strings.EqualFold(users[i].Country, users[j].Country) {
+					score += 0.2
+					pair.Reasons = append(pair.Reasons, "Same country")
+				}
+				if users[i].JoinDate != "" && users[i].JoinDate == users[j].JoinDate {
+					score += 0.2
+					pair.Reasons = append(pair.Reasons, "Joined the same day")
+				}
+				pair.Score = max(pair.Score, score)
+			}
+			if pair.Score >= DuplicateThreshold {
+				pair.Score = math.Round(pair.Score*100) / 100
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].Score > pairs[b].Score })
+	return pairs
+}
+
+// NormalizeEmail lower-cases an email and drops what mail providers ignore:
+// a +tag in the local part, and dots for Gmail addresses.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	local, _, _ = strings.Cut(local, "+")
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// normalizeName lower-cases a name and collapses punctuation and spacing.
+func normalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// JaroWinkler is the Jaro-Winkler similarity of two strings, from 0 (no
+// characters in common) to 1 (equal), favouring a common prefix.
+func JaroWinkler(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 && len(t) == 0 {
+		return 1
+	}
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(max(len(s), len(t))/2-1, 0)
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestJaroWinkler tests the similarity against well-known values
+func TestJaroWinkler(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.813},
+		{"same", "same", 1},
+		{"", "", 1},
+		{"abc", "", 0},
+		{"abc", "xyz", 0},
+	} {
+		if got := JaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestNormalizeEmail tests that provider-ignored differences are dropped
+func TestNormalizeEmail(t *testing.T) {
+	for email, want := range map[string]string{
+		" John.Doe@Example.com ":      "john.doe@example.com",
+		"john.doe+shop@example.com":   "john.doe@example.com",
+		"J.o.h.n+news@googlemail.com": "john@gmail.com",
+		"not-an-email":                "not-an-email",
+	} {
+		if got := NormalizeEmail(email); got != want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+// TestFindDuplicateUsers tests matching by email and by name with join details
+func TestFindDuplicateUsers(t *testing.T) {
+	users := []User{
+		{ID: 1, Email: "jon.smith@gmail.com", Name: "Jon Smith", Country: "US", JoinDate: "2024-01-10"},
+		{ID: 2, Email: "jonsmith+shop@gmail.com", Name: "J. Smith", Country: "CA"},
+		{ID: 3, Email: "jsmith@example.com", Name: "John Smith", Country: "us", JoinDate: "2024-01-10"},
+		{ID: 4, Email: "john.smith@example.org", Name: "John Smith", Country: "UK", JoinDate: "2024-01-10"},
+		{ID: 5, Email: "ann@example.com", Name: "Ann Lee", Country: "US", JoinDate: "2024-01-10"},
+	}
+	pairs := FindDuplicateUsers(users)
+	if len(pairs) != 2 {
+		t.Fatalf("Expected two duplicate pairs, got %+v", pairs)
+	}
+	if pair := pairs[0]; pair.FirstID != 1 || pair.SecondID != 2 || pair.Score != 1 || pair.Reasons[0] != "Same email address" {
+		t.Errorf("Expected users 1 and 2 first, sharing a Gmail address, got %+v", pair)
+	}
+	if pair := pairs[1]; pair.First != 0 || pair.Second != 2 || pair.Score < DuplicateThreshold || len(pair.Reasons) != 3 {
+		t.Errorf("Expected users 1 and 3 by name, country and join date, got %+v", pair)
+	}
+}
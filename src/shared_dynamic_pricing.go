@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Shared dynamic pricing - a product's effective price moves within
+// configured bounds with its demand: how fast it sold over a recent window
+// of the order history against how much stock is left. A product that would
+// sell out well before TargetCoverDays is priced up towards MaxFactor, one
+// that is not moving is priced down towards MinFactor, and one selling at
+// the target pace keeps its list price. Variants keep their PriceDelta on
+// top of the adjusted price.
+
+// DynamicPricing bounds and tunes the pricing engine.
+type DynamicPricing struct {
+	MinFactor float64 `json:"min_factor"`
+	MaxFactor float64 `json:"max_factor"`
+	// WindowDays is how many days of orders demand is measured over
+	WindowDays int `json:"window_days"`
+	// TargetCoverDays is the days of stock at the recent sales pace at
+	// which a product keeps its list price
+	TargetCoverDays float64 `json:"target_cover_days"`
+}
+
+// DefaultDynamicPricing moves prices between 10% off and 20% on, aiming for
+// 60 days of stock at the pace of the last 30.
+var DefaultDynamicPricing = DynamicPricing{MinFactor: 0.9, MaxFactor: 1.2, WindowDays: 30, TargetCoverDays: 60}
+
+// DemandSignals are what a dynamic price is computed from. DaysOfCover is
+// how long the available stock lasts at DailyVelocity, zero when nothing
+// sold in the window.
+type DemandSignals struct {
+	ProductID     int     `json:"product_id"`
+	UnitsSold     int     `json:"units_sold"`
+	DailyVelocity float64 `json:"daily_velocity"`
+	Available     int     `json:"available"`
+	DaysOfCover   float64 `json:"days_of_cover,omitempty"`
+}
+
+// DynamicPriceQuote is a product's effective price and how it was reached.
+type DynamicPriceQuote struct {
+	ProductID int           `json:"product_id"`
+	BasePrice float64       `json:"base_price"`
+	Price     float64       `json:"price"`
+	Factor    float64       `json:"factor"`
+	Signals   DemandSignals `json:"signals"`
+}
+
+// Validate checks that the bounds contain the list price and the window
+// and target are positive.
+func (pricing DynamicPricing) Validate() error {
+	var errs []error
+	if pricing.MinFactor <= 0 || pricing.MinFactor > 1 {
+		errs = append(errs, errors.New("min_factor must be greater than 0 and at most 1"))
+	}
+	if pricing.MaxFactor < 1 {
+		errs = append(errs, errors.New("max_factor must be at least 1"))
+	}
+	if pricing.WindowDays <= 0 || pricing.TargetCoverDays <= 0 {
+		errs = append(errs, errors.New("window_days and target_cover_days must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// ComputeDemandSignals measures a product's demand from the orders placed
+// in the windowDays up to now. Cancelled orders and orders with unreadable
+// dates are not counted.
+func ComputeDemandSignals(product Product, orders []Order, now time.Time, windowDays int) DemandSignals {
+	signals := DemandSignals{ProductID: product.ID, Available: product.Available()}
+	today := now.Format(taxDateLayout)
+	since := now.AddDate(0, 0, -windowDays).Format(taxDateLayout)
+	for _, order := range orders {
+		if order.Status == "cancelled" {
+			continue
+		}
+		if _, err := time.Parse(taxDateLayout, order.OrderDate); err != nil || order.OrderDate <= since || order.OrderDate > today {
+			continue
+		}
+		for _, item := range OrderItems(order) {
+			if item.ProductID == product.ID {
+				signals.UnitsSold += item.Quantity
+			}
+		}
+	}
+	if windowDays > 0 {
+		signals.DailyVelocity = float64(signals.UnitsSold) / float64(windowDays)
+	}
+	if signals.DailyVelocity > 0 {
+		signals.DaysOfCover = math.Round(float64(signals.Available)/signals.DailyVelocity*10) / 10
+	}
+	return signals
+}
+
+// DynamicPrice prices a product for its demand signals. Sold-out products
+// keep their list price, there being nothing left to price.
+func DynamicPrice(product Product, signals DemandSignals, pricing DynamicPricing) DynamicPriceQuote {
+	quote := DynamicPriceQuote{ProductID: product.ID, BasePrice: product.Price, Price: product.Price, Factor: 1, Signals: signals}
+	if signals.Available == 0 {
+		return quote
+	}
+
+	// pressure runs from -1 (no sales) through 0 (selling at the target
+	// pace) to 1 (about to sell out)
+	pressure := -1.0
+	if signals.DailyVelocity > 0 && pricing.TargetCoverDays > 0 {
+		cover := float64(signals.Available) / signals.DailyVelocity
+		pressure = max(-1, min(1, (pricing.TargetCoverDays-cover)/pricing.TargetCoverDays))
+	}
+	factor := 1 + pressure*(1-pricing.MinFactor)
+	if pressure > 0 {
+		factor = 1 + pressure*(pricing.MaxFactor-1)
+	}
+	quote.Factor = math.Round(max(pricing.MinFactor, min(pricing.MaxFactor, factor))*1000) / 1000
+	quote.Price = RoundToCurrency(product.Price*quote.Factor, product.Currency)
+	return quote
+}
+
+// ApplyDynamicPricing is catalog with every product's price replaced by its
+// dynamic price at now.
+func ApplyDynamicPricing(catalog []Product, orders []Order, now time.Time, pricing DynamicPricing) []Product {
+	priced := make([]Product, len(catalog))
+	for i, product := range catalog {
+		signals := ComputeDemandSignals(product, orders, now, pricing.WindowDays)
+		product.Price = DynamicPrice(product, signals, pricing).Price
+		priced[i] = product
+	}
+	return priced
+}
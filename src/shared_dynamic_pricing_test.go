@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestComputeDemandSignals tests counting recent sales from the order history
+func TestComputeDemandSignals(t *testing.T) {
+	now := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC)
+	date := func(daysAgo int) string { return now.AddDate(0, 0, -daysAgo).Format(taxDateLayout) }
+	lamp := Product{ID: 1, Name: "Lamp", Price: 100, OnHand: 25, Reserved: 5}
+	rug := Product{ID: 2, Name: "Rug", Price: 80}
+	orders := []Order{
+		{Products: []Product{lamp, rug}, Quantities: []int{10, 3}, OrderDate: date(5), Status: "delivered"},
+		{Products: []Product{lamp}, Quantities: []int{20}, OrderDate: date(29), Status: "pending"},
+		{Products: []Product{lamp}, Quantities: []int{50}, OrderDate: date(40), Status: "delivered"},
+		{Products: []Product{lamp}, Quantities: []int{50}, OrderDate: date(1), Status: "cancelled"},
+		{Products: []Product{lamp}, Quantities: []int{50}, OrderDate: "last week", Status: "pending"},
+	}
+
+	signals := ComputeDemandSignals(lamp, orders, now, 30)
+	if signals.UnitsSold != 30 || signals.DailyVelocity != 1 || signals.Available != 20 || signals.DaysOfCover != 20 {
+		t.Errorf("Expected 30 units sold in the window and 20 days of cover, got %+v", signals)
+	}
+	if signals := ComputeDemandSignals(Product{ID: 3, OnHand: 4}, orders, now, 30); signals.UnitsSold != 0 || signals.DaysOfCover != 0 {
+		t.Errorf("Expected no demand for an unsold product, got %+v", signals)
+	}
+}
+
+// TestDynamicPrice tests prices following stock cover within the bounds
+func TestDynamicPrice(t *testing.T) {
+	product := Product{ID: 1, Name: "Lamp", Price: 100}
+	for _, tt := range []struct {
+		name      string
+		available int
+		velocity  float64
+		factor    float64
+		price     float64
+	}{
+		{"NoSales", 100, 0, 0.9, 90},
+		{"SlowMover", 90, 1, 0.95, 95},
+		{"TargetPace", 60, 1, 1, 100},
+		{"SellingFast", 15, 1, 1.15, 115},
+		{"AboutToSellOut", 1, 30, 1.2, 120},
+		{"SoldOut", 0, 5, 1, 100},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := DemandSignals{ProductID: 1, Available: tt.available, DailyVelocity: tt.velocity}
+			quote := DynamicPrice(product, signals, DefaultDynamicPricing)
+			if quote.Factor != tt.factor || quote.Price != tt.price || quote.BasePrice != 100 {
+				t.Errorf("Expected factor %v and price %v, got %+v", tt.factor, tt.price, quote)
+			}
+		})
+	}
+
+	yen := Product{ID: 2, Name: "Teapot", Price: 3333, Currency: "JPY"}
+	if quote := DynamicPrice(yen, DemandSignals{Available: 10}, DefaultDynamicPricing); quote.Price != 3000 {
+		t.Errorf("Expected the price rounded to whole yen, got %v", quote.Price)
+	}
+}
+
+// TestApplyDynamicPricing tests pricing a whole catalog
+func TestApplyDynamicPricing(t *testing.T) {
+	catalog := []Product{{ID: 1, Name: "Lamp", Price: 100, OnHand: 10}}
+	priced := ApplyDynamicPricing(catalog, nil, time.Now(), DefaultDynamicPricing)
+	if priced[0].Price != 90 || catalog[0].Price != 100 {
+		t.Errorf("Expected a priced copy of the catalog, got %+v from %+v", priced, catalog)
+	}
+
+	if err := (DynamicPricing{MinFactor: 1.1, MaxFactor: 0.9}).Validate(); err == nil {
+		t.Error("Expected bounds excluding the list price to be invalid")
+	}
+	if err := DefaultDynamicPricing.Validate(); err != nil {
+		t.Errorf("Expected the default pricing to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strings"
+	"unicode"
+)
+
+// Shared email validation - addresses are parsed by the rules of RFC 5321
+// (with RFC 6531 UTF-8 local parts and internationalized domain names
+// checked in their punycode form) instead of matched against a pattern.
+// What is wrong with an address is an error; what is merely suspicious -
+// a disposable mailbox, a likely typo of a common provider, a quoted local
+// part or an IP address for a domain - is a warning, so a form can ask
+// "did you mean ...?" without rejecting the address.
+
+// Warning codes for email addresses.
+const (
+	EmailDisposable   = "disposable_domain"
+	EmailPossibleTypo = "possible_typo"
+	EmailQuotedLocal  = "quoted_local_part"
+	EmailIPLiteral    = "domain_literal"
+)
+
+const (
+	maxEmailLength  = 254 // RFC 5321 path limit less the angle brackets
+	maxLocalLength  = 64
+	maxDomainLength = 253
+	maxLabelLength  = 63
+)
+
+// localAtext are the ASCII characters a dot-atom local part may use besides
+// letters, digits and dots.
+const localAtext = "!#$%&'*+-/=?^_`{|}~"
+
+// disposableEmailDomains are throwaway mailbox providers; subdomains of
+// them count too.
+var disposableEmailDomains = map[string]bool{
+	"10minutemail.com": true, "33mail.com": true, "burnermail.io": true,
+	"discard.email": true, "dispostable.com": true, "emailondeck.com": true,
+	"fakeinbox.com": true, "getnada.com": true, "guerrillamail.com": true,
+	"guerrillamail.net": true, "mailcatch.com": true, "maildrop.cc": true,
+	"mailinator.com": true, "mailnesia.com": true, "mintemail.com": true,
+	"moakt.com": true, "mohmal.com": true, "mytemp.email": true,
+	"sharklasers.com": true, "spambox.us": true, "spamgourmet.com": true,
+	"tempail.com": true, "temp-mail.org": true, "tempinbox.com": true,
+	"tempmail.com": true, "throwawaymail.com": true, "trashmail.com": true,
+	"yopmail.com": true,
+}
+
+// commonEmailProviders are the domains a near miss is corrected to.
+var commonEmailProviders = []string{
+	"aol.com", "comcast.net", "gmail.com", "gmx.com", "gmx.de", "hotmail.co.uk",
+	"hotmail.com", "icloud.com", "live.com", "mail.com", "me.com", "msn.com",
+	"outlook.com", "proton.me", "protonmail.com", "web.de", "yahoo.co.uk",
+	"yahoo.com", "ymail.com",
+}
+
+// ValidateEmail checks an email address. Errors make it invalid; warnings
+// (field "email") leave it valid.
+func ValidateEmail(email string) ValidationResult {
+	result := newValidationResult()
+	fail := func(problem string) ValidationResult {
+		result.AddError("email", CodeInvalidFormat, "Invalid email format: "+problem)
+		return result
+	}
+	warn := func(code, message, suggestion string) {
+		result.AddWarning(ValidationWarning{Field: "email", Code: code, Message: message, Suggestion: suggestion})
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		result.AddError("email", CodeInvalidFormat, "Invalid email format")
+		return result
+	}
+	local, domain := email[:at], email[at+1:]
+
+	switch {
+	case local == "":
+		return fail("the part before @ is empty")
+	case len(local) > maxLocalLength:
+		return fail(fmt.Sprintf("the part before @ is longer than %d characters", maxLocalLength))
+	case strings.HasPrefix(local, `"`):
+		if problem := quotedLocalProblem(local); problem != "" {
+			return fail(problem)
+		}
+		warn(EmailQuotedLocal, "Quoted addresses are valid but often rejected by mail systems", "")
+	default:
+		if problem := dotAtomProblem(local); problem != "" {
+			return fail(problem)
+		}
+	}
+
+	if strings.HasPrefix(domain, "[") {
+		if problem := domainLiteralProblem(domain); problem != "" {
+			return fail(problem)
+		}
+		warn(EmailIPLiteral, "The address is at an IP address rather than a domain", "")
+		if len(local)+1+len(domain) > maxEmailLength {
+			return fail(fmt.Sprintf("the address is longer than %d characters", maxEmailLength))
+		}
+		return result
+	}
+	ascii, problem := asciiDomain(domain)
+	if problem != "" {
+		return fail(problem)
+	}
+	if len(local)+1+len(ascii) > maxEmailLength {
+		return fail(fmt.Sprintf("the address is longer than %d characters", maxEmailLength))
+	}
+
+	if isDisposableDomain(ascii) {
+		warn(EmailDisposable, ascii+" is a disposable email provider", "")
+	}
+	if provider := likelyProvider(ascii); provider != "" {
+		warn(EmailPossibleTypo, "Did you mean "+local+"@"+provider+"?", local+"@"+provider)
+	}
+	return result
+}
+
+// dotAtomProblem describes what is wrong with an unquoted local part.
+func dotAtomProblem(local string) string {
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return "the part before @ must not start or end with a dot or have two in a row"
+	}
+	for _, r := range local {
+		if r < 0x80 && !isASCIIAlnum(r) && r != '.' && !strings.ContainsRune(localAtext, r) {
+			return fmt.Sprintf("%q is not allowed before @ unless the part is quoted", r)
+		}
+		if r >= 0x80 && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+			return fmt.Sprintf("%q is not allowed before @", r)
+		}
+	}
+	return ""
+}
+
+// quotedLocalProblem describes what is wrong with a quoted local part.
+func quotedLocalProblem(local string) string {
+	if len(local) < 2 || !strings.HasSuffix(local, `"`) {
+		return "the quoted part before @ is not closed"
+	}
+	inner := local[1 : len(local)-1]
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case c == '\\':
+			i++
+			if i == len(inner) || inner[i] < 32 || inner[i] > 126 {
+				return "the quoted part before @ has an incomplete escape"
+			}
+		case c == '"':
+			return "a quote inside the quoted part before @ must be escaped"
+		case c < 32 || c > 126:
+			return "the quoted part before @ may only use printable ASCII"
+		}
+	}
+	return ""
+}
+
+// domainLiteralProblem describes what is wrong with an address literal
+// such as [192.0.2.1] or [IPv6:2001:db8::1].
+func domainLiteralProblem(domain string) string {
+	if !strings.HasSuffix(domain, "]") {
+		return "the IP address after @ is not closed with ]"
+	}
+	literal := domain[1 : len(domain)-1]
+	if v6, ok := strings.CutPrefix(literal, "IPv6:"); ok {
+		if addr, err := netip.ParseAddr(v6); err != nil || !addr.Is6() {
+			return "the IPv6 address after @ is malformed"
+		}
+		return ""
+	}
+	if addr, err := netip.ParseAddr(literal); err != nil || !addr.Is4() {
+		return "the IP address after @ must be IPv4 or start with IPv6:"
+	}
+	return ""
+}
+
+// asciiDomain checks a domain and returns its lower-case ASCII form, with
+// internationalized labels in punycode, or a description of the problem.
+// Labels are checked where they lie in the domain, and only a domain with
+// internationalized labels is put together again.
+func asciiDomain(domain string) (string, string) {
+	if domain == "" {
+		return "", "the domain after @ is empty"
+	}
+	lower := strings.ToLower(domain)
+	if !strings.Contains(lower, ".") {
+		return "", "the domain after @ needs a dot, as in example.com"
+	}
+	tld, international := "", false
+	for label := range strings.SplitSeq(lower, ".") {
+		if label == "" {
+			return "", "the domain after @ has an empty part"
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", fmt.Sprintf("domain part %q must not start or end with a hyphen", label)
+		}
+		ascii := true
+		for _, r := range label {
+			switch {
+			case r < 0x80 && (isASCIIAlnum(r) || r == '-'):
+			case r >= 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)):
+				ascii = false
+			default:
+				return "", fmt.Sprintf("%q is not allowed in the domain", r)
+			}
+		}
+		tld = label
+		if !ascii {
+			tld, international = "xn--"+punycode(label), true
+		}
+		if len(tld) > maxLabelLength {
+			return "", fmt.Sprintf("domain part %q is longer than %d characters", label, maxLabelLength)
+		}
+	}
+	if len(tld) < 2 || strings.Trim(tld, "0123456789") == "" {
+		return "", "the domain must end in a top-level domain of two or more letters"
+	}
+	ascii := lower
+	if international {
+		labels := strings.Split(lower, ".")
+		for i, label := range labels {
+			if !isASCII(label) {
+				labels[i] = "xn--" + punycode(label)
+			}
+		}
+		ascii = strings.Join(labels, ".")
+	}
+	if len(ascii) > maxDomainLength {
+		return "", fmt.Sprintf("the domain is longer than %d characters", maxDomainLength)
+	}
+	return ascii, ""
+}
+
+// isDisposableDomain reports whether a domain or one it is under is a
+// disposable provider.
+func isDisposableDomain(domain string) bool {
+	for {
+		if disposableEmailDomains[domain] {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return false
+		}
+		domain = parent
+	}
+}
+
+// commonEmailProviderSet holds commonEmailProviders, so the providers
+// themselves are told apart without comparing them all.
+var commonEmailProviderSet = func() map[string]bool {
+	set := make(map[string]bool, len(commonEmailProviders))
+	for _, provider := range commonEmailProviders {
+		set[provider] = true
+	}
+	return set
+}()
+
+// commonEmailProviderRunes are commonEmailProviders as runes, for
+// osaDistance.
+var commonEmailProviderRunes = func() [][]rune {
+	runes := make([][]rune, len(commonEmailProviders))
+	for i, provider := range commonEmailProviders {
+		runes[i] = []rune(provider)
+	}
+	return runes
+}()
+
+// likelyProvider is the common provider a domain is most likely a typo of:
+// one or two edits away (one for short domains), "" for the providers
+// themselves and anything further off.
+func likelyProvider(domain string) string {
+	if commonEmailProviderSet[domain] {
+		return ""
+	}
+	var buf [editDistanceBuffer]rune
+	runes := appendRunes(buf[:0], domain)
+	best, bestDistance := "", math.MaxInt
+	for i, provider := range commonEmailProviderRunes {
+		limit := 2
+		if len(provider) < 8 {
+			limit = 1
+		}
+		if distance := osaDistance(runes, provider, min(limit, bestDistance-1)); distance <= limit && distance < bestDistance {
+			best, bestDistance = commonEmailProviders[i], distance
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance: the insertions,
+// deletions, substitutions and swaps of adjacent characters that turn a into
+// b.
+func editDistance(a, b string) int {
+	return boundedEditDistance(a, b, math.MaxInt-1)
+}
+
+// editDistanceBuffer is the length of string, in runes, the edit distances
+// are worked out for without allocating.
+const editDistanceBuffer = 32
+
+// boundedEditDistance is editDistance(a, b) when that is at most limit, and
+// limit+1 when it is more.
+func boundedEditDistance(a, b string, limit int) int {
+	var aBuf, bBuf [editDistanceBuffer]rune
+	return osaDistance(appendRunes(aBuf[:0], a), appendRunes(bBuf[:0], b), limit)
+}
+
+// osaDistance is boundedEditDistance of runes. Only the cells of the
+// distance matrix within limit of its diagonal can be at most limit, so
+// each row is worked out across that band, with the cells either side of it
+// standing for "more than limit"; it gives up once a whole band is over
+// limit. Three rows are kept, all the next row needs.
+func osaDistance(s, t []rune, limit int) int {
+	if len(s)-len(t) > limit || len(t)-len(s) > limit {
+		return limit + 1
+	}
+
+	var rowBuf [3][editDistanceBuffer + 1]int
+	var twoBack, previous, current []int
+	if len(t) < editDistanceBuffer {
+		twoBack, previous, current = rowBuf[0][:len(t)+1], rowBuf[1][:len(t)+1], rowBuf[2][:len(t)+1]
+	} else {
+		twoBack, previous, current = make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)
+	}
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		first, last := 1, len(t)
+		if i-limit > first {
+			first = i - limit
+		}
+		if limit < len(t)-i {
+			last = i + limit
+		}
+		best := limit + 1
+		if first == 1 {
+			current[0], best = i, i
+		} else {
+			current[first-1] = limit + 1
+		}
+		for j := first; j <= last; j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				current[j] = min(current[j], twoBack[j-2]+1)
+			}
+			best = min(best, current[j])
+		}
+		if last < len(t) {
+			current[last+1] = limit + 1
+		}
+		if best > limit {
+			return limit + 1
+		}
+		twoBack, previous, current = previous, current, twoBack
+	}
+	return min(previous[len(t)], limit+1)
+}
+
+// appendRunes appends the runes of s to runes, as []rune(s) would make.
+func appendRunes(runes []rune, s string) []rune {
+	for _, r := range s {
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// punycode encodes a lower-case label by RFC 3492, without the xn-- prefix.
+func punycode(label string) string {
+	const (
+		base        = 36
+		tmin, tmax  = 1, 26
+		skew, damp  = 38, 700
+		initialBias = 72
+		initialN    = 128
+	)
+	adapt := func(delta, points int, first bool) int {
+		if first {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / points
+		k := 0
+		for delta > (base-tmin)*tmax/2 {
+			delta /= base - tmin
+			k += base
+		}
+		return k + (base-tmin+1)*delta/(delta+skew)
+	}
+	digit := func(d int) byte {
+		if d < 26 {
+			return byte('a' + d)
+		}
+		return byte('0' + d - 26)
+	}
+
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < 0x80 {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := initialN, 0, initialBias
+	for handled < len(runes) {
+		next := math.MaxInt32
+		for _, r := range runes {
+			if int(r) >= n && int(r) < next {
+				next = int(r)
+			}
+		}
+		delta += (next - n) * (handled + 1)
+		n = next
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := base; ; k += base {
+				t := min(max(k-bias, tmin), tmax)
+				if q < t {
+					break
+				}
+				out = append(out, digit(t+(q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			out = append(out, digit(q))
+			bias = adapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestValidateEmail tests RFC 5321 syntax errors
+func TestValidateEmail(t *testing.T) {
+	for _, tt := range []struct {
+		email string
+		valid bool
+		error string
+	}{
+		{"john.doe@example.com", true, ""},
+		{"o'brien+news@example.co.uk", true, ""},
+		{"josé@bücher.de", true, ""},
+		{`"john doe"@example.com`, true, ""},
+		{`"a\"b@c"@example.com`, true, ""},
+		{"admin@[192.0.2.1]", true, ""},
+		{"admin@[IPv6:2001:db8::1]", true, ""},
+		{"invalid-email", false, "Invalid email format"},
+		{"@example.com", false, "before @ is empty"},
+		{"john..doe@example.com", false, "two in a row"},
+		{".john@example.com", false, "start or end with a dot"},
+		{"john doe@example.com", false, "unless the part is quoted"},
+		{`"john@example.com`, false, "not closed"},
+		{`"a"b"@example.com`, false, "must be escaped"},
+		{strings.Repeat("a", 65) + "@example.com", false, "longer than 64"},
+		{"john@", false, "domain after @ is empty"},
+		{"john@localhost", false, "needs a dot"},
+		{"john@example..com", false, "empty part"},
+		{"john@-example.com", false, "hyphen"},
+		{"john@exa_mple.com", false, "not allowed in the domain"},
+		{"john@example.123", false, "top-level domain"},
+		{"john@" + strings.Repeat("a", 64) + ".com", false, "longer than 63"},
+		{"john@[300.1.1.1]", false, "IPv4"},
+	} {
+		result := ValidateEmail(tt.email)
+		if result.Valid != tt.valid || !strings.Contains(strings.Join(result.Errors, "; "), tt.error) {
+			t.Errorf("ValidateEmail(%q) = %+v, want valid %v with %q", tt.email, result, tt.valid, tt.error)
+		}
+	}
+}
+
+// TestValidateEmailWarnings tests disposable domains, typo suggestions and
+// unusual but valid forms
+func TestValidateEmailWarnings(t *testing.T) {
+	for _, tt := range []struct {
+		email      string
+		code       string
+		suggestion string
+	}{
+		{"ann@mailinator.com", EmailDisposable, ""},
+		{"ann@inbox.yopmail.com", EmailDisposable, ""},
+		{"ann@gmial.com", EmailPossibleTypo, "ann@gmail.com"},
+		{"ann@gmail.con", EmailPossibleTypo, "ann@gmail.com"},
+		{"ann@hotmial.co.uk", EmailPossibleTypo, "ann@hotmail.co.uk"},
+		{`"ann"@example.com`, EmailQuotedLocal, ""},
+		{"ann@[192.0.2.1]", EmailIPLiteral, ""},
+	} {
+		result := ValidateEmail(tt.email)
+		if !result.Valid || len(result.Warnings) != 1 || result.Warnings[0].Code != tt.code || result.Warnings[0].Suggestion != tt.suggestion {
+			t.Errorf("ValidateEmail(%q) = %+v, want a valid address warned %s %q", tt.email, result, tt.code, tt.suggestion)
+		}
+	}
+	for _, email := range []string{"ann@gmail.com", "ann@mail.com", "ann@example.com", "ann@ymail.com"} {
+		if result := ValidateEmail(email); len(result.Warnings) != 0 {
+			t.Errorf("ValidateEmail(%q) warned %+v", email, result.Warnings)
+		}
+	}
+
+	user := testUsers[0]
+	user.Email = "john@yahooo.com"
+	if result := ValidateUser(user); !result.Valid || len(result.Warnings) != 1 || result.Warnings[0].Field != "email" {
+		t.Errorf("Expected ValidateUser to pass the typo warning on, got %+v", result)
+	}
+}
+
+// TestPunycode tests encoding internationalized domain labels
+func TestPunycode(t *testing.T) {
+	for label, want := range map[string]string{
+		"bücher":  "bcher-kva",
+		"münchen": "mnchen-3ya",
+		"例え":      "r8jz45g",
+		"ü":       "tda",
+	} {
+		if got := punycode(label); got != want {
+			t.Errorf("punycode(%q) = %q, want %q", label, got, want)
+		}
+	}
+	if ascii, problem := asciiDomain("Bücher.DE"); ascii != "xn--bcher-kva.de" || problem != "" {
+		t.Errorf("Expected the punycode domain, got %q %q", ascii, problem)
+	}
+}
+
+// fullEditDistance is the optimal string alignment distance worked out over
+// the whole matrix, to check the banded one against.
+func fullEditDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
+
+// TestBoundedEditDistance tests the banded distance against the whole
+// matrix, for strings longer than its buffers too
+func TestBoundedEditDistance(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	alphabet := []rune("abcü")
+	word := func(length int) []rune {
+		runes := make([]rune, length)
+		for i := range runes {
+			runes[i] = alphabet[rng.IntN(len(alphabet))]
+		}
+		return runes
+	}
+	for range 2000 {
+		s, u := word(rng.IntN(40)), word(rng.IntN(40))
+		if rng.IntN(2) == 0 {
+			// Near misses, as likelyProvider and search compare
+			prefix := rng.IntN(len(s) + 1)
+			u = append(s[:prefix:prefix], word(rng.IntN(3))...)
+		}
+		a, b := string(s), string(u)
+		want := fullEditDistance(a, b)
+		if got := editDistance(a, b); got != want {
+			t.Fatalf("editDistance(%q, %q) = %d, want %d", a, b, got, want)
+		}
+		for limit := range 4 {
+			if got := boundedEditDistance(a, b, limit); got != min(want, limit+1) {
+				t.Fatalf("boundedEditDistance(%q, %q, %d) = %d, want %d", a, b, limit, got, min(want, limit+1))
+			}
+		}
+	}
+	if got := boundedEditDistance("ab", "ba", math.MaxInt-1); got != 1 {
+		t.Errorf("Expected a swap to be one edit, got %d", got)
+	}
+}
+
+func BenchmarkValidateEmail(b *testing.B) {
+	for name, email := range map[string]string{
+		"plain":         "john.doe@example.com",
+		"typo":          "john.doe@gmial.com",
+		"international": "jörg@bücher.de",
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				ValidateEmail(email)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "time"
+
+// Shared event buffering - an EventBuffer collects behavior events on the
+// page so they reach POST /api/events in batches instead of a request per
+// click. Track stamps an event without a time and turns away invalid ones,
+// so a flushed batch passes the server's validation; Ready says a batch is
+// due and Flush hands over up to one request's worth, oldest first. If the
+// page stops flushing, the oldest events are dropped beyond
+// maxBufferedEvents. trackEventWasm and flushEventsWasm keep the page's
+// buffer.
+
+// maxEventsPerRequest is how many events one POST /api/events may record.
+const maxEventsPerRequest = 1000
+
+// DefaultEventBatchSize is how many events make a batch due.
+const DefaultEventBatchSize = 20
+
+// maxBufferedEvents is how many events a buffer holds.
+const maxBufferedEvents = 5 * maxEventsPerRequest
+
+// EventBuffer holds tracked events until they are flushed. BatchSize is how
+// many make Ready report true, DefaultEventBatchSize when 0.
+type EventBuffer struct {
+	BatchSize int
+
+	events []BehaviorEvent
+}
+
+// Track buffers an event, timed now if it has no time; an invalid event is
+// not buffered.
+func (buffer *EventBuffer) Track(event BehaviorEvent, now time.Time) ValidationResult {
+	if event.Time == "" {
+		event.Time = now.UTC().Format(time.RFC3339)
+	}
+	result := ValidateBehaviorEvent(event)
+	if !result.Valid {
+		return result
+	}
+	buffer.events = append(buffer.events, event)
+	if extra := len(buffer.events) - maxBufferedEvents; extra > 0 {
+		buffer.events = buffer.events[extra:]
+	}
+	return result
+}
+
+// Len is how many events are buffered.
+func (buffer *EventBuffer) Len() int {
+	return len(buffer.events)
+}
+
+// Ready reports whether a batch is due.
+func (buffer *EventBuffer) Ready() bool {
+	size := buffer.BatchSize
+	if size <= 0 {
+		size = DefaultEventBatchSize
+	}
+	return len(buffer.events) >= size
+}
+
+// Flush removes and returns the oldest events, at most
+// maxEventsPerRequest; with none buffered it returns none.
+func (buffer *EventBuffer) Flush() []BehaviorEvent {
+	n := min(len(buffer.events), maxEventsPerRequest)
+	batch := make([]BehaviorEvent, n)
+	copy(batch, buffer.events)
+	buffer.events = buffer.events[n:]
+	return batch
+}
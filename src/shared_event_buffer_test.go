@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEventBuffer tests stamping, rejecting, batching and flushing events
+func TestEventBuffer(t *testing.T) {
+	buffer := &EventBuffer{BatchSize: 2}
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	if result := buffer.Track(BehaviorEvent{Type: EventClicked, SessionID: "s"}, now); !result.Valid {
+		t.Fatalf("Expected a click to be tracked, got %v", result.Errors)
+	}
+	if result := buffer.Track(BehaviorEvent{Type: "hovered", SessionID: "s"}, now); result.Valid || buffer.Len() != 1 {
+		t.Errorf("Expected an unknown type to be turned away, got %d buffered", buffer.Len())
+	}
+	if buffer.Ready() {
+		t.Error("Expected no batch due after one event")
+	}
+	buffer.Track(BehaviorEvent{Type: EventViewed, UserID: 1, ProductID: 2, Time: "2024-01-01T09:00:00Z"}, now)
+	if !buffer.Ready() {
+		t.Error("Expected a batch due after two events")
+	}
+
+	batch := buffer.Flush()
+	if len(batch) != 2 || batch[0].Time != "2024-01-01T10:00:00Z" || batch[1].Time != "2024-01-01T09:00:00Z" || buffer.Len() != 0 {
+		t.Errorf("Unexpected batch %+v, %d left", batch, buffer.Len())
+	}
+	if empty := buffer.Flush(); len(empty) != 0 {
+		t.Errorf("Expected an empty flush, got %+v", empty)
+	}
+}
+
+// TestEventBufferLimits tests the request-sized batches and dropping the
+// oldest events of a full buffer
+func TestEventBufferLimits(t *testing.T) {
+	buffer := &EventBuffer{}
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for id := 1; id <= maxBufferedEvents+1; id++ {
+		buffer.Track(BehaviorEvent{Type: EventViewed, UserID: id}, now)
+	}
+	if buffer.Len() != maxBufferedEvents {
+		t.Fatalf("Expected %d buffered, got %d", maxBufferedEvents, buffer.Len())
+	}
+	batch := buffer.Flush()
+	if len(batch) != maxEventsPerRequest || batch[0].UserID != 2 {
+		t.Errorf("Expected a request's worth from the second event, got %d from user %d", len(batch), batch[0].UserID)
+	}
+}
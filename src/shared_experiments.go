@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Shared A/B experiments - AssignVariant puts a user in one of an
+// experiment's variants by hashing the experiment key with the user ID, so
+// the same user always lands in the same variant, on the server and in the
+// browser alike, without anything stored. AnalyzeExperiment then compares
+// how the variants' users ordered: a user converts with an order that wasn't
+// cancelled, placed on or after the experiment's start. The first variant is
+// the control; each other variant's lift is its conversion rate relative to
+// the control's, with a 95% confidence interval from the normal
+// approximation to the difference of the two rates. The server assigns and
+// analyzes at /api/experiments/assign and /api/experiments/analyze;
+// assignVariantWasm and analyzeExperimentWasm do the same on the page.
+
+// maxExperimentVariants is the most variants an experiment may have.
+const maxExperimentVariants = 10
+
+// maxVariantWeight is the largest weight a variant may have.
+const maxVariantWeight = 1000
+
+// experimentZ is the normal quantile of a two-sided 95% interval.
+const experimentZ = 1.959964
+
+// Experiment is an A/B test: its key and variants, the first the control,
+// and optionally the date (YYYY-MM-DD) orders start counting from.
+type Experiment struct {
+	Key      string              `json:"key"`
+	Variants []ExperimentVariant `json:"variants"`
+	Start    string              `json:"start,omitempty"`
+}
+
+// ExperimentVariant is one arm of an experiment. Weight is its share of the
+// users relative to the other variants'; 0 counts as 1.
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// VariantResult is how a variant's users converted. Lift is the relative
+// change in conversion rate from the control, LiftLower and LiftUpper its
+// 95% interval; Significant is whether the interval leaves out no change.
+// The control's lift is 0, as is every lift when no control user converted.
+type VariantResult struct {
+	Variant        string  `json:"variant"`
+	Users          int     `json:"users"`
+	Conversions    int     `json:"conversions"`
+	ConversionRate float64 `json:"conversion_rate"`
+	Revenue        float64 `json:"revenue"`
+	RevenuePerUser float64 `json:"revenue_per_user"`
+	Lift           float64 `json:"lift"`
+	LiftLower      float64 `json:"lift_lower"`
+	LiftUpper      float64 `json:"lift_upper"`
+	Significant    bool    `json:"significant"`
+}
+
+// ExperimentResult is the analysis of an experiment, variants in the
+// experiment's order.
+type ExperimentResult struct {
+	Experiment string          `json:"experiment"`
+	Control    string          `json:"control"`
+	Variants   []VariantResult `json:"variants"`
+}
+
+// ValidateExperiment checks an experiment has a key, 2 to
+// maxExperimentVariants variants with distinct names and weights from 0 to
+// maxVariantWeight, and a valid start date if any.
+func ValidateExperiment(experiment Experiment) ValidationResult {
+	result := newValidationResult()
+	if experiment.Key == "" {
+		result.AddError("key", CodeRequired, "Experiment key is required")
+	}
+	if len(experiment.Variants) < 2 || len(experiment.Variants) > maxExperimentVariants {
+		result.AddError("variants", CodeOutOfRange, fmt.Sprintf("An experiment needs 2 to %d variants", maxExperimentVariants))
+	}
+	seen := map[string]bool{}
+	for i, variant := range experiment.Variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		if variant.Name == "" {
+			result.AddError(field+".name", CodeRequired, "Variant name is required")
+		} else if seen[variant.Name] {
+			result.AddError(field+".name", CodeDuplicate, fmt.Sprintf("Variant %q is listed twice", variant.Name))
+		}
+		seen[variant.Name] = true
+		if variant.Weight < 0 || variant.Weight > maxVariantWeight {
+			result.AddError(field+".weight", CodeOutOfRange, fmt.Sprintf("Variant weight must be from 0 to %d", maxVariantWeight))
+		}
+	}
+	if experiment.Start != "" {
+		if _, err := time.Parse(taxDateLayout, experiment.Start); err != nil {
+			result.AddError("start", CodeInvalidFormat, "Start must be a YYYY-MM-DD date")
+		}
+	}
+	return result
+}
+
+// weight is a variant's weight, 0 counting as 1.
+func (variant ExperimentVariant) weight() int {
+	if variant.Weight == 0 {
+		return 1
+	}
+	return variant.Weight
+}
+
+// AssignVariant is the variant a user is in, from an FNV-1a hash of the
+// experiment key and user ID; a valid experiment always assigns one.
+func AssignVariant(experiment Experiment, userID int) string {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variant.weight()
+	}
+	if total == 0 {
+		return ""
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(experiment.Key + ":" + strconv.Itoa(userID)))
+	slot := int(hash.Sum64() % uint64(total))
+	for _, variant := range experiment.Variants {
+		if slot < variant.weight() {
+			return variant.Name
+		}
+		slot -= variant.weight()
+	}
+	return ""
+}
+
+// AnalyzeExperiment assigns every user and compares the variants'
+// conversion and revenue, in DefaultCurrency, from their orders. Orders of
+// unknown users are left out. The experiment must be valid.
+func AnalyzeExperiment(experiment Experiment, users []User, orders []Order) ExperimentResult {
+	result := ExperimentResult{Experiment: experiment.Key, Variants: []VariantResult{}}
+	if len(experiment.Variants) == 0 {
+		return result
+	}
+	result.Control = experiment.Variants[0].Name
+
+	index := map[string]int{}
+	for i, variant := range experiment.Variants {
+		index[variant.Name] = i
+		result.Variants = append(result.Variants, VariantResult{Variant: variant.Name})
+	}
+	assigned := map[int]int{}
+	for _, user := range users {
+		if _, seen := assigned[user.ID]; seen {
+			continue
+		}
+		assigned[user.ID] = index[AssignVariant(experiment, user.ID)]
+		result.Variants[assigned[user.ID]].Users++
+	}
+
+	converted := map[int]bool{}
+	revenue := make([]float64, len(result.Variants))
+	for _, order := range orders {
+		at, known := assigned[order.UserID]
+		if !known || order.Status == "cancelled" || (experiment.Start != "" && order.OrderDate < experiment.Start) {
+			continue
+		}
+		revenue[at] += riskAmount(order)
+		if !converted[order.UserID] {
+			converted[order.UserID] = true
+			result.Variants[at].Conversions++
+		}
+	}
+
+	for i := range result.Variants {
+		variant := &result.Variants[i]
+		variant.Revenue = RoundToCurrency(revenue[i], DefaultCurrency)
+		if variant.Users > 0 {
+			variant.ConversionRate = roundRatio(float64(variant.Conversions) / float64(variant.Users))
+			variant.RevenuePerUser = RoundToCurrency(revenue[i]/float64(variant.Users), DefaultCurrency)
+		}
+	}
+	control := result.Variants[0]
+	for i := 1; i < len(result.Variants); i++ {
+		variant := &result.Variants[i]
+		variant.Lift, variant.LiftLower, variant.LiftUpper = conversionLift(control.Conversions, control.Users, variant.Conversions, variant.Users)
+		variant.Significant = variant.LiftLower > 0 || variant.LiftUpper < 0
+	}
+	return result
+}
+
+// conversionLift is the relative lift of a variant's conversion rate over
+// the control's and its 95% interval; all 0 without a control conversion or
+// variant users.
+func conversionLift(controlConversions, controlUsers, conversions, users int) (lift, lower, upper float64) {
+	if controlConversions == 0 || users == 0 {
+		return 0, 0, 0
+	}
+	pc := float64(controlConversions) / float64(controlUsers)
+	pv := float64(conversions) / float64(users)
+	margin := experimentZ * math.Sqrt(pc*(1-pc)/float64(controlUsers)+pv*(1-pv)/float64(users))
+	diff := pv - pc
+	return roundRatio(diff / pc), roundRatio((diff - margin) / pc), roundRatio((diff + margin) / pc)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAssignVariant tests that assignment is deterministic and follows the
+// weights
+func TestAssignVariant(t *testing.T) {
+	experiment := Experiment{Key: "checkout-button", Variants: []ExperimentVariant{{Name: "control", Weight: 3}, {Name: "green", Weight: 1}}}
+	counts := map[string]int{}
+	for id := 1; id <= 10000; id++ {
+		variant := AssignVariant(experiment, id)
+		if again := AssignVariant(experiment, id); again != variant {
+			t.Fatalf("User %d assigned %q then %q", id, variant, again)
+		}
+		counts[variant]++
+	}
+	if math.Abs(float64(counts["control"])/10000-0.75) > 0.02 || counts["control"]+counts["green"] != 10000 {
+		t.Errorf("Expected about 3 in 4 users in control, got %v", counts)
+	}
+
+	other := Experiment{Key: "search-ranking", Variants: experiment.Variants}
+	differ := 0
+	for id := 1; id <= 100; id++ {
+		if AssignVariant(other, id) != AssignVariant(experiment, id) {
+			differ++
+		}
+	}
+	if differ == 0 {
+		t.Error("Expected another experiment key to assign differently")
+	}
+}
+
+// TestValidateExperiment tests the experiment checks
+func TestValidateExperiment(t *testing.T) {
+	valid := Experiment{Key: "k", Variants: []ExperimentVariant{{Name: "a"}, {Name: "b", Weight: 2}}, Start: "2023-05-01"}
+	if result := ValidateExperiment(valid); !result.Valid {
+		t.Fatalf("Expected a valid experiment, got %v", result.Errors)
+	}
+	for name, experiment := range map[string]Experiment{
+		"no key":         {Variants: valid.Variants},
+		"one variant":    {Key: "k", Variants: valid.Variants[:1]},
+		"duplicate":      {Key: "k", Variants: []ExperimentVariant{{Name: "a"}, {Name: "a"}}},
+		"unnamed":        {Key: "k", Variants: []ExperimentVariant{{Name: "a"}, {}}},
+		"negative":       {Key: "k", Variants: []ExperimentVariant{{Name: "a"}, {Name: "b", Weight: -1}}},
+		"bad start date": {Key: "k", Variants: valid.Variants, Start: "May 1"},
+	} {
+		if ValidateExperiment(experiment).Valid {
+			t.Errorf("%s: expected the experiment to be invalid", name)
+		}
+	}
+}
+
+// TestAnalyzeExperiment tests conversion, revenue and lift
+func TestAnalyzeExperiment(t *testing.T) {
+	experiment := Experiment{Key: "banner", Variants: []ExperimentVariant{{Name: "control"}, {Name: "banner"}}, Start: "2023-05-01"}
+	var users []User
+	var orders []Order
+	perVariant := map[string]int{}
+	for id := 1; id <= 2000; id++ {
+		users = append(users, User{ID: id})
+		variant := AssignVariant(experiment, id)
+		perVariant[variant]++
+		// Every 10th control user and every 5th banner user orders
+		every := 10
+		if variant == "banner" {
+			every = 5
+		}
+		if perVariant[variant]%every == 0 {
+			orders = append(orders, Order{ID: id, UserID: id, Total: 10, Currency: DefaultCurrency, OrderDate: "2023-05-02", Status: "delivered"})
+		}
+	}
+	// Before the start, cancelled and of an unknown user: none count
+	orders = append(orders,
+		Order{ID: 9001, UserID: 1, Total: 10, OrderDate: "2023-04-30"},
+		Order{ID: 9002, UserID: 2, Total: 10, OrderDate: "2023-05-02", Status: "cancelled"},
+		Order{ID: 9003, UserID: 99999, Total: 10, OrderDate: "2023-05-02"},
+	)
+
+	result := AnalyzeExperiment(experiment, users, orders)
+	if result.Control != "control" || len(result.Variants) != 2 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	control, banner := result.Variants[0], result.Variants[1]
+	if control.Users+banner.Users != 2000 || control.Conversions != control.Users/10 || banner.Conversions != banner.Users/5 {
+		t.Fatalf("Unexpected conversions %+v %+v", control, banner)
+	}
+	if control.Lift != 0 || control.Significant {
+		t.Errorf("Expected no lift for the control, got %+v", control)
+	}
+	if !floatEqual(banner.Lift, 1, 0.05) || !banner.Significant || banner.LiftLower >= banner.Lift || banner.LiftUpper <= banner.Lift {
+		t.Errorf("Expected banner to about double conversion, got %+v", banner)
+	}
+	if banner.Revenue != float64(banner.Conversions)*10 {
+		t.Errorf("Expected revenue of %d orders, got %v", banner.Conversions, banner.Revenue)
+	}
+
+	none := AnalyzeExperiment(experiment, users, nil)
+	if none.Variants[1].Lift != 0 || none.Variants[1].Significant {
+		t.Errorf("Expected no lift without conversions, got %+v", none.Variants[1])
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Shared export formatting - the server's /api/export endpoints and the
+// WebAssembly exportCsvWasm function build identical tables from this code
+
+// ExportTable is a dataset flattened into columns and typed cell values.
+// Cells hold string, int, float64 or bool so spreadsheet formats can keep
+// numbers numeric; CSV renders them with FormatExportCell.
+type ExportTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ExportDatasets lists the dataset names accepted by ExportTableFromJSON.
+var ExportDatasets = []string{"users", "products", "orders", "analytics"}
+
+// UsersExportTable flattens users into one row per user.
+func UsersExportTable(users []User) ExportTable {
+	table := ExportTable{
+		Name:    "users",
+		Columns: []string{"id", "name", "email", "age", "country", "premium", "join_date", "phone"},
+	}
+	for _, user := range users {
+		table.Rows = append(table.Rows, []interface{}{
+			user.ID, user.Name, user.Email, user.Age, user.Country, user.Premium, user.JoinDate, user.Phone,
+		})
+	}
+	return table
+}
+
+// ProductsExportTable flattens products into one row per product.
+func ProductsExportTable(products []Product) ExportTable {
+	table := ExportTable{
+		Name:    "products",
+		Columns: []string{"id", "name", "category", "price", "on_hand", "reserved", "rating", "description"},
+	}
+	for _, product := range products {
+		table.Rows = append(table.Rows, []interface{}{
+			product.ID, product.Name, product.Category, product.Price, product.OnHand, product.Reserved, product.Rating, product.Description,
+		})
+	}
+	return table
+}
+
+// OrdersExportTable flattens orders into one row per order, listing line
+// items as "name x quantity" separated by semicolons.
+func OrdersExportTable(orders []Order) ExportTable {
+	table := ExportTable{
+		Name: "orders",
+		Columns: []string{
+			"id", "user_id", "order_date", "status", "items", "item_count",
+			"subtotal", "discount", "tax", "shipping", "total",
+		},
+	}
+	for _, order := range orders {
+		items := make([]string, len(order.Products))
+		count := 0
+		for i, product := range order.Products {
+			quantity := 0
+			if i < len(order.Quantities) {
+				quantity = order.Quantities[i]
+			}
+			items[i] = fmt.Sprintf("%s x %d", product.Name, quantity)
+			count += quantity
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			order.ID, order.UserID, order.OrderDate, order.Status, strings.Join(items, "; "), count,
+			order.Subtotal, order.Discount, order.Tax, order.Shipping, order.Total,
+		})
+	}
+	return table
+}
+
+// AnalyticsExportTable lists the analytics summary as metric/value rows.
+func AnalyticsExportTable(analytics UserAnalytics) ExportTable {
+	countries := make([]string, len(analytics.TopCountries))
+	for i, country := range analytics.TopCountries {
+		countries[i] = country.Country
+	}
+	return ExportTable{
+		Name:    "analytics",
+		Columns: []string{"metric", "value"},
+		Rows: [][]interface{}{
+			{"average_age", analytics.AverageAge},
+			{"premium_percentage", analytics.PremiumPercentage},
+			{"total_revenue", analytics.TotalRevenue},
+			{"order_value_mean", analytics.OrderValues.Mean},
+			{"order_value_std_dev", analytics.OrderValues.StdDev},
+			{"order_value_min", analytics.OrderValues.Min},
+			{"order_value_max", analytics.OrderValues.Max},
+			{"order_value_p50", analytics.OrderValues.P50},
+			{"order_value_p90", analytics.OrderValues.P90},
+			{"order_value_p99", analytics.OrderValues.P99},
+			{"top_countries", strings.Join(countries, "; ")},
+			{"average_profile_completeness", analytics.AverageProfileCompleteness},
+			{"incomplete_profiles", analytics.IncompleteProfiles},
+			{"average_churn_probability", analytics.AverageChurnProbability},
+			{"high_churn_risk", analytics.HighChurnRisk},
+			{"total_clv", analytics.TotalCLV},
+			{"average_clv", analytics.AverageCLV},
+			{"anomalous_orders", len(analytics.Anomalies)},
+			{"average_basket_items", analytics.Baskets.AverageItems},
+			{"multi_category_share", analytics.Baskets.MultiCategoryShare},
+		},
+	}
+}
+
+// ExportTableFromJSON builds the table for a dataset from its JSON form: an
+// array of users, products or orders, or a UserAnalytics object.
+func ExportTableFromJSON(dataset string, data []byte) (ExportTable, error) {
+	switch dataset {
+	case "users":
+		var users []User
+		if err := json.Unmarshal(data, &users); err != nil {
+			return ExportTable{}, fmt.Errorf("invalid users JSON: %w", err)
+		}
+		return UsersExportTable(users), nil
+	case "products":
+		var products []Product
+		if err := json.Unmarshal(data, &products); err != nil {
+			return ExportTable{}, fmt.Errorf("invalid products JSON: %w", err)
+		}
+		return ProductsExportTable(products), nil
+	case "orders":
+		var orders []Order
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return ExportTable{}, fmt.Errorf("invalid orders JSON: %w", err)
+		}
+		return OrdersExportTable(orders), nil
+	case "analytics":
+		var analytics UserAnalytics
+		if err := json.Unmarshal(data, &analytics); err != nil {
+			return ExportTable{}, fmt.Errorf("invalid analytics JSON: %w", err)
+		}
+		return AnalyticsExportTable(analytics), nil
+	default:
+		return ExportTable{}, fmt.Errorf("unknown dataset %q (expected one of %s)", dataset, strings.Join(ExportDatasets, ", "))
+	}
+}
+
+// FormatExportCell renders a cell value as text. Strings that a spreadsheet
+// would evaluate as a formula are prefixed with an apostrophe so exported
+// user data can't inject formulas.
+func FormatExportCell(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// UnescapeExportCell reverses the formula escaping of FormatExportCell, so an
+// exported CSV can be imported again unchanged.
+func UnescapeExportCell(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(text[1])) {
+		return text[1:]
+	}
+	return text
+}
+
+// WriteExportCSV writes the table as RFC 4180 CSV with a header row.
+func WriteExportCSV(w io.Writer, table ExportTable) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = FormatExportCell(row[i])
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportCSV returns the table as a CSV string.
+func ExportCSV(table ExportTable) (string, error) {
+	var buf bytes.Buffer
+	if err := WriteExportCSV(&buf, table); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+// TestExportCSV tests the shared CSV formatting used by server and WASM exports
+func TestExportCSV(t *testing.T) {
+	t.Run("UsersWithHeader", func(t *testing.T) {
+		csvText, err := ExportCSV(UsersExportTable(testUsers))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		records, err := csv.NewReader(strings.NewReader(csvText)).ReadAll()
+		if err != nil {
+			t.Fatalf("Generated CSV does not parse: %v", err)
+		}
+		if len(records) != len(testUsers)+1 {
+			t.Fatalf("Expected header plus %d rows, got %d", len(testUsers), len(records))
+		}
+		if strings.Join(records[0], ",") != "id,name,email,age,country,premium,join_date,phone" {
+			t.Errorf("Unexpected header: %v", records[0])
+		}
+		if strings.Join(records[1], ",") != "1,John Doe,john.doe@example.com,28,US,true,2023-01-15," {
+			t.Errorf("Unexpected first row: %v", records[1])
+		}
+	})
+
+	t.Run("OrderLineItems", func(t *testing.T) {
+		order := Order{ID: 7, UserID: 1, Products: testProducts[:2], Quantities: []int{2, 1}, Total: 12.5}
+		table := OrdersExportTable([]Order{order})
+
+		row := table.Rows[0]
+		if row[4] != testProducts[0].Name+" x 2; "+testProducts[1].Name+" x 1" || row[5] != 3 {
+			t.Errorf("Unexpected line items: %v", row)
+		}
+	})
+
+	t.Run("FormulaInjectionEscaped", func(t *testing.T) {
+		for input, want := range map[string]string{
+			"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+			"+1":                "'+1",
+			"@SUM(A1)":          "'@SUM(A1)",
+			"plain":             "plain",
+		} {
+			if got := FormatExportCell(input); got != want {
+				t.Errorf("FormatExportCell(%q) = %q, want %q", input, got, want)
+			}
+		}
+		// Numbers are never escaped
+		if got := FormatExportCell(-2.5); got != "-2.5" {
+			t.Errorf("Expected negative number unchanged, got %q", got)
+		}
+	})
+
+	t.Run("FromJSON", func(t *testing.T) {
+		table, err := ExportTableFromJSON("analytics", []byte(`{"average_age": 30, "top_countries": [{"country": "US"}, {"country": "CA"}]}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if table.Rows[0][1] != 30.0 || table.Rows[10][1] != "US; CA" {
+			t.Errorf("Unexpected analytics rows: %v", table.Rows)
+		}
+
+		if _, err := ExportTableFromJSON("invoices", []byte(`[]`)); err == nil {
+			t.Error("Expected error for unknown dataset")
+		}
+		if _, err := ExportTableFromJSON("users", []byte(`{`)); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+}
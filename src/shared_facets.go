@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Shared catalog facets - ListProducts filters a catalog by category, price,
+// rating and stock, and counts what each filter option would leave so a
+// sidebar can show "electronics (3)" next to its checkbox. Each facet is
+// counted with every filter but its own applied: choosing a category still
+// lists the other categories, narrowed by the price and rating chosen. The
+// server lists with GET /api/products, the page with filterProductsWasm.
+
+// priceBucketBounds split the price facet: under 25, 25 to 50, 50 to 100,
+// 100 to 250 and 250 up.
+var priceBucketBounds = []float64{25, 50, 100, 250}
+
+// ratingBands are the minimum ratings of the rating facet ("4 and up").
+var ratingBands = []float64{4, 3, 2, 1}
+
+// ProductFilter selects products from a catalog; zero fields don't filter.
+type ProductFilter struct {
+	Category  string  `json:"category,omitempty"`  // any case
+	MinPrice  float64 `json:"min_price,omitempty"` // inclusive
+	MaxPrice  float64 `json:"max_price,omitempty"` // exclusive, so a bucket's bounds select it
+	MinRating float64 `json:"min_rating,omitempty"`
+	InStock   bool    `json:"in_stock,omitempty"`
+}
+
+// FacetCount is how many products a facet value would list.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceBucket is how many products are priced from Min up to Max; Max is 0
+// for the top bucket.
+type PriceBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max,omitempty"`
+	Count int     `json:"count"`
+}
+
+// RatingBand is how many products are rated MinRating or more.
+type RatingBand struct {
+	MinRating float64 `json:"min_rating"`
+	Count     int     `json:"count"`
+}
+
+// Facets are the filter options of a listing with their counts.
+type Facets struct {
+	Categories []FacetCount  `json:"categories"`
+	Prices     []PriceBucket `json:"prices"`
+	Ratings    []RatingBand  `json:"ratings"`
+	InStock    int           `json:"in_stock"`
+}
+
+// ProductListing is the products a filter selects and the facets to narrow
+// them further.
+type ProductListing struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+	Facets   Facets    `json:"facets"`
+}
+
+// ValidateProductFilter checks that prices aren't negative, the price range
+// isn't empty and the rating is from 0 to 5.
+func ValidateProductFilter(filter ProductFilter) ValidationResult {
+	result := newValidationResult()
+	if filter.MinPrice < 0 || math.IsNaN(filter.MinPrice) {
+		result.AddError("min_price", CodeOutOfRange, "Minimum price must not be negative")
+	}
+	if filter.MaxPrice < 0 || math.IsNaN(filter.MaxPrice) {
+		result.AddError("max_price", CodeOutOfRange, "Maximum price must not be negative")
+	} else if filter.MaxPrice > 0 && filter.MaxPrice <= filter.MinPrice {
+		result.AddError("max_price", CodeOutOfRange, fmt.Sprintf("Maximum price must be above the minimum of %g", filter.MinPrice))
+	}
+	if !(filter.MinRating >= 0 && filter.MinRating <= 5) {
+		result.AddError("min_rating", CodeOutOfRange, "Minimum rating must be between 0 and 5")
+	}
+	return result
+}
+
+// matches reports whether a product passes the filter, ignoring one facet's
+// own filter ("category", "price", "rating" or "in_stock") when counting it.
+func (filter ProductFilter) matches(product Product, ignore string) bool {
+	if ignore != "category" && filter.Category != "" && !strings.EqualFold(product.Category, filter.Category) {
+		return false
+	}
+	if ignore != "price" && (product.Price < filter.MinPrice || filter.MaxPrice > 0 && product.Price >= filter.MaxPrice) {
+		return false
+	}
+	if ignore != "rating" && product.Rating < filter.MinRating {
+		return false
+	}
+	if ignore != "in_stock" && filter.InStock && !product.InStock() {
+		return false
+	}
+	return true
+}
+
+// ListProducts filters a catalog, keeping its order, and counts the facets.
+// Categories are listed most products first, then by name.
+func ListProducts(catalog []Product, filter ProductFilter) ProductListing {
+	listing := ProductListing{Products: []Product{}}
+	listing.Facets.Prices = make([]PriceBucket, len(priceBucketBounds)+1)
+	for i := range listing.Facets.Prices {
+		if i > 0 {
+			listing.Facets.Prices[i].Min = priceBucketBounds[i-1]
+		}
+		if i < len(priceBucketBounds) {
+			listing.Facets.Prices[i].Max = priceBucketBounds[i]
+		}
+	}
+	listing.Facets.Ratings = make([]RatingBand, len(ratingBands))
+	for i, band := range ratingBands {
+		listing.Facets.Ratings[i].MinRating = band
+	}
+
+	categories := map[string]int{}
+	for _, product := range catalog {
+		if filter.matches(product, "") {
+			listing.Products = append(listing.Products, product)
+		}
+		if filter.matches(product, "category") {
+			categories[strings.ToLower(product.Category)]++
+		}
+		if filter.matches(product, "price") {
+			bucket := sort.Search(len(priceBucketBounds), func(i int) bool { return priceBucketBounds[i] > product.Price })
+			listing.Facets.Prices[bucket].Count++
+		}
+		if filter.matches(product, "rating") {
+			for i, band := range ratingBands {
+				if product.Rating >= band {
+					listing.Facets.Ratings[i].Count++
+				}
+			}
+		}
+		if filter.matches(product, "in_stock") && product.InStock() {
+			listing.Facets.InStock++
+		}
+	}
+	listing.Total = len(listing.Products)
+
+	listing.Facets.Categories = []FacetCount{}
+	for category, count := range categories {
+		listing.Facets.Categories = append(listing.Facets.Categories, FacetCount{Value: category, Count: count})
+	}
+	sort.Slice(listing.Facets.Categories, func(i, j int) bool {
+		a, b := listing.Facets.Categories[i], listing.Facets.Categories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	return listing
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var facetCatalog = []Product{
+	{ID: 1, Name: "Headphones", Price: 99.99, Category: "electronics", Rating: 4.5, OnHand: 5},
+	{ID: 2, Name: "Phone", Price: 699, Category: "Electronics", Rating: 4.7},
+	{ID: 3, Name: "Book", Price: 25, Category: "books", Rating: 3.9, OnHand: 10},
+	{ID: 4, Name: "Mug", Price: 12, Category: "home", Rating: 2.5, OnHand: 3},
+	{ID: 5, Name: "Cable", Price: 9.99, Category: "electronics", Rating: 4.1, OnHand: 50},
+}
+
+func bucketCounts(listing ProductListing) []int {
+	var counts []int
+	for _, bucket := range listing.Facets.Prices {
+		counts = append(counts, bucket.Count)
+	}
+	return counts
+}
+
+// TestListProducts tests the facets of an unfiltered catalog
+func TestListProducts(t *testing.T) {
+	listing := ListProducts(facetCatalog, ProductFilter{})
+	if listing.Total != 5 || len(listing.Products) != 5 {
+		t.Fatalf("Expected every product, got %d", listing.Total)
+	}
+	want := []FacetCount{{"electronics", 3}, {"books", 1}, {"home", 1}}
+	if !slices.Equal(listing.Facets.Categories, want) {
+		t.Errorf("Categories = %+v, want %+v", listing.Facets.Categories, want)
+	}
+	// A price on a bound falls in the bucket above it
+	if got := bucketCounts(listing); !slices.Equal(got, []int{2, 1, 1, 0, 1}) {
+		t.Errorf("Price buckets = %v", got)
+	}
+	if top := listing.Facets.Prices[4]; top.Min != 250 || top.Max != 0 {
+		t.Errorf("Expected the top bucket open-ended, got %+v", top)
+	}
+	wantRatings := []RatingBand{{4, 3}, {3, 4}, {2, 5}, {1, 5}}
+	if !slices.Equal(listing.Facets.Ratings, wantRatings) {
+		t.Errorf("Ratings = %+v, want %+v", listing.Facets.Ratings, wantRatings)
+	}
+	if listing.Facets.InStock != 4 {
+		t.Errorf("Expected 4 in stock, got %d", listing.Facets.InStock)
+	}
+}
+
+// TestListProductsFiltered tests that each facet ignores its own filter
+func TestListProductsFiltered(t *testing.T) {
+	listing := ListProducts(facetCatalog, ProductFilter{Category: "ELECTRONICS", MaxPrice: 100})
+	var ids []int
+	for _, product := range listing.Products {
+		ids = append(ids, product.ID)
+	}
+	if !slices.Equal(ids, []int{1, 5}) || listing.Total != 2 {
+		t.Fatalf("Expected the headphones and cable, got %v", ids)
+	}
+	// Other categories under 100 are still offered
+	want := []FacetCount{{"electronics", 2}, {"books", 1}, {"home", 1}}
+	if !slices.Equal(listing.Facets.Categories, want) {
+		t.Errorf("Categories = %+v, want %+v", listing.Facets.Categories, want)
+	}
+	// Every electronics price is still offered
+	if got := bucketCounts(listing); !slices.Equal(got, []int{1, 0, 1, 0, 1}) {
+		t.Errorf("Price buckets = %v", got)
+	}
+	if listing.Facets.Ratings[0].Count != 2 || listing.Facets.InStock != 2 {
+		t.Errorf("Expected ratings and stock counted within the filter, got %+v", listing.Facets)
+	}
+
+	listing = ListProducts(facetCatalog, ProductFilter{InStock: true, MinRating: 4})
+	if listing.Total != 2 || listing.Facets.InStock != 2 || listing.Facets.Ratings[1].Count != 3 {
+		t.Errorf("Unexpected in-stock listing %+v", listing)
+	}
+	if listing := ListProducts(nil, ProductFilter{}); listing.Products == nil || listing.Facets.Categories == nil {
+		t.Errorf("Expected empty lists for an empty catalog, got %#v", listing)
+	}
+}
+
+// TestValidateProductFilter tests the filter bounds
+func TestValidateProductFilter(t *testing.T) {
+	if result := ValidateProductFilter(ProductFilter{MinPrice: 25, MaxPrice: 50, MinRating: 4}); !result.Valid {
+		t.Errorf("Expected a valid filter, got %v", result.Errors)
+	}
+	result := ValidateProductFilter(ProductFilter{MinPrice: -1, MinRating: 6})
+	if result.Valid || len(result.FieldErrors) != 2 {
+		t.Errorf("Expected negative price and rating errors, got %+v", result.FieldErrors)
+	}
+	if result := ValidateProductFilter(ProductFilter{MinPrice: 50, MaxPrice: 50}); result.Valid || result.FieldErrors[0].Field != "max_price" {
+		t.Errorf("Expected an empty price range to be rejected, got %+v", result.FieldErrors)
+	}
+}
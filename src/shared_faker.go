@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Shared fake data - the values GenerateDemoData makes its users, products
+// and orders from, drawn so the analytics over them look like a real shop's
+// rather than uniform noise: names from the pool of the user's country,
+// email addresses in the patterns people pick, prices at the usual price
+// points, ratings piled up at four and five stars, sign-ups growing over
+// time, and orders that peak before the holidays and on Sundays and Mondays.
+// It draws from the generator's seeded demoRand, so it adds nothing to what
+// the seed decides.
+
+// fakeNamePool is the given and family names of one language.
+type fakeNamePool struct {
+	given, family []string
+}
+
+var fakeNamePools = map[string]fakeNamePool{
+	"en": {
+		given: []string{"James", "Mary", "Michael", "Jennifer", "David", "Sarah", "Chris", "Emily", "Daniel", "Jessica",
+			"Matthew", "Ashley", "Ryan", "Olivia", "Jack", "Emma", "Thomas", "Sophie", "William", "Charlotte",
+			"Liam", "Grace", "Noah", "Chloe", "Ethan", "Hannah", "Oliver", "Amelia", "Samuel", "Megan"},
+		family: []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Miller", "Davis", "Wilson", "Taylor", "Anderson",
+			"Thomas", "Moore", "Martin", "Thompson", "White", "Harris", "Clark", "Lewis", "Walker", "Hall",
+			"Young", "King", "Wright", "Evans", "Roberts", "Campbell", "Murphy", "O'Brien", "Kelly", "Scott"},
+	},
+	"de": {
+		given: []string{"Lukas", "Leon", "Maximilian", "Felix", "Paul", "Jonas", "Tobias", "Jürgen", "Stefan", "Florian",
+			"Anna", "Lena", "Lea", "Hannah", "Sophie", "Marie", "Julia", "Laura", "Katrin", "Sabine"},
+		family: []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker", "Schulz", "Hoffmann",
+			"Koch", "Richter", "Wolf", "Schröder", "Neumann", "Schwarz", "Zimmermann", "Braun", "Krüger", "Hartmann"},
+	},
+	"fr": {
+		given: []string{"Lucas", "Hugo", "Louis", "Gabriel", "Jules", "Théo", "Antoine", "Nicolas", "Mathieu", "Julien",
+			"Léa", "Chloé", "Manon", "Camille", "Inès", "Élodie", "Mathilde", "Céline", "Margaux", "Juliette"},
+		family: []string{"Martin", "Bernard", "Dubois", "Thomas", "Robert", "Richard", "Petit", "Durand", "Leroy", "Moreau",
+			"Simon", "Laurent", "Lefèvre", "Michel", "Fournier", "Girard", "Bonnet", "Dupont", "Lambert", "Rousseau"},
+	},
+	"es": {
+		given: []string{"Alejandro", "Daniel", "Pablo", "Javier", "Diego", "José", "Andrés", "Miguel", "Carlos", "Mateo",
+			"Sofía", "Lucía", "María", "Valentina", "Carmen", "Isabel", "Camila", "Paula", "Elena", "Ximena"},
+		family: []string{"García", "Rodríguez", "González", "Fernández", "López", "Martínez", "Sánchez", "Pérez", "Gómez", "Ruiz",
+			"Hernández", "Díaz", "Moreno", "Álvarez", "Romero", "Torres", "Ramírez", "Flores", "Castillo", "Vargas"},
+	},
+	"pt": {
+		given: []string{"João", "Pedro", "Lucas", "Gabriel", "Rafael", "Thiago", "Bruno", "Gustavo", "Felipe", "Matheus",
+			"Ana", "Beatriz", "Mariana", "Júlia", "Larissa", "Camila", "Fernanda", "Letícia", "Gabriela", "Luana"},
+		family: []string{"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+			"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Araújo", "Barbosa", "Rocha", "Cardoso"},
+	},
+	"it": {
+		given: []string{"Francesco", "Alessandro", "Lorenzo", "Matteo", "Luca", "Marco", "Giuseppe", "Andrea", "Davide", "Riccardo",
+			"Giulia", "Sofia", "Aurora", "Chiara", "Martina", "Francesca", "Alessia", "Sara", "Elisa", "Valentina"},
+		family: []string{"Rossi", "Russo", "Ferrari", "Esposito", "Bianchi", "Romano", "Colombo", "Ricci", "Marino", "Greco",
+			"Bruno", "Gallo", "Conti", "De Luca", "Mancini", "Costa", "Giordano", "Rizzo", "Lombardi", "Moretti"},
+	},
+	"nl": {
+		given: []string{"Daan", "Sem", "Bram", "Jesse", "Thijs", "Ruben", "Lars", "Sven", "Niels", "Joris",
+			"Emma", "Julia", "Sophie", "Tess", "Sanne", "Femke", "Lotte", "Anouk", "Fleur", "Eva"},
+		family: []string{"de Jong", "Jansen", "de Vries", "van den Berg", "van Dijk", "Bakker", "Janssen", "Visser", "Smit", "Meijer",
+			"de Boer", "Mulder", "de Groot", "Bos", "Vos", "Peters", "Hendriks", "van Leeuwen", "Dekker", "Brouwer"},
+	},
+	"ja": {
+		given: []string{"Haruto", "Yuto", "Sota", "Ren", "Hiroshi", "Takumi", "Kenji", "Naoki", "Daiki", "Kaito",
+			"Yui", "Aoi", "Hina", "Sakura", "Yuki", "Mei", "Ayaka", "Misaki", "Nanami", "Riko"},
+		family: []string{"Sato", "Suzuki", "Takahashi", "Tanaka", "Watanabe", "Ito", "Yamamoto", "Nakamura", "Kobayashi", "Kato",
+			"Yoshida", "Yamada", "Sasaki", "Matsumoto", "Inoue", "Kimura", "Hayashi", "Shimizu", "Mori", "Ikeda"},
+	},
+	"hi": {
+		given: []string{"Aarav", "Vivaan", "Aditya", "Arjun", "Rohan", "Rahul", "Sanjay", "Vikram", "Karan", "Amit",
+			"Priya", "Ananya", "Diya", "Isha", "Kavya", "Neha", "Pooja", "Deepika", "Sneha", "Aditi"},
+		family: []string{"Sharma", "Verma", "Gupta", "Patel", "Singh", "Kumar", "Reddy", "Iyer", "Nair", "Rao",
+			"Mehta", "Joshi", "Shah", "Das", "Banerjee", "Chopra", "Malhotra", "Kapoor", "Agarwal", "Pillai"},
+	},
+}
+
+// fakeNameLanguages picks the name pool of a country by its alpha-2 code;
+// users elsewhere get a name from any pool.
+var fakeNameLanguages = map[string]string{
+	"US": "en", "GB": "en", "CA": "en", "AU": "en", "NZ": "en", "IE": "en", "ZA": "en",
+	"DE": "de", "AT": "de", "CH": "de",
+	"FR": "fr", "BE": "fr", "LU": "fr",
+	"ES": "es", "MX": "es", "AR": "es", "CO": "es", "CL": "es", "PE": "es",
+	"BR": "pt", "PT": "pt",
+	"IT": "it", "NL": "nl", "JP": "ja", "IN": "hi",
+}
+
+// fakeEmailPatterns are the ways a local part is made from a name, weighted
+// by how often people choose them.
+var fakeEmailPatterns = map[string]float64{
+	"first.last":     30,
+	"firstlast":      12,
+	"flast":          12,
+	"first_last":     6,
+	"last.first":     5,
+	"first.l":        5,
+	"first+digits":   15,
+	"firstlast+year": 10,
+	"last+digits":    5,
+}
+
+// fakeEmailDomains are reserved for examples (RFC 2606), so no generated
+// address can reach anyone.
+var fakeEmailDomains = map[string]float64{"example.com": 60, "example.net": 25, "example.org": 15}
+
+// Monthly and weekly order rates, relative to an average day
+var (
+	fakeMonthRates   = [12]float64{0.85, 0.8, 0.9, 0.9, 0.95, 0.95, 1, 0.95, 0.95, 1.05, 1.45, 1.7}
+	fakeWeekdayRates = [7]float64{1.15, 1.1, 0.95, 0.95, 1, 0.85, 1} // Sunday first
+)
+
+// fakeEmailFold spells names in ASCII for email addresses.
+var fakeEmailFold = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ß", "ss",
+)
+
+// faker draws fake values from a seeded generator. It remembers the email
+// addresses it has given out, so each is used once.
+type faker struct {
+	rng      *demoRand
+	emails   map[string]bool
+	patterns demoWeights
+	domains  demoWeights
+}
+
+func newFaker(rng *demoRand) *faker {
+	return &faker{
+		rng:      rng,
+		emails:   map[string]bool{},
+		patterns: newDemoWeights(fakeEmailPatterns),
+		domains:  newDemoWeights(fakeEmailDomains),
+	}
+}
+
+// name draws a given and family name common in country.
+func (f *faker) name(country string) (given, family string) {
+	code := country
+	if c, ok := LookupCountry(country); ok {
+		code = c.Code
+	}
+	pool, ok := fakeNamePools[fakeNameLanguages[code]]
+	if !ok {
+		languages := []string{"de", "en", "es", "fr", "hi", "it", "ja", "nl", "pt"}
+		pool = fakeNamePools[f.rng.pick(languages)]
+	}
+	return f.rng.pick(pool.given), f.rng.pick(pool.family)
+}
+
+// email makes an unused address from a name in one of the common patterns;
+// some take the birth year. A taken address gets a number added.
+func (f *faker) email(given, family string, birthYear int) string {
+	first, last := emailPart(given), emailPart(family)
+	var local string
+	switch f.patterns.pick(f.rng) {
+	case "first.last":
+		local = first + "." + last
+	case "firstlast":
+		local = first + last
+	case "flast":
+		local = first[:1] + last
+	case "first_last":
+		local = first + "_" + last
+	case "last.first":
+		local = last + "." + first
+	case "first.l":
+		local = first + "." + last[:1]
+	case "first+digits":
+		local = fmt.Sprintf("%s%02d", first, f.rng.intn(100))
+	case "firstlast+year":
+		local = fmt.Sprintf("%s%s%d", first, last, birthYear)
+	case "last+digits":
+		local = fmt.Sprintf("%s%d", last, f.rng.between(1, 999))
+	}
+	domain := f.domains.pick(f.rng)
+
+	email := local + "@" + domain
+	for n := 2; f.emails[email]; n++ {
+		email = fmt.Sprintf("%s%d@%s", local, n, domain)
+	}
+	f.emails[email] = true
+	return email
+}
+
+// emailPart lower-cases a name to letters and digits.
+func emailPart(name string) string {
+	var b strings.Builder
+	for _, r := range fakeEmailFold.Replace(strings.ToLower(name)) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+// age draws an adult's age, most between their twenties and fifties.
+func (f *faker) age() int {
+	spread := f.rng.float() + f.rng.float() + f.rng.float() - 1.5 // mean 0, sd 0.5
+	return min(max(int(math.Round(38+24*spread)), 18), 85)
+}
+
+// joinDate draws a sign-up day between from and to, more of them later as
+// the shop grows.
+func (f *faker) joinDate(from, to time.Time) time.Time {
+	days := int(to.Sub(from).Hours() / 24)
+	return from.AddDate(0, 0, int(float64(days)*math.Pow(f.rng.float(), 0.7)))
+}
+
+// orderDate draws an order day between from and to, weighted by the month,
+// the weekday and the shop's growth between demoDataStart and demoDataEnd.
+func (f *faker) orderDate(from, to time.Time) time.Time {
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days <= 1 {
+		return from
+	}
+	span := demoDataEnd.Sub(demoDataStart).Hours()
+	maxRate := 1.7 * 1.15 * 1.6
+	var day time.Time
+	for try := 0; try < 100; try++ {
+		day = from.AddDate(0, 0, f.rng.intn(days))
+		growth := 0.4 + 1.2*min(max(day.Sub(demoDataStart).Hours()/span, 0), 1)
+		rate := fakeMonthRates[day.Month()-1] * fakeWeekdayRates[day.Weekday()] * growth
+		if f.rng.float()*maxRate < rate {
+			break
+		}
+	}
+	return day
+}
+
+// price draws a price between lo and hi, spread evenly on a log scale, at
+// the price point a shop would pick: 4.49 or 7.99 under ten, 24.99 under a
+// hundred, 149.99 or 150.00 under a thousand and 1299.00 above.
+func (f *faker) price(lo, hi float64) float64 {
+	p := math.Exp(math.Log(lo) + f.rng.float()*(math.Log(hi)-math.Log(lo)))
+	step := func(size float64) float64 { return math.Max(1, math.Round(p/size)) * size }
+	switch {
+	case p < 10:
+		if f.rng.chance(0.3) {
+			return math.Floor(p) + 0.49
+		}
+		return math.Floor(p) + 0.99
+	case p < 100:
+		if f.rng.chance(0.2) {
+			return math.Floor(p) + 0.95
+		}
+		return step(5) - 0.01
+	case p < 1000:
+		if f.rng.chance(0.25) {
+			return step(10)
+		}
+		return step(10) - 0.01
+	default:
+		return step(100) - 1
+	}
+}
+
+// rating draws an average review score: most products sit between four and
+// five stars, some around three and a few well below.
+func (f *faker) rating() float64 {
+	var r float64
+	switch u := f.rng.float(); {
+	case u < 0.75:
+		r = 3.8 + 1.2*math.Sqrt(f.rng.float())
+	case u < 0.93:
+		r = 3 + 0.8*f.rng.float()
+	default:
+		r = 1.5 + 1.5*f.rng.float()
+	}
+	return math.Min(math.Round(r*10)/10, 5)
+}
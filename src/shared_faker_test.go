@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakerNamesAndEmails(t *testing.T) {
+	rng := demoRand(1)
+	fake := newFaker(&rng)
+
+	inPool := func(language, given, family string) bool {
+		pool := fakeNamePools[language]
+		return strings.Contains(strings.Join(pool.given, "|"), given) && strings.Contains(strings.Join(pool.family, "|"), family)
+	}
+	for country, language := range map[string]string{"JP": "ja", "UK": "en", "deu": "de", "BR": "pt", "MX": "es"} {
+		if given, family := fake.name(country); !inPool(language, given, family) {
+			t.Errorf("Expected a %s name for %s, got %s %s", language, country, given, family)
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 2000; i++ {
+		email := fake.email("Jürgen", "de Vries", 1990)
+		local, domain, _ := strings.Cut(email, "@")
+		if seen[email] {
+			t.Fatalf("Email %s given out twice", email)
+		}
+		seen[email] = true
+		if !strings.HasPrefix(domain, "example.") || !strings.Contains(local, "devries") && !strings.HasPrefix(local, "jurgen") {
+			t.Errorf("Unexpected email %s", email)
+		}
+		if result := ValidateEmail(email); !result.Valid {
+			t.Errorf("Email %s is invalid: %v", email, result.Errors)
+		}
+	}
+	if !seen["jurgen.devries@example.com"] || !seen["jdevries@example.com"] || !seen["jurgendevries1990@example.com"] {
+		t.Error("Expected the common patterns among the emails")
+	}
+}
+
+func TestFakerPricesAndRatings(t *testing.T) {
+	rng := demoRand(2)
+	fake := newFaker(&rng)
+
+	for i := 0; i < 2000; i++ {
+		price := fake.price(4, 2000)
+		cents := int(math.Round(price*100)) % 100
+		if price < 4 || price > 2100 || (cents != 99 && cents != 95 && cents != 49 && cents != 0) {
+			t.Fatalf("Unexpected price point %.2f", price)
+		}
+	}
+
+	high, low, total := 0, 0, 0.0
+	for i := 0; i < 2000; i++ {
+		rating := fake.rating()
+		if rating < 1.5 || rating > 5 {
+			t.Fatalf("Rating %v out of range", rating)
+		}
+		if rating >= 4 {
+			high++
+		}
+		if rating < 3 {
+			low++
+		}
+		total += rating
+	}
+	if high < 1200 || low == 0 || low > 300 {
+		t.Errorf("Expected most ratings at four stars or more and a few below three, got %d and %d", high, low)
+	}
+}
+
+func TestFakerDates(t *testing.T) {
+	rng := demoRand(3)
+	fake := newFaker(&rng)
+
+	months := map[time.Month]int{}
+	weekdays := map[time.Weekday]int{}
+	years := map[int]int{}
+	for i := 0; i < 20000; i++ {
+		day := fake.orderDate(demoDataStart, demoDataEnd)
+		if day.Before(demoDataStart) || day.After(demoDataEnd) {
+			t.Fatalf("Order date %v out of range", day)
+		}
+		months[day.Month()]++
+		weekdays[day.Weekday()]++
+		years[day.Year()]++
+	}
+	if months[time.December] < 2*months[time.February] || weekdays[time.Sunday] <= weekdays[time.Friday] || years[2024] <= years[2022] {
+		t.Errorf("Expected holiday, weekday and growth peaks, got %v, %v and %v", months, weekdays, years)
+	}
+
+	joined := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		joined[fake.joinDate(demoDataStart, demoDataEnd).Year()]++
+	}
+	if joined[2024] <= joined[2022] {
+		t.Errorf("Expected sign-ups to grow, got %v", joined)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if age := fake.age(); age < 18 || age > 85 {
+			t.Fatalf("Age %d out of range", age)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Shared revenue forecasting - ForecastRevenue extends a revenue series from
+// AggregateRevenue with exponential smoothing. With a season (7 for days of
+// the week, 12 for months of the year) and at least two seasons of history
+// it uses additive Holt-Winters, smoothing the level, trend and seasonal
+// effect; otherwise Holt's linear method, smoothing the level and trend.
+// Alpha, beta and gamma are how quickly each follows the latest period. The
+// bands are a 95% interval from the in-sample one-step errors, widening
+// with the square root of the steps ahead; revenue below zero is cut off.
+// GET /api/analytics/forecast forecasts the stored orders, and
+// forecastRevenueWasm lets the page try other settings.
+
+// Forecast methods.
+const (
+	ForecastHolt        = "holt"
+	ForecastHoltWinters = "holt_winters"
+)
+
+// maxForecastHorizon is the most periods a forecast may look ahead.
+const maxForecastHorizon = 366
+
+// maxForecastSeason is the longest season a forecast may have.
+const maxForecastSeason = 366
+
+// forecastZ is the normal quantile of a two-sided 95% band.
+const forecastZ = 1.959964
+
+// ForecastSettings are how far ahead to forecast, the season in periods (0
+// for none) and the smoothing factors, each above 0 and at most 1.
+type ForecastSettings struct {
+	Horizon int     `json:"horizon"`
+	Season  int     `json:"season"`
+	Alpha   float64 `json:"alpha"`
+	Beta    float64 `json:"beta"`
+	Gamma   float64 `json:"gamma"`
+}
+
+// DefaultForecastSettings look six periods ahead without a season.
+var DefaultForecastSettings = ForecastSettings{Horizon: 6, Alpha: 0.5, Beta: 0.3, Gamma: 0.3}
+
+// ForecastPoint is the forecast revenue of one period and its band, in
+// DefaultCurrency.
+type ForecastPoint struct {
+	Period  string  `json:"period"`
+	Revenue float64 `json:"revenue"`
+	Lower   float64 `json:"lower"`
+	Upper   float64 `json:"upper"`
+}
+
+// RevenueForecast is a forecast and how it was made. Season is 0 when
+// Holt's method was used; RMSE is the in-sample one-step error.
+type RevenueForecast struct {
+	Granularity string          `json:"granularity"`
+	Method      string          `json:"method"`
+	Season      int             `json:"season"`
+	RMSE        float64         `json:"rmse"`
+	Points      []ForecastPoint `json:"points"`
+}
+
+// ValidateForecastSettings checks the horizon is from 1 to
+// maxForecastHorizon, the season 0 or from 2 to maxForecastSeason and the
+// smoothing factors above 0 and at most 1.
+func ValidateForecastSettings(settings ForecastSettings) ValidationResult {
+	result := newValidationResult()
+	if settings.Horizon < 1 || settings.Horizon > maxForecastHorizon {
+		result.AddError("horizon", CodeOutOfRange, fmt.Sprintf("Horizon must be from 1 to %d periods", maxForecastHorizon))
+	}
+	if settings.Season != 0 && (settings.Season < 2 || settings.Season > maxForecastSeason) {
+		result.AddError("season", CodeOutOfRange, fmt.Sprintf("Season must be 0 or from 2 to %d periods", maxForecastSeason))
+	}
+	for _, factor := range []struct {
+		field, name string
+		value       float64
+	}{{"alpha", "Alpha", settings.Alpha}, {"beta", "Beta", settings.Beta}, {"gamma", "Gamma", settings.Gamma}} {
+		if !(factor.value > 0 && factor.value <= 1) {
+			result.AddError(factor.field, CodeOutOfRange, factor.name+" must be above 0 and at most 1")
+		}
+	}
+	return result
+}
+
+// ParseForecastSettings reads forecast settings from JSON; settings left
+// out keep their defaults.
+func ParseForecastSettings(data []byte) (ForecastSettings, error) {
+	settings := DefaultForecastSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return ForecastSettings{}, fmt.Errorf("invalid forecast settings JSON: %w", err)
+	}
+	if result := ValidateForecastSettings(settings); !result.Valid {
+		return ForecastSettings{}, fmt.Errorf("invalid forecast settings: %s", result.Errors[0])
+	}
+	return settings, nil
+}
+
+// ForecastRevenue forecasts the periods after a continuous revenue series
+// of granularity, oldest first, as AggregateRevenue returns it. It needs at
+// least two periods.
+func ForecastRevenue(points []RevenuePoint, granularity string, settings ForecastSettings) (RevenueForecast, error) {
+	if granularity != GranularityDay && granularity != GranularityWeek && granularity != GranularityMonth {
+		return RevenueForecast{}, fmt.Errorf("granularity %q must be day, week or month", granularity)
+	}
+	if result := ValidateForecastSettings(settings); !result.Valid {
+		return RevenueForecast{}, fmt.Errorf("invalid forecast settings: %s", result.Errors[0])
+	}
+	if len(points) < 2 {
+		return RevenueForecast{}, fmt.Errorf("a forecast needs at least 2 periods of revenue, got %d", len(points))
+	}
+	last, err := time.Parse(taxDateLayout, points[len(points)-1].Period)
+	if err != nil {
+		return RevenueForecast{}, fmt.Errorf("period %q must be a YYYY-MM-DD date", points[len(points)-1].Period)
+	}
+
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Revenue
+	}
+	forecast := RevenueForecast{Granularity: granularity, Method: ForecastHolt, Points: []ForecastPoint{}}
+	var ahead func(h int) float64
+	var errors []float64
+	if m := settings.Season; m >= 2 && len(values) >= 2*m {
+		forecast.Method, forecast.Season = ForecastHoltWinters, m
+		ahead, errors = holtWinters(values, m, settings)
+	} else {
+		ahead, errors = holtLinear(values, settings)
+	}
+
+	sumSquares := 0.0
+	for _, e := range errors {
+		sumSquares += e * e
+	}
+	sigma := 0.0
+	if len(errors) > 0 {
+		sigma = math.Sqrt(sumSquares / float64(len(errors)))
+	}
+	forecast.RMSE = RoundToCurrency(sigma, DefaultCurrency)
+
+	period := last
+	for h := 1; h <= settings.Horizon; h++ {
+		period = nextPeriod(period, granularity)
+		value := ahead(h)
+		band := forecastZ * sigma * math.Sqrt(float64(h))
+		forecast.Points = append(forecast.Points, ForecastPoint{
+			Period:  period.Format(taxDateLayout),
+			Revenue: RoundToCurrency(max(value, 0), DefaultCurrency),
+			Lower:   RoundToCurrency(max(value-band, 0), DefaultCurrency),
+			Upper:   RoundToCurrency(max(value+band, 0), DefaultCurrency),
+		})
+	}
+	return forecast, nil
+}
+
+// holtLinear smooths the level and trend of at least two values, starting
+// from the first value and the first change. It returns the forecast h
+// periods past the last value and the one-step errors after the start.
+func holtLinear(values []float64, settings ForecastSettings) (func(h int) float64, []float64) {
+	level, trend := values[0], values[1]-values[0]
+	var errors []float64
+	for t := 1; t < len(values); t++ {
+		if t > 1 {
+			errors = append(errors, values[t]-(level+trend))
+		}
+		previous := level
+		level = settings.Alpha*values[t] + (1-settings.Alpha)*(level+trend)
+		trend = settings.Beta*(level-previous) + (1-settings.Beta)*trend
+	}
+	return func(h int) float64 { return level + float64(h)*trend }, errors
+}
+
+// holtWinters smooths the level, trend and additive season of m periods of
+// at least two seasons of values. The first season sets the starting level
+// and season, the change to the second the starting trend. It returns the
+// forecast h periods past the last value and the one-step errors after the
+// first season.
+func holtWinters(values []float64, m int, settings ForecastSettings) (func(h int) float64, []float64) {
+	mean := func(season []float64) float64 {
+		sum := 0.0
+		for _, value := range season {
+			sum += value
+		}
+		return sum / float64(len(season))
+	}
+	level := mean(values[:m])
+	trend := (mean(values[m:2*m]) - level) / float64(m)
+	seasonal := make([]float64, m)
+	for i := range seasonal {
+		seasonal[i] = values[i] - level
+	}
+
+	var errors []float64
+	for t := m; t < len(values); t++ {
+		s := seasonal[t%m]
+		errors = append(errors, values[t]-(level+trend+s))
+		previous := level
+		level = settings.Alpha*(values[t]-s) + (1-settings.Alpha)*(level+trend)
+		trend = settings.Beta*(level-previous) + (1-settings.Beta)*trend
+		seasonal[t%m] = settings.Gamma*(values[t]-level) + (1-settings.Gamma)*s
+	}
+	n := len(values)
+	return func(h int) float64 { return level + float64(h)*trend + seasonal[(n+h-1)%m] }, errors
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// series makes a daily revenue series from 2024-01-01.
+func series(values ...float64) []RevenuePoint {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]RevenuePoint, len(values))
+	for i, value := range values {
+		points[i] = RevenuePoint{Period: start.AddDate(0, 0, i).Format(taxDateLayout), Revenue: value}
+	}
+	return points
+}
+
+// TestForecastRevenueHolt tests that a straight line is continued exactly
+func TestForecastRevenueHolt(t *testing.T) {
+	settings := DefaultForecastSettings
+	settings.Horizon = 2
+	forecast, err := ForecastRevenue(series(10, 20, 30, 40), GranularityDay, settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []ForecastPoint{{Period: "2024-01-05", Revenue: 50, Lower: 50, Upper: 50}, {Period: "2024-01-06", Revenue: 60, Lower: 60, Upper: 60}}
+	if forecast.Method != ForecastHolt || forecast.RMSE != 0 || len(forecast.Points) != 2 || forecast.Points[0] != want[0] || forecast.Points[1] != want[1] {
+		t.Errorf("ForecastRevenue = %+v, want %+v", forecast, want)
+	}
+
+	// Too little history for the season falls back to Holt
+	settings.Season = 7
+	if forecast, _ := ForecastRevenue(series(10, 20, 30, 40), GranularityDay, settings); forecast.Method != ForecastHolt || forecast.Season != 0 {
+		t.Errorf("Expected Holt without two seasons, got %+v", forecast)
+	}
+}
+
+// TestForecastRevenueHoltWinters tests that a repeating season is continued
+// and that the bands widen with noise
+func TestForecastRevenueHoltWinters(t *testing.T) {
+	settings := ForecastSettings{Horizon: 4, Season: 4, Alpha: 0.5, Beta: 0.3, Gamma: 0.3}
+	forecast, err := ForecastRevenue(series(10, 20, 30, 20, 10, 20, 30, 20, 10, 20, 30, 20), GranularityDay, settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if forecast.Method != ForecastHoltWinters || forecast.Season != 4 {
+		t.Fatalf("Expected Holt-Winters, got %+v", forecast)
+	}
+	for i, want := range []float64{10, 20, 30, 20} {
+		if !floatEqual(forecast.Points[i].Revenue, want, 0.01) {
+			t.Errorf("Period %d: expected %v, got %+v", i, want, forecast.Points[i])
+		}
+	}
+
+	noisy, _ := ForecastRevenue(series(12, 18, 33, 19, 9, 22, 28, 21, 11, 17, 31, 22), GranularityDay, settings)
+	first, last := noisy.Points[0], noisy.Points[3]
+	if noisy.RMSE <= 0 || first.Lower >= first.Revenue || first.Upper <= first.Revenue || last.Upper-last.Lower <= first.Upper-first.Lower {
+		t.Errorf("Expected bands widening ahead, got %+v", noisy)
+	}
+}
+
+// TestForecastRevenueErrors tests what ForecastRevenue refuses
+func TestForecastRevenueErrors(t *testing.T) {
+	if _, err := ForecastRevenue(series(10), GranularityDay, DefaultForecastSettings); err == nil {
+		t.Error("Expected one period to be too few")
+	}
+	if _, err := ForecastRevenue(series(10, 20), "hour", DefaultForecastSettings); err == nil {
+		t.Error("Expected an unknown granularity to be rejected")
+	}
+	for name, settings := range map[string]ForecastSettings{
+		"no horizon":  {Horizon: 0, Alpha: 0.5, Beta: 0.5, Gamma: 0.5},
+		"season of 1": {Horizon: 1, Season: 1, Alpha: 0.5, Beta: 0.5, Gamma: 0.5},
+		"zero alpha":  {Horizon: 1, Beta: 0.5, Gamma: 0.5},
+		"beta over 1": {Horizon: 1, Alpha: 0.5, Beta: 1.5, Gamma: 0.5},
+	} {
+		if _, err := ForecastRevenue(series(10, 20), GranularityDay, settings); err == nil {
+			t.Errorf("%s: expected the settings to be rejected", name)
+		}
+	}
+
+	settings, err := ParseForecastSettings([]byte(`{"horizon": 12, "season": 7}`))
+	if err != nil || settings.Horizon != 12 || settings.Season != 7 || settings.Alpha != DefaultForecastSettings.Alpha {
+		t.Errorf("Expected the defaults kept, got %+v %v", settings, err)
+	}
+	if _, err := ParseForecastSettings([]byte(`{"alpha": 0}`)); err == nil {
+		t.Error("Expected a zero alpha to be rejected")
+	}
+}
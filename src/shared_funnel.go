@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Shared funnel analytics - ComputeFunnel follows shoppers through the
+// purchase funnel (viewed, added to cart, checked out, purchased) in a
+// stream of behavior events. A shopper is a session, or a user for events
+// without one. Steps must happen in order: a purchase only counts once the
+// same shopper viewed, added to cart and checked out before it. Each step
+// reports the shoppers who reached it, its conversion from the step before
+// and from the first, and how many dropped off on the way. Clicks are
+// events too, for sessions, but not a funnel step. The server keeps the
+// events posted to /api/events; computeFunnelWasm reads a locally loaded
+// event log.
+
+// Behavior event types, in funnel order.
+const (
+	EventViewed      = "viewed"
+	EventAddedToCart = "added_to_cart"
+	EventCheckedOut  = "checked_out"
+	EventPurchased   = "purchased"
+)
+
+// EventClicked is a click on the page, outside the funnel.
+const EventClicked = "clicked"
+
+// funnelSteps are the event types of the funnel, in order.
+var funnelSteps = []string{EventViewed, EventAddedToCart, EventCheckedOut, EventPurchased}
+
+// eventTypes are every behavior event type.
+var eventTypes = append(slices.Clone(funnelSteps), EventClicked)
+
+// BehaviorEvent is one thing a shopper did.
+type BehaviorEvent struct {
+	Type      string `json:"type"`
+	UserID    int    `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	ProductID int    `json:"product_id,omitempty"`
+	Time      string `json:"time"` // RFC 3339
+}
+
+// FunnelStep is how many shoppers reached a step. Conversion is the share of
+// the previous step's shoppers who reached it, Overall the share of the
+// first step's; DropOff is the shoppers of the previous step who didn't.
+type FunnelStep struct {
+	Step       string  `json:"step"`
+	Shoppers   int     `json:"shoppers"`
+	Conversion float64 `json:"conversion"`
+	Overall    float64 `json:"overall"`
+	DropOff    int     `json:"drop_off"`
+}
+
+// ValidateBehaviorEvent checks an event's type, that it names a user or a
+// session, and its time.
+func ValidateBehaviorEvent(event BehaviorEvent) ValidationResult {
+	result := newValidationResult()
+	if event.Type == "" {
+		result.AddError("type", CodeRequired, "Event type is required")
+	} else if !slices.Contains(eventTypes, event.Type) {
+		result.AddError("type", CodeUnknownValue, fmt.Sprintf("Event type %q must be viewed, clicked, added_to_cart, checked_out or purchased", event.Type))
+	}
+	if event.UserID <= 0 && event.SessionID == "" {
+		result.AddError("user_id", CodeRequired, "Event needs a user ID or a session ID")
+	}
+	if _, err := time.Parse(time.RFC3339, event.Time); err != nil {
+		result.AddError("time", CodeInvalidFormat, "Event time must be an RFC 3339 timestamp")
+	}
+	return result
+}
+
+// shopper is who an event is of: its session, else its user.
+func (event BehaviorEvent) shopper() string {
+	if event.SessionID != "" {
+		return "session:" + event.SessionID
+	}
+	return fmt.Sprintf("user:%d", event.UserID)
+}
+
+// ComputeFunnel counts the shoppers reaching each funnel step. Events that
+// fail ValidateBehaviorEvent are left out; events at the same time keep
+// their order in the stream.
+func ComputeFunnel(events []BehaviorEvent) []FunnelStep {
+	type timedEvent struct {
+		at    time.Time
+		event BehaviorEvent
+	}
+	byShopper := map[string][]timedEvent{}
+	for _, event := range events {
+		if !ValidateBehaviorEvent(event).Valid {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339, event.Time)
+		byShopper[event.shopper()] = append(byShopper[event.shopper()], timedEvent{at, event})
+	}
+
+	reached := make([]int, len(funnelSteps))
+	for _, timeline := range byShopper {
+		sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].at.Before(timeline[j].at) })
+		step := 0
+		for _, entry := range timeline {
+			if step < len(funnelSteps) && entry.event.Type == funnelSteps[step] {
+				reached[step]++
+				step++
+			}
+		}
+	}
+
+	steps := make([]FunnelStep, len(funnelSteps))
+	for i, name := range funnelSteps {
+		steps[i] = FunnelStep{Step: name, Shoppers: reached[i]}
+		if reached[0] > 0 {
+			steps[i].Overall = roundRatio(float64(reached[i]) / float64(reached[0]))
+		}
+		if i == 0 {
+			if reached[0] > 0 {
+				steps[i].Conversion = 1
+			}
+			continue
+		}
+		if reached[i-1] > 0 {
+			steps[i].Conversion = roundRatio(float64(reached[i]) / float64(reached[i-1]))
+		}
+		steps[i].DropOff = reached[i-1] - reached[i]
+	}
+	return steps
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestComputeFunnel tests step order, shoppers by session and drop-off
+func TestComputeFunnel(t *testing.T) {
+	events := []BehaviorEvent{
+		{Type: EventViewed, UserID: 1, Time: "2024-01-01T10:00:00Z"},
+		{Type: EventAddedToCart, UserID: 1, Time: "2024-01-01T10:05:00Z"},
+		{Type: EventCheckedOut, UserID: 1, Time: "2024-01-01T10:10:00Z"},
+		{Type: EventPurchased, UserID: 1, Time: "2024-01-01T10:15:00Z"},
+		// Listed out of order, still a full funnel
+		{Type: EventPurchased, SessionID: "a", Time: "2024-01-02T10:15:00Z"},
+		{Type: EventCheckedOut, SessionID: "a", Time: "2024-01-02T10:10:00Z"},
+		{Type: EventAddedToCart, SessionID: "a", Time: "2024-01-02T10:05:00Z"},
+		{Type: EventViewed, SessionID: "a", Time: "2024-01-02T10:00:00Z"},
+		// A purchase without a checkout stops at the cart
+		{Type: EventViewed, UserID: 2, Time: "2024-01-03T10:00:00Z"},
+		{Type: EventAddedToCart, UserID: 2, Time: "2024-01-03T10:05:00Z"},
+		{Type: EventPurchased, UserID: 2, Time: "2024-01-03T10:15:00Z"},
+		// Adding before viewing doesn't count
+		{Type: EventAddedToCart, UserID: 3, Time: "2024-01-04T09:00:00Z"},
+		{Type: EventViewed, UserID: 3, Time: "2024-01-04T10:00:00Z"},
+		// Invalid events are left out
+		{Type: EventViewed, Time: "2024-01-04T10:00:00Z"},
+		{Type: "hovered", UserID: 9, Time: "2024-01-04T10:00:00Z"},
+		// Clicks are valid but no step
+		{Type: EventClicked, UserID: 3, Time: "2024-01-04T10:05:00Z"},
+		{Type: EventViewed, UserID: 9, Time: "yesterday"},
+	}
+	want := []FunnelStep{
+		{Step: EventViewed, Shoppers: 4, Conversion: 1, Overall: 1},
+		{Step: EventAddedToCart, Shoppers: 3, Conversion: 0.75, Overall: 0.75, DropOff: 1},
+		{Step: EventCheckedOut, Shoppers: 2, Conversion: 0.6667, Overall: 0.5, DropOff: 1},
+		{Step: EventPurchased, Shoppers: 2, Conversion: 1, Overall: 0.5},
+	}
+	if got := ComputeFunnel(events); !slices.Equal(got, want) {
+		t.Errorf("ComputeFunnel = %+v, want %+v", got, want)
+	}
+
+	empty := ComputeFunnel(nil)
+	if len(empty) != 4 || empty[0] != (FunnelStep{Step: EventViewed}) || empty[3].Conversion != 0 {
+		t.Errorf("Expected four empty steps, got %+v", empty)
+	}
+}
+
+// TestValidateBehaviorEvent tests each rejected field
+func TestValidateBehaviorEvent(t *testing.T) {
+	if result := ValidateBehaviorEvent(BehaviorEvent{Type: EventViewed, SessionID: "s", Time: "2024-01-01T10:00:00Z"}); !result.Valid {
+		t.Errorf("Expected a session event to be valid, got %v", result.Errors)
+	}
+	result := ValidateBehaviorEvent(BehaviorEvent{Type: "hovered", Time: "2024-01-01"})
+	if result.Valid || len(result.FieldErrors) != 3 {
+		t.Errorf("Expected type, user_id and time errors, got %+v", result.FieldErrors)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Shared geographic revenue - RevenueByGeography breaks sales down by the
+// country, and region within it, that orders ship to (see ShipTo): the
+// revenue, orders, average order value, the share of the revenue that came
+// from premium members and the area's share of all revenue. Users count
+// where they live, so a country with users but no sales still shows up.
+// AnalyzeUserBehavior reports both breakdowns and ranks its top countries by
+// them, by revenue rather than by how many users live there.
+
+// GeoRevenue is the sales of one country or region, in DefaultCurrency.
+// Region is empty in a country breakdown.
+type GeoRevenue struct {
+	Country           string  `json:"country"`
+	Region            string  `json:"region,omitempty"`
+	Users             int     `json:"users"`
+	Orders            int     `json:"orders"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	PremiumShare      float64 `json:"premium_share"`
+	RevenueShare      float64 `json:"revenue_share"`
+}
+
+// DefaultTopCountries is how many countries UserAnalytics ranks unless
+// asked for another number.
+const DefaultTopCountries = 3
+
+// maxTopCountries is the most countries the API and page rank.
+const maxTopCountries = 50
+
+// TopCountry is one of the countries with the most revenue: its users and
+// their percentage of all users, and its revenue and share of all revenue.
+type TopCountry struct {
+	Country      string  `json:"country"`
+	Users        int     `json:"users"`
+	Percentage   float64 `json:"percentage"`
+	Revenue      float64 `json:"revenue"`
+	RevenueShare float64 `json:"revenue_share"`
+}
+
+// topCountries takes the first n of a ranked country breakdown; users is
+// how many users there are in all.
+func topCountries(countries []GeoRevenue, users, n int) []TopCountry {
+	top := []TopCountry{}
+	for _, country := range countries[:min(n, len(countries))] {
+		entry := TopCountry{Country: country.Country, Users: country.Users, Revenue: country.Revenue, RevenueShare: country.RevenueShare}
+		if users > 0 {
+			entry.Percentage = math.Round(float64(country.Users)/float64(users)*1000) / 10
+		}
+		top = append(top, entry)
+	}
+	return top
+}
+
+// geoKey is a country, or a region within one.
+type geoKey struct {
+	country, region string
+}
+
+// geoBreakdown collects users and orders for RevenueByGeography.
+type geoBreakdown struct {
+	areas   map[geoKey]*GeoRevenue
+	premium map[geoKey]float64 // revenue from premium members
+	total   float64
+}
+
+func newGeoBreakdown() *geoBreakdown {
+	return &geoBreakdown{areas: map[geoKey]*GeoRevenue{}, premium: map[geoKey]float64{}}
+}
+
+// area returns the sales of a country or region, adding it if new.
+func (breakdown *geoBreakdown) area(key geoKey) *GeoRevenue {
+	if breakdown.areas[key] == nil {
+		breakdown.areas[key] = &GeoRevenue{Country: key.country, Region: key.region}
+	}
+	return breakdown.areas[key]
+}
+
+// addUser counts a user where they live.
+func (breakdown *geoBreakdown) addUser(user User) {
+	if user.Country == "" {
+		return
+	}
+	breakdown.area(geoKey{user.Country, ""}).Users++
+	if user.Region != "" {
+		breakdown.area(geoKey{user.Country, user.Region}).Users++
+	}
+}
+
+// addOrder counts an order placed by user where it ships.
+func (breakdown *geoBreakdown) addOrder(order Order, user User) {
+	country, region := ShipTo(order, user)
+	if country == "" || order.Status == "cancelled" {
+		return
+	}
+	amount := riskAmount(order)
+	breakdown.total += amount
+	keys := []geoKey{{country, ""}}
+	if region != "" {
+		keys = append(keys, geoKey{country, region})
+	}
+	for _, key := range keys {
+		entry := breakdown.area(key)
+		entry.Orders++
+		entry.Revenue += amount
+		if user.Premium {
+			breakdown.premium[key] += amount
+		}
+	}
+}
+
+// lists ranks the countries and regions counted so far.
+func (breakdown *geoBreakdown) lists() (countries, regions []GeoRevenue) {
+	countries, regions = []GeoRevenue{}, []GeoRevenue{}
+	for key, area := range breakdown.areas {
+		entry := *area
+		if entry.Orders > 0 {
+			entry.AverageOrderValue = RoundToCurrency(entry.Revenue/float64(entry.Orders), DefaultCurrency)
+			entry.PremiumShare = roundRatio(breakdown.premium[key] / entry.Revenue)
+		}
+		if breakdown.total > 0 {
+			entry.RevenueShare = roundRatio(entry.Revenue / breakdown.total)
+		}
+		entry.Revenue = RoundToCurrency(entry.Revenue, DefaultCurrency)
+		if key.region == "" {
+			countries = append(countries, entry)
+		} else {
+			regions = append(regions, entry)
+		}
+	}
+	for _, list := range [][]GeoRevenue{countries, regions} {
+		sort.Slice(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if a.Revenue != b.Revenue {
+				return a.Revenue > b.Revenue
+			}
+			if a.Users != b.Users {
+				return a.Users > b.Users
+			}
+			if a.Country != b.Country {
+				return a.Country < b.Country
+			}
+			return a.Region < b.Region
+		})
+	}
+	return countries, regions
+}
+
+// RevenueByGeography breaks orders down by country and by region, highest
+// revenue first, then most users, then by name. Cancelled orders and orders
+// without a destination are left out; orders without a region count towards
+// their country only.
+func RevenueByGeography(users []User, orders []Order) (countries, regions []GeoRevenue) {
+	byID := map[int]User{}
+	breakdown := newGeoBreakdown()
+	for _, user := range users {
+		byID[user.ID] = user
+		breakdown.addUser(user)
+	}
+	for _, order := range orders {
+		breakdown.addOrder(order, byID[order.UserID])
+	}
+	return breakdown.lists()
+}
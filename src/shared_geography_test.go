@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+// TestRevenueByGeography tests shipping destinations, regions and shares
+func TestRevenueByGeography(t *testing.T) {
+	users := []User{
+		{ID: 1, Country: "US", Region: "CA", Premium: true},
+		{ID: 2, Country: "US", Region: "NY"},
+		{ID: 3, Country: "CA"},
+		{ID: 4, Country: "DE"},
+	}
+	orders := []Order{
+		{UserID: 1, Total: 100, Status: "delivered"},
+		{UserID: 2, Total: 50, Status: "delivered"},
+		{UserID: 2, Total: 30, Status: "shipped"},
+		// Ships to the user's friend in Canada
+		{UserID: 1, Total: 20, Status: "delivered", ShippingAddress: &Address{Country: "CA", Region: "ON"}},
+		{UserID: 3, Total: 999, Status: "cancelled"},
+	}
+	countries, regions := RevenueByGeography(users, orders)
+
+	want := []GeoRevenue{
+		{Country: "US", Users: 2, Orders: 3, Revenue: 180, AverageOrderValue: 60, PremiumShare: 0.5556, RevenueShare: 0.9},
+		{Country: "CA", Users: 1, Orders: 1, Revenue: 20, AverageOrderValue: 20, PremiumShare: 1, RevenueShare: 0.1},
+		{Country: "DE", Users: 1},
+	}
+	if len(countries) != len(want) {
+		t.Fatalf("Expected %d countries, got %+v", len(want), countries)
+	}
+	for i := range want {
+		if countries[i] != want[i] {
+			t.Errorf("Country %d = %+v, want %+v", i, countries[i], want[i])
+		}
+	}
+
+	if len(regions) != 3 || regions[0].Region != "CA" || regions[0].Revenue != 100 || regions[1].Region != "NY" || regions[1].AverageOrderValue != 40 {
+		t.Errorf("Unexpected regions %+v", regions)
+	}
+	if regions[2] != (GeoRevenue{Country: "CA", Region: "ON", Orders: 1, Revenue: 20, AverageOrderValue: 20, PremiumShare: 1, RevenueShare: 0.1}) {
+		t.Errorf("Expected the shipping region without users, got %+v", regions[2])
+	}
+
+	analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{})
+	top := TopCountry{Country: "US", Users: 2, Percentage: 50, Revenue: 180, RevenueShare: 0.9}
+	if len(analytics.TopCountries) != 3 || analytics.TopCountries[0] != top || analytics.TopCountries[1].Country != "CA" || analytics.TopCountries[2].Percentage != 25 {
+		t.Errorf("Expected top countries by revenue, got %+v", analytics.TopCountries)
+	}
+	if analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{TopCountries: 1}); len(analytics.TopCountries) != 1 || len(analytics.CountryRevenue) != 3 {
+		t.Errorf("Expected only the top country, got %+v", analytics.TopCountries)
+	}
+}
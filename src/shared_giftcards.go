@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Shared gift cards - a gift card holds a balance in one currency until its
+// expiry date. An order names a card by code and CalculateOrderTotal pays as
+// much of the order as the balance covers, after tax: the card is a way of
+// paying, not a discount, so the tax is the same as without it.
+
+// giftCardCodePattern matches codes like GIFT-7Q2M-K9XD-4HPA.
+const giftCardCodePattern = `^GIFT(-[2-9A-HJ-NP-Z]{4}){3}$`
+
+// giftCardAlphabet leaves out 0, 1, I and O, which are easily misread.
+const giftCardAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// MaxGiftCardBalance bounds the value of one card.
+const MaxGiftCardBalance = 2000
+
+// GiftCard is a prepaid balance. Expiry is the last taxDateLayout day the
+// card can be used; empty means it never expires.
+type GiftCard struct {
+	Code     string  `json:"code"`
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency"`
+	Expiry   string  `json:"expiry,omitempty"`
+}
+
+// Gift card errors callers tell apart from malformed cards.
+var (
+	ErrGiftCardExpired = errors.New("gift card has expired")
+	ErrGiftCardEmpty   = errors.New("gift card has no balance left")
+)
+
+// NormalizeGiftCardCode uppercases a code and trims spaces, as codes are
+// often typed by hand.
+func NormalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidateGiftCard checks a card's code, balance, currency and expiry.
+func ValidateGiftCard(card GiftCard) ValidationResult {
+	result := newValidationResult()
+
+	if !matchText(giftCardCodePattern, matchGiftCardCode, card.Code) {
+		result.AddError("code", CodeInvalidFormat, "Gift card code must look like GIFT-XXXX-XXXX-XXXX")
+	}
+	if card.Balance < 0 || card.Balance > MaxGiftCardBalance {
+		result.AddError("balance", CodeOutOfRange, fmt.Sprintf("Balance must be between 0 and %d", MaxGiftCardBalance))
+	}
+	if _, ok := LookupCurrency(card.Currency); !ok {
+		result.AddError("currency", CodeUnknownValue, fmt.Sprintf("Unsupported currency %q", card.Currency))
+	}
+	if card.Expiry != "" {
+		if _, err := time.Parse(taxDateLayout, card.Expiry); err != nil {
+			result.AddError("expiry", CodeInvalidFormat, "Expiry must be a YYYY-MM-DD date")
+		}
+	}
+
+	return result
+}
+
+// CheckGiftCard reports why a card can't pay for an order in a currency on
+// a date, or nil when it can.
+func CheckGiftCard(card GiftCard, currency string, date time.Time) error {
+	if card.Expiry != "" && card.Expiry < date.Format(taxDateLayout) {
+		return ErrGiftCardExpired
+	}
+	if card.Balance <= 0 {
+		return ErrGiftCardEmpty
+	}
+	if currencyFor(card.Currency).Code != currencyFor(currency).Code {
+		return fmt.Errorf("gift card is in %s, not %s", currencyFor(card.Currency).Code, currencyFor(currency).Code)
+	}
+	return nil
+}
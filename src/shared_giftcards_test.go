@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestValidateGiftCard tests gift card codes, balances and expiry
+func TestValidateGiftCard(t *testing.T) {
+	valid := GiftCard{Code: "GIFT-7Q2M-K9XD-4HPA", Balance: 50, Currency: "USD", Expiry: "2027-12-31"}
+	if result := ValidateGiftCard(valid); !result.Valid {
+		t.Errorf("Expected a valid card, got %v", result.Errors)
+	}
+
+	for name, card := range map[string]GiftCard{
+		"ambiguous letters": {Code: "GIFT-7Q2M-K9XD-4HP0", Balance: 50, Currency: "USD"},
+		"negative balance":  {Code: valid.Code, Balance: -1, Currency: "USD"},
+		"too large":         {Code: valid.Code, Balance: MaxGiftCardBalance + 1, Currency: "USD"},
+		"unknown currency":  {Code: valid.Code, Balance: 50, Currency: "XYZ"},
+		"bad expiry":        {Code: valid.Code, Balance: 50, Currency: "USD", Expiry: "31/12/2027"},
+	} {
+		if ValidateGiftCard(card).Valid {
+			t.Errorf("%s: expected the card to be invalid", name)
+		}
+	}
+
+	day := time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := CheckGiftCard(valid, "USD", day); !errors.Is(err, ErrGiftCardExpired) {
+		t.Errorf("Expected the card to have expired, got %v", err)
+	}
+	if err := CheckGiftCard(valid, "EUR", day.AddDate(0, 0, -1)); err == nil {
+		t.Error("Expected a USD card to be refused for a EUR order")
+	}
+}
+
+// TestCalculateOrderTotalGiftCard tests that gift cards pay after tax
+func TestCalculateOrderTotalGiftCard(t *testing.T) {
+	order := Order{Products: []Product{{ID: 1, Name: "Lamp", Price: 40, Category: "home"}}, Quantities: []int{1}, OrderDate: "2026-10-14"}
+	user := User{Country: "US"}
+	CalculateOrderTotal(&order, user)
+	plain := order
+
+	order.GiftCard = &GiftCard{Code: "GIFT-7Q2M-K9XD-4HPA", Balance: 30, Currency: "USD"}
+	CalculateOrderTotal(&order, user)
+	if order.Tax != plain.Tax || order.Total != plain.Total {
+		t.Errorf("Expected a gift card not to change tax or total, got %+v", order)
+	}
+	if order.GiftCardAmount != 30 || order.AmountDue != RoundToCurrency(plain.Total-30, "USD") {
+		t.Errorf("Expected the card to pay 30, got %v with %v due", order.GiftCardAmount, order.AmountDue)
+	}
+
+	order.GiftCard.Balance = 500
+	CalculateOrderTotal(&order, user)
+	if order.GiftCardAmount != plain.Total || order.AmountDue != 0 {
+		t.Errorf("Expected a large balance to pay the whole total, got %v with %v due", order.GiftCardAmount, order.AmountDue)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Headless jobs - under Node.js, with no browser around, the WASM module
+// runs jobs instead of waiting for a page to call it: a job names one of the
+// functions the module registers and the arguments to call it with, as a
+// page would pass them ("args": ["{\"name\": ...}"] for the JSON-string
+// functions, numbers for the benchmarks). run_wasm_node.js passes the spec
+// with --job, or pipes it in on stdin; the module prints the results as JSON
+// and exits. main_node.go runs the jobs.
+
+// maxHeadlessJobs is how many jobs one spec may hold.
+const maxHeadlessJobs = 1000
+
+// HeadlessJob is one call of a registered function.
+type HeadlessJob struct {
+	Function string        `json:"function"`
+	Args     []interface{} `json:"args"`
+}
+
+// headlessUsage is reported when the arguments make no sense.
+const headlessUsage = "usage: node run_wasm_node.js [--job '<json>' | --job - | < spec.json]"
+
+// headlessSpec reads the job spec named by the module's arguments: the JSON
+// after --job (or --job=), or stdin when that is "-" or there are no
+// arguments.
+func headlessSpec(args []string, stdin io.Reader) ([]byte, error) {
+	spec := "-"
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "--job":
+		spec = args[1]
+	case len(args) == 1 && strings.HasPrefix(args[0], "--job="):
+		spec = strings.TrimPrefix(args[0], "--job=")
+	default:
+		return nil, errors.New(headlessUsage)
+	}
+	if spec != "-" {
+		return []byte(spec), nil
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("reading the job spec from stdin: %w", err)
+	}
+	return data, nil
+}
+
+// ParseHeadlessJobs reads a job spec: one job, or an array of them. single
+// reports whether it was one job, which is answered with one result rather
+// than an array.
+func ParseHeadlessJobs(data []byte) (jobs []HeadlessJob, single bool, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, false, errors.New("empty job spec")
+	}
+	if data[0] == '{' {
+		var job HeadlessJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, false, fmt.Errorf("invalid job JSON: %w", err)
+		}
+		jobs, single = []HeadlessJob{job}, true
+	} else if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, false, fmt.Errorf("invalid job spec JSON: %w", err)
+	}
+	if len(jobs) == 0 || len(jobs) > maxHeadlessJobs {
+		return nil, false, fmt.Errorf("a job spec must hold from 1 to %d jobs, got %d", maxHeadlessJobs, len(jobs))
+	}
+	for i, job := range jobs {
+		if job.Function == "" {
+			return nil, false, fmt.Errorf("job %d names no function", i)
+		}
+	}
+	return jobs, single, nil
+}
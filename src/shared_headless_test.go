@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadlessSpec(t *testing.T) {
+	stdin := `{"function": "fromStdin"}`
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no arguments read stdin", nil, stdin},
+		{"--job dash reads stdin", []string{"--job", "-"}, stdin},
+		{"--job value", []string{"--job", `{"function": "a"}`}, `{"function": "a"}`},
+		{"--job= value", []string{`--job={"function": "b"}`}, `{"function": "b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := headlessSpec(tt.args, strings.NewReader(stdin))
+			if err != nil {
+				t.Fatalf("headlessSpec: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("spec = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	for _, args := range [][]string{{"--job"}, {"--bench"}, {"--job", "{}", "extra"}} {
+		if _, err := headlessSpec(args, strings.NewReader(stdin)); err == nil {
+			t.Errorf("headlessSpec(%q): expected a usage error", args)
+		}
+	}
+}
+
+func TestParseHeadlessJobs(t *testing.T) {
+	jobs, single, err := ParseHeadlessJobs([]byte(` {"function": "formatMoneyWasm", "args": [12.5, "EUR", "de-DE"]} `))
+	if err != nil {
+		t.Fatalf("ParseHeadlessJobs: %v", err)
+	}
+	if !single || len(jobs) != 1 || jobs[0].Function != "formatMoneyWasm" || len(jobs[0].Args) != 3 {
+		t.Errorf("single job = %+v (single %v)", jobs, single)
+	}
+	if amount, ok := jobs[0].Args[0].(float64); !ok || amount != 12.5 {
+		t.Errorf("first argument = %#v, want 12.5", jobs[0].Args[0])
+	}
+
+	jobs, single, err = ParseHeadlessJobs([]byte(`[{"function": "a"}, {"function": "b", "args": ["{}"]}]`))
+	if err != nil {
+		t.Fatalf("ParseHeadlessJobs: %v", err)
+	}
+	if single || len(jobs) != 2 || jobs[1].Function != "b" {
+		t.Errorf("job list = %+v (single %v)", jobs, single)
+	}
+
+	for _, spec := range []string{"", "   ", "[]", `{"args": []}`, `[{"function": "a"}, {}]`, `{"function": `, `"a"`} {
+		if _, _, err := ParseHeadlessJobs([]byte(spec)); err == nil {
+			t.Errorf("ParseHeadlessJobs(%q): expected an error", spec)
+		}
+	}
+	if _, _, err := ParseHeadlessJobs([]byte("[" + strings.Repeat(`{"function": "a"},`, maxHeadlessJobs) + `{"function": "a"}]`)); err == nil {
+		t.Error("expected an error past maxHeadlessJobs")
+	}
+}
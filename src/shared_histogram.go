@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Shared histograms - BuildHistogram counts values into the buckets of a
+// BucketSpec so dashboards can chart a distribution rather than an average.
+// A spec's edges are the lower bounds of its buckets: each bucket holds the
+// values from its edge up to, but not including, the next one, and the last
+// holds everything from its edge up. Values below the first edge are left
+// out. AnalyzeUserBehavior reports the users' ages in AgeBuckets and the
+// order totals, in DefaultCurrency, in OrderValueBuckets; buildHistogramWasm
+// buckets values passed from the page.
+
+// BucketSpec is the lower edges of a histogram's buckets, ascending.
+type BucketSpec struct {
+	Edges []float64 `json:"edges"`
+}
+
+// AgeBuckets are the age bands of the users' age histogram.
+var AgeBuckets = BucketSpec{Edges: []float64{0, 18, 25, 35, 45, 55, 65}}
+
+// OrderValueBuckets are the bands of the order value histogram.
+var OrderValueBuckets = BucketSpec{Edges: []float64{0, 25, 50, 100, 250, 500, 1000}}
+
+// maxHistogramBuckets is the most buckets a spec may have.
+const maxHistogramBuckets = 100
+
+// HistogramBucket is how many values fell from Min up to the next bucket's
+// Min. Label reads "18-25", or "65+" for the last bucket; Percentage is of
+// the values bucketed, to one decimal.
+type HistogramBucket struct {
+	Label      string  `json:"label"`
+	Min        float64 `json:"min"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// ValidateBucketSpec checks a spec has 1 to maxHistogramBuckets finite
+// edges in ascending order.
+func ValidateBucketSpec(spec BucketSpec) error {
+	if len(spec.Edges) == 0 || len(spec.Edges) > maxHistogramBuckets {
+		return fmt.Errorf("a histogram needs 1 to %d bucket edges", maxHistogramBuckets)
+	}
+	for i, edge := range spec.Edges {
+		if math.IsNaN(edge) || math.IsInf(edge, 0) {
+			return fmt.Errorf("bucket edge %d must be a finite number", i)
+		}
+		if i > 0 && edge <= spec.Edges[i-1] {
+			return fmt.Errorf("bucket edges must be ascending, but %g follows %g", edge, spec.Edges[i-1])
+		}
+	}
+	return nil
+}
+
+// histogram counts values into a spec's buckets one at a time.
+type histogram struct {
+	spec   BucketSpec
+	counts []int
+	total  int
+}
+
+// newHistogram returns an empty histogram of a valid spec.
+func newHistogram(spec BucketSpec) *histogram {
+	return &histogram{spec: spec, counts: make([]int, len(spec.Edges))}
+}
+
+// add counts one value; NaN is left out.
+func (h *histogram) add(value float64) {
+	// The first edge above value is one past its bucket
+	at := sort.Search(len(h.spec.Edges), func(i int) bool { return h.spec.Edges[i] > value }) - 1
+	if at < 0 || math.IsNaN(value) {
+		return
+	}
+	h.counts[at]++
+	h.total++
+}
+
+// buckets lists the counts, lowest bucket first.
+func (h *histogram) buckets() []HistogramBucket {
+	label := func(edge float64) string { return strconv.FormatFloat(edge, 'f', -1, 64) }
+	buckets := make([]HistogramBucket, len(h.spec.Edges))
+	for i, edge := range h.spec.Edges {
+		buckets[i] = HistogramBucket{Label: label(edge) + "+", Min: edge, Count: h.counts[i]}
+		if i+1 < len(h.spec.Edges) {
+			buckets[i].Label = label(edge) + "-" + label(h.spec.Edges[i+1])
+		}
+		if h.total > 0 {
+			buckets[i].Percentage = math.Round(float64(h.counts[i])/float64(h.total)*1000) / 10
+		}
+	}
+	return buckets
+}
+
+// BuildHistogram counts values into the buckets of spec.
+func BuildHistogram(values []float64, spec BucketSpec) ([]HistogramBucket, error) {
+	if err := ValidateBucketSpec(spec); err != nil {
+		return nil, err
+	}
+	h := newHistogram(spec)
+	for _, value := range values {
+		h.add(value)
+	}
+	return h.buckets(), nil
+}
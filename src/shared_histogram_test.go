@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestBuildHistogram tests bucket edges, the open last bucket and values
+// below the first edge
+func TestBuildHistogram(t *testing.T) {
+	got, err := BuildHistogram([]float64{-5, 0, 9.99, 10, 24, 25, 1000, math.NaN()}, BucketSpec{Edges: []float64{0, 10, 25}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []HistogramBucket{
+		{Label: "0-10", Min: 0, Count: 2, Percentage: 33.3},
+		{Label: "10-25", Min: 10, Count: 2, Percentage: 33.3},
+		{Label: "25+", Min: 25, Count: 2, Percentage: 33.3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildHistogram = %+v, want %+v", got, want)
+	}
+
+	empty, _ := BuildHistogram(nil, BucketSpec{Edges: []float64{0.5}})
+	if len(empty) != 1 || empty[0].Label != "0.5+" || empty[0].Percentage != 0 {
+		t.Errorf("Expected one empty bucket, got %+v", empty)
+	}
+
+	for _, edges := range [][]float64{nil, {0, 10, 10}, {5, 1}, {0, math.Inf(1)}} {
+		if _, err := BuildHistogram([]float64{1}, BucketSpec{Edges: edges}); err == nil {
+			t.Errorf("Expected edges %v to be rejected", edges)
+		}
+	}
+}
+
+// TestAnalyticsHistograms tests the age and order value histograms of the
+// analytics
+func TestAnalyticsHistograms(t *testing.T) {
+	users := []User{{ID: 1, Age: 17}, {ID: 2, Age: 30}, {ID: 3, Age: 34}, {ID: 4, Age: 70}}
+	orders := []Order{{ID: 1, UserID: 1, Total: 20}, {ID: 2, UserID: 2, Total: 120}}
+	analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{})
+
+	ages := map[string]int{}
+	for _, bucket := range analytics.AgeHistogram {
+		ages[bucket.Label] = bucket.Count
+	}
+	if len(analytics.AgeHistogram) != len(AgeBuckets.Edges) || ages["0-18"] != 1 || ages["25-35"] != 2 || ages["65+"] != 1 {
+		t.Errorf("Unexpected age histogram %+v", analytics.AgeHistogram)
+	}
+	values := map[string]int{}
+	for _, bucket := range analytics.OrderValueHistogram {
+		values[bucket.Label] = bucket.Count
+	}
+	if values["0-25"] != 1 || values["100-250"] != 1 {
+		t.Errorf("Unexpected order value histogram %+v", analytics.OrderValueHistogram)
+	}
+}
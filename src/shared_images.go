@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Shared product images - a product's ImageURL is its main picture, and
+// Images are the pictures it has with their sizes in pixels and the alt
+// text a screen reader reads out: renditions of the main picture and other
+// views of the product. When a product has both, the main picture is one
+// of its Images. SelectThumbnail picks the picture to show in a tile of a
+// given size, so the catalog page and the server agree on it.
+
+// Image limits
+const (
+	MaxProductImages  = 10
+	MaxImageSidePx    = 8000
+	MaxImageURLLength = 2048
+	MaxImageAltLength = 125 // as much as screen readers read out in one go
+)
+
+// ProductImage is one picture of a product.
+type ProductImage struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Alt    string `json:"alt"`
+}
+
+// longestSide is the image's width or height, whichever is larger.
+func (image ProductImage) longestSide() int {
+	return max(image.Width, image.Height)
+}
+
+// SelectThumbnail picks the picture to show in a size by size pixel tile:
+// the smallest of the product's Images whose longest side is at least size,
+// so it is scaled down rather than up, or the largest when none is that
+// big. A product with only an ImageURL is shown with that, under its name.
+// ok is false for products without pictures.
+func (p Product) SelectThumbnail(size int) (thumbnail ProductImage, ok bool) {
+	if len(p.Images) == 0 {
+		if p.ImageURL == "" {
+			return ProductImage{}, false
+		}
+		return ProductImage{URL: p.ImageURL, Alt: p.Name}, true
+	}
+	best := p.Images[0]
+	for _, image := range p.Images[1:] {
+		bigEnough, bestBigEnough := image.longestSide() >= size, best.longestSide() >= size
+		switch {
+		case bigEnough && (!bestBigEnough || image.longestSide() < best.longestSide()):
+			best = image
+		case !bigEnough && !bestBigEnough && image.longestSide() > best.longestSide():
+			best = image
+		}
+	}
+	return best, true
+}
+
+// validImageURL says whether raw is an absolute http or https URL.
+func validImageURL(raw string) bool {
+	if len(raw) > MaxImageURLLength {
+		return false
+	}
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != "" && parsed.User == nil
+}
+
+// validateImages records what is wrong with a product's pictures.
+func validateImages(product Product, result *ValidationResult) {
+	if product.ImageURL != "" && !validImageURL(product.ImageURL) {
+		result.AddError("image_url", CodeInvalidFormat, "Image URL must be an absolute http or https URL")
+	}
+	if len(product.Images) > MaxProductImages {
+		result.AddError("images", CodeOutOfRange, fmt.Sprintf("A product can have at most %d images", MaxProductImages))
+	}
+	seen := map[string]bool{}
+	for i, image := range product.Images {
+		field := func(name string) string { return fmt.Sprintf("images[%d].%s", i, name) }
+		switch {
+		case !validImageURL(image.URL):
+			result.AddError(field("url"), CodeInvalidFormat, fmt.Sprintf("Image %d must have an absolute http or https URL", i+1))
+		case seen[image.URL]:
+			result.AddError(field("url"), CodeDuplicate, fmt.Sprintf("Image %s is listed twice", image.URL))
+		}
+		seen[image.URL] = true
+		if image.Width <= 0 || image.Height <= 0 || image.longestSide() > MaxImageSidePx {
+			result.AddError(field("width"), CodeOutOfRange, fmt.Sprintf("Image %d must be between 1 and %d pixels wide and high", i+1, MaxImageSidePx))
+		}
+		switch alt := strings.TrimSpace(image.Alt); {
+		case alt == "":
+			result.AddError(field("alt"), CodeRequired, fmt.Sprintf("Image %d must have alt text", i+1))
+		case utf8.RuneCountInString(alt) > MaxImageAltLength:
+			result.AddError(field("alt"), CodeTooLong, fmt.Sprintf("Image %d alt text must be at most %d characters", i+1, MaxImageAltLength))
+		}
+	}
+	if product.ImageURL != "" && len(product.Images) > 0 && !seen[product.ImageURL] {
+		result.AddError("image_url", CodeInconsistent, "Image URL must be one of the product's images")
+	}
+}
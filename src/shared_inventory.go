@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Shared inventory - a product has OnHand units in the warehouse, Reserved
+// of which are held for placed orders, and the rest are Available to sell.
+// Placing an order reserves its units; shipping it commits them (they leave
+// OnHand) and cancelling releases them. Reservations are kept per order so
+// a release or commit moves exactly what the order reserved. Lines of a
+// product with variants reserve the variant's stock as well as the
+// product's total.
+
+// ErrNoReservation is returned for orders that hold no stock.
+var ErrNoReservation = errors.New("no stock reserved for order")
+
+// StockReservations are the units held for each order, by order ID.
+type StockReservations map[int][]CartItem
+
+// StockLevel is the stock of one product.
+type StockLevel struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	OnHand    int    `json:"on_hand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+// ItemAvailability is whether a requested quantity can be sold.
+type ItemAvailability struct {
+	ProductID int    `json:"product_id"`
+	SKU       string `json:"sku,omitempty"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+	OK        bool   `json:"ok"`
+}
+
+// Available is the number of units that can still be sold.
+func (product Product) Available() int {
+	return max(product.OnHand-product.Reserved, 0)
+}
+
+// InStock reports whether any units can be sold.
+func (product Product) InStock() bool {
+	return product.Available() > 0
+}
+
+// StockLevels lists the stock of each product.
+func StockLevels(catalog []Product) []StockLevel {
+	levels := make([]StockLevel, 0, len(catalog))
+	for _, product := range catalog {
+		levels = append(levels, StockLevel{
+			ProductID: product.ID,
+			Name:      product.Name,
+			OnHand:    product.OnHand,
+			Reserved:  product.Reserved,
+			Available: product.Available(),
+		})
+	}
+	return levels
+}
+
+// CheckAvailability reports for each item whether its quantity is
+// available. Unknown products and variants have nothing available.
+func CheckAvailability(catalog []Product, items []CartItem) []ItemAvailability {
+	results := make([]ItemAvailability, 0, len(items))
+	for _, item := range items {
+		available := availableUnits(catalog, item)
+		results = append(results, ItemAvailability{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Requested: item.Quantity,
+			Available: available,
+			OK:        item.Quantity <= available,
+		})
+	}
+	return results
+}
+
+// availableUnits is how many units of an item's product variant can be
+// sold, 0 for unknown ones.
+func availableUnits(catalog []Product, item CartItem) int {
+	product, ok := findProduct(catalog, item.ProductID)
+	if !ok {
+		return 0
+	}
+	sold, err := VariantProduct(product, item.SKU)
+	if err != nil {
+		return 0
+	}
+	return sold.Available()
+}
+
+// ReserveStock holds the items' units in catalog for an order. Either every
+// item is reserved or, when one is short, none is and the shortage is
+// returned.
+func ReserveStock(catalog []Product, reservations StockReservations, orderID int, items []CartItem) error {
+	if _, ok := reservations[orderID]; ok {
+		return fmt.Errorf("stock already reserved for order %d", orderID)
+	}
+	wanted := map[CartItem]int{}
+	for _, item := range items {
+		wanted[CartItem{ProductID: item.ProductID, SKU: item.SKU}] += item.Quantity
+	}
+	for line, quantity := range wanted {
+		i := productIndex(catalog, line.ProductID)
+		if i < 0 {
+			return fmt.Errorf("product %d does not exist", line.ProductID)
+		}
+		sold, err := VariantProduct(catalog[i], line.SKU)
+		if err != nil {
+			return err
+		}
+		if available := sold.Available(); quantity > available {
+			return fmt.Errorf("only %d of %s available", available, sold.Name)
+		}
+	}
+
+	for line, quantity := range wanted {
+		adjustStock(catalog, line, quantity, 0)
+	}
+	reservations[orderID] = append([]CartItem(nil), items...)
+	return nil
+}
+
+// ReleaseStock returns an order's reserved units to available stock.
+func ReleaseStock(catalog []Product, reservations StockReservations, orderID int) error {
+	return settleReservation(catalog, reservations, orderID, false)
+}
+
+// CommitStock takes an order's reserved units out of stock on hand.
+func CommitStock(catalog []Product, reservations StockReservations, orderID int) error {
+	return settleReservation(catalog, reservations, orderID, true)
+}
+
+// settleReservation drops an order's reservation, removing its units from
+// OnHand too when commit is set. Products deleted since are skipped.
+func settleReservation(catalog []Product, reservations StockReservations, orderID int, commit bool) error {
+	items, ok := reservations[orderID]
+	if !ok {
+		return ErrNoReservation
+	}
+	for _, item := range items {
+		committed := 0
+		if commit {
+			committed = item.Quantity
+		}
+		adjustStock(catalog, item, -item.Quantity, -committed)
+	}
+	delete(reservations, orderID)
+	return nil
+}
+
+// adjustStock changes the reserved and on-hand units of an item's product,
+// and of its variant if it names one, without going below zero. Products
+// and variants deleted since are skipped. The variants are copied before
+// changing them, as catalog copies handed out earlier share them.
+func adjustStock(catalog []Product, item CartItem, reserved, onHand int) {
+	i := productIndex(catalog, item.ProductID)
+	if i < 0 {
+		return
+	}
+	product := &catalog[i]
+	product.Reserved = max(product.Reserved+reserved, 0)
+	product.OnHand = max(product.OnHand+onHand, 0)
+	if v := variantIndex(*product, item.SKU); v >= 0 {
+		product.Variants = slices.Clone(product.Variants)
+		variant := &product.Variants[v]
+		variant.Reserved = max(variant.Reserved+reserved, 0)
+		variant.OnHand = max(variant.OnHand+onHand, 0)
+	}
+}
+
+// productIndex is the index of a product in catalog, or -1.
+func productIndex(catalog []Product, id int) int {
+	for i := range catalog {
+		if catalog[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// OrderItems lists an order's products and quantities as cart items.
+func OrderItems(order Order) []CartItem {
+	items := make([]CartItem, 0, len(order.Products))
+	for i, product := range order.Products {
+		if i < len(order.Quantities) {
+			item := CartItem{ProductID: product.ID, Quantity: order.Quantities[i]}
+			if i < len(order.SKUs) {
+				item.SKU = order.SKUs[i]
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestStockReservations tests reserving, releasing and committing stock
+func TestStockReservations(t *testing.T) {
+	catalog := []Product{
+		{ID: 1, Name: "Lamp", OnHand: 5},
+		{ID: 2, Name: "Rug", OnHand: 2, Reserved: 1},
+	}
+	reservations := StockReservations{}
+
+	if err := ReserveStock(catalog, reservations, 10, []CartItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 1}}); err != nil {
+		t.Fatalf("ReserveStock() error = %v", err)
+	}
+	if catalog[0].Available() != 2 || catalog[1].Available() != 0 || catalog[1].InStock() {
+		t.Errorf("Expected reserved units to be unavailable, got %+v", catalog)
+	}
+
+	// A short item reserves nothing
+	if err := ReserveStock(catalog, reservations, 11, []CartItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}); err == nil {
+		t.Error("Expected a shortage to be reported")
+	}
+	if catalog[0].Reserved != 3 || reservations[11] != nil {
+		t.Errorf("Expected a failed reservation to change nothing, got %+v", catalog)
+	}
+	if err := ReserveStock(catalog, reservations, 10, nil); err == nil {
+		t.Error("Expected a second reservation for an order to be rejected")
+	}
+
+	if err := CommitStock(catalog, reservations, 10); err != nil {
+		t.Fatalf("CommitStock() error = %v", err)
+	}
+	if catalog[0].OnHand != 2 || catalog[0].Reserved != 0 || catalog[1].OnHand != 1 || catalog[1].Reserved != 1 {
+		t.Errorf("Expected committed units to leave stock, got %+v", catalog)
+	}
+	if err := ReleaseStock(catalog, reservations, 10); !errors.Is(err, ErrNoReservation) {
+		t.Errorf("Expected ErrNoReservation after commit, got %v", err)
+	}
+
+	ReserveStock(catalog, reservations, 12, []CartItem{{ProductID: 1, Quantity: 2}})
+	if err := ReleaseStock(catalog, reservations, 12); err != nil || catalog[0].Available() != 2 || catalog[0].OnHand != 2 {
+		t.Errorf("Expected released units to be available again, got %+v (%v)", catalog[0], err)
+	}
+}
+
+// TestCheckAvailability tests per-item availability
+func TestCheckAvailability(t *testing.T) {
+	catalog := []Product{{ID: 1, Name: "Lamp", OnHand: 5, Reserved: 2}}
+	items := CheckAvailability(catalog, []CartItem{{ProductID: 1, Quantity: 3}, {ProductID: 1, Quantity: 4}, {ProductID: 9, Quantity: 1}})
+	if !items[0].OK || items[0].Available != 3 || items[1].OK || items[2].OK || items[2].Available != 0 {
+		t.Errorf("Unexpected availability: %+v", items)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Shared invoices - GenerateInvoice turns a priced order into an invoice
+// document, and the embedded templates render it as HTML and plain text.
+// The server's /api/orders/{id}/invoice endpoint and generateInvoiceWasm
+// render from the same templates (shared_invoice_render.go), so the preview
+// a page shows is the invoice the server sends.
+
+// InvoicePaymentDays is how long after the order date an unpaid invoice is
+// due.
+const InvoicePaymentDays = 30
+
+// Invoice is the invoice of one order. Amounts are in Currency and, like the
+// order's, tax-inclusive when IncludesTax is set.
+type Invoice struct {
+	Number       string        `json:"number"`
+	OrderID      int           `json:"order_id"`
+	IssueDate    string        `json:"issue_date"`
+	DueDate      string        `json:"due_date"`
+	PaymentTerms string        `json:"payment_terms"`
+	Currency     string        `json:"currency"`
+	IncludesTax  bool          `json:"includes_tax,omitempty"`
+	BillTo       InvoiceParty  `json:"bill_to"`
+	ShipTo       *Address      `json:"ship_to,omitempty"`
+	Lines        []InvoiceLine `json:"lines"`
+	// TaxBreakdown sums the lines by tax rate, after the discount
+	TaxBreakdown   []InvoiceTax `json:"tax_breakdown"`
+	Subtotal       float64      `json:"subtotal"`
+	Discount       float64      `json:"discount"`
+	Shipping       float64      `json:"shipping"`
+	Tax            float64      `json:"tax"`
+	Total          float64      `json:"total"`
+	GiftCardAmount float64      `json:"gift_card_amount,omitempty"`
+	AmountDue      float64      `json:"amount_due"`
+}
+
+// InvoiceParty is who an invoice is addressed to.
+type InvoiceParty struct {
+	Name    string   `json:"name"`
+	Email   string   `json:"email"`
+	Address *Address `json:"address,omitempty"`
+}
+
+// InvoiceLine is one product line of an invoice.
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	SKU         string  `json:"sku,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	TaxRate     float64 `json:"tax_rate"`
+	Amount      float64 `json:"amount"`
+}
+
+// InvoiceTax is the net amount taxed at one rate and the tax on it.
+type InvoiceTax struct {
+	Rate float64 `json:"rate"`
+	Net  float64 `json:"net"`
+	Tax  float64 `json:"tax"`
+}
+
+// GenerateInvoice builds the invoice of an order for the user who placed
+// it. Orders stored without line prices are priced again for their lines;
+// the totals are always the order's own.
+func GenerateInvoice(order Order, user User) Invoice {
+	lines := order.Lines
+	if len(lines) == 0 {
+		priced := order
+		CalculateOrderTotal(&priced, user)
+		lines = priced.Lines
+	}
+	currency := OrderCurrency(order)
+	round := func(amount float64) float64 { return RoundToCurrency(amount, currency) }
+
+	issued := orderPlacedDate(order)
+	invoice := Invoice{
+		Number:         fmt.Sprintf("INV-%06d", order.ID),
+		OrderID:        order.ID,
+		IssueDate:      issued.Format(taxDateLayout),
+		DueDate:        issued.AddDate(0, 0, InvoicePaymentDays).Format(taxDateLayout),
+		PaymentTerms:   fmt.Sprintf("Net %d days", InvoicePaymentDays),
+		Currency:       currency,
+		IncludesTax:    order.IncludesTax,
+		BillTo:         InvoiceParty{Name: user.Name, Email: user.Email, Address: user.Address},
+		ShipTo:         order.ShippingAddress,
+		Lines:          []InvoiceLine{},
+		TaxBreakdown:   []InvoiceTax{},
+		Subtotal:       order.Subtotal,
+		Discount:       order.Discount,
+		Shipping:       order.Shipping,
+		Tax:            order.Tax,
+		Total:          order.Total,
+		GiftCardAmount: order.GiftCardAmount,
+		AmountDue:      order.AmountDue,
+	}
+	if invoice.ShipTo == nil {
+		invoice.ShipTo = user.Address
+	}
+	if order.AmountDue <= 0 {
+		invoice.DueDate = invoice.IssueDate
+		invoice.PaymentTerms = "Paid in full"
+	}
+
+	byRate := map[float64]*InvoiceTax{}
+	netSum, taxSum := 0.0, 0.0
+	for i, line := range lines {
+		description, sku := fmt.Sprintf("Product %d", line.ProductID), ""
+		if i < len(order.Products) {
+			description = order.Products[i].Name
+		}
+		if i < len(order.SKUs) {
+			sku = order.SKUs[i]
+		}
+		unit, amount := line.UnitNet, line.Net
+		if order.IncludesTax {
+			unit, amount = line.UnitGross, line.Gross
+		}
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			Description: description,
+			SKU:         sku,
+			Quantity:    line.Quantity,
+			UnitPrice:   unit,
+			TaxRate:     line.TaxRate,
+			Amount:      amount,
+		})
+
+		group, ok := byRate[line.TaxRate]
+		if !ok {
+			group = &InvoiceTax{Rate: line.TaxRate}
+			byRate[line.TaxRate] = group
+		}
+		group.Net += line.Net
+		group.Tax += line.Tax
+		netSum += line.Net
+		taxSum += line.Tax
+	}
+
+	// The discount reduces every line, and so every rate's net and tax, by
+	// the same fraction
+	remaining := 1.0
+	switch {
+	case taxSum > 0:
+		remaining = order.Tax / taxSum
+	case netSum > 0:
+		remaining = (netSum - order.Discount) / netSum
+	}
+	for _, group := range byRate {
+		invoice.TaxBreakdown = append(invoice.TaxBreakdown, InvoiceTax{
+			Rate: group.Rate,
+			Net:  round(group.Net * remaining),
+			Tax:  round(group.Tax * remaining),
+		})
+	}
+	sort.Slice(invoice.TaxBreakdown, func(i, j int) bool { return invoice.TaxBreakdown[i].Rate > invoice.TaxBreakdown[j].Rate })
+
+	// The largest rate takes the rounding, so the breakdown adds up to the
+	// order's tax
+	if len(invoice.TaxBreakdown) > 0 {
+		rounded := 0.0
+		for _, group := range invoice.TaxBreakdown {
+			rounded += group.Tax
+		}
+		invoice.TaxBreakdown[0].Tax = round(invoice.TaxBreakdown[0].Tax + order.Tax - rounded)
+	}
+	return invoice
+}
+
+// invoiceDateLayout is how invoices print dates. It and the helpers below
+// are shared by the templates and the PDF, which is in the lite server
+// build the templates are left out of.
+const invoiceDateLayout = "January 2, 2006"
+
+// invoicePercent prints a tax rate as a percentage, 0.2 as 20%.
+func invoicePercent(rate float64) string {
+	return strconv.FormatFloat(math.Round(rate*10000)/100, 'f', -1, 64) + "%"
+}
+
+// invoiceDate prints a YYYY-MM-DD date in invoiceDateLayout, returning other
+// strings unchanged.
+func invoiceDate(date string) string {
+	parsed, err := time.Parse(taxDateLayout, date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(invoiceDateLayout)
+}
@@ -0,0 +1,49 @@
+//go:build !tinygo && !lite
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// invoiceTemplatesBuilt reports that invoices render in this build.
+const invoiceTemplatesBuilt = true
+
+//go:embed invoice.html.tmpl
+var invoiceHTMLSource string
+
+//go:embed invoice.txt.tmpl
+var invoiceTextSource string
+
+// invoiceFuncs are the helpers both invoice templates use.
+var invoiceFuncs = map[string]interface{}{
+	"money":   FormatCurrency,
+	"percent": invoicePercent,
+	"date":    invoiceDate,
+}
+
+var (
+	invoiceHTMLTemplate = htmltemplate.Must(htmltemplate.New("invoice").Funcs(invoiceFuncs).Parse(invoiceHTMLSource))
+	invoiceTextTemplate = texttemplate.Must(texttemplate.New("invoice").Funcs(invoiceFuncs).Parse(invoiceTextSource))
+)
+
+// RenderInvoiceHTML renders an invoice as a standalone HTML page.
+func RenderInvoiceHTML(invoice Invoice) (string, error) {
+	var out bytes.Buffer
+	if err := invoiceHTMLTemplate.Execute(&out, invoice); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// RenderInvoiceText renders an invoice as plain text, as for an email.
+func RenderInvoiceText(invoice Invoice) (string, error) {
+	var out bytes.Buffer
+	if err := invoiceTextTemplate.Execute(&out, invoice); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,23 @@
+//go:build tinygo || lite
+
+package main
+
+import "errors"
+
+// invoiceTemplatesBuilt reports that the invoice templates are left out.
+const invoiceTemplatesBuilt = false
+
+// errInvoiceRendering is returned by the TinyGo build, whose reflection
+// support is too limited for text/template, and the lite build, which
+// leaves the templates out for size; invoices render on the server.
+var errInvoiceRendering = errors.New("invoice rendering is not supported in this build - render invoices on the server")
+
+// RenderInvoiceHTML is not available in the TinyGo and lite builds.
+func RenderInvoiceHTML(invoice Invoice) (string, error) {
+	return "", errInvoiceRendering
+}
+
+// RenderInvoiceText is not available in the TinyGo and lite builds.
+func RenderInvoiceText(invoice Invoice) (string, error) {
+	return "", errInvoiceRendering
+}
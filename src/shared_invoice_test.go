@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestGenerateInvoice tests the lines, tax breakdown and terms of an invoice
+func TestGenerateInvoice(t *testing.T) {
+	user := User{ID: 3, Name: "Ann <Admin>", Email: "ann@example.com", Country: "UK", Premium: true,
+		Address: &Address{Street: "1 High St", City: "London", PostalCode: "SW1A 1AA", Country: "UK"}}
+	order := Order{
+		ID:         7,
+		UserID:     3,
+		OrderDate:  "2024-03-01",
+		Products:   []Product{{ID: 1, Name: "Laptop", Price: 100, Category: "electronics"}, {ID: 2, Name: "Novel", Price: 20, Category: "books"}},
+		Quantities: []int{1, 2},
+		SKUs:       []string{"", "NOVEL-PB"},
+	}
+	CalculateOrderTotal(&order, user)
+	invoice := GenerateInvoice(order, user)
+
+	if invoice.Number != "INV-000007" || invoice.IssueDate != "2024-03-01" || invoice.DueDate != "2024-03-31" || invoice.PaymentTerms != "Net 30 days" {
+		t.Errorf("Unexpected invoice header: %+v", invoice)
+	}
+	if len(invoice.Lines) != 2 || invoice.Lines[1].Description != "Novel" || invoice.Lines[1].SKU != "NOVEL-PB" || invoice.Lines[1].Quantity != 2 {
+		t.Errorf("Unexpected lines: %+v", invoice.Lines)
+	}
+	if invoice.ShipTo != user.Address {
+		t.Error("Expected the user's address as the shipping address")
+	}
+
+	// The premium discount reduces each rate's share; books are zero-rated
+	if len(invoice.TaxBreakdown) != 2 || invoice.TaxBreakdown[0].Rate != 0.20 || invoice.TaxBreakdown[1].Tax != 0 {
+		t.Fatalf("Unexpected tax breakdown: %+v", invoice.TaxBreakdown)
+	}
+	tax, net := 0.0, 0.0
+	for _, group := range invoice.TaxBreakdown {
+		tax += group.Tax
+		net += group.Net
+	}
+	if math.Abs(tax-order.Tax) > 1e-9 || math.Abs(net-(order.Subtotal-order.Discount)) > 0.01 {
+		t.Errorf("Tax breakdown adds up to %v on %v, want %v on %v", tax, net, order.Tax, order.Subtotal-order.Discount)
+	}
+
+	order.GiftCardAmount, order.AmountDue = order.Total, 0
+	if paid := GenerateInvoice(order, user); paid.PaymentTerms != "Paid in full" || paid.DueDate != paid.IssueDate {
+		t.Errorf("Expected a paid invoice to be due on issue, got %+v", paid)
+	}
+}
+
+// TestRenderInvoice tests that both renderings show the invoice
+func TestRenderInvoice(t *testing.T) {
+	user := User{ID: 3, Name: "Ann <Admin>", Email: "ann@example.com", Country: "US"}
+	order := Order{ID: 7, OrderDate: "2024-03-01", Products: []Product{{ID: 1, Name: "Laptop", Price: 100, Category: "electronics"}}, Quantities: []int{1}}
+	CalculateOrderTotal(&order, user)
+	invoice := GenerateInvoice(order, user)
+
+	html, err := RenderInvoiceHTML(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "Invoice INV-000007") || !strings.Contains(html, "Ann &lt;Admin&gt;") || !strings.Contains(html, "March 31, 2024") {
+		t.Errorf("Unexpected HTML invoice:\n%s", html)
+	}
+
+	text, err := RenderInvoiceText(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"INVOICE INV-000007", "Ann <Admin>", "Laptop", "Tax 8%", FormatCurrency(order.AmountDue, "USD")} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the text invoice to contain %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "false") || strings.Contains(text, "Discount") {
+		t.Errorf("Expected no discount line or stray values:\n%s", text)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shared locale formatting - FormatMoney writes an amount the way a locale
+// writes it: its decimal and thousands separators, digit grouping (Indian
+// lakh grouping included) and where the currency symbol goes, with the
+// currency's own minor units. Locales write their home currency with the
+// local symbol ("$" for CAD in Canada, where en-US writes "CA$"). The
+// separators follow CLDR, so French groups with a narrow no-break space and
+// puts a no-break space before the symbol. FormatDate writes dates in the
+// locale's numeric order.
+
+// DefaultLocale is the locale of amounts formatted without one.
+const DefaultLocale = "en-US"
+
+// Locale describes how one locale writes numbers and amounts.
+type Locale struct {
+	Tag     string `json:"tag"` // BCP 47
+	Decimal string `json:"decimal"`
+	Group   string `json:"group"`
+	// Grouping is the digits in each group from the right; the last size
+	// repeats
+	Grouping    []int             `json:"grouping"`
+	SymbolAfter bool              `json:"symbol_after,omitempty"`
+	SymbolSpace bool              `json:"symbol_space,omitempty"`
+	Symbols     map[string]string `json:"symbols,omitempty"` // local symbols by currency code
+	// DateLayout is how the locale writes a date, as a time layout
+	DateLayout string `json:"date_layout"`
+}
+
+// locales lists the supported locales, covering the demo countries.
+var locales = map[string]Locale{
+	"en-US": {Tag: "en-US", Decimal: ".", Group: ",", Grouping: []int{3}, DateLayout: "01/02/2006"},
+	"en-GB": {Tag: "en-GB", Decimal: ".", Group: ",", Grouping: []int{3}, DateLayout: "02/01/2006"},
+	"en-CA": {Tag: "en-CA", Decimal: ".", Group: ",", Grouping: []int{3}, Symbols: map[string]string{"CAD": "$", "USD": "US$"}, DateLayout: "2006-01-02"},
+	"fr-CA": {Tag: "fr-CA", Decimal: ",", Group: "\u202f", Grouping: []int{3}, SymbolAfter: true, SymbolSpace: true, Symbols: map[string]string{"CAD": "$", "USD": "$\u00a0US"}, DateLayout: "2006-01-02"},
+	"de-DE": {Tag: "de-DE", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolAfter: true, SymbolSpace: true, DateLayout: "02.01.2006"},
+	"fr-FR": {Tag: "fr-FR", Decimal: ",", Group: "\u202f", Grouping: []int{3}, SymbolAfter: true, SymbolSpace: true, DateLayout: "02/01/2006"},
+	"ja-JP": {Tag: "ja-JP", Decimal: ".", Group: ",", Grouping: []int{3}, Symbols: map[string]string{"JPY": "￥"}, DateLayout: "2006/01/02"},
+	"en-AU": {Tag: "en-AU", Decimal: ".", Group: ",", Grouping: []int{3}, Symbols: map[string]string{"AUD": "$", "USD": "USD"}, DateLayout: "02/01/2006"},
+	"en-IN": {Tag: "en-IN", Decimal: ".", Group: ",", Grouping: []int{3, 2}, DateLayout: "02/01/2006"},
+	"pt-BR": {Tag: "pt-BR", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolSpace: true, Symbols: map[string]string{"USD": "US$"}, DateLayout: "02/01/2006"},
+	"es-MX": {Tag: "es-MX", Decimal: ".", Group: ",", Grouping: []int{3}, Symbols: map[string]string{"MXN": "$", "USD": "USD"}, DateLayout: "02/01/2006"},
+}
+
+// plainLocale writes amounts without grouping, as FormatCurrency always has.
+var plainLocale = Locale{Decimal: "."}
+
+// languageLocales are the locales a bare language finds when it has more
+// than one.
+var languageLocales = map[string]string{"en": "en-US", "fr": "fr-FR"}
+
+// LookupLocale finds a locale by tag, ignoring case and accepting "_" for
+// "-". A bare language ("de") finds a locale of that language, and an empty
+// tag is the default locale.
+func LookupLocale(tag string) (Locale, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		tag = DefaultLocale
+	}
+	if !strings.Contains(tag, "-") {
+		language := strings.ToLower(tag)
+		if preferred, ok := languageLocales[language]; ok {
+			return locales[preferred], true
+		}
+		for _, key := range Locales() {
+			if strings.HasPrefix(strings.ToLower(key), language+"-") {
+				return locales[key], true
+			}
+		}
+		return Locale{}, false
+	}
+	for key, locale := range locales {
+		if strings.EqualFold(key, tag) {
+			return locale, true
+		}
+	}
+	return Locale{}, false
+}
+
+// localeFor is LookupLocale falling back to the default locale.
+func localeFor(tag string) Locale {
+	if locale, ok := LookupLocale(tag); ok {
+		return locale
+	}
+	return locales[DefaultLocale]
+}
+
+// Locales lists the supported locale tags in order.
+func Locales() []string {
+	tags := slices.Collect(maps.Keys(locales))
+	slices.Sort(tags)
+	return tags
+}
+
+// FormatMoney writes an amount in a currency as a locale writes it. Unknown
+// currencies format as USD and unknown locales as DefaultLocale.
+func FormatMoney(amount float64, code, locale string) string {
+	return formatMoney(amount, currencyFor(code), localeFor(locale))
+}
+
+// FormatNumber writes a number with a locale's separators, rounded to
+// decimals places.
+func FormatNumber(value float64, decimals int, locale string) string {
+	sign, digits := formatDigits(value, decimals, localeFor(locale))
+	return sign + digits
+}
+
+// FormatDate writes a YYYY-MM-DD date as a locale writes it, returning
+// other strings unchanged.
+func FormatDate(date, locale string) string {
+	parsed, err := time.Parse(taxDateLayout, date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(localeFor(locale).DateLayout)
+}
+
+// CountryLocale is the supported locale of a country, the first by tag when
+// it has several (en-CA for Canada), or DefaultLocale.
+func CountryLocale(country string) string {
+	if found, ok := LookupCountry(country); ok {
+		for _, tag := range Locales() {
+			if strings.HasSuffix(tag, "-"+found.Code) {
+				return tag
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+func formatMoney(amount float64, currency Currency, locale Locale) string {
+	symbol := currency.Symbol
+	if local, ok := locale.Symbols[currency.Code]; ok {
+		symbol = local
+	}
+	space := ""
+	if locale.SymbolSpace {
+		space = "\u00a0" // no-break space
+	}
+	sign, digits := formatDigits(amount, currency.Decimals, locale)
+	if locale.SymbolAfter {
+		return sign + digits + space + symbol
+	}
+	return sign + symbol + space + digits
+}
+
+// formatDigits writes the magnitude of a rounded value with a locale's
+// separators, returning "-" for negative values separately so the symbol can
+// go between.
+func formatDigits(value float64, decimals int, locale Locale) (string, string) {
+	scale := math.Pow10(decimals)
+	value = math.Round(value*scale) / scale
+	sign := ""
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	whole, fraction, _ := strings.Cut(strconv.FormatFloat(value, 'f', decimals, 64), ".")
+
+	if locale.Group != "" && len(locale.Grouping) > 0 {
+		var groups []string
+		for i := 0; len(whole) > 0; i++ {
+			size := locale.Grouping[min(i, len(locale.Grouping)-1)]
+			if size <= 0 || size >= len(whole) {
+				groups = append(groups, whole)
+				break
+			}
+			groups = append(groups, whole[len(whole)-size:])
+			whole = whole[:len(whole)-size]
+		}
+		for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+			groups[i], groups[j] = groups[j], groups[i]
+		}
+		whole = strings.Join(groups, locale.Group)
+	}
+	if fraction != "" {
+		return sign, whole + locale.Decimal + fraction
+	}
+	return sign, whole
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+// TestFormatMoney tests separators, grouping and symbol placement per locale
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount       float64
+		code, locale string
+		want         string
+	}{
+		{1234567.891, "USD", "en-US", "$1,234,567.89"},
+		{1234.5, "EUR", "de-DE", "1.234,50\u00a0€"},
+		{1234.5, "EUR", "fr-FR", "1\u202f234,50\u00a0€"},
+		{-1234.5, "EUR", "de", "-1.234,50\u00a0€"},
+		{1234.5, "CAD", "en-CA", "$1,234.50"},
+		{1234.5, "CAD", "en-US", "CA$1,234.50"},
+		{1234.5, "USD", "en_CA", "US$1,234.50"},
+		{1500.4, "JPY", "ja-JP", "￥1,500"},
+		{12345678.9, "INR", "en-IN", "₹1,23,45,678.90"},
+		{1234.5, "BRL", "pt-BR", "R$\u00a01.234,50"},
+		{999, "USD", "xx-XX", "$999.00"},
+		{0.5, "XYZ", "", "$0.50"},
+	}
+	for _, tt := range tests {
+		if got := FormatMoney(tt.amount, tt.code, tt.locale); got != tt.want {
+			t.Errorf("FormatMoney(%v, %q, %q) = %q, want %q", tt.amount, tt.code, tt.locale, got, tt.want)
+		}
+	}
+
+	if got := FormatNumber(1234567.125, 1, "de-DE"); got != "1.234.567,1" {
+		t.Errorf("FormatNumber() = %q, want 1.234.567,1", got)
+	}
+	if locale, ok := LookupLocale("fr"); !ok || locale.Tag != "fr-FR" {
+		t.Errorf("Expected fr to find fr-FR, got %+v", locale)
+	}
+	if _, ok := LookupLocale("xx"); ok {
+		t.Error("Expected an unknown language to be rejected")
+	}
+}
+
+// TestFormatDate tests date order per locale and the locales of countries
+func TestFormatDate(t *testing.T) {
+	for locale, want := range map[string]string{"en-US": "03/31/2024", "en-GB": "31/03/2024", "de-DE": "31.03.2024", "ja-JP": "2024/03/31", "en-CA": "2024-03-31", "xx": "03/31/2024"} {
+		if got := FormatDate("2024-03-31", locale); got != want {
+			t.Errorf("FormatDate(%q) = %q, want %q", locale, got, want)
+		}
+	}
+	if got := FormatDate("soon", "de-DE"); got != "soon" {
+		t.Errorf("Expected a non-date unchanged, got %q", got)
+	}
+	for country, want := range map[string]string{"DE": "de-DE", "UK": "en-GB", "CA": "en-CA", "jpn": "ja-JP", "ZZ": DefaultLocale, "": DefaultLocale} {
+		if got := CountryLocale(country); got != want {
+			t.Errorf("CountryLocale(%q) = %q, want %q", country, got, want)
+		}
+	}
+	for _, tag := range Locales() {
+		if locales[tag].DateLayout == "" {
+			t.Errorf("%s has no date layout", tag)
+		}
+	}
+}
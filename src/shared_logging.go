@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Shared logging - business logic logs through the log/slog default logger,
+// so one code path writes the same records on both sides: the server's
+// logger (setupLogging) writes text to stderr and JSON lines to -log-file,
+// the WASM module's (main_console.go) writes to the browser console. The
+// request or benchmark a call serves travels in its context, taken by the
+// ...Context variants of the business logic, and logIDHandler adds its ID to
+// every record logged with that context.
+
+// logIDKey is the context key of one kind of ID, named as its attribute.
+type logIDKey struct{ attr string }
+
+var (
+	requestIDKey   = logIDKey{"request_id"}
+	benchmarkIDKey = logIDKey{"benchmark_id"}
+)
+
+// WithRequestID returns a copy of ctx whose records carry a request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// WithBenchmarkID returns a copy of ctx whose records carry a benchmark ID,
+// such as the challenge a benchmark run answers.
+func WithBenchmarkID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, benchmarkIDKey, id)
+}
+
+// logIDHandler adds the IDs of each record's context to the record, the
+// trace and span (shared_tracing.go) included, unless it already has them.
+type logIDHandler struct {
+	slog.Handler
+}
+
+func (h logIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, key := range []logIDKey{requestIDKey, benchmarkIDKey} {
+		if id, ok := ctx.Value(key).(string); ok {
+			addLogID(&record, key.attr, id)
+		}
+	}
+	if span, ok := ctx.Value(activeSpanKey{}).(activeSpan); ok {
+		addLogID(&record, "trace_id", span.trace.ID)
+		addLogID(&record, "span_id", span.id)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// addLogID adds an ID attribute to record, unless the ID is empty or the
+// record already has the attribute.
+func addLogID(record *slog.Record, attr, id string) {
+	if id == "" {
+		return
+	}
+	present := false
+	record.Attrs(func(a slog.Attr) bool {
+		present = a.Key == attr
+		return !present
+	})
+	if !present {
+		record.AddAttrs(slog.String(attr, id))
+	}
+}
+
+func (h logIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logIDHandler) WithGroup(name string) slog.Handler {
+	return logIDHandler{h.Handler.WithGroup(name)}
+}
+
+// logValidation records a failed validation of a model. The level is checked
+// first, as building the record's attributes allocates even when it is
+// dropped.
+func logValidation(ctx context.Context, model string, result ValidationResult) {
+	if !result.Valid && slog.Default().Enabled(ctx, slog.LevelDebug) {
+		slog.DebugContext(ctx, "validation failed", "model", model, "errors", len(result.Errors))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger's records, debug included, to a
+// buffer as JSON lines through logIDHandler.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logIDHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// logRecords decodes the captured records.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogIDHandler(t *testing.T) {
+	buf := captureLogs(t)
+	ctx := WithBenchmarkID(WithRequestID(context.Background(), "req-1"), "bench-1")
+	slog.InfoContext(ctx, "one")
+	slog.InfoContext(ctx, "two", "request_id", "explicit")
+	slog.Info("three")
+
+	records := logRecords(t, buf)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %q", buf.String())
+	}
+	if records[0]["request_id"] != "req-1" || records[0]["benchmark_id"] != "bench-1" {
+		t.Errorf("Expected both IDs, got %v", records[0])
+	}
+	if records[1]["request_id"] != "explicit" || strings.Count(buf.String(), `"request_id"`) != 2 {
+		t.Errorf("Expected the record's own request_id to be kept once, got %q", buf.String())
+	}
+	if _, ok := records[2]["request_id"]; ok {
+		t.Errorf("Expected no IDs without a context, got %v", records[2])
+	}
+}
+
+func TestBusinessLogicLogsForTheCall(t *testing.T) {
+	buf := captureLogs(t)
+	ctx := WithRequestID(context.Background(), "req-2")
+
+	ValidateUserContext(ctx, User{Name: "x"})
+	ValidateProductContext(ctx, Product{Name: "Valid product", Price: 10, Category: "books"})
+	order := Order{Products: []Product{{ID: 1, Name: "Book", Price: 10, Category: "books"}}, Quantities: []int{1}}
+	CalculateOrderTotalContext(ctx, &order, User{Country: "US"})
+	runChallengeBenchmark(WithBenchmarkID(ctx, "ch-1"), "matrix", map[string]int{}, 1)
+
+	records := logRecords(t, buf)
+	var messages []string
+	for _, record := range records {
+		messages = append(messages, record["msg"].(string))
+		if record["request_id"] != "req-2" {
+			t.Errorf("Expected request_id req-2, got %v", record)
+		}
+	}
+	if got := strings.Join(messages, ", "); got != "validation failed, order priced, benchmark proof failed" {
+		t.Errorf("Expected a failed user validation, a priced order and a failed proof, got %s", got)
+	}
+	if records[len(records)-1]["benchmark_id"] != "ch-1" {
+		t.Errorf("Expected the proof logged under its benchmark, got %v", records[len(records)-1])
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
+	"log/slog"
+	"math"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Shared data models - used identically on both server and client
@@ -15,8 +22,39 @@ type User struct {
 	Name     string `json:"name"`
 	Age      int    `json:"age"`
 	Country  string `json:"country"`
+	Region   string `json:"region,omitempty"` // state or province, for regional tax
 	Premium  bool   `json:"premium"`
 	JoinDate string `json:"join_date"`
+	// EmailVerified is set once the user has confirmed Email
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// Anonymized is set once AnonymizeUser has scrubbed the personal data
+	Anonymized bool `json:"anonymized,omitempty"`
+	// Phone is optional; ValidatePhone checks it for Country
+	Phone string `json:"phone,omitempty"`
+	// Address is where the user's orders ship unless an order says otherwise
+	Address *Address `json:"address,omitempty"`
+	// Preferences are the user's stated tastes, if they gave any
+	Preferences *Preferences `json:"preferences,omitempty"`
+	// Notifications are how the user hears about their orders; nil is
+	// email as events happen
+	Notifications *NotificationSettings `json:"notifications,omitempty"`
+	// LoyaltyPoints is the user's balance of points to redeem on orders
+	LoyaltyPoints int `json:"loyalty_points,omitempty"`
+	// SchemaVersion is the model version the user was written with; see
+	// MigrateJSON
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// Version goes up with every change to the user; updates name the
+	// version they were made against, see CheckVersion
+	Version int `json:"version,omitempty"`
+}
+
+// Address is a postal address.
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"` // state, province or prefecture
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
 }
 
 type Product struct {
@@ -24,9 +62,58 @@ type Product struct {
 	Name        string  `json:"name"`
 	Price       float64 `json:"price"`
 	Category    string  `json:"category"`
-	InStock     bool    `json:"in_stock"`
 	Rating      float64 `json:"rating"`
 	Description string  `json:"description"`
+	Currency    string  `json:"currency,omitempty"`
+	// Stock counts; see Available. For products with Variants they are the
+	// variants' totals.
+	OnHand   int              `json:"on_hand"`
+	Reserved int              `json:"reserved"`
+	Variants []ProductVariant `json:"variants,omitempty"`
+	// Warehouse stocking the product; empty for DefaultWarehouse
+	Warehouse string `json:"warehouse,omitempty"`
+	// PriceTiers are quantity breaks; see UnitPrice
+	PriceTiers []PriceTier `json:"price_tiers,omitempty"`
+	// Shipping weight and parcel size; see BillableWeight
+	WeightKg float64 `json:"weight_kg,omitempty"`
+	LengthCm float64 `json:"length_cm,omitempty"`
+	WidthCm  float64 `json:"width_cm,omitempty"`
+	HeightCm float64 `json:"height_cm,omitempty"`
+	// UnitCost is what a unit costs the shop, in the currency of Price; 0
+	// when unknown. See TopProducts
+	UnitCost float64 `json:"unit_cost,omitempty"`
+	// Accessories are the IDs of products sold with this one; see
+	// RecommendCrossSells
+	Accessories []int `json:"accessories,omitempty"`
+	// ImageURL is the main picture and Images the pictures with their
+	// sizes; see SelectThumbnail
+	ImageURL string         `json:"image_url,omitempty"`
+	Images   []ProductImage `json:"images,omitempty"`
+	// SchemaVersion is the model version the product was written with
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// Version goes up with every change to the product; updates name the
+	// version they were made against, see CheckVersion
+	Version int `json:"version,omitempty"`
+}
+
+// PriceTier takes DiscountPercent off the unit price of an order line of at
+// least MinQuantity units.
+type PriceTier struct {
+	MinQuantity     int     `json:"min_quantity"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// UnitPrice is the product's price per unit when quantity units are ordered
+// together: the price less the discount of the largest tier reached.
+func (p Product) UnitPrice(quantity int) float64 {
+	discount := 0.0
+	best := 0
+	for _, tier := range p.PriceTiers {
+		if quantity >= tier.MinQuantity && tier.MinQuantity > best {
+			best, discount = tier.MinQuantity, tier.DiscountPercent
+		}
+	}
+	return p.Price * (1 - discount/100)
 }
 
 type Order struct {
@@ -41,169 +128,616 @@ type Order struct {
 	Discount   float64   `json:"discount"`
 	OrderDate  string    `json:"order_date"`
 	Status     string    `json:"status"`
+	Currency   string    `json:"currency,omitempty"`
+	// IncludesTax shows Subtotal and Discount tax-inclusive; see
+	// CalculateOrderTotal
+	IncludesTax bool             `json:"includes_tax,omitempty"`
+	Lines       []PriceBreakdown `json:"lines,omitempty"`
+	// The shipping option chosen from QuoteShipping, if any, and the last
+	// day it is expected to arrive
+	ShippingCarrier   string `json:"shipping_carrier,omitempty"`
+	ShippingService   string `json:"shipping_service,omitempty"`
+	EstimatedDelivery string `json:"estimated_delivery,omitempty"`
+	// ShippingAddress overrides the user's address for this order
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	// Shipments split the order into parcels; see SplitOrder
+	Shipments []Shipment `json:"shipments,omitempty"`
+	// SKUs name the variant each line is of, "" for products without
+	// variants; nil when no line has one
+	SKUs []string `json:"skus,omitempty"`
+	// GiftCardCode names a gift card paying for the order. Callers look the
+	// card up into GiftCard before pricing; GiftCardAmount is what it pays
+	// and AmountDue the rest of the total.
+	GiftCardCode   string    `json:"gift_card_code,omitempty"`
+	GiftCard       *GiftCard `json:"-"`
+	GiftCardAmount float64   `json:"gift_card_amount,omitempty"`
+	AmountDue      float64   `json:"amount_due"`
+	// CouponCode and LoyaltyPoints ask for a coupon and a redemption of the
+	// user's points; Discounts itemizes the Discount the stacking rules
+	// allowed, see shared_discounts.go
+	CouponCode    string         `json:"coupon_code,omitempty"`
+	LoyaltyPoints int            `json:"loyalty_points,omitempty"`
+	Discounts     []DiscountLine `json:"discounts,omitempty"`
+	// Promotions are the campaigns the order qualified for when priced
+	Promotions []AppliedPromotion `json:"promotions,omitempty"`
+	// RiskScore is the order's ScoreOrderRisk score when it was placed
+	RiskScore int `json:"risk_score,omitempty"`
+	// SchemaVersion is the model version the order was written with
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// Version goes up with every change to the order; updates name the
+	// version they were made against, see CheckVersion
+	Version int `json:"version,omitempty"`
+}
+
+// PriceBreakdown is one order line priced before the order's discount: the
+// net price, the tax on it and the gross price a customer sees.
+type PriceBreakdown struct {
+	ProductID int     `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	TaxRate   float64 `json:"tax_rate"`
+	UnitNet   float64 `json:"unit_net"`
+	UnitGross float64 `json:"unit_gross"`
+	Net       float64 `json:"net"`
+	Tax       float64 `json:"tax"`
+	Gross     float64 `json:"gross"`
 }
 
+// ValidationResult is the outcome of a validator. FieldErrors tie each
+// error and warning to the input it concerns, so forms can highlight it.
 type ValidationResult struct {
-	Valid  bool     `json:"valid"`
-	Errors []string `json:"errors"`
+	Valid bool `json:"valid"`
+	// Errors are the messages of the error FieldErrors, for clients written
+	// before FieldErrors
+	Errors      []string     `json:"errors"`
+	FieldErrors []FieldError `json:"field_errors"`
+	// Warnings flag what is suspicious but allowed
+	Warnings []ValidationWarning `json:"warnings,omitempty"`
+}
+
+// FieldError is one validation problem. Field is the JSON path of the input,
+// as in "address.postal_code" or "variants[1].sku"; Code is one of the
+// Code constants or a warning code.
+type FieldError struct {
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// FieldError severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Validation error codes
+const (
+	CodeRequired      = "required"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeUnknownValue  = "unknown_value"
+	CodeDuplicate     = "duplicate"
+	CodeInconsistent  = "inconsistent"
+	CodeNotFound      = "not_found"
+	CodeUnavailable   = "unavailable"
+)
+
+// newValidationResult is a valid result with nothing to report.
+func newValidationResult() ValidationResult {
+	return ValidationResult{Valid: true, Errors: []string{}, FieldErrors: []FieldError{}}
+}
+
+// validationResultCapacity is the room made for errors at the first one; a
+// result seldom has more, so its lists aren't grown one error at a time.
+const validationResultCapacity = 4
+
+// AddError records an error on a field, which makes the result invalid.
+func (result *ValidationResult) AddError(field, code, message string) {
+	result.Valid = false
+	if cap(result.Errors) == 0 {
+		result.Errors = make([]string, 0, validationResultCapacity)
+	}
+	result.Errors = append(result.Errors, message)
+	result.addFieldError(FieldError{Field: field, Code: code, Message: message, Severity: SeverityError})
+}
+
+// AddWarning records a warning, which leaves the result valid.
+func (result *ValidationResult) AddWarning(warning ValidationWarning) {
+	result.Warnings = append(result.Warnings, warning)
+	result.addFieldError(FieldError{Field: warning.Field, Code: warning.Code, Message: warning.Message, Severity: SeverityWarning})
+}
+
+func (result *ValidationResult) addFieldError(fieldErr FieldError) {
+	if cap(result.FieldErrors) == 0 {
+		result.FieldErrors = make([]FieldError, 0, validationResultCapacity)
+	}
+	result.FieldErrors = append(result.FieldErrors, fieldErr)
+}
+
+// Merge adds the errors and warnings of a nested value's result, with
+// prefix ("address.") before their fields.
+func (result *ValidationResult) Merge(prefix string, other ValidationResult) {
+	for _, fieldErr := range other.FieldErrors {
+		if fieldErr.Severity == SeverityError {
+			result.AddError(prefix+fieldErr.Field, fieldErr.Code, fieldErr.Message)
+		}
+	}
+	for _, warning := range other.Warnings {
+		warning.Field = prefix + warning.Field
+		result.AddWarning(warning)
+	}
+}
+
+// ValidationWarning is a problem that doesn't make a value invalid, with a
+// machine-readable code and, where there is one, a suggested correction.
+type ValidationWarning struct {
+	Field      string `json:"field"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
 }
 
 // Shared business logic - identical implementation on server and client
 func ValidateUser(user User) ValidationResult {
-	result := ValidationResult{Valid: true, Errors: []string{}}
+	return ValidateUserContext(context.Background(), user)
+}
+
+// ValidateUserContext is ValidateUser logging and tracing for the call in ctx.
+func ValidateUserContext(ctx context.Context, user User) ValidationResult {
+	ctx, end := StartSpan(ctx, "ValidateUser")
+	defer end()
+
+	result := newValidationResult()
 
 	// Email validation
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(user.Email) {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Invalid email format")
+	result.Merge("", ValidateEmail(user.Email))
+
+	// Name, age and other field constraints from the ruleset
+	applyValidationRules(SchemaUser, user, &result)
+
+	// Country validation
+	if _, ok := LookupCountry(user.Country); !ok {
+		result.AddError("country", CodeUnknownValue, "Invalid country code")
 	}
 
-	// Name validation
-	if len(strings.TrimSpace(user.Name)) < 2 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Name must be at least 2 characters")
+	// Phone validation
+	if user.Phone != "" {
+		if _, _, err := checkPhone(user.Phone, user.Country); err != nil {
+			result.AddError("phone", CodeInvalidFormat, "Invalid phone number: "+err.Error())
+		}
 	}
 
-	// Age validation
-	if user.Age < 13 || user.Age > 120 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Age must be between 13 and 120")
+	// Address validation
+	if user.Address != nil {
+		result.Merge("address.", ValidateAddress(*user.Address))
 	}
 
-	// Country validation
-	validCountries := []string{"US", "CA", "UK", "DE", "FR", "JP", "AU", "IN", "BR", "MX"}
-	isValidCountry := false
-	for _, country := range validCountries {
-		if user.Country == country {
-			isValidCountry = true
-			break
+	// Preference validation
+	if user.Preferences != nil {
+		result.Merge("preferences.", ValidatePreferences(*user.Preferences))
+	}
+
+	// Notification settings validation
+	if user.Notifications != nil {
+		result.Merge("notifications.", ValidateNotificationSettings(*user.Notifications))
+	}
+
+	logValidation(ctx, SchemaUser, result)
+	return result
+}
+
+// regionRequired lists the countries whose addresses need a state or
+// province.
+var regionRequired = []string{"US", "CA", "AU", "BR", "MX", "IN"}
+
+// ValidateAddress checks an address against its country's rules.
+func ValidateAddress(address Address) ValidationResult {
+	result := newValidationResult()
+
+	if strings.TrimSpace(address.Street) == "" {
+		result.AddError("street", CodeRequired, "Address street is required")
+	}
+	if strings.TrimSpace(address.City) == "" {
+		result.AddError("city", CodeRequired, "Address city is required")
+	}
+
+	if _, ok := LookupCountry(address.Country); !ok {
+		result.AddError("country", CodeUnknownValue, "Invalid address country code")
+		return result
+	}
+	country := canonicalCountry(address.Country)
+	if slices.Contains(regionRequired, country) && strings.TrimSpace(address.Region) == "" {
+		result.AddError("region", CodeRequired, "Address region is required in "+country)
+	}
+	// Postal codes are only checked where their format is known
+	if _, ok := postalCodeFormats[country]; ok {
+		if _, err := ValidatePostalCode(address.PostalCode, country); err != nil {
+			result.AddError("postal_code", CodeInvalidFormat, "Invalid postal code for "+country+": "+err.Error())
 		}
 	}
-	if !isValidCountry {
+
+	return result
+}
+
+// Password rules - checked the same way by the client as the user types
+// and by the server before a password is accepted.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+	// passphraseLength is the length from which any mix of characters will do
+	passphraseLength = 16
+	// minPasswordEntropy is the estimated strength, in bits, a password needs
+	minPasswordEntropy = 36
+)
+
+// commonPasswords are among the most used passwords; they are rejected
+// whatever their case or the digits and symbols added to their end.
+var commonPasswords = map[string]bool{
+	"123456": true, "12345678": true, "123456789": true, "1234567890": true,
+	"password": true, "passw0rd": true, "p@ssw0rd": true, "qwerty": true,
+	"qwertyuiop": true, "asdfghjkl": true, "zxcvbnm": true, "1q2w3e4r": true,
+	"abc123": true, "111111": true, "000000": true, "iloveyou": true,
+	"admin": true, "administrator": true, "welcome": true, "letmein": true,
+	"monkey": true, "dragon": true, "football": true, "baseball": true,
+	"sunshine": true, "princess": true, "master": true, "shadow": true,
+	"superman": true, "batman": true, "trustno1": true, "starwars": true,
+	"whatever": true, "freedom": true, "computer": true, "michael": true,
+	"jennifer": true, "charlie": true, "secret": true, "changeme": true,
+	"default": true, "login": true, "hello": true, "loveme": true,
+	"summer": true, "winter": true, "spring": true, "autumn": true,
+}
+
+// PasswordStrength is the outcome of a password check. Score runs from 0
+// (very weak) to 4 (very strong); the password is accepted when Valid.
+type PasswordStrength struct {
+	Valid       bool     `json:"valid"`
+	Score       int      `json:"score"`
+	Rating      string   `json:"rating"`
+	EntropyBits float64  `json:"entropy_bits"`
+	Errors      []string `json:"errors"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// passwordRatings name the scores.
+var passwordRatings = []string{"very weak", "weak", "fair", "strong", "very strong"}
+
+// ValidatePasswordStrength checks a password's length, character classes
+// and estimated entropy, and rejects common passwords. Passwords shorter
+// than passphraseLength need three of lower case, upper case, digits and
+// symbols.
+func ValidatePasswordStrength(password string) PasswordStrength {
+	result := PasswordStrength{Valid: true, Errors: []string{}, Suggestions: []string{}}
+	fail := func(message, suggestion string) {
 		result.Valid = false
-		result.Errors = append(result.Errors, "Invalid country code")
+		result.Errors = append(result.Errors, message)
+		if suggestion != "" {
+			result.Suggestions = append(result.Suggestions, suggestion)
+		}
 	}
 
+	length := utf8.RuneCountInString(password)
+	if length < MinPasswordLength {
+		fail(fmt.Sprintf("Password must be at least %d characters", MinPasswordLength), "Use a longer password or a passphrase of several words")
+	}
+	if length > MaxPasswordLength {
+		fail(fmt.Sprintf("Password must be at most %d characters", MaxPasswordLength), "")
+	}
+
+	lower, upper, digit, symbol, other := false, false, false, false, false
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= ' ' && r <= '~':
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol || other} {
+		if present {
+			classes++
+		}
+	}
+	if length >= MinPasswordLength && length < passphraseLength && classes < 3 {
+		fail("Password must mix at least three of lower case, upper case, digits and symbols", "Add upper case letters, digits or symbols, or use a passphrase of 16 or more characters")
+	}
+
+	common := isCommonPassword(password)
+	if common {
+		fail("Password is too common", "Avoid well-known passwords, even with digits or symbols added")
+	}
+
+	result.EntropyBits = passwordEntropy(password, lower, upper, digit, symbol, other)
+	switch bits := result.EntropyBits; {
+	case common || bits < 28:
+		result.Score = 0
+	case bits < minPasswordEntropy:
+		result.Score = 1
+	case bits < 60:
+		result.Score = 2
+	case bits < 80:
+		result.Score = 3
+	default:
+		result.Score = 4
+	}
+	if result.Valid && result.EntropyBits < minPasswordEntropy {
+		fail("Password is too easy to guess", "Avoid repeated characters and sequences such as aaa or 123")
+	}
+	result.Rating = passwordRatings[result.Score]
 	return result
 }
 
-func ValidateProduct(product Product) ValidationResult {
-	result := ValidationResult{Valid: true, Errors: []string{}}
+// isCommonPassword reports whether a password is a common one, ignoring
+// case and any digits and symbols appended to it.
+func isCommonPassword(password string) bool {
+	lowered := strings.ToLower(password)
+	if commonPasswords[lowered] {
+		return true
+	}
+	stem := strings.TrimRightFunc(lowered, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return stem != "" && commonPasswords[stem]
+}
 
-	// Name validation
-	if len(strings.TrimSpace(product.Name)) < 3 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Product name must be at least 3 characters")
+// passwordEntropy estimates a password's strength in bits: each character
+// adds log2 of the size of the character classes used, except that one
+// repeating or continuing a sequence from the previous character ("aa",
+// "ab", "21") adds a single bit.
+func passwordEntropy(password string, lower, upper, digit, symbol, other bool) float64 {
+	pool := 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.present {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
 	}
 
-	// Price validation
-	if product.Price <= 0 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Price must be greater than 0")
+	perChar := math.Log2(float64(pool))
+	bits := 0.0
+	var previous rune = -1
+	for _, r := range password {
+		if delta := r - previous; previous >= 0 && delta >= -1 && delta <= 1 {
+			bits++
+		} else {
+			bits += perChar
+		}
+		previous = r
 	}
+	return math.Round(bits*10) / 10
+}
 
-	if product.Price > 10000 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Price cannot exceed $10,000")
+// ShipTo is the country and region an order ships to: its shipping address,
+// else the user's address, else the user's country and region.
+func ShipTo(order Order, user User) (country, region string) {
+	switch {
+	case order.ShippingAddress != nil:
+		return order.ShippingAddress.Country, order.ShippingAddress.Region
+	case user.Address != nil:
+		return user.Address.Country, user.Address.Region
+	}
+	return user.Country, user.Region
+}
+
+func ValidateProduct(product Product) ValidationResult {
+	return ValidateProductContext(context.Background(), product)
+}
+
+// ValidateProductContext is ValidateProduct logging and tracing for the call in ctx.
+func ValidateProductContext(ctx context.Context, product Product) ValidationResult {
+	ctx, end := StartSpan(ctx, "ValidateProduct")
+	defer end()
+
+	result := newValidationResult()
+
+	// Name, price, category, rating and other field constraints from the
+	// ruleset
+	applyValidationRules(SchemaProduct, product, &result)
+
+	// Stock validation
+	if product.OnHand < 0 || product.Reserved < 0 || product.Reserved > product.OnHand {
+		result.AddError("on_hand", CodeOutOfRange, "Stock must not be negative and reserved units must not exceed units on hand")
 	}
 
-	// Category validation
-	validCategories := []string{"electronics", "clothing", "books", "home", "sports", "toys", "beauty"}
-	isValidCategory := false
-	for _, cat := range validCategories {
-		if strings.ToLower(product.Category) == cat {
-			isValidCategory = true
+	// Variant validation
+	validateVariants(product, &result)
+
+	// Image URLs, sizes and alt text
+	validateImages(product, &result)
+
+	if product.UnitCost < 0 {
+		result.AddError("unit_cost", CodeOutOfRange, "Unit cost must not be negative")
+	}
+
+	// Weight and size validation - sizes are all given or not at all
+	if product.WeightKg < 0 || product.WeightKg > MaxProductWeightKg {
+		result.AddError("weight_kg", CodeOutOfRange, fmt.Sprintf("Weight must be between 0 and %d kg", MaxProductWeightKg))
+	}
+	sides := []float64{product.LengthCm, product.WidthCm, product.HeightCm}
+	sideFields := []string{"length_cm", "width_cm", "height_cm"}
+	given := 0
+	for i, side := range sides {
+		if side < 0 || side > MaxProductSideCm {
+			result.AddError(sideFields[i], CodeOutOfRange, fmt.Sprintf("Dimensions must be between 0 and %d cm", MaxProductSideCm))
 			break
 		}
+		if side > 0 {
+			given++
+		}
 	}
-	if !isValidCategory {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Invalid category")
+	if given != 0 && given != len(sides) {
+		missing := ""
+		for i, side := range sides {
+			if side == 0 && missing == "" {
+				missing = sideFields[i]
+			}
+		}
+		result.AddError(missing, CodeRequired, "Length, width and height must be given together")
 	}
 
-	// Rating validation
-	if product.Rating < 0 || product.Rating > 5 {
-		result.Valid = false
-		result.Errors = append(result.Errors, "Rating must be between 0 and 5")
+	// Price tier validation - each tier needs a larger quantity and discount
+	// than the one before
+	previous := PriceTier{MinQuantity: 1}
+	for i, tier := range product.PriceTiers {
+		if tier.MinQuantity <= previous.MinQuantity || tier.DiscountPercent <= previous.DiscountPercent || tier.DiscountPercent >= 100 {
+			result.AddError(fmt.Sprintf("price_tiers[%d]", i), CodeInconsistent, "Price tiers must have increasing quantities above 1 and increasing discounts below 100%")
+			break
+		}
+		previous = tier
 	}
 
+	logValidation(ctx, SchemaProduct, result)
 	return result
 }
 
+// CalculateOrderTotal prices an order in its currency (see OrderCurrency),
+// rounding each amount to the currency's minor units. Discount and shipping
+// thresholds are applied to the amounts as they are, whatever the currency.
+//
+// Prices are net of tax. With IncludesTax set, as for shoppers in countries
+// that quote gross prices, Subtotal and Discount are shown tax-inclusive and
+// Tax is the tax the total contains, so Total = Subtotal - Discount +
+// Shipping. The total is the same in both modes.
 func CalculateOrderTotal(order *Order, user User) {
-	// Calculate subtotal
-	order.Subtotal = 0
+	CalculateOrderTotalContext(context.Background(), order, user)
+}
+
+// CalculateOrderTotalContext is CalculateOrderTotal logging and tracing for
+// the call in ctx.
+func CalculateOrderTotalContext(ctx context.Context, order *Order, user User) {
+	ctx, end := StartSpan(ctx, "CalculateOrderTotal")
+	defer end()
+
+	order.Currency = OrderCurrency(*order)
+	zero := Money{Currency: order.Currency}
+
+	// Price each line and calculate the subtotal; tax rates vary by
+	// jurisdiction, date and category. Tax is rounded half to even on each
+	// line.
+	order.Lines = []PriceBreakdown{}
+	date := orderPlacedDate(*order)
+	country, region := ShipTo(*order, user)
+	subtotal, grossSubtotal, taxBeforeDiscount := zero, zero, zero
+	tierSavings, grossTierSavings := zero, zero
 	for i, product := range order.Products {
-		if i < len(order.Quantities) {
-			order.Subtotal += product.Price * float64(order.Quantities[i])
+		if i >= len(order.Quantities) {
+			continue
 		}
-	}
-
-	// Apply premium discount
-	order.Discount = 0
-	if user.Premium {
-		if order.Subtotal > 100 {
-			order.Discount = order.Subtotal * 0.15 // 15% premium discount
-		} else if order.Subtotal > 50 {
-			order.Discount = order.Subtotal * 0.10 // 10% premium discount
+		quantity := order.Quantities[i]
+		unit := NewMoney(product.UnitPrice(quantity), order.Currency)
+		rate := TaxRateFor(country, region, product.Category, date)
+		net := unit.Times(quantity)
+		tax := net.MulRate(rate, RoundHalfEven)
+		subtotal = subtotal.Add(net)
+		grossSubtotal = grossSubtotal.Add(net).Add(tax)
+		taxBeforeDiscount = taxBeforeDiscount.Add(tax)
+		if list := NewMoney(product.Price, order.Currency); list.Minor > unit.Minor {
+			saved := list.Sub(unit).Times(quantity)
+			tierSavings = tierSavings.Add(saved)
+			grossTierSavings = grossTierSavings.Add(saved).Add(saved.MulRate(rate, RoundHalfEven))
 		}
+		order.Lines = append(order.Lines, PriceBreakdown{
+			ProductID: product.ID,
+			Quantity:  quantity,
+			TaxRate:   rate,
+			UnitNet:   unit.Float64(),
+			UnitGross: unit.Add(unit.MulRate(rate, RoundHalfEven)).Float64(),
+			Net:       net.Float64(),
+			Tax:       tax.Float64(),
+			Gross:     net.Add(tax).Float64(),
+		})
 	}
 
-	// Calculate tax (varies by country)
-	taxRate := GetTaxRate(user.Country)
-	order.Tax = (order.Subtotal - order.Discount) * taxRate
+	// Apply the premium discount, coupon and loyalty points the stacking
+	// rules allow
+	applied := stackDiscounts(subtotal, orderDiscounts(*order, user, subtotal, date))
+	discount := Money{Minor: discountSum(applied), Currency: order.Currency}
 
-	// Calculate shipping
-	order.Shipping = CalculateShipping(order.Subtotal, user.Country, user.Premium)
+	// The discount is shared across lines in proportion to price, so it
+	// reduces each line's tax by the same fraction
+	tax := zero
+	if subtotal.Minor > 0 {
+		tax = taxBeforeDiscount.MulDiv(subtotal.Sub(discount).Minor, subtotal.Minor, RoundHalfEven)
+	}
 
-	// Calculate total
-	order.Total = order.Subtotal - order.Discount + order.Tax + order.Shipping
-}
+	// Calculate shipping: the sum of the shipments of a split order, else
+	// the chosen option, standard shipping for the order's weight or the
+	// flat rate
+	var shipping float64
+	if len(order.Shipments) > 0 {
+		shipping, order.EstimatedDelivery = priceShipments(order, user, date, subtotal.Float64())
+	} else {
+		shipping, order.EstimatedDelivery = shippingCharge(*order, user, date)
+	}
+	shippingCharged := NewMoney(shipping, order.Currency)
+
+	// Promotions running on the order date look at the discounted subtotal
+	order.Promotions = applyPromotions(date, subtotal.Sub(discount), shippingCharged)
+	if FreeShipping(order.Promotions) {
+		shippingCharged = zero
+		for i := range order.Shipments {
+			order.Shipments[i].Shipping = 0
+		}
+	}
 
-func GetTaxRate(country string) float64 {
-	taxRates := map[string]float64{
-		"US": 0.08, // 8%
-		"CA": 0.13, // 13% GST+PST
-		"UK": 0.20, // 20% VAT
-		"DE": 0.19, // 19% VAT
-		"FR": 0.20, // 20% VAT
-		"JP": 0.10, // 10% consumption tax
-		"AU": 0.10, // 10% GST
-		"IN": 0.18, // 18% GST
-		"BR": 0.17, // 17% ICMS
-		"MX": 0.16, // 16% IVA
+	// The total is exactly the sum of its parts
+	total := subtotal.Sub(discount).Add(tax).Add(shippingCharged)
+	order.Subtotal = subtotal.Float64()
+	order.Discount = discount.Float64()
+	order.Tax = tax.Float64()
+	order.Shipping = shippingCharged.Float64()
+	order.Total = total.Float64()
+
+	// Show gross amounts, deriving the discount so the total is unchanged
+	grossDiscount := discount
+	if order.IncludesTax {
+		grossDiscount = grossSubtotal.Add(shippingCharged).Sub(total)
+		order.Subtotal = grossSubtotal.Float64()
+		order.Discount = grossDiscount.Float64()
+		tierSavings = grossTierSavings
+	}
+	order.Discounts = discountLines(applied, discount, grossDiscount)
+	if tierSavings.Minor > 0 {
+		order.Discounts = append(order.Discounts, DiscountLine{
+			Source:      DiscountTier,
+			Description: "Quantity breaks",
+			Stacking:    StackAdditive,
+			Amount:      tierSavings.Float64(),
+			InPrice:     true,
+		})
 	}
 
-	if rate, exists := taxRates[country]; exists {
-		return rate
+	// A gift card pays what it can of the taxed total
+	paid := zero
+	if card := order.GiftCard; card != nil && CheckGiftCard(*card, order.Currency, date) == nil {
+		paid = NewMoney(card.Balance, order.Currency).Min(total)
 	}
-	return 0.08 // Default 8%
+	order.GiftCardAmount = paid.Float64()
+	order.AmountDue = total.Sub(paid).Float64()
+	slog.DebugContext(ctx, "order priced", "lines", len(order.Lines), "total", order.Total, "currency", order.Currency, "discounts", len(order.Discounts))
 }
 
+// CalculateShipping is the flat shipping rate of an order that hasn't chosen
+// a shipping option.
 func CalculateShipping(subtotal float64, country string, isPremium bool) float64 {
 	if isPremium && subtotal > 75 {
 		return 0 // Free shipping for premium users over $75
 	}
 
-	// Base shipping rates by country
-	shippingRates := map[string]float64{
-		"US": 8.99,
-		"CA": 12.99,
-		"UK": 15.99,
-		"DE": 14.99,
-		"FR": 14.99,
-		"JP": 18.99,
-		"AU": 19.99,
-		"IN": 9.99,
-		"BR": 16.99,
-		"MX": 13.99,
-	}
-
-	baseRate := 12.99 // Default
-	if rate, exists := shippingRates[country]; exists {
-		baseRate = rate
-	}
+	baseRate := zoneRate(country)
 
 	// Free shipping threshold
 	if subtotal > 100 {
@@ -212,7 +746,7 @@ func CalculateShipping(subtotal float64, country string, isPremium bool) float64
 
 	// Express shipping for orders over $50
 	if subtotal > 50 {
-		return baseRate * 1.5
+		return NewMoney(baseRate, DefaultCurrency).MulRate(1.5, RoundHalfUp).Float64()
 	}
 
 	return baseRate
@@ -220,81 +754,179 @@ func CalculateShipping(subtotal float64, country string, isPremium bool) float64
 
 // Advanced business logic - recommendation algorithm
 func RecommendProducts(user User, allProducts []Product, currentOrder Order) []Product {
+	return RecommendWithWishlist(user, allProducts, currentOrder, Wishlist{})
+}
+
+// RecommendProductsContext is RecommendProducts stopping with ctx's error
+// once ctx is done.
+func RecommendProductsContext(ctx context.Context, user User, allProducts []Product, currentOrder Order) ([]Product, error) {
+	return RecommendWithWishlistContext(ctx, user, allProducts, currentOrder, Wishlist{})
+}
+
+// RecommendWithWishlist is RecommendProducts that also favours products in
+// the categories of the user's wishlist.
+func RecommendWithWishlist(user User, allProducts []Product, currentOrder Order, wishlist Wishlist) []Product {
+	recommendations, _ := RecommendWithWishlistContext(context.Background(), user, allProducts, currentOrder, wishlist)
+	return recommendations
+}
+
+// RecommendWithWishlistContext is RecommendWithWishlist stopping with ctx's
+// error once ctx is done.
+func RecommendWithWishlistContext(ctx context.Context, user User, allProducts []Product, currentOrder Order, wishlist Wishlist) ([]Product, error) {
+	explained, err := ExplainRecommendationsContext(ctx, user, allProducts, currentOrder, wishlist, DefaultRecommendOptions())
+	if err != nil {
+		return nil, err
+	}
 	recommendations := []Product{}
+	for _, recommendation := range explained {
+		recommendations = append(recommendations, recommendation.Product)
+	}
+	return recommendations, nil
+}
+
+// Recommendation is a recommended product with its score and the
+// components the score is made of, for showing why it was suggested.
+type Recommendation struct {
+	Product
+	Score   float64          `json:"score"`
+	Reasons []ScoreComponent `json:"reasons"`
+}
+
+// ScoreComponent is one reason a product was recommended and the score it
+// added.
+type ScoreComponent struct {
+	Reason string  `json:"reason"`
+	Score  float64 `json:"score"`
+}
+
+// ExplainRecommendations is RecommendWithWishlist keeping each
+// recommendation's score components, scored with the options' weights and
+// diversified as they say. Ties go to the product listed first.
+func ExplainRecommendations(user User, allProducts []Product, currentOrder Order, wishlist Wishlist, options RecommendOptions) []Recommendation {
+	recommendations, _ := ExplainRecommendationsContext(context.Background(), user, allProducts, currentOrder, wishlist, options)
+	return recommendations
+}
+
+// ExplainRecommendationsContext is ExplainRecommendations stopping with
+// ctx's error once ctx is done.
+func ExplainRecommendationsContext(ctx context.Context, user User, allProducts []Product, currentOrder Order, wishlist Wishlist, options RecommendOptions) ([]Recommendation, error) {
+	ctx, end := StartSpan(ctx, "ExplainRecommendations")
+	defer end()
+
+	weights := options.Weights
+	prefs := UserPreferences(user)
 	userCategory := inferUserPreference(user, currentOrder)
+	wishlisted := wishlistCategories(wishlist, allProducts)
+	// Cold start: with an empty cart, go by what similar users bought
+	cohort, coldStart := CohortStats{}, false
+	if len(currentOrder.Products) == 0 {
+		cohort, coldStart = userCohort(user)
+	}
 
 	// Score-based recommendation
-	productScores := make(map[int]float64)
+	var scored []Recommendation
 
-	for _, product := range allProducts {
-		if !product.InStock {
+	for i, product := range allProducts {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if !product.InStock() || prefs.excludes(product.Category) {
 			continue
 		}
 
-		score := 0.0
+		recommendation := Recommendation{Product: product, Reasons: []ScoreComponent{}}
+		add := func(reason string, score float64) {
+			if score > 0 {
+				recommendation.Score += score
+				recommendation.Reasons = append(recommendation.Reasons, ScoreComponent{Reason: reason, Score: math.Round(score*100) / 100})
+			}
+		}
 
 		// Category preference
 		if strings.ToLower(product.Category) == userCategory {
-			score += 3.0
+			switch {
+			case len(currentOrder.Products) > 0:
+				add("same category as cart", weights.Category)
+			case coldStart:
+				add("popular category with customers like you", weights.Category)
+			default:
+				add("popular category for your age", weights.Category)
+			}
 		}
 
+		// Best sellers of the user's cohort
+		if coldStart {
+			add("popular with customers like you", weights.Cohort*cohort.productShare(product.ID))
+		}
+
+		// Favourite categories the user picked
+		if prefs.favors(product.Category) {
+			add("one of your favorite categories", weights.Favorite)
+		}
+
+		// Wishlist categories
+		if wishlisted[strings.ToLower(product.Category)] {
+			add("same category as your wishlist", weights.Wishlist)
+		}
+
+		// Bought together with the order's products, once a similarity
+		// matrix is built
+		add("often bought with items in cart", weights.Collaborative*collaborativeScore(product.ID, currentOrder))
+
+		// Selling fast lately, once a trending list is installed
+		add("trending now", weights.Trending*trendingShare(product.ID))
+
 		// Price preference based on user's current order
 		avgOrderPrice := getAverageProductPrice(currentOrder)
 		priceDiff := abs(product.Price - avgOrderPrice)
 		if priceDiff < avgOrderPrice*0.3 { // Within 30% of average
-			score += 2.0
+			add("matches your price range", weights.PriceRange)
+		}
+
+		// Price sensitivity the user stated
+		switch {
+		case prefs.PriceSensitivity == PriceSensitivityHigh && product.Price < avgOrderPrice*0.8:
+			add("fits your budget", weights.PriceSensitivity)
+		case prefs.PriceSensitivity == PriceSensitivityLow && product.Price > avgOrderPrice*1.2:
+			add("a step up in quality", weights.PriceSensitivity)
 		}
 
 		// Rating boost
-		score += product.Rating * 0.5
+		add(fmt.Sprintf("rated %.1f", product.Rating), product.Rating*weights.Rating)
 
 		// Premium user gets higher-end recommendations
 		if user.Premium && product.Price > avgOrderPrice*1.2 {
-			score += 1.0
+			add("popular with premium users", weights.Premium)
 		}
 
 		// Age-based preferences
 		if user.Age < 25 && (product.Category == "electronics" || product.Category == "toys") {
-			score += 1.0
+			add("popular with under-25s", weights.AgeGroup)
 		} else if user.Age > 40 && (product.Category == "home" || product.Category == "books") {
-			score += 1.0
+			add("popular with over-40s", weights.AgeGroup)
 		}
 
-		productScores[product.ID] = score
+		scored = append(scored, recommendation)
 	}
 
-	// Sort by score and return top 5
-	for len(recommendations) < 5 {
-		bestID := -1
-		bestScore := -1.0
-
-		for id, score := range productScores {
-			if score > bestScore {
-				bestScore = score
-				bestID = id
-			}
-		}
-
-		if bestID == -1 {
-			break
-		}
-
-		// Find and add the product
-		for _, product := range allProducts {
-			if product.ID == bestID {
-				recommendations = append(recommendations, product)
-				break
-			}
-		}
-
-		delete(productScores, bestID)
+	// Sort by score and return the top 5, diversified
+	slices.SortStableFunc(scored, func(a, b Recommendation) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	for i := range scored {
+		scored[i].Score = math.Round(scored[i].Score*100) / 100
 	}
-
-	return recommendations
+	return diversify(scored, options.Diversity, 5), nil
 }
 
 func inferUserPreference(user User, order Order) string {
 	if len(order.Products) == 0 {
+		// The top category of similar users, once cohort preferences are
+		// built
+		if cohort, ok := userCohort(user); ok && cohort.topCategory() != "" {
+			return cohort.topCategory()
+		}
+
 		// Default preferences by age
 		if user.Age < 25 {
 			return "electronics"
@@ -353,111 +985,195 @@ func abs(x float64) float64 {
 	return x
 }
 
-// Data processing and analytics - same algorithms on server and client
-func AnalyzeUserBehavior(users []User, orders []Order) UserAnalytics {
-	analytics := UserAnalytics{}
+// Data processing and analytics - same algorithms on server and client.
+// See AnalyticsAccumulator for the metrics and AnalyticsFilter for what
+// filter selects.
+func AnalyzeUserBehavior(users []User, orders []Order, filter AnalyticsFilter) UserAnalytics {
+	analytics, _ := AnalyzeUserBehaviorContext(context.Background(), users, orders, filter)
+	return analytics
+}
 
-	if len(users) == 0 {
-		return analytics
+// AnalyzeUserBehaviorContext is AnalyzeUserBehavior stopping with ctx's
+// error once ctx is done.
+func AnalyzeUserBehaviorContext(ctx context.Context, users []User, orders []Order, filter AnalyticsFilter) (UserAnalytics, error) {
+	ctx, end := StartSpan(ctx, "AnalyzeUserBehavior")
+	defer end()
+
+	users, orders = filter.Apply(users, orders)
+	acc := NewAnalyticsAccumulator()
+	acc.TopCountries = filter.TopCountries
+	for i, user := range users {
+		if err := checkCanceled(ctx, i); err != nil {
+			return UserAnalytics{}, err
+		}
+		acc.AddUser(user)
 	}
-
-	// Calculate demographics
-	ageSum := 0
-	countryCount := make(map[string]int)
-	premiumCount := 0
-
-	for _, user := range users {
-		ageSum += user.Age
-		countryCount[user.Country]++
-		if user.Premium {
-			premiumCount++
+	for i, order := range orders {
+		if err := checkCanceled(ctx, i); err != nil {
+			return UserAnalytics{}, err
 		}
+		acc.AddOrder(order)
 	}
-
-	if len(users) > 0 {
-		analytics.AverageAge = float64(ageSum) / float64(len(users))
-		analytics.PremiumPercentage = (float64(premiumCount) / float64(len(users))) * 100
+	if err := ctx.Err(); err != nil {
+		return UserAnalytics{}, err
 	}
-	analytics.TopCountries = getTopCountries(countryCount, 3)
+	return acc.Snapshot(), nil
+}
 
-	// Analyze orders
-	if len(orders) > 0 {
-		totalRevenue := 0.0
-		totalOrders := len(orders)
+type UserAnalytics struct {
+	AverageAge        float64      `json:"average_age"`
+	PremiumPercentage float64      `json:"premium_percentage"`
+	TopCountries      []TopCountry `json:"top_countries"`
+	TotalRevenue      float64      `json:"total_revenue"`
+	// CountryRevenue and RegionRevenue break revenue down by where orders
+	// ship, from RevenueByGeography
+	CountryRevenue []GeoRevenue `json:"country_revenue"`
+	RegionRevenue  []GeoRevenue `json:"region_revenue"`
+	// OrderValues is the spread of order totals from DescribeOrderValues
+	OrderValues OrderValueStats `json:"order_values"`
+	// AgeHistogram and OrderValueHistogram are the users' ages in AgeBuckets
+	// and the order totals in OrderValueBuckets, from BuildHistogram
+	AgeHistogram        []HistogramBucket `json:"age_histogram"`
+	OrderValueHistogram []HistogramBucket `json:"order_value_histogram"`
+	// Baskets is the items and category mix of the orders, from
+	// AnalyzeBaskets
+	Baskets BasketStats `json:"baskets"`
+	// AverageProfileCompleteness is the users' mean ProfileCompleteness
+	// percentage; IncompleteProfiles counts those with gaps
+	AverageProfileCompleteness float64 `json:"average_profile_completeness"`
+	IncompleteProfiles         int     `json:"incomplete_profiles"`
+	// AverageChurnProbability is the customers' mean ScoreChurnRisk
+	// probability; HighChurnRisk counts those at high risk
+	AverageChurnProbability float64 `json:"average_churn_probability"`
+	HighChurnRisk           int     `json:"high_churn_risk"`
+	// TotalCLV and AverageCLV are the users' CalculateCLV values over
+	// DefaultCLVHorizonMonths, in DefaultCurrency
+	TotalCLV   float64 `json:"total_clv"`
+	AverageCLV float64 `json:"average_clv"`
+	// Anomalies are the orders DetectOrderAnomalies flags
+	Anomalies []OrderAnomaly `json:"anomalies"`
+}
 
-		for _, order := range orders {
-			totalRevenue += order.Total
-		}
+// Model errors. The JSON helpers and CheckOrderLines return them, wrapped,
+// so bridges and handlers can tell failures apart with errors.Is; ErrorCode
+// names them for results.
+var (
+	ErrInvalidJSON      = errors.New("invalid JSON")
+	ErrEmptyOrder       = errors.New("order must contain at least one product")
+	ErrQuantityMismatch = errors.New("product and quantity arrays must be the same length")
+)
 
-		analytics.TotalRevenue = totalRevenue
-		analytics.AverageOrderValue = totalRevenue / float64(totalOrders)
+// ErrorCode is the machine-readable code of a model error: "invalid_json",
+// "empty_order", "quantity_mismatch", "version_conflict" or
+// "version_required", and "" for any other error.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidJSON):
+		return "invalid_json"
+	case errors.Is(err, ErrEmptyOrder):
+		return "empty_order"
+	case errors.Is(err, ErrQuantityMismatch):
+		return "quantity_mismatch"
+	case errors.Is(err, ErrVersionConflict):
+		return "version_conflict"
+	case errors.Is(err, ErrVersionRequired):
+		return "version_required"
 	}
-
-	return analytics
+	return ""
 }
 
-type UserAnalytics struct {
-	AverageAge        float64  `json:"average_age"`
-	PremiumPercentage float64  `json:"premium_percentage"`
-	TopCountries      []string `json:"top_countries"`
-	TotalRevenue      float64  `json:"total_revenue"`
-	AverageOrderValue float64  `json:"average_order_value"`
+// CheckOrderLines reports whether an order has products with one quantity
+// each, returning ErrEmptyOrder or ErrQuantityMismatch when it doesn't.
+func CheckOrderLines(order Order) error {
+	if len(order.Products) == 0 {
+		return ErrEmptyOrder
+	}
+	if len(order.Products) != len(order.Quantities) {
+		return fmt.Errorf("%w: %d products, %d quantities", ErrQuantityMismatch, len(order.Products), len(order.Quantities))
+	}
+	return nil
 }
 
-func getTopCountries(countryCount map[string]int, limit int) []string {
-	type countryPair struct {
-		country string
-		count   int
-	}
+// jsonDecodeError is a payload a JSON helper couldn't decode. It reads as
+// the decoder's message, which bridges prefix with the model, and matches
+// ErrInvalidJSON as well as the decoder's error.
+type jsonDecodeError struct{ err error }
 
-	pairs := []countryPair{}
-	for country, count := range countryCount {
-		pairs = append(pairs, countryPair{country, count})
-	}
+func (e jsonDecodeError) Error() string   { return e.err.Error() }
+func (e jsonDecodeError) Unwrap() []error { return []error{ErrInvalidJSON, e.err} }
 
-	// Simple bubble sort
-	for i := 0; i < len(pairs); i++ {
-		for j := i + 1; j < len(pairs); j++ {
-			if pairs[j].count > pairs[i].count {
-				pairs[i], pairs[j] = pairs[j], pairs[i]
-			}
-		}
+// decodeModelJSON decodes a model's JSON into v, upgrading payloads written
+// by older models.
+func decodeModelJSON(model, jsonStr string, v interface{}) error {
+	data, err := MigrateJSON(model, []byte(jsonStr))
+	if err == nil {
+		err = json.Unmarshal(data, v)
 	}
-
-	result := []string{}
-	for i := 0; i < limit && i < len(pairs); i++ {
-		result = append(result, pairs[i].country)
+	if err != nil {
+		return jsonDecodeError{err}
 	}
-
-	return result
+	return nil
 }
 
 // JSON serialization helpers - identical on both sides
-func UserToJSON(user User) string {
-	data, _ := json.Marshal(user)
-	return string(data)
+func UserToJSON(user User) (string, error) {
+	user.SchemaVersion = CurrentSchemaVersion
+	data, err := json.Marshal(user)
+	if err != nil {
+		return "", fmt.Errorf("encoding user: %w", err)
+	}
+	return string(data), nil
 }
 
+// UserFromJSON decodes a user, upgrading payloads written by older models.
+// Payloads that can't be decoded fail with ErrInvalidJSON.
 func UserFromJSON(jsonStr string) (User, error) {
 	var user User
-	err := json.Unmarshal([]byte(jsonStr), &user)
+	err := decodeModelJSON(SchemaUser, jsonStr, &user)
 	return user, err
 }
 
-func OrderToJSON(order Order) string {
-	data, _ := json.Marshal(order)
-	return string(data)
+func OrderToJSON(order Order) (string, error) {
+	order.SchemaVersion = CurrentSchemaVersion
+	data, err := json.Marshal(order)
+	if err != nil {
+		return "", fmt.Errorf("encoding order: %w", err)
+	}
+	return string(data), nil
 }
 
+// OrderFromJSON decodes an order, upgrading payloads written by older
+// models. Payloads that can't be decoded fail with ErrInvalidJSON.
 func OrderFromJSON(jsonStr string) (Order, error) {
 	var order Order
-	err := json.Unmarshal([]byte(jsonStr), &order)
+	err := decodeModelJSON(SchemaOrder, jsonStr, &order)
 	return order, err
 }
 
+// ProductFromJSON decodes a product, upgrading payloads written by older
+// models. Payloads that can't be decoded fail with ErrInvalidJSON.
+func ProductFromJSON(jsonStr string) (Product, error) {
+	var product Product
+	err := decodeModelJSON(SchemaProduct, jsonStr, &product)
+	return product, err
+}
+
+func ProductToJSON(product Product) (string, error) {
+	product.SchemaVersion = CurrentSchemaVersion
+	data, err := json.Marshal(product)
+	if err != nil {
+		return "", fmt.Errorf("encoding product: %w", err)
+	}
+	return string(data), nil
+}
+
 // Utility functions
-func FormatCurrency(amount float64) string {
-	return fmt.Sprintf("$%.2f", amount)
+// FormatCurrency writes an amount with the symbol and minor units of a
+// currency and no digit grouping, as in exports and logs; an empty or
+// unknown code formats as USD. FormatMoney writes amounts for people to
+// read.
+func FormatCurrency(amount float64, code string) string {
+	return formatMoney(amount, currencyFor(code), plainLocale)
 }
 
 func GetCurrentTimestamp() string {
@@ -2,7 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -43,7 +47,7 @@ var testProducts = []Product{
 		Name:        "Wireless Headphones",
 		Price:       99.99,
 		Category:    "electronics",
-		InStock:     true,
+		OnHand:      10,
 		Rating:      4.5,
 		Description: "High-quality wireless headphones",
 	},
@@ -52,7 +56,7 @@ var testProducts = []Product{
 		Name:        "A",       // Too short name
 		Price:       -10.99,    // Invalid price
 		Category:    "invalid", // Invalid category
-		InStock:     true,
+		OnHand:      10,
 		Rating:      6.0, // Invalid rating
 		Description: "Invalid product for testing",
 	},
@@ -61,7 +65,7 @@ var testProducts = []Product{
 		Name:        "Programming Book",
 		Price:       49.99,
 		Category:    "books",
-		InStock:     true,
+		OnHand:      10,
 		Rating:      4.8,
 		Description: "Learn advanced programming techniques",
 	},
@@ -207,6 +211,57 @@ func TestValidateProduct(t *testing.T) {
 	}
 }
 
+// TestValidationFieldErrors tests that errors name the field and code they
+// concern, and that Errors still lists the error messages
+func TestValidationFieldErrors(t *testing.T) {
+	user := User{
+		Email:   "jane@gmial.com",
+		Name:    "J",
+		Age:     30,
+		Country: "US",
+		Address: &Address{Street: "1 Main St", City: "Springfield", Country: "US", PostalCode: "123"},
+	}
+	result := ValidateUser(user)
+
+	want := []FieldError{
+		{Field: "name", Code: CodeTooShort, Message: "Name must be at least 2 characters", Severity: SeverityError},
+		{Field: "address.region", Code: CodeRequired, Message: "Address region is required in US", Severity: SeverityError},
+		{Field: "address.postal_code", Code: CodeInvalidFormat, Severity: SeverityError},
+	}
+	var errs []FieldError
+	for _, fieldErr := range result.FieldErrors {
+		if fieldErr.Severity == SeverityError {
+			errs = append(errs, fieldErr)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("FieldErrors = %+v, want %d errors", result.FieldErrors, len(want))
+	}
+	for i, fieldErr := range errs {
+		if fieldErr.Field != want[i].Field || fieldErr.Code != want[i].Code || (want[i].Message != "" && fieldErr.Message != want[i].Message) {
+			t.Errorf("FieldErrors[%d] = %+v, want %+v", i, fieldErr, want[i])
+		}
+		if result.Errors[i] != fieldErr.Message {
+			t.Errorf("Errors[%d] = %q, want %q", i, result.Errors[i], fieldErr.Message)
+		}
+	}
+	if len(result.Warnings) != 1 || result.FieldErrors[0].Severity != SeverityWarning || result.FieldErrors[0].Field != "email" {
+		t.Errorf("Expected the email typo as a warning field error, got %+v", result.FieldErrors)
+	}
+
+	product := Product{Name: "Mug", Price: 10, Category: "home", OnHand: 2, Variants: []ProductVariant{
+		{SKU: "MUG-RED", Color: "red", OnHand: 1},
+		{SKU: "MUG-RED", Color: "blue", OnHand: 1},
+	}}
+	fields := map[string]string{}
+	for _, fieldErr := range ValidateProduct(product).FieldErrors {
+		fields[fieldErr.Field] = fieldErr.Code
+	}
+	if fields["variants[1].sku"] != CodeDuplicate {
+		t.Errorf("Expected variants[1].sku to be a duplicate, got %v", fields)
+	}
+}
+
 // TestCalculateOrderTotal tests the order calculation logic
 func TestCalculateOrderTotal(t *testing.T) {
 	tests := []struct {
@@ -257,6 +312,38 @@ func TestCalculateOrderTotal(t *testing.T) {
 	}
 }
 
+// TestPriceTiers tests quantity-break pricing
+func TestPriceTiers(t *testing.T) {
+	mug := Product{Name: "Coffee Mug", Price: 20, Category: "home", Rating: 4,
+		PriceTiers: []PriceTier{{MinQuantity: 10, DiscountPercent: 5}, {MinQuantity: 50, DiscountPercent: 12}}}
+
+	for quantity, want := range map[int]float64{1: 20, 9: 20, 10: 19, 49: 19, 50: 17.6, 99: 17.6} {
+		if got := mug.UnitPrice(quantity); math.Abs(got-want) > 1e-9 {
+			t.Errorf("UnitPrice(%d) = %v, want %v", quantity, got, want)
+		}
+	}
+
+	order := Order{Products: []Product{mug}, Quantities: []int{10}}
+	CalculateOrderTotal(&order, User{Country: "US"})
+	if order.Subtotal != 190 {
+		t.Errorf("Expected the tier price in the subtotal, got %v", order.Subtotal)
+	}
+
+	if !ValidateProduct(mug).Valid {
+		t.Errorf("Expected increasing tiers to be valid, got %v", ValidateProduct(mug).Errors)
+	}
+	for _, tiers := range [][]PriceTier{
+		{{MinQuantity: 1, DiscountPercent: 5}},
+		{{MinQuantity: 10, DiscountPercent: 10}, {MinQuantity: 20, DiscountPercent: 5}},
+		{{MinQuantity: 10, DiscountPercent: 100}},
+	} {
+		mug.PriceTiers = tiers
+		if ValidateProduct(mug).Valid {
+			t.Errorf("Expected tiers %+v to be rejected", tiers)
+		}
+	}
+}
+
 // TestGetTaxRate tests tax rate calculation
 func TestGetTaxRate(t *testing.T) {
 	tests := []struct {
@@ -325,7 +412,7 @@ func TestRecommendProducts(t *testing.T) {
 
 	// Should not include out-of-stock items
 	for _, rec := range recommendations {
-		if !rec.InStock {
+		if !rec.InStock() {
 			t.Errorf("RecommendProducts() included out-of-stock item: %s", rec.Name)
 		}
 	}
@@ -343,6 +430,60 @@ func TestRecommendProducts(t *testing.T) {
 	}
 }
 
+// TestExplainRecommendations tests that the reasons add up to the score
+func TestExplainRecommendations(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+
+	user := User{Age: 30, Country: "US", Premium: true}
+	catalog := []Product{
+		{ID: 1, Name: "Headphones", Price: 100, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 2, Name: "Speaker", Price: 90, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 3, Name: "Camera", Price: 400, Category: "electronics", OnHand: 5, Rating: 4},
+		{ID: 4, Name: "Novel", Price: 20, Category: "books", OnHand: 5, Rating: 4},
+	}
+	order := Order{Products: []Product{catalog[0]}, Quantities: []int{1}}
+
+	got := ExplainRecommendations(user, catalog, order, Wishlist{}, RecommendOptions{Weights: DefaultRecommendationWeights})
+	if len(got) != 4 || got[0].ID != 1 || got[1].ID != 2 || got[2].ID != 3 {
+		t.Fatalf("Expected the cart's category first, ties in catalog order, got %+v", got)
+	}
+	reasons := func(recommendation Recommendation) []string {
+		var list []string
+		for _, component := range recommendation.Reasons {
+			list = append(list, component.Reason)
+		}
+		return list
+	}
+	if want := []string{"same category as cart", "matches your price range", "rated 4.0"}; !slices.Equal(reasons(got[1]), want) {
+		t.Errorf("Expected the speaker's reasons %v, got %v", want, reasons(got[1]))
+	}
+	if !slices.Contains(reasons(got[2]), "popular with premium users") {
+		t.Errorf("Expected the camera to be a premium pick, got %v", reasons(got[2]))
+	}
+	for _, recommendation := range got {
+		sum := 0.0
+		for _, component := range recommendation.Reasons {
+			sum += component.Score
+		}
+		if math.Abs(sum-recommendation.Score) > 0.01 {
+			t.Errorf("%s: reasons add up to %v, score is %v", recommendation.Name, sum, recommendation.Score)
+		}
+	}
+
+	products := RecommendProducts(user, catalog, order)
+	for i, recommendation := range ExplainRecommendations(user, catalog, order, Wishlist{}, DefaultRecommendOptions()) {
+		if products[i].ID != recommendation.ID {
+			t.Errorf("Expected RecommendProducts to rank alike, got %v", products)
+			break
+		}
+	}
+}
+
 // TestAnalyzeUserBehavior tests user analytics
 func TestAnalyzeUserBehavior(t *testing.T) {
 	users := testUsers[:2] // Two users: one premium, one not
@@ -359,7 +500,7 @@ func TestAnalyzeUserBehavior(t *testing.T) {
 		},
 	}
 
-	analytics := AnalyzeUserBehavior(users, orders)
+	analytics := AnalyzeUserBehavior(users, orders, AnalyticsFilter{})
 
 	// Check average age calculation
 	expectedAge := (28.0 + 12.0) / 2.0 // 20.0
@@ -380,8 +521,11 @@ func TestAnalyzeUserBehavior(t *testing.T) {
 	}
 
 	expectedAvgOrder := expectedRevenue / 2.0 // 106.475
-	if !floatEqual(analytics.AverageOrderValue, expectedAvgOrder, 0.01) {
-		t.Errorf("AnalyzeUserBehavior() average order = %v, want %v", analytics.AverageOrderValue, expectedAvgOrder)
+	if !floatEqual(analytics.OrderValues.Mean, expectedAvgOrder, 0.01) {
+		t.Errorf("AnalyzeUserBehavior() average order = %v, want %v", analytics.OrderValues.Mean, expectedAvgOrder)
+	}
+	if analytics.OrderValues.Min != 62.98 || analytics.OrderValues.Max != 149.97 || analytics.OrderValues.Count != 2 {
+		t.Errorf("AnalyzeUserBehavior() order values = %+v", analytics.OrderValues)
 	}
 }
 
@@ -389,10 +533,13 @@ func TestAnalyzeUserBehavior(t *testing.T) {
 func TestJSONSerialization(t *testing.T) {
 	// Test User JSON serialization
 	user := testUsers[0]
-	jsonStr := UserToJSON(user)
+	jsonStr, err := UserToJSON(user)
+	if err != nil {
+		t.Fatalf("UserToJSON() failed: %v", err)
+	}
 
 	var parsedUser User
-	err := json.Unmarshal([]byte(jsonStr), &parsedUser)
+	err = json.Unmarshal([]byte(jsonStr), &parsedUser)
 	if err != nil {
 		t.Errorf("UserToJSON() produced invalid JSON: %v", err)
 	}
@@ -410,6 +557,45 @@ func TestJSONSerialization(t *testing.T) {
 	if parsedUser2.Email != user.Email {
 		t.Errorf("UserFromJSON() failed: got %+v, want %+v", parsedUser2, user)
 	}
+
+	// Undecodable payloads fail with ErrInvalidJSON, keeping the decoder's message
+	for _, payload := range []string{`{"email": `, `{"id": "one"}`, `[]`} {
+		_, err := UserFromJSON(payload)
+		if !errors.Is(err, ErrInvalidJSON) || ErrorCode(err) != "invalid_json" {
+			t.Errorf("UserFromJSON(%s) error = %v, want ErrInvalidJSON", payload, err)
+		} else if strings.Contains(err.Error(), ErrInvalidJSON.Error()) {
+			t.Errorf("UserFromJSON(%s) error = %q, want the decoder's message", payload, err)
+		}
+	}
+	if _, err := OrderFromJSON(`{"products": 1}`); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("OrderFromJSON() error = %v, want ErrInvalidJSON", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if _, err := ProductFromJSON(`{"price": "free"}`); !errors.As(err, &typeErr) {
+		t.Errorf("ProductFromJSON() error = %v, want the decoder's type error", err)
+	}
+}
+
+func TestCheckOrderLines(t *testing.T) {
+	product := Product{ID: 1, Name: "Lamp", Price: 40}
+	tests := []struct {
+		order Order
+		want  error
+		code  string
+	}{
+		{Order{Products: []Product{product}, Quantities: []int{2}}, nil, ""},
+		{Order{}, ErrEmptyOrder, "empty_order"},
+		{Order{Products: []Product{product, product}, Quantities: []int{1}}, ErrQuantityMismatch, "quantity_mismatch"},
+	}
+	for _, tt := range tests {
+		err := CheckOrderLines(tt.order)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("CheckOrderLines(%d products, %d quantities) = %v, want %v", len(tt.order.Products), len(tt.order.Quantities), err, tt.want)
+		}
+		if code := ErrorCode(err); code != tt.code {
+			t.Errorf("ErrorCode(%v) = %q, want %q", err, code, tt.code)
+		}
+	}
 }
 
 // TestUtilityFunctions tests helper functions
@@ -426,7 +612,7 @@ func TestUtilityFunctions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		got := FormatCurrency(tt.amount)
+		got := FormatCurrency(tt.amount, "")
 		if got != tt.want {
 			t.Errorf("FormatCurrency(%v) = %v, want %v", tt.amount, got, tt.want)
 		}
@@ -447,6 +633,7 @@ func TestUtilityFunctions(t *testing.T) {
 // Benchmark tests for performance validation
 func BenchmarkValidateUser(b *testing.B) {
 	user := testUsers[0]
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -456,6 +643,7 @@ func BenchmarkValidateUser(b *testing.B) {
 
 func BenchmarkValidateProduct(b *testing.B) {
 	product := testProducts[0]
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -463,6 +651,44 @@ func BenchmarkValidateProduct(b *testing.B) {
 	}
 }
 
+// invalidBenchmarkUser and invalidBenchmarkProduct fail most of their
+// checks, for the cost of building errors
+var (
+	invalidBenchmarkUser    = User{Name: "J", Email: "nope", Age: 300, Country: "XX", Phone: "12", Address: &Address{Country: "US"}}
+	invalidBenchmarkProduct = Product{Price: -1, Category: "weapons", Rating: 9, OnHand: -1}
+)
+
+func BenchmarkValidateUserInvalid(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		ValidateUser(invalidBenchmarkUser)
+	}
+}
+
+func BenchmarkValidateProductInvalid(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		ValidateProduct(invalidBenchmarkProduct)
+	}
+}
+
+// TestValidatorsDontAllocate tests that valid users and products are
+// checked without allocating
+func TestValidatorsDontAllocate(t *testing.T) {
+	user := User{ID: 1, Name: "Ann Lee", Email: "ann@example.com", Age: 30, Country: "US", Phone: "+14155552671",
+		Address: &Address{Street: "1 Main St", City: "Austin", Region: "TX", PostalCode: "78701", Country: "US"}}
+	product := Product{ID: 1, Name: "Desk Lamp", Price: 39.99, Category: "Home", OnHand: 4}
+	if !ValidateUser(user).Valid || !ValidateProduct(product).Valid {
+		t.Fatal("Expected the user and product to be valid")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { ValidateUser(user) }); allocs != 0 {
+		t.Errorf("ValidateUser allocated %v times", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { ValidateProduct(product) }); allocs != 0 {
+		t.Errorf("ValidateProduct allocated %v times", allocs)
+	}
+}
+
 func BenchmarkCalculateOrderTotal(b *testing.B) {
 	user := testUsers[0]
 	order := Order{
@@ -499,7 +725,7 @@ func BenchmarkAnalyzeUserBehavior(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		AnalyzeUserBehavior(users, orders)
+		AnalyzeUserBehavior(users, orders, AnalyticsFilter{})
 	}
 }
 
@@ -534,7 +760,7 @@ func TestEdgeCases(t *testing.T) {
 	}
 
 	// Test empty analytics
-	emptyAnalytics := AnalyzeUserBehavior([]User{}, []Order{})
+	emptyAnalytics := AnalyzeUserBehavior([]User{}, []Order{}, AnalyticsFilter{})
 	if emptyAnalytics.AverageAge != 0 {
 		t.Error("AnalyzeUserBehavior() should handle empty input")
 	}
@@ -565,8 +791,8 @@ func TestBusinessLogicIntegration(t *testing.T) {
 
 	// Create products
 	products := []Product{
-		{Name: "Test Product 1", Price: 50.0, Category: "electronics", InStock: true, Rating: 4.5},
-		{Name: "Test Product 2", Price: 30.0, Category: "books", InStock: true, Rating: 4.0},
+		{Name: "Test Product 1", Price: 50.0, Category: "electronics", OnHand: 10, Rating: 4.5},
+		{Name: "Test Product 2", Price: 30.0, Category: "books", OnHand: 10, Rating: 4.0},
 	}
 
 	// Validate products
@@ -598,9 +824,8 @@ func TestBusinessLogicIntegration(t *testing.T) {
 	}
 
 	// Should have US tax rate
-	expectedTaxBase := order.Subtotal - order.Discount
-	expectedTax := expectedTaxBase * 0.08 // US tax rate
-	if !floatEqual(order.Tax, expectedTax, 0.01) {
+	expectedTax := 7.48 // US tax rate of 8% on $110 less the $16.50 discount
+	if order.Tax != expectedTax {
 		t.Errorf("Integration test: tax = %v, want %v", order.Tax, expectedTax)
 	}
 
@@ -616,7 +841,7 @@ func TestBusinessLogicIntegration(t *testing.T) {
 	}
 
 	// Analyze behavior
-	analytics := AnalyzeUserBehavior([]User{user}, []Order{order})
+	analytics := AnalyzeUserBehavior([]User{user}, []Order{order}, AnalyticsFilter{})
 	if analytics.TotalRevenue <= 0 {
 		t.Error("Integration test: should have positive revenue")
 	}
@@ -627,8 +852,81 @@ func TestBusinessLogicIntegration(t *testing.T) {
 
 	t.Logf("Integration test completed successfully:")
 	t.Logf("  User: %s (%s)", user.Name, user.Email)
-	t.Logf("  Order total: %s", FormatCurrency(order.Total))
-	t.Logf("  Tax: %s", FormatCurrency(order.Tax))
-	t.Logf("  Discount: %s", FormatCurrency(order.Discount))
+	t.Logf("  Order total: %s", FormatCurrency(order.Total, order.Currency))
+	t.Logf("  Tax: %s", FormatCurrency(order.Tax, order.Currency))
+	t.Logf("  Discount: %s", FormatCurrency(order.Discount, order.Currency))
 	t.Logf("  Recommendations: %d", len(recommendations))
 }
+
+// TestValidateAddress tests country-aware address rules
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address Address
+		valid   bool
+	}{
+		{"US ZIP+4", Address{Street: "1 Main St", City: "Austin", Region: "TX", PostalCode: "78701-1234", Country: "US"}, true},
+		{"Canadian postal code", Address{Street: "1 Rue", City: "Montreal", Region: "QC", PostalCode: "H2X 1Y4", Country: "CA"}, true},
+		{"UK postcode without region", Address{Street: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "UK"}, true},
+		{"German postal code", Address{Street: "Unter den Linden 1", City: "Berlin", PostalCode: "1011", Country: "DE"}, false},
+		{"US without a state", Address{Street: "1 Main St", City: "Austin", PostalCode: "78701", Country: "US"}, false},
+		{"Missing street", Address{City: "Tokyo", PostalCode: "100-0001", Country: "JP"}, false},
+		{"Unknown country", Address{Street: "1 Main St", City: "Nowhere", PostalCode: "1", Country: "XX"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateAddress(tt.address); got.Valid != tt.valid {
+				t.Errorf("ValidateAddress() = %+v, want valid %v", got, tt.valid)
+			}
+		})
+	}
+
+	user := testUsers[0]
+	user.Address = &Address{Street: "1 Main St", City: "Austin", PostalCode: "ABC", Country: "US"}
+	if ValidateUser(user).Valid {
+		t.Error("Expected ValidateUser to reject an invalid address")
+	}
+
+	country, region := ShipTo(Order{ShippingAddress: &Address{Country: "CA", Region: "ON"}}, user)
+	if country != "CA" || region != "ON" {
+		t.Errorf("Expected the order's shipping address to win, got %s %s", country, region)
+	}
+}
+
+// TestValidatePasswordStrength tests password length, classes, common
+// passwords and the entropy estimate
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+		minScore int
+	}{
+		{"Mixed classes", "Tr0ub4dor&3x", true, 3},
+		{"Passphrase", "correct horse battery staple", true, 4},
+		{"Too short", "Ab1!", false, 0},
+		{"Two classes", "lowercase99", false, 0},
+		{"Common with suffix", "Password123!", false, 0},
+		{"Common spelled with a zero", "P@ssw0rd", false, 0},
+		{"Sequence", "abcdefgH1", false, 0},
+		{"Repeated passphrase", "aaaaaaaaaaaaaaaaaaaa", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidatePasswordStrength(tt.password)
+			if got.Valid != tt.valid || got.Score < tt.minScore {
+				t.Errorf("ValidatePasswordStrength(%q) = %+v, want valid %v and score >= %d", tt.password, got, tt.valid, tt.minScore)
+			}
+			if !got.Valid && len(got.Errors) == 0 {
+				t.Errorf("Expected a rejected password to say why, got %+v", got)
+			}
+		})
+	}
+
+	if got := ValidatePasswordStrength("Password123!"); got.Score != 0 || got.Rating != "very weak" {
+		t.Errorf("Expected a common password rated very weak, got %+v", got)
+	}
+	if got := ValidatePasswordStrength(strings.Repeat("Ab1!", 40)); got.Valid {
+		t.Error("Expected an overlong password to be rejected")
+	}
+}
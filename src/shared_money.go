@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Shared money arithmetic - order pricing adds, multiplies and splits
+// amounts as whole minor units (cents, or yen) so sums are exact, and
+// rounds only where a rate or share makes a fraction of a unit, in an
+// explicit RoundingMode. Rates are taken at their decimal value (0.0725,
+// not the nearest binary float), so 7.25% of $10.00 is exactly 72.5 cents
+// before rounding. The models keep float64 fields, converted from Money
+// with Float64, which gives the float closest to the decimal amount.
+
+// RoundingMode says which way a fraction of a minor unit goes.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, as prices and
+	// discounts are.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even unit, so tax rounding
+	// doesn't drift upwards over many lines.
+	RoundHalfEven
+	// RoundDown drops the fraction.
+	RoundDown
+)
+
+// Money is an amount in the minor units of a currency.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// NewMoney is amount in a currency, rounded half up to its minor units. The
+// amount is read at its shortest decimal form, so 2.675 is 2.68.
+func NewMoney(amount float64, code string) Money {
+	currency := currencyFor(code)
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || math.Abs(amount) > 1e15 {
+		return Money{Minor: int64(math.Round(amount * math.Pow10(currency.Decimals))), Currency: currency.Code}
+	}
+
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', -1, 64)
+	whole, fraction, _ := strings.Cut(digits, ".")
+	up := len(fraction) > currency.Decimals && fraction[currency.Decimals] >= '5'
+	fraction = (fraction + strings.Repeat("0", currency.Decimals))[:currency.Decimals]
+	minor, _ := strconv.ParseInt(whole+fraction, 10, 64)
+	if up {
+		minor++
+	}
+	if amount < 0 {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency.Code}
+}
+
+// Float64 is the amount in major units.
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / math.Pow10(currencyFor(m.Currency).Decimals)
+}
+
+// Add is m plus other, which must be in the same currency.
+func (m Money) Add(other Money) Money {
+	return Money{Minor: m.Minor + other.Minor, Currency: m.Currency}
+}
+
+// Sub is m minus other, which must be in the same currency.
+func (m Money) Sub(other Money) Money {
+	return Money{Minor: m.Minor - other.Minor, Currency: m.Currency}
+}
+
+// Times is m multiplied by a whole number, as for a quantity.
+func (m Money) Times(n int) Money {
+	return Money{Minor: m.Minor * int64(n), Currency: m.Currency}
+}
+
+// MulRate is m multiplied by a rate (0.0725 for 7.25%) and rounded.
+func (m Money) MulRate(rate float64, mode RoundingMode) Money {
+	num, den := decimalFraction(rate)
+	return m.MulDiv(num, den, mode)
+}
+
+// MulDiv is m times num/den, rounded; a share of m. den must be positive.
+func (m Money) MulDiv(num, den int64, mode RoundingMode) Money {
+	return Money{Minor: mulDivRound(m.Minor, num, den, mode), Currency: m.Currency}
+}
+
+// Min is the smaller of m and other.
+func (m Money) Min(other Money) Money {
+	if other.Minor < m.Minor {
+		return other
+	}
+	return m
+}
+
+// decimalFraction is a rate's shortest decimal form as num/den.
+func decimalFraction(rate float64) (int64, int64) {
+	digits := strconv.FormatFloat(rate, 'f', -1, 64)
+	whole, fraction, _ := strings.Cut(digits, ".")
+	// int64 holds 18 decimal places
+	if len(fraction) > 18 {
+		fraction = fraction[:18]
+	}
+	num, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		// Too large for 18 places; whole units are enough
+		return int64(math.Round(rate)), 1
+	}
+	return num, int64(math.Pow10(len(fraction)))
+}
+
+// mulDivRound is a*num/den rounded in mode, exact for any a*num that fits
+// in 128 bits.
+func mulDivRound(a, num, den int64, mode RoundingMode) int64 {
+	negative := (a < 0) != (num < 0)
+	hi, lo := bits.Mul64(absUint64(a), absUint64(num))
+	if hi >= uint64(den) {
+		panic("money: amount out of range")
+	}
+	q, r := bits.Div64(hi, lo, uint64(den))
+
+	half := uint64(den) - r // r is at least half of den when r >= half
+	switch mode {
+	case RoundHalfUp:
+		if r >= half {
+			q++
+		}
+	case RoundHalfEven:
+		if r > half || (r == half && q%2 == 1) {
+			q++
+		}
+	}
+	if negative {
+		return -int64(q)
+	}
+	return int64(q)
+}
+
+func absUint64(v int64) uint64 {
+	if v < 0 {
+		return uint64(-v)
+	}
+	return uint64(v)
+}
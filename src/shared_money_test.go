@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// TestMoneyRounding tests exact decimal amounts and the rounding modes
+func TestMoneyRounding(t *testing.T) {
+	for amount, want := range map[float64]int64{2.675: 268, 1.005: 101, -1.005: -101, 19.99: 1999, 0.1 + 0.2: 30} {
+		if got := NewMoney(amount, "USD").Minor; got != want {
+			t.Errorf("NewMoney(%v) = %d cents, want %d", amount, got, want)
+		}
+	}
+	if got := NewMoney(1500.5, "JPY").Minor; got != 1501 {
+		t.Errorf("Expected whole yen, got %d", got)
+	}
+
+	tests := []struct {
+		minor int64
+		rate  float64
+		mode  RoundingMode
+		want  int64
+	}{
+		{1000, 0.0725, RoundHalfUp, 73}, // 72.5 cents
+		{1000, 0.0725, RoundHalfEven, 72},
+		{1000, 0.0775, RoundHalfEven, 78},
+		{1000, 0.0725, RoundDown, 72},
+		{-1000, 0.0725, RoundHalfUp, -73},
+		{899, 1.5, RoundHalfUp, 1349}, // 13.485
+		{2999, 0.14975, RoundHalfEven, 449},
+	}
+	for _, tt := range tests {
+		if got := (Money{Minor: tt.minor, Currency: "USD"}).MulRate(tt.rate, tt.mode).Minor; got != tt.want {
+			t.Errorf("%d x %v (mode %d) = %d, want %d", tt.minor, tt.rate, tt.mode, got, tt.want)
+		}
+	}
+	if got := (Money{Minor: 1000}).MulDiv(1, 3, RoundHalfEven).Minor; got != 333 {
+		t.Errorf("Expected a third of $10.00 to be 333 cents, got %d", got)
+	}
+}
+
+// TestOrderTotalIsExact tests that an order's parts add up to its total
+// without drift
+func TestOrderTotalIsExact(t *testing.T) {
+	products := []Product{
+		{ID: 1, Name: "Pen", Price: 0.1, Category: "home"},
+		{ID: 2, Name: "Cable", Price: 0.7, Category: "electronics"},
+		{ID: 3, Name: "Lamp", Price: 33.33, Category: "home"},
+	}
+	order := Order{Products: products, Quantities: []int{3, 3, 3}}
+	user := User{Country: "CA", Region: "QC", Premium: true}
+	CalculateOrderTotal(&order, user)
+
+	if order.Subtotal != 102.39 || order.Discount != 15.36 || order.Tax != 13.02 {
+		t.Errorf("Unexpected amounts: %+v", order)
+	}
+	if order.Total != NewMoney(order.Subtotal, "USD").Sub(NewMoney(order.Discount, "USD")).Add(NewMoney(order.Tax, "USD")).Add(NewMoney(order.Shipping, "USD")).Float64() {
+		t.Errorf("Expected the parts to add up to the total, got %+v", order)
+	}
+}
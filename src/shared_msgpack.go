@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Shared MessagePack encoding - the server's content negotiation uses it and
+// it builds unchanged for WebAssembly. Values go through their JSON form, so
+// MessagePack payloads have the same field names, omitempty rules and
+// decoding errors as the JSON API.
+
+// maxMsgPackDepth bounds nesting when decoding untrusted input.
+const maxMsgPackDepth = 100
+
+// MarshalMsgPack encodes v as MessagePack. Integral numbers become
+// MessagePack integers and all other numbers 64-bit floats.
+func MarshalMsgPack(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := ParseJSONTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeMsgPack(&buf, tree)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes MessagePack into v like json.Unmarshal would
+// decode the equivalent JSON. Binary values decode like base64 strings.
+func UnmarshalMsgPack(data []byte, v interface{}) error {
+	r := &msgPackReader{data: data}
+	value, err := r.value(0)
+	if err != nil {
+		return err
+	}
+	if r.pos != len(data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(data)-r.pos)
+	}
+	data, err = json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// JSONTree is a parsed JSON value that keeps object keys in order. Scalars
+// are json.Number, string, bool or nil.
+type JSONTree struct {
+	Keys   []string // object keys, parallel to Items
+	Items  []JSONTree
+	Value  interface{}
+	Object bool
+	Array  bool
+}
+
+// ParseJSONTree parses one JSON value.
+func ParseJSONTree(data []byte) (JSONTree, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return parseJSONTreeValue(decoder)
+}
+
+func parseJSONTreeValue(decoder *json.Decoder) (JSONTree, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return JSONTree{}, err
+	}
+	switch token {
+	case json.Delim('{'):
+		node := JSONTree{Object: true}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return JSONTree{}, err
+			}
+			item, err := parseJSONTreeValue(decoder)
+			if err != nil {
+				return JSONTree{}, err
+			}
+			node.Keys = append(node.Keys, key.(string))
+			node.Items = append(node.Items, item)
+		}
+		_, err = decoder.Token()
+		return node, err
+	case json.Delim('['):
+		node := JSONTree{Array: true}
+		for decoder.More() {
+			item, err := parseJSONTreeValue(decoder)
+			if err != nil {
+				return JSONTree{}, err
+			}
+			node.Items = append(node.Items, item)
+		}
+		_, err = decoder.Token()
+		return node, err
+	}
+	return JSONTree{Value: token}, nil
+}
+
+func writeMsgPack(buf *bytes.Buffer, node JSONTree) {
+	switch {
+	case node.Object:
+		writeMsgPackHeader(buf, len(node.Items), 0x80, 16, 0xde, 0xdf)
+		for i, item := range node.Items {
+			writeMsgPackString(buf, node.Keys[i])
+			writeMsgPack(buf, item)
+		}
+		return
+	case node.Array:
+		writeMsgPackHeader(buf, len(node.Items), 0x90, 16, 0xdc, 0xdd)
+		for _, item := range node.Items {
+			writeMsgPack(buf, item)
+		}
+		return
+	}
+
+	switch value := node.Value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if value {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		writeMsgPackString(buf, value)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			writeMsgPackInt(buf, n)
+		} else if u, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			binary.Write(buf, binary.BigEndian, u)
+		} else {
+			f, _ := value.Float64()
+			buf.WriteByte(0xcb)
+			binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	}
+}
+
+// writeMsgPackHeader writes a map or array length in its fix, 16- or 32-bit
+// form.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fix byte, fixLimit int, code16, code32 byte) {
+	switch {
+	case n < fixLimit:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgPackString(buf *bytes.Buffer, s string) {
+	if len(s) <= math.MaxUint8 && len(s) >= 32 {
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(len(s)))
+	} else {
+		writeMsgPackHeader(buf, len(s), 0xa0, 32, 0xda, 0xdb)
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= 127, n < 0 && n >= -32:
+		buf.WriteByte(byte(n))
+	case n > 0 && n <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(n)})
+	case n > 0 && n <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n > 0 && n <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	case n > 0:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, uint64(n))
+	case n >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(n)})
+	case n >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+var errMsgPackTruncated = errors.New("msgpack: unexpected end of data")
+
+// msgPackReader decodes MessagePack into the values encoding/json produces
+// (map[string]interface{}, []interface{}, float64 and so on), using int64
+// and uint64 for integers so they round-trip exactly.
+type msgPackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgPackReader) take(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, errMsgPackTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgPackReader) uint(size int) (uint64, error) {
+	b, err := r.take(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (r *msgPackReader) value(depth int) (interface{}, error) {
+	if depth > maxMsgPackDepth {
+		return nil, errors.New("msgpack: nesting too deep")
+	}
+	b, err := r.take(1)
+	if err != nil {
+		return nil, err
+	}
+	code := b[0]
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return r.mapOf(int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return r.arrayOf(int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return r.str(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8/16/32
+		n, err := r.uint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := r.take(int(n))
+		return append([]byte(nil), bin...), err
+	case 0xca:
+		n, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := r.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (code - 0xcc))
+		if n <= math.MaxInt64 {
+			return int64(n), err
+		}
+		return n, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		n, err := r.uint(size)
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	case 0xd9, 0xda, 0xdb: // str 8/16/32
+		n, err := r.uint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayOf(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapOf(int(n), depth)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", code)
+}
+
+func (r *msgPackReader) str(n int) (string, error) {
+	b, err := r.take(n)
+	return string(b), err
+}
+
+func (r *msgPackReader) arrayOf(n int, depth int) (interface{}, error) {
+	// Every element takes at least one byte, which bounds the allocation
+	if n > len(r.data)-r.pos {
+		return nil, errMsgPackTruncated
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (r *msgPackReader) mapOf(n int, depth int) (interface{}, error) {
+	if n > (len(r.data)-r.pos)/2 {
+		return nil, errMsgPackTruncated
+	}
+	object := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key %v is not a string", key)
+		}
+		value, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	return object, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMsgPackEncoding tests the wire format against known encodings
+func TestMsgPackEncoding(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{map[string]interface{}{"a": 1}, []byte{0x81, 0xa1, 'a', 0x01}},
+		{[]int{-1, 200, -200, 70000}, []byte{0x94, 0xff, 0xcc, 0xc8, 0xd1, 0xff, 0x38, 0xce, 0x00, 0x01, 0x11, 0x70}},
+		{[]interface{}{nil, true, false, 1.5}, []byte{0x94, 0xc0, 0xc3, 0xc2, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{strings.Repeat("x", 40), append([]byte{0xd9, 40}, strings.Repeat("x", 40)...)},
+	}
+	for _, tt := range tests {
+		data, err := MarshalMsgPack(tt.value)
+		if err != nil || !bytes.Equal(data, tt.expected) {
+			t.Errorf("MarshalMsgPack(%v) = % x, %v; expected % x", tt.value, data, err, tt.expected)
+		}
+	}
+}
+
+// TestMsgPackRoundTrip tests that models survive encoding and decoding
+func TestMsgPackRoundTrip(t *testing.T) {
+	order := Order{
+		ID: 7, UserID: 1, Status: "pending", Total: 129.99,
+		Products:   []Product{{ID: 3, Name: "Desk Lamp", Price: 49.5, OnHand: 4}},
+		Quantities: []int{2},
+	}
+	data, err := MarshalMsgPack(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Order
+	if err := UnmarshalMsgPack(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, order) {
+		t.Errorf("Round trip changed the order:\n%+v\n%+v", order, decoded)
+	}
+
+	for _, bad := range [][]byte{{0x92, 0x01}, {0x81, 0x01, 0x01}, {0xc1}, {0x01, 0x02}, {0xdd, 0xff, 0xff, 0xff, 0xff}} {
+		var v interface{}
+		if err := UnmarshalMsgPack(bad, &v); err == nil {
+			t.Errorf("Expected an error decoding % x", bad)
+		}
+	}
+}
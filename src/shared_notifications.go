@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Shared notifications - how and how often a user hears about their orders,
+// and the message they get each time. A user's notification settings pick
+// email or no notifications at all, and whether emails go out as events
+// happen or collected into a daily or weekly digest. FormatOrderNotification
+// turns an order event into the subject and body of that message in the
+// user's locale, with the order's total and dates written as the locale
+// writes them. Webhook events carry the formatted notification for the mail
+// system to send or collect, and previewNotificationWasm shows the page the
+// same message.
+
+// Order event types
+const (
+	eventOrderCreated       = "order.created"
+	eventOrderStatusChanged = "order.status_changed"
+)
+
+// Notification channels
+const (
+	NotificationEmail = "email"
+	NotificationNone  = "none" // the user hears nothing
+)
+
+var notificationChannels = []string{NotificationEmail, NotificationNone}
+
+// Digest frequencies
+const (
+	DigestInstant = "instant" // one email per event
+	DigestDaily   = "daily"
+	DigestWeekly  = "weekly"
+)
+
+var digestFrequencies = []string{DigestInstant, DigestDaily, DigestWeekly}
+
+// NotificationSettings are how a user is notified. Empty fields are the
+// defaults, email as events happen.
+type NotificationSettings struct {
+	Channel string `json:"channel,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+// UserNotifications returns a user's notification settings with the
+// defaults filled in.
+func UserNotifications(user User) NotificationSettings {
+	var settings NotificationSettings
+	if user.Notifications != nil {
+		settings = *user.Notifications
+	}
+	if settings.Channel == "" {
+		settings.Channel = NotificationEmail
+	}
+	if settings.Digest == "" {
+		settings.Digest = DigestInstant
+	}
+	return settings
+}
+
+// ValidateNotificationSettings checks the channel and digest frequency. A
+// digest other than instant needs email to collect into.
+func ValidateNotificationSettings(settings NotificationSettings) ValidationResult {
+	result := newValidationResult()
+	if settings.Channel != "" && !slices.Contains(notificationChannels, settings.Channel) {
+		result.AddError("channel", CodeUnknownValue, "Channel must be email or none")
+	}
+	switch {
+	case settings.Digest != "" && !slices.Contains(digestFrequencies, settings.Digest):
+		result.AddError("digest", CodeUnknownValue, "Digest must be instant, daily or weekly")
+	case settings.Channel == NotificationNone && settings.Digest != "" && settings.Digest != DigestInstant:
+		result.AddError("digest", CodeInconsistent, "A digest needs the email channel")
+	}
+	return result
+}
+
+// Notification is the message a user gets about an order event. Channel and
+// Digest are the user's settings when it was formatted; a daily or weekly
+// digest collects the message rather than sending it on its own.
+type Notification struct {
+	Event   string `json:"event"`
+	OrderID int    `json:"order_id"`
+	UserID  int    `json:"user_id"`
+	Channel string `json:"channel"`
+	Digest  string `json:"digest"`
+	Locale  string `json:"locale"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Deliverable reports whether the user wants to hear about the event.
+func (n Notification) Deliverable() bool {
+	return n.Channel != NotificationNone
+}
+
+// notificationMessages are the notification texts by language. Languages
+// missing a text fall back to English; the order number, name, total,
+// dates and statuses are filled in as each text names them.
+var notificationMessages = map[string]map[string]string{
+	"en": {
+		"created_subject":   "We've received your order %s",
+		"created_body":      "Hello %s, thank you for your order %s of %s placed on %s.",
+		"status_subject":    "Your order %s is %s",
+		"status_body":       "Hello %s, your order %s has gone from %s to %s.",
+		"delivery":          "Estimated delivery: %s.",
+		"status_pending":    "pending",
+		"status_processing": "being prepared",
+		"status_shipped":    "shipped",
+		"status_delivered":  "delivered",
+		"status_cancelled":  "cancelled",
+	},
+	"fr": {
+		"created_subject":   "Nous avons reçu votre commande %s",
+		"created_body":      "Bonjour %s, merci pour votre commande %s de %s passée le %s.",
+		"status_subject":    "Votre commande %s : %s",
+		"status_body":       "Bonjour %s, votre commande %s est passée de « %s » à « %s ».",
+		"delivery":          "Livraison estimée : %s.",
+		"status_pending":    "en attente",
+		"status_processing": "en préparation",
+		"status_shipped":    "expédiée",
+		"status_delivered":  "livrée",
+		"status_cancelled":  "annulée",
+	},
+	"de": {
+		"created_subject":   "Wir haben Ihre Bestellung %s erhalten",
+		"created_body":      "Hallo %s, vielen Dank für Ihre Bestellung %s über %s vom %s.",
+		"status_subject":    "Ihre Bestellung %s: %s",
+		"status_body":       "Hallo %s, Ihre Bestellung %s ist von „%s“ auf „%s“ gewechselt.",
+		"delivery":          "Voraussichtliche Lieferung: %s.",
+		"status_pending":    "ausstehend",
+		"status_processing": "in Bearbeitung",
+		"status_shipped":    "versandt",
+		"status_delivered":  "zugestellt",
+		"status_cancelled":  "storniert",
+	},
+	"es": {
+		"created_subject":   "Hemos recibido tu pedido %s",
+		"created_body":      "Hola %s, gracias por tu pedido %s de %s realizado el %s.",
+		"status_subject":    "Tu pedido %s: %s",
+		"status_body":       "Hola %s, tu pedido %s ha pasado de «%s» a «%s».",
+		"delivery":          "Entrega estimada: %s.",
+		"status_pending":    "pendiente",
+		"status_processing": "en preparación",
+		"status_shipped":    "enviado",
+		"status_delivered":  "entregado",
+		"status_cancelled":  "cancelado",
+	},
+	"pt": {
+		"created_subject":   "Recebemos o seu pedido %s",
+		"created_body":      "Olá %s, obrigado pelo seu pedido %s de %s feito em %s.",
+		"status_subject":    "Seu pedido %s: %s",
+		"status_body":       "Olá %s, seu pedido %s passou de \"%s\" para \"%s\".",
+		"delivery":          "Entrega prevista: %s.",
+		"status_pending":    "pendente",
+		"status_processing": "em preparação",
+		"status_shipped":    "enviado",
+		"status_delivered":  "entregue",
+		"status_cancelled":  "cancelado",
+	},
+	"ja": {
+		"created_subject":   "ご注文 %s を承りました",
+		"created_body":      "%s 様、ご注文 %s（%s、%s）をありがとうございます。",
+		"status_subject":    "ご注文 %s：%s",
+		"status_body":       "%s 様、ご注文 %s のステータスが「%s」から「%s」に変わりました。",
+		"delivery":          "お届け予定日：%s",
+		"status_pending":    "保留中",
+		"status_processing": "準備中",
+		"status_shipped":    "発送済み",
+		"status_delivered":  "配達済み",
+		"status_cancelled":  "キャンセル済み",
+	},
+}
+
+// notificationMessage is the text for key in language, formatted with args.
+func notificationMessage(language, key string, args ...interface{}) string {
+	message, ok := notificationMessages[language][key]
+	if !ok {
+		message = notificationMessages["en"][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// notificationStatus is an order status as language writes it. Statuses
+// without a text are written as they are.
+func notificationStatus(language, status string) string {
+	if message := notificationMessage(language, "status_"+status); message != "" {
+		return message
+	}
+	return status
+}
+
+// FormatOrderNotification formats the notification of an order event for
+// the user who placed the order, in their country's locale. previousStatus
+// is the status an order.status_changed event moved the order from. The
+// notification is formatted whatever the user's settings; Deliverable says
+// whether to send it.
+func FormatOrderNotification(event string, order Order, previousStatus string, user User) (Notification, error) {
+	settings := UserNotifications(user)
+	locale := localeFor(CountryLocale(user.Country)).Tag
+	language, _, _ := strings.Cut(locale, "-")
+	number := fmt.Sprintf("ORD-%06d", order.ID)
+
+	notification := Notification{
+		Event:   event,
+		OrderID: order.ID,
+		UserID:  user.ID,
+		Channel: settings.Channel,
+		Digest:  settings.Digest,
+		Locale:  locale,
+		To:      user.Email,
+	}
+	var body []string
+	switch event {
+	case eventOrderCreated:
+		notification.Subject = notificationMessage(language, "created_subject", number)
+		body = append(body, notificationMessage(language, "created_body", user.Name, number, FormatMoney(order.Total, order.Currency, locale), FormatDate(order.OrderDate, locale)))
+	case eventOrderStatusChanged:
+		if previousStatus == "" {
+			return Notification{}, fmt.Errorf("%s needs the previous status", event)
+		}
+		status := notificationStatus(language, order.Status)
+		notification.Subject = notificationMessage(language, "status_subject", number, status)
+		body = append(body, notificationMessage(language, "status_body", user.Name, number, notificationStatus(language, previousStatus), status))
+	default:
+		return Notification{}, fmt.Errorf("unknown order event %q", event)
+	}
+	if order.EstimatedDelivery != "" && order.Status != "delivered" && order.Status != "cancelled" {
+		body = append(body, notificationMessage(language, "delivery", FormatDate(order.EstimatedDelivery, locale)))
+	}
+	notification.Body = strings.Join(body, " ")
+	return notification, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateNotificationSettings tests the checks on how a user is
+// notified
+func TestValidateNotificationSettings(t *testing.T) {
+	for _, settings := range []NotificationSettings{{}, {Channel: NotificationEmail, Digest: DigestWeekly}, {Channel: NotificationNone}} {
+		if result := ValidateNotificationSettings(settings); !result.Valid {
+			t.Errorf("Expected %+v to be valid, got %v", settings, result.Errors)
+		}
+	}
+
+	for name, test := range map[string]struct {
+		settings NotificationSettings
+		field    string
+		code     string
+	}{
+		"channel":           {NotificationSettings{Channel: "sms"}, "channel", CodeUnknownValue},
+		"digest":            {NotificationSettings{Digest: "hourly"}, "digest", CodeUnknownValue},
+		"digest of nothing": {NotificationSettings{Channel: NotificationNone, Digest: DigestDaily}, "digest", CodeInconsistent},
+	} {
+		result := ValidateNotificationSettings(test.settings)
+		if result.Valid || result.FieldErrors[0].Field != test.field || result.FieldErrors[0].Code != test.code {
+			t.Errorf("%s: expected %s on %s, got %+v", name, test.code, test.field, result.FieldErrors)
+		}
+	}
+
+	user := testUsers[0]
+	user.Notifications = &NotificationSettings{Channel: "pigeon"}
+	if result := ValidateUser(user); result.Valid || result.FieldErrors[0].Field != "notifications.channel" {
+		t.Errorf("Expected the user's notification settings checked, got %+v", result.FieldErrors)
+	}
+	if settings := UserNotifications(User{}); settings.Channel != NotificationEmail || settings.Digest != DigestInstant {
+		t.Errorf("Expected email as events happen by default, got %+v", settings)
+	}
+}
+
+// TestFormatOrderNotification tests the messages of order events in the
+// customer's locale
+func TestFormatOrderNotification(t *testing.T) {
+	order := Order{ID: 42, UserID: 7, Total: 1234.5, Currency: "EUR", OrderDate: "2024-03-05", EstimatedDelivery: "2024-03-09", Status: "shipped"}
+	for country, want := range map[string]struct {
+		subject, body string
+	}{
+		"US": {"Your order ORD-000042 is shipped", "Hello Ada, your order ORD-000042 has gone from being prepared to shipped. Estimated delivery: 03/09/2024."},
+		"DE": {"Ihre Bestellung ORD-000042: versandt", "Hallo Ada, Ihre Bestellung ORD-000042 ist von „in Bearbeitung“ auf „versandt“ gewechselt. Voraussichtliche Lieferung: 09.03.2024."},
+		"JP": {"ご注文 ORD-000042：発送済み", "Ada 様、ご注文 ORD-000042 のステータスが「準備中」から「発送済み」に変わりました。 お届け予定日：2024/03/09"},
+	} {
+		user := User{ID: 7, Name: "Ada", Email: "ada@example.com", Country: country}
+		notification, err := FormatOrderNotification(eventOrderStatusChanged, order, "processing", user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if notification.Subject != want.subject || notification.Body != want.body || notification.To != "ada@example.com" || !notification.Deliverable() {
+			t.Errorf("%s: unexpected notification %+v", country, notification)
+		}
+	}
+
+	user := User{ID: 7, Name: "Ada", Country: "FR", Notifications: &NotificationSettings{Digest: DigestDaily}}
+	order.Status = "pending"
+	notification, err := FormatOrderNotification(eventOrderCreated, order, "", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notification.Locale != "fr-FR" || notification.Digest != DigestDaily || !strings.Contains(notification.Body, FormatMoney(1234.5, "EUR", "fr-FR")) || !strings.Contains(notification.Body, "05/03/2024") {
+		t.Errorf("Expected the French confirmation for the daily digest, got %+v", notification)
+	}
+
+	user.Notifications = &NotificationSettings{Channel: NotificationNone}
+	if notification, _ := FormatOrderNotification(eventOrderCreated, order, "", user); notification.Deliverable() {
+		t.Error("Expected no notification for a user who wants none")
+	}
+	if _, err := FormatOrderNotification("order.lost", order, "", user); err == nil {
+		t.Error("Expected an unknown event refused")
+	}
+	if _, err := FormatOrderNotification(eventOrderStatusChanged, order, "", user); err == nil {
+		t.Error("Expected a status change without the previous status refused")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// Shared order value statistics - DescribeOrderValues summarizes the spread
+// of order totals: the mean and standard deviation, the smallest and largest
+// order and the 50th, 90th and 99th percentiles. A mean alone hides a few
+// large orders among many small ones; the percentiles show them. Percentiles
+// interpolate linearly between the closest ranks, as spreadsheets and NumPy
+// do by default, so they are continuous and p50 is the usual median.
+// AnalyzeUserBehavior reports them as order_values.
+
+// OrderValueStats describes the order totals in DefaultCurrency. StdDev is
+// the population standard deviation.
+type OrderValueStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+}
+
+// Quantile is the q-quantile (0 to 1) of sorted values, interpolating
+// between the two closest ranks; there is none of no values.
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := min(max(q, 0), 1) * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	if lower == len(sorted)-1 {
+		return sorted[lower]
+	}
+	return sorted[lower] + (position-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// orderValueSummary collects order totals one at a time for
+// OrderValueStats: a running mean and sum of squared deviations (Welford's
+// method) and the totals kept sorted for the percentiles.
+type orderValueSummary struct {
+	mean, m2 float64
+	sorted   []float64
+}
+
+// add counts one order total.
+func (summary *orderValueSummary) add(value float64) {
+	at, _ := slices.BinarySearch(summary.sorted, value)
+	summary.sorted = slices.Insert(summary.sorted, at, value)
+	delta := value - summary.mean
+	summary.mean += delta / float64(len(summary.sorted))
+	summary.m2 += delta * (value - summary.mean)
+}
+
+// stats describes the totals counted so far.
+func (summary *orderValueSummary) stats() OrderValueStats {
+	values := summary.sorted
+	stats := OrderValueStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	stats.Mean = RoundToCurrency(summary.mean, DefaultCurrency)
+	stats.StdDev = RoundToCurrency(math.Sqrt(summary.m2/float64(len(values))), DefaultCurrency)
+	stats.Min = RoundToCurrency(values[0], DefaultCurrency)
+	stats.Max = RoundToCurrency(values[len(values)-1], DefaultCurrency)
+	stats.P50 = RoundToCurrency(Quantile(values, 0.5), DefaultCurrency)
+	stats.P90 = RoundToCurrency(Quantile(values, 0.9), DefaultCurrency)
+	stats.P99 = RoundToCurrency(Quantile(values, 0.99), DefaultCurrency)
+	return stats
+}
+
+// DescribeOrderValues summarizes the totals of orders; totals in other
+// currencies are converted with the exchange rates in use. No orders give
+// all zeros.
+func DescribeOrderValues(orders []Order) OrderValueStats {
+	var summary orderValueSummary
+	for _, order := range orders {
+		summary.add(riskAmount(order))
+	}
+	return summary.stats()
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+// TestQuantile tests interpolation between ranks and the ends
+func TestQuantile(t *testing.T) {
+	values := []float64{10, 20, 30, 40}
+	for q, want := range map[float64]float64{0: 10, 0.5: 25, 0.9: 37, 1: 40, 1.5: 40, -1: 10} {
+		if got := Quantile(values, q); !floatEqual(got, want, 1e-9) {
+			t.Errorf("Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+	if got := Quantile([]float64{7}, 0.99); got != 7 {
+		t.Errorf("Quantile of one value = %v, want 7", got)
+	}
+	if got := Quantile(nil, 0.5); got != 0 {
+		t.Errorf("Quantile of no values = %v, want 0", got)
+	}
+}
+
+// TestDescribeOrderValues tests the summary of a skewed order history
+func TestDescribeOrderValues(t *testing.T) {
+	orders := []Order{{Total: 20}, {Total: 10}, {Total: 30}, {Total: 40}, {Total: 400}}
+	want := OrderValueStats{Count: 5, Mean: 100, StdDev: 150.33, Min: 10, Max: 400, P50: 30, P90: 256, P99: 385.6}
+	if got := DescribeOrderValues(orders); got != want {
+		t.Errorf("DescribeOrderValues = %+v, want %+v", got, want)
+	}
+	if got := DescribeOrderValues(nil); got != (OrderValueStats{}) {
+		t.Errorf("Expected no orders to give zeros, got %+v", got)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Shared phone number validation - numbers are accepted in national form
+// ("(415) 555-0132", "030 1234567") or international form ("+49 30 1234567",
+// "0049 30 1234567"), checked against the length and prefix rules of the
+// country they belong to and normalized to E.164 ("+493012345678").
+// National numbers belong to the user's country; international numbers to
+// the country of their calling code, and those with a calling code outside
+// phoneRules only need E.164's 8 to 15 digits.
+
+// phoneRule describes the national significant numbers (the digits after
+// the calling code) of a country.
+type phoneRule struct {
+	CallingCode string
+	// TrunkPrefix is dialled before national numbers within the country
+	TrunkPrefix   string
+	MinDigits     int
+	MaxDigits     int
+	LeadingDigits string // the digits a number may start with
+	// check applies rules beyond length and leading digit, if any
+	check func(nsn string) error
+}
+
+// phoneRules are the numbering plans of the supported countries.
+var phoneRules = map[string]phoneRule{
+	"US": {CallingCode: "1", TrunkPrefix: "1", MinDigits: 10, MaxDigits: 10, LeadingDigits: "23456789", check: checkNANP},
+	"CA": {CallingCode: "1", TrunkPrefix: "1", MinDigits: 10, MaxDigits: 10, LeadingDigits: "23456789", check: checkNANP},
+	"UK": {CallingCode: "44", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 10, LeadingDigits: "123789"},
+	"DE": {CallingCode: "49", TrunkPrefix: "0", MinDigits: 6, MaxDigits: 11, LeadingDigits: "123456789"},
+	"FR": {CallingCode: "33", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, LeadingDigits: "123456789"},
+	"JP": {CallingCode: "81", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 10, LeadingDigits: "123456789"},
+	"AU": {CallingCode: "61", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, LeadingDigits: "23478"},
+	"IN": {CallingCode: "91", TrunkPrefix: "0", MinDigits: 10, MaxDigits: 10, LeadingDigits: "23456789"},
+	"BR": {CallingCode: "55", TrunkPrefix: "0", MinDigits: 10, MaxDigits: 11, LeadingDigits: "123456789", check: checkBrazil},
+	"MX": {CallingCode: "52", MinDigits: 10, MaxDigits: 10, LeadingDigits: "123456789"},
+}
+
+// checkNANP checks the North American area code and exchange, neither of
+// which may start with 0 or 1.
+func checkNANP(nsn string) error {
+	if nsn[3] == '0' || nsn[3] == '1' {
+		return errors.New("the exchange (digits 4-6) must not start with 0 or 1")
+	}
+	return nil
+}
+
+// checkBrazil checks for a two-digit area code followed by an 8-digit
+// landline or a 9-digit mobile number starting with 9.
+func checkBrazil(nsn string) error {
+	if nsn[1] == '0' {
+		return errors.New("the area code must not end in 0")
+	}
+	if len(nsn) == 11 && nsn[2] != '9' {
+		return errors.New("9-digit numbers must be mobile numbers starting with 9")
+	}
+	return nil
+}
+
+// ValidatePhone checks a phone number for a country (an ISO code as in
+// User.Country) and returns it in E.164 form.
+func ValidatePhone(number, country string) (string, error) {
+	callingCode, nsn, err := checkPhone(number, country)
+	if err != nil {
+		return "", err
+	}
+	return "+" + callingCode + nsn, nil
+}
+
+// checkPhone is ValidatePhone without writing the number out: it returns
+// the calling code and national significant number, or for calling codes
+// outside phoneRules "" and the digits after the +.
+func checkPhone(number, country string) (string, string, error) {
+	digits, international, err := phoneDigits(number)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !international {
+		rule, ok := phoneRules[canonicalCountry(country)]
+		if !ok {
+			return "", "", fmt.Errorf("national numbers are not supported for country %q; use the +country code form", country)
+		}
+		// No national significant number starts with its trunk prefix
+		nsn := digits
+		if rule.TrunkPrefix != "" {
+			nsn = strings.TrimPrefix(digits, rule.TrunkPrefix)
+		}
+		if err := rule.validate(nsn); err != nil {
+			return "", "", err
+		}
+		return rule.CallingCode, nsn, nil
+	}
+
+	rule, nsn, ok := phoneRuleFor(digits, canonicalCountry(country))
+	if !ok {
+		if len(digits) < 8 || len(digits) > 15 {
+			return "", "", errors.New("international numbers must have 8 to 15 digits")
+		}
+		return "", digits, nil
+	}
+	// A trunk 0 written after the code, as in +44 (0)20 ..., is dropped, as
+	// is the 1 Mexican mobiles used to be dialled with from abroad
+	if rule.TrunkPrefix == "0" {
+		nsn = strings.TrimPrefix(nsn, "0")
+	}
+	if rule.CallingCode == "52" && len(nsn) == 11 && nsn[0] == '1' {
+		nsn = nsn[1:]
+	}
+	if err := rule.validate(nsn); err != nil {
+		return "", "", err
+	}
+	return rule.CallingCode, nsn, nil
+}
+
+// validate checks a national significant number against the rule.
+func (rule phoneRule) validate(nsn string) error {
+	if len(nsn) < rule.MinDigits || len(nsn) > rule.MaxDigits {
+		if rule.MinDigits == rule.MaxDigits {
+			return fmt.Errorf("numbers with country code +%s must have %d digits after it", rule.CallingCode, rule.MinDigits)
+		}
+		return fmt.Errorf("numbers with country code +%s must have %d to %d digits after it", rule.CallingCode, rule.MinDigits, rule.MaxDigits)
+	}
+	if !strings.ContainsRune(rule.LeadingDigits, rune(nsn[0])) {
+		return fmt.Errorf("numbers with country code +%s must not start with %c after it", rule.CallingCode, nsn[0])
+	}
+	if rule.check != nil {
+		return rule.check(nsn)
+	}
+	return nil
+}
+
+// phoneRuleFor finds the rule for the calling code an international number
+// starts with, preferring country for codes shared by several countries.
+func phoneRuleFor(digits, country string) (phoneRule, string, bool) {
+	if rule, ok := phoneRules[country]; ok && strings.HasPrefix(digits, rule.CallingCode) {
+		return rule, digits[len(rule.CallingCode):], true
+	}
+	for _, code := range []string{"US", "UK", "DE", "FR", "JP", "AU", "IN", "BR", "MX"} {
+		if rule := phoneRules[code]; strings.HasPrefix(digits, rule.CallingCode) {
+			return rule, digits[len(rule.CallingCode):], true
+		}
+	}
+	return phoneRule{}, "", false
+}
+
+// phoneDigits strips the separators people write numbers with and reports
+// whether the number is international (written with + or 00).
+func phoneDigits(number string) (string, bool, error) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return "", false, errors.New("phone number is empty")
+	}
+	international := false
+	if rest, ok := strings.CutPrefix(number, "+"); ok {
+		number, international = rest, true
+	}
+	// Numbers written without separators, as stored numbers are, are their
+	// own digits
+	digits := number
+	if strings.ContainsFunc(number, func(r rune) bool { return r < '0' || r > '9' }) {
+		var err error
+		if digits, err = stripPhoneSeparators(number); err != nil {
+			return "", false, err
+		}
+	}
+	if rest, ok := strings.CutPrefix(digits, "00"); ok && !international {
+		digits, international = rest, true
+	}
+	if digits == "" {
+		return "", false, errors.New("phone number has no digits")
+	}
+	return digits, international, nil
+}
+
+// stripPhoneSeparators returns the digits of a number written with spaces,
+// hyphens, dots or parentheses.
+func stripPhoneSeparators(number string) (string, error) {
+	var sb strings.Builder
+	sb.Grow(len(number))
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("%q is not allowed in a phone number", r)
+		}
+	}
+	return sb.String(), nil
+}
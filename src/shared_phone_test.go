@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidatePhone tests per-country rules and E.164 normalization
+func TestValidatePhone(t *testing.T) {
+	for _, tt := range []struct {
+		number, country string
+		e164            string
+		error           string
+	}{
+		{"(415) 555-0132", "US", "+14155550132", ""},
+		{"1-415-555-0132", "US", "+14155550132", ""},
+		{"+1 604 555 0132", "CA", "+16045550132", ""},
+		{"020 7946 0958", "UK", "+442079460958", ""},
+		{"+44 (0)20 7946 0958", "US", "+442079460958", ""},
+		{"030 1234567", "DE", "+49301234567", ""},
+		{"0049 151 23456789", "FR", "+4915123456789", ""},
+		{"01 23 45 67 89", "FR", "+33123456789", ""},
+		{"03-1234-5678", "JP", "+81312345678", ""},
+		{"0412 345 678", "AU", "+61412345678", ""},
+		{"98765 43210", "IN", "+919876543210", ""},
+		{"(11) 91234-5678", "BR", "+5511912345678", ""},
+		{"(11) 2345-6789", "BR", "+551123456789", ""},
+		{"+52 1 55 1234 5678", "MX", "+525512345678", ""},
+		{"+34 612 345 678", "US", "+34612345678", ""},
+		{"555-0132", "US", "", "must have 10 digits"},
+		{"(415) 155-0132", "US", "", "exchange"},
+		{"(015) 555-0132", "US", "", "must not start with 0"},
+		{"0412 345 6789", "AU", "", "must have 9 digits"},
+		{"(11) 81234-5678", "BR", "", "mobile numbers starting with 9"},
+		{"(10) 2345-6789", "BR", "", "area code"},
+		{"+999 12", "US", "", "8 to 15 digits"},
+		{"415 555 0132 ext 4", "US", "", "not allowed"},
+		{"12345678", "XX", "", "not supported"},
+		{"  ", "US", "", "empty"},
+	} {
+		e164, err := ValidatePhone(tt.number, tt.country)
+		if e164 != tt.e164 || (tt.error == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.error)) {
+			t.Errorf("ValidatePhone(%q, %s) = %q, %v, want %q with %q", tt.number, tt.country, e164, err, tt.e164, tt.error)
+		}
+	}
+
+	user := testUsers[0]
+	user.Phone = "555-0132"
+	if result := ValidateUser(user); result.Valid || !strings.HasPrefix(result.Errors[0], "Invalid phone number") {
+		t.Errorf("Expected ValidateUser to reject the short number, got %+v", result)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shared postal code validation - each supported country's format is one
+// entry in postalCodeFormats, so the server and the WASM client check codes
+// against the same table. Codes are matched with spaces and hyphens removed
+// and case ignored ("sw1a2aa", "787011234"), then written the way the
+// country writes them ("SW1A 2AA", "78701-1234").
+
+// postalCodeFormat describes a country's postal codes.
+type postalCodeFormat struct {
+	Name    string            // what the country calls them
+	Example string            // shown in errors
+	Pattern string            // matched against the code without separators
+	Match   func(string) bool // Pattern, hand-written
+	// Separator is inserted at SplitAt (counted from the end when negative)
+	// when the code is longer than that
+	Separator string
+	SplitAt   int
+}
+
+// postalCodeFormats are the postal code formats of the supported countries.
+var postalCodeFormats = map[string]postalCodeFormat{
+	"US": {Name: "ZIP code", Example: "12345 or 12345-6789", Pattern: `^\d{5}(\d{4})?$`, Match: matchUSZip, Separator: "-", SplitAt: 5},
+	// Canadian codes never use D, F, I, O, Q or U, nor start with W or Z
+	"CA": {Name: "postal code", Example: "K1A 0B1", Pattern: `^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z]\d[ABCEGHJ-NPRSTV-Z]\d$`, Match: matchCAPostalCode, Separator: " ", SplitAt: -3},
+	// An outcode (area letters, district digits and an optional letter)
+	// then an inward code; GIR 0AA is the one historical exception
+	"UK": {Name: "postcode", Example: "SW1A 1AA", Pattern: `^(GIR0AA|[A-PR-UWYZ]([0-9][0-9A-HJKPSTUW]?|[A-HK-Y][0-9][0-9ABEHMNPRVWXY]?)[0-9][ABD-HJLNP-UW-Z]{2})$`, Match: matchUKPostcode, Separator: " ", SplitAt: -3},
+	"DE": {Name: "Postleitzahl", Example: "10115", Pattern: `^\d{5}$`, Match: matchDigits(5)},
+	"FR": {Name: "code postal", Example: "75008", Pattern: `^\d{5}$`, Match: matchDigits(5)},
+	"JP": {Name: "postal code", Example: "100-0001", Pattern: `^\d{7}$`, Match: matchDigits(7), Separator: "-", SplitAt: 3},
+	"AU": {Name: "postcode", Example: "2000", Pattern: `^\d{4}$`, Match: matchDigits(4)},
+	"IN": {Name: "PIN code", Example: "110001", Pattern: `^[1-9]\d{5}$`, Match: matchINPinCode},
+	"BR": {Name: "CEP", Example: "01310-100", Pattern: `^\d{8}$`, Match: matchDigits(8), Separator: "-", SplitAt: 5},
+	"MX": {Name: "código postal", Example: "06600", Pattern: `^\d{5}$`, Match: matchDigits(5)},
+}
+
+// postalCodeSeparators removes the spaces and hyphens codes are written with.
+var postalCodeSeparators = strings.NewReplacer(" ", "", "-", "")
+
+// ValidatePostalCode checks a postal code for a country (an ISO code as in
+// Address.Country) and returns it in the country's canonical form.
+func ValidatePostalCode(code, country string) (string, error) {
+	country = canonicalCountry(country)
+	format, ok := postalCodeFormats[country]
+	if !ok {
+		return "", fmt.Errorf("postal codes are not supported for country %q", country)
+	}
+
+	compact := strings.ToUpper(postalCodeSeparators.Replace(strings.TrimSpace(code)))
+	if !matchText(format.Pattern, format.Match, compact) {
+		return "", fmt.Errorf("expected a %s such as %s", format.Name, format.Example)
+	}
+
+	split := format.SplitAt
+	if split < 0 {
+		split += len(compact)
+	}
+	if format.Separator == "" || split <= 0 || split >= len(compact) {
+		return compact, nil
+	}
+	return compact[:split] + format.Separator + compact[split:], nil
+}
+
+// NormalizeAddress writes an address's country code and postal code in
+// canonical form, leaving codes that don't validate as they are.
+func NormalizeAddress(address Address) Address {
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	if code, err := ValidatePostalCode(address.PostalCode, address.Country); err == nil {
+		address.PostalCode = code
+	}
+	return address
+}
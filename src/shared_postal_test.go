@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// TestValidatePostalCode tests per-country formats and canonical forms
+func TestValidatePostalCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		country string
+		want    string
+		valid   bool
+	}{
+		{"US ZIP", "78701", "US", "78701", true},
+		{"US ZIP+4 without hyphen", "787011234", "US", "78701-1234", true},
+		{"US too short", "7870", "US", "", false},
+		{"Canadian lower case", "k1a0b1", "ca", "K1A 0B1", true},
+		{"Canadian D", "D1A 0B1", "CA", "", false},
+		{"Canadian W first", "W1A 0B1", "CA", "", false},
+		{"UK postcode", "sw1a 1aa", "UK", "SW1A 1AA", true},
+		{"UK short outcode", "M11AE", "UK", "M1 1AE", true},
+		{"UK GIR", "GIR 0AA", "UK", "GIR 0AA", true},
+		{"UK Q first", "QA1 1AA", "UK", "", false},
+		{"UK inward C", "SW1A 1CA", "UK", "", false},
+		{"Japanese code", "1000001", "JP", "100-0001", true},
+		{"Brazilian CEP", "01310 100", "BR", "01310-100", true},
+		{"Indian PIN starting with 0", "010001", "IN", "", false},
+		{"German code", "10115", "DE", "10115", true},
+		{"Unsupported country", "12345", "XX", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidatePostalCode(tt.code, tt.country)
+			if (err == nil) != tt.valid || got != tt.want {
+				t.Errorf("ValidatePostalCode(%q, %q) = %q, %v; want %q, valid %v", tt.code, tt.country, got, err, tt.want, tt.valid)
+			}
+		})
+	}
+
+	address := NormalizeAddress(Address{PostalCode: "h2x1y4", Country: "ca"})
+	if address.PostalCode != "H2X 1Y4" || address.Country != "CA" {
+		t.Errorf("Expected the address normalized, got %+v", address)
+	}
+	if address := NormalizeAddress(Address{PostalCode: "nope", Country: "US"}); address.PostalCode != "nope" {
+		t.Errorf("Expected an invalid postal code kept as written, got %+v", address)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Shared user preferences - what a user has told the shop about their
+// tastes: the categories they like and the ones they never want to see, how
+// much price matters to them and whether they may be sent marketing.
+// Recommendations favour the favourite categories, leave the excluded ones
+// out and lean cheaper or pricier with the price sensitivity. Analytics
+// filters select users by their preferences, and the RFM segments count who
+// opted in. Users without preferences are recommended to as before.
+
+// Price sensitivities, from least to most.
+const (
+	PriceSensitivityLow    = "low"    // leans to higher-end products
+	PriceSensitivityMedium = "medium" // no lean
+	PriceSensitivityHigh   = "high"   // leans to cheaper products
+)
+
+var priceSensitivities = []string{PriceSensitivityLow, PriceSensitivityMedium, PriceSensitivityHigh}
+
+// MaxFavoriteCategories is how many categories a user can pick as
+// favourites.
+const MaxFavoriteCategories = 3
+
+// Preferences are a user's stated tastes.
+type Preferences struct {
+	FavoriteCategories []string `json:"favorite_categories,omitempty"`
+	ExcludedCategories []string `json:"excluded_categories,omitempty"`
+	// PriceSensitivity is one of the PriceSensitivity constants, or empty
+	// when the user didn't say
+	PriceSensitivity string `json:"price_sensitivity,omitempty"`
+	MarketingOptIn   bool   `json:"marketing_opt_in"`
+}
+
+// UserPreferences returns a user's preferences, the zero Preferences when
+// they have none.
+func UserPreferences(user User) Preferences {
+	if user.Preferences == nil {
+		return Preferences{}
+	}
+	return *user.Preferences
+}
+
+// favors reports whether category is one of the favourites, in any case.
+func (prefs Preferences) favors(category string) bool {
+	return slices.ContainsFunc(prefs.FavoriteCategories, func(favorite string) bool { return strings.EqualFold(favorite, category) })
+}
+
+// excludes reports whether category is excluded, in any case.
+func (prefs Preferences) excludes(category string) bool {
+	return slices.ContainsFunc(prefs.ExcludedCategories, func(excluded string) bool { return strings.EqualFold(excluded, category) })
+}
+
+// ValidatePreferences checks that the categories are product categories of
+// the ruleset in use, each listed once, that no category is both a
+// favourite and excluded, and the price sensitivity.
+func ValidatePreferences(prefs Preferences) ValidationResult {
+	result := newValidationResult()
+	categories := productCategories(CurrentValidationRules())
+	known := func(category string) bool {
+		return slices.ContainsFunc(categories, func(allowed string) bool { return strings.EqualFold(allowed, category) })
+	}
+	if len(prefs.FavoriteCategories) > MaxFavoriteCategories {
+		result.AddError("favorite_categories", CodeOutOfRange, fmt.Sprintf("Pick at most %d favorite categories", MaxFavoriteCategories))
+	}
+	for _, list := range []struct {
+		field      string
+		categories []string
+	}{{"favorite_categories", prefs.FavoriteCategories}, {"excluded_categories", prefs.ExcludedCategories}} {
+		for i, category := range list.categories {
+			field := fmt.Sprintf("%s[%d]", list.field, i)
+			switch {
+			case !known(category):
+				result.AddError(field, CodeUnknownValue, fmt.Sprintf("Unknown category %q", category))
+			case slices.ContainsFunc(list.categories[:i], func(earlier string) bool { return strings.EqualFold(earlier, category) }):
+				result.AddError(field, CodeDuplicate, fmt.Sprintf("Category %s is listed twice", category))
+			case list.field == "excluded_categories" && prefs.favors(category):
+				result.AddError(field, CodeInconsistent, fmt.Sprintf("Category %s can't be both a favorite and excluded", category))
+			}
+		}
+	}
+	if prefs.PriceSensitivity != "" && !slices.Contains(priceSensitivities, prefs.PriceSensitivity) {
+		result.AddError("price_sensitivity", CodeUnknownValue, "Price sensitivity must be low, medium or high")
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestValidatePreferences tests the checks on a user's preferences
+func TestValidatePreferences(t *testing.T) {
+	valid := Preferences{FavoriteCategories: []string{"books", "Home"}, ExcludedCategories: []string{"toys"}, PriceSensitivity: PriceSensitivityHigh, MarketingOptIn: true}
+	if result := ValidatePreferences(valid); !result.Valid {
+		t.Fatalf("Expected valid preferences, got %v", result.Errors)
+	}
+	if result := ValidatePreferences(Preferences{}); !result.Valid {
+		t.Errorf("Expected no preferences to be valid, got %v", result.Errors)
+	}
+
+	for name, test := range map[string]struct {
+		prefs Preferences
+		field string
+		code  string
+	}{
+		"unknown category":   {Preferences{FavoriteCategories: []string{"garden"}}, "favorite_categories[0]", CodeUnknownValue},
+		"listed twice":       {Preferences{ExcludedCategories: []string{"toys", "TOYS"}}, "excluded_categories[1]", CodeDuplicate},
+		"favorite excluded":  {Preferences{FavoriteCategories: []string{"books"}, ExcludedCategories: []string{"books"}}, "excluded_categories[0]", CodeInconsistent},
+		"too many favorites": {Preferences{FavoriteCategories: []string{"books", "home", "toys", "beauty"}}, "favorite_categories", CodeOutOfRange},
+		"sensitivity":        {Preferences{PriceSensitivity: "extreme"}, "price_sensitivity", CodeUnknownValue},
+	} {
+		result := ValidatePreferences(test.prefs)
+		if result.Valid || result.FieldErrors[0].Field != test.field || result.FieldErrors[0].Code != test.code {
+			t.Errorf("%s: expected %s on %s, got %+v", name, test.code, test.field, result.FieldErrors)
+		}
+	}
+
+	// A user's preferences are checked with the user
+	user := testUsers[0]
+	user.Preferences = &Preferences{PriceSensitivity: "extreme"}
+	if result := ValidateUser(user); result.Valid || result.FieldErrors[0].Field != "preferences.price_sensitivity" {
+		t.Errorf("Expected the user's preferences checked, got %+v", result.FieldErrors)
+	}
+}
+
+// TestRecommendWithPreferences tests that preferences steer the scores
+func TestRecommendWithPreferences(t *testing.T) {
+	user := User{ID: 1, Age: 30, Country: "US"}
+	products := []Product{
+		{ID: 1, Name: "Novel", Price: 20, Category: "books", Rating: 4, OnHand: 5},
+		{ID: 2, Name: "Blender", Price: 90, Category: "home", Rating: 4, OnHand: 5},
+		{ID: 3, Name: "Kite", Price: 30, Category: "toys", Rating: 4, OnHand: 5},
+		{ID: 4, Name: "Camera", Price: 400, Category: "electronics", Rating: 4, OnHand: 5},
+	}
+	ids := func(recommendations []Recommendation) []int {
+		var ids []int
+		for _, recommendation := range recommendations {
+			ids = append(ids, recommendation.ID)
+		}
+		return ids
+	}
+	reasons := func(recommendations []Recommendation, id int) []string {
+		var reasons []string
+		for _, recommendation := range recommendations {
+			if recommendation.ID == id {
+				for _, reason := range recommendation.Reasons {
+					reasons = append(reasons, reason.Reason)
+				}
+			}
+		}
+		return reasons
+	}
+	options := RecommendOptions{Weights: DefaultRecommendationWeights}
+
+	before := ExplainRecommendations(user, products, Order{}, Wishlist{}, options)
+	if len(before) != 4 || slices.Contains(reasons(before, 1), "one of your favorite categories") {
+		t.Fatalf("Expected every product without preference reasons, got %+v", before)
+	}
+
+	user.Preferences = &Preferences{FavoriteCategories: []string{"Books"}, ExcludedCategories: []string{"toys"}, PriceSensitivity: PriceSensitivityHigh}
+	after := ExplainRecommendations(user, products, Order{}, Wishlist{}, options)
+	if got := ids(after); slices.Contains(got, 3) || got[0] != 1 {
+		t.Errorf("Expected the novel first and no toys, got %v", got)
+	}
+	if got := reasons(after, 1); !slices.Contains(got, "one of your favorite categories") || !slices.Contains(got, "fits your budget") {
+		t.Errorf("Expected the novel favoured and within budget, got %v", got)
+	}
+
+	user.Preferences = &Preferences{PriceSensitivity: PriceSensitivityLow}
+	if got := reasons(ExplainRecommendations(user, products, Order{}, Wishlist{}, options), 4); !slices.Contains(got, "a step up in quality") {
+		t.Errorf("Expected the camera suggested as a step up, got %v", got)
+	}
+}
+
+// TestAnalyticsFilterPreferences tests segmenting analytics by preferences
+func TestAnalyticsFilterPreferences(t *testing.T) {
+	users := []User{
+		{ID: 1, Preferences: &Preferences{MarketingOptIn: true, FavoriteCategories: []string{"books"}}},
+		{ID: 2, Preferences: &Preferences{FavoriteCategories: []string{"home"}}},
+		{ID: 3},
+	}
+	orders := []Order{{ID: 1, UserID: 1}, {ID: 2, UserID: 2}, {ID: 3, UserID: 3}}
+
+	for name, test := range map[string]struct {
+		filter AnalyticsFilter
+		want   int
+	}{
+		"opted in":          {AnalyticsFilter{MarketingOptIn: true}, 1},
+		"favorite category": {AnalyticsFilter{FavoriteCategory: "HOME"}, 2},
+		"both":              {AnalyticsFilter{MarketingOptIn: true, FavoriteCategory: "home"}, 0},
+	} {
+		keptUsers, keptOrders := test.filter.Apply(users, orders)
+		if test.want == 0 {
+			if len(keptUsers) != 0 || len(keptOrders) != 0 {
+				t.Errorf("%s: expected nobody, got %+v %+v", name, keptUsers, keptOrders)
+			}
+			continue
+		}
+		if len(keptUsers) != 1 || keptUsers[0].ID != test.want || len(keptOrders) != 1 || keptOrders[0].UserID != test.want {
+			t.Errorf("%s: expected user %d and their order, got %+v %+v", name, test.want, keptUsers, keptOrders)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Shared price history - every price a product has had and when it took
+// effect, for price charts and honest "was/now" labels. A was-price is only
+// shown when the current price is lower than the lowest price of the
+// reference period before the change (30 days by default), so a price
+// raised and dropped again the same week can't be advertised as a sale.
+
+// WasPricePeriod is the period before a price change whose lowest price a
+// reduction is compared with.
+const WasPricePeriod = 30 * 24 * time.Hour
+
+// PricePoint is a price that took effect at From (RFC 3339).
+type PricePoint struct {
+	Price float64 `json:"price"`
+	From  string  `json:"from"`
+}
+
+// PriceHistory is a product's prices, oldest first.
+type PriceHistory []PricePoint
+
+// PriceHistories are the price histories of products, by product ID.
+type PriceHistories map[int]PriceHistory
+
+// PriceComparison is a product's current price against its was-price.
+// Was and DiscountPercent are zero unless the product is on sale.
+type PriceComparison struct {
+	Now             float64 `json:"now"`
+	Was             float64 `json:"was,omitempty"`
+	DiscountPercent float64 `json:"discount_percent,omitempty"`
+	OnSale          bool    `json:"on_sale"`
+	Since           string  `json:"since,omitempty"`
+}
+
+// RecordPriceChange adds a price taking effect at time at, keeping the
+// history in order. A price equal to the one already in effect then is
+// not recorded again.
+func RecordPriceChange(history PriceHistory, price float64, at time.Time) PriceHistory {
+	if current, ok := history.PriceAt(at); ok && current == price {
+		return history
+	}
+	point := PricePoint{Price: price, From: at.UTC().Format(time.RFC3339)}
+	i := sort.Search(len(history), func(i int) bool { return history[i].From > point.From })
+	history = append(history, PricePoint{})
+	copy(history[i+1:], history[i:])
+	history[i] = point
+	return history
+}
+
+// PriceAt is the price in effect at time at, false before the first one.
+func (history PriceHistory) PriceAt(at time.Time) (float64, bool) {
+	cutoff := at.UTC().Format(time.RFC3339)
+	i := sort.Search(len(history), func(i int) bool { return history[i].From > cutoff })
+	if i == 0 {
+		return 0, false
+	}
+	return history[i-1].Price, true
+}
+
+// GetPriceAt is the price a product had at time at, false for products
+// without a history or times before it starts.
+func (histories PriceHistories) GetPriceAt(productID int, at time.Time) (float64, bool) {
+	return histories[productID].PriceAt(at)
+}
+
+// Between lists the points in effect at some time from since until until:
+// the one in effect at since and every change after it, up to until.
+func (history PriceHistory) Between(since, until time.Time) PriceHistory {
+	from, to := since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)
+	points := PriceHistory{}
+	for i, point := range history {
+		if point.From > to {
+			break
+		}
+		if point.From >= from || i == len(history)-1 || history[i+1].From > from {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// ComparePrice compares the price in effect at now with the lowest price of
+// the period before it took effect.
+func ComparePrice(history PriceHistory, now time.Time, period time.Duration) PriceComparison {
+	cutoff := now.UTC().Format(time.RFC3339)
+	i := sort.Search(len(history), func(i int) bool { return history[i].From > cutoff }) - 1
+	if i < 0 {
+		return PriceComparison{}
+	}
+	current := history[i]
+	comparison := PriceComparison{Now: current.Price}
+	if i == 0 {
+		return comparison
+	}
+
+	changed, err := time.Parse(time.RFC3339, current.From)
+	if err != nil {
+		return comparison
+	}
+	start := changed.Add(-period)
+	lowest := math.Inf(1)
+	for j := i - 1; j >= 0; j-- {
+		lowest = math.Min(lowest, history[j].Price)
+		if from, err := time.Parse(time.RFC3339, history[j].From); err != nil || !from.After(start) {
+			break
+		}
+	}
+	if current.Price < lowest {
+		comparison.Was = lowest
+		comparison.DiscountPercent = math.Round((lowest-current.Price)/lowest*1000) / 10
+		comparison.OnSale = true
+		comparison.Since = current.From
+	}
+	return comparison
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPriceHistory tests recording prices and looking them up by time
+func TestPriceHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 12, 0, 0, 0, time.UTC) }
+	var history PriceHistory
+	history = RecordPriceChange(history, 50, day(1))
+	history = RecordPriceChange(history, 40, day(20))
+	history = RecordPriceChange(history, 45, day(10))
+	history = RecordPriceChange(history, 45, day(12))
+	if len(history) != 3 || history[1].Price != 45 || history[2].Price != 40 {
+		t.Fatalf("Expected three changes in time order, got %+v", history)
+	}
+
+	histories := PriceHistories{7: history}
+	for _, tt := range []struct {
+		at    time.Time
+		price float64
+		ok    bool
+	}{
+		{day(1).Add(-time.Hour), 0, false},
+		{day(5), 50, true},
+		{day(10), 45, true},
+		{day(25), 40, true},
+	} {
+		if price, ok := histories.GetPriceAt(7, tt.at); price != tt.price || ok != tt.ok {
+			t.Errorf("GetPriceAt(%v) = %v, %v, want %v, %v", tt.at, price, ok, tt.price, tt.ok)
+		}
+	}
+	if _, ok := histories.GetPriceAt(8, day(5)); ok {
+		t.Error("Expected no price for a product without a history")
+	}
+
+	if points := history.Between(day(15), day(25)); len(points) != 2 || points[0].Price != 45 {
+		t.Errorf("Expected the price in effect at the start and the change after it, got %+v", points)
+	}
+}
+
+// TestComparePrice tests was/now labels against the lowest recent price
+func TestComparePrice(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 12, 0, 0, 0, time.UTC) }
+	var history PriceHistory
+	history = RecordPriceChange(history, 50, day(1))
+	history = RecordPriceChange(history, 40, day(20))
+
+	comparison := ComparePrice(history, day(25), WasPricePeriod)
+	if !comparison.OnSale || comparison.Was != 50 || comparison.Now != 40 || comparison.DiscountPercent != 20 {
+		t.Errorf("Expected 20%% off 50, got %+v", comparison)
+	}
+
+	// Raising the price shortly before a drop doesn't make a bigger sale
+	raised := RecordPriceChange(history, 60, day(26))
+	raised = RecordPriceChange(raised, 45, day(28))
+	if comparison := ComparePrice(raised, day(29), WasPricePeriod); comparison.OnSale {
+		t.Errorf("Expected no sale when the price was lower within the period, got %+v", comparison)
+	}
+
+	if comparison := ComparePrice(history[:1], day(25), WasPricePeriod); comparison.OnSale || comparison.Now != 50 {
+		t.Errorf("Expected a single price not to be on sale, got %+v", comparison)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import "fmt"
+
+// Shared personal data handling for data subject requests (GDPR articles 15
+// and 17) - a user can get a copy of everything held about them, or have
+// their personal data erased. Erasure anonymizes rather than deletes: the
+// user and their orders stay in place with the identifying fields scrubbed,
+// so revenue, country and age analytics keep adding up.
+
+// anonymizedName replaces the name of an anonymized user.
+const anonymizedName = "Anonymized User"
+
+// UserDataExport is every record linked to a user, as one document.
+type UserDataExport struct {
+	ExportedAt    string         `json:"exported_at"`
+	User          User           `json:"user"`
+	Orders        []Order        `json:"orders"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	Wishlist      Wishlist       `json:"wishlist"`
+}
+
+// AnonymizeUser scrubs a user's personal data: name, email, phone and
+// address. Everything else is kept - what the analytics aggregate (age,
+// country and region, premium status and join date), the loyalty balance,
+// preferences and version - and the ID keeps the user's orders linked.
+// There is no way back; the email becomes a placeholder unique to the ID,
+// and so is no longer verified.
+func AnonymizeUser(user User) User {
+	user.Name = anonymizedName
+	user.Email = fmt.Sprintf("user-%d@anonymized.invalid", user.ID)
+	user.EmailVerified = false
+	user.Phone = ""
+	user.Address = nil
+	user.Anonymized = true
+	return user
+}
+
+// AnonymizeOrder reduces an order's shipping address to the country and
+// region it was taxed and shipped for.
+func AnonymizeOrder(order Order) Order {
+	if order.ShippingAddress != nil {
+		order.ShippingAddress = &Address{Country: order.ShippingAddress.Country, Region: order.ShippingAddress.Region}
+	}
+	return order
+}
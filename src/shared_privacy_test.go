@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+// TestAnonymizeUser tests scrubbing personal data while keeping analytics
+func TestAnonymizeUser(t *testing.T) {
+	user := User{
+		ID: 7, Email: "jane.smith@example.com", EmailVerified: true, Name: "Jane Smith", Age: 34,
+		Country: "CA", Region: "ON", Premium: true, JoinDate: "2023-02-20", Phone: "+14165550132",
+		Address:       &Address{Street: "1 Queen St", City: "Toronto", Region: "ON", PostalCode: "M5H 2N2", Country: "CA"},
+		LoyaltyPoints: 120, Preferences: &Preferences{FavoriteCategories: []string{"books"}}, SchemaVersion: CurrentSchemaVersion, Version: 4,
+	}
+	anonymized := AnonymizeUser(user)
+	if anonymized.Name != anonymizedName || anonymized.Email != "user-7@anonymized.invalid" || anonymized.Phone != "" || anonymized.Address != nil || anonymized.EmailVerified {
+		t.Errorf("Expected the personal data scrubbed, got %+v", anonymized)
+	}
+	if !anonymized.Anonymized || anonymized.ID != 7 || anonymized.Age != 34 || anonymized.Country != "CA" || anonymized.Region != "ON" || !anonymized.Premium || anonymized.JoinDate != user.JoinDate {
+		t.Errorf("Expected the analytics fields kept, got %+v", anonymized)
+	}
+	if anonymized.LoyaltyPoints != 120 || anonymized.Preferences != user.Preferences || anonymized.SchemaVersion != user.SchemaVersion || anonymized.Version != 4 {
+		t.Errorf("Expected the loyalty balance, preferences and versions kept, got %+v", anonymized)
+	}
+
+	before := AnalyzeUserBehavior([]User{user}, nil, AnalyticsFilter{})
+	after := AnalyzeUserBehavior([]User{anonymized}, nil, AnalyticsFilter{})
+	if before.AverageAge != after.AverageAge || before.PremiumPercentage != after.PremiumPercentage || before.TopCountries[0] != after.TopCountries[0] {
+		t.Errorf("Expected the same aggregates, got %+v and %+v", before, after)
+	}
+
+	order := AnonymizeOrder(Order{ID: 1, UserID: 7, Total: 50, ShippingAddress: user.Address})
+	if order.ShippingAddress.Street != "" || order.ShippingAddress.PostalCode != "" || order.ShippingAddress.Country != "CA" || order.ShippingAddress.Region != "ON" || order.Total != 50 {
+		t.Errorf("Expected only the shipping country and region kept, got %+v", order.ShippingAddress)
+	}
+	if user.Address.Street == "" {
+		t.Error("Expected the original address left alone")
+	}
+}
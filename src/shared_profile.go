@@ -0,0 +1,112 @@
+package main
+
+import "strings"
+
+// Shared profile completeness - a user's profile scores the weight of each
+// field that is filled in, half of it for a field that is filled in poorly
+// (a one-word name, an email with a likely typo, a phone that doesn't
+// validate). The frontend renders its progress widget from the same result
+// the server's analytics average.
+
+// ProfileGap is a profile field that is missing or weak, and what to do
+// about it.
+type ProfileGap struct {
+	Field   string `json:"field"`
+	Status  string `json:"status"` // "missing" or "weak"
+	Message string `json:"message"`
+	Weight  int    `json:"weight"`
+}
+
+// ProfileScore is how complete a user's profile is, from 0 to 100 percent,
+// with the gaps that hold it back, most valuable first.
+type ProfileScore struct {
+	Percent int          `json:"percent"`
+	Gaps    []ProfileGap `json:"gaps"`
+}
+
+// Complete reports whether the profile has no gaps.
+func (score ProfileScore) Complete() bool {
+	return len(score.Gaps) == 0
+}
+
+// profileFields are the fields a profile is scored on, with their weights
+// (summing to 100). check returns the gap status ("" when the field is
+// complete) and a message.
+var profileFields = []struct {
+	Field  string
+	Weight int
+	check  func(user User) (string, string)
+}{
+	{"email_verified", 20, func(user User) (string, string) {
+		if !user.EmailVerified {
+			return "missing", "Verify your email address"
+		}
+		return "", ""
+	}},
+	{"address", 20, func(user User) (string, string) {
+		if user.Address == nil {
+			return "missing", "Add a shipping address"
+		}
+		if !ValidateAddress(*user.Address).Valid {
+			return "weak", "Check your shipping address"
+		}
+		return "", ""
+	}},
+	{"name", 15, func(user User) (string, string) {
+		switch fields := strings.Fields(user.Name); {
+		case len(fields) == 0:
+			return "missing", "Add your name"
+		case len(fields) == 1:
+			return "weak", "Add your full name"
+		}
+		return "", ""
+	}},
+	{"email", 15, func(user User) (string, string) {
+		if strings.TrimSpace(user.Email) == "" {
+			return "missing", "Add an email address"
+		}
+		if result := ValidateEmail(user.Email); !result.Valid || len(result.Warnings) > 0 {
+			return "weak", "Check your email address"
+		}
+		return "", ""
+	}},
+	{"phone", 15, func(user User) (string, string) {
+		if user.Phone == "" {
+			return "missing", "Add a phone number"
+		}
+		if _, err := ValidatePhone(user.Phone, user.Country); err != nil {
+			return "weak", "Check your phone number"
+		}
+		return "", ""
+	}},
+	{"country", 10, func(user User) (string, string) {
+		if user.Country == "" {
+			return "missing", "Add your country"
+		}
+		return "", ""
+	}},
+	{"age", 5, func(user User) (string, string) {
+		if user.Age <= 0 {
+			return "missing", "Add your age"
+		}
+		return "", ""
+	}},
+}
+
+// ProfileCompleteness scores how complete a user's profile is.
+func ProfileCompleteness(user User) ProfileScore {
+	score := ProfileScore{Gaps: []ProfileGap{}}
+	for _, field := range profileFields {
+		status, message := field.check(user)
+		switch status {
+		case "":
+			score.Percent += field.Weight
+		case "weak":
+			score.Percent += field.Weight / 2
+		}
+		if status != "" {
+			score.Gaps = append(score.Gaps, ProfileGap{Field: field.Field, Status: status, Message: message, Weight: field.Weight})
+		}
+	}
+	return score
+}
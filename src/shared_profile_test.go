@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+// TestProfileCompleteness tests scoring filled-in, weak and missing fields
+func TestProfileCompleteness(t *testing.T) {
+	complete := User{
+		ID: 1, Email: "john.doe@example.com", EmailVerified: true, Name: "John Doe", Age: 28, Country: "US",
+		Phone:   "(415) 555-0132",
+		Address: &Address{Street: "1 Main St", City: "Austin", Region: "TX", PostalCode: "78701", Country: "US"},
+	}
+	if score := ProfileCompleteness(complete); score.Percent != 100 || !score.Complete() {
+		t.Errorf("Expected a complete profile, got %+v", score)
+	}
+
+	sparse := User{ID: 2, Email: "jane@gmial.com", Name: "Jane", Country: "CA", Phone: "12"}
+	score := ProfileCompleteness(sparse)
+	// Country 10 plus half of name, email and phone (7 each)
+	if score.Percent != 31 {
+		t.Errorf("Expected 31%%, got %+v", score)
+	}
+	want := map[string]string{"email_verified": "missing", "address": "missing", "name": "weak", "email": "weak", "phone": "weak", "age": "missing"}
+	if len(score.Gaps) != len(want) {
+		t.Fatalf("Expected %d gaps, got %+v", len(want), score.Gaps)
+	}
+	for _, gap := range score.Gaps {
+		if want[gap.Field] != gap.Status || gap.Message == "" {
+			t.Errorf("Unexpected gap %+v", gap)
+		}
+	}
+	if score.Gaps[0].Field != "email_verified" {
+		t.Errorf("Expected the most valuable gap first, got %+v", score.Gaps[0])
+	}
+
+	analytics := AnalyzeUserBehavior([]User{complete, sparse}, nil, AnalyticsFilter{})
+	if analytics.AverageProfileCompleteness != 65.5 || analytics.IncompleteProfiles != 1 {
+		t.Errorf("Expected analytics to average completeness, got %+v", analytics)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Shared promotions - campaigns such as "free gift over $150" or "free
+// shipping this weekend" that apply to every order meeting a threshold
+// while they run. CalculateOrderTotal evaluates the promotions in use and
+// lists those that applied in Order.Promotions. Administrators set the
+// promotions with PUT /api/promotions; pages pass GET /api/promotions to
+// setPromotionsWasm, so a preview prices with the same campaigns.
+
+// Kinds of promotion.
+const (
+	PromotionFreeGift     = "free_gift"
+	PromotionFreeShipping = "free_shipping"
+)
+
+// Promotion is a campaign that runs from StartDate to EndDate, both
+// taxDateLayout days and included; an empty date leaves that end open. It
+// applies to orders whose subtotal after discounts is at least MinSubtotal,
+// in the order's currency like the discount thresholds.
+type Promotion struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	MinSubtotal float64 `json:"min_subtotal,omitempty"`
+	StartDate   string  `json:"start_date,omitempty"`
+	EndDate     string  `json:"end_date,omitempty"`
+	// GiftProductID is the product a free_gift promotion adds, one unit per
+	// order
+	GiftProductID int `json:"gift_product_id,omitempty"`
+}
+
+// AppliedPromotion is a promotion an order qualified for. Saving is the
+// shipping a free_shipping promotion waived.
+type AppliedPromotion struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Kind          string  `json:"kind"`
+	GiftProductID int     `json:"gift_product_id,omitempty"`
+	Saving        float64 `json:"saving,omitempty"`
+}
+
+var (
+	promotionsMu sync.RWMutex
+	promotions   []Promotion
+)
+
+// ValidatePromotion checks a promotion's kind, threshold, dates and gift.
+func ValidatePromotion(promotion Promotion) ValidationResult {
+	result := newValidationResult()
+
+	if promotion.ID == "" {
+		result.AddError("id", CodeRequired, "Promotion ID is required")
+	}
+	if promotion.Name == "" {
+		result.AddError("name", CodeRequired, "Promotion name is required")
+	}
+	switch promotion.Kind {
+	case PromotionFreeShipping:
+	case PromotionFreeGift:
+		if promotion.GiftProductID <= 0 {
+			result.AddError("gift_product_id", CodeRequired, "Free gift promotions need a gift product")
+		}
+	default:
+		result.AddError("kind", CodeUnknownValue, fmt.Sprintf("Kind must be %s or %s", PromotionFreeGift, PromotionFreeShipping))
+	}
+	if promotion.MinSubtotal < 0 {
+		result.AddError("min_subtotal", CodeOutOfRange, "Minimum subtotal must not be negative")
+	}
+	if _, err := time.Parse(taxDateLayout, promotion.StartDate); promotion.StartDate != "" && err != nil {
+		result.AddError("start_date", CodeInvalidFormat, "Start date must be a YYYY-MM-DD date")
+	}
+	if _, err := time.Parse(taxDateLayout, promotion.EndDate); promotion.EndDate != "" && err != nil {
+		result.AddError("end_date", CodeInvalidFormat, "End date must be a YYYY-MM-DD date")
+	}
+	if promotion.StartDate != "" && promotion.EndDate != "" && promotion.EndDate < promotion.StartDate {
+		result.AddError("end_date", CodeInconsistent, "End date must not be before the start date")
+	}
+
+	return result
+}
+
+// ParsePromotions reads a list of promotions from JSON, checking each and
+// that their IDs are unique.
+func ParsePromotions(data []byte) ([]Promotion, error) {
+	var list []Promotion
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid promotions JSON: %w", err)
+	}
+	if err := CheckPromotions(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CheckPromotions validates a list of promotions, reporting the first
+// problem.
+func CheckPromotions(list []Promotion) error {
+	seen := map[string]bool{}
+	for i, promotion := range list {
+		if result := ValidatePromotion(promotion); !result.Valid {
+			return fmt.Errorf("promotion %d: %s", i, result.Errors[0])
+		}
+		if seen[promotion.ID] {
+			return fmt.Errorf("promotion %d: duplicate ID %q", i, promotion.ID)
+		}
+		seen[promotion.ID] = true
+	}
+	return nil
+}
+
+// SetPromotions replaces the promotions CalculateOrderTotal evaluates.
+func SetPromotions(list []Promotion) {
+	promotionsMu.Lock()
+	promotions = slices.Clone(list)
+	promotionsMu.Unlock()
+}
+
+// CurrentPromotions returns a copy of the promotions in use.
+func CurrentPromotions() []Promotion {
+	promotionsMu.RLock()
+	defer promotionsMu.RUnlock()
+	return append([]Promotion{}, promotions...)
+}
+
+// Active reports whether a promotion runs on a date.
+func (promotion Promotion) Active(date time.Time) bool {
+	day := date.Format(taxDateLayout)
+	return (promotion.StartDate == "" || promotion.StartDate <= day) && (promotion.EndDate == "" || day <= promotion.EndDate)
+}
+
+// applyPromotions lists the promotions in use that an order qualifies for on
+// a date with a subtotal after discounts. A free_shipping promotion waives
+// shipping, so its Saving is the shipping charged before.
+func applyPromotions(date time.Time, subtotal Money, shipping Money) []AppliedPromotion {
+	var applied []AppliedPromotion
+	for _, promotion := range CurrentPromotions() {
+		if !promotion.Active(date) || subtotal.Minor < NewMoney(promotion.MinSubtotal, subtotal.Currency).Minor {
+			continue
+		}
+		entry := AppliedPromotion{ID: promotion.ID, Name: promotion.Name, Kind: promotion.Kind}
+		switch promotion.Kind {
+		case PromotionFreeGift:
+			entry.GiftProductID = promotion.GiftProductID
+		case PromotionFreeShipping:
+			if shipping.Minor <= 0 {
+				continue
+			}
+			entry.Saving = shipping.Float64()
+			shipping = Money{Currency: shipping.Currency}
+		}
+		applied = append(applied, entry)
+	}
+	return applied
+}
+
+// FreeShipping reports whether an order's promotions waived its shipping.
+func FreeShipping(applied []AppliedPromotion) bool {
+	return slices.ContainsFunc(applied, func(promotion AppliedPromotion) bool { return promotion.Kind == PromotionFreeShipping })
+}
+
+// GiftItems are the products an order's promotions add, one unit each.
+func GiftItems(order Order) []CartItem {
+	var items []CartItem
+	for _, promotion := range order.Promotions {
+		if promotion.GiftProductID > 0 {
+			items = append(items, CartItem{ProductID: promotion.GiftProductID, Quantity: 1})
+		}
+	}
+	return items
+}
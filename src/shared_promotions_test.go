@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// withPromotions replaces the promotions for the test.
+func withPromotions(t *testing.T, list ...Promotion) {
+	t.Helper()
+	previous := CurrentPromotions()
+	SetPromotions(list)
+	t.Cleanup(func() { SetPromotions(previous) })
+}
+
+// TestPromotionsApply tests free gift and free shipping thresholds and date
+// ranges
+func TestPromotionsApply(t *testing.T) {
+	withPromotions(t,
+		Promotion{ID: "gift-150", Name: "Free tote over 150", Kind: PromotionFreeGift, MinSubtotal: 150, GiftProductID: 4},
+		Promotion{ID: "weekend", Name: "Free shipping weekend", Kind: PromotionFreeShipping, StartDate: "2026-10-17", EndDate: "2026-10-18"},
+	)
+	order := func(price float64, date string) Order {
+		order := Order{Products: []Product{{ID: 1, Name: "Desk", Price: price, Category: "home"}}, Quantities: []int{1}, OrderDate: date}
+		CalculateOrderTotal(&order, User{Country: "US"})
+		return order
+	}
+
+	weekday := order(40, "2026-10-14")
+	if len(weekday.Promotions) != 0 || weekday.Shipping == 0 {
+		t.Errorf("Expected no promotion on a weekday under 150, got %+v", weekday.Promotions)
+	}
+	weekend := order(40, "2026-10-17")
+	if len(weekend.Promotions) != 1 || weekend.Shipping != 0 || weekend.Promotions[0].Saving != weekday.Shipping {
+		t.Errorf("Expected the weekend to waive %v shipping, got %v and %+v", weekday.Shipping, weekend.Shipping, weekend.Promotions)
+	}
+	if weekend.Total != RoundToCurrency(weekday.Total-weekday.Shipping, "USD") {
+		t.Errorf("Expected the total to drop by the shipping, got %v", weekend.Total)
+	}
+	if after := order(40, "2026-10-19"); len(after.Promotions) != 0 {
+		t.Errorf("Expected the weekend promotion to have ended, got %+v", after.Promotions)
+	}
+
+	gift := order(160, "2026-10-14")
+	if len(gift.Promotions) != 1 || gift.Promotions[0].GiftProductID != 4 {
+		t.Fatalf("Expected a free gift over 150, got %+v", gift.Promotions)
+	}
+	if items := GiftItems(gift); len(items) != 1 || items[0] != (CartItem{ProductID: 4, Quantity: 1}) {
+		t.Errorf("Expected one gift item, got %+v", items)
+	}
+}
+
+// TestValidatePromotion tests promotion kinds, gifts and dates
+func TestValidatePromotion(t *testing.T) {
+	valid := Promotion{ID: "weekend", Name: "Weekend", Kind: PromotionFreeShipping, StartDate: "2026-10-17", EndDate: "2026-10-18"}
+	if result := ValidatePromotion(valid); !result.Valid {
+		t.Errorf("Expected a valid promotion, got %v", result.Errors)
+	}
+	for name, promotion := range map[string]Promotion{
+		"unknown kind":     {ID: "a", Name: "A", Kind: "half_price"},
+		"gift without one": {ID: "a", Name: "A", Kind: PromotionFreeGift},
+		"ends first":       {ID: "a", Name: "A", Kind: PromotionFreeShipping, StartDate: "2026-10-18", EndDate: "2026-10-17"},
+		"bad date":         {ID: "a", Name: "A", Kind: PromotionFreeShipping, EndDate: "18/10/2026"},
+	} {
+		if ValidatePromotion(promotion).Valid {
+			t.Errorf("%s: expected the promotion to be invalid", name)
+		}
+	}
+	if _, err := ParsePromotions([]byte(`[{"id": "a", "name": "A", "kind": "free_shipping"}, {"id": "a", "name": "B", "kind": "free_shipping"}]`)); err == nil {
+		t.Error("Expected duplicate IDs to be rejected")
+	}
+}
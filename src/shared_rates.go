@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Shared exchange rates - ConvertPrice converts between the currencies of the
+// currency table through a rate table. The server loads its table from a
+// file or a provider endpoint and serves it at /api/rates; pages pass that
+// table to the WebAssembly module, so both sides convert with the same
+// rates and the same rounding. Until a table is loaded the built-in demo
+// rates are used.
+
+// ExchangeRates is a rate table in the layout most rate providers use: the
+// units of each currency that one unit of Base buys.
+type ExchangeRates struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date,omitempty"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// defaultExchangeRates are fixed demo rates against USD.
+var defaultExchangeRates = ExchangeRates{
+	Base: "USD",
+	Rates: map[string]float64{
+		"USD": 1,
+		"CAD": 1.37,
+		"GBP": 0.79,
+		"EUR": 0.92,
+		"JPY": 151.5,
+		"AUD": 1.52,
+		"INR": 83.4,
+		"BRL": 5.05,
+		"MXN": 17.1,
+	},
+}
+
+var (
+	exchangeRatesMu sync.RWMutex
+	exchangeRates   = defaultExchangeRates
+)
+
+// ParseExchangeRates reads a rate table from JSON. Rates for currencies
+// missing from the currency table are dropped, since nothing is priced in
+// them; every supported currency must have a rate.
+func ParseExchangeRates(data []byte) (ExchangeRates, error) {
+	var raw ExchangeRates
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ExchangeRates{}, fmt.Errorf("invalid rates JSON: %w", err)
+	}
+
+	base, ok := LookupCurrency(raw.Base)
+	if !ok || strings.TrimSpace(raw.Base) == "" {
+		return ExchangeRates{}, fmt.Errorf("unsupported base currency %q", raw.Base)
+	}
+	rates := ExchangeRates{Base: base.Code, Date: raw.Date, Rates: map[string]float64{base.Code: 1}}
+	for code, rate := range raw.Rates {
+		currency, ok := LookupCurrency(code)
+		if !ok || currency.Code == base.Code {
+			continue
+		}
+		if rate <= 0 {
+			return ExchangeRates{}, fmt.Errorf("rate for %s must be positive", currency.Code)
+		}
+		rates.Rates[currency.Code] = rate
+	}
+	for code := range currencies {
+		if _, ok := rates.Rates[code]; !ok {
+			return ExchangeRates{}, fmt.Errorf("missing rate for %s", code)
+		}
+	}
+	return rates, nil
+}
+
+// SetExchangeRates replaces the rate table used by ConvertPrice.
+func SetExchangeRates(rates ExchangeRates) {
+	rates.Rates = maps.Clone(rates.Rates)
+	exchangeRatesMu.Lock()
+	exchangeRates = rates
+	exchangeRatesMu.Unlock()
+}
+
+// CurrentExchangeRates returns a copy of the rate table in use.
+func CurrentExchangeRates() ExchangeRates {
+	exchangeRatesMu.RLock()
+	defer exchangeRatesMu.RUnlock()
+	rates := exchangeRates
+	rates.Rates = maps.Clone(rates.Rates)
+	return rates
+}
+
+// ConvertPrice converts an amount between two currencies, rounding the
+// result to the minor units of the target currency.
+func ConvertPrice(amount float64, from, to string) (float64, error) {
+	source, ok := LookupCurrency(from)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency %q", from)
+	}
+	target, ok := LookupCurrency(to)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency %q", to)
+	}
+	if source.Code == target.Code {
+		return RoundToCurrency(amount, target.Code), nil
+	}
+
+	exchangeRatesMu.RLock()
+	fromRate, toRate := exchangeRates.Rates[source.Code], exchangeRates.Rates[target.Code]
+	exchangeRatesMu.RUnlock()
+	if fromRate <= 0 || toRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", source.Code, target.Code)
+	}
+	return RoundToCurrency(amount/fromRate*toRate, target.Code), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// withExchangeRates restores the rate table after the test.
+func withExchangeRates(t *testing.T) {
+	t.Helper()
+	previous := CurrentExchangeRates()
+	t.Cleanup(func() { SetExchangeRates(previous) })
+}
+
+// TestConvertPrice tests conversion through the base currency
+func TestConvertPrice(t *testing.T) {
+	withExchangeRates(t)
+	SetExchangeRates(defaultExchangeRates)
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+	}{
+		{100, "USD", "EUR", 92},
+		{92, "eur", "usd", 100},
+		{10, "GBP", "JPY", 1918}, // 10 / 0.79 * 151.5, in whole yen
+		{19.999, "USD", "", 20},
+	}
+	for _, tt := range tests {
+		got, err := ConvertPrice(tt.amount, tt.from, tt.to)
+		if err != nil || got != tt.want {
+			t.Errorf("ConvertPrice(%v, %q, %q) = %v, %v; want %v", tt.amount, tt.from, tt.to, got, err, tt.want)
+		}
+	}
+	if _, err := ConvertPrice(1, "USD", "XYZ"); err == nil {
+		t.Error("Expected an unsupported currency to be rejected")
+	}
+}
+
+// TestParseExchangeRates tests reading provider rate tables
+func TestParseExchangeRates(t *testing.T) {
+	withExchangeRates(t)
+
+	rates, err := ParseExchangeRates([]byte(`{"base": "eur", "date": "2026-10-01", "rates": {
+		"USD": 1.1, "CAD": 1.5, "GBP": 0.86, "JPY": 165, "AUD": 1.65, "INR": 91, "BRL": 5.5, "MXN": 18.6, "CHF": 0.95}}`))
+	if err != nil {
+		t.Fatalf("ParseExchangeRates() error = %v", err)
+	}
+	if rates.Base != "EUR" || rates.Rates["EUR"] != 1 {
+		t.Errorf("Expected the base to be normalized with rate 1, got %+v", rates)
+	}
+	if _, ok := rates.Rates["CHF"]; ok {
+		t.Error("Expected rates for unsupported currencies to be dropped")
+	}
+
+	SetExchangeRates(rates)
+	if got, _ := ConvertPrice(110, "USD", "EUR"); got != 100 {
+		t.Errorf("Expected conversion with the installed table, got %v", got)
+	}
+
+	for _, bad := range []string{
+		`{"base": "XYZ", "rates": {}}`,
+		`{"base": "USD", "rates": {"EUR": 0.9}}`,
+		`{"base": "USD", "rates": {"EUR": -1, "CAD": 1, "GBP": 1, "JPY": 1, "AUD": 1, "INR": 1, "BRL": 1, "MXN": 1}}`,
+		`not json`,
+	} {
+		if _, err := ParseExchangeRates([]byte(bad)); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+}
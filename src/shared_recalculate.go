@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// Shared order recalculation - prices stored orders again with
+// CalculateOrderTotal, as after a change to the tax tables, the promotions
+// or the coupons, and reports the orders whose amounts would change. The
+// orders themselves are left as they are. Each order is priced as it was
+// placed: for the user who placed it, with the loyalty points and gift card
+// amount it redeemed then, so a recalculation never takes more of either.
+// Orders are split among a bounded number of goroutines; in the browser they
+// run one after another.
+
+// OrderAmounts are the amounts of a priced order.
+type OrderAmounts struct {
+	Subtotal  float64 `json:"subtotal"`
+	Discount  float64 `json:"discount"`
+	Tax       float64 `json:"tax"`
+	Shipping  float64 `json:"shipping"`
+	Total     float64 `json:"total"`
+	AmountDue float64 `json:"amount_due"`
+}
+
+func orderAmounts(order Order) OrderAmounts {
+	return OrderAmounts{
+		Subtotal: order.Subtotal, Discount: order.Discount, Tax: order.Tax,
+		Shipping: order.Shipping, Total: order.Total, AmountDue: order.AmountDue,
+	}
+}
+
+// OrderRecalculation is an order whose amounts changed when it was priced
+// again. Difference is the change of its total.
+type OrderRecalculation struct {
+	OrderID    int          `json:"order_id"`
+	UserID     int          `json:"user_id"`
+	Status     string       `json:"status"`
+	Currency   string       `json:"currency"`
+	Before     OrderAmounts `json:"before"`
+	After      OrderAmounts `json:"after"`
+	Difference float64      `json:"difference"`
+}
+
+// RecalculationReport lists the orders whose amounts changed, the largest
+// change of total first. Differences is the change of all totals, by
+// currency.
+type RecalculationReport struct {
+	Orders      int                  `json:"orders"`
+	Changed     int                  `json:"changed"`
+	Differences map[string]float64   `json:"differences"`
+	Changes     []OrderRecalculation `json:"changes"`
+}
+
+// RecalculateOrders prices orders again for their users from up to workers
+// goroutines at once (at least one).
+func RecalculateOrders(orders []Order, users []User, workers int) RecalculationReport {
+	byID := make(map[int]User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	changes := make([]*OrderRecalculation, len(orders))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(workers, len(orders))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				changes[i] = recalculateOrder(orders[i], byID)
+			}
+		}()
+	}
+	for i := range orders {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := RecalculationReport{Orders: len(orders), Differences: map[string]float64{}, Changes: []OrderRecalculation{}}
+	for _, change := range changes {
+		if change == nil {
+			continue
+		}
+		report.Changes = append(report.Changes, *change)
+		report.Differences[change.Currency] = RoundToCurrency(report.Differences[change.Currency]+change.Difference, change.Currency)
+	}
+	report.Changed = len(report.Changes)
+	slices.SortStableFunc(report.Changes, func(a, b OrderRecalculation) int {
+		if d := math.Abs(b.Difference) - math.Abs(a.Difference); d != 0 {
+			return int(math.Copysign(1, d))
+		}
+		return a.OrderID - b.OrderID
+	})
+	return report
+}
+
+// recalculateOrder prices an order again, returning nil when its amounts
+// are unchanged.
+func recalculateOrder(order Order, users map[int]User) *OrderRecalculation {
+	user, ok := users[order.UserID]
+	if !ok {
+		user = User{ID: order.UserID}
+	}
+	// What was redeemed then is all there is to redeem now
+	user.LoyaltyPoints = LoyaltyPointsRedeemed(order)
+	priced := order
+	priced.GiftCard = nil
+	if order.GiftCardAmount > 0 {
+		priced.GiftCard = &GiftCard{Code: order.GiftCardCode, Balance: order.GiftCardAmount, Currency: OrderCurrency(order)}
+	}
+	// Pricing sets the shipments' charges, and copies handed out share them
+	priced.Shipments = slices.Clone(order.Shipments)
+	CalculateOrderTotal(&priced, user)
+
+	before, after := orderAmounts(order), orderAmounts(priced)
+	currency := priced.Currency
+	if sameAmounts(before, after, currency) {
+		return nil
+	}
+	return &OrderRecalculation{
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     order.Status,
+		Currency:   currency,
+		Before:     before,
+		After:      after,
+		Difference: RoundToCurrency(after.Total-before.Total, currency),
+	}
+}
+
+// sameAmounts compares amounts in the currency's minor units.
+func sameAmounts(a, b OrderAmounts, currency string) bool {
+	same := func(x, y float64) bool { return NewMoney(x, currency).Minor == NewMoney(y, currency).Minor }
+	return same(a.Subtotal, b.Subtotal) && same(a.Discount, b.Discount) && same(a.Tax, b.Tax) &&
+		same(a.Shipping, b.Shipping) && same(a.Total, b.Total) && same(a.AmountDue, b.AmountDue)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRecalculateOrders(t *testing.T) {
+	user := User{ID: 1, Name: "Ann Lee", Country: "US", LoyaltyPoints: 1000}
+	var orders []Order
+	for i := 1; i <= 20; i++ {
+		order := Order{ID: i, UserID: 1, Products: []Product{{ID: 1, Name: "Lamp", Price: 40 + float64(i), Category: "home"}}, Quantities: []int{2}, Status: "pending"}
+		switch i {
+		case 3:
+			order.LoyaltyPoints = 500
+		case 4:
+			order.GiftCard = &GiftCard{Code: "GIFT-4", Balance: 20, Currency: "USD"}
+		}
+		CalculateOrderTotal(&order, user)
+		order.GiftCard = nil
+		orders = append(orders, order)
+	}
+	if orders[2].Discount == 0 || orders[3].GiftCardAmount != 20 {
+		t.Fatalf("Expected the points and the gift card redeemed, got %+v and %+v", orders[2], orders[3])
+	}
+
+	// The points are spent and the card is gone, but the orders are priced
+	// with what they redeemed
+	spent := user
+	spent.LoyaltyPoints = 0
+	report := RecalculateOrders(orders, []User{spent}, 4)
+	if report.Orders != 20 || report.Changed != 0 || len(report.Changes) != 0 {
+		t.Fatalf("Expected no changes to freshly priced orders, got %+v", report)
+	}
+
+	orders[6].Total += 5
+	orders[6].AmountDue += 5
+	orders[11].Tax -= 1.5
+	orders[11].Total -= 1.5
+	orders[11].AmountDue -= 1.5
+	before := orders[6].Total
+	report = RecalculateOrders(orders, []User{spent}, 4)
+	if report.Changed != 2 || len(report.Changes) != 2 {
+		t.Fatalf("Expected two changed orders, got %+v", report)
+	}
+	first, second := report.Changes[0], report.Changes[1]
+	if first.OrderID != 7 || first.Difference != -5 || first.Before.Total != before || first.After.Total != RoundToCurrency(before-5, "USD") {
+		t.Errorf("Expected the largest change, order 7, first, got %+v", first)
+	}
+	if second.OrderID != 12 || second.Difference != 1.5 || second.Currency != "USD" {
+		t.Errorf("Expected order 12 to gain 1.50, got %+v", second)
+	}
+	if report.Differences["USD"] != -3.5 {
+		t.Errorf("Expected the totals to change by -3.50, got %v", report.Differences)
+	}
+	if orders[6].Total != before {
+		t.Error("Expected the orders to be left as they are")
+	}
+
+	// One worker gives the same report, as do more workers than orders
+	for _, workers := range []int{0, 1, 100} {
+		if again := RecalculateOrders(orders, []User{spent}, workers); again.Changed != 2 || again.Changes[0].OrderID != 7 {
+			t.Errorf("Expected the same report from %d workers, got %+v", workers, again)
+		}
+	}
+	if empty := RecalculateOrders(nil, nil, 4); empty.Orders != 0 || empty.Changes == nil {
+		t.Errorf("Expected an empty report, got %+v", empty)
+	}
+}
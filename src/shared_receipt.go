@@ -0,0 +1,82 @@
+package main
+
+import "fmt"
+
+// Shared receipts - the two emails a customer gets about an order: the
+// order confirmation when it is placed, and the receipt once it is paid.
+// GenerateReceipt builds either from the order's invoice lines and totals
+// for a locale, and the embedded templates (shared_receipt_render.go)
+// render it as HTML and plain text with the locale's labels, amounts and
+// dates. The server's /api/orders/{id}/receipt endpoint and
+// renderReceiptWasm render from the same templates.
+
+// Receipt kinds
+const (
+	ReceiptConfirmation = "confirmation"
+	ReceiptPayment      = "receipt"
+)
+
+// Receipt is an order confirmation or receipt. Amounts are in Currency and,
+// like the order's, tax-inclusive when IncludesTax is set. AmountDue is
+// still to pay on a confirmation and what was paid besides the gift card on
+// a receipt.
+type Receipt struct {
+	Kind              string        `json:"kind"`
+	Number            string        `json:"number"`
+	OrderID           int           `json:"order_id"`
+	OrderDate         string        `json:"order_date"`
+	Locale            string        `json:"locale"`
+	Currency          string        `json:"currency"`
+	IncludesTax       bool          `json:"includes_tax,omitempty"`
+	Customer          InvoiceParty  `json:"customer"`
+	ShipTo            *Address      `json:"ship_to,omitempty"`
+	EstimatedDelivery string        `json:"estimated_delivery,omitempty"`
+	Lines             []InvoiceLine `json:"lines"`
+	Subtotal          float64       `json:"subtotal"`
+	Discount          float64       `json:"discount"`
+	Shipping          float64       `json:"shipping"`
+	Tax               float64       `json:"tax"`
+	Total             float64       `json:"total"`
+	GiftCardAmount    float64       `json:"gift_card_amount,omitempty"`
+	AmountDue         float64       `json:"amount_due"`
+}
+
+// GenerateReceipt builds the confirmation or receipt of an order for the
+// user who placed it, in locale. An empty kind is a receipt; an empty
+// locale is the user's country's, and unknown locales are DefaultLocale.
+func GenerateReceipt(order Order, user User, kind, locale string) (Receipt, error) {
+	prefix := "RCT"
+	switch kind {
+	case "", ReceiptPayment:
+		kind = ReceiptPayment
+	case ReceiptConfirmation:
+		prefix = "ORD"
+	default:
+		return Receipt{}, fmt.Errorf("unknown receipt kind %q (use confirmation or receipt)", kind)
+	}
+	if locale == "" {
+		locale = CountryLocale(user.Country)
+	}
+
+	invoice := GenerateInvoice(order, user)
+	return Receipt{
+		Kind:              kind,
+		Number:            fmt.Sprintf("%s-%06d", prefix, order.ID),
+		OrderID:           order.ID,
+		OrderDate:         invoice.IssueDate,
+		Locale:            localeFor(locale).Tag,
+		Currency:          invoice.Currency,
+		IncludesTax:       invoice.IncludesTax,
+		Customer:          invoice.BillTo,
+		ShipTo:            invoice.ShipTo,
+		EstimatedDelivery: order.EstimatedDelivery,
+		Lines:             invoice.Lines,
+		Subtotal:          invoice.Subtotal,
+		Discount:          invoice.Discount,
+		Shipping:          invoice.Shipping,
+		Tax:               invoice.Tax,
+		Total:             invoice.Total,
+		GiftCardAmount:    invoice.GiftCardAmount,
+		AmountDue:         invoice.AmountDue,
+	}, nil
+}
@@ -0,0 +1,222 @@
+//go:build !tinygo && !lite
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed receipt.html.tmpl
+var receiptHTMLSource string
+
+//go:embed receipt.txt.tmpl
+var receiptTextSource string
+
+var (
+	receiptHTMLTemplate = htmltemplate.Must(htmltemplate.New("receipt").Parse(receiptHTMLSource))
+	receiptTextTemplate = texttemplate.Must(texttemplate.New("receipt").Parse(receiptTextSource))
+)
+
+// receiptMessages are the receipt templates' labels by language. Languages
+// missing a label fall back to English; the intros take the customer's name.
+var receiptMessages = map[string]map[string]string{
+	"en": {
+		"confirmation_title": "Order confirmation",
+		"confirmation_intro": "Thank you for your order, %s. We'll let you know when it ships.",
+		"receipt_title":      "Receipt",
+		"receipt_intro":      "Thank you, %s. We've received your payment.",
+		"order":              "Order",
+		"order_date":         "Order date",
+		"ship_to":            "Ship to",
+		"delivery":           "Estimated delivery",
+		"item":               "Item",
+		"qty":                "Qty",
+		"amount":             "Amount",
+		"subtotal":           "Subtotal",
+		"discount":           "Discount",
+		"shipping":           "Shipping",
+		"tax":                "Tax",
+		"total":              "Total",
+		"tax_included":       "tax included",
+		"gift_card":          "Paid by gift card",
+		"amount_due":         "Amount due",
+		"amount_paid":        "Amount paid",
+	},
+	"fr": {
+		"confirmation_title": "Confirmation de commande",
+		"confirmation_intro": "Merci pour votre commande, %s. Nous vous préviendrons de son expédition.",
+		"receipt_title":      "Reçu",
+		"receipt_intro":      "Merci, %s. Nous avons bien reçu votre paiement.",
+		"order":              "Commande",
+		"order_date":         "Date de commande",
+		"ship_to":            "Livrer à",
+		"delivery":           "Livraison estimée",
+		"item":               "Article",
+		"qty":                "Qté",
+		"amount":             "Montant",
+		"subtotal":           "Sous-total",
+		"discount":           "Remise",
+		"shipping":           "Livraison",
+		"tax":                "Taxes",
+		"total":              "Total",
+		"tax_included":       "taxes comprises",
+		"gift_card":          "Payé par carte cadeau",
+		"amount_due":         "Montant dû",
+		"amount_paid":        "Montant payé",
+	},
+	"de": {
+		"confirmation_title": "Bestellbestätigung",
+		"confirmation_intro": "Vielen Dank für Ihre Bestellung, %s. Wir benachrichtigen Sie, sobald sie versandt wird.",
+		"receipt_title":      "Quittung",
+		"receipt_intro":      "Vielen Dank, %s. Wir haben Ihre Zahlung erhalten.",
+		"order":              "Bestellung",
+		"order_date":         "Bestelldatum",
+		"ship_to":            "Lieferadresse",
+		"delivery":           "Voraussichtliche Lieferung",
+		"item":               "Artikel",
+		"qty":                "Menge",
+		"amount":             "Betrag",
+		"subtotal":           "Zwischensumme",
+		"discount":           "Rabatt",
+		"shipping":           "Versand",
+		"tax":                "Steuer",
+		"total":              "Gesamt",
+		"tax_included":       "inkl. Steuer",
+		"gift_card":          "Mit Geschenkkarte bezahlt",
+		"amount_due":         "Offener Betrag",
+		"amount_paid":        "Bezahlter Betrag",
+	},
+	"es": {
+		"confirmation_title": "Confirmación de pedido",
+		"confirmation_intro": "Gracias por tu pedido, %s. Te avisaremos cuando se envíe.",
+		"receipt_title":      "Recibo",
+		"receipt_intro":      "Gracias, %s. Hemos recibido tu pago.",
+		"order":              "Pedido",
+		"order_date":         "Fecha del pedido",
+		"ship_to":            "Enviar a",
+		"delivery":           "Entrega estimada",
+		"item":               "Artículo",
+		"qty":                "Cant.",
+		"amount":             "Importe",
+		"subtotal":           "Subtotal",
+		"discount":           "Descuento",
+		"shipping":           "Envío",
+		"tax":                "Impuestos",
+		"total":              "Total",
+		"tax_included":       "impuestos incluidos",
+		"gift_card":          "Pagado con tarjeta de regalo",
+		"amount_due":         "Importe pendiente",
+		"amount_paid":        "Importe pagado",
+	},
+	"pt": {
+		"confirmation_title": "Confirmação do pedido",
+		"confirmation_intro": "Obrigado pelo seu pedido, %s. Avisaremos quando ele for enviado.",
+		"receipt_title":      "Recibo",
+		"receipt_intro":      "Obrigado, %s. Recebemos o seu pagamento.",
+		"order":              "Pedido",
+		"order_date":         "Data do pedido",
+		"ship_to":            "Entregar em",
+		"delivery":           "Entrega prevista",
+		"item":               "Item",
+		"qty":                "Qtd.",
+		"amount":             "Valor",
+		"subtotal":           "Subtotal",
+		"discount":           "Desconto",
+		"shipping":           "Frete",
+		"tax":                "Impostos",
+		"total":              "Total",
+		"tax_included":       "impostos incluídos",
+		"gift_card":          "Pago com cartão-presente",
+		"amount_due":         "Valor devido",
+		"amount_paid":        "Valor pago",
+	},
+	"ja": {
+		"confirmation_title": "ご注文確認",
+		"confirmation_intro": "%s様、ご注文ありがとうございます。発送時にお知らせします。",
+		"receipt_title":      "領収書",
+		"receipt_intro":      "%s様、お支払いを確認しました。ありがとうございます。",
+		"order":              "注文",
+		"order_date":         "注文日",
+		"ship_to":            "お届け先",
+		"delivery":           "お届け予定日",
+		"item":               "商品",
+		"qty":                "数量",
+		"amount":             "金額",
+		"subtotal":           "小計",
+		"discount":           "割引",
+		"shipping":           "送料",
+		"tax":                "税",
+		"total":              "合計",
+		"tax_included":       "税込",
+		"gift_card":          "ギフトカード支払い",
+		"amount_due":         "お支払い金額",
+		"amount_paid":        "支払済み金額",
+	},
+}
+
+// receiptView is what the receipt templates execute on: the receipt, with
+// its locale's labels and formatting as methods.
+type receiptView struct {
+	Receipt
+	messages map[string]string
+}
+
+func newReceiptView(receipt Receipt) receiptView {
+	language, _, _ := strings.Cut(receipt.Locale, "-")
+	return receiptView{Receipt: receipt, messages: receiptMessages[language]}
+}
+
+// T is the label for key, formatted with args when given.
+func (v receiptView) T(key string, args ...interface{}) string {
+	message, ok := v.messages[key]
+	if !ok {
+		message = receiptMessages["en"][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Title is the heading of the receipt's kind.
+func (v receiptView) Title() string {
+	return v.T(v.Kind + "_title")
+}
+
+// Intro is the opening line of the receipt's kind.
+func (v receiptView) Intro() string {
+	return v.T(v.Kind+"_intro", v.Customer.Name)
+}
+
+// Money formats an amount in the receipt's currency and locale.
+func (v receiptView) Money(amount float64) string {
+	return FormatMoney(amount, v.Currency, v.Locale)
+}
+
+// Date formats a YYYY-MM-DD date in the receipt's locale.
+func (v receiptView) Date(date string) string {
+	return FormatDate(date, v.Locale)
+}
+
+// RenderReceiptHTML renders a confirmation or receipt as an HTML email.
+func RenderReceiptHTML(receipt Receipt) (string, error) {
+	var out bytes.Buffer
+	if err := receiptHTMLTemplate.Execute(&out, newReceiptView(receipt)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// RenderReceiptText renders a confirmation or receipt as a plain-text email.
+func RenderReceiptText(receipt Receipt) (string, error) {
+	var out bytes.Buffer
+	if err := receiptTextTemplate.Execute(&out, newReceiptView(receipt)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
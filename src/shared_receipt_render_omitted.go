@@ -0,0 +1,19 @@
+//go:build tinygo || lite
+
+package main
+
+import "errors"
+
+// errReceiptRendering is returned where the invoice templates are left out
+// too (shared_invoice_render_omitted.go); receipts render on the server.
+var errReceiptRendering = errors.New("receipt rendering is not supported in this build - render receipts on the server")
+
+// RenderReceiptHTML is not available in the TinyGo and lite builds.
+func RenderReceiptHTML(receipt Receipt) (string, error) {
+	return "", errReceiptRendering
+}
+
+// RenderReceiptText is not available in the TinyGo and lite builds.
+func RenderReceiptText(receipt Receipt) (string, error) {
+	return "", errReceiptRendering
+}
@@ -0,0 +1,63 @@
+//go:build !tinygo && !lite
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRenderReceipt tests that both renderings use the locale's labels,
+// amounts and dates
+func TestRenderReceipt(t *testing.T) {
+	user := User{ID: 3, Name: "Anna <Admin>", Email: "anna@example.com", Country: "DE"}
+	order := Order{ID: 42, OrderDate: "2024-03-01", Products: []Product{{ID: 1, Name: "Laptop", Price: 1200, Category: "electronics"}}, Quantities: []int{1}}
+	CalculateOrderTotal(&order, user)
+
+	receipt, err := GenerateReceipt(order, user, ReceiptConfirmation, "de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := RenderReceiptHTML(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<html lang="de-DE">`, "Bestellbestätigung ORD-000042", "Anna &lt;Admin&gt;", "01.03.2024", FormatMoney(order.Total, order.Currency, "de-DE"), "Offener Betrag"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected the HTML confirmation to contain %q:\n%s", want, html)
+		}
+	}
+
+	receipt.Kind, receipt.Locale = ReceiptPayment, "en-US"
+	text, err := RenderReceiptText(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Receipt ORD-000042", "Thank you, Anna <Admin>.", "03/01/2024", "Laptop", FormatMoney(order.Total, order.Currency, "en-US"), "Amount paid"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the text receipt to contain %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Discount") || strings.Contains(text, "Amount due") {
+		t.Errorf("Expected no discount or amount due line:\n%s", text)
+	}
+}
+
+// TestReceiptMessages tests that every language labels everything English
+// does
+func TestReceiptMessages(t *testing.T) {
+	keys := slices.Sorted(maps.Keys(receiptMessages["en"]))
+	for language, messages := range receiptMessages {
+		if got := slices.Sorted(maps.Keys(messages)); !slices.Equal(got, keys) {
+			t.Errorf("%s labels %v, want %v", language, got, keys)
+		}
+	}
+	for _, tag := range Locales() {
+		language, _, _ := strings.Cut(tag, "-")
+		if _, ok := receiptMessages[language]; !ok {
+			t.Errorf("No receipt labels for %s", tag)
+		}
+	}
+}
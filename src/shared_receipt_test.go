@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+// TestGenerateReceipt tests the kinds and locale of a receipt
+func TestGenerateReceipt(t *testing.T) {
+	user := User{ID: 3, Name: "Anna", Email: "anna@example.com", Country: "DE",
+		Address: &Address{Street: "Hauptstr. 1", City: "Berlin", PostalCode: "10115", Country: "DE"}}
+	order := Order{ID: 42, UserID: 3, OrderDate: "2024-03-01", Products: []Product{{ID: 1, Name: "Laptop", Price: 100, Category: "electronics"}}, Quantities: []int{2}}
+	CalculateOrderTotal(&order, user)
+
+	receipt, err := GenerateReceipt(order, user, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Kind != ReceiptPayment || receipt.Number != "RCT-000042" || receipt.Locale != "de-DE" || receipt.Total != order.Total || len(receipt.Lines) != 1 {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+	if receipt.ShipTo != user.Address || receipt.Customer.Name != "Anna" {
+		t.Errorf("Expected the customer's address, got %+v", receipt)
+	}
+
+	confirmation, err := GenerateReceipt(order, user, ReceiptConfirmation, "fr")
+	if err != nil || confirmation.Number != "ORD-000042" || confirmation.Locale != "fr-FR" {
+		t.Errorf("Unexpected confirmation %+v: %v", confirmation, err)
+	}
+	if _, err := GenerateReceipt(order, user, "refund", ""); err == nil {
+		t.Error("Expected an unknown kind to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Shared recommendation weights - what each RecommendProducts score component
+// is worth. The weights in use start at DefaultRecommendationWeights;
+// administrators replace them with PUT /api/recommendations/weights, which
+// the server keeps under -storage-path across restarts, and pages pass GET
+// /api/recommendations/weights to setRecommendationWeightsWasm. A single
+// request can also bring its own weights to try a change out.
+
+// RecommendationWeights are the points of each score component. PriceRange
+// is for products within 30% of the order's average price, Rating is per
+// star, and Collaborative, Trending and Cohort are for a full match, scaled
+// down for weaker ones.
+type RecommendationWeights struct {
+	Category      float64 `json:"category"`      // the cart's or the user's category
+	Wishlist      float64 `json:"wishlist"`      // a category on the user's wishlist
+	Collaborative float64 `json:"collaborative"` // bought with the order's products
+	Trending      float64 `json:"trending"`      // the fastest selling product
+	PriceRange    float64 `json:"price_range"`   // near the order's average price
+	Rating        float64 `json:"rating"`        // per star
+	Premium       float64 `json:"premium"`       // higher-end picks for premium users
+	AgeGroup      float64 `json:"age_group"`     // categories popular with the user's age
+	Cohort        float64 `json:"cohort"`        // the best seller of the user's cohort, with an empty cart
+	// Favorite is for the user's favourite categories, and PriceSensitivity
+	// for products on the side of the order's average price their
+	// preferences lean to
+	Favorite         float64 `json:"favorite"`
+	PriceSensitivity float64 `json:"price_sensitivity"`
+}
+
+// RecommendOptions tune one call of ExplainRecommendations.
+type RecommendOptions struct {
+	Weights   RecommendationWeights `json:"weights"`
+	Diversity Diversity             `json:"diversity"`
+}
+
+// DefaultRecommendationWeights are the weights the scoring was designed with.
+var DefaultRecommendationWeights = RecommendationWeights{
+	Category:         3,
+	Wishlist:         2,
+	Collaborative:    3,
+	Trending:         2,
+	PriceRange:       2,
+	Rating:           0.5,
+	Premium:          1,
+	AgeGroup:         1,
+	Cohort:           2,
+	Favorite:         3,
+	PriceSensitivity: 1.5,
+}
+
+var (
+	recommendationWeightsMu sync.RWMutex
+	recommendationWeights   = DefaultRecommendationWeights
+)
+
+// DefaultRecommendOptions are the weights in use and DefaultDiversity.
+func DefaultRecommendOptions() RecommendOptions {
+	return RecommendOptions{Weights: CurrentRecommendationWeights(), Diversity: DefaultDiversity}
+}
+
+// ValidateRecommendationWeights checks that every weight is from 0 to 100.
+func ValidateRecommendationWeights(weights RecommendationWeights) ValidationResult {
+	result := newValidationResult()
+	for _, weight := range []struct {
+		field string
+		value float64
+	}{
+		{"category", weights.Category},
+		{"wishlist", weights.Wishlist},
+		{"collaborative", weights.Collaborative},
+		{"trending", weights.Trending},
+		{"price_range", weights.PriceRange},
+		{"rating", weights.Rating},
+		{"premium", weights.Premium},
+		{"age_group", weights.AgeGroup},
+		{"cohort", weights.Cohort},
+		{"favorite", weights.Favorite},
+		{"price_sensitivity", weights.PriceSensitivity},
+	} {
+		if !(weight.value >= 0 && weight.value <= 100) || math.IsNaN(weight.value) {
+			result.AddError(weight.field, CodeOutOfRange, fmt.Sprintf("Weight %s must be between 0 and 100", weight.field))
+		}
+	}
+	return result
+}
+
+// ParseRecommendationWeights reads weights from JSON, as served by
+// /api/recommendations/weights. Weights left out keep their defaults.
+func ParseRecommendationWeights(data []byte) (RecommendationWeights, error) {
+	weights := DefaultRecommendationWeights
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return RecommendationWeights{}, fmt.Errorf("invalid recommendation weights JSON: %w", err)
+	}
+	if result := ValidateRecommendationWeights(weights); !result.Valid {
+		return RecommendationWeights{}, fmt.Errorf("invalid recommendation weights: %s", result.Errors[0])
+	}
+	return weights, nil
+}
+
+// SetRecommendationWeights replaces the weights RecommendProducts scores
+// with.
+func SetRecommendationWeights(weights RecommendationWeights) {
+	recommendationWeightsMu.Lock()
+	recommendationWeights = weights
+	recommendationWeightsMu.Unlock()
+}
+
+// CurrentRecommendationWeights returns the weights in use.
+func CurrentRecommendationWeights() RecommendationWeights {
+	recommendationWeightsMu.RLock()
+	defer recommendationWeightsMu.RUnlock()
+	return recommendationWeights
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+// withRecommendationWeights restores the weights in use after the test.
+func withRecommendationWeights(t *testing.T) {
+	t.Helper()
+	previous := CurrentRecommendationWeights()
+	t.Cleanup(func() { SetRecommendationWeights(previous) })
+}
+
+// TestRecommendationWeights tests that every component follows its weight
+func TestRecommendationWeights(t *testing.T) {
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	withTrending(t)
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+
+	user := User{Age: 30, Country: "US"}
+	catalog := []Product{
+		{ID: 1, Name: "Jacket", Price: 50, Category: "clothing", OnHand: 5, Rating: 3},
+		{ID: 2, Name: "Novel", Price: 50, Category: "books", OnHand: 5, Rating: 5},
+	}
+	order := Order{Products: []Product{{ID: 9, Price: 50, Category: "clothing"}}, Quantities: []int{1}}
+	recommend := func(weights RecommendationWeights) []Recommendation {
+		return ExplainRecommendations(user, catalog, order, Wishlist{}, RecommendOptions{Weights: weights})
+	}
+
+	// Category 3 + price 2 + rating 1.5 beats price 2 + rating 2.5
+	if got := recommend(DefaultRecommendationWeights); got[0].ID != 1 || got[0].Score != 6.5 || got[1].Score != 4.5 {
+		t.Errorf("Expected the jacket first with the defaults, got %+v", got)
+	}
+	weights := DefaultRecommendationWeights
+	weights.Category = 0
+	weights.Rating = 2
+	got := recommend(weights)
+	if got[0].ID != 2 || got[0].Score != 12 || len(got[0].Reasons) != 2 {
+		t.Errorf("Expected the novel first when rating counts most, got %+v", got)
+	}
+
+	if got := recommend(RecommendationWeights{}); got[0].Score != 0 || len(got[0].Reasons) != 0 {
+		t.Errorf("Expected zero weights to score nothing, got %+v", got)
+	}
+}
+
+// TestParseRecommendationWeights tests defaults for missing weights and the
+// ranges
+func TestParseRecommendationWeights(t *testing.T) {
+	weights, err := ParseRecommendationWeights([]byte(`{"category": 5}`))
+	if err != nil || weights.Category != 5 || weights.Rating != DefaultRecommendationWeights.Rating {
+		t.Errorf("Expected the category weight over the defaults, got %+v (%v)", weights, err)
+	}
+	for _, data := range []string{`{"rating": -1}`, `{"premium": 101}`, `[1]`} {
+		if _, err := ParseRecommendationWeights([]byte(data)); err == nil {
+			t.Errorf("Expected %s to be rejected", data)
+		}
+	}
+}
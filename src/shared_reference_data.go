@@ -0,0 +1,114 @@
+package main
+
+//go:generate go test -run TestReferenceDataGenerated -update .
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+)
+
+// Shared reference data - the tables the business logic prices, taxes and
+// ships by (countries, currencies, product categories, tax rules and
+// shipping rates), collected for pages that show them. The Go tables stay
+// the only source: GET /api/reference-data serves them, and go generate
+// writes them into assets/js/reference-data.js for pages to load before the
+// module, so a country picker or a tax-inclusive price label offers exactly
+// what the server and WASM accept.
+
+// marketCountries are the demo countries, in the order pickers list them.
+// Each has a currency, a shipping zone rate and tax rules of its own.
+var marketCountries = []string{"US", "CA", "UK", "DE", "FR", "JP", "AU", "IN", "BR", "MX"}
+
+// Market is one demo country as a page shows it.
+type Market struct {
+	Code             string  `json:"code"` // as the demo's tables list it, UK for the United Kingdom
+	Name             string  `json:"name"`
+	Currency         string  `json:"currency"`
+	PricesIncludeTax bool    `json:"prices_include_tax"`
+	ShippingRate     float64 `json:"shipping_rate"` // standard zone rate, in USD
+}
+
+// ShippingReference is what QuoteShipping charges by.
+type ShippingReference struct {
+	Origin          string             `json:"origin"`
+	ZoneRates       map[string]float64 `json:"zone_rates"`
+	RegionZoneRates map[string]float64 `json:"region_zone_rates"`
+	DefaultZoneRate float64            `json:"default_zone_rate"`
+	Services        []CarrierService   `json:"services"`
+}
+
+// ReferenceData is the reference tables.
+type ReferenceData struct {
+	Markets        []Market          `json:"markets"`
+	Categories     []string          `json:"categories"`
+	Currencies     []Currency        `json:"currencies"`
+	TaxRules       []TaxRule         `json:"tax_rules"`
+	DefaultTaxRate float64           `json:"default_tax_rate"`
+	Shipping       ShippingReference `json:"shipping"`
+	Countries      []Country         `json:"countries"`
+}
+
+// BuildReferenceData collects the reference tables. Categories are those the
+// built-in validation rules accept, whatever ruleset is installed.
+func BuildReferenceData() ReferenceData {
+	data := ReferenceData{
+		Markets:        make([]Market, len(marketCountries)),
+		Categories:     productCategories(mustParseValidationRules(validationRulesJSON)),
+		Currencies:     make([]Currency, 0, len(currencies)),
+		TaxRules:       taxRules,
+		DefaultTaxRate: defaultTaxRate,
+		Shipping: ShippingReference{
+			Origin:          shippingOrigin,
+			ZoneRates:       shippingZoneRates,
+			RegionZoneRates: regionZoneRates,
+			DefaultZoneRate: defaultZoneRate,
+			Services:        shippingServices,
+		},
+		Countries: Countries(""),
+	}
+	for i, code := range marketCountries {
+		country, _ := LookupCountry(code)
+		data.Markets[i] = Market{
+			Code:             code,
+			Name:             country.Name,
+			Currency:         country.Currency,
+			PricesIncludeTax: PricesIncludeTax(code),
+			ShippingRate:     zoneRate(code),
+		}
+	}
+	for _, currency := range currencies {
+		data.Currencies = append(data.Currencies, currency)
+	}
+	sort.Slice(data.Currencies, func(i, j int) bool { return data.Currencies[i].Code < data.Currencies[j].Code })
+	return data
+}
+
+// productCategories are the categories a ruleset's product rules allow.
+func productCategories(rules ValidationRules) []string {
+	for _, rule := range rules["product"] {
+		if rule.Field == "category" && len(rule.OneOf) > 0 {
+			return slices.Clone(rule.OneOf)
+		}
+	}
+	return []string{}
+}
+
+// RenderReferenceDataJS renders the reference-data.js of data.
+func RenderReferenceDataJS(data ReferenceData) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go generate from src/shared_reference_data.go; DO NOT EDIT.\n\n")
+	b.WriteString("// The tables the business logic prices, taxes and ships by, as\n")
+	b.WriteString("// GET /api/reference-data serves them\n")
+	b.WriteString("window.referenceData = ")
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	b.Truncate(b.Len() - 1) // Encode's newline
+	b.WriteString(";\n")
+	return b.Bytes(), nil
+}
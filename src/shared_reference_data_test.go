@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+var updateGenerated = flag.Bool("update", false, "rewrite generated files instead of checking them")
+
+func TestBuildReferenceData(t *testing.T) {
+	data := BuildReferenceData()
+	for _, market := range data.Markets {
+		if _, ok := currencies[market.Currency]; !ok {
+			t.Errorf("%s: currency %q is not supported", market.Code, market.Currency)
+		}
+		if _, ok := shippingZoneRates[market.Code]; !ok {
+			t.Errorf("%s: no shipping zone rate", market.Code)
+		}
+		if _, ok := TaxRuleFor(market.Code, "", time.Now()); !ok {
+			t.Errorf("%s: no tax rule", market.Code)
+		}
+		if market.Name == "" {
+			t.Errorf("%s: not in the country dataset", market.Code)
+		}
+	}
+	if uk := data.Markets[slices.IndexFunc(data.Markets, func(m Market) bool { return m.Code == "UK" })]; uk.Currency != "GBP" || uk.ShippingRate != 15.99 {
+		t.Errorf("UK market = %+v", uk)
+	}
+	if de := data.Markets[slices.IndexFunc(data.Markets, func(m Market) bool { return m.Code == "DE" })]; !de.PricesIncludeTax {
+		t.Error("German prices should include tax")
+	}
+	if !slices.Contains(data.Categories, "electronics") || len(data.Currencies) != len(currencies) || len(data.Countries) < 200 {
+		t.Errorf("categories %v, %d currencies, %d countries", data.Categories, len(data.Currencies), len(data.Countries))
+	}
+
+	// An installed ruleset does not change the reference categories
+	defer SetValidationRules(CurrentValidationRules())
+	SetValidationRules(ValidationRules{})
+	if got := BuildReferenceData().Categories; !slices.Equal(got, data.Categories) {
+		t.Errorf("categories with an empty ruleset = %v", got)
+	}
+}
+
+func TestReferenceDataGenerated(t *testing.T) {
+	const path = "../assets/js/reference-data.js"
+	want, err := RenderReferenceDataJS(BuildReferenceData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updateGenerated {
+		if err := os.WriteFile(path, want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Error("assets/js/reference-data.js is out of date - run go generate in src")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Shared fraud risk scoring - a priced order gets a 0-100 risk score from a
+// few heuristics: shipping to a country other than the account's, spending
+// far more than the user usually does, and a new account placing a
+// high-value order. Orders scoring RiskReviewThreshold or more are held in
+// pending until an administrator advances them. Amounts are compared in the
+// default currency.
+
+// RiskReviewThreshold is the score from which an order needs review.
+const RiskReviewThreshold = 70
+
+const (
+	// highValueOrder is the total, in the default currency, from which an
+	// order from a new account or without any history is risky.
+	highValueOrder = 500.0
+	// newAccountDays is how long after joining an account counts as new.
+	newAccountDays = 30
+)
+
+// ErrRiskReview is returned when a held high-risk order is advanced without
+// approval.
+var ErrRiskReview = errors.New("order is held for risk review; an administrator must approve it")
+
+// OrderRisk is an order's risk score and the heuristics that raised it.
+type OrderRisk struct {
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// NeedsReview reports whether the order must be reviewed before it ships.
+func (risk OrderRisk) NeedsReview() bool {
+	return risk.Score >= RiskReviewThreshold
+}
+
+// ScoreOrderRisk scores a priced order placed by user against the user's
+// order history. History may hold other users' orders and the order itself;
+// only the user's other orders that weren't cancelled count, and guests
+// (user ID 0) have none.
+func ScoreOrderRisk(order Order, user User, history []Order) OrderRisk {
+	risk := OrderRisk{Reasons: []string{}}
+	add := func(points int, reason string, args ...interface{}) {
+		risk.Score += points
+		risk.Reasons = append(risk.Reasons, fmt.Sprintf(reason, args...))
+	}
+	total := riskAmount(order)
+
+	country := ""
+	if order.ShippingAddress != nil {
+		country = order.ShippingAddress.Country
+	} else if user.Address != nil {
+		country = user.Address.Country
+	}
+	if country != "" && user.Country != "" && !strings.EqualFold(country, user.Country) {
+		add(35, "Ships to %s but the account is in %s", strings.ToUpper(country), strings.ToUpper(user.Country))
+	}
+
+	previous, spent := 0, 0.0
+	for _, past := range history {
+		if user.ID != 0 && past.UserID == user.ID && (order.ID == 0 || past.ID != order.ID) && past.Status != "cancelled" {
+			previous++
+			spent += riskAmount(past)
+		}
+	}
+	if previous > 0 && spent > 0 {
+		switch ratio := total / (spent / float64(previous)); {
+		case ratio >= 5:
+			add(40, "Order is %.0f times the user's average order", math.Floor(ratio))
+		case ratio >= 3:
+			add(25, "Order is %.0f times the user's average order", math.Floor(ratio))
+		}
+	}
+
+	if total >= highValueOrder {
+		placed := orderPlacedDate(order)
+		if joined, err := time.Parse(taxDateLayout, user.JoinDate); err == nil && placed.Sub(joined) < newAccountDays*24*time.Hour {
+			add(35, "High-value order from an account %d days old", max(int(placed.Sub(joined).Hours()/24), 0))
+		}
+		if previous == 0 {
+			add(15, "High-value first order")
+		}
+	}
+
+	risk.Score = min(risk.Score, 100)
+	return risk
+}
+
+// CheckRiskHold returns ErrRiskReview when moving a pending order with a
+// risk score of RiskReviewThreshold or more to a status other than
+// cancelled, unless approved.
+func CheckRiskHold(order Order, status string, approved bool) error {
+	if approved || order.Status != "pending" || order.RiskScore < RiskReviewThreshold {
+		return nil
+	}
+	if status == "pending" || status == "cancelled" {
+		return nil
+	}
+	return ErrRiskReview
+}
+
+// riskAmount is an order's total in the default currency, or as priced when
+// its currency has no exchange rate.
+func riskAmount(order Order) float64 {
+	if amount, err := ConvertPrice(order.Total, OrderCurrency(order), DefaultCurrency); err == nil {
+		return amount
+	}
+	return order.Total
+}
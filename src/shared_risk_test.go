@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestScoreOrderRisk tests the risk heuristics and the score cap
+func TestScoreOrderRisk(t *testing.T) {
+	lamp := Product{ID: 1, Name: "Lamp", Price: 100, Category: "home"}
+	order := func(total float64) Order {
+		return Order{ID: 9, UserID: 1, Products: []Product{lamp}, Quantities: []int{1}, Total: total, OrderDate: "2026-06-30"}
+	}
+	regular := User{ID: 1, Country: "US", JoinDate: "2024-01-10"}
+	history := []Order{
+		{ID: 1, UserID: 1, Total: 100, Status: "delivered"},
+		{ID: 2, UserID: 1, Total: 120, Status: "delivered"},
+		{ID: 3, UserID: 1, Total: 5000, Status: "cancelled"},
+		{ID: 4, UserID: 2, Total: 5, Status: "delivered"},
+		{ID: 9, UserID: 1, Total: 3000, Status: "pending"},
+	}
+	abroad := order(110)
+	abroad.ShippingAddress = &Address{Street: "1 Rue", City: "Paris", PostalCode: "75001", Country: "fr"}
+
+	for _, tt := range []struct {
+		name    string
+		order   Order
+		user    User
+		history []Order
+		score   int
+		reason  string
+	}{
+		{"Usual", order(110), regular, history, 0, ""},
+		{"ShipsAbroad", abroad, regular, history, 35, "Ships to FR but the account is in US"},
+		{"ThreeTimesAverage", order(400), regular, history, 25, "3 times the user's average"},
+		{"FiveTimesAverage", order(1100), regular, history, 40, "10 times the user's average"},
+		{"HighValueFirstOrder", order(600), regular, nil, 15, "High-value first order"},
+		{"NewAccount", order(600), User{ID: 1, Country: "US", JoinDate: "2026-06-20"}, history, 75, "account 10 days old"},
+		{"GuestHasNoHistory", order(100), User{Country: "US"}, []Order{{UserID: 0, Total: 5}}, 0, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := ScoreOrderRisk(tt.order, tt.user, tt.history)
+			if risk.Score != tt.score || !strings.Contains(strings.Join(risk.Reasons, "; "), tt.reason) {
+				t.Errorf("Expected score %d mentioning %q, got %+v", tt.score, tt.reason, risk)
+			}
+		})
+	}
+
+	everything := order(5000)
+	everything.ShippingAddress = abroad.ShippingAddress
+	risk := ScoreOrderRisk(everything, User{ID: 1, Country: "US", JoinDate: "2026-06-29"}, history)
+	if risk.Score != 100 || !risk.NeedsReview() || len(risk.Reasons) != 3 {
+		t.Errorf("Expected the score capped at 100, got %+v", risk)
+	}
+}
+
+// TestCheckRiskHold tests that held orders need approval to leave pending
+func TestCheckRiskHold(t *testing.T) {
+	held := Order{Status: "pending", RiskScore: RiskReviewThreshold}
+	if err := CheckRiskHold(held, "shipped", false); err != ErrRiskReview {
+		t.Errorf("Expected a held order not to ship, got %v", err)
+	}
+	for _, status := range []string{"cancelled", "pending"} {
+		if err := CheckRiskHold(held, status, false); err != nil {
+			t.Errorf("Expected a held order to be able to move to %s, got %v", status, err)
+		}
+	}
+	if err := CheckRiskHold(held, "processing", true); err != nil {
+		t.Errorf("Expected an approved order to proceed, got %v", err)
+	}
+	if err := CheckRiskHold(Order{Status: "pending", RiskScore: RiskReviewThreshold - 1}, "shipped", false); err != nil {
+		t.Errorf("Expected a low-risk order to proceed, got %v", err)
+	}
+}
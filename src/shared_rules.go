@@ -0,0 +1,213 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Shared validation rules - the per-field constraints of users and products
+// (lengths, ranges, patterns and allowed values) are data rather than code:
+// a ruleset keyed by model, embedded from validation_rules.json. The server
+// can load its own ruleset with -validation-rules-file and serves the one in
+// use at /api/validation-rules; pages pass it to the WebAssembly module, so
+// a rule change reaches both sides without touching ValidateUser or
+// ValidateProduct. Checks that span fields or look things up (countries,
+// phones, stock, variants) stay in the validators.
+
+//go:embed validation_rules.json
+var validationRulesJSON []byte
+
+// ValidationRules are the rules of each model ("user", "product"), applied
+// in order.
+type ValidationRules map[string][]FieldRule
+
+// FieldRule constrains one field, named by its JSON name. Every constraint
+// given must hold; the first that doesn't reports Message under Code, or
+// under the code its kind implies when Code is empty. Lengths count the
+// characters of the trimmed string, and OneOf ignores case.
+type FieldRule struct {
+	Field        string   `json:"field"`
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	ExclusiveMin *float64 `json:"exclusive_min,omitempty"`
+	MinLength    *int     `json:"min_length,omitempty"`
+	MaxLength    *int     `json:"max_length,omitempty"`
+	Pattern      string   `json:"pattern,omitempty"`
+	OneOf        []string `json:"one_of,omitempty"`
+	Code         string   `json:"code,omitempty"`
+	Message      string   `json:"message"`
+
+	index   int // of the field in the model struct
+	pattern func(string) bool
+	oneOf   map[string]bool // OneOf, for values written as they are there
+}
+
+// ruleModels are the models rules can be declared for.
+var ruleModels = map[string]reflect.Type{
+	SchemaUser:    reflect.TypeOf(User{}),
+	SchemaProduct: reflect.TypeOf(Product{}),
+}
+
+var (
+	validationRulesMu sync.RWMutex
+	validationRules   = mustParseValidationRules(validationRulesJSON)
+	// validationRulesGeneration counts the rulesets set, so results kept
+	// under one ruleset aren't reused under the next
+	validationRulesGeneration int
+)
+
+// mustParseValidationRules reads the embedded ruleset, which is part of the
+// source, so an invalid rule is a bug.
+func mustParseValidationRules(data []byte) ValidationRules {
+	rules, err := ParseValidationRules(data)
+	if err != nil {
+		panic("validation_rules.json: " + err.Error())
+	}
+	return rules
+}
+
+// ParseValidationRules reads a ruleset from JSON, checking that each rule
+// names a field of its model that its constraints apply to.
+func ParseValidationRules(data []byte) (ValidationRules, error) {
+	var rules ValidationRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid validation rules JSON: %w", err)
+	}
+	for model, modelRules := range rules {
+		modelType, ok := ruleModels[model]
+		if !ok {
+			return nil, fmt.Errorf("unknown model %q", model)
+		}
+		for i := range modelRules {
+			if err := compileFieldRule(modelType, &modelRules[i]); err != nil {
+				return nil, fmt.Errorf("%s rule %d: %w", model, i, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// compileFieldRule resolves a rule's field and compiles its pattern.
+func compileFieldRule(modelType reflect.Type, rule *FieldRule) error {
+	rule.index = -1
+	for i := 0; i < modelType.NumField(); i++ {
+		if name, _, _ := strings.Cut(modelType.Field(i).Tag.Get("json"), ","); name == rule.Field {
+			rule.index = i
+		}
+	}
+	if rule.index < 0 {
+		return fmt.Errorf("no field %q", rule.Field)
+	}
+	if rule.Message == "" {
+		return fmt.Errorf("%s needs a message", rule.Field)
+	}
+
+	numeric := rule.Min != nil || rule.Max != nil || rule.ExclusiveMin != nil
+	textual := rule.MinLength != nil || rule.MaxLength != nil || rule.Pattern != "" || len(rule.OneOf) > 0
+	switch modelType.Field(rule.index).Type.Kind() {
+	case reflect.Int, reflect.Float64:
+		if textual {
+			return fmt.Errorf("%s is a number, not a string", rule.Field)
+		}
+	case reflect.String:
+		if numeric {
+			return fmt.Errorf("%s is a string, not a number", rule.Field)
+		}
+	default:
+		return fmt.Errorf("%s is neither a number nor a string", rule.Field)
+	}
+
+	if len(rule.OneOf) > 0 {
+		rule.oneOf = make(map[string]bool, len(rule.OneOf))
+		for _, allowed := range rule.OneOf {
+			rule.oneOf[allowed] = true
+		}
+	}
+	if rule.Pattern != "" {
+		pattern, err := compileTextPattern(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s pattern: %w", rule.Field, err)
+		}
+		rule.pattern = pattern
+	}
+	return nil
+}
+
+// SetValidationRules replaces the ruleset. Rules must come from
+// ParseValidationRules.
+func SetValidationRules(rules ValidationRules) {
+	validationRulesMu.Lock()
+	validationRules = rules
+	validationRulesGeneration++
+	validationRulesMu.Unlock()
+}
+
+// CurrentValidationRules returns the ruleset in use.
+func CurrentValidationRules() ValidationRules {
+	validationRulesMu.RLock()
+	defer validationRulesMu.RUnlock()
+	return validationRules
+}
+
+// currentValidationRulesGeneration is the number of the ruleset in use.
+func currentValidationRulesGeneration() int {
+	validationRulesMu.RLock()
+	defer validationRulesMu.RUnlock()
+	return validationRulesGeneration
+}
+
+// applyValidationRules checks value, a model struct, against the rules of
+// model.
+func applyValidationRules(model string, value interface{}, result *ValidationResult) {
+	fields := reflect.ValueOf(value)
+	rules := CurrentValidationRules()[model]
+	for i := range rules {
+		rule := &rules[i]
+		if code := rule.violation(fields.Field(rule.index)); code != "" {
+			if rule.Code != "" {
+				code = rule.Code
+			}
+			result.AddError(rule.Field, code, rule.Message)
+		}
+	}
+}
+
+// violation is the code of the first constraint field breaks, "" when it
+// keeps them all.
+func (rule *FieldRule) violation(field reflect.Value) string {
+	if field.Kind() != reflect.String {
+		// Int and Float rather than Convert, which TinyGo's reflect only
+		// partly supports
+		number := 0.0
+		if field.Kind() == reflect.Int {
+			number = float64(field.Int())
+		} else {
+			number = field.Float()
+		}
+		if (rule.Min != nil && number < *rule.Min) || (rule.Max != nil && number > *rule.Max) ||
+			(rule.ExclusiveMin != nil && number <= *rule.ExclusiveMin) {
+			return CodeOutOfRange
+		}
+		return ""
+	}
+
+	text := strings.TrimSpace(field.String())
+	length := utf8.RuneCountInString(text)
+	switch {
+	case rule.MinLength != nil && length < *rule.MinLength:
+		return CodeTooShort
+	case rule.MaxLength != nil && length > *rule.MaxLength:
+		return CodeTooLong
+	case rule.pattern != nil && !rule.pattern(text):
+		return CodeInvalidFormat
+	case len(rule.OneOf) > 0 && !rule.oneOf[text] && !slices.ContainsFunc(rule.OneOf, func(allowed string) bool { return strings.EqualFold(allowed, text) }):
+		return CodeUnknownValue
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// withValidationRules restores the ruleset after the test.
+func withValidationRules(t *testing.T) {
+	t.Helper()
+	previous := CurrentValidationRules()
+	t.Cleanup(func() { SetValidationRules(previous) })
+}
+
+// TestValidationRulesApply tests that a loaded ruleset changes what the
+// validators accept without changing their code
+func TestValidationRulesApply(t *testing.T) {
+	withValidationRules(t)
+
+	user := User{Email: "ann@example.com", Name: "Ann", Age: 15, Country: "US"}
+	if result := ValidateUser(user); !result.Valid {
+		t.Fatalf("Expected a valid user with the built-in rules, got %v", result.Errors)
+	}
+
+	rules, err := ParseValidationRules([]byte(`{"user": [
+		{"field": "age", "min": 18, "message": "Must be an adult"},
+		{"field": "name", "pattern": "^[A-Z]", "message": "Name must be capitalized"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetValidationRules(rules)
+
+	user.Name = "ann"
+	result := ValidateUser(user)
+	if len(result.FieldErrors) != 2 || result.FieldErrors[0].Field != "age" || result.FieldErrors[0].Code != CodeOutOfRange ||
+		result.FieldErrors[1].Field != "name" || result.FieldErrors[1].Code != CodeInvalidFormat {
+		t.Errorf("Unexpected field errors: %+v", result.FieldErrors)
+	}
+	// Rules for products were not given, so none apply
+	if result := ValidateProduct(Product{Name: "X", Price: -1}); !result.Valid {
+		t.Errorf("Expected no product rules, got %v", result.Errors)
+	}
+}
+
+// TestParseValidationRules tests rejecting rules that can't be applied
+func TestParseValidationRules(t *testing.T) {
+	tests := []struct {
+		rules string
+		error string
+	}{
+		{`{"order": []}`, "unknown model"},
+		{`{"user": [{"field": "nickname", "message": "x"}]}`, "no field"},
+		{`{"user": [{"field": "age"}]}`, "needs a message"},
+		{`{"user": [{"field": "age", "min_length": 2, "message": "x"}]}`, "is a number"},
+		{`{"product": [{"field": "name", "max": 2, "message": "x"}]}`, "is a string"},
+		{`{"product": [{"field": "variants", "min": 1, "message": "x"}]}`, "neither"},
+		{`{"product": [{"field": "name", "pattern": "(", "message": "x"}]}`, "pattern"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseValidationRules([]byte(tt.rules)); err == nil || !strings.Contains(err.Error(), tt.error) {
+			t.Errorf("ParseValidationRules(%s) error = %v, want %q", tt.rules, err, tt.error)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Shared schema versioning - users, products and orders are written with
+// the schema_version of the models that wrote them, and older payloads are
+// upgraded one version at a time by the migrations registered for their
+// model before they are decoded. Payloads without a version predate
+// versioning and count as version 1. Migrations work on the decoded JSON
+// object, so they can read fields the current models no longer have.
+
+// CurrentSchemaVersion is the version of the models in this build.
+const CurrentSchemaVersion = 2
+
+// Models with a schema, as passed to MigrateJSON
+const (
+	SchemaUser    = "user"
+	SchemaProduct = "product"
+	SchemaOrder   = "order"
+)
+
+// migratedInStockUnits is the stock given to version 1 products, which only
+// said whether they were in stock.
+const migratedInStockUnits = 100
+
+// schemaMigration upgrades a JSON object of one model from a version to the
+// next, in place.
+type schemaMigration func(doc map[string]interface{}) error
+
+// schemaMigrations are the migrations of each model by the version they
+// upgrade from. A version without one needs no changes.
+var schemaMigrations = map[string]map[int]schemaMigration{
+	SchemaProduct: {1: migrateProductV1},
+	SchemaOrder:   {1: migrateOrderV1},
+}
+
+// MigrateJSON upgrades a JSON object of a model to CurrentSchemaVersion.
+// Payloads from a newer version are rejected rather than misread.
+func MigrateJSON(model string, data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentSchemaVersion {
+		return data, nil
+	}
+	if err := migrateDocument(model, doc, version); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// migrateDocument applies a model's migrations from version on.
+func migrateDocument(model string, doc map[string]interface{}, version int) error {
+	for ; version < CurrentSchemaVersion; version++ {
+		if migrate, ok := schemaMigrations[model][version]; ok {
+			if err := migrate(doc); err != nil {
+				return fmt.Errorf("migrating %s from schema version %d: %w", model, version, err)
+			}
+		}
+	}
+	doc["schema_version"] = CurrentSchemaVersion
+	return nil
+}
+
+// schemaVersion reads a JSON object's schema_version, 1 when it has none.
+func schemaVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || raw == nil {
+		return 1, nil
+	}
+	number, ok := raw.(float64)
+	if !ok || number != float64(int(number)) || number < 1 {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+	if int(number) > CurrentSchemaVersion {
+		return 0, fmt.Errorf("schema version %d is newer than this version supports (%d)", int(number), CurrentSchemaVersion)
+	}
+	return int(number), nil
+}
+
+// migrateProductV1 replaces a version 1 product's in_stock flag with stock
+// counts and turns the sku, size and color it was sold in into its one
+// variant.
+func migrateProductV1(doc map[string]interface{}) error {
+	if inStock, ok := doc["in_stock"]; ok {
+		delete(doc, "in_stock")
+		if _, counted := doc["on_hand"]; !counted {
+			doc["on_hand"] = 0
+			if inStock == true {
+				doc["on_hand"] = migratedInStockUnits
+			}
+		}
+	}
+
+	sku, flat := doc["sku"]
+	if !flat {
+		return nil
+	}
+	if _, ok := sku.(string); !ok {
+		return fmt.Errorf("sku must be a string")
+	}
+	variant := map[string]interface{}{"sku": sku, "on_hand": doc["on_hand"]}
+	for _, attribute := range []string{"size", "color"} {
+		if value, ok := doc[attribute]; ok {
+			variant[attribute] = value
+			delete(doc, attribute)
+		}
+	}
+	delete(doc, "sku")
+	if _, ok := doc["variants"]; !ok {
+		doc["variants"] = []interface{}{variant}
+	}
+	return nil
+}
+
+// migrateOrderV1 upgrades a version 1 order's products and sets the
+// amount_due, which was the total before gift cards.
+func migrateOrderV1(doc map[string]interface{}) error {
+	if products, ok := doc["products"].([]interface{}); ok {
+		for i, raw := range products {
+			product, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("products[%d] must be an object", i)
+			}
+			if err := migrateProductV1(product); err != nil {
+				return fmt.Errorf("products[%d]: %w", i, err)
+			}
+		}
+	}
+	if _, ok := doc["amount_due"]; !ok {
+		if total, ok := doc["total"]; ok {
+			doc["amount_due"] = total
+		}
+	}
+	return nil
+}
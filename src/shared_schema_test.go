@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestMigrateJSON tests upgrading older payloads to the current models
+func TestMigrateJSON(t *testing.T) {
+	t.Run("FlatProduct", func(t *testing.T) {
+		product, err := ProductFromJSON(`{"id": 2, "name": "Cotton T-Shirt", "price": 24.99, "in_stock": true, "sku": "TSHIRT-BLK-M", "size": "M", "color": "black"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if product.SchemaVersion != CurrentSchemaVersion || len(product.Variants) != 1 || product.OnHand != migratedInStockUnits {
+			t.Fatalf("Expected a versioned product with one variant, got %+v", product)
+		}
+		if variant := product.Variants[0]; variant.SKU != "TSHIRT-BLK-M" || variant.Size != "M" || variant.Color != "black" || variant.OnHand != migratedInStockUnits {
+			t.Errorf("Expected the flat fields moved into the variant, got %+v", variant)
+		}
+	})
+
+	t.Run("CurrentPayloadUntouched", func(t *testing.T) {
+		data := []byte(`{"id": 1, "on_hand": 5, "schema_version": 2}`)
+		migrated, err := MigrateJSON(SchemaProduct, data)
+		if err != nil || string(migrated) != string(data) {
+			t.Errorf("Expected a current payload returned as is, got %s, %v", migrated, err)
+		}
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		order, err := OrderFromJSON(`{"id": 1, "user_id": 1, "products": [{"id": 4, "name": "Coffee Mug", "price": 12.99, "in_stock": false}], "quantities": [1], "total": 14.02}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order.SchemaVersion != CurrentSchemaVersion || order.AmountDue != 14.02 || order.Products[0].OnHand != 0 {
+			t.Errorf("Expected the order and its products upgraded, got %+v", order)
+		}
+		encoded, err := OrderToJSON(order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		json.Unmarshal([]byte(encoded), &doc)
+		if doc["schema_version"] != float64(CurrentSchemaVersion) {
+			t.Errorf("Expected OrderToJSON to write the schema version, got %v", doc["schema_version"])
+		}
+	})
+
+	t.Run("User", func(t *testing.T) {
+		user, err := UserFromJSON(`{"id": 1, "email": "john.doe@example.com", "name": "John Doe"}`)
+		if err != nil || user.SchemaVersion != CurrentSchemaVersion || user.Email != "john.doe@example.com" {
+			t.Errorf("Expected an unversioned user read as the current version, got %+v, %v", user, err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		for _, payload := range []string{`{"schema_version": 3}`, `{"schema_version": "two"}`, `{"schema_version": 0}`, `[]`} {
+			if _, err := UserFromJSON(payload); err == nil {
+				t.Errorf("Expected %s to be rejected", payload)
+			}
+		}
+		if _, err := MigrateJSON(SchemaProduct, []byte(`{"sku": 5}`)); err == nil {
+			t.Error("Expected a non-string sku to be rejected")
+		}
+	})
+}
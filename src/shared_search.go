@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Shared product search - typo-tolerant matching of product names and
+// descriptions, so "hedphones" finds "Wireless Headphones". BuildSearchIndex
+// indexes the words of a catalog twice: sorted, for prefix matches as the
+// user types, and by trigram, so a misspelt word is only compared with the
+// words it shares three letters with. Each query word scores its best match
+// in a product: exact, prefix, or within a couple of edits (a transposition
+// counts as one). A product's text score is the average over the query
+// words, name matches counting double. Results sort by relevance - the text
+// score blended with the product's rating and how well it is selling
+// (CurrentTrending) - or by price, rating or newest first, and list the
+// matched spans for highlighting.
+
+// maxSearchResults is how many results Search returns unless asked for
+// fewer.
+const maxSearchResults = 20
+
+// Search sort modes; SortNewest goes by ID, as products are numbered in the
+// order they are added.
+const (
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortRating    = "rating"
+	SortNewest    = "newest"
+)
+
+// searchSorts are the sort modes in the order they are documented.
+var searchSorts = []string{SortRelevance, SortPriceAsc, SortPriceDesc, SortRating, SortNewest}
+
+// Shares of the relevance score: the text score, the rating out of 5 and the
+// trending share.
+const (
+	relevanceText       = 0.7
+	relevanceRating     = 0.2
+	relevancePopularity = 0.1
+)
+
+// Match scores of a query word against a product word.
+const (
+	matchExact  = 1.0
+	matchPrefix = 0.8
+	matchFuzzy  = 0.6 // less 0.15 per edit
+)
+
+// MatchSpan is a matched word in a product field, in characters (runes)
+// from the start of the field; End is exclusive.
+type MatchSpan struct {
+	Field string `json:"field"` // "name" or "description"
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// SearchResult is a product matching a search, with its relevance from 0 to
+// 1, how well its text matched (up to 2, for every word exactly in the name)
+// and the spans to highlight.
+type SearchResult struct {
+	Product
+	Score     float64     `json:"score"`
+	TextScore float64     `json:"text_score"`
+	Matches   []MatchSpan `json:"matches"`
+}
+
+// SearchOptions tune one search.
+type SearchOptions struct {
+	Sort  string `json:"sort,omitempty"`  // SortRelevance unless set
+	Limit int    `json:"limit,omitempty"` // maxSearchResults unless set
+}
+
+// ValidateSearchOptions checks the sort mode is known and the limit is from
+// 0 to maxSearchResults.
+func ValidateSearchOptions(options SearchOptions) ValidationResult {
+	result := newValidationResult()
+	if options.Sort != "" && !slices.Contains(searchSorts, options.Sort) {
+		result.AddError("sort", CodeUnknownValue, "Sort must be one of "+strings.Join(searchSorts, ", "))
+	}
+	if options.Limit < 0 || options.Limit > maxSearchResults {
+		result.AddError("limit", CodeOutOfRange, fmt.Sprintf("Limit must be between 0 and %d", maxSearchResults))
+	}
+	return result
+}
+
+// relevance blends a product's text score with its rating and trending
+// share.
+func relevance(product Product, textScore float64) float64 {
+	return roundRatio(relevanceText*min(textScore/2, 1) + relevanceRating*min(product.Rating/5, 1) + relevancePopularity*trendingShare(product.ID))
+}
+
+// wordOccurrence is where an indexed word appears.
+type wordOccurrence struct {
+	product int // index in the catalog
+	span    MatchSpan
+}
+
+// SearchIndex is the word indexes of a catalog.
+type SearchIndex struct {
+	products    []Product
+	occurrences map[string][]wordOccurrence
+	sorted      []string            // distinct words, for prefix lookups
+	trigrams    map[string][]string // trigram to the words containing it
+}
+
+// searchWords splits text into lower-case words with their rune spans.
+func searchWords(text string) (words []string, spans [][2]int) {
+	runes := []rune(text)
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		inWord := i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	return words, spans
+}
+
+// wordTrigrams are the trigrams of a word padded with a space each side, so
+// short words have some and the ends of words count.
+func wordTrigrams(word string) []string {
+	runes := []rune(" " + word + " ")
+	var trigrams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams = append(trigrams, string(runes[i:i+3]))
+	}
+	return trigrams
+}
+
+// BuildSearchIndex indexes the names and descriptions of a catalog.
+func BuildSearchIndex(catalog []Product) *SearchIndex {
+	index, _ := BuildSearchIndexContext(context.Background(), catalog)
+	return index
+}
+
+// BuildSearchIndexContext is BuildSearchIndex stopping with ctx's error once
+// ctx is done.
+func BuildSearchIndexContext(ctx context.Context, catalog []Product) (*SearchIndex, error) {
+	ctx, end := StartSpan(ctx, "BuildSearchIndex")
+	defer end()
+
+	index := &SearchIndex{products: catalog, occurrences: map[string][]wordOccurrence{}, trigrams: map[string][]string{}}
+	for i, product := range catalog {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		for _, field := range []struct{ name, text string }{{"name", product.Name}, {"description", product.Description}} {
+			words, spans := searchWords(field.text)
+			for j, word := range words {
+				if _, seen := index.occurrences[word]; !seen {
+					index.sorted = append(index.sorted, word)
+					for _, trigram := range wordTrigrams(word) {
+						index.trigrams[trigram] = append(index.trigrams[trigram], word)
+					}
+				}
+				index.occurrences[word] = append(index.occurrences[word], wordOccurrence{
+					product: i,
+					span:    MatchSpan{Field: field.name, Start: spans[j][0], End: spans[j][1]},
+				})
+			}
+		}
+	}
+	sort.Strings(index.sorted)
+	return index, nil
+}
+
+// maxEdits is the edits a query word may be from a word it matches: none
+// for short words, whose typos match too much.
+func maxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchingWords scores the indexed words a query word matches.
+func (index *SearchIndex) matchingWords(term string) map[string]float64 {
+	matches := map[string]float64{}
+	if _, ok := index.occurrences[term]; ok {
+		matches[term] = matchExact
+	}
+	// Words the term starts, from the sorted list
+	if len([]rune(term)) >= 2 {
+		for i := sort.SearchStrings(index.sorted, term); i < len(index.sorted) && strings.HasPrefix(index.sorted[i], term); i++ {
+			if index.sorted[i] != term {
+				matches[index.sorted[i]] = matchPrefix
+			}
+		}
+	}
+	// Misspellings, among the words sharing a trigram
+	if limit := maxEdits(term); limit > 0 {
+		compared := map[string]bool{}
+		for _, trigram := range wordTrigrams(term) {
+			for _, word := range index.trigrams[trigram] {
+				if _, matched := matches[word]; matched || compared[word] {
+					continue
+				}
+				compared[word] = true
+				if edits := boundedEditDistance(term, word, limit); edits <= limit {
+					matches[word] = matchFuzzy - 0.15*float64(edits)
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// Search sorts the products matching a query, ties going to the more
+// relevant and then to the catalog order, up to the options' limit.
+func (index *SearchIndex) Search(query string, options SearchOptions) []SearchResult {
+	results, _ := index.SearchContext(context.Background(), query, options)
+	return results
+}
+
+// SearchContext is Search stopping with ctx's error once ctx is done.
+func (index *SearchIndex) SearchContext(ctx context.Context, query string, options SearchOptions) ([]SearchResult, error) {
+	ctx, end := StartSpan(ctx, "Search")
+	defer end()
+
+	limit := options.Limit
+	if limit <= 0 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+	terms, _ := searchWords(query)
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	type hit struct {
+		score   float64
+		matches []MatchSpan
+	}
+	hits := map[int]*hit{}
+	checked := 0
+	for _, term := range terms {
+		best := map[int]float64{}
+		spans := map[int][]MatchSpan{}
+		for word, score := range index.matchingWords(term) {
+			for _, occurrence := range index.occurrences[word] {
+				if err := checkCanceled(ctx, checked); err != nil {
+					return nil, err
+				}
+				checked++
+				weighted := score
+				if occurrence.span.Field == "name" {
+					weighted *= 2
+				}
+				best[occurrence.product] = max(best[occurrence.product], weighted)
+				spans[occurrence.product] = append(spans[occurrence.product], occurrence.span)
+			}
+		}
+		for product, score := range best {
+			if hits[product] == nil {
+				hits[product] = &hit{}
+			}
+			hits[product].score += score / float64(len(terms))
+			hits[product].matches = append(hits[product].matches, spans[product]...)
+		}
+	}
+
+	for i, product := range index.products {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if h := hits[i]; h != nil {
+			results = append(results, SearchResult{Product: product, Score: relevance(product, h.score), TextScore: roundRatio(h.score), Matches: mergeSpans(h.matches)})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch options.Sort {
+		case SortPriceAsc:
+			if a.Price != b.Price {
+				return a.Price < b.Price
+			}
+		case SortPriceDesc:
+			if a.Price != b.Price {
+				return a.Price > b.Price
+			}
+		case SortRating:
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+		case SortNewest:
+			if a.ID != b.ID {
+				return a.ID > b.ID
+			}
+		}
+		return a.Score > b.Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// SearchProducts searches a catalog; see SearchIndex.Search.
+func SearchProducts(catalog []Product, query string, options SearchOptions) []SearchResult {
+	return BuildSearchIndex(catalog).Search(query, options)
+}
+
+// SearchProductsContext is SearchProducts stopping with ctx's error once ctx
+// is done.
+func SearchProductsContext(ctx context.Context, catalog []Product, query string, options SearchOptions) ([]SearchResult, error) {
+	ctx, end := StartSpan(ctx, "SearchProducts")
+	defer end()
+
+	index, err := BuildSearchIndexContext(ctx, catalog)
+	if err != nil {
+		return nil, err
+	}
+	return index.SearchContext(ctx, query, options)
+}
+
+// mergeSpans sorts spans by field and position, dropping duplicates.
+func mergeSpans(spans []MatchSpan) []MatchSpan {
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Field != spans[j].Field {
+			return spans[i].Field > spans[j].Field // name first
+		}
+		return spans[i].Start < spans[j].Start
+	})
+	merged := []MatchSpan{}
+	for _, span := range spans {
+		if len(merged) == 0 || merged[len(merged)-1] != span {
+			merged = append(merged, span)
+		}
+	}
+	return merged
+}
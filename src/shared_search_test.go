@@ -0,0 +1,132 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var searchCatalog = []Product{
+	{ID: 1, Name: "Wireless Headphones", Category: "electronics", Description: "High-quality wireless headphones with noise cancellation"},
+	{ID: 2, Name: "Studio Monitors", Category: "electronics", Description: "Studio speakers for mixing"},
+	{ID: 3, Name: "Phone Case", Category: "electronics", Description: "Slim case for your phone"},
+	{ID: 4, Name: "Headband", Category: "sports", Description: "Sweatband for running"},
+	{ID: 5, Name: "Desk Lamp", Category: "home", Description: "Warm light for the studio"},
+}
+
+func searchIDs(results []SearchResult) []int {
+	var ids []int
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+// TestSearchProductsFuzzy tests misspellings, transpositions and short words
+func TestSearchProductsFuzzy(t *testing.T) {
+	got := SearchProducts(searchCatalog, "hedphones", SearchOptions{})
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("Expected \"hedphones\" to find the headphones, got %+v", got)
+	}
+	// One edit off a name word: 0.45, doubled
+	if got[0].TextScore != 0.9 {
+		t.Errorf("Expected text score 0.9, got %v", got[0].TextScore)
+	}
+	want := []MatchSpan{{"name", 9, 19}, {"description", 22, 32}}
+	if !slices.Equal(got[0].Matches, want) {
+		t.Errorf("Matches = %+v, want %+v", got[0].Matches, want)
+	}
+
+	if got := SearchProducts(searchCatalog, "wirelses", SearchOptions{}); len(got) != 1 || got[0].ID != 1 || got[0].TextScore != 0.9 {
+		t.Errorf("Expected a transposition to count as one edit, got %+v", got)
+	}
+	if got := SearchProducts(searchCatalog, "phnoe", SearchOptions{}); len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("Expected \"phnoe\" to find the phone case, got %+v", got)
+	}
+	if got := SearchProducts(searchCatalog, "hed", SearchOptions{}); len(got) != 0 {
+		t.Errorf("Expected no typo tolerance for short words, got %+v", got)
+	}
+	if got := SearchProducts(searchCatalog, " ,, ", SearchOptions{}); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list for an empty query, got %#v", got)
+	}
+}
+
+// TestSearchProductsRanking tests prefixes, field weights and limits
+func TestSearchProductsRanking(t *testing.T) {
+	// Prefix matches tie, so catalog order decides
+	got := SearchProducts(searchCatalog, "head", SearchOptions{})
+	if ids := searchIDs(got); !slices.Equal(ids, []int{1, 4}) {
+		t.Fatalf("Expected both head- products, got %v", ids)
+	}
+	if got[0].TextScore != 1.6 {
+		t.Errorf("Expected a name prefix to score 1.6, got %v", got[0].TextScore)
+	}
+
+	// A name match outranks a description match
+	got = SearchProducts(searchCatalog, "studio", SearchOptions{})
+	if ids := searchIDs(got); !slices.Equal(ids, []int{2, 5}) {
+		t.Errorf("Expected the monitors before the lamp, got %v", ids)
+	}
+	if want := []MatchSpan{{"name", 0, 6}, {"description", 0, 6}}; !slices.Equal(got[0].Matches, want) {
+		t.Errorf("Matches = %+v, want %+v", got[0].Matches, want)
+	}
+
+	// Scores average over the query words
+	got = SearchProducts(searchCatalog, "phone speakers", SearchOptions{})
+	if ids := searchIDs(got); !slices.Equal(ids, []int{3, 2}) || got[0].TextScore != 1 || got[1].TextScore != 0.5 {
+		t.Errorf("Expected the case at 1 then the monitors at 0.5, got %+v", got)
+	}
+
+	if got := SearchProducts(searchCatalog, "head", SearchOptions{Limit: 1}); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("Expected the limit to apply, got %+v", got)
+	}
+}
+
+// TestSearchProductsSort tests the relevance blend and the sort modes
+func TestSearchProductsSort(t *testing.T) {
+	withTrending(t)
+	SetTrending(nil)
+	catalog := []Product{
+		{ID: 1, Name: "Basic Headphones", Price: 50, Rating: 3.0},
+		{ID: 2, Name: "Studio Headphones", Price: 200, Rating: 4.8},
+		{ID: 3, Name: "Headphone Stand", Price: 20, Rating: 4.0},
+	}
+
+	got := SearchProducts(catalog, "headphones", SearchOptions{})
+	// 0.7 of the text score out of 2, 0.2 of the rating out of 5
+	if ids := searchIDs(got); !slices.Equal(ids, []int{2, 1, 3}) {
+		t.Fatalf("Expected relevance order 2, 1, 3, got %v", ids)
+	}
+	if got[0].Score != 0.892 || got[2].Score != 0.475 || got[2].TextScore != 0.9 {
+		t.Errorf("Unexpected scores %+v", got)
+	}
+
+	for sort, want := range map[string][]int{
+		SortRelevance: {2, 1, 3},
+		SortPriceAsc:  {3, 1, 2},
+		SortPriceDesc: {2, 1, 3},
+		SortRating:    {2, 3, 1},
+		SortNewest:    {3, 2, 1},
+	} {
+		if ids := searchIDs(SearchProducts(catalog, "headphones", SearchOptions{Sort: sort})); !slices.Equal(ids, want) {
+			t.Errorf("Sort %s = %v, want %v", sort, ids, want)
+		}
+	}
+
+	// The best seller gains 0.1
+	SetTrending([]TrendingProduct{{ProductID: 1, Score: 2}, {ProductID: 2, Score: 0.5}})
+	got = SearchProducts(catalog, "headphones", SearchOptions{})
+	if ids := searchIDs(got); !slices.Equal(ids, []int{1, 2, 3}) || got[0].Score != 0.92 {
+		t.Errorf("Expected trending to lift the basic headphones, got %+v", got)
+	}
+}
+
+// TestValidateSearchOptions tests the sort mode and limit
+func TestValidateSearchOptions(t *testing.T) {
+	if result := ValidateSearchOptions(SearchOptions{Sort: SortNewest, Limit: 5}); !result.Valid {
+		t.Errorf("Expected valid options, got %v", result.Errors)
+	}
+	result := ValidateSearchOptions(SearchOptions{Sort: "cheapest", Limit: 50})
+	if result.Valid || len(result.FieldErrors) != 2 || result.FieldErrors[0].Code != CodeUnknownValue {
+		t.Errorf("Expected sort and limit errors, got %+v", result.FieldErrors)
+	}
+}
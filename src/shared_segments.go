@@ -0,0 +1,192 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Shared RFM segmentation - SegmentUsersRFM scores each customer on recency
+// (days since their last order), frequency (orders) and monetary value
+// (spend, in DefaultCurrency). Each is scored 1 to 5 by where the customer
+// ranks among all customers, so the scores adapt to any order history, and
+// recency is measured back from the newest order rather than the clock, as
+// for TrendingProducts. The recency score and the average of the frequency
+// and monetary scores place a customer in a segment: champions bought
+// recently and often, at-risk customers used to buy often but have stopped,
+// hibernating ones bought little, long ago.
+
+// RFM segments, in the order SegmentUsersRFM checks them.
+const (
+	SegmentChampions      = "champions"       // recent, frequent, high spend
+	SegmentLoyal          = "loyal"           // buy regularly
+	SegmentAtRisk         = "at_risk"         // used to buy regularly, not lately
+	SegmentPromising      = "promising"       // recent, not yet frequent
+	SegmentHibernating    = "hibernating"     // bought little, long ago
+	SegmentNeedsAttention = "needs_attention" // middling on every score
+)
+
+// rfmSegments are the segments in the order they are checked and listed.
+var rfmSegments = []string{SegmentChampions, SegmentLoyal, SegmentAtRisk, SegmentPromising, SegmentHibernating, SegmentNeedsAttention}
+
+// CustomerRFM is one customer's RFM values, scores and segment.
+type CustomerRFM struct {
+	UserID         int     `json:"user_id"`
+	Name           string  `json:"name"`
+	LastOrder      string  `json:"last_order"` // YYYY-MM-DD
+	RecencyDays    int     `json:"recency_days"`
+	Orders         int     `json:"orders"`
+	Monetary       float64 `json:"monetary"`
+	RecencyScore   int     `json:"recency_score"`
+	FrequencyScore int     `json:"frequency_score"`
+	MonetaryScore  int     `json:"monetary_score"`
+	Segment        string  `json:"segment"`
+	// MarketingOptIn is set when the customer's preferences let them be
+	// sent marketing
+	MarketingOptIn bool `json:"marketing_opt_in"`
+}
+
+// SegmentSummary is the size and spend of one segment, and how many of its
+// customers a campaign can reach.
+type SegmentSummary struct {
+	Segment         string  `json:"segment"`
+	Customers       int     `json:"customers"`
+	Monetary        float64 `json:"monetary"`
+	MarketingOptIns int     `json:"marketing_opt_ins"`
+}
+
+// RFMSegmentation is every customer's segment and a summary of each segment.
+type RFMSegmentation struct {
+	AsOf      string           `json:"as_of,omitempty"` // the newest order's date
+	Customers []CustomerRFM    `json:"customers"`
+	Segments  []SegmentSummary `json:"segments"`
+}
+
+// rfmSegment places a customer by the recency score and the average of the
+// frequency and monetary scores.
+func rfmSegment(recency, frequency, monetary int) string {
+	value := float64(frequency+monetary) / 2
+	switch {
+	case recency >= 4 && value >= 4:
+		return SegmentChampions
+	case recency >= 3 && value >= 3:
+		return SegmentLoyal
+	case recency <= 2 && value >= 3:
+		return SegmentAtRisk
+	case recency >= 4:
+		return SegmentPromising
+	case recency <= 2:
+		return SegmentHibernating
+	default:
+		return SegmentNeedsAttention
+	}
+}
+
+// quintileScores scores values 1 to 5 by their percentile rank, higher
+// values scoring higher; equal values score the same.
+func quintileScores(values []float64) []int {
+	scores := make([]int, len(values))
+	for i, value := range values {
+		below, equal := 0, 0
+		for _, other := range values {
+			if other < value {
+				below++
+			} else if other == value {
+				equal++
+			}
+		}
+		rank := (float64(below) + float64(equal)/2) / float64(len(values))
+		scores[i] = min(5, 1+int(rank*5))
+	}
+	return scores
+}
+
+// SegmentUsersRFM segments the users with at least one order. Cancelled and
+// undated orders and orders of unknown users are left out; spend in other
+// currencies is converted with the exchange rates in use. Customers are
+// listed best scores first, ties going to the lower user ID.
+func SegmentUsersRFM(users []User, orders []Order) RFMSegmentation {
+	segmentation := RFMSegmentation{Customers: []CustomerRFM{}, Segments: []SegmentSummary{}}
+	byID := map[int]User{}
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	customers := map[int]*CustomerRFM{}
+	lastOrder := map[int]time.Time{}
+	var newest time.Time
+	for _, order := range orders {
+		user, known := byID[order.UserID]
+		date, err := time.Parse(taxDateLayout, order.OrderDate)
+		if !known || err != nil || order.Status == "cancelled" {
+			continue
+		}
+		customer := customers[user.ID]
+		if customer == nil {
+			customer = &CustomerRFM{UserID: user.ID, Name: user.Name, MarketingOptIn: UserPreferences(user).MarketingOptIn}
+			customers[user.ID] = customer
+		}
+		total, err := ConvertPrice(order.Total, OrderCurrency(order), DefaultCurrency)
+		if err != nil {
+			total = order.Total
+		}
+		customer.Orders++
+		customer.Monetary += total
+		if date.After(lastOrder[user.ID]) {
+			lastOrder[user.ID] = date
+		}
+		if date.After(newest) {
+			newest = date
+		}
+	}
+	if len(customers) == 0 {
+		return segmentation
+	}
+	segmentation.AsOf = newest.Format(taxDateLayout)
+
+	for _, customer := range customers {
+		customer.LastOrder = lastOrder[customer.UserID].Format(taxDateLayout)
+		customer.RecencyDays = int(newest.Sub(lastOrder[customer.UserID]).Hours() / 24)
+		customer.Monetary = RoundToCurrency(customer.Monetary, DefaultCurrency)
+		segmentation.Customers = append(segmentation.Customers, *customer)
+	}
+	recency := make([]float64, len(segmentation.Customers))
+	frequency := make([]float64, len(segmentation.Customers))
+	monetary := make([]float64, len(segmentation.Customers))
+	for i, customer := range segmentation.Customers {
+		recency[i] = -float64(customer.RecencyDays)
+		frequency[i] = float64(customer.Orders)
+		monetary[i] = customer.Monetary
+	}
+	recencyScores, frequencyScores, monetaryScores := quintileScores(recency), quintileScores(frequency), quintileScores(monetary)
+
+	summaries := map[string]*SegmentSummary{}
+	for _, segment := range rfmSegments {
+		summaries[segment] = &SegmentSummary{Segment: segment}
+	}
+	for i := range segmentation.Customers {
+		customer := &segmentation.Customers[i]
+		customer.RecencyScore, customer.FrequencyScore, customer.MonetaryScore = recencyScores[i], frequencyScores[i], monetaryScores[i]
+		customer.Segment = rfmSegment(customer.RecencyScore, customer.FrequencyScore, customer.MonetaryScore)
+		summaries[customer.Segment].Customers++
+		summaries[customer.Segment].Monetary += customer.Monetary
+		if customer.MarketingOptIn {
+			summaries[customer.Segment].MarketingOptIns++
+		}
+	}
+	for _, segment := range rfmSegments {
+		summary := *summaries[segment]
+		summary.Monetary = RoundToCurrency(summary.Monetary, DefaultCurrency)
+		segmentation.Segments = append(segmentation.Segments, summary)
+	}
+
+	sort.Slice(segmentation.Customers, func(i, j int) bool {
+		a, b := segmentation.Customers[i], segmentation.Customers[j]
+		scoreA := a.RecencyScore + a.FrequencyScore + a.MonetaryScore
+		scoreB := b.RecencyScore + b.FrequencyScore + b.MonetaryScore
+		if scoreA != scoreB {
+			return scoreA > scoreB
+		}
+		return a.UserID < b.UserID
+	})
+	return segmentation
+}
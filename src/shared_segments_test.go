@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSegmentUsersRFM tests scoring and segmenting a small history
+func TestSegmentUsersRFM(t *testing.T) {
+	var users []User
+	for id := 1; id <= 6; id++ {
+		users = append(users, User{ID: id, Name: string(rune('A' + id - 1))})
+	}
+	// Campaigns can only reach the champion
+	users[0].Preferences = &Preferences{MarketingOptIn: true}
+	users[1].Preferences = &Preferences{FavoriteCategories: []string{"books"}}
+	order := func(userID int, date string, total float64) Order {
+		return Order{UserID: userID, OrderDate: date, Total: total, Status: "delivered"}
+	}
+	orders := []Order{
+		order(1, "2024-06-30", 100), order(1, "2024-06-20", 100), order(1, "2024-06-10", 100), order(1, "2024-05-30", 100),
+		order(2, "2024-01-01", 100), order(2, "2024-01-15", 100), order(2, "2024-02-01", 100),
+		order(3, "2024-06-25", 50),
+		order(4, "2023-06-30", 20),
+		order(5, "2024-03-01", 60), order(5, "2024-04-15", 60),
+		{UserID: 4, OrderDate: "2024-06-29", Total: 500, Status: "cancelled"},
+		order(99, "2024-07-04", 1000),
+	}
+
+	got := SegmentUsersRFM(users, orders)
+	if got.AsOf != "2024-06-30" {
+		t.Errorf("Expected recency measured from the newest order, got %q", got.AsOf)
+	}
+	var ids []int
+	segments := map[int]string{}
+	for _, customer := range got.Customers {
+		ids = append(ids, customer.UserID)
+		segments[customer.UserID] = customer.Segment
+	}
+	// User 6 never ordered
+	if !slices.Equal(ids, []int{1, 2, 5, 3, 4}) {
+		t.Fatalf("Expected customers by total score, got %v", ids)
+	}
+	want := map[int]string{1: SegmentChampions, 2: SegmentAtRisk, 3: SegmentPromising, 4: SegmentHibernating, 5: SegmentLoyal}
+	for id, segment := range want {
+		if segments[id] != segment {
+			t.Errorf("User %d: expected %s, got %s", id, segment, segments[id])
+		}
+	}
+
+	// Users 3 and 4 both ordered once, so share a frequency score
+	at := got.Customers[3]
+	if at.UserID != 3 || at.RecencyDays != 5 || at.Orders != 1 || at.Monetary != 50 || at.RecencyScore != 4 || at.FrequencyScore != 2 || at.MonetaryScore != 2 {
+		t.Errorf("Unexpected scores for user 3: %+v", at)
+	}
+	if got.Customers[4].FrequencyScore != 2 || got.Customers[4].LastOrder != "2023-06-30" {
+		t.Errorf("Unexpected scores for user 4: %+v", got.Customers[4])
+	}
+
+	wantSummary := []SegmentSummary{
+		{SegmentChampions, 1, 400, 1}, {SegmentLoyal, 1, 120, 0}, {SegmentAtRisk, 1, 300, 0},
+		{SegmentPromising, 1, 50, 0}, {SegmentHibernating, 1, 20, 0}, {SegmentNeedsAttention, 0, 0, 0},
+	}
+	if !slices.Equal(got.Segments, wantSummary) {
+		t.Errorf("Segments = %+v, want %+v", got.Segments, wantSummary)
+	}
+
+	empty := SegmentUsersRFM(users, nil)
+	if empty.Customers == nil || empty.Segments == nil || empty.AsOf != "" {
+		t.Errorf("Expected empty lists without orders, got %#v", empty)
+	}
+}
+
+// TestQuintileScores tests ranks, ties and a single value
+func TestQuintileScores(t *testing.T) {
+	if got := quintileScores([]float64{5, 1, 4, 2, 3}); !slices.Equal(got, []int{5, 1, 4, 2, 3}) {
+		t.Errorf("quintileScores() = %v", got)
+	}
+	if got := quintileScores([]float64{7, 7, 7}); !slices.Equal(got, []int{3, 3, 3}) {
+		t.Errorf("Expected equal values to score the middle, got %v", got)
+	}
+	if got := quintileScores([]float64{1}); !slices.Equal(got, []int{3}) {
+		t.Errorf("Expected a lone value to score the middle, got %v", got)
+	}
+}
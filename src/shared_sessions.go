@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Shared sessionization - SessionizeEvents splits each visitor's behavior
+// events into sessions: a visitor is a user, or a session ID for events
+// without one, and a session ends once the visitor is idle for longer than
+// the gap. Each session reports when it started and ended, how much it
+// covered and how far down the funnel it went. Sessions feed the other
+// analytics: SessionFunnel counts a step only when it happens in the same
+// visit as the steps before it, and ScoreChurnRiskWithSessions treats a
+// customer who is still browsing as recent. The server sessionizes the
+// stored events at /api/analytics/sessions; sessionizeEventsWasm does the
+// same on the page.
+
+// DefaultSessionGap is the idle time that ends a session, as in most web
+// analytics.
+const DefaultSessionGap = 30 * time.Minute
+
+// maxSessionGap is the longest gap sessions may be split at.
+const maxSessionGap = 24 * time.Hour
+
+// Session is one visit: its visitor's events from Start to End (RFC 3339).
+// ID is the visitor and the visit's number, as in "user:1#2"; SessionID is
+// the client's session ID of the first event, if any. Products counts the
+// distinct products the visit involved; the flags say which funnel steps it
+// reached.
+type Session struct {
+	ID              string `json:"id"`
+	UserID          int    `json:"user_id,omitempty"`
+	SessionID       string `json:"session_id,omitempty"`
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Events          int    `json:"events"`
+	Views           int    `json:"views"`
+	Clicks          int    `json:"clicks"`
+	Products        int    `json:"products"`
+	AddedToCart     bool   `json:"added_to_cart"`
+	CheckedOut      bool   `json:"checked_out"`
+	Purchased       bool   `json:"purchased"`
+
+	events []BehaviorEvent
+	end    time.Time
+}
+
+// visitor is who a session belongs to: the event's user, else its session.
+func (event BehaviorEvent) visitor() string {
+	if event.UserID > 0 {
+		return fmt.Sprintf("user:%d", event.UserID)
+	}
+	return "session:" + event.SessionID
+}
+
+// SessionizeEvents groups events into sessions split at idle gaps longer
+// than gap, DefaultSessionGap when 0 or less. Events that fail
+// ValidateBehaviorEvent are left out. Sessions are listed by start, ties by
+// ID.
+func SessionizeEvents(events []BehaviorEvent, gap time.Duration) []Session {
+	if gap <= 0 {
+		gap = DefaultSessionGap
+	}
+	type timedEvent struct {
+		at    time.Time
+		event BehaviorEvent
+	}
+	byVisitor := map[string][]timedEvent{}
+	for _, event := range events {
+		if !ValidateBehaviorEvent(event).Valid {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339, event.Time)
+		byVisitor[event.visitor()] = append(byVisitor[event.visitor()], timedEvent{at.UTC(), event})
+	}
+
+	sessions := []Session{}
+	for visitor, timeline := range byVisitor {
+		sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].at.Before(timeline[j].at) })
+		var current *Session
+		var start time.Time
+		var products map[int]bool
+		finish := func() {
+			if current != nil {
+				current.Start, current.End = start.Format(time.RFC3339), current.end.Format(time.RFC3339)
+				current.DurationSeconds = int(current.end.Sub(start).Seconds())
+				current.Products = len(products)
+				sessions = append(sessions, *current)
+			}
+		}
+		visits := 0
+		for _, entry := range timeline {
+			if current == nil || entry.at.Sub(current.end) > gap {
+				finish()
+				visits++
+				current = &Session{ID: fmt.Sprintf("%s#%d", visitor, visits), UserID: entry.event.UserID, SessionID: entry.event.SessionID}
+				start, products = entry.at, map[int]bool{}
+			}
+			current.end = entry.at
+			current.events = append(current.events, entry.event)
+			current.Events++
+			if entry.event.ProductID > 0 {
+				products[entry.event.ProductID] = true
+			}
+			switch entry.event.Type {
+			case EventViewed:
+				current.Views++
+			case EventClicked:
+				current.Clicks++
+			case EventAddedToCart:
+				current.AddedToCart = true
+			case EventCheckedOut:
+				current.CheckedOut = true
+			case EventPurchased:
+				current.Purchased = true
+			}
+		}
+		finish()
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Start != sessions[j].Start {
+			return sessions[i].Start < sessions[j].Start
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+// SessionFunnel is ComputeFunnel with each session from SessionizeEvents as
+// a shopper, so a step only counts when the visit reached the steps before
+// it.
+func SessionFunnel(sessions []Session) []FunnelStep {
+	var events []BehaviorEvent
+	for _, session := range sessions {
+		for _, event := range session.events {
+			event.SessionID = session.ID
+			events = append(events, event)
+		}
+	}
+	return ComputeFunnel(events)
+}
+
+// lastActive is the day of each user's last session.
+func lastActive(sessions []Session) map[int]time.Time {
+	active := map[int]time.Time{}
+	for _, session := range sessions {
+		end, err := time.Parse(time.RFC3339, session.End)
+		if session.UserID <= 0 || err != nil {
+			continue
+		}
+		day := end.UTC().Truncate(24 * time.Hour)
+		if day.After(active[session.UserID]) {
+			active[session.UserID] = day
+		}
+	}
+	return active
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestSessionizeEvents tests splitting at idle gaps, visitors and the
+// session counts and flags
+func TestSessionizeEvents(t *testing.T) {
+	events := []BehaviorEvent{
+		{Type: EventViewed, UserID: 1, ProductID: 1, Time: "2024-01-01T10:00:00Z"},
+		{Type: EventClicked, UserID: 1, Time: "2024-01-01T10:10:00Z"},
+		{Type: EventAddedToCart, UserID: 1, ProductID: 1, Time: "2024-01-01T10:30:00Z"},
+		// Over half an hour later: a second session
+		{Type: EventViewed, UserID: 1, ProductID: 2, Time: "2024-01-01T11:30:00Z"},
+		{Type: EventPurchased, UserID: 1, Time: "2024-01-01T11:40:00Z"},
+		// A guest, listed out of order
+		{Type: EventViewed, SessionID: "g", ProductID: 3, Time: "2024-01-01T09:05:00Z"},
+		{Type: EventViewed, SessionID: "g", ProductID: 4, Time: "2024-01-01T09:00:00Z"},
+		{Type: "hovered", UserID: 1, Time: "2024-01-01T10:20:00Z"},
+	}
+	sessions := SessionizeEvents(events, 0)
+	var ids []string
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	if !slices.Equal(ids, []string{"session:g#1", "user:1#1", "user:1#2"}) {
+		t.Fatalf("Unexpected sessions %v", ids)
+	}
+	guest, first, second := sessions[0], sessions[1], sessions[2]
+	if guest.Start != "2024-01-01T09:00:00Z" || guest.DurationSeconds != 300 || guest.Views != 2 || guest.Products != 2 || guest.SessionID != "g" {
+		t.Errorf("Unexpected guest session %+v", guest)
+	}
+	if first.Events != 3 || first.Clicks != 1 || first.Products != 1 || !first.AddedToCart || first.Purchased || first.DurationSeconds != 1800 {
+		t.Errorf("Unexpected first session %+v", first)
+	}
+	if !second.Purchased || second.AddedToCart || second.End != "2024-01-01T11:40:00Z" {
+		t.Errorf("Unexpected second session %+v", second)
+	}
+
+	if merged := SessionizeEvents(events, 2*time.Hour); len(merged) != 2 {
+		t.Errorf("Expected a two-hour gap to merge the user's visits, got %d sessions", len(merged))
+	}
+	if empty := SessionizeEvents(nil, 0); empty == nil || len(empty) != 0 {
+		t.Errorf("Expected no sessions, got %#v", empty)
+	}
+}
+
+// TestSessionFunnel tests that a step only counts within one visit
+func TestSessionFunnel(t *testing.T) {
+	events := []BehaviorEvent{
+		{Type: EventViewed, UserID: 1, Time: "2024-01-01T10:00:00Z"},
+		{Type: EventAddedToCart, UserID: 1, Time: "2024-01-01T10:05:00Z"},
+		// The next day: a new visit that checks out without adding to cart
+		{Type: EventCheckedOut, UserID: 1, Time: "2024-01-02T10:00:00Z"},
+	}
+	if steps := ComputeFunnel(events); steps[2].Shoppers != 1 {
+		t.Fatalf("Expected the user funnel to reach checkout, got %+v", steps)
+	}
+	steps := SessionFunnel(SessionizeEvents(events, 0))
+	if steps[0].Shoppers != 1 || steps[1].Shoppers != 1 || steps[2].Shoppers != 0 {
+		t.Errorf("Expected the session funnel to stop at the cart, got %+v", steps)
+	}
+}
+
+// TestScoreChurnRiskWithSessions tests that recent browsing lowers the risk
+func TestScoreChurnRiskWithSessions(t *testing.T) {
+	users := []User{{ID: 1, Name: "Recent"}, {ID: 2, Name: "Browsing"}}
+	orders := []Order{
+		{UserID: 1, OrderDate: "2024-06-30", Status: "delivered"},
+		{UserID: 2, OrderDate: "2024-01-01", Status: "delivered"},
+	}
+	sessions := SessionizeEvents([]BehaviorEvent{{Type: EventViewed, UserID: 2, Time: "2024-06-29T18:00:00Z"}}, 0)
+
+	without := ScoreChurnRisk(users, orders)
+	with := ScoreChurnRiskWithSessions(users, orders, sessions)
+	if without[0].UserID != 2 || without[0].Risk != ChurnHigh {
+		t.Fatalf("Expected user 2 at high risk by orders alone, got %+v", without)
+	}
+	var browsing ChurnRisk
+	for _, risk := range with {
+		if risk.UserID == 2 {
+			browsing = risk
+		}
+	}
+	if browsing.LastActive != "2024-06-29" || browsing.DaysSinceOrder != without[0].DaysSinceOrder || browsing.Probability >= 0.3 {
+		t.Errorf("Expected the session to make user 2 recent, got %+v", browsing)
+	}
+}
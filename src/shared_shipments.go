@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Shared shipments - an order can be split into shipments, one per
+// warehouse its products are stocked in, plus a backordered shipment for
+// units the warehouse doesn't have yet. Each shipment is priced as a parcel
+// of its own and has its own status; CalculateOrderTotal charges the order
+// the sum of its shipments.
+
+// Shipment statuses
+const (
+	ShipmentPending     = "pending"
+	ShipmentBackordered = "backordered"
+	ShipmentShipped     = "shipped"
+	ShipmentDelivered   = "delivered"
+)
+
+// shipmentStatuses are the statuses a shipment can be moved between.
+var shipmentStatuses = []string{ShipmentPending, ShipmentBackordered, ShipmentShipped, ShipmentDelivered}
+
+// DefaultWarehouse stocks products that don't name a warehouse.
+const DefaultWarehouse = "main"
+
+// Shipment is the part of an order sent in one parcel.
+type Shipment struct {
+	ID                int        `json:"id"`
+	Warehouse         string     `json:"warehouse"`
+	Items             []CartItem `json:"items"`
+	Status            string     `json:"status"`
+	Shipping          float64    `json:"shipping"`
+	EstimatedDelivery string     `json:"estimated_delivery,omitempty"`
+}
+
+// productWarehouse is the warehouse a product ships from.
+func productWarehouse(product Product) string {
+	if product.Warehouse == "" {
+		return DefaultWarehouse
+	}
+	return product.Warehouse
+}
+
+// SplitOrder splits an order into shipments by warehouse, using the stock in
+// catalog: units beyond what is available go into a backordered shipment
+// from the same warehouse. Products missing from catalog are backordered.
+func SplitOrder(order Order, catalog []Product) []Shipment {
+	type key struct {
+		warehouse   string
+		backordered bool
+	}
+	groups := map[key][]CartItem{}
+	var keys []key
+	add := func(k key, item CartItem) {
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], item)
+	}
+
+	for _, item := range OrderItems(order) {
+		if item.Quantity <= 0 {
+			continue
+		}
+		available := availableUnits(catalog, item)
+		warehouse := DefaultWarehouse
+		if product, ok := findProduct(catalog, item.ProductID); ok {
+			warehouse = productWarehouse(product)
+		}
+		if now := min(item.Quantity, available); now > 0 {
+			add(key{warehouse, false}, CartItem{ProductID: item.ProductID, SKU: item.SKU, Quantity: now})
+		}
+		if later := item.Quantity - available; later > 0 {
+			add(key{warehouse, true}, CartItem{ProductID: item.ProductID, SKU: item.SKU, Quantity: later})
+		}
+	}
+
+	// Ready shipments first, then by warehouse
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].backordered != keys[j].backordered {
+			return !keys[i].backordered
+		}
+		return keys[i].warehouse < keys[j].warehouse
+	})
+	shipments := make([]Shipment, 0, len(keys))
+	for i, k := range keys {
+		status := ShipmentPending
+		if k.backordered {
+			status = ShipmentBackordered
+		}
+		shipments = append(shipments, Shipment{ID: i + 1, Warehouse: k.warehouse, Items: groups[k], Status: status})
+	}
+	return shipments
+}
+
+// ValidateShipments checks that an order's shipments, if any, list exactly
+// the order's units.
+func ValidateShipments(order Order) error {
+	if len(order.Shipments) == 0 {
+		return nil
+	}
+	remaining := map[CartItem]int{}
+	for _, item := range OrderItems(order) {
+		remaining[CartItem{ProductID: item.ProductID, SKU: item.SKU}] += item.Quantity
+	}
+	ids := map[int]bool{}
+	for _, shipment := range order.Shipments {
+		if ids[shipment.ID] {
+			return fmt.Errorf("duplicate shipment ID %d", shipment.ID)
+		}
+		ids[shipment.ID] = true
+		for _, item := range shipment.Items {
+			if item.Quantity <= 0 {
+				return fmt.Errorf("shipment %d has a non-positive quantity", shipment.ID)
+			}
+			line := CartItem{ProductID: item.ProductID, SKU: item.SKU}
+			if _, ok := remaining[line]; !ok {
+				return fmt.Errorf("shipment %d ships %s, which is not in the order", shipment.ID, lineName(line))
+			}
+			remaining[line] -= item.Quantity
+		}
+	}
+	for line, quantity := range remaining {
+		if quantity != 0 {
+			return fmt.Errorf("shipments don't add up to the ordered quantity of %s", lineName(line))
+		}
+	}
+	return nil
+}
+
+// shipmentOrder is the part of an order one shipment carries, for pricing it.
+func shipmentOrder(order Order, shipment Shipment) Order {
+	part := order
+	part.Products, part.Quantities, part.SKUs, part.Shipments = nil, nil, nil, nil
+	lines := OrderItems(order)
+	for _, item := range shipment.Items {
+		if i := slices.IndexFunc(lines, item.sameLine); i >= 0 {
+			part.Products = append(part.Products, order.Products[i])
+			part.Quantities = append(part.Quantities, item.Quantity)
+			part.SKUs = append(part.SKUs, item.SKU)
+		}
+	}
+	return part
+}
+
+// lineName names an order line's product and variant in errors.
+func lineName(line CartItem) string {
+	if line.SKU == "" {
+		return fmt.Sprintf("product %d", line.ProductID)
+	}
+	return fmt.Sprintf("product %d variant %s", line.ProductID, line.SKU)
+}
+
+// priceShipments prices each shipment of an order as its own parcel and
+// returns the total charge and the latest delivery estimate. An order that
+// ships free as a whole ships every shipment free.
+func priceShipments(order *Order, user User, date time.Time, subtotal float64) (float64, string) {
+	country, _ := ShipTo(*order, user)
+	free := CalculateShipping(subtotal, country, user.Premium) == 0 && order.ShippingService == ""
+	total, latest := Money{Currency: order.Currency}, ""
+	for i := range order.Shipments {
+		shipment := &order.Shipments[i]
+		part := shipmentOrder(*order, *shipment)
+		shipment.Shipping, shipment.EstimatedDelivery = shippingCharge(part, user, date)
+		if free {
+			shipment.Shipping = 0
+		}
+		shipment.Shipping = RoundToCurrency(shipment.Shipping, order.Currency)
+		total = total.Add(NewMoney(shipment.Shipping, order.Currency))
+		if shipment.EstimatedDelivery > latest {
+			latest = shipment.EstimatedDelivery
+		}
+	}
+	return total.Float64(), latest
+}
@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+// TestSplitOrder tests splitting by warehouse and available stock
+func TestSplitOrder(t *testing.T) {
+	catalog := []Product{
+		{ID: 1, Name: "Lamp", Price: 30, OnHand: 5},
+		{ID: 2, Name: "Novel", Price: 15, OnHand: 1, Warehouse: "media"},
+		{ID: 3, Name: "Rug", Price: 60, OnHand: 4, Reserved: 4},
+	}
+	order := Order{Products: catalog, Quantities: []int{2, 3, 1}}
+
+	shipments := SplitOrder(order, catalog)
+	want := []Shipment{
+		{ID: 1, Warehouse: "main", Status: ShipmentPending, Items: []CartItem{{ProductID: 1, Quantity: 2}}},
+		{ID: 2, Warehouse: "media", Status: ShipmentPending, Items: []CartItem{{ProductID: 2, Quantity: 1}}},
+		{ID: 3, Warehouse: "main", Status: ShipmentBackordered, Items: []CartItem{{ProductID: 3, Quantity: 1}}},
+		{ID: 4, Warehouse: "media", Status: ShipmentBackordered, Items: []CartItem{{ProductID: 2, Quantity: 2}}},
+	}
+	if len(shipments) != len(want) {
+		t.Fatalf("Expected %d shipments, got %+v", len(want), shipments)
+	}
+	for i := range want {
+		got := shipments[i]
+		if got.ID != want[i].ID || got.Warehouse != want[i].Warehouse || got.Status != want[i].Status ||
+			len(got.Items) != 1 || got.Items[0] != want[i].Items[0] {
+			t.Errorf("Shipment %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	order.Shipments = shipments
+	if err := ValidateShipments(order); err != nil {
+		t.Errorf("Expected the split to cover the order, got %v", err)
+	}
+	order.Shipments = shipments[:3]
+	if err := ValidateShipments(order); err == nil {
+		t.Error("Expected missing units to be reported")
+	}
+}
+
+// TestCalculateOrderTotalShipments tests charging each shipment
+func TestCalculateOrderTotalShipments(t *testing.T) {
+	catalog := []Product{
+		{ID: 1, Name: "Kettle", Price: 20, OnHand: 5, WeightKg: 1},
+		{ID: 2, Name: "Atlas", Price: 25, OnHand: 5, WeightKg: 3, Warehouse: "media"},
+	}
+	order := Order{Products: catalog, Quantities: []int{1, 1}, OrderDate: "2026-10-09"}
+	CalculateOrderTotal(&order, User{Country: "US"})
+	whole := order.Shipping
+
+	order.Shipments = SplitOrder(order, catalog)
+	CalculateOrderTotal(&order, User{Country: "US"})
+	if len(order.Shipments) != 2 || order.Shipments[0].Shipping != 8.99 || order.Shipments[1].Shipping != RoundToCurrency(8.99+2*2, "USD") {
+		t.Fatalf("Expected each parcel priced by its weight, got %+v", order.Shipments)
+	}
+	if order.Shipping != order.Shipments[0].Shipping+order.Shipments[1].Shipping || order.Shipping <= whole {
+		t.Errorf("Expected the order charged the sum of its shipments, got %v (unsplit %v)", order.Shipping, whole)
+	}
+	if order.Total != RoundToCurrency(order.Subtotal+order.Tax+order.Shipping, "USD") {
+		t.Errorf("Expected the total to include shipment charges, got %+v", order)
+	}
+
+	// An order over the free shipping threshold ships every parcel free
+	order.Quantities = []int{3, 2}
+	CalculateOrderTotal(&order, User{Country: "US"})
+	if order.Shipping != 0 || order.Shipments[1].Shipping != 0 {
+		t.Errorf("Expected free shipping over $100, got %v", order.Shipping)
+	}
+}
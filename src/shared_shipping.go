@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Shared shipping engine - carriers offer service levels (standard, express,
+// overnight) to some destinations, each priced from the destination's zone
+// rate, a service multiplier and a per-kilogram charge on the order's
+// billable weight. QuoteShipping lists every option for an order with its
+// price and delivery window; an order that names a carrier and service is
+// charged that option by CalculateOrderTotal, and one that doesn't the
+// cheapest standard service. Only orders of products with no weight or size
+// at all keep the flat CalculateShipping rate.
+//
+// Parcels ship from the US, so other destinations take longer, and prices
+// are quoted in USD and converted to the order's currency.
+
+// Shipping service levels
+const (
+	ServiceStandard  = "standard"
+	ServiceExpress   = "express"
+	ServiceOvernight = "overnight"
+)
+
+const (
+	// MaxProductWeightKg and MaxProductSideCm bound what a product may
+	// declare; no carrier takes heavier or longer parcels.
+	MaxProductWeightKg = 70
+	MaxProductSideCm   = 150
+
+	// shippingOrigin is the country parcels are sent from.
+	shippingOrigin = "US"
+	// defaultWeightKg is assumed for products without a weight.
+	defaultWeightKg = 0.5
+	// volumetricDivisor converts cubic centimetres to a dimensional weight
+	// in kilograms, as carriers bill bulky light parcels.
+	volumetricDivisor = 5000
+	// defaultZoneRate is the zone rate of destinations in no region.
+	defaultZoneRate = 12.99
+)
+
+// shippingZoneRates is the standard rate to each demo country, in USD; other
+// destinations are charged their region's rate (see zoneRate).
+var shippingZoneRates = map[string]float64{
+	"US": 8.99,
+	"CA": 12.99,
+	"UK": 15.99,
+	"DE": 14.99,
+	"FR": 14.99,
+	"JP": 18.99,
+	"AU": 19.99,
+	"IN": 9.99,
+	"BR": 16.99,
+	"MX": 13.99,
+}
+
+// CarrierService is one service level of a carrier.
+type CarrierService struct {
+	Carrier         string   `json:"carrier"`
+	CarrierName     string   `json:"carrier_name"`
+	Level           string   `json:"level"`
+	Countries       []string `json:"countries,omitempty"` // destinations served; empty for all
+	Multiplier      float64  `json:"multiplier"`          // times the zone rate
+	PerKg           float64  `json:"per_kg"`              // per billable kg beyond the first
+	MaxWeightKg     float64  `json:"max_weight_kg"`
+	MinDays         int      `json:"min_days"` // business days, domestic
+	MaxDays         int      `json:"max_days"`
+	ExtraDaysAbroad int      `json:"extra_days_abroad"`
+}
+
+// shippingServices are the carriers' offerings.
+var shippingServices = []CarrierService{
+	{Carrier: "demopost", CarrierName: "DemoPost", Level: ServiceStandard, Multiplier: 1, PerKg: 2, MaxWeightKg: 30, MinDays: 3, MaxDays: 6, ExtraDaysAbroad: 5},
+	{Carrier: "demopost", CarrierName: "DemoPost", Level: ServiceExpress, Multiplier: 1.8, PerKg: 3.5, MaxWeightKg: 30, MinDays: 2, MaxDays: 3, ExtraDaysAbroad: 3},
+	{Carrier: "swiftship", CarrierName: "SwiftShip", Level: ServiceExpress, Multiplier: 2, PerKg: 3, MaxWeightKg: 70, MinDays: 1, MaxDays: 2, ExtraDaysAbroad: 2},
+	{Carrier: "swiftship", CarrierName: "SwiftShip", Level: ServiceOvernight, Countries: []string{"US"}, Multiplier: 3.2, PerKg: 5, MaxWeightKg: 70, MinDays: 1, MaxDays: 1},
+}
+
+// ShippingQuote is one way to ship an order.
+type ShippingQuote struct {
+	Carrier          string  `json:"carrier"`
+	CarrierName      string  `json:"carrier_name"`
+	Service          string  `json:"service"`
+	Price            float64 `json:"price"`
+	Currency         string  `json:"currency"`
+	BillableWeightKg float64 `json:"billable_weight_kg"`
+	EarliestDelivery string  `json:"earliest_delivery"`
+	LatestDelivery   string  `json:"latest_delivery"`
+}
+
+// BillableWeight is the weight an order is charged by: for each product the
+// larger of its weight and its dimensional weight.
+func BillableWeight(order Order) float64 {
+	total := 0.0
+	for i, product := range order.Products {
+		if i >= len(order.Quantities) {
+			continue
+		}
+		weight := product.WeightKg
+		if weight <= 0 {
+			weight = defaultWeightKg
+		}
+		volumetric := product.LengthCm * product.WidthCm * product.HeightCm / volumetricDivisor
+		total += math.Max(weight, volumetric) * float64(order.Quantities[i])
+	}
+	return math.Round(total*100) / 100
+}
+
+// QuoteShipping lists the options for shipping an order to its ShipTo
+// country, cheapest first, with delivery windows counted in business days
+// from date. Standard shipping is free when CalculateShipping would be.
+func QuoteShipping(order Order, user User, date time.Time) []ShippingQuote {
+	country, _ := ShipTo(order, user)
+	country = canonicalCountry(country)
+	baseRate := zoneRate(country)
+	currency := OrderCurrency(order)
+	subtotal := orderSubtotal(order).Float64()
+	weight := BillableWeight(order)
+
+	quotes := []ShippingQuote{}
+	for _, service := range shippingServices {
+		if weight > service.MaxWeightKg || (len(service.Countries) > 0 && !slices.Contains(service.Countries, country)) {
+			continue
+		}
+		charge := NewMoney(baseRate, DefaultCurrency).MulRate(service.Multiplier, RoundHalfUp).
+			Add(NewMoney(service.PerKg, DefaultCurrency).MulRate(math.Max(weight-1, 0), RoundHalfUp))
+		if service.Level == ServiceStandard && CalculateShipping(subtotal, country, user.Premium) == 0 {
+			charge = Money{Currency: DefaultCurrency}
+		}
+		price, _ := ConvertPrice(charge.Float64(), DefaultCurrency, currency)
+
+		minDays, maxDays := service.MinDays, service.MaxDays
+		if country != shippingOrigin {
+			minDays += service.ExtraDaysAbroad
+			maxDays += service.ExtraDaysAbroad
+		}
+		quotes = append(quotes, ShippingQuote{
+			Carrier:          service.Carrier,
+			CarrierName:      service.CarrierName,
+			Service:          service.Level,
+			Price:            price,
+			Currency:         currency,
+			BillableWeightKg: weight,
+			EarliestDelivery: addBusinessDays(date, minDays).Format(taxDateLayout),
+			LatestDelivery:   addBusinessDays(date, maxDays).Format(taxDateLayout),
+		})
+	}
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Price < quotes[j].Price })
+	return quotes
+}
+
+// SelectedShipping finds the quote for the carrier and service an order
+// names. It reports false when the order names none.
+func SelectedShipping(order Order, user User, date time.Time) (ShippingQuote, bool, error) {
+	if order.ShippingCarrier == "" && order.ShippingService == "" {
+		return ShippingQuote{}, false, nil
+	}
+	carrier := strings.ToLower(strings.TrimSpace(order.ShippingCarrier))
+	service := strings.ToLower(strings.TrimSpace(order.ShippingService))
+	for _, quote := range QuoteShipping(order, user, date) {
+		if quote.Carrier == carrier && quote.Service == service {
+			return quote, true, nil
+		}
+	}
+	return ShippingQuote{}, false, fmt.Errorf("%s %s shipping is not available for this order", order.ShippingCarrier, order.ShippingService)
+}
+
+// hasShippingSizes reports whether any product of an order has a weight or
+// parcel size, so its shipping can be priced from its contents.
+func hasShippingSizes(order Order) bool {
+	for _, product := range order.Products {
+		if product.WeightKg > 0 || product.LengthCm > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultShipping is the option charged to an order that hasn't chosen one:
+// the cheapest standard service when the products' weights or sizes are
+// known. It reports false for orders priced at the flat rate instead.
+func DefaultShipping(order Order, user User, date time.Time) (ShippingQuote, bool) {
+	if !hasShippingSizes(order) {
+		return ShippingQuote{}, false
+	}
+	for _, quote := range QuoteShipping(order, user, date) {
+		if quote.Service == ServiceStandard {
+			return quote, true
+		}
+	}
+	return ShippingQuote{}, false
+}
+
+// shippingCharge is the cost of shipping an order in one parcel - the chosen
+// option, else standard shipping for its weight, else the flat rate - with
+// the delivery estimate when one is known.
+func shippingCharge(order Order, user User, date time.Time) (float64, string) {
+	quote, ok, err := SelectedShipping(order, user, date)
+	if !ok && err == nil {
+		quote, ok = DefaultShipping(order, user, date)
+	}
+	if ok {
+		return quote.Price, quote.LatestDelivery
+	}
+
+	country, _ := ShipTo(order, user)
+	subtotal := orderSubtotal(order).Float64()
+	return CalculateShipping(subtotal, country, user.Premium), ""
+}
+
+// orderSubtotal is the net price of an order's lines, before discounts.
+func orderSubtotal(order Order) Money {
+	currency := OrderCurrency(order)
+	subtotal := Money{Currency: currency}
+	for i, product := range order.Products {
+		if i < len(order.Quantities) {
+			subtotal = subtotal.Add(NewMoney(product.UnitPrice(order.Quantities[i]), currency).Times(order.Quantities[i]))
+		}
+	}
+	return subtotal
+}
+
+// addBusinessDays counts days forward from date, skipping weekends.
+func addBusinessDays(date time.Time, days int) time.Time {
+	for days > 0 {
+		date = date.AddDate(0, 0, 1)
+		if date.Weekday() != time.Saturday && date.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return date
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestBillableWeight tests actual and dimensional weight
+func TestBillableWeight(t *testing.T) {
+	order := Order{
+		Products: []Product{
+			{Name: "Book", WeightKg: 0.8},
+			{Name: "Lamp shade", WeightKg: 0.4, LengthCm: 40, WidthCm: 40, HeightCm: 25}, // 8 kg dimensional
+			{Name: "Unweighed"},
+		},
+		Quantities: []int{2, 1, 2},
+	}
+	if got := BillableWeight(order); got != 10.6 {
+		t.Errorf("BillableWeight() = %v, want 10.6", got)
+	}
+}
+
+// TestQuoteShipping tests carrier options, prices and delivery windows
+func TestQuoteShipping(t *testing.T) {
+	friday := time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)
+	order := Order{Products: []Product{{Name: "Boots", Price: 40, WeightKg: 3}}, Quantities: []int{1}}
+
+	quotes := QuoteShipping(order, User{Country: "US"}, friday)
+	if len(quotes) != 4 {
+		t.Fatalf("Expected four US options, got %+v", quotes)
+	}
+	for i := 1; i < len(quotes); i++ {
+		if quotes[i].Price < quotes[i-1].Price {
+			t.Errorf("Expected quotes cheapest first, got %+v", quotes)
+		}
+	}
+	standard := quotes[0]
+	if standard.Service != ServiceStandard || math.Abs(standard.Price-12.99) > 0.001 {
+		t.Errorf("Expected standard at 8.99 + 2 x 2 kg, got %+v", standard)
+	}
+	if standard.EarliestDelivery != "2026-10-14" || standard.LatestDelivery != "2026-10-19" {
+		t.Errorf("Expected 3-6 business days skipping the weekend, got %s to %s", standard.EarliestDelivery, standard.LatestDelivery)
+	}
+
+	for _, quote := range QuoteShipping(order, User{Country: "DE"}, friday) {
+		if quote.Service == ServiceOvernight {
+			t.Error("Expected no overnight service abroad")
+		}
+	}
+
+	free := Order{Products: []Product{{Name: "Boots", Price: 150, WeightKg: 3}}, Quantities: []int{1}}
+	if quotes := QuoteShipping(free, User{Country: "US"}, friday); quotes[0].Price != 0 {
+		t.Errorf("Expected free standard shipping over $100, got %+v", quotes[0])
+	}
+}
+
+// TestCalculateOrderTotalShippingChoice tests charging the chosen option
+func TestCalculateOrderTotalShippingChoice(t *testing.T) {
+	order := Order{
+		Products:        []Product{{Name: "Boots", Price: 40, WeightKg: 3}},
+		Quantities:      []int{1},
+		OrderDate:       "2026-10-09",
+		ShippingCarrier: "swiftship",
+		ShippingService: ServiceOvernight,
+	}
+	CalculateOrderTotal(&order, User{Country: "US"})
+	if order.Shipping != RoundToCurrency(8.99*3.2+5*2, "USD") || order.EstimatedDelivery != "2026-10-12" {
+		t.Errorf("Expected overnight pricing and Monday delivery, got %v and %q", order.Shipping, order.EstimatedDelivery)
+	}
+
+	order.ShippingService = "teleport"
+	if _, _, err := SelectedShipping(order, User{Country: "US"}, orderPlacedDate(order)); err == nil {
+		t.Error("Expected an unavailable service to be reported")
+	}
+}
+
+// TestCalculateOrderTotalWeightShipping tests default shipping by weight
+func TestCalculateOrderTotalWeightShipping(t *testing.T) {
+	light := Order{Products: []Product{{Name: "Socks", Price: 10, WeightKg: 0.2}}, Quantities: []int{1}, OrderDate: "2026-10-09"}
+	heavy := Order{Products: []Product{{Name: "Dumbbell", Price: 10, WeightKg: 10}}, Quantities: []int{1}, OrderDate: "2026-10-09"}
+	CalculateOrderTotal(&light, User{Country: "US"})
+	CalculateOrderTotal(&heavy, User{Country: "US"})
+	if light.Shipping != 8.99 || heavy.Shipping != RoundToCurrency(8.99+2*9, "USD") {
+		t.Errorf("Expected standard shipping by weight, got %v and %v", light.Shipping, heavy.Shipping)
+	}
+	if heavy.EstimatedDelivery != "2026-10-19" {
+		t.Errorf("Expected a standard delivery estimate, got %q", heavy.EstimatedDelivery)
+	}
+
+	unweighed := Order{Products: []Product{{Name: "Socks", Price: 10}}, Quantities: []int{1}}
+	CalculateOrderTotal(&unweighed, User{Country: "US"})
+	if unweighed.Shipping != CalculateShipping(10, "US", false) || unweighed.EstimatedDelivery != "" {
+		t.Errorf("Expected the flat rate without weights, got %v", unweighed.Shipping)
+	}
+}
+
+// TestValidateProductShippingSizes tests weight and dimension limits
+func TestValidateProductShippingSizes(t *testing.T) {
+	base := Product{ID: 1, Name: "Crate", Price: 20, Category: "home", OnHand: 1}
+	tests := []struct {
+		name  string
+		edit  func(*Product)
+		valid bool
+	}{
+		{"no sizes", func(p *Product) {}, true},
+		{"full sizes", func(p *Product) { p.WeightKg, p.LengthCm, p.WidthCm, p.HeightCm = 4, 40, 30, 30 }, true},
+		{"negative weight", func(p *Product) { p.WeightKg = -1 }, false},
+		{"too heavy", func(p *Product) { p.WeightKg = MaxProductWeightKg + 1 }, false},
+		{"partial dimensions", func(p *Product) { p.LengthCm, p.WidthCm = 40, 30 }, false},
+		{"too long", func(p *Product) { p.LengthCm, p.WidthCm, p.HeightCm = MaxProductSideCm+1, 30, 30 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := base
+			tt.edit(&product)
+			if result := ValidateProduct(product); result.Valid != tt.valid {
+				t.Errorf("ValidateProduct() valid = %v, want %v (%v)", result.Valid, tt.valid, result.Errors)
+			}
+		})
+	}
+}
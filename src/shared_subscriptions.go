@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Shared subscriptions - a subscription delivers a quantity of a product
+// every interval. Each renewal is an ordinary order priced by
+// CalculateOrderTotal on the renewal date, so tax rules, tiers and shipping
+// apply as they would to an order placed that day. Changing the product or
+// quantity mid-period is prorated: the unused part of the current period is
+// credited and the same part of the new plan charged.
+
+// Subscription intervals
+const (
+	IntervalWeekly    = "weekly"
+	IntervalMonthly   = "monthly"
+	IntervalQuarterly = "quarterly"
+	IntervalYearly    = "yearly"
+)
+
+// Subscription statuses
+const (
+	SubscriptionActive    = "active"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// maxUpcomingCharges bounds the renewals one preview lists.
+const maxUpcomingCharges = 24
+
+var (
+	subscriptionIntervals = []string{IntervalWeekly, IntervalMonthly, IntervalQuarterly, IntervalYearly}
+	subscriptionStatuses  = []string{SubscriptionActive, SubscriptionPaused, SubscriptionCancelled}
+)
+
+// Subscription is a recurring order of one product. NextRenewal is the
+// taxDateLayout date the next order is placed on.
+type Subscription struct {
+	ID          int     `json:"id"`
+	UserID      int     `json:"user_id"`
+	Product     Product `json:"product"`
+	Quantity    int     `json:"quantity"`
+	Interval    string  `json:"interval"`
+	NextRenewal string  `json:"next_renewal"`
+	Status      string  `json:"status"`
+}
+
+// UpcomingCharge is the order a subscription will place on a date.
+type UpcomingCharge struct {
+	Date     string  `json:"date"`
+	Subtotal float64 `json:"subtotal"`
+	Tax      float64 `json:"tax"`
+	Shipping float64 `json:"shipping"`
+	Discount float64 `json:"discount"`
+	Total    float64 `json:"total"`
+	Currency string  `json:"currency"`
+}
+
+// Proration is the one-off adjustment for changing a subscription on Date,
+// part way through the period ending on PeriodEnd. Net is Charge less
+// Credit, negative when the change is a downgrade.
+type Proration struct {
+	Date           string  `json:"date"`
+	PeriodEnd      string  `json:"period_end"`
+	UnusedFraction float64 `json:"unused_fraction"`
+	Credit         float64 `json:"credit"`
+	Charge         float64 `json:"charge"`
+	Net            float64 `json:"net"`
+	Currency       string  `json:"currency"`
+}
+
+// ValidateSubscription checks a subscription's plan and dates.
+func ValidateSubscription(sub Subscription) ValidationResult {
+	result := newValidationResult()
+
+	if sub.Product.ID == 0 || sub.Product.Price <= 0 {
+		result.AddError("product", CodeRequired, "Subscription must be for a priced product")
+	}
+	if sub.Quantity < 1 || sub.Quantity > MaxCartQuantity {
+		result.AddError("quantity", CodeOutOfRange, fmt.Sprintf("Quantity must be between 1 and %d", MaxCartQuantity))
+	}
+	if !slices.Contains(subscriptionIntervals, sub.Interval) {
+		result.AddError("interval", CodeUnknownValue, fmt.Sprintf("Interval must be one of %v", subscriptionIntervals))
+	}
+	if !slices.Contains(subscriptionStatuses, sub.Status) {
+		result.AddError("status", CodeUnknownValue, fmt.Sprintf("Status must be one of %v", subscriptionStatuses))
+	}
+	if _, err := time.Parse(taxDateLayout, sub.NextRenewal); err != nil {
+		result.AddError("next_renewal", CodeInvalidFormat, "Next renewal must be a YYYY-MM-DD date")
+	}
+
+	return result
+}
+
+// AddInterval moves a date n intervals forward (or back for negative n).
+// Monthly steps keep the day of the month, or take the month's last day
+// when it is shorter, rather than spilling into the next month.
+func AddInterval(date time.Time, interval string, n int) time.Time {
+	months := 0
+	switch interval {
+	case IntervalWeekly:
+		return date.AddDate(0, 0, 7*n)
+	case IntervalMonthly:
+		months = n
+	case IntervalQuarterly:
+		months = 3 * n
+	case IntervalYearly:
+		months = 12 * n
+	}
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
+
+// GenerateRenewalOrder is the pending order a subscription places on its
+// next renewal date.
+func GenerateRenewalOrder(sub Subscription, user User) (Order, error) {
+	if sub.Status != SubscriptionActive {
+		return Order{}, fmt.Errorf("subscription is %s", sub.Status)
+	}
+	if result := ValidateSubscription(sub); !result.Valid {
+		return Order{}, fmt.Errorf("invalid subscription: %v", result.Errors)
+	}
+
+	order := Order{
+		UserID:     sub.UserID,
+		Products:   []Product{sub.Product},
+		Quantities: []int{sub.Quantity},
+		OrderDate:  sub.NextRenewal,
+		Status:     "pending",
+		Currency:   sub.Product.Currency,
+	}
+	order.IncludesTax = PricesIncludeTax(user.Country)
+	CalculateOrderTotal(&order, user)
+	return order, nil
+}
+
+// AdvanceRenewal moves a subscription to its following renewal date.
+func AdvanceRenewal(sub Subscription) Subscription {
+	if next, err := time.Parse(taxDateLayout, sub.NextRenewal); err == nil {
+		sub.NextRenewal = AddInterval(next, sub.Interval, 1).Format(taxDateLayout)
+	}
+	return sub
+}
+
+// UpcomingCharges previews the next count renewals of a subscription.
+func UpcomingCharges(sub Subscription, user User, count int) ([]UpcomingCharge, error) {
+	charges := make([]UpcomingCharge, 0, count)
+	for range count {
+		order, err := GenerateRenewalOrder(sub, user)
+		if err != nil {
+			return nil, err
+		}
+		charges = append(charges, UpcomingCharge{
+			Date:     order.OrderDate,
+			Subtotal: order.Subtotal,
+			Tax:      order.Tax,
+			Shipping: order.Shipping,
+			Discount: order.Discount,
+			Total:    order.Total,
+			Currency: order.Currency,
+		})
+		sub = AdvanceRenewal(sub)
+	}
+	return charges, nil
+}
+
+// ProrateChange is the adjustment for switching sub to change's product and
+// quantity on date. Plans are compared at their pre-tax line price.
+func ProrateChange(sub, change Subscription, date time.Time) (Proration, error) {
+	end, err := time.Parse(taxDateLayout, sub.NextRenewal)
+	if err != nil {
+		return Proration{}, fmt.Errorf("invalid next renewal %q", sub.NextRenewal)
+	}
+	start := AddInterval(end, sub.Interval, -1)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, end.Location())
+	if day.Before(start) || !day.Before(end) {
+		return Proration{}, fmt.Errorf("%s is outside the current period %s to %s",
+			day.Format(taxDateLayout), start.Format(taxDateLayout), end.Format(taxDateLayout))
+	}
+
+	currency := currencyFor(sub.Product.Currency).Code
+	if currencyFor(change.Product.Currency).Code != currency {
+		return Proration{}, fmt.Errorf("cannot change a %s subscription to a %s product", currency, currencyFor(change.Product.Currency).Code)
+	}
+
+	days := func(from, to time.Time) float64 { return math.Round(to.Sub(from).Hours() / 24) }
+	unused := days(day, end) / days(start, end)
+	linePrice := func(s Subscription) float64 { return s.Product.UnitPrice(s.Quantity) * float64(s.Quantity) }
+
+	proration := Proration{
+		Date:           day.Format(taxDateLayout),
+		PeriodEnd:      sub.NextRenewal,
+		UnusedFraction: math.Round(unused*10000) / 10000,
+		Credit:         RoundToCurrency(linePrice(sub)*unused, currency),
+		Charge:         RoundToCurrency(linePrice(change)*unused, currency),
+		Currency:       currency,
+	}
+	proration.Net = RoundToCurrency(proration.Charge-proration.Credit, currency)
+	return proration, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func testSubscription() Subscription {
+	return Subscription{
+		ID:          1,
+		UserID:      1,
+		Product:     Product{ID: 4, Name: "Coffee Beans", Price: 15, Category: "home"},
+		Quantity:    2,
+		Interval:    IntervalMonthly,
+		NextRenewal: "2026-01-31",
+		Status:      SubscriptionActive,
+	}
+}
+
+// TestAddInterval tests renewal date arithmetic
+func TestAddInterval(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(taxDateLayout, s)
+		return d
+	}
+	tests := []struct {
+		from, interval string
+		n              int
+		want           string
+	}{
+		{"2026-01-31", IntervalMonthly, 1, "2026-02-28"},
+		{"2024-01-31", IntervalMonthly, 1, "2024-02-29"},
+		{"2026-03-31", IntervalMonthly, -1, "2026-02-28"},
+		{"2026-11-30", IntervalQuarterly, 1, "2027-02-28"},
+		{"2026-10-14", IntervalWeekly, 2, "2026-10-28"},
+		{"2028-02-29", IntervalYearly, 1, "2029-02-28"},
+	}
+	for _, tt := range tests {
+		if got := AddInterval(day(tt.from), tt.interval, tt.n).Format(taxDateLayout); got != tt.want {
+			t.Errorf("AddInterval(%s, %s, %d) = %s, want %s", tt.from, tt.interval, tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateRenewalOrder tests renewal orders and upcoming charges
+func TestGenerateRenewalOrder(t *testing.T) {
+	sub := testSubscription()
+	user := User{ID: 1, Country: "US"}
+
+	order, err := GenerateRenewalOrder(sub, user)
+	if err != nil {
+		t.Fatalf("GenerateRenewalOrder() error = %v", err)
+	}
+	expected := Order{Products: []Product{sub.Product}, Quantities: []int{2}, OrderDate: "2026-01-31"}
+	CalculateOrderTotal(&expected, user)
+	if order.OrderDate != "2026-01-31" || order.Status != "pending" || order.UserID != 1 || order.Total != expected.Total {
+		t.Errorf("Expected a pending order priced like any other, got %+v", order)
+	}
+
+	charges, err := UpcomingCharges(sub, user, 3)
+	if err != nil || len(charges) != 3 {
+		t.Fatalf("UpcomingCharges() = %+v, %v", charges, err)
+	}
+	if charges[1].Date != "2026-02-28" || charges[2].Date != "2026-03-28" || charges[2].Total != expected.Total {
+		t.Errorf("Unexpected upcoming charges: %+v", charges)
+	}
+
+	sub.Status = SubscriptionPaused
+	if _, err := GenerateRenewalOrder(sub, user); err == nil {
+		t.Error("Expected a paused subscription not to renew")
+	}
+}
+
+// TestProrateChange tests mid-period upgrades and downgrades
+func TestProrateChange(t *testing.T) {
+	sub := testSubscription()
+	sub.NextRenewal = "2026-05-01" // period 2026-04-01 to 2026-05-01, 30 days
+	upgrade := sub
+	upgrade.Quantity = 4
+
+	proration, err := ProrateChange(sub, upgrade, time.Date(2026, 4, 16, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ProrateChange() error = %v", err)
+	}
+	if proration.UnusedFraction != 0.5 || proration.Credit != 15 || proration.Charge != 30 || proration.Net != 15 {
+		t.Errorf("Expected half of each plan's price, got %+v", proration)
+	}
+
+	downgrade := sub
+	downgrade.Quantity = 1
+	if proration, _ := ProrateChange(sub, downgrade, time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)); proration.Net != -7.5 {
+		t.Errorf("Expected a credit for a downgrade, got %+v", proration)
+	}
+	if _, err := ProrateChange(sub, upgrade, time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected a date outside the period to be rejected")
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Shared tax engine - sales tax, VAT and GST rates by jurisdiction. A rule
+// covers a country or one of its states or provinces from an effective date
+// on, and may exempt product categories. For an order line the engine picks
+// the most specific jurisdiction with a rule in force on the order date, so
+// a state without its own rule is taxed at the country rate and a country
+// without any at its standard rate from the country dataset, or
+// defaultTaxRate.
+
+// defaultTaxRate applies to countries with no rule or known standard rate.
+const defaultTaxRate = 0.08
+
+// taxDateLayout is the layout of effective and order dates.
+const taxDateLayout = "2006-01-02"
+
+// TaxRule is the rate of one jurisdiction from Effective (a taxDateLayout
+// date; empty means always) until a later rule for the same jurisdiction.
+type TaxRule struct {
+	Country          string   `json:"country"`
+	Region           string   `json:"region,omitempty"` // state or province; empty for the whole country
+	Rate             float64  `json:"rate"`
+	Effective        string   `json:"effective,omitempty"`
+	ExemptCategories []string `json:"exempt_categories,omitempty"`
+}
+
+// taxRules are listed oldest first within each jurisdiction.
+var taxRules = []TaxRule{
+	{Country: "US", Rate: 0.08}, // national demo rate for addresses without a state
+	{Country: "CA", Rate: 0.13}, // GST+PST
+	{Country: "UK", Rate: 0.175, ExemptCategories: []string{"books"}},
+	{Country: "UK", Rate: 0.20, Effective: "2011-01-04", ExemptCategories: []string{"books"}}, // VAT, books zero-rated
+	{Country: "DE", Rate: 0.19},                                                               // VAT
+	{Country: "FR", Rate: 0.20},                                                               // VAT
+	{Country: "JP", Rate: 0.08},
+	{Country: "JP", Rate: 0.10, Effective: "2019-10-01"}, // consumption tax
+	{Country: "AU", Rate: 0.10},                          // GST
+	{Country: "IN", Rate: 0.18},                          // GST
+	{Country: "BR", Rate: 0.17},                          // ICMS
+	{Country: "MX", Rate: 0.16},                          // IVA
+
+	// US state sales tax (state portion only)
+	{Country: "US", Region: "CA", Rate: 0.0725},
+	{Country: "US", Region: "NY", Rate: 0.04},
+	{Country: "US", Region: "TX", Rate: 0.0625},
+	{Country: "US", Region: "FL", Rate: 0.06},
+	{Country: "US", Region: "WA", Rate: 0.065},
+	{Country: "US", Region: "IL", Rate: 0.0625},
+	{Country: "US", Region: "PA", Rate: 0.06, ExemptCategories: []string{"clothing", "books"}},
+	{Country: "US", Region: "MN", Rate: 0.06875, ExemptCategories: []string{"clothing"}},
+	{Country: "US", Region: "OR", Rate: 0},
+	{Country: "US", Region: "DE", Rate: 0},
+	{Country: "US", Region: "NH", Rate: 0},
+	{Country: "US", Region: "MT", Rate: 0},
+
+	// Canadian GST/HST plus provincial sales tax
+	{Country: "CA", Region: "AB", Rate: 0.05},
+	{Country: "CA", Region: "BC", Rate: 0.12},
+	{Country: "CA", Region: "MB", Rate: 0.12},
+	{Country: "CA", Region: "NB", Rate: 0.15},
+	{Country: "CA", Region: "NL", Rate: 0.15},
+	{Country: "CA", Region: "NS", Rate: 0.15},
+	{Country: "CA", Region: "NS", Rate: 0.14, Effective: "2025-04-01"},
+	{Country: "CA", Region: "NT", Rate: 0.05},
+	{Country: "CA", Region: "NU", Rate: 0.05},
+	{Country: "CA", Region: "ON", Rate: 0.13},
+	{Country: "CA", Region: "PE", Rate: 0.15},
+	{Country: "CA", Region: "QC", Rate: 0.14975},
+	{Country: "CA", Region: "SK", Rate: 0.11},
+	{Country: "CA", Region: "YT", Rate: 0.05},
+}
+
+// euCountries are the demo countries in the EU, where consumer prices are
+// quoted including VAT.
+var euCountries = []string{"DE", "FR"}
+
+// PricesIncludeTax reports whether shoppers in a country see tax-inclusive
+// prices, which sets Order.IncludesTax for them.
+func PricesIncludeTax(country string) bool {
+	return slices.Contains(euCountries, strings.ToUpper(strings.TrimSpace(country)))
+}
+
+// TaxRuleFor finds the rule taxing a country and region on a date.
+func TaxRuleFor(country, region string, date time.Time) (TaxRule, bool) {
+	country = canonicalCountry(country)
+	region = strings.ToUpper(strings.TrimSpace(region))
+	day := date.Format(taxDateLayout)
+
+	find := func(region string) (TaxRule, bool) {
+		var found TaxRule
+		ok := false
+		for _, rule := range taxRules {
+			// Dates in taxDateLayout compare correctly as strings
+			if rule.Country == country && rule.Region == region && rule.Effective <= day {
+				found, ok = rule, true
+			}
+		}
+		return found, ok
+	}
+
+	if region != "" {
+		if rule, ok := find(region); ok {
+			return rule, true
+		}
+	}
+	return find("")
+}
+
+// TaxRateFor is the tax rate on a product category shipped to a country and
+// region on a date.
+func TaxRateFor(country, region, category string, date time.Time) float64 {
+	rule, ok := TaxRuleFor(country, region, date)
+	if !ok {
+		return countryTaxRate(country)
+	}
+	if slices.Contains(rule.ExemptCategories, strings.ToLower(category)) {
+		return 0
+	}
+	return rule.Rate
+}
+
+// GetTaxRate is the current country-wide rate for taxable goods, as used
+// before regional rates existed.
+func GetTaxRate(country string) float64 {
+	return TaxRateFor(country, "", "", time.Now())
+}
+
+// orderPlacedDate is the date an order is taxed and shipped on: its order
+// date, or today for orders not placed yet.
+func orderPlacedDate(order Order) time.Time {
+	if date, err := time.Parse(taxDateLayout, order.OrderDate); err == nil {
+		return date
+	}
+	return time.Now()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTaxRateFor tests jurisdiction, date and category lookups
+func TestTaxRateFor(t *testing.T) {
+	day := func(s string) time.Time {
+		date, _ := time.Parse(taxDateLayout, s)
+		return date
+	}
+	tests := []struct {
+		name                      string
+		country, region, category string
+		date                      string
+		want                      float64
+	}{
+		{"state rate", "US", "CA", "electronics", "2024-06-01", 0.0725},
+		{"no-tax state", "us", "or", "electronics", "2024-06-01", 0},
+		{"state without a rule", "US", "ZZ", "electronics", "2024-06-01", 0.08},
+		{"state exemption", "US", "PA", "clothing", "2024-06-01", 0},
+		{"province", "CA", "QC", "home", "2024-06-01", 0.14975},
+		{"before rate change", "CA", "NS", "home", "2025-03-31", 0.15},
+		{"after rate change", "CA", "NS", "home", "2025-04-01", 0.14},
+		{"country exemption", "UK", "", "books", "2024-06-01", 0},
+		{"country rate", "UK", "", "electronics", "2024-06-01", 0.20},
+		{"historic country rate", "JP", "", "home", "2019-09-30", 0.08},
+		{"unknown country", "ZZ", "", "home", "2024-06-01", defaultTaxRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TaxRateFor(tt.country, tt.region, tt.category, day(tt.date)); got != tt.want {
+				t.Errorf("TaxRateFor(%q, %q, %q, %s) = %v, want %v", tt.country, tt.region, tt.category, tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCalculateOrderTotalRegionalTax tests per-line tax with exemptions
+func TestCalculateOrderTotalRegionalTax(t *testing.T) {
+	order := Order{
+		Products: []Product{
+			{Name: "Jeans", Price: 80, Category: "clothing"},
+			{Name: "Headphones", Price: 20, Category: "electronics"},
+		},
+		Quantities: []int{1, 1},
+		OrderDate:  "2024-06-01",
+	}
+	CalculateOrderTotal(&order, User{Country: "US", Region: "PA", Premium: true})
+
+	// 10% premium discount on $100, then 6% on the discounted headphones only
+	if order.Discount != 10 || order.Tax != 1.08 {
+		t.Errorf("Expected discount 10 and tax 1.08, got %v and %v", order.Discount, order.Tax)
+	}
+}
+
+// TestCalculateOrderTotalIncludesTax tests tax-inclusive display amounts
+func TestCalculateOrderTotalIncludesTax(t *testing.T) {
+	products := []Product{{ID: 1, Name: "Headphones", Price: 60, Category: "electronics"}, {ID: 2, Name: "Mug", Price: 15, Category: "home"}}
+	user := User{Country: "DE", Premium: true}
+
+	net := Order{Products: products, Quantities: []int{1, 2}}
+	CalculateOrderTotal(&net, user)
+	gross := Order{Products: products, Quantities: []int{1, 2}, IncludesTax: PricesIncludeTax(user.Country)}
+	CalculateOrderTotal(&gross, user)
+
+	if !gross.IncludesTax || gross.Total != net.Total {
+		t.Fatalf("Expected the same total in both modes, got %v and %v", net.Total, gross.Total)
+	}
+	if gross.Subtotal != 107.1 || gross.Tax != net.Tax {
+		t.Errorf("Expected a gross subtotal of 107.10 with the same tax, got %+v", gross)
+	}
+	if RoundToCurrency(gross.Subtotal-gross.Discount+gross.Shipping, gross.Currency) != gross.Total {
+		t.Errorf("Expected total = subtotal - discount + shipping, got %+v", gross)
+	}
+
+	want := PriceBreakdown{ProductID: 2, Quantity: 2, TaxRate: 0.19, UnitNet: 15, UnitGross: 17.85, Net: 30, Tax: 5.7, Gross: 35.7}
+	if len(gross.Lines) != 2 || gross.Lines[1] != want {
+		t.Errorf("Expected line breakdown %+v, got %+v", want, gross.Lines)
+	}
+	if PricesIncludeTax("US") {
+		t.Error("Expected US prices to exclude tax")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Shared content-based similarity - products whose names and descriptions
+// use the same distinctive words are alike, whatever their categories.
+// BuildTextIndex turns each product into a TF-IDF vector: how often it uses
+// each word, weighted up for words few other products use, with the name
+// counting twice. SimilarProducts ranks the other products by the cosine of
+// their vectors. The server indexes the catalog for /api/products/{id}, and
+// similarProductsWasm indexes the catalog passed from the page.
+
+// stopWords are too common to say what a product is.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "for": true, "from": true, "in": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// TextIndex holds the TF-IDF vectors of a catalog, unit length.
+type TextIndex struct {
+	products []Product
+	vectors  map[int]map[string]float64
+}
+
+// SimilarProduct is a product with its similarity to another, from 0 to 1.
+type SimilarProduct struct {
+	Product
+	Similarity float64 `json:"similarity"`
+}
+
+// textTerms splits text into lower-case words without stop words, folding
+// simple plurals ("shoes" and "shoe").
+func textTerms(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// BuildTextIndex indexes the names and descriptions of a catalog.
+func BuildTextIndex(catalog []Product) *TextIndex {
+	index := &TextIndex{products: catalog, vectors: map[int]map[string]float64{}}
+	counts := map[int]map[string]int{}
+	documents := map[string]int{}
+	for _, product := range catalog {
+		terms := textTerms(product.Name + " " + product.Name + " " + product.Description)
+		count := map[string]int{}
+		for _, term := range terms {
+			if count[term] == 0 {
+				documents[term]++
+			}
+			count[term]++
+		}
+		counts[product.ID] = count
+	}
+
+	for id, count := range counts {
+		vector := map[string]float64{}
+		norm := 0.0
+		for term, n := range count {
+			// Smoothed IDF stays positive for words every product uses
+			weight := float64(n) * (math.Log(float64(1+len(catalog))/float64(1+documents[term])) + 1)
+			vector[term] = weight
+			norm += weight * weight
+		}
+		for term := range vector {
+			vector[term] /= math.Sqrt(norm)
+		}
+		index.vectors[id] = vector
+	}
+	return index
+}
+
+// SimilarProducts ranks up to n products by how alike their text is to a
+// product's, most similar first and ties going to the lower ID. Products
+// with no words in common are left out, as are all for a product not in the
+// index.
+func (index *TextIndex) SimilarProducts(productID, n int) []SimilarProduct {
+	similar := []SimilarProduct{}
+	target, ok := index.vectors[productID]
+	if !ok {
+		return similar
+	}
+	for _, product := range index.products {
+		if product.ID == productID {
+			continue
+		}
+		score := 0.0
+		for term, weight := range index.vectors[product.ID] {
+			score += weight * target[term]
+		}
+		if score > 0 {
+			similar = append(similar, SimilarProduct{Product: product, Similarity: math.Round(score*10000) / 10000})
+		}
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Similarity != similar[j].Similarity {
+			return similar[i].Similarity > similar[j].Similarity
+		}
+		return similar[i].ID < similar[j].ID
+	})
+	if n >= 0 && len(similar) > n {
+		similar = similar[:n]
+	}
+	return similar
+}
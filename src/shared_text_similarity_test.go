@@ -0,0 +1,51 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestTextTerms tests tokenizing, stop words and plurals
+func TestTextTerms(t *testing.T) {
+	got := textTerms("Running Shoes, for the athletes & a 5K!")
+	if want := []string{"running", "shoe", "athlete", "5k"}; !slices.Equal(got, want) {
+		t.Errorf("textTerms() = %v, want %v", got, want)
+	}
+	if got := textTerms("Glass dress"); !slices.Equal(got, []string{"glass", "dress"}) {
+		t.Errorf("Expected double-s words to be kept, got %v", got)
+	}
+}
+
+// TestSimilarProducts tests TF-IDF ranking across categories
+func TestSimilarProducts(t *testing.T) {
+	catalog := []Product{
+		{ID: 1, Name: "Trail Running Shoes", Category: "sports", Description: "Grippy shoes for muddy trails"},
+		{ID: 2, Name: "Road Running Shoes", Category: "sports", Description: "Light shoes for the road"},
+		{ID: 3, Name: "Running Socks", Category: "clothing", Description: "Cushioned socks for running"},
+		{ID: 4, Name: "Trail Map", Category: "books", Description: "A map of local trails"},
+		{ID: 5, Name: "Coffee Mug", Category: "home", Description: "Ceramic mug"},
+	}
+	index := BuildTextIndex(catalog)
+
+	got := index.SimilarProducts(1, 10)
+	var ids []int
+	for _, similar := range got {
+		ids = append(ids, similar.ID)
+		if similar.Similarity <= 0 || similar.Similarity > 1 {
+			t.Errorf("Expected similarity between 0 and 1, got %v", similar.Similarity)
+		}
+	}
+	// The road shoes share "running shoes", the map the rarer "trail"
+	if want := []int{2, 4, 3}; !slices.Equal(ids, want) {
+		t.Errorf("SimilarProducts(1) = %v, want %v", ids, want)
+	}
+	if got := index.SimilarProducts(1, 1); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("Expected the count to be capped, got %+v", got)
+	}
+	if got := index.SimilarProducts(5, 10); len(got) != 0 {
+		t.Errorf("Expected no similar products without words in common, got %+v", got)
+	}
+	if got := index.SimilarProducts(99, 10); got == nil || len(got) != 0 {
+		t.Errorf("Expected nothing for an unknown product, got %#v", got)
+	}
+}
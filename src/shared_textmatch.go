@@ -0,0 +1,112 @@
+package main
+
+import "strings"
+
+// Shared text matching - the formats validators check (gift card codes,
+// SKUs, postal codes) are written down as regular expressions, and each has
+// a hand-written matcher that accepts exactly the same strings. matchText
+// uses the expression in the standard build and the matcher in the TinyGo
+// and lite builds (shared_textmatch_manual.go), which leave regexp out of
+// the module; shared_textmatch_test.go checks the two agree.
+
+// Character classes of the hand-written matchers.
+const (
+	digitClass        = "0123456789"
+	alphanumericClass = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// matchClasses reports whether text has one byte per class, each in its
+// class.
+func matchClasses(text string, classes ...string) bool {
+	if len(text) != len(classes) {
+		return false
+	}
+	for i, class := range classes {
+		if strings.IndexByte(class, text[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// repeatClass is n copies of class, for matchClasses.
+func repeatClass(class string, n int) []string {
+	classes := make([]string, n)
+	for i := range classes {
+		classes[i] = class
+	}
+	return classes
+}
+
+// matchDigits returns a matcher of exactly n digits.
+func matchDigits(n int) func(string) bool {
+	classes := repeatClass(digitClass, n)
+	return func(text string) bool { return matchClasses(text, classes...) }
+}
+
+// matchGiftCardCode matches giftCardCodePattern.
+func matchGiftCardCode(code string) bool {
+	rest, ok := strings.CutPrefix(code, "GIFT")
+	if !ok || len(rest) != 15 {
+		return false
+	}
+	for i := 0; i < len(rest); i += 5 {
+		if !matchClasses(rest[i:i+5], "-", giftCardAlphabet, giftCardAlphabet, giftCardAlphabet, giftCardAlphabet) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSKU matches skuPattern.
+func matchSKU(sku string) bool {
+	for _, part := range strings.Split(sku, "-") {
+		if part == "" || !matchClasses(part, repeatClass(alphanumericClass, len(part))...) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchUSZip matches a five or nine digit ZIP code.
+func matchUSZip(code string) bool {
+	return matchDigits(5)(code) || matchDigits(9)(code)
+}
+
+// matchCAPostalCode matches a Canadian postal code.
+func matchCAPostalCode(code string) bool {
+	const first, later = "ABCEGHJKLMNPRSTVXY", "ABCEGHJKLMNPRSTVWXYZ"
+	return matchClasses(code, first, digitClass, later, digitClass, later, digitClass)
+}
+
+// matchUKPostcode matches a UK postcode: the inward code is always its last
+// three characters, so the outcode is what comes before them.
+func matchUKPostcode(code string) bool {
+	if code == "GIR0AA" {
+		return true
+	}
+	if len(code) < 5 || !matchClasses(code[len(code)-3:], digitClass, "ABDEFGHJLNPQRSTUWXYZ", "ABDEFGHJLNPQRSTUWXYZ") {
+		return false
+	}
+	const (
+		area           = "ABCDEFGHIJKLMNOPRSTUWYZ"
+		areaSecond     = "ABCDEFGHKLMNOPQRSTUVWXY"
+		districtLetter = "0123456789ABCDEFGHJKPSTUW"
+		subdistrict    = "0123456789ABEHMNPRVWXY"
+	)
+	outcode := code[:len(code)-3]
+	switch len(outcode) {
+	case 2:
+		return matchClasses(outcode, area, digitClass)
+	case 3:
+		return matchClasses(outcode, area, digitClass, districtLetter) || matchClasses(outcode, area, areaSecond, digitClass)
+	case 4:
+		return matchClasses(outcode, area, areaSecond, digitClass, subdistrict)
+	}
+	return false
+}
+
+// matchINPinCode matches an Indian PIN code, which never starts with 0.
+func matchINPinCode(code string) bool {
+	return matchClasses(code, "123456789", digitClass, digitClass, digitClass, digitClass, digitClass)
+}
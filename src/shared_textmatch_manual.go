@@ -0,0 +1,20 @@
+//go:build tinygo || lite
+
+package main
+
+import "errors"
+
+// regexpBuilt reports that regexp is left out.
+const regexpBuilt = false
+
+// matchText reports whether text matches pattern using match, its
+// hand-written equivalent, so the TinyGo and lite builds need no regexp.
+func matchText(pattern string, match func(string) bool, text string) bool {
+	return match(text)
+}
+
+// compileTextPattern turns patterns from data away: without regexp there is
+// no way to match them.
+func compileTextPattern(pattern string) (func(string) bool, error) {
+	return nil, errors.New("patterns are not supported in builds without regexp (tinygo, lite)")
+}
@@ -0,0 +1,35 @@
+//go:build !tinygo && !lite
+
+package main
+
+import (
+	"regexp"
+	"sync"
+)
+
+// regexpBuilt reports that patterns are matched with regexp.
+const regexpBuilt = true
+
+// compiledPatterns caches the expressions matchText has compiled.
+var compiledPatterns sync.Map
+
+// matchText reports whether text matches pattern, a regular expression that
+// is part of the source; match is its hand-written equivalent for the
+// TinyGo and lite builds.
+func matchText(pattern string, match func(string) bool, text string) bool {
+	compiled, ok := compiledPatterns.Load(pattern)
+	if !ok {
+		compiled, _ = compiledPatterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	}
+	return compiled.(*regexp.Regexp).MatchString(text)
+}
+
+// compileTextPattern compiles a pattern that comes from data, such as a
+// validation rule.
+func compileTextPattern(pattern string) (func(string) bool, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.MatchString, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// mutations are the samples with every character replaced, dropped and
+// doubled in turn, and with each of alphabet inserted at every position.
+func mutations(samples []string, alphabet string) []string {
+	texts := append([]string{""}, samples...)
+	for _, sample := range samples {
+		for i := 0; i <= len(sample); i++ {
+			if i < len(sample) {
+				texts = append(texts, sample[:i]+sample[i+1:], sample[:i+1]+sample[i:])
+			}
+			for j := 0; j < len(alphabet); j++ {
+				texts = append(texts, sample[:i]+alphabet[j:j+1]+sample[i:])
+				if i < len(sample) {
+					texts = append(texts, sample[:i]+alphabet[j:j+1]+sample[i+1:])
+				}
+			}
+		}
+	}
+	return texts
+}
+
+const mutationAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ- a"
+
+func checkMatcherAgrees(t *testing.T, name, pattern string, match func(string) bool, samples ...string) {
+	t.Helper()
+	expr := regexp.MustCompile(pattern)
+	for _, sample := range samples {
+		if !match(sample) {
+			t.Errorf("%s: sample %q does not match", name, sample)
+		}
+	}
+	for _, text := range mutations(samples, mutationAlphabet) {
+		if got, want := match(text), expr.MatchString(text); got != want {
+			t.Errorf("%s: match(%q) = %v, the pattern says %v", name, text, got, want)
+		}
+	}
+}
+
+func TestHandWrittenMatchersAgreeWithPatterns(t *testing.T) {
+	checkMatcherAgrees(t, "gift card", giftCardCodePattern, matchGiftCardCode, "GIFT-7Q2M-K9XD-4HPA", "GIFT-2222-ZZZZ-9A9A")
+	checkMatcherAgrees(t, "sku", skuPattern, matchSKU, "TSHIRT-BLK-M", "A", "MUG2-01")
+
+	samples := map[string][]string{
+		"US": {"78701", "787011234"},
+		"CA": {"K1A0B1", "V6B4Y8"},
+		"UK": {"SW1A1AA", "M11AE", "B338TH", "CR26XH", "DN551PT", "W1A0AX", "EC1A1BB", "GIR0AA"},
+		"DE": {"10115"},
+		"FR": {"75008"},
+		"JP": {"1000001"},
+		"AU": {"2000"},
+		"IN": {"110001"},
+		"BR": {"01310100"},
+		"MX": {"06600"},
+	}
+	for country, format := range postalCodeFormats {
+		if len(samples[country]) == 0 {
+			t.Errorf("%s: no samples", country)
+			continue
+		}
+		checkMatcherAgrees(t, country, format.Pattern, format.Match, samples[country]...)
+	}
+}
+
+func TestCompileTextPattern(t *testing.T) {
+	match, err := compileTextPattern(`^[a-z]+$`)
+	if err != nil {
+		t.Fatalf("compileTextPattern: %v", err)
+	}
+	if !match("abc") || match("ab1") {
+		t.Error("compiled pattern matched the wrong strings")
+	}
+	if _, err := compileTextPattern(`(`); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Shared revenue time series - AggregateRevenue buckets an order history by
+// day, week or month for charts: the revenue, order count and average order
+// value of each period. Periods are continuous from the first order to the
+// last, with empty ones at zero, so a chart shows the quiet days too. Weeks
+// start on Monday. Revenue is in DefaultCurrency; cancelled and undated
+// orders are left out. The server serves a range of the stored orders at
+// /api/analytics/timeseries, and aggregateRevenueWasm series orders passed
+// from the page.
+
+// Time series granularities.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// RevenuePoint is one period of a revenue series, starting on Period
+// (YYYY-MM-DD).
+type RevenuePoint struct {
+	Period            string  `json:"period"`
+	Revenue           float64 `json:"revenue"`
+	Orders            int     `json:"orders"`
+	AverageOrderValue float64 `json:"average_order_value"`
+}
+
+// periodStart is the first day of the period a date falls in.
+func periodStart(date time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		// Monday is 0 days back, Sunday 6
+		return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return date
+	}
+}
+
+// nextPeriod is the start of the period after start.
+func nextPeriod(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// AggregateRevenue series an order history by granularity, oldest period
+// first; an empty history has no periods.
+func AggregateRevenue(orders []Order, granularity string) ([]RevenuePoint, error) {
+	if granularity != GranularityDay && granularity != GranularityWeek && granularity != GranularityMonth {
+		return nil, fmt.Errorf("granularity %q must be day, week or month", granularity)
+	}
+
+	type bucket struct {
+		revenue float64
+		orders  int
+	}
+	buckets := map[time.Time]*bucket{}
+	var first, last time.Time
+	for _, order := range orders {
+		date, err := time.Parse(taxDateLayout, order.OrderDate)
+		if err != nil || order.Status == "cancelled" {
+			continue
+		}
+		total, err := ConvertPrice(order.Total, OrderCurrency(order), DefaultCurrency)
+		if err != nil {
+			total = order.Total
+		}
+		start := periodStart(date, granularity)
+		if buckets[start] == nil {
+			buckets[start] = &bucket{}
+		}
+		buckets[start].revenue += total
+		buckets[start].orders++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+
+	points := []RevenuePoint{}
+	if len(buckets) == 0 {
+		return points, nil
+	}
+	for start := first; !start.After(last); start = nextPeriod(start, granularity) {
+		point := RevenuePoint{Period: start.Format(taxDateLayout)}
+		if b := buckets[start]; b != nil {
+			point.Revenue = RoundToCurrency(b.revenue, DefaultCurrency)
+			point.Orders = b.orders
+			point.AverageOrderValue = RoundToCurrency(b.revenue/float64(b.orders), DefaultCurrency)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// ordersBetween keeps the orders dated from from to to, both YYYY-MM-DD and
+// inclusive; an empty bound is open.
+func ordersBetween(orders []Order, from, to string) []Order {
+	var kept []Order
+	for _, order := range orders {
+		if (from == "" || order.OrderDate >= from) && (to == "" || order.OrderDate <= to) {
+			kept = append(kept, order)
+		}
+	}
+	return kept
+}
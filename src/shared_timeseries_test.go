@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var timeseriesOrders = []Order{
+	{OrderDate: "2024-01-01", Total: 100, Status: "delivered"},
+	{OrderDate: "2024-01-03", Total: 50, Status: "delivered"},
+	{OrderDate: "2024-01-10", Total: 30, Status: "shipped"},
+	{OrderDate: "2024-02-15", Total: 60, Status: "pending"},
+	{OrderDate: "2024-01-02", Total: 999, Status: "cancelled"},
+	{Total: 999, Status: "delivered"},
+}
+
+// TestAggregateRevenue tests each granularity and the empty periods
+func TestAggregateRevenue(t *testing.T) {
+	months, err := AggregateRevenue(timeseriesOrders, GranularityMonth)
+	want := []RevenuePoint{{"2024-01-01", 180, 3, 60}, {"2024-02-01", 60, 1, 60}}
+	if err != nil || !slices.Equal(months, want) {
+		t.Errorf("Monthly = %+v, %v; want %+v", months, err, want)
+	}
+
+	weeks, _ := AggregateRevenue(timeseriesOrders, GranularityWeek)
+	if len(weeks) != 7 || weeks[0] != (RevenuePoint{"2024-01-01", 150, 2, 75}) || weeks[1].Revenue != 30 || weeks[6].Period != "2024-02-12" {
+		t.Errorf("Unexpected weekly series %+v", weeks)
+	}
+	if weeks[2] != (RevenuePoint{Period: "2024-01-15"}) {
+		t.Errorf("Expected an empty week at zero, got %+v", weeks[2])
+	}
+
+	days, _ := AggregateRevenue(timeseriesOrders, GranularityDay)
+	// The cancelled order's day stays empty
+	if len(days) != 46 || days[1] != (RevenuePoint{Period: "2024-01-02"}) || days[2].Revenue != 50 {
+		t.Errorf("Unexpected daily series of %d points, starting %+v", len(days), days[:3])
+	}
+
+	if _, err := AggregateRevenue(timeseriesOrders, "hour"); err == nil {
+		t.Error("Expected an unknown granularity to be rejected")
+	}
+	if points, err := AggregateRevenue(nil, GranularityDay); err != nil || points == nil || len(points) != 0 {
+		t.Errorf("Expected an empty series without orders, got %#v, %v", points, err)
+	}
+}
+
+// TestPeriodStart tests week and month starts
+func TestPeriodStart(t *testing.T) {
+	sunday := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	if got := periodStart(sunday, GranularityWeek).Format(taxDateLayout); got != "2024-01-01" {
+		t.Errorf("Expected Sunday in the week from Monday, got %s", got)
+	}
+	if got := periodStart(sunday, GranularityMonth).Format(taxDateLayout); got != "2024-01-01" {
+		t.Errorf("Expected the first of the month, got %s", got)
+	}
+	if got := nextPeriod(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), GranularityDay).Format(taxDateLayout); got != "2024-02-01" {
+		t.Errorf("Expected the next day, got %s", got)
+	}
+}
+
+// TestOrdersBetween tests the inclusive and open bounds
+func TestOrdersBetween(t *testing.T) {
+	if got := ordersBetween(timeseriesOrders, "2024-01-03", "2024-01-10"); len(got) != 2 {
+		t.Errorf("Expected 2 orders in range, got %+v", got)
+	}
+	if got := ordersBetween(timeseriesOrders, "2024-02-01", ""); len(got) != 1 {
+		t.Errorf("Expected 1 order from February, got %+v", got)
+	}
+}
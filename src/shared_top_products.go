@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Shared top products - TopProducts ranks the products in an order history
+// by the revenue they brought, the units sold or the margin kept, with each
+// product's share of the total across all products. A line's revenue is its
+// price times quantity, in DefaultCurrency; its margin is that revenue less
+// the product's UnitCost per unit, or the CLVSettings margin of it for
+// products without a cost. Cancelled orders are left out. The server ranks
+// the stored orders at /api/analytics/top-products and topProductsWasm ranks
+// orders passed from the dashboard.
+
+// Top product metrics.
+const (
+	TopByRevenue = "revenue"
+	TopByUnits   = "units"
+	TopByMargin  = "margin"
+)
+
+// TopProduct is one product's sales. Value is the ranked metric and Share
+// its fraction of that metric over all products.
+type TopProduct struct {
+	Rank      int     `json:"rank"`
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Units     int     `json:"units"`
+	Revenue   float64 `json:"revenue"`
+	Margin    float64 `json:"margin"`
+	Value     float64 `json:"value"`
+	Share     float64 `json:"share"`
+}
+
+// TopProducts ranks the products sold by metric and keeps the first n, all
+// of them when n is 0 or less. Ties go to the lower product ID.
+func TopProducts(orders []Order, n int, metric string) ([]TopProduct, error) {
+	if metric != TopByRevenue && metric != TopByUnits && metric != TopByMargin {
+		return nil, fmt.Errorf("metric %q must be revenue, units or margin", metric)
+	}
+
+	margin := CurrentCLVSettings().Margin
+	byProduct := map[int]*TopProduct{}
+	for _, order := range orders {
+		if order.Status == "cancelled" {
+			continue
+		}
+		for i, product := range order.Products {
+			quantity := 1
+			if i < len(order.Quantities) {
+				quantity = order.Quantities[i]
+			}
+			revenue, err := ConvertPrice(product.Price*float64(quantity), OrderCurrency(order), DefaultCurrency)
+			if err != nil {
+				revenue = product.Price * float64(quantity)
+			}
+			kept := revenue * margin
+			if product.UnitCost > 0 && product.Price > 0 {
+				kept = revenue * (1 - product.UnitCost/product.Price)
+			}
+			entry := byProduct[product.ID]
+			if entry == nil {
+				entry = &TopProduct{ProductID: product.ID, Name: product.Name}
+				byProduct[product.ID] = entry
+			}
+			entry.Units += quantity
+			entry.Revenue += revenue
+			entry.Margin += kept
+		}
+	}
+
+	ranked := []TopProduct{}
+	total := 0.0
+	for _, entry := range byProduct {
+		entry.Revenue = RoundToCurrency(entry.Revenue, DefaultCurrency)
+		entry.Margin = RoundToCurrency(entry.Margin, DefaultCurrency)
+		switch metric {
+		case TopByRevenue:
+			entry.Value = entry.Revenue
+		case TopByUnits:
+			entry.Value = float64(entry.Units)
+		case TopByMargin:
+			entry.Value = entry.Margin
+		}
+		total += entry.Value
+		ranked = append(ranked, *entry)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Value != ranked[j].Value {
+			return ranked[i].Value > ranked[j].Value
+		}
+		return ranked[i].ProductID < ranked[j].ProductID
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	for i := range ranked {
+		entry := &ranked[i]
+		entry.Rank = i + 1
+		if total != 0 {
+			entry.Share = roundRatio(entry.Value / total)
+		}
+	}
+	return ranked, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+var topProductOrders = []Order{
+	{Products: []Product{{ID: 1, Name: "Lamp", Price: 50, UnitCost: 40}, {ID: 2, Name: "Bulb", Price: 5, UnitCost: 1}}, Quantities: []int{2, 10}, Status: "delivered"},
+	{Products: []Product{{ID: 3, Name: "Shade", Price: 20}}, Quantities: []int{2}, Status: "shipped"},
+	{Products: []Product{{ID: 2, Name: "Bulb", Price: 5, UnitCost: 1}}, Quantities: []int{100}, Status: "cancelled"},
+}
+
+// TestTopProducts tests each metric, the shares and the cut-off
+func TestTopProducts(t *testing.T) {
+	withCLVSettings(t)
+	SetCLVSettings(CLVSettings{Margin: 0.5, DiscountRate: 0.1})
+
+	byRevenue, err := TopProducts(topProductOrders, 0, TopByRevenue)
+	if err != nil || len(byRevenue) != 3 {
+		t.Fatalf("Expected three products, got %+v, %v", byRevenue, err)
+	}
+	want := TopProduct{Rank: 1, ProductID: 1, Name: "Lamp", Units: 2, Revenue: 100, Margin: 20, Value: 100, Share: 0.5263}
+	if byRevenue[0] != want {
+		t.Errorf("Top by revenue = %+v, want %+v", byRevenue[0], want)
+	}
+	// Ties go to the lower ID
+	if byRevenue[1].ProductID != 2 || byRevenue[2].ProductID != 3 || byRevenue[2].Share != 0.2105 {
+		t.Errorf("Unexpected revenue ranking %+v", byRevenue)
+	}
+
+	byUnits, _ := TopProducts(topProductOrders, 1, TopByUnits)
+	if len(byUnits) != 1 || byUnits[0].ProductID != 2 || byUnits[0].Value != 10 || byUnits[0].Share != 0.7143 {
+		t.Errorf("Unexpected units ranking %+v", byUnits)
+	}
+
+	// The shade has no cost, so keeps the CLV margin of half
+	byMargin, _ := TopProducts(topProductOrders, 0, TopByMargin)
+	if byMargin[0].ProductID != 2 || byMargin[0].Margin != 40 || byMargin[2].ProductID != 3 || byMargin[2].Margin != 20 {
+		t.Errorf("Unexpected margin ranking %+v", byMargin)
+	}
+
+	if _, err := TopProducts(topProductOrders, 5, "profit"); err == nil {
+		t.Error("Expected an unknown metric to be rejected")
+	}
+	if empty, _ := TopProducts(nil, 5, TopByRevenue); empty == nil || len(empty) != 0 {
+		t.Errorf("Expected an empty ranking, got %#v", empty)
+	}
+}
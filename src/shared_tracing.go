@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shared tracing - every server request and every call from the page into
+// the WASM module is a trace, identified the way W3C Trace Context does: a
+// 32-hex-digit trace ID, and a 16-hex-digit ID per span. The business logic
+// times itself as child spans of the call in its context, so one operation
+// can be compared span by span on both sides. The server reports the spans
+// in the response envelope's meta, the WASM module in the bridge call's
+// result. Records logged with a traced context carry its trace_id and
+// span_id, next to the request_id.
+
+// maxTraceSpans bounds the spans a trace keeps, since a bulk import
+// validates every row as a span of its own.
+const maxTraceSpans = 100
+
+// Span is one timed operation of a trace.
+type Span struct {
+	Name       string  `json:"name"`
+	SpanID     string  `json:"span_id"`
+	ParentID   string  `json:"parent_id,omitempty"`
+	StartMs    float64 `json:"start_ms"` // since the trace started
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Trace collects the spans of one request or call. Its root span is the
+// request or call itself.
+type Trace struct {
+	ID    string
+	start time.Time
+
+	mu       sync.Mutex
+	root     Span
+	finished bool
+	spans    []traceSpan // in the order they started
+	dropped  int         // spans past maxTraceSpans
+}
+
+// traceSpan is a span of a trace, ended or not.
+type traceSpan struct {
+	Span
+	start time.Time
+	ended bool
+}
+
+type activeSpanKey struct{}
+
+// activeSpan is the trace and span a context is in.
+type activeSpan struct {
+	trace *Trace
+	id    string
+}
+
+// randomHex returns n random bytes in hex.
+func randomHex(n int) string {
+	id := make([]byte, n)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// spanMs is d in milliseconds, to the microsecond.
+func spanMs(d time.Duration) float64 {
+	return math.Round(float64(d.Nanoseconds())/1e3) / 1e3
+}
+
+// StartTrace starts a trace whose root span is name. A traceID and
+// parentID, as parsed from a traceparent header, continue the caller's
+// trace under its span; an empty traceID starts a new one.
+func StartTrace(ctx context.Context, name, traceID, parentID string) (context.Context, *Trace) {
+	if traceID == "" {
+		traceID, parentID = randomHex(16), ""
+	}
+	trace := &Trace{
+		ID:    traceID,
+		start: time.Now(),
+		root:  Span{Name: name, SpanID: randomHex(8), ParentID: parentID},
+	}
+	return context.WithValue(ctx, activeSpanKey{}, activeSpan{trace: trace, id: trace.root.SpanID}), trace
+}
+
+// TraceFromContext returns the trace ctx is in, or nil.
+func TraceFromContext(ctx context.Context) *Trace {
+	if span, ok := ctx.Value(activeSpanKey{}).(activeSpan); ok {
+		return span.trace
+	}
+	return nil
+}
+
+// StartSpan starts a span of ctx's trace, a child of ctx's span, returning
+// the span's context and the function that ends it. Outside a trace, or
+// once it holds maxTraceSpans, it does nothing.
+func StartSpan(ctx context.Context, name string) (context.Context, func()) {
+	parent, ok := ctx.Value(activeSpanKey{}).(activeSpan)
+	if !ok {
+		return ctx, func() {}
+	}
+	trace := parent.trace
+	span := traceSpan{Span: Span{Name: name, SpanID: randomHex(8), ParentID: parent.id}, start: time.Now()}
+	span.StartMs = spanMs(span.start.Sub(trace.start))
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if len(trace.spans) == maxTraceSpans {
+		trace.dropped++
+		return ctx, func() {}
+	}
+	index := len(trace.spans)
+	trace.spans = append(trace.spans, span)
+	return context.WithValue(ctx, activeSpanKey{}, activeSpan{trace: trace, id: span.SpanID}), func() {
+		trace.mu.Lock()
+		defer trace.mu.Unlock()
+		trace.spans[index].DurationMs = spanMs(time.Since(span.start))
+		trace.spans[index].ended = true
+	}
+}
+
+// RootSpanID is the ID of the trace's root span.
+func (t *Trace) RootSpanID() string {
+	return t.root.SpanID
+}
+
+// Finish ends the root span. Later calls do nothing.
+func (t *Trace) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.finished {
+		t.root.DurationMs = spanMs(time.Since(t.start))
+		t.finished = true
+	}
+}
+
+// Spans returns the root span, then the others in the order they started.
+// Spans that haven't ended are timed up to now.
+func (t *Trace) Spans() []Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	root := t.root
+	if !t.finished {
+		root.DurationMs = spanMs(time.Since(t.start))
+	}
+	spans := []Span{root}
+	for _, span := range t.spans {
+		if !span.ended {
+			span.DurationMs = spanMs(time.Since(span.start))
+		}
+		spans = append(spans, span.Span)
+	}
+	return spans
+}
+
+// Dropped is the number of spans started after the trace held
+// maxTraceSpans.
+func (t *Trace) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// Traceparent is the trace's root span as a W3C traceparent header value,
+// sampled.
+func (t *Trace) Traceparent() string {
+	return "00-" + t.ID + "-" + t.root.SpanID + "-01"
+}
+
+// ParseTraceparent returns the trace and parent span IDs of a W3C
+// traceparent header value, reporting false if it isn't a valid one.
+func ParseTraceparent(header string) (traceID, parentID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || !isLowerHex(parts[0], 2) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	traceID, parentID = parts[1], parts[2]
+	if !isLowerHex(traceID, 32) || !isLowerHex(parentID, 16) || !isLowerHex(parts[3], 2) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, parentID, true
+}
+
+// isLowerHex reports whether s is n lowercase hex digits.
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{"00-" + traceID + "-" + parentID + "-01", true},
+		{" 00-" + traceID + "-" + parentID + "-00 ", true},
+		// Later versions may append fields
+		{"01-" + traceID + "-" + parentID + "-01-extra", true},
+		{"00-" + traceID + "-" + parentID + "-01-extra", false},
+		{"ff-" + traceID + "-" + parentID + "-01", false},
+		{"00-" + "4BF92F3577B34DA6A3CE929D0E0E4736" + "-" + parentID + "-01", false},
+		{"00-00000000000000000000000000000000-" + parentID + "-01", false},
+		{"00-" + traceID + "-0000000000000000-01", false},
+		{"00-" + traceID + "-" + parentID, false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		gotTrace, gotParent, ok := ParseTraceparent(tt.header)
+		if ok != tt.ok || (ok && (gotTrace != traceID || gotParent != parentID)) {
+			t.Errorf("ParseTraceparent(%q) = %q, %q, %v", tt.header, gotTrace, gotParent, ok)
+		}
+	}
+}
+
+func TestTraceSpans(t *testing.T) {
+	ctx, trace := StartTrace(context.Background(), "POST /api/calculate-order", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	if TraceFromContext(ctx) != trace || trace.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+trace.RootSpanID()+"-01" {
+		t.Fatalf("Expected the trace to continue the caller's, got %s", trace.Traceparent())
+	}
+
+	// The business logic's spans nest under the span of their context
+	outer, end := StartSpan(ctx, "checkout")
+	ValidateUserContext(outer, User{Email: "ann@example.com", Name: "Ann", Age: 30, Country: "US"})
+	end()
+	trace.Finish()
+
+	spans := trace.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %+v, want the request, checkout and ValidateUser", spans)
+	}
+	root, checkout, validate := spans[0], spans[1], spans[2]
+	if root.Name != "POST /api/calculate-order" || root.ParentID != "00f067aa0ba902b7" || root.SpanID != trace.RootSpanID() {
+		t.Errorf("root span = %+v", root)
+	}
+	if checkout.Name != "checkout" || checkout.ParentID != root.SpanID {
+		t.Errorf("checkout span = %+v", checkout)
+	}
+	if validate.Name != "ValidateUser" || validate.ParentID != checkout.SpanID || validate.StartMs < checkout.StartMs {
+		t.Errorf("ValidateUser span = %+v", validate)
+	}
+	if root.DurationMs < checkout.DurationMs || checkout.DurationMs < validate.DurationMs {
+		t.Errorf("Expected each span to last as long as its children: %+v", spans)
+	}
+
+	// Untraced calls record nothing, and a trace keeps maxTraceSpans
+	if same, end := StartSpan(context.Background(), "untraced"); TraceFromContext(same) != nil {
+		t.Error("Expected no trace outside one")
+	} else {
+		end()
+	}
+	ctx, trace = StartTrace(context.Background(), "import", "", "")
+	for i := 0; i < maxTraceSpans+5; i++ {
+		ValidateProductContext(ctx, Product{Name: "Widget", Price: 10, Category: "Electronics"})
+	}
+	if got := len(trace.Spans()); got != maxTraceSpans+1 || trace.Dropped() != 5 {
+		t.Errorf("Expected %d spans and 5 dropped, got %d and %d", maxTraceSpans+1, got, trace.Dropped())
+	}
+}
+
+func TestTraceLogIDs(t *testing.T) {
+	buf := captureLogs(t)
+	ctx, trace := StartTrace(WithRequestID(context.Background(), "req-1"), "call", "", "")
+	spanCtx, end := StartSpan(ctx, "child")
+	slog.InfoContext(spanCtx, "traced")
+	end()
+
+	records := logRecords(t, buf)
+	if len(records) != 1 {
+		t.Fatalf("records = %v", records)
+	}
+	child := trace.Spans()[1]
+	if records[0]["trace_id"] != trace.ID || records[0]["span_id"] != child.SpanID || records[0]["request_id"] != "req-1" {
+		t.Errorf("record = %v, want trace %s and span %s", records[0], trace.ID, child.SpanID)
+	}
+}
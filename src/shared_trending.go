@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Shared trending products - TrendingProducts scores how fast each product is
+// selling: units sold per day over a window, each order weighted by how
+// recent it is so a product that sold last week beats one that sold the
+// same a month ago. The window ends at the newest order rather than the
+// clock, so replayed or demo history trends too. Once a trending list is
+// installed (POST /api/recommendations/rebuild on the server,
+// setTrendingWasm in the browser) RecommendProducts boosts the products on
+// it.
+
+// DefaultTrendingWindow is the window trending lists are built over unless
+// one is given.
+const DefaultTrendingWindow = 30 * 24 * time.Hour
+
+// TrendingProduct is a product's sales in a trending window. Score is the
+// recency-weighted units per day, Units and Orders the plain counts.
+type TrendingProduct struct {
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Units     int     `json:"units"`
+	Orders    int     `json:"orders"`
+	Score     float64 `json:"score"`
+}
+
+var (
+	trendingMu sync.RWMutex
+	trending   []TrendingProduct
+)
+
+// TrendingProducts ranks the products sold in the window before the newest
+// order, fastest selling first. An order's units count in full on its day
+// and half at half the window before; cancelled orders and orders without a
+// date are left out.
+func TrendingProducts(orders []Order, window time.Duration) []TrendingProduct {
+	list := []TrendingProduct{}
+	days := window.Hours() / 24
+	if days <= 0 {
+		return list
+	}
+
+	var dated []Order
+	var newest time.Time
+	for _, order := range orders {
+		date, err := time.Parse(taxDateLayout, order.OrderDate)
+		if err != nil || order.Status == "cancelled" {
+			continue
+		}
+		dated = append(dated, order)
+		if date.After(newest) {
+			newest = date
+		}
+	}
+
+	byProduct := map[int]*TrendingProduct{}
+	for _, order := range dated {
+		date, _ := time.Parse(taxDateLayout, order.OrderDate)
+		age := newest.Sub(date).Hours() / 24
+		if age >= days {
+			continue
+		}
+		weight := math.Pow(0.5, age/(days/2))
+		counted := map[int]bool{}
+		for i, product := range order.Products {
+			quantity := 1
+			if i < len(order.Quantities) {
+				quantity = order.Quantities[i]
+			}
+			entry := byProduct[product.ID]
+			if entry == nil {
+				entry = &TrendingProduct{ProductID: product.ID, Name: product.Name}
+				byProduct[product.ID] = entry
+			}
+			entry.Units += quantity
+			entry.Score += float64(quantity) * weight
+			if !counted[product.ID] {
+				entry.Orders++
+				counted[product.ID] = true
+			}
+		}
+	}
+
+	for _, entry := range byProduct {
+		entry.Score = math.Round(entry.Score/days*10000) / 10000
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].ProductID < list[j].ProductID
+	})
+	return list
+}
+
+// ParseTrending reads a trending list from JSON, as served in the products
+// of /api/analytics/trending.
+func ParseTrending(data []byte) ([]TrendingProduct, error) {
+	var body struct {
+		Products []TrendingProduct `json:"products"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("invalid trending JSON: %w", err)
+	}
+	for _, entry := range body.Products {
+		if entry.Score < 0 || math.IsNaN(entry.Score) {
+			return nil, fmt.Errorf("trending score of %d must not be negative", entry.ProductID)
+		}
+	}
+	return body.Products, nil
+}
+
+// SetTrending replaces the trending list RecommendProducts boosts; an empty
+// list turns the boost off.
+func SetTrending(list []TrendingProduct) {
+	trendingMu.Lock()
+	trending = append([]TrendingProduct{}, list...)
+	trendingMu.Unlock()
+}
+
+// CurrentTrending returns a copy of the trending list in use.
+func CurrentTrending() []TrendingProduct {
+	trendingMu.RLock()
+	defer trendingMu.RUnlock()
+	return append([]TrendingProduct{}, trending...)
+}
+
+// trendingShare is a product's share of the top trending score, which
+// scales its RecommendProducts boost; 0 without a list.
+func trendingShare(productID int) float64 {
+	trendingMu.RLock()
+	defer trendingMu.RUnlock()
+	top := 0.0
+	for _, entry := range trending {
+		top = max(top, entry.Score)
+	}
+	for _, entry := range trending {
+		if entry.ProductID == productID && top > 0 {
+			return entry.Score / top
+		}
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// withTrending restores the trending list after the test.
+func withTrending(t *testing.T) {
+	t.Helper()
+	previous := CurrentTrending()
+	t.Cleanup(func() { SetTrending(previous) })
+}
+
+// TestTrendingProducts tests recency weighting within the window
+func TestTrendingProducts(t *testing.T) {
+	order := func(date, status string, lines map[int]int) Order {
+		order := Order{OrderDate: date, Status: status}
+		for id := 1; id <= 4; id++ {
+			if quantity, ok := lines[id]; ok {
+				order.Products = append(order.Products, Product{ID: id, Name: "P"})
+				order.Quantities = append(order.Quantities, quantity)
+			}
+		}
+		return order
+	}
+	orders := []Order{
+		order("2024-03-31", "delivered", map[int]int{1: 1, 2: 2}),
+		order("2024-03-21", "delivered", map[int]int{1: 2}),
+		order("2024-03-11", "shipped", map[int]int{2: 2, 3: 5}),
+		order("2024-01-01", "delivered", map[int]int{4: 10}), // outside the window
+		order("2024-03-31", "cancelled", map[int]int{3: 10}),
+		order("", "pending", map[int]int{4: 10}),
+	}
+
+	got := TrendingProducts(orders, 20*24*time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 products in the window, got %+v", got)
+	}
+	// Over 20 days with a 10-day half-life: product 1 sold 1 today and 2
+	// ten days ago, and product 2 sold 2 today; the order twenty days ago is
+	// at the edge of the window, so left out, and product 3 with it. Ties go
+	// to the lower ID
+	want := []TrendingProduct{
+		{ProductID: 1, Units: 3, Orders: 2, Score: (1 + 2*0.5) / 20},
+		{ProductID: 2, Units: 2, Orders: 1, Score: 2.0 / 20},
+	}
+	for i, entry := range want {
+		if got[i].ProductID != entry.ProductID || got[i].Units != entry.Units || got[i].Orders != entry.Orders || math.Abs(got[i].Score-entry.Score) > 1e-4 {
+			t.Errorf("Rank %d: expected %+v, got %+v", i, entry, got[i])
+		}
+	}
+	if got := TrendingProducts(orders, 0); got == nil || len(got) != 0 {
+		t.Errorf("Expected nothing for an empty window, got %#v", got)
+	}
+}
+
+// TestTrendingBoost tests that the recommender boosts the installed list
+func TestTrendingBoost(t *testing.T) {
+	withTrending(t)
+	withItemSimilarity(t)
+	SetItemSimilarity(ItemSimilarity{})
+	SetTrending(nil)
+	withCohortPreferences(t)
+	SetCohortPreferences(CohortPreferences{})
+
+	user := User{Age: 30, Country: "US"}
+	catalog := []Product{
+		{ID: 1, Name: "Lamp", Price: 40, Category: "home", OnHand: 5, Rating: 4},
+		{ID: 2, Name: "Rug", Price: 40, Category: "home", OnHand: 5, Rating: 4},
+	}
+	if got := ExplainRecommendations(user, catalog, Order{}, Wishlist{}, RecommendOptions{Weights: DefaultRecommendationWeights}); got[0].ID != 1 {
+		t.Fatalf("Expected ties in catalog order without a list, got %+v", got)
+	}
+
+	list, err := ParseTrending([]byte(`{"days": 30, "products": [{"product_id": 2, "score": 0.5}, {"product_id": 1, "score": 0.25}]}`))
+	if err != nil {
+		t.Fatalf("ParseTrending() error = %v", err)
+	}
+	SetTrending(list)
+	got := ExplainRecommendations(user, catalog, Order{}, Wishlist{}, RecommendOptions{Weights: DefaultRecommendationWeights})
+	if got[0].ID != 2 || got[0].Score-got[1].Score != DefaultRecommendationWeights.Trending/2 {
+		t.Errorf("Expected the top trending product first by half the weight, got %+v", got)
+	}
+
+	if _, err := ParseTrending([]byte(`{"products": [{"product_id": 1, "score": -1}]}`)); err == nil {
+		t.Error("Expected a negative score to be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Shared validation cache - bulk imports are full of repeated records, and
+// validating the same user or product twice gives the same result. A
+// ValidationCache keeps the results of the most recently validated records,
+// keyed by a hash of the record's JSON and of the ruleset in use, and
+// answers repeats from memory. It holds at most its capacity of results,
+// dropping the least recently used first. A nil *ValidationCache validates
+// every record.
+
+// ValidationCacheStats describe a cache: its capacity, the results it
+// holds and has dropped, and its lookups. Hits are the validations it
+// answered, Misses the ones it ran.
+type ValidationCacheStats struct {
+	Capacity  int     `json:"capacity"`
+	Entries   int     `json:"entries"`
+	Evictions int64   `json:"evictions"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+func hitRate(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return math.Round(float64(hits)/float64(hits+misses)*1000) / 1000
+}
+
+// ValidationCache is a bounded LRU of validation results. It is safe for
+// concurrent use.
+type ValidationCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	order    *list.List // of *validationCacheEntry, most recently used first
+	stats    ValidationCacheStats
+}
+
+type validationCacheEntry struct {
+	key    [sha256.Size]byte
+	result ValidationResult
+}
+
+// NewValidationCache returns a cache of up to capacity results, or nil,
+// which caches nothing, when capacity isn't positive.
+func NewValidationCache(capacity int) *ValidationCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &ValidationCache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// ValidateUser is ValidateUser, answered from the cache for a user it has
+// seen under the same ruleset; hit says whether it was.
+func (c *ValidationCache) ValidateUser(user User) (result ValidationResult, hit bool) {
+	return c.validate(SchemaUser, user, func() ValidationResult { return ValidateUser(user) })
+}
+
+// ValidateProduct is ValidateProduct, answered from the cache for a product
+// it has seen under the same ruleset; hit says whether it was.
+func (c *ValidationCache) ValidateProduct(product Product) (result ValidationResult, hit bool) {
+	return c.validate(SchemaProduct, product, func() ValidationResult { return ValidateProduct(product) })
+}
+
+// Stats returns the cache's counts so far.
+func (c *ValidationCache) Stats() ValidationCacheStats {
+	if c == nil {
+		return ValidationCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Capacity = c.capacity
+	stats.Entries = c.order.Len()
+	stats.HitRate = hitRate(stats.Hits, stats.Misses)
+	return stats
+}
+
+// validate looks record up, running validate and keeping its result on a
+// miss. Records that can't be hashed (a NaN price) are validated uncached.
+func (c *ValidationCache) validate(model string, record interface{}, validate func() ValidationResult) (ValidationResult, bool) {
+	if c == nil {
+		return validate(), false
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return validate(), false
+	}
+	hash := sha256.New()
+	hash.Write([]byte(model + "\x00" + strconv.Itoa(currentValidationRulesGeneration()) + "\x00"))
+	hash.Write(data)
+	var key [sha256.Size]byte
+	hash.Sum(key[:0])
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+		result := element.Value.(*validationCacheEntry).result
+		c.mu.Unlock()
+		return cloneValidationResult(result), true
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Validate outside the lock; goroutines missing on one record at once
+	// each validate it, and the first to finish keeps its result
+	result := validate()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return result, false
+	}
+	c.entries[key] = c.order.PushFront(&validationCacheEntry{key: key, result: cloneValidationResult(result)})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*validationCacheEntry).key)
+		c.stats.Evictions++
+	}
+	return result, false
+}
+
+// cloneValidationResult copies a result's slices, so callers can't change
+// the cached one.
+func cloneValidationResult(result ValidationResult) ValidationResult {
+	result.Errors = slices.Clone(result.Errors)
+	result.FieldErrors = slices.Clone(result.FieldErrors)
+	result.Warnings = slices.Clone(result.Warnings)
+	return result
+}
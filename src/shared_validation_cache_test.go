@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidationCache(t *testing.T) {
+	cache := NewValidationCache(2)
+	ann := User{ID: 1, Name: "Ann Lee", Email: "ann@example.com", Age: 30, Country: "DE"}
+	bob := User{ID: 2, Name: "Bob", Email: "not-an-email", Age: 40, Country: "US"}
+
+	result, hit := cache.ValidateUser(bob)
+	if hit || result.Valid || !reflect.DeepEqual(result, ValidateUser(bob)) {
+		t.Fatalf("Expected a miss with ValidateUser's result, got %v %+v", hit, result)
+	}
+	// Changing a result handed out leaves the cached one alone
+	result.Errors[0] = "changed"
+	cached, hit := cache.ValidateUser(bob)
+	if !hit || !reflect.DeepEqual(cached, ValidateUser(bob)) {
+		t.Fatalf("Expected a hit with the cached result, got %v %+v", hit, cached)
+	}
+
+	// Any change to the record is a different payload
+	renamed := bob
+	renamed.Name = "Robert"
+	if _, hit := cache.ValidateUser(renamed); hit {
+		t.Error("Expected a changed record to miss")
+	}
+	// and Ann's result pushes out the least recently used, Bob's
+	cache.ValidateUser(ann)
+	if _, hit := cache.ValidateUser(bob); hit {
+		t.Error("Expected the oldest result to have been dropped")
+	}
+
+	// Users and products are kept apart
+	product := Product{ID: 1, Name: "Lamp", Price: 20, Category: "home"}
+	if result, hit := cache.ValidateProduct(product); hit || !result.Valid {
+		t.Errorf("Expected a valid product from a miss, got %v %+v", hit, result)
+	}
+
+	stats := cache.Stats()
+	if stats.Capacity != 2 || stats.Entries != 2 || stats.Hits != 1 || stats.Misses != 5 || stats.Evictions != 3 || stats.HitRate != 0.167 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// A new ruleset, even the same one again, validates everything afresh
+	if _, hit := cache.ValidateProduct(product); !hit {
+		t.Fatal("Expected the product to be cached")
+	}
+	SetValidationRules(CurrentValidationRules())
+	if _, hit := cache.ValidateProduct(product); hit {
+		t.Error("Expected a ruleset change to miss")
+	}
+}
+
+func TestValidationCacheOff(t *testing.T) {
+	cache := NewValidationCache(0)
+	if cache != nil {
+		t.Fatal("Expected no cache with capacity 0")
+	}
+	user := User{ID: 1, Name: "Ann Lee", Email: "ann@example.com", Age: 30, Country: "DE"}
+	for range 2 {
+		if result, hit := cache.ValidateUser(user); hit || !result.Valid {
+			t.Errorf("Expected every user validated, got %v %+v", hit, result)
+		}
+	}
+	if stats := cache.Stats(); stats != (ValidationCacheStats{}) {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Shared product variants - a product can come in variants, such as sizes
+// and colours, each with its own SKU, a price that differs from the
+// product's by PriceDelta, and its own stock. Every cart, order and
+// shipment line of such a product names the variant by SKU, and the
+// product's OnHand and Reserved are the totals across its variants.
+
+// skuPattern matches SKUs like TSHIRT-BLK-M.
+const skuPattern = `^[A-Z0-9]+(-[A-Z0-9]+)*$`
+
+// ProductVariant is one buyable version of a product.
+type ProductVariant struct {
+	SKU        string  `json:"sku"`
+	Size       string  `json:"size,omitempty"`
+	Color      string  `json:"color,omitempty"`
+	PriceDelta float64 `json:"price_delta,omitempty"`
+	OnHand     int     `json:"on_hand"`
+	Reserved   int     `json:"reserved"`
+}
+
+// Available is the number of units of the variant that can still be sold.
+func (variant ProductVariant) Available() int {
+	return max(variant.OnHand-variant.Reserved, 0)
+}
+
+// Label describes the variant's attributes, as in "L, black".
+func (variant ProductVariant) Label() string {
+	var parts []string
+	for _, attribute := range []string{variant.Size, variant.Color} {
+		if attribute != "" {
+			parts = append(parts, attribute)
+		}
+	}
+	if len(parts) == 0 {
+		return variant.SKU
+	}
+	return strings.Join(parts, ", ")
+}
+
+// variantIndex is the index of the variant with a SKU, or -1.
+func variantIndex(product Product, sku string) int {
+	return slices.IndexFunc(product.Variants, func(variant ProductVariant) bool { return variant.SKU == sku })
+}
+
+// VariantProduct is the product as sold in one variant: priced with the
+// variant's delta, named after its attributes and carrying its stock.
+// Products without variants are sold as they are, with an empty SKU.
+func VariantProduct(product Product, sku string) (Product, error) {
+	if len(product.Variants) == 0 {
+		if sku != "" {
+			return Product{}, fmt.Errorf("%s has no variant %s", product.Name, sku)
+		}
+		return product, nil
+	}
+	if sku == "" {
+		return Product{}, fmt.Errorf("choose a variant of %s", product.Name)
+	}
+	i := variantIndex(product, sku)
+	if i < 0 {
+		return Product{}, fmt.Errorf("%s has no variant %s", product.Name, sku)
+	}
+	variant := product.Variants[i]
+	sold := product
+	sold.Name = fmt.Sprintf("%s (%s)", product.Name, variant.Label())
+	sold.Price = product.Price + variant.PriceDelta
+	sold.OnHand, sold.Reserved = variant.OnHand, variant.Reserved
+	sold.Variants = nil
+	return sold, nil
+}
+
+// SumVariantStock sets a product's OnHand and Reserved to the totals of its
+// variants, if it has any.
+func SumVariantStock(product Product) Product {
+	if len(product.Variants) == 0 {
+		return product
+	}
+	product.OnHand, product.Reserved = 0, 0
+	for _, variant := range product.Variants {
+		product.OnHand += variant.OnHand
+		product.Reserved += variant.Reserved
+	}
+	return product
+}
+
+// validateVariants records what is wrong with a product's variants.
+func validateVariants(product Product, result *ValidationResult) {
+	seen := map[string]bool{}
+	for i, variant := range product.Variants {
+		field := func(name string) string { return fmt.Sprintf("variants[%d].%s", i, name) }
+		switch {
+		case !matchText(skuPattern, matchSKU, variant.SKU):
+			result.AddError(field("sku"), CodeInvalidFormat, fmt.Sprintf("Variant SKU %q must be upper-case letters and digits separated by hyphens", variant.SKU))
+		case seen[variant.SKU]:
+			result.AddError(field("sku"), CodeDuplicate, fmt.Sprintf("Variant SKU %s is used twice", variant.SKU))
+		}
+		seen[variant.SKU] = true
+		if variant.Size == "" && variant.Color == "" {
+			result.AddError(field("size"), CodeRequired, fmt.Sprintf("Variant %s must have a size or color", variant.SKU))
+		}
+		if product.Price+variant.PriceDelta <= 0 {
+			result.AddError(field("price_delta"), CodeOutOfRange, fmt.Sprintf("Variant %s must have a price greater than 0", variant.SKU))
+		}
+		if variant.OnHand < 0 || variant.Reserved < 0 || variant.Reserved > variant.OnHand {
+			result.AddError(field("on_hand"), CodeOutOfRange, fmt.Sprintf("Variant %s stock must not be negative and reserved units must not exceed units on hand", variant.SKU))
+		}
+	}
+	if len(product.Variants) > 0 {
+		if totals := SumVariantStock(product); totals.OnHand != product.OnHand || totals.Reserved != product.Reserved {
+			result.AddError("on_hand", CodeInconsistent, "Product stock must be the total of its variants' stock")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// variantProduct is a product sold in two sizes, the larger costing more
+func variantProduct() Product {
+	return SumVariantStock(Product{
+		ID: 10, Name: "Hoodie", Price: 40, Category: "clothing", Rating: 4,
+		Variants: []ProductVariant{
+			{SKU: "HOODIE-M", Size: "M", Color: "grey", OnHand: 5},
+			{SKU: "HOODIE-XL", Size: "XL", Color: "grey", PriceDelta: 4, OnHand: 2},
+		},
+	})
+}
+
+// TestVariantProduct tests resolving a product to one of its variants
+func TestVariantProduct(t *testing.T) {
+	product := variantProduct()
+	if product.OnHand != 7 {
+		t.Fatalf("Expected stock totalled across variants, got %d", product.OnHand)
+	}
+
+	sold, err := VariantProduct(product, "HOODIE-XL")
+	if err != nil {
+		t.Fatalf("VariantProduct() error = %v", err)
+	}
+	if sold.Price != 44 || sold.Name != "Hoodie (XL, grey)" || sold.OnHand != 2 || sold.Variants != nil {
+		t.Errorf("Unexpected variant product: %+v", sold)
+	}
+	for _, sku := range []string{"", "HOODIE-S"} {
+		if _, err := VariantProduct(product, sku); err == nil {
+			t.Errorf("Expected an error for SKU %q", sku)
+		}
+	}
+	if _, err := VariantProduct(testProducts[0], "HEADPHONES-RED"); err == nil {
+		t.Error("Expected an error naming a variant of a product without variants")
+	}
+}
+
+// TestValidateProductVariants tests the checks on a product's variants
+func TestValidateProductVariants(t *testing.T) {
+	if result := ValidateProduct(variantProduct()); !result.Valid {
+		t.Fatalf("Expected a valid product, got %v", result.Errors)
+	}
+
+	for name, change := range map[string]func(p *Product){
+		"bad sku":        func(p *Product) { p.Variants[0].SKU = "hoodie m" },
+		"duplicate sku":  func(p *Product) { p.Variants[1].SKU = p.Variants[0].SKU },
+		"no attributes":  func(p *Product) { p.Variants[0].Size, p.Variants[0].Color = "", "" },
+		"free variant":   func(p *Product) { p.Variants[0].PriceDelta = -40 },
+		"over reserved":  func(p *Product) { p.Variants[1].Reserved = 3 },
+		"wrong total":    func(p *Product) { p.OnHand = 100 },
+		"negative stock": func(p *Product) { p.Variants[0].OnHand = -1 },
+	} {
+		product := variantProduct()
+		product.Variants = append([]ProductVariant(nil), product.Variants...)
+		change(&product)
+		if ValidateProduct(product).Valid {
+			t.Errorf("%s: expected the product to be invalid", name)
+		}
+	}
+}
+
+// TestCartVariantLines tests pricing, reserving and shipping variant lines
+func TestCartVariantLines(t *testing.T) {
+	catalog := []Product{variantProduct(), testProducts[0]}
+	now := time.Now()
+
+	if ValidateCartItem(CartItem{ProductID: 10, Quantity: 1}, catalog).Valid {
+		t.Error("Expected a line without a variant to be refused")
+	}
+	if ValidateCartItem(CartItem{ProductID: 10, SKU: "HOODIE-XL", Quantity: 3}, catalog).Valid {
+		t.Error("Expected the XL's own stock to limit the line")
+	}
+
+	var cart Cart
+	cart.AddItem(catalog[0], "HOODIE-M", 1, now)
+	cart.AddItem(catalog[0], "HOODIE-XL", 2, now)
+	cart.AddItem(catalog[0], "HOODIE-M", 1, now)
+	if len(cart.Items) != 2 || cart.Items[0].Quantity != 2 || cart.Items[1].PriceAtAdd != 44 {
+		t.Fatalf("Expected a line per variant, got %+v", cart.Items)
+	}
+
+	order := CartToOrder(cart, catalog, User{Country: "US"})
+	if order.Subtotal != 2*40+2*44 || len(order.SKUs) != 2 || order.SKUs[1] != "HOODIE-XL" {
+		t.Errorf("Expected each variant priced on its own, got %+v", order)
+	}
+
+	reservations := StockReservations{}
+	if err := ReserveStock(catalog, reservations, 1, OrderItems(order)); err != nil {
+		t.Fatalf("ReserveStock() error = %v", err)
+	}
+	if catalog[0].Reserved != 4 || catalog[0].Variants[1].Available() != 0 {
+		t.Errorf("Expected the variants and the product total reserved, got %+v", catalog[0])
+	}
+	if err := ReserveStock(catalog, reservations, 2, []CartItem{{ProductID: 10, SKU: "HOODIE-XL", Quantity: 1}}); err == nil {
+		t.Error("Expected the sold-out XL to be refused")
+	}
+	if err := CommitStock(catalog, reservations, 1); err != nil || catalog[0].OnHand != 3 || catalog[0].Variants[0].OnHand != 3 {
+		t.Errorf("Expected committed units to leave the variants, got %v %+v", err, catalog[0])
+	}
+
+	order.Shipments = SplitOrder(order, []Product{variantProduct()})
+	if err := ValidateShipments(order); err != nil || order.Shipments[0].Items[1].SKU != "HOODIE-XL" {
+		t.Errorf("Expected shipments to carry the variant lines, got %v %+v", err, order.Shipments)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+//go:generate go run gen_wasm_api.go shared_wasm_api.go
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The WASM API registry - every business logic function registerBusinessLogic
+// exposes to JavaScript, with the arguments it takes. gen_wasm_api.go renders
+// it into assets/js/wasm-api.js, whose window.wasmApi wraps each function in
+// one that checks its arguments, stringifies objects passed for JSON, waits
+// for the module and rejects with the result's error; shared_wasm_api_test.go
+// fails when the registry, the registrations and the generated file drift.
+// The file builds on its own for go:generate, so it uses nothing else in the
+// package.
+
+// Kinds of WASM API arguments.
+const (
+	wasmArgJSON    = "json"    // an object or a JSON string, passed as a string
+	wasmArgString  = "string"  // a string
+	wasmArgNumber  = "number"  // a finite number
+	wasmArgInteger = "integer" // a whole number
+)
+
+// WasmParam is one argument of a WASM API function.
+type WasmParam struct {
+	Name     string
+	Kind     string
+	Optional bool // only trailing arguments are optional
+}
+
+// WasmFunction is one function of the WASM API.
+type WasmFunction struct {
+	Name   string // as registered, ending in Wasm
+	Doc    string
+	Params []WasmParam
+}
+
+// JSName is the function's name on window.wasmApi, without the Wasm suffix.
+func (fn WasmFunction) JSName() string {
+	return strings.TrimSuffix(fn.Name, "Wasm")
+}
+
+// jsonArgs are required JSON arguments of the given names.
+func jsonArgs(names ...string) []WasmParam {
+	params := make([]WasmParam, len(names))
+	for i, name := range names {
+		params[i] = WasmParam{Name: name, Kind: wasmArgJSON}
+	}
+	return params
+}
+
+// withArgs appends more arguments to params.
+func withArgs(params []WasmParam, more ...WasmParam) []WasmParam {
+	return append(params, more...)
+}
+
+// wasmAPI lists the functions in the order registerBusinessLogic registers
+// them.
+var wasmAPI = []WasmFunction{
+	{Name: "validateUserWasm", Doc: "Validates a user with the server's rules.", Params: jsonArgs("user")},
+	{Name: "validateProductWasm", Doc: "Validates a product with the server's rules.", Params: jsonArgs("product")},
+	{Name: "validatePhoneWasm", Doc: "Checks a phone number for a country and returns it in E.164 form.", Params: []WasmParam{{Name: "phone", Kind: wasmArgString}, {Name: "country", Kind: wasmArgString}}},
+	{Name: "validatePostalCodeWasm", Doc: "Checks a postal code for a country and returns it in canonical form.", Params: []WasmParam{{Name: "code", Kind: wasmArgString}, {Name: "country", Kind: wasmArgString}}},
+	{Name: "validatePasswordWasm", Doc: "Rates a password with the rules the server applies.", Params: []WasmParam{{Name: "password", Kind: wasmArgString}}},
+	{Name: "setUserPreferencesWasm", Doc: "Checks preferences as the server does and returns the user JSON with them set.", Params: jsonArgs("user", "preferences")},
+	{Name: "lookupCountryWasm", Doc: "Finds a country by alpha-2 or alpha-3 code.", Params: []WasmParam{{Name: "code", Kind: wasmArgString}}},
+	{Name: "calculateOrderTotalWasm", Doc: "Prices an order for a user, optionally paying with a gift card.", Params: withArgs(jsonArgs("order", "user"), WasmParam{Name: "giftCard", Kind: wasmArgJSON, Optional: true})},
+	{Name: "recommendProductsWasm", Doc: "Recommends products for a user and order; pass an empty wishlist string to give only options.", Params: withArgs(jsonArgs("user", "products", "order"), WasmParam{Name: "wishlist", Kind: wasmArgJSON, Optional: true}, WasmParam{Name: "options", Kind: wasmArgJSON, Optional: true})},
+	{Name: "recommendCrossSellsWasm", Doc: "Recommends accessories for an order's products.", Params: jsonArgs("order", "products")},
+	{Name: "recommendUpsellsWasm", Doc: "Recommends higher-tier alternatives to a product.", Params: jsonArgs("product", "products")},
+	{Name: "similarProductsWasm", Doc: "Finds the count products most like one by name and description.", Params: withArgs(jsonArgs("products"), WasmParam{Name: "productId", Kind: wasmArgInteger}, WasmParam{Name: "count", Kind: wasmArgInteger})},
+	{Name: "selectThumbnailWasm", Doc: "Picks the picture of a product to show in a tile of size pixels square, or null.", Params: withArgs(jsonArgs("product"), WasmParam{Name: "size", Kind: wasmArgInteger})},
+	{Name: "searchProductsWasm", Doc: "Searches products typo-tolerantly, with options picking the sort and limit.", Params: withArgs(jsonArgs("products"), WasmParam{Name: "query", Kind: wasmArgString}, WasmParam{Name: "options", Kind: wasmArgJSON, Optional: true})},
+	{Name: "filterProductsWasm", Doc: "Selects the products a filter matches, with the facets to narrow them.", Params: jsonArgs("products", "filter")},
+	{Name: "mineAssociationsWasm", Doc: "Mines frequently bought together rules, optionally with a minimum support and confidence.", Params: withArgs(jsonArgs("orders"), WasmParam{Name: "minSupport", Kind: wasmArgNumber, Optional: true}, WasmParam{Name: "minConfidence", Kind: wasmArgNumber, Optional: true})},
+	{Name: "trendingProductsWasm", Doc: "Ranks products by recency-weighted sales velocity over an optional window in days.", Params: withArgs(jsonArgs("orders"), WasmParam{Name: "days", Kind: wasmArgInteger, Optional: true})},
+	{Name: "segmentUsersRFMWasm", Doc: "Segments customers by recency, frequency and monetary value.", Params: jsonArgs("users", "orders")},
+	{Name: "scoreChurnRiskWasm", Doc: "Scores each customer's churn probability, optionally only those above a minimum.", Params: withArgs(jsonArgs("users", "orders"), WasmParam{Name: "minProbability", Kind: wasmArgNumber, Optional: true})},
+	{Name: "calculateCLVWasm", Doc: "Projects a user's lifetime value over an optional horizon in months.", Params: withArgs(jsonArgs("user", "orders"), WasmParam{Name: "months", Kind: wasmArgInteger, Optional: true})},
+	{Name: "aggregateRevenueWasm", Doc: "Aggregates revenue by day, week or month, optionally only from one date to another.", Params: withArgs(jsonArgs("orders"), WasmParam{Name: "granularity", Kind: wasmArgString}, WasmParam{Name: "from", Kind: wasmArgString, Optional: true}, WasmParam{Name: "to", Kind: wasmArgString, Optional: true})},
+	{Name: "forecastRevenueWasm", Doc: "Forecasts the revenue series, with optional settings for horizon, seasons and smoothing.", Params: withArgs(jsonArgs("orders"), WasmParam{Name: "granularity", Kind: wasmArgString}, WasmParam{Name: "settings", Kind: wasmArgJSON, Optional: true})},
+	{Name: "computeFunnelWasm", Doc: "Follows an event log from viewed to purchased.", Params: jsonArgs("events")},
+	{Name: "sessionizeEventsWasm", Doc: "Splits an event log into sessions at an optional idle gap in minutes.", Params: withArgs(jsonArgs("events"), WasmParam{Name: "gapMinutes", Kind: wasmArgInteger, Optional: true})},
+	{Name: "trackEventWasm", Doc: "Buffers a behavior event and reports whether a batch is due.", Params: jsonArgs("event")},
+	{Name: "flushEventsWasm", Doc: "Takes the oldest buffered events as the body of a POST /api/events."},
+	{Name: "topProductsWasm", Doc: "Ranks the first count best sellers by revenue, units or margin.", Params: withArgs(jsonArgs("orders"), WasmParam{Name: "count", Kind: wasmArgInteger}, WasmParam{Name: "metric", Kind: wasmArgString})},
+	{Name: "buildHistogramWasm", Doc: "Buckets values by the lower edges of a bucket spec.", Params: jsonArgs("values", "spec")},
+	{Name: "assignVariantWasm", Doc: "Assigns a user to an experiment's variant, as the server does.", Params: withArgs(jsonArgs("experiment"), WasmParam{Name: "userId", Kind: wasmArgInteger})},
+	{Name: "analyzeExperimentWasm", Doc: "Measures the conversion lift of an experiment's variants.", Params: jsonArgs("experiment", "users", "orders")},
+	{Name: "analyzeUserBehaviorWasm", Doc: "Analyzes users and orders, optionally through a filter.", Params: withArgs(jsonArgs("users", "orders"), WasmParam{Name: "filter", Kind: wasmArgJSON, Optional: true})},
+	{Name: "resetAnalyticsWasm", Doc: "Starts the page's incremental analytics over."},
+	{Name: "addAnalyticsUserWasm", Doc: "Adds a user to the page's analytics; add users before their orders.", Params: jsonArgs("user")},
+	{Name: "addAnalyticsOrderWasm", Doc: "Adds an order to the page's analytics.", Params: jsonArgs("order")},
+	{Name: "analyticsSnapshotWasm", Doc: "Reports the analytics of what was added so far, optionally ranking topCountries countries.", Params: []WasmParam{{Name: "topCountries", Kind: wasmArgInteger, Optional: true}}},
+	{Name: "profileCompletenessWasm", Doc: "Scores how complete a user's profile is.", Params: jsonArgs("user")},
+	{Name: "exportCsvWasm", Doc: "Formats a dataset as CSV like the server's /api/export endpoints.", Params: withArgs([]WasmParam{{Name: "dataset", Kind: wasmArgString}}, jsonArgs("data")...)},
+	{Name: "cartSummaryWasm", Doc: "Prices a cart like the server's /api/cart endpoints.", Params: jsonArgs("cart", "products", "user")},
+	{Name: "mergeCartsWasm", Doc: "Folds a guest cart into the user's cart.", Params: jsonArgs("guestCart", "userCart")},
+	{Name: "moveToCartWasm", Doc: "Moves a wishlisted product into the cart, optionally a quantity of one variant SKU.", Params: withArgs(jsonArgs("wishlist", "cart", "products"), WasmParam{Name: "productId", Kind: wasmArgInteger}, WasmParam{Name: "quantity", Kind: wasmArgInteger, Optional: true}, WasmParam{Name: "sku", Kind: wasmArgString, Optional: true})},
+	{Name: "comparePriceWasm", Doc: "Labels a price history was/now, as of now or an RFC 3339 time.", Params: withArgs(jsonArgs("history"), WasmParam{Name: "at", Kind: wasmArgString, Optional: true})},
+	{Name: "getDynamicPriceWasm", Doc: "Previews a product's demand-based price, optionally with pricing overrides.", Params: withArgs(jsonArgs("product", "orders"), WasmParam{Name: "pricing", Kind: wasmArgJSON, Optional: true})},
+	{Name: "scoreOrderRiskWasm", Doc: "Scores an order's fraud risk, optionally against the order history.", Params: withArgs(jsonArgs("order", "user"), WasmParam{Name: "history", Kind: wasmArgJSON, Optional: true})},
+	{Name: "generateInvoiceWasm", Doc: "Renders an order's invoice as HTML and plain text.", Params: jsonArgs("order", "user")},
+	{Name: "renderReceiptWasm", Doc: "Previews an order's confirmation or receipt email as HTML and plain text, from the server's templates.", Params: append(jsonArgs("order", "user"), WasmParam{Name: "kind", Kind: wasmArgString, Optional: true}, WasmParam{Name: "locale", Kind: wasmArgString, Optional: true})},
+	{Name: "previewNotificationWasm", Doc: "Previews the notification a user gets about an order event, as order webhooks carry it.", Params: append(jsonArgs("order", "user"), WasmParam{Name: "event", Kind: wasmArgString}, WasmParam{Name: "previousStatus", Kind: wasmArgString, Optional: true})},
+	{Name: "findDuplicateUsersWasm", Doc: "Finds the likely duplicate users of a bulk import.", Params: jsonArgs("users")},
+	{Name: "generateDemoDataWasm", Doc: "Generates a seeded dataset of demo users, products and orders, the same as /api/demo-data.", Params: []WasmParam{{Name: "options", Kind: wasmArgJSON, Optional: true}}},
+	{Name: "updateRecordWasm", Doc: "Applies a JSON merge patch to a user, product or order made against the record's current version, as the server's updates do.", Params: withArgs([]WasmParam{{Name: "model", Kind: wasmArgString}}, jsonArgs("record", "patch")...)},
+	{Name: "openDataCacheWasm", Doc: "Opens the page's cache of fetched users, products and orders in localStorage, sessionStorage or memory.", Params: []WasmParam{{Name: "storage", Kind: wasmArgString, Optional: true}}},
+	{Name: "cacheStoreWasm", Doc: "Caches users, products or orders fetched from the server, keeping records with edits not yet synced.", Params: withArgs([]WasmParam{{Name: "model", Kind: wasmArgString}}, jsonArgs("records")...)},
+	{Name: "cacheRecordsWasm", Doc: "Returns the cached records of a model with the page's edits, for working offline.", Params: []WasmParam{{Name: "model", Kind: wasmArgString}}},
+	{Name: "cacheUpdateWasm", Doc: "Edits a cached user or product with a JSON merge patch naming its version, to sync later.", Params: withArgs([]WasmParam{{Name: "model", Kind: wasmArgString}, {Name: "id", Kind: wasmArgInteger}}, jsonArgs("patch")...)},
+	{Name: "cacheSyncRequestWasm", Doc: "Returns the body of a POST /api/data/sync with the edits not yet synced."},
+	{Name: "cacheApplySyncWasm", Doc: "Takes in the answer to POST /api/data/sync and returns the conflicts and rejected edits.", Params: jsonArgs("response")},
+	{Name: "cacheResolveWasm", Doc: "Settles a record in conflict by keeping the page's edits (local) or the server's record (server).", Params: []WasmParam{{Name: "model", Kind: wasmArgString}, {Name: "id", Kind: wasmArgInteger}, {Name: "keep", Kind: wasmArgString}}},
+	{Name: "recalculateOrdersWasm", Doc: "Prices orders again for their users and reports the orders whose totals would change, as /api/orders/recalculate does.", Params: jsonArgs("orders", "users")},
+	{Name: "convertCurrencyWasm", Doc: "Converts an amount between currencies, optionally formatted for a locale.", Params: []WasmParam{{Name: "amount", Kind: wasmArgNumber}, {Name: "from", Kind: wasmArgString}, {Name: "to", Kind: wasmArgString}, {Name: "locale", Kind: wasmArgString, Optional: true}}},
+	{Name: "formatMoneyWasm", Doc: "Formats an amount for a locale exactly as the server does.", Params: []WasmParam{{Name: "amount", Kind: wasmArgNumber}, {Name: "currency", Kind: wasmArgString}, {Name: "locale", Kind: wasmArgString}}},
+	{Name: "shippingQuotesWasm", Doc: "Quotes the shipping options of an order.", Params: jsonArgs("order", "user")},
+	{Name: "checkAvailabilityWasm", Doc: "Checks a cart against available stock.", Params: jsonArgs("cart", "products")},
+	{Name: "splitOrderWasm", Doc: "Splits an order into shipments by warehouse and stock.", Params: jsonArgs("order", "products", "user")},
+	{Name: "upcomingChargesWasm", Doc: "Previews a subscription's next count renewals.", Params: withArgs(jsonArgs("subscription", "user"), WasmParam{Name: "count", Kind: wasmArgInteger})},
+	{Name: "prorateSubscriptionWasm", Doc: "Prorates a subscription change on a YYYY-MM-DD date.", Params: withArgs(jsonArgs("subscription", "change"), WasmParam{Name: "date", Kind: wasmArgString})},
+	{Name: "setExchangeRatesWasm", Doc: "Installs a rate table, normally the JSON served by /api/rates.", Params: jsonArgs("rates")},
+	{Name: "setValidationRulesWasm", Doc: "Installs a validation ruleset, normally the JSON served by /api/validation-rules.", Params: jsonArgs("rules")},
+	{Name: "setPromotionsWasm", Doc: "Installs the promotions, normally the JSON served by /api/promotions.", Params: jsonArgs("promotions")},
+	{Name: "setItemSimilarityWasm", Doc: "Installs the similarity matrix, normally the JSON served by /api/recommendations/similarity.", Params: jsonArgs("similarity")},
+	{Name: "setTrendingWasm", Doc: "Installs the trending list, normally the JSON served by /api/analytics/trending.", Params: jsonArgs("trending")},
+	{Name: "setCohortPreferencesWasm", Doc: "Installs the cohort preferences, normally the JSON served by /api/recommendations/cohorts.", Params: jsonArgs("preferences")},
+	{Name: "setRecommendationWeightsWasm", Doc: "Installs the scoring weights, normally the JSON served by /api/recommendations/weights.", Params: jsonArgs("weights")},
+	{Name: "setCLVSettingsWasm", Doc: "Installs the margin and discount rate CLV projects with.", Params: jsonArgs("settings")},
+}
+
+// wasmArgJSDocTypes are the JSDoc types of the argument kinds.
+var wasmArgJSDocTypes = map[string]string{
+	wasmArgJSON:    "Object|string",
+	wasmArgString:  "string",
+	wasmArgNumber:  "number",
+	wasmArgInteger: "number",
+}
+
+// RenderWasmAPIJS renders the wasm-api.js of fns.
+func RenderWasmAPIJS(fns []WasmFunction) []byte {
+	var b strings.Builder
+	b.WriteString(wasmAPIHeader)
+	for _, fn := range fns {
+		names := make([]string, len(fn.Params))
+		specs := make([]string, len(fn.Params))
+		fmt.Fprintf(&b, "\n        /**\n         * %s\n", fn.Doc)
+		for i, p := range fn.Params {
+			names[i] = p.Name
+			specs[i] = fmt.Sprintf("['%s', '%s']", p.Name, p.Kind)
+			name := p.Name
+			if p.Optional {
+				specs[i] = fmt.Sprintf("['%s', '%s', true]", p.Name, p.Kind)
+				name = "[" + p.Name + "]"
+			}
+			fmt.Fprintf(&b, "         * @param {%s} %s\n", wasmArgJSDocTypes[p.Kind], name)
+		}
+		b.WriteString("         * @returns {Promise<Object>}\n         */\n")
+		fmt.Fprintf(&b, "        %s(%s) {\n", fn.JSName(), strings.Join(names, ", "))
+		fmt.Fprintf(&b, "            return call('%s', '%s', [%s], [%s]);\n        },\n",
+			fn.JSName(), fn.Name, strings.Join(specs, ", "), strings.Join(names, ", "))
+	}
+	b.WriteString(wasmAPIFooter)
+	return []byte(b.String())
+}
+
+const wasmAPIHeader = `// Code generated by go generate from src/shared_wasm_api.go; DO NOT EDIT.
+
+// ============================================================================
+// WASM API
+// Typed wrappers of the business logic functions the WebAssembly module
+// registers: window.wasmApi.validateUser(user) calls validateUserWasm once the
+// module is loaded, resolving with its result or rejecting with its error
+// ============================================================================
+
+(function() {
+    'use strict';
+
+    const kindNames = {
+        json: 'an object or a JSON string',
+        string: 'a string',
+        number: 'a finite number',
+        integer: 'a whole number',
+    };
+
+    // Convert an argument to what the Go function takes for its kind, or
+    // throw a TypeError naming the argument
+    function convert(fn, name, kind, value) {
+        switch (kind) {
+        case 'json':
+            if (typeof value === 'string') {
+                return value;
+            }
+            if (value !== null && typeof value === 'object') {
+                return JSON.stringify(value);
+            }
+            break;
+        case 'string':
+            if (typeof value === 'string') {
+                return value;
+            }
+            break;
+        case 'number':
+            if (Number.isFinite(value)) {
+                return value;
+            }
+            break;
+        case 'integer':
+            if (Number.isInteger(value)) {
+                return value;
+            }
+            break;
+        }
+        throw new TypeError(` + "`wasmApi.${fn}: ${name} must be ${kindNames[kind]}`" + `);
+    }
+
+    // Check values against params ([name, kind, optional]), load the module
+    // and call the Go function; optional arguments left undefined at the end
+    // are not passed
+    async function call(fn, goName, params, values) {
+        const args = [];
+        let missing = null;
+        params.forEach(([name, kind, optional], i) => {
+            if (values[i] === undefined) {
+                if (!optional) {
+                    throw new TypeError(` + "`wasmApi.${fn}: ${name} is required`" + `);
+                }
+                missing = missing || name;
+                return;
+            }
+            if (missing) {
+                throw new TypeError(` + "`wasmApi.${fn}: ${name} needs ${missing} too`" + `);
+            }
+            args.push(convert(fn, name, kind, values[i]));
+        });
+
+        await window.initializeWasm();
+        const goFunc = window[goName];
+        if (typeof goFunc !== 'function') {
+            throw new Error(` + "`wasmApi.${fn}: this module does not register ${goName}`" + `);
+        }
+        const result = goFunc(...args);
+        if (result && typeof result.error === 'string' && result.error !== '') {
+            throw new Error(result.error);
+        }
+        return result;
+    }
+
+    window.wasmApi = {`
+
+const wasmAPIFooter = `    };
+})();
+`
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWasmAPIRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, fn := range wasmAPI {
+		if !strings.HasSuffix(fn.Name, "Wasm") || seen[fn.Name] {
+			t.Errorf("%s: names must end in Wasm and be unique", fn.Name)
+		}
+		seen[fn.Name] = true
+		if fn.Doc == "" {
+			t.Errorf("%s: no doc", fn.Name)
+		}
+		optional := false
+		for _, p := range fn.Params {
+			if _, ok := wasmArgJSDocTypes[p.Kind]; !ok {
+				t.Errorf("%s: %s has unknown kind %q", fn.Name, p.Name, p.Kind)
+			}
+			if optional && !p.Optional {
+				t.Errorf("%s: required %s follows an optional argument", fn.Name, p.Name)
+			}
+			optional = optional || p.Optional
+		}
+	}
+}
+
+// TestWasmAPIRegistered checks the registry lists what registerBusinessLogic
+// registers, in order.
+func TestWasmAPIRegistered(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "main_wasm.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var registered []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "registerBusinessLogic" {
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Set" {
+					return true
+				}
+				if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+					name, _ := strconv.Unquote(lit.Value)
+					registered = append(registered, name)
+				}
+				return true
+			})
+		}
+	}
+
+	names := make([]string, len(wasmAPI))
+	for i, fn := range wasmAPI {
+		names[i] = fn.Name
+	}
+	if got, want := strings.Join(names, " "), strings.Join(registered, " "); got != want {
+		t.Errorf("wasmAPI lists\n%s\nregisterBusinessLogic registers\n%s", got, want)
+	}
+}
+
+func TestWasmAPIGenerated(t *testing.T) {
+	data, err := os.ReadFile("../assets/js/wasm-api.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, RenderWasmAPIJS(wasmAPI)) {
+		t.Error("assets/js/wasm-api.js is out of date - run go generate in src")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Shared wishlists - a user's wishlist is the products they are saving for
+// later. MoveToCart takes a product off the wishlist into the cart, and the
+// categories on a wishlist boost those products in RecommendProducts.
+
+// ErrNotWishlisted is returned for products that aren't on the wishlist.
+var ErrNotWishlisted = errors.New("product is not on the wishlist")
+
+// WishlistItem is a saved product; AddedAt is RFC 3339.
+type WishlistItem struct {
+	ProductID int    `json:"product_id"`
+	AddedAt   string `json:"added_at"`
+}
+
+// Wishlist is the products one user saved, oldest first.
+type Wishlist struct {
+	UserID int            `json:"user_id"`
+	Items  []WishlistItem `json:"items"`
+}
+
+// Contains reports whether a product is on the wishlist.
+func (wishlist Wishlist) Contains(productID int) bool {
+	return slices.ContainsFunc(wishlist.Items, func(item WishlistItem) bool { return item.ProductID == productID })
+}
+
+// AddItem saves a product at time at. It reports false, leaving the
+// wishlist as it was, when the product is already saved.
+func (wishlist *Wishlist) AddItem(productID int, at time.Time) bool {
+	if wishlist.Contains(productID) {
+		return false
+	}
+	wishlist.Items = append(wishlist.Items, WishlistItem{ProductID: productID, AddedAt: at.UTC().Format(time.RFC3339)})
+	return true
+}
+
+// RemoveItem drops a product and reports whether it was on the wishlist.
+func (wishlist *Wishlist) RemoveItem(productID int) bool {
+	before := len(wishlist.Items)
+	wishlist.Items = slices.DeleteFunc(wishlist.Items, func(item WishlistItem) bool { return item.ProductID == productID })
+	return len(wishlist.Items) < before
+}
+
+// MoveToCart moves quantity of a wishlisted product, in variant sku for
+// products with variants, into cart. The cart line is checked against
+// catalog first; on any error neither the wishlist nor the cart changes.
+func MoveToCart(wishlist *Wishlist, cart *Cart, catalog []Product, productID int, sku string, quantity int, at time.Time) error {
+	if !wishlist.Contains(productID) {
+		return ErrNotWishlisted
+	}
+	if result := ValidateCartItem(CartItem{ProductID: productID, SKU: sku, Quantity: quantity}, catalog); !result.Valid {
+		return errors.New(strings.Join(result.Errors, "; "))
+	}
+	product, _ := findProduct(catalog, productID)
+	cart.AddItem(product, sku, quantity, at)
+	wishlist.RemoveItem(productID)
+	return nil
+}
+
+// wishlistCategories are the lower-cased categories of the wishlisted
+// products found in catalog.
+func wishlistCategories(wishlist Wishlist, catalog []Product) map[string]bool {
+	categories := map[string]bool{}
+	for _, item := range wishlist.Items {
+		if product, ok := findProduct(catalog, item.ProductID); ok {
+			categories[strings.ToLower(product.Category)] = true
+		}
+	}
+	return categories
+}
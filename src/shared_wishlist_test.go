@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestWishlistOperations tests saving, removing and moving products to a cart
+func TestWishlistOperations(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	wishlist := Wishlist{UserID: 1}
+	if !wishlist.AddItem(1, at) || !wishlist.AddItem(3, at) || wishlist.AddItem(1, at) {
+		t.Fatalf("Expected each product saved once, got %+v", wishlist.Items)
+	}
+	if wishlist.Items[0].AddedAt != "2026-05-01T12:00:00Z" {
+		t.Errorf("Unexpected added time %q", wishlist.Items[0].AddedAt)
+	}
+
+	var cart Cart
+	if err := MoveToCart(&wishlist, &cart, testProducts, 3, "", 2, at); err != nil {
+		t.Fatalf("MoveToCart() error = %v", err)
+	}
+	if wishlist.Contains(3) || len(cart.Items) != 1 || cart.Items[0].Quantity != 2 || cart.Items[0].PriceAtAdd != 49.99 {
+		t.Errorf("Expected the book moved to the cart, got wishlist %+v and cart %+v", wishlist.Items, cart.Items)
+	}
+	if err := MoveToCart(&wishlist, &cart, testProducts, 3, "", 1, at); !errors.Is(err, ErrNotWishlisted) {
+		t.Errorf("Expected ErrNotWishlisted, got %v", err)
+	}
+	if err := MoveToCart(&wishlist, &cart, testProducts, 1, "", 11, at); err == nil || !wishlist.Contains(1) || len(cart.Items) != 1 {
+		t.Errorf("Expected a short-stock move to fail without changes, got %v", err)
+	}
+
+	if !wishlist.RemoveItem(1) || wishlist.RemoveItem(1) || len(wishlist.Items) != 0 {
+		t.Errorf("Unexpected result removing products: %+v", wishlist.Items)
+	}
+}
+
+// TestRecommendWithWishlist tests the boost for wishlisted categories
+func TestRecommendWithWishlist(t *testing.T) {
+	catalog := []Product{
+		{ID: 1, Name: "Novel", Price: 50, Category: "books", OnHand: 5, Rating: 4},
+		{ID: 2, Name: "Racket", Price: 50, Category: "sports", OnHand: 5, Rating: 4},
+		{ID: 3, Name: "Bicycle", Price: 400, Category: "sports", OnHand: 0, Rating: 4},
+	}
+	user := User{Age: 30}
+	wishlist := Wishlist{UserID: 1, Items: []WishlistItem{{ProductID: 3}}}
+
+	recommendations := RecommendWithWishlist(user, catalog, Order{}, wishlist)
+	if len(recommendations) != 2 || recommendations[0].ID != 2 {
+		t.Errorf("Expected the in-stock sports product first, got %+v", recommendations)
+	}
+}
@@ -0,0 +1,366 @@
+;; The SIMD kernels: the matrix multiplication and Mandelbrot inner loops
+;; written by hand with 128-bit vector instructions, two f64 lanes at a time.
+;; benchmarks_simd.go copies the inputs into this module's memory, calls a
+;; kernel and copies the result out. go generate assembles this file into
+;; simd_kernels.wasm with internal/wat, which supports only the flat
+;; instruction form used here.
+;;
+;; Both kernels do the same floating point operations, in the same order, as
+;; the Go kernels in benchmarks_comprehensive.go (WebAssembly has no fused
+;; multiply-add to change them), so their results are bit-for-bit identical.
+(module
+  ;; Inputs and results, at offsets the caller chooses. The caller grows it
+  ;; to fit.
+  (memory (export "memory") 1)
+
+  ;; matmul stores the product of the n×n row-major f64 matrices at $a and $b
+  ;; at $c. Each pair of columns of a result row is summed over k in one
+  ;; f64x2; an odd last column is summed in scalar.
+  (func (export "matmul") (param $a i32) (param $b i32) (param $c i32) (param $n i32)
+    (local $stride i32) (local $i i32) (local $j i32) (local $k i32)
+    (local $rowA i32) (local $rowC i32) (local $p i32)
+    (local $acc v128) (local $sum f64)
+
+    ;; Bytes per row
+    local.get $n
+    i32.const 3
+    i32.shl
+    local.set $stride
+
+    i32.const 0
+    local.set $i
+    block $rowsDone
+      loop $rows
+        local.get $i
+        local.get $n
+        i32.ge_s
+        br_if $rowsDone
+
+        local.get $a
+        local.get $i
+        local.get $stride
+        i32.mul
+        i32.add
+        local.set $rowA
+        local.get $c
+        local.get $i
+        local.get $stride
+        i32.mul
+        i32.add
+        local.set $rowC
+
+        ;; Column pairs: acc = sum over k of a[i][k] * b[k][j:j+2]
+        i32.const 0
+        local.set $j
+        block $pairsDone
+          loop $pairs
+            local.get $j
+            i32.const 1
+            i32.add
+            local.get $n
+            i32.ge_s
+            br_if $pairsDone
+
+            f64.const 0
+            f64x2.splat
+            local.set $acc
+            local.get $b
+            local.get $j
+            i32.const 3
+            i32.shl
+            i32.add
+            local.set $p
+            i32.const 0
+            local.set $k
+            block $pairKsDone
+              loop $pairKs
+                local.get $k
+                local.get $n
+                i32.ge_s
+                br_if $pairKsDone
+
+                local.get $acc
+                local.get $rowA
+                local.get $k
+                i32.const 3
+                i32.shl
+                i32.add
+                f64.load
+                f64x2.splat
+                local.get $p
+                v128.load align=8
+                f64x2.mul
+                f64x2.add
+                local.set $acc
+
+                local.get $p
+                local.get $stride
+                i32.add
+                local.set $p
+                local.get $k
+                i32.const 1
+                i32.add
+                local.set $k
+                br $pairKs
+              end
+            end
+
+            local.get $rowC
+            local.get $j
+            i32.const 3
+            i32.shl
+            i32.add
+            local.get $acc
+            v128.store align=8
+
+            local.get $j
+            i32.const 2
+            i32.add
+            local.set $j
+            br $pairs
+          end
+        end
+
+        ;; The last column of an odd n
+        local.get $j
+        local.get $n
+        i32.lt_s
+        if
+          f64.const 0
+          local.set $sum
+          local.get $b
+          local.get $j
+          i32.const 3
+          i32.shl
+          i32.add
+          local.set $p
+          i32.const 0
+          local.set $k
+          block $lastKsDone
+            loop $lastKs
+              local.get $k
+              local.get $n
+              i32.ge_s
+              br_if $lastKsDone
+
+              local.get $sum
+              local.get $rowA
+              local.get $k
+              i32.const 3
+              i32.shl
+              i32.add
+              f64.load
+              local.get $p
+              f64.load
+              f64.mul
+              f64.add
+              local.set $sum
+
+              local.get $p
+              local.get $stride
+              i32.add
+              local.set $p
+              local.get $k
+              i32.const 1
+              i32.add
+              local.set $k
+              br $lastKs
+            end
+          end
+
+          local.get $rowC
+          local.get $j
+          i32.const 3
+          i32.shl
+          i32.add
+          local.get $sum
+          f64.store
+        end
+
+        local.get $i
+        i32.const 1
+        i32.add
+        local.set $i
+        br $rows
+      end
+    end
+  )
+
+  ;; mandelbrot stores the escape iteration count, up to $maxIter, of each
+  ;; pixel of a $width×$height image as an i32 at $out, row by row. Pixel
+  ;; (px, py) is the point ($xmin + px*$dx, $ymin + py*$dy). Pixels are
+  ;; iterated in pairs: $active masks the lanes that haven't escaped, whose
+  ;; counts go up by one (subtracting the all-ones mask) each iteration, and
+  ;; the pair stops once both have escaped. An odd row's last pair stores
+  ;; only its first lane.
+  (func (export "mandelbrot") (param $out i32) (param $width i32) (param $height i32) (param $maxIter i32)
+      (param $xmin f64) (param $ymin f64) (param $dx f64) (param $dy f64)
+    (local $px i32) (local $py i32) (local $iter i32) (local $pixel i32)
+    (local $cx v128) (local $cy v128) (local $zx v128) (local $zy v128)
+    (local $zx2 v128) (local $zy2 v128) (local $active v128) (local $counts v128)
+
+    i32.const 0
+    local.set $py
+    block $rowsDone
+      loop $rows
+        local.get $py
+        local.get $height
+        i32.ge_s
+        br_if $rowsDone
+
+        local.get $ymin
+        local.get $py
+        f64.convert_i32_s
+        local.get $dy
+        f64.mul
+        f64.add
+        f64x2.splat
+        local.set $cy
+
+        i32.const 0
+        local.set $px
+        block $pairsDone
+          loop $pairs
+            local.get $px
+            local.get $width
+            i32.ge_s
+            br_if $pairsDone
+
+            ;; cx = (xmin + px*dx, xmin + (px+1)*dx)
+            local.get $xmin
+            local.get $px
+            f64.convert_i32_s
+            local.get $dx
+            f64.mul
+            f64.add
+            f64x2.splat
+            local.get $xmin
+            local.get $px
+            i32.const 1
+            i32.add
+            f64.convert_i32_s
+            local.get $dx
+            f64.mul
+            f64.add
+            f64x2.replace_lane 1
+            local.set $cx
+
+            f64.const 0
+            f64x2.splat
+            local.tee $zx
+            local.set $zy
+            i64.const 0
+            i64x2.splat
+            local.set $counts
+            i64.const -1
+            i64x2.splat
+            local.set $active
+
+            i32.const 0
+            local.set $iter
+            block $escaped
+              loop $iterate
+                local.get $iter
+                local.get $maxIter
+                i32.ge_s
+                br_if $escaped
+
+                local.get $zx
+                local.get $zx
+                f64x2.mul
+                local.set $zx2
+                local.get $zy
+                local.get $zy
+                f64x2.mul
+                local.set $zy2
+
+                ;; Lanes still inside the radius 2 circle stay active
+                local.get $active
+                local.get $zx2
+                local.get $zy2
+                f64x2.add
+                f64.const 4
+                f64x2.splat
+                f64x2.le
+                v128.and
+                local.tee $active
+                v128.any_true
+                i32.eqz
+                br_if $escaped
+
+                local.get $counts
+                local.get $active
+                i64x2.sub
+                local.set $counts
+
+                ;; zy = 2*zx*zy + cy, then zx = zx2 - zy2 + cx
+                f64.const 2
+                f64x2.splat
+                local.get $zx
+                f64x2.mul
+                local.get $zy
+                f64x2.mul
+                local.get $cy
+                f64x2.add
+                local.set $zy
+                local.get $zx2
+                local.get $zy2
+                f64x2.sub
+                local.get $cx
+                f64x2.add
+                local.set $zx
+
+                local.get $iter
+                i32.const 1
+                i32.add
+                local.set $iter
+                br $iterate
+              end
+            end
+
+            ;; pixel = out + (py*width + px)*4
+            local.get $out
+            local.get $py
+            local.get $width
+            i32.mul
+            local.get $px
+            i32.add
+            i32.const 2
+            i32.shl
+            i32.add
+            local.tee $pixel
+            local.get $counts
+            i64x2.extract_lane 0
+            i32.wrap_i64
+            i32.store
+
+            local.get $px
+            i32.const 1
+            i32.add
+            local.get $width
+            i32.lt_s
+            if
+              local.get $pixel
+              local.get $counts
+              i64x2.extract_lane 1
+              i32.wrap_i64
+              i32.store offset=4
+            end
+
+            local.get $px
+            i32.const 2
+            i32.add
+            local.set $px
+            br $pairs
+          end
+        end
+
+        local.get $py
+        i32.const 1
+        i32.add
+        local.set $py
+        br $rows
+      end
+    end
+  )
+)